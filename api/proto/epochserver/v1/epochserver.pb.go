@@ -0,0 +1,1551 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: epochserver/v1/epochserver.proto
+
+package epochserverv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type ListEpochsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"` // 1-1000, 0 is 100
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Ascending     bool                   `protobuf:"varint,3,opt,name=ascending,proto3" json:"ascending,omitempty"` // oldest first
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEpochsRequest) Reset() {
+	*x = ListEpochsRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEpochsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEpochsRequest) ProtoMessage() {}
+
+func (x *ListEpochsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEpochsRequest.ProtoReflect.Descriptor instead.
+func (*ListEpochsRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListEpochsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListEpochsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListEpochsRequest) GetAscending() bool {
+	if x != nil {
+		return x.Ascending
+	}
+	return false
+}
+
+type ListEpochsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Epochs        []*Epoch               `protobuf:"bytes,1,rep,name=epochs,proto3" json:"epochs,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"` // epochs the subgraph knows of, across all pages
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListEpochsResponse) Reset() {
+	*x = ListEpochsResponse{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListEpochsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListEpochsResponse) ProtoMessage() {}
+
+func (x *ListEpochsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListEpochsResponse.ProtoReflect.Descriptor instead.
+func (*ListEpochsResponse) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListEpochsResponse) GetEpochs() []*Epoch {
+	if x != nil {
+		return x.Epochs
+	}
+	return nil
+}
+
+func (x *ListEpochsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+// Epoch is an epoch known to the subgraph
+type Epoch struct {
+	state                        protoimpl.MessageState `protogen:"open.v1"`
+	EpochNumber                  string                 `protobuf:"bytes,1,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`
+	Status                       string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	StartTimestamp               string                 `protobuf:"bytes,3,opt,name=start_timestamp,json=startTimestamp,proto3" json:"start_timestamp,omitempty"`
+	EndTimestamp                 string                 `protobuf:"bytes,4,opt,name=end_timestamp,json=endTimestamp,proto3" json:"end_timestamp,omitempty"`
+	ProcessingCompletedTimestamp string                 `protobuf:"bytes,5,opt,name=processing_completed_timestamp,json=processingCompletedTimestamp,proto3" json:"processing_completed_timestamp,omitempty"`
+	TotalSubsidiesDistributed    string                 `protobuf:"bytes,6,opt,name=total_subsidies_distributed,json=totalSubsidiesDistributed,proto3" json:"total_subsidies_distributed,omitempty"`
+	TotalYieldDistributed        string                 `protobuf:"bytes,7,opt,name=total_yield_distributed,json=totalYieldDistributed,proto3" json:"total_yield_distributed,omitempty"`
+	YieldAllocated               string                 `protobuf:"bytes,8,opt,name=yield_allocated,json=yieldAllocated,proto3" json:"yield_allocated,omitempty"`
+	YieldHeldBack                string                 `protobuf:"bytes,9,opt,name=yield_held_back,json=yieldHeldBack,proto3" json:"yield_held_back,omitempty"`
+	DistributionFingerprint      string                 `protobuf:"bytes,10,opt,name=distribution_fingerprint,json=distributionFingerprint,proto3" json:"distribution_fingerprint,omitempty"`
+	unknownFields                protoimpl.UnknownFields
+	sizeCache                    protoimpl.SizeCache
+}
+
+func (x *Epoch) Reset() {
+	*x = Epoch{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Epoch) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Epoch) ProtoMessage() {}
+
+func (x *Epoch) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Epoch.ProtoReflect.Descriptor instead.
+func (*Epoch) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Epoch) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *Epoch) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Epoch) GetStartTimestamp() string {
+	if x != nil {
+		return x.StartTimestamp
+	}
+	return ""
+}
+
+func (x *Epoch) GetEndTimestamp() string {
+	if x != nil {
+		return x.EndTimestamp
+	}
+	return ""
+}
+
+func (x *Epoch) GetProcessingCompletedTimestamp() string {
+	if x != nil {
+		return x.ProcessingCompletedTimestamp
+	}
+	return ""
+}
+
+func (x *Epoch) GetTotalSubsidiesDistributed() string {
+	if x != nil {
+		return x.TotalSubsidiesDistributed
+	}
+	return ""
+}
+
+func (x *Epoch) GetTotalYieldDistributed() string {
+	if x != nil {
+		return x.TotalYieldDistributed
+	}
+	return ""
+}
+
+func (x *Epoch) GetYieldAllocated() string {
+	if x != nil {
+		return x.YieldAllocated
+	}
+	return ""
+}
+
+func (x *Epoch) GetYieldHeldBack() string {
+	if x != nil {
+		return x.YieldHeldBack
+	}
+	return ""
+}
+
+func (x *Epoch) GetDistributionFingerprint() string {
+	if x != nil {
+		return x.DistributionFingerprint
+	}
+	return ""
+}
+
+type GetCurrentEpochRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentEpochRequest) Reset() {
+	*x = GetCurrentEpochRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentEpochRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentEpochRequest) ProtoMessage() {}
+
+func (x *GetCurrentEpochRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentEpochRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentEpochRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{3}
+}
+
+type GetCurrentEpochResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EpochId       uint64                 `protobuf:"varint,1,opt,name=epoch_id,json=epochId,proto3" json:"epoch_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentEpochResponse) Reset() {
+	*x = GetCurrentEpochResponse{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentEpochResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentEpochResponse) ProtoMessage() {}
+
+func (x *GetCurrentEpochResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentEpochResponse.ProtoReflect.Descriptor instead.
+func (*GetCurrentEpochResponse) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *GetCurrentEpochResponse) GetEpochId() uint64 {
+	if x != nil {
+		return x.EpochId
+	}
+	return 0
+}
+
+type GetUserTotalEarnedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserAddress   string                 `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	VaultAddress  string                 `protobuf:"bytes,2,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"` // the server's vault when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserTotalEarnedRequest) Reset() {
+	*x = GetUserTotalEarnedRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserTotalEarnedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserTotalEarnedRequest) ProtoMessage() {}
+
+func (x *GetUserTotalEarnedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserTotalEarnedRequest.ProtoReflect.Descriptor instead.
+func (*GetUserTotalEarnedRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetUserTotalEarnedRequest) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *GetUserTotalEarnedRequest) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+// UserEarnings is what a user earned so far in a vault
+type UserEarnings struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserAddress   string                 `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	VaultAddress  string                 `protobuf:"bytes,2,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	TotalEarned   string                 `protobuf:"bytes,3,opt,name=total_earned,json=totalEarned,proto3" json:"total_earned,omitempty"`
+	CalculatedAt  int64                  `protobuf:"varint,4,opt,name=calculated_at,json=calculatedAt,proto3" json:"calculated_at,omitempty"`
+	DataTimestamp int64                  `protobuf:"varint,5,opt,name=data_timestamp,json=dataTimestamp,proto3" json:"data_timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UserEarnings) Reset() {
+	*x = UserEarnings{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserEarnings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserEarnings) ProtoMessage() {}
+
+func (x *UserEarnings) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserEarnings.ProtoReflect.Descriptor instead.
+func (*UserEarnings) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UserEarnings) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *UserEarnings) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *UserEarnings) GetTotalEarned() string {
+	if x != nil {
+		return x.TotalEarned
+	}
+	return ""
+}
+
+func (x *UserEarnings) GetCalculatedAt() int64 {
+	if x != nil {
+		return x.CalculatedAt
+	}
+	return 0
+}
+
+func (x *UserEarnings) GetDataTimestamp() int64 {
+	if x != nil {
+		return x.DataTimestamp
+	}
+	return 0
+}
+
+type StreamAllocationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress  string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"` // the server's vault when empty
+	EpochNumber   string                 `protobuf:"bytes,2,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamAllocationsRequest) Reset() {
+	*x = StreamAllocationsRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamAllocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamAllocationsRequest) ProtoMessage() {}
+
+func (x *StreamAllocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamAllocationsRequest.ProtoReflect.Descriptor instead.
+func (*StreamAllocationsRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamAllocationsRequest) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *StreamAllocationsRequest) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+// Allocation is an account's leaf in the merkle tree of an epoch's distribution
+type Allocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Account       string                 `protobuf:"bytes,1,opt,name=account,proto3" json:"account,omitempty"`
+	TotalEarned   string                 `protobuf:"bytes,2,opt,name=total_earned,json=totalEarned,proto3" json:"total_earned,omitempty"` // cumulative, what the account can have claimed after the epoch
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Allocation) Reset() {
+	*x = Allocation{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Allocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Allocation) ProtoMessage() {}
+
+func (x *Allocation) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Allocation.ProtoReflect.Descriptor instead.
+func (*Allocation) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Allocation) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *Allocation) GetTotalEarned() string {
+	if x != nil {
+		return x.TotalEarned
+	}
+	return ""
+}
+
+type StreamQuarantinedAccountsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress  string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"` // the server's vault when empty
+	EpochNumber   string                 `protobuf:"bytes,2,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`    // every epoch when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamQuarantinedAccountsRequest) Reset() {
+	*x = StreamQuarantinedAccountsRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamQuarantinedAccountsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamQuarantinedAccountsRequest) ProtoMessage() {}
+
+func (x *StreamQuarantinedAccountsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamQuarantinedAccountsRequest.ProtoReflect.Descriptor instead.
+func (*StreamQuarantinedAccountsRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamQuarantinedAccountsRequest) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *StreamQuarantinedAccountsRequest) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+// QuarantinedAccount is an account subsidy a distribution skipped for malformed subgraph data
+type QuarantinedAccount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress  string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	EpochNumber   string                 `protobuf:"bytes,2,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`
+	BlockNumber   uint64                 `protobuf:"varint,3,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	SubsidyId     string                 `protobuf:"bytes,4,opt,name=subsidy_id,json=subsidyId,proto3" json:"subsidy_id,omitempty"`
+	Account       string                 `protobuf:"bytes,5,opt,name=account,proto3" json:"account,omitempty"`
+	Reason        string                 `protobuf:"bytes,6,opt,name=reason,proto3" json:"reason,omitempty"`
+	QuarantinedAt int64                  `protobuf:"varint,7,opt,name=quarantined_at,json=quarantinedAt,proto3" json:"quarantined_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QuarantinedAccount) Reset() {
+	*x = QuarantinedAccount{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QuarantinedAccount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QuarantinedAccount) ProtoMessage() {}
+
+func (x *QuarantinedAccount) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QuarantinedAccount.ProtoReflect.Descriptor instead.
+func (*QuarantinedAccount) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *QuarantinedAccount) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *QuarantinedAccount) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *QuarantinedAccount) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *QuarantinedAccount) GetSubsidyId() string {
+	if x != nil {
+		return x.SubsidyId
+	}
+	return ""
+}
+
+func (x *QuarantinedAccount) GetAccount() string {
+	if x != nil {
+		return x.Account
+	}
+	return ""
+}
+
+func (x *QuarantinedAccount) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *QuarantinedAccount) GetQuarantinedAt() int64 {
+	if x != nil {
+		return x.QuarantinedAt
+	}
+	return 0
+}
+
+type GetProofRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserAddress   string                 `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	VaultAddress  string                 `protobuf:"bytes,2,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"` // the server's vault when empty
+	EpochNumber   string                 `protobuf:"bytes,3,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`    // the latest distribution when empty
+	MerkleRoot    string                 `protobuf:"bytes,4,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`       // a root pushed for epoch_number, which it requires
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetProofRequest) Reset() {
+	*x = GetProofRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetProofRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetProofRequest) ProtoMessage() {}
+
+func (x *GetProofRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetProofRequest.ProtoReflect.Descriptor instead.
+func (*GetProofRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetProofRequest) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *GetProofRequest) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *GetProofRequest) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *GetProofRequest) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+// MerkleProof proves a user's total earned against a merkle root
+type MerkleProof struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserAddress   string                 `protobuf:"bytes,1,opt,name=user_address,json=userAddress,proto3" json:"user_address,omitempty"`
+	VaultAddress  string                 `protobuf:"bytes,2,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	EpochNumber   string                 `protobuf:"bytes,3,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`
+	TotalEarned   string                 `protobuf:"bytes,4,opt,name=total_earned,json=totalEarned,proto3" json:"total_earned,omitempty"`
+	MerkleProof   []string               `protobuf:"bytes,5,rep,name=merkle_proof,json=merkleProof,proto3" json:"merkle_proof,omitempty"`
+	MerkleRoot    string                 `protobuf:"bytes,6,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	LeafIndex     int64                  `protobuf:"varint,7,opt,name=leaf_index,json=leafIndex,proto3" json:"leaf_index,omitempty"`
+	GeneratedAt   int64                  `protobuf:"varint,8,opt,name=generated_at,json=generatedAt,proto3" json:"generated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MerkleProof) Reset() {
+	*x = MerkleProof{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MerkleProof) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MerkleProof) ProtoMessage() {}
+
+func (x *MerkleProof) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MerkleProof.ProtoReflect.Descriptor instead.
+func (*MerkleProof) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *MerkleProof) GetUserAddress() string {
+	if x != nil {
+		return x.UserAddress
+	}
+	return ""
+}
+
+func (x *MerkleProof) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *MerkleProof) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *MerkleProof) GetTotalEarned() string {
+	if x != nil {
+		return x.TotalEarned
+	}
+	return ""
+}
+
+func (x *MerkleProof) GetMerkleProof() []string {
+	if x != nil {
+		return x.MerkleProof
+	}
+	return nil
+}
+
+func (x *MerkleProof) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *MerkleProof) GetLeafIndex() int64 {
+	if x != nil {
+		return x.LeafIndex
+	}
+	return 0
+}
+
+func (x *MerkleProof) GetGeneratedAt() int64 {
+	if x != nil {
+		return x.GeneratedAt
+	}
+	return 0
+}
+
+// ProofToVerify is a claim's proof to check
+type ProofToVerify struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress  string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	Recipient     string                 `protobuf:"bytes,2,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	TotalEarned   string                 `protobuf:"bytes,3,opt,name=total_earned,json=totalEarned,proto3" json:"total_earned,omitempty"`
+	MerkleProof   []string               `protobuf:"bytes,4,rep,name=merkle_proof,json=merkleProof,proto3" json:"merkle_proof,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProofToVerify) Reset() {
+	*x = ProofToVerify{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProofToVerify) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProofToVerify) ProtoMessage() {}
+
+func (x *ProofToVerify) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProofToVerify.ProtoReflect.Descriptor instead.
+func (*ProofToVerify) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ProofToVerify) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *ProofToVerify) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *ProofToVerify) GetTotalEarned() string {
+	if x != nil {
+		return x.TotalEarned
+	}
+	return ""
+}
+
+func (x *ProofToVerify) GetMerkleProof() []string {
+	if x != nil {
+		return x.MerkleProof
+	}
+	return nil
+}
+
+type VerifyProofsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Proofs        []*ProofToVerify       `protobuf:"bytes,1,rep,name=proofs,proto3" json:"proofs,omitempty"`
+	OnChain       bool                   `protobuf:"varint,2,opt,name=on_chain,json=onChain,proto3" json:"on_chain,omitempty"` // also require the vault's on-chain root
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyProofsRequest) Reset() {
+	*x = VerifyProofsRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyProofsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyProofsRequest) ProtoMessage() {}
+
+func (x *VerifyProofsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyProofsRequest.ProtoReflect.Descriptor instead.
+func (*VerifyProofsRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *VerifyProofsRequest) GetProofs() []*ProofToVerify {
+	if x != nil {
+		return x.Proofs
+	}
+	return nil
+}
+
+func (x *VerifyProofsRequest) GetOnChain() bool {
+	if x != nil {
+		return x.OnChain
+	}
+	return false
+}
+
+type VerifyProofsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*ProofVerification   `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // in request order
+	Valid         int32                  `protobuf:"varint,2,opt,name=valid,proto3" json:"valid,omitempty"`
+	Invalid       int32                  `protobuf:"varint,3,opt,name=invalid,proto3" json:"invalid,omitempty"`
+	VerifiedAt    int64                  `protobuf:"varint,4,opt,name=verified_at,json=verifiedAt,proto3" json:"verified_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VerifyProofsResponse) Reset() {
+	*x = VerifyProofsResponse{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VerifyProofsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VerifyProofsResponse) ProtoMessage() {}
+
+func (x *VerifyProofsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VerifyProofsResponse.ProtoReflect.Descriptor instead.
+func (*VerifyProofsResponse) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *VerifyProofsResponse) GetResults() []*ProofVerification {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *VerifyProofsResponse) GetValid() int32 {
+	if x != nil {
+		return x.Valid
+	}
+	return 0
+}
+
+func (x *VerifyProofsResponse) GetInvalid() int32 {
+	if x != nil {
+		return x.Invalid
+	}
+	return 0
+}
+
+func (x *VerifyProofsResponse) GetVerifiedAt() int64 {
+	if x != nil {
+		return x.VerifiedAt
+	}
+	return 0
+}
+
+// ProofVerification is whether a proof resolves to a stored root
+type ProofVerification struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress   string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	Recipient      string                 `protobuf:"bytes,2,opt,name=recipient,proto3" json:"recipient,omitempty"`
+	TotalEarned    string                 `protobuf:"bytes,3,opt,name=total_earned,json=totalEarned,proto3" json:"total_earned,omitempty"`
+	Valid          bool                   `protobuf:"varint,4,opt,name=valid,proto3" json:"valid,omitempty"`
+	MerkleRoot     string                 `protobuf:"bytes,5,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	EpochNumber    string                 `protobuf:"bytes,6,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"`
+	LeafEncoding   string                 `protobuf:"bytes,7,opt,name=leaf_encoding,json=leafEncoding,proto3" json:"leaf_encoding,omitempty"`
+	OnChainChecked bool                   `protobuf:"varint,8,opt,name=on_chain_checked,json=onChainChecked,proto3" json:"on_chain_checked,omitempty"` // whether on_chain was checked
+	OnChain        bool                   `protobuf:"varint,9,opt,name=on_chain,json=onChain,proto3" json:"on_chain,omitempty"`                        // whether the root is the vault's on-chain root
+	Reason         string                 `protobuf:"bytes,10,opt,name=reason,proto3" json:"reason,omitempty"`                                         // why the proof is invalid
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ProofVerification) Reset() {
+	*x = ProofVerification{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProofVerification) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProofVerification) ProtoMessage() {}
+
+func (x *ProofVerification) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProofVerification.ProtoReflect.Descriptor instead.
+func (*ProofVerification) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ProofVerification) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetRecipient() string {
+	if x != nil {
+		return x.Recipient
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetTotalEarned() string {
+	if x != nil {
+		return x.TotalEarned
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetValid() bool {
+	if x != nil {
+		return x.Valid
+	}
+	return false
+}
+
+func (x *ProofVerification) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetLeafEncoding() string {
+	if x != nil {
+		return x.LeafEncoding
+	}
+	return ""
+}
+
+func (x *ProofVerification) GetOnChainChecked() bool {
+	if x != nil {
+		return x.OnChainChecked
+	}
+	return false
+}
+
+func (x *ProofVerification) GetOnChain() bool {
+	if x != nil {
+		return x.OnChain
+	}
+	return false
+}
+
+func (x *ProofVerification) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type StreamRootUpdatesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress  string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"` // the server's vault when empty
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamRootUpdatesRequest) Reset() {
+	*x = StreamRootUpdatesRequest{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamRootUpdatesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamRootUpdatesRequest) ProtoMessage() {}
+
+func (x *StreamRootUpdatesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamRootUpdatesRequest.ProtoReflect.Descriptor instead.
+func (*StreamRootUpdatesRequest) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *StreamRootUpdatesRequest) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+// RootUpdate is a MerkleRootUpdated event of the DebtSubsidizer
+type RootUpdate struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	VaultAddress   string                 `protobuf:"bytes,1,opt,name=vault_address,json=vaultAddress,proto3" json:"vault_address,omitempty"`
+	MerkleRoot     string                 `protobuf:"bytes,2,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	EpochNumber    string                 `protobuf:"bytes,3,opt,name=epoch_number,json=epochNumber,proto3" json:"epoch_number,omitempty"` // epoch of the stored tree with this root, empty when none has it
+	TotalSubsidies string                 `protobuf:"bytes,4,opt,name=total_subsidies,json=totalSubsidies,proto3" json:"total_subsidies,omitempty"`
+	UpdatedBy      string                 `protobuf:"bytes,5,opt,name=updated_by,json=updatedBy,proto3" json:"updated_by,omitempty"`
+	TxHash         string                 `protobuf:"bytes,6,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	BlockNumber    uint64                 `protobuf:"varint,7,opt,name=block_number,json=blockNumber,proto3" json:"block_number,omitempty"`
+	BlockHash      string                 `protobuf:"bytes,8,opt,name=block_hash,json=blockHash,proto3" json:"block_hash,omitempty"`
+	LogIndex       uint32                 `protobuf:"varint,9,opt,name=log_index,json=logIndex,proto3" json:"log_index,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *RootUpdate) Reset() {
+	*x = RootUpdate{}
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RootUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RootUpdate) ProtoMessage() {}
+
+func (x *RootUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_epochserver_v1_epochserver_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RootUpdate.ProtoReflect.Descriptor instead.
+func (*RootUpdate) Descriptor() ([]byte, []int) {
+	return file_epochserver_v1_epochserver_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *RootUpdate) GetVaultAddress() string {
+	if x != nil {
+		return x.VaultAddress
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetMerkleRoot() string {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetEpochNumber() string {
+	if x != nil {
+		return x.EpochNumber
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetTotalSubsidies() string {
+	if x != nil {
+		return x.TotalSubsidies
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetUpdatedBy() string {
+	if x != nil {
+		return x.UpdatedBy
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetTxHash() string {
+	if x != nil {
+		return x.TxHash
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetBlockNumber() uint64 {
+	if x != nil {
+		return x.BlockNumber
+	}
+	return 0
+}
+
+func (x *RootUpdate) GetBlockHash() string {
+	if x != nil {
+		return x.BlockHash
+	}
+	return ""
+}
+
+func (x *RootUpdate) GetLogIndex() uint32 {
+	if x != nil {
+		return x.LogIndex
+	}
+	return 0
+}
+
+var File_epochserver_v1_epochserver_proto protoreflect.FileDescriptor
+
+const file_epochserver_v1_epochserver_proto_rawDesc = "" +
+	"\n" +
+	" epochserver/v1/epochserver.proto\x12\x0eepochserver.v1\"_\n" +
+	"\x11ListEpochsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x1c\n" +
+	"\tascending\x18\x03 \x01(\bR\tascending\"Y\n" +
+	"\x12ListEpochsResponse\x12-\n" +
+	"\x06epochs\x18\x01 \x03(\v2\x15.epochserver.v1.EpochR\x06epochs\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\"\xda\x03\n" +
+	"\x05Epoch\x12!\n" +
+	"\fepoch_number\x18\x01 \x01(\tR\vepochNumber\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12'\n" +
+	"\x0fstart_timestamp\x18\x03 \x01(\tR\x0estartTimestamp\x12#\n" +
+	"\rend_timestamp\x18\x04 \x01(\tR\fendTimestamp\x12D\n" +
+	"\x1eprocessing_completed_timestamp\x18\x05 \x01(\tR\x1cprocessingCompletedTimestamp\x12>\n" +
+	"\x1btotal_subsidies_distributed\x18\x06 \x01(\tR\x19totalSubsidiesDistributed\x126\n" +
+	"\x17total_yield_distributed\x18\a \x01(\tR\x15totalYieldDistributed\x12'\n" +
+	"\x0fyield_allocated\x18\b \x01(\tR\x0eyieldAllocated\x12&\n" +
+	"\x0fyield_held_back\x18\t \x01(\tR\ryieldHeldBack\x129\n" +
+	"\x18distribution_fingerprint\x18\n" +
+	" \x01(\tR\x17distributionFingerprint\"\x18\n" +
+	"\x16GetCurrentEpochRequest\"4\n" +
+	"\x17GetCurrentEpochResponse\x12\x19\n" +
+	"\bepoch_id\x18\x01 \x01(\x04R\aepochId\"c\n" +
+	"\x19GetUserTotalEarnedRequest\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\x12#\n" +
+	"\rvault_address\x18\x02 \x01(\tR\fvaultAddress\"\xc5\x01\n" +
+	"\fUserEarnings\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\x12#\n" +
+	"\rvault_address\x18\x02 \x01(\tR\fvaultAddress\x12!\n" +
+	"\ftotal_earned\x18\x03 \x01(\tR\vtotalEarned\x12#\n" +
+	"\rcalculated_at\x18\x04 \x01(\x03R\fcalculatedAt\x12%\n" +
+	"\x0edata_timestamp\x18\x05 \x01(\x03R\rdataTimestamp\"b\n" +
+	"\x18StreamAllocationsRequest\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12!\n" +
+	"\fepoch_number\x18\x02 \x01(\tR\vepochNumber\"I\n" +
+	"\n" +
+	"Allocation\x12\x18\n" +
+	"\aaccount\x18\x01 \x01(\tR\aaccount\x12!\n" +
+	"\ftotal_earned\x18\x02 \x01(\tR\vtotalEarned\"j\n" +
+	" StreamQuarantinedAccountsRequest\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12!\n" +
+	"\fepoch_number\x18\x02 \x01(\tR\vepochNumber\"\xf7\x01\n" +
+	"\x12QuarantinedAccount\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12!\n" +
+	"\fepoch_number\x18\x02 \x01(\tR\vepochNumber\x12!\n" +
+	"\fblock_number\x18\x03 \x01(\x04R\vblockNumber\x12\x1d\n" +
+	"\n" +
+	"subsidy_id\x18\x04 \x01(\tR\tsubsidyId\x12\x18\n" +
+	"\aaccount\x18\x05 \x01(\tR\aaccount\x12\x16\n" +
+	"\x06reason\x18\x06 \x01(\tR\x06reason\x12%\n" +
+	"\x0equarantined_at\x18\a \x01(\x03R\rquarantinedAt\"\x9d\x01\n" +
+	"\x0fGetProofRequest\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\x12#\n" +
+	"\rvault_address\x18\x02 \x01(\tR\fvaultAddress\x12!\n" +
+	"\fepoch_number\x18\x03 \x01(\tR\vepochNumber\x12\x1f\n" +
+	"\vmerkle_root\x18\x04 \x01(\tR\n" +
+	"merkleRoot\"\xa1\x02\n" +
+	"\vMerkleProof\x12!\n" +
+	"\fuser_address\x18\x01 \x01(\tR\vuserAddress\x12#\n" +
+	"\rvault_address\x18\x02 \x01(\tR\fvaultAddress\x12!\n" +
+	"\fepoch_number\x18\x03 \x01(\tR\vepochNumber\x12!\n" +
+	"\ftotal_earned\x18\x04 \x01(\tR\vtotalEarned\x12!\n" +
+	"\fmerkle_proof\x18\x05 \x03(\tR\vmerkleProof\x12\x1f\n" +
+	"\vmerkle_root\x18\x06 \x01(\tR\n" +
+	"merkleRoot\x12\x1d\n" +
+	"\n" +
+	"leaf_index\x18\a \x01(\x03R\tleafIndex\x12!\n" +
+	"\fgenerated_at\x18\b \x01(\x03R\vgeneratedAt\"\x98\x01\n" +
+	"\rProofToVerify\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12\x1c\n" +
+	"\trecipient\x18\x02 \x01(\tR\trecipient\x12!\n" +
+	"\ftotal_earned\x18\x03 \x01(\tR\vtotalEarned\x12!\n" +
+	"\fmerkle_proof\x18\x04 \x03(\tR\vmerkleProof\"g\n" +
+	"\x13VerifyProofsRequest\x125\n" +
+	"\x06proofs\x18\x01 \x03(\v2\x1d.epochserver.v1.ProofToVerifyR\x06proofs\x12\x19\n" +
+	"\bon_chain\x18\x02 \x01(\bR\aonChain\"\xa4\x01\n" +
+	"\x14VerifyProofsResponse\x12;\n" +
+	"\aresults\x18\x01 \x03(\v2!.epochserver.v1.ProofVerificationR\aresults\x12\x14\n" +
+	"\x05valid\x18\x02 \x01(\x05R\x05valid\x12\x18\n" +
+	"\ainvalid\x18\x03 \x01(\x05R\ainvalid\x12\x1f\n" +
+	"\vverified_at\x18\x04 \x01(\x03R\n" +
+	"verifiedAt\"\xd5\x02\n" +
+	"\x11ProofVerification\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12\x1c\n" +
+	"\trecipient\x18\x02 \x01(\tR\trecipient\x12!\n" +
+	"\ftotal_earned\x18\x03 \x01(\tR\vtotalEarned\x12\x14\n" +
+	"\x05valid\x18\x04 \x01(\bR\x05valid\x12\x1f\n" +
+	"\vmerkle_root\x18\x05 \x01(\tR\n" +
+	"merkleRoot\x12!\n" +
+	"\fepoch_number\x18\x06 \x01(\tR\vepochNumber\x12#\n" +
+	"\rleaf_encoding\x18\a \x01(\tR\fleafEncoding\x12(\n" +
+	"\x10on_chain_checked\x18\b \x01(\bR\x0eonChainChecked\x12\x19\n" +
+	"\bon_chain\x18\t \x01(\bR\aonChain\x12\x16\n" +
+	"\x06reason\x18\n" +
+	" \x01(\tR\x06reason\"?\n" +
+	"\x18StreamRootUpdatesRequest\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\"\xb5\x02\n" +
+	"\n" +
+	"RootUpdate\x12#\n" +
+	"\rvault_address\x18\x01 \x01(\tR\fvaultAddress\x12\x1f\n" +
+	"\vmerkle_root\x18\x02 \x01(\tR\n" +
+	"merkleRoot\x12!\n" +
+	"\fepoch_number\x18\x03 \x01(\tR\vepochNumber\x12'\n" +
+	"\x0ftotal_subsidies\x18\x04 \x01(\tR\x0etotalSubsidies\x12\x1d\n" +
+	"\n" +
+	"updated_by\x18\x05 \x01(\tR\tupdatedBy\x12\x17\n" +
+	"\atx_hash\x18\x06 \x01(\tR\x06txHash\x12!\n" +
+	"\fblock_number\x18\a \x01(\x04R\vblockNumber\x12\x1d\n" +
+	"\n" +
+	"block_hash\x18\b \x01(\tR\tblockHash\x12\x1b\n" +
+	"\tlog_index\x18\t \x01(\rR\blogIndex2\xa6\x02\n" +
+	"\fEpochService\x12S\n" +
+	"\n" +
+	"ListEpochs\x12!.epochserver.v1.ListEpochsRequest\x1a\".epochserver.v1.ListEpochsResponse\x12b\n" +
+	"\x0fGetCurrentEpoch\x12&.epochserver.v1.GetCurrentEpochRequest\x1a'.epochserver.v1.GetCurrentEpochResponse\x12]\n" +
+	"\x12GetUserTotalEarned\x12).epochserver.v1.GetUserTotalEarnedRequest\x1a\x1c.epochserver.v1.UserEarnings2\xe2\x01\n" +
+	"\x0eSubsidyService\x12[\n" +
+	"\x11StreamAllocations\x12(.epochserver.v1.StreamAllocationsRequest\x1a\x1a.epochserver.v1.Allocation0\x01\x12s\n" +
+	"\x19StreamQuarantinedAccounts\x120.epochserver.v1.StreamQuarantinedAccountsRequest\x1a\".epochserver.v1.QuarantinedAccount0\x012\x91\x02\n" +
+	"\rMerkleService\x12H\n" +
+	"\bGetProof\x12\x1f.epochserver.v1.GetProofRequest\x1a\x1b.epochserver.v1.MerkleProof\x12Y\n" +
+	"\fVerifyProofs\x12#.epochserver.v1.VerifyProofsRequest\x1a$.epochserver.v1.VerifyProofsResponse\x12[\n" +
+	"\x11StreamRootUpdates\x12(.epochserver.v1.StreamRootUpdatesRequest\x1a\x1a.epochserver.v1.RootUpdate0\x01BGZEgithub.com/andrey/epoch-server/api/proto/epochserver/v1;epochserverv1b\x06proto3"
+
+var (
+	file_epochserver_v1_epochserver_proto_rawDescOnce sync.Once
+	file_epochserver_v1_epochserver_proto_rawDescData []byte
+)
+
+func file_epochserver_v1_epochserver_proto_rawDescGZIP() []byte {
+	file_epochserver_v1_epochserver_proto_rawDescOnce.Do(func() {
+		file_epochserver_v1_epochserver_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_epochserver_v1_epochserver_proto_rawDesc), len(file_epochserver_v1_epochserver_proto_rawDesc)))
+	})
+	return file_epochserver_v1_epochserver_proto_rawDescData
+}
+
+var file_epochserver_v1_epochserver_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_epochserver_v1_epochserver_proto_goTypes = []any{
+	(*ListEpochsRequest)(nil),                // 0: epochserver.v1.ListEpochsRequest
+	(*ListEpochsResponse)(nil),               // 1: epochserver.v1.ListEpochsResponse
+	(*Epoch)(nil),                            // 2: epochserver.v1.Epoch
+	(*GetCurrentEpochRequest)(nil),           // 3: epochserver.v1.GetCurrentEpochRequest
+	(*GetCurrentEpochResponse)(nil),          // 4: epochserver.v1.GetCurrentEpochResponse
+	(*GetUserTotalEarnedRequest)(nil),        // 5: epochserver.v1.GetUserTotalEarnedRequest
+	(*UserEarnings)(nil),                     // 6: epochserver.v1.UserEarnings
+	(*StreamAllocationsRequest)(nil),         // 7: epochserver.v1.StreamAllocationsRequest
+	(*Allocation)(nil),                       // 8: epochserver.v1.Allocation
+	(*StreamQuarantinedAccountsRequest)(nil), // 9: epochserver.v1.StreamQuarantinedAccountsRequest
+	(*QuarantinedAccount)(nil),               // 10: epochserver.v1.QuarantinedAccount
+	(*GetProofRequest)(nil),                  // 11: epochserver.v1.GetProofRequest
+	(*MerkleProof)(nil),                      // 12: epochserver.v1.MerkleProof
+	(*ProofToVerify)(nil),                    // 13: epochserver.v1.ProofToVerify
+	(*VerifyProofsRequest)(nil),              // 14: epochserver.v1.VerifyProofsRequest
+	(*VerifyProofsResponse)(nil),             // 15: epochserver.v1.VerifyProofsResponse
+	(*ProofVerification)(nil),                // 16: epochserver.v1.ProofVerification
+	(*StreamRootUpdatesRequest)(nil),         // 17: epochserver.v1.StreamRootUpdatesRequest
+	(*RootUpdate)(nil),                       // 18: epochserver.v1.RootUpdate
+}
+var file_epochserver_v1_epochserver_proto_depIdxs = []int32{
+	2,  // 0: epochserver.v1.ListEpochsResponse.epochs:type_name -> epochserver.v1.Epoch
+	13, // 1: epochserver.v1.VerifyProofsRequest.proofs:type_name -> epochserver.v1.ProofToVerify
+	16, // 2: epochserver.v1.VerifyProofsResponse.results:type_name -> epochserver.v1.ProofVerification
+	0,  // 3: epochserver.v1.EpochService.ListEpochs:input_type -> epochserver.v1.ListEpochsRequest
+	3,  // 4: epochserver.v1.EpochService.GetCurrentEpoch:input_type -> epochserver.v1.GetCurrentEpochRequest
+	5,  // 5: epochserver.v1.EpochService.GetUserTotalEarned:input_type -> epochserver.v1.GetUserTotalEarnedRequest
+	7,  // 6: epochserver.v1.SubsidyService.StreamAllocations:input_type -> epochserver.v1.StreamAllocationsRequest
+	9,  // 7: epochserver.v1.SubsidyService.StreamQuarantinedAccounts:input_type -> epochserver.v1.StreamQuarantinedAccountsRequest
+	11, // 8: epochserver.v1.MerkleService.GetProof:input_type -> epochserver.v1.GetProofRequest
+	14, // 9: epochserver.v1.MerkleService.VerifyProofs:input_type -> epochserver.v1.VerifyProofsRequest
+	17, // 10: epochserver.v1.MerkleService.StreamRootUpdates:input_type -> epochserver.v1.StreamRootUpdatesRequest
+	1,  // 11: epochserver.v1.EpochService.ListEpochs:output_type -> epochserver.v1.ListEpochsResponse
+	4,  // 12: epochserver.v1.EpochService.GetCurrentEpoch:output_type -> epochserver.v1.GetCurrentEpochResponse
+	6,  // 13: epochserver.v1.EpochService.GetUserTotalEarned:output_type -> epochserver.v1.UserEarnings
+	8,  // 14: epochserver.v1.SubsidyService.StreamAllocations:output_type -> epochserver.v1.Allocation
+	10, // 15: epochserver.v1.SubsidyService.StreamQuarantinedAccounts:output_type -> epochserver.v1.QuarantinedAccount
+	12, // 16: epochserver.v1.MerkleService.GetProof:output_type -> epochserver.v1.MerkleProof
+	15, // 17: epochserver.v1.MerkleService.VerifyProofs:output_type -> epochserver.v1.VerifyProofsResponse
+	18, // 18: epochserver.v1.MerkleService.StreamRootUpdates:output_type -> epochserver.v1.RootUpdate
+	11, // [11:19] is the sub-list for method output_type
+	3,  // [3:11] is the sub-list for method input_type
+	3,  // [3:3] is the sub-list for extension type_name
+	3,  // [3:3] is the sub-list for extension extendee
+	0,  // [0:3] is the sub-list for field type_name
+}
+
+func init() { file_epochserver_v1_epochserver_proto_init() }
+func file_epochserver_v1_epochserver_proto_init() {
+	if File_epochserver_v1_epochserver_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_epochserver_v1_epochserver_proto_rawDesc), len(file_epochserver_v1_epochserver_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   3,
+		},
+		GoTypes:           file_epochserver_v1_epochserver_proto_goTypes,
+		DependencyIndexes: file_epochserver_v1_epochserver_proto_depIdxs,
+		MessageInfos:      file_epochserver_v1_epochserver_proto_msgTypes,
+	}.Build()
+	File_epochserver_v1_epochserver_proto = out.File
+	file_epochserver_v1_epochserver_proto_goTypes = nil
+	file_epochserver_v1_epochserver_proto_depIdxs = nil
+}
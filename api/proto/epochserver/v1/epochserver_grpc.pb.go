@@ -0,0 +1,553 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.29.3
+// source: epochserver/v1/epochserver.proto
+
+package epochserverv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	EpochService_ListEpochs_FullMethodName         = "/epochserver.v1.EpochService/ListEpochs"
+	EpochService_GetCurrentEpoch_FullMethodName    = "/epochserver.v1.EpochService/GetCurrentEpoch"
+	EpochService_GetUserTotalEarned_FullMethodName = "/epochserver.v1.EpochService/GetUserTotalEarned"
+)
+
+// EpochServiceClient is the client API for EpochService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// EpochService reads epochs and what users earned in them
+type EpochServiceClient interface {
+	// ListEpochs returns a page of the epochs known to the subgraph, newest first unless ascending is set
+	ListEpochs(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error)
+	// GetCurrentEpoch returns the current epoch ID from the EpochManager
+	GetCurrentEpoch(ctx context.Context, in *GetCurrentEpochRequest, opts ...grpc.CallOption) (*GetCurrentEpochResponse, error)
+	// GetUserTotalEarned returns the subsidies a user earned so far in a vault
+	GetUserTotalEarned(ctx context.Context, in *GetUserTotalEarnedRequest, opts ...grpc.CallOption) (*UserEarnings, error)
+}
+
+type epochServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEpochServiceClient(cc grpc.ClientConnInterface) EpochServiceClient {
+	return &epochServiceClient{cc}
+}
+
+func (c *epochServiceClient) ListEpochs(ctx context.Context, in *ListEpochsRequest, opts ...grpc.CallOption) (*ListEpochsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListEpochsResponse)
+	err := c.cc.Invoke(ctx, EpochService_ListEpochs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *epochServiceClient) GetCurrentEpoch(ctx context.Context, in *GetCurrentEpochRequest, opts ...grpc.CallOption) (*GetCurrentEpochResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCurrentEpochResponse)
+	err := c.cc.Invoke(ctx, EpochService_GetCurrentEpoch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *epochServiceClient) GetUserTotalEarned(ctx context.Context, in *GetUserTotalEarnedRequest, opts ...grpc.CallOption) (*UserEarnings, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UserEarnings)
+	err := c.cc.Invoke(ctx, EpochService_GetUserTotalEarned_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EpochServiceServer is the server API for EpochService service.
+// All implementations must embed UnimplementedEpochServiceServer
+// for forward compatibility.
+//
+// EpochService reads epochs and what users earned in them
+type EpochServiceServer interface {
+	// ListEpochs returns a page of the epochs known to the subgraph, newest first unless ascending is set
+	ListEpochs(context.Context, *ListEpochsRequest) (*ListEpochsResponse, error)
+	// GetCurrentEpoch returns the current epoch ID from the EpochManager
+	GetCurrentEpoch(context.Context, *GetCurrentEpochRequest) (*GetCurrentEpochResponse, error)
+	// GetUserTotalEarned returns the subsidies a user earned so far in a vault
+	GetUserTotalEarned(context.Context, *GetUserTotalEarnedRequest) (*UserEarnings, error)
+	mustEmbedUnimplementedEpochServiceServer()
+}
+
+// UnimplementedEpochServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEpochServiceServer struct{}
+
+func (UnimplementedEpochServiceServer) ListEpochs(context.Context, *ListEpochsRequest) (*ListEpochsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListEpochs not implemented")
+}
+func (UnimplementedEpochServiceServer) GetCurrentEpoch(context.Context, *GetCurrentEpochRequest) (*GetCurrentEpochResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentEpoch not implemented")
+}
+func (UnimplementedEpochServiceServer) GetUserTotalEarned(context.Context, *GetUserTotalEarnedRequest) (*UserEarnings, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserTotalEarned not implemented")
+}
+func (UnimplementedEpochServiceServer) mustEmbedUnimplementedEpochServiceServer() {}
+func (UnimplementedEpochServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeEpochServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EpochServiceServer will
+// result in compilation errors.
+type UnsafeEpochServiceServer interface {
+	mustEmbedUnimplementedEpochServiceServer()
+}
+
+func RegisterEpochServiceServer(s grpc.ServiceRegistrar, srv EpochServiceServer) {
+	// If the following call pancis, it indicates UnimplementedEpochServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&EpochService_ServiceDesc, srv)
+}
+
+func _EpochService_ListEpochs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListEpochsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EpochServiceServer).ListEpochs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EpochService_ListEpochs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EpochServiceServer).ListEpochs(ctx, req.(*ListEpochsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EpochService_GetCurrentEpoch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentEpochRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EpochServiceServer).GetCurrentEpoch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EpochService_GetCurrentEpoch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EpochServiceServer).GetCurrentEpoch(ctx, req.(*GetCurrentEpochRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EpochService_GetUserTotalEarned_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserTotalEarnedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EpochServiceServer).GetUserTotalEarned(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EpochService_GetUserTotalEarned_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EpochServiceServer).GetUserTotalEarned(ctx, req.(*GetUserTotalEarnedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// EpochService_ServiceDesc is the grpc.ServiceDesc for EpochService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var EpochService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "epochserver.v1.EpochService",
+	HandlerType: (*EpochServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListEpochs",
+			Handler:    _EpochService_ListEpochs_Handler,
+		},
+		{
+			MethodName: "GetCurrentEpoch",
+			Handler:    _EpochService_GetCurrentEpoch_Handler,
+		},
+		{
+			MethodName: "GetUserTotalEarned",
+			Handler:    _EpochService_GetUserTotalEarned_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "epochserver/v1/epochserver.proto",
+}
+
+const (
+	SubsidyService_StreamAllocations_FullMethodName         = "/epochserver.v1.SubsidyService/StreamAllocations"
+	SubsidyService_StreamQuarantinedAccounts_FullMethodName = "/epochserver.v1.SubsidyService/StreamQuarantinedAccounts"
+)
+
+// SubsidyServiceClient is the client API for SubsidyService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// SubsidyService reads the allocations of distributions
+type SubsidyServiceClient interface {
+	// StreamAllocations streams every account's cumulative amount in the merkle tree of an epoch's distribution
+	StreamAllocations(ctx context.Context, in *StreamAllocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Allocation], error)
+	// StreamQuarantinedAccounts streams the account subsidies distributions skipped for malformed subgraph data
+	StreamQuarantinedAccounts(ctx context.Context, in *StreamQuarantinedAccountsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QuarantinedAccount], error)
+}
+
+type subsidyServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSubsidyServiceClient(cc grpc.ClientConnInterface) SubsidyServiceClient {
+	return &subsidyServiceClient{cc}
+}
+
+func (c *subsidyServiceClient) StreamAllocations(ctx context.Context, in *StreamAllocationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Allocation], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SubsidyService_ServiceDesc.Streams[0], SubsidyService_StreamAllocations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamAllocationsRequest, Allocation]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SubsidyService_StreamAllocationsClient = grpc.ServerStreamingClient[Allocation]
+
+func (c *subsidyServiceClient) StreamQuarantinedAccounts(ctx context.Context, in *StreamQuarantinedAccountsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QuarantinedAccount], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &SubsidyService_ServiceDesc.Streams[1], SubsidyService_StreamQuarantinedAccounts_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamQuarantinedAccountsRequest, QuarantinedAccount]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SubsidyService_StreamQuarantinedAccountsClient = grpc.ServerStreamingClient[QuarantinedAccount]
+
+// SubsidyServiceServer is the server API for SubsidyService service.
+// All implementations must embed UnimplementedSubsidyServiceServer
+// for forward compatibility.
+//
+// SubsidyService reads the allocations of distributions
+type SubsidyServiceServer interface {
+	// StreamAllocations streams every account's cumulative amount in the merkle tree of an epoch's distribution
+	StreamAllocations(*StreamAllocationsRequest, grpc.ServerStreamingServer[Allocation]) error
+	// StreamQuarantinedAccounts streams the account subsidies distributions skipped for malformed subgraph data
+	StreamQuarantinedAccounts(*StreamQuarantinedAccountsRequest, grpc.ServerStreamingServer[QuarantinedAccount]) error
+	mustEmbedUnimplementedSubsidyServiceServer()
+}
+
+// UnimplementedSubsidyServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedSubsidyServiceServer struct{}
+
+func (UnimplementedSubsidyServiceServer) StreamAllocations(*StreamAllocationsRequest, grpc.ServerStreamingServer[Allocation]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAllocations not implemented")
+}
+func (UnimplementedSubsidyServiceServer) StreamQuarantinedAccounts(*StreamQuarantinedAccountsRequest, grpc.ServerStreamingServer[QuarantinedAccount]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamQuarantinedAccounts not implemented")
+}
+func (UnimplementedSubsidyServiceServer) mustEmbedUnimplementedSubsidyServiceServer() {}
+func (UnimplementedSubsidyServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeSubsidyServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SubsidyServiceServer will
+// result in compilation errors.
+type UnsafeSubsidyServiceServer interface {
+	mustEmbedUnimplementedSubsidyServiceServer()
+}
+
+func RegisterSubsidyServiceServer(s grpc.ServiceRegistrar, srv SubsidyServiceServer) {
+	// If the following call pancis, it indicates UnimplementedSubsidyServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&SubsidyService_ServiceDesc, srv)
+}
+
+func _SubsidyService_StreamAllocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAllocationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SubsidyServiceServer).StreamAllocations(m, &grpc.GenericServerStream[StreamAllocationsRequest, Allocation]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SubsidyService_StreamAllocationsServer = grpc.ServerStreamingServer[Allocation]
+
+func _SubsidyService_StreamQuarantinedAccounts_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamQuarantinedAccountsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(SubsidyServiceServer).StreamQuarantinedAccounts(m, &grpc.GenericServerStream[StreamQuarantinedAccountsRequest, QuarantinedAccount]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type SubsidyService_StreamQuarantinedAccountsServer = grpc.ServerStreamingServer[QuarantinedAccount]
+
+// SubsidyService_ServiceDesc is the grpc.ServiceDesc for SubsidyService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SubsidyService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "epochserver.v1.SubsidyService",
+	HandlerType: (*SubsidyServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAllocations",
+			Handler:       _SubsidyService_StreamAllocations_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamQuarantinedAccounts",
+			Handler:       _SubsidyService_StreamQuarantinedAccounts_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "epochserver/v1/epochserver.proto",
+}
+
+const (
+	MerkleService_GetProof_FullMethodName          = "/epochserver.v1.MerkleService/GetProof"
+	MerkleService_VerifyProofs_FullMethodName      = "/epochserver.v1.MerkleService/VerifyProofs"
+	MerkleService_StreamRootUpdates_FullMethodName = "/epochserver.v1.MerkleService/StreamRootUpdates"
+)
+
+// MerkleServiceClient is the client API for MerkleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MerkleService serves and verifies merkle proofs
+type MerkleServiceClient interface {
+	// GetProof returns a user's proof in the latest distribution, in an epoch's, or against a root pushed for an epoch
+	GetProof(ctx context.Context, in *GetProofRequest, opts ...grpc.CallOption) (*MerkleProof, error)
+	// VerifyProofs checks up to 1000 proofs against the roots stored for their vaults
+	VerifyProofs(ctx context.Context, in *VerifyProofsRequest, opts ...grpc.CallOption) (*VerifyProofsResponse, error)
+	// StreamRootUpdates streams every merkle root pushed for a vault, oldest first
+	StreamRootUpdates(ctx context.Context, in *StreamRootUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RootUpdate], error)
+}
+
+type merkleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMerkleServiceClient(cc grpc.ClientConnInterface) MerkleServiceClient {
+	return &merkleServiceClient{cc}
+}
+
+func (c *merkleServiceClient) GetProof(ctx context.Context, in *GetProofRequest, opts ...grpc.CallOption) (*MerkleProof, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MerkleProof)
+	err := c.cc.Invoke(ctx, MerkleService_GetProof_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) VerifyProofs(ctx context.Context, in *VerifyProofsRequest, opts ...grpc.CallOption) (*VerifyProofsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VerifyProofsResponse)
+	err := c.cc.Invoke(ctx, MerkleService_VerifyProofs_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) StreamRootUpdates(ctx context.Context, in *StreamRootUpdatesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[RootUpdate], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &MerkleService_ServiceDesc.Streams[0], MerkleService_StreamRootUpdates_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamRootUpdatesRequest, RootUpdate]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MerkleService_StreamRootUpdatesClient = grpc.ServerStreamingClient[RootUpdate]
+
+// MerkleServiceServer is the server API for MerkleService service.
+// All implementations must embed UnimplementedMerkleServiceServer
+// for forward compatibility.
+//
+// MerkleService serves and verifies merkle proofs
+type MerkleServiceServer interface {
+	// GetProof returns a user's proof in the latest distribution, in an epoch's, or against a root pushed for an epoch
+	GetProof(context.Context, *GetProofRequest) (*MerkleProof, error)
+	// VerifyProofs checks up to 1000 proofs against the roots stored for their vaults
+	VerifyProofs(context.Context, *VerifyProofsRequest) (*VerifyProofsResponse, error)
+	// StreamRootUpdates streams every merkle root pushed for a vault, oldest first
+	StreamRootUpdates(*StreamRootUpdatesRequest, grpc.ServerStreamingServer[RootUpdate]) error
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+// UnimplementedMerkleServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMerkleServiceServer struct{}
+
+func (UnimplementedMerkleServiceServer) GetProof(context.Context, *GetProofRequest) (*MerkleProof, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetProof not implemented")
+}
+func (UnimplementedMerkleServiceServer) VerifyProofs(context.Context, *VerifyProofsRequest) (*VerifyProofsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyProofs not implemented")
+}
+func (UnimplementedMerkleServiceServer) StreamRootUpdates(*StreamRootUpdatesRequest, grpc.ServerStreamingServer[RootUpdate]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamRootUpdates not implemented")
+}
+func (UnimplementedMerkleServiceServer) mustEmbedUnimplementedMerkleServiceServer() {}
+func (UnimplementedMerkleServiceServer) testEmbeddedByValue()                       {}
+
+// UnsafeMerkleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MerkleServiceServer will
+// result in compilation errors.
+type UnsafeMerkleServiceServer interface {
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+func RegisterMerkleServiceServer(s grpc.ServiceRegistrar, srv MerkleServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMerkleServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MerkleService_ServiceDesc, srv)
+}
+
+func _MerkleService_GetProof_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetProofRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).GetProof(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_GetProof_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).GetProof(ctx, req.(*GetProofRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_VerifyProofs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(VerifyProofsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).VerifyProofs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_VerifyProofs_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).VerifyProofs(ctx, req.(*VerifyProofsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_StreamRootUpdates_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamRootUpdatesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MerkleServiceServer).StreamRootUpdates(m, &grpc.GenericServerStream[StreamRootUpdatesRequest, RootUpdate]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type MerkleService_StreamRootUpdatesServer = grpc.ServerStreamingServer[RootUpdate]
+
+// MerkleService_ServiceDesc is the grpc.ServiceDesc for MerkleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MerkleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "epochserver.v1.MerkleService",
+	HandlerType: (*MerkleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetProof",
+			Handler:    _MerkleService_GetProof_Handler,
+		},
+		{
+			MethodName: "VerifyProofs",
+			Handler:    _MerkleService_VerifyProofs_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamRootUpdates",
+			Handler:       _MerkleService_StreamRootUpdates_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "epochserver/v1/epochserver.proto",
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// apiClient is a thin client for the epoch server HTTP API
+type apiClient struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// apiError is returned for non-2xx responses
+type apiError struct {
+	StatusCode int
+	Message    string
+	Body       json.RawMessage
+}
+
+func (e *apiError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("server returned %d", e.StatusCode)
+}
+
+func newAPIClient(baseURL string, timeout time.Duration) *apiClient {
+	return &apiClient{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// get performs a GET request and returns the raw JSON body
+func (c *apiClient) get(ctx context.Context, path string, query url.Values) (json.RawMessage, error) {
+	return c.do(ctx, http.MethodGet, path, query)
+}
+
+// post performs a POST request without a body and returns the raw JSON body
+func (c *apiClient) post(ctx context.Context, path string, query url.Values) (json.RawMessage, error) {
+	return c.do(ctx, http.MethodPost, path, query)
+}
+
+func (c *apiClient) do(ctx context.Context, method, path string, query url.Values) (json.RawMessage, error) {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &apiError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &errBody) == nil {
+			apiErr.Message = errBody.Error
+		}
+		if json.Valid(body) {
+			apiErr.Body = body
+		}
+		return nil, apiErr
+	}
+
+	return body, nil
+}
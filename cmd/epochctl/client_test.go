@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAPIClient_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("epochId") != "7" {
+			t.Errorf("expected epochId=7, got %q", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`{"error":"Failed to force end epoch"}`))
+	}))
+	defer server.Close()
+
+	client := newAPIClient(server.URL+"/", time.Second)
+	_, err := client.post(context.Background(), "/api/epochs/force-end", map[string][]string{"epochId": {"7"}})
+
+	var apiErr *apiError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("expected apiError, got %v", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "Failed to force end epoch" {
+		t.Errorf("unexpected error: %+v", apiErr)
+	}
+}
+
+func TestTailCommand_Report(t *testing.T) {
+	var out bytes.Buffer
+	cmd := &tailCommand{out: &out}
+	seen := make(map[string]string)
+
+	first := `{"epochs":[{"epochNumber":"2","status":"ACTIVE"},{"epochNumber":"1","status":"COMPLETED"}]}`
+	if err := cmd.report([]byte(first), seen); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "epoch 1 COMPLETED") || !strings.HasSuffix(lines[1], "epoch 2 ACTIVE") {
+		t.Fatalf("unexpected initial output:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := cmd.report([]byte(first), seen); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output for unchanged epochs, got %q", out.String())
+	}
+
+	second := `{"epochs":[{"epochNumber":"3","status":"ACTIVE"},{"epochNumber":"2","status":"COMPLETED"}]}`
+	if err := cmd.report([]byte(second), seen); err != nil {
+		t.Fatalf("report failed: %v", err)
+	}
+	lines = strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "epoch 2 ACTIVE -> COMPLETED") || !strings.HasSuffix(lines[1], "epoch 3 ACTIVE") {
+		t.Errorf("unexpected transition output:\n%s", out.String())
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// epochsCommand lists recent epochs
+type epochsCommand struct {
+	opts  *options
+	Limit int `short:"n" long:"limit" default:"20" description:"Maximum number of epochs to list"`
+}
+
+func (c *epochsCommand) Execute(_ []string) error {
+	query := url.Values{"limit": {strconv.Itoa(c.Limit)}}
+	body, err := c.opts.client().get(context.Background(), "/api/epochs", query)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// startCommand starts a new epoch
+type startCommand struct {
+	opts *options
+}
+
+func (c *startCommand) Execute(_ []string) error {
+	body, err := c.opts.client().post(context.Background(), "/api/epochs/start", nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// distributeCommand triggers subsidy distribution for the current epoch
+type distributeCommand struct {
+	opts *options
+}
+
+func (c *distributeCommand) Execute(_ []string) error {
+	body, err := c.opts.client().post(context.Background(), "/api/epochs/distribute", nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// forceEndCommand force-ends an epoch with zero yield
+type forceEndCommand struct {
+	opts  *options
+	Epoch uint64 `short:"e" long:"epoch" required:"true" description:"Epoch ID to force-end"`
+}
+
+func (c *forceEndCommand) Execute(_ []string) error {
+	query := url.Values{"epochId": {strconv.FormatUint(c.Epoch, 10)}}
+	body, err := c.opts.client().post(context.Background(), "/api/epochs/force-end", query)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// proofCommand fetches a user's merkle proof
+type proofCommand struct {
+	opts  *options
+	Epoch string `short:"e" long:"epoch" description:"Historical epoch number (defaults to the latest snapshot)"`
+	Root  string `long:"root" description:"Root submitted for the epoch, to prove against a root that was since replaced"`
+	Vault string `long:"vault" description:"Vault address (defaults to the server's configured vault)"`
+	Args  struct {
+		Address string `positional-arg-name:"address" required:"true"`
+	} `positional-args:"yes"`
+}
+
+func (c *proofCommand) Execute(_ []string) error {
+	query := url.Values{"address": {c.Args.Address}}
+	for name, value := range map[string]string{"epoch": c.Epoch, "root": c.Root, "vault": c.Vault} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+
+	body, err := c.opts.client().get(context.Background(), "/api/proofs", query)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// earnedCommand shows a user's total earned subsidies
+type earnedCommand struct {
+	opts *options
+	Args struct {
+		Address string `positional-arg-name:"address" required:"true"`
+	} `positional-args:"yes"`
+}
+
+func (c *earnedCommand) Execute(_ []string) error {
+	path := "/api/users/" + url.PathEscape(c.Args.Address) + "/total-earned"
+	body, err := c.opts.client().get(context.Background(), path, nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// verifyCommand compares a vault's stored merkle root with the on-chain root
+type verifyCommand struct {
+	opts  *options
+	Vault string `long:"vault" required:"true" description:"Vault address"`
+}
+
+func (c *verifyCommand) Execute(_ []string) error {
+	path := "/api/vaults/" + url.PathEscape(c.Vault) + "/merkle-root/verify"
+	body, err := c.opts.client().get(context.Background(), path, nil)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// onchainCommand shows the current epoch state as the contracts report it
+type onchainCommand struct {
+	opts  *options
+	Vault string `long:"vault" description:"Vault address (default: the server's configured vault)"`
+}
+
+func (c *onchainCommand) Execute(_ []string) error {
+	query := url.Values{}
+	if c.Vault != "" {
+		query.Set("vault", c.Vault)
+	}
+	body, err := c.opts.client().get(context.Background(), "/api/epochs/current/onchain", query)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, body)
+}
+
+// replayCommand recomputes a past epoch's distribution and reports drift from what was committed
+type replayCommand struct {
+	opts  *options
+	Vault string `long:"vault" required:"true" description:"Vault address"`
+	Epoch string `short:"e" long:"epoch" required:"true" description:"Epoch number to replay"`
+}
+
+func (c *replayCommand) Execute(_ []string) error {
+	path := "/api/vaults/" + url.PathEscape(c.Vault) + "/epochs/" + url.PathEscape(c.Epoch) + "/replay"
+	body, err := c.opts.client().get(context.Background(), path, nil)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(os.Stdout, body); err != nil {
+		return err
+	}
+
+	var result struct {
+		Matches bool `json:"matches"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse replay result: %w", err)
+	}
+	if !result.Matches {
+		return errReplayDrift
+	}
+	return nil
+}
+
+// tailCommand polls the epoch list and prints lifecycle changes
+type tailCommand struct {
+	opts     *options
+	out      io.Writer
+	Interval time.Duration `short:"i" long:"interval" default:"15s" description:"Polling interval"`
+	Limit    int           `short:"n" long:"limit" default:"5" description:"Number of recent epochs to watch"`
+}
+
+type epochState struct {
+	EpochNumber string `json:"epochNumber"`
+	Status      string `json:"status"`
+}
+
+func (c *tailCommand) Execute(_ []string) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	client := c.opts.client()
+	query := url.Values{"limit": {strconv.Itoa(c.Limit)}}
+	seen := make(map[string]string)
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for {
+		body, err := client.get(ctx, "/api/epochs", query)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "%s poll failed: %v\n", time.Now().Format(time.RFC3339), err)
+		} else if err := c.report(body, seen); err != nil {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// report prints epochs that are new or whose status changed since the previous poll
+func (c *tailCommand) report(body json.RawMessage, seen map[string]string) error {
+	var response struct {
+		Epochs []epochState `json:"epochs"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to decode epochs: %w", err)
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	// the server lists newest first, print oldest first so the output reads chronologically
+	for i := len(response.Epochs) - 1; i >= 0; i-- {
+		e := response.Epochs[i]
+		previous, ok := seen[e.EpochNumber]
+		switch {
+		case !ok:
+			fmt.Fprintf(c.out, "%s epoch %s %s\n", now, e.EpochNumber, e.Status)
+		case previous != e.Status:
+			fmt.Fprintf(c.out, "%s epoch %s %s -> %s\n", now, e.EpochNumber, previous, e.Status)
+		}
+		seen[e.EpochNumber] = e.Status
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// epochctl is an operator CLI for the epoch server API.
+//
+// It wraps the server's HTTP endpoints so routine workflows (listing epochs, triggering
+// distribution, fetching proofs, verifying roots, force-ending epochs, replaying past
+// epochs) don't need curl.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// errReplayDrift is returned by replay when the recomputed distribution differs from the committed one
+var errReplayDrift = errors.New("replayed distribution differs from the committed one")
+
+// options holds flags shared by all commands
+type options struct {
+	Server  string        `short:"s" long:"server" env:"EPOCHCTL_SERVER" default:"http://localhost:8080" description:"Epoch server base URL"`
+	Timeout time.Duration `long:"timeout" env:"EPOCHCTL_TIMEOUT" default:"5m" description:"Request timeout"`
+}
+
+func (o *options) client() *apiClient {
+	return newAPIClient(o.Server, o.Timeout)
+}
+
+func main() {
+	var opts options
+	parser := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash)
+
+	commands := []struct {
+		name, short, long string
+		data              interface{}
+	}{
+		{"epochs", "List epochs", "List the most recent epochs known to the server, newest first.", &epochsCommand{opts: &opts}},
+		{"start", "Start a new epoch", "Start a new epoch on-chain.", &startCommand{opts: &opts}},
+		{"distribute", "Trigger subsidy distribution", "Build the merkle tree for the current epoch, submit its root and complete the epoch.", &distributeCommand{opts: &opts}},
+		{"force-end", "Force-end an epoch", "Force-end an epoch with zero yield.", &forceEndCommand{opts: &opts}},
+		{"proof", "Fetch a user's merkle proof", "Fetch the merkle proof for a user, optionally for a historical epoch.", &proofCommand{opts: &opts}},
+		{"earned", "Show a user's total earned", "Show the total subsidies earned by a user.", &earnedCommand{opts: &opts}},
+		{"verify", "Verify a vault's merkle root", "Recompute the vault's merkle root from stored leaves and compare it to the on-chain root.", &verifyCommand{opts: &opts}},
+		{"onchain", "Show on-chain epoch state", "Show the current epoch, the vault's yield and the DebtSubsidizer's merkle root and totals as the contracts report them, read at one block.", &onchainCommand{opts: &opts}},
+		{"replay", "Replay a past epoch", "Recompute an epoch's allocations and merkle root from the subgraph state at its snapshot block and diff them against the committed distribution. Exits with status 3 on drift.", &replayCommand{opts: &opts}},
+		{"tail", "Follow epoch events", "Poll the server and print epoch lifecycle changes as they happen.", &tailCommand{opts: &opts, out: os.Stdout}},
+	}
+	for _, c := range commands {
+		if _, err := parser.AddCommand(c.name, c.short, c.long, c.data); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to register command %s: %v\n", c.name, err)
+			os.Exit(2)
+		}
+	}
+
+	if _, err := parser.Parse(); err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) {
+			if flagsErr.Type == flags.ErrHelp {
+				fmt.Fprintln(os.Stdout, flagsErr.Message)
+				os.Exit(0)
+			}
+			fmt.Fprintln(os.Stderr, flagsErr.Message)
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, errReplayDrift) {
+			os.Exit(3)
+		}
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && len(apiErr.Body) > 0 {
+			_ = printJSON(os.Stderr, apiErr.Body)
+		}
+		os.Exit(1)
+	}
+}
+
+// printJSON writes raw JSON to w, indented for readability
+func printJSON(w io.Writer, raw json.RawMessage) error {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, raw, "", "  "); err != nil {
+		_, err = fmt.Fprintln(w, string(raw))
+		return err
+	}
+	buf.WriteByte('\n')
+	_, err := buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,142 @@
+// loadtest generates claim traffic against a running epoch server and reports latency percentiles.
+//
+// Workers fetch merkle proofs, claimable summaries and claim payloads of the given users and verify batches of
+// the proofs fetched, picking operations at random in the proportions of --mix, until --duration passes or
+// --requests were sent. It prints the latency percentiles, throughput and status codes of each operation, and
+// exits with status 3 when more requests failed than --max-error-rate allows, so a run can gate a deployment.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/jessevdk/go-flags"
+)
+
+// errTooManyErrors is returned when the share of failed requests is above --max-error-rate
+var errTooManyErrors = errors.New("error rate above the allowed maximum")
+
+// options of a load test run
+type options struct {
+	Server       string        `short:"s" long:"server" env:"LOADTEST_SERVER" default:"http://localhost:8080" description:"Epoch server base URL"`
+	Concurrency  int           `short:"c" long:"concurrency" default:"16" description:"Workers sending requests at once"`
+	Duration     time.Duration `short:"d" long:"duration" default:"30s" description:"How long to send requests"`
+	Requests     int           `short:"n" long:"requests" description:"Stop after this many requests (default: only the duration limits the run)"`
+	Users        []string      `short:"u" long:"user" description:"User address to request, repeatable"`
+	UsersFile    string        `long:"users-file" description:"File with one user address per line"`
+	Vault        string        `long:"vault" description:"Vault of the claim payloads (default: the server's CollectionsVault)"`
+	Mix          string        `long:"mix" default:"proof=5,claimable=3,payload=1,verify=1" description:"Relative weights of the operations"`
+	VerifyBatch  int           `long:"verify-batch" default:"10" description:"Proofs sent in one verification request"`
+	Timeout      time.Duration `long:"timeout" default:"10s" description:"Timeout of one request"`
+	MaxErrorRate float64       `long:"max-error-rate" default:"0.01" description:"Share of failed requests above which the run exits with status 3"`
+}
+
+func main() {
+	var opts options
+	if _, err := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash).Parse(); err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+			fmt.Fprintln(os.Stdout, flagsErr.Message)
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	// an interrupted run still reports what it measured
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := run(ctx, opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, errTooManyErrors) {
+			os.Exit(3)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts options, out io.Writer) error {
+	if opts.Concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", opts.Concurrency)
+	}
+	if opts.VerifyBatch < 1 {
+		return fmt.Errorf("verify batch must be at least 1, got %d", opts.VerifyBatch)
+	}
+	mix, err := parseMix(opts.Mix)
+	if err != nil {
+		return err
+	}
+	users, err := loadUsers(opts.Users, opts.UsersFile)
+	if err != nil {
+		return err
+	}
+	if opts.Vault != "" && !utils.IsValidAddress(opts.Vault) {
+		return fmt.Errorf("invalid vault address %q", opts.Vault)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Duration)
+	defer cancel()
+
+	gen := newGenerator(opts, users, mix)
+	fmt.Fprintf(out, "sending %s traffic for %d users to %s with %d workers for %s\n",
+		opts.Mix, len(users), gen.baseURL, opts.Concurrency, opts.Duration)
+	report := gen.run(ctx)
+	if err := report.print(out); err != nil {
+		return err
+	}
+
+	if rate := report.errorRate(); rate > opts.MaxErrorRate {
+		return fmt.Errorf("%w: %.2f%% of requests failed, at most %.2f%% allowed",
+			errTooManyErrors, rate*100, opts.MaxErrorRate*100)
+	}
+	return nil
+}
+
+// loadUsers returns the users given on the command line and in the file, normalized and without duplicates
+func loadUsers(given []string, file string) ([]string, error) {
+	addresses := append([]string(nil), given...)
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open users file: %w", err)
+		}
+		defer f.Close() //nolint:errcheck // read-only file
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				addresses = append(addresses, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read users file: %w", err)
+		}
+	}
+
+	seen := make(map[string]bool, len(addresses))
+	users := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		normalized, err := utils.ValidateAndNormalizeAddress(address)
+		if err != nil {
+			return nil, fmt.Errorf("invalid user address %q", address)
+		}
+		if !seen[normalized] {
+			seen[normalized] = true
+			users = append(users, normalized)
+		}
+	}
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no users to request, give them with --user or --users-file")
+	}
+	return users, nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+const (
+	testUser    = "0x1111111111111111111111111111111111111111"
+	testNewUser = "0x2222222222222222222222222222222222222222" // in no distribution
+)
+
+// claimServer answers the claim endpoints for testUser and 404 for anyone else, counting requests by route
+type claimServer struct {
+	mu       sync.Mutex
+	requests map[string]int
+	verified []merkle.ProofToVerify
+}
+
+func (s *claimServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	route := r.Method + " " + strings.Replace(r.URL.Path, testNewUser, "{address}", 1)
+	route = strings.Replace(route, testUser, "{address}", 1)
+	s.requests[route]++
+
+	if r.Method == http.MethodPost {
+		var req struct {
+			Proofs []merkle.ProofToVerify `json:"proofs"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Proofs) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.verified = append(s.verified, req.Proofs...)
+		_ = json.NewEncoder(w).Encode(merkle.ProofVerifications{Valid: len(req.Proofs)})
+		return
+	}
+	if !strings.Contains(r.URL.Path, testUser) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(merkle.UserMerkleProofResponse{
+		UserAddress: testUser, VaultAddress: "0x3333333333333333333333333333333333333333",
+		TotalEarned: "1000", MerkleProof: []string{"ab"},
+	})
+}
+
+func TestRun(t *testing.T) {
+	handler := &claimServer{requests: make(map[string]int)}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		Server: server.URL, Concurrency: 4, Duration: time.Minute, Requests: 200,
+		Users: []string{testUser, testNewUser}, Mix: "proof=1,claimable=1,verify=2",
+		VerifyBatch: 3, Timeout: time.Second, MaxErrorRate: 0.01,
+	}, &out)
+	require.NoError(t, err, "404s for users without a claim are not failures")
+
+	handler.mu.Lock()
+	defer handler.mu.Unlock()
+	total := 0
+	for _, n := range handler.requests {
+		total += n
+	}
+	assert.Equal(t, 200, total, "the run stops at the request limit")
+	assert.Zero(t, handler.requests["GET /api/users/{address}/claim-payload"], "operations left out are not sent")
+	assert.Positive(t, handler.requests["POST /api/proofs/verify"])
+	for _, proof := range handler.verified {
+		assert.Equal(t, testUser, proof.Recipient, "only fetched proofs are verified")
+	}
+
+	report := out.String()
+	for _, line := range []string{"proof", "claimable", "verify", "total"} {
+		assert.Contains(t, report, "\n"+line+" ")
+	}
+	assert.Contains(t, report, "404:")
+}
+
+func TestRun_FailsAboveErrorRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	err := run(context.Background(), options{
+		Server: server.URL, Concurrency: 2, Duration: time.Minute, Requests: 20,
+		Users: []string{testUser}, Mix: "claimable=1", VerifyBatch: 1, Timeout: time.Second, MaxErrorRate: 0.5,
+	}, &out)
+	assert.True(t, errors.Is(err, errTooManyErrors), "got %v", err)
+	assert.Contains(t, out.String(), "503:20")
+}
+
+func TestParseMix(t *testing.T) {
+	mix, err := parseMix("proof=3, verify=0,claimable=1")
+	require.NoError(t, err)
+	assert.Equal(t, []weightedOp{{op: opProof, weight: 3}, {op: opClaimable, weight: 1}}, mix)
+
+	for _, invalid := range []string{"proof", "proof=x", "proof=-1", "refund=1", "proof=1,proof=2", "proof=0"} {
+		_, err := parseMix(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLoadUsers(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.txt")
+	content := "# recipients of epoch 5\n0x" + strings.ToUpper(testUser[2:]) + "\n\n" + testNewUser + "\n"
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o600))
+
+	users, err := loadUsers([]string{testUser}, file)
+	require.NoError(t, err)
+	assert.Equal(t, []string{testUser, testNewUser}, users, "addresses are normalized and deduplicated")
+
+	_, err = loadUsers(nil, "")
+	assert.Error(t, err)
+	_, err = loadUsers([]string{"not-an-address"}, "")
+	assert.Error(t, err)
+}
+
+func TestPercentile(t *testing.T) {
+	latencies := make([]time.Duration, 100)
+	for i := range latencies {
+		latencies[i] = time.Duration(i+1) * time.Millisecond
+	}
+	assert.Equal(t, 50*time.Millisecond, percentile(latencies, 0.50))
+	assert.Equal(t, 99*time.Millisecond, percentile(latencies, 0.99))
+	assert.Equal(t, 100*time.Millisecond, percentile(latencies, 1))
+	assert.Equal(t, time.Millisecond, percentile(latencies[:1], 0.99))
+}
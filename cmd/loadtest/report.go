@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// opStats are the results of one operation's requests
+type opStats struct {
+	latencies []time.Duration
+	statuses  map[int]int // by status code, 0 for requests that got no response
+	failed    int
+}
+
+// report collects the results of a run from every worker
+type report struct {
+	mu      sync.Mutex
+	ops     map[operation]*opStats
+	elapsed time.Duration
+}
+
+func newReport() *report {
+	return &report{ops: make(map[operation]*opStats)}
+}
+
+// record adds a request's result. A user without a claim is answered 404 or 409 by a healthy server, the
+// request failed when it got no response, a 429, or any other status.
+func (r *report) record(op operation, took time.Duration, status int, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, ok := r.ops[op]
+	if !ok {
+		stats = &opStats{statuses: make(map[int]int)}
+		r.ops[op] = stats
+	}
+	stats.latencies = append(stats.latencies, took)
+	if err != nil {
+		status = 0
+	}
+	stats.statuses[status]++
+	switch status {
+	case http.StatusOK, http.StatusNotFound, http.StatusConflict:
+	default:
+		stats.failed++
+	}
+}
+
+// errorRate is the share of failed requests over every operation
+func (r *report) errorRate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var total, failed int
+	for _, stats := range r.ops {
+		total += len(stats.latencies)
+		failed += stats.failed
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(failed) / float64(total)
+}
+
+// print writes a line of latency percentiles and status codes per operation, and one over all of them
+func (r *report) print(out io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "OPERATION\tREQUESTS\tRPS\tFAILED\tP50\tP90\tP95\tP99\tMAX\tSTATUSES")
+	all := &opStats{statuses: make(map[int]int)}
+	for _, op := range operations {
+		stats, ok := r.ops[op]
+		if !ok {
+			continue
+		}
+		r.printLine(w, string(op), stats)
+		all.latencies = append(all.latencies, stats.latencies...)
+		all.failed += stats.failed
+		for status, n := range stats.statuses {
+			all.statuses[status] += n
+		}
+	}
+	if len(all.latencies) == 0 {
+		fmt.Fprintln(w, "no requests completed")
+		return w.Flush()
+	}
+	r.printLine(w, "total", all)
+	return w.Flush()
+}
+
+func (r *report) printLine(w io.Writer, name string, stats *opStats) {
+	sorted := slices.Clone(stats.latencies)
+	slices.Sort(sorted)
+	rps := 0.0
+	if r.elapsed > 0 {
+		rps = float64(len(sorted)) / r.elapsed.Seconds()
+	}
+	fmt.Fprintf(w, "%s\t%d\t%.1f\t%d\t%s\t%s\t%s\t%s\t%s\t%s\n", name, len(sorted), rps, stats.failed,
+		formatLatency(percentile(sorted, 0.50)), formatLatency(percentile(sorted, 0.90)),
+		formatLatency(percentile(sorted, 0.95)), formatLatency(percentile(sorted, 0.99)),
+		formatLatency(sorted[len(sorted)-1]), formatStatuses(stats.statuses))
+}
+
+// percentile returns the nearest-rank percentile p of sorted latencies, which must not be empty
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
+
+func formatLatency(d time.Duration) string {
+	return d.Round(10 * time.Microsecond).String()
+}
+
+// formatStatuses lists the count of each status code in ascending order, requests without a response as error
+func formatStatuses(statuses map[int]int) string {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, code := range codes {
+		name := strconv.Itoa(code)
+		if code == 0 {
+			name = "error"
+		}
+		parts = append(parts, fmt.Sprintf("%s:%d", name, statuses[code]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,275 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// operation is a kind of request the generator sends
+type operation string
+
+const (
+	opProof     operation = "proof"     // GET /api/users/{address}/merkle-proof
+	opClaimable operation = "claimable" // GET /api/users/{address}/claimable
+	opPayload   operation = "payload"   // GET /api/users/{address}/claim-payload
+	opVerify    operation = "verify"    // POST /api/proofs/verify with proofs fetched earlier
+)
+
+// operations in the order they are reported
+var operations = []operation{opProof, opClaimable, opPayload, opVerify}
+
+// maxCachedProofs bounds the proofs kept for verification requests, later proofs replace random ones
+const maxCachedProofs = 1000
+
+// weightedOp is an operation and its share of the traffic
+type weightedOp struct {
+	op     operation
+	weight int
+}
+
+// parseMix parses weights given as op=weight pairs separated by commas, every operation left out is not sent
+func parseMix(mix string) ([]weightedOp, error) {
+	var weighted []weightedOp
+	seen := make(map[operation]bool)
+	for _, part := range strings.Split(mix, ",") {
+		name, weight, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mix entry %q, expected op=weight", part)
+		}
+		op := operation(strings.TrimSpace(name))
+		if !isOperation(op) {
+			return nil, fmt.Errorf("unknown operation %q in mix, expected one of %v", op, operations)
+		}
+		if seen[op] {
+			return nil, fmt.Errorf("operation %s is in the mix twice", op)
+		}
+		seen[op] = true
+		w, err := strconv.Atoi(strings.TrimSpace(weight))
+		if err != nil || w < 0 {
+			return nil, fmt.Errorf("invalid weight %q of %s", weight, op)
+		}
+		if w > 0 {
+			weighted = append(weighted, weightedOp{op: op, weight: w})
+		}
+	}
+	if len(weighted) == 0 {
+		return nil, fmt.Errorf("mix %q sends no requests", mix)
+	}
+	return weighted, nil
+}
+
+func isOperation(op operation) bool {
+	for _, known := range operations {
+		if op == known {
+			return true
+		}
+	}
+	return false
+}
+
+// generator sends the requests of a run from its workers
+type generator struct {
+	baseURL     string
+	client      *http.Client
+	users       []string
+	vault       string
+	mix         []weightedOp
+	totalWeight int
+	concurrency int
+	verifyBatch int
+	limit       int64 // requests to send, 0 for no limit
+	sent        atomic.Int64
+
+	proofsMu sync.Mutex
+	proofs   []merkle.ProofToVerify // proofs fetched by proof requests, for verification requests
+}
+
+func newGenerator(opts options, users []string, mix []weightedOp) *generator {
+	g := &generator{
+		baseURL: strings.TrimRight(opts.Server, "/"),
+		// every worker keeps its connection, as the clients of a deployed server would
+		client: &http.Client{
+			Timeout:   opts.Timeout,
+			Transport: &http.Transport{MaxIdleConnsPerHost: opts.Concurrency},
+		},
+		users:       users,
+		vault:       opts.Vault,
+		mix:         mix,
+		concurrency: opts.Concurrency,
+		verifyBatch: opts.VerifyBatch,
+		limit:       int64(opts.Requests),
+	}
+	for _, w := range mix {
+		g.totalWeight += w.weight
+	}
+	return g
+}
+
+// run sends requests from every worker until ctx is done or the request limit is reached
+func (g *generator) run(ctx context.Context) *report {
+	rep := newReport()
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for i := range g.concurrency {
+		wg.Add(1)
+		go func(rng *rand.Rand) {
+			defer wg.Done()
+			g.work(ctx, rep, rng)
+		}(rand.New(rand.NewPCG(uint64(start.UnixNano()), uint64(i))))
+	}
+	wg.Wait()
+
+	rep.elapsed = time.Since(start)
+	return rep
+}
+
+func (g *generator) work(ctx context.Context, rep *report, rng *rand.Rand) {
+	for ctx.Err() == nil {
+		if g.limit > 0 && g.sent.Add(1) > g.limit {
+			return
+		}
+		op := g.pick(rng)
+		user := g.users[rng.IntN(len(g.users))]
+
+		op, took, status, err := g.send(ctx, op, user, rng)
+		if err != nil && ctx.Err() != nil {
+			return // cut short by the end of the run, not a failure of the server
+		}
+		rep.record(op, took, status, err)
+	}
+}
+
+// pick draws an operation in proportion to its weight
+func (g *generator) pick(rng *rand.Rand) operation {
+	n := rng.IntN(g.totalWeight)
+	for _, w := range g.mix {
+		if n < w.weight {
+			return w.op
+		}
+		n -= w.weight
+	}
+	return g.mix[len(g.mix)-1].op
+}
+
+// send makes the request of op for user and returns the operation actually sent: a verification before any
+// proof was fetched fetches one instead
+func (g *generator) send(
+	ctx context.Context,
+	op operation,
+	user string,
+	rng *rand.Rand,
+) (_ operation, took time.Duration, status int, err error) {
+	switch op {
+	case opClaimable:
+		took, status, _, err = g.do(ctx, http.MethodGet, "/api/users/"+user+"/claimable", nil, nil)
+	case opPayload:
+		var query url.Values
+		if g.vault != "" {
+			query = url.Values{"vault": {g.vault}}
+		}
+		took, status, _, err = g.do(ctx, http.MethodGet, "/api/users/"+user+"/claim-payload", query, nil)
+	case opVerify:
+		proofs := g.sampleProofs(rng)
+		if len(proofs) == 0 {
+			return g.send(ctx, opProof, user, rng)
+		}
+		body, marshalErr := json.Marshal(map[string]interface{}{"proofs": proofs})
+		if marshalErr != nil {
+			return op, 0, 0, marshalErr
+		}
+		took, status, _, err = g.do(ctx, http.MethodPost, "/api/proofs/verify", nil, body)
+	default:
+		var body []byte
+		took, status, body, err = g.do(ctx, http.MethodGet, "/api/users/"+user+"/merkle-proof", nil, nil)
+		if err == nil && status == http.StatusOK {
+			g.keepProof(body, rng)
+		}
+	}
+	return op, took, status, err
+}
+
+// do sends a request and reads the whole response, the latency is until its last byte. The body is returned
+// for a 200 only.
+func (g *generator) do(
+	ctx context.Context,
+	method, path string,
+	query url.Values,
+	body []byte,
+) (time.Duration, int, []byte, error) {
+	endpoint := g.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	start := time.Now()
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return time.Since(start), 0, nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+
+	if resp.StatusCode != http.StatusOK {
+		_, err = io.Copy(io.Discard, resp.Body)
+		return time.Since(start), resp.StatusCode, nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	return time.Since(start), resp.StatusCode, data, err
+}
+
+// keepProof caches the proof in a merkle-proof response for later verification requests
+func (g *generator) keepProof(body []byte, rng *rand.Rand) {
+	var proof merkle.UserMerkleProofResponse
+	if json.Unmarshal(body, &proof) != nil {
+		return
+	}
+	toVerify := merkle.ProofToVerify{
+		VaultAddress: proof.VaultAddress,
+		Recipient:    proof.UserAddress,
+		TotalEarned:  proof.TotalEarned,
+		MerkleProof:  proof.MerkleProof,
+	}
+
+	g.proofsMu.Lock()
+	defer g.proofsMu.Unlock()
+	if len(g.proofs) < maxCachedProofs {
+		g.proofs = append(g.proofs, toVerify)
+		return
+	}
+	g.proofs[rng.IntN(len(g.proofs))] = toVerify
+}
+
+// sampleProofs returns up to verifyBatch cached proofs picked at random
+func (g *generator) sampleProofs(rng *rand.Rand) []merkle.ProofToVerify {
+	g.proofsMu.Lock()
+	defer g.proofsMu.Unlock()
+	if len(g.proofs) == 0 {
+		return nil
+	}
+	batch := make([]merkle.ProofToVerify, min(g.verifyBatch, len(g.proofs)))
+	for i := range batch {
+		batch[i] = g.proofs[rng.IntN(len(g.proofs))]
+	}
+	return batch
+}
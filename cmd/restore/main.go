@@ -0,0 +1,154 @@
+// restore rebuilds the epoch server's database from a backup taken with BACKUP_TARGET.
+//
+// It reads the server's configuration from the same environment, restores the newest backup (or the one
+// given with --backup) into the configured database, which must not hold any state yet, and recomputes
+// every vault's latest merkle root from the restored leaves. It exits with status 3 when a root does not
+// match, the restored database is kept for inspection.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/andrey/epoch-server/internal/services/backup/backupimpl"
+	storageService "github.com/andrey/epoch-server/internal/services/storage"
+	"github.com/jessevdk/go-flags"
+)
+
+// options of the restore, the server's configuration comes from the environment
+type options struct {
+	Backup  string        `short:"b" long:"backup" description:"Key of the backup to restore (default: the newest)"`
+	List    bool          `short:"l" long:"list" description:"List the stored backups, newest first, and exit"`
+	Tenant  string        `short:"t" long:"tenant" description:"Tenant whose database is restored, required when TENANTS lists several"`
+	Timeout time.Duration `long:"timeout" default:"1h" description:"Time the restore may take"`
+}
+
+func main() {
+	var opts options
+	if _, err := flags.NewParser(&opts, flags.HelpFlag|flags.PassDoubleDash).Parse(); err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+			fmt.Fprintln(os.Stdout, flagsErr.Message)
+			os.Exit(0)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	if err := run(opts, os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		if errors.Is(err, backup.ErrRootMismatch) {
+			os.Exit(3)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(opts options, out io.Writer) error {
+	cfg, err := tenantConfig(opts.Tenant)
+	if err != nil {
+		return err
+	}
+	if cfg.Backup.Target == backup.TargetNone {
+		return fmt.Errorf("BACKUP_TARGET is none, there is nothing to restore from")
+	}
+	logger, err := logging.NewWithConfig(logging.Config{Level: cfg.Logging.Level, Format: "text", Output: "stderr"})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opts.Timeout)
+	defer cancel()
+
+	if opts.List {
+		service, err := backupimpl.New(ctx, nil, metrics.NewRegistry(), logger, cfg)
+		if err != nil {
+			return err
+		}
+		backups, err := service.List(ctx)
+		if err != nil {
+			return err
+		}
+		return printBackups(out, backups)
+	}
+
+	storageClient, err := storageService.ProvideClient(storage.Config{
+		Type:      cfg.Database.Type,
+		Path:      cfg.Database.ConnectionString,
+		LowMemory: cfg.Database.LowMemory,
+	}, logger)
+	if err != nil {
+		return err
+	}
+	service, err := backupimpl.New(ctx, storageClient.GetDB(), metrics.NewRegistry(), logger, cfg)
+	if err != nil {
+		return errors.Join(err, storageClient.Close())
+	}
+
+	// the database is closed even when a root does not match, so a sqlite file holds what was restored
+	restored, err := service.Restore(ctx, opts.Backup)
+	err = errors.Join(err, storageClient.Close())
+	if restored != nil {
+		fmt.Fprintf(out, "restored %s (%s) into %s\n", restored.Backup.Location,
+			restored.Backup.CreatedAt.Format(time.RFC3339), cfg.Database.ConnectionString)
+		if printErr := printRoots(out, restored.Roots); printErr != nil {
+			err = errors.Join(err, printErr)
+		}
+	}
+	return err
+}
+
+// tenantConfig returns the configuration of the tenant, the only one outside multi-tenant mode
+func tenantConfig(tenant string) (*config.Config, error) {
+	configs, err := config.LoadTenants(nil)
+	if err != nil {
+		return nil, err
+	}
+	if tenant == "" {
+		if len(configs) > 1 {
+			return nil, fmt.Errorf("%d tenants are configured, select one with --tenant", len(configs))
+		}
+		return configs[0], nil
+	}
+	for _, cfg := range configs {
+		if cfg.Tenant == tenant {
+			return cfg, nil
+		}
+	}
+	return nil, fmt.Errorf("tenant %s is not configured", tenant)
+}
+
+func printBackups(out io.Writer, backups []backup.Backup) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tCREATED\tSIZE\tLOCATION")
+	for _, b := range backups {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", b.Key, b.CreatedAt.Format(time.RFC3339), b.Size, b.Location)
+	}
+	return w.Flush()
+}
+
+func printRoots(out io.Writer, roots []backup.RootCheck) error {
+	if len(roots) == 0 {
+		_, err := fmt.Fprintln(out, "no merkle snapshots to verify")
+		return err
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VAULT\tEPOCH\tLEAVES\tROOT\tVERIFIED")
+	for _, root := range roots {
+		verified := "yes"
+		if !root.Match {
+			verified = "no, leaves hash to " + root.ComputedRoot
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", root.VaultID, root.EpochNumber, root.Leaves, root.StoredRoot, verified)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/andrey/epoch-server/internal/services/backup/backupimpl"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	storageService "github.com/andrey/epoch-server/internal/services/storage"
+)
+
+const testVault = "0x1111111111111111111111111111111111111111"
+
+func setServerEnv(t *testing.T, backupDir, databaseDir string) {
+	t.Helper()
+	for key, value := range map[string]string{
+		"RPC_URL":                       "https://rpc.example",
+		"PRIVATE_KEY":                   "0x01",
+		"SUBGRAPH_ENDPOINT":             "https://subgraph.example",
+		"COMPTROLLER_ADDRESS":           "0x1111111111111111111111111111111111111111",
+		"EPOCH_MANAGER_ADDRESS":         "0x2222222222222222222222222222222222222222",
+		"DEBT_SUBSIDIZER_PROXY_ADDRESS": "0x3333333333333333333333333333333333333333",
+		"LENDING_MANAGER_ADDRESS":       "0x4444444444444444444444444444444444444444",
+		"COLLECTION_REGISTRY_ADDRESS":   "0x5555555555555555555555555555555555555555",
+		"VAULT_ADDRESS":                 "0x6666666666666666666666666666666666666666",
+		"DATABASE_TYPE":                 "badger",
+		"DATABASE_CONNECTION_STRING":    databaseDir,
+		"BACKUP_TARGET":                 backup.TargetLocal,
+		"BACKUP_DIR":                    backupDir,
+		"LOG_LEVEL":                     "error",
+		"TENANTS":                       "",
+		"NETWORK":                       "",
+	} {
+		t.Setenv(key, value)
+	}
+}
+
+// backUpTestDatabase backs up a database holding a snapshot of the test vault to the configured target
+func backUpTestDatabase(t *testing.T) {
+	t.Helper()
+	ctx := context.Background()
+	cfg, err := config.Load()
+	require.NoError(t, err)
+
+	client, err := storageService.ProvideClient(storage.Config{Type: "badger", Path: t.TempDir()}, lgr.NoOp)
+	require.NoError(t, err)
+	defer client.Close()
+
+	merkleService := merkleimpl.New(client.GetDB(), nil, nil, lgr.NoOp)
+	entries := []merkle.Entry{{Address: "0x3575b992c5337226aecf4e7f93dfbe80c576ce15", TotalEarned: big.NewInt(1000)}}
+	root := merkleService.BuildMerkleRootFromEntries(entries)
+	snapshot := merkle.MerkleSnapshot{
+		VaultID:    testVault,
+		MerkleRoot: fmt.Sprintf("%x", root),
+		Entries:    []merkle.MerkleEntry{merkle.MerkleEntry(entries[0])},
+	}
+	require.NoError(t, merkleService.SaveSnapshot(ctx, big.NewInt(7), snapshot))
+
+	service, err := backupimpl.New(ctx, client.GetDB(), metrics.NewRegistry(), lgr.NoOp, cfg)
+	require.NoError(t, err)
+	_, err = service.Backup(ctx)
+	require.NoError(t, err)
+}
+
+func TestRun(t *testing.T) {
+	backupDir := t.TempDir()
+	databaseDir := filepath.Join(t.TempDir(), "restored")
+	setServerEnv(t, backupDir, databaseDir)
+	backUpTestDatabase(t)
+
+	var out bytes.Buffer
+	require.NoError(t, run(options{List: true, Timeout: time.Minute}, &out))
+	assert.Contains(t, out.String(), "epoch-server-")
+	assert.Contains(t, out.String(), backupDir)
+
+	out.Reset()
+	require.NoError(t, run(options{Timeout: time.Minute}, &out))
+	assert.Contains(t, out.String(), "into "+databaseDir)
+	assert.Regexp(t, testVault+`\s+7\s+1\s+[0-9a-f]{64}\s+yes`, out.String())
+
+	// the restored database serves the snapshot
+	client, err := storageService.ProvideClient(storage.Config{Type: "badger", Path: databaseDir}, lgr.NoOp)
+	require.NoError(t, err)
+	snapshot, err := merkleimpl.NewStore(client.GetDB(), lgr.NoOp).GetLatestSnapshot(context.Background(), testVault)
+	require.NoError(t, err)
+	assert.Equal(t, "7", snapshot.EpochNumber.String())
+	require.NoError(t, client.Close())
+
+	// restoring again would merge two states
+	err = run(options{Timeout: time.Minute}, &out)
+	assert.ErrorIs(t, err, backup.ErrNotEmpty)
+}
+
+func TestRun_RequiresTarget(t *testing.T) {
+	setServerEnv(t, t.TempDir(), t.TempDir())
+	t.Setenv("BACKUP_TARGET", backup.TargetNone)
+	err := run(options{Timeout: time.Minute}, os.Stdout)
+	assert.ErrorContains(t, err, "nothing to restore from")
+}
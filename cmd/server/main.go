@@ -12,50 +12,280 @@
 // @schemes http https
 // @accept json
 // @produce json
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
 package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
 
 	"github.com/andrey/epoch-server/internal/api"
+	"github.com/andrey/epoch-server/internal/api/grpcapi"
 	"github.com/andrey/epoch-server/internal/infra/blockchain"
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/faults"
 	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
 	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/analytics/analyticsimpl"
+	"github.com/andrey/epoch-server/internal/services/archive"
+	"github.com/andrey/epoch-server/internal/services/archive/archiveimpl"
+	"github.com/andrey/epoch-server/internal/services/assets/assetsimpl"
+	"github.com/andrey/epoch-server/internal/services/audit/auditimpl"
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/andrey/epoch-server/internal/services/backup/backupimpl"
 	blockchainService "github.com/andrey/epoch-server/internal/services/blockchain"
+	"github.com/andrey/epoch-server/internal/services/compatibility/compatibilityimpl"
+	"github.com/andrey/epoch-server/internal/services/contractstate/contractstateimpl"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/deadletter/deadletterimpl"
 	"github.com/andrey/epoch-server/internal/services/epoch/epochimpl"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/events/eventsimpl"
+	"github.com/andrey/epoch-server/internal/services/features/featuresimpl"
+	"github.com/andrey/epoch-server/internal/services/gas/gasimpl"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/andrey/epoch-server/internal/services/idempotency/idempotencyimpl"
+	"github.com/andrey/epoch-server/internal/services/ipfs/ipfsimpl"
+	"github.com/andrey/epoch-server/internal/services/jobs/jobsimpl"
+	"github.com/andrey/epoch-server/internal/services/leader"
+	"github.com/andrey/epoch-server/internal/services/leader/leaderimpl"
+	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/pause/pauseimpl"
+	"github.com/andrey/epoch-server/internal/services/preflight"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/andrey/epoch-server/internal/services/queue/queueimpl"
+	"github.com/andrey/epoch-server/internal/services/reconciliation/reconciliationimpl"
 	"github.com/andrey/epoch-server/internal/services/scheduler"
+	"github.com/andrey/epoch-server/internal/services/signer/signerimpl"
 	storageService "github.com/andrey/epoch-server/internal/services/storage"
 	subgraphService "github.com/andrey/epoch-server/internal/services/subgraph"
 	"github.com/andrey/epoch-server/internal/services/subsidy/subsidyimpl"
+	"github.com/andrey/epoch-server/internal/services/vaults/vaultsimpl"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/andrey/epoch-server/internal/services/webhook/webhookimpl"
 	"github.com/go-pkgz/lgr"
+	"github.com/jessevdk/go-flags"
 )
 
 func main() {
-	cfg, err := config.Load()
+	if len(os.Args) > 1 && os.Args[1] == validateConfigCommand {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+
+	tenants, err := config.LoadTenants(os.Args[1:])
 	if err != nil {
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) && flagsErr.Type == flags.ErrHelp {
+			os.Exit(0)
+		}
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	// logging, tracing and the server are not network scoped, every tenant has the same options for them
+	cfg := tenants[0]
 	logger := setupLogging(cfg)
 	ctx := context.Background()
 
-	subgraphClient := setupSubgraphClient(cfg, logger, ctx)
-	contractClient := setupBlockchainClient(cfg, logger)
-	storageClient := setupDatabase(cfg, logger)
+	shutdownTracing := setupTracing(cfg, logger, ctx)
 	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Logf("WARN failed to shutdown tracing: %v", err)
+		}
+	}()
+
+	if cfg.Tenant == "" {
+		server, backend, closeTenant := setupTenant(ctx, cfg, logger)
+		defer closeTenant()
+		serveGRPC(cfg, logger, map[string]grpcapi.Backend{"": backend})
+		if err := server.Start(); err != nil {
+			logger.Logf("ERROR server failed to start: %v", err)
+		}
+		return
+	}
+
+	// every tenant runs its own services and scheduler, one listener routes requests to them
+	routes := make(map[string]http.Handler, len(tenants))
+	backends := make(map[string]grpcapi.Backend, len(tenants))
+	for _, tenantCfg := range tenants {
+		server, backend, closeTenant := setupTenant(ctx, tenantCfg, logging.ForTenant(logger, tenantCfg.Tenant))
+		defer closeTenant()
+		routes[tenantCfg.Tenant] = server.SetupRoutes()
+		backends[tenantCfg.Tenant] = backend
+	}
+	logger.Logf("INFO serving %d tenants", len(tenants))
+	serveGRPC(cfg, logger, backends)
+	if err := api.Serve(api.NewTenantRouter(routes, logger), cfg, logger); err != nil {
+		logger.Logf("ERROR server failed to start: %v", err)
+	}
+}
+
+// setupTenant connects to the deployment cfg configures and starts its scheduler. It returns the server of its
+// routes, the services it serves over gRPC and a func closing what it opened.
+func setupTenant(ctx context.Context, cfg *config.Config, logger lgr.L) (*api.Server, grpcapi.Backend, func()) {
+	if cfg.Network != "" {
+		logger.Logf("INFO using network profile %s (chain ID %d)", cfg.Network, cfg.Ethereum.ChainID)
+	}
+	if cfg.Approval.Enabled {
+		logger.Logf("INFO distribution approval enabled (auto-approve max total %q, max accounts %d)",
+			cfg.Approval.AutoApproveMaxTotal, cfg.Approval.AutoApproveMaxAccounts)
+	}
+	if cfg.Server.ReadOnly {
+		logger.Logf("INFO read-only mode, scheduler disabled and write endpoints rejected")
+	}
+	if cfg.Signer.MinBalance != "" {
+		logger.Logf("INFO scheduled transactions pause while the signer balance is below %s wei", cfg.Signer.MinBalance)
+	}
+
+	subgraphTransport, rpcTransport := setupFaults(cfg, logger)
+	subgraphClient := setupSubgraphClient(cfg, logger, ctx, subgraphTransport)
+	storageClient := setupDatabase(cfg, logger)
+
+	// webhook deliveries and transactions that failed for good are kept for operators to retry or discard
+	// through /admin/dead-letters, retrying and discarding them is audited
+	auditService := auditimpl.New(storageClient.GetDB(), logger)
+	deadLetterService := deadletterimpl.New(storageClient.GetDB(), auditService, logger)
+
+	// closed before the database so pending deliveries are dead-lettered before it closes
+	notifier := setupWebhooks(cfg, logger, deadLetterService)
+	deadLetterService.SetRetrier(deadletter.KindWebhook, notifier)
+	closeTenant := func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), cfg.Webhooks.Timeout)
+		defer cancel()
+		if closeErr := notifier.Close(closeCtx); closeErr != nil {
+			logger.Logf("WARN failed to flush webhooks: %v", closeErr)
+		}
 		if closeErr := storageClient.Close(); closeErr != nil {
 			logger.Logf("WARN failed to close database: %v", closeErr)
 		}
-	}()
+	}
+
+	// audit log and gas reports record every transaction the blockchain client sends, and the
+	// tracker updates epoch state once a transaction sent without waiting settles, keeping failed ones as dead letters
+	gasService := gasimpl.New(storageClient.GetDB(), notifier, logger, cfg)
+	txTracker := epochimpl.NewTxTracker(storageClient.GetDB(), notifier, logger)
+	txTracker.SetDeadLetters(deadLetterService)
+	contractClient := setupBlockchainClient(cfg, logger, auditService, gasService, txTracker, rpcTransport)
+
+	// contracts, the signer and the subgraph schema are checked before anything runs, every problem at once
+	if err := preflight.Check(ctx, cfg, contractClient, subgraphClient, logger); err != nil {
+		log.Fatalf("Configuration checks failed: %v", err)
+	}
+
+	// vault assets are read once, so amounts are logged and served in whole tokens next to wei
+	assetService := assetsimpl.New(contractClient, logger)
 
-	epochService, subsidyService, merkleService := setupServices(cfg, logger, contractClient, subgraphClient, storageClient)
+	// signer balance is checked every scheduler tick and exposed on /metrics
+	registry := metrics.NewRegistry()
 
-	setupScheduler(cfg, logger, ctx, epochService, subsidyService)
-	startServer(cfg, logger, epochService, subsidyService, merkleService)
+	// deployed contracts are compared with the compiled bindings, bindings older than the deployment are logged loudly
+	compatibilityService := compatibilityimpl.New(contractClient, registry, logger, cfg)
+	if cfg.Ethereum.Type == blockchain.TypeSimulated {
+		logger.Logf("INFO the simulated chain deploys no contract code, contract compatibility is not checked")
+	} else if _, err := compatibilityService.Check(ctx); err != nil {
+		logger.Logf("WARN failed to check contract compatibility: %v", err)
+	}
+
+	// epoch and distribution events reach webhooks, metrics, the audit log and the subgraph cache through the bus
+	bus := setupEvents(logger, notifier, subgraphClient, registry, auditService)
+
+	// risky distribution behaviors start as the environment's features file sets them, operators switch them off
+	// through /admin/features without a redeploy
+	featuresService, err := featuresimpl.New(storageClient.GetDB(), auditService, logger, cfg)
+	if err != nil {
+		log.Fatalf("Failed to setup feature flags: %v", err)
+	}
+
+	epochService, subsidyService, merkleService := setupServices(
+		cfg, logger, contractClient, subgraphClient, storageClient, notifier, bus, auditService, assetService, featuresService,
+	)
+	// a root update is only resent while its root is still the vault's latest
+	deadLetterService.SetRetrier(deadletter.KindTransaction, deadletterimpl.NewTransactionRetrier(contractClient, merkleService, logger))
+
+	// the database is copied to the backup target on a schedule, cmd/restore rebuilds a lost one from a copy
+	if cfg.Backup.Target != backup.TargetNone {
+		backupService, err := backupimpl.New(ctx, storageClient.GetDB(), registry, logger, cfg)
+		if err != nil {
+			log.Fatalf("Failed to setup backups: %v", err)
+		}
+		go backupService.Run(ctx)
+	}
+	signerService := signerimpl.New(contractClient, notifier, registry, logger, cfg)
+
+	// the DebtSubsidizer pause state is checked every scheduler tick, distributions are skipped while it is paused
+	contractState := contractstateimpl.New(contractClient, notifier, registry, logger, cfg)
+
+	// operators pause scheduled jobs through /admin, the pauses are stored so they survive restarts
+	pauseService := pauseimpl.New(storageClient.GetDB(), auditService, logger)
+
+	// the scheduler records every job run, served on /api/scheduler/jobs by every replica
+	jobService := jobsimpl.New(storageClient.GetDB(), logger)
+
+	// every boundary checks the distributed subsidies against the yield the vault allocated, reports are kept for /api/reports
+	reconciliationService := reconciliationimpl.New(contractClient, merkleService, storageClient.GetDB(), notifier, logger, cfg)
+	reconciliationService.SetAssets(assetService)
+
+	// per-epoch yield, subsidy, APY and claim series built from the reconciliation reports, for /api/analytics,
+	// and the epoch reports built from every record stored for an epoch, for /api/epochs/{id}/report
+	analyticsService := analyticsimpl.New(reconciliationService, epochService, contractClient, subsidyService, merkleService,
+		gasService, logger)
+
+	// operators onboard and decommission vaults through /admin/vaults, the registry keeps how far each got
+	vaultsService := vaultsimpl.New(contractClient, storageClient.GetDB(), auditService, logger, cfg)
+	// with discovery the registry also follows the DebtSubsidizer's VaultAdded and VaultRemoved events
+	if cfg.Discovery.Enabled {
+		go vaultsService.Run(ctx)
+	}
+
+	// distributions, replays and proof verifications requested with async=true run on the queue's workers
+	queueService := setupQueue(cfg, logger, ctx, storageClient, subsidyService, merkleService)
+
+	trigger := setupScheduler(
+		cfg, logger, ctx, epochService, subsidyService, signerService, contractState, pauseService, jobService, reconciliationService,
+		vaultsService, storageClient, contractClient, subgraphClient, notifier, registry,
+	)
+	// responses to write requests with an Idempotency-Key are stored, so every replica answers a retry from the first request
+	var idempotencyService idempotency.Service
+	if cfg.Idempotency.TTL > 0 {
+		idempotencyService = idempotencyimpl.New(storageClient.GetDB(), logger, cfg)
+	}
+
+	server := api.NewServer(
+		epochService, subsidyService, merkleService, auditService, signerService, contractState, gasService, pauseService, jobService,
+		reconciliationService, analyticsService, vaultsService, queueService, assetService, deadLetterService, compatibilityService,
+		featuresService, idempotencyService, trigger, registry, logger, cfg,
+	)
+	backend := grpcapi.Backend{
+		Epoch:     epochService,
+		Subsidy:   subsidyService,
+		Merkle:    merkleService,
+		Snapshots: merkleService,
+		Config:    cfg,
+	}
+	return server, backend, closeTenant
+}
+
+// serveGRPC serves the tenants' epoch, subsidy and merkle services over gRPC in the background, when a gRPC
+// port is configured
+func serveGRPC(cfg *config.Config, logger lgr.L, backends map[string]grpcapi.Backend) {
+	if cfg.Server.GRPCPort == 0 {
+		return
+	}
+	server := grpcapi.NewServer(backends, logger)
+	go func() {
+		if err := grpcapi.Serve(server, cfg, logger); err != nil {
+			logger.Logf("ERROR gRPC server failed: %v", err)
+		}
+	}()
 }
 
 func setupLogging(cfg *config.Config) lgr.L {
@@ -70,8 +300,74 @@ func setupLogging(cfg *config.Config) lgr.L {
 	return logger
 }
 
-func setupSubgraphClient(cfg *config.Config, logger lgr.L, ctx context.Context) subgraph.SubgraphClient {
-	subgraphClient := subgraphService.ProvideClient(cfg.Subgraph.Endpoint, logger)
+func setupTracing(cfg *config.Config, logger lgr.L, ctx context.Context) func(context.Context) error {
+	shutdown, err := tracing.Setup(ctx, tracing.Config{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+		Insecure:    cfg.Tracing.Insecure,
+	}, logger)
+	if err != nil {
+		log.Fatalf("Failed to setup tracing: %v", err)
+	}
+	return shutdown
+}
+
+// setupFaults returns the transports injecting the configured faults into subgraph and RPC requests, nil for
+// a dependency without faults
+func setupFaults(cfg *config.Config, logger lgr.L) (subgraphTransport, rpcTransport http.RoundTripper) {
+	if !cfg.Faults.Enabled {
+		return nil, nil
+	}
+	logger.Logf("WARN fault injection enabled, requests to dependencies fail on purpose")
+
+	subgraphRule := faults.Rule{
+		Latency:     cfg.Faults.SubgraphLatency,
+		ErrorRate:   cfg.Faults.SubgraphErrorRate,
+		PartialRate: cfg.Faults.SubgraphPartialRate,
+		Match:       cfg.Faults.SubgraphMatch,
+	}
+	if subgraphRule.Active() {
+		transport, err := faults.NewTransport("subgraph", nil, subgraphRule, faults.TruncateLists, cfg.Faults.Seed, logger)
+		if err != nil {
+			log.Fatalf("Failed to setup fault injection: %v", err)
+		}
+		subgraphTransport = transport
+	}
+
+	rpcRule := faults.Rule{
+		Latency:     cfg.Faults.RPCLatency,
+		ErrorRate:   cfg.Faults.RPCErrorRate,
+		PartialRate: cfg.Faults.RPCPartialRate,
+		Match:       cfg.Faults.RPCMatch,
+	}
+	if rpcRule.Active() {
+		if cfg.Ethereum.Type == blockchain.TypeSimulated {
+			logger.Logf("WARN the simulated chain is not reached over RPC, RPC faults are not injected")
+			return subgraphTransport, nil
+		}
+		transport, err := faults.NewTransport("rpc", nil, rpcRule, faults.TruncateBody, cfg.Faults.Seed, logger)
+		if err != nil {
+			log.Fatalf("Failed to setup fault injection: %v", err)
+		}
+		rpcTransport = transport
+	}
+	return subgraphTransport, rpcTransport
+}
+
+func setupSubgraphClient(cfg *config.Config, logger lgr.L, ctx context.Context, transport http.RoundTripper) subgraph.SubgraphClient {
+	subgraphClient := subgraphService.ProvideClientWithConfig(subgraph.Config{
+		Endpoint:         cfg.Subgraph.Endpoint,
+		Timeout:          cfg.Subgraph.Timeout,
+		PageSize:         cfg.Subgraph.PaginationSize,
+		MinSchemaVersion: cfg.Subgraph.MinSchemaVersion,
+		CacheTTL:         cfg.Subgraph.CacheTTL,
+		CacheBlockTTL:    cfg.Subgraph.CacheBlockTTL,
+		CacheStaleTTL:    cfg.Subgraph.CacheStaleTTL,
+		CacheMaxEntries:  cfg.Subgraph.CacheMaxEntries,
+		Transport:        transport,
+	}, logger)
 
 	if err := subgraphClient.HealthCheck(ctx); err != nil {
 		log.Fatalf("Failed to connect to subgraph: %v", err)
@@ -81,18 +377,44 @@ func setupSubgraphClient(cfg *config.Config, logger lgr.L, ctx context.Context)
 	return subgraphClient
 }
 
-func setupBlockchainClient(cfg *config.Config, logger lgr.L) blockchain.BlockchainClient {
+func setupBlockchainClient(
+	cfg *config.Config,
+	logger lgr.L,
+	auditService *auditimpl.Service,
+	gasService *gasimpl.Service,
+	txTracker *epochimpl.TxTracker,
+	transport http.RoundTripper,
+) blockchain.BlockchainClient {
+	// validated when loaded
+	confirmations, _ := config.ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth)
 	contractClient, err := blockchainService.ProvideClientWithConfig(logger, blockchain.Config{
+		Type:               cfg.Ethereum.Type,
 		RPCURL:             cfg.Ethereum.RPCURL,
 		PrivateKey:         cfg.Ethereum.PrivateKey,
 		GasLimit:           cfg.Ethereum.GasLimit,
 		GasPrice:           cfg.Ethereum.GasPrice,
+		ChainID:            cfg.Ethereum.ChainID,
+		ReadOnly:           cfg.Server.ReadOnly,
+		ForkURL:            cfg.Ethereum.ForkURL,
 		Comptroller:        cfg.Contracts.Comptroller,
 		EpochManager:       cfg.Contracts.EpochManager,
 		DebtSubsidizer:     cfg.Contracts.DebtSubsidizer,
 		LendingManager:     cfg.Contracts.LendingManager,
 		CollectionRegistry: cfg.Contracts.CollectionRegistry,
-	})
+		Multicall:          cfg.Ethereum.Multicall,
+		MulticallBatchSize: cfg.Ethereum.MulticallBatch,
+		ReadConcurrency:    cfg.Ethereum.ReadConcurrency,
+
+		ReceiptConfirmations: confirmations.Status,
+		RootConfirmations:    confirmations.MerkleRoot,
+		ReceiptTimeout:       cfg.Ethereum.ReceiptTimeout,
+		PollInterval:         cfg.Ethereum.BlockPollInterval,
+
+		SimulatedContracts: simulatedContracts(cfg),
+		SimulatedBlockTime: cfg.Ethereum.SimulatedBlockTime,
+
+		Transport: transport,
+	}, auditService, gasService, txTracker)
 	if err != nil {
 		log.Fatalf("Failed to initialize contract client: %v", err)
 	}
@@ -100,10 +422,20 @@ func setupBlockchainClient(cfg *config.Config, logger lgr.L) blockchain.Blockcha
 	return contractClient
 }
 
+// simulatedContracts lists every configured contract address, all given code on a simulated chain
+func simulatedContracts(cfg *config.Config) []string {
+	var addresses []string
+	for _, contract := range config.ContractAddresses(cfg) {
+		addresses = append(addresses, contract.Address)
+	}
+	return addresses
+}
+
 func setupDatabase(cfg *config.Config, logger lgr.L) storage.StorageClient {
 	storageClient, err := storageService.ProvideClient(storage.Config{
-		Type: cfg.Database.Type,
-		Path: cfg.Database.ConnectionString,
+		Type:      cfg.Database.Type,
+		Path:      cfg.Database.ConnectionString,
+		LowMemory: cfg.Database.LowMemory,
 	}, logger)
 	if err != nil {
 		log.Fatalf("Failed to initialize database: %v", err)
@@ -111,46 +443,173 @@ func setupDatabase(cfg *config.Config, logger lgr.L) storage.StorageClient {
 	return storageClient
 }
 
+func setupWebhooks(cfg *config.Config, logger lgr.L, deadLetters deadletter.Recorder) *webhookimpl.Dispatcher {
+	endpoints := make([]webhook.Endpoint, 0, len(cfg.Webhooks.URLs))
+	for i, url := range cfg.Webhooks.URLs {
+		endpoint := webhook.Endpoint{URL: url}
+		switch len(cfg.Webhooks.Secrets) {
+		case 0:
+		case 1:
+			endpoint.Secret = cfg.Webhooks.Secrets[0]
+		default:
+			endpoint.Secret = cfg.Webhooks.Secrets[i]
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if len(endpoints) > 0 {
+		logger.Logf("INFO webhooks enabled for %d endpoints", len(endpoints))
+	}
+
+	return webhookimpl.New(webhook.Config{
+		Endpoints:    endpoints,
+		Timeout:      cfg.Webhooks.Timeout,
+		MaxRetries:   cfg.Webhooks.MaxRetries,
+		RetryBackoff: cfg.Webhooks.RetryBackoff,
+	}, deadLetters, logger)
+}
+
 func setupServices(
 	cfg *config.Config,
 	logger lgr.L,
 	contractClient blockchain.BlockchainClient,
 	subgraphClient subgraph.SubgraphClient,
 	storageClient storage.StorageClient,
+	notifier webhook.Notifier,
+	publisher events.Publisher,
+	auditService *auditimpl.Service,
+	assetService *assetsimpl.Service,
+	featuresService *featuresimpl.Service,
 ) (*epochimpl.Service, *subsidyimpl.Service, *merkleimpl.Service) {
 	// merkle service handles proof generation and verification
-	merkleService := merkleimpl.New(storageClient.GetDB(), subgraphClient, logger)
-	epochService := epochimpl.New(contractClient, subgraphClient, merkleService, logger, cfg)
-	
+	merkleService := merkleimpl.New(storageClient.GetDB(), subgraphClient, contractClient, logger)
+	vaultEncodings, _ := config.ParseVaultLeafEncodings(cfg.Merkle.VaultLeafEncodings) // validated when loaded
+	leafEncodings := make(map[string]merkle.LeafEncoding, len(vaultEncodings))
+	for vault, encoding := range vaultEncodings {
+		leafEncodings[vault] = merkle.LeafEncoding(encoding)
+	}
+	merkleService.SetLeafEncodings(merkle.LeafEncoding(cfg.Merkle.LeafEncoding), leafEncodings)
+	merkleService.SetRootHistory(cfg.Merkle.RootsStartBlock, cfg.Ethereum.ConfirmationDepth)
+	merkleService.SetClaimDomain(cfg.Ethereum.ChainID, cfg.Contracts.DebtSubsidizer)
+	epochService := epochimpl.New(storageClient.GetDB(), contractClient, subgraphClient, merkleService, publisher, logger, cfg)
+	epochService.SetSnapshots(merkleService)
+	epochService.SetAuditLog(auditService)
+	epochService.SetAssets(assetService)
+
 	// lazy distributor pattern for efficient subsidy distribution
-	lazyDistributor := subsidyimpl.NewLazyDistributor(contractClient, merkleService, subgraphClient, logger)
-	subsidyService := subsidyimpl.New(lazyDistributor, epochService, logger, cfg)
+	lazyDistributor := subsidyimpl.NewLazyDistributor(contractClient, merkleService, subgraphClient, notifier, publisher, auditService, storageClient.GetDB(), logger, cfg)
+	lazyDistributor.SetAssets(assetService)
+	lazyDistributor.SetFeatures(featuresService)
+	// epoch distributions are also written as Parquet files for offline analysis when an archive target is set
+	if cfg.Archive.Target != archive.TargetNone {
+		archiveService, err := archiveimpl.New(context.Background(), logger, cfg)
+		if err != nil {
+			log.Fatalf("Failed to setup archive: %v", err)
+		}
+		lazyDistributor.SetArchive(archiveService)
+	}
+	// each epoch's leaves and root are published to IPFS, and the CID saved with its snapshot, when an API is set
+	if cfg.IPFS.APIURL != "" {
+		lazyDistributor.SetIPFS(ipfsimpl.New(logger, cfg))
+	}
+	repaymentPlanner := subsidyimpl.NewRepaymentPlanner(contractClient, storageClient.GetDB(), logger, cfg)
+	subsidyService := subsidyimpl.New(lazyDistributor, repaymentPlanner, epochService, notifier, logger, cfg)
 
 	return epochService, subsidyService, merkleService
 }
 
-func setupScheduler(
+// setupEvents subscribes the subsystems reacting to epoch and distribution events to a new bus
+func setupEvents(
+	logger lgr.L,
+	notifier webhook.Notifier,
+	subgraphClient subgraph.SubgraphClient,
+	registry *metrics.Registry,
+	auditService *auditimpl.Service,
+) *eventsimpl.Bus {
+	bus := eventsimpl.New(logger)
+	bus.Subscribe("cache", eventsimpl.CacheHandler(subgraphClient), eventsimpl.CacheTypes...)
+	bus.Subscribe("webhook", eventsimpl.WebhookHandler(notifier), eventsimpl.WebhookTypes...)
+	bus.Subscribe("metrics", eventsimpl.MetricsHandler(registry))
+	bus.Subscribe("audit", eventsimpl.AuditHandler(auditService, logger), eventsimpl.AuditTypes...)
+	return bus
+}
+
+// setupQueue registers how every kind of queued job runs and starts the queue's workers
+func setupQueue(
 	cfg *config.Config,
 	logger lgr.L,
 	ctx context.Context,
-	epochService *epochimpl.Service,
+	storageClient storage.StorageClient,
 	subsidyService *subsidyimpl.Service,
-) {
-	// start scheduler in goroutine for automated epoch operations
-	schedulerInstance := scheduler.NewScheduler(epochService, subsidyService, cfg.Scheduler.Interval, logger, cfg)
-	go schedulerInstance.Start(ctx)
+	merkleService *merkleimpl.Service,
+) *queueimpl.Service {
+	queueService := queueimpl.New(storageClient.GetDB(), logger, cfg)
+	queueService.Register(queue.KindDistribute, queue.RunnerFor(func(ctx context.Context, payload queue.DistributePayload) (interface{}, error) {
+		return subsidyService.DistributeSubsidies(ctx, payload.VaultID)
+	}))
+	queueService.Register(queue.KindReplay, queue.RunnerFor(func(ctx context.Context, payload queue.ReplayPayload) (interface{}, error) {
+		return subsidyService.ReplayEpoch(ctx, payload.VaultID, payload.EpochNumber)
+	}))
+	queueService.Register(queue.KindVerifyProofs, queue.RunnerFor(func(ctx context.Context, payload queue.VerifyProofsPayload) (interface{}, error) {
+		return merkleService.VerifyProofs(ctx, payload.Proofs, payload.OnChain)
+	}))
+	go queueService.Start(ctx)
+	return queueService
 }
 
-func startServer(
+func setupScheduler(
 	cfg *config.Config,
 	logger lgr.L,
+	ctx context.Context,
 	epochService *epochimpl.Service,
 	subsidyService *subsidyimpl.Service,
-	merkleService *merkleimpl.Service,
-) {
-	server := api.NewServer(epochService, subsidyService, merkleService, logger, cfg)
+	signerService *signerimpl.Service,
+	contractState *contractstateimpl.Service,
+	pauseService *pauseimpl.Service,
+	jobService *jobsimpl.Service,
+	reconciliationService *reconciliationimpl.Service,
+	vaultsService *vaultsimpl.Service,
+	storageClient storage.StorageClient,
+	contractClient blockchain.BlockchainClient,
+	subgraphClient subgraph.SubgraphClient,
+	notifier webhook.Notifier,
+	registry *metrics.Registry,
+) scheduler.Trigger {
+	// read replicas never send transactions, so they run no scheduler at all
+	if cfg.Server.ReadOnly {
+		return nil
+	}
 
-	if err := server.Start(); err != nil {
-		logger.Logf("ERROR server failed to start: %v", err)
+	// with leader election the scheduler runs on every replica but only the lease holder acts
+	var elector leader.Elector
+	if cfg.Leader.Enabled {
+		// the lease must be shared by every replica, so it is never kept in the server's own database
+		store := leaderimpl.NewRedisStore(cfg.Leader.RedisAddr, cfg.Leader.RedisPassword, logger)
+		leaderService := leaderimpl.New(store, registry, logger, cfg)
+		go leaderService.Run(ctx)
+		elector = leaderService
+		logger.Logf("INFO leader election enabled with %s backend", cfg.Leader.Backend)
 	}
+
+	// start scheduler in goroutine for automated epoch operations
+	schedulerInstance := scheduler.NewScheduler(
+		epochService, subsidyService, signerService, contractState, elector, pauseService, jobService, reconciliationService,
+		cfg.Scheduler.Interval, logger, cfg,
+	)
+	schedulerInstance.SetNotifier(notifier)
+	// a decommissioned vault gets no more distributions, its history stays served
+	schedulerInstance.SetVaults(vaultsService)
+	// epochs are started and ended by the EpochManager's clock, a schedule running ahead of it waits for the epoch's end
+	schedulerInstance.SetEpochClock(epochService)
+	// jobs suspended after repeated failures resume once the chain and the subgraph answer again
+	schedulerInstance.SetHealthCheck(func(ctx context.Context) error {
+		if _, err := contractClient.GetCurrentEpochId(ctx); err != nil {
+			return fmt.Errorf("chain: %w", err)
+		}
+		if err := subgraphClient.HealthCheck(ctx); err != nil {
+			return fmt.Errorf("subgraph: %w", err)
+		}
+		return nil
+	})
+	go schedulerInstance.Start(ctx)
+	return schedulerInstance
 }
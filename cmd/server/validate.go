@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	blockchainService "github.com/andrey/epoch-server/internal/services/blockchain"
+	"github.com/andrey/epoch-server/internal/services/preflight"
+	subgraphService "github.com/andrey/epoch-server/internal/services/subgraph"
+	"github.com/go-pkgz/lgr"
+	"github.com/jessevdk/go-flags"
+)
+
+// validateConfigCommand checks the configuration without starting the server
+const validateConfigCommand = "validate-config"
+
+// validateConfig loads the configuration of every tenant from args and the environment, checks it against the
+// chain and subgraph it points at and prints every problem found. It returns the process exit code.
+func validateConfig(args []string) int {
+	tenants, err := config.LoadTenants(args)
+	if err != nil {
+		// the parser already printed its own errors
+		var flagsErr *flags.Error
+		if errors.As(err, &flagsErr) {
+			if flagsErr.Type == flags.ErrHelp {
+				return 0
+			}
+			return 1
+		}
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var problems []error
+	for _, cfg := range tenants {
+		for _, problem := range checkConfig(context.Background(), cfg) {
+			if cfg.Tenant != "" {
+				problem = fmt.Errorf("tenant %s: %w", cfg.Tenant, problem)
+			}
+			problems = append(problems, problem)
+		}
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintln(os.Stderr, &config.ValidationError{Problems: problems})
+		return 1
+	}
+	fmt.Println("configuration is valid")
+	return 0
+}
+
+// checkConfig returns every problem the pre-flight checks find with the chain and subgraph cfg points at
+func checkConfig(ctx context.Context, cfg *config.Config) []error {
+	logger := lgr.NoOp
+	subgraphClient := subgraphService.ProvideClientWithConfig(subgraph.Config{
+		Endpoint:         cfg.Subgraph.Endpoint,
+		Timeout:          cfg.Subgraph.Timeout,
+		PageSize:         cfg.Subgraph.PaginationSize,
+		MinSchemaVersion: cfg.Subgraph.MinSchemaVersion,
+	}, logger)
+
+	// nothing is sent, so transactions are neither audited nor watched
+	contractClient, err := blockchainService.ProvideClientWithConfig(logger, blockchain.Config{
+		Type:           cfg.Ethereum.Type,
+		RPCURL:         cfg.Ethereum.RPCURL,
+		PrivateKey:     cfg.Ethereum.PrivateKey,
+		ChainID:        cfg.Ethereum.ChainID,
+		ReadOnly:       cfg.Server.ReadOnly,
+		EpochManager:   cfg.Contracts.EpochManager,
+		DebtSubsidizer: cfg.Contracts.DebtSubsidizer,
+
+		SimulatedContracts: simulatedContracts(cfg),
+	}, nil, nil, nil)
+
+	var problems []error
+	if err != nil {
+		problems = append(problems, fmt.Errorf("failed to connect to the chain: %w", err))
+		contractClient = nil
+	}
+	var validationErr *config.ValidationError
+	if err := preflight.Check(ctx, cfg, contractClient, subgraphClient, logger); errors.As(err, &validationErr) {
+		problems = append(problems, validationErr.Problems...)
+	}
+	return problems
+}
@@ -30,88 +30,190 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/epochs/distribute": {
-            "post": {
-                "description": "Initiates the distribution of subsidies for the current epoch",
-                "consumes": [
-                    "application/json"
+        "/admin/adjustments": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Lists manual allocation adjustments with who proposed and decided them, filtered by vault, epoch and\nstatus when they are given. The number of adjustments is returned in X-Total-Count and the next page\nis linked in the Link header. Requires an admin API key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "epochs"
+                    "admin"
+                ],
+                "summary": "List allocation adjustments",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "epoch",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending_approval",
+                            "approved",
+                            "rejected"
+                        ],
+                        "type": "string",
+                        "description": "Adjustment status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of adjustments to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of adjustments to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "proposedAt"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default proposedAt)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Distribute subsidies",
                 "responses": {
-                    "202": {
-                        "description": "Subsidy distribution accepted",
+                    "200": {
+                        "description": "Allocation adjustments",
                         "schema": {
-                            "$ref": "#/definitions/handlers.DistributeSubsidiesResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationAdjustment"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching adjustments across all pages"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Invalid vault, epoch, status or paging parameters",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/epochs/force-end": {
+        "/admin/adjustments/{id}/approve": {
             "post": {
-                "description": "Forcibly ends an epoch with zero yield distribution",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Approves a pending adjustment so distributions of its epoch built from now on apply it. The adjustment\ncannot be approved with the API key it was proposed with. Requires an admin API key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "epochs"
+                    "admin"
                 ],
-                "summary": "Force end epoch",
+                "summary": "Approve allocation adjustment",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Epoch ID to force end",
-                        "name": "epochId",
-                        "in": "query",
+                        "type": "string",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "202": {
-                        "description": "Epoch force end accepted",
+                    "200": {
+                        "description": "Adjustment approved",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ForceEndEpochResponse"
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationAdjustment"
                         }
                     },
                     "400": {
-                        "description": "Bad request - missing or invalid epochId",
+                        "description": "Adjustment is not pending approval",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The approver proposed the adjustment",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Adjustment not found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/epochs/start": {
+        "/admin/adjustments/{id}/reject": {
             "post": {
-                "description": "Initiates the start of a new epoch for yield distribution",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Discards a pending adjustment. The adjustment cannot be rejected with the API key it was proposed\nwith. Requires an admin API key.",
                 "consumes": [
                     "application/json"
                 ],
@@ -119,90 +221,164 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "epochs"
+                    "admin"
+                ],
+                "summary": "Reject allocation adjustment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Adjustment ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.RejectDistributionRequest"
+                        }
+                    }
                 ],
-                "summary": "Start epoch",
                 "responses": {
-                    "202": {
-                        "description": "Epoch start accepted",
+                    "200": {
+                        "description": "Adjustment rejected",
                         "schema": {
-                            "$ref": "#/definitions/handlers.StartEpochResponse"
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationAdjustment"
                         }
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "Adjustment is not pending approval or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The rejecting admin proposed the adjustment",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Adjustment not found",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/users/{address}/merkle-proof": {
+        "/admin/blocklist": {
             "get": {
-                "description": "Generates a merkle proof for a user's current earnings",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Lists the sanctioned or compromised addresses left out of every distribution, with who blocked them\nand why. The number of addresses is returned in X-Total-Count and the next page is linked in the\nLink header. Requires an admin API key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get user merkle proof",
+                "summary": "List blocked addresses",
                 "parameters": [
                     {
+                        "type": "integer",
+                        "description": "Maximum number of addresses to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of addresses to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "address",
+                            "blockedAt"
+                        ],
                         "type": "string",
-                        "description": "User wallet address",
-                        "name": "address",
-                        "in": "path",
-                        "required": true
+                        "description": "Sort field (default address)",
+                        "name": "sort",
+                        "in": "query"
                     },
                     {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
                         "type": "string",
-                        "description": "Vault address (optional, uses default if not provided)",
-                        "name": "vault",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Merkle proof generated successfully",
+                        "description": "Blocked addresses",
                         "schema": {
-                            "$ref": "#/definitions/merkle.UserMerkleProofResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.BlockedAddress"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of blocked addresses across all pages"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid address",
+                        "description": "Invalid paging parameters",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "401": {
+                        "description": "Missing or invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/users/{address}/merkle-proof/epoch/{epochNumber}": {
-            "get": {
-                "description": "Generates a merkle proof for a user's earnings at a specific epoch",
+        "/admin/blocklist/{address}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Leaves the address out of the merkle trees of every distribution built from now on. What it would have\nreceived is burned or spread over the other allocations as BLOCKLIST_REMAINDER configures. Blocking\nagain replaces the reason. Requires an admin API key.",
                 "consumes": [
                     "application/json"
                 ],
@@ -210,211 +386,7011 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get historical merkle proof",
+                "summary": "Block address",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "User wallet address",
+                        "description": "Address to block",
                         "name": "address",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Epoch number",
-                        "name": "epochNumber",
-                        "in": "path",
-                        "required": true
+                        "description": "Reason for blocking",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.BlockAddressRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Address blocked",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.BlockedAddress"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
                     },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes the address from the blocklist so later distributions include it again. Distributions\nalready built keep it out. Requires an admin API key.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Unblock address",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "Vault address (optional, uses default if not provided)",
-                        "name": "vault",
-                        "in": "query"
+                        "description": "Address to unblock",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Historical merkle proof generated successfully",
+                    "204": {
+                        "description": "Address unblocked"
+                    },
+                    "400": {
+                        "description": "Invalid address",
                         "schema": {
-                            "$ref": "#/definitions/merkle.UserMerkleProofResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
-                    "400": {
-                        "description": "Bad request - invalid address or epoch",
+                    "401": {
+                        "description": "Missing or invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "404": {
-                        "description": "User or epoch not found",
+                        "description": "The address is not blocked",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/api/users/{address}/total-earned": {
+        "/admin/dead-letters": {
             "get": {
-                "description": "Retrieves the total amount earned by a user across all epochs",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Lists the webhook deliveries that exhausted their retries and the transactions sent without waiting\nthat reverted or never confirmed, oldest failure first. The number of matching entries is returned\nin X-Total-Count and the next page is linked in the Link header. Requires an admin API key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "admin"
                 ],
-                "summary": "Get user total earned",
+                "summary": "List dead letters",
                 "parameters": [
                     {
+                        "enum": [
+                            "webhook",
+                            "transaction"
+                        ],
                         "type": "string",
-                        "description": "User wallet address",
-                        "name": "address",
-                        "in": "path",
-                        "required": true
+                        "description": "Filter by kind",
+                        "name": "kind",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of entries to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "User earnings information",
+                        "description": "Dead letters",
                         "schema": {
-                            "$ref": "#/definitions/epoch.UserEarningsResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_deadletter.Entry"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching entries across all pages"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid address",
+                        "description": "Unknown kind or invalid paging parameters",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
-                    "404": {
-                        "description": "User not found",
+                    "401": {
+                        "description": "Missing or invalid API key",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     },
                     "500": {
                         "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.ErrorResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
             }
         },
-        "/health": {
+        "/admin/dead-letters/{id}": {
             "get": {
-                "description": "Returns the current health status of the epoch server",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns a dead letter with its payload: the webhook event as it was sent, or the transaction with\nits parameters and revert reason. Requires an admin API key.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "health"
+                    "admin"
+                ],
+                "summary": "Get dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Health check",
                 "responses": {
                     "200": {
-                        "description": "Service is healthy",
+                        "description": "Dead letter",
                         "schema": {
-                            "$ref": "#/definitions/handlers.HealthResponse"
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_deadletter.Entry"
                         }
                     },
-                    "503": {
-                        "description": "Service is unhealthy",
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such dead letter",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
                         "schema": {
-                            "$ref": "#/definitions/handlers.HealthResponse"
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
-        "epoch.UserEarningsResponse": {
-            "type": "object",
-            "properties": {
-                "calculatedAt": {
-                    "type": "integer"
-                },
-                "dataTimestamp": {
-                    "description": "Timestamp used for calculations",
-                    "type": "integer"
-                },
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes the dead letter without retrying it. Requires an admin API key.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Discard dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Dead letter discarded"
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such dead letter",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/dead-letters/{id}/retry": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delivers the webhook event to its endpoint again, or sends the transaction again, and removes the\nentry once that succeeded. A root update is only sent while its root is the vault's latest and not\non-chain yet, one already on-chain is removed without sending anything. A failed retry keeps the\nentry with the attempt counted. Requires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Retry dead letter",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Dead letter ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Retried, the entry is removed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_deadletter.RetryResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No such dead letter",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The entry cannot be retried, such as a superseded root or a removed endpoint",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The retry failed, the entry is kept",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/features": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every feature flag, whether it is on, and whether that comes from the default, the environment's\nfeatures file or an operator's override. Requires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List feature flags",
+                "responses": {
+                    "200": {
+                        "description": "State of every flag",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_features.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/features/{flag}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Switches the behavior the flag gates on or off from the next distribution, on every replica, without\na redeploy. The override is stored and survives restarts; setting it again replaces it. Requires an\nadmin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Override feature flag",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feature flag",
+                        "name": "flag",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Whether the flag is on, and why",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.SetFeatureRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Flag overridden",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_features.FlagState"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown flag or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Returns the flag to what the environment's features file sets, on when it sets nothing. Requires an\nadmin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Clear feature flag override",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Feature flag",
+                        "name": "flag",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Flag returned to its configured state",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_features.FlagState"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown flag",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists every scheduler job and whether an operator paused it. Requires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Get scheduler pause state",
+                "responses": {
+                    "200": {
+                        "description": "Pause state of every job",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_pause.Status"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Stops a scheduler job, or every job, from running on later cycles until it is resumed, e.g. during\na contract upgrade. The pause is stored and survives restarts. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Pause scheduler jobs",
+                "parameters": [
+                    {
+                        "description": "Job to pause (default all) and reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.SchedulerJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job paused",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_pause.JobState"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown job or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lets a paused scheduler job run again from the next cycle; resuming all clears every pause.\nRequires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Resume scheduler jobs",
+                "parameters": [
+                    {
+                        "description": "Job to resume (default all)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.SchedulerJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job resumed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_pause.JobState"
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown job or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/scheduler/trigger": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Queues an epoch boundary, starting the next epoch and distributing subsidies, to run now. This is how\nepochs advance in manual scheduler mode, and it runs alongside the automatic boundaries in the other\nmodes. The boundary runs in the background and its outcome is logged and audited. Requires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Trigger an epoch boundary",
+                "responses": {
+                    "202": {
+                        "description": "Boundary queued",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_scheduler.BoundaryResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Scheduler not running, paused, not the leader, or a boundary is already queued",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the vaults in the server's registry, including those whose onboarding did not complete.\nRequires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List registered vaults",
+                "responses": {
+                    "200": {
+                        "description": "Registered vaults, ordered by address",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.Vault"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Adds the vault to the DebtSubsidizer (IDebtSubsidizer.addVault), grants it the vault role on the\nEpochManager, whitelists the given collections and registers it in the server's vault registry. Steps\nalready done are skipped, so a failed onboarding is resumed by sending the same request again. When a\nstep fails the later ones do not run and the response is 502 with every step's outcome and the calls\nthat roll back the steps this request did. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Onboard a vault",
+                "parameters": [
+                    {
+                        "description": "Vault to onboard",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.OnboardRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Vault onboarded",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.OnboardingResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Vault was decommissioned",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "A step failed, the result lists the rollback calls",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.OnboardingResult"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/collection-weights": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lists the multipliers set on the vault's collections with the epochs they apply to, who set them and why.\nThe number of weights is returned in X-Total-Count and the next page is linked in the Link header.\nRequires an admin API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "List collection weights",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of weights to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of weights to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "collection",
+                            "fromEpoch",
+                            "setAt"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default collection)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collection weights",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.CollectionWeight"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of weights across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/collection-weights/{collection}": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Weights the subsidies earned in the collection by a multiplier (above 0, at most 100) in the\ndistributions of epochs fromEpoch to toEpoch inclusive. Setting a weight again replaces it;\ndistributions already built keep the weights they were built with. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Set collection weight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection address",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Multiplier and epochs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.SetCollectionWeightRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Weight set",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.CollectionWeight"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address, multiplier or epochs, or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Removes the collection's weight so later distributions value its subsidies unweighted.\nDistributions already built keep the weight. Requires an admin API key.",
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Delete collection weight",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Collection address",
+                        "name": "collection",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Weight removed"
+                    },
+                    "400": {
+                        "description": "Invalid address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "The collection has no weight",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/decommission": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Takes the vault out of scheduler runs: no yield allocation, distribution or reconciliation runs for it,\nwhile its stored distributions, proofs and claims stay served. With confirm the vault is also removed\nfrom the DebtSubsidizer (IDebtSubsidizer.removeVault), which cannot be undone; until then onboarding it\nagain puts it back into scheduler runs. A reason is required the first time. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Decommission a vault",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Reason and whether to remove the vault from the DebtSubsidizer",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.DecommissionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Vault decommissioning, or decommissioned once confirmed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.Vault"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid vault address, missing reason or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "removeVault transaction failed, the vault stays out of scheduler runs",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/epochs/{id}/adjustments": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Proposes adding the signed amount of wei to, or taking it from, the account's allocation in the epoch's\ndistribution. The amount is bounded by ADJUSTMENTS_MAX_AMOUNT and a justification is required. The\nadjustment applies to distributions built after an admin with another API key approves it, and is\nrecorded in their fingerprint. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Propose allocation adjustment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Account, signed amount and justification",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ProposeAdjustmentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Adjustment pending approval",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationAdjustment"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address, epoch or amount, missing justification, adjustments disabled, or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/epochs/{id}/fingerprint-override": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Lets the next distribution of the epoch replace its committed distribution although it was computed\nfrom other inputs than the committed fingerprint. Without an override such a distribution is refused.\nThe override applies to one distribution, and not after the committed distribution changed.\nRequires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Override committed distribution fingerprint",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why the committed distribution may be replaced",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.OverrideFingerprintRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Override stored",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.FingerprintOverride"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address or epoch, missing reason, or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Epoch has no committed distribution",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/epochs/{id}/replace-root": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Recomputes the distribution of the vault's latest epoch with what the epoch was carried in, runs the\nleaf total and finalization checks on it again and reports the recomputed root against the pushed one.\nWith confirm set the recomputed root is pushed with its total in place of the pushed root, whose tree\nis kept and recorded as superseded, so proofs against either root resolve and report the replacement.\nOnly the latest epoch's root can be replaced, and only while it is the vault's on-chain root.\nRequires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Replace an epoch's merkle root",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Why the root is replaced and whether to push the replacement",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ReplaceRootRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recomputed root, pushed when confirmed",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.RootReplacement"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address or epoch, missing reason, or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Epoch has no distribution",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Epoch is not the latest, its root is not on-chain, recomputes to the same root, or failed an enforced check",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Replacement transaction failed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/vaults/{vault}/epochs/{id}/snapshot-block": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Makes the epoch's distribution snapshot the vault at the given block instead of the block the\nconfigured snapshot strategy would choose. The block must already be on the chain; pinning again\nreplaces it. Requires an admin API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin"
+                ],
+                "summary": "Pin epoch snapshot block",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Block to snapshot at",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.PinSnapshotBlockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Block pinned",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.SnapshotBlockPin"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid address, epoch or block, or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analytics/vaults/{vault}": {
+            "get": {
+                "description": "Returns the vault's time series per reconciled epoch, oldest first: the yield allocated to the\nepoch, the subsidies its distribution added, the claim rate when it was reconciled and the\neffective APY NFT holders earned, the epoch's subsidies over the assets deposited in the vault\nannualized over the epoch's duration. Totals over the series and the claim rate read on-chain\nnow are returned alongside. With format=csv the series is exported as CSV instead.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get vault analytics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "First epoch included",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Last epoch included",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format (default json)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Vault analytics",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.VaultAnalytics"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault, epoch range or format",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/analytics/vaults/{vault}/collections": {
+            "get": {
+                "description": "Returns what the vault records on-chain for every collection the CollectionRegistry registers\nwith it: borrow volume, performance score, yield generated and assets deposited, all read at\nthe same block. The reads are batched through Multicall3 when it is deployed.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get vault collection stats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Collection stats",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.VaultCollections"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit": {
+            "get": {
+                "description": "Lists recorded state-changing actions (on-chain transactions), newest first. Pages are continued with\nthe nextCursor of the previous page, also linked in the Link header; no total count is returned.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "audit"
+                ],
+                "summary": "List audit log entries",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Action name, e.g. updateMerkleRoot",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Actor that triggered the action, e.g. scheduler or api:10.0.0.1",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Result (success or failed)",
+                        "name": "result",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Transaction hash",
+                        "name": "txHash",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or after this time (RFC3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only entries at or before this time (RFC3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of entries to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "nextCursor of the previous page",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "timestamp"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Audit log entries",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_audit.ListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/distributions": {
+            "get": {
+                "description": "Lists distributions whose merkle root was computed but held back for approval, oldest first. The number\nof matching distributions is returned in X-Total-Count and the next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "distributions"
+                ],
+                "summary": "List staged distributions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status: pending_approval, approved or rejected",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of distributions to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of distributions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "createdAt",
+                            "epochNumber"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default createdAt)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Staged distributions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.StagedDistribution"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching distributions across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - unknown status or invalid paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/distributions/{id}/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Pushes the staged merkle root on-chain and completes its epoch. Requires an approval API key.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "distributions"
+                ],
+                "summary": "Approve staged distribution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Staged distribution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Distribution approved and submitted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.SubsidyDistributionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Distribution is not pending approval",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Staged distribution not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "Merkle root transaction failed",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/distributions/{id}/reject": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Discards a staged distribution; the next scheduled run computes a new one. Requires an approval API key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "distributions"
+                ],
+                "summary": "Reject staged distribution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Staged distribution ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Rejection reason",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.RejectDistributionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Distribution rejected",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.StagedDistribution"
+                        }
+                    },
+                    "400": {
+                        "description": "Distribution is not pending approval or malformed body",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Missing or invalid API key",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Staged distribution not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs": {
+            "get": {
+                "description": "Lists the epochs known to the subgraph, newest first. The number of epochs is returned in X-Total-Count\nand the next page is linked in the Link header. Epochs the server allocated vault yield to also report\nthe amount allocated and the reserve held back.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "List epochs",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of epochs to return (1-1000, default 20)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of epochs to skip (0-5000)",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "epochNumber"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Epoch list",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.ListEpochsResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of epochs across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/current/onchain": {
+            "get": {
+                "description": "Reads the current epoch ID and the vault's epoch yield from the EpochManager, the vault's allocated and\nreserved yield, and the DebtSubsidizer's merkle root and subsidy totals, all at the latest block, and\nreturns them decoded, with the current epoch's start and end from its EpochStarted event. Meant for\ndebugging mismatches between the server, the subgraph and the chain.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Get on-chain epoch state",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address, defaults to the configured vault",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Decoded on-chain state",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.OnChainStateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/distribute": {
+            "post": {
+                "description": "Initiates the distribution of subsidies for the current epoch. With async=true the distribution is\nqueued and runs in the background, its outcome polled on GET /api/jobs/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Distribute subsidies",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Queue the distribution and return the job",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "low",
+                            "normal",
+                            "high"
+                        ],
+                        "type": "string",
+                        "description": "Priority of the queued job (default normal)",
+                        "name": "priority",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Subsidy distribution accepted, or queue.Job with async=true",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.SubsidyDistributionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "No epoch is active (epoch_not_active), pre-flight checks failed (preflight_failed), or the distribution would replace a committed one computed from other inputs (conflict)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "422": {
+                        "description": "Vault has no yield for the epoch and pre-flight checks are enforced (insufficient_yield)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Job queue is full (queue_full), the subgraph trails the chain head by more than SUBGRAPH_MAX_LAG blocks (subgraph_lagging), or reorgs kept orphaning the snapshot block (snapshot_stale)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/force-end": {
+            "post": {
+                "description": "Forcibly ends an epoch with zero yield distribution",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Force end epoch",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Epoch ID to force end",
+                        "name": "epochId",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Epoch force end accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.ForceEndEpochResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - missing or invalid epochId",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/start": {
+            "post": {
+                "description": "Initiates the start of a new epoch for yield distribution",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Start epoch",
+                "responses": {
+                    "202": {
+                        "description": "Epoch start accepted",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.StartEpochResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/{id}/report": {
+            "get": {
+                "description": "Returns a report of the epoch for stakeholders, built from what the server stored for it: every\ndistributed vault's totals, participants and top allocations, its latest reconciliation and the\ngas and hashes of the transactions sent for the epoch. With format=csv the report is exported as\nCSV instead, one section per table separated by an empty row.",
+                "produces": [
+                    "application/json",
+                    "text/csv"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Get epoch report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Top allocations listed per vault (default 10, max 100)",
+                        "name": "top",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv"
+                        ],
+                        "type": "string",
+                        "description": "Response format (default json)",
+                        "name": "format",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Epoch report",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.EpochReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid epoch, top or format",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution recorded for the epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/{id}/stats": {
+            "get": {
+                "description": "Summarizes how concentrated each vault's distribution of the epoch is: the Gini coefficient, the share\nthe 10 largest allocations receive, the median and mean allocation, and how many allocations are\nbelow the configured dust threshold. Statistics are recorded whenever an epoch is distributed; a\nvault distributed before they were has them computed from its stored distribution.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Get epoch distribution statistics",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address, every vault distributed in the epoch when omitted",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Distribution statistics by vault",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.EpochStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid epoch or vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution of the vault for the epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/epochs/{id}/timeline": {
+            "get": {
+                "description": "Lists the milestones of an epoch's processing in order: started, snapshot taken, allocations computed,\nroot submitted, confirmed and claims opened, each with its unix timestamp and the seconds since the\nprevious reached milestone, for rendering the epoch as a Gantt chart. Milestones not reached yet are\nreturned without a timestamp. Roots submitted before send times were recorded have no submission time.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "epochs"
+                ],
+                "summary": "Get epoch timeline",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address, defaults to the configured vault",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Epoch milestones",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.Timeline"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid epoch or vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Epoch not known to the subgraph nor processed by the server",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/graphql": {
+            "get": {
+                "description": "Executes a read-only GraphQL query over epochs, vaults, collections, user allocations and proofs. POST takes a JSON body with query, operationName and variables; GET takes the same as query parameters, with variables JSON-encoded. Mutations are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "Query with GraphQL",
+                "parameters": [
+                    {
+                        "description": "GraphQL request (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Request"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "GraphQL query (GET)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation to execute (GET)",
+                        "name": "operationName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded variables (GET)",
+                        "name": "variables",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Query executed; field errors are listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed, invalid or non-query request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Executes a read-only GraphQL query over epochs, vaults, collections, user allocations and proofs. POST takes a JSON body with query, operationName and variables; GET takes the same as query parameters, with variables JSON-encoded. Mutations are rejected.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "graphql"
+                ],
+                "summary": "Query with GraphQL",
+                "parameters": [
+                    {
+                        "description": "GraphQL request (POST)",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Request"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "GraphQL query (GET)",
+                        "name": "query",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation to execute (GET)",
+                        "name": "operationName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "JSON-encoded variables (GET)",
+                        "name": "variables",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Query executed; field errors are listed in errors",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Malformed, invalid or non-query request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs": {
+            "get": {
+                "description": "Lists the jobs queued with async=true that are waiting, running, or finished within QUEUE_RETENTION,\nmost recently queued first. The number of matching jobs is returned in X-Total-Count and the next\npage is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "List queued jobs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by status: queued, running, succeeded or failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of jobs to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of jobs to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Jobs",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_queue.Job"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching jobs across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - unknown status or invalid paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/jobs/{id}": {
+            "get": {
+                "description": "Returns a job queued with async=true: its status (queued, running, succeeded or failed), the result\nonce it succeeded and the error once it failed. Finished jobs are kept for QUEUE_RETENTION.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Get queued job",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Job",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_queue.Job"
+                        }
+                    },
+                    "404": {
+                        "description": "No such job, or it finished longer than the retention ago",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/proofs": {
+            "get": {
+                "description": "Generates a merkle proof for a user. Without epoch the latest snapshot is used; with epoch the proof is built against the root submitted for that epoch, and root selects an earlier root of the epoch that was since replaced.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proofs"
+                ],
+                "summary": "Get merkle proof",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "epoch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Merkle root submitted for the epoch (requires epoch)",
+                        "name": "root",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address (optional, uses default if not provided)",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merkle proof generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.UserMerkleProofResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address, epoch or root",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User, epoch or root not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/proofs/verify": {
+            "post": {
+                "description": "Verifies up to 1000 (vault, recipient, totalEarned, proof) tuples against the roots stored for each vault, reporting per proof whether it is valid, the epoch of the root it resolves to and, with onChain, whether that root is the vault's on-chain root. Invalid proofs are reported in their result with the reason, not as an error. With async=true the verification is queued and runs in the background, its result polled on GET /api/jobs/{id}.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "proofs"
+                ],
+                "summary": "Verify merkle proofs",
+                "parameters": [
+                    {
+                        "description": "Proofs to verify",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.VerifyProofsRequest"
+                        }
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the verification and return the job",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "low",
+                            "normal",
+                            "high"
+                        ],
+                        "type": "string",
+                        "description": "Priority of the queued job (default normal)",
+                        "name": "priority",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Per-proof validity, in request order",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ProofVerifications"
+                        }
+                    },
+                    "202": {
+                        "description": "Verification queued",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_queue.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - no proofs or too many",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Job queue is full",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports/gas": {
+            "get": {
+                "description": "Rolls up the gas used and ETH spent by mined transactions per operation type\n(epoch_start, epoch_finalize, root_update, batch_repay) and per epoch, with the\ncurrent month's budget status when a monthly budget is configured",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get gas spend report",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only transactions at or after this time (RFC3339)",
+                        "name": "since",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only transactions at or before this time (RFC3339)",
+                        "name": "until",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only transactions attributed to this epoch ID",
+                        "name": "epoch",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Gas spend report",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Report"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid filter",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports/reconciliation": {
+            "get": {
+                "description": "Lists the reconciliation of every distributed epoch, latest first. Each report compares the\nsubsidies the epoch's merkle tree pays with the yield the vault allocated to the epochs up to it\n(getEpochYieldAllocated) and with what was claimed so far, flagging subsidies_exceed_yield and\nclaims_exceed_subsidies when either exceeds the configured tolerance. Epochs are reconciled by\nthe reconcile scheduler job after every distribution. The number of matching reports is returned in\nX-Total-Count and the next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get yield reconciliation reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only reports of this vault address",
+                        "name": "vault",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only reports of this epoch ID",
+                        "name": "epoch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Only reports with discrepancies",
+                        "name": "flagged",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Most reports to return (1-500, default 50)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of reports to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "epochId"
+                        ],
+                        "type": "string",
+                        "description": "Sort field",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Reconciliation reports",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.ReportList"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching reports across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid filter or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/reports/reconciliation/claims": {
+            "get": {
+                "description": "Returns the latest claims reconciliation of every vault, or of one vault. Leaves are cumulative, so\nevery account of the vault's trees is checked against its leaf in the latest tree: an account whose\ngetUserClaimedTotal exceeds it is flagged claim_exceeds_earned, and one whose leaf is lower than in an\nearlier tree is flagged earned_decreased, when the difference exceeds the configured tolerance.\nAccounts dropped from the latest tree are checked against 0. Claims are reconciled by the reconcile\nscheduler job after every distribution.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "reports"
+                ],
+                "summary": "Get claims reconciliation reports",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Only the report of this vault address",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claims reconciliation reports",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.ClaimsReportList"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/scheduler/jobs": {
+            "get": {
+                "description": "Lists every scheduler job with its last run time, duration and outcome, the error of its last failed run, when it runs next, and its most recent runs. Runs are recorded by the replica holding the scheduler lease and kept for the last 100 runs of each job.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scheduler"
+                ],
+                "summary": "Get scheduler job status and history",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Most recent runs to return per job (1-100, default 10)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Run state of every job",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_jobs.Status"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid limit",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/signer": {
+            "get": {
+                "description": "Reads the ETH balance of the transaction signer and reports whether scheduled transactions are paused because it is below the configured minimum",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "signer"
+                ],
+                "summary": "Get signer balance status",
+                "responses": {
+                    "200": {
+                        "description": "Signer balance status",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_signer.BalanceStatus"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/status": {
+            "get": {
+                "description": "Reads whether the DebtSubsidizer is paused or removed the vault, in which case distributions are skipped, and reports the signer balance from the last check. When the contract cannot be read the last known state is returned with lastError set.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Get operational status",
+                "responses": {
+                    "200": {
+                        "description": "Operational status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.StatusResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/status/contracts": {
+            "get": {
+                "description": "Reads the deployed code of every configured contract, through its EIP-1967 proxy when it is one, and compares the function selectors it dispatches with the ABI of the binding compiled into the server. A contract is incompatible when it lacks functions of the binding, whose calls revert, and newer when it dispatches functions the binding lacks. Selectors are recovered from the dispatcher, so a contract they cannot be recovered from is unknown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "status"
+                ],
+                "summary": "Get contract ABI compatibility",
+                "responses": {
+                    "200": {
+                        "description": "Compatibility of each configured contract",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_compatibility.Matrix"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/claim-payload": {
+            "get": {
+                "description": "Encodes the user's claimSubsidy call in the vault's latest distribution: the transaction to send to\nthe DebtSubsidizer and EIP-712 typed data of the same claim for the user to sign with\neth_signTypedData_v4. claimSubsidy pays the recipient whoever sends it and checks no signature, a\nrelayer verifies the signature before sponsoring the claim. recipientType tells a contract wallet,\nwhose signature is checked with ERC-1271, from an externally owned account.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user claim payload",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address (defaults to the configured CollectionsVault)",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claim payload",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ClaimPayload"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address or mixed-case address with a wrong checksum",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found in the vault's latest distribution",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Nothing to claim (claim_unavailable) or the latest root is not on-chain yet (root_mismatch)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/claim-payload/verify": {
+            "post": {
+                "description": "Checks the signature is the recipient's signature of the typed data GET /api/users/{address}/claim-payload\nreturns now. An externally owned account's signature must recover to the recipient, a contract wallet\n(Safe and other ERC-1271 wallets) must accept it in isValidSignature. A relayer checks the claim was\nasked for before paying its gas. An invalid signature is reported in the result, not as an error.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Verify a claim signature",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Recipient address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Signature to verify",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.VerifyClaimSignatureRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Whether the recipient signed the claim",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ClaimSignatureVerification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address, checksum or signature",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found in the vault's latest distribution",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Nothing to claim (claim_unavailable) or the latest root is not on-chain yet (root_mismatch)",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/claimable": {
+            "get": {
+                "description": "Sums a user's earnings across every vault with a distribution, subtracts the on-chain getUserClaimedTotal,\nand returns per vault the ClaimData (recipient, totalEarned, merkleProof) ready to pass to claimSubsidy",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user claimable summary",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Claimable summary",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.UserClaimable"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/expirations": {
+            "get": {
+                "description": "Returns what the user has yet to claim of what each epoch's tree added to its total, with the epoch whose tree leaves it out when it is still unclaimed by then. Claims settle the oldest amounts first and are as the vault's latest distribution saw them at its snapshot block. Read from the positions recorded when distributions were saved, so no subgraph is queried. Empty when claims do not expire.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user claim expirations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the amounts in this vault",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Unclaimed amounts, soonest to expire first",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.UserExpirations"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/history": {
+            "get": {
+                "description": "Returns the user's position in every epoch distribution the server saved: deposit-seconds, NFTs counted, borrow volume, and what the epoch's tree paid and the user had claimed. Positions are recorded when a distribution is saved, from the subgraph state at its snapshot block, so no subgraph is queried and epochs distributed before positions were recorded are missing. The number of matching positions is returned in X-Total-Count and the next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user participation history",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the positions in this vault",
+                        "name": "vault",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of positions to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of positions to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "epochNumber",
+                            "blockNumber"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default epochNumber)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Positions, latest epoch first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.EpochPosition"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching positions across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/merkle-proof": {
+            "get": {
+                "description": "Generates a merkle proof for a user's current earnings",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user merkle proof",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address (optional, uses default if not provided)",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merkle proof generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.UserMerkleProofResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/merkle-proof/epoch/{epochNumber}": {
+            "get": {
+                "description": "Generates a merkle proof for a user's earnings at a specific epoch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get historical merkle proof",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "epochNumber",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Vault address (optional, uses default if not provided)",
+                        "name": "vault",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Historical merkle proof generated successfully",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.UserMerkleProofResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address or epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User or epoch not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{address}/total-earned": {
+            "get": {
+                "description": "Retrieves the total amount earned by a user across all epochs",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Get user total earned",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User wallet address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User earnings information",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.UserEarningsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/asset": {
+            "get": {
+                "description": "Returns the ERC-20 the vault holds, which its wei amounts are denominated in, with its symbol and decimals. JSON responses carry every wei amount field next to a \u003cfield\u003eFormatted string in whole tokens of this asset",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Get vault asset",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Vault asset",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_assets.Asset"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/epochs/{id}/diff": {
+            "get": {
+                "description": "Compares the epoch's stored distribution with the vault's latest distribution before it: new and\ndropped accounts, the accounts whose cumulative amount grew or shrank the most, and the growth of\nthe total. The same diff is computed whenever an epoch is distributed or replayed, and is included\nin staged distributions and their distribution.pending_approval alerts.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Diff epoch distribution against the previous epoch",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Accounts listed per change kind (1-100, default 10)",
+                        "name": "top",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Changes against the previous distribution",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationDiff"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address, epoch or top",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution for the epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/epochs/{id}/explain": {
+            "post": {
+                "description": "Explains up to 1000 users' amounts in an epoch's distribution like the single-user endpoint, reading their subgraph state in batched queries. Users without an allocation are listed in notFound.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Explain many user allocations",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Users to explain",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ExplainAllocationsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Computation trails",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationExplanations"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address, epoch or too many users",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution for the epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/epochs/{id}/replay": {
+            "get": {
+                "description": "Recomputes an epoch's allocations and merkle root from the subgraph state at its snapshot block with the code and caps running now, and diffs them per account against the distribution stored when the epoch was distributed. Streams the whole vault from the subgraph. With async=true the replay is queued and runs in the background, its result polled on GET /api/jobs/{id}.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Replay epoch distribution",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Queue the replay and return the job",
+                        "name": "async",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "low",
+                            "normal",
+                            "high"
+                        ],
+                        "type": "string",
+                        "description": "Priority of the queued job (default normal)",
+                        "name": "priority",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Recomputed distribution and drift",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.ReplayResult"
+                        }
+                    },
+                    "202": {
+                        "description": "Replay queued",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_queue.Job"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address or epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution for the epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Job queue is full",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/epochs/{id}/users/{address}/explain": {
+            "get": {
+                "description": "Recomputes a user's amount in an epoch's distribution from the subgraph state at the snapshot block, using the distributor's own valuation, and lists deposit-seconds, weights and amount per collection with the user's share of the vault total",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Explain user allocation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Epoch number",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User address",
+                        "name": "address",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Computation trail",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationExplanation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address or epoch",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No distribution for the epoch or no allocation for the user",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/merkle-root/verify": {
+            "get": {
+                "description": "Recomputes the merkle root from the latest stored snapshot and compares it with IDebtSubsidizer.getMerkleRoot. Returns 409 with mismatch details when the roots differ.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "Verify vault merkle root",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored and on-chain roots match",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.MerkleRootVerification"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault address",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No snapshot found for vault",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Merkle root mismatch",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.MerkleRootVerification"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/quarantine": {
+            "get": {
+                "description": "Lists the account subsidies distributions set aside because their subgraph records were malformed (invalid address, negative or unparsable values), with the raw values and reason, for manual review. The distribution completed for every other account. The number of matching accounts is returned in X-Total-Count and the next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "List quarantined accounts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Only the quarantine of this epoch",
+                        "name": "epoch",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of accounts to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of accounts to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "blockNumber",
+                            "quarantinedAt",
+                            "account"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default blockNumber)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default asc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Quarantined account subsidies ordered by snapshot block",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.QuarantinedAccount"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of matching accounts across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid address, epoch or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vaults/{vault}/roots": {
+            "get": {
+                "description": "Lists every MerkleRootUpdated event the DebtSubsidizer emitted for the vault, with the epoch of the stored tree the root was built from, so a proof can be matched to the root it was generated for. Events are synced from the chain on request once they are --confirmation-depth blocks deep; while the chain cannot be read the history synced so far is returned. The number of roots is returned in X-Total-Count and the next page is linked in the Link header.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "List vault merkle roots",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Vault address",
+                        "name": "vault",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Maximum number of roots to return (1-1000, default 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Number of roots to skip",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "blockNumber"
+                        ],
+                        "type": "string",
+                        "description": "Sort field (default blockNumber)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Sort order (default desc)",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Merkle roots, latest first",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.RootUpdate"
+                            }
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "URL of the next page, rel=next"
+                            },
+                            "X-Total-Count": {
+                                "type": "integer",
+                                "description": "Number of roots across all pages"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid vault address or paging parameters",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Returns the current health status of the epoch server",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Health check",
+                "responses": {
+                    "200": {
+                        "description": "Service is healthy",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.HealthResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Service is unhealthy",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.HealthResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/metrics": {
+            "get": {
+                "description": "Exposes server gauges, such as the signer balance, in the Prometheus text format",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "metrics"
+                ],
+                "summary": "Metrics",
+                "responses": {
+                    "200": {
+                        "description": "Prometheus metrics",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/vaults": {
+            "get": {
+                "description": "Lists the vaults the server manages: those onboarded through /admin/vaults and, with vault discovery\nenabled, those seen in the DebtSubsidizer's VaultAdded events. Vaults removed from the DebtSubsidizer\nare listed as decommissioned, with the block of their VaultRemoved event.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "vaults"
+                ],
+                "summary": "List vaults",
+                "parameters": [
+                    {
+                        "enum": [
+                            "onboarding",
+                            "active",
+                            "decommissioning",
+                            "decommissioned"
+                        ],
+                        "type": "string",
+                        "description": "Registry status",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Vaults, ordered by address",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.Vault"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Unknown status",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/internal_api_handlers.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_andrey_epoch-server_internal_api_graphql.Error": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {}
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_api_graphql.Request": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_api_graphql.Response": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "errors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_api_graphql.Error"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.CollectionStats": {
+            "type": "object",
+            "properties": {
+                "assetsDeposited": {
+                    "type": "string"
+                },
+                "borrowVolume": {
+                    "type": "string"
+                },
+                "collection": {
+                    "type": "string"
+                },
+                "performanceScore": {
+                    "type": "string"
+                },
+                "yieldGenerated": {
+                    "type": "string"
+                },
+                "yieldShareBps": {
+                    "description": "share of the vault's yield the registry gives the collection",
+                    "type": "integer",
+                    "example": 2500
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.EpochPoint": {
+            "type": "object",
+            "properties": {
+                "assetsDeposited": {
+                    "type": "string"
+                },
+                "claimRate": {
+                    "description": "claimed / cumulativeSubsidies",
+                    "type": "string"
+                },
+                "claimed": {
+                    "description": "claimed from the vault when the epoch was reconciled",
+                    "type": "string"
+                },
+                "cumulativeSubsidies": {
+                    "description": "what the epoch's tree pays in total",
+                    "type": "string"
+                },
+                "effectiveApy": {
+                    "description": "EffectiveAPY is subsidiesDistributed / assetsDeposited annualized over the epoch's duration. It is left\nout when the deposits or the epoch's start and end are not known.",
+                    "type": "string",
+                    "example": "0.042"
+                },
+                "endTimestamp": {
+                    "description": "unix time the epoch ended, when the subgraph knows it",
+                    "type": "integer"
+                },
+                "epochId": {
+                    "type": "string",
+                    "example": "3"
+                },
+                "startTimestamp": {
+                    "description": "unix time the epoch started, when the subgraph knows it",
+                    "type": "integer"
+                },
+                "subsidiesDistributed": {
+                    "description": "what the epoch's tree pays on top of the previous reconciled epoch's",
+                    "type": "string"
+                },
+                "yieldGenerated": {
+                    "description": "yield the vault allocated to the epoch",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.EpochReport": {
+            "type": "object",
+            "properties": {
+                "epochId": {
+                    "type": "string",
+                    "example": "3"
+                },
+                "flaggedVaults": {
+                    "description": "vaults whose reconciliation flagged discrepancies",
+                    "type": "integer"
+                },
+                "gas": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                },
+                "gasByOperation": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                    }
+                },
+                "generatedAt": {
+                    "type": "string"
+                },
+                "participants": {
+                    "description": "accounts with a leaf, counted once per vault",
+                    "type": "integer"
+                },
+                "totalSubsidies": {
+                    "description": "what the vaults' trees pay in total",
+                    "type": "string"
+                },
+                "transactions": {
+                    "description": "mined transactions sent for the epoch, oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Usage"
+                    }
+                },
+                "vaults": {
+                    "description": "by vault address",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.VaultEpochReport"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.ReconciliationSummary": {
+            "type": "object",
+            "properties": {
+                "claimed": {
+                    "type": "string"
+                },
+                "discrepancies": {
+                    "type": "integer"
+                },
+                "flagged": {
+                    "type": "boolean"
+                },
+                "reconciledAt": {
+                    "type": "string"
+                },
+                "subsidies": {
+                    "type": "string"
+                },
+                "yieldAllocated": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.TopAllocation": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "what the tree pays the account in total",
+                    "type": "string"
+                },
+                "rank": {
+                    "type": "integer"
+                },
+                "share": {
+                    "description": "amount / the vault's total",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.VaultAnalytics": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "description": "block claimed was read at",
+                    "type": "integer"
+                },
+                "claimRate": {
+                    "description": "claimed / subsidies the vault's latest root pays",
+                    "type": "string"
+                },
+                "claimed": {
+                    "description": "claimed from the vault's subsidies so far, read on-chain",
+                    "type": "string"
+                },
+                "epochs": {
+                    "description": "oldest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.EpochPoint"
+                    }
+                },
+                "totalSubsidiesDistributed": {
+                    "type": "string"
+                },
+                "totalYieldGenerated": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.VaultCollections": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "collections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.CollectionStats"
+                    }
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_analytics.VaultEpochReport": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "integer"
+                },
+                "dustAccounts": {
+                    "type": "integer"
+                },
+                "gini": {
+                    "type": "string",
+                    "example": "0.412300"
+                },
+                "median": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "reconciliation": {
+                    "description": "set once the vault was reconciled for the epoch",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.ReconciliationSummary"
+                        }
+                    ]
+                },
+                "snapshotBlock": {
+                    "description": "block the subgraph state was read at",
+                    "type": "integer"
+                },
+                "topAllocations": {
+                    "description": "largest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_analytics.TopAllocation"
+                    }
+                },
+                "total": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_assets.Asset": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+                },
+                "decimals": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "symbol": {
+                    "type": "string",
+                    "example": "USDC"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_audit.Entry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "contract": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "parameters": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "result": {
+                    "type": "string"
+                },
+                "revertReason": {
+                    "type": "string"
+                },
+                "sender": {
+                    "type": "string"
+                },
+                "sentAt": {
+                    "description": "when a transaction was broadcast, Timestamp is when it settled",
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_audit.ListResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "entries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_audit.Entry"
+                    }
+                },
+                "nextCursor": {
+                    "description": "continues after the last entry, empty on the last page",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_compatibility.ContractCompatibility": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+                },
+                "binding": {
+                    "type": "string",
+                    "example": "IDebtSubsidizer"
+                },
+                "codeHash": {
+                    "description": "keccak256 of the deployed code, changes with every upgrade",
+                    "type": "string"
+                },
+                "contract": {
+                    "type": "string",
+                    "example": "debt subsidizer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "implementation": {
+                    "description": "the EIP-1967 implementation behind Address, when it is a proxy",
+                    "type": "string"
+                },
+                "missing": {
+                    "description": "Missing are the binding's functions the deployed code does not dispatch, calls to them revert",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "updateMerkleRoot(address",
+                        "bytes32",
+                        "uint256)"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "compatible",
+                        "newer",
+                        "incompatible",
+                        "unknown"
+                    ],
+                    "example": "compatible"
+                },
+                "unknown": {
+                    "description": "Unknown are the selectors the deployed code dispatches that the binding lacks. Bindings of interfaces leave\nout functions the contract inherits, so these are reviewed rather than failed.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0x54fd4d50"
+                    ]
+                },
+                "version": {
+                    "description": "what the contract's version() returned",
+                    "type": "string",
+                    "example": "2.1.0"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_compatibility.Matrix": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "type": "string"
+                },
+                "compatible": {
+                    "description": "no contract is incompatible",
+                    "type": "boolean"
+                },
+                "contracts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_compatibility.ContractCompatibility"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_contractstate.PauseStatus": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "description": "time of the last successful check",
+                    "type": "string"
+                },
+                "debtSubsidizer": {
+                    "type": "string",
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "paused": {
+                    "description": "distributions are skipped",
+                    "type": "boolean"
+                },
+                "subsidizerPaused": {
+                    "type": "boolean"
+                },
+                "vault": {
+                    "type": "string",
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+                },
+                "vaultRemoved": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_deadletter.Entry": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer",
+                    "example": 4
+                },
+                "failedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d65"
+                },
+                "kind": {
+                    "type": "string",
+                    "enum": [
+                        "webhook",
+                        "transaction"
+                    ],
+                    "example": "webhook"
+                },
+                "lastError": {
+                    "type": "string",
+                    "example": "endpoint returned status 502"
+                },
+                "payload": {
+                    "description": "Payload is the webhook.Event of a webhook and the Transaction of a transaction",
+                    "type": "object"
+                },
+                "retriedAt": {
+                    "description": "when an operator last retried it",
+                    "type": "string"
+                },
+                "target": {
+                    "description": "Target is the endpoint URL of a webhook and the contract function of a transaction",
+                    "type": "string",
+                    "example": "https://hooks.example.com/epoch"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_deadletter.RetryResult": {
+            "type": "object",
+            "properties": {
+                "entry": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_deadletter.Entry"
+                },
+                "outcome": {
+                    "type": "string",
+                    "enum": [
+                        "delivered",
+                        "resent",
+                        "confirmed",
+                        "already_applied"
+                    ],
+                    "example": "delivered"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.EpochSummary": {
+            "type": "object",
+            "properties": {
+                "distributionFingerprint": {
+                    "description": "DistributionFingerprint is the hash of the inputs the epoch's distribution was computed from",
+                    "type": "string"
+                },
+                "eligibilityCid": {
+                    "description": "EligibilityCID is the IPFS CID of the epoch's published leaves and root, for verifying the distribution",
+                    "type": "string"
+                },
+                "endTimestamp": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "processingCompletedTimestamp": {
+                    "type": "string"
+                },
+                "startTimestamp": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "totalSubsidiesDistributed": {
+                    "type": "string"
+                },
+                "totalYieldDistributed": {
+                    "type": "string"
+                },
+                "yieldAllocated": {
+                    "description": "wei the server allocated to the epoch",
+                    "type": "string"
+                },
+                "yieldHeldBack": {
+                    "description": "wei kept in the vault as reserve when allocating",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.EpochTiming": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer",
+                    "example": 19025000
+                },
+                "chainTime": {
+                    "description": "timestamp of the latest block",
+                    "type": "integer",
+                    "example": 1717500000
+                },
+                "durationSeconds": {
+                    "type": "integer",
+                    "example": 604800
+                },
+                "endTime": {
+                    "type": "integer",
+                    "example": 1717804800
+                },
+                "ended": {
+                    "description": "the chain reached EndTime, the epoch can be ended and the next one started",
+                    "type": "boolean"
+                },
+                "epochId": {
+                    "type": "string",
+                    "example": "5"
+                },
+                "startBlock": {
+                    "type": "integer",
+                    "example": 19000000
+                },
+                "startTime": {
+                    "description": "unix seconds",
+                    "type": "integer",
+                    "example": 1717200000
+                },
+                "startTxHash": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.ForceEndEpochResponse": {
+            "type": "object",
+            "properties": {
+                "endedAt": {
+                    "type": "integer"
+                },
+                "epochId": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transactionHash": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                },
+                "zeroYieldApplied": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.ListEpochsResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "epochs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.EpochSummary"
+                    }
+                },
+                "total": {
+                    "description": "epochs the subgraph knows of, across all pages",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.Milestone": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "snapshot block or transaction hash",
+                    "type": "string",
+                    "example": "18500000"
+                },
+                "durationSeconds": {
+                    "description": "DurationSeconds is the time since the previous reached milestone",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "snapshot_taken"
+                },
+                "reached": {
+                    "type": "boolean"
+                },
+                "source": {
+                    "description": "subgraph, snapshot or audit",
+                    "type": "string",
+                    "example": "snapshot"
+                },
+                "timestamp": {
+                    "description": "unix seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.OnChainStateResponse": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "blockTimestamp": {
+                    "type": "integer"
+                },
+                "currentEpochId": {
+                    "type": "string"
+                },
+                "debtSubsidizer": {
+                    "type": "string"
+                },
+                "epochManager": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "description": "root claims are verified against, 0x-prefixed",
+                    "type": "string"
+                },
+                "remainingSubsidies": {
+                    "type": "string"
+                },
+                "timing": {
+                    "description": "when the current epoch started and ends, left out when it cannot be read",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.EpochTiming"
+                        }
+                    ]
+                },
+                "totalSubsidies": {
+                    "type": "string"
+                },
+                "totalSubsidiesClaimed": {
+                    "type": "string"
+                },
+                "totalYieldAllocated": {
+                    "description": "vault yield allocated to epochs so far",
+                    "type": "string"
+                },
+                "totalYieldReserved": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                },
+                "vaultYieldForEpoch": {
+                    "description": "yield the EpochManager holds for the vault in the current epoch",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.StartEpochResponse": {
+            "type": "object",
+            "properties": {
+                "epochId": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "transactionHash": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.Timeline": {
+            "type": "object",
+            "properties": {
+                "epochId": {
+                    "type": "string"
+                },
+                "milestones": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_epoch.Milestone"
+                    }
+                },
+                "status": {
+                    "description": "epoch status in the subgraph",
+                    "type": "string"
+                },
+                "totalSeconds": {
+                    "description": "TotalSeconds is the time from the first to the last reached milestone",
+                    "type": "integer"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_epoch.UserEarningsResponse": {
+            "type": "object",
+            "properties": {
+                "calculatedAt": {
+                    "type": "integer"
+                },
+                "dataTimestamp": {
+                    "description": "Timestamp used for calculations",
+                    "type": "integer"
+                },
+                "totalEarned": {
+                    "type": "string"
+                },
+                "userAddress": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_features.FlagState": {
+            "type": "object",
+            "properties": {
+                "configured": {
+                    "description": "Configured is what the features file or the default sets, which the flag returns to when its override is cleared",
+                    "type": "boolean"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "flag": {
+                    "type": "string",
+                    "example": "auto_approval"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "allocation drift on sepolia"
+                },
+                "source": {
+                    "type": "string",
+                    "example": "override"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "updatedBy": {
+                    "type": "string",
+                    "example": "api:10.0.0.1"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_features.Status": {
+            "type": "object",
+            "properties": {
+                "flags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_features.FlagState"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_gas.BudgetStatus": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "exceeded": {
+                    "type": "boolean"
+                },
+                "month": {
+                    "description": "UTC calendar month",
+                    "type": "string",
+                    "example": "2025-07"
+                },
+                "remaining": {
+                    "description": "wei, negative once exceeded",
+                    "type": "string"
+                },
+                "spent": {
+                    "description": "wei",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_gas.EpochRollup": {
+            "type": "object",
+            "properties": {
+                "byOperation": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                    }
+                },
+                "epochId": {
+                    "description": "empty for transactions not tied to an epoch",
+                    "type": "string"
+                },
+                "total": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_gas.Report": {
+            "type": "object",
+            "properties": {
+                "budget": {
+                    "description": "set when a monthly budget is configured",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.BudgetStatus"
+                        }
+                    ]
+                },
+                "byOperation": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                    }
+                },
+                "epochs": {
+                    "description": "ordered by epoch number",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.EpochRollup"
+                    }
+                },
+                "since": {
+                    "type": "string"
+                },
+                "total": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_gas.Rollup"
+                },
+                "until": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_gas.Rollup": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "gasUsed": {
+                    "type": "integer"
+                },
+                "transactions": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_gas.Usage": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "contract method, e.g. updateMerkleRoot",
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "description": "wei, gasUsed * gasPrice",
+                    "type": "string"
+                },
+                "epochId": {
+                    "description": "epoch the transaction was sent for",
+                    "type": "string"
+                },
+                "gasPrice": {
+                    "description": "wei, effective price per gas",
+                    "type": "string"
+                },
+                "gasUsed": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "operation": {
+                    "type": "string"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "txHash": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_jobs.JobStatus": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "description": "most recent first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_jobs.Run"
+                    }
+                },
+                "job": {
+                    "type": "string",
+                    "example": "distribute"
+                },
+                "lastError": {
+                    "description": "LastError is the error of the job's most recent failed run, kept after later runs succeed",
+                    "type": "string"
+                },
+                "lastErrorAt": {
+                    "type": "string"
+                },
+                "lastRun": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_jobs.Run"
+                },
+                "nextRun": {
+                    "description": "none in manual mode or when no scheduler runs",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_jobs.Run": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string",
+                    "example": "scheduler"
+                },
+                "durationMs": {
+                    "type": "integer",
+                    "example": 5230
+                },
+                "error": {
+                    "description": "why the run failed or was skipped",
+                    "type": "string",
+                    "example": "failed to distribute subsidies: execution reverted"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "job": {
+                    "type": "string",
+                    "example": "distribute"
+                },
+                "outcome": {
+                    "type": "string",
+                    "example": "succeeded"
+                },
+                "startedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_jobs.Status": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_jobs.JobStatus"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ClaimData": {
+            "type": "object",
+            "properties": {
+                "merkleProof": {
+                    "description": "0x-prefixed bytes32 values",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "totalEarned": {
+                    "type": "string",
+                    "example": "1500000000000000000"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ClaimPayload": {
+            "type": "object",
+            "properties": {
+                "claim": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ClaimData"
+                },
+                "claimable": {
+                    "description": "wei, totalEarned minus getUserClaimedTotal",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "epochNumber": {
+                    "type": "string",
+                    "example": "5"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "recipientChecksum": {
+                    "description": "RecipientChecksum is the recipient in EIP-55 form, as wallets display it",
+                    "type": "string",
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+                },
+                "recipientType": {
+                    "description": "RecipientType is eoa or contract, whether code is deployed at the recipient, which decides how its signature\nof the typed data is checked",
+                    "type": "string",
+                    "enum": [
+                        "eoa",
+                        "contract"
+                    ],
+                    "example": "eoa"
+                },
+                "transaction": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ClaimTransaction"
+                },
+                "typedData": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.TypedData"
+                },
+                "typedDataHash": {
+                    "description": "TypedDataHash is the EIP-712 digest of TypedData, what eth_signTypedData_v4 signs, 0x-prefixed",
+                    "type": "string"
+                },
+                "userAddress": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ClaimSignatureVerification": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "description": "why the signature is invalid",
+                    "type": "string"
+                },
+                "recipientType": {
+                    "type": "string",
+                    "enum": [
+                        "eoa",
+                        "contract"
+                    ],
+                    "example": "contract"
+                },
+                "signer": {
+                    "description": "account recovered from an EOA signature",
+                    "type": "string"
+                },
+                "typedDataHash": {
+                    "description": "digest the signature was checked against, 0x-prefixed",
+                    "type": "string"
+                },
+                "userAddress": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ClaimTransaction": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "description": "left out when CHAIN_ID is not configured",
+                    "type": "integer"
+                },
+                "data": {
+                    "description": "0x-prefixed calldata",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "DebtSubsidizer",
+                    "type": "string"
+                },
+                "value": {
+                    "description": "wei",
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.MerkleRootVerification": {
+            "type": "object",
+            "properties": {
+                "computedRoot": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "leafCount": {
+                    "type": "integer"
+                },
+                "leafEncoding": {
+                    "description": "encoding the snapshot's tree was built with",
+                    "type": "string"
+                },
+                "match": {
+                    "type": "boolean"
+                },
+                "mismatches": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "onChainRoot": {
+                    "type": "string"
+                },
+                "storedRoot": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                },
+                "verifiedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ProofToVerify": {
+            "type": "object",
+            "properties": {
+                "merkleProof": {
+                    "description": "32-byte hex nodes, 0x optional",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "recipient": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "totalEarned": {
+                    "description": "wei",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ProofVerification": {
+            "type": "object",
+            "properties": {
+                "epochNumber": {
+                    "description": "epoch of the stored tree with that root",
+                    "type": "string"
+                },
+                "leafEncoding": {
+                    "description": "encoding the leaf was hashed with to reach that root",
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "description": "stored root the proof resolves to, lowercase hex without 0x",
+                    "type": "string"
+                },
+                "onChain": {
+                    "description": "whether that root is the vault's on-chain root, when checked",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "description": "why the proof is invalid",
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "supersededBy": {
+                    "description": "root that replaced that root for its epoch, empty while it stands",
+                    "type": "string"
+                },
+                "totalEarned": {
+                    "type": "string"
+                },
+                "valid": {
+                    "type": "boolean"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.ProofVerifications": {
+            "type": "object",
+            "properties": {
+                "invalid": {
+                    "type": "integer"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ProofVerification"
+                    }
+                },
+                "valid": {
+                    "type": "integer"
+                },
+                "verifiedAt": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.RootUpdate": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "epochNumber": {
+                    "description": "epoch of the stored tree with this root, empty when none has it",
+                    "type": "string",
+                    "example": "5"
+                },
+                "logIndex": {
+                    "type": "integer"
+                },
+                "merkleRoot": {
+                    "description": "lowercase hex without 0x, as snapshots store roots",
+                    "type": "string"
+                },
+                "totalSubsidies": {
+                    "description": "totalSubsidiesForEpoch, wei",
+                    "type": "string",
+                    "example": "1500000000000000000"
+                },
+                "txHash": {
+                    "type": "string"
+                },
+                "updatedBy": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.TypedData": {
+            "type": "object",
+            "properties": {
+                "domain": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.TypedDataDomain"
+                },
+                "message": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "primaryType": {
+                    "type": "string",
+                    "example": "ClaimSubsidy"
+                },
+                "types": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.TypedDataField"
+                        }
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.TypedDataDomain": {
+            "type": "object",
+            "properties": {
+                "chainId": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "DebtSubsidizer"
+                },
+                "verifyingContract": {
+                    "type": "string"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.TypedDataField": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.UserClaimable": {
+            "type": "object",
+            "properties": {
+                "totalClaimable": {
+                    "description": "wei, earned minus claimed",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "totalClaimed": {
+                    "description": "wei, from getUserClaimedTotal",
+                    "type": "string",
+                    "example": "500000000000000000"
+                },
+                "totalEarned": {
+                    "description": "wei, cumulative in the latest distributions",
+                    "type": "string",
+                    "example": "1500000000000000000"
+                },
+                "userAddress": {
+                    "type": "string",
+                    "example": "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+                },
+                "vaults": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.VaultClaimable"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.UserMerkleProofResponse": {
+            "type": "object",
+            "properties": {
+                "epochNumber": {
+                    "type": "string"
+                },
+                "generatedAt": {
+                    "type": "integer"
+                },
+                "leafIndex": {
+                    "type": "integer"
+                },
+                "merkleProof": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "supersededBy": {
+                    "description": "root that replaced the proof's root for its epoch, empty while it stands",
+                    "type": "string"
+                },
+                "totalEarned": {
+                    "type": "string"
+                },
+                "userAddress": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_merkle.VaultClaimable": {
+            "type": "object",
+            "properties": {
+                "claim": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ClaimData"
+                },
+                "claimable": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string",
+                    "example": "5"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "totalClaimed": {
+                    "type": "string"
+                },
+                "totalEarned": {
+                    "type": "string"
+                },
+                "vaultAddress": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_pause.JobState": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "type": "string",
+                    "example": "distribute"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "pausedAt": {
+                    "type": "string"
+                },
+                "pausedBy": {
+                    "type": "string",
+                    "example": "api:10.0.0.1"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "contract upgrade"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_pause.Status": {
+            "type": "object",
+            "properties": {
+                "jobs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_pause.JobState"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_queue.Job": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "description": "Attempts counts the runs started, more than one when a restart interrupted the job and it ran again",
+                    "type": "integer",
+                    "example": 1
+                },
+                "enqueuedAt": {
+                    "type": "string"
+                },
+                "enqueuedBy": {
+                    "type": "string",
+                    "example": "api:10.0.0.1"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "failed to distribute subsidies: execution reverted"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "example": "9f86d081884c7d65"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "distribute"
+                },
+                "payload": {
+                    "description": "what the job was queued with",
+                    "type": "object"
+                },
+                "priority": {
+                    "type": "string",
+                    "example": "normal"
+                },
+                "requestId": {
+                    "description": "X-Request-ID of the request that queued the job",
+                    "type": "string"
+                },
+                "result": {
+                    "description": "what the work returned once it succeeded",
+                    "type": "object"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "succeeded"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.AccountDiscrepancy": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "wei claimed beyond earned, or earned lost since PreviousEpochID",
+                    "type": "string",
+                    "example": "250"
+                },
+                "claimed": {
+                    "description": "wei the account claimed on chain (getUserClaimedTotal)",
+                    "type": "string"
+                },
+                "earned": {
+                    "description": "wei the latest tree pays in total, 0 without a leaf",
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "claim_exceeds_earned"
+                },
+                "previousEarned": {
+                    "description": "highest total an earlier tree paid, for earned_decreased",
+                    "type": "string"
+                },
+                "previousEpochId": {
+                    "description": "epoch of that tree",
+                    "type": "string",
+                    "example": "2"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.ClaimsReport": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "description": "accounts checked",
+                    "type": "integer"
+                },
+                "claimed": {
+                    "description": "wei the accounts claimed on chain",
+                    "type": "string"
+                },
+                "discrepancies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.AccountDiscrepancy"
+                    }
+                },
+                "earned": {
+                    "description": "wei the latest tree pays the accounts in total",
+                    "type": "string"
+                },
+                "epochId": {
+                    "description": "epoch of the latest tree",
+                    "type": "string",
+                    "example": "3"
+                },
+                "epochs": {
+                    "description": "trees whose accounts were checked",
+                    "type": "integer"
+                },
+                "flagged": {
+                    "type": "boolean"
+                },
+                "merkleRoot": {
+                    "description": "0x-prefixed root of the latest tree",
+                    "type": "string"
+                },
+                "reconciledAt": {
+                    "type": "string"
+                },
+                "tolerance": {
+                    "description": "wei a discrepancy must exceed to be flagged",
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.ClaimsReportList": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.ClaimsReport"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.Discrepancy": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "wei by which the amount exceeds what covers it",
+                    "type": "string",
+                    "example": "250"
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "tree pays 1250 wei, epochs 1 to 3 were allocated 1000 wei"
+                },
+                "kind": {
+                    "type": "string",
+                    "example": "subsidies_exceed_yield"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.Report": {
+            "type": "object",
+            "properties": {
+                "assetsDeposited": {
+                    "description": "wei deposited in the vault when claims were read",
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "description": "block claims were read at",
+                    "type": "integer"
+                },
+                "claimed": {
+                    "description": "wei claimed from the vault's subsidies so far",
+                    "type": "string"
+                },
+                "cumulativeYieldAllocated": {
+                    "description": "wei allocated to the epochs up to this one",
+                    "type": "string"
+                },
+                "discrepancies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.Discrepancy"
+                    }
+                },
+                "epochId": {
+                    "type": "string",
+                    "example": "3"
+                },
+                "flagged": {
+                    "type": "boolean"
+                },
+                "merkleRoot": {
+                    "description": "0x-prefixed root of the reconciled tree",
+                    "type": "string"
+                },
+                "reconciledAt": {
+                    "type": "string"
+                },
+                "subsidies": {
+                    "description": "wei the tree's leaves add up to",
+                    "type": "string"
+                },
+                "tolerance": {
+                    "description": "wei a discrepancy must exceed to be flagged",
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                },
+                "yieldAllocated": {
+                    "description": "wei the vault allocated to the epoch",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_reconciliation.ReportList": {
+            "type": "object",
+            "properties": {
+                "reports": {
+                    "description": "latest epoch first unless the filter asked for the earliest",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_reconciliation.Report"
+                    }
+                },
+                "total": {
+                    "description": "reports matching the filter, across all pages",
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_scheduler.BoundaryResult": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "type": "string",
+                    "example": "calendar"
+                },
+                "nextBoundary": {
+                    "description": "next calendar boundary, none in the other modes",
+                    "type": "string"
+                },
+                "triggeredAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_signer.BalanceStatus": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+                },
+                "balance": {
+                    "description": "wei",
+                    "type": "string",
+                    "example": "250000000000000000"
+                },
+                "checkedAt": {
+                    "description": "time of the last successful check",
+                    "type": "string"
+                },
+                "halted": {
+                    "type": "boolean"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "minBalance": {
+                    "description": "wei, empty when halting is disabled",
+                    "type": "string",
+                    "example": "100000000000000000"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationAdjustment": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "amount": {
+                    "description": "wei added to the allocation, negative to take from it",
+                    "type": "string",
+                    "example": "-1500000"
+                },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "decidedBy": {
+                    "description": "who approved or rejected it",
+                    "type": "string"
+                },
+                "decidedWith": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "justification": {
+                    "type": "string"
+                },
+                "proposedAt": {
+                    "type": "string"
+                },
+                "proposedBy": {
+                    "type": "string"
+                },
+                "proposedWith": {
+                    "description": "ProposedWith identifies the API key the adjustment was proposed with, it cannot approve it",
+                    "type": "string",
+                    "example": "key:1a2b3c4d"
+                },
+                "rejectionReason": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationChange": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "change": {
+                    "description": "current minus previous",
+                    "type": "string"
+                },
+                "current": {
+                    "description": "wei, 0 when the account is not in this distribution",
+                    "type": "string"
+                },
+                "previous": {
+                    "description": "wei, 0 when the account was not in the previous distribution",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationDiff": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "integer"
+                },
+                "dropped": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationChange"
+                    }
+                },
+                "droppedAccounts": {
+                    "type": "integer"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "growthPercent": {
+                    "description": "of the previous total, empty when it was 0",
+                    "type": "string"
+                },
+                "new": {
+                    "description": "the lists hold at most the requested number of accounts each, largest change first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationChange"
+                    }
+                },
+                "newAccounts": {
+                    "type": "integer"
+                },
+                "previousAccounts": {
+                    "type": "integer"
+                },
+                "previousEpochNumber": {
+                    "description": "empty for the vault's first distribution",
+                    "type": "string"
+                },
+                "previousTotal": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "topDecreases": {
+                    "description": "accounts in both distributions whose amount shrank",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationChange"
+                    }
+                },
+                "topIncreases": {
+                    "description": "accounts in both distributions whose amount grew",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationChange"
+                    }
+                },
+                "total": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "totalGrowth": {
+                    "description": "total minus previous total",
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationDrift": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "difference": {
+                    "description": "recomputed minus stored",
+                    "type": "string"
+                },
+                "recomputed": {
+                    "description": "wei, 0 when the account is not in the recomputed distribution",
+                    "type": "string"
+                },
+                "stored": {
+                    "description": "wei, 0 when the account was not in the stored distribution",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationExplanation": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "description": "block the subgraph state was read at",
+                    "type": "integer"
+                },
+                "collections": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.CollectionAllocation"
+                    }
+                },
+                "computedAmount": {
+                    "description": "wei, sum of the collection amounts",
+                    "type": "string"
+                },
+                "debtCoverage": {
+                    "type": "string"
+                },
+                "distributedAmount": {
+                    "description": "wei, the user's amount in the merkle tree",
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "expired": {
+                    "description": "Expired is what of the user's earlier earnings expired unclaimed by the epoch, left out of computedAmount as\nit was of distributedAmount",
+                    "type": "string"
+                },
+                "matches": {
+                    "description": "computedAmount equals distributedAmount",
+                    "type": "boolean"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "outstandingDebt": {
+                    "description": "OutstandingDebt is what the user owed the vault's lending market at the snapshot block and DebtCoverage\ndistributedAmount / outstandingDebt, set when the distribution held accounts to their outstanding debt",
+                    "type": "string"
+                },
+                "userAddress": {
+                    "type": "string"
+                },
+                "valuedAt": {
+                    "description": "ValuedAt is the unix time accrual was valued at. Snapshots taken before it was recorded\nfall back to the snapshot's creation time and set ValuedAtEstimated.",
+                    "type": "integer"
+                },
+                "valuedAtEstimated": {
+                    "type": "boolean"
+                },
+                "vaultId": {
+                    "type": "string"
+                },
+                "vaultTotal": {
+                    "description": "wei, every amount in the merkle tree",
+                    "type": "string"
+                },
+                "yieldShare": {
+                    "description": "distributedAmount / vaultTotal",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AllocationExplanations": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "explanations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationExplanation"
+                    }
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "mismatched": {
+                    "description": "explanations whose computed amount differs from the tree",
+                    "type": "integer"
+                },
+                "notFound": {
+                    "description": "requested users without an allocation",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.AppliedCap": {
+            "type": "object",
+            "properties": {
+                "clamped": {
+                    "description": "wei this allocation lost to the rule",
+                    "type": "string"
+                },
+                "limit": {
+                    "description": "wei the account or collection was held to",
+                    "type": "string"
+                },
+                "rule": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.BlockedAddress": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string"
+                },
+                "blockedAt": {
+                    "type": "string"
+                },
+                "blockedBy": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.ClaimExpiration": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "wei still unclaimed",
+                    "type": "string",
+                    "example": "1000000000000000000"
+                },
+                "earnedInEpoch": {
+                    "type": "string"
+                },
+                "expiresAtEpoch": {
+                    "description": "epoch whose tree leaves the amount out when it is still unclaimed",
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.CollectionAllocation": {
+            "type": "object",
+            "properties": {
+                "amount": {
+                    "description": "wei, after caps and redistribution",
+                    "type": "string"
+                },
+                "capsApplied": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AppliedCap"
+                    }
+                },
+                "collectionParticipation": {
+                    "type": "string"
+                },
+                "collectionType": {
+                    "description": "ERC721 or ERC1155 as the subgraph reports it",
+                    "type": "string"
+                },
+                "elapsedSeconds": {
+                    "description": "valuation time minus updatedAtTimestamp",
+                    "type": "integer"
+                },
+                "lastEffectiveValue": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "redistributed": {
+                    "description": "wei received from amounts clamped elsewhere or withheld from blocked accounts",
+                    "type": "string"
+                },
+                "roundedOff": {
+                    "description": "fraction of a wei the valued amount was rounded down by",
+                    "type": "string"
+                },
+                "roundingBonus": {
+                    "description": "wei received from the fractions rounded off allocations",
+                    "type": "string"
+                },
+                "secondsAccumulated": {
+                    "type": "string"
+                },
+                "share": {
+                    "description": "fraction of the indexed record valued, for ERC-1155 units, wrapped positions and collection weights",
+                    "type": "string"
+                },
+                "source": {
+                    "type": "string"
+                },
+                "totalRewardsEarned": {
+                    "type": "string"
+                },
+                "totalSeconds": {
+                    "description": "secondsAccumulated + elapsedSeconds * lastEffectiveValue",
+                    "type": "string"
+                },
+                "uncappedAmount": {
+                    "description": "UncappedAmount, CapsApplied and Redistributed are set when caps or the blocklist changed the amount",
+                    "type": "string"
+                },
+                "updatedAtTimestamp": {
+                    "type": "string"
+                },
+                "wrappedBy": {
+                    "description": "wrapper contract the user's share was accrued by",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.CollectionWeight": {
+            "type": "object",
+            "properties": {
+                "collection": {
+                    "description": "collection contract address",
+                    "type": "string"
+                },
+                "fromEpoch": {
+                    "type": "integer"
+                },
+                "multiplier": {
+                    "description": "decimal such as \"2\" or \"1.5\"",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "setAt": {
+                    "type": "string"
+                },
+                "setBy": {
+                    "type": "string"
+                },
+                "toEpoch": {
+                    "description": "last epoch the weight applies to",
+                    "type": "integer"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.DistributionStats": {
+            "type": "object",
+            "properties": {
+                "accounts": {
+                    "type": "integer"
+                },
+                "computedAt": {
+                    "type": "string"
+                },
+                "dustAccounts": {
+                    "type": "integer"
+                },
+                "dustThreshold": {
+                    "description": "allocations below DustThreshold cost their recipient more gas to claim than they are worth",
+                    "type": "string"
+                },
+                "dustTotal": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "gini": {
+                    "description": "Gini is 0 when every account received the same and approaches 1 as one account receives everything",
+                    "type": "string",
+                    "example": "0.412300"
+                },
+                "mean": {
+                    "description": "rounded down",
+                    "type": "string"
+                },
+                "median": {
+                    "description": "rounded down between the two middle allocations of an even count",
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "top10Share": {
+                    "description": "top10Total / total",
+                    "type": "string"
+                },
+                "top10Total": {
+                    "description": "what the 10 largest allocations receive together",
+                    "type": "string"
+                },
+                "total": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.EpochPosition": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "description": "block the subgraph state was read at",
+                    "type": "integer"
+                },
+                "borrowVolume": {
+                    "description": "wei the user borrowed in total",
+                    "type": "string"
+                },
+                "claimed": {
+                    "description": "wei the user had claimed in total",
+                    "type": "string"
+                },
+                "collections": {
+                    "description": "collections the user participated in",
+                    "type": "integer"
+                },
+                "depositSeconds": {
+                    "description": "accrued across the user's collections, scaled by 1e18",
+                    "type": "string",
+                    "example": "86400000000000000000000"
+                },
+                "earned": {
+                    "description": "wei this tree added to the user's total since the vault's previous tree paying the user",
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "expired": {
+                    "description": "wei left out of totalEarned in total, having expired unclaimed",
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "nftsCounted": {
+                    "description": "NFTs, or ERC-1155 units, held in the vault's collections",
+                    "type": "string",
+                    "example": "3"
+                },
                 "totalEarned": {
+                    "description": "wei the tree pays the user in total, 0 without a leaf",
+                    "type": "string"
+                },
+                "valuedAt": {
+                    "description": "unix time deposit-seconds were accrued up to",
+                    "type": "integer"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.EpochStats": {
+            "type": "object",
+            "properties": {
+                "epochNumber": {
+                    "type": "string"
+                },
+                "vaults": {
+                    "description": "by vault address",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.DistributionStats"
+                    }
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.FinalizationCheck": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "description": "why the check failed or could not run",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passed": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.FingerprintOverride": {
+            "type": "object",
+            "properties": {
+                "epochNumber": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "hash of the committed fingerprint being overridden",
+                    "type": "string"
+                },
+                "overriddenAt": {
+                    "type": "string"
+                },
+                "overriddenBy": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.QuarantinedAccount": {
+            "type": "object",
+            "properties": {
+                "account": {
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "description": "snapshot block the record was read at",
+                    "type": "integer"
+                },
+                "collectionParticipation": {
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "lastEffectiveValue": {
+                    "type": "string"
+                },
+                "quarantinedAt": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "secondsAccumulated": {
+                    "type": "string"
+                },
+                "subsidyId": {
+                    "type": "string"
+                },
+                "totalRewardsEarned": {
+                    "type": "string"
+                },
+                "updatedAtTimestamp": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.ReplayResult": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "diff": {
+                    "description": "Diff compares the recomputed distribution with the vault's previous one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationDiff"
+                        }
+                    ]
+                },
+                "drift": {
+                    "description": "accounts whose amount changed, by address",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationDrift"
+                    }
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "matches": {
+                    "description": "recomputedRoot equals storedRoot",
+                    "type": "boolean"
+                },
+                "onChainRoot": {
+                    "description": "OnChainRoot is the root the subgraph indexed for the epoch, empty when it has none",
+                    "type": "string"
+                },
+                "recomputedEntries": {
+                    "type": "integer"
+                },
+                "recomputedRoot": {
+                    "type": "string"
+                },
+                "recomputedTotal": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "storedEntries": {
+                    "type": "integer"
+                },
+                "storedRoot": {
+                    "type": "string"
+                },
+                "storedTotal": {
+                    "description": "wei",
+                    "type": "string"
+                },
+                "valuedAt": {
+                    "type": "integer"
+                },
+                "valuedAtEstimated": {
+                    "type": "boolean"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.RootReplacement": {
+            "type": "object",
+            "properties": {
+                "accountsProcessed": {
+                    "type": "integer"
+                },
+                "blockNumber": {
+                    "description": "block the recomputed tree was snapshotted at",
+                    "type": "integer"
+                },
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.FinalizationCheck"
+                    }
+                },
+                "confirmed": {
+                    "description": "whether the root was pushed",
+                    "type": "boolean"
+                },
+                "diff": {
+                    "description": "the recomputed tree against the replaced one",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationDiff"
+                        }
+                    ]
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "hash of the inputs the recomputed tree was built from",
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "description": "recomputed root, hex without 0x",
+                    "type": "string"
+                },
+                "previousRoot": {
+                    "description": "root on-chain before the replacement, hex without 0x",
+                    "type": "string"
+                },
+                "previousTotal": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "replacedAt": {
+                    "type": "string"
+                },
+                "replacedBy": {
+                    "type": "string"
+                },
+                "totalSubsidies": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.SnapshotBlockPin": {
+            "type": "object",
+            "properties": {
+                "blockHash": {
+                    "description": "canonical hash when the block was pinned",
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "pinnedAt": {
+                    "type": "string"
+                },
+                "pinnedBy": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.StagedDistribution": {
+            "type": "object",
+            "properties": {
+                "accountsProcessed": {
+                    "type": "integer"
+                },
+                "blockHash": {
+                    "type": "string"
+                },
+                "blockNumber": {
+                    "type": "integer"
+                },
+                "blockStrategy": {
+                    "description": "how the snapshot block was chosen",
                     "type": "string"
                 },
-                "userAddress": {
+                "carriedForward": {
+                    "description": "wei clamped by caps and left for the next distribution",
                     "type": "string"
                 },
-                "vaultAddress": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "decidedBy": {
+                    "type": "string"
+                },
+                "diff": {
+                    "description": "changes against the vault's previous distribution",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.AllocationDiff"
+                        }
+                    ]
+                },
+                "dustCarried": {
+                    "description": "fraction of wei rounding left for the next distribution",
+                    "type": "string"
+                },
+                "epochNumber": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "hash of the inputs the distribution was computed from",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "reason": {
+                    "description": "why approval was required, or why it was rejected",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "totalSubsidies": {
+                    "type": "string"
+                },
+                "vaultId": {
                     "type": "string"
                 }
             }
         },
-        "handlers.DistributeSubsidiesResponse": {
+        "github_com_andrey_epoch-server_internal_services_subsidy.SubsidyDistributionResponse": {
             "type": "object",
             "properties": {
-                "message": {
-                    "type": "string",
-                    "example": "Subsidy distribution initiated successfully"
+                "accountsDeferred": {
+                    "description": "AccountsDeferred counts the accounts left out below the vault's minimum allocation, their amounts carried",
+                    "type": "integer"
+                },
+                "accountsProcessed": {
+                    "type": "integer"
+                },
+                "accountsQuarantined": {
+                    "description": "AccountsQuarantined counts the account subsidies skipped for malformed subgraph data",
+                    "type": "integer"
+                },
+                "checks": {
+                    "description": "Checks are the pre-flight checks run before the root was pushed or staged",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.FinalizationCheck"
+                    }
+                },
+                "epochId": {
+                    "type": "string"
+                },
+                "merkleRoot": {
+                    "type": "string"
+                },
+                "resumed": {
+                    "description": "Resumed is set when a distribution computed by an earlier, failed run was submitted instead of a new one",
+                    "type": "boolean"
+                },
+                "stagedId": {
+                    "description": "set when the distribution waits for approval",
+                    "type": "string"
                 },
                 "status": {
+                    "type": "string"
+                },
+                "totalSubsidies": {
+                    "type": "string"
+                },
+                "transactionHash": {
+                    "type": "string"
+                },
+                "vaultId": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_subsidy.UserExpirations": {
+            "type": "object",
+            "properties": {
+                "expirations": {
+                    "description": "soonest first",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_subsidy.ClaimExpiration"
+                    }
+                },
+                "expiryEpochs": {
+                    "description": "epochs an amount stays claimable, 0 when amounts never expire",
+                    "type": "integer"
+                },
+                "userAddress": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_vaults.DecommissionRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "call removeVault, which cannot be undone",
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "migrated to the v2 vault"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_vaults.OnboardRequest": {
+            "type": "object",
+            "properties": {
+                "collections": {
+                    "description": "whitelisted for the vault",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "lendingManager": {
+                    "description": "defaults to the configured lending manager",
                     "type": "string",
-                    "example": "accepted"
+                    "example": "0x2345678901234567890123456789012345678901"
                 },
-                "vaultID": {
+                "vaultAddress": {
                     "type": "string",
                     "example": "0x1234567890123456789012345678901234567890"
                 }
             }
         },
-        "handlers.ErrorResponse": {
+        "github_com_andrey_epoch-server_internal_services_vaults.OnboardingResult": {
             "type": "object",
             "properties": {
-                "code": {
-                    "type": "integer"
+                "complete": {
+                    "type": "boolean"
                 },
-                "details": {
-                    "type": "string"
+                "rollback": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.OnboardingStep"
+                    }
                 },
+                "vault": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_vaults.Vault"
+                }
+            }
+        },
+        "github_com_andrey_epoch-server_internal_services_vaults.OnboardingStep": {
+            "type": "object",
+            "properties": {
                 "error": {
                     "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "whitelist_collection"
+                },
+                "status": {
+                    "type": "string",
+                    "example": "done"
+                },
+                "target": {
+                    "description": "collection of a whitelist step",
+                    "type": "string",
+                    "example": "0x3456789012345678901234567890123456789012"
                 }
             }
         },
-        "handlers.ForceEndEpochResponse": {
+        "github_com_andrey_epoch-server_internal_services_vaults.Vault": {
             "type": "object",
             "properties": {
-                "epochId": {
+                "addedBlock": {
                     "type": "integer",
-                    "example": 1
+                    "example": 19000000
                 },
-                "message": {
+                "address": {
+                    "type": "string",
+                    "example": "0x1234567890123456789012345678901234567890"
+                },
+                "cToken": {
+                    "type": "string",
+                    "example": "0x3456789012345678901234567890123456789012"
+                },
+                "collections": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "decommissionReason": {
+                    "type": "string",
+                    "example": "migrated to the v2 vault"
+                },
+                "decommissionedAt": {
+                    "type": "string"
+                },
+                "decommissionedBy": {
+                    "type": "string",
+                    "example": "api:10.0.0.1"
+                },
+                "discovered": {
+                    "description": "set for vaults whose VaultAdded event was seen on the DebtSubsidizer, Discovered when that is how the\nregistry learned of them",
+                    "type": "boolean"
+                },
+                "lendingManager": {
+                    "type": "string",
+                    "example": "0x2345678901234567890123456789012345678901"
+                },
+                "onboardedAt": {
+                    "type": "string"
+                },
+                "onboardedBy": {
                     "type": "string",
-                    "example": "Force end epoch initiated successfully"
+                    "example": "api:10.0.0.1"
+                },
+                "removedAt": {
+                    "description": "when removeVault was mined, or found already done",
+                    "type": "string"
+                },
+                "removedBlock": {
+                    "type": "integer",
+                    "example": 19500000
+                },
+                "roleGranted": {
+                    "type": "boolean"
                 },
                 "status": {
                     "type": "string",
-                    "example": "accepted"
+                    "example": "active"
                 },
-                "vaultID": {
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.BlockAddressRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
                     "type": "string",
-                    "example": "0x1234567890123456789012345678901234567890"
+                    "example": "OFAC sanctions list"
+                }
+            }
+        },
+        "internal_api_handlers.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer"
+                },
+                "details": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "errorCode": {
+                    "type": "string",
+                    "example": "epoch_not_active"
+                }
+            }
+        },
+        "internal_api_handlers.ExplainAllocationsRequest": {
+            "type": "object",
+            "properties": {
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
-        "handlers.HealthResponse": {
+        "internal_api_handlers.HealthResponse": {
             "type": "object",
             "properties": {
                 "checks": {
@@ -429,50 +7405,160 @@ const docTemplate = `{
                 }
             }
         },
-        "handlers.StartEpochResponse": {
+        "internal_api_handlers.OverrideFingerprintRequest": {
             "type": "object",
             "properties": {
-                "message": {
+                "reason": {
+                    "type": "string",
+                    "example": "subgraph reindexed the snapshot block"
+                }
+            }
+        },
+        "internal_api_handlers.PinSnapshotBlockRequest": {
+            "type": "object",
+            "properties": {
+                "blockNumber": {
+                    "type": "integer",
+                    "example": 19000000
+                }
+            }
+        },
+        "internal_api_handlers.ProposeAdjustmentRequest": {
+            "type": "object",
+            "properties": {
+                "account": {
                     "type": "string",
-                    "example": "Epoch start initiated successfully"
+                    "example": "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
                 },
-                "status": {
+                "amount": {
+                    "type": "string",
+                    "example": "-250000000000000000"
+                },
+                "justification": {
                     "type": "string",
-                    "example": "accepted"
+                    "example": "deposit credited twice after the subgraph reorg at block 19000000"
                 }
             }
         },
-        "merkle.UserMerkleProofResponse": {
+        "internal_api_handlers.RejectDistributionRequest": {
             "type": "object",
             "properties": {
-                "epochNumber": {
+                "reason": {
                     "type": "string"
+                }
+            }
+        },
+        "internal_api_handlers.ReplaceRootRequest": {
+            "type": "object",
+            "properties": {
+                "confirm": {
+                    "description": "push the recomputed root, otherwise only report it",
+                    "type": "boolean"
                 },
-                "generatedAt": {
-                    "type": "integer"
+                "reason": {
+                    "type": "string",
+                    "example": "blocked account was left in the tree"
+                }
+            }
+        },
+        "internal_api_handlers.SchedulerJobRequest": {
+            "type": "object",
+            "properties": {
+                "job": {
+                    "description": "all, start_epoch, allocate_yield, distribute, catch_up or reconcile; empty means all",
+                    "type": "string",
+                    "example": "distribute"
                 },
-                "leafIndex": {
-                    "type": "integer"
+                "reason": {
+                    "type": "string",
+                    "example": "contract upgrade"
+                }
+            }
+        },
+        "internal_api_handlers.SetCollectionWeightRequest": {
+            "type": "object",
+            "properties": {
+                "fromEpoch": {
+                    "type": "integer",
+                    "example": 5
                 },
-                "merkleProof": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "multiplier": {
+                    "type": "string",
+                    "example": "1.5"
                 },
-                "merkleRoot": {
-                    "type": "string"
+                "reason": {
+                    "type": "string",
+                    "example": "launch campaign"
                 },
-                "totalEarned": {
-                    "type": "string"
+                "toEpoch": {
+                    "type": "integer",
+                    "example": 8
+                }
+            }
+        },
+        "internal_api_handlers.SetFeatureRequest": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": false
                 },
-                "userAddress": {
+                "reason": {
+                    "type": "string",
+                    "example": "allocation drift on sepolia"
+                }
+            }
+        },
+        "internal_api_handlers.StatusResponse": {
+            "type": "object",
+            "properties": {
+                "contract": {
+                    "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_contractstate.PauseStatus"
+                },
+                "signer": {
+                    "description": "as of the last scheduler tick",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_signer.BalanceStatus"
+                        }
+                    ]
+                }
+            }
+        },
+        "internal_api_handlers.VerifyClaimSignatureRequest": {
+            "type": "object",
+            "properties": {
+                "signature": {
+                    "description": "0x-prefixed, what eth_signTypedData_v4 or the contract wallet returned",
                     "type": "string"
                 },
-                "vaultAddress": {
+                "vault": {
+                    "description": "defaults to the configured CollectionsVault",
                     "type": "string"
                 }
             }
+        },
+        "internal_api_handlers.VerifyProofsRequest": {
+            "type": "object",
+            "properties": {
+                "onChain": {
+                    "description": "also require the vault's on-chain root",
+                    "type": "boolean"
+                },
+                "proofs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_andrey_epoch-server_internal_services_merkle.ProofToVerify"
+                    }
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "ApiKeyAuth": {
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
         }
     }
 }`
@@ -480,7 +7566,7 @@ const docTemplate = `{
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0",
-	Host:             "localhost:8080",
+	Host:             "localhost:8088",
 	BasePath:         "/",
 	Schemes:          []string{"http", "https"},
 	Title:            "Epoch Server API",
@@ -0,0 +1,494 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Request is a GraphQL request as sent over HTTP
+type Request struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// Response is the result of a request.
+// Data is absent when the request could not be executed at all.
+type Response struct {
+	Data   interface{} `json:"data,omitempty"`
+	Errors []*Error    `json:"errors,omitempty"`
+}
+
+// Error is a request or field error; Path is set for field errors
+type Error struct {
+	Message string        `json:"message"`
+	Path    []interface{} `json:"path,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Execute parses, validates and executes a query against the schema
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	if strings.TrimSpace(req.Query) == "" {
+		return requestError("query cannot be empty")
+	}
+	doc, err := Parse(req.Query)
+	if err != nil {
+		return requestError("syntax error: %v", err)
+	}
+
+	operation, err := selectOperation(doc, req.OperationName)
+	if err != nil {
+		return requestError("%v", err)
+	}
+	if operation.Type != "query" {
+		return requestError("%s operations are not supported, this endpoint is read-only", operation.Type)
+	}
+
+	v := &validator{schema: s, doc: doc, variables: make(map[string]bool)}
+	for _, variable := range operation.Variables {
+		v.variables[variable.Name] = true
+	}
+	v.validateSelections(s.Query, operation.SelectionSet, nil)
+	if len(v.errors) > 0 {
+		return &Response{Errors: v.errors}
+	}
+
+	variables, err := coerceVariables(operation.Variables, req.Variables)
+	if err != nil {
+		return requestError("%v", err)
+	}
+
+	e := &executor{doc: doc, variables: variables}
+	data := e.executeSelections(ctx, s.Query, nil, operation.SelectionSet, nil)
+	return &Response{Data: data, Errors: e.errors}
+}
+
+func requestError(format string, args ...interface{}) *Response {
+	return &Response{Errors: []*Error{{Message: fmt.Sprintf(format, args...)}}}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, fmt.Errorf("operationName is required when the document contains several operations")
+		}
+		return doc.Operations[0], nil
+	}
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown operation %q", name)
+}
+
+// coerceVariables applies defaults and checks that required variables are provided
+func coerceVariables(definitions []VariableDefinition, given map[string]interface{}) (map[string]interface{}, error) {
+	variables := make(map[string]interface{}, len(definitions))
+	for _, definition := range definitions {
+		value, ok := given[definition.Name]
+		if !ok || value == nil {
+			value = definition.Default
+		}
+		if value == nil && definition.Required {
+			return nil, fmt.Errorf("variable $%s of type %s! is required", definition.Name, definition.Type)
+		}
+		variables[definition.Name] = value
+	}
+	return variables, nil
+}
+
+// validator checks selections against the schema before anything is resolved
+type validator struct {
+	schema    *Schema
+	doc       *Document
+	variables map[string]bool
+	fields    int
+	errors    []*Error
+}
+
+func (v *validator) errorf(format string, args ...interface{}) {
+	v.errors = append(v.errors, &Error{Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateSelections(object *Object, selections []Selection, spreading []string) {
+	for _, selection := range selections {
+		v.validateDirectives(selection.directives())
+
+		switch selection := selection.(type) {
+		case *Field:
+			v.fields++
+			if v.schema.MaxFields > 0 && v.fields == v.schema.MaxFields+1 {
+				v.errorf("query selects more than %d fields", v.schema.MaxFields)
+			}
+			v.validateField(object, selection, spreading)
+		case *InlineFragment:
+			if selection.TypeCondition != "" && selection.TypeCondition != object.Name {
+				v.errorf("inline fragment on %q cannot be spread on type %q", selection.TypeCondition, object.Name)
+				continue
+			}
+			v.validateSelections(object, selection.SelectionSet, spreading)
+		case *FragmentSpread:
+			fragment, ok := v.doc.Fragments[selection.Name]
+			if !ok {
+				v.errorf("unknown fragment %q", selection.Name)
+				continue
+			}
+			if contains(spreading, selection.Name) {
+				v.errorf("fragment %q spreads itself", selection.Name)
+				continue
+			}
+			if fragment.TypeCondition != object.Name {
+				v.errorf("fragment %q on %q cannot be spread on type %q", fragment.Name, fragment.TypeCondition, object.Name)
+				continue
+			}
+			v.validateSelections(object, fragment.SelectionSet, append(spreading, selection.Name))
+		}
+
+		if v.schema.MaxFields > 0 && v.fields > v.schema.MaxFields {
+			return
+		}
+	}
+}
+
+func (v *validator) validateField(object *Object, field *Field, spreading []string) {
+	if field.Name == "__typename" {
+		if field.SelectionSet != nil {
+			v.errorf("field \"__typename\" cannot have a selection set")
+		}
+		return
+	}
+
+	def, ok := object.Fields[field.Name]
+	if !ok {
+		v.errorf("cannot query field %q on type %q", field.Name, object.Name)
+		return
+	}
+
+	for name, value := range field.Arguments {
+		if _, ok := def.Args[name]; !ok {
+			v.errorf("unknown argument %q on field %q", name, field.Name)
+			continue
+		}
+		v.validateValue(value)
+	}
+	for name, arg := range def.Args {
+		if value, ok := field.Arguments[name]; arg.Required && arg.Default == nil && (!ok || value == nil) {
+			v.errorf("field %q argument %q of type %s! is required", field.Name, name, arg.Kind)
+		}
+	}
+
+	switch {
+	case def.Type == nil && field.SelectionSet != nil:
+		v.errorf("field %q of scalar type cannot have a selection set", field.Name)
+	case def.Type != nil && field.SelectionSet == nil:
+		v.errorf("field %q of type %q must have a selection of subfields", field.Name, def.Type.Name)
+	case def.Type != nil:
+		v.validateSelections(def.Type, field.SelectionSet, spreading)
+	}
+}
+
+func (v *validator) validateDirectives(directives []Directive) {
+	for _, directive := range directives {
+		if directive.Name != "skip" && directive.Name != "include" {
+			v.errorf("unknown directive @%s", directive.Name)
+			continue
+		}
+		value, ok := directive.Arguments["if"]
+		if !ok || len(directive.Arguments) != 1 {
+			v.errorf("directive @%s takes a single \"if\" argument", directive.Name)
+			continue
+		}
+		v.validateValue(value)
+	}
+}
+
+// validateValue checks that every variable referenced in value is declared
+func (v *validator) validateValue(value Value) {
+	switch value := value.(type) {
+	case Variable:
+		if !v.variables[string(value)] {
+			v.errorf("variable $%s is not defined", value)
+		}
+	case []Value:
+		for _, item := range value {
+			v.validateValue(item)
+		}
+	case map[string]Value:
+		for _, item := range value {
+			v.validateValue(item)
+		}
+	}
+}
+
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+type executor struct {
+	doc       *Document
+	variables map[string]interface{}
+	errors    []*Error
+}
+
+func (e *executor) fieldError(path []interface{}, err error) {
+	e.errors = append(e.errors, &Error{Message: err.Error(), Path: append([]interface{}{}, path...)})
+}
+
+// executeSelections resolves the selected fields of source in selection order
+func (e *executor) executeSelections(
+	ctx context.Context,
+	object *Object,
+	source interface{},
+	selections []Selection,
+	path []interface{},
+) *orderedMap {
+	result := &orderedMap{values: make(map[string]interface{})}
+	grouped := e.collectFields(object, selections, nil, result)
+
+	for _, key := range result.keys {
+		fields := grouped[key]
+		field := fields[0]
+		fieldPath := append(path[:len(path):len(path)], key)
+
+		if field.Name == "__typename" {
+			result.values[key] = object.Name
+			continue
+		}
+
+		def := object.Fields[field.Name]
+		args, err := e.coerceArguments(def.Args, field.Arguments)
+		if err != nil {
+			e.fieldError(fieldPath, fmt.Errorf("field %q: %w", field.Name, err))
+			continue
+		}
+
+		var value interface{}
+		if def.Resolve != nil {
+			value, err = def.Resolve(ctx, source, args)
+		} else {
+			value, err = defaultResolve(source, field.Name)
+		}
+		if err != nil {
+			e.fieldError(fieldPath, err)
+			continue
+		}
+
+		var subSelections []Selection
+		for _, f := range fields {
+			subSelections = append(subSelections, f.SelectionSet...)
+		}
+		result.values[key] = e.completeValue(ctx, def, value, subSelections, fieldPath)
+	}
+	return result
+}
+
+// collectFields flattens fragments and groups fields by response key, recording key order in result
+func (e *executor) collectFields(
+	object *Object,
+	selections []Selection,
+	grouped map[string][]*Field,
+	result *orderedMap,
+) map[string][]*Field {
+	if grouped == nil {
+		grouped = make(map[string][]*Field)
+	}
+	for _, selection := range selections {
+		if !e.included(selection.directives()) {
+			continue
+		}
+		switch selection := selection.(type) {
+		case *Field:
+			key := selection.ResponseKey()
+			if _, ok := grouped[key]; !ok {
+				result.keys = append(result.keys, key)
+			}
+			grouped[key] = append(grouped[key], selection)
+		case *InlineFragment:
+			e.collectFields(object, selection.SelectionSet, grouped, result)
+		case *FragmentSpread:
+			e.collectFields(object, e.doc.Fragments[selection.Name].SelectionSet, grouped, result)
+		}
+	}
+	return grouped
+}
+
+// included evaluates @skip and @include; a missing or non-boolean condition keeps the selection
+func (e *executor) included(directives []Directive) bool {
+	for _, directive := range directives {
+		condition, ok := e.resolveValue(directive.Arguments["if"]).(bool)
+		if !ok {
+			continue
+		}
+		if directive.Name == "skip" && condition || directive.Name == "include" && !condition {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *executor) coerceArguments(defs map[string]Argument, given map[string]Value) (Args, error) {
+	args := make(Args, len(defs))
+	for name, def := range defs {
+		value := e.resolveValue(given[name])
+		if value == nil {
+			value = def.Default
+		}
+		if value == nil {
+			if def.Required {
+				return nil, fmt.Errorf("argument %q of type %s! is required", name, def.Kind)
+			}
+			continue
+		}
+		coerced, err := def.Kind.coerce(value)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %w", name, err)
+		}
+		args[name] = coerced
+	}
+	return args, nil
+}
+
+// resolveValue substitutes variables in a literal
+func (e *executor) resolveValue(value Value) interface{} {
+	switch value := value.(type) {
+	case Variable:
+		return e.variables[string(value)]
+	case []Value:
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = e.resolveValue(item)
+		}
+		return list
+	case map[string]Value:
+		object := make(map[string]interface{}, len(value))
+		for name, item := range value {
+			object[name] = e.resolveValue(item)
+		}
+		return object
+	default:
+		return value
+	}
+}
+
+func (e *executor) completeValue(
+	ctx context.Context,
+	def *FieldDef,
+	value interface{},
+	selections []Selection,
+	path []interface{},
+) interface{} {
+	if isNil(value) {
+		return nil
+	}
+
+	if def.List {
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			e.fieldError(path, fmt.Errorf("expected a list, got %T", value))
+			return nil
+		}
+		list := make([]interface{}, items.Len())
+		for i := range list {
+			list[i] = e.completeItem(ctx, def, items.Index(i).Interface(), selections, append(path[:len(path):len(path)], i))
+		}
+		return list
+	}
+	return e.completeItem(ctx, def, value, selections, path)
+}
+
+func (e *executor) completeItem(
+	ctx context.Context,
+	def *FieldDef,
+	value interface{},
+	selections []Selection,
+	path []interface{},
+) interface{} {
+	if isNil(value) {
+		return nil
+	}
+	if def.Type == nil {
+		return value
+	}
+	return e.executeSelections(ctx, def.Type, value, selections, path)
+}
+
+// defaultResolve reads a field from a map or from a struct field with a matching json tag
+func defaultResolve(source interface{}, name string) (interface{}, error) {
+	if m, ok := source.(map[string]interface{}); ok {
+		return m[name], nil
+	}
+
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot resolve field %q on %T", name, source)
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if tag == name || (tag == "" && strings.EqualFold(t.Field(i).Name, name)) {
+			return v.Field(i).Interface(), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot resolve field %q on %T", name, source)
+}
+
+func isNil(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// orderedMap renders fields in the order they were selected, as the spec requires
+type orderedMap struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range m.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(m.values[key])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,196 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEpoch struct {
+	Number string `json:"number"`
+	Status string `json:"status"`
+}
+
+func newTestSchema() *Schema {
+	epochType := &Object{Name: "Epoch", Fields: map[string]*FieldDef{
+		"number": {},
+		"status": {},
+		"broken": {Resolve: func(context.Context, interface{}, Args) (interface{}, error) {
+			return nil, errors.New("boom")
+		}},
+	}}
+	epochs := []testEpoch{{Number: "3", Status: "active"}, {Number: "2", Status: "completed"}, {Number: "1", Status: "completed"}}
+
+	return &Schema{MaxFields: 20, Query: &Object{Name: "Query", Fields: map[string]*FieldDef{
+		"epochs": {
+			Type: epochType,
+			List: true,
+			Args: map[string]Argument{"limit": {Kind: Int, Default: int64(2)}},
+			Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				limit, _ := args.Int("limit")
+				return epochs[:min(int(limit), len(epochs))], nil
+			},
+		},
+		"epoch": {
+			Type: epochType,
+			Args: map[string]Argument{"number": {Kind: String, Required: true}},
+			Resolve: func(_ context.Context, _ interface{}, args Args) (interface{}, error) {
+				for _, e := range epochs {
+					if e.Number == args.String("number") {
+						return &e, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+		"tags": {List: true, Resolve: func(context.Context, interface{}, Args) (interface{}, error) {
+			return []string{"a", "b"}, nil
+		}},
+	}}}
+}
+
+func execute(t *testing.T, req Request) string {
+	t.Helper()
+	body, err := json.Marshal(newTestSchema().Execute(context.Background(), req))
+	require.NoError(t, err)
+	return string(body)
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{
+			name:     "shorthand_with_default_argument",
+			query:    `{ epochs { number } }`,
+			expected: `{"data":{"epochs":[{"number":"3"},{"number":"2"}]}}`,
+		},
+		{
+			name:     "selection_order_and_aliases",
+			query:    `{ tags latest: epoch(number: "3") { status number __typename } }`,
+			expected: `{"data":{"tags":["a","b"],"latest":{"status":"active","number":"3","__typename":"Epoch"}}}`,
+		},
+		{
+			name:      "variables_and_defaults",
+			query:     `query Q($n: Int = 1, $num: String!) { epochs(limit: $n) { number } epoch(number: $num) { status } }`,
+			variables: map[string]interface{}{"num": "2"},
+			expected:  `{"data":{"epochs":[{"number":"3"}],"epoch":{"status":"completed"}}}`,
+		},
+		{
+			name:      "json_numbers_coerce_to_int",
+			query:     `query($n: Int) { epochs(limit: $n) { number } }`,
+			variables: map[string]interface{}{"n": float64(3)},
+			expected:  `{"data":{"epochs":[{"number":"3"},{"number":"2"},{"number":"1"}]}}`,
+		},
+		{
+			name: "fragments_merge_fields",
+			query: `
+				query { epoch(number: "1") { ...Num ... on Epoch { status } number } }
+				fragment Num on Epoch { number }
+			`,
+			expected: `{"data":{"epoch":{"number":"1","status":"completed"}}}`,
+		},
+		{
+			name:      "skip_and_include",
+			query:     `query($yes: Boolean!) { epoch(number: "1") { number @skip(if: $yes) status @include(if: $yes) } }`,
+			variables: map[string]interface{}{"yes": true},
+			expected:  `{"data":{"epoch":{"status":"completed"}}}`,
+		},
+		{
+			name:      "operation_name_selects_operation",
+			query:     `query A { tags } query B { epoch(number: "9") { number } }`,
+			operation: "B",
+			expected:  `{"data":{"epoch":null}}`,
+		},
+		{
+			name:     "resolver_error_nulls_field_with_path",
+			query:    `{ epochs(limit: 1) { number broken } }`,
+			expected: `{"data":{"epochs":[{"number":"3","broken":null}]},"errors":[{"message":"boom","path":["epochs",0,"broken"]}]}`,
+		},
+		{
+			name:     "invalid_argument_value",
+			query:    `{ epochs(limit: "two") { number } tags }`,
+			expected: `{"data":{"epochs":null,"tags":["a","b"]},"errors":[{"message":"field \"epochs\": argument \"limit\": expected Int, got two","path":["epochs"]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.JSONEq(t, tt.expected, execute(t, Request{Query: tt.query, OperationName: tt.operation, Variables: tt.variables}))
+		})
+	}
+}
+
+func TestExecute_RejectedRequests(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		expected  string
+	}{
+		{name: "empty_query", query: " ", expected: "query cannot be empty"},
+		{name: "syntax_error", query: `{ epochs { number }`, expected: "syntax error: unexpected end of document"},
+		{name: "mutation", query: `mutation { epochs { number } }`, expected: "mutation operations are not supported, this endpoint is read-only"},
+		{name: "unknown_field", query: `{ vaults { id } }`, expected: `cannot query field "vaults" on type "Query"`},
+		{name: "unknown_argument", query: `{ epochs(first: 1) { number } }`, expected: `unknown argument "first" on field "epochs"`},
+		{name: "missing_required_argument", query: `{ epoch { number } }`, expected: `field "epoch" argument "number" of type String! is required`},
+		{name: "object_without_selection", query: `{ epochs }`, expected: `field "epochs" of type "Epoch" must have a selection of subfields`},
+		{name: "scalar_with_selection", query: `{ tags { id } }`, expected: `field "tags" of scalar type cannot have a selection set`},
+		{name: "undefined_variable", query: `{ epochs(limit: $n) { number } }`, expected: "variable $n is not defined"},
+		{name: "missing_required_variable", query: `query($n: String!) { epoch(number: $n) { number } }`, expected: "variable $n of type String! is required"},
+		{name: "ambiguous_operation", query: `query A { tags } query B { tags }`, expected: "operationName is required when the document contains several operations"},
+		{name: "fragment_cycle", query: `{ epochs { ...A } } fragment A on Epoch { number ...A }`, expected: `fragment "A" spreads itself`},
+		{name: "fragment_type_mismatch", query: `{ epochs { ...A } } fragment A on Query { tags }`, expected: `fragment "A" on "Query" cannot be spread on type "Epoch"`},
+		{name: "unknown_directive", query: `{ tags @defer }`, expected: "unknown directive @defer"},
+		{
+			name:     "too_many_fields",
+			query:    `{ epochs { ...F } } fragment F on Epoch { a: number b: number c: number d: number e: number f: number g: number h: number i: number j: number k: number l: number m: number n: number o: number p: number q: number r: number s: number t: number }`,
+			expected: "query selects more than 20 fields",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := newTestSchema().Execute(context.Background(), Request{Query: tt.query, Variables: tt.variables})
+			assert.Nil(t, resp.Data)
+			require.NotEmpty(t, resp.Errors)
+			assert.Equal(t, tt.expected, resp.Errors[0].Message)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc, err := Parse(`
+		# comments and commas are ignored
+		query Lookup($ids: [String!]! = ["a", "b"], $n: Int = -1) @cached {
+			first: epoch(number: """3""", filter: {status: ACTIVE, min: 1.5e2}) { number, status }
+		}
+	`)
+	require.NoError(t, err)
+	require.Len(t, doc.Operations, 1)
+
+	operation := doc.Operations[0]
+	assert.Equal(t, "Lookup", operation.Name)
+	require.Len(t, operation.Variables, 2)
+	assert.Equal(t, VariableDefinition{Name: "ids", Type: "[String!]", Required: true, Default: []Value{"a", "b"}}, operation.Variables[0])
+	assert.Equal(t, int64(-1), operation.Variables[1].Default)
+
+	field := operation.SelectionSet[0].(*Field)
+	assert.Equal(t, "first", field.ResponseKey())
+	assert.Equal(t, "3", field.Arguments["number"])
+	assert.Equal(t, map[string]Value{"status": Enum("ACTIVE"), "min": 150.0}, field.Arguments["filter"])
+	assert.Len(t, field.SelectionSet, 2)
+
+	for _, src := range []string{`{}`, `{ a(x: ) }`, `query ($x: Int = $y) { a }`, `{ a "unterminated }`, `fragment on on X { a }`} {
+		_, err := Parse(src)
+		assert.Error(t, err, src)
+	}
+}
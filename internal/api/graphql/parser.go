@@ -0,0 +1,581 @@
+package graphql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Document is a parsed GraphQL request document
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*Fragment
+}
+
+// Operation is a query, mutation or subscription definition
+type Operation struct {
+	Type         string
+	Name         string
+	Variables    []VariableDefinition
+	SelectionSet []Selection
+}
+
+// VariableDefinition declares an operation variable
+type VariableDefinition struct {
+	Name     string
+	Type     string
+	Required bool
+	Default  Value
+}
+
+// Fragment is a named fragment definition
+type Fragment struct {
+	Name          string
+	TypeCondition string
+	SelectionSet  []Selection
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface {
+	directives() []Directive
+}
+
+// Field selects a field, optionally under an alias
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    map[string]Value
+	Directives   []Directive
+	SelectionSet []Selection
+}
+
+// ResponseKey is the key the field is rendered under
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// FragmentSpread includes a named fragment
+type FragmentSpread struct {
+	Name       string
+	Directives []Directive
+}
+
+// InlineFragment includes a selection set, optionally restricted to a type
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []Directive
+	SelectionSet  []Selection
+}
+
+func (f *Field) directives() []Directive          { return f.Directives }
+func (f *FragmentSpread) directives() []Directive { return f.Directives }
+func (f *InlineFragment) directives() []Directive { return f.Directives }
+
+// Directive is an @name(args) annotation
+type Directive struct {
+	Name      string
+	Arguments map[string]Value
+}
+
+// Value is an argument literal; Variable values are resolved during execution
+type Value interface{}
+
+// Variable references an operation variable by name
+type Variable string
+
+// Enum is an unquoted enum literal
+type Enum string
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenPunct
+	tokenName
+	tokenInt
+	tokenFloat
+	tokenString
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+// lex splits the source into tokens, skipping whitespace, commas and comments
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "..."):
+			tokens = append(tokens, token{kind: tokenPunct, value: "...", pos: i})
+			i += 3
+		case strings.ContainsRune("!$():=@[]{}|", rune(c)):
+			tokens = append(tokens, token{kind: tokenPunct, value: string(c), pos: i})
+			i++
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenName, value: src[start:i], pos: start})
+		case c == '-' || isDigit(c):
+			start := i
+			kind := tokenInt
+			i++
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			if i < len(src) && src[i] == '.' {
+				kind = tokenFloat
+				i++
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				kind = tokenFloat
+				i++
+				if i < len(src) && (src[i] == '+' || src[i] == '-') {
+					i++
+				}
+				for i < len(src) && isDigit(src[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, value: src[start:i], pos: start})
+		case c == '"':
+			if strings.HasPrefix(src[i:], `"""`) {
+				end := strings.Index(src[i+3:], `"""`)
+				if end < 0 {
+					return nil, fmt.Errorf("unterminated block string at position %d", i)
+				}
+				tokens = append(tokens, token{kind: tokenString, value: src[i+3 : i+3+end], pos: i})
+				i += end + 6
+				continue
+			}
+			start := i
+			i++
+			for i < len(src) && src[i] != '"' && src[i] != '\n' {
+				if src[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(src) || src[i] != '"' {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			i++
+			value, err := strconv.Unquote(src[start:i])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string at position %d", start)
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+func isLetter(c byte) bool { return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// Parse parses an executable GraphQL document
+func Parse(src string) (*Document, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	doc := &Document{Fragments: make(map[string]*Fragment)}
+
+	for p.peek().kind != tokenEOF {
+		switch {
+		case p.peekPunct("{"):
+			selections, err := p.parseSelectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", SelectionSet: selections})
+		case p.peekName("fragment"):
+			fragment, err := p.parseFragment()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, fmt.Errorf("fragment %q is defined more than once", fragment.Name)
+			}
+			doc.Fragments[fragment.Name] = fragment
+		case p.peekName("query"), p.peekName("mutation"), p.peekName("subscription"):
+			operation, err := p.parseOperation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		default:
+			return nil, p.unexpected()
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, fmt.Errorf("document contains no operations")
+	}
+	return doc, nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) peekPunct(value string) bool {
+	t := p.peek()
+	return t.kind == tokenPunct && t.value == value
+}
+
+func (p *parser) peekName(value string) bool {
+	t := p.peek()
+	return t.kind == tokenName && t.value == value
+}
+
+func (p *parser) skipPunct(value string) bool {
+	if p.peekPunct(value) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expectPunct(value string) error {
+	if !p.skipPunct(value) {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) expectName() (string, error) {
+	if p.peek().kind != tokenName {
+		return "", p.unexpected()
+	}
+	return p.next().value, nil
+}
+
+func (p *parser) unexpected() error {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of document")
+	}
+	return fmt.Errorf("unexpected %q at position %d", t.value, t.pos)
+}
+
+func (p *parser) parseOperation() (*Operation, error) {
+	operation := &Operation{Type: p.next().value}
+	if p.peek().kind == tokenName {
+		operation.Name = p.next().value
+	}
+
+	if p.skipPunct("(") {
+		for !p.skipPunct(")") {
+			variable, err := p.parseVariableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			operation.Variables = append(operation.Variables, variable)
+		}
+	}
+
+	// operation directives have no effect on execution
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	operation.SelectionSet = selections
+	return operation, nil
+}
+
+func (p *parser) parseVariableDefinition() (VariableDefinition, error) {
+	if err := p.expectPunct("$"); err != nil {
+		return VariableDefinition{}, err
+	}
+	name, err := p.expectName()
+	if err != nil {
+		return VariableDefinition{}, err
+	}
+	if err := p.expectPunct(":"); err != nil {
+		return VariableDefinition{}, err
+	}
+
+	variable := VariableDefinition{Name: name}
+	variable.Type, variable.Required, err = p.parseType()
+	if err != nil {
+		return VariableDefinition{}, err
+	}
+	if p.skipPunct("=") {
+		if variable.Default, err = p.parseValue(true); err != nil {
+			return VariableDefinition{}, err
+		}
+	}
+	return variable, nil
+}
+
+// parseType returns the type as written and whether it is non-null
+func (p *parser) parseType() (string, bool, error) {
+	var typeName string
+	if p.skipPunct("[") {
+		inner, required, err := p.parseType()
+		if err != nil {
+			return "", false, err
+		}
+		if err := p.expectPunct("]"); err != nil {
+			return "", false, err
+		}
+		if required {
+			inner += "!"
+		}
+		typeName = "[" + inner + "]"
+	} else {
+		name, err := p.expectName()
+		if err != nil {
+			return "", false, err
+		}
+		typeName = name
+	}
+	return typeName, p.skipPunct("!"), nil
+}
+
+func (p *parser) parseFragment() (*Fragment, error) {
+	p.next()
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if name == "on" {
+		return nil, fmt.Errorf("fragment cannot be named \"on\"")
+	}
+	if !p.peekName("on") {
+		return nil, p.unexpected()
+	}
+	p.next()
+	typeCondition, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	selections, err := p.parseSelectionSet()
+	if err != nil {
+		return nil, err
+	}
+	return &Fragment{Name: name, TypeCondition: typeCondition, SelectionSet: selections}, nil
+}
+
+func (p *parser) parseSelectionSet() ([]Selection, error) {
+	if err := p.expectPunct("{"); err != nil {
+		return nil, err
+	}
+	var selections []Selection
+	for !p.skipPunct("}") {
+		selection, err := p.parseSelection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, fmt.Errorf("selection set cannot be empty")
+	}
+	return selections, nil
+}
+
+func (p *parser) parseSelection() (Selection, error) {
+	if p.skipPunct("...") {
+		if p.peek().kind == tokenName && !p.peekName("on") {
+			spread := &FragmentSpread{Name: p.next().value}
+			var err error
+			spread.Directives, err = p.parseDirectives()
+			return spread, err
+		}
+
+		fragment := &InlineFragment{}
+		if p.peekName("on") {
+			p.next()
+			typeCondition, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			fragment.TypeCondition = typeCondition
+		}
+		var err error
+		if fragment.Directives, err = p.parseDirectives(); err != nil {
+			return nil, err
+		}
+		if fragment.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+		return fragment, nil
+	}
+
+	name, err := p.expectName()
+	if err != nil {
+		return nil, err
+	}
+	field := &Field{Name: name}
+	if p.skipPunct(":") {
+		field.Alias = name
+		if field.Name, err = p.expectName(); err != nil {
+			return nil, err
+		}
+	}
+	if field.Arguments, err = p.parseArguments(); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.parseDirectives(); err != nil {
+		return nil, err
+	}
+	if p.peekPunct("{") {
+		if field.SelectionSet, err = p.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) parseArguments() (map[string]Value, error) {
+	if !p.skipPunct("(") {
+		return nil, nil
+	}
+	args := make(map[string]Value)
+	for !p.skipPunct(")") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectPunct(":"); err != nil {
+			return nil, err
+		}
+		if _, ok := args[name]; ok {
+			return nil, fmt.Errorf("argument %q is given more than once", name)
+		}
+		if args[name], err = p.parseValue(false); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
+
+func (p *parser) parseDirectives() ([]Directive, error) {
+	var directives []Directive
+	for p.skipPunct("@") {
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, Directive{Name: name, Arguments: args})
+	}
+	return directives, nil
+}
+
+// parseValue parses a literal; constant values, such as variable defaults, cannot reference variables
+func (p *parser) parseValue(constant bool) (Value, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenInt:
+		p.next()
+		n, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q at position %d", t.value, t.pos)
+		}
+		return n, nil
+	case tokenFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float %q at position %d", t.value, t.pos)
+		}
+		return f, nil
+	case tokenString:
+		p.next()
+		return t.value, nil
+	case tokenName:
+		p.next()
+		switch t.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return Enum(t.value), nil
+		}
+	}
+
+	switch {
+	case p.skipPunct("$"):
+		if constant {
+			return nil, fmt.Errorf("variable not allowed at position %d", t.pos)
+		}
+		name, err := p.expectName()
+		if err != nil {
+			return nil, err
+		}
+		return Variable(name), nil
+	case p.skipPunct("["):
+		list := []Value{}
+		for !p.skipPunct("]") {
+			item, err := p.parseValue(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case p.skipPunct("{"):
+		object := map[string]Value{}
+		for !p.skipPunct("}") {
+			name, err := p.expectName()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expectPunct(":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.parseValue(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, nil
+	}
+	return nil, p.unexpected()
+}
@@ -0,0 +1,122 @@
+// Package graphql implements the subset of GraphQL needed to serve read-only queries:
+// operations with variables, aliases, arguments, fragments and the @skip/@include directives.
+// Every field is nullable, so a failing resolver nulls its field and reports an error with its path.
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Schema describes the queryable types and the root query object
+type Schema struct {
+	Query *Object
+	// MaxFields caps the number of fields a single request may select, including fragment expansions.
+	// Zero means no limit.
+	MaxFields int
+}
+
+// Object is a type with named fields
+type Object struct {
+	Name   string
+	Fields map[string]*FieldDef
+}
+
+// FieldDef describes a field of an object.
+// Type is nil for scalar fields, which are rendered as returned by the resolver.
+type FieldDef struct {
+	Type    *Object
+	List    bool
+	Args    map[string]Argument
+	Resolve ResolveFunc
+}
+
+// ResolveFunc resolves a field of source.
+// When a field has no resolver its value is read from source by json tag or map key.
+type ResolveFunc func(ctx context.Context, source interface{}, args Args) (interface{}, error)
+
+// ArgKind is the scalar type of an argument
+type ArgKind int
+
+const (
+	String ArgKind = iota
+	Int
+	Boolean
+)
+
+func (k ArgKind) String() string {
+	switch k {
+	case Int:
+		return "Int"
+	case Boolean:
+		return "Boolean"
+	default:
+		return "String"
+	}
+}
+
+// Argument describes a field argument
+type Argument struct {
+	Kind     ArgKind
+	Required bool
+	Default  interface{}
+}
+
+// Args holds coerced argument values; optional arguments without a value or default are absent
+type Args map[string]interface{}
+
+// String returns the named string argument, or "" if absent
+func (a Args) String(name string) string {
+	s, _ := a[name].(string)
+	return s
+}
+
+// Int returns the named integer argument and whether it was given
+func (a Args) Int(name string) (int64, bool) {
+	n, ok := a[name].(int64)
+	return n, ok
+}
+
+// Bool returns the named boolean argument, or false if absent
+func (a Args) Bool(name string) bool {
+	b, _ := a[name].(bool)
+	return b
+}
+
+// coerce converts a literal or variable value to the argument kind
+func (k ArgKind) coerce(value interface{}) (interface{}, error) {
+	switch k {
+	case String:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case Enum:
+			return string(v), nil
+		}
+	case Int:
+		switch v := value.(type) {
+		case int64:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return v, nil
+			}
+		case int:
+			return k.coerce(int64(v))
+		case float64:
+			if v == math.Trunc(v) {
+				return k.coerce(int64(v))
+			}
+		case json.Number:
+			if n, err := strconv.ParseInt(string(v), 10, 64); err == nil {
+				return k.coerce(n)
+			}
+		}
+	case Boolean:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", k, value)
+}
@@ -0,0 +1,98 @@
+package grpcapi
+
+import (
+	"context"
+
+	epochserverv1 "github.com/andrey/epoch-server/api/proto/epochserver/v1"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/go-pkgz/lgr"
+)
+
+// defaultListLimit is how many epochs ListEpochs returns when the request sets no limit
+const defaultListLimit = 100
+
+// epochServer serves EpochService from the epoch service of the tenant a call selects
+type epochServer struct {
+	epochserverv1.UnimplementedEpochServiceServer
+	tenants tenants
+	logger  lgr.L
+}
+
+// ListEpochs returns a page of the epochs known to the subgraph
+func (s *epochServer) ListEpochs(ctx context.Context, req *epochserverv1.ListEpochsRequest) (*epochserverv1.ListEpochsResponse, error) {
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limit := int(req.GetLimit())
+	if limit == 0 {
+		limit = defaultListLimit
+	}
+	response, err := backend.Epoch.ListEpochs(ctx, epoch.ListEpochsQuery{
+		Limit:     limit,
+		Offset:    int(req.GetOffset()),
+		Ascending: req.GetAscending(),
+	})
+	if err != nil {
+		return nil, toStatus(err, "failed to list epochs")
+	}
+
+	epochs := make([]*epochserverv1.Epoch, len(response.Epochs))
+	for i, summary := range response.Epochs {
+		epochs[i] = &epochserverv1.Epoch{
+			EpochNumber:                  summary.EpochNumber,
+			Status:                       summary.Status,
+			StartTimestamp:               summary.StartTimestamp,
+			EndTimestamp:                 summary.EndTimestamp,
+			ProcessingCompletedTimestamp: summary.ProcessingCompletedTimestamp,
+			TotalSubsidiesDistributed:    summary.TotalSubsidiesDistributed,
+			TotalYieldDistributed:        summary.TotalYieldDistributed,
+			YieldAllocated:               summary.YieldAllocated,
+			YieldHeldBack:                summary.YieldHeldBack,
+			DistributionFingerprint:      summary.DistributionFingerprint,
+		}
+	}
+	return &epochserverv1.ListEpochsResponse{Epochs: epochs, Total: int32(response.Total)}, nil
+}
+
+// GetCurrentEpoch returns the current epoch ID from the EpochManager
+func (s *epochServer) GetCurrentEpoch(ctx context.Context, _ *epochserverv1.GetCurrentEpochRequest) (*epochserverv1.GetCurrentEpochResponse, error) {
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	epochID, err := backend.Epoch.GetCurrentEpochId(ctx)
+	if err != nil {
+		return nil, toStatus(err, "failed to get current epoch")
+	}
+	return &epochserverv1.GetCurrentEpochResponse{EpochId: epochID}, nil
+}
+
+// GetUserTotalEarned returns the subsidies a user earned so far in a vault
+func (s *epochServer) GetUserTotalEarned(ctx context.Context, req *epochserverv1.GetUserTotalEarnedRequest) (*epochserverv1.UserEarnings, error) {
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userAddress, err := utils.ValidateAndNormalizeAddress(req.GetUserAddress())
+	if err != nil {
+		return nil, toStatus(epoch.ErrInvalidInput, "missing or invalid user address")
+	}
+	vaultAddress, err := backend.vault(req.GetVaultAddress())
+	if err != nil {
+		return nil, err
+	}
+
+	earnings, err := backend.Epoch.GetUserTotalEarned(ctx, userAddress, vaultAddress)
+	if err != nil {
+		return nil, toStatus(err, "failed to get user total earned")
+	}
+	return &epochserverv1.UserEarnings{
+		UserAddress:   earnings.UserAddress,
+		VaultAddress:  earnings.VaultAddress,
+		TotalEarned:   earnings.TotalEarned,
+		CalculatedAt:  earnings.CalculatedAt,
+		DataTimestamp: earnings.DataTimestamp,
+	}, nil
+}
@@ -0,0 +1,30 @@
+package grpcapi
+
+import (
+	"errors"
+
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// toStatus maps a service error to the gRPC status the REST API's status code corresponds to
+func toStatus(err error, message string) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, epoch.ErrInvalidInput), errors.Is(err, subsidy.ErrInvalidInput), errors.Is(err, merkle.ErrInvalidInput):
+		code = codes.InvalidArgument
+	case errors.Is(err, epoch.ErrNotFound), errors.Is(err, subsidy.ErrNotFound), errors.Is(err, merkle.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, epoch.ErrTimeout), errors.Is(err, subsidy.ErrTimeout):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, epoch.ErrEpochNotActive), errors.Is(err, subsidy.ErrInsufficientYield),
+		errors.Is(err, merkle.ErrRootMismatch), errors.Is(err, merkle.ErrClaimUnavailable):
+		code = codes.FailedPrecondition
+	case errors.Is(err, subsidy.ErrSnapshotStale), errors.Is(err, subsidy.ErrSubgraphLagging):
+		code = codes.Unavailable
+	}
+	return status.Errorf(code, "%s: %v", message, err)
+}
@@ -0,0 +1,138 @@
+package grpcapi
+
+import (
+	"context"
+
+	epochserverv1 "github.com/andrey/epoch-server/api/proto/epochserver/v1"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/go-pkgz/lgr"
+	"google.golang.org/grpc"
+)
+
+// merkleServer serves MerkleService from the merkle service of the tenant a call selects
+type merkleServer struct {
+	epochserverv1.UnimplementedMerkleServiceServer
+	tenants tenants
+	logger  lgr.L
+}
+
+// GetProof returns a user's proof in the latest distribution, in an epoch's, or against a root pushed for an epoch
+func (s *merkleServer) GetProof(ctx context.Context, req *epochserverv1.GetProofRequest) (*epochserverv1.MerkleProof, error) {
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userAddress, err := utils.ValidateAndNormalizeAddress(req.GetUserAddress())
+	if err != nil {
+		return nil, toStatus(merkle.ErrInvalidInput, "missing or invalid user address")
+	}
+	vaultAddress, err := backend.vault(req.GetVaultAddress())
+	if err != nil {
+		return nil, err
+	}
+	epochNumber, merkleRoot := req.GetEpochNumber(), req.GetMerkleRoot()
+	if merkleRoot != "" && epochNumber == "" {
+		return nil, toStatus(merkle.ErrInvalidInput, "merkle root requires an epoch")
+	}
+
+	var proof *merkle.UserMerkleProofResponse
+	switch {
+	case epochNumber == "":
+		proof, err = backend.Merkle.GenerateUserMerkleProof(ctx, userAddress, vaultAddress)
+	case merkleRoot == "":
+		proof, err = backend.Merkle.GenerateHistoricalMerkleProof(ctx, userAddress, vaultAddress, epochNumber)
+	default:
+		proof, err = backend.Merkle.GenerateMerkleProofForRoot(ctx, userAddress, vaultAddress, epochNumber, merkleRoot)
+	}
+	if err != nil {
+		return nil, toStatus(err, "failed to generate merkle proof")
+	}
+	return &epochserverv1.MerkleProof{
+		UserAddress:  proof.UserAddress,
+		VaultAddress: proof.VaultAddress,
+		EpochNumber:  proof.EpochNumber,
+		TotalEarned:  proof.TotalEarned,
+		MerkleProof:  proof.MerkleProof,
+		MerkleRoot:   proof.MerkleRoot,
+		LeafIndex:    int64(proof.LeafIndex),
+		GeneratedAt:  proof.GeneratedAt,
+	}, nil
+}
+
+// VerifyProofs checks proofs against the roots stored for their vaults
+func (s *merkleServer) VerifyProofs(ctx context.Context, req *epochserverv1.VerifyProofsRequest) (*epochserverv1.VerifyProofsResponse, error) {
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return nil, err
+	}
+	proofs := make([]merkle.ProofToVerify, len(req.GetProofs()))
+	for i, proof := range req.GetProofs() {
+		proofs[i] = merkle.ProofToVerify{
+			VaultAddress: proof.GetVaultAddress(),
+			Recipient:    proof.GetRecipient(),
+			TotalEarned:  proof.GetTotalEarned(),
+			MerkleProof:  proof.GetMerkleProof(),
+		}
+	}
+
+	verifications, err := backend.Merkle.VerifyProofs(ctx, proofs, req.GetOnChain())
+	if err != nil {
+		return nil, toStatus(err, "failed to verify proofs")
+	}
+	response := &epochserverv1.VerifyProofsResponse{
+		Results:    make([]*epochserverv1.ProofVerification, len(verifications.Results)),
+		Valid:      int32(verifications.Valid),
+		Invalid:    int32(verifications.Invalid),
+		VerifiedAt: verifications.VerifiedAt,
+	}
+	for i, result := range verifications.Results {
+		response.Results[i] = &epochserverv1.ProofVerification{
+			VaultAddress:   result.VaultAddress,
+			Recipient:      result.Recipient,
+			TotalEarned:    result.TotalEarned,
+			Valid:          result.Valid,
+			MerkleRoot:     result.MerkleRoot,
+			EpochNumber:    result.EpochNumber,
+			LeafEncoding:   result.LeafEncoding,
+			OnChainChecked: result.OnChain != nil,
+			OnChain:        result.OnChain != nil && *result.OnChain,
+			Reason:         result.Reason,
+		}
+	}
+	return response, nil
+}
+
+// StreamRootUpdates streams every merkle root pushed for a vault, oldest first
+func (s *merkleServer) StreamRootUpdates(req *epochserverv1.StreamRootUpdatesRequest, stream grpc.ServerStreamingServer[epochserverv1.RootUpdate]) error {
+	ctx := stream.Context()
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return err
+	}
+	vaultAddress, err := backend.vault(req.GetVaultAddress())
+	if err != nil {
+		return err
+	}
+
+	updates, err := backend.Merkle.ListRootUpdates(ctx, vaultAddress)
+	if err != nil {
+		return toStatus(err, "failed to list merkle root updates")
+	}
+	for _, update := range updates {
+		if err := stream.Send(&epochserverv1.RootUpdate{
+			VaultAddress:   update.VaultAddress,
+			MerkleRoot:     update.MerkleRoot,
+			EpochNumber:    update.EpochNumber,
+			TotalSubsidies: update.TotalSubsidies,
+			UpdatedBy:      update.UpdatedBy,
+			TxHash:         update.TxHash,
+			BlockNumber:    update.BlockNumber,
+			BlockHash:      update.BlockHash,
+			LogIndex:       uint32(update.LogIndex),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package grpcapi
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	epochserverv1 "github.com/andrey/epoch-server/api/proto/epochserver/v1"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/go-pkgz/lgr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// TenantKey is the metadata key selecting the tenant of a call in multi-tenant mode
+	TenantKey = "x-tenant"
+
+	// requestIDKey is the metadata key carrying the caller's request ID, generated when it sends none
+	requestIDKey = "x-request-id"
+)
+
+// Backend is what one tenant serves over gRPC
+type Backend struct {
+	Epoch     epoch.Service
+	Subsidy   subsidy.Service
+	Merkle    merkle.Service
+	Snapshots epoch.SnapshotStore
+	Config    *config.Config
+}
+
+// tenants resolves the backend a call is served by
+type tenants map[string]Backend
+
+// NewServer creates a gRPC server of the epoch, subsidy and merkle services of every tenant, keyed by tenant
+// name. A single deployment is keyed by the empty name and serves calls that select no tenant.
+func NewServer(backends map[string]Backend, logger lgr.L) *grpc.Server {
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryInterceptor(logger)),
+		grpc.ChainStreamInterceptor(streamInterceptor(logger)),
+	)
+	t := tenants(backends)
+	epochserverv1.RegisterEpochServiceServer(server, &epochServer{tenants: t, logger: logger})
+	epochserverv1.RegisterSubsidyServiceServer(server, &subsidyServer{tenants: t, logger: logger})
+	epochserverv1.RegisterMerkleServiceServer(server, &merkleServer{tenants: t, logger: logger})
+	// lets grpcurl and other tools discover the services
+	reflection.Register(server)
+	return server
+}
+
+// Serve listens on the configured host and gRPC port and serves server until it is stopped
+func Serve(server *grpc.Server, cfg *config.Config, logger lgr.L) error {
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.GRPCPort)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	logger.Logf("INFO starting gRPC server on %s", addr)
+	return server.Serve(listener)
+}
+
+// backend returns the backend of the tenant the call selected with TenantKey
+func (t tenants) backend(ctx context.Context) (Backend, error) {
+	var tenant string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(TenantKey); len(values) > 0 {
+			tenant = strings.ToLower(values[0])
+		}
+	}
+	if tenant == "" {
+		if backend, ok := t[""]; ok {
+			return backend, nil
+		}
+		return Backend{}, status.Errorf(codes.InvalidArgument, "tenant required, set the %s metadata key", TenantKey)
+	}
+	backend, ok := t[tenant]
+	if !ok {
+		return Backend{}, status.Errorf(codes.NotFound, "unknown tenant %q", tenant)
+	}
+	return backend, nil
+}
+
+// withRequestID returns ctx carrying the request ID the caller sent, or a new one, for log correlation
+func withRequestID(ctx context.Context) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDKey); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	return logging.WithFields(ctx, logging.Fields{RequestID: requestID})
+}
+
+// unaryInterceptor logs every call with its code and duration, and turns a panic into an Internal error
+func unaryInterceptor(logger lgr.L) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		ctx = withRequestID(ctx)
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx, logger).Logf("ERROR panic in %s: %v", info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logging.FromContext(ctx, logger).Logf("INFO gRPC %s %s %v", info.FullMethod, status.Code(err), time.Since(start))
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// streamInterceptor logs and recovers streaming calls as unaryInterceptor does unary ones
+func streamInterceptor(logger lgr.L) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := withRequestID(stream.Context())
+		start := time.Now()
+		defer func() {
+			if r := recover(); r != nil {
+				logging.FromContext(ctx, logger).Logf("ERROR panic in %s: %v", info.FullMethod, r)
+				err = status.Error(codes.Internal, "internal error")
+			}
+			logging.FromContext(ctx, logger).Logf("INFO gRPC %s %s %v", info.FullMethod, status.Code(err), time.Since(start))
+		}()
+		return handler(srv, &contextStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// contextStream is a server stream whose context carries the request ID
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// vault returns the normalized vault address a request names, the backend's vault when it names none
+func (b Backend) vault(address string) (string, error) {
+	if address == "" {
+		return b.Config.Contracts.CollectionsVault, nil
+	}
+	normalized, err := utils.ValidateAndNormalizeAddress(address)
+	if err != nil {
+		return "", status.Errorf(codes.InvalidArgument, "invalid vault address format: %v", err)
+	}
+	return normalized, nil
+}
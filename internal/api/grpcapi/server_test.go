@@ -0,0 +1,215 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	epochserverv1 "github.com/andrey/epoch-server/api/proto/epochserver/v1"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const (
+	testVault = "0x1111111111111111111111111111111111111111"
+	testUser  = "0x742d35cc6634c0532925a3b844bc454e4438f44e"
+)
+
+// snapshotsFunc serves snapshots from a func
+type snapshotsFunc func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error)
+
+func (f snapshotsFunc) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return f(ctx, epochNumber, vaultID)
+}
+
+// newTestBackend returns a backend of mocks serving testVault
+func newTestBackend() Backend {
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = testVault
+	return Backend{
+		Epoch:   &epoch.ServiceMock{},
+		Subsidy: &subsidy.ServiceMock{},
+		Merkle:  &merkle.ServiceMock{},
+		Snapshots: snapshotsFunc(func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+			return nil, fmt.Errorf("%w: no snapshot", merkle.ErrNotFound)
+		}),
+		Config: cfg,
+	}
+}
+
+// dial serves the backends on an in-memory listener and returns a connection to them
+func dial(t *testing.T, backends map[string]Backend) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(backends, lgr.NoOp)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestEpochServer_ListEpochs(t *testing.T) {
+	backend := newTestBackend()
+	epochService := backend.Epoch.(*epoch.ServiceMock)
+	epochService.ListEpochsFunc = func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+		if query.Limit > 1000 {
+			return nil, fmt.Errorf("%w: limit %d", epoch.ErrInvalidInput, query.Limit)
+		}
+		return &epoch.ListEpochsResponse{
+			Epochs: []epoch.EpochSummary{{EpochNumber: "5", Status: "COMPLETED", DistributionFingerprint: "0xabc"}},
+			Count:  1,
+			Total:  7,
+		}, nil
+	}
+	client := epochserverv1.NewEpochServiceClient(dial(t, map[string]Backend{"": backend}))
+
+	response, err := client.ListEpochs(context.Background(), &epochserverv1.ListEpochsRequest{Offset: 2, Ascending: true})
+	require.NoError(t, err)
+	require.Len(t, response.GetEpochs(), 1)
+	assert.Equal(t, "5", response.GetEpochs()[0].GetEpochNumber())
+	assert.Equal(t, "0xabc", response.GetEpochs()[0].GetDistributionFingerprint())
+	assert.Equal(t, int32(7), response.GetTotal())
+	query := epochService.ListEpochsCalls()[0].Query
+	assert.Equal(t, epoch.ListEpochsQuery{Limit: defaultListLimit, Offset: 2, Ascending: true}, query, "no limit is the default")
+
+	_, err = client.ListEpochs(context.Background(), &epochserverv1.ListEpochsRequest{Limit: 5000})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestEpochServer_GetUserTotalEarned(t *testing.T) {
+	backend := newTestBackend()
+	backend.Epoch.(*epoch.ServiceMock).GetUserTotalEarnedFunc = func(ctx context.Context, userAddress, vaultId string) (*epoch.UserEarningsResponse, error) {
+		if vaultId != testVault {
+			return nil, fmt.Errorf("%w: vault %s", epoch.ErrNotFound, vaultId)
+		}
+		return &epoch.UserEarningsResponse{UserAddress: userAddress, VaultAddress: vaultId, TotalEarned: "1000"}, nil
+	}
+	client := epochserverv1.NewEpochServiceClient(dial(t, map[string]Backend{"": backend}))
+
+	earnings, err := client.GetUserTotalEarned(context.Background(), &epochserverv1.GetUserTotalEarnedRequest{UserAddress: testUser})
+	require.NoError(t, err)
+	assert.Equal(t, testVault, earnings.GetVaultAddress(), "the server's vault when none is named")
+	assert.Equal(t, "1000", earnings.GetTotalEarned())
+
+	_, err = client.GetUserTotalEarned(context.Background(), &epochserverv1.GetUserTotalEarnedRequest{UserAddress: "bad"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = client.GetUserTotalEarned(context.Background(), &epochserverv1.GetUserTotalEarnedRequest{
+		UserAddress:  testUser,
+		VaultAddress: "0x2222222222222222222222222222222222222222",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestSubsidyServer_StreamAllocations(t *testing.T) {
+	backend := newTestBackend()
+	backend.Snapshots = snapshotsFunc(func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+		if epochNumber.Int64() != 5 {
+			return nil, fmt.Errorf("%w: no snapshot for epoch %s", merkle.ErrNotFound, epochNumber)
+		}
+		return &merkle.MerkleSnapshot{Entries: []merkle.MerkleEntry{
+			{Address: testUser, TotalEarned: big.NewInt(1000)},
+			{Address: testVault, TotalEarned: big.NewInt(500)},
+		}}, nil
+	})
+	client := epochserverv1.NewSubsidyServiceClient(dial(t, map[string]Backend{"": backend}))
+
+	stream, err := client.StreamAllocations(context.Background(), &epochserverv1.StreamAllocationsRequest{EpochNumber: "5"})
+	require.NoError(t, err)
+	var allocations []*epochserverv1.Allocation
+	for {
+		allocation, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		allocations = append(allocations, allocation)
+	}
+	require.Len(t, allocations, 2)
+	assert.Equal(t, testUser, allocations[0].GetAccount())
+	assert.Equal(t, "500", allocations[1].GetTotalEarned())
+
+	for epochNumber, code := range map[string]codes.Code{"6": codes.NotFound, "x": codes.InvalidArgument} {
+		stream, err := client.StreamAllocations(context.Background(), &epochserverv1.StreamAllocationsRequest{EpochNumber: epochNumber})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		assert.Equal(t, code, status.Code(err), "epoch %s", epochNumber)
+	}
+}
+
+func TestMerkleServer_VerifyProofs(t *testing.T) {
+	backend := newTestBackend()
+	onChain := false
+	backend.Merkle.(*merkle.ServiceMock).VerifyProofsFunc = func(ctx context.Context, proofs []merkle.ProofToVerify, checkOnChain bool) (*merkle.ProofVerifications, error) {
+		return &merkle.ProofVerifications{
+			Results: []merkle.ProofVerification{
+				{Recipient: proofs[0].Recipient, Valid: true},
+				{Recipient: proofs[1].Recipient, OnChain: &onChain, Reason: "not on-chain"},
+			},
+			Valid:   1,
+			Invalid: 1,
+		}, nil
+	}
+	client := epochserverv1.NewMerkleServiceClient(dial(t, map[string]Backend{"": backend}))
+
+	response, err := client.VerifyProofs(context.Background(), &epochserverv1.VerifyProofsRequest{
+		Proofs:  []*epochserverv1.ProofToVerify{{Recipient: "a"}, {Recipient: "b"}},
+		OnChain: true,
+	})
+	require.NoError(t, err)
+	require.Len(t, response.GetResults(), 2)
+	assert.False(t, response.GetResults()[0].GetOnChainChecked())
+	assert.True(t, response.GetResults()[1].GetOnChainChecked())
+	assert.False(t, response.GetResults()[1].GetOnChain())
+	assert.Equal(t, "b", response.GetResults()[1].GetRecipient())
+	assert.True(t, backend.Merkle.(*merkle.ServiceMock).VerifyProofsCalls()[0].OnChain)
+}
+
+func TestNewServer_Tenants(t *testing.T) {
+	mainnet, base := newTestBackend(), newTestBackend()
+	mainnet.Epoch.(*epoch.ServiceMock).GetCurrentEpochIdFunc = func(ctx context.Context) (uint64, error) { return 5, nil }
+	base.Epoch.(*epoch.ServiceMock).GetCurrentEpochIdFunc = func(ctx context.Context) (uint64, error) { return 9, nil }
+	client := epochserverv1.NewEpochServiceClient(dial(t, map[string]Backend{"mainnet": mainnet, "base": base}))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), TenantKey, "Base")
+	response, err := client.GetCurrentEpoch(ctx, &epochserverv1.GetCurrentEpochRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(9), response.GetEpochId())
+
+	_, err = client.GetCurrentEpoch(context.Background(), &epochserverv1.GetCurrentEpochRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err), "a tenant is required")
+	ctx = metadata.AppendToOutgoingContext(context.Background(), TenantKey, "sepolia")
+	_, err = client.GetCurrentEpoch(ctx, &epochserverv1.GetCurrentEpochRequest{})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestNewServer_RecoversPanics(t *testing.T) {
+	backend := newTestBackend()
+	backend.Epoch.(*epoch.ServiceMock).GetCurrentEpochIdFunc = func(ctx context.Context) (uint64, error) { panic("boom") }
+	client := epochserverv1.NewEpochServiceClient(dial(t, map[string]Backend{"": backend}))
+
+	_, err := client.GetCurrentEpoch(context.Background(), &epochserverv1.GetCurrentEpochRequest{})
+	assert.Equal(t, codes.Internal, status.Code(err))
+}
@@ -0,0 +1,85 @@
+package grpcapi
+
+import (
+	"math/big"
+
+	epochserverv1 "github.com/andrey/epoch-server/api/proto/epochserver/v1"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/go-pkgz/lgr"
+	"google.golang.org/grpc"
+)
+
+// subsidyServer serves SubsidyService from the distributions of the tenant a call selects
+type subsidyServer struct {
+	epochserverv1.UnimplementedSubsidyServiceServer
+	tenants tenants
+	logger  lgr.L
+}
+
+// StreamAllocations streams every account's cumulative amount in the merkle tree of an epoch's distribution,
+// one message per leaf, so large trees are never encoded into a single response
+func (s *subsidyServer) StreamAllocations(req *epochserverv1.StreamAllocationsRequest, stream grpc.ServerStreamingServer[epochserverv1.Allocation]) error {
+	ctx := stream.Context()
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return err
+	}
+	vaultAddress, err := backend.vault(req.GetVaultAddress())
+	if err != nil {
+		return err
+	}
+	epochNumber, ok := new(big.Int).SetString(req.GetEpochNumber(), 10)
+	if !ok || epochNumber.Sign() < 0 {
+		return toStatus(subsidy.ErrInvalidInput, "invalid epoch number")
+	}
+
+	snapshot, err := backend.Snapshots.GetSnapshot(ctx, epochNumber, vaultAddress)
+	if err != nil {
+		return toStatus(err, "failed to get distribution")
+	}
+	for _, entry := range snapshot.Entries {
+		allocation := &epochserverv1.Allocation{Account: entry.Address}
+		if entry.TotalEarned != nil {
+			allocation.TotalEarned = entry.TotalEarned.String()
+		}
+		if err := stream.Send(allocation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamQuarantinedAccounts streams the account subsidies distributions skipped for malformed subgraph data
+func (s *subsidyServer) StreamQuarantinedAccounts(
+	req *epochserverv1.StreamQuarantinedAccountsRequest,
+	stream grpc.ServerStreamingServer[epochserverv1.QuarantinedAccount],
+) error {
+	ctx := stream.Context()
+	backend, err := s.tenants.backend(ctx)
+	if err != nil {
+		return err
+	}
+	vaultAddress, err := backend.vault(req.GetVaultAddress())
+	if err != nil {
+		return err
+	}
+
+	accounts, err := backend.Subsidy.ListQuarantinedAccounts(ctx, vaultAddress, req.GetEpochNumber())
+	if err != nil {
+		return toStatus(err, "failed to list quarantined accounts")
+	}
+	for _, account := range accounts {
+		if err := stream.Send(&epochserverv1.QuarantinedAccount{
+			VaultAddress:  account.VaultID,
+			EpochNumber:   account.EpochNumber,
+			BlockNumber:   account.BlockNumber,
+			SubsidyId:     account.SubsidyID,
+			Account:       account.Account,
+			Reason:        account.Reason,
+			QuarantinedAt: account.QuarantinedAt.Unix(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/scheduler"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// AdminHandler handles operator HTTP requests
+type AdminHandler struct {
+	pauseService pause.Service
+	trigger      scheduler.Trigger // nil when this replica runs no scheduler
+	logger       lgr.L
+	config       *config.Config
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(pauseService pause.Service, trigger scheduler.Trigger, logger lgr.L, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		pauseService: pauseService,
+		trigger:      trigger,
+		logger:       logger,
+		config:       cfg,
+	}
+}
+
+// SchedulerJobRequest is the optional body of a pause or resume, selecting the job and why it is paused
+type SchedulerJobRequest struct {
+	Job    string `json:"job" example:"distribute"` // all, start_epoch, allocate_yield, distribute, catch_up or reconcile; empty means all
+	Reason string `json:"reason,omitempty" example:"contract upgrade"`
+}
+
+// HandleSchedulerStatus handles requests for the scheduler's paused jobs
+// @Summary Get scheduler pause state
+// @Description Lists every scheduler job and whether an operator paused it. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} pause.Status "Pause state of every job"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/scheduler [get]
+func (h *AdminHandler) HandleSchedulerStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.pauseService.Status(r.Context())
+	if err != nil {
+		h.logger.Logf("ERROR failed to get scheduler pause state: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get scheduler pause state")
+		return
+	}
+
+	rest.RenderJSON(w, status)
+}
+
+// HandlePauseScheduler handles pausing scheduler jobs
+// @Summary Pause scheduler jobs
+// @Description Stops a scheduler job, or every job, from running on later cycles until it is resumed, e.g. during
+// @Description a contract upgrade. The pause is stored and survives restarts. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body SchedulerJobRequest false "Job to pause (default all) and reason"
+// @Success 200 {object} pause.JobState "Job paused"
+// @Failure 400 {object} ErrorResponse "Unknown job or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/scheduler/pause [post]
+func (h *AdminHandler) HandlePauseScheduler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeJobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.pauseService.Pause(r.Context(), req.Job, req.Reason)
+	if err != nil {
+		h.logger.Logf("ERROR failed to pause scheduler job %s: %v", req.Job, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to pause scheduler job")
+		return
+	}
+
+	rest.RenderJSON(w, state)
+}
+
+// HandleResumeScheduler handles resuming scheduler jobs
+// @Summary Resume scheduler jobs
+// @Description Lets a paused scheduler job run again from the next cycle; resuming all clears every pause.
+// @Description Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body SchedulerJobRequest false "Job to resume (default all)"
+// @Success 200 {object} pause.JobState "Job resumed"
+// @Failure 400 {object} ErrorResponse "Unknown job or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/scheduler/resume [post]
+func (h *AdminHandler) HandleResumeScheduler(w http.ResponseWriter, r *http.Request) {
+	req, ok := h.decodeJobRequest(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.pauseService.Resume(r.Context(), req.Job)
+	if err != nil {
+		h.logger.Logf("ERROR failed to resume scheduler job %s: %v", req.Job, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to resume scheduler job")
+		return
+	}
+
+	rest.RenderJSON(w, state)
+}
+
+// HandleTriggerBoundary handles running an epoch boundary on demand
+// @Summary Trigger an epoch boundary
+// @Description Queues an epoch boundary, starting the next epoch and distributing subsidies, to run now. This is how
+// @Description epochs advance in manual scheduler mode, and it runs alongside the automatic boundaries in the other
+// @Description modes. The boundary runs in the background and its outcome is logged and audited. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 202 {object} scheduler.BoundaryResult "Boundary queued"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 409 {object} ErrorResponse "Scheduler not running, paused, not the leader, or a boundary is already queued"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/scheduler/trigger [post]
+func (h *AdminHandler) HandleTriggerBoundary(w http.ResponseWriter, r *http.Request) {
+	if h.trigger == nil {
+		writeErrorResponse(w, r, h.logger, scheduler.ErrNotRunning, "Scheduler is not running")
+		return
+	}
+
+	result, err := h.trigger.Trigger(r.Context())
+	if err != nil {
+		h.logger.Logf("WARN failed to trigger epoch boundary: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to trigger epoch boundary")
+		return
+	}
+
+	if err := rest.EncodeJSON(w, http.StatusAccepted, result); err != nil {
+		h.logger.Logf("ERROR failed to encode trigger response: %v", err)
+	}
+}
+
+// decodeJobRequest reads the optional job request, writing an error response when it is malformed
+func (h *AdminHandler) decodeJobRequest(w http.ResponseWriter, r *http.Request) (SchedulerJobRequest, bool) {
+	var req SchedulerJobRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, r, h.logger, pause.ErrInvalidInput, "Invalid request body")
+		return req, false
+	}
+	if req.Job == "" {
+		req.Job = pause.JobAll
+	}
+	return req, true
+}
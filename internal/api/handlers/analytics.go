@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// analyticsCSVHeader is the header row of the CSV export, one column per EpochPoint field
+var analyticsCSVHeader = []string{
+	"epochId", "startTimestamp", "endTimestamp", "yieldGenerated", "subsidiesDistributed", "cumulativeSubsidies",
+	"claimed", "claimRate", "assetsDeposited", "effectiveApy",
+}
+
+// epochReportCSVSummaryHeader, epochReportCSVVaultHeader, epochReportCSVTopHeader and epochReportCSVTransactionHeader
+// are the header rows of the sections of an epoch report's CSV export
+var (
+	epochReportCSVSummaryHeader = []string{
+		"epochId", "generatedAt", "vaults", "participants", "totalSubsidies", "flaggedVaults",
+		"transactions", "gasUsed", "gasCost",
+	}
+	epochReportCSVVaultHeader = []string{
+		"vaultId", "merkleRoot", "snapshotBlock", "accounts", "total", "median", "gini", "dustAccounts",
+		"yieldAllocated", "reconciledSubsidies", "claimed", "discrepancies", "flagged",
+	}
+	epochReportCSVTopHeader         = []string{"vaultId", "rank", "account", "amount", "share"}
+	epochReportCSVTransactionHeader = []string{
+		"txHash", "action", "operation", "blockNumber", "gasUsed", "gasPrice", "cost", "timestamp",
+	}
+)
+
+// AnalyticsHandler handles vault analytics HTTP requests
+type AnalyticsHandler struct {
+	analyticsService analytics.Service
+	logger           lgr.L
+	config           *config.Config
+}
+
+// NewAnalyticsHandler creates a new analytics handler
+func NewAnalyticsHandler(analyticsService analytics.Service, logger lgr.L, cfg *config.Config) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		logger:           logger,
+		config:           cfg,
+	}
+}
+
+// HandleVaultAnalytics handles vault analytics requests
+// @Summary Get vault analytics
+// @Description Returns the vault's time series per reconciled epoch, oldest first: the yield allocated to the
+// @Description epoch, the subsidies its distribution added, the claim rate when it was reconciled and the
+// @Description effective APY NFT holders earned, the epoch's subsidies over the assets deposited in the vault
+// @Description annualized over the epoch's duration. Totals over the series and the claim rate read on-chain
+// @Description now are returned alongside. With format=csv the series is exported as CSV instead.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Param vault path string true "Vault address"
+// @Param from query int false "First epoch included"
+// @Param to query int false "Last epoch included"
+// @Param format query string false "Response format (default json)" Enums(json, csv)
+// @Success 200 {object} analytics.VaultAnalytics "Vault analytics"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault, epoch range or format"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/analytics/vaults/{vault} [get]
+func (h *AnalyticsHandler) HandleVaultAnalytics(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	analyticsQuery := analytics.Query{VaultID: r.PathValue("vault")}
+
+	var err error
+	if analyticsQuery.FromEpoch, err = parseEpochParam(query.Get("from")); err != nil {
+		writeErrorResponse(w, r, h.logger, analytics.ErrInvalidInput, "invalid from parameter, expected an epoch number")
+		return
+	}
+	if analyticsQuery.ToEpoch, err = parseEpochParam(query.Get("to")); err != nil {
+		writeErrorResponse(w, r, h.logger, analytics.ErrInvalidInput, "invalid to parameter, expected an epoch number")
+		return
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeErrorResponse(w, r, h.logger, analytics.ErrInvalidInput, "invalid format parameter, expected json or csv")
+		return
+	}
+
+	result, err := h.analyticsService.VaultAnalytics(r.Context(), analyticsQuery)
+	if err != nil {
+		h.logger.Logf("ERROR failed to build analytics of vault %s: %v", analyticsQuery.VaultID, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to build vault analytics")
+		return
+	}
+
+	if format == "csv" {
+		h.writeAnalyticsCSV(w, result)
+		return
+	}
+	rest.RenderJSON(w, result)
+}
+
+// HandleVaultCollections handles per-collection vault stats requests
+// @Summary Get vault collection stats
+// @Description Returns what the vault records on-chain for every collection the CollectionRegistry registers
+// @Description with it: borrow volume, performance score, yield generated and assets deposited, all read at
+// @Description the same block. The reads are batched through Multicall3 when it is deployed.
+// @Tags analytics
+// @Produce json
+// @Param vault path string true "Vault address"
+// @Success 200 {object} analytics.VaultCollections "Collection stats"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/analytics/vaults/{vault}/collections [get]
+func (h *AnalyticsHandler) HandleVaultCollections(w http.ResponseWriter, r *http.Request) {
+	vault := r.PathValue("vault")
+	result, err := h.analyticsService.VaultCollections(r.Context(), vault)
+	if err != nil {
+		h.logger.Logf("ERROR failed to read collection stats of vault %s: %v", vault, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to read vault collection stats")
+		return
+	}
+	rest.RenderJSON(w, result)
+}
+
+// HandleEpochReport handles epoch report requests
+// @Summary Get epoch report
+// @Description Returns a report of the epoch for stakeholders, built from what the server stored for it: every
+// @Description distributed vault's totals, participants and top allocations, its latest reconciliation and the
+// @Description gas and hashes of the transactions sent for the epoch. With format=csv the report is exported as
+// @Description CSV instead, one section per table separated by an empty row.
+// @Tags analytics
+// @Produce json
+// @Produce text/csv
+// @Param id path string true "Epoch number"
+// @Param top query int false "Top allocations listed per vault (default 10, max 100)"
+// @Param format query string false "Response format (default json)" Enums(json, csv)
+// @Success 200 {object} analytics.EpochReport "Epoch report"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid epoch, top or format"
+// @Failure 404 {object} ErrorResponse "No distribution recorded for the epoch"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/epochs/{id}/report [get]
+func (h *AnalyticsHandler) HandleEpochReport(w http.ResponseWriter, r *http.Request) {
+	epochID := r.PathValue("id")
+	query := r.URL.Query()
+
+	top := 0
+	if value := query.Get("top"); value != "" {
+		var err error
+		if top, err = strconv.Atoi(value); err != nil {
+			writeErrorResponse(w, r, h.logger, analytics.ErrInvalidInput, "invalid top parameter, expected a number")
+			return
+		}
+	}
+	format := query.Get("format")
+	if format != "" && format != "json" && format != "csv" {
+		writeErrorResponse(w, r, h.logger, analytics.ErrInvalidInput, "invalid format parameter, expected json or csv")
+		return
+	}
+
+	result, err := h.analyticsService.EpochReport(r.Context(), epochID, top)
+	if err != nil {
+		h.logger.Logf("ERROR failed to build report of epoch %s: %v", epochID, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to build epoch report")
+		return
+	}
+
+	if format == "csv" {
+		h.writeEpochReportCSV(w, result)
+		return
+	}
+	rest.RenderJSON(w, result)
+}
+
+// writeAnalyticsCSV writes the series as an attachment, one row per epoch
+func (h *AnalyticsHandler) writeAnalyticsCSV(w http.ResponseWriter, result *analytics.VaultAnalytics) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "analytics-"+result.VaultID+".csv"))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	rows := [][]string{analyticsCSVHeader}
+	for _, point := range result.Epochs {
+		rows = append(rows, []string{
+			point.EpochID, formatTimestamp(point.StartTimestamp), formatTimestamp(point.EndTimestamp),
+			point.YieldGenerated, point.SubsidiesDistributed, point.CumulativeSubsidies,
+			point.Claimed, point.ClaimRate, point.AssetsDeposited, point.EffectiveAPY,
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		h.logger.Logf("WARN failed to write analytics CSV of vault %s: %v", result.VaultID, err)
+	}
+}
+
+// writeEpochReportCSV writes the report as an attachment: its summary, the vaults, their top allocations and the
+// transactions, each a table with its own header
+func (h *AnalyticsHandler) writeEpochReportCSV(w http.ResponseWriter, result *analytics.EpochReport) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "epoch-"+result.EpochID+"-report.csv"))
+	w.WriteHeader(http.StatusOK)
+
+	writer := csv.NewWriter(w)
+	rows := [][]string{
+		epochReportCSVSummaryHeader,
+		{
+			result.EpochID, result.GeneratedAt.Format(time.RFC3339), strconv.Itoa(len(result.Vaults)),
+			strconv.Itoa(result.Participants), result.TotalSubsidies, strconv.Itoa(result.FlaggedVaults),
+			strconv.Itoa(result.Gas.Transactions), strconv.FormatUint(result.Gas.GasUsed, 10), result.Gas.Cost,
+		},
+		{},
+		epochReportCSVVaultHeader,
+	}
+	for _, vault := range result.Vaults {
+		row := []string{
+			vault.VaultID, vault.MerkleRoot, strconv.FormatInt(vault.SnapshotBlock, 10), strconv.Itoa(vault.Accounts),
+			vault.Total, vault.Median, vault.Gini, strconv.Itoa(vault.DustAccounts),
+		}
+		if rec := vault.Reconciliation; rec != nil {
+			row = append(row, rec.YieldAllocated, rec.Subsidies, rec.Claimed, strconv.Itoa(rec.Discrepancies),
+				strconv.FormatBool(rec.Flagged))
+		} else {
+			row = append(row, "", "", "", "", "")
+		}
+		rows = append(rows, row)
+	}
+
+	rows = append(rows, []string{}, epochReportCSVTopHeader)
+	for _, vault := range result.Vaults {
+		for _, allocation := range vault.TopAllocations {
+			rows = append(rows, []string{
+				vault.VaultID, strconv.Itoa(allocation.Rank), allocation.Account, allocation.Amount, allocation.Share,
+			})
+		}
+	}
+
+	rows = append(rows, []string{}, epochReportCSVTransactionHeader)
+	for _, tx := range result.Transactions {
+		rows = append(rows, []string{
+			tx.TxHash, tx.Action, tx.Operation, strconv.FormatUint(tx.BlockNumber, 10), strconv.FormatUint(tx.GasUsed, 10),
+			tx.GasPrice, tx.Cost, tx.Timestamp.UTC().Format(time.RFC3339),
+		})
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		h.logger.Logf("WARN failed to write report CSV of epoch %s: %v", result.EpochID, err)
+	}
+}
+
+// parseEpochParam parses an optional epoch number, 0 when it is not given
+func parseEpochParam(value string) (uint64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+// formatTimestamp formats a unix time for the CSV export, empty when it is not known
+func formatTimestamp(ts int64) string {
+	if ts == 0 {
+		return ""
+	}
+	return strconv.FormatInt(ts, 10)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// AssetHandler handles vault asset HTTP requests
+type AssetHandler struct {
+	assetService assets.Service
+	logger       lgr.L
+	config       *config.Config
+}
+
+// NewAssetHandler creates a new asset handler
+func NewAssetHandler(assetService assets.Service, logger lgr.L, cfg *config.Config) *AssetHandler {
+	return &AssetHandler{
+		assetService: assetService,
+		logger:       logger,
+		config:       cfg,
+	}
+}
+
+// HandleGetAsset handles vault asset requests
+// @Summary Get vault asset
+// @Description Returns the ERC-20 the vault holds, which its wei amounts are denominated in, with its symbol and decimals. JSON responses carry every wei amount field next to a <field>Formatted string in whole tokens of this asset
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address"
+// @Success 200 {object} assets.Asset "Vault asset"
+// @Failure 400 {object} ErrorResponse "Invalid vault address"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/asset [get]
+func (h *AssetHandler) HandleGetAsset(w http.ResponseWriter, r *http.Request) {
+	asset, err := h.assetService.GetAsset(r.Context(), r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "failed to get vault asset")
+		return
+	}
+
+	rest.RenderJSON(w, asset)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/api/pagination"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// AuditHandler handles audit log HTTP requests
+type AuditHandler struct {
+	auditService audit.Service
+	logger       lgr.L
+	config       *config.Config
+}
+
+// NewAuditHandler creates a new audit handler
+func NewAuditHandler(auditService audit.Service, logger lgr.L, cfg *config.Config) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+		config:       cfg,
+	}
+}
+
+// auditPages pages the audit log by time with a cursor, counting the whole log would walk all of it
+var auditPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"timestamp"}, DefaultOrder: pagination.OrderDesc, Cursor: true,
+}
+
+// HandleListAudit handles audit log queries
+// @Summary List audit log entries
+// @Description Lists recorded state-changing actions (on-chain transactions), newest first. Pages are continued with
+// @Description the nextCursor of the previous page, also linked in the Link header; no total count is returned.
+// @Tags audit
+// @Accept json
+// @Produce json
+// @Param action query string false "Action name, e.g. updateMerkleRoot"
+// @Param actor query string false "Actor that triggered the action, e.g. scheduler or api:10.0.0.1"
+// @Param result query string false "Result (success or failed)"
+// @Param txHash query string false "Transaction hash"
+// @Param since query string false "Only entries at or after this time (RFC3339)"
+// @Param until query string false "Only entries at or before this time (RFC3339)"
+// @Param limit query int false "Maximum number of entries to return (1-1000, default 100)"
+// @Param cursor query string false "nextCursor of the previous page"
+// @Param sort query string false "Sort field" Enums(timestamp)
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {object} audit.ListResponse "Audit log entries"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid filter"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/audit [get]
+func (h *AuditHandler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := pagination.Parse(query, auditPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+	filter := audit.Filter{
+		Action:    query.Get("action"),
+		Actor:     query.Get("actor"),
+		Result:    query.Get("result"),
+		TxHash:    query.Get("txHash"),
+		Limit:     page.Limit,
+		Cursor:    page.Cursor,
+		Ascending: !page.Descending(),
+	}
+
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		writeErrorResponse(w, r, h.logger, audit.ErrInvalidInput, "invalid since parameter, expected RFC3339")
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		writeErrorResponse(w, r, h.logger, audit.ErrInvalidInput, "invalid until parameter, expected RFC3339")
+		return
+	}
+
+	response, err := h.auditService.List(r.Context(), filter)
+	if err != nil {
+		h.logger.Logf("ERROR failed to list audit entries: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list audit entries")
+		return
+	}
+
+	pagination.WriteCursor(w, r, response.NextCursor)
+	rest.RenderJSON(w, response)
+}
+
+// parseTimeParam parses an optional RFC3339 query parameter, returning the zero time when empty
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
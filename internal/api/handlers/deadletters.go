@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/api/pagination"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// DeadLetterHandler handles the webhook deliveries and transactions that failed for good
+type DeadLetterHandler struct {
+	deadLetters deadletter.Service
+	logger      lgr.L
+	config      *config.Config
+}
+
+// NewDeadLetterHandler creates a new dead letter handler
+func NewDeadLetterHandler(deadLetterService deadletter.Service, logger lgr.L, cfg *config.Config) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		deadLetters: deadLetterService,
+		logger:      logger,
+		config:      cfg,
+	}
+}
+
+// deadLetterPages pages dead letters, oldest failure first
+var deadLetterPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"failedAt"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var deadLetterSorts = map[string]func(a, b deadletter.Entry) int{
+	"failedAt": func(a, b deadletter.Entry) int { return a.FailedAt.Compare(b.FailedAt) },
+}
+
+// HandleListDeadLetters handles listing of dead letters
+// @Summary List dead letters
+// @Description Lists the webhook deliveries that exhausted their retries and the transactions sent without waiting
+// @Description that reverted or never confirmed, oldest failure first. The number of matching entries is returned
+// @Description in X-Total-Count and the next page is linked in the Link header. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param kind query string false "Filter by kind" Enums(webhook, transaction)
+// @Param limit query int false "Maximum number of entries to return (1-1000, default 100)"
+// @Param offset query int false "Number of entries to skip"
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} deadletter.Entry "Dead letters"
+// @Header 200 {integer} X-Total-Count "Number of matching entries across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Unknown kind or invalid paging parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/dead-letters [get]
+func (h *DeadLetterHandler) HandleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), deadLetterPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	entries, err := h.deadLetters.List(r.Context(), r.URL.Query().Get("kind"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to list dead letters")
+		return
+	}
+
+	entries, total := pagination.Apply(entries, page, deadLetterSorts)
+	pagination.WritePage(w, r, page, len(entries), total)
+	rest.RenderJSON(w, entries)
+}
+
+// HandleGetDeadLetter handles inspecting a dead letter
+// @Summary Get dead letter
+// @Description Returns a dead letter with its payload: the webhook event as it was sent, or the transaction with
+// @Description its parameters and revert reason. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} deadletter.Entry "Dead letter"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "No such dead letter"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/dead-letters/{id} [get]
+func (h *DeadLetterHandler) HandleGetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.deadLetters.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to get dead letter")
+		return
+	}
+
+	rest.RenderJSON(w, entry)
+}
+
+// HandleRetryDeadLetter handles retrying a dead letter
+// @Summary Retry dead letter
+// @Description Delivers the webhook event to its endpoint again, or sends the transaction again, and removes the
+// @Description entry once that succeeded. A root update is only sent while its root is the vault's latest and not
+// @Description on-chain yet, one already on-chain is removed without sending anything. A failed retry keeps the
+// @Description entry with the attempt counted. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Produce json
+// @Param id path string true "Dead letter ID"
+// @Success 200 {object} deadletter.RetryResult "Retried, the entry is removed"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "No such dead letter"
+// @Failure 409 {object} ErrorResponse "The entry cannot be retried, such as a superseded root or a removed endpoint"
+// @Failure 502 {object} ErrorResponse "The retry failed, the entry is kept"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/dead-letters/{id}/retry [post]
+func (h *DeadLetterHandler) HandleRetryDeadLetter(w http.ResponseWriter, r *http.Request) {
+	result, err := h.deadLetters.Retry(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to retry dead letter")
+		return
+	}
+
+	rest.RenderJSON(w, result)
+}
+
+// HandleDiscardDeadLetter handles discarding a dead letter
+// @Summary Discard dead letter
+// @Description Removes the dead letter without retrying it. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Param id path string true "Dead letter ID"
+// @Success 204 "Dead letter discarded"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "No such dead letter"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/dead-letters/{id} [delete]
+func (h *DeadLetterHandler) HandleDiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if err := h.deadLetters.Discard(r.Context(), r.PathValue("id")); err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to discard dead letter")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/andrey/epoch-server/internal/api/pagination"
 	"github.com/andrey/epoch-server/internal/infra/config"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/epoch"
@@ -96,6 +97,114 @@ func (h *EpochHandler) HandleForceEndEpoch(w http.ResponseWriter, r *http.Reques
 	}
 }
 
+// epochPages pages epochs by number, newest first
+var epochPages = pagination.Spec{DefaultLimit: 20, MaxLimit: 1000, Sorts: []string{"epochNumber"}, DefaultOrder: pagination.OrderDesc}
+
+// HandleListEpochs handles epoch listing requests
+// @Summary List epochs
+// @Description Lists the epochs known to the subgraph, newest first. The number of epochs is returned in X-Total-Count
+// @Description and the next page is linked in the Link header. Epochs the server allocated vault yield to also report
+// @Description the amount allocated and the reserve held back.
+// @Tags epochs
+// @Accept json
+// @Produce json
+// @Param limit query int false "Maximum number of epochs to return (1-1000, default 20)"
+// @Param offset query int false "Number of epochs to skip (0-5000)"
+// @Param sort query string false "Sort field" Enums(epochNumber)
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {object} epoch.ListEpochsResponse "Epoch list"
+// @Header 200 {integer} X-Total-Count "Number of epochs across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/epochs [get]
+func (h *EpochHandler) HandleListEpochs(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), epochPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	response, err := h.epochService.ListEpochs(r.Context(), epoch.ListEpochsQuery{
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		Ascending: !page.Descending(),
+	})
+	if err != nil {
+		h.logger.Logf("ERROR failed to list epochs: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list epochs")
+		return
+	}
+
+	pagination.WritePage(w, r, page, response.Count, response.Total)
+	rest.RenderJSON(w, response)
+}
+
+// HandleGetOnChainState handles requests for the contracts' view of the current epoch
+// @Summary Get on-chain epoch state
+// @Description Reads the current epoch ID and the vault's epoch yield from the EpochManager, the vault's allocated and
+// @Description reserved yield, and the DebtSubsidizer's merkle root and subsidy totals, all at the latest block, and
+// @Description returns them decoded, with the current epoch's start and end from its EpochStarted event. Meant for
+// @Description debugging mismatches between the server, the subgraph and the chain.
+// @Tags epochs
+// @Produce json
+// @Param vault query string false "Vault address, defaults to the configured vault" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} epoch.OnChainStateResponse "Decoded on-chain state"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault address"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/epochs/current/onchain [get]
+func (h *EpochHandler) HandleGetOnChainState(w http.ResponseWriter, r *http.Request) {
+	vaultId := r.URL.Query().Get("vault")
+	if vaultId == "" {
+		vaultId = h.config.Contracts.CollectionsVault
+	}
+
+	response, err := h.epochService.GetOnChainState(r.Context(), vaultId)
+	if err != nil {
+		h.logger.Logf("ERROR failed to get on-chain epoch state of vault %s: %v", vaultId, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get on-chain epoch state")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// HandleGetTimeline handles requests for an epoch's processing timeline
+// @Summary Get epoch timeline
+// @Description Lists the milestones of an epoch's processing in order: started, snapshot taken, allocations computed,
+// @Description root submitted, confirmed and claims opened, each with its unix timestamp and the seconds since the
+// @Description previous reached milestone, for rendering the epoch as a Gantt chart. Milestones not reached yet are
+// @Description returned without a timestamp. Roots submitted before send times were recorded have no submission time.
+// @Tags epochs
+// @Produce json
+// @Param id path uint64 true "Epoch number" example:"5"
+// @Param vault query string false "Vault address, defaults to the configured vault" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} epoch.Timeline "Epoch milestones"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid epoch or vault address"
+// @Failure 404 {object} ErrorResponse "Epoch not known to the subgraph nor processed by the server"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/epochs/{id}/timeline [get]
+func (h *EpochHandler) HandleGetTimeline(w http.ResponseWriter, r *http.Request) {
+	epochId, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, epoch.ErrInvalidInput, "invalid epoch id")
+		return
+	}
+	vaultId := r.URL.Query().Get("vault")
+	if vaultId == "" {
+		vaultId = h.config.Contracts.CollectionsVault
+	}
+
+	response, err := h.epochService.GetTimeline(r.Context(), epochId, vaultId)
+	if err != nil {
+		h.logger.Logf("ERROR failed to get timeline of epoch %d of vault %s: %v", epochId, vaultId, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get epoch timeline")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
 // HandleGetUserTotalEarned handles user total earned requests
 // @Summary Get user total earned
 // @Description Retrieves the total amount earned by a user across all epochs
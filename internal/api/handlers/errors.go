@@ -4,59 +4,158 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/andrey/epoch-server/internal/api/pagination"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/jobs"
 	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/scheduler"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
 	"github.com/go-pkgz/lgr"
 	"github.com/go-pkgz/rest"
 )
 
 // ErrorResponse represents the structure of error responses
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Code    int    `json:"code"`
-	Details string `json:"details,omitempty"`
+	Error     string `json:"error"`
+	Code      int    `json:"code"`
+	ErrorCode string `json:"errorCode,omitempty" example:"epoch_not_active"`
+	Details   string `json:"details,omitempty"`
 }
 
-// writeErrorResponse writes a structured error response based on the error type
+// Error codes sent as the errorCode of error responses, so clients can tell errors apart without matching messages
+const (
+	ErrorCodeInvalidInput          = "invalid_input"
+	ErrorCodeNotFound              = "not_found"
+	ErrorCodeTimeout               = "timeout"
+	ErrorCodeConflict              = "conflict"
+	ErrorCodeForbidden             = "forbidden"
+	ErrorCodeTransactionFailed     = "transaction_failed"
+	ErrorCodeInternal              = "internal_error"
+	ErrorCodeEpochNotActive        = "epoch_not_active"
+	ErrorCodeYieldAlreadyAllocated = "yield_already_allocated"
+	ErrorCodeInsufficientYield     = "insufficient_yield"
+	ErrorCodeSnapshotStale         = "snapshot_stale"
+	ErrorCodeSubgraphLagging       = "subgraph_lagging"
+	ErrorCodeRootMismatch          = "root_mismatch"
+	ErrorCodePreflightFailed       = "preflight_failed"
+	ErrorCodeClaimUnavailable      = "claim_unavailable"
+	ErrorCodeQueueFull             = "queue_full"
+)
+
+// writeErrorResponse logs err and writes a structured error response with the status and error code of its type
 func writeErrorResponse(w http.ResponseWriter, r *http.Request, logger lgr.L, err error, message string) {
-	// Determine appropriate HTTP status code based on error type
-	var statusCode int
-	if isTransactionFailedError(err) {
-		statusCode = http.StatusBadGateway
-	} else if isInvalidInputError(err) {
-		statusCode = http.StatusBadRequest
-	} else if isNotFoundError(err) {
-		statusCode = http.StatusNotFound
-	} else if isTimeoutError(err) {
-		statusCode = http.StatusRequestTimeout
-	} else {
-		// Default to internal server error
-		statusCode = http.StatusInternalServerError
+	statusCode, errorCode := errorStatus(err)
+	logger = logging.FromContext(r.Context(), logger)
+	logger.Logf("%s %s - %v - %d - %s %s", logLevel(statusCode), message, err, statusCode, r.Method, r.URL.Path)
+	response := ErrorResponse{Error: message, Code: statusCode, ErrorCode: errorCode}
+	if err := rest.EncodeJSON(w, statusCode, response); err != nil {
+		logger.Logf("ERROR failed to encode error response: %v", err)
 	}
+}
 
-	rest.SendErrorJSON(w, r, logger, statusCode, err, message)
+// errorStatus returns the HTTP status and error code of err. The domain errors are checked before the broader
+// kinds some of them are also wrapped with.
+func errorStatus(err error) (int, string) {
+	switch {
+	case errors.Is(err, epoch.ErrEpochNotActive):
+		return http.StatusConflict, ErrorCodeEpochNotActive
+	case errors.Is(err, epoch.ErrYieldAlreadyAllocated):
+		return http.StatusConflict, ErrorCodeYieldAlreadyAllocated
+	case errors.Is(err, subsidy.ErrInsufficientYield):
+		return http.StatusUnprocessableEntity, ErrorCodeInsufficientYield
+	case errors.Is(err, merkle.ErrRootMismatch):
+		return http.StatusConflict, ErrorCodeRootMismatch
+	case errors.Is(err, subsidy.ErrSnapshotStale):
+		return http.StatusServiceUnavailable, ErrorCodeSnapshotStale
+	case errors.Is(err, subsidy.ErrSubgraphLagging):
+		return http.StatusServiceUnavailable, ErrorCodeSubgraphLagging
+	case errors.Is(err, queue.ErrQueueFull):
+		return http.StatusServiceUnavailable, ErrorCodeQueueFull
+	case errors.Is(err, subsidy.ErrPreflightFailed):
+		return http.StatusConflict, ErrorCodePreflightFailed
+	case errors.Is(err, merkle.ErrClaimUnavailable):
+		return http.StatusConflict, ErrorCodeClaimUnavailable
+	case isTransactionFailedError(err):
+		return http.StatusBadGateway, ErrorCodeTransactionFailed
+	case isInvalidInputError(err):
+		return http.StatusBadRequest, ErrorCodeInvalidInput
+	case isNotFoundError(err):
+		return http.StatusNotFound, ErrorCodeNotFound
+	case isTimeoutError(err):
+		return http.StatusRequestTimeout, ErrorCodeTimeout
+	case isConflictError(err):
+		return http.StatusConflict, ErrorCodeConflict
+	case errors.Is(err, subsidy.ErrSameApprover):
+		return http.StatusForbidden, ErrorCodeForbidden
+	default:
+		return http.StatusInternalServerError, ErrorCodeInternal
+	}
+}
+
+// logLevel is the level an error response with the status is logged at, server errors being the ones to act on
+func logLevel(statusCode int) string {
+	if statusCode >= http.StatusInternalServerError {
+		return "ERROR"
+	}
+	return "WARN"
 }
 
 // Helper functions to check error types across all services
 func isTransactionFailedError(err error) bool {
 	return errors.Is(err, epoch.ErrTransactionFailed) ||
-		errors.Is(err, subsidy.ErrTransactionFailed)
+		errors.Is(err, subsidy.ErrTransactionFailed) ||
+		errors.Is(err, vaults.ErrTransactionFailed) ||
+		errors.Is(err, deadletter.ErrRetryFailed)
 }
 
 func isInvalidInputError(err error) bool {
 	return errors.Is(err, epoch.ErrInvalidInput) ||
 		errors.Is(err, subsidy.ErrInvalidInput) ||
-		errors.Is(err, merkle.ErrInvalidInput)
+		errors.Is(err, merkle.ErrInvalidInput) ||
+		errors.Is(err, audit.ErrInvalidInput) ||
+		errors.Is(err, gas.ErrInvalidInput) ||
+		errors.Is(err, pause.ErrInvalidInput) ||
+		errors.Is(err, jobs.ErrInvalidInput) ||
+		errors.Is(err, reconciliation.ErrInvalidInput) ||
+		errors.Is(err, analytics.ErrInvalidInput) ||
+		errors.Is(err, vaults.ErrInvalidInput) ||
+		errors.Is(err, queue.ErrInvalidInput) ||
+		errors.Is(err, assets.ErrInvalidInput) ||
+		errors.Is(err, deadletter.ErrInvalidInput) ||
+		errors.Is(err, features.ErrInvalidInput) ||
+		errors.Is(err, pagination.ErrInvalidInput)
 }
 
 func isNotFoundError(err error) bool {
 	return errors.Is(err, epoch.ErrNotFound) ||
 		errors.Is(err, subsidy.ErrNotFound) ||
-		errors.Is(err, merkle.ErrNotFound)
+		errors.Is(err, merkle.ErrNotFound) ||
+		errors.Is(err, queue.ErrNotFound) ||
+		errors.Is(err, deadletter.ErrNotFound) ||
+		errors.Is(err, analytics.ErrNotFound)
 }
 
 func isTimeoutError(err error) bool {
 	return errors.Is(err, epoch.ErrTimeout) ||
 		errors.Is(err, subsidy.ErrTimeout)
 }
+
+func isConflictError(err error) bool {
+	return errors.Is(err, scheduler.ErrCannotRun) ||
+		errors.Is(err, scheduler.ErrNotRunning) ||
+		errors.Is(err, vaults.ErrDecommissioned) ||
+		errors.Is(err, subsidy.ErrFingerprintMismatch) ||
+		errors.Is(err, subsidy.ErrRootNotReplaceable) ||
+		errors.Is(err, deadletter.ErrNotRetryable)
+}
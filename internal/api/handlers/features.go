@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// FeaturesHandler handles the feature flags gating risky behaviors
+type FeaturesHandler struct {
+	features features.Service
+	logger   lgr.L
+	config   *config.Config
+}
+
+// NewFeaturesHandler creates a new feature flags handler
+func NewFeaturesHandler(featuresService features.Service, logger lgr.L, cfg *config.Config) *FeaturesHandler {
+	return &FeaturesHandler{
+		features: featuresService,
+		logger:   logger,
+		config:   cfg,
+	}
+}
+
+// SetFeatureRequest switches a feature flag on or off, and says why
+type SetFeatureRequest struct {
+	Enabled *bool  `json:"enabled" example:"false"`
+	Reason  string `json:"reason,omitempty" example:"allocation drift on sepolia"`
+}
+
+// HandleListFeatures handles requests for the feature flags
+// @Summary List feature flags
+// @Description Lists every feature flag, whether it is on, and whether that comes from the default, the environment's
+// @Description features file or an operator's override. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} features.Status "State of every flag"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/features [get]
+func (h *FeaturesHandler) HandleListFeatures(w http.ResponseWriter, r *http.Request) {
+	status, err := h.features.Status(r.Context())
+	if err != nil {
+		h.logger.Logf("ERROR failed to get feature flags: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get feature flags")
+		return
+	}
+
+	rest.RenderJSON(w, status)
+}
+
+// HandleSetFeature handles overriding a feature flag
+// @Summary Override feature flag
+// @Description Switches the behavior the flag gates on or off from the next distribution, on every replica, without
+// @Description a redeploy. The override is stored and survives restarts; setting it again replaces it. Requires an
+// @Description admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param flag path string true "Feature flag" example:"auto_approval"
+// @Param request body SetFeatureRequest true "Whether the flag is on, and why"
+// @Success 200 {object} features.FlagState "Flag overridden"
+// @Failure 400 {object} ErrorResponse "Unknown flag or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/features/{flag} [put]
+func (h *FeaturesHandler) HandleSetFeature(w http.ResponseWriter, r *http.Request) {
+	var req SetFeatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Enabled == nil {
+		writeErrorResponse(w, r, h.logger, features.ErrInvalidInput, "Request body must set enabled")
+		return
+	}
+
+	flag := r.PathValue("flag")
+	state, err := h.features.Set(r.Context(), flag, *req.Enabled, req.Reason)
+	if err != nil {
+		h.logger.Logf("ERROR failed to set feature flag %s: %v", flag, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to set feature flag")
+		return
+	}
+
+	rest.RenderJSON(w, state)
+}
+
+// HandleClearFeature handles removing the override of a feature flag
+// @Summary Clear feature flag override
+// @Description Returns the flag to what the environment's features file sets, on when it sets nothing. Requires an
+// @Description admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param flag path string true "Feature flag" example:"auto_approval"
+// @Success 200 {object} features.FlagState "Flag returned to its configured state"
+// @Failure 400 {object} ErrorResponse "Unknown flag"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/features/{flag} [delete]
+func (h *FeaturesHandler) HandleClearFeature(w http.ResponseWriter, r *http.Request) {
+	flag := r.PathValue("flag")
+	state, err := h.features.Clear(r.Context(), flag)
+	if err != nil {
+		h.logger.Logf("ERROR failed to clear feature flag %s: %v", flag, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to clear feature flag")
+		return
+	}
+
+	rest.RenderJSON(w, state)
+}
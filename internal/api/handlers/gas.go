@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// GasHandler handles gas spend report HTTP requests
+type GasHandler struct {
+	gasService gas.Service
+	logger     lgr.L
+	config     *config.Config
+}
+
+// NewGasHandler creates a new gas handler
+func NewGasHandler(gasService gas.Service, logger lgr.L, cfg *config.Config) *GasHandler {
+	return &GasHandler{
+		gasService: gasService,
+		logger:     logger,
+		config:     cfg,
+	}
+}
+
+// HandleGasReport handles gas spend report requests
+// @Summary Get gas spend report
+// @Description Rolls up the gas used and ETH spent by mined transactions per operation type
+// @Description (epoch_start, epoch_finalize, root_update, batch_repay) and per epoch, with the
+// @Description current month's budget status when a monthly budget is configured
+// @Tags reports
+// @Accept json
+// @Produce json
+// @Param since query string false "Only transactions at or after this time (RFC3339)"
+// @Param until query string false "Only transactions at or before this time (RFC3339)"
+// @Param epoch query string false "Only transactions attributed to this epoch ID"
+// @Success 200 {object} gas.Report "Gas spend report"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid filter"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/reports/gas [get]
+func (h *GasHandler) HandleGasReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := gas.ReportFilter{EpochID: query.Get("epoch")}
+
+	var err error
+	if filter.Since, err = parseTimeParam(query.Get("since")); err != nil {
+		writeErrorResponse(w, r, h.logger, gas.ErrInvalidInput, "invalid since parameter, expected RFC3339")
+		return
+	}
+	if filter.Until, err = parseTimeParam(query.Get("until")); err != nil {
+		writeErrorResponse(w, r, h.logger, gas.ErrInvalidInput, "invalid until parameter, expected RFC3339")
+		return
+	}
+
+	report, err := h.gasService.Report(r.Context(), filter)
+	if err != nil {
+		h.logger.Logf("ERROR failed to build gas report: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to build gas report")
+		return
+	}
+
+	rest.RenderJSON(w, report)
+}
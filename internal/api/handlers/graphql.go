@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/api/graphql"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// graphqlMaxFields bounds how many fields, and so service calls, a single query can select
+const graphqlMaxFields = 200
+
+// GraphQLHandler serves read-only GraphQL queries over epochs, vaults, collections, users and proofs
+type GraphQLHandler struct {
+	epochService  epoch.Service
+	merkleService merkle.Service
+	logger        lgr.L
+	config        *config.Config
+	schema        *graphql.Schema
+}
+
+// vaultSource is the value resolved for a Vault object
+type vaultSource struct {
+	Address string `json:"address"`
+}
+
+// userSource is the value resolved for a User object
+type userSource struct {
+	Address string `json:"address"`
+	Vault   string `json:"vault"`
+}
+
+// NewGraphQLHandler creates a new GraphQL handler
+func NewGraphQLHandler(epochService epoch.Service, merkleService merkle.Service, logger lgr.L, cfg *config.Config) *GraphQLHandler {
+	h := &GraphQLHandler{
+		epochService:  epochService,
+		merkleService: merkleService,
+		logger:        logger,
+		config:        cfg,
+	}
+	h.schema = h.buildSchema()
+	return h
+}
+
+// HandleGraphQL handles GraphQL queries
+// @Summary Query with GraphQL
+// @Description Executes a read-only GraphQL query over epochs, vaults, collections, user allocations and proofs. POST takes a JSON body with query, operationName and variables; GET takes the same as query parameters, with variables JSON-encoded. Mutations are rejected.
+// @Tags graphql
+// @Accept json
+// @Produce json
+// @Param request body graphql.Request false "GraphQL request (POST)"
+// @Param query query string false "GraphQL query (GET)"
+// @Param operationName query string false "Operation to execute (GET)"
+// @Param variables query string false "JSON-encoded variables (GET)"
+// @Success 200 {object} graphql.Response "Query executed; field errors are listed in errors"
+// @Failure 400 {object} graphql.Response "Malformed, invalid or non-query request"
+// @Router /api/graphql [get]
+// @Router /api/graphql [post]
+func (h *GraphQLHandler) HandleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		query := r.URL.Query()
+		req.Query = query.Get("query")
+		req.OperationName = query.Get("operationName")
+		if variables := query.Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				h.renderResponse(w, &graphql.Response{Errors: []*graphql.Error{{Message: "variables must be a JSON object"}}})
+				return
+			}
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.renderResponse(w, &graphql.Response{Errors: []*graphql.Error{{Message: "request body must be a JSON object with a query"}}})
+		return
+	}
+
+	h.renderResponse(w, h.schema.Execute(r.Context(), req))
+}
+
+// renderResponse answers 400 when the request was rejected before execution, 200 otherwise
+func (h *GraphQLHandler) renderResponse(w http.ResponseWriter, response *graphql.Response) {
+	status := http.StatusOK
+	if response.Data == nil {
+		status = http.StatusBadRequest
+	}
+	if err := rest.EncodeJSON(w, status, response); err != nil {
+		h.logger.Logf("ERROR failed to encode GraphQL response: %v", err)
+	}
+}
+
+func (h *GraphQLHandler) buildSchema() *graphql.Schema {
+	epochType := &graphql.Object{Name: "Epoch", Fields: scalarFields(
+		"epochNumber", "status", "startTimestamp", "endTimestamp",
+		"processingCompletedTimestamp", "totalSubsidiesDistributed", "totalYieldDistributed",
+	)}
+	collectionType := &graphql.Object{Name: "Collection", Fields: scalarFields(
+		"id", "contractAddress", "name", "symbol", "collectionType",
+		"isActive", "yieldSharePercentage", "totalNFTsDeposited",
+	)}
+	allocationType := &graphql.Object{Name: "Allocation", Fields: scalarFields(
+		"collectionAddress", "secondsAccumulated", "secondsClaimed", "subsidiesAccrued",
+		"subsidiesClaimed", "totalRewardsEarned", "updatedAtTimestamp",
+	)}
+	proofType := &graphql.Object{Name: "Proof", Fields: scalarFields(
+		"userAddress", "vaultAddress", "epochNumber", "totalEarned",
+		"merkleRoot", "leafIndex", "generatedAt",
+	)}
+	proofType.Fields["merkleProof"] = &graphql.FieldDef{List: true}
+	verificationType := &graphql.Object{Name: "MerkleRootVerification", Fields: scalarFields(
+		"vaultAddress", "epochNumber", "leafCount", "computedRoot",
+		"storedRoot", "onChainRoot", "match", "verifiedAt",
+	)}
+	verificationType.Fields["mismatches"] = &graphql.FieldDef{List: true}
+
+	proofArgs := map[string]graphql.Argument{
+		"epoch": {Kind: graphql.Int},
+		"root":  {Kind: graphql.String},
+	}
+
+	vaultType := &graphql.Object{Name: "Vault", Fields: scalarFields("address")}
+	vaultType.Fields["collections"] = &graphql.FieldDef{
+		Type: collectionType,
+		List: true,
+		Resolve: func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			vault := source.(vaultSource)
+			response, err := h.epochService.ListCollections(ctx, vault.Address)
+			if err != nil {
+				return nil, h.fieldError("collections", err)
+			}
+			return response.Collections, nil
+		},
+	}
+	vaultType.Fields["merkleRootVerification"] = &graphql.FieldDef{
+		Type: verificationType,
+		Resolve: func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			vault := source.(vaultSource)
+			response, err := h.merkleService.VerifyMerkleRoot(ctx, vault.Address)
+			if err != nil {
+				return nil, h.fieldError("merkleRootVerification", err)
+			}
+			return response, nil
+		},
+	}
+
+	userType := &graphql.Object{Name: "User", Fields: scalarFields("address", "vault")}
+	userType.Fields["totalEarned"] = &graphql.FieldDef{
+		Resolve: func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			user := source.(userSource)
+			response, err := h.epochService.GetUserTotalEarned(ctx, user.Address, user.Vault)
+			if err != nil {
+				return nil, h.fieldError("totalEarned", err)
+			}
+			return response.TotalEarned, nil
+		},
+	}
+	userType.Fields["allocations"] = &graphql.FieldDef{
+		Type: allocationType,
+		List: true,
+		Resolve: func(ctx context.Context, source interface{}, _ graphql.Args) (interface{}, error) {
+			user := source.(userSource)
+			response, err := h.epochService.GetUserAllocations(ctx, user.Address, user.Vault)
+			if err != nil {
+				return nil, h.fieldError("allocations", err)
+			}
+			return response.Allocations, nil
+		},
+	}
+	userType.Fields["proof"] = &graphql.FieldDef{
+		Type: proofType,
+		Args: proofArgs,
+		Resolve: func(ctx context.Context, source interface{}, args graphql.Args) (interface{}, error) {
+			user := source.(userSource)
+			return h.resolveProof(ctx, user.Address, user.Vault, args)
+		},
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: map[string]*graphql.FieldDef{
+		"epochs": {
+			Type: epochType,
+			List: true,
+			Args: map[string]graphql.Argument{"limit": {Kind: graphql.Int, Default: int64(20)}},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				limit, _ := args.Int("limit")
+				response, err := h.epochService.ListEpochs(ctx, epoch.ListEpochsQuery{Limit: int(limit)})
+				if err != nil {
+					return nil, h.fieldError("epochs", err)
+				}
+				return response.Epochs, nil
+			},
+		},
+		"currentEpochId": {
+			Resolve: func(ctx context.Context, _ interface{}, _ graphql.Args) (interface{}, error) {
+				epochId, err := h.epochService.GetCurrentEpochId(ctx)
+				if err != nil {
+					return nil, h.fieldError("currentEpochId", err)
+				}
+				return strconv.FormatUint(epochId, 10), nil
+			},
+		},
+		"vault": {
+			Type: vaultType,
+			Args: map[string]graphql.Argument{"address": {Kind: graphql.String}},
+			Resolve: func(_ context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				vaultAddress, err := h.vaultAddress(args.String("address"))
+				if err != nil {
+					return nil, err
+				}
+				return vaultSource{Address: vaultAddress}, nil
+			},
+		},
+		"user": {
+			Type: userType,
+			Args: map[string]graphql.Argument{
+				"address": {Kind: graphql.String, Required: true},
+				"vault":   {Kind: graphql.String},
+			},
+			Resolve: func(_ context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				userAddress, err := utils.ValidateAndNormalizeAddress(args.String("address"))
+				if err != nil {
+					return nil, errors.New("invalid user address format")
+				}
+				vaultAddress, err := h.vaultAddress(args.String("vault"))
+				if err != nil {
+					return nil, err
+				}
+				return userSource{Address: userAddress, Vault: vaultAddress}, nil
+			},
+		},
+		"proof": {
+			Type: proofType,
+			Args: map[string]graphql.Argument{
+				"address": {Kind: graphql.String, Required: true},
+				"vault":   {Kind: graphql.String},
+				"epoch":   proofArgs["epoch"],
+				"root":    proofArgs["root"],
+			},
+			Resolve: func(ctx context.Context, _ interface{}, args graphql.Args) (interface{}, error) {
+				userAddress, err := utils.ValidateAndNormalizeAddress(args.String("address"))
+				if err != nil {
+					return nil, errors.New("invalid user address format")
+				}
+				vaultAddress, err := h.vaultAddress(args.String("vault"))
+				if err != nil {
+					return nil, err
+				}
+				return h.resolveProof(ctx, userAddress, vaultAddress, args)
+			},
+		},
+	}}
+
+	return &graphql.Schema{Query: query, MaxFields: graphqlMaxFields}
+}
+
+// resolveProof dispatches like HandleGetProof: latest snapshot, the epoch's root, or a replaced root of the epoch
+func (h *GraphQLHandler) resolveProof(ctx context.Context, userAddress, vaultAddress string, args graphql.Args) (interface{}, error) {
+	epochNumber, hasEpoch := args.Int("epoch")
+	merkleRoot := args.String("root")
+	if merkleRoot != "" && !hasEpoch {
+		return nil, errors.New("root requires an epoch")
+	}
+
+	var response *merkle.UserMerkleProofResponse
+	var err error
+	switch {
+	case !hasEpoch:
+		response, err = h.merkleService.GenerateUserMerkleProof(ctx, userAddress, vaultAddress)
+	case merkleRoot == "":
+		response, err = h.merkleService.GenerateHistoricalMerkleProof(ctx, userAddress, vaultAddress, strconv.FormatInt(epochNumber, 10))
+	default:
+		response, err = h.merkleService.GenerateMerkleProofForRoot(ctx, userAddress, vaultAddress, strconv.FormatInt(epochNumber, 10), merkleRoot)
+	}
+	if err != nil {
+		return nil, h.fieldError("proof", err)
+	}
+	return response, nil
+}
+
+// vaultAddress validates an optional vault argument, defaulting to the configured vault
+func (h *GraphQLHandler) vaultAddress(vault string) (string, error) {
+	if vault == "" {
+		return utils.NormalizeAddress(h.config.Contracts.CollectionsVault), nil
+	}
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(vault)
+	if err != nil {
+		return "", errors.New("invalid vault address format")
+	}
+	return vaultAddress, nil
+}
+
+// fieldError exposes client errors as they are and hides the details of internal failures
+func (h *GraphQLHandler) fieldError(field string, err error) error {
+	if isInvalidInputError(err) || isNotFoundError(err) {
+		return err
+	}
+	h.logger.Logf("ERROR failed to resolve GraphQL field %s: %v", field, err)
+	return errors.New("failed to resolve " + field)
+}
+
+// scalarFields defines fields read from the source by json tag
+func scalarFields(names ...string) map[string]*graphql.FieldDef {
+	fields := make(map[string]*graphql.FieldDef, len(names))
+	for _, name := range names {
+		fields[name] = &graphql.FieldDef{}
+	}
+	return fields
+}
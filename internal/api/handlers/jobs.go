@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// defaultJobHistory is how many of each job's runs are returned when no limit is given
+const defaultJobHistory = 10
+
+// JobsHandler handles scheduler job run HTTP requests
+type JobsHandler struct {
+	jobService jobs.Service
+	logger     lgr.L
+	config     *config.Config
+}
+
+// NewJobsHandler creates a new jobs handler
+func NewJobsHandler(jobService jobs.Service, logger lgr.L, cfg *config.Config) *JobsHandler {
+	return &JobsHandler{
+		jobService: jobService,
+		logger:     logger,
+		config:     cfg,
+	}
+}
+
+// HandleListJobs handles scheduler job status requests
+// @Summary Get scheduler job status and history
+// @Description Lists every scheduler job with its last run time, duration and outcome, the error of its last failed run, when it runs next, and its most recent runs. Runs are recorded by the replica holding the scheduler lease and kept for the last 100 runs of each job.
+// @Tags scheduler
+// @Produce json
+// @Param limit query int false "Most recent runs to return per job (1-100, default 10)"
+// @Success 200 {object} jobs.Status "Run state of every job"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/scheduler/jobs [get]
+func (h *JobsHandler) HandleListJobs(w http.ResponseWriter, r *http.Request) {
+	limit := defaultJobHistory
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		parsed, err := strconv.Atoi(limitStr)
+		if err != nil {
+			writeErrorResponse(w, r, h.logger, jobs.ErrInvalidInput, "invalid limit parameter")
+			return
+		}
+		limit = parsed
+	}
+
+	status, err := h.jobService.Status(r.Context(), limit)
+	if err != nil {
+		h.logger.Logf("ERROR failed to get scheduler job status: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get scheduler job status")
+		return
+	}
+
+	rest.RenderJSON(w, status)
+}
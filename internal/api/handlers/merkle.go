@@ -1,11 +1,16 @@
 package handlers
 
 import (
+	"cmp"
+	"encoding/json"
 	"net/http"
 
+	"github.com/andrey/epoch-server/internal/api/pagination"
 	"github.com/andrey/epoch-server/internal/infra/config"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/go-pkgz/lgr"
 	"github.com/go-pkgz/rest"
 )
@@ -13,14 +18,16 @@ import (
 // MerkleHandler handles merkle proof-related HTTP requests
 type MerkleHandler struct {
 	merkleService merkle.Service
+	queue         queue.Service
 	logger        lgr.L
 	config        *config.Config
 }
 
 // NewMerkleHandler creates a new merkle handler
-func NewMerkleHandler(merkleService merkle.Service, logger lgr.L, cfg *config.Config) *MerkleHandler {
+func NewMerkleHandler(merkleService merkle.Service, queueService queue.Service, logger lgr.L, cfg *config.Config) *MerkleHandler {
 	return &MerkleHandler{
 		merkleService: merkleService,
+		queue:         queueService,
 		logger:        logger,
 		config:        cfg,
 	}
@@ -141,3 +148,325 @@ func (h *MerkleHandler) HandleGetUserHistoricalMerkleProof(w http.ResponseWriter
 
 	rest.RenderJSON(w, response)
 }
+
+// HandleGetProof handles merkle proof requests for the latest, a historical, or a replaced root
+// @Summary Get merkle proof
+// @Description Generates a merkle proof for a user. Without epoch the latest snapshot is used; with epoch the proof is built against the root submitted for that epoch, and root selects an earlier root of the epoch that was since replaced.
+// @Tags proofs
+// @Accept json
+// @Produce json
+// @Param address query string true "User wallet address" example:"0x1234567890123456789012345678901234567890"
+// @Param epoch query string false "Epoch number" example:"1"
+// @Param root query string false "Merkle root submitted for the epoch (requires epoch)"
+// @Param vault query string false "Vault address (optional, uses default if not provided)" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} merkle.UserMerkleProofResponse "Merkle proof generated successfully"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address, epoch or root"
+// @Failure 404 {object} ErrorResponse "User, epoch or root not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/proofs [get]
+func (h *MerkleHandler) HandleGetProof(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	userAddress, err := utils.ValidateAndNormalizeAddress(query.Get("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Missing or invalid user address")
+		return
+	}
+
+	vaultAddress := query.Get("vault")
+	if vaultAddress == "" {
+		vaultAddress = h.config.Contracts.CollectionsVault
+	} else {
+		vaultAddress, err = utils.ValidateAndNormalizeAddress(vaultAddress)
+		if err != nil {
+			writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid vault address format")
+			return
+		}
+	}
+
+	epochNumber := query.Get("epoch")
+	merkleRoot := query.Get("root")
+	if merkleRoot != "" && epochNumber == "" {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "root requires an epoch")
+		return
+	}
+
+	var response *merkle.UserMerkleProofResponse
+	switch {
+	case epochNumber == "":
+		response, err = h.merkleService.GenerateUserMerkleProof(r.Context(), userAddress, vaultAddress)
+	case merkleRoot == "":
+		response, err = h.merkleService.GenerateHistoricalMerkleProof(r.Context(), userAddress, vaultAddress, epochNumber)
+	default:
+		response, err = h.merkleService.GenerateMerkleProofForRoot(r.Context(), userAddress, vaultAddress, epochNumber, merkleRoot)
+	}
+	if err != nil {
+		h.logger.Logf("ERROR failed to generate merkle proof for user %s (epoch %q, root %q): %v", userAddress, epochNumber, merkleRoot, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to generate merkle proof")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// VerifyProofsRequest lists the proofs to verify
+type VerifyProofsRequest struct {
+	Proofs  []merkle.ProofToVerify `json:"proofs"`
+	OnChain bool                   `json:"onChain"` // also require the vault's on-chain root
+}
+
+// HandleVerifyProofs handles bulk merkle proof verification requests
+// @Summary Verify merkle proofs
+// @Description Verifies up to 1000 (vault, recipient, totalEarned, proof) tuples against the roots stored for each vault, reporting per proof whether it is valid, the epoch of the root it resolves to and, with onChain, whether that root is the vault's on-chain root. Invalid proofs are reported in their result with the reason, not as an error. With async=true the verification is queued and runs in the background, its result polled on GET /api/jobs/{id}.
+// @Tags proofs
+// @Accept json
+// @Produce json
+// @Param request body VerifyProofsRequest true "Proofs to verify"
+// @Param async query bool false "Queue the verification and return the job"
+// @Param priority query string false "Priority of the queued job (default normal)" Enums(low, normal, high)
+// @Success 200 {object} merkle.ProofVerifications "Per-proof validity, in request order"
+// @Success 202 {object} queue.Job "Verification queued"
+// @Failure 400 {object} ErrorResponse "Bad request - no proofs or too many"
+// @Failure 503 {object} ErrorResponse "Job queue is full"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/proofs/verify [post]
+func (h *MerkleHandler) HandleVerifyProofs(w http.ResponseWriter, r *http.Request) {
+	var req VerifyProofsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid request body")
+		return
+	}
+	if enqueueIfAsync(w, r, h.queue, h.logger, queue.KindVerifyProofs, queue.VerifyProofsPayload{Proofs: req.Proofs, OnChain: req.OnChain}) {
+		return
+	}
+
+	response, err := h.merkleService.VerifyProofs(r.Context(), req.Proofs, req.OnChain)
+	if err != nil {
+		h.logger.Logf("ERROR failed to verify %d proofs: %v", len(req.Proofs), err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to verify proofs")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// HandleVerifyMerkleRoot handles merkle root verification requests
+// @Summary Verify vault merkle root
+// @Description Recomputes the merkle root from the latest stored snapshot and compares it with IDebtSubsidizer.getMerkleRoot. Returns 409 with mismatch details when the roots differ.
+// @Tags vaults
+// @Accept json
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} merkle.MerkleRootVerification "Stored and on-chain roots match"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault address"
+// @Failure 404 {object} ErrorResponse "No snapshot found for vault"
+// @Failure 409 {object} merkle.MerkleRootVerification "Merkle root mismatch"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/merkle-root/verify [get]
+func (h *MerkleHandler) HandleVerifyMerkleRoot(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+
+	response, err := h.merkleService.VerifyMerkleRoot(r.Context(), vaultAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to verify merkle root for vault %s: %v", vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to verify merkle root")
+		return
+	}
+
+	if !response.Match {
+		if err := rest.EncodeJSON(w, http.StatusConflict, response); err != nil {
+			h.logger.Logf("ERROR failed to encode JSON response: %v", err)
+		}
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// rootUpdatePages pages a vault's root history in chain order
+var rootUpdatePages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"blockNumber"}, DefaultOrder: pagination.OrderDesc,
+}
+
+var rootUpdateSorts = map[string]func(a, b merkle.RootUpdate) int{
+	"blockNumber": func(a, b merkle.RootUpdate) int {
+		return cmp.Or(cmp.Compare(a.BlockNumber, b.BlockNumber), cmp.Compare(a.LogIndex, b.LogIndex))
+	},
+}
+
+// HandleListRootUpdates handles requests for the merkle roots pushed for a vault
+// @Summary List vault merkle roots
+// @Description Lists every MerkleRootUpdated event the DebtSubsidizer emitted for the vault, with the epoch of the stored tree the root was built from, so a proof can be matched to the root it was generated for. Events are synced from the chain on request once they are --confirmation-depth blocks deep; while the chain cannot be read the history synced so far is returned. The number of roots is returned in X-Total-Count and the next page is linked in the Link header.
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param limit query int false "Maximum number of roots to return (1-1000, default 100)"
+// @Param offset query int false "Number of roots to skip"
+// @Param sort query string false "Sort field (default blockNumber)" Enums(blockNumber)
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {array} merkle.RootUpdate "Merkle roots, latest first"
+// @Header 200 {integer} X-Total-Count "Number of roots across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault address or paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/roots [get]
+func (h *MerkleHandler) HandleListRootUpdates(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	page, err := pagination.Parse(r.URL.Query(), rootUpdatePages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	updates, err := h.merkleService.ListRootUpdates(r.Context(), vaultAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to list merkle roots of vault %s: %v", vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list merkle roots")
+		return
+	}
+
+	updates, total := pagination.Apply(updates, page, rootUpdateSorts)
+	pagination.WritePage(w, r, page, len(updates), total)
+	rest.RenderJSON(w, updates)
+}
+
+// HandleGetUserClaimable handles requests for what a user can claim across vaults
+// @Summary Get user claimable summary
+// @Description Sums a user's earnings across every vault with a distribution, subtracts the on-chain getUserClaimedTotal,
+// @Description and returns per vault the ClaimData (recipient, totalEarned, merkleProof) ready to pass to claimSubsidy
+// @Tags users
+// @Produce json
+// @Param address path string true "User wallet address" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Success 200 {object} merkle.UserClaimable "Claimable summary"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{address}/claimable [get]
+func (h *MerkleHandler) HandleGetUserClaimable(w http.ResponseWriter, r *http.Request) {
+	userAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid user address format")
+		return
+	}
+
+	response, err := h.merkleService.GetUserClaimable(r.Context(), userAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to get claimable summary for user %s: %v", userAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get claimable summary")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// HandleGetUserClaimPayload handles requests for a user's claim encoded for a gas relayer
+// @Summary Get user claim payload
+// @Description Encodes the user's claimSubsidy call in the vault's latest distribution: the transaction to send to
+// @Description the DebtSubsidizer and EIP-712 typed data of the same claim for the user to sign with
+// @Description eth_signTypedData_v4. claimSubsidy pays the recipient whoever sends it and checks no signature, a
+// @Description relayer verifies the signature before sponsoring the claim. recipientType tells a contract wallet,
+// @Description whose signature is checked with ERC-1271, from an externally owned account.
+// @Tags users
+// @Produce json
+// @Param address path string true "User wallet address" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Param vault query string false "Vault address (defaults to the configured CollectionsVault)"
+// @Success 200 {object} merkle.ClaimPayload "Claim payload"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address or mixed-case address with a wrong checksum"
+// @Failure 404 {object} ErrorResponse "User not found in the vault's latest distribution"
+// @Failure 409 {object} ErrorResponse "Nothing to claim (claim_unavailable) or the latest root is not on-chain yet (root_mismatch)"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{address}/claim-payload [get]
+func (h *MerkleHandler) HandleGetUserClaimPayload(w http.ResponseWriter, r *http.Request) {
+	userAddress, vaultAddress, ok := h.claimAddresses(w, r, r.URL.Query().Get("vault"))
+	if !ok {
+		return
+	}
+
+	response, err := h.merkleService.GetClaimPayload(r.Context(), userAddress, vaultAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to build claim payload for user %s in vault %s: %v", userAddress, vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to build claim payload")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// VerifyClaimSignatureRequest is a recipient's signature of their claim payload's typed data
+type VerifyClaimSignatureRequest struct {
+	Vault     string `json:"vault,omitempty"` // defaults to the configured CollectionsVault
+	Signature string `json:"signature"`       // 0x-prefixed, what eth_signTypedData_v4 or the contract wallet returned
+}
+
+// HandleVerifyClaimSignature handles checks that a claim's recipient signed its typed data
+// @Summary Verify a claim signature
+// @Description Checks the signature is the recipient's signature of the typed data GET /api/users/{address}/claim-payload
+// @Description returns now. An externally owned account's signature must recover to the recipient, a contract wallet
+// @Description (Safe and other ERC-1271 wallets) must accept it in isValidSignature. A relayer checks the claim was
+// @Description asked for before paying its gas. An invalid signature is reported in the result, not as an error.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param address path string true "Recipient address" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Param request body VerifyClaimSignatureRequest true "Signature to verify"
+// @Success 200 {object} merkle.ClaimSignatureVerification "Whether the recipient signed the claim"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address, checksum or signature"
+// @Failure 404 {object} ErrorResponse "User not found in the vault's latest distribution"
+// @Failure 409 {object} ErrorResponse "Nothing to claim (claim_unavailable) or the latest root is not on-chain yet (root_mismatch)"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{address}/claim-payload/verify [post]
+func (h *MerkleHandler) HandleVerifyClaimSignature(w http.ResponseWriter, r *http.Request) {
+	var req VerifyClaimSignatureRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid request body")
+		return
+	}
+	userAddress, vaultAddress, ok := h.claimAddresses(w, r, req.Vault)
+	if !ok {
+		return
+	}
+	signature, err := hexutil.Decode(req.Signature)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid signature, expected 0x-prefixed hex")
+		return
+	}
+
+	response, err := h.merkleService.VerifyClaimSignature(r.Context(), userAddress, vaultAddress, signature)
+	if err != nil {
+		h.logger.Logf("ERROR failed to verify claim signature of user %s in vault %s: %v", userAddress, vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to verify claim signature")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// claimAddresses validates the recipient in the path and the vault, the configured CollectionsVault when empty.
+// Funds are paid to the recipient, so a mixed-case recipient with a wrong EIP-55 checksum is refused as mistyped.
+func (h *MerkleHandler) claimAddresses(w http.ResponseWriter, r *http.Request, vault string) (user, vaultAddress string, ok bool) {
+	raw := r.PathValue("address")
+	user, err := utils.ValidateAndNormalizeAddress(raw)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid user address format")
+		return "", "", false
+	}
+	if !utils.HasValidChecksum(raw) {
+		writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "User address checksum mismatch")
+		return "", "", false
+	}
+	vaultAddress = h.config.Contracts.CollectionsVault
+	if vault != "" {
+		if vaultAddress, err = utils.ValidateAndNormalizeAddress(vault); err != nil {
+			writeErrorResponse(w, r, h.logger, merkle.ErrInvalidInput, "Invalid vault address format")
+			return "", "", false
+		}
+	}
+	return user, vaultAddress, true
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/go-pkgz/lgr"
+)
+
+// MetricsHandler exposes the metrics registry
+type MetricsHandler struct {
+	registry *metrics.Registry
+	logger   lgr.L
+}
+
+// NewMetricsHandler creates a new metrics handler
+func NewMetricsHandler(registry *metrics.Registry, logger lgr.L) *MetricsHandler {
+	return &MetricsHandler{
+		registry: registry,
+		logger:   logger,
+	}
+}
+
+// HandleMetrics serves gauges in the Prometheus text exposition format
+// @Summary Metrics
+// @Description Exposes server gauges, such as the signer balance, in the Prometheus text format
+// @Tags metrics
+// @Produce plain
+// @Success 200 {string} string "Prometheus metrics"
+// @Router /metrics [get]
+func (h *MetricsHandler) HandleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+	if err := h.registry.Write(w); err != nil {
+		h.logger.Logf("ERROR failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/api/pagination"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// QueueHandler handles polling of jobs queued by API requests
+type QueueHandler struct {
+	queue  queue.Service
+	logger lgr.L
+	config *config.Config
+}
+
+// NewQueueHandler creates a new queue handler
+func NewQueueHandler(queueService queue.Service, logger lgr.L, cfg *config.Config) *QueueHandler {
+	return &QueueHandler{
+		queue:  queueService,
+		logger: logger,
+		config: cfg,
+	}
+}
+
+// queuedJobPages pages queued jobs, most recently queued first
+var queuedJobPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"enqueuedAt"}, DefaultOrder: pagination.OrderDesc,
+}
+
+var queuedJobSorts = map[string]func(a, b queue.Job) int{
+	"enqueuedAt": func(a, b queue.Job) int { return a.EnqueuedAt.Compare(b.EnqueuedAt) },
+}
+
+// HandleGetJob handles polling of a queued job
+// @Summary Get queued job
+// @Description Returns a job queued with async=true: its status (queued, running, succeeded or failed), the result
+// @Description once it succeeded and the error once it failed. Finished jobs are kept for QUEUE_RETENTION.
+// @Tags jobs
+// @Produce json
+// @Param id path string true "Job ID"
+// @Success 200 {object} queue.Job "Job"
+// @Failure 404 {object} ErrorResponse "No such job, or it finished longer than the retention ago"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/jobs/{id} [get]
+func (h *QueueHandler) HandleGetJob(w http.ResponseWriter, r *http.Request) {
+	job, err := h.queue.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to get job")
+		return
+	}
+
+	rest.RenderJSON(w, job)
+}
+
+// HandleListQueuedJobs handles listing of queued jobs
+// @Summary List queued jobs
+// @Description Lists the jobs queued with async=true that are waiting, running, or finished within QUEUE_RETENTION,
+// @Description most recently queued first. The number of matching jobs is returned in X-Total-Count and the next
+// @Description page is linked in the Link header.
+// @Tags jobs
+// @Produce json
+// @Param status query string false "Filter by status: queued, running, succeeded or failed"
+// @Param limit query int false "Maximum number of jobs to return (1-1000, default 100)"
+// @Param offset query int false "Number of jobs to skip"
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {array} queue.Job "Jobs"
+// @Header 200 {integer} X-Total-Count "Number of matching jobs across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - unknown status or invalid paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/jobs [get]
+func (h *QueueHandler) HandleListQueuedJobs(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), queuedJobPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	jobs, err := h.queue.List(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to list jobs")
+		return
+	}
+
+	jobs, total := pagination.Apply(jobs, page, queuedJobSorts)
+	pagination.WritePage(w, r, page, len(jobs), total)
+	rest.RenderJSON(w, jobs)
+}
+
+// enqueueIfAsync queues the request's work as a job of kind when it asked for async=true, with the priority
+// it asked for, and writes 202 with the job or an error response. It reports whether the request was handled.
+func enqueueIfAsync(
+	w http.ResponseWriter,
+	r *http.Request,
+	queueService queue.Service,
+	logger lgr.L,
+	kind string,
+	payload interface{},
+) bool {
+	asyncStr := r.URL.Query().Get("async")
+	if asyncStr == "" {
+		return false
+	}
+	async, err := strconv.ParseBool(asyncStr)
+	if err != nil {
+		writeErrorResponse(w, r, logger, queue.ErrInvalidInput, "invalid async parameter")
+		return true
+	}
+	if !async {
+		return false
+	}
+
+	job, err := queueService.Enqueue(r.Context(), kind, r.URL.Query().Get("priority"), payload)
+	if err != nil {
+		logger.Logf("WARN failed to queue %s job: %v", kind, err)
+		writeErrorResponse(w, r, logger, err, "Failed to queue job")
+		return true
+	}
+
+	if err := rest.EncodeJSON(w, http.StatusAccepted, job); err != nil {
+		logger.Logf("ERROR failed to encode queued job: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/api/pagination"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// ReconciliationHandler handles yield reconciliation report HTTP requests
+type ReconciliationHandler struct {
+	reconciliationService reconciliation.Service
+	logger                lgr.L
+	config                *config.Config
+}
+
+// NewReconciliationHandler creates a new reconciliation handler
+func NewReconciliationHandler(reconciliationService reconciliation.Service, logger lgr.L, cfg *config.Config) *ReconciliationHandler {
+	return &ReconciliationHandler{
+		reconciliationService: reconciliationService,
+		logger:                logger,
+		config:                cfg,
+	}
+}
+
+// reportPages pages reconciliation reports by epoch, latest first
+var reportPages = pagination.Spec{
+	DefaultLimit: 50, MaxLimit: reconciliation.MaxReports, Sorts: []string{"epochId"}, DefaultOrder: pagination.OrderDesc,
+}
+
+// HandleReconciliationReport handles yield reconciliation report requests
+// @Summary Get yield reconciliation reports
+// @Description Lists the reconciliation of every distributed epoch, latest first. Each report compares the
+// @Description subsidies the epoch's merkle tree pays with the yield the vault allocated to the epochs up to it
+// @Description (getEpochYieldAllocated) and with what was claimed so far, flagging subsidies_exceed_yield and
+// @Description claims_exceed_subsidies when either exceeds the configured tolerance. Epochs are reconciled by
+// @Description the reconcile scheduler job after every distribution. The number of matching reports is returned in
+// @Description X-Total-Count and the next page is linked in the Link header.
+// @Tags reports
+// @Produce json
+// @Param vault query string false "Only reports of this vault address"
+// @Param epoch query string false "Only reports of this epoch ID"
+// @Param flagged query bool false "Only reports with discrepancies"
+// @Param limit query int false "Most reports to return (1-500, default 50)"
+// @Param offset query int false "Number of reports to skip"
+// @Param sort query string false "Sort field" Enums(epochId)
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {object} reconciliation.ReportList "Reconciliation reports"
+// @Header 200 {integer} X-Total-Count "Number of matching reports across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid filter or paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/reports/reconciliation [get]
+func (h *ReconciliationHandler) HandleReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	page, err := pagination.Parse(query, reportPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+	filter := reconciliation.ReportFilter{
+		VaultID:   query.Get("vault"),
+		EpochID:   query.Get("epoch"),
+		Limit:     page.Limit,
+		Offset:    page.Offset,
+		Ascending: !page.Descending(),
+	}
+
+	if flagged := query.Get("flagged"); flagged != "" {
+		parsed, err := strconv.ParseBool(flagged)
+		if err != nil {
+			writeErrorResponse(w, r, h.logger, reconciliation.ErrInvalidInput, "invalid flagged parameter, expected true or false")
+			return
+		}
+		filter.FlaggedOnly = parsed
+	}
+
+	reports, err := h.reconciliationService.Reports(r.Context(), filter)
+	if err != nil {
+		h.logger.Logf("ERROR failed to list reconciliation reports: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list reconciliation reports")
+		return
+	}
+
+	pagination.WritePage(w, r, page, len(reports.Reports), reports.Total)
+	rest.RenderJSON(w, reports)
+}
+
+// HandleClaimsReconciliationReport handles claims reconciliation report requests
+// @Summary Get claims reconciliation reports
+// @Description Returns the latest claims reconciliation of every vault, or of one vault. Leaves are cumulative, so
+// @Description every account of the vault's trees is checked against its leaf in the latest tree: an account whose
+// @Description getUserClaimedTotal exceeds it is flagged claim_exceeds_earned, and one whose leaf is lower than in an
+// @Description earlier tree is flagged earned_decreased, when the difference exceeds the configured tolerance.
+// @Description Accounts dropped from the latest tree are checked against 0. Claims are reconciled by the reconcile
+// @Description scheduler job after every distribution.
+// @Tags reports
+// @Produce json
+// @Param vault query string false "Only the report of this vault address"
+// @Success 200 {object} reconciliation.ClaimsReportList "Claims reconciliation reports"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid vault address"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/reports/reconciliation/claims [get]
+func (h *ReconciliationHandler) HandleClaimsReconciliationReport(w http.ResponseWriter, r *http.Request) {
+	reports, err := h.reconciliationService.ClaimsReports(r.Context(), r.URL.Query().Get("vault"))
+	if err != nil {
+		h.logger.Logf("ERROR failed to list claims reconciliation reports: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list claims reconciliation reports")
+		return
+	}
+
+	rest.RenderJSON(w, reports)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/signer"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// SignerHandler handles signer balance HTTP requests
+type SignerHandler struct {
+	signerService signer.Service
+	logger        lgr.L
+	config        *config.Config
+}
+
+// NewSignerHandler creates a new signer handler
+func NewSignerHandler(signerService signer.Service, logger lgr.L, cfg *config.Config) *SignerHandler {
+	return &SignerHandler{
+		signerService: signerService,
+		logger:        logger,
+		config:        cfg,
+	}
+}
+
+// HandleGetSignerStatus handles signer balance requests
+// @Summary Get signer balance status
+// @Description Reads the ETH balance of the transaction signer and reports whether scheduled transactions are paused because it is below the configured minimum
+// @Tags signer
+// @Produce json
+// @Success 200 {object} signer.BalanceStatus "Signer balance status"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/signer [get]
+func (h *SignerHandler) HandleGetSignerStatus(w http.ResponseWriter, r *http.Request) {
+	status, err := h.signerService.CheckBalance(r.Context())
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "failed to check signer balance")
+		return
+	}
+
+	rest.RenderJSON(w, status)
+}
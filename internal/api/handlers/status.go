@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/compatibility"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
+	"github.com/andrey/epoch-server/internal/services/signer"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// StatusHandler handles operational status HTTP requests
+type StatusHandler struct {
+	contracts     contractstate.Service
+	signerService signer.Service
+	compatibility compatibility.Service
+	logger        lgr.L
+	config        *config.Config
+}
+
+// NewStatusHandler creates a new status handler
+func NewStatusHandler(
+	contracts contractstate.Service,
+	signerService signer.Service,
+	compatibilityService compatibility.Service,
+	logger lgr.L,
+	cfg *config.Config,
+) *StatusHandler {
+	return &StatusHandler{
+		contracts:     contracts,
+		signerService: signerService,
+		compatibility: compatibilityService,
+		logger:        logger,
+		config:        cfg,
+	}
+}
+
+// StatusResponse is what currently stops scheduled transactions, if anything
+type StatusResponse struct {
+	Contract contractstate.PauseStatus `json:"contract"`
+	Signer   signer.BalanceStatus      `json:"signer"` // as of the last scheduler tick
+}
+
+// HandleGetStatus handles operational status requests
+// @Summary Get operational status
+// @Description Reads whether the DebtSubsidizer is paused or removed the vault, in which case distributions are skipped, and reports the signer balance from the last check. When the contract cannot be read the last known state is returned with lastError set.
+// @Tags status
+// @Produce json
+// @Success 200 {object} StatusResponse "Operational status"
+// @Router /api/status [get]
+func (h *StatusHandler) HandleGetStatus(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.contracts.Check(r.Context()); err != nil {
+		h.logger.Logf("WARN failed to check contract pause state: %v", err)
+	}
+
+	rest.RenderJSON(w, StatusResponse{
+		Contract: h.contracts.Status(),
+		Signer:   h.signerService.Status(),
+	})
+}
+
+// HandleGetContractCompatibility handles contract ABI compatibility requests
+// @Summary Get contract ABI compatibility
+// @Description Reads the deployed code of every configured contract, through its EIP-1967 proxy when it is one, and compares the function selectors it dispatches with the ABI of the binding compiled into the server. A contract is incompatible when it lacks functions of the binding, whose calls revert, and newer when it dispatches functions the binding lacks. Selectors are recovered from the dispatcher, so a contract they cannot be recovered from is unknown.
+// @Tags status
+// @Produce json
+// @Success 200 {object} compatibility.Matrix "Compatibility of each configured contract"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/status/contracts [get]
+func (h *StatusHandler) HandleGetContractCompatibility(w http.ResponseWriter, r *http.Request) {
+	matrix, err := h.compatibility.Check(r.Context())
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to check contract compatibility")
+		return
+	}
+
+	rest.RenderJSON(w, matrix)
+}
@@ -1,9 +1,18 @@
 package handlers
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/andrey/epoch-server/internal/api/pagination"
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/queue"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
 	"github.com/go-pkgz/lgr"
 	"github.com/go-pkgz/rest"
@@ -12,14 +21,16 @@ import (
 // SubsidyHandler handles subsidy-related HTTP requests
 type SubsidyHandler struct {
 	subsidyService subsidy.Service
+	queue          queue.Service
 	logger         lgr.L
 	config         *config.Config
 }
 
 // NewSubsidyHandler creates a new subsidy handler
-func NewSubsidyHandler(subsidyService subsidy.Service, logger lgr.L, cfg *config.Config) *SubsidyHandler {
+func NewSubsidyHandler(subsidyService subsidy.Service, queueService queue.Service, logger lgr.L, cfg *config.Config) *SubsidyHandler {
 	return &SubsidyHandler{
 		subsidyService: subsidyService,
+		queue:          queueService,
 		logger:         logger,
 		config:         cfg,
 	}
@@ -27,12 +38,18 @@ func NewSubsidyHandler(subsidyService subsidy.Service, logger lgr.L, cfg *config
 
 // HandleDistributeSubsidies handles subsidy distribution requests
 // @Summary Distribute subsidies
-// @Description Initiates the distribution of subsidies for the current epoch
+// @Description Initiates the distribution of subsidies for the current epoch. With async=true the distribution is
+// @Description queued and runs in the background, its outcome polled on GET /api/jobs/{id}.
 // @Tags epochs
 // @Accept json
 // @Produce json
-// @Success 202 {object} subsidy.SubsidyDistributionResponse "Subsidy distribution accepted"
+// @Param async query bool false "Queue the distribution and return the job"
+// @Param priority query string false "Priority of the queued job (default normal)" Enums(low, normal, high)
+// @Success 202 {object} subsidy.SubsidyDistributionResponse "Subsidy distribution accepted, or queue.Job with async=true"
 // @Failure 400 {object} ErrorResponse "Bad request"
+// @Failure 409 {object} ErrorResponse "No epoch is active (epoch_not_active), pre-flight checks failed (preflight_failed), or the distribution would replace a committed one computed from other inputs (conflict)"
+// @Failure 422 {object} ErrorResponse "Vault has no yield for the epoch and pre-flight checks are enforced (insufficient_yield)"
+// @Failure 503 {object} ErrorResponse "Job queue is full (queue_full), the subgraph trails the chain head by more than SUBGRAPH_MAX_LAG blocks (subgraph_lagging), or reorgs kept orphaning the snapshot block (snapshot_stale)"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/epochs/distribute [post]
 func (h *SubsidyHandler) HandleDistributeSubsidies(w http.ResponseWriter, r *http.Request) {
@@ -40,6 +57,9 @@ func (h *SubsidyHandler) HandleDistributeSubsidies(w http.ResponseWriter, r *htt
 	vaultId := h.config.Contracts.CollectionsVault
 
 	h.logger.Logf("INFO received distribute subsidies request for vault %s", vaultId)
+	if enqueueIfAsync(w, r, h.queue, h.logger, queue.KindDistribute, queue.DistributePayload{VaultID: vaultId}) {
+		return
+	}
 
 	response, err := h.subsidyService.DistributeSubsidies(r.Context(), vaultId)
 	if err != nil {
@@ -52,3 +72,1030 @@ func (h *SubsidyHandler) HandleDistributeSubsidies(w http.ResponseWriter, r *htt
 		h.logger.Logf("ERROR failed to encode JSON response: %v", err)
 	}
 }
+
+// RejectDistributionRequest is the optional body of a rejection
+type RejectDistributionRequest struct {
+	Reason string `json:"reason"`
+}
+
+// stagedPages pages staged distributions, oldest first
+var stagedPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"createdAt", "epochNumber"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var stagedSorts = map[string]func(a, b subsidy.StagedDistribution) int{
+	"createdAt": func(a, b subsidy.StagedDistribution) int { return a.CreatedAt.Compare(b.CreatedAt) },
+	"epochNumber": func(a, b subsidy.StagedDistribution) int {
+		return pagination.CompareDecimal(a.EpochNumber, b.EpochNumber)
+	},
+}
+
+// HandleListStagedDistributions handles requests for distributions staged for approval
+// @Summary List staged distributions
+// @Description Lists distributions whose merkle root was computed but held back for approval, oldest first. The number
+// @Description of matching distributions is returned in X-Total-Count and the next page is linked in the Link header.
+// @Tags distributions
+// @Produce json
+// @Param status query string false "Filter by status: pending_approval, approved or rejected"
+// @Param limit query int false "Maximum number of distributions to return (1-1000, default 100)"
+// @Param offset query int false "Number of distributions to skip"
+// @Param sort query string false "Sort field (default createdAt)" Enums(createdAt, epochNumber)
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.StagedDistribution "Staged distributions"
+// @Header 200 {integer} X-Total-Count "Number of matching distributions across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - unknown status or invalid paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/distributions [get]
+func (h *SubsidyHandler) HandleListStagedDistributions(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), stagedPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	staged, err := h.subsidyService.ListStagedDistributions(r.Context(), r.URL.Query().Get("status"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to list staged distributions")
+		return
+	}
+
+	staged, total := pagination.Apply(staged, page, stagedSorts)
+	pagination.WritePage(w, r, page, len(staged), total)
+	rest.RenderJSON(w, staged)
+}
+
+// HandleApproveDistribution handles approval of a staged distribution
+// @Summary Approve staged distribution
+// @Description Pushes the staged merkle root on-chain and completes its epoch. Requires an approval API key.
+// @Tags distributions
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Staged distribution ID"
+// @Success 200 {object} subsidy.SubsidyDistributionResponse "Distribution approved and submitted"
+// @Failure 400 {object} ErrorResponse "Distribution is not pending approval"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "Staged distribution not found"
+// @Failure 502 {object} ErrorResponse "Merkle root transaction failed"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/distributions/{id}/approve [post]
+func (h *SubsidyHandler) HandleApproveDistribution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	h.logger.Logf("INFO received approval for staged distribution %s", id)
+
+	response, err := h.subsidyService.ApproveDistribution(r.Context(), id)
+	if err != nil {
+		h.logger.Logf("ERROR failed to approve staged distribution %s: %v", id, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to approve distribution")
+		return
+	}
+
+	rest.RenderJSON(w, response)
+}
+
+// HandleRejectDistribution handles rejection of a staged distribution
+// @Summary Reject staged distribution
+// @Description Discards a staged distribution; the next scheduled run computes a new one. Requires an approval API key.
+// @Tags distributions
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Staged distribution ID"
+// @Param request body RejectDistributionRequest false "Rejection reason"
+// @Success 200 {object} subsidy.StagedDistribution "Distribution rejected"
+// @Failure 400 {object} ErrorResponse "Distribution is not pending approval or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "Staged distribution not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/distributions/{id}/reject [post]
+func (h *SubsidyHandler) HandleRejectDistribution(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req RejectDistributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	staged, err := h.subsidyService.RejectDistribution(r.Context(), id, req.Reason)
+	if err != nil {
+		h.logger.Logf("ERROR failed to reject staged distribution %s: %v", id, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to reject distribution")
+		return
+	}
+
+	rest.RenderJSON(w, staged)
+}
+
+// HandleExplainAllocation handles requests for the computation behind a user's subsidy
+// @Summary Explain user allocation
+// @Description Recomputes a user's amount in an epoch's distribution from the subgraph state at the snapshot block, using the distributor's own valuation, and lists deposit-seconds, weights and amount per collection with the user's share of the vault total
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param address path string true "User address" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Success 200 {object} subsidy.AllocationExplanation "Computation trail"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address or epoch"
+// @Failure 404 {object} ErrorResponse "No distribution for the epoch or no allocation for the user"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/epochs/{id}/users/{address}/explain [get]
+func (h *SubsidyHandler) HandleExplainAllocation(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	userAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid user address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	explanation, err := h.subsidyService.ExplainAllocation(r.Context(), vaultAddress, epochNumber, userAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to explain allocation of %s in vault %s epoch %s: %v", userAddress, vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to explain allocation")
+		return
+	}
+
+	rest.RenderJSON(w, explanation)
+}
+
+// ExplainAllocationsRequest lists the users to explain
+type ExplainAllocationsRequest struct {
+	Users []string `json:"users"`
+}
+
+// HandleExplainAllocations handles requests for the computation behind many users' subsidies
+// @Summary Explain many user allocations
+// @Description Explains up to 1000 users' amounts in an epoch's distribution like the single-user endpoint, reading their subgraph state in batched queries. Users without an allocation are listed in notFound.
+// @Tags vaults
+// @Accept json
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param request body ExplainAllocationsRequest true "Users to explain"
+// @Success 200 {object} subsidy.AllocationExplanations "Computation trails"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address, epoch or too many users"
+// @Failure 404 {object} ErrorResponse "No distribution for the epoch"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/epochs/{id}/explain [post]
+func (h *SubsidyHandler) HandleExplainAllocations(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	var req ExplainAllocationsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+	users := make([]string, len(req.Users))
+	for i, address := range req.Users {
+		if users[i], err = utils.ValidateAndNormalizeAddress(address); err != nil {
+			writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid user address format")
+			return
+		}
+	}
+
+	explanations, err := h.subsidyService.ExplainAllocations(r.Context(), vaultAddress, epochNumber, users)
+	if err != nil {
+		h.logger.Logf("ERROR failed to explain %d allocations in vault %s epoch %s: %v", len(users), vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to explain allocations")
+		return
+	}
+
+	rest.RenderJSON(w, explanations)
+}
+
+// quarantinePages pages quarantined accounts by snapshot block
+var quarantinePages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"blockNumber", "quarantinedAt", "account"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var quarantineSorts = map[string]func(a, b subsidy.QuarantinedAccount) int{
+	"blockNumber":   func(a, b subsidy.QuarantinedAccount) int { return cmp.Compare(a.BlockNumber, b.BlockNumber) },
+	"quarantinedAt": func(a, b subsidy.QuarantinedAccount) int { return a.QuarantinedAt.Compare(b.QuarantinedAt) },
+	"account":       func(a, b subsidy.QuarantinedAccount) int { return strings.Compare(a.Account, b.Account) },
+}
+
+// HandleListQuarantinedAccounts handles requests for account subsidies distributions skipped
+// @Summary List quarantined accounts
+// @Description Lists the account subsidies distributions set aside because their subgraph records were malformed (invalid address, negative or unparsable values), with the raw values and reason, for manual review. The distribution completed for every other account. The number of matching accounts is returned in X-Total-Count and the next page is linked in the Link header.
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param epoch query string false "Only the quarantine of this epoch" example:"5"
+// @Param limit query int false "Maximum number of accounts to return (1-1000, default 100)"
+// @Param offset query int false "Number of accounts to skip"
+// @Param sort query string false "Sort field (default blockNumber)" Enums(blockNumber, quarantinedAt, account)
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.QuarantinedAccount "Quarantined account subsidies ordered by snapshot block"
+// @Header 200 {integer} X-Total-Count "Number of matching accounts across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address, epoch or paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/quarantine [get]
+func (h *SubsidyHandler) HandleListQuarantinedAccounts(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.URL.Query().Get("epoch")
+	page, err := pagination.Parse(r.URL.Query(), quarantinePages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	accounts, err := h.subsidyService.ListQuarantinedAccounts(r.Context(), vaultAddress, epochNumber)
+	if err != nil {
+		h.logger.Logf("ERROR failed to list quarantined accounts of vault %s: %v", vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list quarantined accounts")
+		return
+	}
+
+	accounts, total := pagination.Apply(accounts, page, quarantineSorts)
+	pagination.WritePage(w, r, page, len(accounts), total)
+	rest.RenderJSON(w, accounts)
+}
+
+// historyPages pages a user's epoch positions, latest epoch first
+var historyPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"epochNumber", "blockNumber"}, DefaultOrder: pagination.OrderDesc,
+}
+
+var historySorts = map[string]func(a, b subsidy.EpochPosition) int{
+	"epochNumber": func(a, b subsidy.EpochPosition) int {
+		return cmp.Or(pagination.CompareDecimal(a.EpochNumber, b.EpochNumber), strings.Compare(a.VaultID, b.VaultID))
+	},
+	"blockNumber": func(a, b subsidy.EpochPosition) int { return cmp.Compare(a.BlockNumber, b.BlockNumber) },
+}
+
+// HandleGetUserHistory handles requests for a user's participation history
+// @Summary Get user participation history
+// @Description Returns the user's position in every epoch distribution the server saved: deposit-seconds, NFTs counted, borrow volume, and what the epoch's tree paid and the user had claimed. Positions are recorded when a distribution is saved, from the subgraph state at its snapshot block, so no subgraph is queried and epochs distributed before positions were recorded are missing. The number of matching positions is returned in X-Total-Count and the next page is linked in the Link header.
+// @Tags users
+// @Produce json
+// @Param address path string true "User wallet address" example:"0x1234567890123456789012345678901234567890"
+// @Param vault query string false "Only the positions in this vault" example:"0x1234567890123456789012345678901234567890"
+// @Param limit query int false "Maximum number of positions to return (1-1000, default 100)"
+// @Param offset query int false "Number of positions to skip"
+// @Param sort query string false "Sort field (default epochNumber)" Enums(epochNumber, blockNumber)
+// @Param order query string false "Sort order (default desc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.EpochPosition "Positions, latest epoch first"
+// @Header 200 {integer} X-Total-Count "Number of matching positions across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address or paging parameters"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{address}/history [get]
+func (h *SubsidyHandler) HandleGetUserHistory(w http.ResponseWriter, r *http.Request) {
+	userAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid user address format")
+		return
+	}
+	page, err := pagination.Parse(r.URL.Query(), historyPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	positions, err := h.subsidyService.UserHistory(r.Context(), userAddress, r.URL.Query().Get("vault"))
+	if err != nil {
+		h.logger.Logf("ERROR failed to get history of user %s: %v", userAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get user history")
+		return
+	}
+
+	positions, total := pagination.Apply(positions, page, historySorts)
+	pagination.WritePage(w, r, page, len(positions), total)
+	rest.RenderJSON(w, positions)
+}
+
+// HandleGetUserExpirations handles requests for when a user's unclaimed amounts expire
+// @Summary Get user claim expirations
+// @Description Returns what the user has yet to claim of what each epoch's tree added to its total, with the epoch whose tree leaves it out when it is still unclaimed by then. Claims settle the oldest amounts first and are as the vault's latest distribution saw them at its snapshot block. Read from the positions recorded when distributions were saved, so no subgraph is queried. Empty when claims do not expire.
+// @Tags users
+// @Produce json
+// @Param address path string true "User wallet address" example:"0x1234567890123456789012345678901234567890"
+// @Param vault query string false "Only the amounts in this vault" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} subsidy.UserExpirations "Unclaimed amounts, soonest to expire first"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/users/{address}/expirations [get]
+func (h *SubsidyHandler) HandleGetUserExpirations(w http.ResponseWriter, r *http.Request) {
+	userAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid user address format")
+		return
+	}
+
+	expirations, err := h.subsidyService.UserExpirations(r.Context(), userAddress, r.URL.Query().Get("vault"))
+	if err != nil {
+		h.logger.Logf("ERROR failed to get claim expirations of user %s: %v", userAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get claim expirations")
+		return
+	}
+	rest.RenderJSON(w, expirations)
+}
+
+// HandleReplayEpoch handles requests to recompute a past epoch's distribution
+// @Summary Replay epoch distribution
+// @Description Recomputes an epoch's allocations and merkle root from the subgraph state at its snapshot block with the code and caps running now, and diffs them per account against the distribution stored when the epoch was distributed. Streams the whole vault from the subgraph. With async=true the replay is queued and runs in the background, its result polled on GET /api/jobs/{id}.
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param async query bool false "Queue the replay and return the job"
+// @Param priority query string false "Priority of the queued job (default normal)" Enums(low, normal, high)
+// @Success 200 {object} subsidy.ReplayResult "Recomputed distribution and drift"
+// @Success 202 {object} queue.Job "Replay queued"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address or epoch"
+// @Failure 404 {object} ErrorResponse "No distribution for the epoch"
+// @Failure 503 {object} ErrorResponse "Job queue is full"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/epochs/{id}/replay [get]
+func (h *SubsidyHandler) HandleReplayEpoch(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+	if enqueueIfAsync(w, r, h.queue, h.logger, queue.KindReplay, queue.ReplayPayload{VaultID: vaultAddress, EpochNumber: epochNumber}) {
+		return
+	}
+
+	result, err := h.subsidyService.ReplayEpoch(r.Context(), vaultAddress, epochNumber)
+	if err != nil {
+		h.logger.Logf("ERROR failed to replay vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to replay epoch")
+		return
+	}
+
+	rest.RenderJSON(w, result)
+}
+
+// HandleDiffAllocations handles requests to compare an epoch's distribution with the previous one
+// @Summary Diff epoch distribution against the previous epoch
+// @Description Compares the epoch's stored distribution with the vault's latest distribution before it: new and
+// @Description dropped accounts, the accounts whose cumulative amount grew or shrank the most, and the growth of
+// @Description the total. The same diff is computed whenever an epoch is distributed or replayed, and is included
+// @Description in staged distributions and their distribution.pending_approval alerts.
+// @Tags vaults
+// @Produce json
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param top query int false "Accounts listed per change kind (1-100, default 10)"
+// @Success 200 {object} subsidy.AllocationDiff "Changes against the previous distribution"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid address, epoch or top"
+// @Failure 404 {object} ErrorResponse "No distribution for the epoch"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/vaults/{vault}/epochs/{id}/diff [get]
+func (h *SubsidyHandler) HandleDiffAllocations(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+	top := 0
+	if value := r.URL.Query().Get("top"); value != "" {
+		if top, err = strconv.Atoi(value); err != nil || top < 1 {
+			writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "invalid top parameter, expected a positive integer")
+			return
+		}
+	}
+
+	diff, err := h.subsidyService.DiffAllocations(r.Context(), vaultAddress, epochNumber, top)
+	if err != nil {
+		h.logger.Logf("ERROR failed to diff vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to diff epoch distribution")
+		return
+	}
+
+	rest.RenderJSON(w, diff)
+}
+
+// HandleGetEpochStats handles requests for the fairness statistics of an epoch's distributions
+// @Summary Get epoch distribution statistics
+// @Description Summarizes how concentrated each vault's distribution of the epoch is: the Gini coefficient, the share
+// @Description the 10 largest allocations receive, the median and mean allocation, and how many allocations are
+// @Description below the configured dust threshold. Statistics are recorded whenever an epoch is distributed; a
+// @Description vault distributed before they were has them computed from its stored distribution.
+// @Tags epochs
+// @Produce json
+// @Param id path string true "Epoch number" example:"5"
+// @Param vault query string false "Vault address, every vault distributed in the epoch when omitted" example:"0x1234567890123456789012345678901234567890"
+// @Success 200 {object} subsidy.EpochStats "Distribution statistics by vault"
+// @Failure 400 {object} ErrorResponse "Bad request - invalid epoch or vault address"
+// @Failure 404 {object} ErrorResponse "No distribution of the vault for the epoch"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/epochs/{id}/stats [get]
+func (h *SubsidyHandler) HandleGetEpochStats(w http.ResponseWriter, r *http.Request) {
+	epochNumber := r.PathValue("id")
+	vaultId := r.URL.Query().Get("vault")
+
+	stats, err := h.subsidyService.EpochStats(r.Context(), epochNumber, vaultId)
+	if err != nil {
+		h.logger.Logf("ERROR failed to get distribution stats of epoch %s: %v", epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to get epoch distribution statistics")
+		return
+	}
+
+	rest.RenderJSON(w, stats)
+}
+
+// PinSnapshotBlockRequest is the block an epoch's distribution is snapshotted at
+type PinSnapshotBlockRequest struct {
+	BlockNumber uint64 `json:"blockNumber" example:"19000000"`
+}
+
+// HandlePinSnapshotBlock handles pinning the snapshot block of an epoch's distribution
+// @Summary Pin epoch snapshot block
+// @Description Makes the epoch's distribution snapshot the vault at the given block instead of the block the
+// @Description configured snapshot strategy would choose. The block must already be on the chain; pinning again
+// @Description replaces it. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param request body PinSnapshotBlockRequest true "Block to snapshot at"
+// @Success 200 {object} subsidy.SnapshotBlockPin "Block pinned"
+// @Failure 400 {object} ErrorResponse "Invalid address, epoch or block, or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/epochs/{id}/snapshot-block [post]
+func (h *SubsidyHandler) HandlePinSnapshotBlock(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	var req PinSnapshotBlockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	pin, err := h.subsidyService.PinSnapshotBlock(r.Context(), vaultAddress, epochNumber, req.BlockNumber)
+	if err != nil {
+		h.logger.Logf("ERROR failed to pin snapshot block of vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to pin snapshot block")
+		return
+	}
+
+	rest.RenderJSON(w, pin)
+}
+
+// OverrideFingerprintRequest is why a committed distribution may be replaced by one computed from other inputs
+type OverrideFingerprintRequest struct {
+	Reason string `json:"reason" example:"subgraph reindexed the snapshot block"`
+}
+
+// HandleOverrideFingerprint handles letting a committed epoch distribution be replaced
+// @Summary Override committed distribution fingerprint
+// @Description Lets the next distribution of the epoch replace its committed distribution although it was computed
+// @Description from other inputs than the committed fingerprint. Without an override such a distribution is refused.
+// @Description The override applies to one distribution, and not after the committed distribution changed.
+// @Description Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param request body OverrideFingerprintRequest true "Why the committed distribution may be replaced"
+// @Success 200 {object} subsidy.FingerprintOverride "Override stored"
+// @Failure 400 {object} ErrorResponse "Invalid address or epoch, missing reason, or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "Epoch has no committed distribution"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/epochs/{id}/fingerprint-override [post]
+func (h *SubsidyHandler) HandleOverrideFingerprint(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	var req OverrideFingerprintRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	override, err := h.subsidyService.OverrideFingerprint(r.Context(), vaultAddress, epochNumber, req.Reason)
+	if err != nil {
+		h.logger.Logf("ERROR failed to override fingerprint of vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to override fingerprint")
+		return
+	}
+
+	rest.RenderJSON(w, override)
+}
+
+// ReplaceRootRequest is why the root pushed for an epoch is replaced, and whether to push the replacement
+type ReplaceRootRequest struct {
+	Reason  string `json:"reason" example:"blocked account was left in the tree"`
+	Confirm bool   `json:"confirm"` // push the recomputed root, otherwise only report it
+}
+
+// HandleReplaceRoot handles replacing the merkle root pushed for a vault's latest epoch
+// @Summary Replace an epoch's merkle root
+// @Description Recomputes the distribution of the vault's latest epoch with what the epoch was carried in, runs the
+// @Description leaf total and finalization checks on it again and reports the recomputed root against the pushed one.
+// @Description With confirm set the recomputed root is pushed with its total in place of the pushed root, whose tree
+// @Description is kept and recorded as superseded, so proofs against either root resolve and report the replacement.
+// @Description Only the latest epoch's root can be replaced, and only while it is the vault's on-chain root.
+// @Description Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param request body ReplaceRootRequest true "Why the root is replaced and whether to push the replacement"
+// @Success 200 {object} subsidy.RootReplacement "Recomputed root, pushed when confirmed"
+// @Failure 400 {object} ErrorResponse "Invalid address or epoch, missing reason, or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "Epoch has no distribution"
+// @Failure 409 {object} ErrorResponse "Epoch is not the latest, its root is not on-chain, recomputes to the same root, or failed an enforced check"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 502 {object} ErrorResponse "Replacement transaction failed"
+// @Router /admin/vaults/{vault}/epochs/{id}/replace-root [post]
+func (h *SubsidyHandler) HandleReplaceRoot(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	var req ReplaceRootRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	replacement, err := h.subsidyService.ReplaceRoot(r.Context(), vaultAddress, epochNumber, req.Reason, req.Confirm)
+	if err != nil {
+		h.logger.Logf("ERROR failed to replace root of vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to replace merkle root")
+		return
+	}
+
+	rest.RenderJSON(w, replacement)
+}
+
+// collectionWeightPages pages collection weights by collection address
+var collectionWeightPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"collection", "fromEpoch", "setAt"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var collectionWeightSorts = map[string]func(a, b subsidy.CollectionWeight) int{
+	"collection": func(a, b subsidy.CollectionWeight) int { return strings.Compare(a.Collection, b.Collection) },
+	"fromEpoch":  func(a, b subsidy.CollectionWeight) int { return cmp.Compare(a.FromEpoch, b.FromEpoch) },
+	"setAt":      func(a, b subsidy.CollectionWeight) int { return a.SetAt.Compare(b.SetAt) },
+}
+
+// HandleListCollectionWeights handles requests for the weights set on a vault's collections
+// @Summary List collection weights
+// @Description Lists the multipliers set on the vault's collections with the epochs they apply to, who set them and why.
+// @Description The number of weights is returned in X-Total-Count and the next page is linked in the Link header.
+// @Description Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param limit query int false "Maximum number of weights to return (1-1000, default 100)"
+// @Param offset query int false "Number of weights to skip"
+// @Param sort query string false "Sort field (default collection)" Enums(collection, fromEpoch, setAt)
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.CollectionWeight "Collection weights"
+// @Header 200 {integer} X-Total-Count "Number of weights across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Invalid address or paging parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/collection-weights [get]
+func (h *SubsidyHandler) HandleListCollectionWeights(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	page, err := pagination.Parse(r.URL.Query(), collectionWeightPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	weights, err := h.subsidyService.ListCollectionWeights(r.Context(), vaultAddress)
+	if err != nil {
+		h.logger.Logf("ERROR failed to list collection weights of vault %s: %v", vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list collection weights")
+		return
+	}
+
+	weights, total := pagination.Apply(weights, page, collectionWeightSorts)
+	pagination.WritePage(w, r, page, len(weights), total)
+	rest.RenderJSON(w, weights)
+}
+
+// SetCollectionWeightRequest is the multiplier a collection's subsidies are weighted with and the epochs it applies to
+type SetCollectionWeightRequest struct {
+	Multiplier string `json:"multiplier" example:"1.5"`
+	FromEpoch  uint64 `json:"fromEpoch" example:"5"`
+	ToEpoch    uint64 `json:"toEpoch" example:"8"`
+	Reason     string `json:"reason,omitempty" example:"launch campaign"`
+}
+
+// HandleSetCollectionWeight handles setting the weight of a vault's collection
+// @Summary Set collection weight
+// @Description Weights the subsidies earned in the collection by a multiplier (above 0, at most 100) in the
+// @Description distributions of epochs fromEpoch to toEpoch inclusive. Setting a weight again replaces it;
+// @Description distributions already built keep the weights they were built with. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param collection path string true "Collection address" example:"0x1234567890123456789012345678901234567890"
+// @Param request body SetCollectionWeightRequest true "Multiplier and epochs"
+// @Success 200 {object} subsidy.CollectionWeight "Weight set"
+// @Failure 400 {object} ErrorResponse "Invalid address, multiplier or epochs, or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/collection-weights/{collection} [put]
+func (h *SubsidyHandler) HandleSetCollectionWeight(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	collection, err := utils.ValidateAndNormalizeAddress(r.PathValue("collection"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid collection address format")
+		return
+	}
+
+	var req SetCollectionWeightRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	weight, err := h.subsidyService.SetCollectionWeight(r.Context(), subsidy.CollectionWeight{
+		VaultID:    vaultAddress,
+		Collection: collection,
+		Multiplier: req.Multiplier,
+		FromEpoch:  req.FromEpoch,
+		ToEpoch:    req.ToEpoch,
+		Reason:     req.Reason,
+	})
+	if err != nil {
+		h.logger.Logf("ERROR failed to set weight of collection %s in vault %s: %v", collection, vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to set collection weight")
+		return
+	}
+
+	rest.RenderJSON(w, weight)
+}
+
+// HandleDeleteCollectionWeight handles removing the weight of a vault's collection
+// @Summary Delete collection weight
+// @Description Removes the collection's weight so later distributions value its subsidies unweighted.
+// @Description Distributions already built keep the weight. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param collection path string true "Collection address" example:"0x1234567890123456789012345678901234567890"
+// @Success 204 "Weight removed"
+// @Failure 400 {object} ErrorResponse "Invalid address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "The collection has no weight"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/collection-weights/{collection} [delete]
+func (h *SubsidyHandler) HandleDeleteCollectionWeight(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	collection, err := utils.ValidateAndNormalizeAddress(r.PathValue("collection"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid collection address format")
+		return
+	}
+
+	if err := h.subsidyService.DeleteCollectionWeight(r.Context(), vaultAddress, collection); err != nil {
+		h.logger.Logf("ERROR failed to delete weight of collection %s in vault %s: %v", collection, vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to delete collection weight")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// blocklistPages pages the blocklist by address
+var blocklistPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"address", "blockedAt"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var blocklistSorts = map[string]func(a, b subsidy.BlockedAddress) int{
+	"address":   func(a, b subsidy.BlockedAddress) int { return strings.Compare(a.Address, b.Address) },
+	"blockedAt": func(a, b subsidy.BlockedAddress) int { return a.BlockedAt.Compare(b.BlockedAt) },
+}
+
+// HandleListBlockedAddresses handles requests for the blocklist
+// @Summary List blocked addresses
+// @Description Lists the sanctioned or compromised addresses left out of every distribution, with who blocked them
+// @Description and why. The number of addresses is returned in X-Total-Count and the next page is linked in the
+// @Description Link header. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Maximum number of addresses to return (1-1000, default 100)"
+// @Param offset query int false "Number of addresses to skip"
+// @Param sort query string false "Sort field (default address)" Enums(address, blockedAt)
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.BlockedAddress "Blocked addresses"
+// @Header 200 {integer} X-Total-Count "Number of blocked addresses across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Invalid paging parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/blocklist [get]
+func (h *SubsidyHandler) HandleListBlockedAddresses(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), blocklistPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	blocked, err := h.subsidyService.ListBlockedAddresses(r.Context())
+	if err != nil {
+		h.logger.Logf("ERROR failed to list blocked addresses: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list blocked addresses")
+		return
+	}
+
+	blocked, total := pagination.Apply(blocked, page, blocklistSorts)
+	pagination.WritePage(w, r, page, len(blocked), total)
+	rest.RenderJSON(w, blocked)
+}
+
+// BlockAddressRequest is why an address is blocklisted
+type BlockAddressRequest struct {
+	Reason string `json:"reason,omitempty" example:"OFAC sanctions list"`
+}
+
+// HandleBlockAddress handles adding an address to the blocklist
+// @Summary Block address
+// @Description Leaves the address out of the merkle trees of every distribution built from now on. What it would have
+// @Description received is burned or spread over the other allocations as BLOCKLIST_REMAINDER configures. Blocking
+// @Description again replaces the reason. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param address path string true "Address to block" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Param request body BlockAddressRequest false "Reason for blocking"
+// @Success 200 {object} subsidy.BlockedAddress "Address blocked"
+// @Failure 400 {object} ErrorResponse "Invalid address or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/blocklist/{address} [put]
+func (h *SubsidyHandler) HandleBlockAddress(w http.ResponseWriter, r *http.Request) {
+	address, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid address format")
+		return
+	}
+
+	var req BlockAddressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	blocked, err := h.subsidyService.BlockAddress(r.Context(), subsidy.BlockedAddress{Address: address, Reason: req.Reason})
+	if err != nil {
+		h.logger.Logf("ERROR failed to block address %s: %v", address, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to block address")
+		return
+	}
+
+	rest.RenderJSON(w, blocked)
+}
+
+// HandleUnblockAddress handles removing an address from the blocklist
+// @Summary Unblock address
+// @Description Removes the address from the blocklist so later distributions include it again. Distributions
+// @Description already built keep it out. Requires an admin API key.
+// @Tags admin
+// @Security ApiKeyAuth
+// @Param address path string true "Address to unblock" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+// @Success 204 "Address unblocked"
+// @Failure 400 {object} ErrorResponse "Invalid address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 404 {object} ErrorResponse "The address is not blocked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/blocklist/{address} [delete]
+func (h *SubsidyHandler) HandleUnblockAddress(w http.ResponseWriter, r *http.Request) {
+	address, err := utils.ValidateAndNormalizeAddress(r.PathValue("address"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid address format")
+		return
+	}
+
+	if err := h.subsidyService.UnblockAddress(r.Context(), address); err != nil {
+		h.logger.Logf("ERROR failed to unblock address %s: %v", address, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to unblock address")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ProposeAdjustmentRequest is a manual correction of an account's allocation
+type ProposeAdjustmentRequest struct {
+	Account       string `json:"account" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	Amount        string `json:"amount" example:"-250000000000000000"`
+	Justification string `json:"justification" example:"deposit credited twice after the subgraph reorg at block 19000000"`
+}
+
+// HandleProposeAdjustment handles proposing a manual allocation adjustment
+// @Summary Propose allocation adjustment
+// @Description Proposes adding the signed amount of wei to, or taking it from, the account's allocation in the epoch's
+// @Description distribution. The amount is bounded by ADJUSTMENTS_MAX_AMOUNT and a justification is required. The
+// @Description adjustment applies to distributions built after an admin with another API key approves it, and is
+// @Description recorded in their fingerprint. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param id path string true "Epoch number" example:"5"
+// @Param request body ProposeAdjustmentRequest true "Account, signed amount and justification"
+// @Success 200 {object} subsidy.AllocationAdjustment "Adjustment pending approval"
+// @Failure 400 {object} ErrorResponse "Invalid address, epoch or amount, missing justification, adjustments disabled, or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults/{vault}/epochs/{id}/adjustments [post]
+func (h *SubsidyHandler) HandleProposeAdjustment(w http.ResponseWriter, r *http.Request) {
+	vaultAddress, err := utils.ValidateAndNormalizeAddress(r.PathValue("vault"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid vault address format")
+		return
+	}
+	epochNumber := r.PathValue("id")
+
+	var req ProposeAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	adjustment, err := h.subsidyService.ProposeAdjustment(r.Context(), subsidy.AllocationAdjustment{
+		VaultID:       vaultAddress,
+		EpochNumber:   epochNumber,
+		Account:       req.Account,
+		Amount:        req.Amount,
+		Justification: req.Justification,
+	})
+	if err != nil {
+		h.logger.Logf("ERROR failed to propose adjustment of vault %s epoch %s: %v", vaultAddress, epochNumber, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to propose adjustment")
+		return
+	}
+
+	rest.RenderJSON(w, adjustment)
+}
+
+// adjustmentPages pages allocation adjustments, oldest first
+var adjustmentPages = pagination.Spec{
+	DefaultLimit: 100, MaxLimit: 1000, Sorts: []string{"proposedAt"}, DefaultOrder: pagination.OrderAsc,
+}
+
+var adjustmentSorts = map[string]func(a, b subsidy.AllocationAdjustment) int{
+	"proposedAt": func(a, b subsidy.AllocationAdjustment) int { return a.ProposedAt.Compare(b.ProposedAt) },
+}
+
+// HandleListAdjustments handles requests for manual allocation adjustments
+// @Summary List allocation adjustments
+// @Description Lists manual allocation adjustments with who proposed and decided them, filtered by vault, epoch and
+// @Description status when they are given. The number of adjustments is returned in X-Total-Count and the next page
+// @Description is linked in the Link header. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault query string false "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param epoch query string false "Epoch number" example:"5"
+// @Param status query string false "Adjustment status" Enums(pending_approval, approved, rejected)
+// @Param limit query int false "Maximum number of adjustments to return (1-1000, default 100)"
+// @Param offset query int false "Number of adjustments to skip"
+// @Param sort query string false "Sort field (default proposedAt)" Enums(proposedAt)
+// @Param order query string false "Sort order (default asc)" Enums(asc, desc)
+// @Success 200 {array} subsidy.AllocationAdjustment "Allocation adjustments"
+// @Header 200 {integer} X-Total-Count "Number of matching adjustments across all pages"
+// @Header 200 {string} Link "URL of the next page, rel=next"
+// @Failure 400 {object} ErrorResponse "Invalid vault, epoch, status or paging parameters"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/adjustments [get]
+func (h *SubsidyHandler) HandleListAdjustments(w http.ResponseWriter, r *http.Request) {
+	page, err := pagination.Parse(r.URL.Query(), adjustmentPages)
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "invalid paging parameters")
+		return
+	}
+
+	query := r.URL.Query()
+	adjustments, err := h.subsidyService.ListAdjustments(r.Context(), query.Get("vault"), query.Get("epoch"), query.Get("status"))
+	if err != nil {
+		writeErrorResponse(w, r, h.logger, err, "Failed to list adjustments")
+		return
+	}
+
+	adjustments, total := pagination.Apply(adjustments, page, adjustmentSorts)
+	pagination.WritePage(w, r, page, len(adjustments), total)
+	rest.RenderJSON(w, adjustments)
+}
+
+// HandleApproveAdjustment handles approval of a manual allocation adjustment
+// @Summary Approve allocation adjustment
+// @Description Approves a pending adjustment so distributions of its epoch built from now on apply it. The adjustment
+// @Description cannot be approved with the API key it was proposed with. Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Adjustment ID"
+// @Success 200 {object} subsidy.AllocationAdjustment "Adjustment approved"
+// @Failure 400 {object} ErrorResponse "Adjustment is not pending approval"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} ErrorResponse "The approver proposed the adjustment"
+// @Failure 404 {object} ErrorResponse "Adjustment not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/adjustments/{id}/approve [post]
+func (h *SubsidyHandler) HandleApproveAdjustment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	adjustment, err := h.subsidyService.ApproveAdjustment(r.Context(), id)
+	if err != nil {
+		h.logger.Logf("ERROR failed to approve adjustment %s: %v", id, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to approve adjustment")
+		return
+	}
+
+	rest.RenderJSON(w, adjustment)
+}
+
+// HandleRejectAdjustment handles rejection of a manual allocation adjustment
+// @Summary Reject allocation adjustment
+// @Description Discards a pending adjustment. The adjustment cannot be rejected with the API key it was proposed
+// @Description with. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Adjustment ID"
+// @Param request body RejectDistributionRequest false "Rejection reason"
+// @Success 200 {object} subsidy.AllocationAdjustment "Adjustment rejected"
+// @Failure 400 {object} ErrorResponse "Adjustment is not pending approval or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 403 {object} ErrorResponse "The rejecting admin proposed the adjustment"
+// @Failure 404 {object} ErrorResponse "Adjustment not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/adjustments/{id}/reject [post]
+func (h *SubsidyHandler) HandleRejectAdjustment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req RejectDistributionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeErrorResponse(w, r, h.logger, subsidy.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	adjustment, err := h.subsidyService.RejectAdjustment(r.Context(), id, req.Reason)
+	if err != nil {
+		h.logger.Logf("ERROR failed to reject adjustment %s: %v", id, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to reject adjustment")
+		return
+	}
+
+	rest.RenderJSON(w, adjustment)
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/swaggo/swag"
+)
+
+// SwaggerHandler serves the generated OpenAPI document
+type SwaggerHandler struct {
+	logger lgr.L
+}
+
+// NewSwaggerHandler creates a new swagger handler
+func NewSwaggerHandler(logger lgr.L) *SwaggerHandler {
+	return &SwaggerHandler{
+		logger: logger,
+	}
+}
+
+// HandleSpec serves the OpenAPI document registered by the docs package, for client generators
+func (h *SwaggerHandler) HandleSpec(w http.ResponseWriter, r *http.Request) {
+	doc, err := swag.ReadDoc()
+	if err != nil {
+		h.logger.Logf("ERROR failed to read swagger document: %v", err)
+		http.Error(w, "swagger document unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(doc)); err != nil {
+		h.logger.Logf("ERROR failed to write swagger document: %v", err)
+	}
+}
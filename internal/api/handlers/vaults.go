@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+// VaultsHandler handles vault onboarding and decommissioning HTTP requests
+type VaultsHandler struct {
+	vaultsService vaults.Service
+	logger        lgr.L
+	config        *config.Config
+}
+
+// NewVaultsHandler creates a new vaults handler
+func NewVaultsHandler(vaultsService vaults.Service, logger lgr.L, cfg *config.Config) *VaultsHandler {
+	return &VaultsHandler{
+		vaultsService: vaultsService,
+		logger:        logger,
+		config:        cfg,
+	}
+}
+
+// HandleOnboardVault handles vault onboarding requests
+// @Summary Onboard a vault
+// @Description Adds the vault to the DebtSubsidizer (IDebtSubsidizer.addVault), grants it the vault role on the
+// @Description EpochManager, whitelists the given collections and registers it in the server's vault registry. Steps
+// @Description already done are skipped, so a failed onboarding is resumed by sending the same request again. When a
+// @Description step fails the later ones do not run and the response is 502 with every step's outcome and the calls
+// @Description that roll back the steps this request did. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body vaults.OnboardRequest true "Vault to onboard"
+// @Success 200 {object} vaults.OnboardingResult "Vault onboarded"
+// @Failure 400 {object} ErrorResponse "Invalid address or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 409 {object} ErrorResponse "Vault was decommissioned"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 502 {object} vaults.OnboardingResult "A step failed, the result lists the rollback calls"
+// @Router /admin/vaults [post]
+func (h *VaultsHandler) HandleOnboardVault(w http.ResponseWriter, r *http.Request) {
+	var req vaults.OnboardRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, vaults.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	result, err := h.vaultsService.Onboard(r.Context(), req)
+	if err != nil {
+		h.logger.Logf("ERROR failed to onboard vault %s: %v", req.VaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to onboard vault")
+		return
+	}
+
+	if !result.Complete {
+		if err := rest.EncodeJSON(w, http.StatusBadGateway, result); err != nil {
+			h.logger.Logf("ERROR failed to encode onboarding response: %v", err)
+		}
+		return
+	}
+	rest.RenderJSON(w, result)
+}
+
+// HandleDecommissionVault handles vault decommissioning requests
+// @Summary Decommission a vault
+// @Description Takes the vault out of scheduler runs: no yield allocation, distribution or reconciliation runs for it,
+// @Description while its stored distributions, proofs and claims stay served. With confirm the vault is also removed
+// @Description from the DebtSubsidizer (IDebtSubsidizer.removeVault), which cannot be undone; until then onboarding it
+// @Description again puts it back into scheduler runs. A reason is required the first time. Requires an admin API key.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param vault path string true "Vault address" example:"0x1234567890123456789012345678901234567890"
+// @Param request body vaults.DecommissionRequest true "Reason and whether to remove the vault from the DebtSubsidizer"
+// @Success 200 {object} vaults.Vault "Vault decommissioning, or decommissioned once confirmed"
+// @Failure 400 {object} ErrorResponse "Invalid vault address, missing reason or malformed body"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 502 {object} ErrorResponse "removeVault transaction failed, the vault stays out of scheduler runs"
+// @Router /admin/vaults/{vault}/decommission [post]
+func (h *VaultsHandler) HandleDecommissionVault(w http.ResponseWriter, r *http.Request) {
+	vaultAddress := r.PathValue("vault")
+
+	var req vaults.DecommissionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorResponse(w, r, h.logger, vaults.ErrInvalidInput, "Invalid request body")
+		return
+	}
+
+	vault, err := h.vaultsService.Decommission(r.Context(), vaultAddress, req)
+	if err != nil {
+		h.logger.Logf("ERROR failed to decommission vault %s: %v", vaultAddress, err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to decommission vault")
+		return
+	}
+
+	rest.RenderJSON(w, vault)
+}
+
+// HandleListVaults handles requests for the vault registry
+// @Summary List registered vaults
+// @Description Lists the vaults in the server's registry, including those whose onboarding did not complete.
+// @Description Requires an admin API key.
+// @Tags admin
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {array} vaults.Vault "Registered vaults, ordered by address"
+// @Failure 401 {object} ErrorResponse "Missing or invalid API key"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /admin/vaults [get]
+func (h *VaultsHandler) HandleListVaults(w http.ResponseWriter, r *http.Request) {
+	registry, err := h.vaultsService.ListVaults(r.Context())
+	if err != nil {
+		h.logger.Logf("ERROR failed to list vaults: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list vaults")
+		return
+	}
+
+	rest.RenderJSON(w, registry)
+}
+
+// HandleListRegisteredVaults handles public requests for the vault registry
+// @Summary List vaults
+// @Description Lists the vaults the server manages: those onboarded through /admin/vaults and, with vault discovery
+// @Description enabled, those seen in the DebtSubsidizer's VaultAdded events. Vaults removed from the DebtSubsidizer
+// @Description are listed as decommissioned, with the block of their VaultRemoved event.
+// @Tags vaults
+// @Produce json
+// @Param status query string false "Registry status" Enums(onboarding, active, decommissioning, decommissioned)
+// @Success 200 {array} vaults.Vault "Vaults, ordered by address"
+// @Failure 400 {object} ErrorResponse "Unknown status"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /vaults [get]
+func (h *VaultsHandler) HandleListRegisteredVaults(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", vaults.StatusOnboarding, vaults.StatusActive, vaults.StatusDecommissioning, vaults.StatusDecommissioned:
+	default:
+		writeErrorResponse(w, r, h.logger, fmt.Errorf("%w: unknown status %q", vaults.ErrInvalidInput, status), "Invalid status")
+		return
+	}
+
+	registry, err := h.vaultsService.ListVaults(r.Context())
+	if err != nil {
+		h.logger.Logf("ERROR failed to list vaults: %v", err)
+		writeErrorResponse(w, r, h.logger, err, "Failed to list vaults")
+		return
+	}
+
+	listed := make([]vaults.Vault, 0, len(registry))
+	for _, vault := range registry {
+		if status == "" || vault.Status == status {
+			listed = append(listed, vault)
+		}
+	}
+	rest.RenderJSON(w, listed)
+}
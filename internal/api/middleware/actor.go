@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/services/audit"
+)
+
+// Actor creates a middleware that attributes actions taken while serving a request to the calling client.
+// It relies on rest.RealIP having resolved the client address.
+func Actor() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := r.RemoteAddr
+			if host, _, err := net.SplitHostPort(client); err == nil {
+				client = host
+			}
+			next.ServeHTTP(w, r.WithContext(audit.WithActor(r.Context(), "api:"+client)))
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/go-pkgz/lgr"
+)
+
+// formattedSuffix is appended to an amount field's name for the field holding it in whole tokens
+const formattedSuffix = "Formatted"
+
+// assetAmountFields are the response fields holding wei of a vault's asset
+var assetAmountFields = map[string]bool{
+	"allocated": true, "amount": true, "assetsDeposited": true, "available": true, "borrowVolume": true, "carriedForward": true,
+	"change": true, "claimable": true, "claimed": true, "clamped": true, "computedAmount": true, "current": true,
+	"cumulativeSubsidies": true, "cumulativeYieldAllocated": true, "difference": true, "distributedAmount": true,
+	"earned": true, "heldBack": true, "limit": true, "previous": true, "previousEarned": true, "previousTotal": true,
+	"recomputed": true, "recomputedTotal": true, "redistributed": true, "remainingSubsidies": true,
+	"roundingBonus": true, "stored": true, "storedTotal": true, "subsidies": true, "subsidiesAccrued": true,
+	"subsidiesClaimed": true, "subsidiesDistributed": true, "tolerance": true, "total": true, "totalAmount": true,
+	"totalClaimable": true, "totalClaimed": true, "totalEarned": true, "totalGrowth": true, "totalRewardsEarned": true,
+	"totalSubsidies": true, "totalSubsidiesClaimed": true, "totalSubsidiesDistributed": true,
+	"totalYieldAllocated": true, "totalYieldDistributed": true, "totalYieldGenerated": true,
+	"totalYieldReserved": true, "uncappedAmount": true, "vaultTotal": true, "vaultYieldForEpoch": true,
+	"yieldAllocated": true, "yieldGenerated": true, "yieldHeldBack": true,
+}
+
+// nativeAmountFields are the response fields holding wei of the chain's native currency, gas and signer balances
+var nativeAmountFields = map[string]bool{
+	"balance": true, "budget": true, "cost": true, "gasPrice": true, "minBalance": true, "remaining": true, "spent": true,
+}
+
+// vaultFields name the vault the amounts of an object are wei of
+var vaultFields = []string{"vaultAddress", "vaultId", "vault"}
+
+// AssetAmounts adds a <field>Formatted string next to every wei amount of a successful JSON response, the amount
+// in whole tokens, so clients do not convert wei with decimals they have to guess. Amounts are of the asset of
+// the vault the object names, else of the vault in the path or query, else of defaultVault; gas and signer
+// balances are of the native currency. Amounts of a vault whose asset cannot be read are left as they are.
+func AssetAmounts(resolver assets.Service, defaultVault string, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if resolver == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writer := &amountsWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+			if writer.buffer == nil {
+				return
+			}
+
+			body := writer.buffer.Bytes()
+			vault := defaultVault
+			if v := r.PathValue("vault"); v != "" {
+				vault = v
+			} else if v := r.URL.Query().Get("vault"); v != "" {
+				vault = v
+			}
+			formatter := &amountFormatter{r: r, resolver: resolver, logger: logger, assets: make(map[string]*assets.Asset)}
+			if formatted, err := formatter.formatJSON(body, vault); err != nil {
+				logging.FromContext(r.Context(), logger).Logf("WARN failed to add formatted amounts to %s: %v", r.URL.Path, err)
+			} else {
+				body = formatted
+			}
+
+			w.Header().Del("Content-Length")
+			w.WriteHeader(writer.status)
+			if _, err := w.Write(body); err != nil {
+				logging.FromContext(r.Context(), logger).Logf("ERROR failed to write response: %v", err)
+			}
+		})
+	}
+}
+
+// amountsWriter holds back a successful JSON response so amounts can be added to it, and passes any other
+// response through
+type amountsWriter struct {
+	http.ResponseWriter
+	status  int
+	started bool
+	buffer  *bytes.Buffer // nil when the response is passed through
+}
+
+func (w *amountsWriter) WriteHeader(status int) {
+	if w.started {
+		return
+	}
+	w.started, w.status = true, status
+	if status >= 200 && status < 300 && strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		w.buffer = &bytes.Buffer{}
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *amountsWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffer != nil {
+		return w.buffer.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// amountFormatter formats the amounts of one response, resolving each vault's asset once
+type amountFormatter struct {
+	r        *http.Request
+	resolver assets.Service
+	logger   lgr.L
+	assets   map[string]*assets.Asset // nil for vaults whose asset could not be read
+}
+
+// jsonField is a field of a decoded JSON object; objects are kept as fields so their order is kept
+type jsonField struct {
+	key   string
+	value interface{}
+}
+
+type jsonObject []jsonField
+
+// formatJSON returns body with the formatted amounts added, fields in their original order
+func (f *amountFormatter) formatJSON(body []byte, vault string) ([]byte, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	value, err := decodeJSON(decoder)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := encodeJSON(&out, f.format(value, vault)); err != nil {
+		return nil, err
+	}
+	out.WriteByte('\n')
+	return out.Bytes(), nil
+}
+
+// format adds the formatted amounts to every object in value, whose amounts are of vault unless it names another
+func (f *amountFormatter) format(value interface{}, vault string) interface{} {
+	switch v := value.(type) {
+	case jsonObject:
+		for _, field := range v {
+			if name, ok := field.value.(string); ok && slices.Contains(vaultFields, field.key) && utils.IsValidAddress(name) {
+				vault = name
+				break
+			}
+		}
+		formatted := make(jsonObject, 0, len(v))
+		for _, field := range v {
+			formatted = append(formatted, jsonField{key: field.key, value: f.format(field.value, vault)})
+			if amount, ok := f.formatAmount(v, field, vault); ok {
+				formatted = append(formatted, jsonField{key: field.key + formattedSuffix, value: amount})
+			}
+		}
+		return formatted
+	case []interface{}:
+		for i := range v {
+			v[i] = f.format(v[i], vault)
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// formatAmount returns the field's amount in whole tokens when it is a wei amount the object has no formatted
+// field for yet
+func (f *amountFormatter) formatAmount(object jsonObject, field jsonField, vault string) (string, bool) {
+	native, asset := nativeAmountFields[field.key], assetAmountFields[field.key]
+	if !native && !asset {
+		return "", false
+	}
+	raw, ok := field.value.(string)
+	if !ok {
+		return "", false
+	}
+	wei, ok := new(big.Int).SetString(raw, 10)
+	if !ok {
+		return "", false
+	}
+	for _, other := range object {
+		if other.key == field.key+formattedSuffix {
+			return "", false
+		}
+	}
+
+	if native {
+		return utils.FormatUnits(wei, assets.NativeDecimals), true
+	}
+	resolved := f.asset(vault)
+	if resolved == nil {
+		return "", false
+	}
+	return resolved.Format(wei), true
+}
+
+// asset returns the asset of the vault, nil when it cannot be read
+func (f *amountFormatter) asset(vault string) *assets.Asset {
+	vault = utils.NormalizeAddress(vault)
+	if asset, ok := f.assets[vault]; ok {
+		return asset
+	}
+	asset, err := f.resolver.GetAsset(f.r.Context(), vault)
+	if err != nil {
+		logging.FromContext(f.r.Context(), f.logger).Logf("WARN amounts of vault %s are not formatted: %v", vault, err)
+		asset = nil
+	}
+	f.assets[vault] = asset
+	return asset
+}
+
+// decodeJSON decodes the next JSON value, objects as jsonObject and numbers as json.Number
+func decodeJSON(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	delim, ok := token.(json.Delim)
+	if !ok {
+		return token, nil
+	}
+
+	switch delim {
+	case '{':
+		object := jsonObject{}
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, jsonField{key: key.(string), value: value})
+		}
+		_, err = decoder.Token() // closing brace
+		return object, err
+	case '[':
+		array := []interface{}{}
+		for decoder.More() {
+			value, err := decodeJSON(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		_, err = decoder.Token() // closing bracket
+		return array, err
+	default:
+		return nil, fmt.Errorf("unexpected %v", delim)
+	}
+}
+
+// encodeJSON writes a value decoded by decodeJSON, without escaping HTML like rest.RenderJSON
+func encodeJSON(out *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case jsonObject:
+		out.WriteByte('{')
+		for i, field := range v {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, field.key); err != nil {
+				return err
+			}
+			out.WriteByte(':')
+			if err := encodeJSON(out, field.value); err != nil {
+				return err
+			}
+		}
+		out.WriteByte('}')
+	case []interface{}:
+		out.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				out.WriteByte(',')
+			}
+			if err := encodeJSON(out, item); err != nil {
+				return err
+			}
+		}
+		out.WriteByte(']')
+	default:
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(v); err != nil {
+			return err
+		}
+		out.Truncate(out.Len() - 1) // the encoder ends every value with a newline
+	}
+	return nil
+}
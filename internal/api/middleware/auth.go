@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"encoding/json"
 	"net/http"
 
+	"github.com/andrey/epoch-server/internal/services/audit"
 	"github.com/go-pkgz/lgr"
 )
 
@@ -55,6 +57,38 @@ func RequireAuth(logger lgr.L) func(http.Handler) http.Handler {
 	}
 }
 
+// RequireAPIKey creates a middleware that only passes requests whose X-API-Key header matches one of keys.
+// With no keys configured every request is rejected. The key a request authenticated with is recorded in its
+// context as an audit credential, so actions needing a second admin can tell the two apart.
+func RequireAPIKey(keys []string, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			apiKey := r.Header.Get("X-API-Key")
+			authorized := false
+			for _, key := range keys {
+				if key != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(key)) == 1 {
+					authorized = true
+				}
+			}
+
+			if !authorized {
+				logger.Logf("WARN rejected request to %s from %s: missing or invalid API key", r.URL.Path, r.RemoteAddr)
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusUnauthorized)
+				if err := json.NewEncoder(w).Encode(map[string]interface{}{
+					"error": "Unauthorized",
+					"code":  http.StatusUnauthorized,
+				}); err != nil {
+					logger.Logf("ERROR failed to encode auth error response: %v", err)
+				}
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(audit.WithCredential(r.Context(), audit.KeyID(apiKey))))
+		})
+	}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andybalholm/brotli"
+	"github.com/go-pkgz/lgr"
+)
+
+// compressibleTypes are the content types worth compressing, everything else is passed through
+var compressibleTypes = []string{"application/json", "application/xml", "application/javascript", "text/"}
+
+// ETag answers GET and HEAD requests with a strong ETag of every successful response body, and with
+// 304 Not Modified without a body when the request's If-None-Match already names it, so integrators
+// polling large responses such as allocation lists only download them again once they change. Responses
+// that set their own ETag are passed through.
+func ETag(logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writer := &etagWriter{ResponseWriter: w}
+			next.ServeHTTP(writer, r)
+			if writer.buffer == nil {
+				if !writer.started {
+					w.WriteHeader(http.StatusOK)
+				}
+				return
+			}
+
+			sum := sha256.Sum256(writer.buffer.Bytes())
+			tag := `"` + hex.EncodeToString(sum[:16]) + `"`
+			w.Header().Set("ETag", tag)
+			if etagMatches(r.Header.Get("If-None-Match"), tag) {
+				for _, header := range []string{"Content-Type", "Content-Length"} {
+					w.Header().Del(header)
+				}
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("Content-Length", strconv.Itoa(writer.buffer.Len()))
+			w.WriteHeader(http.StatusOK)
+			if _, err := w.Write(writer.buffer.Bytes()); err != nil {
+				logging.FromContext(r.Context(), logger).Logf("ERROR failed to write response: %v", err)
+			}
+		})
+	}
+}
+
+// etagMatches reports whether an If-None-Match header names tag, comparing weakly as RFC 9110 asks,
+// so the weak tag of a compressed response still matches
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// etagWriter holds back a 200 response without an ETag of its own so it can be tagged, and passes any
+// other response through
+type etagWriter struct {
+	http.ResponseWriter
+	started bool
+	buffer  *bytes.Buffer // nil when the response is passed through
+}
+
+func (w *etagWriter) WriteHeader(status int) {
+	if w.started {
+		return
+	}
+	w.started = true
+	if status == http.StatusOK && w.Header().Get("ETag") == "" {
+		w.buffer = &bytes.Buffer{}
+		return
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if !w.started {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.buffer != nil {
+		return w.buffer.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Compress encodes responses of compressible content types with brotli or gzip, whichever the client
+// prefers in Accept-Encoding (brotli on a tie), once they are at least minSize bytes; smaller responses
+// are sent as they are since compression would barely shrink them. A compressed response's ETag is made
+// weak, as it no longer identifies the exact bytes sent.
+func Compress(minSize int, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			writer := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			next.ServeHTTP(writer, r)
+			if err := writer.Close(); err != nil {
+				logging.FromContext(r.Context(), logger).Logf("ERROR failed to write compressed response: %v", err)
+			}
+		})
+	}
+}
+
+// negotiateEncoding returns "br" or "gzip", whichever Accept-Encoding gives the higher quality, or
+// an empty string when the client accepts neither
+func negotiateEncoding(header string) string {
+	best, bestQuality := "", 0.0
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if (name != "br" && name != "gzip") || quality <= 0 {
+			continue
+		}
+		if quality > bestQuality || (quality == bestQuality && name == "br") {
+			best, bestQuality = name, quality
+		}
+	}
+	return best
+}
+
+// compressWriter holds back the first minSize bytes of a response, then either compresses it from there
+// on or, when the response ended sooner or is not worth compressing, sends it as it is
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+	status   int
+	decided  bool // whether the response is compressed or passed through is settled
+	buffer   bytes.Buffer
+	encoder  io.WriteCloser // nil when the response is passed through
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status != 0 {
+		return
+	}
+	w.status = status
+	header := w.Header()
+	// bodiless, already encoded or incompressible responses are passed through
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		header.Get("Content-Encoding") != "" || !compressible(header.Get("Content-Type")) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.buffer.Write(p)
+		if w.buffer.Len() < w.minSize {
+			return len(p), nil
+		}
+		if err := w.flushBuffer(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Close sends what is still held back and finishes the compressed stream
+func (w *compressWriter) Close() error {
+	if w.status == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		if err := w.flushBuffer(false); err != nil {
+			return err
+		}
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+// flushBuffer settles whether to compress and writes the held back bytes
+func (w *compressWriter) flushBuffer(compress bool) error {
+	w.decide(compress)
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.encoder != nil {
+		_, err = w.encoder.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+	w.buffer.Reset()
+	return err
+}
+
+// decide writes the headers of a compressed or passed through response
+func (w *compressWriter) decide(compress bool) {
+	w.decided = true
+	header := w.Header()
+	if compress {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if tag := header.Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+			header.Set("ETag", "W/"+tag)
+		}
+		if w.encoding == "br" {
+			w.encoder = brotli.NewWriterLevel(w.ResponseWriter, brotli.DefaultCompression)
+		} else {
+			w.encoder = gzip.NewWriter(w.ResponseWriter)
+		}
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+}
+
+// compressible reports whether a response of contentType is worth compressing
+func compressible(contentType string) bool {
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/go-pkgz/rest"
+)
+
+// Correlation creates a middleware that carries the request ID into the context, so every line logged while
+// serving the request can be correlated through logging.FromContext. It relies on rest.Trace having set the ID,
+// which is sent back as X-Request-ID.
+func Correlation() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := logging.WithFields(r.Context(), logging.Fields{RequestID: rest.GetTraceID(r)})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/go-pkgz/lgr"
+)
+
+// Idempotency answers a write request carrying the Idempotency-Key of a completed request with the stored
+// response of that request instead of running it again, so a client retrying a distribution after a dropped
+// connection does not start a second one. The first request's response is kept whatever its status: a
+// 5xx may come from a transaction that is still mined, a new attempt needs a new key. A retry sent while
+// the first request runs gets 409, a key reused for a different request 422. Keys are kept per tenant and
+// caller, see idempotencyScope. Requests without the header are not affected, and a nil service disables it.
+func Idempotency(service idempotency.Service, tenant string, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if service == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(idempotency.Header)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			logger := logging.FromContext(r.Context(), logger)
+			if !validIdempotencyKey(key) {
+				writeIdempotencyError(w, logger, http.StatusBadRequest,
+					"Idempotency-Key must be 1 to "+strconv.Itoa(idempotency.MaxKeyLength)+" printable ASCII characters")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeIdempotencyError(w, logger, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scopedKey := idempotencyScope(r, tenant) + key
+			record, err := service.Begin(r.Context(), scopedKey, requestFingerprint(r, body))
+			switch {
+			case errors.Is(err, idempotency.ErrKeyReused):
+				logger.Logf("WARN %s %s: %v", r.Method, r.URL.Path, err)
+				writeIdempotencyError(w, logger, http.StatusUnprocessableEntity,
+					"Idempotency-Key was already used for a different request")
+				return
+			case errors.Is(err, idempotency.ErrInProgress):
+				w.Header().Set("Retry-After", "5")
+				writeIdempotencyError(w, logger, http.StatusConflict,
+					"A request with this Idempotency-Key is still in progress")
+				return
+			case err != nil:
+				// without the key the request could run twice, so it is not run at all
+				logger.Logf("ERROR failed to check idempotency key of %s %s: %v", r.Method, r.URL.Path, err)
+				writeIdempotencyError(w, logger, http.StatusServiceUnavailable, "Failed to check Idempotency-Key")
+				return
+			case record != nil:
+				logger.Logf("INFO replaying response to %s %s for idempotency key %s", r.Method, r.URL.Path, key)
+				if record.Response.ContentType != "" {
+					w.Header().Set("Content-Type", record.Response.ContentType)
+				}
+				w.Header().Set(idempotency.ReplayedHeader, "true")
+				w.WriteHeader(record.Response.Status)
+				if _, err := w.Write(record.Response.Body); err != nil {
+					logger.Logf("WARN failed to write replayed response: %v", err)
+				}
+				return
+			}
+
+			recorder := &recordingWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// a request that panicked gave no response to keep, its retry runs it again
+				if completed {
+					return
+				}
+				if err := service.Release(r.Context(), scopedKey); err != nil {
+					logger.Logf("ERROR failed to release idempotency key %s: %v", key, err)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+			completed = true
+
+			response := idempotency.Response{
+				Status:      recorder.statusCode(),
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        recorder.body.Bytes(),
+			}
+			if err := service.Complete(r.Context(), scopedKey, response); err != nil {
+				logger.Logf("ERROR failed to store response for idempotency key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+// validIdempotencyKey reports whether key is short enough to store and printable ASCII
+func validIdempotencyKey(key string) bool {
+	if len(key) > idempotency.MaxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyScope prefixes the stored key with the tenant and the caller, so one client can neither replay
+// another's response nor block its request by sending the same key. The caller is the API key the request
+// authenticated with, or its actor on routes taking none.
+func idempotencyScope(r *http.Request, tenant string) string {
+	caller := audit.CredentialFromContext(r.Context())
+	if caller == "" {
+		caller = audit.ActorFromContext(r.Context())
+	}
+	return tenant + "/" + caller + "/"
+}
+
+// requestFingerprint hashes what tells requests apart: the method, the path with its query and the body
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func writeIdempotencyError(w http.ResponseWriter, logger lgr.L, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": message,
+		"code":  status,
+	}); err != nil {
+		logger.Logf("ERROR failed to encode idempotency error response: %v", err)
+	}
+}
+
+// recordingWriter writes the response through and keeps a copy of its status and body
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (w *recordingWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
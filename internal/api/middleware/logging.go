@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/logging"
 	"github.com/go-pkgz/lgr"
 )
 
@@ -21,7 +22,7 @@ func Logging(logger lgr.L) func(http.Handler) http.Handler {
 
 			// Log the request
 			duration := time.Since(start)
-			logger.Logf("INFO %s %s %d %v %s",
+			logging.FromContext(r.Context(), logger).Logf("INFO %s %s %d %v %s",
 				r.Method,
 				r.URL.Path,
 				wrapper.statusCode,
@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-pkgz/lgr"
+)
+
+// ReadOnly creates a middleware that rejects every request with 403 when enabled,
+// used on write endpoints so read replicas never start an on-chain action
+func ReadOnly(enabled bool, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logger.Logf("WARN rejected %s %s from %s: server is read-only", r.Method, r.URL.Path, r.RemoteAddr)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			if err := json.NewEncoder(w).Encode(map[string]interface{}{
+				"error": "server is running in read-only mode",
+				"code":  http.StatusForbidden,
+			}); err != nil {
+				logger.Logf("ERROR failed to encode read-only error response: %v", err)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"runtime/debug"
 
+	"github.com/andrey/epoch-server/internal/infra/logging"
 	"github.com/go-pkgz/lgr"
 )
 
@@ -14,6 +15,7 @@ func Recovery(logger lgr.L) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			defer func() {
 				if err := recover(); err != nil {
+					logger := logging.FromContext(r.Context(), logger)
 					// Log the panic with stack trace
 					logger.Logf("ERROR panic recovered: %v\nStack trace:\n%s", err, debug.Stack())
 
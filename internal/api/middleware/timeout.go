@@ -0,0 +1,162 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/go-pkgz/lgr"
+)
+
+// TimeoutResponse is the 504 body of a request that ran out of its budget, listing the operations it
+// started, subgraph queries and contract calls among them, so the slow dependency can be told apart
+type TimeoutResponse struct {
+	Error      string             `json:"error"`
+	Code       int                `json:"code"`
+	Budget     string             `json:"budget"`
+	Operations []TimeoutOperation `json:"operations"`
+}
+
+// TimeoutOperation is an operation of a timed out request
+type TimeoutOperation struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // done, failed or pending when the budget ran out
+	Elapsed string `json:"elapsed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Timeout gives every request budget to complete in. The request's context carries the deadline, so
+// subgraph queries and contract calls made for it are canceled once it passes, and the client gets a 504
+// listing the operations the request started instead of waiting on a connection that never answers.
+// A budget of zero disables it.
+func Timeout(budget time.Duration, logger lgr.L) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if budget <= 0 {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), budget)
+			defer cancel()
+			ctx, operations := tracing.WithOperationLog(ctx)
+
+			writer := &timeoutWriter{header: make(http.Header)}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(writer, r.WithContext(ctx))
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				// re-raised on the serving goroutine for the recovery middleware
+				panic(p)
+			case <-done:
+				writer.flush(w)
+			case <-ctx.Done():
+				writer.abandon()
+				writeTimeout(w, r, budget, operations.Operations(), logger)
+			}
+		})
+	}
+}
+
+// writeTimeout answers a request that ran out of its budget with 504 and its operations
+func writeTimeout(w http.ResponseWriter, r *http.Request, budget time.Duration, operations []tracing.Operation, logger lgr.L) {
+	logger = logging.FromContext(r.Context(), logger)
+	resp := TimeoutResponse{
+		Error:      fmt.Sprintf("request did not complete within its %s budget", budget),
+		Code:       http.StatusGatewayTimeout,
+		Budget:     budget.String(),
+		Operations: make([]TimeoutOperation, 0, len(operations)),
+	}
+	var pending []string
+	for _, op := range operations {
+		status := "done"
+		switch {
+		case !op.Done:
+			status = "pending"
+			pending = append(pending, op.Name)
+		case op.Err != "":
+			status = "failed"
+		}
+		resp.Operations = append(resp.Operations, TimeoutOperation{
+			Name:    op.Name,
+			Status:  status,
+			Elapsed: op.Elapsed.Round(time.Millisecond).String(),
+			Error:   op.Err,
+		})
+	}
+	logger.Logf("WARN %s %s exceeded its %s budget, still waiting on %v", r.Method, r.URL.Path, budget, pending)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusGatewayTimeout)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		logger.Logf("ERROR failed to encode timeout response: %v", err)
+	}
+}
+
+// timeoutWriter holds a response back until the handler returns, and drops it once the request timed out
+type timeoutWriter struct {
+	mu        sync.Mutex
+	header    http.Header
+	status    int
+	buffer    bytes.Buffer
+	abandoned bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *timeoutWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.abandoned {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buffer.Write(p)
+}
+
+// abandon drops whatever the handler writes from now on
+func (w *timeoutWriter) abandon() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.abandoned = true
+}
+
+// flush sends the held back response
+func (w *timeoutWriter) flush(dst http.ResponseWriter) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for name, values := range w.header {
+		dst.Header()[name] = values
+	}
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	dst.WriteHeader(w.status)
+	_, _ = dst.Write(w.buffer.Bytes())
+}
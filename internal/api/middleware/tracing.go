@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Tracing creates a middleware that starts a server span for each request,
+// continuing any trace propagated by the caller
+func Tracing() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracing.StartSpan(ctx, r.Method+" "+r.URL.Path,
+				attribute.String("http.request.method", r.Method),
+				attribute.String("url.path", r.URL.Path),
+			)
+			defer span.End()
+
+			wrapper := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(wrapper, r.WithContext(ctx))
+
+			span.SetAttributes(attribute.Int("http.response.status_code", wrapper.statusCode))
+			if wrapper.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(wrapper.statusCode))
+			}
+		})
+	}
+}
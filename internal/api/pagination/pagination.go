@@ -0,0 +1,154 @@
+// Package pagination holds the paging and sorting conventions list endpoints share. Every list endpoint takes
+// limit, sort and order query parameters, and either offset or cursor. The total number of matching items is
+// returned in the X-Total-Count header when it is known, and the URL of the next page in a Link header with
+// rel="next", so response bodies keep their shape.
+package pagination
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidInput is returned for malformed or out of range paging parameters
+var ErrInvalidInput = errors.New("invalid pagination parameters")
+
+// sort orders
+const (
+	OrderAsc  = "asc"
+	OrderDesc = "desc"
+)
+
+// TotalCountHeader carries the number of items matching the request across all pages
+const TotalCountHeader = "X-Total-Count"
+
+// Spec is how an endpoint pages its items
+type Spec struct {
+	DefaultLimit int
+	MaxLimit     int
+	Sorts        []string // fields items can be sorted by, the first is the default
+	DefaultOrder string
+	Cursor       bool // pages are continued with an opaque cursor instead of an offset
+}
+
+// Params are the paging parameters of a request, validated against the endpoint's Spec
+type Params struct {
+	Limit  int
+	Offset int
+	Cursor string
+	Sort   string
+	Order  string
+}
+
+// Descending reports whether items are sorted from the highest value down
+func (p Params) Descending() bool {
+	return p.Order == OrderDesc
+}
+
+// Parse reads limit, offset or cursor, sort and order from query, filling in the spec's defaults
+func Parse(query url.Values, spec Spec) (Params, error) {
+	params := Params{Limit: spec.DefaultLimit, Order: spec.DefaultOrder}
+	if len(spec.Sorts) > 0 {
+		params.Sort = spec.Sorts[0]
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil {
+			return Params{}, fmt.Errorf("%w: invalid limit %q", ErrInvalidInput, value)
+		}
+		params.Limit = limit
+	}
+	if params.Limit < 1 || params.Limit > spec.MaxLimit {
+		return Params{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidInput, spec.MaxLimit)
+	}
+
+	if value := query.Get("offset"); value != "" {
+		if spec.Cursor {
+			return Params{}, fmt.Errorf("%w: pages are continued with cursor, not offset", ErrInvalidInput)
+		}
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			return Params{}, fmt.Errorf("%w: offset must be a non-negative integer", ErrInvalidInput)
+		}
+		params.Offset = offset
+	}
+	if value := query.Get("cursor"); value != "" {
+		if !spec.Cursor {
+			return Params{}, fmt.Errorf("%w: pages are continued with offset, not cursor", ErrInvalidInput)
+		}
+		params.Cursor = value
+	}
+
+	if value := query.Get("sort"); value != "" {
+		if !slices.Contains(spec.Sorts, value) {
+			return Params{}, fmt.Errorf("%w: unknown sort field %q", ErrInvalidInput, value)
+		}
+		params.Sort = value
+	}
+	switch value := query.Get("order"); value {
+	case "":
+	case OrderAsc, OrderDesc:
+		params.Order = value
+	default:
+		return Params{}, fmt.Errorf("%w: order must be %s or %s", ErrInvalidInput, OrderAsc, OrderDesc)
+	}
+	return params, nil
+}
+
+// Apply sorts items by the requested field, compared by sorts[params.Sort], and returns the requested page
+// along with the number of items across all pages. The sort is stable, so items comparing equal keep the order
+// they came in.
+func Apply[T any](items []T, params Params, sorts map[string]func(a, b T) int) ([]T, int) {
+	if compare, ok := sorts[params.Sort]; ok {
+		items = slices.Clone(items)
+		slices.SortStableFunc(items, func(a, b T) int {
+			if params.Descending() {
+				return compare(b, a)
+			}
+			return compare(a, b)
+		})
+	}
+
+	total := len(items)
+	if params.Offset >= total {
+		return items[:0], total
+	}
+	return items[params.Offset:min(params.Offset+params.Limit, total)], total
+}
+
+// CompareDecimal orders non-negative decimal strings such as epoch numbers numerically, empty ones first
+func CompareDecimal(a, b string) int {
+	if len(a) != len(b) {
+		return cmp.Compare(len(a), len(b))
+	}
+	return strings.Compare(a, b)
+}
+
+// WritePage sets the headers of an offset page of returned items out of total
+func WritePage(w http.ResponseWriter, r *http.Request, params Params, returned, total int) {
+	w.Header().Set(TotalCountHeader, strconv.Itoa(total))
+	if next := params.Offset + returned; returned > 0 && next < total {
+		writeNext(w, r, "offset", strconv.Itoa(next))
+	}
+}
+
+// WriteCursor sets the Link header of a cursor page, continued with next unless it is empty
+func WriteCursor(w http.ResponseWriter, r *http.Request, next string) {
+	if next != "" {
+		writeNext(w, r, "cursor", next)
+	}
+}
+
+// writeNext links the request with param set to value as the next page
+func writeNext(w http.ResponseWriter, r *http.Request, param, value string) {
+	query := r.URL.Query()
+	query.Set(param, value)
+	next := url.URL{Path: r.URL.Path, RawQuery: query.Encode()}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
@@ -0,0 +1,104 @@
+package pagination
+
+import (
+	"cmp"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSpec = Spec{DefaultLimit: 2, MaxLimit: 10, Sorts: []string{"id", "name"}, DefaultOrder: OrderAsc}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		spec    Spec
+		want    Params
+		wantErr bool
+	}{
+		{name: "defaults", query: "", spec: testSpec, want: Params{Limit: 2, Sort: "id", Order: OrderAsc}},
+		{name: "all_set", query: "limit=5&offset=4&sort=name&order=desc", spec: testSpec,
+			want: Params{Limit: 5, Offset: 4, Sort: "name", Order: OrderDesc}},
+		{name: "cursor", query: "cursor=abc", spec: Spec{DefaultLimit: 2, MaxLimit: 10, Cursor: true},
+			want: Params{Limit: 2, Cursor: "abc"}},
+		{name: "limit_too_large", query: "limit=11", spec: testSpec, wantErr: true},
+		{name: "limit_zero", query: "limit=0", spec: testSpec, wantErr: true},
+		{name: "limit_malformed", query: "limit=ten", spec: testSpec, wantErr: true},
+		{name: "negative_offset", query: "offset=-1", spec: testSpec, wantErr: true},
+		{name: "unknown_sort", query: "sort=size", spec: testSpec, wantErr: true},
+		{name: "unknown_order", query: "order=up", spec: testSpec, wantErr: true},
+		{name: "cursor_on_offset_endpoint", query: "cursor=abc", spec: testSpec, wantErr: true},
+		{name: "offset_on_cursor_endpoint", query: "offset=2", spec: Spec{DefaultLimit: 2, MaxLimit: 10, Cursor: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := url.ParseQuery(tt.query)
+			require.NoError(t, err)
+			params, err := Parse(query, tt.spec)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidInput)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, params)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	type item struct {
+		id   int
+		name string
+	}
+	items := []item{{3, "c"}, {1, "b"}, {2, "a"}}
+	sorts := map[string]func(a, b item) int{
+		"id":   func(a, b item) int { return cmp.Compare(a.id, b.id) },
+		"name": func(a, b item) int { return cmp.Compare(a.name, b.name) },
+	}
+
+	page, total := Apply(items, Params{Limit: 2, Sort: "id", Order: OrderAsc}, sorts)
+	assert.Equal(t, 3, total)
+	assert.Equal(t, []item{{1, "b"}, {2, "a"}}, page)
+	assert.Equal(t, item{3, "c"}, items[0], "the caller's slice is not reordered")
+
+	page, _ = Apply(items, Params{Limit: 2, Offset: 2, Sort: "id", Order: OrderAsc}, sorts)
+	assert.Equal(t, []item{{3, "c"}}, page)
+
+	page, _ = Apply(items, Params{Limit: 1, Sort: "name", Order: OrderDesc}, sorts)
+	assert.Equal(t, []item{{3, "c"}}, page)
+
+	page, total = Apply(items, Params{Limit: 2, Offset: 5}, sorts)
+	assert.Empty(t, page)
+	assert.Equal(t, 3, total)
+}
+
+func TestCompareDecimal(t *testing.T) {
+	assert.Negative(t, CompareDecimal("9", "10"))
+	assert.Positive(t, CompareDecimal("21", "12"))
+	assert.Zero(t, CompareDecimal("7", "7"))
+	assert.Negative(t, CompareDecimal("", "1"))
+}
+
+func TestWritePage(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/distributions?status=approved&limit=2", nil)
+
+	w := httptest.NewRecorder()
+	WritePage(w, r, Params{Limit: 2}, 2, 5)
+	assert.Equal(t, "5", w.Header().Get(TotalCountHeader))
+	assert.Equal(t, `</api/distributions?limit=2&offset=2&status=approved>; rel="next"`, w.Header().Get("Link"))
+
+	w = httptest.NewRecorder()
+	WritePage(w, r, Params{Limit: 2, Offset: 4}, 1, 5)
+	assert.Equal(t, "5", w.Header().Get(TotalCountHeader))
+	assert.Empty(t, w.Header().Get("Link"), "the last page links no next page")
+
+	w = httptest.NewRecorder()
+	WriteCursor(w, httptest.NewRequest("GET", "/api/audit?cursor=old", nil), "new")
+	assert.Equal(t, `</api/audit?cursor=new>; rel="next"`, w.Header().Get("Link"))
+	assert.Empty(t, w.Header().Get(TotalCountHeader))
+}
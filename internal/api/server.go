@@ -3,26 +3,62 @@ package api
 import (
 	"fmt"
 	"net/http"
-	"time"
 
 	_ "github.com/andrey/epoch-server/docs"
 	"github.com/andrey/epoch-server/internal/api/handlers"
 	"github.com/andrey/epoch-server/internal/api/middleware"
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/compatibility"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/andrey/epoch-server/internal/services/jobs"
 	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/scheduler"
+	"github.com/andrey/epoch-server/internal/services/signer"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
 	"github.com/go-pkgz/lgr"
 	"github.com/go-pkgz/rest"
 	"github.com/go-pkgz/routegroup"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
+// compressMinSize is the smallest response body compressed, below it compression barely shrinks the body
+const compressMinSize = 1024
+
 // Server represents the HTTP server
 type Server struct {
 	epochService   epoch.Service
 	subsidyService subsidy.Service
 	merkleService  merkle.Service
+	auditService   audit.Service
+	signerService  signer.Service
+	contracts      contractstate.Service
+	gasService     gas.Service
+	pauseService   pause.Service
+	jobService     jobs.Service
+	reconciliation reconciliation.Service
+	analytics      analytics.Service
+	vaults         vaults.Service
+	queue          queue.Service
+	assets         assets.Service
+	deadLetters    deadletter.Service
+	compatibility  compatibility.Service
+	features       features.Service
+	idempotency    idempotency.Service // nil ignores Idempotency-Key headers
+	trigger        scheduler.Trigger   // nil when this replica runs no scheduler
+	metrics        *metrics.Registry
 	logger         lgr.L
 	config         *config.Config
 }
@@ -32,6 +68,23 @@ func NewServer(
 	epochService epoch.Service,
 	subsidyService subsidy.Service,
 	merkleService merkle.Service,
+	auditService audit.Service,
+	signerService signer.Service,
+	contracts contractstate.Service,
+	gasService gas.Service,
+	pauseService pause.Service,
+	jobService jobs.Service,
+	reconciliationService reconciliation.Service,
+	analyticsService analytics.Service,
+	vaultsService vaults.Service,
+	queueService queue.Service,
+	assetService assets.Service,
+	deadLetterService deadletter.Service,
+	compatibilityService compatibility.Service,
+	featuresService features.Service,
+	idempotencyService idempotency.Service,
+	trigger scheduler.Trigger,
+	registry *metrics.Registry,
 	logger lgr.L,
 	cfg *config.Config,
 ) *Server {
@@ -39,6 +92,23 @@ func NewServer(
 		epochService:   epochService,
 		subsidyService: subsidyService,
 		merkleService:  merkleService,
+		auditService:   auditService,
+		signerService:  signerService,
+		contracts:      contracts,
+		gasService:     gasService,
+		pauseService:   pauseService,
+		jobService:     jobService,
+		reconciliation: reconciliationService,
+		analytics:      analyticsService,
+		vaults:         vaultsService,
+		queue:          queueService,
+		assets:         assetService,
+		deadLetters:    deadLetterService,
+		compatibility:  compatibilityService,
+		features:       featuresService,
+		idempotency:    idempotencyService,
+		trigger:        trigger,
+		metrics:        registry,
 		logger:         logger,
 		config:         cfg,
 	}
@@ -49,8 +119,24 @@ func (s *Server) SetupRoutes() http.Handler {
 	// Create handlers
 	healthHandler := handlers.NewHealthHandler(s.logger, s.checkEpochService, s.checkSubsidyService, s.checkMerkleService)
 	epochHandler := handlers.NewEpochHandler(s.epochService, s.logger, s.config)
-	subsidyHandler := handlers.NewSubsidyHandler(s.subsidyService, s.logger, s.config)
-	merkleHandler := handlers.NewMerkleHandler(s.merkleService, s.logger, s.config)
+	subsidyHandler := handlers.NewSubsidyHandler(s.subsidyService, s.queue, s.logger, s.config)
+	merkleHandler := handlers.NewMerkleHandler(s.merkleService, s.queue, s.logger, s.config)
+	auditHandler := handlers.NewAuditHandler(s.auditService, s.logger, s.config)
+	graphqlHandler := handlers.NewGraphQLHandler(s.epochService, s.merkleService, s.logger, s.config)
+	signerHandler := handlers.NewSignerHandler(s.signerService, s.logger, s.config)
+	statusHandler := handlers.NewStatusHandler(s.contracts, s.signerService, s.compatibility, s.logger, s.config)
+	gasHandler := handlers.NewGasHandler(s.gasService, s.logger, s.config)
+	jobsHandler := handlers.NewJobsHandler(s.jobService, s.logger, s.config)
+	reconciliationHandler := handlers.NewReconciliationHandler(s.reconciliation, s.logger, s.config)
+	analyticsHandler := handlers.NewAnalyticsHandler(s.analytics, s.logger, s.config)
+	adminHandler := handlers.NewAdminHandler(s.pauseService, s.trigger, s.logger, s.config)
+	vaultsHandler := handlers.NewVaultsHandler(s.vaults, s.logger, s.config)
+	queueHandler := handlers.NewQueueHandler(s.queue, s.logger, s.config)
+	assetHandler := handlers.NewAssetHandler(s.assets, s.logger, s.config)
+	deadLetterHandler := handlers.NewDeadLetterHandler(s.deadLetters, s.logger, s.config)
+	featuresHandler := handlers.NewFeaturesHandler(s.features, s.logger, s.config)
+	metricsHandler := handlers.NewMetricsHandler(s.metrics, s.logger)
+	swaggerHandler := handlers.NewSwaggerHandler(s.logger)
 
 	// Create base router with routegroup
 	router := routegroup.New(http.NewServeMux())
@@ -58,23 +144,52 @@ func (s *Server) SetupRoutes() http.Handler {
 	// Apply global middlewares
 	router.Use(rest.RealIP)
 	router.Use(rest.Trace)                  // Add request tracing
+	router.Use(middleware.Correlation())    // Log lines carry the request ID
+	router.Use(middleware.Tracing())        // OpenTelemetry server spans
+	router.Use(middleware.Actor())          // Attribute audited actions to the client
 	router.Use(rest.SizeLimit(1024 * 1024)) // 1MB request size limit
 	// router.Use(middleware.Auth(s.logger))
 	router.Use(middleware.Logging(s.logger)) // Keep custom logging middleware
 	router.Use(middleware.Recovery(s.logger))
+	router.Use(middleware.Compress(compressMinSize, s.logger)) // br or gzip for large responses
+	router.Use(middleware.ETag(s.logger))                      // 304 for responses the client already has
 	router.Use(rest.AppInfo("epoch-server", "andrey", "1.0.0"))
 	router.Use(rest.Ping)
 
 	// Health check route (no grouping needed)
 	router.HandleFunc("GET /health", healthHandler.HandleHealth)
 
+	// Prometheus metrics
+	router.HandleFunc("GET /metrics", metricsHandler.HandleMetrics)
+
 	// Swagger documentation route
 	router.HandleFunc("GET /swagger/*", httpSwagger.Handler())
+	router.HandleFunc("GET /swagger.json", swaggerHandler.HandleSpec)
+
+	// write endpoints are rejected on read-only replicas
+	readOnly := middleware.ReadOnly(s.config.Server.ReadOnly, s.logger)
+	// retries of write requests sent with an Idempotency-Key get the first request's response
+	idempotent := middleware.Idempotency(s.idempotency, s.config.Tenant, s.logger)
+	// wei amounts of JSON responses are also given in whole tokens
+	amounts := middleware.AssetAmounts(s.assets, s.config.Contracts.CollectionsVault, s.logger)
+	// reads answer 504 once their budget runs out, endpoints recomputing allocations, verifying proofs or
+	// building reports get a larger one; writes sending transactions are not cut short
+	reads := middleware.Timeout(s.config.Server.RequestTimeout, s.logger)
+	heavy := middleware.Timeout(s.config.Server.HeavyRequestTimeout, s.logger)
 
 	// API routes group
 	router.Group().Mount("/api").Route(func(apiRouter *routegroup.Bundle) {
+		// the GraphQL facade answers in the shape of the query, so formatted amounts are not added to it
+		graphqlRouter := apiRouter.Group()
+		apiRouter.Use(amounts)
+
 		// Epoch management routes
+		apiRouter.With(reads).HandleFunc("GET /epochs", epochHandler.HandleListEpochs)
+		apiRouter.With(reads).HandleFunc("GET /epochs/current/onchain", epochHandler.HandleGetOnChainState)
+		apiRouter.With(reads).HandleFunc("GET /epochs/{id}/timeline", epochHandler.HandleGetTimeline)
+		apiRouter.With(reads).HandleFunc("GET /epochs/{id}/stats", subsidyHandler.HandleGetEpochStats)
 		apiRouter.Group().Mount("/epochs").Route(func(epochRouter *routegroup.Bundle) {
+			epochRouter.Use(readOnly, idempotent)
 			epochRouter.HandleFunc("POST /start", epochHandler.HandleStartEpoch)
 			epochRouter.HandleFunc("POST /force-end", epochHandler.HandleForceEndEpoch)
 			epochRouter.HandleFunc("POST /distribute", subsidyHandler.HandleDistributeSubsidies)
@@ -82,13 +197,108 @@ func (s *Server) SetupRoutes() http.Handler {
 
 		// User-related routes
 		apiRouter.Group().Mount("/users").Route(func(userRouter *routegroup.Bundle) {
-			userRouter.HandleFunc("GET /{address}/total-earned", epochHandler.HandleGetUserTotalEarned)
-			userRouter.HandleFunc("GET /{address}/merkle-proof", merkleHandler.HandleGetUserMerkleProof)
-			userRouter.HandleFunc(
+			userRouter.With(reads).HandleFunc("GET /{address}/total-earned", epochHandler.HandleGetUserTotalEarned)
+			userRouter.With(reads).HandleFunc("GET /{address}/history", subsidyHandler.HandleGetUserHistory)
+			userRouter.With(reads).HandleFunc("GET /{address}/expirations", subsidyHandler.HandleGetUserExpirations)
+			userRouter.With(reads).HandleFunc("GET /{address}/merkle-proof", merkleHandler.HandleGetUserMerkleProof)
+			userRouter.With(reads).HandleFunc("GET /{address}/claimable", merkleHandler.HandleGetUserClaimable)
+			userRouter.With(reads).HandleFunc("GET /{address}/claim-payload", merkleHandler.HandleGetUserClaimPayload)
+			userRouter.With(heavy).HandleFunc("POST /{address}/claim-payload/verify", merkleHandler.HandleVerifyClaimSignature)
+			userRouter.With(reads).HandleFunc(
 				"GET /{address}/merkle-proof/epoch/{epochNumber}",
 				merkleHandler.HandleGetUserHistoricalMerkleProof,
 			)
 		})
+
+		// Proofs for the latest, historical or replaced roots
+		apiRouter.With(reads).HandleFunc("GET /proofs", merkleHandler.HandleGetProof)
+		apiRouter.With(heavy).HandleFunc("POST /proofs/verify", merkleHandler.HandleVerifyProofs)
+
+		// Vault-related routes
+		apiRouter.With(reads).HandleFunc("GET /vaults", vaultsHandler.HandleListRegisteredVaults)
+		apiRouter.Group().Mount("/vaults").Route(func(vaultRouter *routegroup.Bundle) {
+			vaultRouter.With(reads).HandleFunc("GET /{vault}/asset", assetHandler.HandleGetAsset)
+			vaultRouter.With(reads).HandleFunc("GET /{vault}/merkle-root/verify", merkleHandler.HandleVerifyMerkleRoot)
+			vaultRouter.With(reads).HandleFunc("GET /{vault}/roots", merkleHandler.HandleListRootUpdates)
+			vaultRouter.With(heavy).HandleFunc("GET /{vault}/epochs/{id}/users/{address}/explain", subsidyHandler.HandleExplainAllocation)
+			vaultRouter.With(heavy).HandleFunc("POST /{vault}/epochs/{id}/explain", subsidyHandler.HandleExplainAllocations)
+			vaultRouter.With(heavy).HandleFunc("GET /{vault}/epochs/{id}/replay", subsidyHandler.HandleReplayEpoch)
+			vaultRouter.With(heavy).HandleFunc("GET /{vault}/epochs/{id}/diff", subsidyHandler.HandleDiffAllocations)
+			vaultRouter.With(reads).HandleFunc("GET /{vault}/quarantine", subsidyHandler.HandleListQuarantinedAccounts)
+		})
+
+		// Distributions staged for approval; decisions require an approval API key
+		apiRouter.With(reads).HandleFunc("GET /distributions", subsidyHandler.HandleListStagedDistributions)
+		apiRouter.Group().Mount("/distributions").Route(func(distributionRouter *routegroup.Bundle) {
+			approvalRouter := distributionRouter.With(readOnly, middleware.RequireAPIKey(s.config.Approval.APIKeys, s.logger), idempotent)
+			approvalRouter.HandleFunc("POST /{id}/approve", subsidyHandler.HandleApproveDistribution)
+			approvalRouter.HandleFunc("POST /{id}/reject", subsidyHandler.HandleRejectDistribution)
+		})
+
+		// Balance of the transaction signer and whether scheduled transactions are paused
+		apiRouter.With(reads).HandleFunc("GET /signer", signerHandler.HandleGetSignerStatus)
+
+		// Whether the contracts accept distributions, and the signer balance
+		apiRouter.With(reads).HandleFunc("GET /status", statusHandler.HandleGetStatus)
+		apiRouter.With(reads).HandleFunc("GET /status/contracts", statusHandler.HandleGetContractCompatibility)
+
+		// Last and next runs of every scheduler job, with their recent history
+		apiRouter.With(reads).HandleFunc("GET /scheduler/jobs", jobsHandler.HandleListJobs)
+
+		// Work queued by requests made with async=true, polled until it finished
+		apiRouter.With(reads).HandleFunc("GET /jobs", queueHandler.HandleListQueuedJobs)
+		apiRouter.With(reads).HandleFunc("GET /jobs/{id}", queueHandler.HandleGetJob)
+
+		// Audit log of state-changing actions
+		apiRouter.With(reads).HandleFunc("GET /audit", auditHandler.HandleListAudit)
+
+		// Gas spent by mined transactions, per operation and epoch
+		apiRouter.With(heavy).HandleFunc("GET /reports/gas", gasHandler.HandleGasReport)
+
+		// Distributed subsidies checked against allocated yield and claims, per epoch
+		apiRouter.With(heavy).HandleFunc("GET /reports/reconciliation", reconciliationHandler.HandleReconciliationReport)
+		apiRouter.With(heavy).HandleFunc("GET /reports/reconciliation/claims", reconciliationHandler.HandleClaimsReconciliationReport)
+
+		// Per-epoch vault analytics
+		apiRouter.With(heavy).HandleFunc("GET /analytics/vaults/{vault}", analyticsHandler.HandleVaultAnalytics)
+		apiRouter.With(heavy).HandleFunc("GET /analytics/vaults/{vault}/collections", analyticsHandler.HandleVaultCollections)
+		apiRouter.With(heavy).HandleFunc("GET /epochs/{id}/report", analyticsHandler.HandleEpochReport)
+
+		// Read-only GraphQL facade over the routes above
+		graphqlRouter.With(heavy).HandleFunc("GET /graphql", graphqlHandler.HandleGraphQL)
+		graphqlRouter.With(heavy).HandleFunc("POST /graphql", graphqlHandler.HandleGraphQL)
+	})
+
+	// Operator routes, every one requires an admin API key
+	router.Group().Mount("/admin").Route(func(adminRouter *routegroup.Bundle) {
+		adminRouter.Use(middleware.RequireAPIKey(s.config.Admin.APIKeys, s.logger), amounts)
+		adminRouter.With(reads).HandleFunc("GET /scheduler", adminHandler.HandleSchedulerStatus)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /scheduler/pause", adminHandler.HandlePauseScheduler)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /scheduler/resume", adminHandler.HandleResumeScheduler)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /scheduler/trigger", adminHandler.HandleTriggerBoundary)
+		adminRouter.With(reads).HandleFunc("GET /vaults", vaultsHandler.HandleListVaults)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults", vaultsHandler.HandleOnboardVault)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults/{vault}/decommission", vaultsHandler.HandleDecommissionVault)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults/{vault}/epochs/{id}/snapshot-block", subsidyHandler.HandlePinSnapshotBlock)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults/{vault}/epochs/{id}/fingerprint-override", subsidyHandler.HandleOverrideFingerprint)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults/{vault}/epochs/{id}/replace-root", subsidyHandler.HandleReplaceRoot)
+		adminRouter.With(reads).HandleFunc("GET /vaults/{vault}/collection-weights", subsidyHandler.HandleListCollectionWeights)
+		adminRouter.With(readOnly, idempotent).HandleFunc("PUT /vaults/{vault}/collection-weights/{collection}", subsidyHandler.HandleSetCollectionWeight)
+		adminRouter.With(readOnly, idempotent).HandleFunc("DELETE /vaults/{vault}/collection-weights/{collection}", subsidyHandler.HandleDeleteCollectionWeight)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /vaults/{vault}/epochs/{id}/adjustments", subsidyHandler.HandleProposeAdjustment)
+		adminRouter.With(reads).HandleFunc("GET /adjustments", subsidyHandler.HandleListAdjustments)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /adjustments/{id}/approve", subsidyHandler.HandleApproveAdjustment)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /adjustments/{id}/reject", subsidyHandler.HandleRejectAdjustment)
+		adminRouter.With(reads).HandleFunc("GET /blocklist", subsidyHandler.HandleListBlockedAddresses)
+		adminRouter.With(readOnly, idempotent).HandleFunc("PUT /blocklist/{address}", subsidyHandler.HandleBlockAddress)
+		adminRouter.With(readOnly, idempotent).HandleFunc("DELETE /blocklist/{address}", subsidyHandler.HandleUnblockAddress)
+		adminRouter.With(reads).HandleFunc("GET /dead-letters", deadLetterHandler.HandleListDeadLetters)
+		adminRouter.With(reads).HandleFunc("GET /dead-letters/{id}", deadLetterHandler.HandleGetDeadLetter)
+		adminRouter.With(readOnly, idempotent).HandleFunc("POST /dead-letters/{id}/retry", deadLetterHandler.HandleRetryDeadLetter)
+		adminRouter.With(readOnly, idempotent).HandleFunc("DELETE /dead-letters/{id}", deadLetterHandler.HandleDiscardDeadLetter)
+		adminRouter.With(reads).HandleFunc("GET /features", featuresHandler.HandleListFeatures)
+		adminRouter.With(readOnly, idempotent).HandleFunc("PUT /features/{flag}", featuresHandler.HandleSetFeature)
+		adminRouter.With(readOnly, idempotent).HandleFunc("DELETE /features/{flag}", featuresHandler.HandleClearFeature)
 	})
 
 	return router
@@ -96,20 +306,7 @@ func (s *Server) SetupRoutes() http.Handler {
 
 // Start starts the HTTP server with proper timeouts
 func (s *Server) Start() error {
-	handler := s.SetupRoutes()
-	addr := fmt.Sprintf("%s:%d", s.config.Server.Host, s.config.Server.Port)
-	s.logger.Logf("INFO starting server on %s", addr)
-
-	// Create server with security timeouts
-	server := &http.Server{
-		Addr:         addr,
-		Handler:      handler,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	return server.ListenAndServe()
+	return Serve(s.SetupRoutes(), s.config, s.logger)
 }
 
 // Health check functions for services
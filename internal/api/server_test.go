@@ -1,15 +1,43 @@
 package api
 
 import (
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/andrey/epoch-server/internal/api/handlers"
+	"github.com/andrey/epoch-server/internal/api/middleware"
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/compatibility"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/andrey/epoch-server/internal/services/jobs"
 	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/scheduler"
+	"github.com/andrey/epoch-server/internal/services/signer"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"github.com/andybalholm/brotli"
 	"github.com/go-pkgz/lgr"
 )
 
@@ -25,12 +53,105 @@ func TestServerRoutes(t *testing.T) {
 		GetUserTotalEarnedFunc: func(ctx context.Context, userAddress, vaultId string) (*epoch.UserEarningsResponse, error) {
 			return &epoch.UserEarningsResponse{}, nil
 		},
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			return &epoch.ListEpochsResponse{}, nil
+		},
+		GetOnChainStateFunc: func(ctx context.Context, vaultId string) (*epoch.OnChainStateResponse, error) {
+			if vaultId == "bad" {
+				return nil, epoch.ErrInvalidInput
+			}
+			return &epoch.OnChainStateResponse{VaultAddress: vaultId, CurrentEpochID: "3"}, nil
+		},
+		GetTimelineFunc: func(ctx context.Context, epochId uint64, vaultId string) (*epoch.Timeline, error) {
+			if epochId > 3 {
+				return nil, epoch.ErrNotFound
+			}
+			return &epoch.Timeline{EpochID: "3", VaultAddress: vaultId}, nil
+		},
 	}
 
 	mockSubsidyService := &subsidy.ServiceMock{
 		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
 			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
 		},
+		UserHistoryFunc: func(ctx context.Context, userAddress, vaultId string) ([]subsidy.EpochPosition, error) {
+			return []subsidy.EpochPosition{{VaultID: "0x1234567890123456789012345678901234567890", EpochNumber: "3"}}, nil
+		},
+		UserExpirationsFunc: func(ctx context.Context, userAddress, vaultId string) (*subsidy.UserExpirations, error) {
+			return &subsidy.UserExpirations{UserAddress: userAddress, ExpiryEpochs: 4, Expirations: []subsidy.ClaimExpiration{}}, nil
+		},
+		ListStagedDistributionsFunc: func(ctx context.Context, status string) ([]subsidy.StagedDistribution, error) {
+			return []subsidy.StagedDistribution{}, nil
+		},
+		ApproveDistributionFunc: func(ctx context.Context, id string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed", StagedID: id}, nil
+		},
+		RejectDistributionFunc: func(ctx context.Context, id, reason string) (*subsidy.StagedDistribution, error) {
+			return &subsidy.StagedDistribution{ID: id, Status: subsidy.StagedRejected}, nil
+		},
+		ExplainAllocationFunc: func(ctx context.Context, vaultId, epochNumber, userAddress string) (*subsidy.AllocationExplanation, error) {
+			if epochNumber == "404" {
+				return nil, subsidy.ErrNotFound
+			}
+			return &subsidy.AllocationExplanation{VaultID: vaultId, EpochNumber: epochNumber, UserAddress: userAddress}, nil
+		},
+		ExplainAllocationsFunc: func(ctx context.Context, vaultId, epochNumber string, userAddresses []string) (*subsidy.AllocationExplanations, error) {
+			return &subsidy.AllocationExplanations{VaultID: vaultId, EpochNumber: epochNumber}, nil
+		},
+		ListQuarantinedAccountsFunc: func(ctx context.Context, vaultId, epochNumber string) ([]subsidy.QuarantinedAccount, error) {
+			if epochNumber == "x" {
+				return nil, subsidy.ErrInvalidInput
+			}
+			return []subsidy.QuarantinedAccount{}, nil
+		},
+		ReplayEpochFunc: func(ctx context.Context, vaultId, epochNumber string) (*subsidy.ReplayResult, error) {
+			if epochNumber == "404" {
+				return nil, subsidy.ErrNotFound
+			}
+			return &subsidy.ReplayResult{VaultID: vaultId, EpochNumber: epochNumber, Matches: true}, nil
+		},
+		DiffAllocationsFunc: func(ctx context.Context, vaultId, epochNumber string, top int) (*subsidy.AllocationDiff, error) {
+			if epochNumber == "404" {
+				return nil, subsidy.ErrNotFound
+			}
+			return &subsidy.AllocationDiff{VaultID: vaultId, EpochNumber: epochNumber}, nil
+		},
+		EpochStatsFunc: func(ctx context.Context, epochNumber, vaultId string) (*subsidy.EpochStats, error) {
+			if epochNumber == "404" {
+				return nil, subsidy.ErrNotFound
+			}
+			if vaultId == "bad" {
+				return nil, subsidy.ErrInvalidInput
+			}
+			return &subsidy.EpochStats{EpochNumber: epochNumber, Vaults: []subsidy.DistributionStats{}}, nil
+		},
+		ListCollectionWeightsFunc: func(ctx context.Context, vaultId string) ([]subsidy.CollectionWeight, error) {
+			return []subsidy.CollectionWeight{}, nil
+		},
+		DeleteCollectionWeightFunc: func(ctx context.Context, vaultId, collection string) error {
+			return subsidy.ErrNotFound
+		},
+		ListBlockedAddressesFunc: func(ctx context.Context) ([]subsidy.BlockedAddress, error) {
+			return []subsidy.BlockedAddress{}, nil
+		},
+		BlockAddressFunc: func(ctx context.Context, blocked subsidy.BlockedAddress) (*subsidy.BlockedAddress, error) {
+			return &blocked, nil
+		},
+		UnblockAddressFunc: func(ctx context.Context, address string) error {
+			return nil
+		},
+		ListAdjustmentsFunc: func(ctx context.Context, vaultId, epochNumber, status string) ([]subsidy.AllocationAdjustment, error) {
+			return []subsidy.AllocationAdjustment{}, nil
+		},
+		ApproveAdjustmentFunc: func(ctx context.Context, id string) (*subsidy.AllocationAdjustment, error) {
+			if id == "own" {
+				return nil, subsidy.ErrSameApprover
+			}
+			return &subsidy.AllocationAdjustment{ID: id, Status: subsidy.AdjustmentApproved}, nil
+		},
+		RejectAdjustmentFunc: func(ctx context.Context, id, reason string) (*subsidy.AllocationAdjustment, error) {
+			return &subsidy.AllocationAdjustment{ID: id, Status: subsidy.AdjustmentRejected}, nil
+		},
 	}
 
 	mockMerkleService := &merkle.ServiceMock{
@@ -46,13 +167,269 @@ func TestServerRoutes(t *testing.T) {
 		) (*merkle.UserMerkleProofResponse, error) {
 			return &merkle.UserMerkleProofResponse{}, nil
 		},
+		GenerateMerkleProofForRootFunc: func(
+			ctx context.Context,
+			userAddress, vaultAddress, epochNumber, merkleRoot string,
+		) (*merkle.UserMerkleProofResponse, error) {
+			return &merkle.UserMerkleProofResponse{}, nil
+		},
+		VerifyMerkleRootFunc: func(ctx context.Context, vaultAddress string) (*merkle.MerkleRootVerification, error) {
+			return &merkle.MerkleRootVerification{VaultAddress: vaultAddress, Match: true}, nil
+		},
+		ListRootUpdatesFunc: func(ctx context.Context, vaultAddress string) ([]merkle.RootUpdate, error) {
+			return []merkle.RootUpdate{{VaultAddress: vaultAddress, BlockNumber: 90}}, nil
+		},
+		GetUserClaimableFunc: func(ctx context.Context, userAddress string) (*merkle.UserClaimable, error) {
+			return &merkle.UserClaimable{UserAddress: userAddress, Vaults: []merkle.VaultClaimable{}}, nil
+		},
+		GetClaimPayloadFunc: func(ctx context.Context, userAddress, vaultAddress string) (*merkle.ClaimPayload, error) {
+			if userAddress == "0x2222222222222222222222222222222222222222" {
+				return nil, merkle.ErrClaimUnavailable
+			}
+			return &merkle.ClaimPayload{UserAddress: userAddress, VaultAddress: vaultAddress}, nil
+		},
+	}
+
+	mockAuditService := &audit.ServiceMock{
+		ListFunc: func(ctx context.Context, filter audit.Filter) (*audit.ListResponse, error) {
+			return &audit.ListResponse{}, nil
+		},
+	}
+
+	mockSignerService := &signer.ServiceMock{
+		CheckBalanceFunc: func(ctx context.Context) (*signer.BalanceStatus, error) {
+			return &signer.BalanceStatus{Balance: "1000"}, nil
+		},
+		StatusFunc: func() signer.BalanceStatus {
+			return signer.BalanceStatus{Balance: "1000"}
+		},
+	}
+
+	mockContracts := &contractstate.ServiceMock{
+		CheckFunc: func(ctx context.Context) (*contractstate.PauseStatus, error) {
+			return &contractstate.PauseStatus{}, nil
+		},
+		StatusFunc: func() contractstate.PauseStatus {
+			return contractstate.PauseStatus{}
+		},
+	}
+
+	mockGasService := &gas.ServiceMock{
+		ReportFunc: func(ctx context.Context, filter gas.ReportFilter) (*gas.Report, error) {
+			if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+				return nil, gas.ErrInvalidInput
+			}
+			return &gas.Report{ByOperation: map[string]gas.Rollup{}}, nil
+		},
+	}
+
+	mockPauseService := &pause.ServiceMock{
+		PauseFunc: func(ctx context.Context, job, reason string) (*pause.JobState, error) {
+			return &pause.JobState{Job: job, Paused: true, Reason: reason}, nil
+		},
+		ResumeFunc: func(ctx context.Context, job string) (*pause.JobState, error) {
+			return &pause.JobState{Job: job}, nil
+		},
+		StatusFunc: func(ctx context.Context) (*pause.Status, error) {
+			return &pause.Status{Jobs: []pause.JobState{{Job: pause.JobAll}}}, nil
+		},
+	}
+
+	mockJobService := &jobs.ServiceMock{
+		StatusFunc: func(ctx context.Context, limit int) (*jobs.Status, error) {
+			if limit > jobs.MaxHistory {
+				return nil, jobs.ErrInvalidInput
+			}
+			return &jobs.Status{Jobs: []jobs.JobStatus{{Job: pause.JobStartEpoch}}}, nil
+		},
+	}
+
+	mockReconciliation := &reconciliation.ServiceMock{
+		ReportsFunc: func(ctx context.Context, filter reconciliation.ReportFilter) (*reconciliation.ReportList, error) {
+			if filter.VaultID == "bad" {
+				return nil, reconciliation.ErrInvalidInput
+			}
+			return &reconciliation.ReportList{Reports: []reconciliation.Report{}}, nil
+		},
+		ClaimsReportsFunc: func(ctx context.Context, vaultId string) (*reconciliation.ClaimsReportList, error) {
+			if vaultId == "bad" {
+				return nil, reconciliation.ErrInvalidInput
+			}
+			return &reconciliation.ClaimsReportList{Reports: []reconciliation.ClaimsReport{}}, nil
+		},
+	}
+
+	mockAnalytics := &analytics.ServiceMock{
+		VaultAnalyticsFunc: func(ctx context.Context, query analytics.Query) (*analytics.VaultAnalytics, error) {
+			if query.VaultID == "bad" {
+				return nil, analytics.ErrInvalidInput
+			}
+			return &analytics.VaultAnalytics{
+				VaultID: query.VaultID,
+				Epochs:  []analytics.EpochPoint{{EpochID: "1", YieldGenerated: "1000", SubsidiesDistributed: "900"}},
+			}, nil
+		},
+		VaultCollectionsFunc: func(ctx context.Context, vaultID string) (*analytics.VaultCollections, error) {
+			if vaultID == "bad" {
+				return nil, analytics.ErrInvalidInput
+			}
+			return &analytics.VaultCollections{
+				VaultID:     vaultID,
+				Collections: []analytics.CollectionStats{{Collection: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", BorrowVolume: "700"}},
+				BlockNumber: 100,
+			}, nil
+		},
+		EpochReportFunc: func(ctx context.Context, epochID string, top int) (*analytics.EpochReport, error) {
+			if epochID == "99" {
+				return nil, analytics.ErrNotFound
+			}
+			if top > analytics.MaxReportTop {
+				return nil, analytics.ErrInvalidInput
+			}
+			return &analytics.EpochReport{
+				EpochID: epochID,
+				Vaults: []analytics.VaultEpochReport{{
+					VaultID:        "0x1234567890123456789012345678901234567890",
+					Total:          "900",
+					TopAllocations: []analytics.TopAllocation{{Rank: 1, Account: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Amount: "900", Share: "1"}},
+					Reconciliation: &analytics.ReconciliationSummary{YieldAllocated: "1000", Subsidies: "900", Claimed: "0"},
+				}},
+				Participants:   1,
+				TotalSubsidies: "900",
+				Transactions:   []gas.Usage{{TxHash: "0xabc", Action: "updateMerkleRoot", EpochID: epochID}},
+			}, nil
+		},
+	}
+
+	mockVaults := &vaults.ServiceMock{
+		ListVaultsFunc: func(ctx context.Context) ([]vaults.Vault, error) {
+			return []vaults.Vault{{Address: "0x1234567890123456789012345678901234567890", Status: vaults.StatusActive}}, nil
+		},
+	}
+
+	mockQueue := &queue.ServiceMock{
+		EnqueueFunc: func(ctx context.Context, kind, priority string, payload interface{}) (*queue.Job, error) {
+			if priority == "urgent" {
+				return nil, queue.ErrInvalidInput
+			}
+			if priority == queue.PriorityLow {
+				return nil, queue.ErrQueueFull
+			}
+			return &queue.Job{ID: "job-1", Kind: kind, Priority: priority, Status: queue.StatusQueued, EnqueuedAt: time.Now()}, nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*queue.Job, error) {
+			if id == "missing" {
+				return nil, queue.ErrNotFound
+			}
+			return &queue.Job{ID: id, Kind: queue.KindReplay, Status: queue.StatusSucceeded}, nil
+		},
+		ListFunc: func(ctx context.Context, status string) ([]queue.Job, error) {
+			if status == "done" {
+				return nil, queue.ErrInvalidInput
+			}
+			return []queue.Job{{ID: "job-1", Kind: queue.KindReplay, Status: queue.StatusSucceeded}}, nil
+		},
+	}
+
+	mockAssets := &assets.ServiceMock{
+		GetAssetFunc: func(ctx context.Context, vaultId string) (*assets.Asset, error) {
+			if vaultId == "invalid" {
+				return nil, assets.ErrInvalidInput
+			}
+			return &assets.Asset{VaultAddress: vaultId, Symbol: "USDC", Decimals: 6}, nil
+		},
+	}
+
+	mockDeadLetters := &deadletter.ServiceMock{
+		ListFunc: func(ctx context.Context, kind string) ([]deadletter.Entry, error) {
+			if kind == "email" {
+				return nil, deadletter.ErrInvalidInput
+			}
+			return []deadletter.Entry{{ID: "letter-1", Kind: deadletter.KindWebhook, Target: "https://hooks.example.com"}}, nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*deadletter.Entry, error) {
+			if id == "missing" {
+				return nil, deadletter.ErrNotFound
+			}
+			return &deadletter.Entry{ID: id, Kind: deadletter.KindWebhook, Target: "https://hooks.example.com"}, nil
+		},
+		RetryFunc: func(ctx context.Context, id string) (*deadletter.RetryResult, error) {
+			switch id {
+			case "superseded":
+				return nil, deadletter.ErrNotRetryable
+			case "unreachable":
+				return nil, deadletter.ErrRetryFailed
+			}
+			return &deadletter.RetryResult{Entry: deadletter.Entry{ID: id}, Outcome: deadletter.OutcomeDelivered}, nil
+		},
+		DiscardFunc: func(ctx context.Context, id string) error {
+			if id == "missing" {
+				return deadletter.ErrNotFound
+			}
+			return nil
+		},
+	}
+
+	mockCompatibility := &compatibility.ServiceMock{
+		CheckFunc: func(ctx context.Context) (*compatibility.Matrix, error) {
+			return &compatibility.Matrix{Contracts: []compatibility.ContractCompatibility{}, Compatible: true}, nil
+		},
+	}
+
+	mockFeatures := &features.ServiceMock{
+		StatusFunc: func(ctx context.Context) (*features.Status, error) {
+			return &features.Status{Flags: []features.FlagState{{Flag: features.FlagAutoApproval, Enabled: true, Source: features.SourceDefault}}}, nil
+		},
+		SetFunc: func(ctx context.Context, flag string, enabled bool, reason string) (*features.FlagState, error) {
+			if !features.ValidFlag(flag) {
+				return nil, features.ErrInvalidInput
+			}
+			return &features.FlagState{Flag: flag, Enabled: enabled, Source: features.SourceOverride, Reason: reason}, nil
+		},
+		ClearFunc: func(ctx context.Context, flag string) (*features.FlagState, error) {
+			if !features.ValidFlag(flag) {
+				return nil, features.ErrInvalidInput
+			}
+			return &features.FlagState{Flag: flag, Enabled: true, Source: features.SourceDefault}, nil
+		},
+	}
+
+	mockTrigger := &scheduler.TriggerMock{
+		TriggerFunc: func(ctx context.Context) (*scheduler.BoundaryResult, error) {
+			return &scheduler.BoundaryResult{Mode: scheduler.ModeManual, TriggeredAt: time.Now()}, nil
+		},
 	}
 
 	logger := lgr.NoOp
 	cfg := &config.Config{}
+	cfg.Approval.APIKeys = []string{"approver-key"}
+	cfg.Admin.APIKeys = []string{"admin-key"}
 
 	// Create server
-	server := NewServer(mockEpochService, mockSubsidyService, mockMerkleService, logger, cfg)
+	server := NewServer(
+		mockEpochService,
+		mockSubsidyService,
+		mockMerkleService,
+		mockAuditService,
+		mockSignerService,
+		mockContracts,
+		mockGasService,
+		mockPauseService,
+		mockJobService,
+		mockReconciliation,
+		mockAnalytics,
+		mockVaults,
+		mockQueue,
+		mockAssets,
+		mockDeadLetters,
+		mockCompatibility,
+		mockFeatures,
+		nil,
+		mockTrigger,
+		metrics.NewRegistry(),
+		logger,
+		cfg,
+	)
 	handler := server.SetupRoutes()
 
 	// Test cases for different routes
@@ -60,6 +437,7 @@ func TestServerRoutes(t *testing.T) {
 		name           string
 		method         string
 		path           string
+		apiKey         string
 		expectedStatus int
 		description    string
 	}{
@@ -70,6 +448,69 @@ func TestServerRoutes(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			description:    "Health check endpoint",
 		},
+		{
+			name:           "epoch_list",
+			method:         "GET",
+			path:           "/api/epochs",
+			expectedStatus: http.StatusOK,
+			description:    "List epochs endpoint",
+		},
+		{
+			name:           "epoch_onchain_state",
+			method:         "GET",
+			path:           "/api/epochs/current/onchain",
+			expectedStatus: http.StatusOK,
+			description:    "On-chain epoch state endpoint",
+		},
+		{
+			name:           "epoch_onchain_state_invalid_vault",
+			method:         "GET",
+			path:           "/api/epochs/current/onchain?vault=bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "On-chain epoch state rejects invalid vault addresses",
+		},
+		{
+			name:           "epoch_timeline",
+			method:         "GET",
+			path:           "/api/epochs/3/timeline",
+			expectedStatus: http.StatusOK,
+			description:    "Epoch timeline endpoint",
+		},
+		{
+			name:           "epoch_timeline_invalid_id",
+			method:         "GET",
+			path:           "/api/epochs/three/timeline",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Epoch timeline rejects invalid epoch ids",
+		},
+		{
+			name:           "epoch_timeline_unknown_epoch",
+			method:         "GET",
+			path:           "/api/epochs/9/timeline",
+			expectedStatus: http.StatusNotFound,
+			description:    "Epoch timeline of an epoch nobody knows of",
+		},
+		{
+			name:           "epoch_stats",
+			method:         "GET",
+			path:           "/api/epochs/5/stats?vault=0x1234567890123456789012345678901234567890",
+			expectedStatus: http.StatusOK,
+			description:    "Epoch distribution statistics endpoint",
+		},
+		{
+			name:           "epoch_stats_invalid_vault",
+			method:         "GET",
+			path:           "/api/epochs/5/stats?vault=bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Epoch distribution statistics reject invalid vault addresses",
+		},
+		{
+			name:           "epoch_stats_not_found",
+			method:         "GET",
+			path:           "/api/epochs/404/stats?vault=0x1234567890123456789012345678901234567890",
+			expectedStatus: http.StatusNotFound,
+			description:    "Epoch distribution statistics of a vault not distributed in the epoch",
+		},
 		{
 			name:           "epoch_start",
 			method:         "POST",
@@ -91,6 +532,20 @@ func TestServerRoutes(t *testing.T) {
 			expectedStatus: http.StatusAccepted,
 			description:    "Distribute subsidies endpoint",
 		},
+		{
+			name:           "epoch_distribute_async",
+			method:         "POST",
+			path:           "/api/epochs/distribute?async=true&priority=high",
+			expectedStatus: http.StatusAccepted,
+			description:    "Distribution queued as a job",
+		},
+		{
+			name:           "epoch_distribute_async_invalid",
+			method:         "POST",
+			path:           "/api/epochs/distribute?async=maybe",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Distribution rejects a malformed async parameter",
+		},
 		{
 			name:           "user_total_earned",
 			method:         "GET",
@@ -98,6 +553,34 @@ func TestServerRoutes(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			description:    "Get user total earned endpoint",
 		},
+		{
+			name:           "user_history",
+			method:         "GET",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/history?limit=10",
+			expectedStatus: http.StatusOK,
+			description:    "Get user participation history endpoint",
+		},
+		{
+			name:           "user_history_invalid_address",
+			method:         "GET",
+			path:           "/api/users/not-an-address/history",
+			expectedStatus: http.StatusBadRequest,
+			description:    "User history rejects a malformed address",
+		},
+		{
+			name:           "user_expirations",
+			method:         "GET",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/expirations",
+			expectedStatus: http.StatusOK,
+			description:    "Get user claim expirations endpoint",
+		},
+		{
+			name:           "user_expirations_invalid_address",
+			method:         "GET",
+			path:           "/api/users/not-an-address/expirations",
+			expectedStatus: http.StatusBadRequest,
+			description:    "User claim expirations reject a malformed address",
+		},
 		{
 			name:           "user_merkle_proof",
 			method:         "GET",
@@ -112,27 +595,908 @@ func TestServerRoutes(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			description:    "Get user historical merkle proof endpoint",
 		},
-		// Note: Swagger UI test is disabled as it requires static files to be served
-		// which don't work well in test environment. The endpoint works in production.
-		// {
-		//     name:           "swagger_ui",
-		//     method:         "GET",
-		//     path:           "/swagger/",
-		//     expectedStatus: http.StatusOK,
-		//     description:    "Swagger UI endpoint",
-		// },
 		{
-			name:           "not_found",
+			name:           "user_claimable",
 			method:         "GET",
-			path:           "/api/nonexistent",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/claimable",
+			expectedStatus: http.StatusOK,
+			description:    "Get user claimable summary endpoint",
+		},
+		{
+			name:           "user_claimable_invalid_address",
+			method:         "GET",
+			path:           "/api/users/not-an-address/claimable",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Claimable summary rejects malformed addresses",
+		},
+		{
+			name:           "user_claim_payload",
+			method:         "GET",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/claim-payload",
+			expectedStatus: http.StatusOK,
+			description:    "Get user claim payload endpoint",
+		},
+		{
+			name:           "user_claim_payload_invalid_vault",
+			method:         "GET",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/claim-payload?vault=bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Claim payload rejects malformed vault addresses",
+		},
+		{
+			name:           "user_claim_payload_unavailable",
+			method:         "GET",
+			path:           "/api/users/0x2222222222222222222222222222222222222222/claim-payload",
+			expectedStatus: http.StatusConflict,
+			description:    "Claim payload refuses claims that would revert",
+		},
+		{
+			name:           "user_claim_payload_bad_checksum",
+			method:         "GET",
+			path:           "/api/users/0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD/claim-payload",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Claim payload refuses mixed-case recipients with a wrong checksum",
+		},
+		{
+			name:           "user_claim_signature_missing_body",
+			method:         "POST",
+			path:           "/api/users/0x1234567890123456789012345678901234567890/claim-payload/verify",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Claim signature verification requires the signature in the body",
+		},
+		{
+			name:           "proof_latest",
+			method:         "GET",
+			path:           "/api/proofs?address=0x1234567890123456789012345678901234567890",
+			expectedStatus: http.StatusOK,
+			description:    "Get proof against the latest root",
+		},
+		{
+			name:           "proof_for_replaced_root",
+			method:         "GET",
+			path:           "/api/proofs?address=0x1234567890123456789012345678901234567890&epoch=3&root=0xabc",
+			expectedStatus: http.StatusOK,
+			description:    "Get proof against a specific root of an epoch",
+		},
+		{
+			name:           "proof_root_without_epoch",
+			method:         "GET",
+			path:           "/api/proofs?address=0x1234567890123456789012345678901234567890&root=0xabc",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Root selection requires an epoch",
+		},
+		{
+			name:           "proofs_verify_missing_body",
+			method:         "POST",
+			path:           "/api/proofs/verify",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Bulk proof verification requires the proofs to verify",
+		},
+		{
+			name:           "vault_merkle_root_verify",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/merkle-root/verify",
+			expectedStatus: http.StatusOK,
+			description:    "Verify vault merkle root endpoint",
+		},
+		{
+			name:           "vault_roots",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/roots",
+			expectedStatus: http.StatusOK,
+			description:    "Vault merkle root history endpoint",
+		},
+		{
+			name:           "vault_roots_invalid_vault",
+			method:         "GET",
+			path:           "/api/vaults/vault/roots",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Root history requires a valid vault address",
+		},
+		{
+			name:           "explain_allocation",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/users/0x742d35Cc6634C0532925a3b844Bc454e4438f44e/explain",
+			expectedStatus: http.StatusOK,
+			description:    "Explain user allocation endpoint",
+		},
+		{
+			name:           "explain_allocation_invalid_user",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/users/not-an-address/explain",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Explain user allocation rejects malformed addresses",
+		},
+		{
+			name:           "explain_allocation_not_found",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/404/users/0x742d35Cc6634C0532925a3b844Bc454e4438f44e/explain",
 			expectedStatus: http.StatusNotFound,
-			description:    "Non-existent endpoint should return 404",
+			description:    "Explain user allocation without a distribution",
 		},
-	}
-
-	for _, tt := range tests {
+		{
+			name:           "explain_allocations_missing_body",
+			method:         "POST",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/explain",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Batched explanation requires the users to explain",
+		},
+		{
+			name:           "list_quarantined_accounts",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/quarantine?epoch=5",
+			expectedStatus: http.StatusOK,
+			description:    "List quarantined accounts endpoint",
+		},
+		{
+			name:           "list_quarantined_accounts_invalid_epoch",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/quarantine?epoch=x",
+			expectedStatus: http.StatusBadRequest,
+			description:    "List quarantined accounts rejects malformed epochs",
+		},
+		{
+			name:           "replay_epoch_async",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/replay?async=true",
+			expectedStatus: http.StatusAccepted,
+			description:    "Replay queued as a job",
+		},
+		{
+			name:           "replay_epoch_async_invalid_priority",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/replay?async=true&priority=urgent",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Queued replay rejects an unknown priority",
+		},
+		{
+			name:           "replay_epoch_async_queue_full",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/replay?async=true&priority=low",
+			expectedStatus: http.StatusServiceUnavailable,
+			description:    "Replay is not queued while the queue is full",
+		},
+		{
+			name:           "replay_epoch",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/replay",
+			expectedStatus: http.StatusOK,
+			description:    "Replay epoch endpoint",
+		},
+		{
+			name:           "replay_epoch_not_found",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/404/replay",
+			expectedStatus: http.StatusNotFound,
+			description:    "Replay epoch without a distribution",
+		},
+		{
+			name:           "diff_allocations",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/diff?top=20",
+			expectedStatus: http.StatusOK,
+			description:    "Allocation diff endpoint",
+		},
+		{
+			name:           "diff_allocations_invalid_top",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/5/diff?top=0",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Allocation diff endpoint rejects a non-positive top",
+		},
+		{
+			name:           "diff_allocations_not_found",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/epochs/404/diff",
+			expectedStatus: http.StatusNotFound,
+			description:    "Allocation diff of an epoch without a distribution",
+		},
+		{
+			name:           "audit_list",
+			method:         "GET",
+			path:           "/api/audit?action=updateMerkleRoot&since=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+			description:    "List audit log endpoint",
+		},
+		{
+			name:           "audit_list_invalid_since",
+			method:         "GET",
+			path:           "/api/audit?since=yesterday",
+			expectedStatus: http.StatusBadRequest,
+			description:    "List audit log endpoint rejects malformed time filters",
+		},
+		{
+			name:           "gas_report",
+			method:         "GET",
+			path:           "/api/reports/gas?epoch=3&since=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusOK,
+			description:    "Gas spend report endpoint",
+		},
+		{
+			name:           "gas_report_invalid_window",
+			method:         "GET",
+			path:           "/api/reports/gas?since=2025-02-01T00:00:00Z&until=2025-01-01T00:00:00Z",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Gas spend report endpoint rejects an inverted time window",
+		},
+		{
+			name:           "reconciliation_report",
+			method:         "GET",
+			path:           "/api/reports/reconciliation?flagged=true&limit=10",
+			expectedStatus: http.StatusOK,
+			description:    "Yield reconciliation report endpoint",
+		},
+		{
+			name:           "reconciliation_report_invalid_flagged",
+			method:         "GET",
+			path:           "/api/reports/reconciliation?flagged=maybe",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Yield reconciliation report endpoint rejects a malformed flagged filter",
+		},
+		{
+			name:           "reconciliation_report_invalid_vault",
+			method:         "GET",
+			path:           "/api/reports/reconciliation?vault=bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Yield reconciliation report endpoint rejects an invalid vault",
+		},
+		{
+			name:           "claims_reconciliation_report",
+			method:         "GET",
+			path:           "/api/reports/reconciliation/claims?vault=0x1234567890123456789012345678901234567890",
+			expectedStatus: http.StatusOK,
+			description:    "Claims reconciliation report endpoint",
+		},
+		{
+			name:           "claims_reconciliation_report_invalid_vault",
+			method:         "GET",
+			path:           "/api/reports/reconciliation/claims?vault=bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Claims reconciliation report endpoint rejects an invalid vault",
+		},
+		{
+			name:           "vault_analytics",
+			method:         "GET",
+			path:           "/api/analytics/vaults/0x1234567890123456789012345678901234567890?from=1&to=5",
+			expectedStatus: http.StatusOK,
+			description:    "Vault analytics endpoint",
+		},
+		{
+			name:           "vault_analytics_csv",
+			method:         "GET",
+			path:           "/api/analytics/vaults/0x1234567890123456789012345678901234567890?format=csv",
+			expectedStatus: http.StatusOK,
+			description:    "Vault analytics endpoint exports CSV",
+		},
+		{
+			name:           "vault_analytics_invalid_format",
+			method:         "GET",
+			path:           "/api/analytics/vaults/0x1234567890123456789012345678901234567890?format=xml",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Vault analytics endpoint rejects an unknown format",
+		},
+		{
+			name:           "vault_analytics_invalid_vault",
+			method:         "GET",
+			path:           "/api/analytics/vaults/bad",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Vault analytics endpoint rejects an invalid vault",
+		},
+		{
+			name:           "vault_collections",
+			method:         "GET",
+			path:           "/api/analytics/vaults/0x1234567890123456789012345678901234567890/collections",
+			expectedStatus: http.StatusOK,
+			description:    "Vault collection stats endpoint",
+		},
+		{
+			name:           "vault_collections_invalid_vault",
+			method:         "GET",
+			path:           "/api/analytics/vaults/bad/collections",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Vault collection stats endpoint rejects an invalid vault",
+		},
+		{
+			name:           "epoch_report",
+			method:         "GET",
+			path:           "/api/epochs/1/report?top=5",
+			expectedStatus: http.StatusOK,
+			description:    "Epoch report endpoint",
+		},
+		{
+			name:           "epoch_report_csv",
+			method:         "GET",
+			path:           "/api/epochs/1/report?format=csv",
+			expectedStatus: http.StatusOK,
+			description:    "Epoch report endpoint exports CSV",
+		},
+		{
+			name:           "epoch_report_invalid_top",
+			method:         "GET",
+			path:           "/api/epochs/1/report?top=many",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Epoch report endpoint rejects a malformed top",
+		},
+		{
+			name:           "epoch_report_not_found",
+			method:         "GET",
+			path:           "/api/epochs/99/report",
+			expectedStatus: http.StatusNotFound,
+			description:    "Epoch report endpoint reports epochs without a distribution as not found",
+		},
+		{
+			name:           "distributions_list",
+			method:         "GET",
+			path:           "/api/distributions?status=pending_approval",
+			expectedStatus: http.StatusOK,
+			description:    "List staged distributions endpoint",
+		},
+		{
+			name:           "distributions_list_paged",
+			method:         "GET",
+			path:           "/api/distributions?limit=10&offset=20&sort=epochNumber&order=desc",
+			expectedStatus: http.StatusOK,
+			description:    "List staged distributions endpoint pages and sorts",
+		},
+		{
+			name:           "distributions_list_unknown_sort",
+			method:         "GET",
+			path:           "/api/distributions?sort=size",
+			expectedStatus: http.StatusBadRequest,
+			description:    "List staged distributions endpoint rejects unknown sort fields",
+		},
+		{
+			name:           "epoch_list_invalid_order",
+			method:         "GET",
+			path:           "/api/epochs?order=sideways",
+			expectedStatus: http.StatusBadRequest,
+			description:    "List epochs endpoint rejects unknown sort orders",
+		},
+		{
+			name:           "audit_list_offset",
+			method:         "GET",
+			path:           "/api/audit?offset=100",
+			expectedStatus: http.StatusBadRequest,
+			description:    "List audit log endpoint pages with cursors, not offsets",
+		},
+		{
+			name:           "distribution_approve_without_key",
+			method:         "POST",
+			path:           "/api/distributions/abc/approve",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Approval requires an API key",
+		},
+		{
+			name:           "distribution_approve_wrong_key",
+			method:         "POST",
+			path:           "/api/distributions/abc/approve",
+			apiKey:         "guess",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Approval rejects unknown API keys",
+		},
+		{
+			name:           "distribution_approve",
+			method:         "POST",
+			path:           "/api/distributions/abc/approve",
+			apiKey:         "approver-key",
+			expectedStatus: http.StatusOK,
+			description:    "Approve staged distribution endpoint",
+		},
+		{
+			name:           "distribution_reject",
+			method:         "POST",
+			path:           "/api/distributions/abc/reject",
+			apiKey:         "approver-key",
+			expectedStatus: http.StatusOK,
+			description:    "Reject staged distribution endpoint",
+		},
+		{
+			name:           "graphql_query",
+			method:         "GET",
+			path:           "/api/graphql?query=%7Bepochs(limit:5)%7BepochNumber%7D%7D",
+			expectedStatus: http.StatusOK,
+			description:    "GraphQL query over GET",
+		},
+		{
+			name:           "graphql_mutation_rejected",
+			method:         "GET",
+			path:           "/api/graphql?query=mutation%7Bepochs%7BepochNumber%7D%7D",
+			expectedStatus: http.StatusBadRequest,
+			description:    "GraphQL endpoint is read-only",
+		},
+		{
+			name:           "graphql_missing_body",
+			method:         "POST",
+			path:           "/api/graphql",
+			expectedStatus: http.StatusBadRequest,
+			description:    "GraphQL POST requires a JSON body",
+		},
+		{
+			name:           "signer_status",
+			method:         "GET",
+			path:           "/api/signer",
+			expectedStatus: http.StatusOK,
+			description:    "Signer balance status endpoint",
+		},
+		{
+			name:           "operational_status",
+			method:         "GET",
+			path:           "/api/status",
+			expectedStatus: http.StatusOK,
+			description:    "Contract pause and signer status endpoint",
+		},
+		{
+			name:           "contract_compatibility",
+			method:         "GET",
+			path:           "/api/status/contracts",
+			expectedStatus: http.StatusOK,
+			description:    "Contract ABI compatibility endpoint",
+		},
+		{
+			name:           "scheduler_jobs",
+			method:         "GET",
+			path:           "/api/scheduler/jobs",
+			expectedStatus: http.StatusOK,
+			description:    "Scheduler job status endpoint",
+		},
+		{
+			name:           "scheduler_jobs_invalid_limit",
+			method:         "GET",
+			path:           "/api/scheduler/jobs?limit=abc",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Scheduler job status rejects a malformed limit",
+		},
+		{
+			name:           "scheduler_jobs_limit_too_large",
+			method:         "GET",
+			path:           "/api/scheduler/jobs?limit=500",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Scheduler job status rejects a limit over the history kept",
+		},
+		{
+			name:           "queued_jobs",
+			method:         "GET",
+			path:           "/api/jobs?status=succeeded",
+			expectedStatus: http.StatusOK,
+			description:    "Jobs queued with async=true",
+		},
+		{
+			name:           "queued_jobs_invalid_status",
+			method:         "GET",
+			path:           "/api/jobs?status=done",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Queued jobs reject an unknown status",
+		},
+		{
+			name:           "queued_job",
+			method:         "GET",
+			path:           "/api/jobs/job-1",
+			expectedStatus: http.StatusOK,
+			description:    "Queued job status endpoint",
+		},
+		{
+			name:           "queued_job_not_found",
+			method:         "GET",
+			path:           "/api/jobs/missing",
+			expectedStatus: http.StatusNotFound,
+			description:    "Unknown or pruned job",
+		},
+		{
+			name:           "scheduler_pause_state",
+			method:         "GET",
+			path:           "/admin/scheduler",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Scheduler pause state endpoint",
+		},
+		{
+			name:           "scheduler_pause",
+			method:         "POST",
+			path:           "/admin/scheduler/pause",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Pause scheduler endpoint",
+		},
+		{
+			name:           "scheduler_resume",
+			method:         "POST",
+			path:           "/admin/scheduler/resume",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Resume scheduler endpoint",
+		},
+		{
+			name:           "scheduler_trigger",
+			method:         "POST",
+			path:           "/admin/scheduler/trigger",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusAccepted,
+			description:    "Trigger epoch boundary endpoint",
+		},
+		{
+			name:           "vaults",
+			method:         "GET",
+			path:           "/admin/vaults",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Vault registry endpoint",
+		},
+		{
+			name:           "vault_onboard_missing_body",
+			method:         "POST",
+			path:           "/admin/vaults",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Onboarding a vault requires the vault in the body",
+		},
+		{
+			name:           "public_vaults",
+			method:         "GET",
+			path:           "/api/vaults?status=decommissioned",
+			expectedStatus: http.StatusOK,
+			description:    "Public vault registry endpoint",
+		},
+		{
+			name:           "public_vaults_unknown_status",
+			method:         "GET",
+			path:           "/api/vaults?status=paused",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Public vault registry filters by registry status only",
+		},
+		{
+			name:           "vault_asset",
+			method:         "GET",
+			path:           "/api/vaults/0x1234567890123456789012345678901234567890/asset",
+			expectedStatus: http.StatusOK,
+			description:    "Vault asset endpoint",
+		},
+		{
+			name:           "vault_asset_invalid_vault",
+			method:         "GET",
+			path:           "/api/vaults/invalid/asset",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Vault asset requires a valid vault address",
+		},
+		{
+			name:           "vault_onboard_no_key",
+			method:         "POST",
+			path:           "/admin/vaults",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Onboarding a vault requires an admin API key",
+		},
+		{
+			name:           "vault_decommission_missing_body",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/decommission",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Decommissioning a vault requires the reason in the body",
+		},
+		{
+			name:           "snapshot_block_pin_missing_body",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/snapshot-block",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Pinning a snapshot block requires the block in the body",
+		},
+		{
+			name:           "snapshot_block_pin_no_key",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/snapshot-block",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Pinning a snapshot block requires an admin API key",
+		},
+		{
+			name:           "fingerprint_override_missing_body",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/fingerprint-override",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Overriding a distribution fingerprint requires the reason in the body",
+		},
+		{
+			name:           "fingerprint_override_no_key",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/fingerprint-override",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Overriding a distribution fingerprint requires an admin API key",
+		},
+		{
+			name:           "replace_root_missing_body",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/replace-root",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Replacing an epoch root requires the reason in the body",
+		},
+		{
+			name:           "replace_root_no_key",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/replace-root",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Replacing an epoch root requires an admin API key",
+		},
+		{
+			name:           "collection_weights",
+			method:         "GET",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Collection weights endpoint",
+		},
+		{
+			name:           "collection_weight_set_missing_body",
+			method:         "PUT",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights/0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Setting a collection weight requires the multiplier in the body",
+		},
+		{
+			name:           "collection_weight_invalid_collection",
+			method:         "PUT",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights/invalid",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Setting a collection weight requires a valid collection address",
+		},
+		{
+			name:           "collection_weight_delete_not_found",
+			method:         "DELETE",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights/0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusNotFound,
+			description:    "Deleting a weight that was never set",
+		},
+		{
+			name:           "collection_weights_no_key",
+			method:         "GET",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Collection weights require an admin API key",
+		},
+		{
+			name:           "adjustments",
+			method:         "GET",
+			path:           "/admin/adjustments?status=pending_approval",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Allocation adjustments endpoint",
+		},
+		{
+			name:           "adjustment_propose_without_body",
+			method:         "POST",
+			path:           "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/adjustments",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Proposing an adjustment requires its account, amount and justification",
+		},
+		{
+			name:           "adjustment_approve",
+			method:         "POST",
+			path:           "/admin/adjustments/abc/approve",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Approve adjustment endpoint",
+		},
+		{
+			name:           "adjustment_approve_own",
+			method:         "POST",
+			path:           "/admin/adjustments/own/approve",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusForbidden,
+			description:    "An adjustment cannot be approved by whoever proposed it",
+		},
+		{
+			name:           "adjustment_reject_without_reason",
+			method:         "POST",
+			path:           "/admin/adjustments/abc/reject",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Rejecting an adjustment does not require a reason",
+		},
+		{
+			name:           "adjustments_no_key",
+			method:         "GET",
+			path:           "/admin/adjustments",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Allocation adjustments require an admin API key",
+		},
+		{
+			name:           "blocklist",
+			method:         "GET",
+			path:           "/admin/blocklist",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Blocklist endpoint",
+		},
+		{
+			name:           "blocklist_block_without_reason",
+			method:         "PUT",
+			path:           "/admin/blocklist/0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Blocking an address does not require a reason",
+		},
+		{
+			name:           "blocklist_block_invalid_address",
+			method:         "PUT",
+			path:           "/admin/blocklist/invalid",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Blocking requires a valid address",
+		},
+		{
+			name:           "blocklist_unblock",
+			method:         "DELETE",
+			path:           "/admin/blocklist/0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusNoContent,
+			description:    "Unblock address endpoint",
+		},
+		{
+			name:           "blocklist_no_key",
+			method:         "PUT",
+			path:           "/admin/blocklist/0x742d35Cc6634C0532925a3b844Bc454e4438f44e",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Blocking an address requires an admin API key",
+		},
+		{
+			name:           "dead_letters",
+			method:         "GET",
+			path:           "/admin/dead-letters?kind=webhook",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Dead letters endpoint",
+		},
+		{
+			name:           "dead_letters_unknown_kind",
+			method:         "GET",
+			path:           "/admin/dead-letters?kind=email",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Dead letters are filtered by a known kind",
+		},
+		{
+			name:           "dead_letters_no_key",
+			method:         "GET",
+			path:           "/admin/dead-letters",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Dead letters require an admin API key",
+		},
+		{
+			name:           "dead_letter_missing",
+			method:         "GET",
+			path:           "/admin/dead-letters/missing",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusNotFound,
+			description:    "Unknown dead letter",
+		},
+		{
+			name:           "dead_letter_retry",
+			method:         "POST",
+			path:           "/admin/dead-letters/letter-1/retry",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Retry dead letter endpoint",
+		},
+		{
+			name:           "dead_letter_retry_not_retryable",
+			method:         "POST",
+			path:           "/admin/dead-letters/superseded/retry",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusConflict,
+			description:    "A dead letter that can no longer be retried conflicts",
+		},
+		{
+			name:           "dead_letter_retry_failed",
+			method:         "POST",
+			path:           "/admin/dead-letters/unreachable/retry",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadGateway,
+			description:    "A failed retry is a bad gateway",
+		},
+		{
+			name:           "dead_letter_discard",
+			method:         "DELETE",
+			path:           "/admin/dead-letters/letter-1",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusNoContent,
+			description:    "Discard dead letter endpoint",
+		},
+		{
+			name:           "features",
+			method:         "GET",
+			path:           "/admin/features",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Feature flags endpoint",
+		},
+		{
+			name:           "features_no_key",
+			method:         "GET",
+			path:           "/admin/features",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Feature flags require an admin API key",
+		},
+		{
+			name:           "feature_set_without_enabled",
+			method:         "PUT",
+			path:           "/admin/features/auto_approval",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Overriding a flag requires whether it is on",
+		},
+		{
+			name:           "feature_clear",
+			method:         "DELETE",
+			path:           "/admin/features/auto_approval",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusOK,
+			description:    "Clear feature flag override endpoint",
+		},
+		{
+			name:           "feature_clear_unknown",
+			method:         "DELETE",
+			path:           "/admin/features/new_thing",
+			apiKey:         "admin-key",
+			expectedStatus: http.StatusBadRequest,
+			description:    "Only known flags can be cleared",
+		},
+		{
+			name:           "scheduler_pause_approval_key",
+			method:         "POST",
+			path:           "/admin/scheduler/pause",
+			apiKey:         "approver-key",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Pausing the scheduler requires an admin API key",
+		},
+		{
+			name:           "scheduler_pause_state_no_key",
+			method:         "GET",
+			path:           "/admin/scheduler",
+			expectedStatus: http.StatusUnauthorized,
+			description:    "Scheduler pause state requires an admin API key",
+		},
+		{
+			name:           "metrics",
+			method:         "GET",
+			path:           "/metrics",
+			expectedStatus: http.StatusOK,
+			description:    "Prometheus metrics endpoint",
+		},
+		{
+			name:           "swagger_spec",
+			method:         "GET",
+			path:           "/swagger.json",
+			expectedStatus: http.StatusOK,
+			description:    "OpenAPI document endpoint",
+		},
+		// Note: Swagger UI test is disabled as it requires static files to be served
+		// which don't work well in test environment. The endpoint works in production.
+		// {
+		//     name:           "swagger_ui",
+		//     method:         "GET",
+		//     path:           "/swagger/",
+		//     expectedStatus: http.StatusOK,
+		//     description:    "Swagger UI endpoint",
+		// },
+		{
+			name:           "not_found",
+			method:         "GET",
+			path:           "/api/nonexistent",
+			expectedStatus: http.StatusNotFound,
+			description:    "Non-existent endpoint should return 404",
+		},
+	}
+
+	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set("X-API-Key", tt.apiKey)
+			}
 			rr := httptest.NewRecorder()
 
 			handler.ServeHTTP(rr, req)
@@ -144,9 +1508,447 @@ func TestServerRoutes(t *testing.T) {
 	}
 }
 
+func TestServerRoutes_ReadOnly(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			return &epoch.ListEpochsResponse{}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		ApproveDistributionFunc: func(ctx context.Context, id string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed", StagedID: id}, nil
+		},
+	}
+	mockMerkleService := &merkle.ServiceMock{
+		GenerateUserMerkleProofFunc: func(
+			ctx context.Context,
+			userAddress, vaultAddress string,
+		) (*merkle.UserMerkleProofResponse, error) {
+			return &merkle.UserMerkleProofResponse{}, nil
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Server.ReadOnly = true
+	cfg.Approval.APIKeys = []string{"approver-key"}
+	cfg.Admin.APIKeys = []string{"approver-key"}
+	server := NewServer(mockEpochService, mockSubsidyService, mockMerkleService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, metrics.NewRegistry(), lgr.NoOp, cfg)
+	handler := server.SetupRoutes()
+
+	tests := []struct {
+		method         string
+		path           string
+		expectedStatus int
+	}{
+		{"POST", "/api/epochs/start", http.StatusForbidden},
+		{"POST", "/api/epochs/force-end", http.StatusForbidden},
+		{"POST", "/api/epochs/distribute", http.StatusForbidden},
+		{"POST", "/api/distributions/staged-1/approve", http.StatusForbidden},
+		{"POST", "/api/distributions/staged-1/reject", http.StatusForbidden},
+		{"POST", "/admin/scheduler/pause", http.StatusForbidden},
+		{"POST", "/admin/scheduler/resume", http.StatusForbidden},
+		{"POST", "/admin/scheduler/trigger", http.StatusForbidden},
+		{"POST", "/admin/vaults", http.StatusForbidden},
+		{"POST", "/admin/vaults/0x1234567890123456789012345678901234567890/decommission", http.StatusForbidden},
+		{"POST", "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/snapshot-block", http.StatusForbidden},
+		{"POST", "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/fingerprint-override", http.StatusForbidden},
+		{"POST", "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/replace-root", http.StatusForbidden},
+		{"PUT", "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights/0x742d35Cc6634C0532925a3b844Bc454e4438f44e", http.StatusForbidden},
+		{"DELETE", "/admin/vaults/0x1234567890123456789012345678901234567890/collection-weights/0x742d35Cc6634C0532925a3b844Bc454e4438f44e", http.StatusForbidden},
+		{"POST", "/admin/vaults/0x1234567890123456789012345678901234567890/epochs/5/adjustments", http.StatusForbidden},
+		{"POST", "/admin/adjustments/abc/approve", http.StatusForbidden},
+		{"POST", "/admin/adjustments/abc/reject", http.StatusForbidden},
+		{"PUT", "/admin/blocklist/0x742d35Cc6634C0532925a3b844Bc454e4438f44e", http.StatusForbidden},
+		{"DELETE", "/admin/blocklist/0x742d35Cc6634C0532925a3b844Bc454e4438f44e", http.StatusForbidden},
+		{"POST", "/admin/dead-letters/letter-1/retry", http.StatusForbidden},
+		{"DELETE", "/admin/dead-letters/letter-1", http.StatusForbidden},
+		{"PUT", "/admin/features/auto_approval", http.StatusForbidden},
+		{"DELETE", "/admin/features/auto_approval", http.StatusForbidden},
+		{"GET", "/api/epochs", http.StatusOK},
+		{"GET", "/api/users/0x742d35Cc6634C0532925a3b844Bc454e4438f44e/merkle-proof?vault=0x742d35Cc6634C0532925a3b844Bc454e4438f44e", http.StatusOK},
+		{"GET", "/health", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-API-Key", "approver-key")
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectedStatus {
+				t.Errorf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if n := len(mockEpochService.StartEpochCalls()); n != 0 {
+		t.Errorf("expected no start epoch calls on a read-only server, got %d", n)
+	}
+	if n := len(mockSubsidyService.ApproveDistributionCalls()); n != 0 {
+		t.Errorf("expected no approvals on a read-only server, got %d", n)
+	}
+}
+
+func TestServer_FormattedAmounts(t *testing.T) {
+	const (
+		vault      = "0x1234567890123456789012345678901234567890"
+		otherVault = "0x2222222222222222222222222222222222222222"
+	)
+	mockEpochService := &epoch.ServiceMock{
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			return &epoch.ListEpochsResponse{Epochs: []epoch.EpochSummary{{EpochNumber: "2", YieldAllocated: "1500000"}}}, nil
+		},
+		GetUserTotalEarnedFunc: func(ctx context.Context, userAddress, vaultId string) (*epoch.UserEarningsResponse, error) {
+			return &epoch.UserEarningsResponse{UserAddress: userAddress, VaultAddress: otherVault, TotalEarned: "2000000000000000000"}, nil
+		},
+	}
+	mockSignerService := &signer.ServiceMock{
+		CheckBalanceFunc: func(ctx context.Context) (*signer.BalanceStatus, error) {
+			return &signer.BalanceStatus{Balance: "250000000000000000"}, nil
+		},
+	}
+	mockAssets := &assets.ServiceMock{
+		GetAssetFunc: func(ctx context.Context, vaultId string) (*assets.Asset, error) {
+			if vaultId == otherVault {
+				return &assets.Asset{VaultAddress: vaultId, Symbol: "WETH", Decimals: 18}, nil
+			}
+			return &assets.Asset{VaultAddress: vaultId, Symbol: "USDC", Decimals: 6}, nil
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = vault
+	server := NewServer(mockEpochService, nil, nil, nil, mockSignerService, nil, nil, nil, nil, nil, nil, nil, nil,
+		mockAssets, nil, nil, nil, nil, nil, metrics.NewRegistry(), lgr.NoOp, cfg)
+	handler := server.SetupRoutes()
+
+	get := func(path string) string {
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected status 200, got %d: %s", path, rr.Code, rr.Body.String())
+		}
+		return rr.Body.String()
+	}
+
+	body := get("/api/epochs")
+	if want := `"yieldAllocated":"1500000","yieldAllocatedFormatted":"1.5"`; !strings.Contains(body, want) {
+		t.Errorf("expected %s of the configured vault's asset, in field order, got %s", want, body)
+	}
+	body = get("/api/users/0x742d35Cc6634C0532925a3b844Bc454e4438f44e/total-earned?vault=" + vault)
+	if want := `"totalEarnedFormatted":"2"`; !strings.Contains(body, want) {
+		t.Errorf("expected %s of the asset of the vault the response names, got %s", want, body)
+	}
+	body = get("/api/signer")
+	if want := `"balanceFormatted":"0.25"`; !strings.Contains(body, want) {
+		t.Errorf("expected %s in native currency, got %s", want, body)
+	}
+}
+
+func TestServer_CompressionAndETag(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			epochs := make([]epoch.EpochSummary, 100)
+			for i := range epochs {
+				epochs[i] = epoch.EpochSummary{EpochNumber: strconv.Itoa(i + 1), YieldAllocated: "1500000"}
+			}
+			return &epoch.ListEpochsResponse{Epochs: epochs}, nil
+		},
+	}
+	server := NewServer(mockEpochService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, metrics.NewRegistry(), lgr.NoOp, &config.Config{})
+	handler := server.SetupRoutes()
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	plain := get("/api/epochs", nil)
+	if plain.Code != http.StatusOK || plain.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("expected an uncompressed 200 without Accept-Encoding, got %d %q", plain.Code, plain.Header().Get("Content-Encoding"))
+	}
+	tag := plain.Header().Get("ETag")
+	if tag == "" {
+		t.Fatal("expected an ETag")
+	}
+
+	readers := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, newReader := range readers {
+		rr := get("/api/epochs", map[string]string{"Accept-Encoding": encoding})
+		if got := rr.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("expected %s encoding, got %q", encoding, got)
+		}
+		if got := rr.Header().Get("ETag"); got != "W/"+tag {
+			t.Errorf("expected the weak ETag W/%s of a compressed response, got %s", tag, got)
+		}
+		if rr.Body.Len() >= plain.Body.Len() {
+			t.Errorf("expected %s to shrink the %d byte body, got %d bytes", encoding, plain.Body.Len(), rr.Body.Len())
+		}
+		reader, err := newReader(rr.Body)
+		if err != nil {
+			t.Fatalf("failed to read %s body: %v", encoding, err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("failed to decompress %s body: %v", encoding, err)
+		}
+		if string(body) != plain.Body.String() {
+			t.Errorf("expected the %s body to decompress to the plain one", encoding)
+		}
+	}
+	if got := get("/api/epochs", map[string]string{"Accept-Encoding": "gzip;q=1, br;q=0.5"}).Header().Get("Content-Encoding"); got != "gzip" {
+		t.Errorf("expected the encoding the client prefers, got %q", got)
+	}
+
+	for _, ifNoneMatch := range []string{tag, "W/" + tag, `"other", ` + tag} {
+		rr := get("/api/epochs", map[string]string{"If-None-Match": ifNoneMatch, "Accept-Encoding": "br"})
+		if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected an empty 304, got %d with %d bytes", ifNoneMatch, rr.Code, rr.Body.Len())
+		}
+	}
+	if rr := get("/api/epochs", map[string]string{"If-None-Match": `"other"`}); rr.Code != http.StatusOK {
+		t.Errorf("expected 200 for a stale ETag, got %d", rr.Code)
+	}
+
+	if rr := get("/health", map[string]string{"Accept-Encoding": "gzip"}); rr.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected a small response to be sent uncompressed, got %q", rr.Header().Get("Content-Encoding"))
+	}
+}
+
+func TestServer_RequestTimeout(t *testing.T) {
+	// the contract call hangs past the budget the way an unresponsive RPC node would
+	release := make(chan struct{})
+	defer close(release)
+	mockEpochService := &epoch.ServiceMock{
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			_, cached := tracing.StartSpan(ctx, "subgraph.QueryEpochs")
+			tracing.EndSpan(cached, nil)
+			_, slow := tracing.StartSpan(ctx, "blockchain.GetCurrentEpochId")
+			defer tracing.EndSpan(slow, nil)
+			<-release
+			return nil, ctx.Err()
+		},
+		GetUserTotalEarnedFunc: func(ctx context.Context, userAddress, vaultId string) (*epoch.UserEarningsResponse, error) {
+			return &epoch.UserEarningsResponse{UserAddress: userAddress, TotalEarned: "1"}, nil
+		},
+	}
+	cfg := &config.Config{}
+	cfg.Server.RequestTimeout = 50 * time.Millisecond
+	server := NewServer(mockEpochService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, metrics.NewRegistry(), lgr.NoOp, cfg)
+	handler := server.SetupRoutes()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/epochs", nil))
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Fatalf("expected status 504, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp middleware.TimeoutResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("failed to decode timeout response: %v", err)
+	}
+	if resp.Budget != "50ms" || len(resp.Operations) != 2 {
+		t.Fatalf("expected the 50ms budget and both operations, got %+v", resp)
+	}
+	if op := resp.Operations[0]; op.Name != "subgraph.QueryEpochs" || op.Status != "done" {
+		t.Errorf("expected the finished subgraph query first, got %+v", op)
+	}
+	if op := resp.Operations[1]; op.Name != "blockchain.GetCurrentEpochId" || op.Status != "pending" {
+		t.Errorf("expected the contract call still pending, got %+v", op)
+	}
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/api/users/0x742d35Cc6634C0532925a3b844Bc454e4438f44e/total-earned", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"totalEarned":"1"`) {
+		t.Errorf("expected a request within its budget to pass through, got %d: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestServer_IdempotencyKey(t *testing.T) {
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed", VaultID: vaultId}, nil
+		},
+	}
+	records := make(map[string]*idempotency.Record)
+	mockIdempotency := &idempotency.ServiceMock{
+		BeginFunc: func(ctx context.Context, key, fingerprint string) (*idempotency.Record, error) {
+			record, ok := records[key]
+			switch {
+			case !ok:
+				records[key] = &idempotency.Record{Key: key, Fingerprint: fingerprint}
+				return nil, nil
+			case record.Fingerprint != fingerprint:
+				return nil, idempotency.ErrKeyReused
+			case !record.Completed:
+				return nil, idempotency.ErrInProgress
+			}
+			return record, nil
+		},
+		CompleteFunc: func(ctx context.Context, key string, response idempotency.Response) error {
+			records[key].Completed = true
+			records[key].Response = response
+			return nil
+		},
+		ReleaseFunc: func(ctx context.Context, key string) error {
+			delete(records, key)
+			return nil
+		},
+	}
+	server := NewServer(nil, mockSubsidyService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, mockIdempotency, nil, metrics.NewRegistry(), lgr.NoOp, &config.Config{})
+	handler := server.SetupRoutes()
+
+	send := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", path, nil)
+		if key != "" {
+			req.Header.Set(idempotency.Header, key)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("/api/epochs/distribute", "distribute-1")
+	if first.Code != http.StatusAccepted {
+		t.Fatalf("expected status 202, got %d: %s", first.Code, first.Body.String())
+	}
+	retry := send("/api/epochs/distribute", "distribute-1")
+	if retry.Code != http.StatusAccepted || retry.Body.String() != first.Body.String() {
+		t.Errorf("expected the first response replayed, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Errorf("expected the replayed response marked, got headers %v", retry.Header())
+	}
+	if n := len(mockSubsidyService.DistributeSubsidiesCalls()); n != 1 {
+		t.Errorf("expected one distribution for a retried key, got %d", n)
+	}
+
+	// keys are per caller, another client's request with the same key runs
+	other := httptest.NewRequest("POST", "/api/epochs/distribute", nil)
+	other.Header.Set(idempotency.Header, "distribute-1")
+	other.RemoteAddr = "198.51.100.7:4000"
+	otherRR := httptest.NewRecorder()
+	handler.ServeHTTP(otherRR, other)
+	if otherRR.Code != http.StatusAccepted || otherRR.Header().Get(idempotency.ReplayedHeader) != "" {
+		t.Errorf("expected another caller's key to run its own request, got %d: %v", otherRR.Code, otherRR.Header())
+	}
+	if n := len(mockSubsidyService.DistributeSubsidiesCalls()); n != 2 {
+		t.Errorf("expected a distribution for each caller, got %d", n)
+	}
+	if _, ok := records["/api:198.51.100.7/distribute-1"]; !ok {
+		t.Errorf("expected the key stored under its caller, got %v", records)
+	}
+
+	if rr := send("/api/epochs/distribute?async=true", "distribute-1"); rr.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422 for a key reused on another request, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr := send("/api/epochs/distribute", "bad key"); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for an invalid key, got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	send("/api/epochs/distribute", "")
+	send("/api/epochs/distribute", "")
+	if n := len(mockSubsidyService.DistributeSubsidiesCalls()); n != 4 {
+		t.Errorf("expected requests without a key to run every time, got %d distributions", n)
+	}
+}
+
+func TestServer_ErrorCodes(t *testing.T) {
+	var distributeErr error
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return nil, distributeErr
+		},
+	}
+	server := NewServer(nil, mockSubsidyService, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil,
+		nil, nil, metrics.NewRegistry(), lgr.NoOp, &config.Config{})
+	handler := server.SetupRoutes()
+
+	preflight := fmt.Errorf("%w for vault 0x1 epoch 3: epoch_yield: no yield", subsidy.ErrPreflightFailed)
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{"epoch_not_active", fmt.Errorf("%w: epoch ID is 0", epoch.ErrEpochNotActive), http.StatusConflict, handlers.ErrorCodeEpochNotActive},
+		{"insufficient_yield", fmt.Errorf("%w: %w", subsidy.ErrInsufficientYield, preflight), http.StatusUnprocessableEntity,
+			handlers.ErrorCodeInsufficientYield},
+		{"preflight_failed", preflight, http.StatusConflict, handlers.ErrorCodePreflightFailed},
+		{"root_mismatch", fmt.Errorf("resume: %w: stored tree builds another root", merkle.ErrRootMismatch), http.StatusConflict,
+			handlers.ErrorCodeRootMismatch},
+		{"snapshot_stale", fmt.Errorf("%w: snapshot is not final: %w", subsidy.ErrSnapshotStale, subsidy.ErrSnapshotReorged),
+			http.StatusServiceUnavailable, handlers.ErrorCodeSnapshotStale},
+		{"subgraph_lagging", subsidy.ErrSubgraphLagging, http.StatusServiceUnavailable, handlers.ErrorCodeSubgraphLagging},
+		{"transaction_failed", subsidy.ErrTransactionFailed, http.StatusBadGateway, handlers.ErrorCodeTransactionFailed},
+		{"internal", io.ErrUnexpectedEOF, http.StatusInternalServerError, handlers.ErrorCodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distributeErr = tt.err
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("POST", "/api/epochs/distribute", nil))
+
+			if rr.Code != tt.expectedStatus {
+				t.Fatalf("expected status %d, got %d: %s", tt.expectedStatus, rr.Code, rr.Body.String())
+			}
+			var resp handlers.ErrorResponse
+			if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("failed to decode error response: %v", err)
+			}
+			if resp.ErrorCode != tt.expectedCode || resp.Code != tt.expectedStatus || resp.Error != "Failed to distribute subsidies" {
+				t.Errorf("expected error code %s with status %d, got %+v", tt.expectedCode, tt.expectedStatus, resp)
+			}
+		})
+	}
+}
+
+func TestServer_SwaggerSpec(t *testing.T) {
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, lgr.NoOp, &config.Config{})
+	handler := server.SetupRoutes()
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest("GET", "/swagger.json", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", rr.Code)
+	}
+
+	var spec struct {
+		Swagger string                     `json:"swagger"`
+		Paths   map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("expected a JSON document: %v", err)
+	}
+	if spec.Swagger != "2.0" {
+		t.Errorf("expected a swagger 2.0 document, got %q", spec.Swagger)
+	}
+	for _, path := range []string{"/api/epochs", "/api/distributions/{id}/approve", "/api/reports/gas"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("expected %s in the document", path)
+		}
+	}
+}
+
 func TestRouteGrouping(t *testing.T) {
 	// Create minimal server for testing structure
-	server := NewServer(nil, nil, nil, lgr.NoOp, &config.Config{})
+	server := NewServer(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, lgr.NoOp, &config.Config{})
 	handler := server.SetupRoutes()
 
 	// Test that routes are properly grouped
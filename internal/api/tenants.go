@@ -0,0 +1,98 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/api/handlers"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/go-pkgz/lgr"
+	"github.com/go-pkgz/rest"
+)
+
+const (
+	// TenantHeader selects the tenant of a request whose path is not scoped to one
+	TenantHeader = "X-Tenant"
+
+	// tenantPathPrefix scopes a request to the tenant named after it, /tenants/<name>/api/... serves /api/...
+	tenantPathPrefix = "/tenants/"
+)
+
+// TenantRouter serves the routes of several tenants from one listener. A request is scoped to a tenant by
+// its path prefix, or by TenantHeader when the path has none, and served by that tenant's routes with the
+// prefix stripped.
+type TenantRouter struct {
+	tenants map[string]http.Handler
+	names   []string
+	logger  lgr.L
+}
+
+// NewTenantRouter creates a router over the routes of every tenant, keyed by tenant name
+func NewTenantRouter(tenants map[string]http.Handler, logger lgr.L) *TenantRouter {
+	names := make([]string, 0, len(tenants))
+	for name := range tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return &TenantRouter{tenants: tenants, names: names, logger: logger}
+}
+
+// ServeHTTP dispatches the request to its tenant's routes. GET /tenants lists the tenants.
+func (t *TenantRouter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/tenants" || r.URL.Path == "/tenants/" {
+		rest.RenderJSON(w, map[string][]string{"tenants": t.names})
+		return
+	}
+
+	tenant, path := r.Header.Get(TenantHeader), r.URL.Path
+	if scoped, ok := strings.CutPrefix(r.URL.Path, tenantPathPrefix); ok {
+		tenant, path, _ = strings.Cut(scoped, "/")
+		path = "/" + path
+	}
+	if tenant == "" {
+		t.writeError(w, r, http.StatusBadRequest, fmt.Sprintf("tenant required, prefix the path with %s<tenant>/ or set the %s header",
+			tenantPathPrefix, TenantHeader))
+		return
+	}
+	handler, ok := t.tenants[strings.ToLower(tenant)]
+	if !ok {
+		t.writeError(w, r, http.StatusNotFound, fmt.Sprintf("unknown tenant %q", tenant))
+		return
+	}
+
+	scopedReq := r.Clone(r.Context())
+	scopedReq.URL.Path = path
+	scopedReq.URL.RawPath = ""
+	handler.ServeHTTP(w, scopedReq)
+}
+
+func (t *TenantRouter) writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	t.logger.Logf("WARN rejected %s %s from %s: %s", r.Method, r.URL.Path, r.RemoteAddr, message)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	errorCode := handlers.ErrorCodeInvalidInput
+	if status == http.StatusNotFound {
+		errorCode = handlers.ErrorCodeNotFound
+	}
+	rest.RenderJSON(w, handlers.ErrorResponse{Error: message, Code: status, ErrorCode: errorCode})
+}
+
+// Serve listens on the configured address and serves handler with the same timeouts Start uses
+func Serve(handler http.Handler, cfg *config.Config, logger lgr.L) error {
+	addr := fmt.Sprintf("%s:%d", cfg.Server.Host, cfg.Server.Port)
+	logger.Logf("INFO starting server on %s", addr)
+
+	// Create server with security timeouts; responses may be written until the longest request budget ran out
+	server := &http.Server{
+		Addr:         addr,
+		Handler:      handler,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: max(15*time.Second, cfg.Server.RequestTimeout+5*time.Second, cfg.Server.HeavyRequestTimeout+5*time.Second),
+		IdleTimeout:  60 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
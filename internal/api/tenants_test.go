@@ -0,0 +1,60 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRouter(t *testing.T) {
+	tenantHandler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(name + " " + r.URL.Path + "?" + r.URL.RawQuery))
+		})
+	}
+	router := NewTenantRouter(map[string]http.Handler{
+		"sepolia": tenantHandler("sepolia"),
+		"mainnet": tenantHandler("mainnet"),
+	}, lgr.NoOp)
+
+	tests := []struct {
+		name         string
+		path         string
+		header       string
+		expectedCode int
+		expectedBody string
+	}{
+		{"path_prefix", "/tenants/sepolia/api/epochs?limit=5", "", http.StatusOK, "sepolia /api/epochs?limit=5"},
+		{"path_prefix_wins", "/tenants/mainnet/health", "sepolia", http.StatusOK, "mainnet /health?"},
+		{"header", "/api/status", "Mainnet", http.StatusOK, "mainnet /api/status?"},
+		{"tenant_root", "/tenants/sepolia", "", http.StatusOK, "sepolia /?"},
+		{"missing_tenant", "/api/status", "", http.StatusBadRequest, ""},
+		{"unknown_tenant", "/tenants/holesky/api/status", "", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(TenantHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/tenants", nil))
+	var listed map[string][]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listed))
+	assert.Equal(t, []string{"mainnet", "sepolia"}, listed["tenants"])
+}
@@ -3,6 +3,8 @@ package blockchain
 import (
 	"context"
 	"math/big"
+	"net/http"
+	"time"
 )
 
 //go:generate moq -out blockchain_mocks.go . BlockchainClient
@@ -23,6 +25,9 @@ type BlockchainClient interface {
 
 	// lending operations
 	UpdateExchangeRate(ctx context.Context, lendingManagerAddress string) error
+	// GetBorrowBalances returns what each borrower owes the vault's lending market at blockNumber, keyed by
+	// normalized address
+	GetBorrowBalances(ctx context.Context, vaultAddress string, borrowers []string, blockNumber *big.Int) (map[string]*big.Int, error)
 
 	// vault operations
 	AllocateYieldToEpoch(ctx context.Context, epochId *big.Int, vaultAddress string) error
@@ -32,6 +37,16 @@ type BlockchainClient interface {
 		vaultAddress string,
 		amount *big.Int,
 	) error
+	GetEpochYieldAllocated(ctx context.Context, epochId *big.Int, vaultAddress string) (*big.Int, error)
+	GetRemainingCumulativeYield(ctx context.Context, vaultAddress string) (*big.Int, error)
+	GetCurrentEpochYield(ctx context.Context, vaultAddress string) (*big.Int, error)
+	// GetCollectionStats reads what the vault records for every collection the CollectionRegistry registers with
+	// it, all in one block
+	GetCollectionStats(ctx context.Context, vaultAddress string) (*VaultCollectionStats, error)
+
+	// tokens
+	GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*big.Int, error)
+	GetAssetMetadata(ctx context.Context, vaultAddress string) (*AssetMetadata, error)
 
 	// subsidy distribution
 	UpdateMerkleRoot(
@@ -47,17 +62,268 @@ type BlockchainClient interface {
 		totalSubsidies *big.Int,
 	) error
 	DistributeSubsidies(ctx context.Context, epochID string) error
+	EstimateRepayBorrowBehalfBatchGas(
+		ctx context.Context,
+		vaultAddress string,
+		borrowers []string,
+		amounts []*big.Int,
+	) (uint64, error)
+	RepayBorrowBehalfBatch(
+		ctx context.Context,
+		vaultAddress string,
+		borrowers []string,
+		amounts []*big.Int,
+		gasLimit uint64,
+	) error
+	GetMerkleRoot(ctx context.Context, vaultId string) ([32]byte, error)
+	GetUserClaimedTotal(ctx context.Context, vaultId, userAddress string) (*big.Int, error)
+	// GetClaimedTotals reads how much of the vault's subsidies each user claimed so far in one block, keyed by
+	// normalized address
+	GetClaimedTotals(ctx context.Context, vaultId string, users []string) (map[string]*big.Int, error)
+	GetPauseState(ctx context.Context, vaultId string) (*PauseState, error)
+	GetOnChainEpochState(ctx context.Context, vaultId string) (*OnChainEpochState, error)
+	// GetEpochStart returns the latest EpochStarted event the EpochManager emitted for the epoch between fromBlock
+	// and toBlock inclusive, nil when there is none
+	GetEpochStart(ctx context.Context, epochId *big.Int, fromBlock, toBlock uint64) (*EpochStart, error)
+	GetMerkleRootUpdates(ctx context.Context, vaultId string, fromBlock, toBlock uint64) ([]MerkleRootUpdate, error)
+	SimulateEpochFinalization(
+		ctx context.Context,
+		epochId *big.Int,
+		vaultId string,
+		root [32]byte,
+		totalSubsidies *big.Int,
+	) error
+
+	// vault onboarding and decommissioning, every write waits for its transaction to be mined
+	GetVaultRegistration(ctx context.Context, vaultAddress string) (*VaultRegistration, error)
+	IsCollectionWhitelisted(ctx context.Context, vaultAddress, collectionAddress string) (bool, error)
+	AddVault(ctx context.Context, vaultAddress, lendingManagerAddress string) error
+	GrantVaultRole(ctx context.Context, vaultAddress string) error
+	WhitelistCollection(ctx context.Context, vaultAddress, collectionAddress string) error
+	RemoveVault(ctx context.Context, vaultAddress string) error
+	GetVaultEvents(ctx context.Context, fromBlock, toBlock uint64) ([]VaultEvent, error)
+
+	// BatchCall makes view calls packed by the contract bindings at blockNumber, the latest block when nil,
+	// aggregated through Multicall3 when it is deployed. Results are in the order of calls and a call that fails
+	// only fails its own result.
+	BatchCall(ctx context.Context, calls []ViewCall, blockNumber *big.Int) ([]ViewResult, error)
+
+	// chain state
+	GetBlockRef(ctx context.Context, blockNumber *big.Int) (*BlockRef, error)
+	HasCode(ctx context.Context, address string) (bool, error)
+	// GetContractCode reads the code a contract runs, through an EIP-1967 proxy to its implementation
+	GetContractCode(ctx context.Context, address string) (*ContractCode, error)
+	// IsValidSignature asks a contract wallet whether it signed hash, ERC-1271 isValidSignature(bytes32,bytes)
+	IsValidSignature(ctx context.Context, account string, hash [32]byte, signature []byte) (bool, error)
+
+	// signer account
+	GetSignerBalance(ctx context.Context) (*SignerBalance, error)
+	// GetSignerRoles reads whether the signer holds the on-chain roles the server's transactions need
+	GetSignerRoles(ctx context.Context, vaultAddress string) ([]SignerRole, error)
+}
+
+// ContractCode is the runtime code behind a contract address in the latest block
+type ContractCode struct {
+	Address        string
+	Implementation string // the EIP-1967 implementation Code was read from, empty when Address is no proxy
+	Code           []byte
+	Version        string // what a version() getter returned, empty when the contract has none
+}
+
+// ViewCall is a view function call of a batch, Data being what a binding's Pack method returned
+type ViewCall struct {
+	Target string
+	Data   []byte
+}
+
+// ViewResult is what a ViewCall returned, for the binding's Unpack method, or why it failed
+type ViewResult struct {
+	Output []byte
+	Err    error // wraps ErrCallFailed
+}
+
+// VaultCollectionStats is what a vault records for the collections registered with it, read at Block
+type VaultCollectionStats struct {
+	Block       BlockRef
+	Collections []CollectionStats // in the CollectionRegistry's order
+}
+
+// CollectionStats is what a vault records for a collection
+type CollectionStats struct {
+	Collection       string
+	YieldShareBps    uint16   // CollectionRegistry yieldSharePercentage, in basis points
+	BorrowVolume     *big.Int // getCollectionTotalBorrowVolume
+	PerformanceScore *big.Int // getCollectionPerformanceScore
+	YieldGenerated   *big.Int // getCollectionTotalYieldGenerated
+	AssetsDeposited  *big.Int // collectionTotalAssetsDeposited
+}
+
+// BlockRef identifies a block by number and hash
+type BlockRef struct {
+	Number    uint64
+	Hash      string
+	Timestamp uint64 // unix time the block was produced at
+}
+
+// PauseState is whether the DebtSubsidizer accepts subsidy transactions for a vault
+type PauseState struct {
+	SubsidizerPaused bool // the DebtSubsidizer is paused, every subsidy transaction reverts
+	VaultRemoved     bool // the vault was removed from the DebtSubsidizer
+}
+
+// VaultRegistration is how the DebtSubsidizer knows a vault
+type VaultRegistration struct {
+	LendingManager string // empty when the vault was never added
+	CToken         string
+	Removed        bool // the vault was removed, addVault rejects it again
 }
 
+// OnChainEpochState is the epoch, yield and subsidy state the contracts report for a vault, all read at Block
+type OnChainEpochState struct {
+	Block                 BlockRef
+	CurrentEpochID        *big.Int // EpochManager.getCurrentEpochId
+	VaultYieldForEpoch    *big.Int // EpochManager.getVaultYieldForEpoch for the current epoch
+	TotalYieldAllocated   *big.Int // vault totalYieldAllocated
+	TotalYieldReserved    *big.Int // vault totalYieldReserved
+	TotalAssetsDeposited  *big.Int // vault totalAssetsDeposited, the principal NFT holders deposited
+	MerkleRoot            [32]byte // DebtSubsidizer.getMerkleRoot
+	TotalSubsidies        *big.Int // DebtSubsidizer.getTotalSubsidies
+	TotalSubsidiesClaimed *big.Int // DebtSubsidizer.getTotalSubsidiesClaimed
+	RemainingSubsidies    *big.Int // DebtSubsidizer.getRemainingSubsidies
+}
+
+// MerkleRootUpdate is a MerkleRootUpdated event the DebtSubsidizer emitted for a vault
+type MerkleRootUpdate struct {
+	Vault          string
+	MerkleRoot     [32]byte
+	UpdatedBy      string
+	TotalSubsidies *big.Int // totalSubsidiesForEpoch
+	TxHash         string
+	BlockNumber    uint64
+	BlockHash      string
+	LogIndex       uint
+}
+
+// EpochStart is an EpochStarted event the EpochManager emitted, the epoch's authoritative timing
+type EpochStart struct {
+	EpochID     *big.Int
+	StartTime   uint64 // unix time the epoch started at
+	EndTime     uint64 // unix time after which the epoch can be ended
+	TxHash      string
+	BlockNumber uint64
+}
+
+// kinds of VaultEvent
+const (
+	VaultEventAdded   = "added"
+	VaultEventRemoved = "removed"
+)
+
+// VaultEvent is a VaultAdded or VaultRemoved event the DebtSubsidizer emitted
+type VaultEvent struct {
+	Kind           string // VaultEventAdded or VaultEventRemoved
+	Vault          string
+	CToken         string // of VaultAdded only
+	LendingManager string // of VaultAdded only
+	TxHash         string
+	BlockNumber    uint64
+	LogIndex       uint
+}
+
+// AssetMetadata is the ERC-20 a vault holds, the token its wei amounts are denominated in
+type AssetMetadata struct {
+	Address  string
+	Symbol   string
+	Decimals uint8
+}
+
+// SignerBalance is the ETH balance of the account that signs transactions
+type SignerBalance struct {
+	Address string
+	Balance *big.Int // wei
+}
+
+// SignerRole is an on-chain permission the signer needs, granted by one contract
+type SignerRole struct {
+	Contract string // name of the contract, as in config.ContractAddresses
+	Address  string
+	Role     string
+	Signer   string
+	Held     bool
+}
+
+// Backend types a client can be configured with
+const (
+	TypeRPC       = "rpc"       // a node reached at RPCURL
+	TypeSimulated = "simulated" // an in-process chain with the protocol contracts emulated, for tests and local development
+)
+
 // Config represents the configuration needed for blockchain clients
 type Config struct {
+	Type               string // TypeRPC when empty
 	RPCURL             string
 	PrivateKey         string
 	GasLimit           uint64
 	GasPrice           string
+	ChainID            uint64 // expected chain ID, 0 skips the check
+	ReadOnly           bool   // no private key is loaded and every transaction is refused
+	ForkURL            string // anvil fork endpoint epoch finalizations are dry-run against first, empty disables dry runs
 	Comptroller        string
 	EpochManager       string
 	DebtSubsidizer     string
 	LendingManager     string
 	CollectionRegistry string
+
+	// batched view calls are aggregated into eth_calls through the Multicall3 contract at Multicall, or made
+	// separately when it is empty or not deployed, ReadConcurrency requests at a time
+	Multicall          string
+	MulticallBatchSize int // calls an aggregate3 call carries, larger batches are split
+	ReadConcurrency    int
+
+	// transactions sent without waiting are watched until their receipt is ReceiptConfirmations blocks deep, and
+	// updateMerkleRoot transactions, sent with or without waiting, until it is RootConfirmations deep
+	ReceiptConfirmations uint64
+	RootConfirmations    uint64
+	ReceiptTimeout       time.Duration
+	PollInterval         time.Duration
+
+	// the simulated chain deploys placeholder code at these addresses, so they pass deployment checks,
+	// and mines a block every SimulatedBlockTime besides one per transaction (0 mines only transactions)
+	SimulatedContracts []string
+	SimulatedBlockTime time.Duration
+
+	// Transport sends the requests to an http(s) RPCURL, http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+// TxStatus is how a transaction watched for its receipt settled
+type TxStatus string
+
+const (
+	TxConfirmed   TxStatus = "confirmed"
+	TxReverted    TxStatus = "reverted"
+	TxUnconfirmed TxStatus = "unconfirmed" // no receipt was buried deep enough before the watch timed out
+)
+
+// TxOutcome is what the receipt watcher learned about a transaction sent without waiting for it
+type TxOutcome struct {
+	Action       string
+	TxHash       string
+	Parameters   map[string]string
+	Status       TxStatus
+	BlockNumber  uint64
+	RevertReason string
+	Events       []TxEvent
+}
+
+// TxEvent is a protocol contract event a transaction emitted, with its arguments formatted as strings
+type TxEvent struct {
+	Contract string
+	Name     string
+	Args     map[string]string
+}
+
+// TxObserver is told how every transaction sent without waiting for its receipt settled
+type TxObserver interface {
+	TxSettled(ctx context.Context, outcome TxOutcome)
 }
@@ -0,0 +1,20 @@
+package blockchain
+
+import "errors"
+
+var (
+	// ErrChainIDMismatch is returned when the RPC serves a different chain than configured
+	ErrChainIDMismatch = errors.New("RPC chain ID does not match configuration")
+	// ErrBatchSizeExceedsLimit is returned when the vault rejects a batch for having too many entries
+	ErrBatchSizeExceedsLimit = errors.New("batch size exceeds contract limit")
+	// ErrTxNotSent is returned when a transaction failed before it was broadcast
+	ErrTxNotSent = errors.New("transaction was not sent")
+	// ErrTxReverted is returned when a transaction was mined but reverted, so it changed no state
+	ErrTxReverted = errors.New("transaction reverted")
+	// ErrForkDryRunFailed is returned when a transaction reverted or missed its events on the fork, so it was not sent
+	ErrForkDryRunFailed = errors.New("fork dry run failed")
+	// ErrCallFailed is the error of a call of a batch that reverted or could not be made, the others are unaffected
+	ErrCallFailed = errors.New("view call failed")
+	// ErrReadOnly is returned when a transaction is requested from a client running without a signer
+	ErrReadOnly = errors.New("blockchain client is read-only")
+)
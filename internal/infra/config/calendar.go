@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendar periods a schedule can recur on
+const (
+	calendarDaily   = "daily"
+	calendarWeekly  = "weekly"
+	calendarMonthly = "monthly"
+)
+
+// Calendar is a boundary recurring on the calendar in a timezone, such as every Monday at 00:00 UTC
+type Calendar struct {
+	period   string
+	weekday  time.Weekday // weekly boundaries only
+	day      int          // monthly boundaries only, 1 to 28 so every month has it
+	hour     int
+	minute   int
+	location *time.Location
+}
+
+// ParseCalendar parses daily@HH:MM, weekly:<weekday>@HH:MM or monthly:<day>@HH:MM, read in timezone
+func ParseCalendar(spec, timezone string) (*Calendar, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid scheduler timezone %q: %w", timezone, err)
+	}
+
+	when, clock, ok := strings.Cut(strings.ToLower(strings.TrimSpace(spec)), "@")
+	if !ok {
+		return nil, fmt.Errorf("scheduler calendar %q must end in @HH:MM", spec)
+	}
+	at, err := time.Parse("15:04", clock)
+	if err != nil {
+		return nil, fmt.Errorf("scheduler calendar %q has an invalid time of day %q", spec, clock)
+	}
+
+	c := &Calendar{hour: at.Hour(), minute: at.Minute(), location: location}
+	period, day, _ := strings.Cut(when, ":")
+	switch period {
+	case calendarDaily:
+		if day != "" {
+			return nil, fmt.Errorf("scheduler calendar %q: daily boundaries take no day", spec)
+		}
+	case calendarWeekly:
+		weekday, ok := parseWeekday(day)
+		if !ok {
+			return nil, fmt.Errorf("scheduler calendar %q has an invalid weekday %q", spec, day)
+		}
+		c.weekday = weekday
+	case calendarMonthly:
+		n, err := strconv.Atoi(day)
+		if err != nil || n < 1 || n > 28 {
+			return nil, fmt.Errorf("scheduler calendar %q: monthly boundaries need a day from 1 to 28, got %q", spec, day)
+		}
+		c.day = n
+	default:
+		return nil, fmt.Errorf("scheduler calendar %q must be daily, weekly or monthly", spec)
+	}
+	c.period = period
+	return c, nil
+}
+
+func parseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		full := strings.ToLower(d.String())
+		if name == full || (len(name) == 3 && strings.HasPrefix(full, name)) {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// Next returns the first boundary strictly after after. Boundaries keep their wall clock time across
+// daylight saving changes.
+func (c *Calendar) Next(after time.Time) time.Time {
+	local := after.In(c.location)
+	year, month, day := local.Date()
+
+	var next time.Time
+	switch c.period {
+	case calendarWeekly:
+		ahead := (int(c.weekday) - int(local.Weekday()) + 7) % 7
+		next = time.Date(year, month, day+ahead, c.hour, c.minute, 0, 0, c.location)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 7)
+		}
+	case calendarMonthly:
+		next = time.Date(year, month, c.day, c.hour, c.minute, 0, 0, c.location)
+		if !next.After(after) {
+			next = time.Date(year, month+1, c.day, c.hour, c.minute, 0, 0, c.location)
+		}
+	default:
+		next = time.Date(year, month, day, c.hour, c.minute, 0, 0, c.location)
+		if !next.After(after) {
+			next = next.AddDate(0, 0, 1)
+		}
+	}
+	return next
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalendar_Next(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		spec     string
+		timezone string
+		after    time.Time
+		next     time.Time
+	}{
+		{
+			name:  "daily_later_today",
+			spec:  "daily@18:30",
+			after: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+			next:  time.Date(2026, 10, 15, 18, 30, 0, 0, time.UTC),
+		},
+		{
+			name:  "daily_at_boundary_moves_to_tomorrow",
+			spec:  "daily@00:00",
+			after: time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC),
+			next:  time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekly_on_monday",
+			spec:  "weekly:Monday@00:00",
+			after: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+			next:  time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "weekly_same_day_after_time",
+			spec:  "weekly:thu@09:00",
+			after: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC),
+			next:  time.Date(2026, 10, 22, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:  "monthly_next_month",
+			spec:  "monthly:1@00:00",
+			after: time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC),
+			next:  time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Berlin leaves summer time on 2026-10-25, the boundary keeps its wall clock time
+			name:     "weekly_across_dst",
+			spec:     "weekly:mon@00:00",
+			timezone: "Europe/Berlin",
+			after:    time.Date(2026, 10, 21, 12, 0, 0, 0, time.UTC),
+			next:     time.Date(2026, 10, 26, 0, 0, 0, 0, berlin),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timezone := tt.timezone
+			if timezone == "" {
+				timezone = "UTC"
+			}
+			calendar, err := ParseCalendar(tt.spec, timezone)
+			require.NoError(t, err)
+			assert.True(t, tt.next.Equal(calendar.Next(tt.after)), "expected %s, got %s", tt.next, calendar.Next(tt.after))
+		})
+	}
+}
+
+func TestParseCalendar_Invalid(t *testing.T) {
+	for _, spec := range []string{"", "weekly@00:00", "weekly:someday@00:00", "monthly:31@00:00", "daily@25:00", "daily:1@00:00", "hourly@00:00"} {
+		_, err := ParseCalendar(spec, "UTC")
+		assert.Error(t, err, spec)
+	}
+
+	_, err := ParseCalendar("daily@00:00", "Mars/Olympus")
+	assert.Error(t, err)
+}
@@ -1,6 +1,16 @@
 package config
 
 import (
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/utils"
@@ -8,16 +18,30 @@ import (
 )
 
 type Config struct {
+	// Network selects a deployment profile, see LoadArgs
+	Network string `long:"network" env:"NETWORK" description:"Network profile; <NETWORK>_ prefixed variables override ethereum, subgraph, contract, archive, backup and feature options (e.g. SEPOLIA_RPC_URL)"`
+
+	// Tenants are deployments served by one process, see LoadTenants
+	Tenants []string `long:"tenant" env:"TENANTS" env-delim:"," description:"Deployments served by this process, each read as the network profile of the same name with a storage namespace of its own"`
+	// Tenant is the tenant this configuration was loaded for, empty outside multi-tenant mode
+	Tenant string `no-flag:"true"`
+
 	// Server configuration
 	Server struct {
-		Host string `long:"server-host" env:"SERVER_HOST" default:"0.0.0.0" description:"Server host"`
-		Port int    `long:"server-port" env:"SERVER_PORT" default:"8080" description:"Server port"`
+		Host     string `long:"server-host" env:"SERVER_HOST" default:"0.0.0.0" description:"Server host"`
+		Port     int    `long:"server-port" env:"SERVER_PORT" default:"8080" description:"Server port"`
+		GRPCPort int    `long:"server-grpc-port" env:"SERVER_GRPC_PORT" default:"0" description:"Port of the gRPC API for internal consumers, 0 disables it"`
+		ReadOnly bool   `long:"server-read-only" env:"SERVER_READ_ONLY" description:"Serve queries only: no scheduled transactions, write endpoints rejected and no private key needed"`
+
+		RequestTimeout      time.Duration `long:"server-request-timeout" env:"SERVER_REQUEST_TIMEOUT" default:"10s" description:"Budget of read endpoints before they answer 504, 0 disables it"`
+		HeavyRequestTimeout time.Duration `long:"server-heavy-request-timeout" env:"SERVER_HEAVY_REQUEST_TIMEOUT" default:"2m" description:"Budget of endpoints that recompute allocations, verify proofs or build reports, 0 disables it"`
 	} `group:"Server Options" namespace:"server"`
 
 	// Database configuration
 	Database struct {
 		Type             string `long:"database-type" env:"DATABASE_TYPE" default:"memory" description:"Database type"`
 		ConnectionString string `long:"database-connection-string" env:"DATABASE_CONNECTION_STRING" default:"" description:"Database connection string"`
+		LowMemory        bool   `long:"database-low-memory" env:"DATABASE_LOW_MEMORY" description:"Size badger's memtables and block cache for a 512MB container, keep leaf proofs in its value log and hold the Go runtime to a 400MiB soft memory limit unless GOMEMLIMIT sets one"`
 	} `group:"Database Options" namespace:"database"`
 
 	// Logging configuration
@@ -29,32 +53,270 @@ type Config struct {
 
 	// Ethereum configuration
 	Ethereum struct {
-		RPCURL     string `long:"rpc-url" env:"RPC_URL" required:"true" description:"Ethereum RPC URL"`
-		PrivateKey string `long:"private-key" env:"PRIVATE_KEY" required:"true" description:"Ethereum private key"`
+		Type       string `long:"type" env:"ETHEREUM_TYPE" default:"rpc" choice:"rpc" choice:"simulated" description:"Chain backend: a node at --rpc-url, or an in-process simulated chain with the protocol contracts emulated, for tests and local development"`
+		RPCURL     string `long:"rpc-url" env:"RPC_URL" description:"Ethereum RPC URL (required unless the chain is simulated)"`
+		PrivateKey string `long:"private-key" env:"PRIVATE_KEY" description:"Ethereum private key (required unless the server is read-only)"`
 		Sender     string `long:"sender" env:"SENDER" description:"Sender address"`
 		GasLimit   uint64 `long:"gas-limit" env:"GAS_LIMIT" default:"500000" description:"Gas limit"`
 		GasPrice   string `long:"gas-price" env:"GAS_PRICE" default:"20000000000" description:"Gas price"`
+		ChainID    uint64 `long:"chain-id" env:"CHAIN_ID" description:"Expected chain ID, startup fails if the RPC reports another (defaults from --network for well-known networks)"`
+
+		ConfirmationDepth uint64        `long:"confirmation-depth" env:"CONFIRMATION_DEPTH" default:"6" description:"Blocks a snapshot block must be buried under before its merkle root is submitted (0 disables reorg checks)"`
+		MaxResnapshots    int           `long:"max-resnapshots" env:"MAX_RESNAPSHOTS" default:"3" description:"How many times to re-snapshot after a reorg before giving up"`
+		BlockPollInterval time.Duration `long:"block-poll-interval" env:"BLOCK_POLL_INTERVAL" default:"2s" description:"How often to poll for new blocks while waiting for confirmations"`
+		SnapshotStrategy  string        `long:"snapshot-strategy" env:"SNAPSHOT_STRATEGY" default:"latest" choice:"latest" choice:"finalized" choice:"epoch_end" description:"Block each epoch's distribution is snapshotted at: the chain head, the latest finalized block, or --snapshot-block-offset blocks before the last block of the epoch (an admin-pinned block overrides it)"`
+		SnapshotOffset    uint64        `long:"snapshot-block-offset" env:"SNAPSHOT_BLOCK_OFFSET" default:"0" description:"Blocks before the epoch's last block the epoch_end strategy snapshots at"`
+
+		ForkURL string `long:"fork-url" env:"FORK_URL" description:"Anvil node forking this chain; endEpochWithSubsidies is first sent there at the chain head and the real transaction is not sent unless it succeeds and emits EpochFinalized (empty disables the dry run)"`
+
+		ReceiptConfirmations uint64        `long:"receipt-confirmations" env:"RECEIPT_CONFIRMATIONS" default:"3" description:"Blocks a transaction sent without waiting must be buried under before its outcome updates epoch state"`
+		ReceiptTimeout       time.Duration `long:"receipt-timeout" env:"RECEIPT_TIMEOUT" default:"10m" description:"How long to watch a transaction for a confirmed receipt before reporting it unconfirmed"`
+		Confirmations        []string      `long:"confirmations" env:"CONFIRMATIONS" env-delim:"," description:"Confirmation depths per operation, as operation:blocks pairs: status (a transaction whose outcome updates epoch state, --receipt-confirmations by default), merkle_root (an updateMerkleRoot transaction treated as final, --receipt-confirmations by default) and snapshot (a snapshot block its merkle root is submitted for, --confirmation-depth by default)"`
+
+		Multicall       string `long:"multicall-address" env:"MULTICALL_ADDRESS" default:"0xcA11bde05977b3631167028862bE2a173976CA11" description:"Multicall3 contract batched view calls are aggregated through (empty, or no code at the address, makes the calls separately)"`
+		MulticallBatch  int    `long:"multicall-batch-size" env:"MULTICALL_BATCH_SIZE" default:"100" description:"Most calls aggregated into one Multicall3 eth_call, larger batches are split"`
+		ReadConcurrency int    `long:"read-concurrency" env:"READ_CONCURRENCY" default:"8" description:"Most eth_calls of a batch made at once, Multicall3 chunks or separate calls"`
+
+		SimulatedBlockTime time.Duration `long:"simulated-block-time" env:"SIMULATED_BLOCK_TIME" default:"1s" description:"How often the simulated chain mines a block besides one per transaction (0 mines only transactions)"`
 	} `group:"Ethereum Options" namespace:"ethereum"`
 
 	// Subgraph configuration
 	Subgraph struct {
-		Endpoint       string        `long:"subgraph-endpoint" env:"SUBGRAPH_ENDPOINT" required:"true" description:"Subgraph endpoint"`
-		Timeout        time.Duration `long:"subgraph-timeout" env:"SUBGRAPH_TIMEOUT" default:"30s" description:"Subgraph timeout"`
-		MaxRetries     int           `long:"subgraph-max-retries" env:"SUBGRAPH_MAX_RETRIES" default:"3" description:"Subgraph max retries"`
-		PaginationSize int           `long:"subgraph-pagination-size" env:"SUBGRAPH_PAGINATION_SIZE" default:"1000" description:"Subgraph pagination size"`
+		Endpoint         string        `long:"subgraph-endpoint" env:"SUBGRAPH_ENDPOINT" required:"true" description:"Subgraph endpoint"`
+		Timeout          time.Duration `long:"subgraph-timeout" env:"SUBGRAPH_TIMEOUT" default:"30s" description:"Subgraph timeout"`
+		MaxRetries       int           `long:"subgraph-max-retries" env:"SUBGRAPH_MAX_RETRIES" default:"3" description:"Subgraph max retries"`
+		PaginationSize   int           `long:"subgraph-pagination-size" env:"SUBGRAPH_PAGINATION_SIZE" default:"1000" description:"Subgraph pagination size"`
+		CacheTTL         time.Duration `long:"subgraph-cache-ttl" env:"SUBGRAPH_CACHE_TTL" default:"30s" description:"How long subgraph query results stay fresh (0 disables caching)"`
+		CacheBlockTTL    time.Duration `long:"subgraph-cache-block-ttl" env:"SUBGRAPH_CACHE_BLOCK_TTL" default:"1h" description:"Cache TTL for block-pinned subgraph queries"`
+		CacheStaleTTL    time.Duration `long:"subgraph-cache-stale-ttl" env:"SUBGRAPH_CACHE_STALE_TTL" default:"2m" description:"How long expired results are served while being revalidated"`
+		CacheMaxEntries  int           `long:"subgraph-cache-max-entries" env:"SUBGRAPH_CACHE_MAX_ENTRIES" default:"1000" description:"Most subgraph query results cached; the least recently used are evicted beyond it"`
+		MaxLag           uint64        `long:"subgraph-max-lag" env:"SUBGRAPH_MAX_LAG" default:"0" description:"Most blocks the subgraph's indexed block may trail the chain head by when a distribution is computed; further behind, the distribution is delayed and subgraph.lagging sent (0 disables the check)"`
+		LagRetry         time.Duration `long:"subgraph-lag-retry" env:"SUBGRAPH_LAG_RETRY" default:"5m" description:"How long a scheduled distribution delayed by a lagging subgraph waits before it is attempted again (0 waits for the next boundary)"`
+		MinSchemaVersion int           `long:"subgraph-min-schema-version" env:"SUBGRAPH_MIN_SCHEMA_VERSION" default:"1" choice:"1" choice:"2" description:"Oldest subgraph schema version accepted; v1 subgraphs have no collection types or wrapped positions and queries are adapted to them"`
 	} `group:"Subgraph Options" namespace:"subgraph"`
 
 	// Scheduler configuration
 	Scheduler struct {
-		Interval time.Duration `long:"scheduler-interval" env:"SCHEDULER_INTERVAL" default:"1h" description:"Scheduler interval"`
-		Enabled  bool          `long:"scheduler-enabled" env:"SCHEDULER_ENABLED" description:"Enable scheduler"`
-		Timezone string        `long:"scheduler-timezone" env:"SCHEDULER_TIMEZONE" default:"UTC" description:"Scheduler timezone"`
+		Interval     time.Duration `long:"scheduler-interval" env:"SCHEDULER_INTERVAL" default:"1h" description:"Scheduler interval"`
+		Enabled      bool          `long:"scheduler-enabled" env:"SCHEDULER_ENABLED" description:"Enable scheduler"`
+		Timezone     string        `long:"scheduler-timezone" env:"SCHEDULER_TIMEZONE" default:"UTC" description:"Scheduler timezone"`
+		CatchUpLimit int           `long:"scheduler-catch-up-limit" env:"SCHEDULER_CATCH_UP_LIMIT" default:"10" description:"Most missed epochs processed when the scheduler starts or becomes leader (0 disables catch-up)"`
+		Mode         string        `long:"scheduler-mode" env:"SCHEDULER_MODE" default:"interval" choice:"interval" choice:"calendar" choice:"manual" description:"When epoch boundaries run: every interval, on calendar boundaries, or only when triggered through POST /admin/scheduler/trigger"`
+		Calendar     string        `long:"scheduler-calendar" env:"SCHEDULER_CALENDAR" description:"Calendar mode boundaries in the scheduler timezone: daily@HH:MM, weekly:<weekday>@HH:MM or monthly:<day 1-28>@HH:MM"`
+
+		RetryBackoff    time.Duration `long:"scheduler-retry-backoff" env:"SCHEDULER_RETRY_BACKOFF" default:"1m" description:"How long scheduled boundaries skip a job after it failed, doubled after every consecutive failure with 20% jitter (0 retries failed jobs every boundary)"`
+		RetryBackoffMax time.Duration `long:"scheduler-retry-backoff-max" env:"SCHEDULER_RETRY_BACKOFF_MAX" default:"1h" description:"Longest a failing job is skipped for"`
+		MaxRetries      int           `long:"scheduler-max-retries" env:"SCHEDULER_MAX_RETRIES" default:"5" description:"Consecutive failures after which scheduler.job_failing is sent and the job waits for the chain and subgraph to be healthy (0 disables)"`
 	} `group:"Scheduler Options" namespace:"scheduler"`
 
+	// Leader election, so only one replica runs the scheduler
+	Leader struct {
+		Enabled       bool          `long:"leader-election" env:"LEADER_ELECTION" description:"Run the scheduler only on the replica holding the leader lease"`
+		Backend       string        `long:"leader-backend" env:"LEADER_BACKEND" default:"redis" choice:"redis" description:"Where the lease is kept, a store every replica shares"`
+		RedisAddr     string        `long:"leader-redis-addr" env:"LEADER_REDIS_ADDR" description:"Redis host:port for the redis backend"`
+		RedisPassword string        `long:"leader-redis-password" env:"LEADER_REDIS_PASSWORD" description:"Redis password"`
+		LeaseName     string        `long:"leader-lease-name" env:"LEADER_LEASE_NAME" default:"scheduler" description:"Lease shared by the replicas"`
+		TTL           time.Duration `long:"leader-ttl" env:"LEADER_TTL" default:"30s" description:"Lease duration, a failed leader is replaced within this time"`
+		NodeID        string        `long:"leader-node-id" env:"LEADER_NODE_ID" description:"Identity of this replica (default hostname-pid)"`
+	} `group:"Leader Options" namespace:"leader"`
+
+	// Operator endpoints under /admin
+	Admin struct {
+		APIKeys []string `long:"admin-api-key" env:"ADMIN_API_KEYS" env-delim:"," description:"API keys accepted by the /admin endpoints (none rejects every admin request)"`
+	} `group:"Admin Options" namespace:"admin"`
+
+	// Responses kept for retries of write requests sent with an Idempotency-Key header
+	Idempotency struct {
+		TTL     time.Duration `long:"idempotency-ttl" env:"IDEMPOTENCY_TTL" default:"24h" description:"How long the response to a request with an Idempotency-Key is replayed to its retries, 0 ignores the header"`
+		LockTTL time.Duration `long:"idempotency-lock-ttl" env:"IDEMPOTENCY_LOCK_TTL" default:"30m" description:"How long a running request holds its key, retries meanwhile get 409; frees the key of a request the server crashed in"`
+	} `group:"Idempotency Options" namespace:"idempotency"`
+
+	// Tracing configuration
+	Tracing struct {
+		Enabled     bool    `long:"tracing-enabled" env:"TRACING_ENABLED" description:"Enable OpenTelemetry tracing"`
+		Endpoint    string  `long:"tracing-endpoint" env:"TRACING_ENDPOINT" default:"localhost:4318" description:"OTLP/HTTP collector endpoint"`
+		ServiceName string  `long:"tracing-service-name" env:"TRACING_SERVICE_NAME" default:"epoch-server" description:"Service name reported in traces"`
+		SampleRatio float64 `long:"tracing-sample-ratio" env:"TRACING_SAMPLE_RATIO" default:"1.0" description:"Fraction of traces to sample"`
+		Insecure    bool    `long:"tracing-insecure" env:"TRACING_INSECURE" description:"Disable TLS for the OTLP exporter"`
+	} `group:"Tracing Options" namespace:"tracing"`
+
+	// Fault injection into dependency requests, for resilience testing in builds made with -tags faults
+	Faults struct {
+		Enabled             bool          `long:"faults-enabled" env:"FAULTS_ENABLED" description:"Inject the configured faults into subgraph and RPC requests, startup fails in builds without -tags faults"`
+		Seed                uint64        `long:"faults-seed" env:"FAULTS_SEED" description:"Seed of the fault dice, so a run can be repeated (0 seeds from the clock)"`
+		SubgraphLatency     time.Duration `long:"faults-subgraph-latency" env:"FAULTS_SUBGRAPH_LATENCY" description:"Latency added to every faulted subgraph request"`
+		SubgraphErrorRate   float64       `long:"faults-subgraph-error-rate" env:"FAULTS_SUBGRAPH_ERROR_RATE" description:"Share of faulted subgraph requests failed with 503, 0 to 1"`
+		SubgraphPartialRate float64       `long:"faults-subgraph-partial-rate" env:"FAULTS_SUBGRAPH_PARTIAL_RATE" description:"Share of faulted subgraph responses with every entity list cut in half, 0 to 1"`
+		SubgraphMatch       string        `long:"faults-subgraph-match" env:"FAULTS_SUBGRAPH_MATCH" description:"Only subgraph requests whose body contains this are faulted, e.g. accountSubsidies"`
+		RPCLatency          time.Duration `long:"faults-rpc-latency" env:"FAULTS_RPC_LATENCY" description:"Latency added to every faulted RPC request"`
+		RPCErrorRate        float64       `long:"faults-rpc-error-rate" env:"FAULTS_RPC_ERROR_RATE" description:"Share of faulted RPC requests failed with 503, 0 to 1"`
+		RPCPartialRate      float64       `long:"faults-rpc-partial-rate" env:"FAULTS_RPC_PARTIAL_RATE" description:"Share of faulted RPC responses cut off halfway, 0 to 1"`
+		RPCMatch            string        `long:"faults-rpc-match" env:"FAULTS_RPC_MATCH" description:"Only RPC requests whose body contains this are faulted, e.g. eth_sendRawTransaction"`
+	} `group:"Fault Injection Options" namespace:"faults"`
+
+	// Webhook configuration
+	Webhooks struct {
+		URLs         []string      `long:"webhook-url" env:"WEBHOOK_URLS" env-delim:"," description:"Webhook endpoints notified of epoch lifecycle events"`
+		Secrets      []string      `long:"webhook-secret" env:"WEBHOOK_SECRETS" env-delim:"," description:"HMAC secrets matching webhook URLs by position (a single secret applies to all URLs)"`
+		Timeout      time.Duration `long:"webhook-timeout" env:"WEBHOOK_TIMEOUT" default:"10s" description:"Webhook request timeout"`
+		MaxRetries   int           `long:"webhook-max-retries" env:"WEBHOOK_MAX_RETRIES" default:"5" description:"Retries before a webhook delivery is dead-lettered"`
+		RetryBackoff time.Duration `long:"webhook-retry-backoff" env:"WEBHOOK_RETRY_BACKOFF" default:"2s" description:"Initial delay between webhook retries, doubled after each attempt"`
+	} `group:"Webhook Options" namespace:"webhooks"`
+
+	// Queue of API-triggered work run in the background
+	Queue struct {
+		Workers   int           `long:"queue-workers" env:"QUEUE_WORKERS" default:"2" description:"Queued jobs run at once"`
+		MaxQueued int           `long:"queue-max-queued" env:"QUEUE_MAX_QUEUED" default:"100" description:"Jobs waiting to run before new ones are rejected with 503"`
+		Timeout   time.Duration `long:"queue-timeout" env:"QUEUE_TIMEOUT" default:"30m" description:"Longest a job runs before it is canceled and fails"`
+		Retention time.Duration `long:"queue-retention" env:"QUEUE_RETENTION" default:"168h" description:"How long finished jobs are kept for GET /api/jobs/{id} (0 keeps them)"`
+	} `group:"Queue Options" namespace:"queue"`
+
+	// Columnar copies of epoch distributions for offline analysis
+	Archive struct {
+		Target     string `long:"archive-target" env:"ARCHIVE_TARGET" default:"none" choice:"none" choice:"local" choice:"s3" description:"Where every epoch distribution is also written as a Parquet file: nowhere, under --archive-dir, or to --archive-s3-bucket"`
+		Dir        string `long:"archive-dir" env:"ARCHIVE_DIR" default:"./data/archive" description:"Directory the local target writes Parquet files under"`
+		S3Bucket   string `long:"archive-s3-bucket" env:"ARCHIVE_S3_BUCKET" description:"Bucket the s3 target writes Parquet files to, with credentials from the AWS environment"`
+		S3Prefix   string `long:"archive-s3-prefix" env:"ARCHIVE_S3_PREFIX" description:"Key prefix of the Parquet files in the bucket"`
+		S3Region   string `long:"archive-s3-region" env:"ARCHIVE_S3_REGION" description:"Region of the bucket (defaults from the AWS environment)"`
+		S3Endpoint string `long:"archive-s3-endpoint" env:"ARCHIVE_S3_ENDPOINT" description:"Endpoint of an S3-compatible store such as MinIO"`
+	} `group:"Archive Options" namespace:"archive"`
+
+	// Claim eligibility of every epoch distribution published to IPFS, so anyone can check the tree
+	IPFS struct {
+		APIURL  string        `long:"ipfs-api-url" env:"IPFS_API_URL" description:"Kubo RPC compatible API (/api/v0/add) of the IPFS node or pinning service every epoch's leaves and root are pinned through, empty publishes nothing"`
+		Token   string        `long:"ipfs-token" env:"IPFS_TOKEN" description:"Bearer token the pinning service is called with"`
+		Timeout time.Duration `long:"ipfs-timeout" env:"IPFS_TIMEOUT" default:"30s" description:"Pinning request timeout"`
+	} `group:"IPFS Options" namespace:"ipfs"`
+
+	// Copies of the whole database, restored with cmd/restore when the local database is lost
+	Backup struct {
+		Target   string        `long:"backup-target" env:"BACKUP_TARGET" default:"none" choice:"none" choice:"local" choice:"s3" choice:"gcs" description:"Where the database is backed up: nowhere, under --backup-dir, or to --backup-bucket on S3 or Google Cloud Storage (HMAC keys as AWS credentials)"`
+		Interval time.Duration `long:"backup-interval" env:"BACKUP_INTERVAL" default:"6h" description:"Time between backups"`
+		Retain   int           `long:"backup-retain" env:"BACKUP_RETAIN" default:"28" description:"Most recent backups kept, older ones are deleted after each backup"`
+		Dir      string        `long:"backup-dir" env:"BACKUP_DIR" default:"./data/backups" description:"Directory the local target writes backups under"`
+		Bucket   string        `long:"backup-bucket" env:"BACKUP_BUCKET" description:"Bucket the s3 and gcs targets write backups to, with credentials from the AWS environment"`
+		Prefix   string        `long:"backup-prefix" env:"BACKUP_PREFIX" default:"backups" description:"Key prefix of the backups in the bucket"`
+		Region   string        `long:"backup-region" env:"BACKUP_REGION" description:"Region of the bucket (defaults from the AWS environment, auto for gcs)"`
+		Endpoint string        `long:"backup-endpoint" env:"BACKUP_ENDPOINT" description:"Endpoint of an S3-compatible store such as MinIO (defaults to the XML API for gcs)"`
+	} `group:"Backup Options" namespace:"backup"`
+
+	// Batch repayment configuration
+	Repayment struct {
+		MaxBatchSize     int    `long:"repayment-max-batch-size" env:"REPAYMENT_MAX_BATCH_SIZE" default:"200" description:"Most borrowers repaid in one repayBorrowBehalfBatch call"`
+		GasBudget        uint64 `long:"repayment-gas-budget" env:"REPAYMENT_GAS_BUDGET" default:"10000000" description:"Most gas a single repayment batch may use (0 disables the check)"`
+		GasMarginPercent uint64 `long:"repayment-gas-margin" env:"REPAYMENT_GAS_MARGIN" default:"20" description:"Percent added to the gas estimate when sending a repayment batch"`
+	} `group:"Repayment Options" namespace:"repayment"`
+
+	// Distribution approval configuration
+	Approval struct {
+		Enabled                bool     `long:"approval-enabled" env:"APPROVAL_ENABLED" description:"Stage distributions and wait for approval before pushing the merkle root on-chain"`
+		AutoApproveMaxTotal    string   `long:"approval-auto-max-total" env:"APPROVAL_AUTO_APPROVE_MAX_TOTAL" description:"Distributions totalling at most this many wei are approved automatically"`
+		AutoApproveMaxAccounts int      `long:"approval-auto-max-accounts" env:"APPROVAL_AUTO_APPROVE_MAX_ACCOUNTS" description:"Distributions to at most this many accounts are approved automatically"`
+		APIKeys                []string `long:"approval-api-key" env:"APPROVAL_API_KEYS" env-delim:"," description:"API keys accepted by the approve and reject endpoints"`
+	} `group:"Approval Options" namespace:"approval"`
+
+	// Governance caps on what a distribution pays out. Debt is the account's totalBorrowVolume in the subgraph.
+	Caps struct {
+		UserMax                  string `long:"caps-user-max" env:"CAPS_USER_MAX" description:"Most wei an account receives in one distribution (empty disables the cap)"`
+		UserMaxDebtPercent       uint64 `long:"caps-user-max-debt-percent" env:"CAPS_USER_MAX_DEBT_PERCENT" description:"Most an account receives in one distribution, as a percent of its debt (0 disables the cap)"`
+		CollectionMax            string `long:"caps-collection-max" env:"CAPS_COLLECTION_MAX" description:"Most wei a collection's participants receive together in one distribution (empty disables the cap)"`
+		CollectionMaxDebtPercent uint64 `long:"caps-collection-max-debt-percent" env:"CAPS_COLLECTION_MAX_DEBT_PERCENT" description:"Most a collection's participants receive together, as a percent of their debt (0 disables the cap)"`
+		OutstandingDebt          bool   `long:"caps-outstanding-debt" env:"CAPS_OUTSTANDING_DEBT" description:"Most an account receives in one distribution is what it owes the vault's lending market at the snapshot block"`
+		Remainder                string `long:"caps-remainder" env:"CAPS_REMAINDER" default:"redistribute" choice:"redistribute" choice:"carry_forward" description:"Whether clamped amounts go to uncapped allocations now or to the next distribution"`
+	} `group:"Cap Options" namespace:"caps"`
+
+	// Addresses blocklisted through the admin API are left out of every merkle tree
+	Blocklist struct {
+		Remainder string `long:"blocklist-remainder" env:"BLOCKLIST_REMAINDER" default:"burn" choice:"burn" choice:"redistribute" description:"Whether what blocked addresses would have received is left undistributed or spread over the other allocations"`
+	} `group:"Blocklist Options" namespace:"blocklist"`
+
+	// Pre-flight checks run before a distribution's root is pushed to finalize its epoch
+	Finalization struct {
+		Checks string `long:"finalization-checks" env:"FINALIZATION_CHECKS" default:"warn" choice:"warn" choice:"enforce" choice:"off" description:"What failed finalization checks do: warn logs and returns them, enforce also keeps the root from being pushed, off skips the checks"`
+	} `group:"Finalization Options" namespace:"finalization"`
+
+	// What happens to the fractions of a wei allocations are rounded down by
+	Rounding struct {
+		Policy string `long:"rounding-policy" env:"ROUNDING_POLICY" default:"floor" choice:"floor" choice:"largest_holders" choice:"carry_forward" description:"Whether rounded off fractions of a wei are left undistributed, paid to the largest allocations, or carried to the next distribution"`
+	} `group:"Rounding Options" namespace:"rounding"`
+
+	// Manual corrections of allocations, each proposed by one admin and approved by another
+	Adjustments struct {
+		MaxAmount string `long:"adjustments-max-amount" env:"ADJUSTMENTS_MAX_AMOUNT" description:"Most wei one manual adjustment may add to or take from an allocation; adjustments are refused while it is empty"`
+	} `group:"Adjustment Options" namespace:"adjustments"`
+
+	// Minimum allocations keep dust out of the trees: an account earning less waits for its cumulative total to
+	// reach the minimum before it gets a leaf
+	Minimums struct {
+		Allocation       string   `long:"minimum-allocation" env:"MINIMUM_ALLOCATION" description:"Wei below which an account without a leaf in the vault's last tree is left out of the next one, its amount carried until its cumulative total reaches it (empty disables it)"`
+		VaultAllocations []string `long:"minimum-vault-allocation" env:"MINIMUM_VAULT_ALLOCATIONS" env-delim:"," description:"Vaults with their own minimum allocation, as vault:wei pairs"`
+	} `group:"Minimum Allocation Options" namespace:"minimums"`
+
+	// Claim expiry withholds what accounts leave unclaimed for too long: leaves are cumulative, so an expired amount
+	// is left out of every later tree. It stays in the vault and is not distributed to other accounts.
+	ClaimExpiry struct {
+		Epochs uint64 `long:"claim-expiry-epochs" env:"CLAIM_EXPIRY_EPOCHS" default:"0" description:"Epochs an amount stays claimable; what is still unclaimed of it by then is left out of the next trees, 0 disables expiry"`
+	} `group:"Claim Expiry Options" namespace:"expiry"`
+
+	// Statistics computed for every epoch's distribution
+	Stats struct {
+		DustThreshold string `long:"stats-dust-threshold" env:"STATS_DUST_THRESHOLD" default:"1000000000000" description:"Allocations of fewer wei are counted as dust in distribution statistics"`
+	} `group:"Statistics Options" namespace:"stats"`
+
+	// How merkle leaves are hashed, which must match the DebtSubsidizer deployment a vault's roots are pushed to
+	Merkle struct {
+		LeafEncoding       string   `long:"merkle-leaf-encoding" env:"MERKLE_LEAF_ENCODING" default:"packed" choice:"packed" choice:"abi" choice:"double_hash" description:"Leaf encoding: keccak256 of abi.encodePacked(recipient, totalEarned), of abi.encode(recipient, totalEarned), or of that hash again as OpenZeppelin's StandardMerkleTree does"`
+		VaultLeafEncodings []string `long:"merkle-vault-leaf-encoding" env:"MERKLE_VAULT_LEAF_ENCODINGS" env-delim:"," description:"Vaults whose DebtSubsidizer hashes leaves differently, as vault:encoding pairs"`
+		RootsStartBlock    uint64   `long:"merkle-roots-start-block" env:"MERKLE_ROOTS_START_BLOCK" default:"0" description:"Block the history of MerkleRootUpdated events is synced from, the DebtSubsidizer's deployment block"`
+	} `group:"Merkle Options" namespace:"merkle"`
+
+	// Holdings that are not ERC-721 tokens held by the account earning on them
+	Holdings struct {
+		ERC1155Units []string `long:"holdings-erc1155-units" env:"HOLDINGS_ERC1155_UNITS" env-delim:"," description:"ERC-1155 collections valued per NFT-equivalent, as collection:units pairs; units tokens earn what one ERC-721 token does"`
+		Wrappers     []string `long:"holdings-wrapper" env:"HOLDINGS_WRAPPERS" env-delim:"," description:"Staking and wrapper contracts whose subsidies are delegated to the owners of the wrapped positions"`
+		Eligibility  string   `long:"holdings-eligibility" env:"HOLDINGS_ELIGIBILITY" default:"snapshot" choice:"snapshot" choice:"time_weighted" description:"How an epoch's accrual is shared among holders: as the snapshot block saw them, or by how long each held its tokens over the epoch according to the subgraph's transfer history"`
+	} `group:"Holdings Options" namespace:"holdings"`
+
+	// Risky behaviors switched on per environment, each can also be overridden at runtime through /admin/features
+	Features struct {
+		File string `long:"features-file" env:"FEATURES_FILE" description:"JSON file of feature flags switched on or off in this environment, as {\"flag\": true|false}; flags it does not set are on"`
+	} `group:"Feature Options" namespace:"features"`
+
+	// Signer account configuration
+	Signer struct {
+		MinBalance string `long:"signer-min-balance" env:"SIGNER_MIN_BALANCE" description:"Wei below which scheduled transactions are paused and an alert is sent (empty disables halting)"`
+	} `group:"Signer Options" namespace:"signer"`
+
+	// Gas spend reporting
+	Gas struct {
+		MonthlyBudget string `long:"gas-monthly-budget" env:"GAS_MONTHLY_BUDGET" description:"Wei of gas spend per UTC calendar month above which an alert is sent (empty disables the budget)"`
+	} `group:"Gas Options" namespace:"gas"`
+
+	// Yield reconciliation
+	Reconciliation struct {
+		Tolerance string `long:"reconciliation-tolerance" env:"RECONCILIATION_TOLERANCE" default:"0" description:"Wei by which distributed subsidies may exceed allocated yield, or claims exceed subsidies or an account's cumulative total, before the reconciliation report flags it"`
+	} `group:"Reconciliation Options" namespace:"reconciliation"`
+
+	// Yield allocation
+	Yield struct {
+		Allocate       bool     `long:"yield-allocate" env:"YIELD_ALLOCATE" description:"Allocate the vault's remaining cumulative yield to each new epoch at the boundary"`
+		ReservePercent uint64   `long:"yield-reserve-percent" env:"YIELD_RESERVE_PERCENT" default:"0" description:"Percent of the remaining cumulative yield held back in the vault when allocating to an epoch"`
+		ReserveMin     string   `long:"yield-reserve-min" env:"YIELD_RESERVE_MIN" default:"0" description:"Wei always held back when allocating to an epoch, when the percentage holds back less"`
+		Sources        []string `long:"yield-sources" env:"YIELD_SOURCES" env-delim:"," default:"lending_manager" description:"Sources summed into each epoch's allocation: lending_manager, or erc20:token[:holder] for a token balance held by holder, the vault by default"`
+	} `group:"Yield Options" namespace:"yield"`
+
+	// Vaults discovered from the DebtSubsidizer's VaultAdded and VaultRemoved events
+	Discovery struct {
+		Enabled    bool          `long:"vault-discovery-enabled" env:"VAULT_DISCOVERY_ENABLED" description:"Keep the vault registry in sync with the vaults added to and removed from the DebtSubsidizer"`
+		StartBlock uint64        `long:"vault-discovery-start-block" env:"VAULT_DISCOVERY_START_BLOCK" default:"0" description:"Block vault events are scanned from, the DebtSubsidizer's deployment block"`
+		Interval   time.Duration `long:"vault-discovery-interval" env:"VAULT_DISCOVERY_INTERVAL" default:"5m" description:"Time between scans for new vault events"`
+	} `group:"Vault Discovery Options" namespace:"discovery"`
+
 	// Contract addresses
 	Contracts struct {
 		Comptroller        string `long:"comptroller-address" env:"COMPTROLLER_ADDRESS" required:"true" description:"Comptroller contract address"`
 		EpochManager       string `long:"epoch-manager-address" env:"EPOCH_MANAGER_ADDRESS" required:"true" description:"Epoch manager contract address"`
+		EpochManagerBlock  uint64 `long:"epoch-manager-start-block" env:"EPOCH_MANAGER_START_BLOCK" default:"0" description:"Block the EpochManager was deployed at, the oldest searched for EpochStarted events"`
 		DebtSubsidizer     string `long:"debt-subsidizer-address" env:"DEBT_SUBSIDIZER_PROXY_ADDRESS" required:"true" description:"Debt subsidizer contract address"`
 		LendingManager     string `long:"lending-manager-address" env:"LENDING_MANAGER_ADDRESS" required:"true" description:"Lending manager contract address"`
 		CollectionRegistry string `long:"collection-registry-address" env:"COLLECTION_REGISTRY_ADDRESS" required:"true" description:"Collection registry contract address"`
@@ -65,15 +327,115 @@ type Config struct {
 	} `group:"Contract Options" namespace:"contracts"`
 }
 
+// knownChainIDs maps well-known network names to their chain IDs
+var knownChainIDs = map[string]uint64{
+	"mainnet":      1,
+	"sepolia":      11155111,
+	"holesky":      17000,
+	"base":         8453,
+	"base-sepolia": 84532,
+}
+
+// networkGroups are the option groups a network profile can override
+var networkGroups = map[string]bool{
+	"Ethereum Options": true,
+	"Subgraph Options": true,
+	"Contract Options": true,
+	"Archive Options":  true,
+	"Backup Options":   true,
+	"Feature Options":  true,
+}
+
+// Load reads configuration from environment variables only
 func Load() (*Config, error) {
-	var cfg Config
+	return LoadArgs(nil)
+}
+
+// LoadArgs reads configuration from command line arguments and environment variables.
+// When a network is selected with --network or NETWORK, every ethereum, subgraph, contract, archive, backup and
+// feature option reads <NETWORK>_<VAR> before <VAR>, so one environment can hold several deployments.
+func LoadArgs(args []string) (*Config, error) {
+	network, err := selectedNetwork(args)
+	if err != nil {
+		return nil, err
+	}
+	return loadNetwork(args, network, "")
+}
 
+// LoadTenants reads the configuration of every tenant listed with --tenant or TENANTS. A tenant is read as
+// LoadArgs reads the network profile of the same name, so its contracts, subgraph, signer, archive and backups come
+// from <TENANT>_ prefixed variables, and it keeps its database, leader lease, archived files and backups apart from
+// the other tenants'.
+// Options outside the network profile, like the server's, are the same for every tenant. Without tenants
+// the one configuration LoadArgs reads is returned.
+func LoadTenants(args []string) ([]*Config, error) {
+	tenants, err := selectedTenants(args)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenants) == 0 {
+		cfg, err := LoadArgs(args)
+		if err != nil {
+			return nil, err
+		}
+		return []*Config{cfg}, nil
+	}
+	network, err := selectedNetwork(args)
+	if err != nil {
+		return nil, err
+	}
+	if network != "" {
+		return nil, fmt.Errorf("network %s cannot be selected with tenants, every tenant is its own network profile", network)
+	}
+
+	configs := make([]*Config, 0, len(tenants))
+	for _, tenant := range tenants {
+		cfg, err := loadNetwork(args, tenant, tenant)
+		if err != nil {
+			var validationErr *ValidationError
+			if errors.As(err, &validationErr) {
+				for i, problem := range validationErr.Problems {
+					validationErr.Problems[i] = fmt.Errorf("tenant %s: %w", tenant, problem)
+				}
+				return nil, validationErr
+			}
+			return nil, fmt.Errorf("tenant %s: %w", tenant, err)
+		}
+		configs = append(configs, cfg)
+	}
+	return configs, nil
+}
+
+// loadNetwork reads the configuration with the network's profile applied, for the tenant when one is given
+func loadNetwork(args []string, network, tenant string) (*Config, error) {
+	var cfg Config
 	parser := flags.NewParser(&cfg, flags.Default)
+	if network != "" {
+		applyNetworkEnv(parser, network)
+	}
 
-	// Parse only environment variables and ignore command line arguments
-	if _, err := parser.ParseArgs([]string{}); err != nil {
+	if _, err := parser.ParseArgs(args); err != nil {
 		return nil, err
 	}
+	if tenant != "" {
+		cfg.Network = network
+		cfg.Tenant = tenant
+		cfg.Database.ConnectionString = tenantDatabasePath(cfg.Database.Type, cfg.Database.ConnectionString, tenant)
+		cfg.Leader.LeaseName += "-" + tenant
+		cfg.Archive.Dir = filepath.Join(cfg.Archive.Dir, tenant)
+		cfg.Archive.S3Prefix = path.Join(cfg.Archive.S3Prefix, tenant)
+		cfg.Backup.Dir = filepath.Join(cfg.Backup.Dir, tenant)
+		cfg.Backup.Prefix = path.Join(cfg.Backup.Prefix, tenant)
+	}
+
+	if err := resolveChainID(&cfg); err != nil {
+		return nil, err
+	}
+
+	// every problem is reported at once rather than one per restart
+	if problems := validate(&cfg); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
 
 	// Normalize all contract addresses to lowercase
 	cfg.Contracts.Comptroller = utils.NormalizeAddress(cfg.Contracts.Comptroller)
@@ -88,3 +450,350 @@ func Load() (*Config, error) {
 
 	return &cfg, nil
 }
+
+// validateLeader checks the lease can be renewed in time and that the redis backend has an address. The lease
+// must live in a store all replicas share, which the server's own database is not.
+func validateLeader(cfg *Config) error {
+	if !cfg.Leader.Enabled {
+		return nil
+	}
+	if cfg.Leader.TTL < 3*time.Second {
+		return fmt.Errorf("leader ttl must be at least 3s, got %v", cfg.Leader.TTL)
+	}
+	if cfg.Leader.LeaseName == "" {
+		return fmt.Errorf("leader lease name is required")
+	}
+	if cfg.Leader.RedisAddr == "" {
+		return fmt.Errorf("leader redis address is required for the redis backend")
+	}
+	return nil
+}
+
+// validateCaps checks the absolute caps are amounts of wei and the percentage caps are at most 100
+func validateCaps(cfg *Config) error {
+	if limit := cfg.Caps.UserMax; limit != "" {
+		if n, ok := new(big.Int).SetString(limit, 10); !ok || n.Sign() < 0 {
+			return fmt.Errorf("user cap must be a non-negative integer amount of wei, got %q", limit)
+		}
+	}
+	if limit := cfg.Caps.CollectionMax; limit != "" {
+		if n, ok := new(big.Int).SetString(limit, 10); !ok || n.Sign() < 0 {
+			return fmt.Errorf("collection cap must be a non-negative integer amount of wei, got %q", limit)
+		}
+	}
+	if cfg.Caps.UserMaxDebtPercent > 100 {
+		return fmt.Errorf("user debt cap cannot exceed 100 percent, got %d", cfg.Caps.UserMaxDebtPercent)
+	}
+	if cfg.Caps.CollectionMaxDebtPercent > 100 {
+		return fmt.Errorf("collection debt cap cannot exceed 100 percent, got %d", cfg.Caps.CollectionMaxDebtPercent)
+	}
+	return nil
+}
+
+// ParseERC1155Units parses collection:units pairs into the units of every collection, keyed by
+// normalized collection address
+func ParseERC1155Units(pairs []string) (map[string]*big.Int, error) {
+	units := make(map[string]*big.Int, len(pairs))
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		collection, value, ok := strings.Cut(pair, ":")
+		if !ok || !utils.IsValidAddress(collection) {
+			return nil, fmt.Errorf("erc1155 units must be collection:units with a valid collection address, got %q", pair)
+		}
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok || n.Sign() <= 0 {
+			return nil, fmt.Errorf("erc1155 units of %s must be a positive integer, got %q", collection, value)
+		}
+		units[utils.NormalizeAddress(collection)] = n
+	}
+	return units, nil
+}
+
+// leafEncodings are the merkle leaf encodings a vault can be configured for
+var leafEncodings = []string{"packed", "abi", "double_hash"}
+
+// ParseVaultLeafEncodings parses vault:encoding pairs into the leaf encoding of every vault, keyed by
+// normalized vault address
+func ParseVaultLeafEncodings(pairs []string) (map[string]string, error) {
+	encodings := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		vault, encoding, ok := strings.Cut(pair, ":")
+		if !ok || !utils.IsValidAddress(vault) {
+			return nil, fmt.Errorf("vault leaf encodings must be vault:encoding with a valid vault address, got %q", pair)
+		}
+		if !slices.Contains(leafEncodings, encoding) {
+			return nil, fmt.Errorf("leaf encoding of vault %s must be one of %s, got %q", vault, strings.Join(leafEncodings, ", "), encoding)
+		}
+		encodings[utils.NormalizeAddress(vault)] = encoding
+	}
+	return encodings, nil
+}
+
+// ParseVaultMinimums parses vault:wei pairs into the minimum allocation of every vault, keyed by normalized
+// vault address
+func ParseVaultMinimums(pairs []string) (map[string]*big.Int, error) {
+	minimums := make(map[string]*big.Int, len(pairs))
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		vault, value, ok := strings.Cut(pair, ":")
+		if !ok || !utils.IsValidAddress(vault) {
+			return nil, fmt.Errorf("vault minimum allocations must be vault:wei with a valid vault address, got %q", pair)
+		}
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok || n.Sign() < 0 {
+			return nil, fmt.Errorf("minimum allocation of vault %s must be a non-negative integer amount of wei, got %q", vault, value)
+		}
+		minimums[utils.NormalizeAddress(vault)] = n
+	}
+	return minimums, nil
+}
+
+// operations with a confirmation depth of their own
+const (
+	ConfirmStatus     = "status"
+	ConfirmMerkleRoot = "merkle_root"
+	ConfirmSnapshot   = "snapshot"
+)
+
+// Confirmations are how many blocks each operation waits for its block to be buried under
+type Confirmations struct {
+	Status     uint64 // a transaction's outcome updating epoch state
+	MerkleRoot uint64 // an updateMerkleRoot transaction being final
+	Snapshot   uint64 // a snapshot block, before its merkle root is submitted
+}
+
+// ParseConfirmations parses operation:blocks pairs over the depths the operations have without them: status and
+// merkle_root wait for receipt blocks, snapshot for snapshot blocks
+func ParseConfirmations(pairs []string, receipt, snapshot uint64) (Confirmations, error) {
+	confirmations := Confirmations{Status: receipt, MerkleRoot: receipt, Snapshot: snapshot}
+	for _, pair := range pairs {
+		if pair == "" {
+			continue
+		}
+		operation, value, ok := strings.Cut(pair, ":")
+		if !ok {
+			return Confirmations{}, fmt.Errorf("confirmations must be operation:blocks, got %q", pair)
+		}
+		blocks, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return Confirmations{}, fmt.Errorf("confirmations of %s must be a non-negative number of blocks, got %q", operation, value)
+		}
+		switch operation {
+		case ConfirmStatus:
+			confirmations.Status = blocks
+		case ConfirmMerkleRoot:
+			confirmations.MerkleRoot = blocks
+		case ConfirmSnapshot:
+			confirmations.Snapshot = blocks
+		default:
+			return Confirmations{}, fmt.Errorf("confirmations operation must be one of %s, %s, %s, got %q",
+				ConfirmStatus, ConfirmMerkleRoot, ConfirmSnapshot, operation)
+		}
+	}
+	return confirmations, nil
+}
+
+// yield source kinds
+const (
+	YieldSourceLendingManager = "lending_manager"
+	YieldSourceERC20          = "erc20"
+)
+
+// YieldSource is a configured source of the yield allocated to epochs. Token and Holder are only set for erc20
+// sources; an empty Holder is the vault.
+type YieldSource struct {
+	Kind   string
+	Token  string
+	Holder string
+}
+
+// Name identifies the source, the entry it was parsed from with addresses normalized
+func (y YieldSource) Name() string {
+	if y.Kind != YieldSourceERC20 {
+		return y.Kind
+	}
+	if y.Holder == "" {
+		return y.Kind + ":" + y.Token
+	}
+	return y.Kind + ":" + y.Token + ":" + y.Holder
+}
+
+// ParseYieldSources parses lending_manager and erc20:token[:holder] entries into yield sources, rejecting an
+// entry configured twice
+func ParseYieldSources(entries []string) ([]YieldSource, error) {
+	var sources []YieldSource
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if entry == "" {
+			continue
+		}
+		kind, rest, _ := strings.Cut(entry, ":")
+		source := YieldSource{Kind: kind}
+		switch kind {
+		case YieldSourceLendingManager:
+			if rest != "" {
+				return nil, fmt.Errorf("yield source %s takes no arguments, got %q", kind, entry)
+			}
+		case YieldSourceERC20:
+			token, holder, hasHolder := strings.Cut(rest, ":")
+			if !utils.IsValidAddress(token) || (hasHolder && !utils.IsValidAddress(holder)) {
+				return nil, fmt.Errorf("erc20 yield source must be erc20:token[:holder] with valid addresses, got %q", entry)
+			}
+			source.Token = utils.NormalizeAddress(token)
+			if hasHolder {
+				source.Holder = utils.NormalizeAddress(holder)
+			}
+		default:
+			return nil, fmt.Errorf("yield source must be %s or %s:token[:holder], got %q",
+				YieldSourceLendingManager, YieldSourceERC20, entry)
+		}
+		if seen[source.Name()] {
+			return nil, fmt.Errorf("yield source %s is configured twice", source.Name())
+		}
+		seen[source.Name()] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// validateHoldings checks the ERC-1155 units and that every wrapper is an address
+func validateHoldings(cfg *Config) []error {
+	var problems []error
+	if _, err := ParseERC1155Units(cfg.Holdings.ERC1155Units); err != nil {
+		problems = append(problems, err)
+	}
+	wrappers := 0
+	for _, wrapper := range cfg.Holdings.Wrappers {
+		if wrapper != "" && !utils.IsValidAddress(wrapper) {
+			problems = append(problems, fmt.Errorf("holdings wrapper %q is not a valid address", wrapper))
+		}
+		if wrapper != "" {
+			wrappers++
+		}
+	}
+	// a wrapper is the holder its transfers show, so holding times would pay the contract rather than the owners
+	if cfg.Holdings.Eligibility == "time_weighted" && wrappers > 0 {
+		problems = append(problems, errors.New("time_weighted holdings eligibility cannot be combined with holdings wrappers"))
+	}
+	return problems
+}
+
+// validateApproval checks the auto-approve thresholds and that approvals can be authenticated.
+// Thresholds only apply when approval is enabled; a distribution within every configured threshold skips approval.
+func validateApproval(cfg *Config) error {
+	if total := cfg.Approval.AutoApproveMaxTotal; total != "" {
+		if n, ok := new(big.Int).SetString(total, 10); !ok || n.Sign() < 0 {
+			return fmt.Errorf("approval auto-approve max total must be a non-negative integer amount of wei, got %q", total)
+		}
+	}
+	if cfg.Approval.AutoApproveMaxAccounts < 0 {
+		return fmt.Errorf("approval auto-approve max accounts cannot be negative, got %d", cfg.Approval.AutoApproveMaxAccounts)
+	}
+	if cfg.Approval.Enabled && len(cfg.Approval.APIKeys) == 0 {
+		return fmt.Errorf("approval is enabled but no approval API keys are configured")
+	}
+	return nil
+}
+
+// tenantNamePattern is what tenant names look like, they prefix variables and name storage paths
+var tenantNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// selectedTenants finds the tenants before the full parse, each is read with its own profile
+func selectedTenants(args []string) ([]string, error) {
+	var opts struct {
+		Tenants []string `long:"tenant" env:"TENANTS" env-delim:","`
+	}
+	if _, err := flags.NewParser(&opts, flags.IgnoreUnknown).ParseArgs(args); err != nil {
+		return nil, fmt.Errorf("failed to parse tenants: %w", err)
+	}
+	var tenants []string
+	for _, tenant := range opts.Tenants {
+		tenant = strings.ToLower(strings.TrimSpace(tenant))
+		if tenant == "" {
+			continue
+		}
+		if !tenantNamePattern.MatchString(tenant) {
+			return nil, fmt.Errorf("tenant name %q must start with a letter and hold only letters, digits and dashes", tenant)
+		}
+		if slices.Contains(tenants, tenant) {
+			return nil, fmt.Errorf("tenant %s is listed twice", tenant)
+		}
+		tenants = append(tenants, tenant)
+	}
+	return tenants, nil
+}
+
+// tenantDatabasePath returns where the tenant's database is kept: a directory of its own in a badger
+// directory, or a sqlite file named after the tenant next to the configured one
+func tenantDatabasePath(databaseType, path, tenant string) string {
+	if path == "" {
+		return ""
+	}
+	if databaseType == "sqlite" {
+		ext := filepath.Ext(path)
+		return strings.TrimSuffix(path, ext) + "-" + tenant + ext
+	}
+	return filepath.Join(path, tenant)
+}
+
+// selectedNetwork finds the network before the full parse, since it decides which variables are read
+func selectedNetwork(args []string) (string, error) {
+	var opts struct {
+		Network string `long:"network" env:"NETWORK"`
+	}
+	if _, err := flags.NewParser(&opts, flags.IgnoreUnknown).ParseArgs(args); err != nil {
+		return "", fmt.Errorf("failed to parse network: %w", err)
+	}
+	return strings.ToLower(strings.TrimSpace(opts.Network)), nil
+}
+
+// applyNetworkEnv points network-scoped options at their <NETWORK>_ prefixed variables when those are set
+func applyNetworkEnv(parser *flags.Parser, network string) {
+	prefix := strings.ToUpper(strings.ReplaceAll(network, "-", "_")) + "_"
+	var walk func(groups []*flags.Group)
+	walk = func(groups []*flags.Group) {
+		for _, group := range groups {
+			if networkGroups[group.ShortDescription] {
+				overrideEnvKeys(group, prefix)
+			}
+			walk(group.Groups())
+		}
+	}
+	walk(parser.Groups())
+}
+
+// overrideEnvKeys makes the group's options read prefix+VAR wherever that variable is set
+func overrideEnvKeys(group *flags.Group, prefix string) {
+	for _, opt := range group.Options() {
+		if opt.EnvDefaultKey == "" {
+			continue
+		}
+		if _, ok := os.LookupEnv(prefix + opt.EnvDefaultKey); ok {
+			opt.EnvDefaultKey = prefix + opt.EnvDefaultKey
+		}
+	}
+}
+
+// resolveChainID fills the expected chain ID for well-known networks and rejects a contradicting one
+func resolveChainID(cfg *Config) error {
+	cfg.Network = strings.ToLower(strings.TrimSpace(cfg.Network))
+	known, ok := knownChainIDs[cfg.Network]
+	if !ok {
+		return nil
+	}
+	if cfg.Ethereum.ChainID == 0 {
+		cfg.Ethereum.ChainID = known
+		return nil
+	}
+	if cfg.Ethereum.ChainID != known {
+		return fmt.Errorf("chain ID %d does not match network %s (chain ID %d)", cfg.Ethereum.ChainID, cfg.Network, known)
+	}
+	return nil
+}
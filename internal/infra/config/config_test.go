@@ -0,0 +1,797 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	for key, value := range map[string]string{
+		"RPC_URL":                       "https://rpc.default.example",
+		"PRIVATE_KEY":                   "0x01",
+		"SUBGRAPH_ENDPOINT":             "https://subgraph.default.example",
+		"COMPTROLLER_ADDRESS":           "0x1111111111111111111111111111111111111111",
+		"EPOCH_MANAGER_ADDRESS":         "0x2222222222222222222222222222222222222222",
+		"DEBT_SUBSIDIZER_PROXY_ADDRESS": "0x3333333333333333333333333333333333333333",
+		"LENDING_MANAGER_ADDRESS":       "0x4444444444444444444444444444444444444444",
+		"COLLECTION_REGISTRY_ADDRESS":   "0x5555555555555555555555555555555555555555",
+		"VAULT_ADDRESS":                 "0x6666666666666666666666666666666666666666",
+	} {
+		t.Setenv(key, value)
+	}
+	unsetEnv(t, "NETWORK")
+	unsetEnv(t, "CHAIN_ID")
+	unsetEnv(t, "TENANTS")
+}
+
+// unsetEnv removes key for the duration of the test
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "")
+	require.NoError(t, os.Unsetenv(key))
+}
+
+func TestLoadArgs_NetworkOverrides(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("SEPOLIA_RPC_URL", "https://rpc.sepolia.example")
+	t.Setenv("SEPOLIA_SUBGRAPH_ENDPOINT", "https://subgraph.sepolia.example")
+	t.Setenv("SEPOLIA_VAULT_ADDRESS", "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	t.Setenv("SEPOLIA_SERVER_PORT", "9999")
+
+	t.Run("without_network", func(t *testing.T) {
+		cfg, err := LoadArgs(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "https://rpc.default.example", cfg.Ethereum.RPCURL)
+		assert.Equal(t, uint64(0), cfg.Ethereum.ChainID)
+	})
+
+	t.Run("network_flag", func(t *testing.T) {
+		cfg, err := LoadArgs([]string{"--network", "sepolia"})
+		require.NoError(t, err)
+		assert.Equal(t, "sepolia", cfg.Network)
+		assert.Equal(t, uint64(11155111), cfg.Ethereum.ChainID)
+		assert.Equal(t, "https://rpc.sepolia.example", cfg.Ethereum.RPCURL)
+		assert.Equal(t, "https://subgraph.sepolia.example", cfg.Subgraph.Endpoint)
+		assert.Equal(t, "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", cfg.Contracts.CollectionsVault)
+		assert.Equal(t, "0x2222222222222222222222222222222222222222", cfg.Contracts.EpochManager, "unset overrides fall back")
+		assert.Equal(t, 8080, cfg.Server.Port, "server options are not network scoped")
+	})
+
+	t.Run("network_env", func(t *testing.T) {
+		t.Setenv("NETWORK", "Sepolia")
+		cfg, err := LoadArgs(nil)
+		require.NoError(t, err)
+		assert.Equal(t, "https://rpc.sepolia.example", cfg.Ethereum.RPCURL)
+	})
+
+	t.Run("flag_overrides_profile", func(t *testing.T) {
+		cfg, err := LoadArgs([]string{"--network=sepolia", "--ethereum.rpc-url", "https://rpc.flag.example"})
+		require.NoError(t, err)
+		assert.Equal(t, "https://rpc.flag.example", cfg.Ethereum.RPCURL)
+	})
+}
+
+func TestLoadArgs_ChainID(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		chainID       string
+		expectedID    uint64
+		expectedError string
+	}{
+		{name: "known_network_default", args: []string{"--network", "mainnet"}, expectedID: 1},
+		{name: "explicit_matches_network", args: []string{"--network", "mainnet"}, chainID: "1", expectedID: 1},
+		{name: "explicit_contradicts_network", args: []string{"--network", "mainnet"}, chainID: "5", expectedError: "does not match network mainnet"},
+		{name: "custom_network", args: []string{"--network", "devnet"}, chainID: "31337", expectedID: 31337},
+		{name: "custom_network_without_chain_id", args: []string{"--network", "devnet"}, expectedID: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			if tt.chainID != "" {
+				t.Setenv("CHAIN_ID", tt.chainID)
+			}
+
+			cfg, err := LoadArgs(tt.args)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedID, cfg.Ethereum.ChainID)
+		})
+	}
+}
+
+func TestLoadArgs_Approval(t *testing.T) {
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectedError string
+	}{
+		{name: "disabled_by_default"},
+		{name: "enabled_with_keys", env: map[string]string{"APPROVAL_ENABLED": "true", "APPROVAL_API_KEYS": "a,b", "APPROVAL_AUTO_APPROVE_MAX_TOTAL": "1000"}},
+		{name: "enabled_without_keys", env: map[string]string{"APPROVAL_ENABLED": "true"}, expectedError: "no approval API keys"},
+		{name: "malformed_total", env: map[string]string{"APPROVAL_AUTO_APPROVE_MAX_TOTAL": "1e18"}, expectedError: "non-negative integer amount of wei"},
+		{name: "negative_accounts", env: map[string]string{"APPROVAL_AUTO_APPROVE_MAX_ACCOUNTS": "-1"}, expectedError: "cannot be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := LoadArgs(nil)
+			if tt.expectedError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			if tt.env["APPROVAL_API_KEYS"] != "" {
+				assert.Equal(t, []string{"a", "b"}, cfg.Approval.APIKeys)
+			}
+		})
+	}
+}
+
+func TestLoadArgs_AdminAPIKeys(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Admin.APIKeys)
+
+	t.Setenv("ADMIN_API_KEYS", "ops-1,ops-2")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ops-1", "ops-2"}, cfg.Admin.APIKeys)
+}
+
+func TestLoadArgs_SignerMinBalance(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Setenv("SIGNER_MIN_BALANCE", "100000000000000000")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000", cfg.Signer.MinBalance)
+
+	t.Setenv("SIGNER_MIN_BALANCE", "0.1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "signer min balance must be a non-negative integer amount of wei")
+}
+
+func TestLoadArgs_SchedulerCatchUpLimit(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "SCHEDULER_CATCH_UP_LIMIT")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 10, cfg.Scheduler.CatchUpLimit)
+
+	t.Setenv("SCHEDULER_CATCH_UP_LIMIT", "-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scheduler catch-up limit cannot be negative")
+}
+
+func TestLoadArgs_SchedulerRetryBackoff(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "SCHEDULER_RETRY_BACKOFF")
+	unsetEnv(t, "SCHEDULER_RETRY_BACKOFF_MAX")
+	unsetEnv(t, "SCHEDULER_MAX_RETRIES")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, cfg.Scheduler.RetryBackoff)
+	assert.Equal(t, time.Hour, cfg.Scheduler.RetryBackoffMax)
+	assert.Equal(t, 5, cfg.Scheduler.MaxRetries)
+
+	t.Setenv("SCHEDULER_RETRY_BACKOFF", "2h")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scheduler retry backoff must be between 0 and the max backoff")
+
+	t.Setenv("SCHEDULER_RETRY_BACKOFF", "0s")
+	t.Setenv("SCHEDULER_MAX_RETRIES", "-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "scheduler max retries cannot be negative")
+}
+
+func TestLoadArgs_ReceiptWatching(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "RECEIPT_CONFIRMATIONS")
+	unsetEnv(t, "RECEIPT_TIMEOUT")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), cfg.Ethereum.ReceiptConfirmations)
+	assert.Equal(t, 10*time.Minute, cfg.Ethereum.ReceiptTimeout)
+
+	t.Setenv("RECEIPT_TIMEOUT", "0s")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "receipt timeout must be positive")
+}
+
+func TestLoadArgs_SchedulerMode(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "SCHEDULER_MODE")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "interval", cfg.Scheduler.Mode)
+
+	t.Setenv("SCHEDULER_MODE", "calendar")
+	t.Setenv("SCHEDULER_CALENDAR", "weekly:monday@00:00")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "weekly:monday@00:00", cfg.Scheduler.Calendar)
+
+	t.Setenv("SCHEDULER_CALENDAR", "")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must end in @HH:MM")
+}
+
+func TestLoadArgs_GasMonthlyBudget(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Setenv("GAS_MONTHLY_BUDGET", "500000000000000000")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "500000000000000000", cfg.Gas.MonthlyBudget)
+
+	t.Setenv("GAS_MONTHLY_BUDGET", "-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gas monthly budget must be a non-negative integer amount of wei")
+}
+
+func TestLoadArgs_ReconciliationTolerance(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "RECONCILIATION_TOLERANCE")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "0", cfg.Reconciliation.Tolerance)
+
+	t.Setenv("RECONCILIATION_TOLERANCE", "1.5")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reconciliation tolerance must be a non-negative integer amount of wei")
+}
+
+func TestLoadArgs_YieldReserve(t *testing.T) {
+	setRequiredEnv(t)
+	for _, key := range []string{"YIELD_ALLOCATE", "YIELD_RESERVE_PERCENT", "YIELD_RESERVE_MIN"} {
+		unsetEnv(t, key)
+	}
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.False(t, cfg.Yield.Allocate)
+	assert.Equal(t, uint64(0), cfg.Yield.ReservePercent)
+	assert.Equal(t, "0", cfg.Yield.ReserveMin)
+
+	t.Setenv("YIELD_ALLOCATE", "true")
+	t.Setenv("YIELD_RESERVE_PERCENT", "10")
+	t.Setenv("YIELD_RESERVE_MIN", "1000")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.Yield.Allocate)
+	assert.Equal(t, uint64(10), cfg.Yield.ReservePercent)
+	assert.Equal(t, "1000", cfg.Yield.ReserveMin)
+
+	t.Setenv("YIELD_RESERVE_PERCENT", "101")
+	t.Setenv("YIELD_RESERVE_MIN", "-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "yield reserve percent must be between 0 and 100")
+	assert.Contains(t, err.Error(), "yield reserve min must be a non-negative integer amount of wei")
+}
+
+func TestLoadArgs_Rounding(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "ROUNDING_POLICY")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "floor", cfg.Rounding.Policy)
+
+	t.Setenv("ROUNDING_POLICY", "largest_holders")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "largest_holders", cfg.Rounding.Policy)
+
+	t.Setenv("ROUNDING_POLICY", "round_half_up")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_Caps(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "CAPS_REMAINDER")
+
+	t.Setenv("CAPS_USER_MAX", "1000000000000000000")
+	t.Setenv("CAPS_COLLECTION_MAX_DEBT_PERCENT", "25")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000000", cfg.Caps.UserMax)
+	assert.Equal(t, uint64(25), cfg.Caps.CollectionMaxDebtPercent)
+	assert.Equal(t, "redistribute", cfg.Caps.Remainder)
+
+	t.Setenv("CAPS_REMAINDER", "carry_forward")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "carry_forward", cfg.Caps.Remainder)
+
+	t.Setenv("CAPS_REMAINDER", "burn")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	t.Setenv("CAPS_REMAINDER", "redistribute")
+
+	t.Setenv("CAPS_COLLECTION_MAX", "1e18")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "collection cap must be a non-negative integer amount of wei")
+	unsetEnv(t, "CAPS_COLLECTION_MAX")
+
+	t.Setenv("CAPS_USER_MAX_DEBT_PERCENT", "101")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "user debt cap cannot exceed 100 percent")
+}
+
+func TestLoadArgs_Blocklist(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "BLOCKLIST_REMAINDER")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "burn", cfg.Blocklist.Remainder)
+
+	t.Setenv("BLOCKLIST_REMAINDER", "redistribute")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "redistribute", cfg.Blocklist.Remainder)
+
+	t.Setenv("BLOCKLIST_REMAINDER", "carry_forward")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_FinalizationChecks(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "FINALIZATION_CHECKS")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "warn", cfg.Finalization.Checks)
+
+	t.Setenv("FINALIZATION_CHECKS", "enforce")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "enforce", cfg.Finalization.Checks)
+
+	t.Setenv("FINALIZATION_CHECKS", "strict")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_SubgraphMinSchemaVersion(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "SUBGRAPH_MIN_SCHEMA_VERSION")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, cfg.Subgraph.MinSchemaVersion)
+
+	t.Setenv("SUBGRAPH_MIN_SCHEMA_VERSION", "2")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, cfg.Subgraph.MinSchemaVersion)
+
+	t.Setenv("SUBGRAPH_MIN_SCHEMA_VERSION", "3")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_Holdings(t *testing.T) {
+	setRequiredEnv(t)
+
+	t.Setenv("HOLDINGS_ERC1155_UNITS", "0x6666666666666666666666666666666666666666:100")
+	t.Setenv("HOLDINGS_WRAPPERS", "0x5555555555555555555555555555555555555555")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	units, err := ParseERC1155Units(cfg.Holdings.ERC1155Units)
+	require.NoError(t, err)
+	assert.Equal(t, "100", units["0x6666666666666666666666666666666666666666"].String())
+	assert.Equal(t, []string{"0x5555555555555555555555555555555555555555"}, cfg.Holdings.Wrappers)
+
+	t.Setenv("HOLDINGS_ERC1155_UNITS", "0x6666666666666666666666666666666666666666:0")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be a positive integer")
+
+	t.Setenv("HOLDINGS_ERC1155_UNITS", "")
+	t.Setenv("HOLDINGS_WRAPPERS", "staking-pool")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "holdings wrapper \"staking-pool\" is not a valid address")
+
+	t.Setenv("HOLDINGS_WRAPPERS", "")
+	t.Setenv("HOLDINGS_ELIGIBILITY", "time_weighted")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "time_weighted", cfg.Holdings.Eligibility)
+
+	t.Setenv("HOLDINGS_WRAPPERS", "0x5555555555555555555555555555555555555555")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot be combined with holdings wrappers")
+}
+
+func TestLoadArgs_LeafEncodings(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "packed", cfg.Merkle.LeafEncoding)
+
+	t.Setenv("MERKLE_LEAF_ENCODING", "abi")
+	t.Setenv("MERKLE_VAULT_LEAF_ENCODINGS", "0x6666666666666666666666666666666666666666:double_hash")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abi", cfg.Merkle.LeafEncoding)
+	encodings, err := ParseVaultLeafEncodings(cfg.Merkle.VaultLeafEncodings)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"0x6666666666666666666666666666666666666666": "double_hash"}, encodings)
+
+	t.Setenv("MERKLE_VAULT_LEAF_ENCODINGS", "0x6666666666666666666666666666666666666666:sha256")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "must be one of packed, abi, double_hash")
+
+	t.Setenv("MERKLE_VAULT_LEAF_ENCODINGS", "")
+	t.Setenv("MERKLE_LEAF_ENCODING", "sha256")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_Minimums(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Minimums.Allocation)
+
+	t.Setenv("MINIMUM_ALLOCATION", "1000000000000000")
+	t.Setenv("MINIMUM_VAULT_ALLOCATIONS", "0x6666666666666666666666666666666666666666:0")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "1000000000000000", cfg.Minimums.Allocation)
+	minimums, err := ParseVaultMinimums(cfg.Minimums.VaultAllocations)
+	require.NoError(t, err)
+	assert.Equal(t, "0", minimums["0x6666666666666666666666666666666666666666"].String())
+
+	t.Setenv("MINIMUM_VAULT_ALLOCATIONS", "0x6666666666666666666666666666666666666666:-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "minimum allocation of vault")
+
+	t.Setenv("MINIMUM_VAULT_ALLOCATIONS", "")
+	t.Setenv("MINIMUM_ALLOCATION", "dust")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "minimum allocation must be")
+}
+
+func TestLoadArgs_IPFS(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Empty(t, cfg.IPFS.APIURL, "nothing is published by default")
+	assert.Equal(t, 30*time.Second, cfg.IPFS.Timeout)
+
+	t.Setenv("IPFS_API_URL", "https://ipfs.example.com:5001")
+	t.Setenv("IPFS_TOKEN", "secret")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://ipfs.example.com:5001", cfg.IPFS.APIURL)
+	assert.Equal(t, "secret", cfg.IPFS.Token)
+
+	t.Setenv("IPFS_API_URL", "ipfs.example.com")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "IPFS API URL must be")
+}
+
+func TestLoadArgs_YieldSources(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lending_manager"}, cfg.Yield.Sources)
+
+	t.Setenv("YIELD_SOURCES", "lending_manager,erc20:0x6666666666666666666666666666666666666666,"+
+		"erc20:0x6666666666666666666666666666666666666666:0x5555555555555555555555555555555555555555")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	sources, err := ParseYieldSources(cfg.Yield.Sources)
+	require.NoError(t, err)
+	assert.Equal(t, []YieldSource{
+		{Kind: YieldSourceLendingManager},
+		{Kind: YieldSourceERC20, Token: "0x6666666666666666666666666666666666666666"},
+		{Kind: YieldSourceERC20, Token: "0x6666666666666666666666666666666666666666", Holder: "0x5555555555555555555555555555555555555555"},
+	}, sources)
+	assert.Equal(t, "erc20:0x6666666666666666666666666666666666666666", sources[1].Name())
+
+	t.Setenv("YIELD_SOURCES", "lending_manager,lending_manager")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "yield source lending_manager is configured twice")
+
+	t.Setenv("YIELD_SOURCES", "erc20:reward-token")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "erc20:token[:holder] with valid addresses")
+
+	t.Setenv("YIELD_SOURCES", "airdrop")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_SimulatedChain(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "RPC_URL")
+	unsetEnv(t, "PRIVATE_KEY")
+	unsetEnv(t, "ETHEREUM_TYPE")
+
+	_, err := LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "RPC URL is required unless the chain is simulated")
+
+	cfg, err := LoadArgs([]string{"--ethereum.type=simulated"})
+	require.NoError(t, err, "a simulated chain needs neither a node nor a key")
+	assert.Equal(t, "simulated", cfg.Ethereum.Type)
+	assert.Equal(t, time.Second, cfg.Ethereum.SimulatedBlockTime)
+
+	_, err = LoadArgs([]string{"--ethereum.type=simulated", "--ethereum.fork-url=http://localhost:8545"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "fork URL cannot be used with a simulated chain")
+
+	t.Setenv("ETHEREUM_TYPE", "anvil")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+}
+
+func TestLoadArgs_ReadOnly(t *testing.T) {
+	setRequiredEnv(t)
+	unsetEnv(t, "PRIVATE_KEY")
+	unsetEnv(t, "SERVER_READ_ONLY")
+
+	_, err := LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "private key is required unless the server is read-only")
+
+	t.Setenv("SERVER_READ_ONLY", "true")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.True(t, cfg.Server.ReadOnly)
+	assert.Empty(t, cfg.Ethereum.PrivateKey)
+}
+
+func TestLoadArgs_Leader(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("LEADER_ELECTION", "true")
+
+	_, err := LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "leader redis address is required")
+
+	t.Setenv("LEADER_REDIS_ADDR", "localhost:6379")
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "redis", cfg.Leader.Backend)
+	assert.Equal(t, "scheduler", cfg.Leader.LeaseName)
+	assert.Equal(t, 30*time.Second, cfg.Leader.TTL)
+
+	t.Setenv("LEADER_BACKEND", "storage")
+	_, err = LoadArgs(nil)
+	require.Error(t, err, "a lease in the server's own database is not shared between replicas")
+
+	t.Setenv("LEADER_BACKEND", "redis")
+	t.Setenv("LEADER_TTL", "1s")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "leader ttl must be at least 3s")
+}
+
+func TestLoadArgs_Backup(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "none", cfg.Backup.Target)
+	assert.Equal(t, 6*time.Hour, cfg.Backup.Interval)
+	assert.Equal(t, 28, cfg.Backup.Retain)
+
+	t.Setenv("BACKUP_TARGET", "gcs")
+	t.Setenv("BACKUP_RETAIN", "0")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "backup bucket is required with the gcs backup target")
+	assert.Contains(t, err.Error(), "backup retain must be at least 1")
+
+	t.Setenv("BACKUP_BUCKET", "epoch-backups")
+	t.Setenv("BACKUP_RETAIN", "7")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "epoch-backups", cfg.Backup.Bucket)
+}
+
+func TestLoadArgs_Faults(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("FAULTS_RPC_ERROR_RATE", "2")
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err, "rates are only checked when faults are enabled")
+	assert.False(t, cfg.Faults.Enabled)
+
+	t.Setenv("FAULTS_ENABLED", "true")
+	t.Setenv("FAULTS_SUBGRAPH_LATENCY", "-1s")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "faults rpc error rate must be between 0 and 1, got 2")
+	assert.Contains(t, err.Error(), "faults latency cannot be negative")
+
+	t.Setenv("FAULTS_RPC_ERROR_RATE", "0.25")
+	t.Setenv("FAULTS_SUBGRAPH_LATENCY", "500ms")
+	t.Setenv("FAULTS_RPC_MATCH", "eth_sendRawTransaction")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 0.25, cfg.Faults.RPCErrorRate)
+	assert.Equal(t, 500*time.Millisecond, cfg.Faults.SubgraphLatency)
+	assert.Equal(t, "eth_sendRawTransaction", cfg.Faults.RPCMatch)
+}
+
+func TestLoadArgs_ReportsEveryProblem(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("VAULT_ADDRESS", "0x6666")
+	t.Setenv("SCHEDULER_INTERVAL", "0s")
+	t.Setenv("WEBHOOK_TIMEOUT", "-1s")
+	t.Setenv("SIGNER_MIN_BALANCE", "lots")
+
+	_, err := LoadArgs(nil)
+	require.Error(t, err)
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 4)
+	assert.Contains(t, err.Error(), "4 problems")
+	assert.Contains(t, err.Error(), `collections vault address "0x6666" is not a valid address`)
+	assert.Contains(t, err.Error(), "scheduler interval must be positive")
+	assert.Contains(t, err.Error(), "webhook timeout must be positive")
+	assert.Contains(t, err.Error(), "signer min balance must be a non-negative integer amount of wei")
+}
+
+func TestLoadTenants(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("DATABASE_TYPE", "badger")
+	t.Setenv("DATABASE_CONNECTION_STRING", "/data/epoch")
+	t.Setenv("SEPOLIA_RPC_URL", "https://rpc.sepolia.example")
+	t.Setenv("SEPOLIA_PRIVATE_KEY", "0x02")
+	t.Setenv("STAGING_EU_VAULT_ADDRESS", "0xAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA")
+	t.Setenv("ARCHIVE_S3_PREFIX", "epochs")
+	t.Setenv("SEPOLIA_ARCHIVE_TARGET", "s3")
+	t.Setenv("SEPOLIA_ARCHIVE_S3_BUCKET", "testnet-analytics")
+	t.Setenv("SEPOLIA_BACKUP_TARGET", "s3")
+	t.Setenv("SEPOLIA_BACKUP_BUCKET", "testnet-backups")
+
+	t.Run("single", func(t *testing.T) {
+		configs, err := LoadTenants(nil)
+		require.NoError(t, err)
+		require.Len(t, configs, 1)
+		assert.Empty(t, configs[0].Tenant)
+		assert.Equal(t, "/data/epoch", configs[0].Database.ConnectionString)
+	})
+
+	t.Run("tenants", func(t *testing.T) {
+		t.Setenv("TENANTS", "Sepolia, staging-eu")
+		configs, err := LoadTenants(nil)
+		require.NoError(t, err)
+		require.Len(t, configs, 2)
+
+		sepolia, staging := configs[0], configs[1]
+		assert.Equal(t, "sepolia", sepolia.Tenant)
+		assert.Equal(t, uint64(11155111), sepolia.Ethereum.ChainID)
+		assert.Equal(t, "https://rpc.sepolia.example", sepolia.Ethereum.RPCURL)
+		assert.Equal(t, "0x02", sepolia.Ethereum.PrivateKey)
+		assert.Equal(t, "/data/epoch/sepolia", sepolia.Database.ConnectionString)
+		assert.Equal(t, "scheduler-sepolia", sepolia.Leader.LeaseName)
+		assert.Equal(t, "testnet-analytics", sepolia.Archive.S3Bucket)
+		assert.Equal(t, "epochs/sepolia", sepolia.Archive.S3Prefix, "tenants archive apart")
+		assert.Equal(t, "testnet-backups", sepolia.Backup.Bucket)
+		assert.Equal(t, "backups/sepolia", sepolia.Backup.Prefix, "tenants back up apart")
+
+		assert.Equal(t, "staging-eu", staging.Tenant)
+		assert.Equal(t, "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", staging.Contracts.CollectionsVault)
+		assert.Equal(t, "https://rpc.default.example", staging.Ethereum.RPCURL, "unset overrides fall back")
+		assert.Equal(t, "/data/epoch/staging-eu", staging.Database.ConnectionString)
+		assert.Equal(t, "none", staging.Archive.Target)
+		assert.Equal(t, filepath.Join("data", "archive", "staging-eu"), staging.Archive.Dir)
+		assert.Equal(t, filepath.Join("data", "backups", "staging-eu"), staging.Backup.Dir)
+	})
+
+	t.Run("sqlite_files", func(t *testing.T) {
+		t.Setenv("DATABASE_TYPE", "sqlite")
+		t.Setenv("DATABASE_CONNECTION_STRING", "/data/epoch.db")
+		configs, err := LoadTenants([]string{"--tenant", "sepolia"})
+		require.NoError(t, err)
+		assert.Equal(t, "/data/epoch-sepolia.db", configs[0].Database.ConnectionString)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := LoadTenants([]string{"--tenant", "sepolia", "--tenant", "sepolia"})
+		assert.ErrorContains(t, err, "listed twice")
+
+		_, err = LoadTenants([]string{"--tenant", "eu_west"})
+		assert.ErrorContains(t, err, "must start with a letter")
+
+		_, err = LoadTenants([]string{"--tenant", "sepolia", "--network", "sepolia"})
+		assert.ErrorContains(t, err, "cannot be selected with tenants")
+
+		t.Setenv("STAGING_EU_VAULT_ADDRESS", "0x6666")
+		_, err = LoadTenants([]string{"--tenant", "staging-eu"})
+		var validationErr *ValidationError
+		require.ErrorAs(t, err, &validationErr)
+		assert.Contains(t, err.Error(), "tenant staging-eu: collections vault address")
+	})
+}
+
+func TestLoadArgs_Confirmations(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := LoadArgs(nil)
+	require.NoError(t, err)
+	confirmations, err := ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth)
+	require.NoError(t, err)
+	assert.Equal(t, Confirmations{Status: 3, MerkleRoot: 3, Snapshot: 6}, confirmations, "without pairs the depths already configured apply")
+
+	t.Setenv("CONFIRMATIONS", "status:1,merkle_root:12")
+	t.Setenv("SEPOLIA_CONFIRMATIONS", "status:2,merkle_root:12,snapshot:30")
+	cfg, err = LoadArgs(nil)
+	require.NoError(t, err)
+	confirmations, err = ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth)
+	require.NoError(t, err)
+	assert.Equal(t, Confirmations{Status: 1, MerkleRoot: 12, Snapshot: 6}, confirmations)
+
+	cfg, err = LoadArgs([]string{"--network", "sepolia"})
+	require.NoError(t, err)
+	confirmations, err = ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth)
+	require.NoError(t, err)
+	assert.Equal(t, Confirmations{Status: 2, MerkleRoot: 12, Snapshot: 30}, confirmations, "every network has depths of its own")
+
+	t.Setenv("CONFIRMATIONS", "finality:12")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "confirmations operation must be one of")
+
+	t.Setenv("CONFIRMATIONS", "snapshot:-1")
+	_, err = LoadArgs(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "confirmations of snapshot must be")
+}
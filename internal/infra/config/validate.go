@@ -0,0 +1,292 @@
+package config
+
+import (
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+)
+
+// ValidationError lists every problem found in a configuration
+type ValidationError struct {
+	Problems []error
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid configuration: " + e.Problems[0].Error()
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "invalid configuration, %d problems:", len(e.Problems))
+	for _, problem := range e.Problems {
+		sb.WriteString("\n  - ")
+		sb.WriteString(problem.Error())
+	}
+	return sb.String()
+}
+
+// Unwrap lets errors.Is and errors.As match any of the problems
+func (e *ValidationError) Unwrap() []error {
+	return e.Problems
+}
+
+// validate returns every problem of a parsed configuration, checks needing the network excluded
+func validate(cfg *Config) []error {
+	var problems []error
+	add := func(err error) {
+		if err != nil {
+			problems = append(problems, err)
+		}
+	}
+
+	// a simulated chain needs no node and generates a signer when none is configured
+	if cfg.Ethereum.Type != "simulated" {
+		if cfg.Ethereum.RPCURL == "" {
+			add(fmt.Errorf("RPC URL is required unless the chain is simulated"))
+		}
+		if cfg.Ethereum.PrivateKey == "" && !cfg.Server.ReadOnly {
+			add(fmt.Errorf("private key is required unless the server is read-only"))
+		}
+	} else if cfg.Ethereum.ForkURL != "" {
+		add(fmt.Errorf("fork URL cannot be used with a simulated chain, which has no network to fork"))
+	}
+
+	if cfg.Server.GRPCPort < 0 || (cfg.Server.GRPCPort != 0 && cfg.Server.GRPCPort == cfg.Server.Port) {
+		add(fmt.Errorf("gRPC port must be 0 or a port other than the server port %d, got %d", cfg.Server.Port, cfg.Server.GRPCPort))
+	}
+
+	if cfg.Idempotency.TTL < 0 || (cfg.Idempotency.TTL > 0 && cfg.Idempotency.LockTTL <= 0) {
+		add(fmt.Errorf("idempotency TTL cannot be negative and lock TTL must be positive, got %s and %s",
+			cfg.Idempotency.TTL, cfg.Idempotency.LockTTL))
+	}
+
+	if n := len(cfg.Webhooks.Secrets); n > 1 && n != len(cfg.Webhooks.URLs) {
+		add(fmt.Errorf("got %d webhook secrets for %d webhook URLs", n, len(cfg.Webhooks.URLs)))
+	}
+
+	if cfg.Archive.Target == "s3" && cfg.Archive.S3Bucket == "" {
+		add(fmt.Errorf("archive S3 bucket is required with the s3 archive target"))
+	}
+	if cfg.IPFS.APIURL != "" {
+		if u, err := url.Parse(cfg.IPFS.APIURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add(fmt.Errorf("IPFS API URL must be an http or https URL, got %q", cfg.IPFS.APIURL))
+		}
+		if cfg.IPFS.Timeout <= 0 {
+			add(fmt.Errorf("IPFS timeout must be positive, got %s", cfg.IPFS.Timeout))
+		}
+	}
+	if cfg.Backup.Target != "none" {
+		if (cfg.Backup.Target == "s3" || cfg.Backup.Target == "gcs") && cfg.Backup.Bucket == "" {
+			add(fmt.Errorf("backup bucket is required with the %s backup target", cfg.Backup.Target))
+		}
+		if cfg.Backup.Interval <= 0 {
+			add(fmt.Errorf("backup interval must be positive, got %s", cfg.Backup.Interval))
+		}
+		if cfg.Backup.Retain < 1 {
+			add(fmt.Errorf("backup retain must be at least 1, got %d", cfg.Backup.Retain))
+		}
+	}
+
+	if cfg.Faults.Enabled {
+		rates := []struct {
+			name  string
+			value float64
+		}{
+			{"subgraph error rate", cfg.Faults.SubgraphErrorRate},
+			{"subgraph partial rate", cfg.Faults.SubgraphPartialRate},
+			{"rpc error rate", cfg.Faults.RPCErrorRate},
+			{"rpc partial rate", cfg.Faults.RPCPartialRate},
+		}
+		for _, rate := range rates {
+			if rate.value < 0 || rate.value > 1 {
+				add(fmt.Errorf("faults %s must be between 0 and 1, got %g", rate.name, rate.value))
+			}
+		}
+		if cfg.Faults.SubgraphLatency < 0 || cfg.Faults.RPCLatency < 0 {
+			add(fmt.Errorf("faults latency cannot be negative"))
+		}
+	}
+
+	if cfg.Repayment.MaxBatchSize < 1 {
+		add(fmt.Errorf("repayment max batch size must be at least 1, got %d", cfg.Repayment.MaxBatchSize))
+	}
+
+	if cfg.Queue.Workers < 1 || cfg.Queue.MaxQueued < 1 {
+		add(fmt.Errorf("queue workers and max queued must be at least 1, got %d and %d", cfg.Queue.Workers, cfg.Queue.MaxQueued))
+	}
+	if cfg.Queue.Timeout <= 0 || cfg.Queue.Retention < 0 {
+		add(fmt.Errorf("queue timeout must be positive and retention non-negative, got %v and %v", cfg.Queue.Timeout, cfg.Queue.Retention))
+	}
+
+	if cfg.Scheduler.CatchUpLimit < 0 {
+		add(fmt.Errorf("scheduler catch-up limit cannot be negative, got %d", cfg.Scheduler.CatchUpLimit))
+	}
+
+	if cfg.Scheduler.RetryBackoff < 0 || cfg.Scheduler.RetryBackoffMax < cfg.Scheduler.RetryBackoff {
+		add(fmt.Errorf("scheduler retry backoff must be between 0 and the max backoff %v, got %v",
+			cfg.Scheduler.RetryBackoffMax, cfg.Scheduler.RetryBackoff))
+	}
+	if cfg.Scheduler.MaxRetries < 0 {
+		add(fmt.Errorf("scheduler max retries cannot be negative, got %d", cfg.Scheduler.MaxRetries))
+	}
+
+	if cfg.Scheduler.Mode == "calendar" {
+		if _, err := ParseCalendar(cfg.Scheduler.Calendar, cfg.Scheduler.Timezone); err != nil {
+			add(err)
+		}
+	}
+
+	add(validateApproval(cfg))
+	add(validateLeader(cfg))
+	add(validateCaps(cfg))
+	problems = append(problems, validateHoldings(cfg)...)
+	if _, err := ParseVaultLeafEncodings(cfg.Merkle.VaultLeafEncodings); err != nil {
+		add(err)
+	}
+	if _, err := ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth); err != nil {
+		add(err)
+	}
+
+	if minBalance := cfg.Signer.MinBalance; minBalance != "" {
+		if n, ok := new(big.Int).SetString(minBalance, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("signer min balance must be a non-negative integer amount of wei, got %q", minBalance))
+		}
+	}
+
+	if budget := cfg.Gas.MonthlyBudget; budget != "" {
+		if n, ok := new(big.Int).SetString(budget, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("gas monthly budget must be a non-negative integer amount of wei, got %q", budget))
+		}
+	}
+
+	if maxAmount := cfg.Adjustments.MaxAmount; maxAmount != "" {
+		if n, ok := new(big.Int).SetString(maxAmount, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("adjustments max amount must be a non-negative integer amount of wei, got %q", maxAmount))
+		}
+	}
+
+	if minimum := cfg.Minimums.Allocation; minimum != "" {
+		if n, ok := new(big.Int).SetString(minimum, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("minimum allocation must be a non-negative integer amount of wei, got %q", minimum))
+		}
+	}
+	if _, err := ParseVaultMinimums(cfg.Minimums.VaultAllocations); err != nil {
+		add(err)
+	}
+
+	if threshold := cfg.Stats.DustThreshold; threshold != "" {
+		if n, ok := new(big.Int).SetString(threshold, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("stats dust threshold must be a non-negative integer amount of wei, got %q", threshold))
+		}
+	}
+
+	if tolerance := cfg.Reconciliation.Tolerance; tolerance != "" {
+		if n, ok := new(big.Int).SetString(tolerance, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("reconciliation tolerance must be a non-negative integer amount of wei, got %q", tolerance))
+		}
+	}
+
+	if cfg.Yield.ReservePercent > 100 {
+		add(fmt.Errorf("yield reserve percent must be between 0 and 100, got %d", cfg.Yield.ReservePercent))
+	}
+	if reserveMin := cfg.Yield.ReserveMin; reserveMin != "" {
+		if n, ok := new(big.Int).SetString(reserveMin, 10); !ok || n.Sign() < 0 {
+			add(fmt.Errorf("yield reserve min must be a non-negative integer amount of wei, got %q", reserveMin))
+		}
+	}
+	if _, err := ParseYieldSources(cfg.Yield.Sources); err != nil {
+		add(err)
+	}
+
+	problems = append(problems, validateAddresses(cfg)...)
+	problems = append(problems, validateIntervals(cfg)...)
+	return problems
+}
+
+// validateAddresses checks every configured contract address is a 0x-prefixed 20 byte hex address
+func validateAddresses(cfg *Config) []error {
+	var problems []error
+	for _, contract := range ContractAddresses(cfg) {
+		if !utils.IsValidAddress(contract.Address) {
+			problems = append(problems, fmt.Errorf("%s address %q is not a valid address", contract.Name, contract.Address))
+		}
+	}
+	return problems
+}
+
+// validateIntervals checks durations are positive where zero would spin or time out at once
+func validateIntervals(cfg *Config) []error {
+	var problems []error
+	positive := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"subgraph timeout", cfg.Subgraph.Timeout},
+		{"block poll interval", cfg.Ethereum.BlockPollInterval},
+		{"receipt timeout", cfg.Ethereum.ReceiptTimeout},
+		{"webhook timeout", cfg.Webhooks.Timeout},
+	}
+	for _, d := range positive {
+		if d.value <= 0 {
+			problems = append(problems, fmt.Errorf("%s must be positive, got %s", d.name, d.value))
+		}
+	}
+
+	nonNegative := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"subgraph cache ttl", cfg.Subgraph.CacheTTL},
+		{"subgraph cache block ttl", cfg.Subgraph.CacheBlockTTL},
+		{"subgraph cache stale ttl", cfg.Subgraph.CacheStaleTTL},
+		{"webhook retry backoff", cfg.Webhooks.RetryBackoff},
+	}
+	for _, d := range nonNegative {
+		if d.value < 0 {
+			problems = append(problems, fmt.Errorf("%s cannot be negative, got %s", d.name, d.value))
+		}
+	}
+
+	if cfg.Discovery.Enabled && cfg.Discovery.Interval <= 0 {
+		problems = append(problems, fmt.Errorf("vault discovery interval must be positive, got %s", cfg.Discovery.Interval))
+	}
+	if cfg.Scheduler.Mode == "interval" && cfg.Scheduler.Interval <= 0 {
+		problems = append(problems, fmt.Errorf("scheduler interval must be positive, got %s", cfg.Scheduler.Interval))
+	}
+	if size := cfg.Subgraph.PaginationSize; size < 1 || size > 1000 {
+		problems = append(problems, fmt.Errorf("subgraph pagination size must be between 1 and 1000, got %d", size))
+	}
+	return problems
+}
+
+// ContractAddress is a configured contract and its address
+type ContractAddress struct {
+	Name    string
+	Address string
+}
+
+// ContractAddresses lists the configured contract addresses, optional ones only when set
+func ContractAddresses(cfg *Config) []ContractAddress {
+	contracts := []ContractAddress{
+		{"comptroller", cfg.Contracts.Comptroller},
+		{"epoch manager", cfg.Contracts.EpochManager},
+		{"debt subsidizer", cfg.Contracts.DebtSubsidizer},
+		{"lending manager", cfg.Contracts.LendingManager},
+		{"collection registry", cfg.Contracts.CollectionRegistry},
+		{"collections vault", cfg.Contracts.CollectionsVault},
+	}
+	optional := []ContractAddress{
+		{"asset", cfg.Contracts.Asset},
+		{"nft", cfg.Contracts.NFT},
+		{"ctoken", cfg.Contracts.CToken},
+	}
+	for _, contract := range optional {
+		if contract.Address != "" {
+			contracts = append(contracts, contract)
+		}
+	}
+	return contracts
+}
@@ -0,0 +1,7 @@
+//go:build !faults
+
+package faults
+
+// Available reports whether this build can inject faults, production builds leave the tag out so no
+// configuration can turn fault injection on
+const Available = false
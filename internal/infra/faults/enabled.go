@@ -0,0 +1,6 @@
+//go:build faults
+
+package faults
+
+// Available reports whether this build can inject faults, it is built with -tags faults
+const Available = true
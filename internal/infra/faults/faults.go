@@ -0,0 +1,200 @@
+// Package faults injects latency, errors and partial responses into the HTTP requests the server makes to its
+// dependencies, so scheduler and distribution resilience can be tested on purpose rather than during outages.
+// Faults are only injected by builds made with -tags faults.
+package faults
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+)
+
+// ErrUnavailable is returned when faults are configured in a build without the faults tag
+var ErrUnavailable = errors.New("fault injection is not built in, build with -tags faults")
+
+// InjectedStatus is the status of a response failed by an injected error
+const InjectedStatus = http.StatusServiceUnavailable
+
+// injectedBody is the body of a response failed by an injected error
+const injectedBody = "fault injected"
+
+// Rule is what is injected into a dependency's requests
+type Rule struct {
+	Latency     time.Duration // added before every matching request
+	ErrorRate   float64       // share of matching requests failed with InjectedStatus, 0 to 1
+	PartialRate float64       // share of matching responses cut short, 0 to 1
+	Match       string        // only requests whose body contains it are faulted, every request when empty
+}
+
+// Active reports whether the rule injects anything
+func (r Rule) Active() bool {
+	return r.Latency > 0 || r.ErrorRate > 0 || r.PartialRate > 0
+}
+
+// PartialFunc returns a partial copy of a response body
+type PartialFunc func(body []byte) []byte
+
+// Transport injects a rule's faults into the requests it forwards
+type Transport struct {
+	base    http.RoundTripper
+	name    string
+	rule    Rule
+	partial PartialFunc
+	logger  lgr.L
+	sleep   func(req *http.Request, d time.Duration) error
+
+	mu     sync.Mutex
+	random *rand.Rand
+}
+
+// NewTransport returns a transport injecting rule's faults into the requests to the dependency called name
+// before forwarding them to base, the default transport when nil. Responses picked for a partial response are
+// passed through partial. A seed of zero seeds the random source from the clock.
+func NewTransport(name string, base http.RoundTripper, rule Rule, partial PartialFunc, seed uint64, logger lgr.L) (*Transport, error) {
+	if !Available {
+		return nil, ErrUnavailable
+	}
+	return newTransport(name, base, rule, partial, seed, logger), nil
+}
+
+func newTransport(name string, base http.RoundTripper, rule Rule, partial PartialFunc, seed uint64, logger lgr.L) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	return &Transport{
+		base:    base,
+		name:    name,
+		rule:    rule,
+		partial: partial,
+		logger:  logger,
+		sleep:   sleepContext,
+		random:  rand.New(rand.NewPCG(seed, seed>>1|1)),
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	matches, err := t.matches(req)
+	if err != nil {
+		return nil, err
+	}
+	if !matches {
+		return t.base.RoundTrip(req)
+	}
+
+	if t.rule.Latency > 0 {
+		if err := t.sleep(req, t.rule.Latency); err != nil {
+			return nil, err
+		}
+	}
+	if t.roll(t.rule.ErrorRate) {
+		t.logger.Logf("INFO injected %d into %s request", InjectedStatus, t.name)
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", InjectedStatus, http.StatusText(InjectedStatus)),
+			StatusCode:    InjectedStatus,
+			Proto:         req.Proto,
+			ProtoMajor:    req.ProtoMajor,
+			ProtoMinor:    req.ProtoMinor,
+			Header:        http.Header{"Content-Type": []string{"text/plain"}},
+			Body:          io.NopCloser(strings.NewReader(injectedBody)),
+			ContentLength: int64(len(injectedBody)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK || !t.roll(t.rule.PartialRate) {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	partial := t.partial(body)
+	t.logger.Logf("INFO injected partial %s response, %d of %d bytes", t.name, len(partial), len(body))
+	resp.Body = io.NopCloser(bytes.NewReader(partial))
+	resp.ContentLength = int64(len(partial))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+// matches reports whether the request is faulted, reading its body for the rule's match and restoring it
+func (t *Transport) matches(req *http.Request) (bool, error) {
+	if t.rule.Match == "" {
+		return true, nil
+	}
+	if req.Body == nil {
+		return false, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if err != nil {
+		return false, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return bytes.Contains(body, []byte(t.rule.Match)), nil
+}
+
+// roll reports whether an event of the given probability happens
+func (t *Transport) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.random.Float64() < rate
+}
+
+// sleepContext waits d unless the request is cancelled first
+func sleepContext(req *http.Request, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-req.Context().Done():
+		return req.Context().Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// TruncateBody keeps the first half of the body, like a connection dropped mid-response
+func TruncateBody(body []byte) []byte {
+	return body[:len(body)/2]
+}
+
+// TruncateLists keeps the first half of every list in a GraphQL response's data, like a subgraph returning
+// fewer entities than it indexed. A paginated query reads the short page as its last. Bodies that are not
+// GraphQL responses are truncated instead.
+func TruncateLists(body []byte) []byte {
+	var response map[string]json.RawMessage
+	if err := json.Unmarshal(body, &response); err != nil {
+		return TruncateBody(body)
+	}
+	var data map[string]json.RawMessage
+	if err := json.Unmarshal(response["data"], &data); err != nil || data == nil {
+		return TruncateBody(body)
+	}
+	for field, value := range data {
+		var list []json.RawMessage
+		if err := json.Unmarshal(value, &list); err != nil {
+			continue
+		}
+		truncated, _ := json.Marshal(list[:len(list)/2])
+		data[field] = truncated
+	}
+	response["data"], _ = json.Marshal(data)
+	partial, _ := json.Marshal(response)
+	return partial
+}
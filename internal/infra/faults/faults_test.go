@@ -0,0 +1,143 @@
+package faults
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const graphQLResponse = `{"data":{"accountSubsidies":[{"id":"1"},{"id":"2"},{"id":"3"},{"id":"4"}],"_meta":{"block":{"number":7}}}}`
+
+func post(t *testing.T, client *http.Client, url, body string) (*http.Response, []byte) {
+	t.Helper()
+
+	resp, err := client.Post(url, "application/json", strings.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, data
+}
+
+func TestTransport_Latency(t *testing.T) {
+	var slept []time.Duration
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	transport := newTransport("rpc", nil, Rule{Latency: 2 * time.Second}, TruncateBody, 1, lgr.NoOp)
+	transport.sleep = func(_ *http.Request, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	resp, _ := post(t, &http.Client{Transport: transport}, server.URL, "{}")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []time.Duration{2 * time.Second}, slept)
+}
+
+func TestTransport_LatencyHonoursCancellation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	transport := newTransport("rpc", nil, Rule{Latency: time.Hour}, TruncateBody, 1, lgr.NoOp)
+
+	client := &http.Client{Transport: transport, Timeout: 50 * time.Millisecond}
+	_, err := client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	require.Error(t, err)
+}
+
+func TestTransport_Errors(t *testing.T) {
+	served := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { served++ }))
+	defer server.Close()
+	transport := newTransport("rpc", nil, Rule{ErrorRate: 1}, TruncateBody, 1, lgr.NoOp)
+
+	resp, body := post(t, &http.Client{Transport: transport}, server.URL, "{}")
+	assert.Equal(t, InjectedStatus, resp.StatusCode)
+	assert.Equal(t, injectedBody, string(body))
+	assert.Zero(t, served, "a failed request never reaches the dependency")
+}
+
+func TestTransport_ErrorRate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	client := &http.Client{Transport: newTransport("rpc", nil, Rule{ErrorRate: 0.3}, TruncateBody, 42, lgr.NoOp)}
+
+	failed := 0
+	for i := 0; i < 200; i++ {
+		if resp, _ := post(t, client, server.URL, "{}"); resp.StatusCode == InjectedStatus {
+			failed++
+		}
+	}
+	assert.InDelta(t, 60, failed, 25)
+
+	// the same seed fails the same requests
+	again := &http.Client{Transport: newTransport("rpc", nil, Rule{ErrorRate: 0.3}, TruncateBody, 42, lgr.NoOp)}
+	repeated := 0
+	for i := 0; i < 200; i++ {
+		if resp, _ := post(t, again, server.URL, "{}"); resp.StatusCode == InjectedStatus {
+			repeated++
+		}
+	}
+	assert.Equal(t, failed, repeated)
+}
+
+func TestTransport_Partial(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, graphQLResponse)
+	}))
+	defer server.Close()
+	transport := newTransport("subgraph", nil, Rule{PartialRate: 1}, TruncateLists, 1, lgr.NoOp)
+
+	resp, body := post(t, &http.Client{Transport: transport}, server.URL, `{"query":"{ accountSubsidies { id } }"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var decoded struct {
+		Data struct {
+			AccountSubsidies []struct{ ID string } `json:"accountSubsidies"`
+			Meta             json.RawMessage       `json:"_meta"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	assert.Len(t, decoded.Data.AccountSubsidies, 2)
+	assert.JSONEq(t, `{"block":{"number":7}}`, string(decoded.Data.Meta), "objects are kept whole")
+}
+
+func TestTransport_Match(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+	client := &http.Client{Transport: newTransport("rpc", nil, Rule{ErrorRate: 1, Match: "eth_sendRawTransaction"},
+		TruncateBody, 1, lgr.NoOp)}
+
+	resp, body := post(t, client, server.URL, `{"method":"eth_blockNumber"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `{"method":"eth_blockNumber"}`, string(body), "the body read for matching is forwarded")
+
+	resp, _ = post(t, client, server.URL, `{"method":"eth_sendRawTransaction"}`)
+	assert.Equal(t, InjectedStatus, resp.StatusCode)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", string(TruncateBody([]byte("abcdef"))))
+	assert.Equal(t, "not j", string(TruncateLists([]byte("not json!!"))), "bodies that are not GraphQL are cut")
+	assert.JSONEq(t, `{"data":{"a":[1],"b":null}}`, string(TruncateLists([]byte(`{"data":{"a":[1,2,3],"b":null}}`))))
+}
+
+func TestNewTransport(t *testing.T) {
+	transport, err := NewTransport("rpc", nil, Rule{ErrorRate: 1}, TruncateBody, 0, lgr.NoOp)
+	if !Available {
+		assert.ErrorIs(t, err, ErrUnavailable)
+		return
+	}
+	require.NoError(t, err)
+	assert.NotNil(t, transport)
+}
@@ -0,0 +1,159 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/go-pkgz/lgr"
+)
+
+// field names the correlation fields are logged with
+const (
+	fieldRequestID = "request_id"
+	fieldEpochID   = "epoch_id"
+	fieldVault     = "vault"
+	fieldTxHash    = "tx_hash"
+	fieldTenant    = "tenant"
+)
+
+// levels are the lgr level prefixes a text line's fields are inserted after
+var levels = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "PANIC", "FATAL"}
+
+// Fields correlate the lines logged while handling one request or scheduler run
+type Fields struct {
+	RequestID string // X-Request-ID of an API request, or generated for a scheduler run
+	EpochID   string
+	Vault     string
+	TxHash    string
+}
+
+type fieldsKey struct{}
+
+// WithFields returns ctx carrying fields over the ones ctx already carries. Empty fields keep the value ctx has.
+func WithFields(ctx context.Context, fields Fields) context.Context {
+	current := FieldsFromContext(ctx)
+	if fields.RequestID != "" {
+		current.RequestID = fields.RequestID
+	}
+	if fields.EpochID != "" {
+		current.EpochID = fields.EpochID
+	}
+	if fields.Vault != "" {
+		current.Vault = fields.Vault
+	}
+	if fields.TxHash != "" {
+		current.TxHash = fields.TxHash
+	}
+	return context.WithValue(ctx, fieldsKey{}, current)
+}
+
+// FieldsFromContext returns the fields ctx carries
+func FieldsFromContext(ctx context.Context) Fields {
+	fields, _ := ctx.Value(fieldsKey{}).(Fields)
+	return fields
+}
+
+// NewRequestID returns a random id correlating the lines of work no API request started
+func NewRequestID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// FromContext returns a logger adding the fields ctx carries to every line: as attributes of JSON lines and as
+// key=value pairs after the level of text lines. Loggers NewWithConfig did not create are returned as they are.
+func FromContext(ctx context.Context, logger lgr.L) lgr.L {
+	structured, ok := logger.(*structuredLogger)
+	if !ok {
+		return logger
+	}
+	attrs := FieldsFromContext(ctx).attrs()
+	if len(attrs) == 0 {
+		return logger
+	}
+	return structured.with(attrs)
+}
+
+// ForTenant returns a logger adding the tenant to every line, loggers FromContext builds from it add it too.
+// Loggers NewWithConfig did not create are returned as they are.
+func ForTenant(logger lgr.L, tenant string) lgr.L {
+	structured, ok := logger.(*structuredLogger)
+	if !ok || tenant == "" {
+		return logger
+	}
+	return structured.with([]slog.Attr{slog.String(fieldTenant, tenant)})
+}
+
+func (f Fields) attrs() []slog.Attr {
+	var attrs []slog.Attr
+	for _, field := range []struct{ key, value string }{
+		{fieldRequestID, f.RequestID},
+		{fieldEpochID, f.EpochID},
+		{fieldVault, f.Vault},
+		{fieldTxHash, f.TxHash},
+	} {
+		if field.value != "" {
+			attrs = append(attrs, slog.String(field.key, field.value))
+		}
+	}
+	return attrs
+}
+
+// structuredLogger is the logger NewWithConfig returns. It keeps what it was built with so FromContext can build
+// one logging the same way with fields added.
+type structuredLogger struct {
+	lgr.L
+	options     []lgr.Option
+	jsonHandler slog.Handler // nil for text output
+	callerDepth int
+	attrs       []slog.Attr // fields every line carries
+}
+
+func newStructuredLogger(options []lgr.Option, jsonHandler slog.Handler, callerDepth int) *structuredLogger {
+	l := &structuredLogger{options: options, jsonHandler: jsonHandler, callerDepth: callerDepth}
+	if jsonHandler != nil {
+		l.L = lgr.New(append(slices.Clip(options), lgr.SlogHandler(jsonHandler))...)
+	} else {
+		l.L = lgr.New(options...)
+	}
+	return l
+}
+
+// with returns a logger adding attrs to the fields l adds to every line
+func (l *structuredLogger) with(attrs []slog.Attr) *structuredLogger {
+	attrs = append(slices.Clip(l.attrs), attrs...)
+	with := &structuredLogger{options: l.options, jsonHandler: l.jsonHandler, callerDepth: l.callerDepth, attrs: attrs}
+	if l.jsonHandler != nil {
+		with.L = lgr.New(append(slices.Clip(l.options), lgr.SlogHandler(l.jsonHandler.WithAttrs(attrs)))...)
+		return with
+	}
+
+	pairs := make([]string, len(attrs))
+	for i, attr := range attrs {
+		pairs[i] = attr.Key + "=" + attr.Value.String()
+	}
+	prefix := strings.Join(pairs, " ")
+	// lgr.Func.Logf and the func it calls are two more frames between the caller and lgr
+	logger := lgr.New(append(slices.Clip(l.options), lgr.CallerDepth(l.callerDepth+2))...)
+	with.L = lgr.Func(func(format string, args ...interface{}) {
+		msg := format
+		if len(args) > 0 {
+			msg = fmt.Sprintf(format, args...)
+		}
+		for _, level := range levels {
+			if rest, ok := strings.CutPrefix(msg, level+" "); ok {
+				logger.Logf("%s %s %s", level, prefix, rest)
+				return
+			}
+		}
+		logger.Logf("%s %s", prefix, msg)
+	})
+	return with
+}
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFields(t *testing.T) {
+	ctx := WithFields(context.Background(), Fields{RequestID: "req-1", Vault: "0xvault"})
+	ctx = WithFields(ctx, Fields{EpochID: "7", Vault: "0xother"})
+
+	assert.Equal(t, Fields{RequestID: "req-1", EpochID: "7", Vault: "0xother"}, FieldsFromContext(ctx))
+	assert.Equal(t, Fields{}, FieldsFromContext(context.Background()))
+	assert.Len(t, NewRequestID(), 16)
+}
+
+func TestFromContext_JSON(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "json.log")
+	logger, err := NewWithConfig(Config{Level: "info", Format: "json", Output: logFile})
+	require.NoError(t, err)
+
+	ctx := WithFields(context.Background(), Fields{RequestID: "req-1", EpochID: "7", Vault: "0xvault", TxHash: "0xtx"})
+	FromContext(ctx, logger).Logf("WARN transaction %s is slow", "0xtx")
+	FromContext(context.Background(), logger).Logf("INFO no fields")
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	require.Len(t, lines, 2)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "WARN", line["level"])
+	assert.Equal(t, "transaction 0xtx is slow", line["msg"])
+	assert.Equal(t, "req-1", line["request_id"])
+	assert.Equal(t, "7", line["epoch_id"])
+	assert.Equal(t, "0xvault", line["vault"])
+	assert.Equal(t, "0xtx", line["tx_hash"])
+
+	var plain map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &plain))
+	assert.NotContains(t, plain, "request_id")
+}
+
+func TestFromContext_Text(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "text.log")
+	logger, err := NewWithConfig(Config{Level: "info", Format: "text", Output: logFile})
+	require.NoError(t, err)
+
+	ctx := WithFields(context.Background(), Fields{RequestID: "req-1", Vault: "0xvault"})
+	FromContext(ctx, logger).Logf("INFO distributing %d accounts", 3)
+	FromContext(ctx, logger).Logf("DEBUG filtered out at info")
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[INFO]  request_id=req-1 vault=0xvault distributing 3 accounts")
+	assert.NotContains(t, string(content), "filtered out")
+
+	// loggers built elsewhere log without the fields
+	assert.NotNil(t, FromContext(ctx, lgr.NoOp))
+}
+
+func TestForTenant(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "tenant.log")
+	logger, err := NewWithConfig(Config{Level: "debug", Format: "text", Output: logFile})
+	require.NoError(t, err)
+
+	tenantLogger := ForTenant(logger, "sepolia")
+	tenantLogger.Logf("INFO scheduler started")
+	ctx := WithFields(context.Background(), Fields{RequestID: "req-1"})
+	FromContext(ctx, tenantLogger).Logf("INFO serving request")
+
+	content, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "[INFO]  {logging/context_test.go:")
+	assert.Contains(t, string(content), "tenant=sepolia scheduler started")
+	assert.Contains(t, string(content), "tenant=sepolia request_id=req-1 serving request")
+
+	assert.Same(t, logger, ForTenant(logger, ""))
+}
@@ -88,10 +88,10 @@ func NewWithConfig(cfg Config) (lgr.L, error) {
 	}
 
 	// JSON format uses slog handler for structured logging
+	var jsonHandler slog.Handler
 	switch strings.ToLower(cfg.Format) {
 	case formatJSON:
-		jsonHandler := createJSONHandler(cfg, output)
-		options = append(options, lgr.SlogHandler(jsonHandler))
+		jsonHandler = createJSONHandler(cfg, output)
 	default:
 		options = append(options, lgr.LevelBraces, lgr.Out(output))
 	}
@@ -136,7 +136,7 @@ func NewWithConfig(cfg Config) (lgr.L, error) {
 		}
 	}
 
-	return lgr.New(options...), nil
+	return newStructuredLogger(options, jsonHandler, cfg.CallerDepth), nil
 }
 
 // createJSONHandler creates a slog JSON handler with mapped levels and custom attributes
@@ -0,0 +1,64 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// ContentType is the Prometheus text exposition format written by Registry.Write
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Registry holds gauges and renders them in the Prometheus text exposition format
+type Registry struct {
+	mu     sync.RWMutex
+	gauges map[string]gauge
+}
+
+type gauge struct {
+	help  string
+	value float64
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{gauges: make(map[string]gauge)}
+}
+
+// SetGauge sets the value of the named gauge, registering it on first use
+func (r *Registry) SetGauge(name, help string, value float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gauges[name] = gauge{help: help, value: value}
+}
+
+// Gauge returns the current value of the named gauge and whether it was set
+func (r *Registry) Gauge(name string) (float64, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	g, ok := r.gauges[name]
+	return g.value, ok
+}
+
+// Write renders every gauge sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.gauges))
+	for name := range r.gauges {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		g := r.gauges[name]
+		value := strconv.FormatFloat(g.value, 'g', -1, 64)
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %s\n", name, g.help, name, name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	registry := NewRegistry()
+	registry.SetGauge("b_gauge", "Second gauge", 1e18)
+	registry.SetGauge("a_gauge", "First gauge", 1)
+	registry.SetGauge("a_gauge", "First gauge", 0)
+
+	var sb strings.Builder
+	require.NoError(t, registry.Write(&sb))
+	assert.Equal(t, "# HELP a_gauge First gauge\n# TYPE a_gauge gauge\na_gauge 0\n"+
+		"# HELP b_gauge Second gauge\n# TYPE b_gauge gauge\nb_gauge 1e+18\n", sb.String())
+
+	value, ok := registry.Gauge("a_gauge")
+	assert.True(t, ok)
+	assert.Zero(t, value)
+	_, ok = registry.Gauge("missing")
+	assert.False(t, ok)
+}
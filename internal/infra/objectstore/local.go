@@ -0,0 +1,100 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tempPrefix starts the names of the files Put writes before renaming them, List skips them
+const tempPrefix = ".objectstore-"
+
+// Local stores objects as files under a directory
+type Local struct {
+	dir string
+}
+
+// NewLocal returns a store keeping objects under dir, which is created on the first Put
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+// Put writes the file next to its destination and renames it
+func (s *Local) Put(_ context.Context, key, _ string, body io.ReadSeeker) error {
+	dest := s.Location(key)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+func (s *Local) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	file, err := os.Open(s.Location(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return file, err
+}
+
+func (s *Local) List(_ context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(s.dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == s.dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		objects = append(objects, Object{Key: key, Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+func (s *Local) Delete(_ context.Context, key string) error {
+	err := os.Remove(s.Location(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *Local) Location(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
@@ -0,0 +1,34 @@
+// Package objectstore keeps files under slash-separated keys in a local directory or an S3 bucket. Buckets of
+// S3-compatible stores, like MinIO or Google Cloud Storage through its XML API, are reached with a custom endpoint.
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// Store keeps objects under slash-separated keys
+type Store interface {
+	// Put stores body under key, replacing what was there. Readers never see a partial object.
+	Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error
+	// Get opens the object under key, ErrNotFound when there is none
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose key starts with prefix, ordered by key
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object under key, deleting a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// Location is where the object under key is stored, as other tools open it
+	Location(key string) string
+}
+
+// Object is a stored object
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
@@ -0,0 +1,155 @@
+package objectstore
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3 serves the object operations of one bucket, addressed path-style
+type fakeS3 struct {
+	mu      sync.Mutex
+	bucket  string
+	objects map[string][]byte
+}
+
+type listResult struct {
+	XMLName     xml.Name      `xml:"ListBucketResult"`
+	Name        string        `xml:"Name"`
+	Prefix      string        `xml:"Prefix"`
+	KeyCount    int           `xml:"KeyCount"`
+	IsTruncated bool          `xml:"IsTruncated"`
+	Contents    []listContent `xml:"Contents"`
+}
+
+type listContent struct {
+	Key          string `xml:"Key"`
+	Size         int    `xml:"Size"`
+	LastModified string `xml:"LastModified"`
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key, ok := strings.CutPrefix(r.URL.Path, "/"+f.bucket)
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	key = strings.TrimPrefix(key, "/")
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		result := listResult{Name: f.bucket, Prefix: r.URL.Query().Get("prefix")}
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, result.Prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			result.Contents = append(result.Contents, listContent{Key: k, Size: len(f.objects[k]), LastModified: "2026-10-16T12:00:00.000Z"})
+		}
+		result.KeyCount = len(keys)
+		w.Header().Set("Content-Type", "application/xml")
+		_ = xml.NewEncoder(w).Encode(result)
+	case r.Method == http.MethodPut:
+		f.objects[key], _ = io.ReadAll(r.Body)
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>`)
+			return
+		}
+		_, _ = w.Write(data)
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// testStore runs the operations every store supports
+func testStore(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	_, err := store.Get(ctx, "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+	objects, err := store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+
+	require.NoError(t, store.Put(ctx, "b/two", "text/plain", strings.NewReader("22")))
+	require.NoError(t, store.Put(ctx, "a-one", "text/plain", strings.NewReader("1")))
+	require.NoError(t, store.Put(ctx, "a-one", "text/plain", strings.NewReader("111")))
+
+	body, err := store.Get(ctx, "a-one")
+	require.NoError(t, err)
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	require.NoError(t, body.Close())
+	assert.Equal(t, "111", string(data), "put replaces the object")
+
+	objects, err = store.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, objects, 2)
+	assert.Equal(t, "a-one", objects[0].Key)
+	assert.Equal(t, int64(3), objects[0].Size)
+	assert.False(t, objects[0].Modified.IsZero())
+	assert.Equal(t, "b/two", objects[1].Key)
+
+	objects, err = store.List(ctx, "a-")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+
+	require.NoError(t, store.Delete(ctx, "a-one"))
+	require.NoError(t, store.Delete(ctx, "a-one"), "deleting a missing object is not an error")
+	objects, err = store.List(ctx, "")
+	require.NoError(t, err)
+	assert.Len(t, objects, 1)
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocal(dir)
+	testStore(t, store)
+	assert.Equal(t, filepath.Join(dir, "b", "two"), store.Location("b/two"))
+
+	// a directory that was never written to lists nothing
+	objects, err := NewLocal(filepath.Join(dir, "missing")).List(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, objects)
+}
+
+func TestS3(t *testing.T) {
+	fake := &fakeS3{bucket: "backups", objects: map[string][]byte{"other/file": []byte("x")}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	store, err := NewS3(context.Background(), S3Config{Bucket: "backups", Prefix: "epochs", Region: "us-east-1", Endpoint: server.URL})
+	require.NoError(t, err)
+	testStore(t, store)
+	assert.Equal(t, "s3://backups/epochs/b/two", store.Location("b/two"))
+	assert.Contains(t, fake.objects, "epochs/b/two", "keys are stored under the prefix")
+	assert.Contains(t, fake.objects, "other/file", "objects outside the prefix are left alone")
+
+	gcs, err := NewS3(context.Background(), S3Config{Bucket: "backups", Region: "auto", Endpoint: GCSEndpoint})
+	require.NoError(t, err)
+	assert.Equal(t, "gs://backups/key", gcs.Location("key"))
+}
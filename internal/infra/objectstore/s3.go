@@ -0,0 +1,130 @@
+package objectstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// GCSEndpoint is the S3-compatible XML API of Google Cloud Storage, reached with HMAC keys as AWS credentials
+const GCSEndpoint = "https://storage.googleapis.com"
+
+// S3Config selects the bucket a store keeps objects in
+type S3Config struct {
+	Bucket   string
+	Prefix   string // prepended to every key
+	Region   string // read from the AWS environment when empty
+	Endpoint string // of an S3-compatible store, empty for AWS
+}
+
+// S3 stores objects in an S3 bucket, or a bucket of an S3-compatible store
+type S3 struct {
+	client *s3.Client
+	bucket string
+	prefix string
+	scheme string // of locations, gs for Google Cloud Storage
+}
+
+// NewS3 returns a store keeping objects in the configured bucket, credentials are read from the AWS environment
+func NewS3(ctx context.Context, cfg S3Config) (*S3, error) {
+	var opts []func(*awsconfig.LoadOptions) error
+	if cfg.Region != "" {
+		opts = append(opts, awsconfig.WithRegion(cfg.Region))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	client := s3.NewFromConfig(awsCfg, func(o *s3.Options) {
+		if cfg.Endpoint != "" {
+			// S3-compatible stores serve buckets under the path rather than as subdomains, and not all of them
+			// accept the checksums AWS adds to every request
+			o.BaseEndpoint = aws.String(cfg.Endpoint)
+			o.UsePathStyle = true
+			o.RequestChecksumCalculation = aws.RequestChecksumCalculationWhenRequired
+			o.ResponseChecksumValidation = aws.ResponseChecksumValidationWhenRequired
+		}
+	})
+	scheme := "s3"
+	if cfg.Endpoint == GCSEndpoint {
+		scheme = "gs"
+	}
+	return &S3{client: client, bucket: cfg.Bucket, prefix: cfg.Prefix, scheme: scheme}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key, contentType string, body io.ReadSeeker) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(s.objectKey(key)),
+		Body:        body,
+		ContentType: aws.String(contentType),
+	})
+	return err
+}
+
+func (s *S3) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	output, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	var noSuchKey *types.NoSuchKey
+	if errors.As(err, &noSuchKey) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return output.Body, nil
+}
+
+func (s *S3) List(ctx context.Context, prefix string) ([]Object, error) {
+	// the bucket prefix is a directory, so a key prefix does not match its siblings
+	bucketPrefix := s.objectKey("")
+	if bucketPrefix != "" {
+		bucketPrefix += "/"
+	}
+	var objects []Object
+	pages := s3.NewListObjectsV2Paginator(s.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(bucketPrefix + prefix),
+	})
+	for pages.HasMorePages() {
+		page, err := pages.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range page.Contents {
+			objects = append(objects, Object{
+				Key:      strings.TrimPrefix(aws.ToString(object.Key), bucketPrefix),
+				Size:     aws.ToInt64(object.Size),
+				Modified: aws.ToTime(object.LastModified),
+			})
+		}
+	}
+	return objects, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.objectKey(key)),
+	})
+	return err
+}
+
+func (s *S3) Location(key string) string {
+	return s.scheme + "://" + s.bucket + "/" + s.objectKey(key)
+}
+
+// objectKey is the key under the store's prefix
+func (s *S3) objectKey(key string) string {
+	return path.Join(s.prefix, key)
+}
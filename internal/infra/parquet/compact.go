@@ -0,0 +1,94 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// thrift compact protocol types of the fields Parquet's metadata uses
+const (
+	typeI32    = 5
+	typeI64    = 6
+	typeBinary = 8
+	typeList   = 9
+	typeStruct = 12
+)
+
+// compactWriter encodes thrift structs in the compact protocol Parquet's page headers and footer are written in
+type compactWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16 // id of the last field written in each open struct
+}
+
+// beginStruct opens a struct, its field ids are relative to each other
+func (w *compactWriter) beginStruct() {
+	w.lastIDs = append(w.lastIDs, 0)
+}
+
+// endStruct writes the stop field of the innermost open struct
+func (w *compactWriter) endStruct() {
+	w.buf.WriteByte(0)
+	w.lastIDs = w.lastIDs[:len(w.lastIDs)-1]
+}
+
+func (w *compactWriter) fieldHeader(id int16, typ byte) {
+	last := &w.lastIDs[len(w.lastIDs)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		w.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		w.buf.WriteByte(typ)
+		w.varint(uint64(zigzag(int64(id))))
+	}
+	*last = id
+}
+
+func (w *compactWriter) i32Field(id int16, v int32) {
+	w.fieldHeader(id, typeI32)
+	w.varint(zigzag(int64(v)))
+}
+
+func (w *compactWriter) i64Field(id int16, v int64) {
+	w.fieldHeader(id, typeI64)
+	w.varint(zigzag(v))
+}
+
+func (w *compactWriter) stringField(id int16, v string) {
+	w.fieldHeader(id, typeBinary)
+	w.binary(v)
+}
+
+// structField opens a struct valued field, closed with endStruct
+func (w *compactWriter) structField(id int16) {
+	w.fieldHeader(id, typeStruct)
+	w.beginStruct()
+}
+
+// listField writes the header of a list field of size elements of typ, written next
+func (w *compactWriter) listField(id int16, typ byte, size int) {
+	w.fieldHeader(id, typeList)
+	if size < 15 {
+		w.buf.WriteByte(byte(size)<<4 | typ)
+		return
+	}
+	w.buf.WriteByte(0xf0 | typ)
+	w.varint(uint64(size))
+}
+
+// i32 writes an i32 list element
+func (w *compactWriter) i32(v int32) {
+	w.varint(zigzag(int64(v)))
+}
+
+// binary writes a string list element, or the value of a string field
+func (w *compactWriter) binary(v string) {
+	w.varint(uint64(len(v)))
+	w.buf.WriteString(v)
+}
+
+func (w *compactWriter) varint(v uint64) {
+	w.buf.Write(binary.AppendUvarint(nil, v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
@@ -0,0 +1,218 @@
+// Package parquet writes flat tables as Parquet files, the columnar format data science tools (pandas, Spark,
+// DuckDB, Athena) read. It supports what archived distributions need and nothing more: required INT64 and UTF8
+// columns, one row group, plain encoding and gzip compression.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+)
+
+// magic starts and ends every Parquet file
+const magic = "PAR1"
+
+// createdBy is recorded in the footer of the files this package writes
+const createdBy = "epoch-server"
+
+// physical and converted types, encodings and codecs from the Parquet format specification
+const (
+	physicalInt64     = 2
+	physicalByteArray = 6
+	convertedUTF8     = 0
+	repetitionReq     = 0
+	encodingPlain     = 0
+	encodingRLE       = 3
+	codecGzip         = 2
+	pageTypeData      = 0
+)
+
+// ColumnType is the type of a column's values
+type ColumnType int
+
+const (
+	// Int64 columns hold int64 values
+	Int64 ColumnType = iota
+	// String columns hold UTF-8 strings
+	String
+)
+
+// Column names a column of a table and the type of its values
+type Column struct {
+	Name string
+	Type ColumnType
+}
+
+// Table collects the rows of a Parquet file. It is not safe for concurrent use.
+type Table struct {
+	columns []Column
+	values  [][]byte // plain encoded values of each column
+	rows    int
+}
+
+// NewTable creates an empty table of the columns
+func NewTable(columns ...Column) *Table {
+	return &Table{columns: columns, values: make([][]byte, len(columns))}
+}
+
+// Append adds a row, with an int64 or string value per column in column order
+func (t *Table) Append(values ...interface{}) error {
+	if len(values) != len(t.columns) {
+		return fmt.Errorf("row has %d values for %d columns", len(values), len(t.columns))
+	}
+	encoded := make([][]byte, len(values))
+	for i, value := range values {
+		switch v := value.(type) {
+		case int64:
+			if t.columns[i].Type != Int64 {
+				return fmt.Errorf("column %s does not hold int64 values", t.columns[i].Name)
+			}
+			encoded[i] = binary.LittleEndian.AppendUint64(nil, uint64(v))
+		case string:
+			if t.columns[i].Type != String {
+				return fmt.Errorf("column %s does not hold string values", t.columns[i].Name)
+			}
+			encoded[i] = binary.LittleEndian.AppendUint32(nil, uint32(len(v)))
+			encoded[i] = append(encoded[i], v...)
+		default:
+			return fmt.Errorf("column %s got unsupported value %T", t.columns[i].Name, value)
+		}
+	}
+	// a row is added whole or not at all
+	for i := range encoded {
+		t.values[i] = append(t.values[i], encoded[i]...)
+	}
+	t.rows++
+	return nil
+}
+
+// Rows returns how many rows were appended
+func (t *Table) Rows() int {
+	return t.rows
+}
+
+// columnChunk is where a column's only page was written
+type columnChunk struct {
+	offset           int64
+	compressedSize   int64 // page header included
+	uncompressedSize int64 // page header included
+}
+
+// Encode returns the table as a Parquet file, each column one gzip-compressed data page
+func (t *Table) Encode() ([]byte, error) {
+	var file bytes.Buffer
+	file.WriteString(magic)
+
+	chunks := make([]columnChunk, len(t.columns))
+	for i, values := range t.values {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(values); err != nil {
+			return nil, fmt.Errorf("failed to compress column %s: %w", t.columns[i].Name, err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("failed to compress column %s: %w", t.columns[i].Name, err)
+		}
+
+		header := pageHeader(len(values), compressed.Len(), t.rows)
+		chunks[i] = columnChunk{
+			offset:           int64(file.Len()),
+			compressedSize:   int64(len(header) + compressed.Len()),
+			uncompressedSize: int64(len(header) + len(values)),
+		}
+		file.Write(header)
+		file.Write(compressed.Bytes())
+	}
+
+	footer := t.fileMetaData(chunks)
+	file.Write(footer)
+	file.Write(binary.LittleEndian.AppendUint32(nil, uint32(len(footer))))
+	file.WriteString(magic)
+	return file.Bytes(), nil
+}
+
+// pageHeader encodes the header of a data page of rows values. Columns are required and flat, so the page
+// holds no repetition or definition levels.
+func pageHeader(uncompressedSize, compressedSize, rows int) []byte {
+	var w compactWriter
+	w.beginStruct()
+	w.i32Field(1, pageTypeData)
+	w.i32Field(2, int32(uncompressedSize))
+	w.i32Field(3, int32(compressedSize))
+	w.structField(5) // data_page_header
+	w.i32Field(1, int32(rows))
+	w.i32Field(2, encodingPlain)
+	w.i32Field(3, encodingRLE)
+	w.i32Field(4, encodingRLE)
+	w.endStruct()
+	w.endStruct()
+	return w.buf.Bytes()
+}
+
+// fileMetaData encodes the footer describing the schema and where every column chunk was written
+func (t *Table) fileMetaData(chunks []columnChunk) []byte {
+	var w compactWriter
+	w.beginStruct()
+	w.i32Field(1, 1) // version
+
+	// the root of the schema is a group of every column
+	w.listField(2, typeStruct, len(t.columns)+1)
+	w.beginStruct()
+	w.stringField(4, "schema")
+	w.i32Field(5, int32(len(t.columns)))
+	w.endStruct()
+	for _, column := range t.columns {
+		w.beginStruct()
+		w.i32Field(1, physicalType(column.Type))
+		w.i32Field(3, repetitionReq)
+		w.stringField(4, column.Name)
+		if column.Type == String {
+			w.i32Field(6, convertedUTF8)
+		}
+		w.endStruct()
+	}
+
+	w.i64Field(3, int64(t.rows))
+
+	var totalSize int64
+	for _, chunk := range chunks {
+		totalSize += chunk.uncompressedSize
+	}
+	w.listField(4, typeStruct, 1) // row_groups
+	w.beginStruct()
+	w.listField(1, typeStruct, len(t.columns))
+	for i, column := range t.columns {
+		chunk := chunks[i]
+		w.beginStruct()
+		w.i64Field(2, chunk.offset) // file_offset
+		w.structField(3)            // meta_data
+		w.i32Field(1, physicalType(column.Type))
+		w.listField(2, typeI32, 2)
+		w.i32(encodingPlain)
+		w.i32(encodingRLE)
+		w.listField(3, typeBinary, 1)
+		w.binary(column.Name)
+		w.i32Field(4, codecGzip)
+		w.i64Field(5, int64(t.rows))
+		w.i64Field(6, chunk.uncompressedSize)
+		w.i64Field(7, chunk.compressedSize)
+		w.i64Field(9, chunk.offset) // data_page_offset
+		w.endStruct()
+		w.endStruct()
+	}
+	w.i64Field(2, totalSize)
+	w.i64Field(3, int64(t.rows))
+	w.endStruct()
+
+	w.stringField(6, createdBy)
+	w.endStruct()
+	return w.buf.Bytes()
+}
+
+func physicalType(typ ColumnType) int32 {
+	if typ == String {
+		return physicalByteArray
+	}
+	return physicalInt64
+}
@@ -0,0 +1,202 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goparquet "github.com/xitongsys/parquet-go/parquet"
+	"github.com/xitongsys/parquet-go/reader"
+	"github.com/xitongsys/parquet-go/source"
+)
+
+// compactReader decodes thrift compact structs into maps of field id to value, enough to check what
+// compactWriter wrote
+type compactReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *compactReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *compactReader) value(typ byte) interface{} {
+	switch typ {
+	case typeI32, typeI64:
+		v := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case typeBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return string(r.data[r.pos-n : r.pos])
+	case typeList:
+		header := r.data[r.pos]
+		r.pos++
+		size, elem := int(header>>4), header&0x0f
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case typeStruct:
+		return r.structure()
+	}
+	panic("unexpected type")
+}
+
+func (r *compactReader) structure() map[int64]interface{} {
+	fields := make(map[int64]interface{})
+	var last int64
+	for {
+		header := r.data[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		id := last + int64(header>>4)
+		if header>>4 == 0 {
+			v := r.uvarint()
+			id = int64(v>>1) ^ -int64(v&1)
+		}
+		last = id
+		fields[id] = r.value(header & 0x0f)
+	}
+}
+
+func TestTable_Encode(t *testing.T) {
+	table := NewTable(Column{Name: "account", Type: String}, Column{Name: "epoch", Type: Int64})
+	require.NoError(t, table.Append("0xabc", int64(5)))
+	require.NoError(t, table.Append("", int64(-1)))
+	assert.Error(t, table.Append("0xdef"), "a value per column")
+	assert.Error(t, table.Append(int64(5), "0xdef"), "values of the column's type")
+	assert.Error(t, table.Append("0xdef", 5), "int is not int64")
+	assert.Equal(t, 2, table.Rows())
+
+	data, err := table.Encode()
+	require.NoError(t, err)
+	require.Equal(t, magic, string(data[:4]))
+	require.Equal(t, magic, string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := (&compactReader{data: data, pos: len(data) - 8 - footerLen}).structure()
+	assert.Equal(t, int64(2), footer[3], "num_rows")
+	assert.Equal(t, createdBy, footer[6])
+	schema := footer[2].([]interface{})
+	require.Len(t, schema, 3)
+	assert.Equal(t, int64(2), schema[0].(map[int64]interface{})[5], "root has every column")
+	assert.Equal(t, "account", schema[1].(map[int64]interface{})[4])
+	assert.Equal(t, int64(convertedUTF8), schema[1].(map[int64]interface{})[6])
+	assert.Equal(t, int64(physicalInt64), schema[2].(map[int64]interface{})[1])
+
+	rowGroup := footer[4].([]interface{})[0].(map[int64]interface{})
+	chunks := rowGroup[1].([]interface{})
+	require.Len(t, chunks, 2)
+	var values [][]byte
+	for _, chunk := range chunks {
+		meta := chunk.(map[int64]interface{})[3].(map[int64]interface{})
+		assert.Equal(t, int64(2), meta[5], "num_values")
+
+		page := &compactReader{data: data, pos: int(meta[9].(int64))}
+		header := page.structure()
+		assert.Equal(t, meta[7], int64(page.pos)-meta[9].(int64)+header[3].(int64), "compressed size includes the header")
+		zr, err := gzip.NewReader(bytes.NewReader(data[page.pos : page.pos+int(header[3].(int64))]))
+		require.NoError(t, err)
+		raw, err := io.ReadAll(zr)
+		require.NoError(t, err)
+		assert.Len(t, raw, int(header[2].(int64)))
+		values = append(values, raw)
+	}
+
+	assert.Equal(t, append(append([]byte{5, 0, 0, 0}, "0xabc"...), 0, 0, 0, 0), values[0], "length prefixed strings")
+	assert.Equal(t, int64(5), int64(binary.LittleEndian.Uint64(values[1])))
+	assert.Equal(t, int64(-1), int64(binary.LittleEndian.Uint64(values[1][8:])))
+}
+
+func TestCompactWriter_LongForms(t *testing.T) {
+	var w compactWriter
+	w.beginStruct()
+	w.i32Field(20, 7) // a field id jump past 15 is written in full
+	w.listField(21, typeI32, 20)
+	for i := 0; i < 20; i++ {
+		w.i32(int32(i))
+	}
+	w.endStruct()
+
+	fields := (&compactReader{data: w.buf.Bytes()}).structure()
+	assert.Equal(t, int64(7), fields[20])
+	assert.Len(t, fields[21], 20)
+}
+
+// memFile serves encoded bytes to parquet-go's reader, which wants a file it can reopen per column
+type memFile struct {
+	*bytes.Reader
+	data []byte
+}
+
+func newMemFile(data []byte) *memFile { return &memFile{Reader: bytes.NewReader(data), data: data} }
+
+func (f *memFile) Open(string) (source.ParquetFile, error)   { return newMemFile(f.data), nil }
+func (f *memFile) Create(string) (source.ParquetFile, error) { return nil, io.ErrUnexpectedEOF }
+func (f *memFile) Write([]byte) (int, error)                 { return 0, io.ErrShortWrite }
+func (f *memFile) Close() error                              { return nil }
+
+func TestTable_EncodeReadsBackWithParquetGo(t *testing.T) {
+	accounts := []string{"0xabc", "", "0x" + string(bytes.Repeat([]byte("f"), 40)), "Ω"}
+	epochs := []int64{5, -1, 0, 1 << 62}
+	table := NewTable(Column{Name: "account", Type: String}, Column{Name: "epoch", Type: Int64})
+	for i := range accounts {
+		require.NoError(t, table.Append(accounts[i], epochs[i]))
+	}
+	data, err := table.Encode()
+	require.NoError(t, err)
+
+	pr, err := reader.NewParquetReader(newMemFile(data), nil, 1)
+	require.NoError(t, err)
+	defer pr.ReadStop()
+
+	assert.Equal(t, int64(len(accounts)), pr.GetNumRows())
+	assert.Equal(t, createdBy, pr.Footer.GetCreatedBy())
+	schema := pr.Footer.GetSchema()
+	require.Len(t, schema, 3)
+	assert.Equal(t, int32(2), schema[0].GetNumChildren())
+	// the reader renames schema elements to Go identifiers, the names in the file are kept as ExName
+	assert.Equal(t, "account", pr.SchemaHandler.Infos[1].ExName)
+	assert.Equal(t, goparquet.Type_BYTE_ARRAY, schema[1].GetType())
+	assert.Equal(t, goparquet.ConvertedType_UTF8, schema[1].GetConvertedType())
+	assert.Equal(t, goparquet.FieldRepetitionType_REQUIRED, schema[1].GetRepetitionType())
+	assert.Equal(t, "epoch", pr.SchemaHandler.Infos[2].ExName)
+	assert.Equal(t, goparquet.Type_INT64, schema[2].GetType())
+	assert.Equal(t, goparquet.FieldRepetitionType_REQUIRED, schema[2].GetRepetitionType())
+
+	require.Len(t, pr.Footer.GetRowGroups(), 1)
+	rowGroup := pr.Footer.GetRowGroups()[0]
+	assert.Equal(t, int64(len(accounts)), rowGroup.GetNumRows())
+	require.Len(t, rowGroup.GetColumns(), 2)
+	for _, chunk := range rowGroup.GetColumns() {
+		assert.Equal(t, goparquet.CompressionCodec_GZIP, chunk.GetMetaData().GetCodec())
+		assert.Equal(t, int64(len(accounts)), chunk.GetMetaData().GetNumValues())
+	}
+
+	gotAccounts, _, _, err := pr.ReadColumnByIndex(0, int64(len(accounts)))
+	require.NoError(t, err)
+	require.Len(t, gotAccounts, len(accounts))
+	for i, account := range accounts {
+		assert.Equal(t, account, gotAccounts[i])
+	}
+	gotEpochs, _, _, err := pr.ReadColumnByIndex(1, int64(len(epochs)))
+	require.NoError(t, err)
+	require.Len(t, gotEpochs, len(epochs))
+	for i, epoch := range epochs {
+		assert.Equal(t, epoch, gotEpochs[i])
+	}
+}
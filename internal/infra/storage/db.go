@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"io"
+
+	"github.com/dgraph-io/badger/v4"
+)
+
+// DB is the transactional key-value database the services keep their records in. It is the subset of badger's
+// API the stores use: badger serves it as is, the sqlite backend runs every transaction against its file.
+// Missing keys are reported as badger.ErrKeyNotFound by every backend.
+type DB interface {
+	View(fn func(txn Txn) error) error
+	Update(fn func(txn Txn) error) error
+	NewWriteBatch() WriteBatch
+	DropPrefix(prefixes ...[]byte) error
+	// Backup writes every key in badger's backup format, which Load of any backend restores
+	Backup(w io.Writer, since uint64) (uint64, error)
+	Load(r io.Reader, maxPendingWrites int) error
+}
+
+// Txn reads and writes the database within a transaction
+type Txn interface {
+	Get(key []byte) (Item, error)
+	Set(key, val []byte) error
+	SetEntry(e *badger.Entry) error
+	Delete(key []byte) error
+	NewIterator(opt badger.IteratorOptions) Iterator
+}
+
+// Item is a key read from the database
+type Item interface {
+	Key() []byte
+	KeyCopy(dst []byte) []byte
+	Value(fn func(val []byte) error) error
+	ValueCopy(dst []byte) ([]byte, error)
+	ExpiresAt() uint64
+}
+
+// Iterator walks keys in order, honoring the Prefix and Reverse of its options
+type Iterator interface {
+	Rewind()
+	Seek(key []byte)
+	Valid() bool
+	ValidForPrefix(prefix []byte) bool
+	Next()
+	Item() Item
+	Close()
+}
+
+// WriteBatch writes many keys outside a single transaction, committing as it fills
+type WriteBatch interface {
+	Set(key, val []byte) error
+	SetEntry(e *badger.Entry) error
+	Delete(key []byte) error
+	Flush() error
+	Cancel()
+}
+
+// Badger serves db as a DB, nil when db is nil
+func Badger(db *badger.DB) DB {
+	if db == nil {
+		return nil
+	}
+	return badgerDB{db}
+}
+
+type badgerDB struct {
+	*badger.DB
+}
+
+func (db badgerDB) View(fn func(txn Txn) error) error {
+	return db.DB.View(func(txn *badger.Txn) error { return fn(badgerTxn{txn}) })
+}
+
+func (db badgerDB) Update(fn func(txn Txn) error) error {
+	return db.DB.Update(func(txn *badger.Txn) error { return fn(badgerTxn{txn}) })
+}
+
+func (db badgerDB) NewWriteBatch() WriteBatch {
+	return db.DB.NewWriteBatch()
+}
+
+type badgerTxn struct {
+	*badger.Txn
+}
+
+func (txn badgerTxn) Get(key []byte) (Item, error) {
+	item, err := txn.Txn.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (txn badgerTxn) NewIterator(opt badger.IteratorOptions) Iterator {
+	return badgerIterator{txn.Txn.NewIterator(opt)}
+}
+
+type badgerIterator struct {
+	*badger.Iterator
+}
+
+func (it badgerIterator) Item() Item {
+	return it.Iterator.Item()
+}
@@ -1,17 +1,17 @@
 package storage
 
-import "github.com/dgraph-io/badger/v4"
-
 //go:generate moq -out storage_mocks.go . StorageClient
 
 // StorageClient defines the interface for storage operations
 type StorageClient interface {
-	GetDB() *badger.DB
+	GetDB() DB
 	Close() error
 }
 
 // Config contains configuration for storage
 type Config struct {
-	Type string `yaml:"type"` // "badger" or "memory"
-	Path string `yaml:"path"` // path for badger database
+	Type string `yaml:"type"` // "badger", "sqlite" or "memory"
+	Path string `yaml:"path"` // path for the badger database directory or the sqlite file
+	// LowMemory bounds badger's memory and the Go runtime's to fit a 512MB container, at the cost of slower writes
+	LowMemory bool `yaml:"lowMemory"`
 }
@@ -52,6 +52,50 @@ type AccountSubsidy struct {
 	LastEffectiveValue      string  `json:"lastEffectiveValue"`
 	UpdatedAtBlock          string  `json:"updatedAtBlock"`
 	UpdatedAtTimestamp      string  `json:"updatedAtTimestamp"`
+	Collection              string  `json:"collection,omitempty"`     // collection address of the participation
+	CollectionType          string  `json:"collectionType,omitempty"` // ERC721 or ERC1155
+	// Share, WrappedBy and RoundedOff are set by the distributor: Share is the fraction of the indexed
+	// amounts the subsidy is valued at, WrappedBy the wrapper contract an owner's share was accrued by,
+	// and RoundedOff the fraction of a wei scaling rounded totalRewardsEarned down by
+	Share      string `json:"share,omitempty"`
+	WrappedBy  string `json:"wrappedBy,omitempty"`
+	RoundedOff string `json:"roundedOff,omitempty"`
+}
+
+// collection types as the subgraph reports them
+const (
+	CollectionTypeERC721  = "ERC721"
+	CollectionTypeERC1155 = "ERC1155"
+)
+
+// WrappedPosition is what a staking or wrapper contract holds in a collection participation for an owner
+type WrappedPosition struct {
+	ID                      string  `json:"id"`
+	Wrapper                 string  `json:"wrapper"`
+	Owner                   Account `json:"owner"`
+	CollectionParticipation string  `json:"collectionParticipation"`
+	Balance                 string  `json:"balance"` // tokens, or ERC-1155 units, wrapped for the owner
+}
+
+// NFTTransfer is one transfer of a token in a collection. Mints come from and burns go to the zero address.
+type NFTTransfer struct {
+	ID          string `json:"id"`
+	Collection  string `json:"collection"`
+	TokenID     string `json:"tokenId"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"` // units moved, always 1 for ERC-721 tokens
+	BlockNumber int64  `json:"blockNumber"`
+	LogIndex    int64  `json:"logIndex"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+// IndexedBlock is the latest block the subgraph has indexed, as its _meta reports it
+type IndexedBlock struct {
+	Number            int64  `json:"number"`
+	Hash              string `json:"hash"`
+	Timestamp         int64  `json:"timestamp"` // 0 when the graph node does not report it
+	HasIndexingErrors bool   `json:"hasIndexingErrors"`
 }
 
 type Epoch struct {
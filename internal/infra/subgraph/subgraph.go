@@ -1,6 +1,34 @@
 package subgraph
 
-import "context"
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrSchemaIncompatible is returned when the subgraph's schema lacks fields every supported version has, or is
+// older than the configured minimum version
+var ErrSchemaIncompatible = errors.New("subgraph schema incompatible")
+
+// subgraph schema versions the client can query. Queries are written for the latest version and adapted to
+// older ones.
+const (
+	// SchemaV1 has no Collection.collectionType, every collection is ERC-721, and no WrappedPosition entity
+	SchemaV1 = 1
+	// SchemaV2 adds Collection.collectionType for ERC-1155 collections and WrappedPosition
+	SchemaV2 = 2
+	// SchemaLatest is the version queries are written for
+	SchemaLatest = SchemaV2
+)
+
+// Schema is the result of negotiating the schema version with the subgraph
+type Schema struct {
+	Version int
+	// Missing lists the Entity.field pairs of the latest version the subgraph does not expose
+	Missing []string
+}
 
 //go:generate moq -out subgraph_mocks.go . SubgraphClient
 
@@ -9,10 +37,30 @@ type SubgraphClient interface {
 	// basic query operations
 	ExecuteQuery(ctx context.Context, request GraphQLRequest, response interface{}) error
 	HealthCheck(ctx context.Context) error
+	// NegotiateSchema introspects the subgraph's entities and picks the latest schema version it serves every
+	// field of, which later queries are adapted to. It returns ErrSchemaIncompatible when no supported
+	// version is served or the version is below the configured minimum.
+	NegotiateSchema(ctx context.Context) (*Schema, error)
+	// IndexedBlock returns the latest block the subgraph has indexed, never from the cache
+	IndexedBlock(ctx context.Context) (*IndexedBlock, error)
 
 	// account queries
 	QueryAccounts(ctx context.Context) ([]Account, error)
 	QueryAccountSubsidiesForVault(ctx context.Context, vaultAddress string) ([]AccountSubsidy, error)
+	// QueryAccountSubsidiesForAccountAtBlock returns one account's subsidies in every collection of the vault as of blockNumber
+	QueryAccountSubsidiesForAccountAtBlock(
+		ctx context.Context,
+		vaultAddress string,
+		accountAddress string,
+		blockNumber int64,
+	) ([]AccountSubsidy, error)
+	// QueryAccountSubsidiesForAccountsAtBlock looks up many accounts' subsidies in batched requests, keyed by account
+	QueryAccountSubsidiesForAccountsAtBlock(
+		ctx context.Context,
+		vaultAddress string,
+		accounts []string,
+		blockNumber int64,
+	) (map[string][]AccountSubsidy, error)
 	QueryAccountSubsidiesAtBlock(
 		ctx context.Context,
 		vaultAddress string,
@@ -60,10 +108,76 @@ type SubgraphClient interface {
 		blockNumber int64,
 		response interface{},
 	) error
+
+	// streaming queries hand each page to fn as it arrives, so callers never hold every entity at once
+	StreamPaginatedQuery(
+		ctx context.Context,
+		queryTemplate string,
+		variables map[string]interface{},
+		entityField string,
+		fn func(page json.RawMessage) error,
+	) error
+	StreamAccountSubsidiesForVault(
+		ctx context.Context,
+		vaultAddress string,
+		fn func(page []AccountSubsidy) error,
+	) error
+	StreamAccountSubsidiesForVaultAtBlock(
+		ctx context.Context,
+		vaultAddress string,
+		blockNumber int64,
+		fn func(page []AccountSubsidy) error,
+	) error
+
+	// wrapped position queries return what a staking or wrapper contract holds on behalf of each owner
+	QueryWrappedPositions(ctx context.Context, vaultAddress, wrapperAddress string) ([]WrappedPosition, error)
+	QueryWrappedPositionsAtBlock(
+		ctx context.Context,
+		vaultAddress string,
+		wrapperAddress string,
+		blockNumber int64,
+	) ([]WrappedPosition, error)
+
+	// QueryNFTTransfers returns every transfer of tokens in the collections up to untilTimestamp, in the order
+	// they happened, so who held each token at any time up to it can be replayed
+	QueryNFTTransfers(ctx context.Context, collections []string, untilTimestamp int64) ([]NFTTransfer, error)
+
+	// cache management
+	InvalidateCache()
 }
 
 // Config represents the configuration for subgraph client
 type Config struct {
 	Endpoint string
-	Timeout  string
+	Timeout  time.Duration
+	// PageSize is the number of entities requested per page, at most the subgraph's limit of 1000
+	PageSize int
+
+	// MinSchemaVersion is the oldest schema version NegotiateSchema accepts, zero accepts every supported version
+	MinSchemaVersion int
+
+	// CacheTTL is how long query results stay fresh; zero disables caching
+	CacheTTL time.Duration
+	// CacheBlockTTL applies to queries pinned to a block, whose results only change on reorgs
+	CacheBlockTTL time.Duration
+	// CacheStaleTTL is how long an expired result may still be served while it is refreshed
+	CacheStaleTTL time.Duration
+	// CacheMaxEntries is the most results cached, the least recently used are evicted beyond it
+	CacheMaxEntries int
+
+	// Transport sends the client's requests, http.DefaultTransport when nil
+	Transport http.RoundTripper
+}
+
+type freshDataKey struct{}
+
+// WithFreshData marks ctx so that subgraph queries made with it bypass cached results
+func WithFreshData(ctx context.Context) context.Context {
+	return context.WithValue(ctx, freshDataKey{}, true)
+}
+
+// FreshDataRequired reports whether ctx was marked with WithFreshData
+func FreshDataRequired(ctx context.Context) bool {
+	fresh, _ := ctx.Value(freshDataKey{}).(bool)
+	return fresh
 }
@@ -0,0 +1,233 @@
+package testing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/go-pkgz/lgr"
+	"github.com/testcontainers/testcontainers-go"
+	tcexec "github.com/testcontainers/testcontainers-go/exec"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+const (
+	// AnvilDevKey is the private key of the first account anvil funds, the same on every anvil and hardhat node
+	AnvilDevKey = "ac0974bec39a17e36ba4a6b4d238ff944bacb478cbed5efcae784d7bf4f2ff80"
+
+	// AnvilChainID is the chain ID anvil runs with unless configured otherwise, forks included
+	AnvilChainID = 31337
+
+	anvilPort         = "8545/tcp"
+	defaultAnvilImage = "ghcr.io/foundry-rs/foundry:latest"
+)
+
+// deployedToPattern matches the address forge create prints for a deployed contract
+var deployedToPattern = regexp.MustCompile(`Deployed to: (0x[0-9a-fA-F]{40})`)
+
+// AnvilContainer runs an anvil node in a Docker container, optionally forking a live network, and deploys
+// contracts to it and calls them with the forge and cast of the same image
+type AnvilContainer struct {
+	container     testcontainers.Container
+	rpcURL        string
+	chainID       uint64
+	contractsRoot string
+	logger        lgr.L
+}
+
+// AnvilContainerConfig holds configuration for the anvil container
+type AnvilContainerConfig struct {
+	// Docker image with anvil and forge (optional, defaults to the latest foundry image)
+	Image string
+	// RPC URL of the network to fork (optional, a fresh chain without it)
+	ForkURL string
+	// Block to fork at (optional, the latest block without it)
+	ForkBlock uint64
+	// Chain ID anvil reports (optional, defaults to AnvilChainID)
+	ChainID uint64
+	// Seconds between blocks (optional, a block per transaction without it)
+	BlockTime uint64
+	// Foundry project on the host copied into the container for Deploy (optional)
+	ContractsDir string
+	// Logger instance
+	Logger lgr.L
+}
+
+// NewAnvilContainer starts anvil and waits until it answers RPC calls
+func NewAnvilContainer(ctx context.Context, config AnvilContainerConfig) (*AnvilContainer, error) {
+	if config.Image == "" {
+		config.Image = defaultAnvilImage
+	}
+	if config.ChainID == 0 {
+		config.ChainID = AnvilChainID
+	}
+	if config.Logger == nil {
+		config.Logger = lgr.New(lgr.Debug)
+	}
+
+	// the foundry image runs its command with sh -c, so anvil is started from a single command line
+	args := []string{"anvil", "--host", "0.0.0.0", "--port", "8545", "--chain-id", strconv.FormatUint(config.ChainID, 10)}
+	if config.ForkURL != "" {
+		args = append(args, "--fork-url", config.ForkURL)
+		if config.ForkBlock > 0 {
+			args = append(args, "--fork-block-number", strconv.FormatUint(config.ForkBlock, 10))
+		}
+	}
+	if config.BlockTime > 0 {
+		args = append(args, "--block-time", strconv.FormatUint(config.BlockTime, 10))
+	}
+
+	req := testcontainers.ContainerRequest{
+		Image:        config.Image,
+		Cmd:          []string{strings.Join(args, " ")},
+		ExposedPorts: []string{anvilPort},
+		WaitingFor:   wait.ForListeningPort(anvilPort).WithStartupTimeout(2 * time.Minute),
+	}
+	container, err := testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to start anvil container: %w", err)
+	}
+
+	ac := &AnvilContainer{container: container, chainID: config.ChainID, logger: config.Logger}
+	if err := ac.init(ctx, config); err != nil {
+		if termErr := container.Terminate(ctx); termErr != nil {
+			return nil, fmt.Errorf("%w, failed to terminate container: %v", err, termErr)
+		}
+		return nil, err
+	}
+
+	config.Logger.Logf("INFO anvil chain %d listening on %s", ac.chainID, ac.rpcURL)
+	return ac, nil
+}
+
+func (ac *AnvilContainer) init(ctx context.Context, config AnvilContainerConfig) error {
+	host, err := ac.container.Host(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get anvil host: %w", err)
+	}
+	port, err := ac.container.MappedPort(ctx, anvilPort)
+	if err != nil {
+		return fmt.Errorf("failed to get anvil port: %w", err)
+	}
+	ac.rpcURL = fmt.Sprintf("http://%s:%s", host, port.Port())
+
+	if config.ContractsDir != "" {
+		// the directory is copied under its own name to the container root
+		ac.contractsRoot = "/" + filepath.Base(config.ContractsDir)
+		if err := ac.container.CopyDirToContainer(ctx, config.ContractsDir, ac.contractsRoot, 0o755); err != nil {
+			return fmt.Errorf("failed to copy contracts to anvil container: %w", err)
+		}
+	}
+	return nil
+}
+
+// RPCURL returns the URL anvil serves JSON-RPC on from the host
+func (ac *AnvilContainer) RPCURL() string {
+	return ac.rpcURL
+}
+
+// ChainID returns the chain ID anvil reports
+func (ac *AnvilContainer) ChainID() uint64 {
+	return ac.chainID
+}
+
+// GetContainer returns the underlying testcontainer
+func (ac *AnvilContainer) GetContainer() testcontainers.Container {
+	return ac.container
+}
+
+// Deploy compiles contract, a path:name identifier within the contracts directory such as
+// src/MockVault.sol:MockVault, and deploys it from the AnvilDevKey account. It returns the
+// contract's address.
+func (ac *AnvilContainer) Deploy(ctx context.Context, contract string, constructorArgs ...string) (string, error) {
+	if ac.contractsRoot == "" {
+		return "", fmt.Errorf("no contracts directory was copied to the anvil container")
+	}
+
+	// build output goes to /tmp, the copied project may not be writable by the image's user
+	cmd := []string{
+		"forge", "create", contract,
+		"--root", ac.contractsRoot,
+		"--out", "/tmp/forge-out",
+		"--cache-path", "/tmp/forge-cache",
+		"--rpc-url", "http://127.0.0.1:8545",
+		"--private-key", "0x" + AnvilDevKey,
+		"--broadcast",
+	}
+	if len(constructorArgs) > 0 {
+		cmd = append(append(cmd, "--constructor-args"), constructorArgs...)
+	}
+
+	output, err := ac.exec(ctx, cmd)
+	if err != nil {
+		return "", fmt.Errorf("failed to deploy %s: %w", contract, err)
+	}
+	match := deployedToPattern.FindSubmatch(output)
+	if match == nil {
+		return "", fmt.Errorf("forge create %s printed no deployed address: %s", contract, output)
+	}
+	address := strings.ToLower(string(match[1]))
+	ac.logger.Logf("DEBUG deployed %s to %s", contract, address)
+	return address, nil
+}
+
+// Send sends a transaction calling signature, such as setPaused(bool), on the contract at address from the
+// AnvilDevKey account, and waits for it to be mined
+func (ac *AnvilContainer) Send(ctx context.Context, address, signature string, args ...string) error {
+	cmd := append([]string{
+		"cast", "send", address, signature,
+		"--rpc-url", "http://127.0.0.1:8545",
+		"--private-key", "0x" + AnvilDevKey,
+	}, args...)
+	if _, err := ac.exec(ctx, cmd); err != nil {
+		return fmt.Errorf("failed to send %s to %s: %w", signature, address, err)
+	}
+	return nil
+}
+
+// Mine mines that many empty blocks, burying the transactions sent so far under confirmations
+func (ac *AnvilContainer) Mine(ctx context.Context, blocks uint64) error {
+	client, err := rpc.DialContext(ctx, ac.rpcURL)
+	if err != nil {
+		return fmt.Errorf("failed to dial anvil: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.CallContext(ctx, nil, "anvil_mine", fmt.Sprintf("0x%x", blocks)); err != nil {
+		return fmt.Errorf("failed to mine %d blocks: %w", blocks, err)
+	}
+	return nil
+}
+
+// exec runs cmd in the container and returns its combined output, failing when it exits with an error
+func (ac *AnvilContainer) exec(ctx context.Context, cmd []string) ([]byte, error) {
+	exitCode, reader, err := ac.container.Exec(ctx, cmd, tcexec.Multiplexed())
+	if err != nil {
+		return nil, fmt.Errorf("failed to run %s: %w", cmd[0], err)
+	}
+	output, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s output: %w", cmd[0], err)
+	}
+	if exitCode != 0 {
+		return nil, fmt.Errorf("%s exited with %d: %s", strings.Join(cmd[:2], " "), exitCode, output)
+	}
+	return output, nil
+}
+
+// Close stops the container
+func (ac *AnvilContainer) Close(ctx context.Context) error {
+	if err := ac.container.Terminate(ctx); err != nil {
+		return fmt.Errorf("failed to terminate anvil container: %w", err)
+	}
+	return nil
+}
@@ -7,7 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
-	"github.com/dgraph-io/badger/v4"
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/go-pkgz/lgr"
 )
 
@@ -25,7 +25,7 @@ import (
 //
 //	// Use db directly...
 //	store := NewStore(db, logger)
-func SetupTestDB(ctx context.Context) (*badger.DB, func(), error) {
+func SetupTestDB(ctx context.Context) (storage.DB, func(), error) {
 	// Create logger for the test setup
 	logger := lgr.New(lgr.Msec, lgr.Debug)
 
@@ -64,13 +64,13 @@ func SetupTestDB(ctx context.Context) (*badger.DB, func(), error) {
 		}
 	}
 
-	return db, cleanup, nil
+	return storage.Badger(db), cleanup, nil
 }
 
 // SetupTestDBWithConfig creates a BadgerDB instance with custom configuration.
 // This provides more control over the database setup while still handling
 // container management automatically.
-func SetupTestDBWithConfig(ctx context.Context, config TestConfig) (*badger.DB, func(), error) {
+func SetupTestDBWithConfig(ctx context.Context, config TestConfig) (storage.DB, func(), error) {
 	// Create logger for the test setup
 	logger := lgr.New(lgr.Msec, lgr.Debug)
 	if config.BadgerDB.Debug {
@@ -108,12 +108,12 @@ func SetupTestDBWithConfig(ctx context.Context, config TestConfig) (*badger.DB,
 		}
 	}
 
-	return db, cleanup, nil
+	return storage.Badger(db), cleanup, nil
 }
 
 // SetupTestDBAndHelper creates both a database instance and a test helper.
 // This is useful for tests that need the helper utilities.
-func SetupTestDBAndHelper(ctx context.Context) (*badger.DB, *BadgerTestHelper, func(), error) {
+func SetupTestDBAndHelper(ctx context.Context) (storage.DB, *BadgerTestHelper, func(), error) {
 	// Create logger for the test setup
 	logger := lgr.New(lgr.Msec, lgr.Debug)
 
@@ -155,5 +155,5 @@ func SetupTestDBAndHelper(ctx context.Context) (*badger.DB, *BadgerTestHelper, f
 		}
 	}
 
-	return db, helper, cleanup, nil
+	return storage.Badger(db), helper, cleanup, nil
 }
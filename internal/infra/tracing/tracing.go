@@ -0,0 +1,164 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/andrey/epoch-server"
+
+// Config represents the configuration for OpenTelemetry tracing
+type Config struct {
+	Enabled     bool
+	Endpoint    string // OTLP/HTTP collector endpoint, host:port
+	ServiceName string
+	SampleRatio float64
+	Insecure    bool
+}
+
+// Setup installs the global tracer provider and propagator, returning a shutdown
+// function that flushes pending spans. When tracing is disabled the global no-op
+// provider stays in place, so instrumented code has no exporting overhead.
+func Setup(ctx context.Context, cfg Config, logger lgr.L) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if !cfg.Enabled {
+		logger.Logf("INFO tracing disabled")
+		return func(context.Context) error { return nil }, nil
+	}
+
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is required when tracing is enabled")
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.Endpoint)}
+	if cfg.Insecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	logger.Logf("INFO tracing enabled, exporting to %s (sample ratio %.2f)", cfg.Endpoint, cfg.SampleRatio)
+	return provider.Shutdown, nil
+}
+
+// StartSpan starts a span using the global tracer provider, and records it in the context's operation
+// log when there is one
+func StartSpan(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	if log, ok := ctx.Value(operationLogKey{}).(*OperationLog); ok {
+		return ctx, &loggedSpan{Span: span, log: log, operation: log.start(name)}
+	}
+	return ctx, span
+}
+
+// EndSpan records err on the span, if any, and ends it
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type operationLogKey struct{}
+
+// Operation is a span started while serving a request
+type Operation struct {
+	Name    string
+	Elapsed time.Duration // how long it ran, or has been running when it is not done
+	Done    bool
+	Err     string // error the span recorded, if any
+	started time.Time
+}
+
+// OperationLog records every span started under a context, the subgraph queries and contract calls among
+// them, so a request that runs out of time can report what it was still waiting for
+type OperationLog struct {
+	mu         sync.Mutex
+	operations []*Operation
+}
+
+// WithOperationLog returns a context recording the spans started under it in the returned log
+func WithOperationLog(ctx context.Context) (context.Context, *OperationLog) {
+	log := &OperationLog{}
+	return context.WithValue(ctx, operationLogKey{}, log), log
+}
+
+func (l *OperationLog) start(name string) *Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	op := &Operation{Name: name, started: time.Now()}
+	l.operations = append(l.operations, op)
+	return op
+}
+
+// Operations returns a copy of the recorded operations in the order they started
+func (l *OperationLog) Operations() []Operation {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	operations := make([]Operation, len(l.operations))
+	for i, op := range l.operations {
+		operations[i] = *op
+		if !op.Done {
+			operations[i].Elapsed = time.Since(op.started)
+		}
+	}
+	return operations
+}
+
+// loggedSpan records its error and end in its operation
+type loggedSpan struct {
+	trace.Span
+	log       *OperationLog
+	operation *Operation
+}
+
+func (s *loggedSpan) RecordError(err error, options ...trace.EventOption) {
+	s.Span.RecordError(err, options...)
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.operation.Err = err.Error()
+}
+
+func (s *loggedSpan) End(options ...trace.SpanEndOption) {
+	s.Span.End(options...)
+	s.log.mu.Lock()
+	defer s.log.mu.Unlock()
+	s.operation.Done = true
+	s.operation.Elapsed = time.Since(s.operation.started)
+}
@@ -40,6 +40,21 @@ func IsValidAddress(address string) bool {
 	return common.IsHexAddress(address)
 }
 
+// ChecksumAddress returns the EIP-55 mixed-case form of a valid address, the form wallets display
+func ChecksumAddress(address string) string {
+	return common.HexToAddress(address).Hex()
+}
+
+// HasValidChecksum reports whether a valid address is single-case, which carries no checksum, or exactly its
+// EIP-55 form. A mixed-case address with a wrong checksum was mistyped and may belong to nobody.
+func HasValidChecksum(address string) bool {
+	digits := strings.TrimPrefix(address, "0x")
+	if digits == strings.ToLower(digits) || digits == strings.ToUpper(digits) {
+		return true
+	}
+	return address == ChecksumAddress(address)
+}
+
 // ValidateAndNormalizeAddress validates an address and returns it normalized to lowercase
 // Returns an error if the address is invalid
 func ValidateAndNormalizeAddress(address string) (string, error) {
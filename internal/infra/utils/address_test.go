@@ -157,3 +157,39 @@ func TestValidateAndNormalizeAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestHasValidChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{
+			name:     "lowercase address carries no checksum",
+			input:    "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+			expected: true,
+		},
+		{
+			name:     "uppercase address carries no checksum",
+			input:    "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+			expected: true,
+		},
+		{
+			name:     "EIP-55 address",
+			input:    "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+			expected: true,
+		},
+		{
+			name:     "mixed case with a wrong checksum",
+			input:    "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, HasValidChecksum(tt.input))
+		})
+	}
+	assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", ChecksumAddress("0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+}
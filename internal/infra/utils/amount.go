@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"math/big"
+	"strings"
+)
+
+// FormatUnits returns amount in whole tokens of the given decimals as an exact decimal string without trailing
+// zeros, e.g. 1500000 with 6 decimals is "1.5"
+func FormatUnits(amount *big.Int, decimals uint8) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+	unit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	formatted := new(big.Rat).SetFrac(amount, unit).FloatString(int(decimals))
+	return strings.TrimSuffix(strings.TrimRight(formatted, "0"), ".")
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals uint8
+		expected string
+	}{
+		{name: "usdc", amount: "1500000", decimals: 6, expected: "1.5"},
+		{name: "usdc read as 18 decimals", amount: "1500000", decimals: 18, expected: "0.0000000000015"},
+		{name: "whole tokens", amount: "2000000000000000000", decimals: 18, expected: "2"},
+		{name: "below one", amount: "1", decimals: 6, expected: "0.000001"},
+		{name: "zero", amount: "0", decimals: 6, expected: "0"},
+		{name: "negative", amount: "-2500000", decimals: 6, expected: "-2.5"},
+		{name: "no decimals", amount: "100", decimals: 0, expected: "100"},
+		{name: "trailing zeros of the integer part are kept", amount: "100000000", decimals: 6, expected: "100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			amount, ok := new(big.Int).SetString(tt.amount, 10)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expected, FormatUnits(amount, tt.decimals))
+		})
+	}
+}
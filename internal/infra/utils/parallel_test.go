@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestForEach(t *testing.T) {
+	var running, peak, calls int32
+	results := make([]int, 20)
+	err := ForEach(context.Background(), len(results), 3, func(ctx context.Context, i int) error {
+		current := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if current <= seen || atomic.CompareAndSwapInt32(&peak, seen, current) {
+				break
+			}
+		}
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(time.Millisecond)
+		results[i] = i * i
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int32(20), calls)
+	assert.LessOrEqual(t, peak, int32(3), "no more than limit calls run at once")
+	for i, result := range results {
+		assert.Equal(t, i*i, result)
+	}
+}
+
+func TestForEach_StopsAtFirstError(t *testing.T) {
+	failed := errors.New("rpc down")
+	var calls int32
+	err := ForEach(context.Background(), 1000, 2, func(ctx context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		if i == 3 {
+			return failed
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, failed)
+	assert.Less(t, atomic.LoadInt32(&calls), int32(1000), "calls after the failure are skipped")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = ForEach(ctx, 5, 2, func(ctx context.Context, i int) error { return nil })
+	require.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,28 @@
+package analytics
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+//go:generate moq -out analytics_mocks.go . Service
+
+// Service defines the interface for per-epoch vault analytics
+type Service interface {
+	// VaultAnalytics returns the vault's yield, subsidy, APY and claim series for the epochs query selects,
+	// built from the vault's reconciliation reports, oldest epoch first
+	VaultAnalytics(ctx context.Context, query Query) (*VaultAnalytics, error)
+	// VaultCollections returns what the vault records on-chain for every collection registered with it
+	VaultCollections(ctx context.Context, vaultID string) (*VaultCollections, error)
+	// EpochReport summarizes an epoch's distributions for stakeholders from the records stored for it, listing
+	// the top allocations of every vault
+	EpochReport(ctx context.Context, epochID string, top int) (*EpochReport, error)
+}
+
+// SnapshotStore reads the merkle trees distributed for an epoch
+type SnapshotStore interface {
+	// GetSnapshot returns the vault's tree for the epoch, wrapping merkle.ErrNotFound when there is none
+	GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error)
+}
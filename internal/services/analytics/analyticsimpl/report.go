@@ -0,0 +1,160 @@
+package analyticsimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EpochReport builds the epoch's report from what was stored for it: the statistics of every vault's distribution,
+// the top allocations of its tree, its reconciliation report and the gas of the transactions sent for the epoch.
+// Nothing is read from the chain or the subgraph. It wraps analytics.ErrNotFound when no vault was distributed
+// for the epoch.
+func (s *Service) EpochReport(ctx context.Context, epochID string, top int) (_ *analytics.EpochReport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "analytics.EpochReport", attribute.String("epoch.id", epochID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	epochNumber, ok := new(big.Int).SetString(epochID, 10)
+	if !ok || epochNumber.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", analytics.ErrInvalidInput, epochID)
+	}
+	if top == 0 {
+		top = analytics.DefaultReportTop
+	}
+	if top < 0 || top > analytics.MaxReportTop {
+		return nil, fmt.Errorf("%w: top must be between 1 and %d", analytics.ErrInvalidInput, analytics.MaxReportTop)
+	}
+	epochID = epochNumber.String()
+
+	stats, err := s.distributions.EpochStats(ctx, epochID, "")
+	if err != nil {
+		s.logger.Logf("ERROR failed to get distribution stats of epoch %s: %v", epochID, err)
+		return nil, fmt.Errorf("failed to get distribution stats: %w", err)
+	}
+	if len(stats.Vaults) == 0 {
+		return nil, fmt.Errorf("%w: no distribution recorded for epoch %s", analytics.ErrNotFound, epochID)
+	}
+	reconciled, err := s.reports.Reports(ctx, reconciliation.ReportFilter{EpochID: epochID, Limit: reconciliation.MaxReports})
+	if err != nil {
+		s.logger.Logf("ERROR failed to list reconciliation reports of epoch %s: %v", epochID, err)
+		return nil, fmt.Errorf("failed to list reconciliation reports: %w", err)
+	}
+	// latest first, so a vault reconciled again for the epoch reports its latest reconciliation
+	byVault := make(map[string]reconciliation.Report, len(reconciled.Reports))
+	for _, report := range reconciled.Reports {
+		if _, ok := byVault[utils.NormalizeAddress(report.VaultID)]; !ok {
+			byVault[utils.NormalizeAddress(report.VaultID)] = report
+		}
+	}
+
+	result := &analytics.EpochReport{
+		EpochID:     epochID,
+		GeneratedAt: time.Now().UTC(),
+		Vaults:      make([]analytics.VaultEpochReport, 0, len(stats.Vaults)),
+	}
+	total := big.NewInt(0)
+	for _, vaultStats := range stats.Vaults {
+		vault := analytics.VaultEpochReport{
+			VaultID:        vaultStats.VaultID,
+			MerkleRoot:     vaultStats.MerkleRoot,
+			Accounts:       vaultStats.Accounts,
+			Total:          vaultStats.Total,
+			Median:         vaultStats.Median,
+			Gini:           vaultStats.Gini,
+			DustAccounts:   vaultStats.DustAccounts,
+			TopAllocations: []analytics.TopAllocation{},
+		}
+		snapshot, err := s.snapshots.GetSnapshot(ctx, epochNumber, vaultStats.VaultID)
+		switch {
+		case err == nil:
+			vault.SnapshotBlock = snapshot.BlockNumber
+			vault.TopAllocations = topAllocations(snapshot, top)
+		case errors.Is(err, merkle.ErrNotFound):
+			s.logger.Logf("WARN no tree stored for vault %s epoch %s, reporting it without top allocations", vaultStats.VaultID, epochID)
+		default:
+			return nil, fmt.Errorf("failed to get merkle snapshot of vault %s: %w", vaultStats.VaultID, err)
+		}
+		if report, ok := byVault[utils.NormalizeAddress(vaultStats.VaultID)]; ok {
+			vault.Reconciliation = &analytics.ReconciliationSummary{
+				YieldAllocated: report.YieldAllocated,
+				Subsidies:      report.Subsidies,
+				Claimed:        report.Claimed,
+				Discrepancies:  len(report.Discrepancies),
+				Flagged:        report.Flagged,
+				ReconciledAt:   report.ReconciledAt,
+			}
+			if report.Flagged {
+				result.FlaggedVaults++
+			}
+		}
+
+		result.Vaults = append(result.Vaults, vault)
+		result.Participants += vaultStats.Accounts
+		total.Add(total, parseAmount(vaultStats.Total))
+	}
+	result.TotalSubsidies = total.String()
+
+	filter := gas.ReportFilter{EpochID: epochID}
+	spent, err := s.gas.Report(ctx, filter)
+	if err != nil {
+		s.logger.Logf("ERROR failed to build gas report of epoch %s: %v", epochID, err)
+		return nil, fmt.Errorf("failed to build gas report: %w", err)
+	}
+	result.Gas, result.GasByOperation = spent.Total, spent.ByOperation
+	if result.Transactions, err = s.gas.Transactions(ctx, filter); err != nil {
+		s.logger.Logf("ERROR failed to list transactions of epoch %s: %v", epochID, err)
+		return nil, fmt.Errorf("failed to list transactions: %w", err)
+	}
+	return result, nil
+}
+
+// topAllocations returns the top largest amounts the tree pays, each account's entries summed, ties broken by
+// account so reports of the same tree list the same accounts
+func topAllocations(snapshot *merkle.MerkleSnapshot, top int) []analytics.TopAllocation {
+	amounts := make(map[string]*big.Int)
+	total := big.NewInt(0)
+	for _, entry := range snapshot.Entries {
+		if entry.TotalEarned == nil {
+			continue
+		}
+		account := utils.NormalizeAddress(entry.Address)
+		if amounts[account] == nil {
+			amounts[account] = big.NewInt(0)
+		}
+		amounts[account].Add(amounts[account], entry.TotalEarned)
+		total.Add(total, entry.TotalEarned)
+	}
+
+	accounts := make([]string, 0, len(amounts))
+	for account := range amounts {
+		accounts = append(accounts, account)
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		if c := amounts[accounts[i]].Cmp(amounts[accounts[j]]); c != 0 {
+			return c > 0
+		}
+		return accounts[i] < accounts[j]
+	})
+
+	allocations := make([]analytics.TopAllocation, 0, min(top, len(accounts)))
+	for i, account := range accounts[:min(top, len(accounts))] {
+		allocations = append(allocations, analytics.TopAllocation{
+			Rank:    i + 1,
+			Account: account,
+			Amount:  amounts[account].String(),
+			Share:   rate(amounts[account], total),
+		})
+	}
+	return allocations
+}
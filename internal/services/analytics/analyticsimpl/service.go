@@ -0,0 +1,225 @@
+package analyticsimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	// epochTimesLimit is how many of the latest epochs' start and end times are read from the subgraph
+	epochTimesLimit = 1000
+	secondsPerYear  = 365 * 24 * 60 * 60
+)
+
+type Service struct {
+	reports        reconciliation.Service
+	epochs         epoch.Service
+	contractClient blockchain.BlockchainClient
+	distributions  subsidy.Service
+	snapshots      analytics.SnapshotStore
+	gas            gas.Service
+	logger         lgr.L
+}
+
+func New(
+	reports reconciliation.Service,
+	epochs epoch.Service,
+	contractClient blockchain.BlockchainClient,
+	distributions subsidy.Service,
+	snapshots analytics.SnapshotStore,
+	gasService gas.Service,
+	logger lgr.L,
+) *Service {
+	return &Service{
+		reports:        reports,
+		epochs:         epochs,
+		contractClient: contractClient,
+		distributions:  distributions,
+		snapshots:      snapshots,
+		gas:            gasService,
+		logger:         logger,
+	}
+}
+
+// VaultAnalytics builds the vault's series from its reconciliation reports. Trees pay what accounts earned in
+// total, so an epoch's distribution is what its tree pays on top of the previous reconciled epoch's. Epoch times
+// come from the subgraph and claims so far from the chain; the series is served without them when either cannot
+// be read.
+func (s *Service) VaultAnalytics(ctx context.Context, query analytics.Query) (_ *analytics.VaultAnalytics, err error) {
+	ctx, span := tracing.StartSpan(ctx, "analytics.VaultAnalytics", attribute.String("vault.id", query.VaultID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(query.VaultID) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", analytics.ErrInvalidInput, query.VaultID)
+	}
+	if query.ToEpoch != 0 && query.FromEpoch > query.ToEpoch {
+		return nil, fmt.Errorf("%w: from epoch %d is after to epoch %d", analytics.ErrInvalidInput, query.FromEpoch, query.ToEpoch)
+	}
+	vaultId := utils.NormalizeAddress(query.VaultID)
+
+	reports, err := s.vaultReports(ctx, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	times := s.epochTimes(ctx)
+
+	result := &analytics.VaultAnalytics{VaultID: vaultId, Epochs: []analytics.EpochPoint{}}
+	totalYield, totalDistributed := big.NewInt(0), big.NewInt(0)
+	previous := big.NewInt(0)
+	for _, report := range reports {
+		cumulative := parseAmount(report.Subsidies)
+		distributed := new(big.Int).Sub(cumulative, previous)
+		previous = cumulative
+
+		epochID, err := strconv.ParseUint(report.EpochID, 10, 64)
+		if err != nil || epochID < query.FromEpoch || (query.ToEpoch != 0 && epochID > query.ToEpoch) {
+			continue
+		}
+
+		point := analytics.EpochPoint{
+			EpochID:              report.EpochID,
+			YieldGenerated:       report.YieldAllocated,
+			SubsidiesDistributed: distributed.String(),
+			CumulativeSubsidies:  cumulative.String(),
+			Claimed:              report.Claimed,
+			ClaimRate:            rate(parseAmount(report.Claimed), cumulative),
+			AssetsDeposited:      report.AssetsDeposited,
+		}
+		if t, ok := times[report.EpochID]; ok {
+			point.StartTimestamp, point.EndTimestamp = t.start, t.end
+		}
+		point.EffectiveAPY = effectiveAPY(distributed, parseAmount(report.AssetsDeposited), point.StartTimestamp, point.EndTimestamp)
+
+		result.Epochs = append(result.Epochs, point)
+		totalYield.Add(totalYield, parseAmount(report.YieldAllocated))
+		totalDistributed.Add(totalDistributed, distributed)
+	}
+	result.TotalYieldGenerated = totalYield.String()
+	result.TotalSubsidiesDistributed = totalDistributed.String()
+
+	state, err := s.contractClient.GetOnChainEpochState(ctx, vaultId)
+	if err != nil {
+		s.logger.Logf("WARN failed to read on-chain claims of vault %s, serving analytics without them: %v", vaultId, err)
+		return result, nil
+	}
+	result.Claimed = state.TotalSubsidiesClaimed.String()
+	result.ClaimRate = rate(state.TotalSubsidiesClaimed, state.TotalSubsidies)
+	result.BlockNumber = state.Block.Number
+	return result, nil
+}
+
+// VaultCollections reads the vault's per-collection stats from the chain; the registered collections and their
+// stats are each read in one batch
+func (s *Service) VaultCollections(ctx context.Context, vaultID string) (_ *analytics.VaultCollections, err error) {
+	ctx, span := tracing.StartSpan(ctx, "analytics.VaultCollections", attribute.String("vault.id", vaultID))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultID) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", analytics.ErrInvalidInput, vaultID)
+	}
+	vaultId := utils.NormalizeAddress(vaultID)
+
+	stats, err := s.contractClient.GetCollectionStats(ctx, vaultId)
+	if err != nil {
+		s.logger.Logf("ERROR failed to read collection stats of vault %s: %v", vaultId, err)
+		return nil, fmt.Errorf("failed to read collection stats: %w", err)
+	}
+	result := &analytics.VaultCollections{
+		VaultID:     vaultId,
+		Collections: make([]analytics.CollectionStats, 0, len(stats.Collections)),
+		BlockNumber: stats.Block.Number,
+	}
+	for _, collection := range stats.Collections {
+		result.Collections = append(result.Collections, analytics.CollectionStats{
+			Collection:       collection.Collection,
+			YieldShareBps:    collection.YieldShareBps,
+			BorrowVolume:     collection.BorrowVolume.String(),
+			PerformanceScore: collection.PerformanceScore.String(),
+			YieldGenerated:   collection.YieldGenerated.String(),
+			AssetsDeposited:  collection.AssetsDeposited.String(),
+		})
+	}
+	return result, nil
+}
+
+// vaultReports returns every stored reconciliation report of the vault, oldest epoch first
+func (s *Service) vaultReports(ctx context.Context, vaultId string) ([]reconciliation.Report, error) {
+	var reports []reconciliation.Report
+	for {
+		page, err := s.reports.Reports(ctx, reconciliation.ReportFilter{
+			VaultID:   vaultId,
+			Limit:     reconciliation.MaxReports,
+			Offset:    len(reports),
+			Ascending: true,
+		})
+		if err != nil {
+			s.logger.Logf("ERROR failed to list reconciliation reports of vault %s: %v", vaultId, err)
+			return nil, fmt.Errorf("failed to list reconciliation reports: %w", err)
+		}
+		reports = append(reports, page.Reports...)
+		if len(page.Reports) == 0 || len(reports) >= page.Total {
+			return reports, nil
+		}
+	}
+}
+
+type epochTime struct {
+	start, end int64
+}
+
+// epochTimes returns the start and end times of the latest epochs by epoch number, none when the subgraph
+// cannot be read
+func (s *Service) epochTimes(ctx context.Context) map[string]epochTime {
+	times := make(map[string]epochTime)
+	response, err := s.epochs.ListEpochs(ctx, epoch.ListEpochsQuery{Limit: epochTimesLimit})
+	if err != nil {
+		s.logger.Logf("WARN failed to list epochs, serving analytics without epoch times: %v", err)
+		return times
+	}
+	for _, summary := range response.Epochs {
+		start, _ := strconv.ParseInt(summary.StartTimestamp, 10, 64)
+		end, _ := strconv.ParseInt(summary.EndTimestamp, 10, 64)
+		times[summary.EpochNumber] = epochTime{start: start, end: end}
+	}
+	return times
+}
+
+// rate returns part / whole with analytics.RateDecimals decimals, "0" when whole is not positive
+func rate(part, whole *big.Int) string {
+	if part == nil || whole == nil || whole.Sign() <= 0 {
+		return "0"
+	}
+	return new(big.Rat).SetFrac(part, whole).FloatString(analytics.RateDecimals)
+}
+
+// effectiveAPY annualizes distributed / deposited over the epoch's duration, empty when either is not known
+func effectiveAPY(distributed, deposited *big.Int, start, end int64) string {
+	if deposited.Sign() <= 0 || start <= 0 || end <= start {
+		return ""
+	}
+	apy := new(big.Rat).SetFrac(distributed, deposited)
+	apy.Mul(apy, new(big.Rat).SetFrac64(secondsPerYear, end-start))
+	return apy.FloatString(analytics.RateDecimals)
+}
+
+// parseAmount parses a stored wei amount, zero when it is empty or malformed
+func parseAmount(amount string) *big.Int {
+	value, ok := new(big.Int).SetString(amount, 10)
+	if !ok {
+		return big.NewInt(0)
+	}
+	return value
+}
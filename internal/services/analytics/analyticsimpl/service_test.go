@@ -0,0 +1,228 @@
+package analyticsimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/analytics"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+func newTestService(reports []reconciliation.Report, epochsErr error) *Service {
+	reportService := &reconciliation.ServiceMock{
+		ReportsFunc: func(ctx context.Context, filter reconciliation.ReportFilter) (*reconciliation.ReportList, error) {
+			page := reports[min(filter.Offset, len(reports)):min(filter.Offset+filter.Limit, len(reports))]
+			return &reconciliation.ReportList{Reports: page, Total: len(reports)}, nil
+		},
+	}
+	epochService := &epoch.ServiceMock{
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			if epochsErr != nil {
+				return nil, epochsErr
+			}
+			// epoch 2 lasted a week, epoch 3 is still running
+			return &epoch.ListEpochsResponse{Epochs: []epoch.EpochSummary{
+				{EpochNumber: "3", StartTimestamp: "1604800", EndTimestamp: "0"},
+				{EpochNumber: "2", StartTimestamp: "1000000", EndTimestamp: "1604800"},
+			}}, nil
+		},
+	}
+	contractClient := &blockchain.BlockchainClientMock{
+		GetOnChainEpochStateFunc: func(ctx context.Context, vaultAddress string) (*blockchain.OnChainEpochState, error) {
+			return &blockchain.OnChainEpochState{
+				Block:                 blockchain.BlockRef{Number: 200},
+				TotalSubsidies:        big.NewInt(4000),
+				TotalSubsidiesClaimed: big.NewInt(3000),
+			}, nil
+		},
+	}
+	return New(reportService, epochService, contractClient, nil, nil, nil, lgr.NoOp)
+}
+
+func testReports() []reconciliation.Report {
+	return []reconciliation.Report{
+		{EpochID: "1", YieldAllocated: "1500", Subsidies: "1000", Claimed: "0"},
+		{EpochID: "2", YieldAllocated: "2000", Subsidies: "2500", Claimed: "1000", AssetsDeposited: "100000"},
+		{EpochID: "3", YieldAllocated: "1800", Subsidies: "4000", Claimed: "2000", AssetsDeposited: "100000"},
+	}
+}
+
+func TestVaultAnalytics(t *testing.T) {
+	service := newTestService(testReports(), nil)
+
+	result, err := service.VaultAnalytics(context.Background(), analytics.Query{VaultID: testVault})
+	require.NoError(t, err)
+	require.Len(t, result.Epochs, 3)
+
+	first := result.Epochs[0]
+	assert.Equal(t, "1000", first.SubsidiesDistributed)
+	assert.Equal(t, "0.000000", first.ClaimRate)
+	assert.Empty(t, first.EffectiveAPY, "no deposits were recorded")
+
+	second := result.Epochs[1]
+	assert.Equal(t, "2000", second.YieldGenerated)
+	assert.Equal(t, "1500", second.SubsidiesDistributed)
+	assert.Equal(t, "2500", second.CumulativeSubsidies)
+	assert.Equal(t, "0.400000", second.ClaimRate)
+	assert.Equal(t, int64(604800), second.EndTimestamp-second.StartTimestamp)
+	// 1500 / 100000 over a week, 52.14 weeks a year
+	assert.Equal(t, "0.782143", second.EffectiveAPY)
+
+	assert.Empty(t, result.Epochs[2].EffectiveAPY, "the epoch has not ended")
+
+	assert.Equal(t, "5300", result.TotalYieldGenerated)
+	assert.Equal(t, "4000", result.TotalSubsidiesDistributed)
+	assert.Equal(t, "3000", result.Claimed)
+	assert.Equal(t, "0.750000", result.ClaimRate)
+	assert.Equal(t, uint64(200), result.BlockNumber)
+}
+
+func TestVaultAnalytics_EpochRange(t *testing.T) {
+	service := newTestService(testReports(), nil)
+
+	result, err := service.VaultAnalytics(context.Background(), analytics.Query{VaultID: testVault, FromEpoch: 2, ToEpoch: 2})
+	require.NoError(t, err)
+	require.Len(t, result.Epochs, 1)
+	// the distribution is still what the tree added to the epoch before the range
+	assert.Equal(t, "1500", result.Epochs[0].SubsidiesDistributed)
+	assert.Equal(t, "2000", result.TotalYieldGenerated)
+
+	_, err = service.VaultAnalytics(context.Background(), analytics.Query{VaultID: testVault, FromEpoch: 3, ToEpoch: 2})
+	assert.ErrorIs(t, err, analytics.ErrInvalidInput)
+
+	_, err = service.VaultAnalytics(context.Background(), analytics.Query{VaultID: "bad"})
+	assert.ErrorIs(t, err, analytics.ErrInvalidInput)
+}
+
+func TestVaultAnalytics_WithoutEpochTimes(t *testing.T) {
+	service := newTestService(testReports(), errors.New("subgraph unavailable"))
+
+	result, err := service.VaultAnalytics(context.Background(), analytics.Query{VaultID: testVault})
+	require.NoError(t, err)
+	require.Len(t, result.Epochs, 3)
+	assert.Zero(t, result.Epochs[1].StartTimestamp)
+	assert.Empty(t, result.Epochs[1].EffectiveAPY)
+	assert.Equal(t, "1500", result.Epochs[1].SubsidiesDistributed)
+}
+
+func TestVaultCollections(t *testing.T) {
+	service := newTestService(nil, nil)
+	service.contractClient.(*blockchain.BlockchainClientMock).GetCollectionStatsFunc = func(ctx context.Context, vaultAddress string) (*blockchain.VaultCollectionStats, error) {
+		assert.Equal(t, testVault, vaultAddress)
+		return &blockchain.VaultCollectionStats{
+			Block: blockchain.BlockRef{Number: 321},
+			Collections: []blockchain.CollectionStats{{
+				Collection:       "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				YieldShareBps:    2500,
+				BorrowVolume:     big.NewInt(700),
+				PerformanceScore: big.NewInt(9),
+				YieldGenerated:   big.NewInt(40),
+				AssetsDeposited:  big.NewInt(5000),
+			}},
+		}, nil
+	}
+
+	result, err := service.VaultCollections(context.Background(), "0x1234567890123456789012345678901234567890")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(321), result.BlockNumber)
+	assert.Equal(t, []analytics.CollectionStats{{
+		Collection:       "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		YieldShareBps:    2500,
+		BorrowVolume:     "700",
+		PerformanceScore: "9",
+		YieldGenerated:   "40",
+		AssetsDeposited:  "5000",
+	}}, result.Collections)
+
+	_, err = service.VaultCollections(context.Background(), "not-a-vault")
+	require.ErrorIs(t, err, analytics.ErrInvalidInput)
+}
+
+// snapshotStoreFunc serves the trees of an epoch by vault
+type snapshotStoreFunc func(epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error)
+
+func (f snapshotStoreFunc) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return f(epochNumber, vaultID)
+}
+
+func TestEpochReport(t *testing.T) {
+	const otherVault = "0x9999999999999999999999999999999999999999"
+	service := newTestService([]reconciliation.Report{
+		{VaultID: testVault, EpochID: "3", YieldAllocated: "1800", Subsidies: "600", Claimed: "100", Flagged: true,
+			Discrepancies: []reconciliation.Discrepancy{{}}},
+	}, nil)
+	service.distributions = &subsidy.ServiceMock{
+		EpochStatsFunc: func(ctx context.Context, epochNumber, vaultId string) (*subsidy.EpochStats, error) {
+			if epochNumber != "3" {
+				return &subsidy.EpochStats{EpochNumber: epochNumber, Vaults: []subsidy.DistributionStats{}}, nil
+			}
+			return &subsidy.EpochStats{EpochNumber: "3", Vaults: []subsidy.DistributionStats{
+				{VaultID: testVault, MerkleRoot: "root-a", Accounts: 3, Total: "600"},
+				{VaultID: otherVault, MerkleRoot: "root-b", Accounts: 1, Total: "50"},
+			}}, nil
+		},
+	}
+	service.snapshots = snapshotStoreFunc(func(epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+		if vaultID == otherVault {
+			return nil, merkle.ErrNotFound
+		}
+		// B earns in two collections, A and C tie
+		return &merkle.MerkleSnapshot{BlockNumber: 42, Entries: []merkle.MerkleEntry{
+			{Address: "0xcccccccccccccccccccccccccccccccccccccccc", TotalEarned: big.NewInt(100)},
+			{Address: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", TotalEarned: big.NewInt(250)},
+			{Address: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", TotalEarned: big.NewInt(100)},
+			{Address: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", TotalEarned: big.NewInt(150)},
+		}}, nil
+	})
+	service.gas = &gas.ServiceMock{
+		ReportFunc: func(ctx context.Context, filter gas.ReportFilter) (*gas.Report, error) {
+			assert.Equal(t, "3", filter.EpochID)
+			return &gas.Report{Total: gas.Rollup{Transactions: 1, GasUsed: 21000, Cost: "420000"}}, nil
+		},
+		TransactionsFunc: func(ctx context.Context, filter gas.ReportFilter) ([]gas.Usage, error) {
+			return []gas.Usage{{Action: "updateMerkleRoot", EpochID: "3", TxHash: "0xabc", GasUsed: 21000, Cost: "420000"}}, nil
+		},
+	}
+	ctx := context.Background()
+
+	report, err := service.EpochReport(ctx, "3", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.Participants)
+	assert.Equal(t, "650", report.TotalSubsidies)
+	assert.Equal(t, 1, report.FlaggedVaults)
+	assert.Equal(t, uint64(21000), report.Gas.GasUsed)
+	require.Len(t, report.Transactions, 1)
+	assert.Equal(t, "0xabc", report.Transactions[0].TxHash)
+
+	require.Len(t, report.Vaults, 2)
+	vault := report.Vaults[0]
+	assert.Equal(t, int64(42), vault.SnapshotBlock)
+	assert.Equal(t, []analytics.TopAllocation{
+		{Rank: 1, Account: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Amount: "400", Share: "0.666667"},
+		{Rank: 2, Account: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Amount: "100", Share: "0.166667"},
+	}, vault.TopAllocations)
+	require.NotNil(t, vault.Reconciliation)
+	assert.Equal(t, 1, vault.Reconciliation.Discrepancies)
+	assert.Empty(t, report.Vaults[1].TopAllocations, "a vault without a stored tree is reported without them")
+	assert.Nil(t, report.Vaults[1].Reconciliation)
+
+	_, err = service.EpochReport(ctx, "4", 0)
+	assert.ErrorIs(t, err, analytics.ErrNotFound)
+	_, err = service.EpochReport(ctx, "3", analytics.MaxReportTop+1)
+	assert.ErrorIs(t, err, analytics.ErrInvalidInput)
+	_, err = service.EpochReport(ctx, "latest", 0)
+	assert.ErrorIs(t, err, analytics.ErrInvalidInput)
+}
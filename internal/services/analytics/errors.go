@@ -0,0 +1,9 @@
+package analytics
+
+import "errors"
+
+// Predefined error types for analytics operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+	ErrNotFound     = errors.New("not found")
+)
@@ -0,0 +1,115 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/gas"
+)
+
+// RateDecimals is how many decimals claim rates and APYs are given with
+const RateDecimals = 6
+
+const (
+	// DefaultReportTop is how many of a vault's largest allocations an epoch report lists when not asked for
+	DefaultReportTop = 10
+	// MaxReportTop is the most allocations of a vault an epoch report lists
+	MaxReportTop = 100
+)
+
+// Query selects the epochs of a vault's analytics, zero epochs leave the range open
+type Query struct {
+	VaultID   string
+	FromEpoch uint64 // first epoch included
+	ToEpoch   uint64 // last epoch included
+}
+
+// EpochPoint is one reconciled epoch of a vault's analytics series. Amounts are wei and rates are decimal
+// fractions, 0.05 being 5%.
+type EpochPoint struct {
+	EpochID              string `json:"epochId" example:"3"`
+	StartTimestamp       int64  `json:"startTimestamp,omitempty"` // unix time the epoch started, when the subgraph knows it
+	EndTimestamp         int64  `json:"endTimestamp,omitempty"`   // unix time the epoch ended, when the subgraph knows it
+	YieldGenerated       string `json:"yieldGenerated"`           // yield the vault allocated to the epoch
+	SubsidiesDistributed string `json:"subsidiesDistributed"`     // what the epoch's tree pays on top of the previous reconciled epoch's
+	CumulativeSubsidies  string `json:"cumulativeSubsidies"`      // what the epoch's tree pays in total
+	Claimed              string `json:"claimed"`                  // claimed from the vault when the epoch was reconciled
+	ClaimRate            string `json:"claimRate"`                // claimed / cumulativeSubsidies
+	AssetsDeposited      string `json:"assetsDeposited,omitempty"`
+	// EffectiveAPY is subsidiesDistributed / assetsDeposited annualized over the epoch's duration. It is left
+	// out when the deposits or the epoch's start and end are not known.
+	EffectiveAPY string `json:"effectiveApy,omitempty" example:"0.042"`
+}
+
+// VaultAnalytics is a vault's per-epoch series with totals over it and the claims made on-chain so far
+type VaultAnalytics struct {
+	VaultID                   string       `json:"vaultId"`
+	Epochs                    []EpochPoint `json:"epochs"` // oldest first
+	TotalYieldGenerated       string       `json:"totalYieldGenerated"`
+	TotalSubsidiesDistributed string       `json:"totalSubsidiesDistributed"`
+	Claimed                   string       `json:"claimed"`     // claimed from the vault's subsidies so far, read on-chain
+	ClaimRate                 string       `json:"claimRate"`   // claimed / subsidies the vault's latest root pays
+	BlockNumber               uint64       `json:"blockNumber"` // block claimed was read at
+}
+
+// CollectionStats is what a vault records on-chain for one of its collections. Amounts are wei.
+type CollectionStats struct {
+	Collection       string `json:"collection"`
+	YieldShareBps    uint16 `json:"yieldShareBps" example:"2500"` // share of the vault's yield the registry gives the collection
+	BorrowVolume     string `json:"borrowVolume"`
+	PerformanceScore string `json:"performanceScore"`
+	YieldGenerated   string `json:"yieldGenerated"`
+	AssetsDeposited  string `json:"assetsDeposited"`
+}
+
+// VaultCollections is the stats of every collection registered with a vault, all read at BlockNumber
+type VaultCollections struct {
+	VaultID     string            `json:"vaultId"`
+	Collections []CollectionStats `json:"collections"`
+	BlockNumber uint64            `json:"blockNumber"`
+}
+
+// EpochReport is what an epoch's distributions paid, what they cost in gas and how they reconciled, built from the
+// records stored for the epoch. Amounts are wei.
+type EpochReport struct {
+	EpochID        string                `json:"epochId" example:"3"`
+	GeneratedAt    time.Time             `json:"generatedAt"`
+	Vaults         []VaultEpochReport    `json:"vaults"`         // by vault address
+	Participants   int                   `json:"participants"`   // accounts with a leaf, counted once per vault
+	TotalSubsidies string                `json:"totalSubsidies"` // what the vaults' trees pay in total
+	Gas            gas.Rollup            `json:"gas"`
+	GasByOperation map[string]gas.Rollup `json:"gasByOperation"`
+	Transactions   []gas.Usage           `json:"transactions"`  // mined transactions sent for the epoch, oldest first
+	FlaggedVaults  int                   `json:"flaggedVaults"` // vaults whose reconciliation flagged discrepancies
+}
+
+// VaultEpochReport is one vault's distribution in an epoch report
+type VaultEpochReport struct {
+	VaultID        string                 `json:"vaultId"`
+	MerkleRoot     string                 `json:"merkleRoot"`
+	SnapshotBlock  int64                  `json:"snapshotBlock,omitempty"` // block the subgraph state was read at
+	Accounts       int                    `json:"accounts"`
+	Total          string                 `json:"total"`
+	Median         string                 `json:"median"`
+	Gini           string                 `json:"gini" example:"0.412300"`
+	DustAccounts   int                    `json:"dustAccounts"`
+	TopAllocations []TopAllocation        `json:"topAllocations"`           // largest first
+	Reconciliation *ReconciliationSummary `json:"reconciliation,omitempty"` // set once the vault was reconciled for the epoch
+}
+
+// TopAllocation is one of a vault's largest allocations in the epoch's tree
+type TopAllocation struct {
+	Rank    int    `json:"rank"`
+	Account string `json:"account"`
+	Amount  string `json:"amount"` // what the tree pays the account in total
+	Share   string `json:"share"`  // amount / the vault's total
+}
+
+// ReconciliationSummary is how a vault's distribution for the epoch reconciled with the yield allocated to it
+type ReconciliationSummary struct {
+	YieldAllocated string    `json:"yieldAllocated"`
+	Subsidies      string    `json:"subsidies"`
+	Claimed        string    `json:"claimed"`
+	Discrepancies  int       `json:"discrepancies"`
+	Flagged        bool      `json:"flagged"`
+	ReconciledAt   time.Time `json:"reconciledAt"`
+}
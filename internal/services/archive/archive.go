@@ -0,0 +1,13 @@
+package archive
+
+import "context"
+
+//go:generate moq -out archive_mocks.go . Service
+
+// Service keeps a columnar copy of every epoch distribution outside the serving store, so historical holder
+// and borrower data can be analyzed without querying the server or the subgraph
+type Service interface {
+	// WriteDistribution writes the distribution as a Parquet file, replacing the file of an earlier
+	// distribution of the vault's epoch, and returns where it was written
+	WriteDistribution(ctx context.Context, distribution Distribution) (string, error)
+}
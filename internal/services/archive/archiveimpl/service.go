@@ -0,0 +1,132 @@
+package archiveimpl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/big"
+	"path"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/objectstore"
+	"github.com/andrey/epoch-server/internal/infra/parquet"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/archive"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// columns of an archived distribution, one row per allocation. The distribution's columns repeat on every row
+// so a file is one flat table. Amounts are wei as decimal strings, they do not fit 64-bit integers.
+var columns = []parquet.Column{
+	{Name: "vault", Type: parquet.String},
+	{Name: "epoch", Type: parquet.Int64},
+	{Name: "snapshot_block", Type: parquet.Int64},
+	{Name: "block_hash", Type: parquet.String},
+	{Name: "block_strategy", Type: parquet.String},
+	{Name: "valued_at", Type: parquet.Int64},
+	{Name: "merkle_root", Type: parquet.String},
+	{Name: "fingerprint", Type: parquet.String},
+	{Name: "account", Type: parquet.String},
+	{Name: "collection", Type: parquet.String},
+	{Name: "debt", Type: parquet.String},
+	{Name: "valued", Type: parquet.String},
+	{Name: "amount", Type: parquet.String},
+}
+
+// contentType of archived files
+const contentType = "application/vnd.apache.parquet"
+
+type Service struct {
+	store  objectstore.Store
+	logger lgr.L
+}
+
+// New returns the archive ARCHIVE_TARGET selects, S3 credentials are read from the AWS environment
+func New(ctx context.Context, logger lgr.L, cfg *config.Config) (*Service, error) {
+	var store objectstore.Store
+	switch cfg.Archive.Target {
+	case archive.TargetLocal:
+		store = objectstore.NewLocal(cfg.Archive.Dir)
+	case archive.TargetS3:
+		s3Store, err := objectstore.NewS3(ctx, objectstore.S3Config{
+			Bucket:   cfg.Archive.S3Bucket,
+			Prefix:   cfg.Archive.S3Prefix,
+			Region:   cfg.Archive.S3Region,
+			Endpoint: cfg.Archive.S3Endpoint,
+		})
+		if err != nil {
+			return nil, err
+		}
+		store = s3Store
+	default:
+		return nil, fmt.Errorf("%w: archive target %q writes nowhere", archive.ErrInvalidInput, cfg.Archive.Target)
+	}
+	return &Service{store: store, logger: logger}, nil
+}
+
+// WriteDistribution writes the distribution's allocations as a Parquet file at
+// vault=<vault>/epoch=<epoch>/distribution.parquet, partitioned the way query engines discover
+func (s *Service) WriteDistribution(ctx context.Context, distribution archive.Distribution) (_ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "archive.WriteDistribution",
+		attribute.String("vault.id", distribution.VaultID), attribute.Int("archive.rows", len(distribution.Allocations)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if distribution.VaultID == "" || distribution.EpochNumber == nil || !distribution.EpochNumber.IsInt64() {
+		return "", fmt.Errorf("%w: distribution needs a vault and an epoch", archive.ErrInvalidInput)
+	}
+	vault := utils.NormalizeAddress(distribution.VaultID)
+
+	data, err := encode(vault, distribution)
+	if err != nil {
+		return "", err
+	}
+	key := path.Join("vault="+vault, "epoch="+distribution.EpochNumber.String(), "distribution.parquet")
+	if err := s.store.Put(ctx, key, contentType, bytes.NewReader(data)); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", key, err)
+	}
+
+	location := s.store.Location(key)
+	s.logger.Logf("INFO archived %d allocations of vault %s epoch %s to %s",
+		len(distribution.Allocations), vault, distribution.EpochNumber.String(), location)
+	return location, nil
+}
+
+// encode returns the distribution as a Parquet file
+func encode(vault string, distribution archive.Distribution) ([]byte, error) {
+	table := parquet.NewTable(columns...)
+	for _, allocation := range distribution.Allocations {
+		err := table.Append(
+			vault,
+			distribution.EpochNumber.Int64(),
+			int64(distribution.BlockNumber),
+			distribution.BlockHash,
+			distribution.BlockStrategy,
+			distribution.ValuedAt,
+			distribution.MerkleRoot,
+			distribution.Fingerprint,
+			utils.NormalizeAddress(allocation.Account),
+			utils.NormalizeAddress(allocation.Collection),
+			amountOrEmpty(allocation.Debt),
+			amountOrEmpty(allocation.Valued),
+			amountOrEmpty(allocation.Amount),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode allocation of %s: %w", allocation.Account, err)
+		}
+	}
+	data, err := table.Encode()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode parquet file: %w", err)
+	}
+	return data, nil
+}
+
+// amountOrEmpty formats a wei amount, empty when it is nil
+func amountOrEmpty(amount *big.Int) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
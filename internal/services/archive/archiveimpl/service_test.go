@@ -0,0 +1,103 @@
+package archiveimpl
+
+import (
+	"context"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/archive"
+)
+
+func newTestDistribution() archive.Distribution {
+	return archive.Distribution{
+		VaultID:     "0x1111111111111111111111111111111111111ABC",
+		EpochNumber: big.NewInt(5),
+		BlockNumber: 100,
+		MerkleRoot:  "ab",
+		Allocations: []archive.Allocation{
+			{Account: "0x742d35cc6634c0532925a3b844bc454e4438f44e", Debt: big.NewInt(0), Valued: big.NewInt(10), Amount: big.NewInt(8)},
+			{Account: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", Valued: big.NewInt(5), Amount: big.NewInt(5)},
+		},
+	}
+}
+
+func TestService_WriteDistribution_Local(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Archive.Target = archive.TargetLocal
+	cfg.Archive.Dir = t.TempDir()
+	service, err := New(context.Background(), lgr.NoOp, cfg)
+	require.NoError(t, err)
+
+	location, err := service.WriteDistribution(context.Background(), newTestDistribution())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(cfg.Archive.Dir, "vault=0x1111111111111111111111111111111111111abc", "epoch=5", "distribution.parquet"), location)
+	first, err := os.ReadFile(location)
+	require.NoError(t, err)
+	assert.Equal(t, "PAR1", string(first[:4]))
+
+	// a distribution of the same epoch replaces the file, leaving no temporary files behind
+	distribution := newTestDistribution()
+	distribution.Allocations = distribution.Allocations[:1]
+	_, err = service.WriteDistribution(context.Background(), distribution)
+	require.NoError(t, err)
+	second, err := os.ReadFile(location)
+	require.NoError(t, err)
+	assert.NotEqual(t, first, second)
+	files, err := os.ReadDir(filepath.Dir(location))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	distribution.EpochNumber = nil
+	_, err = service.WriteDistribution(context.Background(), distribution)
+	assert.ErrorIs(t, err, archive.ErrInvalidInput)
+}
+
+func TestService_WriteDistribution_S3(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	s3Server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer s3Server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "test")
+
+	cfg := &config.Config{}
+	cfg.Archive.Target = archive.TargetS3
+	cfg.Archive.S3Bucket = "analytics"
+	cfg.Archive.S3Prefix = "epochs/mainnet"
+	cfg.Archive.S3Region = "us-east-1"
+	cfg.Archive.S3Endpoint = s3Server.URL
+	service, err := New(context.Background(), lgr.NoOp, cfg)
+	require.NoError(t, err)
+
+	location, err := service.WriteDistribution(context.Background(), newTestDistribution())
+	require.NoError(t, err)
+	key := "epochs/mainnet/vault=0x1111111111111111111111111111111111111abc/epoch=5/distribution.parquet"
+	assert.Equal(t, "s3://analytics/"+key, location)
+	assert.Equal(t, "/analytics/"+key, gotPath, "S3-compatible endpoints are addressed path-style")
+	assert.Equal(t, "PAR1", string(gotBody[:4]))
+}
+
+func TestNew_RequiresTarget(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Archive.Target = archive.TargetNone
+	_, err := New(context.Background(), lgr.NoOp, cfg)
+	assert.ErrorIs(t, err, archive.ErrInvalidInput)
+}
@@ -0,0 +1,8 @@
+package archive
+
+import "errors"
+
+// Predefined error types for archive operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
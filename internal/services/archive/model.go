@@ -0,0 +1,32 @@
+package archive
+
+import "math/big"
+
+// Targets the archive writes to
+const (
+	TargetNone  = "none"
+	TargetLocal = "local"
+	TargetS3    = "s3"
+)
+
+// Distribution is an epoch distribution as it is archived
+type Distribution struct {
+	VaultID       string
+	EpochNumber   *big.Int
+	BlockNumber   uint64 // snapshot block the subgraph was read at
+	BlockHash     string
+	BlockStrategy string
+	ValuedAt      int64 // unix time accrual was valued at
+	MerkleRoot    string
+	Fingerprint   string
+	Allocations   []Allocation
+}
+
+// Allocation is what an account earned on a collection in a distribution. Amounts are wei.
+type Allocation struct {
+	Account    string
+	Collection string
+	Debt       *big.Int // the account's borrow volume, zero when the subgraph does not report it
+	Valued     *big.Int // as valued from the subgraph, before caps, redistribution and rounding
+	Amount     *big.Int // as distributed
+}
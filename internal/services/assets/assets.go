@@ -0,0 +1,29 @@
+package assets
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+)
+
+//go:generate moq -out assets_mocks.go . Service
+
+// Service resolves the ERC-20 asset a vault's wei amounts are denominated in, so amounts can be shown in whole
+// tokens without every consumer converting them and guessing the decimals.
+type Service interface {
+	// GetAsset returns the asset of the vault, read from the chain once and cached after that
+	GetAsset(ctx context.Context, vaultId string) (*Asset, error)
+}
+
+// Describe returns wei of the vault's asset for log lines with the amount in whole tokens, e.g.
+// "1500000 wei (1.5 USDC)", or only the wei when service is nil or the asset cannot be read
+func Describe(ctx context.Context, service Service, vaultId string, wei *big.Int) string {
+	if service == nil {
+		return wei.String() + " wei"
+	}
+	asset, err := service.GetAsset(ctx, vaultId)
+	if err != nil {
+		return wei.String() + " wei"
+	}
+	return fmt.Sprintf("%s wei (%s %s)", wei, asset.Format(wei), asset.Symbol)
+}
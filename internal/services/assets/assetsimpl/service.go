@@ -0,0 +1,66 @@
+package assetsimpl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Service struct {
+	blockchainClient blockchain.BlockchainClient
+	logger           lgr.L
+
+	mu     sync.RWMutex
+	assets map[string]assets.Asset // by normalized vault address
+}
+
+func New(blockchainClient blockchain.BlockchainClient, logger lgr.L) *Service {
+	return &Service{
+		blockchainClient: blockchainClient,
+		logger:           logger,
+		assets:           make(map[string]assets.Asset),
+	}
+}
+
+// GetAsset returns the asset of the vault. A vault's asset never changes, so it is read from the chain once;
+// a failed read is not cached and is retried on the next call.
+func (s *Service) GetAsset(ctx context.Context, vaultId string) (_ *assets.Asset, err error) {
+	ctx, span := tracing.StartSpan(ctx, "assets.GetAsset", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	vault, err := utils.ValidateAndNormalizeAddress(vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid vault address %q", assets.ErrInvalidInput, vaultId)
+	}
+
+	s.mu.RLock()
+	asset, ok := s.assets[vault]
+	s.mu.RUnlock()
+	if ok {
+		return &asset, nil
+	}
+
+	metadata, err := s.blockchainClient.GetAssetMetadata(ctx, vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset of vault %s: %w", vault, err)
+	}
+	asset = assets.Asset{
+		VaultAddress: vault,
+		Address:      metadata.Address,
+		Symbol:       metadata.Symbol,
+		Decimals:     metadata.Decimals,
+	}
+	s.logger.Logf("INFO vault %s holds %s (%s), %d decimals", vault, asset.Symbol, asset.Address, asset.Decimals)
+
+	s.mu.Lock()
+	s.assets[vault] = asset
+	s.mu.Unlock()
+	return &asset, nil
+}
@@ -0,0 +1,52 @@
+package assetsimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/assets"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+func TestService_GetAssetIsCached(t *testing.T) {
+	fail := false
+	client := &blockchain.BlockchainClientMock{
+		GetAssetMetadataFunc: func(ctx context.Context, vaultAddress string) (*blockchain.AssetMetadata, error) {
+			if fail {
+				return nil, fmt.Errorf("rpc unavailable")
+			}
+			return &blockchain.AssetMetadata{Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", Symbol: "USDC", Decimals: 6}, nil
+		},
+	}
+	service := New(client, lgr.NoOp)
+	ctx := context.Background()
+
+	fail = true
+	_, err := service.GetAsset(ctx, testVault)
+	require.Error(t, err)
+	assert.Equal(t, "1500000 wei", assets.Describe(ctx, service, testVault, big.NewInt(1500000)), "unresolved assets show wei only")
+
+	fail = false
+	asset, err := service.GetAsset(ctx, "0x1234567890123456789012345678901234567890")
+	require.NoError(t, err)
+	assert.Equal(t, assets.Asset{
+		VaultAddress: testVault,
+		Address:      "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+		Symbol:       "USDC",
+		Decimals:     6,
+	}, *asset)
+	assert.Equal(t, "1500000 wei (1.5 USDC)", assets.Describe(ctx, service, testVault, big.NewInt(1500000)))
+	assert.Equal(t, "7 wei", assets.Describe(ctx, nil, testVault, big.NewInt(7)))
+	assert.Len(t, client.GetAssetMetadataCalls(), 3, "a failed read is retried, a resolved asset is not read again")
+
+	_, err = service.GetAsset(ctx, "vault")
+	assert.ErrorIs(t, err, assets.ErrInvalidInput)
+}
@@ -0,0 +1,8 @@
+package assets
+
+import "errors"
+
+var (
+	// ErrInvalidInput is returned when the vault address is malformed
+	ErrInvalidInput = errors.New("invalid input")
+)
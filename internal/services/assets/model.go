@@ -0,0 +1,23 @@
+package assets
+
+import (
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+)
+
+// NativeDecimals are the decimals of the chain's native currency, which gas and signer balances are wei of
+const NativeDecimals = 18
+
+// Asset is the ERC-20 a vault holds; the vault's subsidies, yield and claims are wei of it
+type Asset struct {
+	VaultAddress string `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	Address      string `json:"address" example:"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"`
+	Symbol       string `json:"symbol" example:"USDC"`
+	Decimals     uint8  `json:"decimals" example:"6"`
+}
+
+// Format returns wei of the asset in whole tokens, e.g. "1.5" for 1500000 wei of a 6 decimal token
+func (a Asset) Format(wei *big.Int) string {
+	return utils.FormatUnits(wei, a.Decimals)
+}
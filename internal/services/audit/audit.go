@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+//go:generate moq -out audit_mocks.go . Recorder Service
+
+// Recorder appends entries to the audit log
+type Recorder interface {
+	// Record appends an entry, entries are never modified once written
+	Record(ctx context.Context, entry Entry) error
+}
+
+// Service defines the interface for audit log operations
+type Service interface {
+	Recorder
+
+	// List returns entries matching the filter, newest first
+	List(ctx context.Context, filter Filter) (*ListResponse, error)
+}
+
+type actorKey struct{}
+
+// WithActor returns a context attributing state-changing actions to actor
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by WithActor, or "system" when none was set
+func ActorFromContext(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey{}).(string); ok && actor != "" {
+		return actor
+	}
+	return "system"
+}
+
+type credentialKey struct{}
+
+// WithCredential returns a context recording the credential the request authenticated with, see KeyID
+func WithCredential(ctx context.Context, credential string) context.Context {
+	return context.WithValue(ctx, credentialKey{}, credential)
+}
+
+// CredentialFromContext returns the credential set by WithCredential, empty when the request was not authenticated
+func CredentialFromContext(ctx context.Context) string {
+	credential, _ := ctx.Value(credentialKey{}).(string)
+	return credential
+}
+
+// KeyID identifies an API key in audit records without revealing it
+func KeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key:" + hex.EncodeToString(sum[:4])
+}
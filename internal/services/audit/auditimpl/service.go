@@ -0,0 +1,124 @@
+package auditimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/go-pkgz/lgr"
+)
+
+const maxListLimit = 1000
+
+// cursorPattern matches the cursors List hands out, an entry's timestamp and ID
+var cursorPattern = regexp.MustCompile(`^[0-9]{20}:[0-9a-f]+$`)
+
+type Service struct {
+	store  *Store
+	logger lgr.L
+	now    func() time.Time
+}
+
+func New(db storage.DB, logger lgr.L) *Service {
+	return &Service{
+		store:  NewStore(db, logger),
+		logger: logger,
+		now:    time.Now,
+	}
+}
+
+// Record stamps the entry with an ID and timestamp and appends it to the log
+func (s *Service) Record(ctx context.Context, entry audit.Entry) (err error) {
+	_, span := tracing.StartSpan(ctx, "audit.Record")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if entry.Action == "" {
+		return fmt.Errorf("%w: action cannot be empty", audit.ErrInvalidInput)
+	}
+	if entry.Result == "" {
+		return fmt.Errorf("%w: result cannot be empty", audit.ErrInvalidInput)
+	}
+
+	entry.ID = newEntryID()
+	entry.Timestamp = s.now().UTC()
+	if entry.Actor == "" {
+		entry.Actor = audit.ActorFromContext(ctx)
+	}
+
+	if err := s.store.AppendEntry(entry); err != nil {
+		s.logger.Logf("ERROR failed to record audit entry for %s: %v", entry.Action, err)
+		return err
+	}
+
+	s.logger.Logf("DEBUG recorded audit entry %s: %s by %s (%s)", entry.ID, entry.Action, entry.Actor, entry.Result)
+	return nil
+}
+
+// List returns a page of the entries matching filter, newest first unless filter.Ascending is set
+func (s *Service) List(ctx context.Context, filter audit.Filter) (_ *audit.ListResponse, err error) {
+	_, span := tracing.StartSpan(ctx, "audit.List")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if filter.Limit < 1 || filter.Limit > maxListLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", audit.ErrInvalidInput, maxListLimit)
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("%w: until must not be before since", audit.ErrInvalidInput)
+	}
+
+	if filter.Cursor != "" && !cursorPattern.MatchString(filter.Cursor) {
+		return nil, fmt.Errorf("%w: malformed cursor %q", audit.ErrInvalidInput, filter.Cursor)
+	}
+
+	query := entryQuery{
+		since:     filter.Since,
+		until:     filter.Until,
+		limit:     filter.Limit,
+		after:     filter.Cursor,
+		ascending: filter.Ascending,
+	}
+	entries, next, err := s.store.ListEntries(query, func(entry audit.Entry) bool {
+		return matches(entry, filter)
+	})
+	if err != nil {
+		s.logger.Logf("ERROR failed to list audit entries: %v", err)
+		return nil, err
+	}
+
+	return &audit.ListResponse{
+		Entries:    entries,
+		Count:      len(entries),
+		NextCursor: next,
+	}, nil
+}
+
+func matches(entry audit.Entry, filter audit.Filter) bool {
+	if filter.Action != "" && entry.Action != filter.Action {
+		return false
+	}
+	if filter.Actor != "" && entry.Actor != filter.Actor {
+		return false
+	}
+	if filter.Result != "" && entry.Result != filter.Result {
+		return false
+	}
+	if filter.TxHash != "" && !strings.EqualFold(entry.TxHash, filter.TxHash) {
+		return false
+	}
+	return true
+}
+
+func newEntryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
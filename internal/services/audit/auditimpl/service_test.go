@@ -0,0 +1,167 @@
+package auditimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(t *testing.T) *Service {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() {
+		assert.NoError(t, badgerDB.Close())
+	})
+	return New(db, lgr.NoOp)
+}
+
+func TestService_RecordAndList(t *testing.T) {
+	service := newTestService(t)
+	base := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := base
+	service.now = func() time.Time { return clock }
+
+	apiCtx := audit.WithActor(context.Background(), "api:10.0.0.1")
+	schedulerCtx := audit.WithActor(context.Background(), "scheduler")
+
+	records := []struct {
+		ctx   context.Context
+		entry audit.Entry
+	}{
+		{schedulerCtx, audit.Entry{Action: "startEpoch", TxHash: "0xAA", Result: audit.ResultSuccess}},
+		{apiCtx, audit.Entry{Action: "updateMerkleRoot", TxHash: "0xbb", Result: audit.ResultFailed, RevertReason: "NotAuthorized"}},
+		{schedulerCtx, audit.Entry{Action: "updateMerkleRoot", TxHash: "0xcc", Result: audit.ResultSuccess}},
+		{schedulerCtx, audit.Entry{Action: "endEpochWithSubsidies", TxHash: "0xdd", Result: audit.ResultSuccess}},
+	}
+	for i, r := range records {
+		clock = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, service.Record(r.ctx, r.entry))
+	}
+
+	tests := []struct {
+		name     string
+		filter   audit.Filter
+		expected []string
+	}{
+		{name: "all_newest_first", filter: audit.Filter{Limit: 10}, expected: []string{"0xdd", "0xcc", "0xbb", "0xAA"}},
+		{name: "limit", filter: audit.Filter{Limit: 2}, expected: []string{"0xdd", "0xcc"}},
+		{name: "action", filter: audit.Filter{Action: "updateMerkleRoot", Limit: 10}, expected: []string{"0xcc", "0xbb"}},
+		{name: "actor", filter: audit.Filter{Actor: "api:10.0.0.1", Limit: 10}, expected: []string{"0xbb"}},
+		{name: "result", filter: audit.Filter{Result: audit.ResultFailed, Limit: 10}, expected: []string{"0xbb"}},
+		{name: "tx_hash_case_insensitive", filter: audit.Filter{TxHash: "0xaa", Limit: 10}, expected: []string{"0xAA"}},
+		{
+			name:     "time_range",
+			filter:   audit.Filter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute), Limit: 10},
+			expected: []string{"0xcc", "0xbb"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := service.List(context.Background(), tt.filter)
+			require.NoError(t, err)
+
+			hashes := make([]string, 0, len(response.Entries))
+			for _, entry := range response.Entries {
+				hashes = append(hashes, entry.TxHash)
+			}
+			assert.Equal(t, tt.expected, hashes)
+			assert.Equal(t, len(tt.expected), response.Count)
+		})
+	}
+
+	t.Run("entry_fields", func(t *testing.T) {
+		response, err := service.List(context.Background(), audit.Filter{TxHash: "0xbb", Limit: 1})
+		require.NoError(t, err)
+		require.Len(t, response.Entries, 1)
+
+		entry := response.Entries[0]
+		assert.NotEmpty(t, entry.ID)
+		assert.Equal(t, "api:10.0.0.1", entry.Actor)
+		assert.Equal(t, "NotAuthorized", entry.RevertReason)
+		assert.Equal(t, base.Add(time.Minute), entry.Timestamp)
+	})
+}
+
+func TestService_Validation(t *testing.T) {
+	service := newTestService(t)
+	ctx := context.Background()
+
+	assert.ErrorIs(t, service.Record(ctx, audit.Entry{Result: audit.ResultSuccess}), audit.ErrInvalidInput)
+	assert.ErrorIs(t, service.Record(ctx, audit.Entry{Action: "startEpoch"}), audit.ErrInvalidInput)
+
+	_, err := service.List(ctx, audit.Filter{Limit: 0})
+	assert.ErrorIs(t, err, audit.ErrInvalidInput)
+
+	now := time.Now()
+	_, err = service.List(ctx, audit.Filter{Since: now, Until: now.Add(-time.Hour), Limit: 10})
+	assert.ErrorIs(t, err, audit.ErrInvalidInput)
+}
+
+func TestService_ListPagesWithCursor(t *testing.T) {
+	service := newTestService(t)
+	base := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	clock := base
+	service.now = func() time.Time { return clock }
+	ctx := context.Background()
+
+	for i := 0; i < 5; i++ {
+		clock = base.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, service.Record(ctx, audit.Entry{Action: "startEpoch", Result: audit.ResultSuccess}))
+	}
+
+	walk := func(filter audit.Filter) []time.Time {
+		var seen []time.Time
+		for pages := 0; pages < 10; pages++ {
+			response, err := service.List(ctx, filter)
+			require.NoError(t, err)
+			for _, entry := range response.Entries {
+				seen = append(seen, entry.Timestamp)
+			}
+			if response.NextCursor == "" {
+				return seen
+			}
+			filter.Cursor = response.NextCursor
+		}
+		t.Fatal("pages never ended")
+		return nil
+	}
+
+	newestFirst := walk(audit.Filter{Limit: 2})
+	require.Len(t, newestFirst, 5)
+	assert.Equal(t, base.Add(4*time.Minute), newestFirst[0])
+	assert.Equal(t, base, newestFirst[4])
+
+	oldestFirst := walk(audit.Filter{Limit: 2, Ascending: true, Since: base.Add(time.Minute), Until: base.Add(3 * time.Minute)})
+	assert.Equal(t, []time.Time{base.Add(time.Minute), base.Add(2 * time.Minute), base.Add(3 * time.Minute)}, oldestFirst)
+
+	exact, err := service.List(ctx, audit.Filter{Limit: 5})
+	require.NoError(t, err)
+	assert.Len(t, exact.Entries, 5)
+	assert.Empty(t, exact.NextCursor, "no cursor when the page holds the last entry")
+
+	_, err = service.List(ctx, audit.Filter{Limit: 2, Cursor: "not-a-cursor"})
+	require.ErrorIs(t, err, audit.ErrInvalidInput)
+}
+
+func TestStore_AppendOnly(t *testing.T) {
+	service := newTestService(t)
+	entry := audit.Entry{ID: "fixed", Timestamp: time.Now(), Action: "startEpoch", Result: audit.ResultSuccess}
+
+	require.NoError(t, service.store.AppendEntry(entry))
+	assert.Error(t, service.store.AppendEntry(entry), "existing entries must not be overwritten")
+}
+
+func TestActorFromContext_DefaultsToSystem(t *testing.T) {
+	assert.Equal(t, "system", audit.ActorFromContext(context.Background()))
+}
@@ -0,0 +1,138 @@
+package auditimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const entryPrefix = "audit:entry:"
+
+// Store handles append-only storage of audit entries
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AppendEntry stores an entry, refusing to overwrite an existing one
+func (s *Store) AppendEntry(entry audit.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	key := []byte(s.buildEntryKey(entry.Timestamp, entry.ID))
+	err = s.db.Update(func(txn storage.Txn) error {
+		if _, err := txn.Get(key); err == nil {
+			return fmt.Errorf("audit entry %s already exists", entry.ID)
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		return txn.Set(key, data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to append audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// entryQuery selects the entries ListEntries walks
+type entryQuery struct {
+	since, until time.Time
+	limit        int
+	after        string // cursor of the last entry of the previous page, empty for the first page
+	ascending    bool   // oldest to newest instead of newest to oldest
+}
+
+// ListEntries walks entries in the query's order and returns those accepted by match, up to limit, along with
+// the cursor continuing after the last of them, empty when no matching entry is left
+func (s *Store) ListEntries(query entryQuery, match func(audit.Entry) bool) ([]audit.Entry, string, error) {
+	entries := make([]audit.Entry, 0)
+	var next string
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = !query.ascending
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// keys sort by timestamp, so seeking to the bound skips everything outside it
+		var seek string
+		switch {
+		case query.after != "":
+			seek = entryPrefix + query.after
+		case query.ascending && !query.since.IsZero():
+			seek = s.buildEntryKey(query.since, "")
+		case query.ascending:
+			seek = entryPrefix
+		case !query.until.IsZero():
+			seek = s.buildEntryKey(query.until, "~")
+		default:
+			seek = entryPrefix + "~"
+		}
+
+		var last string
+		for it.Seek([]byte(seek)); it.ValidForPrefix([]byte(entryPrefix)); it.Next() {
+			key := string(it.Item().Key())
+			if query.after != "" && key == seek {
+				continue
+			}
+
+			var entry audit.Entry
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &entry)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode audit entry: %w", err)
+			}
+
+			if !query.since.IsZero() && entry.Timestamp.Before(query.since) {
+				if query.ascending {
+					continue
+				}
+				break
+			}
+			if !query.until.IsZero() && entry.Timestamp.After(query.until) {
+				if query.ascending {
+					break
+				}
+				continue
+			}
+			if !match(entry) {
+				continue
+			}
+
+			if len(entries) == query.limit {
+				next = last
+				break
+			}
+			entries = append(entries, entry)
+			last = strings.TrimPrefix(key, entryPrefix)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list audit entries: %w", err)
+	}
+
+	return entries, next, nil
+}
+
+func (s *Store) buildEntryKey(timestamp time.Time, id string) string {
+	return fmt.Sprintf("%s%020d:%s", entryPrefix, timestamp.UnixNano(), id)
+}
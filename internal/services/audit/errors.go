@@ -0,0 +1,8 @@
+package audit
+
+import "errors"
+
+// Predefined error types for audit log operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
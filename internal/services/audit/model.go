@@ -0,0 +1,49 @@
+package audit
+
+import (
+	"time"
+)
+
+// result values recorded for each entry
+const (
+	ResultSuccess = "success"
+	ResultFailed  = "failed"
+)
+
+// Entry is a single state-changing action, such as an on-chain transaction
+type Entry struct {
+	ID           string            `json:"id"`
+	Timestamp    time.Time         `json:"timestamp"`
+	Action       string            `json:"action"`
+	Actor        string            `json:"actor"`
+	Sender       string            `json:"sender,omitempty"`
+	Contract     string            `json:"contract,omitempty"`
+	Parameters   map[string]string `json:"parameters,omitempty"`
+	TxHash       string            `json:"txHash,omitempty"`
+	BlockNumber  uint64            `json:"blockNumber,omitempty"`
+	Result       string            `json:"result"`
+	Error        string            `json:"error,omitempty"`
+	RevertReason string            `json:"revertReason,omitempty"`
+	SentAt       time.Time         `json:"sentAt,omitempty"` // when a transaction was broadcast, Timestamp is when it settled
+}
+
+// Filter selects audit entries, zero values match everything
+type Filter struct {
+	Action string
+	Actor  string
+	Result string
+	TxHash string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+
+	Cursor    string // NextCursor of the previous page, empty for the first page
+	Ascending bool   // oldest first instead of newest first
+}
+
+// ListResponse represents a page of audit entries
+type ListResponse struct {
+	Entries    []Entry `json:"entries"`
+	Count      int     `json:"count"`
+	NextCursor string  `json:"nextCursor,omitempty"` // continues after the last entry, empty on the last page
+}
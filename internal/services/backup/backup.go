@@ -0,0 +1,18 @@
+package backup
+
+import "context"
+
+//go:generate moq -out backup_mocks.go . Service
+
+// Service copies the whole database, snapshots, merkle trees and epoch state alike, to a backup target and
+// rebuilds a lost database from a copy, so proofs can be served again without recomputing every epoch
+type Service interface {
+	// Backup copies the database to the backup target and deletes the backups past the retained count
+	Backup(ctx context.Context) (*Backup, error)
+	// List returns the stored backups, newest first
+	List(ctx context.Context) ([]Backup, error)
+	// Restore loads the backup under key, the newest when key is empty, into an empty database and
+	// recomputes every vault's latest merkle root from the restored leaves. A root that does not match
+	// fails the restore with ErrRootMismatch, the result still reporting every vault's check.
+	Restore(ctx context.Context, key string) (*Restore, error)
+}
@@ -0,0 +1,299 @@
+package backupimpl
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/objectstore"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// backups are named <keyPrefix><created at, UTC><keySuffix>, so key order is creation order and other files
+// under the backup prefix are never listed or deleted
+const (
+	keyPrefix   = "epoch-server-"
+	keySuffix   = ".badger.gz"
+	keyTime     = "20060102T150405Z"
+	contentType = "application/gzip"
+)
+
+// loadMaxPending bounds the writes a restore keeps in flight
+const loadMaxPending = 256
+
+const (
+	lastSuccessMetric = "epoch_server_backup_last_success_timestamp"
+	sizeMetric        = "epoch_server_backup_size_bytes"
+	failuresMetric    = "epoch_server_backup_consecutive_failures"
+)
+
+type Service struct {
+	db       storage.DB
+	store    objectstore.Store
+	metrics  *metrics.Registry
+	logger   lgr.L
+	interval time.Duration
+	retain   int
+	now      func() time.Time
+
+	failures int // consecutive failed scheduled backups
+}
+
+// New returns the backups of db BACKUP_TARGET selects. The s3 and gcs targets read credentials from the AWS
+// environment, for gcs those are HMAC keys of a service account.
+func New(ctx context.Context, db storage.DB, registry *metrics.Registry, logger lgr.L, cfg *config.Config) (*Service, error) {
+	var store objectstore.Store
+	switch cfg.Backup.Target {
+	case backup.TargetLocal:
+		store = objectstore.NewLocal(cfg.Backup.Dir)
+	case backup.TargetS3, backup.TargetGCS:
+		s3Cfg := objectstore.S3Config{
+			Bucket:   cfg.Backup.Bucket,
+			Prefix:   cfg.Backup.Prefix,
+			Region:   cfg.Backup.Region,
+			Endpoint: cfg.Backup.Endpoint,
+		}
+		if cfg.Backup.Target == backup.TargetGCS {
+			if s3Cfg.Endpoint == "" {
+				s3Cfg.Endpoint = objectstore.GCSEndpoint
+			}
+			if s3Cfg.Region == "" {
+				s3Cfg.Region = "auto"
+			}
+		}
+		s3Store, err := objectstore.NewS3(ctx, s3Cfg)
+		if err != nil {
+			return nil, err
+		}
+		store = s3Store
+	default:
+		return nil, fmt.Errorf("%w: backup target %q writes nowhere", backup.ErrInvalidInput, cfg.Backup.Target)
+	}
+	return &Service{
+		db:       db,
+		store:    store,
+		metrics:  registry,
+		logger:   logger,
+		interval: cfg.Backup.Interval,
+		retain:   cfg.Backup.Retain,
+		now:      time.Now,
+	}, nil
+}
+
+// Run backs the database up every BACKUP_INTERVAL until ctx is done. The first backup is taken once the
+// newest stored one is an interval old, so restarts do not back up more often.
+func (s *Service) Run(ctx context.Context) {
+	s.logger.Logf("INFO backing up the database every %v, keeping %d backups", s.interval, s.retain)
+	timer := time.NewTimer(s.untilNext(ctx))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			s.scheduledBackup(ctx)
+			timer.Reset(s.interval)
+		}
+	}
+}
+
+// untilNext returns how long until the next backup is due, right away when the backups cannot be listed
+func (s *Service) untilNext(ctx context.Context) time.Duration {
+	backups, err := s.List(ctx)
+	if err != nil {
+		s.logger.Logf("WARN failed to list backups: %v", err)
+		return 0
+	}
+	if len(backups) == 0 {
+		return 0
+	}
+	wait := s.interval - s.now().Sub(backups[0].CreatedAt)
+	if wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// scheduledBackup takes a backup, a failure is logged and counted and the next interval tries again
+func (s *Service) scheduledBackup(ctx context.Context) {
+	if _, err := s.Backup(ctx); err != nil {
+		s.failures++
+		s.metrics.SetGauge(failuresMetric, "Consecutive failed scheduled database backups", float64(s.failures))
+		s.logger.Logf("ERROR database backup failed (%d in a row): %v", s.failures, err)
+		return
+	}
+	s.failures = 0
+	s.metrics.SetGauge(failuresMetric, "Consecutive failed scheduled database backups", 0)
+}
+
+func (s *Service) Backup(ctx context.Context) (_ *backup.Backup, err error) {
+	ctx, span := tracing.StartSpan(ctx, "backup.Backup")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	createdAt := s.now().UTC().Truncate(time.Second)
+	key := keyPrefix + createdAt.Format(keyTime) + keySuffix
+
+	// the copy is staged in a file, uploads need to know its length and to rewind it on retries
+	tmp, err := os.CreateTemp("", keyPrefix+"*"+keySuffix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create backup file: %w", err)
+	}
+	defer func() {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+	}()
+
+	gz := gzip.NewWriter(tmp)
+	if _, err := s.db.Backup(gz, 0); err != nil {
+		return nil, fmt.Errorf("failed to back up database: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress backup: %w", err)
+	}
+	size, err := tmp.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err := s.store.Put(ctx, key, contentType, tmp); err != nil {
+		return nil, fmt.Errorf("failed to write backup %s: %w", key, err)
+	}
+
+	result := &backup.Backup{Key: key, Location: s.store.Location(key), Size: size, CreatedAt: createdAt}
+	span.SetAttributes(attribute.String("backup.key", key), attribute.Int64("backup.size", size))
+	s.metrics.SetGauge(lastSuccessMetric, "Unix time of the last successful database backup", float64(createdAt.Unix()))
+	s.metrics.SetGauge(sizeMetric, "Compressed size of the last database backup in bytes", float64(size))
+	s.logger.Logf("INFO backed up the database to %s (%d bytes)", result.Location, size)
+
+	// retention only runs after a successful backup, so failing backups never delete the last good ones
+	s.prune(ctx)
+	return result, nil
+}
+
+// prune deletes the backups past the retained count, a failure is logged and retried after the next backup
+func (s *Service) prune(ctx context.Context) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		s.logger.Logf("WARN failed to list backups for retention: %v", err)
+		return
+	}
+	for i := s.retain; i < len(backups); i++ {
+		if err := s.store.Delete(ctx, backups[i].Key); err != nil {
+			s.logger.Logf("WARN failed to delete expired backup %s: %v", backups[i].Key, err)
+			continue
+		}
+		s.logger.Logf("INFO deleted expired backup %s", backups[i].Location)
+	}
+}
+
+func (s *Service) List(ctx context.Context) ([]backup.Backup, error) {
+	objects, err := s.store.List(ctx, keyPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	var backups []backup.Backup
+	for _, object := range objects {
+		if strings.Contains(object.Key, "/") || !strings.HasSuffix(object.Key, keySuffix) {
+			continue
+		}
+		createdAt, err := time.Parse(keyTime, strings.TrimSuffix(strings.TrimPrefix(object.Key, keyPrefix), keySuffix))
+		if err != nil {
+			continue
+		}
+		backups = append(backups, backup.Backup{
+			Key:       object.Key,
+			Location:  s.store.Location(object.Key),
+			Size:      object.Size,
+			CreatedAt: createdAt,
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Key > backups[j].Key })
+	return backups, nil
+}
+
+func (s *Service) Restore(ctx context.Context, key string) (_ *backup.Restore, err error) {
+	ctx, span := tracing.StartSpan(ctx, "backup.Restore", attribute.String("backup.key", key))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	chosen, err := s.find(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	empty, err := s.empty()
+	if err != nil {
+		return nil, err
+	}
+	if !empty {
+		return nil, fmt.Errorf("%w: restore into a new database so the backup is not merged with other state", backup.ErrNotEmpty)
+	}
+
+	body, err := s.store.Get(ctx, chosen.Key)
+	if errors.Is(err, objectstore.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %s", backup.ErrNotFound, chosen.Key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup %s: %w", chosen.Key, err)
+	}
+	defer body.Close()
+	gz, err := gzip.NewReader(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress backup %s: %w", chosen.Key, err)
+	}
+	if err := s.db.Load(gz, loadMaxPending); err != nil {
+		return nil, fmt.Errorf("failed to load backup %s: %w", chosen.Key, err)
+	}
+	s.logger.Logf("INFO restored the database from %s", chosen.Location)
+
+	roots, err := s.verifyRoots(ctx)
+	result := &backup.Restore{Backup: *chosen, Roots: roots}
+	return result, err
+}
+
+// find returns the backup under key, the newest when key is empty
+func (s *Service) find(ctx context.Context, key string) (*backup.Backup, error) {
+	backups, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range backups {
+		if key == "" || backups[i].Key == key {
+			return &backups[i], nil
+		}
+	}
+	if key == "" {
+		return nil, fmt.Errorf("%w: no backups stored", backup.ErrNotFound)
+	}
+	return nil, fmt.Errorf("%w: %s", backup.ErrNotFound, key)
+}
+
+// empty reports whether the database holds no keys
+func (s *Service) empty() (bool, error) {
+	empty := true
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		it.Rewind()
+		empty = !it.Valid()
+		return nil
+	})
+	return empty, err
+}
@@ -0,0 +1,171 @@
+package backupimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+)
+
+const testVault = "0x1111111111111111111111111111111111111111"
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+func newTestService(t *testing.T, db storage.DB, dir string, retain int) *Service {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Backup.Target = backup.TargetLocal
+	cfg.Backup.Dir = dir
+	cfg.Backup.Interval = time.Hour
+	cfg.Backup.Retain = retain
+	service, err := New(context.Background(), db, metrics.NewRegistry(), lgr.NoOp, cfg)
+	require.NoError(t, err)
+	return service
+}
+
+// saveTestSnapshot stores a snapshot of the vault's epoch whose root is root, the root of its leaves when empty
+func saveTestSnapshot(t *testing.T, db storage.DB, epoch int64, root string) {
+	t.Helper()
+
+	service := merkleimpl.New(db, nil, nil, lgr.NoOp)
+	entries := []merkle.Entry{
+		{Address: "0x3575b992c5337226aecf4e7f93dfbe80c576ce15", TotalEarned: big.NewInt(epoch * 100)},
+		{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)},
+	}
+	if root == "" {
+		computed := service.BuildMerkleRootFromEntries(entries)
+		root = fmt.Sprintf("%x", computed)
+	}
+	snapshot := merkle.MerkleSnapshot{VaultID: testVault, MerkleRoot: root}
+	for _, entry := range entries {
+		snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+	}
+	require.NoError(t, service.SaveSnapshot(context.Background(), big.NewInt(epoch), snapshot))
+}
+
+func TestService_BackupAndRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db := newTestDB(t)
+	saveTestSnapshot(t, db, 3, "")
+	saveTestSnapshot(t, db, 4, "")
+	service := newTestService(t, db, dir, 3)
+	service.now = func() time.Time { return time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) }
+
+	created, err := service.Backup(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "epoch-server-20261016T120000Z.badger.gz", created.Key)
+	assert.Equal(t, filepath.Join(dir, created.Key), created.Location)
+	assert.Positive(t, created.Size)
+	lastSuccess, ok := service.metrics.Gauge(lastSuccessMetric)
+	require.True(t, ok)
+	assert.Equal(t, float64(created.CreatedAt.Unix()), lastSuccess)
+
+	// the lost database is rebuilt from the newest backup and its latest root recomputed
+	restoredDB := newTestDB(t)
+	restored, err := newTestService(t, restoredDB, dir, 3).Restore(ctx, "")
+	require.NoError(t, err)
+	assert.Equal(t, created.Key, restored.Backup.Key)
+	require.Len(t, restored.Roots, 1)
+	assert.True(t, restored.Roots[0].Match)
+	assert.Equal(t, "4", restored.Roots[0].EpochNumber)
+	assert.Equal(t, 2, restored.Roots[0].Leaves)
+
+	snapshot, err := merkleimpl.NewStore(restoredDB, lgr.NoOp).GetSnapshot(ctx, big.NewInt(3), testVault)
+	require.NoError(t, err, "every epoch is restored, not only the latest")
+	assert.Len(t, snapshot.Entries, 2)
+
+	// a database holding state is never merged with a backup
+	_, err = newTestService(t, restoredDB, dir, 3).Restore(ctx, "")
+	assert.ErrorIs(t, err, backup.ErrNotEmpty)
+	_, err = newTestService(t, newTestDB(t), dir, 3).Restore(ctx, "epoch-server-20200101T000000Z.badger.gz")
+	assert.ErrorIs(t, err, backup.ErrNotFound)
+	_, err = newTestService(t, newTestDB(t), t.TempDir(), 3).Restore(ctx, "")
+	assert.ErrorIs(t, err, backup.ErrNotFound)
+}
+
+func TestService_RestoreDetectsRootMismatch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db := newTestDB(t)
+	saveTestSnapshot(t, db, 4, "00000000000000000000000000000000000000000000000000000000000000ff")
+	_, err := newTestService(t, db, dir, 3).Backup(ctx)
+	require.NoError(t, err)
+
+	restored, err := newTestService(t, newTestDB(t), dir, 3).Restore(ctx, "")
+	require.ErrorIs(t, err, backup.ErrRootMismatch)
+	require.Len(t, restored.Roots, 1)
+	assert.False(t, restored.Roots[0].Match)
+	assert.NotEqual(t, restored.Roots[0].StoredRoot, restored.Roots[0].ComputedRoot)
+}
+
+func TestService_Retention(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	service := newTestService(t, newTestDB(t), dir, 2)
+	// files that are not backups are never listed or deleted
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0o644))
+
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	for i := 0; i < 4; i++ {
+		_, err := service.Backup(ctx)
+		require.NoError(t, err)
+		now = now.Add(time.Hour)
+	}
+
+	backups, err := service.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, "epoch-server-20261016T030000Z.badger.gz", backups[0].Key, "newest first")
+	assert.Equal(t, "epoch-server-20261016T020000Z.badger.gz", backups[1].Key)
+	assert.FileExists(t, filepath.Join(dir, "notes.txt"))
+}
+
+func TestService_UntilNext(t *testing.T) {
+	ctx := context.Background()
+	service := newTestService(t, newTestDB(t), t.TempDir(), 2)
+	now := time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+	assert.Zero(t, service.untilNext(ctx), "without backups one is taken right away")
+
+	_, err := service.Backup(ctx)
+	require.NoError(t, err)
+	now = now.Add(20 * time.Minute)
+	assert.Equal(t, 40*time.Minute, service.untilNext(ctx), "a restart waits for the interval to pass")
+	now = now.Add(2 * time.Hour)
+	assert.Zero(t, service.untilNext(ctx))
+}
+
+func TestNew_RequiresTarget(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Backup.Target = backup.TargetNone
+	_, err := New(context.Background(), newTestDB(t), metrics.NewRegistry(), lgr.NoOp, cfg)
+	assert.ErrorIs(t, err, backup.ErrInvalidInput)
+}
@@ -0,0 +1,58 @@
+package backupimpl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/services/backup"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// verifyRoots recomputes the root of every vault's latest snapshot from its restored leaves, with the leaf
+// encoding the snapshot was built with. A backup whose roots do not match would serve proofs that fail on-chain.
+func (s *Service) verifyRoots(ctx context.Context) ([]backup.RootCheck, error) {
+	store := merkleimpl.NewStore(s.db, s.logger)
+	builder := merkleimpl.New(s.db, nil, nil, s.logger)
+
+	vaults, err := store.ListVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]backup.RootCheck, 0, len(vaults))
+	var mismatched []string
+	for _, vault := range vaults {
+		snapshot, err := store.GetLatestSnapshot(ctx, vault)
+		if err != nil {
+			return checks, fmt.Errorf("failed to read latest snapshot of vault %s: %w", vault, err)
+		}
+		entries := make([]merkle.Entry, len(snapshot.Entries))
+		for i, entry := range snapshot.Entries {
+			entries[i] = merkle.Entry(entry)
+		}
+		computed := builder.BuildMerkleRootWithEncoding(entries, snapshot.Encoding())
+
+		check := backup.RootCheck{
+			VaultID:      vault,
+			EpochNumber:  snapshot.EpochNumber.String(),
+			Leaves:       len(entries),
+			StoredRoot:   strings.TrimPrefix(strings.ToLower(snapshot.MerkleRoot), "0x"),
+			ComputedRoot: common.Bytes2Hex(computed[:]),
+		}
+		check.Match = check.StoredRoot == check.ComputedRoot
+		checks = append(checks, check)
+		if !check.Match {
+			mismatched = append(mismatched, vault)
+			s.logger.Logf("ERROR restored root %s of vault %s epoch %s does not match the root %s of its %d leaves",
+				check.StoredRoot, vault, check.EpochNumber, check.ComputedRoot, check.Leaves)
+			continue
+		}
+		s.logger.Logf("INFO restored root of vault %s epoch %s verified: %s", vault, check.EpochNumber, check.StoredRoot)
+	}
+	if len(mismatched) > 0 {
+		return checks, fmt.Errorf("%w: vaults %s", backup.ErrRootMismatch, strings.Join(mismatched, ", "))
+	}
+	return checks, nil
+}
@@ -0,0 +1,11 @@
+package backup
+
+import "errors"
+
+// Predefined error types for backup operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+	ErrNotFound     = errors.New("backup not found")
+	ErrNotEmpty     = errors.New("database is not empty")
+	ErrRootMismatch = errors.New("restored merkle root does not match its leaves")
+)
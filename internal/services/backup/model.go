@@ -0,0 +1,35 @@
+package backup
+
+import "time"
+
+// backup targets, selected with BACKUP_TARGET
+const (
+	TargetNone  = "none"
+	TargetLocal = "local"
+	TargetS3    = "s3"
+	TargetGCS   = "gcs" // Google Cloud Storage through its S3-compatible XML API
+)
+
+// Backup is a stored copy of the database
+type Backup struct {
+	Key       string    `json:"key"`
+	Location  string    `json:"location"`
+	Size      int64     `json:"size"` // compressed, in bytes
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Restore is what a restore loaded and how its merkle roots checked out
+type Restore struct {
+	Backup Backup      `json:"backup"`
+	Roots  []RootCheck `json:"roots"`
+}
+
+// RootCheck compares the restored root of a vault's latest snapshot with the root recomputed from its leaves
+type RootCheck struct {
+	VaultID      string `json:"vaultId"`
+	EpochNumber  string `json:"epochNumber"`
+	Leaves       int    `json:"leaves"`
+	StoredRoot   string `json:"storedRoot"`
+	ComputedRoot string `json:"computedRoot"`
+	Match        bool   `json:"match"`
+}
@@ -0,0 +1,185 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// txRecord collects what a state-changing call did so it can be written to the audit log
+type txRecord struct {
+	action       string
+	contract     string
+	parameters   map[string]string
+	txHash       string
+	sentAt       time.Time
+	blockNumber  uint64
+	gasUsed      uint64
+	gasPrice     *big.Int
+	revertReason string
+	pending      bool // the receipt watcher records the transaction once it settles
+}
+
+func (r *txRecord) sent(tx *types.Transaction) {
+	r.txHash = tx.Hash().Hex()
+	r.sentAt = time.Now().UTC()
+	r.gasPrice = tx.GasPrice()
+}
+
+func (r *txRecord) mined(receipt *types.Receipt) {
+	r.blockNumber = receipt.BlockNumber.Uint64()
+	r.gasUsed = receipt.GasUsed
+	if receipt.EffectiveGasPrice != nil {
+		r.gasPrice = receipt.EffectiveGasPrice
+	}
+}
+
+// recordTx writes the outcome of a transaction to the audit log and its gas spend to the gas recorder.
+// Recording failures are logged rather than returned so they never mask the transaction result.
+func (c *Client) recordTx(ctx context.Context, rec *txRecord, txErr error) {
+	if rec.pending && txErr == nil {
+		return
+	}
+
+	c.recordGas(ctx, rec)
+
+	if c.recorder == nil {
+		return
+	}
+
+	entry := audit.Entry{
+		Action:      rec.action,
+		Actor:       audit.ActorFromContext(ctx),
+		Sender:      c.senderAddress(),
+		Contract:    strings.ToLower(rec.contract),
+		Parameters:  rec.parameters,
+		TxHash:      rec.txHash,
+		BlockNumber: rec.blockNumber,
+		Result:      audit.ResultSuccess,
+		SentAt:      rec.sentAt,
+	}
+	if txErr != nil {
+		entry.Result = audit.ResultFailed
+		entry.Error = txErr.Error()
+		entry.RevertReason = rec.revertReason
+		if entry.RevertReason == "" {
+			entry.RevertReason = decodeRevertReason(txErr)
+		}
+	}
+
+	// the call's context may already be cancelled, the entry must still be written
+	if err := c.recorder.Record(context.WithoutCancel(ctx), entry); err != nil {
+		c.logger.Logf("WARN failed to record audit entry for %s %s: %v", rec.action, rec.txHash, err)
+	}
+}
+
+// replayRevertReason re-executes a mined, failed transaction against the parent block's state
+// to recover the revert reason, which receipts do not carry
+func (c *Client) replayRevertReason(ctx context.Context, tx *types.Transaction, receipt *types.Receipt) string {
+	return c.replayRevertReasonOn(ctx, c.ethClient, tx, receipt)
+}
+
+// replayRevertReasonOn is replayRevertReason against the chain caller serves
+func (c *Client) replayRevertReasonOn(
+	ctx context.Context,
+	caller ethereum.ContractCaller,
+	tx *types.Transaction,
+	receipt *types.Receipt,
+) string {
+	msg := ethereum.CallMsg{
+		From:     crypto.PubkeyToAddress(c.privateKey.PublicKey),
+		To:       tx.To(),
+		Gas:      tx.Gas(),
+		GasPrice: tx.GasPrice(),
+		Value:    tx.Value(),
+		Data:     tx.Data(),
+	}
+	parent := new(big.Int).Sub(receipt.BlockNumber, big.NewInt(1))
+
+	if _, err := caller.CallContract(ctx, msg, parent); err != nil {
+		if reason := decodeRevertReason(err); reason != "" {
+			return reason
+		}
+		return err.Error()
+	}
+	return ""
+}
+
+func (c *Client) senderAddress() string {
+	if c.privateKey == nil {
+		return ""
+	}
+	return strings.ToLower(crypto.PubkeyToAddress(c.privateKey.PublicKey).Hex())
+}
+
+var (
+	knownABIsOnce sync.Once
+	knownABIs     []*abi.ABI
+)
+
+// decodeRevertReason extracts a readable revert reason from an RPC error carrying revert data.
+// It understands Error(string) reverts and custom errors declared by the protocol contracts.
+func decodeRevertReason(err error) string {
+	var dataErr rpc.DataError
+	if !errors.As(err, &dataErr) {
+		return ""
+	}
+	hexData, ok := dataErr.ErrorData().(string)
+	if !ok {
+		return ""
+	}
+	data, decodeErr := hexutil.Decode(hexData)
+	if decodeErr != nil || len(data) < 4 {
+		return ""
+	}
+
+	if reason, unpackErr := abi.UnpackRevert(data); unpackErr == nil {
+		return reason
+	}
+
+	var selector [4]byte
+	copy(selector[:], data[:4])
+	for _, parsed := range protocolABIs() {
+		customErr, lookupErr := parsed.ErrorByID(selector)
+		if lookupErr != nil {
+			continue
+		}
+		args, unpackErr := customErr.Inputs.Unpack(data[4:])
+		if unpackErr != nil || len(args) == 0 {
+			return customErr.Name
+		}
+		return fmt.Sprintf("%s%v", customErr.Name, args)
+	}
+
+	return hexData
+}
+
+func protocolABIs() []*abi.ABI {
+	knownABIsOnce.Do(func() {
+		for _, metadata := range []interface{ ParseABI() (*abi.ABI, error) }{
+			&contracts.IEpochManagerMetaData,
+			&contracts.IDebtSubsidizerMetaData,
+			&contracts.ICollectionsVaultMetaData,
+			&contracts.ILendingManagerMetaData,
+			&contracts.ICollectionRegistryMetaData,
+		} {
+			if parsed, err := metadata.ParseABI(); err == nil {
+				knownABIs = append(knownABIs, parsed)
+			}
+		}
+	})
+	return knownABIs
+}
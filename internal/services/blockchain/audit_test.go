@@ -0,0 +1,73 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// revertError mimics the JSON-RPC error returned for a reverted call
+type revertError struct {
+	data string
+}
+
+func (e *revertError) Error() string          { return "execution reverted" }
+func (e *revertError) ErrorData() interface{} { return e.data }
+
+func TestDecodeRevertReason(t *testing.T) {
+	stringType, err := abi.NewType("string", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	packed, err := abi.Arguments{{Type: stringType}}.Pack("epoch still active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errorSelector := crypto.Keccak256([]byte("Error(string)"))[:4]
+
+	uint256Type, err := abi.NewType("uint256", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	epochID, err := abi.Arguments{{Type: uint256Type}}.Pack(big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalidEpochSelector := crypto.Keccak256([]byte("EpochManager__InvalidEpochId(uint256)"))[:4]
+	stillActiveSelector := crypto.Keccak256([]byte("EpochManager__EpochStillActive()"))[:4]
+
+	unknownSelector := "0xdeadbeef"
+
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{
+			name:     "error_string",
+			err:      fmt.Errorf("failed to call startEpoch: %w", &revertError{data: hexutil.Encode(append(errorSelector, packed...))}),
+			expected: "epoch still active",
+		},
+		{
+			name:     "custom_error_with_args",
+			err:      &revertError{data: hexutil.Encode(append(invalidEpochSelector, epochID...))},
+			expected: "EpochManager__InvalidEpochId[7]",
+		},
+		{name: "custom_error", err: &revertError{data: hexutil.Encode(stillActiveSelector)}, expected: "EpochManager__EpochStillActive"},
+		{name: "unknown_selector", err: &revertError{data: unknownSelector}, expected: unknownSelector},
+		{name: "no_revert_data", err: errors.New("connection refused"), expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeRevertReason(tt.err); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -5,38 +5,97 @@ import (
 	"crypto/ecdsa"
 	"fmt"
 	"math/big"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/gas"
 	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	bind_v2 "github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// chainIDTimeout bounds the chain ID check made at startup
+const chainIDTimeout = 10 * time.Second
+
+// ethBackend is the part of an Ethereum client the blockchain client uses, served by an RPC endpoint or a simulated chain
+type ethBackend interface {
+	bind.ContractBackend
+	bind.DeployBackend
+	ethereum.ChainIDReader
+	ethereum.BlockNumberReader
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error)
+}
+
 type Client struct {
 	logger       lgr.L
 	ethConfig    blockchain.Config
-	ethClient    *ethclient.Client
+	ethClient    ethBackend
 	privateKey   *ecdsa.PrivateKey
 	epochManager *contracts.IEpochManager
 	subsidizer   *contracts.IDebtSubsidizer
+	vault        *contracts.ICollectionsVault
+	erc20        *contracts.IERC20
+	recorder     audit.Recorder
+	gasRecorder  gas.Recorder
+	txObserver   blockchain.TxObserver
+	receipts     receiptSource
+
+	multicallState atomic.Int32 // multicallUnknown until code at the Multicall3 address is read
 }
 
 // ProvideClient creates a new blockchain client implementation
 func ProvideClient(logger lgr.L) blockchain.BlockchainClient {
 	return &Client{
 		logger: logger,
+		vault:  contracts.NewICollectionsVault(),
+		erc20:  contracts.NewIERC20(),
 	}
 }
 
-// ProvideClientWithConfig creates a blockchain client with configuration
-func ProvideClientWithConfig(logger lgr.L, config blockchain.Config) (blockchain.BlockchainClient, error) {
+// ProvideClientWithConfig creates a blockchain client with configuration, on a simulated chain running
+// for the life of the process when config.Type is blockchain.TypeSimulated.
+// Every transaction it sends is written to recorder, which may be nil to disable auditing, and the gas
+// of every mined transaction to gasRecorder, which may be nil to disable gas reporting. Transactions
+// sent without waiting are watched until confirmed and their outcome is passed to txObserver, which may
+// be nil when only the audit log should learn about it.
+func ProvideClientWithConfig(
+	logger lgr.L,
+	config blockchain.Config,
+	recorder audit.Recorder,
+	gasRecorder gas.Recorder,
+	txObserver blockchain.TxObserver,
+) (blockchain.BlockchainClient, error) {
+	if config.Type == blockchain.TypeSimulated {
+		client, _, err := ProvideSimulatedClient(logger, config, recorder, gasRecorder, txObserver)
+		if err != nil {
+			logger.Logf("ERROR failed to start simulated chain: %v", err)
+			return nil, err
+		}
+		return client, nil
+	}
+
 	client := &Client{
-		logger:    logger,
-		ethConfig: config,
+		logger:      logger,
+		ethConfig:   config,
+		recorder:    recorder,
+		gasRecorder: gasRecorder,
+		txObserver:  txObserver,
 	}
 
 	if err := client.initialize(); err != nil {
@@ -52,18 +111,51 @@ func (c *Client) initialize() error {
 	if c.ethConfig.RPCURL == "" {
 		return fmt.Errorf("RPC URL is required")
 	}
-	if c.ethConfig.PrivateKey == "" {
+	if c.ethConfig.PrivateKey == "" && !c.ethConfig.ReadOnly {
 		return fmt.Errorf("private key is required")
 	}
 	if c.ethConfig.EpochManager == "" {
 		return fmt.Errorf("EpochManager contract address is required")
 	}
 
-	ethClient, err := ethclient.Dial(c.ethConfig.RPCURL)
+	ethClient, err := c.dial()
 	if err != nil {
 		return fmt.Errorf("failed to connect to Ethereum RPC: %w", err)
 	}
-	c.ethClient = ethClient
+	return c.connect(ethClient)
+}
+
+// dial connects to RPCURL, through the configured transport when there is one
+func (c *Client) dial() (*ethclient.Client, error) {
+	if c.ethConfig.Transport == nil {
+		return ethclient.Dial(c.ethConfig.RPCURL)
+	}
+	rpcClient, err := rpc.DialOptions(context.Background(), c.ethConfig.RPCURL,
+		rpc.WithHTTPClient(&http.Client{Transport: c.ethConfig.Transport}))
+	if err != nil {
+		return nil, err
+	}
+	return ethclient.NewClient(rpcClient), nil
+}
+
+// connect binds the client to backend once it is verified to serve the configured chain, and loads the signer
+func (c *Client) connect(backend ethBackend) error {
+	c.ethClient = backend
+	c.receipts = backend
+
+	if err := c.verifyChainID(); err != nil {
+		return err
+	}
+
+	c.epochManager = contracts.NewIEpochManager()
+	c.subsidizer = contracts.NewIDebtSubsidizer()
+	c.vault = contracts.NewICollectionsVault()
+	c.erc20 = contracts.NewIERC20()
+
+	if c.ethConfig.ReadOnly {
+		c.logger.Logf("INFO blockchain client is read-only, private key not loaded")
+		return nil
+	}
 
 	privateKeyHex := c.ethConfig.PrivateKey
 	if len(privateKeyHex) > 2 && privateKeyHex[:2] == "0x" {
@@ -74,30 +166,71 @@ func (c *Client) initialize() error {
 		return fmt.Errorf("failed to parse private key: %w", err)
 	}
 	c.privateKey = privateKey
-	c.epochManager = contracts.NewIEpochManager()
-	c.subsidizer = contracts.NewIDebtSubsidizer()
 
 	return nil
 }
 
-func (c *Client) StartEpoch(ctx context.Context) error {
-	c.logger.Logf("INFO starting epoch")
+// rejectReadOnly refuses a transaction when the client runs without a signer, so read replicas
+// never fall through to the unconfigured fallbacks that pretend a transaction succeeded
+func (c *Client) rejectReadOnly(action string) error {
+	if c.ethConfig.ReadOnly {
+		return fmt.Errorf("%w: refusing to send %s", blockchain.ErrReadOnly, action)
+	}
+	return nil
+}
+
+// verifyChainID refuses an RPC endpoint serving another chain, so transactions are never signed for the wrong network
+func (c *Client) verifyChainID() error {
+	if c.ethConfig.ChainID == 0 {
+		c.logger.Logf("WARN expected chain ID not configured, skipping chain ID validation")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), chainIDTimeout)
+	defer cancel()
+
+	chainID, err := c.ethClient.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chain ID from RPC: %w", err)
+	}
+	if !chainID.IsUint64() || chainID.Uint64() != c.ethConfig.ChainID {
+		return fmt.Errorf("%w: RPC reports chain %s, expected %d", blockchain.ErrChainIDMismatch, chainID.String(), c.ethConfig.ChainID)
+	}
+
+	c.logger.Logf("INFO connected to chain %d", c.ethConfig.ChainID)
+	return nil
+}
+
+func (c *Client) StartEpoch(ctx context.Context) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.StartEpoch")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("startEpoch"); err != nil {
+		return err
+	}
+
+	logger.Logf("INFO starting epoch")
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("ERROR Ethereum client not initialized")
+		logger.Logf("ERROR Ethereum client not initialized")
 		return fmt.Errorf("ethereum client not initialized")
 	}
 
+	rec := &txRecord{action: "startEpoch", contract: c.ethConfig.EpochManager}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -111,26 +244,32 @@ func (c *Client) StartEpoch(ctx context.Context) error {
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call startEpoch: %v", err)
+		logger.Logf("ERROR failed to call startEpoch: %v", err)
 		return fmt.Errorf("failed to call startEpoch: %w", err)
 	}
 
-	c.logger.Logf("INFO started epoch transaction sent: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO started epoch transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for startEpoch transaction %s: %v", tx.Hash().Hex(), err)
+		logger.Logf("ERROR failed to wait for startEpoch transaction %s: %v", tx.Hash().Hex(), err)
 		return fmt.Errorf("failed to wait for startEpoch transaction: %w", err)
 	}
+	rec.mined(receipt)
 
-	c.logger.Logf("INFO transaction %s mined in block %d", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
+	logger.Logf("INFO transaction %s mined in block %d", tx.Hash().Hex(), receipt.BlockNumber.Uint64())
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR startEpoch transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR startEpoch transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("startEpoch transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf("INFO startEpoch transaction successful: %s", tx.Hash().Hex())
+	logger.Logf("INFO startEpoch transaction successful: %s", tx.Hash().Hex())
 	return nil
 }
 
@@ -139,8 +278,11 @@ func (c *Client) DistributeSubsidies(ctx context.Context, epochID string) error
 	return nil
 }
 
-func (c *Client) GetCurrentEpochId(ctx context.Context) (*big.Int, error) {
-	if c.ethClient == nil || c.privateKey == nil {
+func (c *Client) GetCurrentEpochId(ctx context.Context) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetCurrentEpochId")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil || (c.privateKey == nil && !c.ethConfig.ReadOnly) {
 		c.logger.Logf("WARN Ethereum client not initialized, returning epoch ID 1")
 		return big.NewInt(1), nil
 	}
@@ -150,7 +292,7 @@ func (c *Client) GetCurrentEpochId(ctx context.Context) (*big.Int, error) {
 
 	callOpts := &bind_v2.CallOpts{Context: ctx}
 	var result []interface{}
-	err := contractInstance.Call(callOpts, &result, "getCurrentEpochId")
+	err = contractInstance.Call(callOpts, &result, "getCurrentEpochId")
 	if err != nil {
 		c.logger.Logf("ERROR failed to call getCurrentEpochId: %v", err)
 		return nil, fmt.Errorf("failed to call getCurrentEpochId: %w", err)
@@ -168,24 +310,40 @@ func (c *Client) GetCurrentEpochId(ctx context.Context) (*big.Int, error) {
 	return epochId, nil
 }
 
-func (c *Client) UpdateExchangeRate(ctx context.Context, lendingManagerAddress string) error {
-	c.logger.Logf("INFO updating exchange rate for LendingManager %s", lendingManagerAddress)
+func (c *Client) UpdateExchangeRate(ctx context.Context, lendingManagerAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.UpdateExchangeRate")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("updateExchangeRate"); err != nil {
+		return err
+	}
+
+	logger.Logf("INFO updating exchange rate for LendingManager %s", lendingManagerAddress)
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("ERROR Ethereum client not initialized")
+		logger.Logf("ERROR Ethereum client not initialized")
 		return fmt.Errorf("ethereum client not initialized")
 	}
 
+	rec := &txRecord{
+		action:     "updateExchangeRate",
+		contract:   lendingManagerAddress,
+		parameters: map[string]string{"lendingManager": lendingManagerAddress},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -200,45 +358,68 @@ func (c *Client) UpdateExchangeRate(ctx context.Context, lendingManagerAddress s
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call updateExchangeRate: %v", err)
+		logger.Logf("ERROR failed to call updateExchangeRate: %v", err)
 		return fmt.Errorf("failed to call updateExchangeRate: %w", err)
 	}
 
-	c.logger.Logf("INFO updateExchangeRate transaction sent: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO updateExchangeRate transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for updateExchangeRate transaction: %v", err)
+		logger.Logf("ERROR failed to wait for updateExchangeRate transaction: %v", err)
 		return fmt.Errorf("failed to wait for updateExchangeRate transaction: %w", err)
 	}
+	rec.mined(receipt)
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR updateExchangeRate transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR updateExchangeRate transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("updateExchangeRate transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf("INFO updateExchangeRate transaction successful: %s", tx.Hash().Hex())
+	logger.Logf("INFO updateExchangeRate transaction successful: %s", tx.Hash().Hex())
 	return nil
 }
 
-func (c *Client) AllocateYieldToEpoch(ctx context.Context, epochId *big.Int, vaultAddress string) error {
-	c.logger.Logf("INFO allocating yield to epoch %s for vault %s", epochId.String(), vaultAddress)
+func (c *Client) AllocateYieldToEpoch(ctx context.Context, epochId *big.Int, vaultAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.AllocateYieldToEpoch",
+		attribute.String("epoch.id", epochId.String()), attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("allocateYieldToEpoch"); err != nil {
+		return err
+	}
+
+	logger.Logf("INFO allocating yield to epoch %s for vault %s", epochId.String(), vaultAddress)
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("WARN Ethereum client not initialized, skipping allocateYieldToEpoch call")
+		logger.Logf("WARN Ethereum client not initialized, skipping allocateYieldToEpoch call")
 		return nil
 	}
 
+	rec := &txRecord{
+		action:     "allocateYieldToEpoch",
+		contract:   vaultAddress,
+		parameters: map[string]string{"epochId": epochId.String()},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -254,24 +435,30 @@ func (c *Client) AllocateYieldToEpoch(ctx context.Context, epochId *big.Int, vau
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call allocateYieldToEpoch: %v", err)
+		logger.Logf("ERROR failed to call allocateYieldToEpoch: %v", err)
 		return fmt.Errorf("failed to call allocateYieldToEpoch: %w", err)
 	}
 
-	c.logger.Logf("INFO allocateYieldToEpoch transaction sent: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO allocateYieldToEpoch transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for allocateYieldToEpoch transaction: %v", err)
+		logger.Logf("ERROR failed to wait for allocateYieldToEpoch transaction: %v", err)
 		return fmt.Errorf("failed to wait for allocateYieldToEpoch transaction: %w", err)
 	}
+	rec.mined(receipt)
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR allocateYieldToEpoch transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR allocateYieldToEpoch transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("allocateYieldToEpoch transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf("INFO allocateYieldToEpoch transaction successful: %s", tx.Hash().Hex())
+	logger.Logf("INFO allocateYieldToEpoch transaction successful: %s", tx.Hash().Hex())
 	return nil
 }
 
@@ -280,8 +467,18 @@ func (c *Client) AllocateCumulativeYieldToEpoch(
 	epochId *big.Int,
 	vaultAddress string,
 	amount *big.Int,
-) error {
-	c.logger.Logf(
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.AllocateCumulativeYieldToEpoch",
+		attribute.String("epoch.id", epochId.String()), attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("allocateCumulativeYieldToEpoch"); err != nil {
+		return err
+	}
+
+	logger.Logf(
 		"INFO allocating cumulative yield %s to epoch %s for vault %s",
 		amount.String(),
 		epochId.String(),
@@ -289,14 +486,21 @@ func (c *Client) AllocateCumulativeYieldToEpoch(
 	)
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("WARN Ethereum client not initialized, skipping allocateCumulativeYieldToEpoch call")
+		logger.Logf("WARN Ethereum client not initialized, skipping allocateCumulativeYieldToEpoch call")
 		return nil
 	}
 
+	rec := &txRecord{
+		action:     "allocateCumulativeYieldToEpoch",
+		contract:   vaultAddress,
+		parameters: map[string]string{"epochId": epochId.String(), "amount": amount.String()},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	// Get chain ID for signing
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
@@ -304,7 +508,7 @@ func (c *Client) AllocateCumulativeYieldToEpoch(
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -322,24 +526,30 @@ func (c *Client) AllocateCumulativeYieldToEpoch(
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call allocateCumulativeYieldToEpoch: %v", err)
+		logger.Logf("ERROR failed to call allocateCumulativeYieldToEpoch: %v", err)
 		return fmt.Errorf("failed to call allocateCumulativeYieldToEpoch: %w", err)
 	}
 
-	c.logger.Logf("INFO allocateCumulativeYieldToEpoch transaction sent: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO allocateCumulativeYieldToEpoch transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for allocateCumulativeYieldToEpoch transaction: %v", err)
+		logger.Logf("ERROR failed to wait for allocateCumulativeYieldToEpoch transaction: %v", err)
 		return fmt.Errorf("failed to wait for allocateCumulativeYieldToEpoch transaction: %w", err)
 	}
+	rec.mined(receipt)
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR allocateCumulativeYieldToEpoch transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR allocateCumulativeYieldToEpoch transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("allocateCumulativeYieldToEpoch transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf("INFO allocateCumulativeYieldToEpoch transaction successful: %s", tx.Hash().Hex())
+	logger.Logf("INFO allocateCumulativeYieldToEpoch transaction successful: %s", tx.Hash().Hex())
 	return nil
 }
 
@@ -349,25 +559,47 @@ func (c *Client) EndEpochWithSubsidies(
 	vaultAddress string,
 	merkleRoot [32]byte,
 	subsidiesDistributed *big.Int,
-) error {
-	c.logger.Logf("INFO ending epoch %s with subsidies: vault=%s, merkleRoot=%x, subsidies=%s",
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.EndEpochWithSubsidies",
+		attribute.String("epoch.id", epochId.String()), attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("endEpochWithSubsidies"); err != nil {
+		return err
+	}
+
+	logger.Logf("INFO ending epoch %s with subsidies: vault=%s, merkleRoot=%x, subsidies=%s",
 		epochId.String(), vaultAddress, merkleRoot, subsidiesDistributed.String())
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("ERROR Ethereum client not initialized")
+		logger.Logf("ERROR Ethereum client not initialized")
 		return fmt.Errorf("ethereum client not initialized")
 	}
 
+	rec := &txRecord{
+		action:   "endEpochWithSubsidies",
+		contract: c.ethConfig.EpochManager,
+		parameters: map[string]string{
+			"epochId":              epochId.String(),
+			"vault":                vaultAddress,
+			"merkleRoot":           fmt.Sprintf("0x%x", merkleRoot),
+			"subsidiesDistributed": subsidiesDistributed.String(),
+		},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -378,48 +610,84 @@ func (c *Client) EndEpochWithSubsidies(
 	contractInstance := c.epochManager.Instance(c.ethClient, contractAddr)
 	vaultAddr := common.HexToAddress(vaultAddress)
 	data := c.epochManager.PackEndEpochWithSubsidies(epochId, vaultAddr, merkleRoot, subsidiesDistributed)
+	// with a fork configured the transaction is only signed here, and sent once it succeeded on the fork
+	opts.NoSend = c.ethConfig.ForkURL != ""
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call endEpochWithSubsidies: %v", err)
+		logger.Logf("ERROR failed to call endEpochWithSubsidies: %v", err)
 		return fmt.Errorf("failed to call endEpochWithSubsidies: %w", err)
 	}
 
-	c.logger.Logf("INFO endEpochWithSubsidies transaction sent: %s", tx.Hash().Hex())
+	if opts.NoSend {
+		if err := c.dryRunOnFork(ctx, tx, "EpochFinalized", map[string]string{"epochId": epochId.String()}); err != nil {
+			logger.Logf("ERROR endEpochWithSubsidies not sent: %v", err)
+			return err
+		}
+		if err := c.ethClient.SendTransaction(ctx, tx); err != nil {
+			logger.Logf("ERROR failed to call endEpochWithSubsidies: %v", err)
+			return fmt.Errorf("failed to call endEpochWithSubsidies: %w", err)
+		}
+	}
+
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO endEpochWithSubsidies transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for endEpochWithSubsidies transaction: %v", err)
+		logger.Logf("ERROR failed to wait for endEpochWithSubsidies transaction: %v", err)
 		return fmt.Errorf("failed to wait for endEpochWithSubsidies transaction: %w", err)
 	}
+	rec.mined(receipt)
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR endEpochWithSubsidies transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR endEpochWithSubsidies transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("endEpochWithSubsidies transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf("INFO endEpochWithSubsidies transaction successful: %s", tx.Hash().Hex())
+	logger.Logf("INFO endEpochWithSubsidies transaction successful: %s", tx.Hash().Hex())
 	return nil
 }
 
-func (c *Client) ForceEndEpochWithZeroYield(ctx context.Context, epochId *big.Int, vaultAddress string) error {
-	c.logger.Logf("INFO force ending epoch %s with zero yield: vault=%s", epochId.String(), vaultAddress)
+func (c *Client) ForceEndEpochWithZeroYield(ctx context.Context, epochId *big.Int, vaultAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.ForceEndEpochWithZeroYield",
+		attribute.String("epoch.id", epochId.String()), attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("forceEndEpochWithZeroYield"); err != nil {
+		return err
+	}
+
+	logger.Logf("INFO force ending epoch %s with zero yield: vault=%s", epochId.String(), vaultAddress)
 
 	if c.ethClient == nil || c.privateKey == nil {
-		c.logger.Logf("ERROR Ethereum client not initialized")
+		logger.Logf("ERROR Ethereum client not initialized")
 		return fmt.Errorf("ethereum client not initialized")
 	}
 
+	rec := &txRecord{
+		action:     "forceEndEpochWithZeroYield",
+		contract:   c.ethConfig.EpochManager,
+		parameters: map[string]string{"epochId": epochId.String(), "vault": vaultAddress},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -433,13 +701,16 @@ func (c *Client) ForceEndEpochWithZeroYield(ctx context.Context, epochId *big.In
 	contractInstance := c.epochManager.Instance(c.ethClient, contractAddr)
 	tx, err := contractInstance.RawTransact(opts, data)
 	if err != nil {
-		c.logger.Logf("ERROR failed to call forceEndEpochWithZeroYield: %v", err)
+		logger.Logf("ERROR failed to call forceEndEpochWithZeroYield: %v", err)
 		return fmt.Errorf("failed to call forceEndEpochWithZeroYield: %w", err)
 	}
 
-	c.logger.Logf("INFO forceEndEpochWithZeroYield transaction sent: %s", tx.Hash().Hex())
-
-	c.logger.Logf("INFO forceEndEpochWithZeroYield transaction successful: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO forceEndEpochWithZeroYield transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
+	c.watchReceipt(ctx, rec, tx)
 	return nil
 }
 
@@ -448,24 +719,44 @@ func (c *Client) UpdateMerkleRoot(
 	vaultId string,
 	root [32]byte,
 	totalSubsidies *big.Int,
-) error {
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.UpdateMerkleRoot", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("updateMerkleRoot"); err != nil {
+		return err
+	}
+
 	if c.ethClient == nil {
-		c.logger.Logf("INFO [MOCK] updating merkle root for vault %s: %x", vaultId, root)
+		logger.Logf("INFO [MOCK] updating merkle root for vault %s: %x", vaultId, root)
 		return nil
 	}
 
-	c.logger.Logf("INFO updating merkle root for vault %s: %x", vaultId, root)
+	rec := &txRecord{
+		action:   "updateMerkleRoot",
+		contract: c.ethConfig.DebtSubsidizer,
+		parameters: map[string]string{
+			"vault":          vaultId,
+			"merkleRoot":     fmt.Sprintf("0x%x", root),
+			"totalSubsidies": totalSubsidies.String(),
+		},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
+	logger.Logf("INFO updating merkle root for vault %s: %x", vaultId, root)
 
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -480,11 +771,16 @@ func (c *Client) UpdateMerkleRoot(
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call updateMerkleRoot: %v", err)
+		logger.Logf("ERROR failed to call updateMerkleRoot: %v", err)
 		return fmt.Errorf("failed to call updateMerkleRoot: %w", err)
 	}
 
-	c.logger.Logf("INFO updateMerkleRoot transaction sent: %s", tx.Hash().Hex())
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO updateMerkleRoot transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
+	c.watchReceipt(ctx, rec, tx)
 	return nil
 }
 
@@ -493,26 +789,46 @@ func (c *Client) UpdateMerkleRootAndWaitForConfirmation(
 	vaultId string,
 	root [32]byte,
 	totalSubsidies *big.Int,
-) error {
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.UpdateMerkleRootAndWaitForConfirmation", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("updateMerkleRoot"); err != nil {
+		return err
+	}
+
 	if c.ethClient == nil {
-		c.logger.Logf("INFO [MOCK] updating merkle root for vault %s: %x", vaultId, root)
-		c.logger.Logf("INFO [MOCK] submitting UpdateMerkleRoot transaction for vault %s", vaultId)
-		c.logger.Logf("INFO [MOCK] waiting for transaction confirmation for vault %s", vaultId)
+		logger.Logf("INFO [MOCK] updating merkle root for vault %s: %x", vaultId, root)
+		logger.Logf("INFO [MOCK] submitting UpdateMerkleRoot transaction for vault %s", vaultId)
+		logger.Logf("INFO [MOCK] waiting for transaction confirmation for vault %s", vaultId)
 		return nil
 	}
 
-	c.logger.Logf("INFO updating merkle root for vault %s: %x", vaultId, root)
+	rec := &txRecord{
+		action:   "updateMerkleRoot",
+		contract: c.ethConfig.DebtSubsidizer,
+		parameters: map[string]string{
+			"vault":          vaultId,
+			"merkleRoot":     fmt.Sprintf("0x%x", root),
+			"totalSubsidies": totalSubsidies.String(),
+		},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
+	logger.Logf("INFO updating merkle root for vault %s: %x", vaultId, root)
 
 	chainID, err := c.ethClient.ChainID(ctx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to get chain ID: %v", err)
+		logger.Logf("ERROR failed to get chain ID: %v", err)
 		return err
 	}
 
 	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
 	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
 	if err != nil {
-		c.logger.Logf("ERROR failed to create transactor: %v", err)
+		logger.Logf("ERROR failed to create transactor: %v", err)
 		return err
 	}
 	opts.GasLimit = c.ethConfig.GasLimit
@@ -527,26 +843,45 @@ func (c *Client) UpdateMerkleRootAndWaitForConfirmation(
 	tx, err := contractInstance.RawTransact(opts, data)
 
 	if err != nil {
-		c.logger.Logf("ERROR failed to call updateMerkleRoot: %v", err)
+		logger.Logf("ERROR failed to call updateMerkleRoot: %v", err)
 		return fmt.Errorf("failed to call updateMerkleRoot: %w", err)
 	}
 
-	c.logger.Logf("INFO submitting UpdateMerkleRoot transaction for vault %s", vaultId)
-	c.logger.Logf("INFO updateMerkleRoot transaction sent: %s", tx.Hash().Hex())
+	logger.Logf("INFO submitting UpdateMerkleRoot transaction for vault %s", vaultId)
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO updateMerkleRoot transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
 
-	c.logger.Logf("INFO waiting for transaction confirmation for vault %s", vaultId)
+	logger.Logf("INFO waiting for transaction confirmation for vault %s", vaultId)
 	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
 	if err != nil {
-		c.logger.Logf("ERROR failed to wait for updateMerkleRoot transaction: %v", err)
+		logger.Logf("ERROR failed to wait for updateMerkleRoot transaction: %v", err)
 		return fmt.Errorf("failed to wait for updateMerkleRoot transaction: %w", err)
 	}
+	rec.mined(receipt)
 
 	if receipt.Status == 0 {
-		c.logger.Logf("ERROR updateMerkleRoot transaction failed: %s", tx.Hash().Hex())
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR updateMerkleRoot transaction failed: %s", tx.Hash().Hex())
 		return fmt.Errorf("updateMerkleRoot transaction failed with hash %s", tx.Hash().Hex())
 	}
 
-	c.logger.Logf(
+	// the root is final once buried under the merkle root confirmations, a reorg meanwhile restarts the count
+	if c.receipts != nil && c.ethConfig.RootConfirmations > 1 {
+		if receipt, err = c.waitConfirmed(ctx, tx.Hash(), c.ethConfig.RootConfirmations); err != nil {
+			logger.Logf("ERROR updateMerkleRoot transaction %s not final: %v", tx.Hash().Hex(), err)
+			return fmt.Errorf("updateMerkleRoot transaction %s not final: %w", tx.Hash().Hex(), err)
+		}
+		rec.mined(receipt)
+		if receipt.Status == 0 {
+			logger.Logf("ERROR updateMerkleRoot transaction failed after a reorg: %s", tx.Hash().Hex())
+			return fmt.Errorf("updateMerkleRoot transaction failed with hash %s", tx.Hash().Hex())
+		}
+	}
+
+	logger.Logf(
 		"INFO transaction confirmed for vault %s (block: %d, gas used: %d)",
 		vaultId,
 		receipt.BlockNumber.Uint64(),
@@ -554,3 +889,535 @@ func (c *Client) UpdateMerkleRootAndWaitForConfirmation(
 	)
 	return nil
 }
+
+// EstimateRepayBorrowBehalfBatchGas estimates the gas a repayBorrowBehalfBatch call would use.
+// A batch rejected for its size returns blockchain.ErrBatchSizeExceedsLimit.
+func (c *Client) EstimateRepayBorrowBehalfBatchGas(
+	ctx context.Context,
+	vaultAddress string,
+	borrowers []string,
+	amounts []*big.Int,
+) (_ uint64, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.EstimateRepayBorrowBehalfBatchGas",
+		attribute.String("vault.id", vaultAddress), attribute.Int("batch.size", len(borrowers)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := c.rejectReadOnly("repayBorrowBehalfBatch"); err != nil {
+		return 0, err
+	}
+
+	if c.ethClient == nil || c.privateKey == nil {
+		c.logger.Logf("INFO [MOCK] estimating repayBorrowBehalfBatch gas for %d borrowers in vault %s", len(borrowers), vaultAddress)
+		return 0, nil
+	}
+
+	data, _ := c.packRepayBorrowBehalfBatch(borrowers, amounts)
+	vaultAddr := common.HexToAddress(vaultAddress)
+	gas, err := c.ethClient.EstimateGas(ctx, ethereum.CallMsg{
+		From: crypto.PubkeyToAddress(c.privateKey.PublicKey),
+		To:   &vaultAddr,
+		Data: data,
+	})
+	if err != nil {
+		if decodeRevertReason(err) == "BatchSizeExceedsLimit" {
+			return 0, fmt.Errorf("%w: %d borrowers", blockchain.ErrBatchSizeExceedsLimit, len(borrowers))
+		}
+		return 0, fmt.Errorf("failed to estimate repayBorrowBehalfBatch gas: %w", err)
+	}
+
+	return gas, nil
+}
+
+// RepayBorrowBehalfBatch repays the borrowers' debt from vault yield in a single transaction and waits for it to be mined.
+// Errors wrap blockchain.ErrTxNotSent or blockchain.ErrTxReverted when the batch certainly did not execute.
+func (c *Client) RepayBorrowBehalfBatch(
+	ctx context.Context,
+	vaultAddress string,
+	borrowers []string,
+	amounts []*big.Int,
+	gasLimit uint64,
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.RepayBorrowBehalfBatch",
+		attribute.String("vault.id", vaultAddress), attribute.Int("batch.size", len(borrowers)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly("repayBorrowBehalfBatch"); err != nil {
+		return err
+	}
+
+	data, totalAmount := c.packRepayBorrowBehalfBatch(borrowers, amounts)
+
+	if c.ethClient == nil || c.privateKey == nil {
+		logger.Logf("INFO [MOCK] repaying %s for %d borrowers in vault %s", totalAmount.String(), len(borrowers), vaultAddress)
+		return nil
+	}
+
+	rec := &txRecord{
+		action:   "repayBorrowBehalfBatch",
+		contract: vaultAddress,
+		parameters: map[string]string{
+			"borrowers":   strconv.Itoa(len(borrowers)),
+			"totalAmount": totalAmount.String(),
+		},
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
+	logger.Logf("INFO repaying %s for %d borrowers in vault %s", totalAmount.String(), len(borrowers), vaultAddress)
+
+	chainID, err := c.ethClient.ChainID(ctx)
+	if err != nil {
+		logger.Logf("ERROR failed to get chain ID: %v", err)
+		return fmt.Errorf("%w: failed to get chain ID: %v", blockchain.ErrTxNotSent, err)
+	}
+
+	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
+	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
+	if err != nil {
+		logger.Logf("ERROR failed to create transactor: %v", err)
+		return fmt.Errorf("%w: failed to create transactor: %v", blockchain.ErrTxNotSent, err)
+	}
+	opts.GasLimit = gasLimit
+	opts.GasPrice = gasPrice
+	opts.Context = ctx
+
+	contractInstance := c.vault.Instance(c.ethClient, common.HexToAddress(vaultAddress))
+	tx, err := contractInstance.RawTransact(opts, data)
+	if err != nil {
+		logger.Logf("ERROR failed to call repayBorrowBehalfBatch: %v", err)
+		return fmt.Errorf("%w: failed to call repayBorrowBehalfBatch: %v", blockchain.ErrTxNotSent, err)
+	}
+
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO repayBorrowBehalfBatch transaction sent: %s", tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
+
+	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
+	if err != nil {
+		logger.Logf("ERROR failed to wait for repayBorrowBehalfBatch transaction %s: %v", tx.Hash().Hex(), err)
+		return fmt.Errorf("failed to wait for repayBorrowBehalfBatch transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	rec.mined(receipt)
+
+	if receipt.Status == 0 {
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR repayBorrowBehalfBatch transaction failed: %s", tx.Hash().Hex())
+		return fmt.Errorf("%w: repayBorrowBehalfBatch transaction %s", blockchain.ErrTxReverted, tx.Hash().Hex())
+	}
+
+	logger.Logf("INFO repayBorrowBehalfBatch transaction successful: %s", tx.Hash().Hex())
+	return nil
+}
+
+// packRepayBorrowBehalfBatch encodes the call and returns it with the batch total the contract checks against
+func (c *Client) packRepayBorrowBehalfBatch(borrowers []string, amounts []*big.Int) ([]byte, *big.Int) {
+	addresses := make([]common.Address, len(borrowers))
+	for i, borrower := range borrowers {
+		addresses[i] = common.HexToAddress(borrower)
+	}
+	totalAmount := new(big.Int)
+	for _, amount := range amounts {
+		totalAmount.Add(totalAmount, amount)
+	}
+	return c.vault.PackRepayBorrowBehalfBatch(amounts, addresses, totalAmount), totalAmount
+}
+
+func (c *Client) GetMerkleRoot(ctx context.Context, vaultId string) (_ [32]byte, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetMerkleRoot", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return [32]byte{}, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(c.ethConfig.DebtSubsidizer)
+	data := c.subsidizer.PackGetMerkleRoot(common.HexToAddress(vaultId))
+
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call getMerkleRoot for vault %s: %v", vaultId, err)
+		return [32]byte{}, fmt.Errorf("failed to call getMerkleRoot: %w", err)
+	}
+
+	root, err := c.subsidizer.UnpackGetMerkleRoot(output)
+	if err != nil {
+		return [32]byte{}, fmt.Errorf("failed to unpack getMerkleRoot result: %w", err)
+	}
+
+	c.logger.Logf("DEBUG on-chain merkle root for vault %s: %x", vaultId, root)
+	return root, nil
+}
+
+// GetUserClaimedTotal returns how much of the vault's subsidies user has claimed so far
+func (c *Client) GetUserClaimedTotal(ctx context.Context, vaultId, userAddress string) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetUserClaimedTotal", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(c.ethConfig.DebtSubsidizer)
+	data := c.subsidizer.PackGetUserClaimedTotal(common.HexToAddress(vaultId), common.HexToAddress(userAddress))
+
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call getUserClaimedTotal for user %s in vault %s: %v", userAddress, vaultId, err)
+		return nil, fmt.Errorf("failed to call getUserClaimedTotal: %w", err)
+	}
+
+	claimed, err := c.subsidizer.UnpackGetUserClaimedTotal(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getUserClaimedTotal result: %w", err)
+	}
+	return claimed, nil
+}
+
+// GetEpochYieldAllocated returns the yield the vault allocated to epochId
+func (c *Client) GetEpochYieldAllocated(ctx context.Context, epochId *big.Int, vaultAddress string) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetEpochYieldAllocated",
+		attribute.String("epoch.id", epochId.String()), attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.vault.PackGetEpochYieldAllocated(epochId)}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call getEpochYieldAllocated for epoch %s in vault %s: %v", epochId, vaultAddress, err)
+		return nil, fmt.Errorf("failed to call getEpochYieldAllocated: %w", err)
+	}
+
+	allocated, err := c.vault.UnpackGetEpochYieldAllocated(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getEpochYieldAllocated result: %w", err)
+	}
+	return allocated, nil
+}
+
+// GetRemainingCumulativeYield returns the yield the vault has earned but not yet allocated to an epoch
+func (c *Client) GetRemainingCumulativeYield(ctx context.Context, vaultAddress string) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetRemainingCumulativeYield", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.vault.PackGetRemainingCumulativeYield()}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call getRemainingCumulativeYield for vault %s: %v", vaultAddress, err)
+		return nil, fmt.Errorf("failed to call getRemainingCumulativeYield: %w", err)
+	}
+
+	remaining, err := c.vault.UnpackGetRemainingCumulativeYield(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getRemainingCumulativeYield result: %w", err)
+	}
+	return remaining, nil
+}
+
+// GetTokenBalance returns the ERC-20 balance of holderAddress, balanceOf(holder) of the token
+func (c *Client) GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetTokenBalance", attribute.String("token.address", tokenAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(tokenAddress)
+	data := c.erc20.PackBalanceOf(common.HexToAddress(holderAddress))
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call balanceOf(%s) on token %s: %v", holderAddress, tokenAddress, err)
+		return nil, fmt.Errorf("failed to call balanceOf: %w", err)
+	}
+
+	balance, err := c.erc20.UnpackBalanceOf(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack balanceOf result: %w", err)
+	}
+	return balance, nil
+}
+
+// GetAssetMetadata reads the vault's asset() and the asset's decimals() and symbol()
+func (c *Client) GetAssetMetadata(ctx context.Context, vaultAddress string) (_ *blockchain.AssetMetadata, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetAssetMetadata", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	vaultAddr := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &vaultAddr, Data: c.vault.PackAsset()}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call asset for vault %s: %v", vaultAddress, err)
+		return nil, fmt.Errorf("failed to call asset: %w", err)
+	}
+	asset, err := c.vault.UnpackAsset(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack asset result: %w", err)
+	}
+
+	// the vault is an ERC-4626 share token, so its binding packs the same ERC-20 metadata calls the asset answers
+	output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &asset, Data: c.vault.PackDecimals()}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call decimals on asset %s: %w", asset.Hex(), err)
+	}
+	decimals, err := c.vault.UnpackDecimals(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack decimals result: %w", err)
+	}
+	output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &asset, Data: c.vault.PackSymbol()}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call symbol on asset %s: %w", asset.Hex(), err)
+	}
+	symbol, err := c.vault.UnpackSymbol(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack symbol result: %w", err)
+	}
+
+	return &blockchain.AssetMetadata{Address: utils.NormalizeAddress(asset.Hex()), Symbol: symbol, Decimals: decimals}, nil
+}
+
+// GetCurrentEpochYield returns the shared yield the vault holds for the current epoch, getCurrentEpochYield(false)
+func (c *Client) GetCurrentEpochYield(ctx context.Context, vaultAddress string) (_ *big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetCurrentEpochYield", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.vault.PackGetCurrentEpochYield(false)}, nil)
+	if err != nil {
+		c.logger.Logf("ERROR failed to call getCurrentEpochYield for vault %s: %v", vaultAddress, err)
+		return nil, fmt.Errorf("failed to call getCurrentEpochYield: %w", err)
+	}
+
+	yield, err := c.vault.UnpackGetCurrentEpochYield(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack getCurrentEpochYield result: %w", err)
+	}
+	return yield, nil
+}
+
+// SimulateEpochFinalization dry-runs, as the signer against the latest block, the updateMerkleRoot and
+// endEpochWithSubsidies calls that finalize the vault's epoch, so a signer without the roles they require is
+// found before a root is pushed. It returns the first call that would revert, with its revert reason.
+func (c *Client) SimulateEpochFinalization(
+	ctx context.Context,
+	epochId *big.Int,
+	vaultId string,
+	root [32]byte,
+	totalSubsidies *big.Int,
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.SimulateEpochFinalization", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if err := c.rejectReadOnly("simulate epoch finalization"); err != nil {
+		return err
+	}
+	if c.ethClient == nil || c.privateKey == nil {
+		return fmt.Errorf("ethereum client not initialized")
+	}
+
+	vaultAddress := common.HexToAddress(vaultId)
+	calls := []struct {
+		method   string
+		contract string
+		data     []byte
+	}{
+		{"updateMerkleRoot", c.ethConfig.DebtSubsidizer, c.subsidizer.PackUpdateMerkleRoot(vaultAddress, root, totalSubsidies)},
+		{"endEpochWithSubsidies", c.ethConfig.EpochManager, c.epochManager.PackEndEpochWithSubsidies(epochId, vaultAddress, [32]byte{}, big.NewInt(0))},
+	}
+	from := crypto.PubkeyToAddress(c.privateKey.PublicKey)
+	for _, call := range calls {
+		to := common.HexToAddress(call.contract)
+		if _, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{From: from, To: &to, Data: call.data}, nil); err != nil {
+			if reason := decodeRevertReason(err); reason != "" {
+				return fmt.Errorf("%s from %s would revert: %s", call.method, from.Hex(), reason)
+			}
+			return fmt.Errorf("failed to simulate %s: %w", call.method, err)
+		}
+	}
+	return nil
+}
+
+// GetPauseState reads whether the DebtSubsidizer is paused and whether it still registers the vault
+func (c *Client) GetPauseState(ctx context.Context, vaultId string) (_ *blockchain.PauseState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetPauseState", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(c.ethConfig.DebtSubsidizer)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.subsidizer.PackPaused()}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call paused: %w", err)
+	}
+	paused, err := c.subsidizer.UnpackPaused(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack paused result: %w", err)
+	}
+
+	data := c.subsidizer.PackIsVaultRemoved(common.HexToAddress(vaultId))
+	output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call isVaultRemoved: %w", err)
+	}
+	removed, err := c.subsidizer.UnpackIsVaultRemoved(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack isVaultRemoved result: %w", err)
+	}
+
+	return &blockchain.PauseState{SubsidizerPaused: paused, VaultRemoved: removed}, nil
+}
+
+// GetOnChainEpochState reads the vault's epoch, yield and subsidy state from the EpochManager, the vault and
+// the DebtSubsidizer, every call pinned to the latest block so the values are consistent with each other
+func (c *Client) GetOnChainEpochState(ctx context.Context, vaultId string) (_ *blockchain.OnChainEpochState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetOnChainEpochState", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	header, err := c.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+	state := &blockchain.OnChainEpochState{
+		Block: blockchain.BlockRef{Number: header.Number.Uint64(), Hash: header.Hash().Hex(), Timestamp: header.Time},
+	}
+
+	epochManagerAddr := common.HexToAddress(c.ethConfig.EpochManager)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &epochManagerAddr, Data: c.epochManager.PackGetCurrentEpochId()}, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call getCurrentEpochId: %w", err)
+	}
+	if state.CurrentEpochID, err = c.epochManager.UnpackGetCurrentEpochId(output); err != nil {
+		return nil, fmt.Errorf("failed to unpack getCurrentEpochId result: %w", err)
+	}
+
+	// the rest of the state is read in one batch
+	vault := common.HexToAddress(vaultId)
+	epochManager, subsidizer := c.ethConfig.EpochManager, c.ethConfig.DebtSubsidizer
+	reads := []struct {
+		method string
+		call   blockchain.ViewCall
+		unpack func([]byte) (*big.Int, error)
+		value  **big.Int
+	}{
+		{"getVaultYieldForEpoch", blockchain.ViewCall{Target: epochManager, Data: c.epochManager.PackGetVaultYieldForEpoch(state.CurrentEpochID, vault)},
+			c.epochManager.UnpackGetVaultYieldForEpoch, &state.VaultYieldForEpoch},
+		{"totalYieldAllocated", blockchain.ViewCall{Target: vaultId, Data: c.vault.PackTotalYieldAllocated()},
+			c.vault.UnpackTotalYieldAllocated, &state.TotalYieldAllocated},
+		{"totalYieldReserved", blockchain.ViewCall{Target: vaultId, Data: c.vault.PackTotalYieldReserved()},
+			c.vault.UnpackTotalYieldReserved, &state.TotalYieldReserved},
+		{"totalAssetsDeposited", blockchain.ViewCall{Target: vaultId, Data: c.vault.PackTotalAssetsDeposited()},
+			c.vault.UnpackTotalAssetsDeposited, &state.TotalAssetsDeposited},
+		{"getTotalSubsidies", blockchain.ViewCall{Target: subsidizer, Data: c.subsidizer.PackGetTotalSubsidies(vault)},
+			c.subsidizer.UnpackGetTotalSubsidies, &state.TotalSubsidies},
+		{"getTotalSubsidiesClaimed", blockchain.ViewCall{Target: subsidizer, Data: c.subsidizer.PackGetTotalSubsidiesClaimed(vault)},
+			c.subsidizer.UnpackGetTotalSubsidiesClaimed, &state.TotalSubsidiesClaimed},
+		{"getRemainingSubsidies", blockchain.ViewCall{Target: subsidizer, Data: c.subsidizer.PackGetRemainingSubsidies(vault)},
+			c.subsidizer.UnpackGetRemainingSubsidies, &state.RemainingSubsidies},
+	}
+	calls := make([]blockchain.ViewCall, 0, len(reads)+1)
+	for _, read := range reads {
+		calls = append(calls, read.call)
+	}
+	calls = append(calls, blockchain.ViewCall{Target: subsidizer, Data: c.subsidizer.PackGetMerkleRoot(vault)})
+
+	outputs, err := c.callViews(ctx, calls, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain state of vault %s: %w", vaultId, err)
+	}
+	for i, read := range reads {
+		if *read.value, err = read.unpack(outputs[i]); err != nil {
+			return nil, fmt.Errorf("failed to unpack %s result: %w", read.method, err)
+		}
+	}
+	if state.MerkleRoot, err = c.subsidizer.UnpackGetMerkleRoot(outputs[len(reads)]); err != nil {
+		return nil, fmt.Errorf("failed to unpack getMerkleRoot result: %w", err)
+	}
+
+	return state, nil
+}
+
+// GetBlockRef returns the number, hash and timestamp of blockNumber, or of the latest block when blockNumber
+// is nil. rpc.FinalizedBlockNumber and the other block tags are accepted as blockNumber.
+func (c *Client) GetBlockRef(ctx context.Context, blockNumber *big.Int) (_ *blockchain.BlockRef, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetBlockRef")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	header, err := c.ethClient.HeaderByNumber(ctx, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+
+	return &blockchain.BlockRef{
+		Number:    header.Number.Uint64(),
+		Hash:      header.Hash().Hex(),
+		Timestamp: header.Time,
+	}, nil
+}
+
+// HasCode reports whether a contract is deployed at address in the latest block
+func (c *Client) HasCode(ctx context.Context, address string) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.HasCode", attribute.String("contract.address", address))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return false, fmt.Errorf("ethereum client not initialized")
+	}
+
+	code, err := c.ethClient.CodeAt(ctx, common.HexToAddress(address), nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get code at %s: %w", address, err)
+	}
+	return len(code) > 0, nil
+}
+
+// GetSignerBalance returns the latest ETH balance of the account derived from the private key
+func (c *Client) GetSignerBalance(ctx context.Context) (_ *blockchain.SignerBalance, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetSignerBalance")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethConfig.ReadOnly {
+		return nil, fmt.Errorf("%w: no signer account", blockchain.ErrReadOnly)
+	}
+	if c.ethClient == nil || c.privateKey == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	address := crypto.PubkeyToAddress(c.privateKey.PublicKey)
+	balance, err := c.ethClient.BalanceAt(ctx, address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance of %s: %w", address.Hex(), err)
+	}
+
+	return &blockchain.SignerBalance{Address: address.Hex(), Balance: balance}, nil
+}
+
+// annotateTx attaches the submitted transaction hash to the current span
+func annotateTx(span trace.Span, txHash string) {
+	span.SetAttributes(attribute.String("tx.hash", txHash))
+}
@@ -0,0 +1,153 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// collectionStatsCalls is how many view calls GetCollectionStats makes per collection
+const collectionStatsCalls = 4
+
+// GetCollectionStats reads the collections the CollectionRegistry has, keeps those registered with the vault and
+// reads what the vault records for each. Collections and their stats are each read in one batch.
+func (c *Client) GetCollectionStats(ctx context.Context, vaultAddress string) (_ *blockchain.VaultCollectionStats, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetCollectionStats", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+	if c.ethConfig.CollectionRegistry == "" {
+		return nil, fmt.Errorf("CollectionRegistry contract address is not configured")
+	}
+
+	header, err := c.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+	result := &blockchain.VaultCollectionStats{
+		Block:       blockchain.BlockRef{Number: header.Number.Uint64(), Hash: header.Hash().Hex(), Timestamp: header.Time},
+		Collections: []blockchain.CollectionStats{},
+	}
+
+	registry := contracts.NewICollectionRegistry()
+	registryAddr := common.HexToAddress(c.ethConfig.CollectionRegistry)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &registryAddr, Data: registry.PackAllCollections()}, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call allCollections: %w", err)
+	}
+	all, err := registry.UnpackAllCollections(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack allCollections result: %w", err)
+	}
+
+	calls := make([]blockchain.ViewCall, len(all))
+	for i, collection := range all {
+		calls[i] = blockchain.ViewCall{Target: c.ethConfig.CollectionRegistry, Data: registry.PackGetCollection(collection)}
+	}
+	outputs, err := c.callViews(ctx, calls, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registered collections: %w", err)
+	}
+	vault := common.HexToAddress(vaultAddress)
+	for i, output := range outputs {
+		collection, err := registry.UnpackGetCollection(output)
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack getCollection(%s) result: %w", all[i].Hex(), err)
+		}
+		for _, registered := range collection.Vaults {
+			if registered == vault {
+				result.Collections = append(result.Collections, blockchain.CollectionStats{
+					Collection:    utils.NormalizeAddress(all[i].Hex()),
+					YieldShareBps: collection.YieldSharePercentage,
+				})
+				break
+			}
+		}
+	}
+
+	calls = make([]blockchain.ViewCall, 0, len(result.Collections)*collectionStatsCalls)
+	for _, stats := range result.Collections {
+		collection := common.HexToAddress(stats.Collection)
+		calls = append(calls,
+			blockchain.ViewCall{Target: vaultAddress, Data: c.vault.PackGetCollectionTotalBorrowVolume(collection)},
+			blockchain.ViewCall{Target: vaultAddress, Data: c.vault.PackGetCollectionPerformanceScore(collection)},
+			blockchain.ViewCall{Target: vaultAddress, Data: c.vault.PackGetCollectionTotalYieldGenerated(collection)},
+			blockchain.ViewCall{Target: vaultAddress, Data: c.vault.PackCollectionTotalAssetsDeposited(collection)},
+		)
+	}
+	outputs, err = c.callViews(ctx, calls, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collection stats of vault %s: %w", vaultAddress, err)
+	}
+	for i := range result.Collections {
+		stats := &result.Collections[i]
+		values := outputs[i*collectionStatsCalls : (i+1)*collectionStatsCalls]
+		if stats.BorrowVolume, err = c.vault.UnpackGetCollectionTotalBorrowVolume(values[0]); err != nil {
+			return nil, fmt.Errorf("failed to unpack getCollectionTotalBorrowVolume result: %w", err)
+		}
+		if stats.PerformanceScore, err = c.vault.UnpackGetCollectionPerformanceScore(values[1]); err != nil {
+			return nil, fmt.Errorf("failed to unpack getCollectionPerformanceScore result: %w", err)
+		}
+		if stats.YieldGenerated, err = c.vault.UnpackGetCollectionTotalYieldGenerated(values[2]); err != nil {
+			return nil, fmt.Errorf("failed to unpack getCollectionTotalYieldGenerated result: %w", err)
+		}
+		if stats.AssetsDeposited, err = c.vault.UnpackCollectionTotalAssetsDeposited(values[3]); err != nil {
+			return nil, fmt.Errorf("failed to unpack collectionTotalAssetsDeposited result: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// GetClaimedTotals reads getUserClaimedTotal of every user in one batch
+func (c *Client) GetClaimedTotals(ctx context.Context, vaultId string, users []string) (_ map[string]*big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetClaimedTotals",
+		attribute.String("vault.id", vaultId), attribute.Int("users", len(users)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	header, err := c.ethClient.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block header: %w", err)
+	}
+	vault := common.HexToAddress(vaultId)
+	accounts := make([]string, 0, len(users))
+	calls := make([]blockchain.ViewCall, 0, len(users))
+	seen := make(map[string]bool, len(users))
+	for _, user := range users {
+		account := utils.NormalizeAddress(user)
+		if seen[account] {
+			continue
+		}
+		seen[account] = true
+		accounts = append(accounts, account)
+		calls = append(calls, blockchain.ViewCall{
+			Target: c.ethConfig.DebtSubsidizer,
+			Data:   c.subsidizer.PackGetUserClaimedTotal(vault, common.HexToAddress(account)),
+		})
+	}
+
+	outputs, err := c.callViews(ctx, calls, header.Number)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claimed totals of vault %s: %w", vaultId, err)
+	}
+	claimed := make(map[string]*big.Int, len(accounts))
+	for i, account := range accounts {
+		if claimed[account], err = c.subsidizer.UnpackGetUserClaimedTotal(outputs[i]); err != nil {
+			return nil, fmt.Errorf("failed to unpack getUserClaimedTotal result of %s: %w", account, err)
+		}
+	}
+	return claimed, nil
+}
@@ -0,0 +1,176 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// registryBackend answers as a CollectionRegistry and a vault recording stats for its collections, behind a
+// Multicall3 contract when deployed is set
+type registryBackend struct {
+	ethBackend
+	t                          *testing.T
+	registry, vault, multicall common.Address
+	collections                []common.Address
+	vaults                     map[common.Address][]common.Address // vaults each collection is registered with
+	deployed                   bool
+	mu                         sync.Mutex
+	calls                      int
+	blocks                     []*big.Int
+}
+
+func (b *registryBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{Number: big.NewInt(50)}, nil
+}
+
+func (b *registryBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	if account == b.multicall && b.deployed {
+		return []byte{0x60}, nil
+	}
+	return nil, nil
+}
+
+func (b *registryBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	b.calls++
+	b.blocks = append(b.blocks, blockNumber)
+	b.mu.Unlock()
+	if *msg.To != b.multicall {
+		return b.view(*msg.To, msg.Data)
+	}
+
+	method := multicall3ABI.Methods["aggregate3"]
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(b.t, err)
+	var calls []multicall3Call
+	require.NoError(b.t, method.Inputs.Copy(&calls, args))
+	results := make([]multicall3Result, len(calls))
+	for i, call := range calls {
+		output, err := b.view(call.Target, call.CallData)
+		results[i] = multicall3Result{Success: err == nil, ReturnData: output}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func (b *registryBackend) view(to common.Address, data []byte) ([]byte, error) {
+	parsed := mustParseBinding(b.t, &contracts.ICollectionRegistryMetaData)
+	if to == b.vault {
+		parsed = mustParseBinding(b.t, &contracts.ICollectionsVaultMetaData)
+	}
+	method, err := parsed.MethodById(data[:4])
+	require.NoError(b.t, err)
+	args, err := method.Inputs.Unpack(data[4:])
+	require.NoError(b.t, err)
+
+	switch method.Name {
+	case "allCollections":
+		return method.Outputs.Pack(b.collections)
+	case "getCollection":
+		collection := args[0].(common.Address)
+		return method.Outputs.Pack(contracts.ICollectionRegistryCollection{
+			CollectionAddress:    collection,
+			WeightFunction:       contracts.ICollectionRegistryWeightFunction{P1: big.NewInt(0), P2: big.NewInt(0)},
+			YieldSharePercentage: 2500,
+			Vaults:               b.vaults[collection],
+		})
+	}
+	// every stat of the n-th collection is n times the stat's base
+	collection := args[0].(common.Address)
+	base := map[string]int64{
+		"getCollectionTotalBorrowVolume":   100,
+		"getCollectionPerformanceScore":    1,
+		"getCollectionTotalYieldGenerated": 10,
+		"collectionTotalAssetsDeposited":   1000,
+	}[method.Name]
+	for i, registered := range b.collections {
+		if registered == collection {
+			return method.Outputs.Pack(big.NewInt(base * int64(i+1)))
+		}
+	}
+	return nil, fmt.Errorf("unknown collection %s", collection.Hex())
+}
+
+func mustParseBinding(t *testing.T, metadata interface{ ParseABI() (*abi.ABI, error) }) *abi.ABI {
+	parsed, err := metadata.ParseABI()
+	require.NoError(t, err)
+	return parsed
+}
+
+func newRegistryBackend(t *testing.T, deployed bool) *registryBackend {
+	vault := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x9999999999999999999999999999999999999999")
+	collections := []common.Address{
+		common.HexToAddress("0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"),
+		common.HexToAddress("0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"),
+		common.HexToAddress("0xcccccccccccccccccccccccccccccccccccccccc"),
+	}
+	return &registryBackend{
+		t:           t,
+		registry:    common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		vault:       vault,
+		multicall:   common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11"),
+		collections: collections,
+		vaults: map[common.Address][]common.Address{
+			collections[0]: {vault},
+			collections[1]: {other},
+			collections[2]: {other, vault},
+		},
+		deployed: deployed,
+	}
+}
+
+func TestClient_GetCollectionStats(t *testing.T) {
+	for _, deployed := range []bool{true, false} {
+		t.Run(fmt.Sprintf("multicall deployed %v", deployed), func(t *testing.T) {
+			backend := newRegistryBackend(t, deployed)
+			client := &Client{
+				logger:    lgr.NoOp,
+				ethClient: backend,
+				vault:     contracts.NewICollectionsVault(),
+				ethConfig: blockchain.Config{
+					CollectionRegistry: backend.registry.Hex(),
+					Multicall:          backend.multicall.Hex(),
+					ReadConcurrency:    2,
+				},
+			}
+
+			stats, err := client.GetCollectionStats(context.Background(), backend.vault.Hex())
+			require.NoError(t, err)
+			assert.Equal(t, uint64(50), stats.Block.Number)
+			require.Len(t, stats.Collections, 2, "collections of other vaults are left out")
+			assert.Equal(t, blockchain.CollectionStats{
+				Collection:       "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+				YieldShareBps:    2500,
+				BorrowVolume:     big.NewInt(100),
+				PerformanceScore: big.NewInt(1),
+				YieldGenerated:   big.NewInt(10),
+				AssetsDeposited:  big.NewInt(1000),
+			}, stats.Collections[0])
+			assert.Equal(t, "0xcccccccccccccccccccccccccccccccccccccccc", stats.Collections[1].Collection)
+			assert.Equal(t, big.NewInt(300), stats.Collections[1].BorrowVolume)
+			assert.Equal(t, big.NewInt(3000), stats.Collections[1].AssetsDeposited)
+
+			if deployed {
+				assert.Equal(t, 3, backend.calls, "allCollections, then one aggregate3 per batch")
+			} else {
+				assert.Equal(t, 1+3+2*collectionStatsCalls, backend.calls, "every view is called separately")
+			}
+			for _, block := range backend.blocks {
+				assert.Equal(t, big.NewInt(50), block, "every read is at the same block")
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// EIP-1967 storage slots of a proxy's implementation and of the beacon a beacon proxy asks for it
+var (
+	implementationSlot = common.HexToHash("0x360894a13ba1a3210667c828492db98dca3e2076cc3735a920a3ca505d382bbc")
+	beaconSlot         = common.HexToHash("0xa3f0ad74e5423aebfd80d3ef4346578335a9a72aeaee59ff6cb3582b35133d50")
+)
+
+// versionABI is the version() getter upgradeable contracts commonly expose, and the implementation() of a beacon
+var versionABI = mustParseABI(`[{"type":"function","name":"version","stateMutability":"view",
+	"inputs":[],"outputs":[{"name":"","type":"string"}]},
+	{"type":"function","name":"implementation","stateMutability":"view",
+	"inputs":[],"outputs":[{"name":"","type":"address"}]}]`)
+
+// GetContractCode reads the runtime code at address in the latest block. When address is an EIP-1967 proxy, or a
+// beacon proxy, the code is its implementation's, which is what calls to the proxy run. Version is read through
+// the proxy; a contract whose version() reverts or returns no string has none.
+func (c *Client) GetContractCode(ctx context.Context, address string) (_ *blockchain.ContractCode, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetContractCode", attribute.String("contract.address", address))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contract := common.HexToAddress(address)
+	implementation, err := c.proxyImplementation(ctx, contract)
+	if err != nil {
+		return nil, err
+	}
+	result := &blockchain.ContractCode{Address: address}
+	target := contract
+	if implementation != (common.Address{}) {
+		result.Implementation = implementation.Hex()
+		target = implementation
+	}
+
+	result.Code, err = c.ethClient.CodeAt(ctx, target, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get code at %s: %w", target.Hex(), err)
+	}
+	result.Version = c.contractVersion(ctx, contract)
+	return result, nil
+}
+
+// proxyImplementation returns the implementation the EIP-1967 slots of contract point at, the zero address when
+// contract is no proxy
+func (c *Client) proxyImplementation(ctx context.Context, contract common.Address) (common.Address, error) {
+	slot, err := c.ethClient.StorageAt(ctx, contract, implementationSlot, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to read implementation slot of %s: %w", contract.Hex(), err)
+	}
+	if implementation := common.BytesToAddress(slot); implementation != (common.Address{}) {
+		return implementation, nil
+	}
+
+	slot, err = c.ethClient.StorageAt(ctx, contract, beaconSlot, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to read beacon slot of %s: %w", contract.Hex(), err)
+	}
+	beacon := common.BytesToAddress(slot)
+	if beacon == (common.Address{}) {
+		return common.Address{}, nil
+	}
+	data, err := versionABI.Pack("implementation")
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to pack implementation: %w", err)
+	}
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &beacon, Data: data}, nil)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call implementation on beacon %s: %w", beacon.Hex(), err)
+	}
+	values, err := versionABI.Unpack("implementation", output)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack implementation result: %w", err)
+	}
+	return values[0].(common.Address), nil
+}
+
+// contractVersion returns what version() of contract returns, empty when it has no such getter
+func (c *Client) contractVersion(ctx context.Context, contract common.Address) string {
+	data, err := versionABI.Pack("version")
+	if err != nil {
+		return ""
+	}
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contract, Data: data}, nil)
+	if err != nil || len(output) == 0 {
+		return ""
+	}
+	values, err := versionABI.Unpack("version", output)
+	if err != nil {
+		return ""
+	}
+	version, _ := values[0].(string)
+	return version
+}
@@ -0,0 +1,91 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// proxyBackend answers as contracts with fixed code, EIP-1967 slots and version() results
+type proxyBackend struct {
+	ethBackend
+	code     map[common.Address][]byte
+	slots    map[common.Address]map[common.Hash]common.Address
+	beacons  map[common.Address]common.Address
+	versions map[common.Address]string
+}
+
+func (b *proxyBackend) StorageAt(ctx context.Context, account common.Address, key common.Hash, blockNumber *big.Int) ([]byte, error) {
+	return common.LeftPadBytes(b.slots[account][key].Bytes(), 32), nil
+}
+
+func (b *proxyBackend) CodeAt(ctx context.Context, account common.Address, blockNumber *big.Int) ([]byte, error) {
+	return b.code[account], nil
+}
+
+func (b *proxyBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	switch method, _ := versionABI.MethodById(msg.Data); {
+	case method != nil && method.Name == "implementation":
+		return common.LeftPadBytes(b.beacons[*msg.To].Bytes(), 32), nil
+	case method != nil && method.Name == "version":
+		version, ok := b.versions[*msg.To]
+		if !ok {
+			return nil, errors.New("execution reverted")
+		}
+		return method.Outputs.Pack(version)
+	}
+	return nil, nil
+}
+
+func TestClient_GetContractCode(t *testing.T) {
+	plain := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	proxy := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	beaconProxy := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	beacon := common.HexToAddress("0x4444444444444444444444444444444444444444")
+	implementation := common.HexToAddress("0x5555555555555555555555555555555555555555")
+
+	backend := &proxyBackend{
+		code: map[common.Address][]byte{
+			plain:          {0x60, 0x01},
+			proxy:          {0x36, 0x3d},
+			beaconProxy:    {0x36, 0x3d},
+			implementation: {0x60, 0x80},
+		},
+		slots: map[common.Address]map[common.Hash]common.Address{
+			proxy:       {implementationSlot: implementation},
+			beaconProxy: {beaconSlot: beacon},
+		},
+		beacons:  map[common.Address]common.Address{beacon: implementation},
+		versions: map[common.Address]string{proxy: "2.1.0"},
+	}
+	client := &Client{logger: lgr.NoOp, ethClient: backend}
+
+	tests := []struct {
+		name                   string
+		address                common.Address
+		expectedImplementation string
+		expectedCode           []byte
+		expectedVersion        string
+	}{
+		{"plain_contract", plain, "", []byte{0x60, 0x01}, ""},
+		{"eip1967_proxy", proxy, implementation.Hex(), []byte{0x60, 0x80}, "2.1.0"},
+		{"beacon_proxy", beaconProxy, implementation.Hex(), []byte{0x60, 0x80}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := client.GetContractCode(context.Background(), tt.address.Hex())
+			require.NoError(t, err)
+			assert.Equal(t, tt.address.Hex(), code.Address)
+			assert.Equal(t, tt.expectedImplementation, code.Implementation)
+			assert.Equal(t, tt.expectedCode, code.Code, "the code is what calls to the contract run")
+			assert.Equal(t, tt.expectedVersion, code.Version)
+		})
+	}
+}
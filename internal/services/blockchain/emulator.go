@@ -0,0 +1,431 @@
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// emulatedMaxBatchSize is the most borrowers an emulated vault repays in one repayBorrowBehalfBatch call
+const emulatedMaxBatchSize = 500
+
+// errNotEmulated marks a call the emulated contracts leave to the chain
+var errNotEmulated = errors.New("call is not emulated")
+
+// emulatedRevert is how the emulated contracts reject a call, shaped like the execution reverted error of a node
+// so decodeRevertReason reads it
+type emulatedRevert struct {
+	reason string
+	data   []byte
+}
+
+func (e *emulatedRevert) Error() string          { return "execution reverted: " + e.reason }
+func (e *emulatedRevert) ErrorCode() int         { return 3 }
+func (e *emulatedRevert) ErrorData() interface{} { return hexutil.Encode(e.data) }
+
+// protocolEmulator plays the EpochManager, the DebtSubsidizer and the collections vaults on the simulated chain.
+// The generated bindings carry no bytecode to deploy, so calls to these contracts are decoded with the bindings'
+// ABIs and answered from state that the transactions sent to them update. A vault is any other address called with
+// a vault method. The operator holds every role the contracts grant. Methods the server does not use are not emulated. Events the server reads back are emitted
+// as logs for the chain to stamp with the transaction that emitted them.
+type protocolEmulator struct {
+	mu sync.Mutex
+
+	caller  common.Address // msg.sender of the call being executed
+	emitted []*types.Log   // logs of committed calls, not yet taken by the chain
+
+	epochManager common.Address
+	subsidizer   common.Address
+	operator     common.Address // the EpochManager's automated system and DEBT_SUBSIDIZER_ROLE holder of every vault
+
+	epochManagerABI *abi.ABI
+	subsidizerABI   *abi.ABI
+	vaultABI        *abi.ABI
+	accessABI       *abi.ABI // role getters any of the contracts answer
+
+	// EpochManager
+	currentEpoch  *big.Int
+	epochDuration uint64              // seconds between an epoch's startTime and endTime, 0 ends epochs as they start
+	vaultYield    map[string]*big.Int // yield allocated to an epoch, by epoch and vault
+
+	// DebtSubsidizer
+	paused         bool
+	added          map[common.Address]bool // by addVault, so VaultAdded is emitted
+	removed        map[common.Address]bool
+	roots          map[common.Address][32]byte
+	totalSubsidies map[common.Address]*big.Int
+	claimed        map[common.Address]map[common.Address]*big.Int // by vault and user
+
+	// collections vaults
+	yieldEarned    map[common.Address]*big.Int // yield the vault has earned, allocated or not
+	yieldAllocated map[common.Address]*big.Int
+	repaid         map[common.Address]map[common.Address]*big.Int // by vault and borrower
+}
+
+func newProtocolEmulator(epochManager, subsidizer common.Address) (*protocolEmulator, error) {
+	e := &protocolEmulator{
+		epochManager:   epochManager,
+		subsidizer:     subsidizer,
+		currentEpoch:   big.NewInt(0),
+		vaultYield:     make(map[string]*big.Int),
+		added:          make(map[common.Address]bool),
+		removed:        make(map[common.Address]bool),
+		roots:          make(map[common.Address][32]byte),
+		totalSubsidies: make(map[common.Address]*big.Int),
+		claimed:        make(map[common.Address]map[common.Address]*big.Int),
+		yieldEarned:    make(map[common.Address]*big.Int),
+		yieldAllocated: make(map[common.Address]*big.Int),
+		repaid:         make(map[common.Address]map[common.Address]*big.Int),
+	}
+
+	var err error
+	if e.epochManagerABI, err = contracts.IEpochManagerMetaData.ParseABI(); err != nil {
+		return nil, fmt.Errorf("failed to parse EpochManager ABI: %w", err)
+	}
+	if e.subsidizerABI, err = contracts.IDebtSubsidizerMetaData.ParseABI(); err != nil {
+		return nil, fmt.Errorf("failed to parse DebtSubsidizer ABI: %w", err)
+	}
+	if e.vaultABI, err = contracts.ICollectionsVaultMetaData.ParseABI(); err != nil {
+		return nil, fmt.Errorf("failed to parse CollectionsVault ABI: %w", err)
+	}
+	if e.accessABI, err = accessMetaData.ParseABI(); err != nil {
+		return nil, fmt.Errorf("failed to parse access ABI: %w", err)
+	}
+	return e, nil
+}
+
+// execute runs data sent by from against the contract at to and returns the packed outputs. With commit false
+// it only checks the call would succeed, as eth_call and gas estimation do. Calls that are not emulated return
+// errNotEmulated.
+func (e *protocolEmulator) execute(from, to common.Address, data []byte, commit bool) ([]byte, error) {
+	if len(data) < 4 {
+		return nil, errNotEmulated
+	}
+
+	contract := e.vaultABI
+	switch to {
+	case e.epochManager:
+		contract = e.epochManagerABI
+	case e.subsidizer:
+		contract = e.subsidizerABI
+	}
+	method, err := contract.MethodById(data[:4])
+	if err != nil {
+		if method, err = e.accessABI.MethodById(data[:4]); err != nil {
+			return nil, errNotEmulated
+		}
+	}
+	args, err := method.Inputs.Unpack(data[4:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s call: %w", method.Name, err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.caller = from
+
+	var outputs []interface{}
+	switch to {
+	case e.epochManager:
+		outputs, err = e.epochManagerCall(method.Name, args, commit)
+	case e.subsidizer:
+		outputs, err = e.subsidizerCall(method.Name, args, commit)
+	default:
+		outputs, err = e.vaultCall(to, method.Name, args, commit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return method.Outputs.Pack(outputs...)
+}
+
+func (e *protocolEmulator) epochManagerCall(method string, args []interface{}, commit bool) ([]interface{}, error) {
+	switch method {
+	case "getCurrentEpochId":
+		return []interface{}{new(big.Int).Set(e.currentEpoch)}, nil
+	case "automatedSystem":
+		return []interface{}{e.operator}, nil
+	case "getVaultYieldForEpoch":
+		return []interface{}{e.yieldFor(args[0].(*big.Int), args[1].(common.Address))}, nil
+	case "startEpoch":
+		next := new(big.Int).Add(e.currentEpoch, big.NewInt(1))
+		if commit {
+			e.currentEpoch = next
+			startTime := big.NewInt(time.Now().Unix())
+			endTime := new(big.Int).Add(startTime, new(big.Int).SetUint64(e.epochDuration))
+			if err := e.emit(e.epochManager, e.epochManagerABI, "EpochStarted", next, startTime, endTime); err != nil {
+				return nil, err
+			}
+		}
+		return []interface{}{next}, nil
+	case "endEpochWithSubsidies", "forceEndEpochWithZeroYield":
+		epochID := args[0].(*big.Int)
+		if epochID.Sign() == 0 || epochID.Cmp(e.currentEpoch) > 0 {
+			return nil, customRevert(e.epochManagerABI, "EpochManager__InvalidEpochId", epochID)
+		}
+		return nil, nil
+	}
+	return nil, errNotEmulated
+}
+
+func (e *protocolEmulator) subsidizerCall(method string, args []interface{}, commit bool) ([]interface{}, error) {
+	switch method {
+	case "paused":
+		return []interface{}{e.paused}, nil
+	case "isVaultRemoved":
+		return []interface{}{e.removed[args[0].(common.Address)]}, nil
+	case "addVault":
+		vault := args[0].(common.Address)
+		if e.added[vault] || e.removed[vault] {
+			return nil, customRevert(e.subsidizerABI, "VaultAlreadyRegistered", vault)
+		}
+		if commit {
+			e.added[vault] = true
+			// the emulated vaults have no cToken
+			if err := e.emit(e.subsidizer, e.subsidizerABI, "VaultAdded", vault, common.Address{}, args[1]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case "removeVault":
+		vault := args[0].(common.Address)
+		if e.removed[vault] {
+			return nil, customRevert(e.subsidizerABI, "VaultNotRegistered", vault)
+		}
+		if commit {
+			e.removed[vault] = true
+			if err := e.emit(e.subsidizer, e.subsidizerABI, "VaultRemoved", vault); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case "getMerkleRoot":
+		return []interface{}{e.roots[args[0].(common.Address)]}, nil
+	case "getTotalSubsidies":
+		return []interface{}{amountOf(e.totalSubsidies[args[0].(common.Address)])}, nil
+	case "getTotalSubsidiesClaimed", "getTotalClaimedForVault":
+		return []interface{}{e.claimedTotal(args[0].(common.Address))}, nil
+	case "getRemainingSubsidies":
+		vault := args[0].(common.Address)
+		remaining := new(big.Int).Sub(amountOf(e.totalSubsidies[vault]), e.claimedTotal(vault))
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		return []interface{}{remaining}, nil
+	case "getUserClaimedTotal":
+		return []interface{}{amountOf(e.claimed[args[0].(common.Address)][args[1].(common.Address)])}, nil
+	case "updateMerkleRoot":
+		vault := args[0].(common.Address)
+		if e.paused {
+			return nil, stringRevert("Pausable: paused")
+		}
+		if e.removed[vault] {
+			return nil, customRevert(e.subsidizerABI, "VaultNotRegistered", vault)
+		}
+		if commit {
+			e.roots[vault] = args[1].([32]byte)
+			e.totalSubsidies[vault] = new(big.Int).Set(args[2].(*big.Int))
+			if err := e.emit(e.subsidizer, e.subsidizerABI, "MerkleRootUpdated", vault, args[1], e.caller, args[2]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+	return nil, errNotEmulated
+}
+
+func (e *protocolEmulator) vaultCall(vault common.Address, method string, args []interface{}, commit bool) ([]interface{}, error) {
+	switch method {
+	case "totalYieldAllocated":
+		return []interface{}{amountOf(e.yieldAllocated[vault])}, nil
+	case "totalYieldReserved", "totalAssetsDeposited":
+		return []interface{}{big.NewInt(0)}, nil
+	case "DEBT_SUBSIDIZER_ROLE":
+		return []interface{}{[32]byte(crypto.Keccak256Hash([]byte("DEBT_SUBSIDIZER_ROLE")))}, nil
+	case "hasRole":
+		return []interface{}{args[1].(common.Address) == e.operator}, nil
+	case "getEpochYieldAllocated":
+		return []interface{}{e.yieldFor(args[0].(*big.Int), vault)}, nil
+	case "getCurrentEpochYield":
+		return []interface{}{e.yieldFor(e.currentEpoch, vault)}, nil
+	case "getRemainingCumulativeYield":
+		remaining := new(big.Int).Sub(amountOf(e.yieldEarned[vault]), amountOf(e.yieldAllocated[vault]))
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		return []interface{}{remaining}, nil
+	case "allocateYieldToEpoch":
+		if args[0].(*big.Int).Sign() == 0 {
+			return nil, customRevert(e.vaultABI, "InvalidEpochId")
+		}
+		return nil, nil
+	case "allocateCumulativeYieldToEpoch":
+		epochID, amount := args[0].(*big.Int), args[1].(*big.Int)
+		if epochID.Sign() == 0 {
+			return nil, customRevert(e.vaultABI, "InvalidEpochId")
+		}
+		if amount.Sign() == 0 {
+			return nil, customRevert(e.vaultABI, "AllocationAmountZero")
+		}
+		if commit {
+			e.yieldAllocated[vault] = new(big.Int).Add(amountOf(e.yieldAllocated[vault]), amount)
+			e.vaultYield[yieldKey(epochID, vault)] = new(big.Int).Add(e.yieldFor(epochID, vault), amount)
+		}
+		return nil, nil
+	case "repayBorrowBehalfBatch":
+		amounts, borrowers, total := args[0].([]*big.Int), args[1].([]common.Address), args[2].(*big.Int)
+		if len(borrowers) > emulatedMaxBatchSize {
+			return nil, customRevert(e.vaultABI, "BatchSizeExceedsLimit")
+		}
+		sum := big.NewInt(0)
+		for _, amount := range amounts {
+			sum.Add(sum, amount)
+		}
+		if len(amounts) != len(borrowers) || sum.Cmp(total) != 0 {
+			return nil, customRevert(e.vaultABI, "RepayBorrowFailed")
+		}
+		if commit {
+			if e.repaid[vault] == nil {
+				e.repaid[vault] = make(map[common.Address]*big.Int)
+			}
+			for i, borrower := range borrowers {
+				e.repaid[vault][borrower] = new(big.Int).Add(amountOf(e.repaid[vault][borrower]), amounts[i])
+			}
+		}
+		return nil, nil
+	}
+	return nil, errNotEmulated
+}
+
+// emit logs the contract's event with args in declaration order
+func (e *protocolEmulator) emit(contract common.Address, contractABI *abi.ABI, name string, args ...interface{}) error {
+	event := contractABI.Events[name]
+	topics := []common.Hash{event.ID}
+	var data []interface{}
+	var dataArgs abi.Arguments
+	for i, input := range event.Inputs {
+		if input.Indexed {
+			// the emulated events only index addresses and epoch IDs
+			switch arg := args[i].(type) {
+			case *big.Int:
+				topics = append(topics, common.BigToHash(arg))
+			default:
+				topics = append(topics, common.BytesToHash(arg.(common.Address).Bytes()))
+			}
+			continue
+		}
+		data = append(data, args[i])
+		dataArgs = append(dataArgs, input)
+	}
+	packed, err := dataArgs.Pack(data...)
+	if err != nil {
+		return fmt.Errorf("failed to pack %s event: %w", name, err)
+	}
+	e.emitted = append(e.emitted, &types.Log{Address: contract, Topics: topics, Data: packed})
+	return nil
+}
+
+// takeLogs returns the logs emitted since it was last called
+func (e *protocolEmulator) takeLogs() []*types.Log {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	logs := e.emitted
+	e.emitted = nil
+	return logs
+}
+
+func (e *protocolEmulator) setEpochDuration(seconds uint64) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.epochDuration = seconds
+}
+
+func (e *protocolEmulator) setYieldEarned(vault common.Address, amount *big.Int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.yieldEarned[vault] = new(big.Int).Set(amount)
+}
+
+func (e *protocolEmulator) setPaused(paused bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.paused = paused
+}
+
+func (e *protocolEmulator) removeVault(vault common.Address) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.removed[vault] = true
+}
+
+func (e *protocolEmulator) setClaimed(vault, user common.Address, amount *big.Int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.claimed[vault] == nil {
+		e.claimed[vault] = make(map[common.Address]*big.Int)
+	}
+	e.claimed[vault][user] = new(big.Int).Set(amount)
+}
+
+func (e *protocolEmulator) repaidTo(vault, borrower common.Address) *big.Int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return amountOf(e.repaid[vault][borrower])
+}
+
+func (e *protocolEmulator) yieldFor(epochID *big.Int, vault common.Address) *big.Int {
+	return amountOf(e.vaultYield[yieldKey(epochID, vault)])
+}
+
+func (e *protocolEmulator) claimedTotal(vault common.Address) *big.Int {
+	total := big.NewInt(0)
+	for _, amount := range e.claimed[vault] {
+		total.Add(total, amount)
+	}
+	return total
+}
+
+func yieldKey(epochID *big.Int, vault common.Address) string {
+	return epochID.String() + ":" + vault.Hex()
+}
+
+// amountOf returns a copy of amount, zero when it was never set
+func amountOf(amount *big.Int) *big.Int {
+	if amount == nil {
+		return big.NewInt(0)
+	}
+	return new(big.Int).Set(amount)
+}
+
+// customRevert encodes the contract's custom error name with args as revert data
+func customRevert(contract *abi.ABI, name string, args ...interface{}) error {
+	customErr := contract.Errors[name]
+	packed, err := customErr.Inputs.Pack(args...)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	reason := name
+	if len(args) > 0 {
+		reason = fmt.Sprintf("%s%v", name, args)
+	}
+	return &emulatedRevert{reason: reason, data: append(customErr.ID.Bytes()[:4], packed...)}
+}
+
+// stringRevert encodes reason as an Error(string) revert
+func stringRevert(reason string) error {
+	stringType, _ := abi.NewType("string", "", nil)
+	packed, _ := abi.Arguments{{Type: stringType}}.Pack(reason)
+	return &emulatedRevert{
+		reason: reason,
+		data:   append(crypto.Keccak256([]byte("Error(string)"))[:4], packed...),
+	}
+}
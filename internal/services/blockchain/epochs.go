@@ -0,0 +1,72 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// epochStartedTopic is the topic of EpochStarted(uint256 indexed epochId, uint256 startTime, uint256 endTime)
+var epochStartedTopic = crypto.Keccak256Hash([]byte("EpochStarted(uint256,uint256,uint256)"))
+
+// GetEpochStart returns the latest EpochStarted event the EpochManager emitted for the epoch between fromBlock
+// and toBlock inclusive, nil when there is none. The range is queried logQueryRange blocks at a time from its
+// end, so the current epoch is found after a few calls however old the start of the range is.
+func (c *Client) GetEpochStart(
+	ctx context.Context,
+	epochId *big.Int,
+	fromBlock, toBlock uint64,
+) (_ *blockchain.EpochStart, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetEpochStart", attribute.String("epoch.id", epochId.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+	if fromBlock > toBlock {
+		return nil, nil
+	}
+
+	for end := toBlock; ; end -= logQueryRange {
+		start := fromBlock
+		if end-fromBlock >= logQueryRange {
+			start = end - logQueryRange + 1
+		}
+		logs, err := c.ethClient.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{common.HexToAddress(c.ethConfig.EpochManager)},
+			Topics:    [][]common.Hash{{epochStartedTopic}, {common.BigToHash(epochId)}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter EpochStarted logs in blocks %d-%d: %w", start, end, err)
+		}
+
+		for i := len(logs) - 1; i >= 0; i-- {
+			if logs[i].Removed {
+				continue
+			}
+			event, err := c.epochManager.UnpackEpochStartedEvent(&logs[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to unpack EpochStarted log of tx %s: %w", logs[i].TxHash.Hex(), err)
+			}
+			return &blockchain.EpochStart{
+				EpochID:     event.EpochId,
+				StartTime:   event.StartTime.Uint64(),
+				EndTime:     event.EndTime.Uint64(),
+				TxHash:      logs[i].TxHash.Hex(),
+				BlockNumber: logs[i].BlockNumber,
+			}, nil
+		}
+		if start == fromBlock {
+			return nil, nil // end -= logQueryRange would underflow at the bottom of the range
+		}
+	}
+}
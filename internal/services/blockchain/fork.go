@@ -0,0 +1,82 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+)
+
+// forkDryRunTimeout bounds resetting the fork, sending a transaction to it and waiting for its receipt
+const forkDryRunTimeout = 60 * time.Second
+
+// dryRunOnFork resets the anvil node at ForkURL to fork the chain at its current head, sends it the signed tx
+// and checks the transaction succeeds and the contract it calls emits event with every one of args. The
+// real transaction is identical, nonce and signature included, so it only has to be sent once the fork
+// accepts it. Any failure is wrapped in blockchain.ErrForkDryRunFailed.
+func (c *Client) dryRunOnFork(ctx context.Context, tx *types.Transaction, event string, args map[string]string) error {
+	ctx, cancel := context.WithTimeout(ctx, forkDryRunTimeout)
+	defer cancel()
+
+	head, err := c.ethClient.BlockNumber(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to get the block to fork at: %v", blockchain.ErrForkDryRunFailed, err)
+	}
+
+	rpcClient, err := rpc.DialContext(ctx, c.ethConfig.ForkURL)
+	if err != nil {
+		return fmt.Errorf("%w: failed to connect to the fork: %v", blockchain.ErrForkDryRunFailed, err)
+	}
+	defer rpcClient.Close()
+	fork := ethclient.NewClient(rpcClient)
+
+	reset := map[string]interface{}{
+		"forking": map[string]interface{}{"jsonRpcUrl": c.ethConfig.RPCURL, "blockNumber": head},
+	}
+	if err := rpcClient.CallContext(ctx, nil, "anvil_reset", reset); err != nil {
+		return fmt.Errorf("%w: failed to reset the fork to block %d: %v", blockchain.ErrForkDryRunFailed, head, err)
+	}
+
+	if err := fork.SendTransaction(ctx, tx); err != nil {
+		if reason := decodeRevertReason(err); reason != "" {
+			return fmt.Errorf("%w: transaction rejected at block %d: %s", blockchain.ErrForkDryRunFailed, head, reason)
+		}
+		return fmt.Errorf("%w: transaction rejected at block %d: %v", blockchain.ErrForkDryRunFailed, head, err)
+	}
+	receipt, err := bind.WaitMined(ctx, fork, tx)
+	if err != nil {
+		return fmt.Errorf("%w: failed to wait for the transaction: %v", blockchain.ErrForkDryRunFailed, err)
+	}
+	if receipt.Status == types.ReceiptStatusFailed {
+		reason := c.replayRevertReasonOn(ctx, fork, tx, receipt)
+		return fmt.Errorf("%w: transaction reverted at block %d: %s", blockchain.ErrForkDryRunFailed, head, reason)
+	}
+
+	contract := strings.ToLower(tx.To().Hex())
+	for _, emitted := range decodeEvents(receipt.Logs) {
+		if emitted.Contract == contract && emitted.Name == event && hasEventArgs(emitted, args) {
+			c.logger.Logf("INFO transaction %s succeeded on a fork of block %d and emitted %s",
+				tx.Hash().Hex(), head, event)
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: transaction succeeded at block %d without emitting %s%v",
+		blockchain.ErrForkDryRunFailed, head, event, args)
+}
+
+// hasEventArgs reports whether event carries every one of args
+func hasEventArgs(event blockchain.TxEvent, args map[string]string) bool {
+	for name, value := range args {
+		if event.Args[name] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,134 @@
+package blockchain
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+)
+
+// fakeAnvil answers the JSON-RPC calls of a fork dry run, mining every transaction sent to it into the receipt
+// the test scripts
+type fakeAnvil struct {
+	mu      sync.Mutex
+	reset   json.RawMessage
+	sent    []*types.Transaction
+	receipt func(tx *types.Transaction) *types.Receipt
+	revert  error // what eth_call answers, replaying a reverted transaction
+}
+
+func (f *fakeAnvil) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	switch req.Method {
+	case "anvil_reset":
+		f.reset = req.Params[0]
+		resp["result"] = nil
+	case "eth_sendRawTransaction":
+		var raw hexutil.Bytes
+		_ = json.Unmarshal(req.Params[0], &raw)
+		tx := new(types.Transaction)
+		_ = tx.UnmarshalBinary(raw)
+		f.sent = append(f.sent, tx)
+		resp["result"] = tx.Hash()
+	case "eth_getTransactionReceipt":
+		receipt := f.receipt(f.sent[len(f.sent)-1])
+		resp["result"] = receipt
+	case "eth_call":
+		revert := f.revert.(*emulatedRevert)
+		resp["error"] = map[string]interface{}{"code": revert.ErrorCode(), "message": revert.Error(), "data": revert.ErrorData()}
+	default:
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "method not found: " + req.Method}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestClient_EndEpochWithSubsidiesDryRunsOnFork(t *testing.T) {
+	minedWith := func(status uint64, logs ...*types.Log) func(tx *types.Transaction) *types.Receipt {
+		if logs == nil {
+			logs = []*types.Log{} // nodes always send the field, receipts without it do not decode
+		}
+		return func(tx *types.Transaction) *types.Receipt {
+			return &types.Receipt{Status: status, TxHash: tx.Hash(), BlockNumber: big.NewInt(100), Logs: logs}
+		}
+	}
+	finalized := func(epochID int64) *types.Log {
+		log := epochFinalizedLog(t, epochID)
+		log.Address = common.HexToAddress(simulatedTestEpochManager)
+		return log
+	}
+
+	tests := []struct {
+		name    string
+		anvil   *fakeAnvil
+		wantErr string
+	}{
+		{
+			name:  "succeeds on the fork",
+			anvil: &fakeAnvil{receipt: minedWith(types.ReceiptStatusSuccessful, finalized(1))},
+		},
+		{
+			name:    "reverts on the fork",
+			anvil:   &fakeAnvil{receipt: minedWith(types.ReceiptStatusFailed), revert: stringRevert("epoch not ready")},
+			wantErr: "transaction reverted at block 1: epoch not ready",
+		},
+		{
+			name:    "finalizes another epoch on the fork",
+			anvil:   &fakeAnvil{receipt: minedWith(types.ReceiptStatusSuccessful, finalized(2))},
+			wantErr: "without emitting EpochFinalized",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newSimulatedTestClient(t, nil)
+			ctx := context.Background()
+			require.NoError(t, client.StartEpoch(ctx))
+
+			server := httptest.NewServer(tt.anvil)
+			defer server.Close()
+			client.ethConfig.ForkURL = server.URL
+			client.ethConfig.RPCURL = "http://mainnet.example"
+			signer := crypto.PubkeyToAddress(client.privateKey.PublicKey)
+
+			err := client.EndEpochWithSubsidies(ctx, big.NewInt(1), simulatedTestVault, [32]byte{1}, big.NewInt(0))
+
+			require.Len(t, tt.anvil.sent, 1)
+			assert.JSONEq(t, `{"forking":{"jsonRpcUrl":"http://mainnet.example","blockNumber":1}}`, string(tt.anvil.reset),
+				"the fork is reset to the live head")
+			nonce, nonceErr := client.ethClient.PendingNonceAt(ctx, signer)
+			require.NoError(t, nonceErr)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				assert.Equal(t, uint64(2), nonce, "the transaction the fork accepted is sent")
+				return
+			}
+			require.ErrorIs(t, err, blockchain.ErrForkDryRunFailed)
+			assert.Contains(t, err.Error(), tt.wantErr)
+			assert.Equal(t, uint64(1), nonce, "the transaction the fork rejected is never sent")
+		})
+	}
+}
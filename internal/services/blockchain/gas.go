@@ -0,0 +1,43 @@
+package blockchain
+
+import (
+	"context"
+
+	"github.com/andrey/epoch-server/internal/services/gas"
+)
+
+// recordGas writes the gas a mined transaction burned to the gas recorder. Reverted transactions
+// are recorded too since they still cost gas, while transactions sent without waiting for a receipt
+// are not, as their gas use is unknown.
+func (c *Client) recordGas(ctx context.Context, rec *txRecord) {
+	if c.gasRecorder == nil || rec.blockNumber == 0 || rec.gasUsed == 0 {
+		return
+	}
+
+	// the call's context may already be cancelled, the usage must still be written
+	ctx = context.WithoutCancel(ctx)
+
+	usage := gas.Usage{
+		Action:      rec.action,
+		EpochID:     rec.parameters["epochId"],
+		TxHash:      rec.txHash,
+		BlockNumber: rec.blockNumber,
+		GasUsed:     rec.gasUsed,
+		GasPrice:    "0",
+	}
+	if rec.gasPrice != nil {
+		usage.GasPrice = rec.gasPrice.String()
+	}
+	if usage.EpochID == "" {
+		// calls that do not name an epoch act on the current one, which startEpoch has just advanced
+		if epochID, err := c.GetCurrentEpochId(ctx); err == nil {
+			usage.EpochID = epochID.String()
+		} else {
+			c.logger.Logf("WARN failed to attribute gas of %s %s to an epoch: %v", rec.action, rec.txHash, err)
+		}
+	}
+
+	if err := c.gasRecorder.Record(ctx, usage); err != nil {
+		c.logger.Logf("WARN failed to record gas usage for %s %s: %v", rec.action, rec.txHash, err)
+	}
+}
@@ -0,0 +1,40 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/gas"
+)
+
+func TestClient_RecordGas(t *testing.T) {
+	recorder := &gas.RecorderMock{RecordFunc: func(ctx context.Context, usage gas.Usage) error { return nil }}
+	client := &Client{logger: lgr.NoOp, gasRecorder: recorder}
+	ctx := context.Background()
+
+	client.recordGas(ctx, &txRecord{
+		action:      "allocateYieldToEpoch",
+		parameters:  map[string]string{"epochId": "7"},
+		txHash:      "0xabc",
+		blockNumber: 100,
+		gasUsed:     21000,
+		gasPrice:    big.NewInt(2),
+	})
+	// without an epochId parameter the current epoch is used
+	client.recordGas(ctx, &txRecord{action: "startEpoch", txHash: "0xdef", blockNumber: 101, gasUsed: 50000})
+	// a transaction that was only sent has no gas use yet
+	client.recordGas(ctx, &txRecord{action: "updateMerkleRoot", txHash: "0x123", gasPrice: big.NewInt(2)})
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, gas.Usage{
+		Action: "allocateYieldToEpoch", EpochID: "7", TxHash: "0xabc", BlockNumber: 100, GasUsed: 21000, GasPrice: "2",
+	}, calls[0].Usage)
+	assert.Equal(t, "1", calls[1].Usage.EpochID)
+	assert.Equal(t, "0", calls[1].Usage.GasPrice)
+}
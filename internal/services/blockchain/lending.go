@@ -0,0 +1,98 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// cTokenABI is the part of the Compound market a vault's LendingManager lends through that the server reads
+var cTokenABI = mustParseABI(`[{"type":"function","name":"borrowBalanceStored","stateMutability":"view",
+	"inputs":[{"name":"account","type":"address"}],
+	"outputs":[{"name":"","type":"uint256"}]}]`)
+
+// GetBorrowBalances reads what each borrower owes the market the vault's LendingManager lends through,
+// borrowBalanceStored(borrower) of its cToken, at blockNumber. Interest accrued since the market was last touched
+// is not included, so the balances of a past block read the same every time. The balances are read in one batch
+// and keyed by normalized address.
+func (c *Client) GetBorrowBalances(
+	ctx context.Context,
+	vaultAddress string,
+	borrowers []string,
+	blockNumber *big.Int,
+) (_ map[string]*big.Int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetBorrowBalances",
+		attribute.String("vault.id", vaultAddress), attribute.Int("borrowers", len(borrowers)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	market, err := c.lendingMarket(ctx, vaultAddress, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	accounts := make([]string, 0, len(borrowers))
+	calls := make([]blockchain.ViewCall, 0, len(borrowers))
+	seen := make(map[string]bool, len(borrowers))
+	for _, borrower := range borrowers {
+		account := utils.NormalizeAddress(borrower)
+		if seen[account] {
+			continue
+		}
+		seen[account] = true
+		data, err := cTokenABI.Pack("borrowBalanceStored", common.HexToAddress(account))
+		if err != nil {
+			return nil, fmt.Errorf("failed to pack borrowBalanceStored: %w", err)
+		}
+		accounts = append(accounts, account)
+		calls = append(calls, blockchain.ViewCall{Target: market.Hex(), Data: data})
+	}
+
+	outputs, err := c.callViews(ctx, calls, blockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read borrowBalanceStored on %s: %w", market.Hex(), err)
+	}
+	balances := make(map[string]*big.Int, len(accounts))
+	for i, account := range accounts {
+		values, err := cTokenABI.Unpack("borrowBalanceStored", outputs[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to unpack borrowBalanceStored result of %s: %w", account, err)
+		}
+		balances[account] = values[0].(*big.Int)
+	}
+	return balances, nil
+}
+
+// lendingMarket returns the cToken the vault's LendingManager lends through at blockNumber
+func (c *Client) lendingMarket(ctx context.Context, vaultAddress string, blockNumber *big.Int) (common.Address, error) {
+	vaultAddr := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &vaultAddr, Data: c.vault.PackLendingManager()}, blockNumber)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call lendingManager on vault %s: %w", vaultAddress, err)
+	}
+	lendingManagerAddr, err := c.vault.UnpackLendingManager(output)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack lendingManager result: %w", err)
+	}
+
+	lendingManager := contracts.NewILendingManager()
+	output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &lendingManagerAddr, Data: lendingManager.PackCToken()}, blockNumber)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to call cToken on LendingManager %s: %w", lendingManagerAddr.Hex(), err)
+	}
+	market, err := lendingManager.UnpackCToken(output)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to unpack cToken result: %w", err)
+	}
+	return market, nil
+}
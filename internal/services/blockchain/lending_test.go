@@ -0,0 +1,69 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// marketBackend answers as a vault whose LendingManager lends through a cToken with fixed borrow balances
+type marketBackend struct {
+	ethBackend
+	vault, lendingManager, market common.Address
+	balances                      map[common.Address]*big.Int
+	mu                            sync.Mutex
+	blocks                        []*big.Int
+}
+
+func (b *marketBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.mu.Lock()
+	b.blocks = append(b.blocks, blockNumber)
+	b.mu.Unlock()
+	switch *msg.To {
+	case b.vault:
+		return common.LeftPadBytes(b.lendingManager.Bytes(), 32), nil
+	case b.lendingManager:
+		return common.LeftPadBytes(b.market.Bytes(), 32), nil
+	case b.market:
+		args, err := cTokenABI.Methods["borrowBalanceStored"].Inputs.Unpack(msg.Data[4:])
+		if err != nil {
+			return nil, err
+		}
+		balance := b.balances[args[0].(common.Address)]
+		if balance == nil {
+			balance = big.NewInt(0)
+		}
+		return common.LeftPadBytes(balance.Bytes(), 32), nil
+	}
+	return nil, nil
+}
+
+func TestClient_GetBorrowBalances(t *testing.T) {
+	borrower := common.HexToAddress("0x742d35Cc6634C0532925a3b844Bc454e4438f44e")
+	backend := &marketBackend{
+		vault:          common.HexToAddress("0x1111111111111111111111111111111111111111"),
+		lendingManager: common.HexToAddress("0x2222222222222222222222222222222222222222"),
+		market:         common.HexToAddress("0x3333333333333333333333333333333333333333"),
+		balances:       map[common.Address]*big.Int{borrower: big.NewInt(750)},
+	}
+	client := &Client{logger: lgr.NoOp, ethClient: backend, vault: contracts.NewICollectionsVault()}
+
+	balances, err := client.GetBorrowBalances(context.Background(), backend.vault.Hex(),
+		[]string{borrower.Hex(), "0x4444444444444444444444444444444444444444", borrower.Hex()}, big.NewInt(100))
+	require.NoError(t, err)
+	require.Len(t, balances, 2)
+	assert.Equal(t, "750", balances["0x742d35cc6634c0532925a3b844bc454e4438f44e"].String())
+	assert.Equal(t, "0", balances["0x4444444444444444444444444444444444444444"].String())
+	require.Len(t, backend.blocks, 4, "the market is resolved once and each borrower read once")
+	for _, block := range backend.blocks {
+		assert.Equal(t, big.NewInt(100), block, "every read is at the snapshot block")
+	}
+}
@@ -0,0 +1,193 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// multicall3ABI is the aggregate3 function of Multicall3, deployed at the same address on most chains
+var multicall3ABI = mustParseABI(`[{"type":"function","name":"aggregate3","stateMutability":"payable",
+	"inputs":[{"name":"calls","type":"tuple[]","components":[
+		{"name":"target","type":"address"},{"name":"allowFailure","type":"bool"},{"name":"callData","type":"bytes"}]}],
+	"outputs":[{"name":"returnData","type":"tuple[]","components":[
+		{"name":"success","type":"bool"},{"name":"returnData","type":"bytes"}]}]}]`)
+
+// whether code was found at the configured Multicall3 address
+const (
+	multicallUnknown int32 = iota
+	multicallDeployed
+	multicallMissing
+)
+
+const (
+	// defaultReadConcurrency is how many calls or chunks of a batch are made at once when ReadConcurrency is not set
+	defaultReadConcurrency = 8
+	// defaultMulticallBatchSize is how many calls an aggregate3 call carries when MulticallBatchSize is not set
+	defaultMulticallBatchSize = 100
+)
+
+// multicall3Call is the Call3 struct aggregate3 takes
+type multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// multicall3Result is the Result struct aggregate3 returns
+type multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// BatchCall makes the calls at blockNumber, the latest block when nil, and returns their results in the same
+// order. When Multicall3 is deployed the calls are split into chunks of MulticallBatchSize, each made in one
+// aggregate3 eth_call; otherwise every call is its own eth_call. Either way ReadConcurrency requests run at once.
+// A call that reverts only fails its own result, with blockchain.ErrCallFailed; an error is returned when a
+// request could not be made at all.
+func (c *Client) BatchCall(ctx context.Context, calls []blockchain.ViewCall, blockNumber *big.Int) (_ []blockchain.ViewResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.BatchCall", attribute.Int("calls", len(calls)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+	results := make([]blockchain.ViewResult, len(calls))
+	if len(calls) == 0 {
+		return results, nil
+	}
+
+	multicall, ok := c.multicallAddress(ctx)
+	if !ok {
+		err = utils.ForEach(ctx, len(calls), c.readConcurrency(), func(ctx context.Context, i int) error {
+			target := common.HexToAddress(calls[i].Target)
+			output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &target, Data: calls[i].Data}, blockNumber)
+			if err != nil {
+				results[i].Err = fmt.Errorf("%w: call %d to %s: %v", blockchain.ErrCallFailed, i, calls[i].Target, err)
+				return nil
+			}
+			results[i].Output = output
+			return nil
+		})
+		return results, err
+	}
+
+	size := c.multicallBatchSize()
+	chunks := (len(calls) + size - 1) / size
+	span.SetAttributes(attribute.Int("chunks", chunks))
+	err = utils.ForEach(ctx, chunks, c.readConcurrency(), func(ctx context.Context, chunk int) error {
+		from := chunk * size
+		to := min(from+size, len(calls))
+		return c.aggregate(ctx, multicall, calls[from:to], results[from:to], from, blockNumber)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// aggregate makes the calls in one aggregate3 call of the Multicall3 contract at multicall, every call allowed to
+// fail on its own, and writes their results. offset is the index of the first call in its batch, for errors.
+func (c *Client) aggregate(
+	ctx context.Context,
+	multicall common.Address,
+	calls []blockchain.ViewCall,
+	results []blockchain.ViewResult,
+	offset int,
+	blockNumber *big.Int,
+) error {
+	batch := make([]multicall3Call, len(calls))
+	for i, call := range calls {
+		batch[i] = multicall3Call{Target: common.HexToAddress(call.Target), AllowFailure: true, CallData: call.Data}
+	}
+	data, err := multicall3ABI.Pack("aggregate3", batch)
+	if err != nil {
+		return fmt.Errorf("failed to pack aggregate3: %w", err)
+	}
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &multicall, Data: data}, blockNumber)
+	if err != nil {
+		return fmt.Errorf("failed to call aggregate3 with %d calls on %s: %w", len(calls), multicall.Hex(), err)
+	}
+
+	var aggregated []multicall3Result
+	if err := multicall3ABI.UnpackIntoInterface(&aggregated, "aggregate3", output); err != nil {
+		return fmt.Errorf("failed to unpack aggregate3 result: %w", err)
+	}
+	if len(aggregated) != len(calls) {
+		return fmt.Errorf("aggregate3 returned %d results for %d calls", len(aggregated), len(calls))
+	}
+	for i, result := range aggregated {
+		if !result.Success {
+			results[i].Err = fmt.Errorf("%w: call %d to %s reverted", blockchain.ErrCallFailed, offset+i, calls[i].Target)
+			continue
+		}
+		results[i].Output = result.ReturnData
+	}
+	return nil
+}
+
+// callViews makes the calls in one batch and returns their outputs in the same order, failing when any call
+// fails, for reads that are of no use partially
+func (c *Client) callViews(ctx context.Context, calls []blockchain.ViewCall, blockNumber *big.Int) ([][]byte, error) {
+	results, err := c.BatchCall(ctx, calls, blockNumber)
+	if err != nil {
+		return nil, err
+	}
+	outputs := make([][]byte, len(results))
+	for i, result := range results {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		outputs[i] = result.Output
+	}
+	return outputs, nil
+}
+
+// multicallAddress returns the configured Multicall3 contract when code is deployed at it. Whether it is deployed
+// is read once; a failed read is retried with the next batch.
+func (c *Client) multicallAddress(ctx context.Context) (common.Address, bool) {
+	if c.ethConfig.Multicall == "" {
+		return common.Address{}, false
+	}
+	address := common.HexToAddress(c.ethConfig.Multicall)
+	switch c.multicallState.Load() {
+	case multicallDeployed:
+		return address, true
+	case multicallMissing:
+		return common.Address{}, false
+	}
+
+	code, err := c.ethClient.CodeAt(ctx, address, nil)
+	if err != nil {
+		c.logger.Logf("WARN failed to read code at multicall address %s, making view calls separately: %v", address.Hex(), err)
+		return common.Address{}, false
+	}
+	if len(code) == 0 {
+		c.logger.Logf("WARN no Multicall3 contract at %s, view calls are made separately", address.Hex())
+		c.multicallState.Store(multicallMissing)
+		return common.Address{}, false
+	}
+	c.multicallState.Store(multicallDeployed)
+	return address, true
+}
+
+func (c *Client) readConcurrency() int {
+	if c.ethConfig.ReadConcurrency > 0 {
+		return c.ethConfig.ReadConcurrency
+	}
+	return defaultReadConcurrency
+}
+
+func (c *Client) multicallBatchSize() int {
+	if c.ethConfig.MulticallBatchSize > 0 {
+		return c.ethConfig.MulticallBatchSize
+	}
+	return defaultMulticallBatchSize
+}
@@ -0,0 +1,70 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_BatchCall(t *testing.T) {
+	for _, deployed := range []bool{true, false} {
+		t.Run(fmt.Sprintf("multicall deployed %v", deployed), func(t *testing.T) {
+			backend := newRegistryBackend(t, deployed)
+			client := &Client{
+				logger:    lgr.NoOp,
+				ethClient: backend,
+				ethConfig: blockchain.Config{Multicall: backend.multicall.Hex(), MulticallBatchSize: 2},
+			}
+			vault := contracts.NewICollectionsVault()
+			unknown := common.HexToAddress("0xdddddddddddddddddddddddddddddddddddddddd")
+			calls := make([]blockchain.ViewCall, 0, 5)
+			for _, collection := range []common.Address{backend.collections[0], backend.collections[1], unknown,
+				backend.collections[2], backend.collections[0]} {
+				calls = append(calls, blockchain.ViewCall{Target: backend.vault.Hex(), Data: vault.PackGetCollectionTotalBorrowVolume(collection)})
+			}
+
+			results, err := client.BatchCall(context.Background(), calls, big.NewInt(50))
+			require.NoError(t, err)
+			require.Len(t, results, 5)
+			for i, expected := range []int64{100, 200, 0, 300, 100} {
+				if i == 2 {
+					require.ErrorIs(t, results[i].Err, blockchain.ErrCallFailed, "the reverting call fails on its own")
+					continue
+				}
+				require.NoError(t, results[i].Err)
+				value, err := vault.UnpackGetCollectionTotalBorrowVolume(results[i].Output)
+				require.NoError(t, err)
+				assert.Equal(t, big.NewInt(expected), value, "result %d is in the order of its call", i)
+			}
+
+			if deployed {
+				assert.Equal(t, 3, backend.calls, "five calls are split into aggregate3 chunks of two")
+			} else {
+				assert.Equal(t, 5, backend.calls)
+			}
+			for _, block := range backend.blocks {
+				assert.Equal(t, big.NewInt(50), block)
+			}
+		})
+	}
+}
+
+func TestClient_CallViewsFailsOnAnyCall(t *testing.T) {
+	backend := newRegistryBackend(t, true)
+	client := &Client{logger: lgr.NoOp, ethClient: backend, ethConfig: blockchain.Config{Multicall: backend.multicall.Hex()}}
+	vault := contracts.NewICollectionsVault()
+	_, err := client.callViews(context.Background(), []blockchain.ViewCall{
+		{Target: backend.vault.Hex(), Data: vault.PackGetCollectionTotalBorrowVolume(backend.collections[0])},
+		{Target: backend.vault.Hex(), Data: vault.PackGetCollectionTotalBorrowVolume(common.HexToAddress("0xdd"))},
+	}, nil)
+	require.ErrorIs(t, err, blockchain.ErrCallFailed)
+	assert.Contains(t, err.Error(), "call 1")
+}
@@ -0,0 +1,42 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+)
+
+func TestClient_ReadOnlyRefusesTransactions(t *testing.T) {
+	client := &Client{logger: lgr.NoOp, ethConfig: blockchain.Config{ReadOnly: true}}
+	ctx := context.Background()
+	vault := "0x6666666666666666666666666666666666666666"
+
+	calls := map[string]func() error{
+		"startEpoch":         func() error { return client.StartEpoch(ctx) },
+		"updateExchangeRate": func() error { return client.UpdateExchangeRate(ctx, vault) },
+		"allocateYield": func() error {
+			return client.AllocateYieldToEpoch(ctx, big.NewInt(1), vault)
+		},
+		"updateMerkleRoot": func() error {
+			return client.UpdateMerkleRoot(ctx, vault, [32]byte{1}, big.NewInt(1))
+		},
+		"repayBorrowBehalfBatch": func() error {
+			return client.RepayBorrowBehalfBatch(ctx, vault, nil, nil, 0)
+		},
+		"signerBalance": func() error {
+			_, err := client.GetSignerBalance(ctx)
+			return err
+		},
+	}
+
+	for name, call := range calls {
+		if err := call(); !errors.Is(err, blockchain.ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+}
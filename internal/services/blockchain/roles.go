@@ -0,0 +1,94 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// accessMetaData holds the role getters the deployed contracts have but their generated interfaces leave out:
+// AccessControl's hasRole and the EpochManager's automatedSystem
+var accessMetaData = bind.MetaData{
+	ABI: `[
+		{"type":"function","name":"hasRole","inputs":[{"name":"role","type":"bytes32"},{"name":"account","type":"address"}],"outputs":[{"name":"","type":"bool"}],"stateMutability":"view"},
+		{"type":"function","name":"automatedSystem","inputs":[],"outputs":[{"name":"","type":"address"}],"stateMutability":"view"}
+	]`,
+	ID: "Access",
+}
+
+// GetSignerRoles reads whether the signer holds DEBT_SUBSIDIZER_ROLE on the collections vault, which repaying
+// borrowers needs, and is the EpochManager's automated system, which starting and ending epochs needs
+func (c *Client) GetSignerRoles(ctx context.Context, vaultAddress string) (_ []blockchain.SignerRole, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetSignerRoles", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethConfig.ReadOnly {
+		return nil, fmt.Errorf("%w: no signer account", blockchain.ErrReadOnly)
+	}
+	if c.ethClient == nil || c.privateKey == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+	access, err := accessMetaData.ParseABI()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse access ABI: %w", err)
+	}
+	signer := crypto.PubkeyToAddress(c.privateKey.PublicKey)
+
+	vault := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &vault, Data: c.vault.PackDEBTSUBSIDIZERROLE()}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call DEBT_SUBSIDIZER_ROLE: %w", err)
+	}
+	role, err := c.vault.UnpackDEBTSUBSIDIZERROLE(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack DEBT_SUBSIDIZER_ROLE result: %w", err)
+	}
+	data, err := access.Pack("hasRole", role, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack hasRole: %w", err)
+	}
+	if output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &vault, Data: data}, nil); err != nil {
+		return nil, fmt.Errorf("failed to call hasRole: %w", err)
+	}
+	hasRole, err := access.Unpack("hasRole", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack hasRole result: %w", err)
+	}
+
+	epochManager := common.HexToAddress(c.ethConfig.EpochManager)
+	data, err = access.Pack("automatedSystem")
+	if err != nil {
+		return nil, fmt.Errorf("failed to pack automatedSystem: %w", err)
+	}
+	if output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &epochManager, Data: data}, nil); err != nil {
+		return nil, fmt.Errorf("failed to call automatedSystem: %w", err)
+	}
+	automatedSystem, err := access.Unpack("automatedSystem", output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack automatedSystem result: %w", err)
+	}
+
+	return []blockchain.SignerRole{
+		{
+			Contract: "collections vault",
+			Address:  vaultAddress,
+			Role:     "DEBT_SUBSIDIZER_ROLE",
+			Signer:   signer.Hex(),
+			Held:     hasRole[0].(bool),
+		},
+		{
+			Contract: "epoch manager",
+			Address:  c.ethConfig.EpochManager,
+			Role:     "automated system",
+			Signer:   signer.Hex(),
+			Held:     automatedSystem[0].(common.Address) == signer,
+		},
+	}, nil
+}
@@ -0,0 +1,75 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// logQueryRange is the most blocks one eth_getLogs call spans; RPC providers reject wider ranges
+const logQueryRange = 10_000
+
+// merkleRootUpdatedTopic is the topic of MerkleRootUpdated(address indexed vaultAddress, bytes32 merkleRoot,
+// address indexed updatedBy, uint256 totalSubsidiesForEpoch)
+var merkleRootUpdatedTopic = crypto.Keccak256Hash([]byte("MerkleRootUpdated(address,bytes32,address,uint256)"))
+
+// GetMerkleRootUpdates returns the MerkleRootUpdated events the DebtSubsidizer emitted for the vault between
+// fromBlock and toBlock inclusive, in chain order. The range is queried logQueryRange blocks at a time.
+func (c *Client) GetMerkleRootUpdates(
+	ctx context.Context,
+	vaultId string,
+	fromBlock, toBlock uint64,
+) (_ []blockchain.MerkleRootUpdate, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetMerkleRootUpdates", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	var updates []blockchain.MerkleRootUpdate
+	for start := fromBlock; start <= toBlock; start += logQueryRange {
+		end := min(start+logQueryRange-1, toBlock)
+		logs, err := c.ethClient.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{common.HexToAddress(c.ethConfig.DebtSubsidizer)},
+			Topics:    [][]common.Hash{{merkleRootUpdatedTopic}, {common.BytesToHash(common.HexToAddress(vaultId).Bytes())}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter MerkleRootUpdated logs in blocks %d-%d: %w", start, end, err)
+		}
+
+		for _, log := range logs {
+			if log.Removed {
+				continue
+			}
+			event, err := c.subsidizer.UnpackMerkleRootUpdatedEvent(&log)
+			if err != nil {
+				return nil, fmt.Errorf("failed to unpack MerkleRootUpdated log of tx %s: %w", log.TxHash.Hex(), err)
+			}
+			updates = append(updates, blockchain.MerkleRootUpdate{
+				Vault:          strings.ToLower(event.VaultAddress.Hex()),
+				MerkleRoot:     event.MerkleRoot,
+				UpdatedBy:      strings.ToLower(event.UpdatedBy.Hex()),
+				TotalSubsidies: event.TotalSubsidiesForEpoch,
+				TxHash:         log.TxHash.Hex(),
+				BlockNumber:    log.BlockNumber,
+				BlockHash:      log.BlockHash.Hex(),
+				LogIndex:       log.Index,
+			})
+		}
+		if end == toBlock {
+			break // start += logQueryRange would overflow at the top of the range
+		}
+	}
+	return updates, nil
+}
@@ -0,0 +1,58 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// erc1271MagicValue is what isValidSignature returns for a signature the wallet accepts, its own selector
+var erc1271MagicValue = []byte{0x16, 0x26, 0xba, 0x7e}
+
+var erc1271ABI = mustParseABI(`[{"type":"function","name":"isValidSignature","stateMutability":"view",
+	"inputs":[{"name":"hash","type":"bytes32"},{"name":"signature","type":"bytes"}],
+	"outputs":[{"name":"magicValue","type":"bytes4"}]}]`)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// IsValidSignature calls the ERC-1271 isValidSignature(hash, signature) of the contract wallet at account in the
+// latest block. A wallet rejecting the signature by reverting, or not implementing ERC-1271 at all, did not sign.
+func (c *Client) IsValidSignature(ctx context.Context, account string, hash [32]byte, signature []byte) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.IsValidSignature", attribute.String("account", account))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return false, fmt.Errorf("ethereum client not initialized")
+	}
+
+	data, err := erc1271ABI.Pack("isValidSignature", hash, signature)
+	if err != nil {
+		return false, fmt.Errorf("failed to pack isValidSignature: %w", err)
+	}
+	wallet := common.HexToAddress(account)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &wallet, Data: data}, nil)
+	if err != nil {
+		var revert rpc.DataError
+		if errors.As(err, &revert) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to call isValidSignature on %s: %w", account, err)
+	}
+	// the magic value is a bytes4, left-aligned in the returned word
+	return len(output) >= 32 && bytes.Equal(output[:4], erc1271MagicValue), nil
+}
@@ -0,0 +1,77 @@
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// walletBackend answers isValidSignature calls as a contract wallet that accepts one signature
+type walletBackend struct {
+	ethBackend
+	accepted []byte
+	answer   []byte // returned for any other signature, nil reverts
+	calls    int
+}
+
+func (b *walletBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	b.calls++
+	args, err := erc1271ABI.Methods["isValidSignature"].Inputs.Unpack(msg.Data[4:])
+	if err != nil {
+		return nil, err
+	}
+	if bytes.Equal(args[1].([]byte), b.accepted) {
+		return append(erc1271MagicValue, make([]byte, 28)...), nil
+	}
+	if b.answer == nil {
+		return nil, &emulatedRevert{reason: "invalid signature"}
+	}
+	return b.answer, nil
+}
+
+func TestClient_IsValidSignature(t *testing.T) {
+	backend := &walletBackend{accepted: []byte{1, 2, 3}}
+	client := &Client{logger: lgr.NoOp, ethClient: backend}
+	ctx := context.Background()
+	wallet := "0x7777777777777777777777777777777777777777"
+
+	valid, err := client.IsValidSignature(ctx, wallet, [32]byte{9}, []byte{1, 2, 3})
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = client.IsValidSignature(ctx, wallet, [32]byte{9}, []byte{4})
+	require.NoError(t, err)
+	assert.False(t, valid, "a reverting wallet did not sign")
+
+	backend.answer = make([]byte, 32)
+	valid, err = client.IsValidSignature(ctx, wallet, [32]byte{9}, []byte{4})
+	require.NoError(t, err)
+	assert.False(t, valid, "anything but the magic value is a rejection")
+
+	backend.answer = []byte{}
+	valid, err = client.IsValidSignature(ctx, wallet, [32]byte{9}, []byte{4})
+	require.NoError(t, err)
+	assert.False(t, valid, "a contract without isValidSignature returns nothing")
+	assert.Equal(t, 4, backend.calls)
+}
+
+func TestClient_IsValidSignatureNodeError(t *testing.T) {
+	client := &Client{logger: lgr.NoOp, ethClient: &failingBackend{}}
+	_, err := client.IsValidSignature(context.Background(), "0x7777777777777777777777777777777777777777", [32]byte{}, nil)
+	assert.Error(t, err, "a node failing is not a rejection")
+}
+
+type failingBackend struct {
+	ethBackend
+}
+
+func (b *failingBackend) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return nil, errors.New("connection refused")
+}
@@ -0,0 +1,303 @@
+package blockchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/go-pkgz/lgr"
+)
+
+// SimulatedChainID is the chain ID of go-ethereum's simulated backend
+const SimulatedChainID = 1337
+
+// simulatedSignerFunds is the ETH the signer starts with on a simulated chain, 1000 ETH
+var simulatedSignerFunds = new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18))
+
+// placeholderCode is deployed at every configured contract address, a single STOP, so the addresses have code
+// and transactions to them succeed
+var placeholderCode = []byte{0x00}
+
+// SimulatedChain is an in-process chain built on go-ethereum's simulated backend, for running the epoch and
+// subsidy flow in tests and local development without a node. Every transaction is mined into a block of its own
+// as soon as it is sent, and the protocol contracts are emulated (see protocolEmulator). A transaction the
+// emulated contracts would revert is refused when sent, with the contract's revert reason, instead of being mined.
+// Calls read the emulated contracts as of the latest block whichever block they ask for. The events the emulated
+// contracts emit are served by FilterLogs, stamped with the transaction and block that emitted them.
+type SimulatedChain struct {
+	simulated.Client
+	backend   *simulated.Backend
+	contracts *protocolEmulator
+
+	mu   sync.Mutex   // serializes sending and mining
+	logs []*types.Log // emitted by the emulated contracts, in chain order
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewSimulatedChain starts a simulated chain with the signer of config funded and placeholder code at its
+// contract addresses. A block is mined every config.SimulatedBlockTime, so receipts sent without waiting
+// get buried under confirmations; with 0 only transactions and Commit mine blocks.
+func NewSimulatedChain(config blockchain.Config) (*SimulatedChain, error) {
+	emulator, err := newProtocolEmulator(common.HexToAddress(config.EpochManager), common.HexToAddress(config.DebtSubsidizer))
+	if err != nil {
+		return nil, err
+	}
+
+	alloc := types.GenesisAlloc{}
+	if config.PrivateKey != "" {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(config.PrivateKey, "0x"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key: %w", err)
+		}
+		emulator.operator = crypto.PubkeyToAddress(key.PublicKey)
+		alloc[emulator.operator] = types.Account{Balance: simulatedSignerFunds}
+	}
+	contracts := []string{config.Comptroller, config.EpochManager, config.DebtSubsidizer, config.LendingManager, config.CollectionRegistry}
+	for _, address := range append(contracts, config.SimulatedContracts...) {
+		if address != "" {
+			alloc[common.HexToAddress(address)] = types.Account{Code: placeholderCode, Balance: big.NewInt(0)}
+		}
+	}
+
+	backend := simulated.NewBackend(alloc)
+	chain := &SimulatedChain{
+		Client:    backend.Client(),
+		backend:   backend,
+		contracts: emulator,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go chain.mine(config.SimulatedBlockTime)
+	return chain, nil
+}
+
+// ProvideSimulatedClient creates a blockchain client on a new simulated chain. Without a private key a signer is
+// generated, unless the client is read-only. The chain must be closed once the client is no longer used.
+func ProvideSimulatedClient(
+	logger lgr.L,
+	config blockchain.Config,
+	recorder audit.Recorder,
+	gasRecorder gas.Recorder,
+	txObserver blockchain.TxObserver,
+) (*Client, *SimulatedChain, error) {
+	if config.EpochManager == "" {
+		return nil, nil, fmt.Errorf("EpochManager contract address is required")
+	}
+	if config.PrivateKey == "" && !config.ReadOnly {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate signer key: %w", err)
+		}
+		config.PrivateKey = hex.EncodeToString(crypto.FromECDSA(key))
+	}
+
+	chain, err := NewSimulatedChain(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := &Client{
+		logger:      logger,
+		ethConfig:   config,
+		recorder:    recorder,
+		gasRecorder: gasRecorder,
+		txObserver:  txObserver,
+	}
+	if err := client.connect(chain); err != nil {
+		chain.Close()
+		return nil, nil, err
+	}
+
+	if client.privateKey != nil {
+		logger.Logf("INFO simulated chain %d started, signer %s", SimulatedChainID, signerAddress(client.privateKey))
+	}
+	return client, chain, nil
+}
+
+// SendTransaction sends tx and mines it into a new block, applying it to the emulated contracts once it succeeded
+func (c *SimulatedChain) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return fmt.Errorf("failed to recover sender of simulated transaction: %w", err)
+	}
+	if to := tx.To(); to != nil {
+		if _, err := c.contracts.execute(from, *to, tx.Data(), false); err != nil && !errors.Is(err, errNotEmulated) {
+			return err
+		}
+	}
+	if err := c.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	c.backend.Commit()
+
+	receipt, err := c.Client.TransactionReceipt(ctx, tx.Hash())
+	if err != nil {
+		return fmt.Errorf("failed to get receipt of simulated transaction %s: %w", tx.Hash().Hex(), err)
+	}
+	if receipt.Status == types.ReceiptStatusSuccessful && tx.To() != nil {
+		if _, err := c.contracts.execute(from, *tx.To(), tx.Data(), true); err != nil && !errors.Is(err, errNotEmulated) {
+			return err
+		}
+		for i, log := range c.contracts.takeLogs() {
+			log.TxHash = tx.Hash()
+			log.TxIndex = receipt.TransactionIndex
+			log.BlockNumber = receipt.BlockNumber.Uint64()
+			log.BlockHash = receipt.BlockHash
+			log.Index = uint(i)
+			c.logs = append(c.logs, log)
+		}
+	}
+	return nil
+}
+
+// FilterLogs returns the logs of the chain and the emulated contracts that match q
+func (c *SimulatedChain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	logs, err := c.Client.FilterLogs(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, log := range c.logs {
+		if matchesFilter(log, q) {
+			logs = append(logs, *log)
+		}
+	}
+	return logs, nil
+}
+
+// CallContract answers calls to the emulated contracts and passes every other call to the chain
+func (c *SimulatedChain) CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	if msg.To != nil {
+		output, err := c.contracts.execute(msg.From, *msg.To, msg.Data, false)
+		if !errors.Is(err, errNotEmulated) {
+			return output, err
+		}
+	}
+	return c.Client.CallContract(ctx, msg, blockNumber)
+}
+
+// EstimateGas fails for calls the emulated contracts would revert, and otherwise estimates on the chain
+func (c *SimulatedChain) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	if msg.To != nil {
+		if _, err := c.contracts.execute(msg.From, *msg.To, msg.Data, false); err != nil && !errors.Is(err, errNotEmulated) {
+			return 0, err
+		}
+	}
+	return c.Client.EstimateGas(ctx, msg)
+}
+
+// Commit mines a block
+func (c *SimulatedChain) Commit() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.backend.Commit()
+}
+
+// SetEpochDuration sets how long epochs started on the emulated EpochManager last, 0 ends them as they start
+func (c *SimulatedChain) SetEpochDuration(duration time.Duration) {
+	c.contracts.setEpochDuration(uint64(duration / time.Second))
+}
+
+// SetSubsidizerPaused pauses or unpauses the emulated DebtSubsidizer
+func (c *SimulatedChain) SetSubsidizerPaused(paused bool) {
+	c.contracts.setPaused(paused)
+}
+
+// SetYieldEarned sets the yield the emulated vault has earned; what is not allocated to an epoch remains cumulative
+func (c *SimulatedChain) SetYieldEarned(vault string, amount *big.Int) {
+	c.contracts.setYieldEarned(common.HexToAddress(vault), amount)
+}
+
+// RemoveVault removes vault from the emulated DebtSubsidizer
+func (c *SimulatedChain) RemoveVault(vault string) {
+	c.contracts.removeVault(common.HexToAddress(vault))
+}
+
+// SetClaimed sets how much of vault's subsidies user has claimed from the emulated DebtSubsidizer
+func (c *SimulatedChain) SetClaimed(vault, user string, amount *big.Int) {
+	c.contracts.setClaimed(common.HexToAddress(vault), common.HexToAddress(user), amount)
+}
+
+// Repaid returns how much of borrower's debt the emulated vault has repaid
+func (c *SimulatedChain) Repaid(vault, borrower string) *big.Int {
+	return c.contracts.repaidTo(common.HexToAddress(vault), common.HexToAddress(borrower))
+}
+
+// Close stops mining and shuts the chain down
+func (c *SimulatedChain) Close() error {
+	close(c.stop)
+	<-c.done
+	return c.backend.Close()
+}
+
+// mine mines a block every blockTime until the chain is closed
+func (c *SimulatedChain) mine(blockTime time.Duration) {
+	defer close(c.done)
+	if blockTime <= 0 {
+		<-c.stop
+		return
+	}
+
+	ticker := time.NewTicker(blockTime)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			c.Commit()
+		}
+	}
+}
+
+// matchesFilter reports whether log is in q's block range and matches its addresses and topics
+func matchesFilter(log *types.Log, q ethereum.FilterQuery) bool {
+	if q.BlockHash != nil {
+		if log.BlockHash != *q.BlockHash {
+			return false
+		}
+	} else {
+		if q.FromBlock != nil && q.FromBlock.Sign() >= 0 && log.BlockNumber < q.FromBlock.Uint64() {
+			return false
+		}
+		if q.ToBlock != nil && q.ToBlock.Sign() >= 0 && log.BlockNumber > q.ToBlock.Uint64() {
+			return false
+		}
+	}
+	if len(q.Addresses) > 0 && !slices.Contains(q.Addresses, log.Address) {
+		return false
+	}
+	for i, alternatives := range q.Topics {
+		if len(alternatives) == 0 {
+			continue
+		}
+		if i >= len(log.Topics) || !slices.Contains(alternatives, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func signerAddress(key *ecdsa.PrivateKey) string {
+	return crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
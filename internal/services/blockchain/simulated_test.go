@@ -0,0 +1,237 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/audit"
+)
+
+const (
+	simulatedTestEpochManager = "0x1000000000000000000000000000000000000001"
+	simulatedTestSubsidizer   = "0x1000000000000000000000000000000000000002"
+	simulatedTestVault        = "0x1000000000000000000000000000000000000003"
+	simulatedTestBorrower     = "0x2000000000000000000000000000000000000001"
+)
+
+func newSimulatedTestClient(t *testing.T, recorder audit.Recorder) (*Client, *SimulatedChain) {
+	t.Helper()
+
+	client, chain, err := ProvideSimulatedClient(lgr.NoOp, blockchain.Config{
+		Type:                 blockchain.TypeSimulated,
+		GasLimit:             500000,
+		GasPrice:             "20000000000",
+		ChainID:              SimulatedChainID,
+		EpochManager:         simulatedTestEpochManager,
+		DebtSubsidizer:       simulatedTestSubsidizer,
+		ReceiptConfirmations: 2,
+		ReceiptTimeout:       5 * time.Second,
+		PollInterval:         time.Millisecond,
+		SimulatedContracts:   []string{simulatedTestVault},
+	}, recorder, nil, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { chain.Close() })
+	return client, chain
+}
+
+func TestSimulatedChain_EpochAndSubsidyFlow(t *testing.T) {
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	client, chain := newSimulatedTestClient(t, recorder)
+	ctx := context.Background()
+
+	for _, address := range []string{simulatedTestEpochManager, simulatedTestSubsidizer, simulatedTestVault} {
+		deployed, err := client.HasCode(ctx, address)
+		require.NoError(t, err)
+		assert.True(t, deployed, "%s has placeholder code", address)
+	}
+	balance, err := client.GetSignerBalance(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, simulatedSignerFunds, balance.Balance, "a generated signer is funded")
+	roles, err := client.GetSignerRoles(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	require.Len(t, roles, 2)
+	for _, role := range roles {
+		assert.True(t, role.Held, "the generated signer holds %s", role.Role)
+		assert.Equal(t, balance.Address, role.Signer)
+	}
+
+	require.NoError(t, client.StartEpoch(ctx))
+	epochID, err := client.GetCurrentEpochId(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", epochID.String())
+
+	chain.SetYieldEarned(simulatedTestVault, big.NewInt(1000))
+	require.NoError(t, client.AllocateCumulativeYieldToEpoch(ctx, epochID, simulatedTestVault, big.NewInt(900)))
+	remaining, err := client.GetRemainingCumulativeYield(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "100", remaining.String(), "yield not allocated stays cumulative")
+	root := [32]byte{0xab}
+	require.NoError(t, client.UpdateMerkleRootAndWaitForConfirmation(ctx, simulatedTestVault, root, big.NewInt(600)))
+	chain.SetClaimed(simulatedTestVault, simulatedTestBorrower, big.NewInt(100))
+
+	state, err := client.GetOnChainEpochState(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "1", state.CurrentEpochID.String())
+	assert.Equal(t, "900", state.VaultYieldForEpoch.String())
+	assert.Equal(t, "900", state.TotalYieldAllocated.String())
+	assert.Equal(t, root, state.MerkleRoot)
+	assert.Equal(t, "600", state.TotalSubsidies.String())
+	assert.Equal(t, "100", state.TotalSubsidiesClaimed.String())
+
+	head, err := client.GetBlockRef(ctx, nil)
+	require.NoError(t, err)
+	updates, err := client.GetMerkleRootUpdates(ctx, simulatedTestVault, 0, head.Number)
+	require.NoError(t, err)
+	require.Len(t, updates, 1, "the emulated DebtSubsidizer emits MerkleRootUpdated")
+	assert.Equal(t, root, updates[0].MerkleRoot)
+	assert.Equal(t, "600", updates[0].TotalSubsidies.String())
+	assert.Equal(t, client.senderAddress(), updates[0].UpdatedBy)
+	assert.NotEmpty(t, updates[0].TxHash)
+	updates, err = client.GetMerkleRootUpdates(ctx, simulatedTestBorrower, 0, head.Number)
+	require.NoError(t, err)
+	assert.Empty(t, updates, "events are filtered by vault")
+	vaultEvents, err := client.GetVaultEvents(ctx, 0, head.Number)
+	require.NoError(t, err)
+	assert.Empty(t, vaultEvents, "no vault was added or removed")
+	assert.Equal(t, "500", state.RemainingSubsidies.String())
+	assert.Equal(t, uint64(3), state.Block.Number, "every transaction is mined into its own block")
+
+	borrowers, amounts := []string{simulatedTestBorrower}, []*big.Int{big.NewInt(250)}
+	gasLimit, err := client.EstimateRepayBorrowBehalfBatchGas(ctx, simulatedTestVault, borrowers, amounts)
+	require.NoError(t, err)
+	require.NoError(t, client.RepayBorrowBehalfBatch(ctx, simulatedTestVault, borrowers, amounts, gasLimit*2))
+	assert.Equal(t, "250", chain.Repaid(simulatedTestVault, simulatedTestBorrower).String())
+
+	require.NoError(t, client.EndEpochWithSubsidies(ctx, epochID, simulatedTestVault, root, big.NewInt(600)))
+	for _, call := range recorder.RecordCalls() {
+		assert.Equal(t, audit.ResultSuccess, call.Entry.Result, call.Entry.Action)
+	}
+}
+
+func TestSimulatedChain_RevertsLikeContracts(t *testing.T) {
+	client, chain := newSimulatedTestClient(t, nil)
+	ctx := context.Background()
+
+	err := client.EndEpochWithSubsidies(ctx, big.NewInt(3), simulatedTestVault, [32]byte{}, big.NewInt(0))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "EpochManager__InvalidEpochId[3]")
+
+	borrowers := make([]string, emulatedMaxBatchSize+1)
+	amounts := make([]*big.Int, len(borrowers))
+	for i := range borrowers {
+		borrowers[i], amounts[i] = simulatedTestBorrower, big.NewInt(1)
+	}
+	_, err = client.EstimateRepayBorrowBehalfBatchGas(ctx, simulatedTestVault, borrowers, amounts)
+	assert.ErrorIs(t, err, blockchain.ErrBatchSizeExceedsLimit)
+
+	chain.SetSubsidizerPaused(true)
+	pause, err := client.GetPauseState(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	assert.True(t, pause.SubsidizerPaused)
+	err = client.UpdateMerkleRootAndWaitForConfirmation(ctx, simulatedTestVault, [32]byte{1}, big.NewInt(1))
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "Pausable: paused"), err.Error())
+
+	chain.SetSubsidizerPaused(false)
+	chain.RemoveVault(simulatedTestVault)
+	pause, err = client.GetPauseState(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	assert.True(t, pause.VaultRemoved)
+
+	root, err := client.GetMerkleRoot(ctx, simulatedTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte{}, root, "rejected transactions change nothing")
+}
+
+func TestSimulatedChain_VaultEvents(t *testing.T) {
+	client, _ := newSimulatedTestClient(t, nil)
+	ctx := context.Background()
+	lendingManager := "0x1000000000000000000000000000000000000004"
+
+	require.NoError(t, client.AddVault(ctx, simulatedTestVault, lendingManager))
+	err := client.AddVault(ctx, simulatedTestVault, lendingManager)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "VaultAlreadyRegistered")
+	require.NoError(t, client.RemoveVault(ctx, simulatedTestVault))
+
+	head, err := client.GetBlockRef(ctx, nil)
+	require.NoError(t, err)
+	events, err := client.GetVaultEvents(ctx, 0, head.Number)
+	require.NoError(t, err)
+	require.Len(t, events, 2, "the emulated DebtSubsidizer emits VaultAdded and VaultRemoved")
+	assert.Equal(t, blockchain.VaultEventAdded, events[0].Kind)
+	assert.Equal(t, simulatedTestVault, events[0].Vault)
+	assert.Equal(t, lendingManager, events[0].LendingManager)
+	assert.Equal(t, blockchain.VaultEventRemoved, events[1].Kind)
+	assert.Equal(t, simulatedTestVault, events[1].Vault)
+	assert.Less(t, events[0].BlockNumber, events[1].BlockNumber)
+
+	later, err := client.GetVaultEvents(ctx, events[0].BlockNumber+1, head.Number)
+	require.NoError(t, err)
+	require.Len(t, later, 1, "events are filtered by block")
+	assert.Equal(t, blockchain.VaultEventRemoved, later[0].Kind)
+}
+
+func TestSimulatedChain_EpochStart(t *testing.T) {
+	client, chain := newSimulatedTestClient(t, nil)
+	ctx := context.Background()
+
+	require.NoError(t, client.StartEpoch(ctx))
+	chain.SetEpochDuration(time.Hour)
+	require.NoError(t, client.StartEpoch(ctx))
+
+	head, err := client.GetBlockRef(ctx, nil)
+	require.NoError(t, err)
+	start, err := client.GetEpochStart(ctx, big.NewInt(2), 0, head.Number)
+	require.NoError(t, err)
+	require.NotNil(t, start, "the emulated EpochManager emits EpochStarted")
+	assert.Equal(t, "2", start.EpochID.String())
+	assert.Equal(t, uint64(3600), start.EndTime-start.StartTime)
+	assert.Equal(t, head.Number, start.BlockNumber)
+	assert.NotEmpty(t, start.TxHash)
+
+	first, err := client.GetEpochStart(ctx, big.NewInt(1), 0, head.Number)
+	require.NoError(t, err)
+	require.NotNil(t, first, "events are filtered by epoch")
+	assert.Equal(t, first.StartTime, first.EndTime)
+	missing, err := client.GetEpochStart(ctx, big.NewInt(3), 0, head.Number)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestSimulatedChain_WatchedTransactionsConfirm(t *testing.T) {
+	settled := make(chan blockchain.TxOutcome, 1)
+	client, chain := newSimulatedTestClient(t, nil)
+	client.txObserver = observerFunc(func(ctx context.Context, outcome blockchain.TxOutcome) { settled <- outcome })
+	ctx := context.Background()
+
+	require.NoError(t, client.UpdateMerkleRoot(ctx, simulatedTestVault, [32]byte{2}, big.NewInt(5)))
+	// without a block time the watched transaction is buried only as blocks are mined
+	chain.Commit()
+	chain.Commit()
+
+	select {
+	case outcome := <-settled:
+		assert.Equal(t, blockchain.TxConfirmed, outcome.Status)
+		assert.Equal(t, "updateMerkleRoot", outcome.Action)
+	case <-time.After(5 * time.Second):
+		t.Fatal("transaction never settled")
+	}
+}
+
+func TestProvideClientWithConfig_ChecksSimulatedChainID(t *testing.T) {
+	_, err := ProvideClientWithConfig(lgr.NoOp, blockchain.Config{
+		Type:         blockchain.TypeSimulated,
+		ChainID:      1,
+		EpochManager: simulatedTestEpochManager,
+	}, nil, nil, nil)
+	assert.ErrorIs(t, err, blockchain.ErrChainIDMismatch)
+}
@@ -0,0 +1,268 @@
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	bind_v2 "github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// GetVaultRegistration reads how the DebtSubsidizer knows the vault: its lending manager and cToken, empty when
+// the vault was never added, and whether it was removed
+func (c *Client) GetVaultRegistration(ctx context.Context, vaultAddress string) (_ *blockchain.VaultRegistration, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetVaultRegistration", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(c.ethConfig.DebtSubsidizer)
+	vault := common.HexToAddress(vaultAddress)
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.subsidizer.PackVault(vault)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call vault: %w", err)
+	}
+	info, err := c.subsidizer.UnpackVault(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack vault result: %w", err)
+	}
+	output, err = c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: c.subsidizer.PackIsVaultRemoved(vault)}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call isVaultRemoved: %w", err)
+	}
+	removed, err := c.subsidizer.UnpackIsVaultRemoved(output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unpack isVaultRemoved result: %w", err)
+	}
+
+	registration := &blockchain.VaultRegistration{Removed: removed}
+	if info.LendingManager != (common.Address{}) {
+		registration.LendingManager = info.LendingManager.Hex()
+		registration.CToken = info.CToken.Hex()
+	}
+	return registration, nil
+}
+
+// IsCollectionWhitelisted reads whether the DebtSubsidizer accepts the collection's NFTs in the vault
+func (c *Client) IsCollectionWhitelisted(ctx context.Context, vaultAddress, collectionAddress string) (_ bool, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.IsCollectionWhitelisted",
+		attribute.String("vault.id", vaultAddress), attribute.String("collection", collectionAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return false, fmt.Errorf("ethereum client not initialized")
+	}
+
+	contractAddr := common.HexToAddress(c.ethConfig.DebtSubsidizer)
+	data := c.subsidizer.PackIsCollectionWhitelisted(common.HexToAddress(vaultAddress), common.HexToAddress(collectionAddress))
+	output, err := c.ethClient.CallContract(ctx, ethereum.CallMsg{To: &contractAddr, Data: data}, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to call isCollectionWhitelisted: %w", err)
+	}
+	whitelisted, err := c.subsidizer.UnpackIsCollectionWhitelisted(output)
+	if err != nil {
+		return false, fmt.Errorf("failed to unpack isCollectionWhitelisted result: %w", err)
+	}
+	return whitelisted, nil
+}
+
+// AddVault registers the vault and its lending manager with the DebtSubsidizer and waits for the transaction
+func (c *Client) AddVault(ctx context.Context, vaultAddress, lendingManagerAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.AddVault", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	rec := &txRecord{
+		action:     "addVault",
+		contract:   c.ethConfig.DebtSubsidizer,
+		parameters: map[string]string{"vault": vaultAddress, "lendingManager": lendingManagerAddress},
+	}
+	data := c.subsidizer.PackAddVault(common.HexToAddress(vaultAddress), common.HexToAddress(lendingManagerAddress))
+	return c.transactAndWait(ctx, span, rec, c.subsidizer.Instance(c.ethClient, common.HexToAddress(rec.contract)), data)
+}
+
+// GrantVaultRole lets the vault allocate yield to epochs on the EpochManager and waits for the transaction
+func (c *Client) GrantVaultRole(ctx context.Context, vaultAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GrantVaultRole", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	rec := &txRecord{
+		action:     "grantVaultRole",
+		contract:   c.ethConfig.EpochManager,
+		parameters: map[string]string{"vault": vaultAddress},
+	}
+	data := c.epochManager.PackGrantVaultRole(common.HexToAddress(vaultAddress))
+	return c.transactAndWait(ctx, span, rec, c.epochManager.Instance(c.ethClient, common.HexToAddress(rec.contract)), data)
+}
+
+// WhitelistCollection lets the collection's NFTs earn subsidies in the vault and waits for the transaction
+func (c *Client) WhitelistCollection(ctx context.Context, vaultAddress, collectionAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.WhitelistCollection",
+		attribute.String("vault.id", vaultAddress), attribute.String("collection", collectionAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	rec := &txRecord{
+		action:     "whitelistCollection",
+		contract:   c.ethConfig.DebtSubsidizer,
+		parameters: map[string]string{"vault": vaultAddress, "collection": collectionAddress},
+	}
+	data := c.subsidizer.PackWhitelistCollection(common.HexToAddress(vaultAddress), common.HexToAddress(collectionAddress))
+	return c.transactAndWait(ctx, span, rec, c.subsidizer.Instance(c.ethClient, common.HexToAddress(rec.contract)), data)
+}
+
+// RemoveVault removes the vault from the DebtSubsidizer for good and waits for the transaction, a removed vault
+// cannot be added again
+func (c *Client) RemoveVault(ctx context.Context, vaultAddress string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.RemoveVault", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	rec := &txRecord{
+		action:     "removeVault",
+		contract:   c.ethConfig.DebtSubsidizer,
+		parameters: map[string]string{"vault": vaultAddress},
+	}
+	data := c.subsidizer.PackRemoveVault(common.HexToAddress(vaultAddress))
+	return c.transactAndWait(ctx, span, rec, c.subsidizer.Instance(c.ethClient, common.HexToAddress(rec.contract)), data)
+}
+
+// vaultAddedTopic and vaultRemovedTopic are the topics of VaultAdded(address indexed vaultAddress, address indexed
+// cTokenAddress, address indexed lendingManagerAddress) and VaultRemoved(address indexed vaultAddress)
+var (
+	vaultAddedTopic   = crypto.Keccak256Hash([]byte("VaultAdded(address,address,address)"))
+	vaultRemovedTopic = crypto.Keccak256Hash([]byte("VaultRemoved(address)"))
+)
+
+// GetVaultEvents returns the VaultAdded and VaultRemoved events the DebtSubsidizer emitted between fromBlock and
+// toBlock inclusive, in chain order. The range is queried logQueryRange blocks at a time.
+func (c *Client) GetVaultEvents(ctx context.Context, fromBlock, toBlock uint64) (_ []blockchain.VaultEvent, err error) {
+	ctx, span := tracing.StartSpan(ctx, "blockchain.GetVaultEvents")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if c.ethClient == nil {
+		return nil, fmt.Errorf("ethereum client not initialized")
+	}
+
+	var events []blockchain.VaultEvent
+	for start := fromBlock; start <= toBlock; start += logQueryRange {
+		end := min(start+logQueryRange-1, toBlock)
+		logs, err := c.ethClient.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(start),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{common.HexToAddress(c.ethConfig.DebtSubsidizer)},
+			Topics:    [][]common.Hash{{vaultAddedTopic, vaultRemovedTopic}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to filter vault logs in blocks %d-%d: %w", start, end, err)
+		}
+
+		for _, log := range logs {
+			if log.Removed {
+				continue
+			}
+			event := blockchain.VaultEvent{TxHash: log.TxHash.Hex(), BlockNumber: log.BlockNumber, LogIndex: log.Index}
+			switch log.Topics[0] {
+			case vaultAddedTopic:
+				added, err := c.subsidizer.UnpackVaultAddedEvent(&log)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unpack VaultAdded log of tx %s: %w", log.TxHash.Hex(), err)
+				}
+				event.Kind = blockchain.VaultEventAdded
+				event.Vault = strings.ToLower(added.VaultAddress.Hex())
+				event.CToken = strings.ToLower(added.CTokenAddress.Hex())
+				event.LendingManager = strings.ToLower(added.LendingManagerAddress.Hex())
+			case vaultRemovedTopic:
+				removed, err := c.subsidizer.UnpackVaultRemovedEvent(&log)
+				if err != nil {
+					return nil, fmt.Errorf("failed to unpack VaultRemoved log of tx %s: %w", log.TxHash.Hex(), err)
+				}
+				event.Kind = blockchain.VaultEventRemoved
+				event.Vault = strings.ToLower(removed.VaultAddress.Hex())
+			}
+			events = append(events, event)
+		}
+		if end == toBlock {
+			break // start += logQueryRange would overflow at the top of the range
+		}
+	}
+	return events, nil
+}
+
+// transactAndWait sends data to the contract and waits for it to be mined, failing when it reverts. The
+// transaction is recorded in the audit log under rec's action.
+func (c *Client) transactAndWait(
+	ctx context.Context,
+	span trace.Span,
+	rec *txRecord,
+	contract *bind_v2.BoundContract,
+	data []byte,
+) (err error) {
+	logger := logging.FromContext(ctx, c.logger)
+
+	if err := c.rejectReadOnly(rec.action); err != nil {
+		return err
+	}
+	if c.ethClient == nil {
+		logger.Logf("INFO [MOCK] sending %s to %s: %v", rec.action, rec.contract, rec.parameters)
+		return nil
+	}
+	if c.privateKey == nil {
+		logger.Logf("ERROR Ethereum client not initialized")
+		return fmt.Errorf("ethereum client not initialized")
+	}
+	defer func() { c.recordTx(ctx, rec, err) }()
+
+	chainID, err := c.ethClient.ChainID(ctx)
+	if err != nil {
+		logger.Logf("ERROR failed to get chain ID: %v", err)
+		return err
+	}
+
+	gasPrice, _ := new(big.Int).SetString(c.ethConfig.GasPrice, 10)
+	opts, err := bind.NewKeyedTransactorWithChainID(c.privateKey, chainID)
+	if err != nil {
+		logger.Logf("ERROR failed to create transactor: %v", err)
+		return err
+	}
+	opts.GasLimit = c.ethConfig.GasLimit
+	opts.GasPrice = gasPrice
+	opts.Context = ctx
+
+	tx, err := contract.RawTransact(opts, data)
+	if err != nil {
+		logger.Logf("ERROR failed to call %s: %v", rec.action, err)
+		return fmt.Errorf("failed to call %s: %w", rec.action, err)
+	}
+
+	ctx = logging.WithFields(ctx, logging.Fields{TxHash: tx.Hash().Hex()})
+	logger = logging.FromContext(ctx, c.logger)
+	logger.Logf("INFO %s transaction sent: %s", rec.action, tx.Hash().Hex())
+	annotateTx(span, tx.Hash().Hex())
+	rec.sent(tx)
+
+	receipt, err := bind.WaitMined(ctx, c.ethClient, tx)
+	if err != nil {
+		logger.Logf("ERROR failed to wait for %s transaction: %v", rec.action, err)
+		return fmt.Errorf("failed to wait for %s transaction: %w", rec.action, err)
+	}
+	rec.mined(receipt)
+
+	if receipt.Status == 0 {
+		rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		logger.Logf("ERROR %s transaction failed: %s", rec.action, tx.Hash().Hex())
+		return fmt.Errorf("%s transaction failed with hash %s", rec.action, tx.Hash().Hex())
+	}
+
+	logger.Logf("INFO %s transaction successful: %s", rec.action, tx.Hash().Hex())
+	return nil
+}
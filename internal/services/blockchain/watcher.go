@@ -0,0 +1,191 @@
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	defaultReceiptTimeout = 10 * time.Minute
+	defaultPollInterval   = 2 * time.Second
+)
+
+// receiptSource is the part of the RPC client the receipt watcher polls
+type receiptSource interface {
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// watchReceipt follows a transaction sent without waiting until its receipt is buried under the confirmations
+// configured for its action, then records it and tells the observer how it settled. The caller's record is marked
+// pending so its deferred recordTx leaves the audit entry to the watcher.
+func (c *Client) watchReceipt(ctx context.Context, rec *txRecord, tx *types.Transaction) {
+	if c.receipts == nil {
+		return
+	}
+
+	watched := *rec
+	rec.pending = true
+
+	// the watch outlives the call that sent the transaction
+	go c.settle(context.WithoutCancel(ctx), &watched, tx)
+}
+
+// settle waits for the transaction's confirmed receipt and reports the outcome
+func (c *Client) settle(ctx context.Context, rec *txRecord, tx *types.Transaction) {
+	timeout := c.ethConfig.ReceiptTimeout
+	if timeout <= 0 {
+		timeout = defaultReceiptTimeout
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	outcome := blockchain.TxOutcome{
+		Action:     rec.action,
+		TxHash:     tx.Hash().Hex(),
+		Parameters: rec.parameters,
+	}
+
+	var txErr error
+	receipt, err := c.waitConfirmed(waitCtx, tx.Hash(), c.confirmations(rec.action))
+	switch {
+	case err != nil:
+		outcome.Status = blockchain.TxUnconfirmed
+		txErr = fmt.Errorf("%s transaction %s not confirmed: %w", rec.action, outcome.TxHash, err)
+		c.logger.Logf("ERROR %v", txErr)
+	case receipt.Status == types.ReceiptStatusFailed:
+		rec.mined(receipt)
+		if c.ethClient != nil && c.privateKey != nil {
+			rec.revertReason = c.replayRevertReason(ctx, tx, receipt)
+		}
+		outcome.Status = blockchain.TxReverted
+		outcome.BlockNumber = rec.blockNumber
+		outcome.RevertReason = rec.revertReason
+		txErr = fmt.Errorf("%s transaction failed with hash %s", rec.action, outcome.TxHash)
+		c.logger.Logf("ERROR %v: %s", txErr, rec.revertReason)
+	default:
+		rec.mined(receipt)
+		outcome.Status = blockchain.TxConfirmed
+		outcome.BlockNumber = rec.blockNumber
+		outcome.Events = decodeEvents(receipt.Logs)
+		c.logger.Logf("INFO %s transaction %s confirmed in block %d", rec.action, outcome.TxHash, rec.blockNumber)
+	}
+
+	c.recordTx(ctx, rec, txErr)
+	if c.txObserver != nil {
+		c.txObserver.TxSettled(ctx, outcome)
+	}
+}
+
+// confirmations returns how many blocks deep the receipt of a transaction calling action must be: updateMerkleRoot
+// is final at RootConfirmations, every other transaction updates epoch state at ReceiptConfirmations
+func (c *Client) confirmations(action string) uint64 {
+	if action == "updateMerkleRoot" {
+		return c.ethConfig.RootConfirmations
+	}
+	return c.ethConfig.ReceiptConfirmations
+}
+
+// waitConfirmed polls for the transaction's receipt until it is confirmations blocks deep. The receipt is fetched
+// again on every poll, so a reorg that moves or drops the transaction restarts the count.
+func (c *Client) waitConfirmed(ctx context.Context, txHash common.Hash, confirmations uint64) (*types.Receipt, error) {
+	interval := c.ethConfig.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := c.receipts.TransactionReceipt(ctx, txHash)
+		switch {
+		case errors.Is(err, ethereum.NotFound):
+			c.logger.Logf("DEBUG waiting for receipt of %s", txHash.Hex())
+		case err != nil:
+			c.logger.Logf("WARN failed to get receipt of %s: %v", txHash.Hex(), err)
+		default:
+			head, err := c.receipts.BlockNumber(ctx)
+			if err != nil {
+				c.logger.Logf("WARN failed to get latest block: %v", err)
+				break
+			}
+			mined := receipt.BlockNumber.Uint64()
+			if head >= mined && head-mined+1 >= confirmations {
+				return receipt, nil
+			}
+			c.logger.Logf("DEBUG waiting for %s mined in block %d to reach %d confirmations (head %d)",
+				txHash.Hex(), mined, confirmations, head)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// decodeEvents decodes the logs emitted by the protocol contracts, skipping logs no known ABI declares
+func decodeEvents(logs []*types.Log) []blockchain.TxEvent {
+	var events []blockchain.TxEvent
+	for _, log := range logs {
+		if len(log.Topics) == 0 {
+			continue
+		}
+		for _, parsed := range protocolABIs() {
+			event, err := parsed.EventByID(log.Topics[0])
+			if err != nil {
+				continue
+			}
+
+			values := make(map[string]interface{})
+			if err := event.Inputs.NonIndexed().UnpackIntoMap(values, log.Data); err != nil {
+				break
+			}
+			var indexed abi.Arguments
+			for _, input := range event.Inputs {
+				if input.Indexed {
+					indexed = append(indexed, input)
+				}
+			}
+			if err := abi.ParseTopicsIntoMap(values, indexed, log.Topics[1:]); err != nil {
+				break
+			}
+
+			args := make(map[string]string, len(values))
+			for name, value := range values {
+				args[name] = formatEventArg(value)
+			}
+			events = append(events, blockchain.TxEvent{
+				Contract: strings.ToLower(log.Address.Hex()),
+				Name:     event.Name,
+				Args:     args,
+			})
+			break
+		}
+	}
+	return events
+}
+
+func formatEventArg(value interface{}) string {
+	switch v := value.(type) {
+	case common.Address:
+		return strings.ToLower(v.Hex())
+	case [32]byte:
+		return fmt.Sprintf("0x%x", v)
+	case *big.Int:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,176 @@
+package blockchain
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/pkg/contracts"
+)
+
+// fakeReceipts serves one receipt per poll from a script, advancing the head by one block each poll
+type fakeReceipts struct {
+	mu       sync.Mutex
+	receipts []*types.Receipt // nil entries are polls before the transaction is mined
+	head     uint64
+	polls    int
+}
+
+func (f *fakeReceipts) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	receipt := f.receipts[min(f.polls, len(f.receipts)-1)]
+	f.polls++
+	f.head++
+	if receipt == nil {
+		return nil, ethereum.NotFound
+	}
+	return receipt, nil
+}
+
+func (f *fakeReceipts) BlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.head, nil
+}
+
+type observerFunc func(ctx context.Context, outcome blockchain.TxOutcome)
+
+func (f observerFunc) TxSettled(ctx context.Context, outcome blockchain.TxOutcome) { f(ctx, outcome) }
+
+func epochFinalizedLog(t *testing.T, epochID int64) *types.Log {
+	parsed, err := contracts.IEpochManagerMetaData.ParseABI()
+	require.NoError(t, err)
+	event := parsed.Events["EpochFinalized"]
+	data, err := event.Inputs.NonIndexed().Pack(big.NewInt(500), big.NewInt(300))
+	require.NoError(t, err)
+	topics, err := abi.MakeTopics([]interface{}{big.NewInt(epochID)})
+	require.NoError(t, err)
+	return &types.Log{
+		Address: common.HexToAddress("0x00000000000000000000000000000000000000Ee"),
+		Topics:  append([]common.Hash{event.ID}, topics[0]...),
+		Data:    data,
+	}
+}
+
+func TestClient_WaitConfirmed(t *testing.T) {
+	first := &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(2)}
+	// the transaction is reorged into a later block, which restarts the confirmation count
+	moved := &types.Receipt{Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(5)}
+	source := &fakeReceipts{receipts: []*types.Receipt{nil, first, nil, moved}}
+	client := &Client{
+		logger:    lgr.NoOp,
+		ethConfig: blockchain.Config{PollInterval: time.Millisecond},
+		receipts:  source,
+	}
+
+	receipt, err := client.waitConfirmed(context.Background(), common.HexToHash("0x01"), 3)
+	require.NoError(t, err)
+	assert.Equal(t, moved, receipt)
+	assert.Equal(t, 7, source.polls, "head 7 is the third block on top of block 5")
+}
+
+func TestClient_WaitConfirmedTimesOut(t *testing.T) {
+	client := &Client{
+		logger:    lgr.NoOp,
+		ethConfig: blockchain.Config{PollInterval: time.Millisecond},
+		receipts:  &fakeReceipts{receipts: []*types.Receipt{nil}},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := client.waitConfirmed(ctx, common.HexToHash("0x01"), 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClient_Confirmations(t *testing.T) {
+	client := &Client{ethConfig: blockchain.Config{ReceiptConfirmations: 2, RootConfirmations: 12}}
+	assert.Equal(t, uint64(12), client.confirmations("updateMerkleRoot"), "merkle roots wait until they are final")
+	assert.Equal(t, uint64(2), client.confirmations("endEpochWithSubsidies"))
+	assert.Equal(t, uint64(2), client.confirmations("forceEndEpochWithZeroYield"))
+}
+
+func TestClient_WatchReceipt(t *testing.T) {
+	tx := types.NewTx(&types.LegacyTx{Nonce: 1, Gas: 21000, GasPrice: big.NewInt(2)})
+	tests := []struct {
+		name    string
+		receipt *types.Receipt
+		timeout time.Duration
+		status  blockchain.TxStatus
+		result  string
+		events  []blockchain.TxEvent
+	}{
+		{
+			name: "confirmed",
+			receipt: &types.Receipt{
+				Status: types.ReceiptStatusSuccessful, BlockNumber: big.NewInt(1), GasUsed: 21000,
+				Logs: []*types.Log{epochFinalizedLog(t, 7)},
+			},
+			status: blockchain.TxConfirmed,
+			result: audit.ResultSuccess,
+			events: []blockchain.TxEvent{{
+				Contract: "0x00000000000000000000000000000000000000ee",
+				Name:     "EpochFinalized",
+				Args:     map[string]string{"epochId": "7", "totalYieldAvailable": "500", "totalSubsidiesDistributed": "300"},
+			}},
+		},
+		{
+			name:    "reverted",
+			receipt: &types.Receipt{Status: types.ReceiptStatusFailed, BlockNumber: big.NewInt(1), GasUsed: 21000},
+			status:  blockchain.TxReverted,
+			result:  audit.ResultFailed,
+		},
+		{name: "unconfirmed", timeout: 20 * time.Millisecond, status: blockchain.TxUnconfirmed, result: audit.ResultFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+			settled := make(chan blockchain.TxOutcome, 1)
+			client := &Client{
+				logger: lgr.NoOp,
+				ethConfig: blockchain.Config{
+					ReceiptConfirmations: 1, ReceiptTimeout: tt.timeout, PollInterval: time.Millisecond,
+				},
+				receipts: &fakeReceipts{receipts: []*types.Receipt{tt.receipt}},
+				recorder: recorder,
+				txObserver: observerFunc(func(ctx context.Context, outcome blockchain.TxOutcome) {
+					settled <- outcome
+				}),
+			}
+
+			rec := &txRecord{action: "forceEndEpochWithZeroYield", parameters: map[string]string{"epochId": "7"}}
+			rec.sent(tx)
+			client.watchReceipt(context.Background(), rec, tx)
+			// the sending call's deferred record is left to the watcher
+			client.recordTx(context.Background(), rec, nil)
+
+			var outcome blockchain.TxOutcome
+			select {
+			case outcome = <-settled:
+			case <-time.After(5 * time.Second):
+				t.Fatal("transaction never settled")
+			}
+			assert.Equal(t, tt.status, outcome.Status)
+			assert.Equal(t, tx.Hash().Hex(), outcome.TxHash)
+			assert.Equal(t, "7", outcome.Parameters["epochId"])
+			assert.Equal(t, tt.events, outcome.Events)
+
+			calls := recorder.RecordCalls()
+			require.Len(t, calls, 1)
+			assert.Equal(t, tt.result, calls[0].Entry.Result)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package compatibility
+
+import (
+	"context"
+)
+
+//go:generate moq -out compatibility_mocks.go . Service
+
+// Service compares the contracts the server is configured with against the bindings compiled into pkg/contracts,
+// so bindings left behind by a contract upgrade are reported instead of surfacing as calls that revert
+type Service interface {
+	// Check reads every configured contract that has a binding and compares the functions its code dispatches
+	// with the binding's
+	Check(ctx context.Context) (*Matrix, error)
+}
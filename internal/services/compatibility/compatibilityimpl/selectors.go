@@ -0,0 +1,41 @@
+package compatibilityimpl
+
+import (
+	"encoding/hex"
+)
+
+// EVM opcodes the dispatcher scan reads
+const (
+	opEQ     = 0x14
+	opPUSH1  = 0x60
+	opPUSH4  = 0x63
+	opPUSH32 = 0x7f
+	opDUP1   = 0x80
+	opDUP16  = 0x8f
+)
+
+// dispatchedSelectors returns the function selectors code compares the calldata selector against. Solidity's
+// dispatcher pushes each selector and compares it for equality, so a PUSH4 followed by EQ, or by a DUP and EQ,
+// is a function. Selectors pushed for anything else, such as custom errors or interface IDs, are shifted into
+// place before they are compared and do not count.
+func dispatchedSelectors(code []byte) map[string]bool {
+	selectors := make(map[string]bool)
+	for i := 0; i < len(code); i++ {
+		op := code[i]
+		if op < opPUSH1 || op > opPUSH32 {
+			continue
+		}
+		size := int(op-opPUSH1) + 1
+		if op == opPUSH4 && i+size+1 < len(code) {
+			next := i + size + 1
+			if code[next] >= opDUP1 && code[next] <= opDUP16 && next+1 < len(code) {
+				next++
+			}
+			if code[next] == opEQ {
+				selectors["0x"+hex.EncodeToString(code[i+1:i+5])] = true
+			}
+		}
+		i += size // push data is not code
+	}
+	return selectors
+}
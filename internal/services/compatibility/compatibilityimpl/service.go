@@ -0,0 +1,165 @@
+package compatibilityimpl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/compatibility"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-pkgz/lgr"
+)
+
+// incompatibleMetric counts the configured contracts missing functions of their binding, exposed on /metrics
+const incompatibleMetric = "epoch_server_contract_bindings_incompatible"
+
+// binding is the generated binding the server calls a configured contract with
+type binding struct {
+	name     string
+	metadata *bind.MetaData
+}
+
+// bindings of the configured contracts by the names config.ContractAddresses gives them. The comptroller, the NFT
+// and the cToken are called through hand-written ABIs and are not checked.
+var bindings = map[string]binding{
+	"epoch manager":       {"IEpochManager", &contracts.IEpochManagerMetaData},
+	"debt subsidizer":     {"IDebtSubsidizer", &contracts.IDebtSubsidizerMetaData},
+	"lending manager":     {"ILendingManager", &contracts.ILendingManagerMetaData},
+	"collection registry": {"ICollectionRegistry", &contracts.ICollectionRegistryMetaData},
+	"collections vault":   {"ICollectionsVault", &contracts.ICollectionsVaultMetaData},
+	"asset":               {"IERC20", &contracts.IERC20MetaData},
+}
+
+type Service struct {
+	blockchainClient blockchain.BlockchainClient
+	metrics          *metrics.Registry
+	logger           lgr.L
+	cfg              *config.Config
+	now              func() time.Time
+}
+
+func New(blockchainClient blockchain.BlockchainClient, registry *metrics.Registry, logger lgr.L, cfg *config.Config) *Service {
+	return &Service{
+		blockchainClient: blockchainClient,
+		metrics:          registry,
+		logger:           logger,
+		cfg:              cfg,
+		now:              time.Now,
+	}
+}
+
+// Check reads the code of every configured contract that has a binding and an address, through its proxy when it is one, and
+// compares the selectors its dispatcher matches with the binding's functions. Contracts missing functions of
+// their binding are logged as errors, since every call to those functions reverts, and contracts dispatching
+// functions their binding lacks as warnings, since the bindings are older than the deployment.
+func (s *Service) Check(ctx context.Context) (_ *compatibility.Matrix, err error) {
+	ctx, span := tracing.StartSpan(ctx, "compatibility.Check")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	matrix := &compatibility.Matrix{Contracts: make([]compatibility.ContractCompatibility, 0), Compatible: true}
+	incompatible := 0
+	for _, contract := range config.ContractAddresses(s.cfg) {
+		b, ok := bindings[contract.Name]
+		if !ok || contract.Address == "" {
+			continue
+		}
+		result := s.checkContract(ctx, contract, b)
+		switch result.Status {
+		case compatibility.StatusIncompatible:
+			incompatible++
+			matrix.Compatible = false
+			s.logger.Logf("ERROR the %s contract at %s does not dispatch %d functions of the %s binding, calls to them revert: %s",
+				contract.Name, contract.Address, len(result.Missing), b.name, strings.Join(result.Missing, ", "))
+		case compatibility.StatusNewer:
+			s.logger.Logf("WARN the %s contract at %s dispatches %d functions the %s binding lacks, the bindings may be older than the deployment: %s",
+				contract.Name, contract.Address, len(result.Unknown), b.name, strings.Join(result.Unknown, ", "))
+		case compatibility.StatusUnknown:
+			s.logger.Logf("WARN could not check the %s contract at %s against the %s binding: %s",
+				contract.Name, contract.Address, b.name, result.Error)
+		}
+		matrix.Contracts = append(matrix.Contracts, result)
+	}
+	matrix.CheckedAt = s.now().UTC()
+
+	if s.metrics != nil {
+		s.metrics.SetGauge(incompatibleMetric, "Configured contracts whose deployed code lacks functions of the binding the server calls them with",
+			float64(incompatible))
+	}
+	return matrix, nil
+}
+
+func (s *Service) checkContract(
+	ctx context.Context,
+	contract config.ContractAddress,
+	b binding,
+) compatibility.ContractCompatibility {
+	result := compatibility.ContractCompatibility{
+		Contract: contract.Name,
+		Address:  contract.Address,
+		Binding:  b.name,
+		Status:   compatibility.StatusUnknown,
+	}
+
+	parsed, err := b.metadata.ParseABI()
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to parse the binding ABI: %v", err)
+		return result
+	}
+	code, err := s.blockchainClient.GetContractCode(ctx, contract.Address)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Implementation = code.Implementation
+	result.Version = code.Version
+	if len(code.Code) > 0 {
+		result.CodeHash = crypto.Keccak256Hash(code.Code).Hex()
+	}
+
+	dispatched := dispatchedSelectors(code.Code)
+	if len(dispatched) == 0 {
+		result.Error = "the deployed code dispatches no function selectors"
+		return result
+	}
+	result.Missing, result.Unknown = compare(parsed, dispatched)
+
+	switch {
+	case len(result.Missing) > 0:
+		result.Status = compatibility.StatusIncompatible
+	case len(result.Unknown) > 0:
+		result.Status = compatibility.StatusNewer
+	default:
+		result.Status = compatibility.StatusCompatible
+	}
+	return result
+}
+
+// compare returns the signatures of the binding's functions not dispatched and the dispatched selectors the
+// binding has no function for, both sorted
+func compare(binding *abi.ABI, dispatched map[string]bool) (missing, unknown []string) {
+	known := make(map[string]bool, len(binding.Methods))
+	for _, method := range binding.Methods {
+		selector := fmt.Sprintf("0x%x", method.ID)
+		known[selector] = true
+		if !dispatched[selector] {
+			missing = append(missing, method.Sig)
+		}
+	}
+	for selector := range dispatched {
+		if !known[selector] {
+			unknown = append(unknown, selector)
+		}
+	}
+	sort.Strings(missing)
+	sort.Strings(unknown)
+	return missing, unknown
+}
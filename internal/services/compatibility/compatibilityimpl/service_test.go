@@ -0,0 +1,151 @@
+package compatibilityimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind/v2"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/compatibility"
+	"github.com/andrey/epoch-server/pkg/contracts"
+)
+
+// dispatcher returns code whose dispatcher matches each selector the way solc emits it:
+// DUP1 PUSH4 <selector> EQ PUSH2 <dest> JUMPI
+func dispatcher(selectors ...[]byte) []byte {
+	code := []byte{0x60, 0xe0, 0x1c} // PUSH1 0xe0 SHR, the calldata selector
+	for _, selector := range selectors {
+		code = append(code, 0x80, 0x63)
+		code = append(code, selector...)
+		code = append(code, 0x14, 0x61, 0x00, 0x10, 0x57)
+	}
+	return append(code, 0x00)
+}
+
+// selectorsOf returns the selectors of every function of the binding but those named in except
+func selectorsOf(t *testing.T, metadata *bind.MetaData, except ...string) [][]byte {
+	t.Helper()
+	parsed, err := metadata.ParseABI()
+	require.NoError(t, err)
+	skip := make(map[string]bool)
+	for _, name := range except {
+		skip[name] = true
+	}
+	var selectors [][]byte
+	for name, method := range parsed.Methods {
+		if !skip[name] {
+			selectors = append(selectors, method.ID)
+		}
+	}
+	return selectors
+}
+
+func TestDispatchedSelectors(t *testing.T) {
+	code := dispatcher([]byte{0x12, 0x34, 0x56, 0x78})
+	// a custom error selector is shifted into place before it is used, it is no function
+	code = append(code, 0x63, 0xaa, 0xbb, 0xcc, 0xdd, 0x60, 0xe0, 0x1b)
+	// push data that looks like a dispatched selector is not code
+	code = append(code, 0x66, 0x63, 0xde, 0xad, 0xbe, 0xef, 0x14, 0x00)
+
+	assert.Equal(t, map[string]bool{"0x12345678": true}, dispatchedSelectors(code))
+}
+
+func TestService_Check(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Contracts.Comptroller = "0x1111111111111111111111111111111111111111"
+	cfg.Contracts.EpochManager = "0x2222222222222222222222222222222222222222"
+	cfg.Contracts.DebtSubsidizer = "0x3333333333333333333333333333333333333333"
+
+	tests := []struct {
+		name               string
+		code               []byte
+		readErr            error
+		expectedStatus     string
+		expectedMissing    []string
+		expectedUnknown    []string
+		expectedCompatible bool
+	}{
+		{
+			name:               "compatible",
+			code:               dispatcher(selectorsOf(t, &contracts.IEpochManagerMetaData)...),
+			expectedStatus:     compatibility.StatusCompatible,
+			expectedCompatible: true,
+		},
+		{
+			name:               "deployment_newer",
+			code:               dispatcher(append(selectorsOf(t, &contracts.IEpochManagerMetaData), []byte{0x54, 0xfd, 0x4d, 0x50})...),
+			expectedStatus:     compatibility.StatusNewer,
+			expectedUnknown:    []string{"0x54fd4d50"},
+			expectedCompatible: true,
+		},
+		{
+			name:               "function_removed",
+			code:               dispatcher(selectorsOf(t, &contracts.IEpochManagerMetaData, "forceEndEpochWithZeroYield")...),
+			expectedStatus:     compatibility.StatusIncompatible,
+			expectedMissing:    []string{"forceEndEpochWithZeroYield(uint256,address)"},
+			expectedCompatible: false,
+		},
+		{
+			name:               "no_dispatcher",
+			code:               []byte{0x00},
+			expectedStatus:     compatibility.StatusUnknown,
+			expectedCompatible: true,
+		},
+		{
+			name:               "unreadable",
+			readErr:            errors.New("rpc down"),
+			expectedStatus:     compatibility.StatusUnknown,
+			expectedCompatible: true,
+		},
+	}
+
+	debtSubsidizerCode := dispatcher(selectorsOf(t, &contracts.IDebtSubsidizerMetaData)...)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &blockchain.BlockchainClientMock{
+				GetContractCodeFunc: func(ctx context.Context, address string) (*blockchain.ContractCode, error) {
+					if tt.readErr != nil {
+						return nil, tt.readErr
+					}
+					code := tt.code
+					if address == cfg.Contracts.DebtSubsidizer {
+						code = debtSubsidizerCode
+					}
+					return &blockchain.ContractCode{
+						Address: address, Implementation: "0x4444444444444444444444444444444444444444", Code: code, Version: "2.1.0",
+					}, nil
+				},
+			}
+			registry := metrics.NewRegistry()
+			matrix, err := New(chain, registry, lgr.NoOp, cfg).Check(context.Background())
+			require.NoError(t, err)
+
+			require.Len(t, matrix.Contracts, 2, "the comptroller has no binding to check")
+			epochManager := matrix.Contracts[0]
+			assert.Equal(t, "IEpochManager", epochManager.Binding)
+			assert.Equal(t, tt.expectedStatus, epochManager.Status)
+			assert.Equal(t, tt.expectedMissing, epochManager.Missing)
+			assert.Equal(t, tt.expectedUnknown, epochManager.Unknown)
+			assert.Equal(t, tt.expectedCompatible, matrix.Compatible)
+			if tt.readErr == nil {
+				assert.Equal(t, "2.1.0", epochManager.Version)
+				assert.NotEmpty(t, epochManager.CodeHash)
+			}
+
+			incompatible, ok := registry.Gauge(incompatibleMetric)
+			require.True(t, ok)
+			if tt.expectedCompatible {
+				assert.Zero(t, incompatible)
+			} else {
+				assert.Positive(t, incompatible)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package compatibility
+
+import (
+	"time"
+)
+
+// compatibility of a deployed contract with its binding
+const (
+	StatusCompatible   = "compatible"   // the deployed code dispatches exactly the binding's functions
+	StatusNewer        = "newer"        // the deployed code also dispatches functions the binding lacks, the binding is older
+	StatusIncompatible = "incompatible" // functions of the binding are not deployed, calls to them revert
+	StatusUnknown      = "unknown"      // the code could not be read, or dispatches no function the check recognizes
+)
+
+// ContractCompatibility is how a configured contract's deployed code compares to the binding the server calls it with
+type ContractCompatibility struct {
+	Contract       string `json:"contract" example:"debt subsidizer"`
+	Address        string `json:"address" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	Implementation string `json:"implementation,omitempty"` // the EIP-1967 implementation behind Address, when it is a proxy
+	Binding        string `json:"binding" example:"IDebtSubsidizer"`
+	Version        string `json:"version,omitempty" example:"2.1.0"` // what the contract's version() returned
+	CodeHash       string `json:"codeHash,omitempty"`                // keccak256 of the deployed code, changes with every upgrade
+	Status         string `json:"status" example:"compatible" enums:"compatible,newer,incompatible,unknown"`
+	// Missing are the binding's functions the deployed code does not dispatch, calls to them revert
+	Missing []string `json:"missing,omitempty" example:"updateMerkleRoot(address,bytes32,uint256)"`
+	// Unknown are the selectors the deployed code dispatches that the binding lacks. Bindings of interfaces leave
+	// out functions the contract inherits, so these are reviewed rather than failed.
+	Unknown []string `json:"unknown,omitempty" example:"0x54fd4d50"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// Matrix is the compatibility of every configured contract that has a binding
+type Matrix struct {
+	Contracts  []ContractCompatibility `json:"contracts"`
+	Compatible bool                    `json:"compatible"` // no contract is incompatible
+	CheckedAt  time.Time               `json:"checkedAt"`
+}
@@ -0,0 +1,17 @@
+package contractstate
+
+import (
+	"context"
+)
+
+//go:generate moq -out contractstate_mocks.go . Service
+
+// Service watches whether the protocol contracts accept subsidy transactions.
+// Distributions are skipped while the DebtSubsidizer is paused or no longer registers the vault,
+// since every transaction sent to it would revert.
+type Service interface {
+	// Check reads the pause state from the chain and updates the skip state and metrics
+	Check(ctx context.Context) (*PauseStatus, error)
+	// Status returns the outcome of the last check without querying the chain
+	Status() PauseStatus
+}
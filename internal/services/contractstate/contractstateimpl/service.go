@@ -0,0 +1,113 @@
+package contractstateimpl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+// metric names exposed on /metrics
+const (
+	pausedMetric  = "epoch_server_contract_paused"
+	checkedMetric = "epoch_server_contract_pause_checked_timestamp_seconds"
+)
+
+type Service struct {
+	blockchainClient blockchain.BlockchainClient
+	notifier         webhook.Notifier
+	metrics          *metrics.Registry
+	logger           lgr.L
+	now              func() time.Time
+
+	mu     sync.RWMutex
+	status contractstate.PauseStatus
+}
+
+func New(
+	blockchainClient blockchain.BlockchainClient,
+	notifier webhook.Notifier,
+	registry *metrics.Registry,
+	logger lgr.L,
+	cfg *config.Config,
+) *Service {
+	return &Service{
+		blockchainClient: blockchainClient,
+		notifier:         notifier,
+		metrics:          registry,
+		logger:           logger,
+		now:              time.Now,
+		status: contractstate.PauseStatus{
+			DebtSubsidizer: cfg.Contracts.DebtSubsidizer,
+			Vault:          cfg.Contracts.CollectionsVault,
+		},
+	}
+}
+
+// Check reads whether the DebtSubsidizer is paused or removed the vault, and skips distributions while it is.
+// An alert is sent once when the contract becomes paused, not on every check.
+// A failed read keeps the previous state, since it says nothing about the contract.
+func (s *Service) Check(ctx context.Context) (_ *contractstate.PauseStatus, err error) {
+	ctx, span := tracing.StartSpan(ctx, "contractstate.Check")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	state, err := s.blockchainClient.GetPauseState(ctx, s.status.Vault)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.status.LastError = err.Error()
+		return nil, fmt.Errorf("failed to get contract pause state: %w", err)
+	}
+
+	wasPaused := s.status.Paused
+	s.status.SubsidizerPaused = state.SubsidizerPaused
+	s.status.VaultRemoved = state.VaultRemoved
+	s.status.Paused = state.SubsidizerPaused || state.VaultRemoved
+	s.status.CheckedAt = s.now().UTC()
+	s.status.LastError = ""
+	s.recordMetrics()
+
+	switch {
+	case s.status.Paused && !wasPaused:
+		s.logger.Logf("WARN DebtSubsidizer %s does not accept subsidies for vault %s (paused: %t, vault removed: %t), skipping distributions",
+			s.status.DebtSubsidizer, s.status.Vault, s.status.SubsidizerPaused, s.status.VaultRemoved)
+		s.notifier.Notify(ctx, webhook.EventContractPaused, map[string]interface{}{
+			"contractAddress":  s.status.DebtSubsidizer,
+			"vaultAddress":     s.status.Vault,
+			"subsidizerPaused": s.status.SubsidizerPaused,
+			"vaultRemoved":     s.status.VaultRemoved,
+		})
+	case !s.status.Paused && wasPaused:
+		s.logger.Logf("INFO DebtSubsidizer %s accepts subsidies for vault %s again, resuming distributions",
+			s.status.DebtSubsidizer, s.status.Vault)
+	}
+
+	status := s.status
+	return &status, nil
+}
+
+// Status returns the outcome of the last check
+func (s *Service) Status() contractstate.PauseStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+func (s *Service) recordMetrics() {
+	paused := 0.0
+	if s.status.Paused {
+		paused = 1
+	}
+	s.metrics.SetGauge(pausedMetric, "Whether distributions are skipped because the DebtSubsidizer is paused or removed the vault", paused)
+	s.metrics.SetGauge(checkedMetric, "Unix time of the last successful contract pause check", float64(s.status.CheckedAt.Unix()))
+}
@@ -0,0 +1,80 @@
+package contractstateimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+// newTestService returns a service whose pause reads come from states in order, nil entries fail
+func newTestService(states ...*blockchain.PauseState) (*Service, *webhook.NotifierMock, *blockchain.BlockchainClientMock) {
+	calls := 0
+	chain := &blockchain.BlockchainClientMock{
+		GetPauseStateFunc: func(ctx context.Context, vaultId string) (*blockchain.PauseState, error) {
+			state := states[calls]
+			calls++
+			if state == nil {
+				return nil, errors.New("connection refused")
+			}
+			return state, nil
+		},
+	}
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = testVault
+	cfg.Contracts.DebtSubsidizer = "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+	return New(chain, notifier, metrics.NewRegistry(), lgr.NoOp, cfg), notifier, chain
+}
+
+func TestService_Check_PausesAndResumes(t *testing.T) {
+	svc, notifier, chain := newTestService(
+		&blockchain.PauseState{},
+		&blockchain.PauseState{SubsidizerPaused: true},
+		&blockchain.PauseState{SubsidizerPaused: true, VaultRemoved: true},
+		nil,
+		&blockchain.PauseState{},
+	)
+	ctx := context.Background()
+
+	status, err := svc.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Paused)
+	assert.Equal(t, testVault, chain.GetPauseStateCalls()[0].VaultId)
+	assert.Empty(t, notifier.NotifyCalls())
+
+	status, err = svc.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Paused)
+	assert.True(t, status.SubsidizerPaused)
+	require.Len(t, notifier.NotifyCalls(), 1)
+	assert.Equal(t, webhook.EventContractPaused, notifier.NotifyCalls()[0].EventType)
+	assert.Equal(t, testVault, notifier.NotifyCalls()[0].Data["vaultAddress"])
+
+	status, err = svc.Check(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.VaultRemoved)
+	assert.Len(t, notifier.NotifyCalls(), 1, "alert only when the contract becomes paused")
+
+	_, err = svc.Check(ctx)
+	require.Error(t, err)
+	assert.True(t, svc.Status().Paused, "a failed read keeps the previous state")
+	assert.Contains(t, svc.Status().LastError, "connection refused")
+
+	status, err = svc.Check(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Paused)
+	assert.Empty(t, status.LastError)
+	assert.Len(t, notifier.NotifyCalls(), 1)
+}
@@ -0,0 +1,16 @@
+package contractstate
+
+import (
+	"time"
+)
+
+// PauseStatus is the outcome of the latest contract pause check
+type PauseStatus struct {
+	DebtSubsidizer   string    `json:"debtSubsidizer,omitempty" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	Vault            string    `json:"vault,omitempty" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	SubsidizerPaused bool      `json:"subsidizerPaused"`
+	VaultRemoved     bool      `json:"vaultRemoved"`
+	Paused           bool      `json:"paused"`    // distributions are skipped
+	CheckedAt        time.Time `json:"checkedAt"` // time of the last successful check
+	LastError        string    `json:"lastError,omitempty"`
+}
@@ -0,0 +1,36 @@
+package deadletter
+
+import "context"
+
+//go:generate moq -out deadletter_mocks.go . Recorder Service
+
+// Recorder keeps work that failed for good as a dead letter
+type Recorder interface {
+	// Record stores the entry, stamping it with an ID and, when it has none, the time it failed
+	Record(ctx context.Context, entry Entry) error
+}
+
+// Service keeps webhook deliveries and transactions that failed for good, so an operator can inspect and
+// retry or discard them instead of finding them in the logs
+type Service interface {
+	Recorder
+
+	// List returns the entries of kind, every kind when it is empty, oldest failure first
+	List(ctx context.Context, kind string) ([]Entry, error)
+
+	// Get returns the entry, ErrNotFound when there is none
+	Get(ctx context.Context, id string) (*Entry, error)
+
+	// Retry hands the entry to the retrier of its kind and removes it once that succeeded. A failed retry is
+	// kept with its attempt counted and wraps ErrRetryFailed, ErrNotRetryable leaves the entry as it was.
+	Retry(ctx context.Context, id string) (*RetryResult, error)
+
+	// Discard removes the entry without retrying it
+	Discard(ctx context.Context, id string) error
+}
+
+// Retrier performs the work of a dead letter of one kind again and returns the outcome, wrapping
+// ErrNotRetryable when retrying it can no longer do what it was meant to
+type Retrier interface {
+	Retry(ctx context.Context, entry Entry) (string, error)
+}
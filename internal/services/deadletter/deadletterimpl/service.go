@@ -0,0 +1,218 @@
+package deadletterimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// audit actions of the operator's handling of dead letters
+const (
+	actionRetry   = "deadLetterRetry"
+	actionDiscard = "deadLetterDiscard"
+)
+
+type Service struct {
+	store    *Store
+	recorder audit.Recorder // nil disables audit entries
+	logger   lgr.L
+	now      func() time.Time
+
+	mu       sync.Mutex // serializes retries and discards, so an entry is never performed twice at once
+	retriers map[string]deadletter.Retrier
+}
+
+// New returns the dead letter service and moves the undelivered events the webhook dispatcher kept into it
+func New(db storage.DB, recorder audit.Recorder, logger lgr.L) *Service {
+	s := &Service{
+		store:    NewStore(db, logger),
+		recorder: recorder,
+		logger:   logger,
+		now:      time.Now,
+		retriers: make(map[string]deadletter.Retrier),
+	}
+	if moved, err := s.store.MigrateLegacyWebhooks(); err != nil {
+		s.logger.Logf("WARN %v", err)
+	} else if moved > 0 {
+		s.logger.Logf("INFO moved %d undelivered webhook events to dead letters", moved)
+	}
+	return s
+}
+
+// SetRetrier sets what retries the dead letters of kind. Entries of a kind without one cannot be retried.
+// It is called once at startup.
+func (s *Service) SetRetrier(kind string, retrier deadletter.Retrier) {
+	s.retriers[kind] = retrier
+}
+
+// Record stamps the entry with an ID, and with the current time when it has no failure time, and stores it
+func (s *Service) Record(ctx context.Context, entry deadletter.Entry) (err error) {
+	_, span := tracing.StartSpan(ctx, "deadletter.Record", attribute.String("deadletter.kind", entry.Kind))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if entry.Kind != deadletter.KindWebhook && entry.Kind != deadletter.KindTransaction {
+		return fmt.Errorf("%w: unknown kind %q", deadletter.ErrInvalidInput, entry.Kind)
+	}
+	if entry.Target == "" {
+		return fmt.Errorf("%w: target cannot be empty", deadletter.ErrInvalidInput)
+	}
+
+	entry.ID = newEntryID()
+	if entry.FailedAt.IsZero() {
+		entry.FailedAt = s.now()
+	}
+	entry.FailedAt = entry.FailedAt.UTC()
+	if err := s.store.SaveEntry(entry); err != nil {
+		s.logger.Logf("ERROR failed to record %s dead letter for %s: %v", entry.Kind, entry.Target, err)
+		return err
+	}
+
+	s.logger.Logf("WARN recorded %s dead letter %s for %s: %s", entry.Kind, entry.ID, entry.Target, entry.LastError)
+	return nil
+}
+
+// List returns the entries of kind, every kind when it is empty, oldest failure first
+func (s *Service) List(ctx context.Context, kind string) (_ []deadletter.Entry, err error) {
+	_, span := tracing.StartSpan(ctx, "deadletter.List")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if kind != "" && kind != deadletter.KindWebhook && kind != deadletter.KindTransaction {
+		return nil, fmt.Errorf("%w: unknown kind %q", deadletter.ErrInvalidInput, kind)
+	}
+
+	stored, err := s.store.ListEntries()
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]deadletter.Entry, 0, len(stored))
+	for _, entry := range stored {
+		if kind == "" || entry.Kind == kind {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].FailedAt.Before(entries[j].FailedAt) })
+	return entries, nil
+}
+
+// Get returns the entry, ErrNotFound when there is none
+func (s *Service) Get(ctx context.Context, id string) (_ *deadletter.Entry, err error) {
+	_, span := tracing.StartSpan(ctx, "deadletter.Get", attribute.String("deadletter.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	entry, err := s.store.GetEntry(id)
+	if err != nil {
+		return nil, err
+	}
+	if entry == nil {
+		return nil, fmt.Errorf("%w: %s", deadletter.ErrNotFound, id)
+	}
+	return entry, nil
+}
+
+// Retry hands the entry to the retrier of its kind and removes it once that succeeded. A failed retry counts an
+// attempt and keeps the entry with the error, an entry that cannot be retried is left as it was.
+func (s *Service) Retry(ctx context.Context, id string) (_ *deadletter.RetryResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "deadletter.Retry", attribute.String("deadletter.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	retrier, ok := s.retriers[entry.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: no retrier for %s dead letters", deadletter.ErrNotRetryable, entry.Kind)
+	}
+
+	outcome, err := retrier.Retry(ctx, *entry)
+	if err != nil {
+		parameters := map[string]string{"id": id, "kind": entry.Kind, "target": entry.Target}
+		if errors.Is(err, deadletter.ErrNotRetryable) {
+			s.record(ctx, actionRetry, parameters, err)
+			return nil, err
+		}
+
+		retriedAt := s.now().UTC()
+		entry.Attempts++
+		entry.LastError = err.Error()
+		entry.RetriedAt = &retriedAt
+		if saveErr := s.store.SaveEntry(*entry); saveErr != nil {
+			s.logger.Logf("ERROR failed to keep attempt at dead letter %s: %v", id, saveErr)
+		}
+		s.logger.Logf("WARN retry of %s dead letter %s for %s failed: %v", entry.Kind, id, entry.Target, err)
+		s.record(ctx, actionRetry, parameters, err)
+		return nil, fmt.Errorf("%w: %v", deadletter.ErrRetryFailed, err)
+	}
+
+	if _, err := s.store.DeleteEntry(id); err != nil {
+		// the work is done, an entry left behind would only be retried again
+		s.logger.Logf("ERROR failed to remove retried dead letter %s: %v", id, err)
+	}
+	s.logger.Logf("INFO retried %s dead letter %s for %s: %s", entry.Kind, id, entry.Target, outcome)
+	s.record(ctx, actionRetry, map[string]string{"id": id, "kind": entry.Kind, "target": entry.Target, "outcome": outcome}, nil)
+	return &deadletter.RetryResult{Entry: *entry, Outcome: outcome}, nil
+}
+
+// Discard removes the entry without retrying it
+func (s *Service) Discard(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "deadletter.Discard", attribute.String("deadletter.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, err := s.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := s.store.DeleteEntry(id); err != nil {
+		return err
+	}
+
+	s.logger.Logf("INFO %s dead letter %s for %s discarded by %s", entry.Kind, id, entry.Target, audit.ActorFromContext(ctx))
+	s.record(ctx, actionDiscard, map[string]string{"id": id, "kind": entry.Kind, "target": entry.Target}, nil)
+	return nil
+}
+
+// record writes the handling of a dead letter to the audit log. Failures are logged, the handling is already done.
+func (s *Service) record(ctx context.Context, action string, parameters map[string]string, actionErr error) {
+	if s.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action:     action,
+		Actor:      audit.ActorFromContext(ctx),
+		Parameters: parameters,
+		Result:     audit.ResultSuccess,
+	}
+	if actionErr != nil {
+		entry.Result = audit.ResultFailed
+		entry.Error = actionErr.Error()
+	}
+	if err := s.recorder.Record(ctx, entry); err != nil {
+		s.logger.Logf("WARN failed to record %s in audit log: %v", action, err)
+	}
+}
+
+func newEntryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
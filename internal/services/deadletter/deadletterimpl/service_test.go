@@ -0,0 +1,223 @@
+package deadletterimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+// retrierFunc adapts a function to deadletter.Retrier
+type retrierFunc func(ctx context.Context, entry deadletter.Entry) (string, error)
+
+func (f retrierFunc) Retry(ctx context.Context, entry deadletter.Entry) (string, error) {
+	return f(ctx, entry)
+}
+
+// snapshotStoreFunc adapts a function to SnapshotStore
+type snapshotStoreFunc func(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error)
+
+func (f snapshotStoreFunc) GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return f(ctx, vaultID)
+}
+
+func TestService_RecordListDiscard(t *testing.T) {
+	service := New(newTestDB(t), nil, lgr.NoOp)
+	ctx := context.Background()
+	base := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, service.Record(ctx, deadletter.Entry{
+		Kind: deadletter.KindTransaction, Target: "updateMerkleRoot", Payload: json.RawMessage(`{}`), FailedAt: base.Add(time.Minute),
+	}))
+	require.NoError(t, service.Record(ctx, deadletter.Entry{
+		Kind: deadletter.KindWebhook, Target: "https://hooks.example.com", Payload: json.RawMessage(`{}`), FailedAt: base,
+	}))
+	require.ErrorIs(t, service.Record(ctx, deadletter.Entry{Kind: "email", Target: "x"}), deadletter.ErrInvalidInput)
+
+	entries, err := service.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, deadletter.KindWebhook, entries[0].Kind, "oldest failure first")
+
+	transactions, err := service.List(ctx, deadletter.KindTransaction)
+	require.NoError(t, err)
+	require.Len(t, transactions, 1)
+	_, err = service.List(ctx, "email")
+	require.ErrorIs(t, err, deadletter.ErrInvalidInput)
+
+	entry, err := service.Get(ctx, transactions[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, "updateMerkleRoot", entry.Target)
+
+	require.NoError(t, service.Discard(ctx, entry.ID))
+	_, err = service.Get(ctx, entry.ID)
+	require.ErrorIs(t, err, deadletter.ErrNotFound)
+	require.ErrorIs(t, service.Discard(ctx, entry.ID), deadletter.ErrNotFound)
+}
+
+func TestService_Retry(t *testing.T) {
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	service := New(newTestDB(t), recorder, lgr.NoOp)
+	ctx := context.Background()
+
+	var retryErr error
+	service.SetRetrier(deadletter.KindWebhook, retrierFunc(func(ctx context.Context, entry deadletter.Entry) (string, error) {
+		if retryErr != nil {
+			return "", retryErr
+		}
+		return deadletter.OutcomeDelivered, nil
+	}))
+	require.NoError(t, service.Record(ctx, deadletter.Entry{
+		Kind: deadletter.KindWebhook, Target: "https://hooks.example.com", Payload: json.RawMessage(`{}`), Attempts: 4,
+	}))
+	entries, err := service.List(ctx, "")
+	require.NoError(t, err)
+	id := entries[0].ID
+
+	retryErr = errors.New("endpoint returned status 502")
+	_, err = service.Retry(ctx, id)
+	require.ErrorIs(t, err, deadletter.ErrRetryFailed)
+	entry, err := service.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 5, entry.Attempts, "a failed retry counts an attempt")
+	assert.Equal(t, "endpoint returned status 502", entry.LastError)
+	require.NotNil(t, entry.RetriedAt)
+
+	retryErr = deadletter.ErrNotRetryable
+	_, err = service.Retry(ctx, id)
+	require.ErrorIs(t, err, deadletter.ErrNotRetryable)
+	entry, err = service.Get(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, 5, entry.Attempts, "an entry that cannot be retried is left as it was")
+
+	retryErr = nil
+	result, err := service.Retry(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.OutcomeDelivered, result.Outcome)
+	_, err = service.Get(ctx, id)
+	require.ErrorIs(t, err, deadletter.ErrNotFound, "a retried entry is no longer kept")
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, audit.ResultFailed, calls[0].Entry.Result)
+	assert.Equal(t, audit.ResultSuccess, calls[2].Entry.Result)
+	assert.Equal(t, deadletter.OutcomeDelivered, calls[2].Entry.Parameters["outcome"])
+
+	require.NoError(t, service.Record(ctx, deadletter.Entry{
+		Kind: deadletter.KindTransaction, Target: "updateMerkleRoot", Payload: json.RawMessage(`{}`),
+	}))
+	entries, err = service.List(ctx, deadletter.KindTransaction)
+	require.NoError(t, err)
+	_, err = service.Retry(ctx, entries[0].ID)
+	require.ErrorIs(t, err, deadletter.ErrNotRetryable, "a kind without a retrier cannot be retried")
+}
+
+func TestService_MigratesLegacyWebhooks(t *testing.T) {
+	db := newTestDB(t)
+	failedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	legacy := `{"event":{"id":"abc","type":"epoch.started"},"url":"https://hooks.example.com","attempts":3,` +
+		`"lastError":"endpoint returned status 502","failedAt":"2026-01-02T03:04:05Z"}`
+	require.NoError(t, db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(legacyWebhookPrefix+"00000000000000000001:abc:0102030405060708"), []byte(legacy))
+	}))
+
+	service := New(db, nil, lgr.NoOp)
+	entries, err := service.List(context.Background(), deadletter.KindWebhook)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "https://hooks.example.com", entries[0].Target)
+	assert.Equal(t, 3, entries[0].Attempts)
+	assert.True(t, failedAt.Equal(entries[0].FailedAt))
+	assert.JSONEq(t, `{"id":"abc","type":"epoch.started"}`, string(entries[0].Payload))
+
+	New(db, nil, lgr.NoOp)
+	entries, err = service.List(context.Background(), "")
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "migrated letters are moved, not copied")
+}
+
+func TestTransactionRetrier_RootUpdate(t *testing.T) {
+	root := "00000000000000000000000000000000000000000000000000000000000000ab"
+	newer := "00000000000000000000000000000000000000000000000000000000000000cd"
+	payload, err := json.Marshal(deadletter.Transaction{
+		Action: "updateMerkleRoot", Status: "reverted",
+		Parameters: map[string]string{"vault": testVault, "merkleRoot": "0x" + root, "totalSubsidies": "13"},
+	})
+	require.NoError(t, err)
+	entry := deadletter.Entry{Kind: deadletter.KindTransaction, Target: "updateMerkleRoot", Payload: payload}
+
+	tests := []struct {
+		name            string
+		onChain         byte
+		latest          string
+		expectedOutcome string
+		expectedErr     error
+		expectSent      bool
+	}{
+		{name: "resent_while_latest", latest: root, expectedOutcome: deadletter.OutcomeConfirmed, expectSent: true},
+		{name: "already_on_chain", onChain: 0xab, latest: root, expectedOutcome: deadletter.OutcomeAlreadyApplied},
+		{name: "superseded", latest: newer, expectedErr: deadletter.ErrNotRetryable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &blockchain.BlockchainClientMock{
+				GetMerkleRootFunc: func(ctx context.Context, vaultId string) ([32]byte, error) {
+					var onChain [32]byte
+					onChain[31] = tt.onChain
+					return onChain, nil
+				},
+				UpdateMerkleRootAndWaitForConfirmationFunc: func(ctx context.Context, vaultId string, root [32]byte, totalSubsidies *big.Int) error {
+					return nil
+				},
+			}
+			snapshots := snapshotStoreFunc(func(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error) {
+				return &merkle.MerkleSnapshot{EpochNumber: big.NewInt(5), MerkleRoot: tt.latest}, nil
+			})
+
+			outcome, err := NewTransactionRetrier(client, snapshots, lgr.NoOp).Retry(context.Background(), entry)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.expectedOutcome, outcome)
+			}
+
+			sent := client.UpdateMerkleRootAndWaitForConfirmationCalls()
+			if !tt.expectSent {
+				assert.Empty(t, sent)
+				return
+			}
+			require.Len(t, sent, 1)
+			assert.Equal(t, testVault, sent[0].VaultId)
+			assert.Equal(t, byte(0xab), sent[0].Root[31])
+			assert.Equal(t, "13", sent[0].TotalSubsidies.String())
+		})
+	}
+}
@@ -0,0 +1,192 @@
+package deadletterimpl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	entryPrefix = "deadletter:entry:"
+
+	// legacyWebhookPrefix is where the webhook dispatcher kept undelivered events before they became dead letters
+	legacyWebhookPrefix = "webhook:deadletter:"
+)
+
+// Store handles storage of dead letters, each kept under its ID
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveEntry stores an entry, replacing the one with its ID
+func (s *Store) SaveEntry(entry deadletter.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal dead letter: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(entryPrefix+entry.ID), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save dead letter %s: %w", entry.ID, err)
+	}
+
+	return nil
+}
+
+// GetEntry returns the entry with the ID, nil when there is none
+func (s *Store) GetEntry(id string) (*deadletter.Entry, error) {
+	var entry *deadletter.Entry
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(entryPrefix + id))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			entry = &deadletter.Entry{}
+			return json.Unmarshal(val, entry)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dead letter %s: %w", id, err)
+	}
+
+	return entry, nil
+}
+
+// DeleteEntry removes the entry with the ID and reports whether there was one
+func (s *Store) DeleteEntry(id string) (bool, error) {
+	deleted := false
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(entryPrefix + id)
+		if _, err := txn.Get(key); errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		deleted = true
+		return txn.Delete(key)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete dead letter %s: %w", id, err)
+	}
+
+	return deleted, nil
+}
+
+// ListEntries returns every stored entry, in no particular order
+func (s *Store) ListEntries() ([]deadletter.Entry, error) {
+	entries := make([]deadletter.Entry, 0)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(entryPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var entry deadletter.Entry
+				if err := json.Unmarshal(val, &entry); err != nil {
+					return err
+				}
+				entries = append(entries, entry)
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode dead letter: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dead letters: %w", err)
+	}
+
+	return entries, nil
+}
+
+// legacyWebhookLetter is an undelivered event as the webhook dispatcher kept it
+type legacyWebhookLetter struct {
+	Event     json.RawMessage `json:"event"`
+	URL       string          `json:"url"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"lastError"`
+	FailedAt  time.Time       `json:"failedAt"`
+}
+
+// MigrateLegacyWebhooks moves the undelivered events the webhook dispatcher kept into dead letters and returns
+// how many it moved. Each keeps an ID derived from its old key, so a migration interrupted halfway moves the
+// rest when it runs again without duplicating any.
+func (s *Store) MigrateLegacyWebhooks() (int, error) {
+	moved := 0
+	err := s.db.Update(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(legacyWebhookPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		keys := make([][]byte, 0)
+		for it.Rewind(); it.Valid(); it.Next() {
+			key := it.Item().KeyCopy(nil)
+			var letter legacyWebhookLetter
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &letter)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode webhook dead letter %s: %w", key, err)
+			}
+
+			sum := sha256.Sum256(key)
+			entry := deadletter.Entry{
+				ID:        hex.EncodeToString(sum[:8]),
+				Kind:      deadletter.KindWebhook,
+				Target:    letter.URL,
+				Payload:   letter.Event,
+				Attempts:  letter.Attempts,
+				LastError: letter.LastError,
+				FailedAt:  letter.FailedAt,
+			}
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("failed to marshal dead letter: %w", err)
+			}
+			if err := txn.Set([]byte(entryPrefix+entry.ID), data); err != nil {
+				return err
+			}
+			keys = append(keys, key)
+		}
+		for _, key := range keys {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+		moved = len(keys)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to migrate webhook dead letters: %w", err)
+	}
+
+	return moved, nil
+}
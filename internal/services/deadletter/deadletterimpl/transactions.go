@@ -0,0 +1,108 @@
+package deadletterimpl
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/go-pkgz/lgr"
+)
+
+// SnapshotStore reads the merkle trees distributed for a vault
+type SnapshotStore interface {
+	// GetLatestSnapshot returns the tree of the vault's latest distributed epoch, wrapping merkle.ErrNotFound when there is none
+	GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error)
+}
+
+// TransactionRetrier sends a transaction that reverted or never confirmed again, once it checked the chain
+// still needs it
+type TransactionRetrier struct {
+	client    blockchain.BlockchainClient
+	snapshots SnapshotStore
+	logger    lgr.L
+}
+
+// NewTransactionRetrier creates a retrier sending transactions through client
+func NewTransactionRetrier(client blockchain.BlockchainClient, snapshots SnapshotStore, logger lgr.L) *TransactionRetrier {
+	return &TransactionRetrier{
+		client:    client,
+		snapshots: snapshots,
+		logger:    logger,
+	}
+}
+
+// Retry sends the transaction of the entry again. A root update is only sent while its root is the vault's
+// latest distributed one and not on-chain yet, so a retry never puts an older root over a newer one.
+func (r *TransactionRetrier) Retry(ctx context.Context, entry deadletter.Entry) (string, error) {
+	var tx deadletter.Transaction
+	if err := json.Unmarshal(entry.Payload, &tx); err != nil {
+		return "", fmt.Errorf("%w: malformed transaction: %v", deadletter.ErrNotRetryable, err)
+	}
+
+	switch tx.Action {
+	case "updateMerkleRoot":
+		return r.retryRootUpdate(ctx, tx.Parameters)
+	case "forceEndEpochWithZeroYield":
+		return r.retryForceEnd(ctx, tx.Parameters)
+	default:
+		return "", fmt.Errorf("%w: %s transactions are not resent", deadletter.ErrNotRetryable, tx.Action)
+	}
+}
+
+func (r *TransactionRetrier) retryRootUpdate(ctx context.Context, parameters map[string]string) (string, error) {
+	vaultID := parameters["vault"]
+	root := strings.ToLower(strings.TrimPrefix(parameters["merkleRoot"], "0x"))
+	rootBytes, err := hex.DecodeString(root)
+	if err != nil || len(rootBytes) != 32 || vaultID == "" {
+		return "", fmt.Errorf("%w: transaction names no vault and root", deadletter.ErrNotRetryable)
+	}
+	totalSubsidies, ok := new(big.Int).SetString(parameters["totalSubsidies"], 10)
+	if !ok {
+		return "", fmt.Errorf("%w: transaction names no total subsidies", deadletter.ErrNotRetryable)
+	}
+
+	onChain, err := r.client.GetMerkleRoot(ctx, vaultID)
+	if err != nil {
+		return "", fmt.Errorf("failed to read merkle root of vault %s: %w", vaultID, err)
+	}
+	if hex.EncodeToString(onChain[:]) == root {
+		return deadletter.OutcomeAlreadyApplied, nil
+	}
+
+	latest, err := r.snapshots.GetLatestSnapshot(ctx, vaultID)
+	if errors.Is(err, merkle.ErrNotFound) {
+		return "", fmt.Errorf("%w: vault %s has no distributed tree", deadletter.ErrNotRetryable, vaultID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read latest tree of vault %s: %w", vaultID, err)
+	}
+	if !strings.EqualFold(strings.TrimPrefix(latest.MerkleRoot, "0x"), root) {
+		return "", fmt.Errorf("%w: root 0x%s was superseded by 0x%s of epoch %s",
+			deadletter.ErrNotRetryable, root, strings.TrimPrefix(latest.MerkleRoot, "0x"), latest.EpochNumber)
+	}
+
+	if err := r.client.UpdateMerkleRootAndWaitForConfirmation(ctx, vaultID, [32]byte(rootBytes), totalSubsidies); err != nil {
+		return "", err
+	}
+	r.logger.Logf("INFO resent merkle root 0x%s of vault %s", root, vaultID)
+	return deadletter.OutcomeConfirmed, nil
+}
+
+func (r *TransactionRetrier) retryForceEnd(ctx context.Context, parameters map[string]string) (string, error) {
+	epochID, ok := new(big.Int).SetString(parameters["epochId"], 10)
+	if !ok || parameters["vault"] == "" {
+		return "", fmt.Errorf("%w: transaction names no epoch and vault", deadletter.ErrNotRetryable)
+	}
+	if err := r.client.ForceEndEpochWithZeroYield(ctx, epochID, parameters["vault"]); err != nil {
+		return "", err
+	}
+	r.logger.Logf("INFO resent force end of epoch %s of vault %s", epochID, parameters["vault"])
+	return deadletter.OutcomeResent, nil
+}
@@ -0,0 +1,11 @@
+package deadletter
+
+import "errors"
+
+// Predefined error types for dead letter operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+	ErrNotFound     = errors.New("dead letter not found")
+	ErrNotRetryable = errors.New("dead letter cannot be retried")
+	ErrRetryFailed  = errors.New("dead letter retry failed")
+)
@@ -0,0 +1,49 @@
+package deadletter
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// kinds of work kept as dead letters
+const (
+	KindWebhook     = "webhook"     // an event an endpoint did not accept after all retries
+	KindTransaction = "transaction" // a transaction sent without waiting that reverted or never confirmed
+)
+
+// outcomes of a successful retry
+const (
+	OutcomeDelivered      = "delivered"       // the webhook endpoint accepted the event
+	OutcomeResent         = "resent"          // the transaction was sent again and is watched like the first one
+	OutcomeConfirmed      = "confirmed"       // the transaction was sent again and confirmed
+	OutcomeAlreadyApplied = "already_applied" // the chain already holds what the transaction set, nothing was sent
+)
+
+// Entry is work that failed for good, with what is needed to perform it again
+type Entry struct {
+	ID   string `json:"id" example:"9f86d081884c7d65"`
+	Kind string `json:"kind" example:"webhook" enums:"webhook,transaction"`
+	// Target is the endpoint URL of a webhook and the contract function of a transaction
+	Target string `json:"target" example:"https://hooks.example.com/epoch"`
+	// Payload is the webhook.Event of a webhook and the Transaction of a transaction
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	Attempts  int             `json:"attempts" example:"4"`
+	LastError string          `json:"lastError" example:"endpoint returned status 502"`
+	FailedAt  time.Time       `json:"failedAt"`
+	RetriedAt *time.Time      `json:"retriedAt,omitempty"` // when an operator last retried it
+}
+
+// Transaction is the payload of a transaction dead letter
+type Transaction struct {
+	Action       string            `json:"action" example:"updateMerkleRoot"`
+	TxHash       string            `json:"txHash"`
+	Status       string            `json:"status" example:"reverted" enums:"reverted,unconfirmed"`
+	RevertReason string            `json:"revertReason,omitempty"`
+	Parameters   map[string]string `json:"parameters"`
+}
+
+// RetryResult is a dead letter retried successfully, it is no longer kept
+type RetryResult struct {
+	Entry   Entry  `json:"entry"`
+	Outcome string `json:"outcome" example:"delivered" enums:"delivered,resent,confirmed,already_applied"`
+}
@@ -20,6 +20,29 @@ type Service interface {
 	// GetCurrentEpochId gets the current epoch ID from the blockchain
 	GetCurrentEpochId(ctx context.Context) (uint64, error)
 
+	// GetEpochTiming reads when the current epoch started and ends from the EpochManager, and whether it ended by
+	// the chain's clock
+	GetEpochTiming(ctx context.Context) (*EpochTiming, error)
+
+	// GetOnChainState reads the vault's current epoch, yield and subsidy state from the contracts
+	GetOnChainState(ctx context.Context, vaultId string) (*OnChainStateResponse, error)
+
+	// ListEpochs returns a page of the epochs known to the subgraph, newest first unless query.Ascending is set
+	ListEpochs(ctx context.Context, query ListEpochsQuery) (*ListEpochsResponse, error)
+
+	// ListCollections returns the collections participating in a vault
+	ListCollections(ctx context.Context, vaultId string) (*ListCollectionsResponse, error)
+
+	// GetUserAllocations returns a user's per-collection subsidy accruals in a vault
+	GetUserAllocations(ctx context.Context, userAddress, vaultId string) (*UserAllocationsResponse, error)
+
+	// AllocateYield allocates the vault's remaining cumulative yield to the current epoch, holding back the
+	// configured reserve
+	AllocateYield(ctx context.Context, vaultId string) (*YieldAllocation, error)
+
+	// GetTimeline returns the milestones of the vault's epoch processing in order, with the time between them
+	GetTimeline(ctx context.Context, epochId uint64, vaultId string) (*Timeline, error)
+
 	// CompleteEpochAfterDistribution completes an epoch after successful subsidy distribution
 	CompleteEpochAfterDistribution(ctx context.Context, epochId uint64, vaultId string) (*CompleteEpochResponse, error)
 }
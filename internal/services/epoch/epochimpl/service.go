@@ -2,71 +2,98 @@ package epochimpl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strconv"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
 	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/assets"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
+	store          *Store
 	contractClient epoch.ContractClient
 	subgraphClient epoch.SubgraphClient
 	calculator     epoch.Calculator
+	events         events.Publisher
+	snapshots      epoch.SnapshotStore // nil leaves distribution fingerprints out of epoch listings
+	auditLog       epoch.AuditLog      // nil leaves root transactions out of epoch timelines
+	assets         assets.Service      // nil logs amounts in wei only
+	epochStarts    epochStarts
 	logger         lgr.L
 	config         *config.Config
 }
 
-func New(contractClient epoch.ContractClient, subgraphClient epoch.SubgraphClient, calculator epoch.Calculator, logger lgr.L, cfg *config.Config) *Service {
+func New(db storage.DB, contractClient epoch.ContractClient, subgraphClient epoch.SubgraphClient, calculator epoch.Calculator, publisher events.Publisher, logger lgr.L, cfg *config.Config) *Service {
 	return &Service{
+		store:          NewStore(db, logger),
 		contractClient: contractClient,
 		subgraphClient: subgraphClient,
 		calculator:     calculator,
+		events:         publisher,
 		logger:         logger,
 		config:         cfg,
 	}
 }
 
-func (s *Service) StartEpoch(ctx context.Context) (*epoch.StartEpochResponse, error) {
+func (s *Service) StartEpoch(ctx context.Context) (_ *epoch.StartEpochResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.StartEpoch")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	logger := logging.FromContext(ctx, s.logger)
+
 	currentEpochId, err := s.contractClient.GetCurrentEpochId(ctx)
 	if err != nil {
-		s.logger.Logf("ERROR failed to get current epoch ID: %v", err)
+		logger.Logf("ERROR failed to get current epoch ID: %v", err)
 		return nil, fmt.Errorf("failed to get current epoch ID: %w", err)
 	}
 
 	if currentEpochId.Cmp(big.NewInt(0)) > 0 {
-		s.logger.Logf("INFO current epoch ID is %s, attempting to start new epoch", currentEpochId.String())
+		logger.Logf("INFO current epoch ID is %s, attempting to start new epoch", currentEpochId.String())
 	}
 
 	accounts, err := s.subgraphClient.QueryAccounts(ctx)
 	if err != nil {
-		s.logger.Logf("ERROR failed to query accounts: %v", err)
+		logger.Logf("ERROR failed to query accounts: %v", err)
 		return nil, fmt.Errorf("failed to query accounts: %w", err)
 	}
 
-	s.logger.Logf("INFO found %d accounts for starting new epoch", len(accounts))
+	logger.Logf("INFO found %d accounts for starting new epoch", len(accounts))
 
 	if err := s.contractClient.StartEpoch(ctx); err != nil {
-		s.logger.Logf("ERROR blockchain transaction failed for startEpoch: %v", err)
+		logger.Logf("ERROR blockchain transaction failed for startEpoch: %v", err)
 		if isEpochStillActiveError(err) {
 			return nil, fmt.Errorf("%w: cannot start new epoch - current epoch %s is still active and must be completed first", epoch.ErrTransactionFailed, currentEpochId.String())
 		}
 		return nil, fmt.Errorf("%w: failed to start epoch: %v", epoch.ErrTransactionFailed, err)
 	}
 
-	s.logger.Logf("INFO successfully initiated epoch start")
+	logger.Logf("INFO successfully initiated epoch start")
 
 	newEpochId, err := s.contractClient.GetCurrentEpochId(ctx)
 	if err != nil {
-		s.logger.Logf("WARN failed to get new epoch ID: %v", err)
+		logger.Logf("WARN failed to get new epoch ID: %v", err)
 		newEpochId = big.NewInt(0)
 	}
 
+	s.events.Publish(ctx, events.Event{
+		Type:    events.EpochStarted,
+		EpochID: newEpochId.String(),
+		Vault:   s.config.Contracts.CollectionsVault,
+	})
+
 	return &epoch.StartEpochResponse{
 		EpochID:      newEpochId.String(),
 		VaultAddress: s.config.Contracts.CollectionsVault,
@@ -76,20 +103,27 @@ func (s *Service) StartEpoch(ctx context.Context) (*epoch.StartEpochResponse, er
 	}, nil
 }
 
-func (s *Service) ForceEndEpoch(ctx context.Context, epochId uint64, vaultId string) (*epoch.ForceEndEpochResponse, error) {
+func (s *Service) ForceEndEpoch(ctx context.Context, epochId uint64, vaultId string) (_ *epoch.ForceEndEpochResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.ForceEndEpoch",
+		attribute.Int64("epoch.id", int64(epochId)), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx = logging.WithFields(ctx, logging.Fields{EpochID: strconv.FormatUint(epochId, 10), Vault: vaultId})
+	logger := logging.FromContext(ctx, s.logger)
+
 	if vaultId == "" {
 		return nil, fmt.Errorf("%w: vaultId cannot be empty", epoch.ErrInvalidInput)
 	}
 
-	s.logger.Logf("INFO force ending epoch %d for vault %s", epochId, vaultId)
+	logger.Logf("INFO force ending epoch %d for vault %s", epochId, vaultId)
 
 	currentEpochId, err := s.contractClient.GetCurrentEpochId(ctx)
 	if err != nil {
-		s.logger.Logf("WARN failed to get current epoch ID, proceeding anyway: %v", err)
+		logger.Logf("WARN failed to get current epoch ID, proceeding anyway: %v", err)
 	} else {
 		currentEpochInt := currentEpochId.Uint64()
 		if epochId < currentEpochInt {
-			s.logger.Logf("INFO epoch %d is already past (current: %d), considering it completed", epochId, currentEpochInt)
+			logger.Logf("INFO epoch %d is already past (current: %d), considering it completed", epochId, currentEpochInt)
 			return &epoch.ForceEndEpochResponse{
 				EpochID:          fmt.Sprintf("%d", epochId),
 				VaultAddress:     vaultId,
@@ -110,17 +144,22 @@ func (s *Service) ForceEndEpoch(ctx context.Context, epochId uint64, vaultId str
 	}
 	epochIdBig := big.NewInt(int64(epochId))
 
-	s.logger.Logf("INFO calling ForceEndEpochWithZeroYield for epoch %d", epochId)
+	logger.Logf("INFO calling ForceEndEpochWithZeroYield for epoch %d", epochId)
 
 	if err := s.contractClient.ForceEndEpochWithZeroYield(ctx, epochIdBig, vaultId); err != nil {
-		s.logger.Logf("ERROR ForceEndEpochWithZeroYield failed for epoch %d: %v", epochId, err)
+		logger.Logf("ERROR ForceEndEpochWithZeroYield failed for epoch %d: %v", epochId, err)
 		if isTransactionError(err) {
 			return nil, fmt.Errorf("%w: failed to force end epoch %d for vault %s: %v", epoch.ErrTransactionFailed, epochId, vaultId, err)
 		}
 		return nil, fmt.Errorf("failed to force end epoch %d for vault %s: %w", epochId, vaultId, err)
 	}
 
-	s.logger.Logf("INFO successfully force ended epoch %d for vault %s with zero yield", epochId, vaultId)
+	logger.Logf("INFO successfully force ended epoch %d for vault %s with zero yield", epochId, vaultId)
+	s.events.Publish(ctx, events.Event{
+		Type:    events.EpochForceEnded,
+		EpochID: fmt.Sprintf("%d", epochId),
+		Vault:   vaultId,
+	})
 
 	return &epoch.ForceEndEpochResponse{
 		EpochID:          fmt.Sprintf("%d", epochId),
@@ -132,7 +171,10 @@ func (s *Service) ForceEndEpoch(ctx context.Context, epochId uint64, vaultId str
 	}, nil
 }
 
-func (s *Service) GetUserTotalEarned(ctx context.Context, userAddress, vaultId string) (*epoch.UserEarningsResponse, error) {
+func (s *Service) GetUserTotalEarned(ctx context.Context, userAddress, vaultId string) (_ *epoch.UserEarningsResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetUserTotalEarned", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if userAddress == "" {
 		return nil, fmt.Errorf("%w: userAddress cannot be empty", epoch.ErrInvalidInput)
 	}
@@ -269,7 +311,10 @@ func (s *Service) GetUserTotalEarned(ctx context.Context, userAddress, vaultId s
 	return response_data, nil
 }
 
-func (s *Service) GetCurrentEpochId(ctx context.Context) (uint64, error) {
+func (s *Service) GetCurrentEpochId(ctx context.Context) (_ uint64, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetCurrentEpochId")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	epochIdBig, err := s.contractClient.GetCurrentEpochId(ctx)
 	if err != nil {
 		s.logger.Logf("ERROR failed to get current epoch ID from blockchain: %v", err)
@@ -281,7 +326,303 @@ func (s *Service) GetCurrentEpochId(ctx context.Context) (uint64, error) {
 	return epochId, nil
 }
 
-func (s *Service) CompleteEpochAfterDistribution(ctx context.Context, epochId uint64, vaultId string) (*epoch.CompleteEpochResponse, error) {
+// maxEpochSkip is the largest skip the graph node accepts, so the deepest offset epochs can be paged to
+const maxEpochSkip = 5000
+
+func (s *Service) ListEpochs(ctx context.Context, query epoch.ListEpochsQuery) (_ *epoch.ListEpochsResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.ListEpochs",
+		attribute.Int("epoch.limit", query.Limit), attribute.Int("epoch.offset", query.Offset))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if query.Limit <= 0 || query.Limit > 1000 {
+		return nil, fmt.Errorf("%w: limit must be between 1 and 1000", epoch.ErrInvalidInput)
+	}
+	if query.Offset < 0 || query.Offset > maxEpochSkip {
+		return nil, fmt.Errorf("%w: offset must be between 0 and %d", epoch.ErrInvalidInput, maxEpochSkip)
+	}
+	direction := "desc"
+	if query.Ascending {
+		direction = "asc"
+	}
+
+	// epochs are numbered from 1, so the latest epoch's number is how many there are
+	graphQuery := `
+		query ListEpochs($first: Int!, $skip: Int!, $direction: OrderDirection!) {
+			epoches(
+				orderBy: epochNumber
+				orderDirection: $direction
+				first: $first
+				skip: $skip
+			) {
+				epochNumber
+				status
+				startTimestamp
+				endTimestamp
+				processingCompletedTimestamp
+				totalSubsidiesDistributed
+				totalYieldDistributed
+			}
+			latest: epoches(orderBy: epochNumber, orderDirection: desc, first: 1) {
+				epochNumber
+			}
+		}
+	`
+
+	var response struct {
+		Epoches []epoch.EpochSummary `json:"epoches"`
+		Latest  []epoch.EpochSummary `json:"latest"`
+	}
+
+	if err := s.subgraphClient.ExecuteQuery(ctx, subgraph.GraphQLRequest{
+		Query:     graphQuery,
+		Variables: map[string]interface{}{"first": query.Limit, "skip": query.Offset, "direction": direction},
+	}, &response); err != nil {
+		s.logger.Logf("ERROR failed to list epochs: %v", err)
+		return nil, fmt.Errorf("failed to list epochs: %w", err)
+	}
+
+	total := 0
+	if len(response.Latest) > 0 {
+		if latest, err := strconv.Atoi(response.Latest[0].EpochNumber); err == nil {
+			total = latest
+		}
+	}
+
+	s.addYieldAllocations(response.Epoches)
+	s.addDistributions(ctx, response.Epoches)
+
+	return &epoch.ListEpochsResponse{
+		Epochs: response.Epoches,
+		Count:  len(response.Epoches),
+		Total:  total,
+	}, nil
+}
+
+// SetSnapshots sets where the merkle snapshots of distributed epochs are read from, so epoch listings include
+// their distribution fingerprints and eligibility CIDs. It is called once at startup.
+func (s *Service) SetSnapshots(snapshots epoch.SnapshotStore) {
+	s.snapshots = snapshots
+}
+
+// SetAssets sets where vault assets are resolved from, so logged amounts include whole tokens. It is called once
+// at startup.
+func (s *Service) SetAssets(service assets.Service) {
+	s.assets = service
+}
+
+// addDistributions fills in the fingerprint and published eligibility CID of each distributed epoch of the
+// configured vault
+func (s *Service) addDistributions(ctx context.Context, epochs []epoch.EpochSummary) {
+	if s.snapshots == nil {
+		return
+	}
+	vaultId := s.config.Contracts.CollectionsVault
+	for i := range epochs {
+		epochNumber, ok := new(big.Int).SetString(epochs[i].EpochNumber, 10)
+		if !ok {
+			continue
+		}
+		snapshot, err := s.snapshots.GetSnapshot(ctx, epochNumber, vaultId)
+		if err != nil {
+			if !errors.Is(err, merkle.ErrNotFound) {
+				s.logger.Logf("WARN failed to get merkle snapshot for epoch %s: %v", epochs[i].EpochNumber, err)
+			}
+			continue
+		}
+		epochs[i].DistributionFingerprint = snapshot.Fingerprint
+		epochs[i].EligibilityCID = snapshot.EligibilityCID
+	}
+}
+
+func (s *Service) GetOnChainState(ctx context.Context, vaultId string) (_ *epoch.OnChainStateResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetOnChainState", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", epoch.ErrInvalidInput, vaultId)
+	}
+	vaultId = utils.NormalizeAddress(vaultId)
+
+	state, err := s.contractClient.GetOnChainEpochState(ctx, vaultId)
+	if err != nil {
+		s.logger.Logf("ERROR failed to read on-chain epoch state of vault %s: %v", vaultId, err)
+		return nil, fmt.Errorf("failed to read on-chain epoch state: %w", err)
+	}
+
+	return &epoch.OnChainStateResponse{
+		VaultAddress:          vaultId,
+		EpochManager:          s.config.Contracts.EpochManager,
+		DebtSubsidizer:        s.config.Contracts.DebtSubsidizer,
+		BlockNumber:           state.Block.Number,
+		BlockHash:             state.Block.Hash,
+		BlockTimestamp:        state.Block.Timestamp,
+		CurrentEpochID:        state.CurrentEpochID.String(),
+		VaultYieldForEpoch:    state.VaultYieldForEpoch.String(),
+		TotalYieldAllocated:   state.TotalYieldAllocated.String(),
+		TotalYieldReserved:    state.TotalYieldReserved.String(),
+		MerkleRoot:            fmt.Sprintf("0x%x", state.MerkleRoot),
+		TotalSubsidies:        state.TotalSubsidies.String(),
+		TotalSubsidiesClaimed: state.TotalSubsidiesClaimed.String(),
+		RemainingSubsidies:    state.RemainingSubsidies.String(),
+		Timing:                s.onChainTiming(ctx),
+	}, nil
+}
+
+// onChainTiming is the current epoch's timing for GetOnChainState, nil when it cannot be read
+func (s *Service) onChainTiming(ctx context.Context) *epoch.EpochTiming {
+	timing, err := s.GetEpochTiming(ctx)
+	if err != nil {
+		s.logger.Logf("WARN failed to read current epoch timing: %v", err)
+		return nil
+	}
+	return timing
+}
+
+func (s *Service) ListCollections(ctx context.Context, vaultId string) (_ *epoch.ListCollectionsResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.ListCollections", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", epoch.ErrInvalidInput)
+	}
+	vaultId = utils.NormalizeAddress(vaultId)
+
+	query := `
+		query ListCollections($vaultId: String!) {
+			collectionParticipations(
+				where: { vault: $vaultId }
+				first: 1000
+			) {
+				collection {
+					id
+					contractAddress
+					name
+					symbol
+					collectionType
+					isActive
+					yieldSharePercentage
+					totalNFTsDeposited
+				}
+			}
+		}
+	`
+
+	var response struct {
+		CollectionParticipations []struct {
+			Collection epoch.CollectionSummary `json:"collection"`
+		} `json:"collectionParticipations"`
+	}
+
+	if err := s.subgraphClient.ExecuteQuery(ctx, subgraph.GraphQLRequest{
+		Query:     query,
+		Variables: map[string]interface{}{"vaultId": vaultId},
+	}, &response); err != nil {
+		s.logger.Logf("ERROR failed to list collections for vault %s: %v", vaultId, err)
+		return nil, fmt.Errorf("failed to list collections: %w", err)
+	}
+
+	collections := make([]epoch.CollectionSummary, 0, len(response.CollectionParticipations))
+	for _, participation := range response.CollectionParticipations {
+		collections = append(collections, participation.Collection)
+	}
+
+	return &epoch.ListCollectionsResponse{
+		VaultAddress: vaultId,
+		Collections:  collections,
+		Count:        len(collections),
+	}, nil
+}
+
+func (s *Service) GetUserAllocations(ctx context.Context, userAddress, vaultId string) (_ *epoch.UserAllocationsResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetUserAllocations", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if userAddress == "" {
+		return nil, fmt.Errorf("%w: userAddress cannot be empty", epoch.ErrInvalidInput)
+	}
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", epoch.ErrInvalidInput)
+	}
+	userAddress = utils.NormalizeAddress(userAddress)
+	vaultId = utils.NormalizeAddress(vaultId)
+
+	query := `
+		query GetUserAllocations($account: String!, $vaultId: String!) {
+			accountSubsidies(
+				where: {
+					account: $account
+					collectionParticipation_: { vault: $vaultId }
+				}
+				first: 1000
+			) {
+				secondsAccumulated
+				secondsClaimed
+				subsidiesAccrued
+				subsidiesClaimed
+				totalRewardsEarned
+				updatedAtTimestamp
+				collectionParticipation {
+					collection {
+						id
+					}
+				}
+			}
+		}
+	`
+
+	var response struct {
+		AccountSubsidies []struct {
+			SecondsAccumulated      string `json:"secondsAccumulated"`
+			SecondsClaimed          string `json:"secondsClaimed"`
+			SubsidiesAccrued        string `json:"subsidiesAccrued"`
+			SubsidiesClaimed        string `json:"subsidiesClaimed"`
+			TotalRewardsEarned      string `json:"totalRewardsEarned"`
+			UpdatedAtTimestamp      string `json:"updatedAtTimestamp"`
+			CollectionParticipation struct {
+				Collection struct {
+					ID string `json:"id"`
+				} `json:"collection"`
+			} `json:"collectionParticipation"`
+		} `json:"accountSubsidies"`
+	}
+
+	if err := s.subgraphClient.ExecuteQuery(ctx, subgraph.GraphQLRequest{
+		Query:     query,
+		Variables: map[string]interface{}{"account": userAddress, "vaultId": vaultId},
+	}, &response); err != nil {
+		s.logger.Logf("ERROR failed to query allocations for user %s: %v", userAddress, err)
+		return nil, fmt.Errorf("failed to query user allocations: %w", err)
+	}
+
+	allocations := make([]epoch.UserAllocation, 0, len(response.AccountSubsidies))
+	for _, subsidy := range response.AccountSubsidies {
+		allocations = append(allocations, epoch.UserAllocation{
+			CollectionAddress:  subsidy.CollectionParticipation.Collection.ID,
+			SecondsAccumulated: subsidy.SecondsAccumulated,
+			SecondsClaimed:     subsidy.SecondsClaimed,
+			SubsidiesAccrued:   subsidy.SubsidiesAccrued,
+			SubsidiesClaimed:   subsidy.SubsidiesClaimed,
+			TotalRewardsEarned: subsidy.TotalRewardsEarned,
+			UpdatedAtTimestamp: subsidy.UpdatedAtTimestamp,
+		})
+	}
+
+	return &epoch.UserAllocationsResponse{
+		UserAddress:  userAddress,
+		VaultAddress: vaultId,
+		Allocations:  allocations,
+		Count:        len(allocations),
+	}, nil
+}
+
+func (s *Service) CompleteEpochAfterDistribution(ctx context.Context, epochId uint64, vaultId string) (_ *epoch.CompleteEpochResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.CompleteEpochAfterDistribution",
+		attribute.Int64("epoch.id", int64(epochId)), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx = logging.WithFields(ctx, logging.Fields{EpochID: strconv.FormatUint(epochId, 10), Vault: vaultId})
+	logger := logging.FromContext(ctx, s.logger)
+
 	if vaultId == "" {
 		return nil, fmt.Errorf("%w: vaultId cannot be empty", epoch.ErrInvalidInput)
 	}
@@ -289,24 +630,29 @@ func (s *Service) CompleteEpochAfterDistribution(ctx context.Context, epochId ui
 		return nil, fmt.Errorf("%w: epochId cannot be zero", epoch.ErrInvalidInput)
 	}
 
-	s.logger.Logf("INFO completing epoch %d after distribution for vault %s", epochId, vaultId)
+	logger.Logf("INFO completing epoch %d after distribution for vault %s", epochId, vaultId)
 
 	epochIdBig := big.NewInt(int64(epochId))
 
-	s.logger.Logf("INFO completing epoch %s for vault %s", epochIdBig.String(), vaultId)
+	logger.Logf("INFO completing epoch %s for vault %s", epochIdBig.String(), vaultId)
 
 	var dummyMerkleRoot [32]byte
 	zeroSubsidies := big.NewInt(0)
 
 	if err := s.contractClient.EndEpochWithSubsidies(ctx, epochIdBig, vaultId, dummyMerkleRoot, zeroSubsidies); err != nil {
-		s.logger.Logf("ERROR EndEpochWithSubsidies failed for epoch %s: %v", epochIdBig.String(), err)
+		logger.Logf("ERROR EndEpochWithSubsidies failed for epoch %s: %v", epochIdBig.String(), err)
 		if isTransactionError(err) {
 			return nil, fmt.Errorf("%w: failed to complete epoch %s for vault %s: %v", epoch.ErrTransactionFailed, epochIdBig.String(), vaultId, err)
 		}
 		return nil, fmt.Errorf("failed to complete epoch %s for vault %s: %w", epochIdBig.String(), vaultId, err)
 	}
 
-	s.logger.Logf("INFO successfully completed epoch %s for vault %s", epochIdBig.String(), vaultId)
+	logger.Logf("INFO successfully completed epoch %s for vault %s", epochIdBig.String(), vaultId)
+	s.events.Publish(ctx, events.Event{
+		Type:    events.EpochFinalized,
+		EpochID: epochIdBig.String(),
+		Vault:   vaultId,
+	})
 
 	return &epoch.CompleteEpochResponse{
 		EpochID:          epochIdBig.String(),
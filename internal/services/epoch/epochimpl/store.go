@@ -8,6 +8,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/epoch"
 	"github.com/dgraph-io/badger/v4"
@@ -16,12 +17,12 @@ import (
 
 // Store handles storage operations for epoch service
 type Store struct {
-	db     *badger.DB
+	db     storage.DB
 	logger lgr.L
 }
 
 // NewStore creates a new store instance
-func NewStore(db *badger.DB, logger lgr.L) *Store {
+func NewStore(db storage.DB, logger lgr.L) *Store {
 	return &Store{
 		db:     db,
 		logger: logger,
@@ -41,7 +42,7 @@ func (s *Store) SaveEpoch(ctx context.Context, epoch epoch.EpochInfo) error {
 		return fmt.Errorf("failed to marshal epoch: %w", err)
 	}
 
-	err = s.db.Update(func(txn *badger.Txn) error {
+	err = s.db.Update(func(txn storage.Txn) error {
 		return txn.Set([]byte(key), data)
 	})
 	if err != nil {
@@ -50,7 +51,7 @@ func (s *Store) SaveEpoch(ctx context.Context, epoch epoch.EpochInfo) error {
 
 	// Update current epoch pointer if this is the latest
 	currentKey := s.buildCurrentKey(epoch.VaultID)
-	err = s.db.Update(func(txn *badger.Txn) error {
+	err = s.db.Update(func(txn storage.Txn) error {
 		return txn.Set([]byte(currentKey), []byte(epoch.Number.String()))
 	})
 	if err != nil {
@@ -67,7 +68,7 @@ func (s *Store) GetEpoch(ctx context.Context, epochNumber *big.Int, vaultID stri
 	key := s.buildEpochKey(epochNumber, vaultID)
 
 	var epochInfo epoch.EpochInfo
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
@@ -80,7 +81,7 @@ func (s *Store) GetEpoch(ctx context.Context, epochNumber *big.Int, vaultID stri
 
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("epoch not found for vault %s, epoch %s", vaultID, epochNumber.String())
+			return nil, fmt.Errorf("%w: epoch not found for vault %s, epoch %s", epoch.ErrNotFound, vaultID, epochNumber.String())
 		}
 		return nil, fmt.Errorf("failed to get epoch: %w", err)
 	}
@@ -93,7 +94,7 @@ func (s *Store) GetCurrentEpoch(ctx context.Context, vaultID string) (*epoch.Epo
 	currentKey := s.buildCurrentKey(vaultID)
 
 	var currentEpochStr string
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		item, err := txn.Get([]byte(currentKey))
 		if err != nil {
 			return err
@@ -107,7 +108,7 @@ func (s *Store) GetCurrentEpoch(ctx context.Context, vaultID string) (*epoch.Epo
 
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("no current epoch found for vault %s", vaultID)
+			return nil, fmt.Errorf("%w: no current epoch found for vault %s", epoch.ErrEpochNotActive, vaultID)
 		}
 		return nil, fmt.Errorf("failed to get current epoch pointer: %w", err)
 	}
@@ -125,7 +126,7 @@ func (s *Store) ListEpochs(ctx context.Context, vaultID string, limit int) ([]ep
 	prefix := s.buildVaultPrefix(vaultID)
 	var epochs []epoch.EpochInfo
 
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Reverse = true // Get latest first
 
@@ -185,12 +186,127 @@ func (s *Store) UpdateEpochStatus(ctx context.Context, epochNumber *big.Int, vau
 	return s.SaveEpoch(ctx, *epoch)
 }
 
+// MarkEpochStatus sets the status of an epoch, recording the epoch first when it was never saved
+func (s *Store) MarkEpochStatus(ctx context.Context, epochNumber *big.Int, vaultID string, status string) error {
+	info, err := s.GetEpoch(ctx, epochNumber, vaultID)
+	if err != nil {
+		info = &epoch.EpochInfo{Number: epochNumber, VaultID: vaultID}
+	}
+	info.Status = status
+	return s.SaveEpoch(ctx, *info)
+}
+
+// SaveYieldAllocation records the yield allocated to the allocation's epoch
+func (s *Store) SaveYieldAllocation(allocation epoch.YieldAllocation) error {
+	data, err := json.Marshal(allocation)
+	if err != nil {
+		return fmt.Errorf("failed to marshal yield allocation: %w", err)
+	}
+
+	key := []byte(s.buildYieldKey(allocation.VaultAddress, allocation.EpochID))
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set(key, data)
+	}); err != nil {
+		return fmt.Errorf("failed to save yield allocation: %w", err)
+	}
+
+	return nil
+}
+
+// GetYieldAllocation returns the yield allocated to the vault's epoch, nil when none was recorded
+func (s *Store) GetYieldAllocation(vaultID, epochID string) (*epoch.YieldAllocation, error) {
+	var allocation *epoch.YieldAllocation
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildYieldKey(vaultID, epochID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			allocation = &epoch.YieldAllocation{}
+			return json.Unmarshal(val, allocation)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get yield allocation: %w", err)
+	}
+
+	return allocation, nil
+}
+
+// GetYieldSourceAllocated returns the wei allocated to the vault's epochs from the yield source so far
+func (s *Store) GetYieldSourceAllocated(vaultID, source string) (*big.Int, error) {
+	allocated := big.NewInt(0)
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildYieldSourceKey(vaultID, source)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			if _, ok := allocated.SetString(string(val), 10); !ok {
+				return fmt.Errorf("invalid amount %q", val)
+			}
+			return nil
+		})
+	})
+
+	if err != nil && err != badger.ErrKeyNotFound {
+		return nil, fmt.Errorf("failed to get yield allocated from %s: %w", source, err)
+	}
+
+	return allocated, nil
+}
+
+// AddYieldSourceAllocated adds amount to the wei allocated to the vault's epochs from the yield source
+func (s *Store) AddYieldSourceAllocated(vaultID, source string, amount *big.Int) error {
+	key := []byte(s.buildYieldSourceKey(vaultID, source))
+	if err := s.db.Update(func(txn storage.Txn) error {
+		total := new(big.Int).Set(amount)
+		item, err := txn.Get(key)
+		switch {
+		case err == nil:
+			if err := item.Value(func(val []byte) error {
+				previous, ok := new(big.Int).SetString(string(val), 10)
+				if !ok {
+					return fmt.Errorf("invalid amount %q", val)
+				}
+				total.Add(total, previous)
+				return nil
+			}); err != nil {
+				return err
+			}
+		case err != badger.ErrKeyNotFound:
+			return err
+		}
+		return txn.Set(key, []byte(total.String()))
+	}); err != nil {
+		return fmt.Errorf("failed to add yield allocated from %s: %w", source, err)
+	}
+
+	return nil
+}
+
 // Key building functions
 func (s *Store) buildEpochKey(epochNumber *big.Int, vaultID string) string {
 	normalizedVaultID := utils.NormalizeAddress(vaultID)
 	return fmt.Sprintf("epoch:vault:%s:epoch:%020s", normalizedVaultID, epochNumber.String())
 }
 
+func (s *Store) buildYieldKey(vaultID, epochID string) string {
+	normalizedVaultID := utils.NormalizeAddress(vaultID)
+	return fmt.Sprintf("epoch:yield:vault:%s:epoch:%020s", normalizedVaultID, epochID)
+}
+
+func (s *Store) buildYieldSourceKey(vaultID, source string) string {
+	normalizedVaultID := utils.NormalizeAddress(vaultID)
+	return fmt.Sprintf("epoch:yield-source:vault:%s:source:%s", normalizedVaultID, source)
+}
+
 func (s *Store) buildCurrentKey(vaultID string) string {
 	normalizedVaultID := utils.NormalizeAddress(vaultID)
 	return fmt.Sprintf("epoch:current:vault:%s", normalizedVaultID)
@@ -0,0 +1,192 @@
+package epochimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// rootTxAction is the audit action of the transaction pushing an epoch's merkle root
+const rootTxAction = "endEpochWithSubsidies"
+
+// timelineAuditPage is the number of audit entries read at once when looking for an epoch's root transaction
+const timelineAuditPage = 100
+
+// SetAuditLog sets where the transactions the server sent are read from, so epoch timelines include when the
+// root was submitted and settled. It is called once at startup.
+func (s *Service) SetAuditLog(auditLog epoch.AuditLog) {
+	s.auditLog = auditLog
+}
+
+func (s *Service) GetTimeline(ctx context.Context, epochId uint64, vaultId string) (_ *epoch.Timeline, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetTimeline",
+		attribute.Int64("epoch.id", int64(epochId)), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", epoch.ErrInvalidInput, vaultId)
+	}
+	vaultId = utils.NormalizeAddress(vaultId)
+	epochNumber := strconv.FormatUint(epochId, 10)
+
+	indexed, err := s.queryTimelineEpoch(ctx, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.timelineSnapshot(ctx, epochId, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	var since time.Time
+	if indexed != nil {
+		since = unixTime(indexed.StartTimestamp)
+	}
+	rootTx, err := s.findRootTx(ctx, epochNumber, vaultId, since)
+	if err != nil {
+		return nil, err
+	}
+	if indexed == nil && snapshot == nil && rootTx == nil {
+		return nil, fmt.Errorf("%w: epoch %d of vault %s", epoch.ErrNotFound, epochId, vaultId)
+	}
+
+	milestones := []epoch.Milestone{
+		{Name: epoch.MilestoneStarted, Source: "subgraph"},
+		{Name: epoch.MilestoneSnapshotTaken, Source: "snapshot"},
+		{Name: epoch.MilestoneAllocationsComputed, Source: "snapshot"},
+		{Name: epoch.MilestoneRootSubmitted, Source: "audit"},
+		{Name: epoch.MilestoneConfirmed, Source: "subgraph"},
+		{Name: epoch.MilestoneClaimsOpened, Source: "audit"},
+	}
+	timeline := &epoch.Timeline{EpochID: epochNumber, VaultAddress: vaultId}
+	if indexed != nil {
+		timeline.Status = indexed.Status
+		reach(&milestones[0], unixTime(indexed.StartTimestamp), "")
+		reach(&milestones[4], unixTime(indexed.ProcessingCompletedTimestamp), "")
+	}
+	if snapshot != nil {
+		reach(&milestones[1], time.Unix(snapshot.Timestamp, 0), strconv.FormatInt(snapshot.BlockNumber, 10))
+		reach(&milestones[2], snapshot.CreatedAt, "")
+	}
+	if rootTx != nil {
+		reach(&milestones[3], rootTx.SentAt, rootTx.TxHash)
+		reach(&milestones[5], rootTx.Timestamp, rootTx.TxHash)
+	}
+
+	// durations run from the previous reached milestone; the chain's clock and the server's may disagree by a
+	// few seconds, which is not reported as negative time
+	var first, last int64
+	for i := range milestones {
+		if !milestones[i].Reached {
+			continue
+		}
+		if first == 0 {
+			first = milestones[i].Timestamp
+		} else {
+			milestones[i].DurationSeconds = max(milestones[i].Timestamp-last, 0)
+		}
+		last = max(milestones[i].Timestamp, last)
+	}
+	timeline.Milestones = milestones
+	timeline.TotalSeconds = last - first
+	return timeline, nil
+}
+
+// reach marks the milestone reached at t, leaving it unreached when t is unknown
+func reach(milestone *epoch.Milestone, t time.Time, detail string) {
+	if t.IsZero() || t.Unix() <= 0 {
+		return
+	}
+	milestone.Reached = true
+	milestone.Timestamp = t.Unix()
+	milestone.Detail = detail
+}
+
+// unixTime parses a subgraph timestamp, the zero time when it is not set
+func unixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// queryTimelineEpoch returns the epoch as the subgraph indexed it, nil when it does not know the epoch
+func (s *Service) queryTimelineEpoch(ctx context.Context, epochNumber string) (*subgraph.Epoch, error) {
+	query := `
+		query EpochTimeline($epochNumber: String!) {
+			epoches(where: { epochNumber: $epochNumber }, first: 1) {
+				epochNumber
+				status
+				startTimestamp
+				processingCompletedTimestamp
+			}
+		}
+	`
+
+	var response struct {
+		Epoches []subgraph.Epoch `json:"epoches"`
+	}
+	if err := s.subgraphClient.ExecuteQuery(ctx, subgraph.GraphQLRequest{
+		Query:     query,
+		Variables: map[string]interface{}{"epochNumber": epochNumber},
+	}, &response); err != nil {
+		s.logger.Logf("ERROR failed to query epoch %s for its timeline: %v", epochNumber, err)
+		return nil, fmt.Errorf("failed to query epoch: %w", err)
+	}
+	if len(response.Epoches) == 0 {
+		return nil, nil
+	}
+	return &response.Epoches[0], nil
+}
+
+// timelineSnapshot returns the merkle snapshot of the epoch's distribution, nil when it was not distributed
+func (s *Service) timelineSnapshot(ctx context.Context, epochId uint64, vaultId string) (*merkle.MerkleSnapshot, error) {
+	if s.snapshots == nil {
+		return nil, nil
+	}
+	snapshot, err := s.snapshots.GetSnapshot(ctx, new(big.Int).SetUint64(epochId), vaultId)
+	if errors.Is(err, merkle.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merkle snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// findRootTx returns the latest successful root transaction of the vault's epoch recorded since the epoch
+// started, nil when there is none or no audit log is set
+func (s *Service) findRootTx(ctx context.Context, epochNumber, vaultId string, since time.Time) (*audit.Entry, error) {
+	if s.auditLog == nil {
+		return nil, nil
+	}
+	filter := audit.Filter{Action: rootTxAction, Result: audit.ResultSuccess, Since: since, Limit: timelineAuditPage}
+	for {
+		page, err := s.auditLog.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list root transactions: %w", err)
+		}
+		for i := range page.Entries {
+			entry := &page.Entries[i]
+			if entry.Parameters["epochId"] == epochNumber && strings.EqualFold(entry.Parameters["vault"], vaultId) {
+				return entry, nil
+			}
+		}
+		if page.NextCursor == "" {
+			return nil, nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
@@ -0,0 +1,114 @@
+package epochimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// timelineSubgraph answers timeline queries with the given epochs
+func timelineSubgraph(epochs ...subgraph.Epoch) *subgraph.SubgraphClientMock {
+	return &subgraph.SubgraphClientMock{
+		ExecuteQueryFunc: func(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+			var matching []subgraph.Epoch
+			for _, e := range epochs {
+				if e.EpochNumber == request.Variables["epochNumber"] {
+					matching = append(matching, e)
+				}
+			}
+			data, err := json.Marshal(map[string]interface{}{"epoches": matching})
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, response)
+		},
+	}
+}
+
+func TestService_GetTimeline(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newYieldService(t, &yieldChain{allocated: map[int64]int64{}}, 0, "0")
+	service.subgraphClient = timelineSubgraph(subgraph.Epoch{
+		EpochNumber:                  "5",
+		Status:                       "COMPLETED",
+		StartTimestamp:               "1000",
+		ProcessingCompletedTimestamp: "1700",
+	})
+	service.SetSnapshots(snapshotStoreFunc(func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+		if epochNumber.Int64() != 5 {
+			return nil, fmt.Errorf("%w: epoch %s", merkle.ErrNotFound, epochNumber)
+		}
+		return &merkle.MerkleSnapshot{Timestamp: 1500, BlockNumber: 42, CreatedAt: time.Unix(1560, 0)}, nil
+	}))
+
+	// the root transaction is on the second page, behind another epoch's and another vault's
+	pages := map[string]*audit.ListResponse{
+		"": {NextCursor: "next", Entries: []audit.Entry{
+			{Parameters: map[string]string{"epochId": "6", "vault": testVault}, Timestamp: time.Unix(9000, 0)},
+			{Parameters: map[string]string{"epochId": "5", "vault": "0x9999999999999999999999999999999999999999"}},
+		}},
+		"next": {Entries: []audit.Entry{{
+			Parameters: map[string]string{"epochId": "5", "vault": "0x1234567890123456789012345678901234567890"},
+			TxHash:     "0xroot",
+			SentAt:     time.Unix(1600, 0),
+			Timestamp:  time.Unix(1760, 0),
+		}}},
+	}
+	auditLog := &audit.ServiceMock{
+		ListFunc: func(ctx context.Context, filter audit.Filter) (*audit.ListResponse, error) {
+			assert.Equal(t, rootTxAction, filter.Action)
+			assert.Equal(t, audit.ResultSuccess, filter.Result)
+			assert.Equal(t, time.Unix(1000, 0), filter.Since, "only transactions since the epoch started are read")
+			return pages[filter.Cursor], nil
+		},
+	}
+	service.SetAuditLog(auditLog)
+
+	timeline, err := service.GetTimeline(ctx, 5, "0x1234567890123456789012345678901234567890")
+	require.NoError(t, err)
+	assert.Equal(t, "5", timeline.EpochID)
+	assert.Equal(t, "COMPLETED", timeline.Status)
+	assert.Equal(t, []epoch.Milestone{
+		{Name: epoch.MilestoneStarted, Reached: true, Timestamp: 1000, Source: "subgraph"},
+		{Name: epoch.MilestoneSnapshotTaken, Reached: true, Timestamp: 1500, DurationSeconds: 500, Source: "snapshot", Detail: "42"},
+		{Name: epoch.MilestoneAllocationsComputed, Reached: true, Timestamp: 1560, DurationSeconds: 60, Source: "snapshot"},
+		{Name: epoch.MilestoneRootSubmitted, Reached: true, Timestamp: 1600, DurationSeconds: 40, Source: "audit", Detail: "0xroot"},
+		{Name: epoch.MilestoneConfirmed, Reached: true, Timestamp: 1700, DurationSeconds: 100, Source: "subgraph"},
+		{Name: epoch.MilestoneClaimsOpened, Reached: true, Timestamp: 1760, DurationSeconds: 60, Source: "audit", Detail: "0xroot"},
+	}, timeline.Milestones)
+	assert.Equal(t, int64(760), timeline.TotalSeconds)
+	assert.Len(t, auditLog.ListCalls(), 2)
+}
+
+func TestService_GetTimelineInProgress(t *testing.T) {
+	ctx := context.Background()
+	service, _ := newYieldService(t, &yieldChain{allocated: map[int64]int64{}}, 0, "0")
+	service.subgraphClient = timelineSubgraph(subgraph.Epoch{EpochNumber: "7", Status: "ACTIVE", StartTimestamp: "1000"})
+
+	// without snapshots or an audit log only the subgraph's milestones are known
+	timeline, err := service.GetTimeline(ctx, 7, testVault)
+	require.NoError(t, err)
+	require.Len(t, timeline.Milestones, 6)
+	assert.True(t, timeline.Milestones[0].Reached)
+	for _, milestone := range timeline.Milestones[1:] {
+		assert.False(t, milestone.Reached, milestone.Name)
+		assert.Zero(t, milestone.Timestamp, milestone.Name)
+	}
+	assert.Zero(t, timeline.TotalSeconds)
+
+	_, err = service.GetTimeline(ctx, 8, testVault)
+	assert.ErrorIs(t, err, epoch.ErrNotFound)
+	_, err = service.GetTimeline(ctx, 7, "bad")
+	assert.ErrorIs(t, err, epoch.ErrInvalidInput)
+}
@@ -0,0 +1,82 @@
+package epochimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+)
+
+// epochStarts keeps the EpochStarted events found, an epoch's start and end never change once it started
+type epochStarts struct {
+	mu     sync.Mutex
+	starts map[string]*blockchain.EpochStart // by epoch ID
+}
+
+func (e *epochStarts) get(epochId string) *blockchain.EpochStart {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.starts[epochId]
+}
+
+func (e *epochStarts) put(start *blockchain.EpochStart) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.starts == nil {
+		e.starts = make(map[string]*blockchain.EpochStart)
+	}
+	e.starts[start.EpochID.String()] = start
+}
+
+// GetEpochTiming reads the current epoch's start and end from its EpochStarted event, searched from the latest
+// block back to EPOCH_MANAGER_START_BLOCK, and compares the end with the latest block's timestamp rather than
+// the server's clock. It wraps epoch.ErrNotFound when the epoch's EpochStarted event is not in that range.
+func (s *Service) GetEpochTiming(ctx context.Context) (_ *epoch.EpochTiming, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.GetEpochTiming")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	epochId, err := s.contractClient.GetCurrentEpochId(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current epoch ID: %w", err)
+	}
+	head, err := s.contractClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	timing := &epoch.EpochTiming{
+		EpochID:     epochId.String(),
+		ChainTime:   head.Timestamp,
+		BlockNumber: head.Number,
+	}
+	if epochId.Sign() == 0 {
+		timing.Ended = true
+		return timing, nil
+	}
+
+	start := s.epochStarts.get(epochId.String())
+	if start == nil {
+		start, err = s.contractClient.GetEpochStart(ctx, new(big.Int).Set(epochId), s.config.Contracts.EpochManagerBlock, head.Number)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read start of epoch %s: %w", epochId, err)
+		}
+		if start == nil {
+			return nil, fmt.Errorf("%w: no EpochStarted event of epoch %s since block %d",
+				epoch.ErrNotFound, epochId, s.config.Contracts.EpochManagerBlock)
+		}
+		s.epochStarts.put(start)
+	}
+
+	timing.StartTime = start.StartTime
+	timing.EndTime = start.EndTime
+	if start.EndTime > start.StartTime {
+		timing.DurationSeconds = start.EndTime - start.StartTime
+	}
+	timing.StartTxHash = start.TxHash
+	timing.StartBlock = start.BlockNumber
+	timing.Ended = head.Timestamp >= start.EndTime
+	return timing, nil
+}
@@ -0,0 +1,63 @@
+package epochimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+)
+
+func TestService_GetEpochTiming(t *testing.T) {
+	currentEpoch, chainTime := int64(0), uint64(1_700_000_000)
+	client := &blockchain.BlockchainClientMock{
+		GetCurrentEpochIdFunc: func(ctx context.Context) (*big.Int, error) { return big.NewInt(currentEpoch), nil },
+		GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+			return &blockchain.BlockRef{Number: 500, Timestamp: chainTime}, nil
+		},
+		GetEpochStartFunc: func(ctx context.Context, epochId *big.Int, fromBlock, toBlock uint64) (*blockchain.EpochStart, error) {
+			if epochId.Int64() != 3 {
+				return nil, nil
+			}
+			return &blockchain.EpochStart{
+				EpochID: big.NewInt(3), StartTime: 1_699_900_000, EndTime: 1_700_000_100, TxHash: "0xabc", BlockNumber: 400,
+			}, nil
+		},
+	}
+	cfg := &config.Config{}
+	cfg.Contracts.EpochManagerBlock = 100
+	service := New(nil, client, nil, nil, nil, lgr.NoOp, cfg)
+	ctx := context.Background()
+
+	timing, err := service.GetEpochTiming(ctx)
+	require.NoError(t, err)
+	assert.True(t, timing.Ended, "epoch 0 was never started")
+	assert.Empty(t, client.GetEpochStartCalls())
+
+	currentEpoch = 3
+	timing, err = service.GetEpochTiming(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "3", timing.EpochID)
+	assert.Equal(t, uint64(100_100), timing.DurationSeconds)
+	assert.Equal(t, uint64(400), timing.StartBlock)
+	assert.False(t, timing.Ended, "the end is compared with the latest block, not the server's clock")
+	require.Len(t, client.GetEpochStartCalls(), 1)
+	assert.Equal(t, uint64(100), client.GetEpochStartCalls()[0].FromBlock)
+	assert.Equal(t, uint64(500), client.GetEpochStartCalls()[0].ToBlock)
+
+	chainTime = 1_700_000_100
+	timing, err = service.GetEpochTiming(ctx)
+	require.NoError(t, err)
+	assert.True(t, timing.Ended)
+	assert.Len(t, client.GetEpochStartCalls(), 1, "an epoch's start is read once")
+
+	currentEpoch = 4
+	_, err = service.GetEpochTiming(ctx)
+	assert.ErrorIs(t, err, epoch.ErrNotFound)
+}
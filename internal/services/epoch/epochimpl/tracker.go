@@ -0,0 +1,127 @@
+package epochimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	epochStatusCompleted = "completed"
+	epochStatusFailed    = "failed"
+)
+
+// TxTracker keeps stored epoch state in step with the transactions the blockchain client watches,
+// so a finalize transaction that reverts or never confirms is reported when it settles rather than
+// when the next epoch trips over it
+type TxTracker struct {
+	store       *Store
+	notifier    webhook.Notifier
+	deadLetters deadletter.Recorder // nil only logs transactions that failed
+	logger      lgr.L
+}
+
+// NewTxTracker creates a tracker writing epoch state to db
+func NewTxTracker(db storage.DB, notifier webhook.Notifier, logger lgr.L) *TxTracker {
+	return &TxTracker{
+		store:    NewStore(db, logger),
+		notifier: notifier,
+		logger:   logger,
+	}
+}
+
+// SetDeadLetters sets where transactions that reverted or never confirmed are kept for an operator to retry.
+// It is called once at startup.
+func (t *TxTracker) SetDeadLetters(deadLetters deadletter.Recorder) {
+	t.deadLetters = deadLetters
+}
+
+// TxSettled updates the epoch a settled transaction acted on. Events the protocol emitted decide the
+// outcome of a confirmed transaction, and a reverted force end marks its epoch failed.
+func (t *TxTracker) TxSettled(ctx context.Context, outcome blockchain.TxOutcome) {
+	vaultID := outcome.Parameters["vault"]
+
+	if outcome.Status != blockchain.TxConfirmed {
+		t.logger.Logf("ERROR %s transaction %s %s: %s", outcome.Action, outcome.TxHash, outcome.Status, outcome.RevertReason)
+		t.notifier.Notify(ctx, webhook.EventTransactionFailed, map[string]interface{}{
+			"action":       outcome.Action,
+			"txHash":       outcome.TxHash,
+			"status":       string(outcome.Status),
+			"revertReason": outcome.RevertReason,
+			"parameters":   outcome.Parameters,
+		})
+		t.deadLetter(ctx, outcome)
+		if outcome.Status == blockchain.TxReverted && outcome.Action == "forceEndEpochWithZeroYield" {
+			t.markEpoch(ctx, outcome.Parameters["epochId"], vaultID, epochStatusFailed, outcome.RevertReason)
+		}
+		return
+	}
+
+	for _, event := range outcome.Events {
+		switch event.Name {
+		case "EpochFinalized":
+			t.markEpoch(ctx, event.Args["epochId"], vaultID, epochStatusCompleted, "")
+		case "EpochFailed", "ProcessingFailed":
+			t.markEpoch(ctx, event.Args["epochId"], vaultID, epochStatusFailed, event.Args["reason"])
+		}
+	}
+}
+
+// deadLetter keeps a transaction that failed for good, with what is needed to send it again
+func (t *TxTracker) deadLetter(ctx context.Context, outcome blockchain.TxOutcome) {
+	if t.deadLetters == nil {
+		return
+	}
+	payload, err := json.Marshal(deadletter.Transaction{
+		Action:       outcome.Action,
+		TxHash:       outcome.TxHash,
+		Status:       string(outcome.Status),
+		RevertReason: outcome.RevertReason,
+		Parameters:   outcome.Parameters,
+	})
+	if err != nil {
+		t.logger.Logf("ERROR failed to marshal dead letter of transaction %s: %v", outcome.TxHash, err)
+		return
+	}
+
+	lastError := fmt.Sprintf("transaction %s %s", outcome.TxHash, outcome.Status)
+	if outcome.RevertReason != "" {
+		lastError += ": " + outcome.RevertReason
+	}
+	err = t.deadLetters.Record(ctx, deadletter.Entry{
+		Kind:      deadletter.KindTransaction,
+		Target:    outcome.Action,
+		Payload:   payload,
+		Attempts:  1,
+		LastError: lastError,
+	})
+	if err != nil {
+		t.logger.Logf("ERROR failed to persist dead letter of transaction %s: %v", outcome.TxHash, err)
+	}
+}
+
+func (t *TxTracker) markEpoch(ctx context.Context, epochID, vaultID, status, reason string) {
+	number, ok := new(big.Int).SetString(epochID, 10)
+	if !ok || vaultID == "" {
+		t.logger.Logf("WARN settled transaction names no epoch to mark %s (epoch %q, vault %q)", status, epochID, vaultID)
+		return
+	}
+
+	if err := t.store.MarkEpochStatus(ctx, number, vaultID, status); err != nil {
+		t.logger.Logf("ERROR failed to mark epoch %s of vault %s %s: %v", epochID, vaultID, status, err)
+	}
+	if status == epochStatusFailed {
+		t.notifier.Notify(ctx, webhook.EventEpochFailed, map[string]interface{}{
+			"epochId":      epochID,
+			"vaultAddress": vaultID,
+			"reason":       reason,
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package epochimpl
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+const trackerTestVault = "0x1234567890123456789012345678901234567890"
+
+func newTrackerTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+func TestTxTracker_TxSettled(t *testing.T) {
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+	deadLetters := &deadletter.RecorderMock{RecordFunc: func(ctx context.Context, entry deadletter.Entry) error { return nil }}
+	tracker := NewTxTracker(newTrackerTestDB(t), notifier, lgr.NoOp)
+	tracker.SetDeadLetters(deadLetters)
+	ctx := context.Background()
+
+	tracker.TxSettled(ctx, blockchain.TxOutcome{
+		Action:     "forceEndEpochWithZeroYield",
+		TxHash:     "0xaaa",
+		Parameters: map[string]string{"epochId": "7", "vault": trackerTestVault},
+		Status:     blockchain.TxConfirmed,
+		Events: []blockchain.TxEvent{
+			{Name: "EpochFinalized", Args: map[string]string{"epochId": "7"}},
+		},
+	})
+	info, err := tracker.store.GetEpoch(ctx, big.NewInt(7), trackerTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, epochStatusCompleted, info.Status)
+	assert.Empty(t, notifier.NotifyCalls())
+
+	tracker.TxSettled(ctx, blockchain.TxOutcome{
+		Action:       "forceEndEpochWithZeroYield",
+		TxHash:       "0xbbb",
+		Parameters:   map[string]string{"epochId": "8", "vault": trackerTestVault},
+		Status:       blockchain.TxReverted,
+		RevertReason: "EpochManager__EpochStillActive",
+	})
+	info, err = tracker.store.GetEpoch(ctx, big.NewInt(8), trackerTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, epochStatusFailed, info.Status)
+
+	calls := notifier.NotifyCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, webhook.EventTransactionFailed, calls[0].EventType)
+	assert.Equal(t, "0xbbb", calls[0].Data["txHash"])
+	assert.Equal(t, webhook.EventEpochFailed, calls[1].EventType)
+	assert.Equal(t, "EpochManager__EpochStillActive", calls[1].Data["reason"])
+
+	letters := deadLetters.RecordCalls()
+	require.Len(t, letters, 1, "only the reverted transaction is kept")
+	assert.Equal(t, deadletter.KindTransaction, letters[0].Entry.Kind)
+	assert.Equal(t, "forceEndEpochWithZeroYield", letters[0].Entry.Target)
+	assert.Equal(t, "transaction 0xbbb reverted: EpochManager__EpochStillActive", letters[0].Entry.LastError)
+	var tx deadletter.Transaction
+	require.NoError(t, json.Unmarshal(letters[0].Entry.Payload, &tx))
+	assert.Equal(t, map[string]string{"epochId": "8", "vault": trackerTestVault}, tx.Parameters)
+}
+
+func TestTxTracker_UnconfirmedMerkleRootLeavesEpochs(t *testing.T) {
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+	tracker := NewTxTracker(newTrackerTestDB(t), notifier, lgr.NoOp)
+	ctx := context.Background()
+
+	tracker.TxSettled(ctx, blockchain.TxOutcome{
+		Action:     "updateMerkleRoot",
+		TxHash:     "0xccc",
+		Parameters: map[string]string{"vault": trackerTestVault},
+		Status:     blockchain.TxUnconfirmed,
+	})
+
+	calls := notifier.NotifyCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, webhook.EventTransactionFailed, calls[0].EventType)
+	assert.Equal(t, "unconfirmed", calls[0].Data["status"])
+	_, err := tracker.store.GetCurrentEpoch(ctx, trackerTestVault)
+	assert.Error(t, err, "no epoch is recorded for a transaction that names none")
+}
@@ -0,0 +1,137 @@
+package epochimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AllocateYield allocates the yield of every source in YIELD_SOURCES to the current epoch, holding back the
+// reserve from YIELD_RESERVE_PERCENT and YIELD_RESERVE_MIN. What is held back stays available to the next epoch.
+// An epoch is allocated to once; ErrYieldAlreadyAllocated is returned after that.
+func (s *Service) AllocateYield(ctx context.Context, vaultId string) (_ *epoch.YieldAllocation, err error) {
+	ctx, span := tracing.StartSpan(ctx, "epoch.AllocateYield", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", epoch.ErrInvalidInput)
+	}
+
+	epochId, err := s.contractClient.GetCurrentEpochId(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current epoch ID: %w", err)
+	}
+	if epochId.Sign() == 0 {
+		return nil, fmt.Errorf("%w: no epoch has been started", epoch.ErrEpochNotActive)
+	}
+
+	stored, err := s.store.GetYieldAllocation(vaultId, epochId.String())
+	if err != nil {
+		return nil, err
+	}
+	if stored != nil {
+		return nil, fmt.Errorf("%w: epoch %s", epoch.ErrYieldAlreadyAllocated, epochId)
+	}
+	allocated, err := s.contractClient.GetEpochYieldAllocated(ctx, epochId, vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get yield allocated to epoch %s: %w", epochId, err)
+	}
+	if allocated.Sign() > 0 {
+		return nil, fmt.Errorf("%w: epoch %s already holds %s wei", epoch.ErrYieldAlreadyAllocated, epochId, allocated)
+	}
+
+	sources, err := s.yieldSources()
+	if err != nil {
+		return nil, err
+	}
+	available := big.NewInt(0)
+	sourceAvailable := make([]*big.Int, len(sources))
+	for i, source := range sources {
+		if sourceAvailable[i], err = source.Available(ctx, vaultId); err != nil {
+			return nil, fmt.Errorf("failed to read yield source %s: %w", source.Name(), err)
+		}
+		available.Add(available, sourceAvailable[i])
+	}
+	heldBack := s.yieldReserve(available)
+	amount := new(big.Int).Sub(available, heldBack)
+
+	allocation := epoch.YieldAllocation{
+		EpochID:        epochId.String(),
+		VaultAddress:   vaultId,
+		Available:      available.String(),
+		Allocated:      amount.String(),
+		HeldBack:       heldBack.String(),
+		ReservePercent: s.config.Yield.ReservePercent,
+		AllocatedAt:    time.Now(),
+	}
+	shares := splitAllocation(sourceAvailable, available, amount)
+	for i, source := range sources {
+		allocation.Sources = append(allocation.Sources, epoch.YieldSourceShare{
+			Source:    source.Name(),
+			Available: sourceAvailable[i].String(),
+			Allocated: shares[i].String(),
+		})
+	}
+	// the vault reverts a zero allocation, so an epoch the reserve takes everything from is only recorded
+	if amount.Sign() > 0 {
+		if err := s.contractClient.AllocateCumulativeYieldToEpoch(ctx, epochId, vaultId, amount); err != nil {
+			s.logger.Logf("ERROR failed to allocate yield to epoch %s: %v", epochId, err)
+			return nil, fmt.Errorf("%w: failed to allocate yield: %v", epoch.ErrTransactionFailed, err)
+		}
+		allocation.TransactionSent = true
+		for i, source := range sources {
+			if _, tracked := source.(erc20Source); !tracked || shares[i].Sign() == 0 {
+				continue
+			}
+			if err := s.store.AddYieldSourceAllocated(vaultId, source.Name(), shares[i]); err != nil {
+				// without the record the next epoch is offered the same balance again
+				s.logger.Logf("ERROR failed to record %s wei allocated from yield source %s: %v", shares[i], source.Name(), err)
+			}
+		}
+	}
+
+	if err := s.store.SaveYieldAllocation(allocation); err != nil {
+		// the allocation is on chain, so the epoch is not allocated to again even without the record
+		s.logger.Logf("WARN failed to record yield allocation for epoch %s: %v", epochId, err)
+	}
+	s.logger.Logf("INFO allocated %s of %s yield to epoch %s for vault %s, held back %s",
+		assets.Describe(ctx, s.assets, vaultId, amount), assets.Describe(ctx, s.assets, vaultId, available), epochId, vaultId,
+		assets.Describe(ctx, s.assets, vaultId, heldBack))
+	return &allocation, nil
+}
+
+// yieldReserve returns the part of available held back: the configured percentage, at least the configured
+// minimum, and never more than available
+func (s *Service) yieldReserve(available *big.Int) *big.Int {
+	reserve := new(big.Int).Mul(available, new(big.Int).SetUint64(s.config.Yield.ReservePercent))
+	reserve.Quo(reserve, big.NewInt(100))
+	if minimum, ok := new(big.Int).SetString(s.config.Yield.ReserveMin, 10); ok && reserve.Cmp(minimum) < 0 {
+		reserve = minimum
+	}
+	if reserve.Cmp(available) > 0 {
+		reserve = new(big.Int).Set(available)
+	}
+	return reserve
+}
+
+// addYieldAllocations fills in the yield the server allocated to each epoch of the configured vault
+func (s *Service) addYieldAllocations(epochs []epoch.EpochSummary) {
+	vaultId := s.config.Contracts.CollectionsVault
+	for i := range epochs {
+		allocation, err := s.store.GetYieldAllocation(vaultId, epochs[i].EpochNumber)
+		if err != nil {
+			s.logger.Logf("WARN failed to get yield allocation for epoch %s: %v", epochs[i].EpochNumber, err)
+			continue
+		}
+		if allocation != nil {
+			epochs[i].YieldAllocated = allocation.Allocated
+			epochs[i].YieldHeldBack = allocation.HeldBack
+		}
+	}
+}
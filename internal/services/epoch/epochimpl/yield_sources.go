@@ -0,0 +1,110 @@
+package epochimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+)
+
+// lendingManagerSource is the yield the vault earns from the lending manager, the vault's remaining
+// cumulative yield. The vault tracks what was allocated from it.
+type lendingManagerSource struct {
+	client epoch.ContractClient
+}
+
+func (l lendingManagerSource) Name() string { return config.YieldSourceLendingManager }
+
+func (l lendingManagerSource) Available(ctx context.Context, vaultId string) (*big.Int, error) {
+	available, err := l.client.GetRemainingCumulativeYield(ctx, vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get remaining cumulative yield: %w", err)
+	}
+	return available, nil
+}
+
+// erc20Source is a token balance allocated to epochs, such as external reward tokens or top-ups transferred to
+// the holder. Nothing on chain tracks what was allocated from a balance, so the store keeps the total and only
+// the balance beyond it is available.
+type erc20Source struct {
+	name   string
+	token  string
+	holder string // the vault when empty
+	client epoch.ContractClient
+	store  *Store
+}
+
+func (e erc20Source) Name() string { return e.name }
+
+func (e erc20Source) Available(ctx context.Context, vaultId string) (*big.Int, error) {
+	holder := e.holder
+	if holder == "" {
+		holder = vaultId
+	}
+	balance, err := e.client.GetTokenBalance(ctx, e.token, holder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s balance of %s: %w", e.token, holder, err)
+	}
+	allocated, err := e.store.GetYieldSourceAllocated(vaultId, e.name)
+	if err != nil {
+		return nil, err
+	}
+	// a balance spent below what was allocated from it has nothing new to allocate
+	if balance.Cmp(allocated) <= 0 {
+		return big.NewInt(0), nil
+	}
+	return balance.Sub(balance, allocated), nil
+}
+
+// yieldSources returns the sources of YIELD_SOURCES, the lending manager alone when none are configured
+func (s *Service) yieldSources() ([]epoch.YieldSource, error) {
+	configured, err := config.ParseYieldSources(s.config.Yield.Sources)
+	if err != nil {
+		return nil, err
+	}
+	if len(configured) == 0 {
+		return []epoch.YieldSource{lendingManagerSource{client: s.contractClient}}, nil
+	}
+
+	sources := make([]epoch.YieldSource, 0, len(configured))
+	for _, source := range configured {
+		switch source.Kind {
+		case config.YieldSourceLendingManager:
+			sources = append(sources, lendingManagerSource{client: s.contractClient})
+		case config.YieldSourceERC20:
+			sources = append(sources, erc20Source{
+				name:   source.Name(),
+				token:  source.Token,
+				holder: source.Holder,
+				client: s.contractClient,
+				store:  s.store,
+			})
+		}
+	}
+	return sources, nil
+}
+
+// splitAllocation shares amount out of total between the sources in proportion to what each had available.
+// The rounding remainder goes to the first source with yield, so the shares add up to amount.
+func splitAllocation(available []*big.Int, total, amount *big.Int) []*big.Int {
+	shares := make([]*big.Int, len(available))
+	remainder := new(big.Int).Set(amount)
+	first := -1
+	for i, a := range available {
+		shares[i] = big.NewInt(0)
+		if total.Sign() == 0 || a.Sign() == 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		shares[i].Mul(a, amount).Quo(shares[i], total)
+		remainder.Sub(remainder, shares[i])
+	}
+	if first >= 0 {
+		shares[first].Add(shares[first], remainder)
+	}
+	return shares
+}
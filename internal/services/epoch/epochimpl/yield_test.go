@@ -0,0 +1,250 @@
+package epochimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+// yieldChain holds remaining cumulative yield and records what is allocated to each epoch
+type yieldChain struct {
+	epoch     int64
+	remaining int64
+	allocated map[int64]int64
+}
+
+func (c *yieldChain) client() *blockchain.BlockchainClientMock {
+	return &blockchain.BlockchainClientMock{
+		GetCurrentEpochIdFunc: func(ctx context.Context) (*big.Int, error) { return big.NewInt(c.epoch), nil },
+		GetRemainingCumulativeYieldFunc: func(ctx context.Context, vaultAddress string) (*big.Int, error) {
+			return big.NewInt(c.remaining), nil
+		},
+		GetEpochYieldAllocatedFunc: func(ctx context.Context, epochId *big.Int, vaultAddress string) (*big.Int, error) {
+			return big.NewInt(c.allocated[epochId.Int64()]), nil
+		},
+		AllocateCumulativeYieldToEpochFunc: func(ctx context.Context, epochId *big.Int, vaultAddress string, amount *big.Int) error {
+			c.allocated[epochId.Int64()] += amount.Int64()
+			c.remaining -= amount.Int64()
+			return nil
+		},
+	}
+}
+
+func newYieldService(t *testing.T, chain *yieldChain, percent uint64, minimum string) (*Service, *blockchain.BlockchainClientMock) {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = testVault
+	cfg.Yield.ReservePercent = percent
+	cfg.Yield.ReserveMin = minimum
+	client := chain.client()
+	return New(db, client, nil, nil, nil, lgr.NoOp, cfg), client
+}
+
+func TestService_AllocateYieldHoldsBackReserve(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int64
+		percent   uint64
+		minimum   string
+		allocated string
+		heldBack  string
+	}{
+		{name: "no_reserve", remaining: 1000, minimum: "0", allocated: "1000", heldBack: "0"},
+		{name: "percent", remaining: 1000, percent: 10, minimum: "0", allocated: "900", heldBack: "100"},
+		{name: "minimum_above_percent", remaining: 1000, percent: 10, minimum: "250", allocated: "750", heldBack: "250"},
+		{name: "percent_above_minimum", remaining: 1000, percent: 30, minimum: "250", allocated: "700", heldBack: "300"},
+		{name: "minimum_above_available", remaining: 200, minimum: "250", allocated: "0", heldBack: "200"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := &yieldChain{epoch: 4, remaining: tt.remaining, allocated: map[int64]int64{}}
+			service, client := newYieldService(t, chain, tt.percent, tt.minimum)
+
+			allocation, err := service.AllocateYield(context.Background(), testVault)
+			require.NoError(t, err)
+			assert.Equal(t, "4", allocation.EpochID)
+			assert.Equal(t, fmt.Sprint(tt.remaining), allocation.Available)
+			assert.Equal(t, tt.allocated, allocation.Allocated)
+			assert.Equal(t, tt.heldBack, allocation.HeldBack)
+			assert.Equal(t, tt.heldBack, fmt.Sprint(chain.remaining), "the reserve stays in the vault")
+			if tt.allocated == "0" {
+				assert.False(t, allocation.TransactionSent)
+				assert.Empty(t, client.AllocateCumulativeYieldToEpochCalls(), "the vault reverts a zero allocation")
+			} else {
+				assert.True(t, allocation.TransactionSent)
+				assert.Len(t, client.AllocateCumulativeYieldToEpochCalls(), 1)
+			}
+
+			stored, err := service.store.GetYieldAllocation(testVault, "4")
+			require.NoError(t, err)
+			require.NotNil(t, stored)
+			assert.Equal(t, tt.heldBack, stored.HeldBack)
+		})
+	}
+}
+
+func TestService_AllocateYieldFromSources(t *testing.T) {
+	const (
+		rewardToken = "0x6666666666666666666666666666666666666666"
+		topUpWallet = "0x5555555555555555555555555555555555555555"
+	)
+	chain := &yieldChain{epoch: 1, remaining: 600, allocated: map[int64]int64{}}
+	service, client := newYieldService(t, chain, 10, "0")
+	service.config.Yield.Sources = []string{"lending_manager", "erc20:" + rewardToken, "erc20:" + rewardToken + ":" + topUpWallet}
+	balances := map[string]int64{testVault: 300, topUpWallet: 100}
+	client.GetTokenBalanceFunc = func(ctx context.Context, tokenAddress, holderAddress string) (*big.Int, error) {
+		assert.Equal(t, rewardToken, tokenAddress)
+		return big.NewInt(balances[holderAddress]), nil
+	}
+	ctx := context.Background()
+
+	allocation, err := service.AllocateYield(ctx, testVault)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", allocation.Available)
+	assert.Equal(t, "900", allocation.Allocated)
+	assert.Equal(t, "100", allocation.HeldBack)
+	assert.Equal(t, []epoch.YieldSourceShare{
+		{Source: "lending_manager", Available: "600", Allocated: "540"},
+		{Source: "erc20:" + rewardToken, Available: "300", Allocated: "270"},
+		{Source: "erc20:" + rewardToken + ":" + topUpWallet, Available: "100", Allocated: "90"},
+	}, allocation.Sources, "the reserve is held back from every source in proportion")
+	require.Len(t, client.AllocateCumulativeYieldToEpochCalls(), 1)
+	assert.Equal(t, "900", client.AllocateCumulativeYieldToEpochCalls()[0].Amount.String())
+
+	// token balances count only what was not allocated from them yet
+	chain.epoch, chain.remaining = 2, 0
+	balances[topUpWallet] = 150
+	allocation, err = service.AllocateYield(ctx, testVault)
+	require.NoError(t, err)
+	assert.Equal(t, "90", allocation.Available, "30 reward and 10 top-up held back, 50 topped up")
+	assert.Equal(t, []epoch.YieldSourceShare{
+		{Source: "lending_manager", Available: "0", Allocated: "0"},
+		{Source: "erc20:" + rewardToken, Available: "30", Allocated: "27"},
+		{Source: "erc20:" + rewardToken + ":" + topUpWallet, Available: "60", Allocated: "54"},
+	}, allocation.Sources)
+
+	client.GetTokenBalanceFunc = func(ctx context.Context, tokenAddress, holderAddress string) (*big.Int, error) {
+		return nil, fmt.Errorf("rpc unavailable")
+	}
+	chain.epoch = 3
+	_, err = service.AllocateYield(ctx, testVault)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read yield source erc20:"+rewardToken)
+}
+
+func TestSplitAllocation(t *testing.T) {
+	shares := splitAllocation([]*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(1), big.NewInt(1)}, big.NewInt(3), big.NewInt(2))
+	assert.Equal(t, "[0 2 0 0]", fmt.Sprint(shares), "the rounding remainder goes to the first source with yield")
+
+	shares = splitAllocation([]*big.Int{big.NewInt(0)}, big.NewInt(0), big.NewInt(0))
+	assert.Equal(t, "[0]", fmt.Sprint(shares))
+}
+
+func TestService_AllocateYieldOncePerEpoch(t *testing.T) {
+	chain := &yieldChain{epoch: 2, remaining: 1000, allocated: map[int64]int64{}}
+	service, client := newYieldService(t, chain, 10, "0")
+	ctx := context.Background()
+
+	_, err := service.AllocateYield(ctx, testVault)
+	require.NoError(t, err)
+	_, err = service.AllocateYield(ctx, testVault)
+	require.ErrorIs(t, err, epoch.ErrYieldAlreadyAllocated)
+
+	// yield allocated outside the server is not allocated to again either
+	chain.epoch, chain.allocated[3] = 3, 50
+	_, err = service.AllocateYield(ctx, testVault)
+	require.ErrorIs(t, err, epoch.ErrYieldAlreadyAllocated)
+	assert.Len(t, client.AllocateCumulativeYieldToEpochCalls(), 1)
+
+	chain.epoch = 0
+	_, err = service.AllocateYield(ctx, testVault)
+	require.ErrorIs(t, err, epoch.ErrEpochNotActive)
+	_, err = service.AllocateYield(ctx, "")
+	require.ErrorIs(t, err, epoch.ErrInvalidInput)
+}
+
+func TestService_ListEpochsReportsYieldAllocations(t *testing.T) {
+	chain := &yieldChain{epoch: 2, remaining: 1000, allocated: map[int64]int64{}}
+	service, _ := newYieldService(t, chain, 20, "0")
+	service.subgraphClient = &subgraph.SubgraphClientMock{
+		ExecuteQueryFunc: func(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+			list := response.(*struct {
+				Epoches []epoch.EpochSummary `json:"epoches"`
+				Latest  []epoch.EpochSummary `json:"latest"`
+			})
+			list.Epoches = []epoch.EpochSummary{{EpochNumber: "2"}, {EpochNumber: "1"}}
+			list.Latest = list.Epoches[:1]
+			return nil
+		},
+	}
+
+	_, err := service.AllocateYield(context.Background(), testVault)
+	require.NoError(t, err)
+
+	list, err := service.ListEpochs(context.Background(), epoch.ListEpochsQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list.Epochs, 2)
+	assert.Equal(t, "800", list.Epochs[0].YieldAllocated)
+	assert.Equal(t, "200", list.Epochs[0].YieldHeldBack)
+	assert.Empty(t, list.Epochs[1].YieldAllocated, "epochs the server did not allocate to report nothing")
+}
+
+// snapshotStoreFunc reads merkle snapshots with a function
+type snapshotStoreFunc func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error)
+
+func (f snapshotStoreFunc) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return f(ctx, epochNumber, vaultID)
+}
+
+func TestService_ListEpochsReportsDistributionFingerprints(t *testing.T) {
+	service, _ := newYieldService(t, &yieldChain{allocated: map[int64]int64{}}, 0, "0")
+	service.subgraphClient = &subgraph.SubgraphClientMock{
+		ExecuteQueryFunc: func(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+			list := response.(*struct {
+				Epoches []epoch.EpochSummary `json:"epoches"`
+				Latest  []epoch.EpochSummary `json:"latest"`
+			})
+			list.Epoches = []epoch.EpochSummary{{EpochNumber: "2"}, {EpochNumber: "1"}}
+			list.Latest = list.Epoches[:1]
+			return nil
+		},
+	}
+	service.SetSnapshots(snapshotStoreFunc(func(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+		assert.Equal(t, testVault, vaultID)
+		if epochNumber.Int64() == 1 {
+			return &merkle.MerkleSnapshot{Fingerprint: "0xabc", EligibilityCID: "bafkreiepoch1"}, nil
+		}
+		return nil, fmt.Errorf("%w: epoch %s", merkle.ErrNotFound, epochNumber)
+	}))
+
+	list, err := service.ListEpochs(context.Background(), epoch.ListEpochsQuery{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, list.Epochs, 2)
+	assert.Empty(t, list.Epochs[0].DistributionFingerprint, "epochs not distributed yet report nothing")
+	assert.Equal(t, "0xabc", list.Epochs[1].DistributionFingerprint)
+	assert.Equal(t, "bafkreiepoch1", list.Epochs[1].EligibilityCID)
+}
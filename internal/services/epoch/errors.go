@@ -8,4 +8,10 @@ var (
 	ErrNotFound          = errors.New("resource not found")
 	ErrTimeout           = errors.New("operation timed out")
 	ErrInvalidEpochState = errors.New("epoch is not in valid state for operation")
+
+	// ErrEpochNotActive is returned for an operation on the current epoch when no epoch was started
+	ErrEpochNotActive = errors.New("no epoch is active")
+
+	// ErrYieldAlreadyAllocated is returned when yield was already allocated to the current epoch
+	ErrYieldAlreadyAllocated = errors.New("yield already allocated to epoch")
 )
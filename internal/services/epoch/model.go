@@ -5,7 +5,10 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/merkle"
 )
 
 // UserEarningsResponse represents the response for user total earned query
@@ -49,12 +52,184 @@ type CompleteEpochResponse struct {
 	YieldDistributed bool   `json:"yieldDistributed"`
 }
 
+// EpochSummary represents an epoch as indexed by the subgraph
+type EpochSummary struct {
+	EpochNumber                  string `json:"epochNumber"`
+	Status                       string `json:"status"`
+	StartTimestamp               string `json:"startTimestamp"`
+	EndTimestamp                 string `json:"endTimestamp"`
+	ProcessingCompletedTimestamp string `json:"processingCompletedTimestamp,omitempty"`
+	TotalSubsidiesDistributed    string `json:"totalSubsidiesDistributed,omitempty"`
+	TotalYieldDistributed        string `json:"totalYieldDistributed,omitempty"`
+	YieldAllocated               string `json:"yieldAllocated,omitempty"` // wei the server allocated to the epoch
+	YieldHeldBack                string `json:"yieldHeldBack,omitempty"`  // wei kept in the vault as reserve when allocating
+	// DistributionFingerprint is the hash of the inputs the epoch's distribution was computed from
+	DistributionFingerprint string `json:"distributionFingerprint,omitempty"`
+	// EligibilityCID is the IPFS CID of the epoch's published leaves and root, for verifying the distribution
+	EligibilityCID string `json:"eligibilityCid,omitempty"`
+}
+
+// YieldAllocation records the vault yield the server allocated to an epoch and the reserve it held back.
+// Amounts are wei; what is held back stays in the vault's remaining cumulative yield.
+type YieldAllocation struct {
+	EpochID         string             `json:"epochId"`
+	VaultAddress    string             `json:"vaultAddress"`
+	Available       string             `json:"available"` // yield of every source before allocating
+	Allocated       string             `json:"allocated"`
+	HeldBack        string             `json:"heldBack"`
+	ReservePercent  uint64             `json:"reservePercent"`
+	Sources         []YieldSourceShare `json:"sources,omitempty"`
+	TransactionSent bool               `json:"transactionSent"` // false when nothing was left to allocate after the reserve
+	AllocatedAt     time.Time          `json:"allocatedAt"`
+}
+
+// YieldSourceShare is what one yield source contributed to an allocation. The reserve is held back from every
+// source in proportion to its available yield.
+type YieldSourceShare struct {
+	Source    string `json:"source"`
+	Available string `json:"available"`
+	Allocated string `json:"allocated"`
+}
+
+// YieldSource is somewhere the yield allocated to epochs comes from, each read from the chain its own way
+type YieldSource interface {
+	// Name identifies the source in allocations as the YIELD_SOURCES entry it was configured with
+	Name() string
+	// Available returns the wei the source holds for the vault that was not allocated to an epoch yet
+	Available(ctx context.Context, vaultId string) (*big.Int, error)
+}
+
+// ListEpochsQuery selects a page of the epochs known to the subgraph
+type ListEpochsQuery struct {
+	Limit     int
+	Offset    int
+	Ascending bool // oldest first instead of newest first
+}
+
+// ListEpochsResponse represents the response for listing epochs
+type ListEpochsResponse struct {
+	Epochs []EpochSummary `json:"epochs"`
+	Count  int            `json:"count"`
+	Total  int            `json:"total"` // epochs the subgraph knows of, across all pages
+}
+
+// epoch timeline milestones, in processing order
+const (
+	MilestoneStarted             = "started"              // EpochStarted, from the subgraph
+	MilestoneSnapshotTaken       = "snapshot_taken"       // time of the snapshot block the distribution valued accruals at
+	MilestoneAllocationsComputed = "allocations_computed" // the distribution's merkle snapshot was saved
+	MilestoneRootSubmitted       = "root_submitted"       // the endEpochWithSubsidies transaction was broadcast
+	MilestoneConfirmed           = "confirmed"            // EpochFinalized was mined, from the subgraph
+	MilestoneClaimsOpened        = "claims_opened"        // the root transaction settled and the server recorded it, claims verify against a final root
+)
+
+// Milestone is a step of an epoch's processing. Steps not reached yet have no timestamp.
+type Milestone struct {
+	Name      string `json:"name" example:"snapshot_taken"`
+	Reached   bool   `json:"reached"`
+	Timestamp int64  `json:"timestamp,omitempty"` // unix seconds
+	// DurationSeconds is the time since the previous reached milestone
+	DurationSeconds int64  `json:"durationSeconds,omitempty"`
+	Source          string `json:"source" example:"snapshot"`           // subgraph, snapshot or audit
+	Detail          string `json:"detail,omitempty" example:"18500000"` // snapshot block or transaction hash
+}
+
+// Timeline is an epoch's processing as ordered milestones, for rendering it as a Gantt chart
+type Timeline struct {
+	EpochID      string      `json:"epochId"`
+	VaultAddress string      `json:"vaultAddress"`
+	Status       string      `json:"status,omitempty"` // epoch status in the subgraph
+	Milestones   []Milestone `json:"milestones"`
+	// TotalSeconds is the time from the first to the last reached milestone
+	TotalSeconds int64 `json:"totalSeconds"`
+}
+
+// OnChainStateResponse is the epoch, yield and subsidy state the contracts report for a vault, decoded.
+// Amounts are wei and every value was read at the same block.
+type OnChainStateResponse struct {
+	VaultAddress          string `json:"vaultAddress"`
+	EpochManager          string `json:"epochManager"`
+	DebtSubsidizer        string `json:"debtSubsidizer"`
+	BlockNumber           uint64 `json:"blockNumber"`
+	BlockHash             string `json:"blockHash"`
+	BlockTimestamp        uint64 `json:"blockTimestamp"`
+	CurrentEpochID        string `json:"currentEpochId"`
+	VaultYieldForEpoch    string `json:"vaultYieldForEpoch"`  // yield the EpochManager holds for the vault in the current epoch
+	TotalYieldAllocated   string `json:"totalYieldAllocated"` // vault yield allocated to epochs so far
+	TotalYieldReserved    string `json:"totalYieldReserved"`
+	MerkleRoot            string `json:"merkleRoot"` // root claims are verified against, 0x-prefixed
+	TotalSubsidies        string `json:"totalSubsidies"`
+	TotalSubsidiesClaimed string `json:"totalSubsidiesClaimed"`
+	RemainingSubsidies    string `json:"remainingSubsidies"`
+	// when the current epoch started and ends, left out when it cannot be read
+	Timing *EpochTiming `json:"timing,omitempty"`
+}
+
+// EpochTiming is when the EpochManager's current epoch started and ends, from its EpochStarted event, against the
+// chain's clock: the timestamp of the latest block. Epoch 0 was never started and counts as ended.
+type EpochTiming struct {
+	EpochID         string `json:"epochId" example:"5"`
+	StartTime       uint64 `json:"startTime,omitempty" example:"1717200000"` // unix seconds
+	EndTime         uint64 `json:"endTime,omitempty" example:"1717804800"`
+	DurationSeconds uint64 `json:"durationSeconds,omitempty" example:"604800"`
+	StartTxHash     string `json:"startTxHash,omitempty"`
+	StartBlock      uint64 `json:"startBlock,omitempty" example:"19000000"`
+	ChainTime       uint64 `json:"chainTime" example:"1717500000"` // timestamp of the latest block
+	BlockNumber     uint64 `json:"blockNumber" example:"19025000"`
+	Ended           bool   `json:"ended"` // the chain reached EndTime, the epoch can be ended and the next one started
+}
+
+// CollectionSummary represents a collection participating in a vault, as indexed by the subgraph
+type CollectionSummary struct {
+	ID                   string `json:"id"`
+	ContractAddress      string `json:"contractAddress"`
+	Name                 string `json:"name"`
+	Symbol               string `json:"symbol"`
+	CollectionType       string `json:"collectionType"`
+	IsActive             bool   `json:"isActive"`
+	YieldSharePercentage string `json:"yieldSharePercentage"`
+	TotalNFTsDeposited   string `json:"totalNFTsDeposited"`
+}
+
+// ListCollectionsResponse represents the response for listing a vault's collections
+type ListCollectionsResponse struct {
+	VaultAddress string              `json:"vaultAddress"`
+	Collections  []CollectionSummary `json:"collections"`
+	Count        int                 `json:"count"`
+}
+
+// UserAllocation represents a user's subsidy accrual for one collection
+type UserAllocation struct {
+	CollectionAddress  string `json:"collectionAddress"`
+	SecondsAccumulated string `json:"secondsAccumulated"`
+	SecondsClaimed     string `json:"secondsClaimed"`
+	SubsidiesAccrued   string `json:"subsidiesAccrued"`
+	SubsidiesClaimed   string `json:"subsidiesClaimed"`
+	TotalRewardsEarned string `json:"totalRewardsEarned"`
+	UpdatedAtTimestamp string `json:"updatedAtTimestamp"`
+}
+
+// UserAllocationsResponse represents the response for a user's allocations in a vault
+type UserAllocationsResponse struct {
+	UserAddress  string           `json:"userAddress"`
+	VaultAddress string           `json:"vaultAddress"`
+	Allocations  []UserAllocation `json:"allocations"`
+	Count        int              `json:"count"`
+}
+
 // ContractClient interface for blockchain operations
 type ContractClient interface {
 	StartEpoch(ctx context.Context) error
 	GetCurrentEpochId(ctx context.Context) (*big.Int, error)
 	ForceEndEpochWithZeroYield(ctx context.Context, epochId *big.Int, vaultAddress string) error
 	EndEpochWithSubsidies(ctx context.Context, epochId *big.Int, vaultAddress string, merkleRoot [32]byte, subsidiesDistributed *big.Int) error
+	GetOnChainEpochState(ctx context.Context, vaultAddress string) (*blockchain.OnChainEpochState, error)
+	GetEpochStart(ctx context.Context, epochId *big.Int, fromBlock, toBlock uint64) (*blockchain.EpochStart, error)
+	GetBlockRef(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error)
+	GetRemainingCumulativeYield(ctx context.Context, vaultAddress string) (*big.Int, error)
+	GetEpochYieldAllocated(ctx context.Context, epochId *big.Int, vaultAddress string) (*big.Int, error)
+	AllocateCumulativeYieldToEpoch(ctx context.Context, epochId *big.Int, vaultAddress string, amount *big.Int) error
+	GetTokenBalance(ctx context.Context, tokenAddress, holderAddress string) (*big.Int, error)
 }
 
 // SubgraphClient interface for querying subgraph data
@@ -68,13 +243,24 @@ type Calculator interface {
 	CalculateTotalEarned(subsidy subgraph.AccountSubsidy, epochEndTime int64) (*big.Int, error)
 }
 
+// SnapshotStore reads the merkle snapshots distributions saved for epochs
+type SnapshotStore interface {
+	// GetSnapshot returns the vault's snapshot for the epoch, wrapping merkle.ErrNotFound when there is none
+	GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error)
+}
+
+// AuditLog lists the audit entries of the transactions the server sent
+type AuditLog interface {
+	List(ctx context.Context, filter audit.Filter) (*audit.ListResponse, error)
+}
+
 // EpochInfo represents information about an epoch
 type EpochInfo struct {
 	Number      *big.Int  `json:"number"`
 	StartTime   time.Time `json:"startTime"`
 	EndTime     time.Time `json:"endTime"`
 	BlockNumber int64     `json:"blockNumber"`
-	Status      string    `json:"status"` // "pending", "active", "completed"
+	Status      string    `json:"status"` // "pending", "active", "completed", "failed"
 	VaultID     string    `json:"vaultId"`
 	CreatedAt   time.Time `json:"createdAt"`
 	UpdatedAt   time.Time `json:"updatedAt"`
@@ -0,0 +1,18 @@
+package events
+
+import (
+	"context"
+)
+
+//go:generate moq -out events_mocks.go . Publisher
+
+// Publisher hands domain events to the subsystems subscribed to them, so the services producing events do not
+// call webhooks, metrics, the audit log or caches themselves
+type Publisher interface {
+	// Publish delivers event to every handler subscribed to its type before returning
+	Publish(ctx context.Context, event Event)
+}
+
+// Handler reacts to a published event. Handlers run on the publisher's goroutine, so slow work belongs in a
+// queue of the subscriber's own, like webhook delivery.
+type Handler func(ctx context.Context, event Event)
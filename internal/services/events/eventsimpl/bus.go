@@ -0,0 +1,70 @@
+package eventsimpl
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/go-pkgz/lgr"
+)
+
+type subscription struct {
+	name    string
+	handler events.Handler
+}
+
+// Bus delivers every published event to the handlers subscribed to its type, synchronously and in the order
+// they subscribed. A handler that panics is logged and skipped, the others still run.
+type Bus struct {
+	mu            sync.RWMutex
+	subscriptions map[events.Type][]subscription
+	logger        lgr.L
+	now           func() time.Time
+}
+
+func New(logger lgr.L) *Bus {
+	return &Bus{
+		subscriptions: make(map[events.Type][]subscription),
+		logger:        logger,
+		now:           time.Now,
+	}
+}
+
+// Subscribe registers handler, named for logs, for the given event types, or for every type when none is given
+func (b *Bus) Subscribe(name string, handler events.Handler, types ...events.Type) {
+	if len(types) == 0 {
+		types = events.Types
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, eventType := range types {
+		b.subscriptions[eventType] = append(b.subscriptions[eventType], subscription{name: name, handler: handler})
+	}
+}
+
+// Publish delivers event to its type's subscribers
+func (b *Bus) Publish(ctx context.Context, event events.Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = b.now().UTC()
+	}
+
+	b.mu.RLock()
+	subscriptions := b.subscriptions[event.Type]
+	b.mu.RUnlock()
+
+	b.logger.Logf("DEBUG publishing %s for vault %s epoch %q to %d subscribers", event.Type, event.Vault, event.EpochID, len(subscriptions))
+	for _, sub := range subscriptions {
+		b.deliver(ctx, sub, event)
+	}
+}
+
+func (b *Bus) deliver(ctx context.Context, sub subscription, event events.Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			b.logger.Logf("ERROR %s subscriber panicked handling %s: %v", sub.name, event.Type, r)
+		}
+	}()
+	sub.handler(ctx, event)
+}
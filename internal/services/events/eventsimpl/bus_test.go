@@ -0,0 +1,82 @@
+package eventsimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func TestBus_Publish(t *testing.T) {
+	bus := New(lgr.NoOp)
+	bus.now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	var delivered []string
+	bus.Subscribe("first", func(ctx context.Context, event events.Event) {
+		delivered = append(delivered, "first:"+string(event.Type))
+	}, events.EpochStarted)
+	bus.Subscribe("broken", func(ctx context.Context, event events.Event) {
+		panic("subscriber bug")
+	})
+	bus.Subscribe("all", func(ctx context.Context, event events.Event) {
+		assert.Equal(t, int64(1700000000), event.OccurredAt.Unix())
+		delivered = append(delivered, "all:"+string(event.Type))
+	})
+
+	bus.Publish(context.Background(), events.Event{Type: events.EpochStarted, EpochID: "7"})
+	bus.Publish(context.Background(), events.Event{Type: events.RootSubmitted, EpochID: "7"})
+
+	assert.Equal(t, []string{"first:epoch.started", "all:epoch.started", "all:root.submitted"}, delivered,
+		"subscribers run in order, a panicking one does not stop the rest")
+}
+
+func TestSubscribers(t *testing.T) {
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	registry := metrics.NewRegistry()
+	invalidated := 0
+
+	bus := New(lgr.NoOp)
+	bus.Subscribe("cache", CacheHandler(cacheFunc(func() { invalidated++ })), CacheTypes...)
+	bus.Subscribe("webhook", WebhookHandler(notifier), WebhookTypes...)
+	bus.Subscribe("metrics", MetricsHandler(registry))
+	bus.Subscribe("audit", AuditHandler(recorder, lgr.NoOp), AuditTypes...)
+
+	ctx := context.Background()
+	bus.Publish(ctx, events.Event{Type: events.EpochSnapshotted, EpochID: "7", Vault: "0xvault",
+		Data: map[string]interface{}{"blockNumber": uint64(100)}})
+	bus.Publish(ctx, events.Event{Type: events.RootSubmitted, EpochID: "7", Vault: "0xvault",
+		Data: map[string]interface{}{"merkleRoot": "0xabc"}})
+	bus.Publish(ctx, events.Event{Type: events.RootSubmitted, Vault: "0xvault"})
+
+	assert.Equal(t, 2, invalidated, "a snapshot changes nothing on-chain")
+
+	notified := notifier.NotifyCalls()
+	require.Len(t, notified, 2)
+	assert.Equal(t, webhook.EventMerkleRootUpdated, notified[0].EventType)
+	assert.Equal(t, map[string]interface{}{"merkleRoot": "0xabc", "epochId": "7", "vaultAddress": "0xvault"}, notified[0].Data)
+	assert.NotContains(t, notified[1].Data, "epochId", "distributions outside epochs carry no epoch")
+
+	recorded := recorder.RecordCalls()
+	require.Len(t, recorded, 1)
+	assert.Equal(t, "epoch.snapshotted", recorded[0].Entry.Action)
+	assert.Equal(t, map[string]string{"blockNumber": "100", "epochId": "7", "vault": "0xvault"}, recorded[0].Entry.Parameters)
+
+	count, ok := registry.Gauge("epoch_server_event_root_submitted_total")
+	require.True(t, ok)
+	assert.Equal(t, float64(2), count)
+	_, ok = registry.Gauge("epoch_server_event_epoch_snapshotted_timestamp_seconds")
+	assert.True(t, ok)
+}
+
+type cacheFunc func()
+
+func (f cacheFunc) InvalidateCache() { f() }
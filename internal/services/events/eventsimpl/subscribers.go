@@ -0,0 +1,101 @@
+package eventsimpl
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+// webhookEvents maps the domain events sent to webhook endpoints to their webhook event types
+var webhookEvents = map[events.Type]webhook.EventType{
+	events.EpochStarted:    webhook.EventEpochStarted,
+	events.EpochForceEnded: webhook.EventEpochForceEnded,
+	events.EpochFinalized:  webhook.EventEpochFinalized,
+	events.RootSubmitted:   webhook.EventMerkleRootUpdated,
+}
+
+// WebhookTypes are the event types WebhookHandler forwards
+var WebhookTypes = []events.Type{events.EpochStarted, events.EpochForceEnded, events.EpochFinalized, events.RootSubmitted}
+
+// WebhookHandler forwards events to the webhook endpoints with their data, epochId and vaultAddress as payload
+func WebhookHandler(notifier webhook.Notifier) events.Handler {
+	return func(ctx context.Context, event events.Event) {
+		eventType, ok := webhookEvents[event.Type]
+		if !ok {
+			return
+		}
+		payload := make(map[string]interface{}, len(event.Data)+2)
+		for key, value := range event.Data {
+			payload[key] = value
+		}
+		payload["vaultAddress"] = event.Vault
+		if event.EpochID != "" {
+			payload["epochId"] = event.EpochID
+		}
+		notifier.Notify(ctx, eventType, payload)
+	}
+}
+
+// CacheTypes are the event types that change on-chain state the subgraph indexes
+var CacheTypes = []events.Type{events.EpochStarted, events.EpochForceEnded, events.EpochFinalized, events.RootSubmitted}
+
+// CacheHandler drops cached subgraph responses, which the event made stale
+func CacheHandler(cache interface{ InvalidateCache() }) events.Handler {
+	return func(ctx context.Context, event events.Event) {
+		cache.InvalidateCache()
+	}
+}
+
+// MetricsHandler counts the events of every type and keeps when the last one happened, as
+// epoch_server_event_<type>_total and epoch_server_event_<type>_timestamp_seconds gauges
+func MetricsHandler(registry *metrics.Registry) events.Handler {
+	var mu sync.Mutex
+	counts := make(map[events.Type]float64)
+	return func(ctx context.Context, event events.Event) {
+		mu.Lock()
+		counts[event.Type]++
+		count := counts[event.Type]
+		mu.Unlock()
+
+		name := "epoch_server_event_" + strings.NewReplacer(".", "_", "-", "_").Replace(string(event.Type))
+		registry.SetGauge(name+"_total", fmt.Sprintf("Number of %s events published since startup", event.Type), count)
+		registry.SetGauge(name+"_timestamp_seconds", fmt.Sprintf("Unix time of the last %s event", event.Type),
+			float64(event.OccurredAt.Unix()))
+	}
+}
+
+// AuditTypes are the event types recorded in the audit log. The transactions behind the other types are
+// recorded by the blockchain client, with their hashes.
+var AuditTypes = []events.Type{events.EpochSnapshotted, events.DistributionComputed}
+
+// AuditHandler records events in the audit log, the action being the event type
+func AuditHandler(recorder audit.Recorder, logger lgr.L) events.Handler {
+	return func(ctx context.Context, event events.Event) {
+		parameters := make(map[string]string, len(event.Data)+2)
+		for key, value := range event.Data {
+			parameters[key] = fmt.Sprint(value)
+		}
+		parameters["vault"] = event.Vault
+		if event.EpochID != "" {
+			parameters["epochId"] = event.EpochID
+		}
+
+		entry := audit.Entry{
+			Action:     string(event.Type),
+			Actor:      audit.ActorFromContext(ctx),
+			Parameters: parameters,
+			Result:     audit.ResultSuccess,
+		}
+		// the event already happened, its entry must be written even when the caller is cancelled
+		if err := recorder.Record(context.WithoutCancel(ctx), entry); err != nil {
+			logger.Logf("WARN failed to record %s in audit log: %v", event.Type, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package events
+
+import (
+	"time"
+)
+
+// Type identifies the kind of domain event
+type Type string
+
+const (
+	EpochStarted         Type = "epoch.started"
+	EpochForceEnded      Type = "epoch.force_ended"
+	EpochFinalized       Type = "epoch.finalized"
+	EpochSnapshotted     Type = "epoch.snapshotted"     // an epoch's snapshot block is final and its merkle tree built
+	DistributionComputed Type = "distribution.computed" // a distribution passed its checks and is about to be submitted or staged
+	RootSubmitted        Type = "root.submitted"        // a distribution's merkle root was pushed on-chain
+)
+
+// Types lists every event type, in the order of an epoch's lifecycle
+var Types = []Type{EpochStarted, EpochSnapshotted, DistributionComputed, RootSubmitted, EpochFinalized, EpochForceEnded}
+
+// Event is something that happened to an epoch or its distribution. EpochID is empty for distributions run
+// outside an epoch.
+type Event struct {
+	Type       Type
+	EpochID    string
+	Vault      string
+	OccurredAt time.Time              // set by the bus when left zero
+	Data       map[string]interface{} // event specific details, shaped like the webhook payloads
+}
@@ -0,0 +1,8 @@
+package features
+
+import "errors"
+
+// Predefined error types for feature flag operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
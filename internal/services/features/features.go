@@ -0,0 +1,31 @@
+package features
+
+import (
+	"context"
+)
+
+//go:generate moq -out features_mocks.go . Service
+
+// Service keeps which risky behaviors are switched on. Each flag starts from the features file of the environment,
+// on when the file does not set it, and operators override it through the admin API. Overrides are stored, so they
+// survive restarts and apply to every replica sharing the database.
+type Service interface {
+	// Enabled reports whether flag is on. When its override cannot be read the configured state is returned.
+	Enabled(ctx context.Context, flag string) bool
+	// Set overrides flag, switching it on or off until the override is cleared
+	Set(ctx context.Context, flag string, enabled bool, reason string) (*FlagState, error)
+	// Clear removes the override of flag, returning it to its configured state
+	Clear(ctx context.Context, flag string) (*FlagState, error)
+	// Status returns the state of every flag
+	Status(ctx context.Context) (*Status, error)
+}
+
+// ValidFlag reports whether flag is known
+func ValidFlag(flag string) bool {
+	for _, known := range Flags {
+		if flag == known.Name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,203 @@
+package featuresimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// audit actions recorded for feature flag changes
+const (
+	actionSetFeature   = "setFeatureFlag"
+	actionClearFeature = "clearFeatureFlag"
+)
+
+type Service struct {
+	store      *Store
+	configured map[string]bool // flags the features file sets, the others are on
+	recorder   audit.Recorder  // nil disables audit entries
+	logger     lgr.L
+	now        func() time.Time
+}
+
+// New reads the features file when one is configured. A file that cannot be read, or that sets a flag that is not
+// known, is an error, so a typo does not leave a behavior on that was meant to be off.
+func New(db storage.DB, recorder audit.Recorder, logger lgr.L, cfg *config.Config) (*Service, error) {
+	configured, err := loadFile(cfg.Features.File)
+	if err != nil {
+		return nil, err
+	}
+	for flag, enabled := range configured {
+		if !enabled {
+			logger.Logf("INFO feature %s is switched off by %s", flag, cfg.Features.File)
+		}
+	}
+	return &Service{
+		store:      NewStore(db, logger),
+		configured: configured,
+		recorder:   recorder,
+		logger:     logger,
+		now:        time.Now,
+	}, nil
+}
+
+// loadFile reads the flags set by the features file at path, none when path is empty
+func loadFile(path string) (map[string]bool, error) {
+	if path == "" {
+		return map[string]bool{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read features file: %w", err)
+	}
+	var configured map[string]bool
+	if err := json.Unmarshal(data, &configured); err != nil {
+		return nil, fmt.Errorf("failed to parse features file %s: %w", path, err)
+	}
+	for flag := range configured {
+		if !features.ValidFlag(flag) {
+			return nil, fmt.Errorf("features file %s sets unknown flag %q", path, flag)
+		}
+	}
+	if configured == nil {
+		configured = map[string]bool{}
+	}
+	return configured, nil
+}
+
+// Enabled returns the override of flag when it has one and its configured state otherwise. The override is read
+// on every call, so a flag switched off on one replica is off on all of them from their next check.
+func (s *Service) Enabled(ctx context.Context, flag string) bool {
+	override, err := s.store.GetOverride(flag)
+	if err != nil {
+		s.logger.Logf("WARN failed to read override of feature %s, using its configured state: %v", flag, err)
+		return s.isConfigured(flag)
+	}
+	if override != nil {
+		return override.Enabled
+	}
+	return s.isConfigured(flag)
+}
+
+// Set stores an override of flag by the context's actor. Setting an overridden flag replaces its override.
+func (s *Service) Set(ctx context.Context, flag string, enabled bool, reason string) (_ *features.FlagState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "features.Set", attribute.String("features.flag", flag))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !features.ValidFlag(flag) {
+		return nil, fmt.Errorf("%w: unknown feature flag %q", features.ErrInvalidInput, flag)
+	}
+
+	updatedAt := s.now().UTC()
+	override := features.FlagState{
+		Flag:      flag,
+		Enabled:   enabled,
+		Source:    features.SourceOverride,
+		Reason:    reason,
+		UpdatedBy: audit.ActorFromContext(ctx),
+		UpdatedAt: &updatedAt,
+	}
+	if err := s.store.SaveOverride(override); err != nil {
+		return nil, err
+	}
+
+	s.logger.Logf("WARN feature %s switched %s by %s: %s", flag, onOff(enabled), override.UpdatedBy, reason)
+	s.record(ctx, actionSetFeature, map[string]string{"flag": flag, "enabled": strconv.FormatBool(enabled), "reason": reason})
+	return s.state(flag, &override), nil
+}
+
+// Clear removes the override of flag, clearing a flag without one is a no-op
+func (s *Service) Clear(ctx context.Context, flag string) (_ *features.FlagState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "features.Clear", attribute.String("features.flag", flag))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !features.ValidFlag(flag) {
+		return nil, fmt.Errorf("%w: unknown feature flag %q", features.ErrInvalidInput, flag)
+	}
+	if err := s.store.DeleteOverride(flag); err != nil {
+		return nil, err
+	}
+
+	state := s.state(flag, nil)
+	s.logger.Logf("INFO override of feature %s cleared by %s, it is %s", flag, audit.ActorFromContext(ctx), onOff(state.Enabled))
+	s.record(ctx, actionClearFeature, map[string]string{"flag": flag})
+	return state, nil
+}
+
+// Status returns the state of every flag, overridden or not
+func (s *Service) Status(ctx context.Context) (_ *features.Status, err error) {
+	_, span := tracing.StartSpan(ctx, "features.Status")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	status := &features.Status{Flags: make([]features.FlagState, 0, len(features.Flags))}
+	for _, flag := range features.Flags {
+		override, err := s.store.GetOverride(flag.Name)
+		if err != nil {
+			return nil, err
+		}
+		status.Flags = append(status.Flags, *s.state(flag.Name, override))
+	}
+	return status, nil
+}
+
+// state is the state of flag with override, nil when it has none
+func (s *Service) state(flag string, override *features.FlagState) *features.FlagState {
+	state := features.FlagState{Flag: flag, Source: features.SourceDefault, Configured: s.isConfigured(flag)}
+	if _, ok := s.configured[flag]; ok {
+		state.Source = features.SourceFile
+	}
+	state.Enabled = state.Configured
+	if override != nil {
+		state.Enabled = override.Enabled
+		state.Source = features.SourceOverride
+		state.Reason = override.Reason
+		state.UpdatedBy = override.UpdatedBy
+		state.UpdatedAt = override.UpdatedAt
+	}
+	for _, known := range features.Flags {
+		if known.Name == flag {
+			state.Description = known.Description
+		}
+	}
+	return &state
+}
+
+// isConfigured reports whether the features file leaves flag on
+func (s *Service) isConfigured(flag string) bool {
+	enabled, ok := s.configured[flag]
+	return !ok || enabled
+}
+
+// record writes a feature flag change to the audit log. Failures are logged, the change itself is already stored.
+func (s *Service) record(ctx context.Context, action string, parameters map[string]string) {
+	if s.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action:     action,
+		Actor:      audit.ActorFromContext(ctx),
+		Parameters: parameters,
+		Result:     audit.ResultSuccess,
+	}
+	if err := s.recorder.Record(ctx, entry); err != nil {
+		s.logger.Logf("WARN failed to record %s in audit log: %v", action, err)
+	}
+}
+
+func onOff(enabled bool) string {
+	if enabled {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,120 @@
+package featuresimpl
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/features"
+)
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+// configWithFile returns a configuration reading the features file with content, none when content is empty
+func configWithFile(t *testing.T, content string) *config.Config {
+	cfg := &config.Config{}
+	if content != "" {
+		cfg.Features.File = filepath.Join(t.TempDir(), "features.json")
+		require.NoError(t, os.WriteFile(cfg.Features.File, []byte(content), 0o600))
+	}
+	return cfg
+}
+
+func TestService_ConfiguredState(t *testing.T) {
+	service, err := New(newTestDB(t), nil, lgr.NoOp, configWithFile(t, `{"delta_merkle_trees": false, "auto_approval": true}`))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	assert.False(t, service.Enabled(ctx, features.FlagDeltaMerkleTrees), "the file switches it off")
+	assert.True(t, service.Enabled(ctx, features.FlagAutoApproval))
+	assert.True(t, service.Enabled(ctx, features.FlagTimeWeightedEligibility), "flags the file does not set are on")
+
+	status, err := service.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Flags, len(features.Flags))
+	sources := map[string]string{}
+	for _, state := range status.Flags {
+		sources[state.Flag] = state.Source
+		assert.NotEmpty(t, state.Description)
+	}
+	assert.Equal(t, map[string]string{
+		features.FlagTimeWeightedEligibility: features.SourceDefault,
+		features.FlagDeltaMerkleTrees:        features.SourceFile,
+		features.FlagAutoApproval:            features.SourceFile,
+	}, sources)
+}
+
+func TestService_SetAndClear(t *testing.T) {
+	recorder := &audit.RecorderMock{
+		RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil },
+	}
+	db := newTestDB(t)
+	cfg := configWithFile(t, `{"delta_merkle_trees": false}`)
+	service, err := New(db, recorder, lgr.NoOp, cfg)
+	require.NoError(t, err)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	state, err := service.Set(ctx, features.FlagAutoApproval, false, "unexpected totals")
+	require.NoError(t, err)
+	assert.False(t, state.Enabled)
+	assert.True(t, state.Configured)
+	assert.Equal(t, features.SourceOverride, state.Source)
+	assert.Equal(t, "api:10.0.0.1", state.UpdatedBy)
+	require.NotNil(t, state.UpdatedAt)
+	assert.False(t, service.Enabled(ctx, features.FlagAutoApproval))
+
+	_, err = service.Set(ctx, features.FlagDeltaMerkleTrees, true, "rolling out")
+	require.NoError(t, err)
+	assert.True(t, service.Enabled(ctx, features.FlagDeltaMerkleTrees), "an override wins over the file")
+
+	// overrides are stored, so a restarted service keeps them
+	restarted, err := New(db, nil, lgr.NoOp, cfg)
+	require.NoError(t, err)
+	assert.False(t, restarted.Enabled(ctx, features.FlagAutoApproval))
+
+	state, err = service.Clear(ctx, features.FlagDeltaMerkleTrees)
+	require.NoError(t, err)
+	assert.False(t, state.Enabled, "clearing returns the flag to the file's state")
+	assert.Equal(t, features.SourceFile, state.Source)
+	assert.False(t, service.Enabled(ctx, features.FlagDeltaMerkleTrees))
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "setFeatureFlag", calls[0].Entry.Action)
+	assert.Equal(t, map[string]string{"flag": "auto_approval", "enabled": "false", "reason": "unexpected totals"}, calls[0].Entry.Parameters)
+	assert.Equal(t, "clearFeatureFlag", calls[2].Entry.Action)
+}
+
+func TestService_UnknownFlag(t *testing.T) {
+	service, err := New(newTestDB(t), nil, lgr.NoOp, configWithFile(t, ""))
+	require.NoError(t, err)
+
+	_, err = service.Set(context.Background(), "new_thing", false, "")
+	require.ErrorIs(t, err, features.ErrInvalidInput)
+	_, err = service.Clear(context.Background(), "new_thing")
+	require.ErrorIs(t, err, features.ErrInvalidInput)
+
+	_, err = New(newTestDB(t), nil, lgr.NoOp, configWithFile(t, `{"delta_merkle_tree": false}`))
+	require.Error(t, err, "a misspelled flag in the file is refused")
+	_, err = New(newTestDB(t), nil, lgr.NoOp, configWithFile(t, `not json`))
+	require.Error(t, err)
+}
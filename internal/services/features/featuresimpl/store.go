@@ -0,0 +1,78 @@
+package featuresimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const overridePrefix = "features:override:"
+
+// Store handles storage of feature flag overrides
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveOverride stores the override of a flag, replacing an earlier one
+func (s *Store) SaveOverride(state features.FlagState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal feature flag override: %w", err)
+	}
+
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(overridePrefix+state.Flag), data)
+	}); err != nil {
+		return fmt.Errorf("failed to save override of feature flag %s: %w", state.Flag, err)
+	}
+
+	return nil
+}
+
+// DeleteOverride removes the override of flag
+func (s *Store) DeleteOverride(flag string) error {
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Delete([]byte(overridePrefix + flag))
+	}); err != nil {
+		return fmt.Errorf("failed to delete override of feature flag %s: %w", flag, err)
+	}
+
+	return nil
+}
+
+// GetOverride returns the override of flag, or nil when it has none
+func (s *Store) GetOverride(flag string) (*features.FlagState, error) {
+	var state *features.FlagState
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(overridePrefix + flag))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			state = &features.FlagState{}
+			return json.Unmarshal(val, state)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get override of feature flag %s: %w", flag, err)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,56 @@
+package features
+
+import (
+	"time"
+)
+
+// flags gating behaviors that change what is distributed or how, each can be switched off at runtime
+const (
+	// FlagTimeWeightedEligibility shares an epoch's accrual by holding time when HOLDINGS_ELIGIBILITY is time_weighted;
+	// switched off, epochs are valued as the snapshot block saw the holders
+	FlagTimeWeightedEligibility = "time_weighted_eligibility"
+	// FlagDeltaMerkleTrees rebuilds a vault's tree from the last one built for it; switched off, every tree is
+	// built in full
+	FlagDeltaMerkleTrees = "delta_merkle_trees"
+	// FlagAutoApproval pushes distributions within the auto-approve thresholds without an approval when approval
+	// is enabled; switched off, every distribution is staged
+	FlagAutoApproval = "auto_approval"
+)
+
+// where a flag's state comes from
+const (
+	SourceDefault  = "default"  // neither the features file nor an override sets it, so it is on
+	SourceFile     = "file"     // the features file sets it
+	SourceOverride = "override" // an operator set it through the admin API
+)
+
+// Flag is a behavior that can be switched off
+type Flag struct {
+	Name        string
+	Description string
+}
+
+// Flags lists every flag
+var Flags = []Flag{
+	{Name: FlagTimeWeightedEligibility, Description: "Share epoch accrual by holding time when time-weighted eligibility is configured"},
+	{Name: FlagDeltaMerkleTrees, Description: "Rebuild each vault's merkle tree incrementally from the last one built for it"},
+	{Name: FlagAutoApproval, Description: "Push distributions within the auto-approve thresholds without an approval"},
+}
+
+// FlagState is whether a flag is on, and who set it
+type FlagState struct {
+	Flag        string `json:"flag" example:"auto_approval"`
+	Description string `json:"description,omitempty"`
+	Enabled     bool   `json:"enabled"`
+	Source      string `json:"source" example:"override"`
+	// Configured is what the features file or the default sets, which the flag returns to when its override is cleared
+	Configured bool       `json:"configured"`
+	Reason     string     `json:"reason,omitempty" example:"allocation drift on sepolia"`
+	UpdatedBy  string     `json:"updatedBy,omitempty" example:"api:10.0.0.1"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Status is the state of every flag
+type Status struct {
+	Flags []FlagState `json:"flags"`
+}
@@ -0,0 +1,8 @@
+package gas
+
+import "errors"
+
+// Predefined error types for gas reporting operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
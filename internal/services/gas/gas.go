@@ -0,0 +1,41 @@
+package gas
+
+import (
+	"context"
+)
+
+//go:generate moq -out gas_mocks.go . Recorder Service
+
+// Recorder stores the gas paid by mined transactions
+type Recorder interface {
+	// Record stores a mined transaction's gas usage and checks it against the monthly budget
+	Record(ctx context.Context, usage Usage) error
+}
+
+// Service defines the interface for gas spend reporting
+type Service interface {
+	Recorder
+
+	// Report rolls up the gas spent in the filter's window by operation and by epoch
+	Report(ctx context.Context, filter ReportFilter) (*Report, error)
+
+	// Transactions returns the usage of every mined transaction the filter matches, oldest first
+	Transactions(ctx context.Context, filter ReportFilter) ([]Usage, error)
+}
+
+// OperationForAction returns the operation type a contract call is reported under
+func OperationForAction(action string) string {
+	switch action {
+	case "startEpoch":
+		return OperationEpochStart
+	case "endEpochWithSubsidies", "forceEndEpochWithZeroYield", "allocateYieldToEpoch",
+		"allocateCumulativeYieldToEpoch", "updateExchangeRate":
+		return OperationEpochFinalize
+	case "updateMerkleRoot":
+		return OperationRootUpdate
+	case "repayBorrowBehalfBatch":
+		return OperationBatchRepay
+	default:
+		return OperationOther
+	}
+}
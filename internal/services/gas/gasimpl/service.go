@@ -0,0 +1,293 @@
+package gasimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+// monthLayout formats the UTC calendar month a budget applies to
+const monthLayout = "2006-01"
+
+type Service struct {
+	store    *Store
+	notifier webhook.Notifier
+	logger   lgr.L
+	budget   *big.Int // wei per month, nil when no budget is configured
+	now      func() time.Time
+
+	budgetMu sync.Mutex // serializes budget checks so an overrun alerts once
+}
+
+func New(db storage.DB, notifier webhook.Notifier, logger lgr.L, cfg *config.Config) *Service {
+	s := &Service{
+		store:    NewStore(db, logger),
+		notifier: notifier,
+		logger:   logger,
+		now:      time.Now,
+	}
+	// config.Load rejects malformed budgets
+	if budget, ok := new(big.Int).SetString(cfg.Gas.MonthlyBudget, 10); ok {
+		s.budget = budget
+	}
+	return s
+}
+
+// Record stamps the usage with an ID, timestamp and operation, stores it and then checks the monthly budget.
+// The cost is derived from gas used and price when it is not set.
+func (s *Service) Record(ctx context.Context, usage gas.Usage) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "gas.Record")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if usage.Action == "" {
+		return fmt.Errorf("%w: action cannot be empty", gas.ErrInvalidInput)
+	}
+	if usage.Cost == "" {
+		price, ok := new(big.Int).SetString(usage.GasPrice, 10)
+		if !ok {
+			return fmt.Errorf("%w: invalid gas price %q", gas.ErrInvalidInput, usage.GasPrice)
+		}
+		usage.Cost = new(big.Int).Mul(price, new(big.Int).SetUint64(usage.GasUsed)).String()
+	}
+
+	usage.ID = newUsageID()
+	usage.Timestamp = s.now().UTC()
+	if usage.Operation == "" {
+		usage.Operation = gas.OperationForAction(usage.Action)
+	}
+
+	if err := s.store.AppendUsage(usage); err != nil {
+		s.logger.Logf("ERROR failed to record gas usage of %s: %v", usage.TxHash, err)
+		return err
+	}
+	s.logger.Logf("DEBUG recorded gas usage of %s %s: %d gas, %s wei", usage.Action, usage.TxHash, usage.GasUsed, usage.Cost)
+
+	s.checkBudget(ctx, usage.Timestamp)
+	return nil
+}
+
+// checkBudget alerts once per month, on the first transaction that takes the month's spend over the budget.
+// The alert is remembered in storage so a restart does not repeat it.
+func (s *Service) checkBudget(ctx context.Context, now time.Time) {
+	if s.budget == nil {
+		return
+	}
+
+	s.budgetMu.Lock()
+	defer s.budgetMu.Unlock()
+
+	status, err := s.budgetStatus(now)
+	if err != nil {
+		s.logger.Logf("WARN failed to check gas budget: %v", err)
+		return
+	}
+	if !status.Exceeded {
+		return
+	}
+
+	first, err := s.store.MarkBudgetAlerted(status.Month)
+	if err != nil {
+		s.logger.Logf("WARN %v", err)
+		return
+	}
+	if !first {
+		return
+	}
+
+	s.logger.Logf("WARN gas spent in %s is %s wei, over the monthly budget of %s wei", status.Month, status.Spent, status.Budget)
+	s.notifier.Notify(ctx, webhook.EventGasBudgetExceeded, map[string]interface{}{
+		"month":  status.Month,
+		"budget": status.Budget,
+		"spent":  status.Spent,
+	})
+}
+
+// budgetStatus sums the spend of now's UTC calendar month
+func (s *Service) budgetStatus(now time.Time) (*gas.BudgetStatus, error) {
+	now = now.UTC()
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	spent := big.NewInt(0)
+	err := s.store.WalkUsage(monthStart, time.Time{}, func(usage gas.Usage) {
+		if cost, ok := new(big.Int).SetString(usage.Cost, 10); ok {
+			spent.Add(spent, cost)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &gas.BudgetStatus{
+		Month:     monthStart.Format(monthLayout),
+		Budget:    s.budget.String(),
+		Spent:     spent.String(),
+		Remaining: new(big.Int).Sub(s.budget, spent).String(),
+		Exceeded:  spent.Cmp(s.budget) > 0,
+	}, nil
+}
+
+// Report rolls up usage in the window by operation and by epoch, with the current month's budget status
+func (s *Service) Report(ctx context.Context, filter gas.ReportFilter) (_ *gas.Report, err error) {
+	_, span := tracing.StartSpan(ctx, "gas.Report")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("%w: until must not be before since", gas.ErrInvalidInput)
+	}
+
+	total := newRollup()
+	byOperation := make(map[string]*rollup)
+	epochs := make(map[string]*epochRollup)
+
+	err = s.store.WalkUsage(filter.Since, filter.Until, func(usage gas.Usage) {
+		if filter.EpochID != "" && usage.EpochID != filter.EpochID {
+			return
+		}
+
+		total.add(usage)
+		rollupFor(byOperation, usage.Operation).add(usage)
+
+		epoch, ok := epochs[usage.EpochID]
+		if !ok {
+			epoch = &epochRollup{total: newRollup(), byOperation: make(map[string]*rollup)}
+			epochs[usage.EpochID] = epoch
+		}
+		epoch.total.add(usage)
+		rollupFor(epoch.byOperation, usage.Operation).add(usage)
+	})
+	if err != nil {
+		s.logger.Logf("ERROR failed to build gas report: %v", err)
+		return nil, err
+	}
+
+	report := &gas.Report{
+		Total:       total.result(),
+		ByOperation: results(byOperation),
+		Epochs:      make([]gas.EpochRollup, 0, len(epochs)),
+	}
+	if !filter.Since.IsZero() {
+		report.Since = &filter.Since
+	}
+	if !filter.Until.IsZero() {
+		report.Until = &filter.Until
+	}
+
+	for epochID, epoch := range epochs {
+		report.Epochs = append(report.Epochs, gas.EpochRollup{
+			EpochID:     epochID,
+			Total:       epoch.total.result(),
+			ByOperation: results(epoch.byOperation),
+		})
+	}
+	sort.Slice(report.Epochs, func(i, j int) bool {
+		return epochLess(report.Epochs[i].EpochID, report.Epochs[j].EpochID)
+	})
+
+	if s.budget != nil {
+		if report.Budget, err = s.budgetStatus(s.now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return report, nil
+}
+
+// Transactions lists the usage in the window, only that of the filter's epoch when it is set
+func (s *Service) Transactions(ctx context.Context, filter gas.ReportFilter) (_ []gas.Usage, err error) {
+	_, span := tracing.StartSpan(ctx, "gas.Transactions")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && filter.Until.Before(filter.Since) {
+		return nil, fmt.Errorf("%w: until must not be before since", gas.ErrInvalidInput)
+	}
+
+	usages := make([]gas.Usage, 0)
+	err = s.store.WalkUsage(filter.Since, filter.Until, func(usage gas.Usage) {
+		if filter.EpochID == "" || usage.EpochID == filter.EpochID {
+			usages = append(usages, usage)
+		}
+	})
+	if err != nil {
+		s.logger.Logf("ERROR failed to list gas usage: %v", err)
+		return nil, err
+	}
+	return usages, nil
+}
+
+// rollup accumulates a gas.Rollup without losing precision on the cost
+type rollup struct {
+	transactions int
+	gasUsed      uint64
+	cost         *big.Int
+}
+
+type epochRollup struct {
+	total       *rollup
+	byOperation map[string]*rollup
+}
+
+func newRollup() *rollup {
+	return &rollup{cost: big.NewInt(0)}
+}
+
+func rollupFor(rollups map[string]*rollup, key string) *rollup {
+	r, ok := rollups[key]
+	if !ok {
+		r = newRollup()
+		rollups[key] = r
+	}
+	return r
+}
+
+func (r *rollup) add(usage gas.Usage) {
+	r.transactions++
+	r.gasUsed += usage.GasUsed
+	if cost, ok := new(big.Int).SetString(usage.Cost, 10); ok {
+		r.cost.Add(r.cost, cost)
+	}
+}
+
+func (r *rollup) result() gas.Rollup {
+	return gas.Rollup{Transactions: r.transactions, GasUsed: r.gasUsed, Cost: r.cost.String()}
+}
+
+func results(rollups map[string]*rollup) map[string]gas.Rollup {
+	out := make(map[string]gas.Rollup, len(rollups))
+	for key, r := range rollups {
+		out[key] = r.result()
+	}
+	return out
+}
+
+// epochLess orders epochs numerically, with usage not tied to an epoch last
+func epochLess(a, b string) bool {
+	if a == "" || b == "" {
+		return b == "" && a != ""
+	}
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if !okA || !okB {
+		return a < b
+	}
+	return x.Cmp(y) < 0
+}
+
+func newUsageID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,132 @@
+package gasimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func newTestService(t *testing.T, budget string) (*Service, *webhook.NotifierMock, *time.Time) {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+
+	notifier := &webhook.NotifierMock{
+		NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {},
+	}
+	cfg := &config.Config{}
+	cfg.Gas.MonthlyBudget = budget
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	service := New(db, notifier, lgr.NoOp, cfg)
+	service.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	return service, notifier, &now
+}
+
+func TestService_Report(t *testing.T) {
+	service, _, _ := newTestService(t, "")
+	ctx := context.Background()
+
+	usages := []gas.Usage{
+		{Action: "startEpoch", EpochID: "2", GasUsed: 100, GasPrice: "10"},
+		{Action: "allocateYieldToEpoch", EpochID: "2", GasUsed: 200, GasPrice: "10"},
+		{Action: "updateMerkleRoot", EpochID: "10", GasUsed: 300, GasPrice: "10"},
+		{Action: "repayBorrowBehalfBatch", EpochID: "10", GasUsed: 400, GasPrice: "10"},
+		{Action: "updateMerkleRoot", GasUsed: 50, Cost: "7"},
+	}
+	for _, usage := range usages {
+		require.NoError(t, service.Record(ctx, usage))
+	}
+
+	report, err := service.Report(ctx, gas.ReportFilter{})
+	require.NoError(t, err)
+
+	assert.Equal(t, gas.Rollup{Transactions: 5, GasUsed: 1050, Cost: "10007"}, report.Total)
+	assert.Equal(t, map[string]gas.Rollup{
+		gas.OperationEpochStart:    {Transactions: 1, GasUsed: 100, Cost: "1000"},
+		gas.OperationEpochFinalize: {Transactions: 1, GasUsed: 200, Cost: "2000"},
+		gas.OperationRootUpdate:    {Transactions: 2, GasUsed: 350, Cost: "3007"},
+		gas.OperationBatchRepay:    {Transactions: 1, GasUsed: 400, Cost: "4000"},
+	}, report.ByOperation)
+	assert.Nil(t, report.Budget)
+
+	require.Len(t, report.Epochs, 3)
+	assert.Equal(t, []string{"2", "10", ""},
+		[]string{report.Epochs[0].EpochID, report.Epochs[1].EpochID, report.Epochs[2].EpochID})
+	assert.Equal(t, gas.Rollup{Transactions: 2, GasUsed: 300, Cost: "3000"}, report.Epochs[0].Total)
+
+	filtered, err := service.Report(ctx, gas.ReportFilter{EpochID: "10"})
+	require.NoError(t, err)
+	assert.Equal(t, gas.Rollup{Transactions: 2, GasUsed: 700, Cost: "7000"}, filtered.Total)
+	require.Len(t, filtered.Epochs, 1)
+
+	transactions, err := service.Transactions(ctx, gas.ReportFilter{EpochID: "10"})
+	require.NoError(t, err)
+	require.Len(t, transactions, 2)
+	assert.Equal(t, "updateMerkleRoot", transactions[0].Action, "oldest first")
+	assert.Equal(t, "4000", transactions[1].Cost)
+
+	_, err = service.Report(ctx, gas.ReportFilter{Since: time.Now(), Until: time.Now().Add(-time.Hour)})
+	assert.True(t, errors.Is(err, gas.ErrInvalidInput))
+}
+
+func TestService_ReportWindow(t *testing.T) {
+	service, _, now := newTestService(t, "")
+	ctx := context.Background()
+
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 1, GasPrice: "1"}))
+	since := now.Add(time.Millisecond)
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 2, GasPrice: "1"}))
+	until := now.Add(time.Millisecond)
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 4, GasPrice: "1"}))
+
+	report, err := service.Report(ctx, gas.ReportFilter{Since: since, Until: until})
+	require.NoError(t, err)
+	assert.Equal(t, gas.Rollup{Transactions: 1, GasUsed: 2, Cost: "2"}, report.Total)
+}
+
+func TestService_BudgetAlertsOncePerMonth(t *testing.T) {
+	service, notifier, now := newTestService(t, "1000")
+	ctx := context.Background()
+
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 60, GasPrice: "10"}))
+	assert.Empty(t, notifier.NotifyCalls())
+
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 60, GasPrice: "10"}))
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 60, GasPrice: "10"}))
+
+	calls := notifier.NotifyCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, webhook.EventGasBudgetExceeded, calls[0].EventType)
+	assert.Equal(t, "2025-03", calls[0].Data["month"])
+	assert.Equal(t, "1200", calls[0].Data["spent"])
+
+	report, err := service.Report(ctx, gas.ReportFilter{})
+	require.NoError(t, err)
+	require.NotNil(t, report.Budget)
+	assert.Equal(t, gas.BudgetStatus{Month: "2025-03", Budget: "1000", Spent: "1800", Remaining: "-800", Exceeded: true}, *report.Budget)
+
+	// a new month starts with a fresh budget
+	*now = time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, service.Record(ctx, gas.Usage{Action: "startEpoch", GasUsed: 200, GasPrice: "10"}))
+	assert.Len(t, notifier.NotifyCalls(), 2)
+}
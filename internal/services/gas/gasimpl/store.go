@@ -0,0 +1,108 @@
+package gasimpl
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	usagePrefix       = "gas:usage:"
+	budgetAlertPrefix = "gas:budget-alert:"
+)
+
+// Store handles append-only storage of gas usage
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// AppendUsage stores the gas usage of a transaction
+func (s *Store) AppendUsage(usage gas.Usage) error {
+	data, err := json.Marshal(usage)
+	if err != nil {
+		return fmt.Errorf("failed to marshal gas usage: %w", err)
+	}
+
+	key := []byte(s.buildUsageKey(usage.Timestamp, usage.ID))
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set(key, data)
+	}); err != nil {
+		return fmt.Errorf("failed to append gas usage: %w", err)
+	}
+
+	return nil
+}
+
+// WalkUsage calls fn with every usage in [since, until], oldest first; zero bounds are open
+func (s *Store) WalkUsage(since, until time.Time, fn func(gas.Usage)) error {
+	err := s.db.View(func(txn storage.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		// keys sort by timestamp, so seeking to the lower bound skips everything older than since
+		seek := usagePrefix
+		if !since.IsZero() {
+			seek = s.buildUsageKey(since, "")
+		}
+
+		for it.Seek([]byte(seek)); it.ValidForPrefix([]byte(usagePrefix)); it.Next() {
+			var usage gas.Usage
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &usage)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode gas usage: %w", err)
+			}
+
+			if !until.IsZero() && usage.Timestamp.After(until) {
+				break
+			}
+			fn(usage)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list gas usage: %w", err)
+	}
+
+	return nil
+}
+
+// MarkBudgetAlerted records that the budget alert for month was sent, reporting false when it already was
+func (s *Store) MarkBudgetAlerted(month string) (bool, error) {
+	key := []byte(budgetAlertPrefix + month)
+
+	marked := false
+	err := s.db.Update(func(txn storage.Txn) error {
+		if _, err := txn.Get(key); err == nil {
+			return nil
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
+		marked = true
+		return txn.Set(key, []byte(time.Now().UTC().Format(time.RFC3339)))
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to mark gas budget alert: %w", err)
+	}
+
+	return marked, nil
+}
+
+func (s *Store) buildUsageKey(timestamp time.Time, id string) string {
+	return fmt.Sprintf("%s%020d:%s", usagePrefix, timestamp.UnixNano(), id)
+}
@@ -0,0 +1,68 @@
+package gas
+
+import (
+	"time"
+)
+
+// operation types gas is reported under
+const (
+	OperationEpochStart    = "epoch_start"
+	OperationEpochFinalize = "epoch_finalize" // yield allocation and epoch end
+	OperationRootUpdate    = "root_update"
+	OperationBatchRepay    = "batch_repay"
+	OperationOther         = "other"
+)
+
+// Usage is the gas a single mined transaction paid for, reverted ones included
+type Usage struct {
+	ID          string    `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Operation   string    `json:"operation"`
+	Action      string    `json:"action"`            // contract method, e.g. updateMerkleRoot
+	EpochID     string    `json:"epochId,omitempty"` // epoch the transaction was sent for
+	TxHash      string    `json:"txHash"`
+	BlockNumber uint64    `json:"blockNumber"`
+	GasUsed     uint64    `json:"gasUsed"`
+	GasPrice    string    `json:"gasPrice"` // wei, effective price per gas
+	Cost        string    `json:"cost"`     // wei, gasUsed * gasPrice
+}
+
+// ReportFilter selects the usage a report covers, zero values match everything
+type ReportFilter struct {
+	Since   time.Time
+	Until   time.Time
+	EpochID string
+}
+
+// Rollup sums the gas of a set of transactions
+type Rollup struct {
+	Transactions int    `json:"transactions"`
+	GasUsed      uint64 `json:"gasUsed"`
+	Cost         string `json:"cost"` // wei
+}
+
+// EpochRollup is the gas spent for one epoch, split by operation
+type EpochRollup struct {
+	EpochID     string            `json:"epochId"` // empty for transactions not tied to an epoch
+	Total       Rollup            `json:"total"`
+	ByOperation map[string]Rollup `json:"byOperation"`
+}
+
+// BudgetStatus compares this month's spend with the configured budget
+type BudgetStatus struct {
+	Month     string `json:"month" example:"2025-07"` // UTC calendar month
+	Budget    string `json:"budget"`                  // wei
+	Spent     string `json:"spent"`                   // wei
+	Remaining string `json:"remaining"`               // wei, negative once exceeded
+	Exceeded  bool   `json:"exceeded"`
+}
+
+// Report is the gas spent in a window, rolled up by operation and by epoch
+type Report struct {
+	Since       *time.Time        `json:"since,omitempty"`
+	Until       *time.Time        `json:"until,omitempty"`
+	Total       Rollup            `json:"total"`
+	ByOperation map[string]Rollup `json:"byOperation"`
+	Epochs      []EpochRollup     `json:"epochs"`           // ordered by epoch number
+	Budget      *BudgetStatus     `json:"budget,omitempty"` // set when a monthly budget is configured
+}
@@ -0,0 +1,12 @@
+package idempotency
+
+import "errors"
+
+// Predefined error types for idempotency keys
+var (
+	// ErrKeyReused is returned for a request sent with the Idempotency-Key of a different request
+	ErrKeyReused = errors.New("idempotency key reused for a different request")
+
+	// ErrInProgress is returned for a retry sent while the request that first used its key still runs
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+)
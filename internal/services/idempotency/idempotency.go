@@ -0,0 +1,22 @@
+package idempotency
+
+import (
+	"context"
+)
+
+//go:generate moq -out idempotency_mocks.go . Service
+
+// Service remembers the responses of requests sent with an Idempotency-Key, so a client retrying a request
+// gets the first response instead of running it again. Keys are stored, a retry reaching another replica or
+// a restarted server is still answered from the first request.
+type Service interface {
+	// Begin claims key for a request with fingerprint. It returns the record of the completed request that
+	// used key with the same fingerprint, wraps ErrKeyReused when key was used for another request and
+	// ErrInProgress while the request that claimed key still runs. A nil record means the caller claimed
+	// key and runs the request, then calls Complete, or Release when the request did not finish.
+	Begin(ctx context.Context, key, fingerprint string) (*Record, error)
+	// Complete stores the response of the request that claimed key, answered to its retries until it expires
+	Complete(ctx context.Context, key string, response Response) error
+	// Release forgets a key whose request did not finish, so a retry runs it
+	Release(ctx context.Context, key string) error
+}
@@ -0,0 +1,86 @@
+package idempotencyimpl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/go-pkgz/lgr"
+)
+
+type Service struct {
+	store   *Store
+	ttl     time.Duration // how long a completed request's response is replayed
+	lockTTL time.Duration // how long a running request holds its key, so a crash mid-request frees it eventually
+	logger  lgr.L
+	now     func() time.Time
+}
+
+func New(db storage.DB, logger lgr.L, cfg *config.Config) *Service {
+	return &Service{
+		store:   NewStore(db, logger),
+		ttl:     cfg.Idempotency.TTL,
+		lockTTL: cfg.Idempotency.LockTTL,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Begin claims key for the request with fingerprint, or returns what the request that claimed it first got
+func (s *Service) Begin(ctx context.Context, key, fingerprint string) (_ *idempotency.Record, err error) {
+	_, span := tracing.StartSpan(ctx, "idempotency.Begin")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	existing, err := s.store.Claim(idempotency.Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   s.now().UTC(),
+	}, s.lockTTL)
+	if err != nil || existing == nil {
+		return nil, err
+	}
+
+	if existing.Fingerprint != fingerprint {
+		return nil, fmt.Errorf("%w: key %s was first used at %s", idempotency.ErrKeyReused, key,
+			existing.CreatedAt.Format(time.RFC3339))
+	}
+	if !existing.Completed {
+		return nil, fmt.Errorf("%w: key %s claimed at %s", idempotency.ErrInProgress, key,
+			existing.CreatedAt.Format(time.RFC3339))
+	}
+	return existing, nil
+}
+
+// Complete stores the response of the request holding key, kept for the configured TTL from now
+func (s *Service) Complete(ctx context.Context, key string, response idempotency.Response) (err error) {
+	_, span := tracing.StartSpan(ctx, "idempotency.Complete")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	record, err := s.store.Get(key)
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		// the claim expired while the request ran, its retries run again rather than see a half-stored key
+		s.logger.Logf("WARN idempotency key %s expired before its request completed, the response is not kept", key)
+		return nil
+	}
+
+	completedAt := s.now().UTC()
+	record.Completed = true
+	record.Response = response
+	record.CompletedAt = &completedAt
+	return s.store.Save(*record, s.ttl)
+}
+
+// Release deletes the claim of key
+func (s *Service) Release(ctx context.Context, key string) (err error) {
+	_, span := tracing.StartSpan(ctx, "idempotency.Release")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.store.Delete(key)
+}
@@ -0,0 +1,83 @@
+package idempotencyimpl
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+)
+
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+
+	cfg := &config.Config{}
+	cfg.Idempotency.TTL = time.Hour
+	cfg.Idempotency.LockTTL = time.Minute
+	return New(db, lgr.NoOp, cfg)
+}
+
+func TestService_BeginAndComplete(t *testing.T) {
+	service := newTestService(t)
+	ctx := context.Background()
+
+	record, err := service.Begin(ctx, "key-1", "fingerprint-a")
+	require.NoError(t, err)
+	assert.Nil(t, record, "the first request claims the key")
+
+	_, err = service.Begin(ctx, "key-1", "fingerprint-a")
+	assert.ErrorIs(t, err, idempotency.ErrInProgress, "a retry while the first request runs")
+	_, err = service.Begin(ctx, "key-1", "fingerprint-b")
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused)
+
+	response := idempotency.Response{Status: 202, ContentType: "application/json", Body: []byte(`{"status":"completed"}`)}
+	require.NoError(t, service.Complete(ctx, "key-1", response))
+
+	record, err = service.Begin(ctx, "key-1", "fingerprint-a")
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.Completed)
+	assert.Equal(t, response, record.Response)
+	require.NotNil(t, record.CompletedAt)
+
+	_, err = service.Begin(ctx, "key-1", "fingerprint-b")
+	assert.ErrorIs(t, err, idempotency.ErrKeyReused, "a completed key is still bound to its request")
+
+	record, err = service.Begin(ctx, "key-2", "fingerprint-b")
+	require.NoError(t, err)
+	assert.Nil(t, record, "keys are independent")
+}
+
+func TestService_Release(t *testing.T) {
+	service := newTestService(t)
+	ctx := context.Background()
+
+	_, err := service.Begin(ctx, "key-1", "fingerprint-a")
+	require.NoError(t, err)
+	require.NoError(t, service.Release(ctx, "key-1"))
+
+	record, err := service.Begin(ctx, "key-1", "fingerprint-a")
+	require.NoError(t, err)
+	assert.Nil(t, record, "a released key is claimed again by the retry")
+
+	// a claim that expired while its request ran keeps no response
+	require.NoError(t, service.store.Delete("key-1"))
+	require.NoError(t, service.Complete(ctx, "key-1", idempotency.Response{Status: 200}))
+	stored, err := service.store.Get("key-1")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
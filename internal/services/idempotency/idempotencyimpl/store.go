@@ -0,0 +1,111 @@
+package idempotencyimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/idempotency"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const keyPrefix = "idempotency:"
+
+// Store handles storage of idempotency keys, every record expires with its key's TTL
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// Claim stores record unless its key is already stored, and returns the stored record of the key otherwise.
+// Two requests claiming a key at once conflict in badger, the one committing second gets ErrInProgress.
+func (s *Store) Claim(record idempotency.Record, ttl time.Duration) (*idempotency.Record, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	var existing *idempotency.Record
+	err = s.db.Update(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(keyPrefix + record.Key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return txn.SetEntry(badger.NewEntry([]byte(keyPrefix+record.Key), data).WithTTL(ttl))
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			existing = &idempotency.Record{}
+			return json.Unmarshal(val, existing)
+		})
+	})
+	if errors.Is(err, badger.ErrConflict) {
+		return nil, fmt.Errorf("%w: key %s was claimed concurrently", idempotency.ErrInProgress, record.Key)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim idempotency key %s: %w", record.Key, err)
+	}
+
+	return existing, nil
+}
+
+// Save stores record, replacing the claim of its key
+func (s *Store) Save(record idempotency.Record, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency record: %w", err)
+	}
+
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.SetEntry(badger.NewEntry([]byte(keyPrefix+record.Key), data).WithTTL(ttl))
+	}); err != nil {
+		return fmt.Errorf("failed to save idempotency key %s: %w", record.Key, err)
+	}
+
+	return nil
+}
+
+// Get returns the record of key, or nil when it is not stored or expired
+func (s *Store) Get(key string) (*idempotency.Record, error) {
+	var record *idempotency.Record
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(keyPrefix + key))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			record = &idempotency.Record{}
+			return json.Unmarshal(val, record)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key %s: %w", key, err)
+	}
+
+	return record, nil
+}
+
+// Delete removes the record of key
+func (s *Store) Delete(key string) error {
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Delete([]byte(keyPrefix + key))
+	}); err != nil {
+		return fmt.Errorf("failed to delete idempotency key %s: %w", key, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package idempotency
+
+import (
+	"time"
+)
+
+// Header is the request header carrying the key, and ReplayedHeader marks a response answered from a
+// stored one
+const (
+	Header         = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// MaxKeyLength is the longest key accepted, a UUID or any client-generated token fits
+const MaxKeyLength = 255
+
+// Response is what a request was answered, replayed to its retries
+type Response struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Record is a key claimed by a request, with the response once the request completed
+type Record struct {
+	Key         string     `json:"key"`
+	Fingerprint string     `json:"fingerprint"` // hash of the method, path, query and body of the request
+	Completed   bool       `json:"completed"`
+	Response    Response   `json:"response"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
+}
@@ -0,0 +1,9 @@
+package ipfs
+
+import "errors"
+
+// Predefined error types for IPFS operations
+var (
+	ErrInvalidInput  = errors.New("invalid input parameters")
+	ErrPinningFailed = errors.New("pinning service request failed")
+)
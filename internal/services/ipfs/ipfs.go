@@ -0,0 +1,12 @@
+package ipfs
+
+import "context"
+
+//go:generate moq -out ipfs_mocks.go . Service
+
+// Service publishes the claim eligibility of every epoch distribution to IPFS, so the community can rebuild each
+// tree from its leaves and check it against the root pushed on-chain without trusting the server
+type Service interface {
+	// PublishEligibility adds the snapshot to IPFS, pins it and returns its CID
+	PublishEligibility(ctx context.Context, snapshot EligibilitySnapshot) (string, error)
+}
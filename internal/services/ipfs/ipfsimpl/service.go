@@ -0,0 +1,135 @@
+package ipfsimpl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/ipfs"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxErrorBody bounds how much of a failed response is kept in the error
+const maxErrorBody = 512
+
+type Service struct {
+	apiURL     string
+	token      string
+	httpClient *http.Client
+	logger     lgr.L
+}
+
+// New returns a publisher adding snapshots through the Kubo RPC compatible API at IPFS_API_URL. Kubo nodes and
+// most pinning services serve it.
+func New(logger lgr.L, cfg *config.Config) *Service {
+	return &Service{
+		apiURL:     strings.TrimSuffix(cfg.IPFS.APIURL, "/"),
+		token:      cfg.IPFS.Token,
+		httpClient: &http.Client{Timeout: cfg.IPFS.Timeout},
+		logger:     logger,
+	}
+}
+
+// addResponse is what /api/v0/add answers for an added file
+type addResponse struct {
+	Name string `json:"Name"`
+	Hash string `json:"Hash"` // CID of the file
+}
+
+// PublishEligibility adds the snapshot as a JSON file with CIDv1, pinned. The encoding is canonical, so the same
+// distribution always has the same CID and publishing it again pins nothing new.
+func (s *Service) PublishEligibility(ctx context.Context, snapshot ipfs.EligibilitySnapshot) (_ string, err error) {
+	ctx, span := tracing.StartSpan(ctx, "ipfs.PublishEligibility",
+		attribute.String("vault.id", snapshot.VaultAddress), attribute.Int("ipfs.leaves", len(snapshot.Leaves)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if snapshot.VaultAddress == "" || snapshot.EpochNumber == "" || snapshot.MerkleRoot == "" {
+		return "", fmt.Errorf("%w: snapshot needs a vault, an epoch and a root", ipfs.ErrInvalidInput)
+	}
+	data, err := encode(snapshot)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("eligibility-%s-epoch-%s.json", utils.NormalizeAddress(snapshot.VaultAddress), snapshot.EpochNumber)
+	cid, err := s.add(ctx, name, data)
+	if err != nil {
+		return "", err
+	}
+	s.logger.Logf("INFO published eligibility of %d accounts in vault %s epoch %s to IPFS as %s",
+		len(snapshot.Leaves), snapshot.VaultAddress, snapshot.EpochNumber, cid)
+	return cid, nil
+}
+
+// encode returns the snapshot as JSON with its addresses normalized and its leaves sorted by account
+func encode(snapshot ipfs.EligibilitySnapshot) ([]byte, error) {
+	snapshot.Version = ipfs.SnapshotVersion
+	snapshot.VaultAddress = utils.NormalizeAddress(snapshot.VaultAddress)
+	leaves := make([]ipfs.Leaf, len(snapshot.Leaves))
+	for i, leaf := range snapshot.Leaves {
+		leaves[i] = ipfs.Leaf{Account: utils.NormalizeAddress(leaf.Account), TotalEarned: leaf.TotalEarned}
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].Account < leaves[j].Account })
+	snapshot.Leaves = leaves
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode eligibility snapshot: %w", err)
+	}
+	return data, nil
+}
+
+// add uploads data as a file named name to /api/v0/add and returns its CID
+func (s *Service) add(ctx context.Context, name string, data []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("failed to build pinning request: %w", err)
+	}
+	if _, err := part.Write(data); err != nil {
+		return "", fmt.Errorf("failed to build pinning request: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return "", fmt.Errorf("failed to build pinning request: %w", err)
+	}
+
+	endpoint := s.apiURL + "/api/v0/add?pin=true&cid-version=1"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to build pinning request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ipfs.ErrPinningFailed, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return "", fmt.Errorf("%w: status %d: %s", ipfs.ErrPinningFailed, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	var added addResponse
+	if err := json.NewDecoder(resp.Body).Decode(&added); err != nil {
+		return "", fmt.Errorf("%w: malformed response: %v", ipfs.ErrPinningFailed, err)
+	}
+	if added.Hash == "" {
+		return "", fmt.Errorf("%w: response has no CID", ipfs.ErrPinningFailed)
+	}
+	return added.Hash, nil
+}
@@ -0,0 +1,94 @@
+package ipfsimpl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/ipfs"
+)
+
+func newTestSnapshot() ipfs.EligibilitySnapshot {
+	return ipfs.EligibilitySnapshot{
+		VaultAddress:   "0x1111111111111111111111111111111111111ABC",
+		EpochNumber:    "5",
+		MerkleRoot:     "0xab",
+		LeafEncoding:   "packed",
+		TotalSubsidies: "13",
+		BlockNumber:    100,
+		Leaves: []ipfs.Leaf{
+			{Account: "0x8F37C5C4FA708E06A656D858003EF7DC5F60A29B", TotalEarned: "5"},
+			{Account: "0x742d35cc6634c0532925a3b844bc454e4438f44e", TotalEarned: "8"},
+		},
+	}
+}
+
+func newTestService(t *testing.T, handler http.HandlerFunc) *Service {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	cfg := &config.Config{}
+	cfg.IPFS.APIURL = server.URL + "/"
+	cfg.IPFS.Token = "secret"
+	cfg.IPFS.Timeout = 5 * time.Second
+	return New(lgr.NoOp, cfg)
+}
+
+func TestService_PublishEligibility(t *testing.T) {
+	var published []byte
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v0/add", r.URL.Path)
+		assert.Equal(t, "true", r.URL.Query().Get("pin"))
+		assert.Equal(t, "1", r.URL.Query().Get("cid-version"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		assert.Equal(t, "eligibility-0x1111111111111111111111111111111111111abc-epoch-5.json", header.Filename)
+		published, err = io.ReadAll(file)
+		require.NoError(t, err)
+		_, _ = w.Write([]byte(`{"Name":"eligibility.json","Hash":"bafkreitest","Size":"120"}`))
+	})
+
+	cid, err := service.PublishEligibility(context.Background(), newTestSnapshot())
+	require.NoError(t, err)
+	assert.Equal(t, "bafkreitest", cid)
+
+	var snapshot ipfs.EligibilitySnapshot
+	require.NoError(t, json.Unmarshal(published, &snapshot))
+	assert.Equal(t, ipfs.SnapshotVersion, snapshot.Version)
+	assert.Equal(t, "0x1111111111111111111111111111111111111abc", snapshot.VaultAddress)
+	assert.Equal(t, []ipfs.Leaf{
+		{Account: "0x742d35cc6634c0532925a3b844bc454e4438f44e", TotalEarned: "8"},
+		{Account: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: "5"},
+	}, snapshot.Leaves, "leaves are normalized and sorted so a distribution always encodes the same")
+}
+
+func TestService_PublishEligibility_Failures(t *testing.T) {
+	service := newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "quota exceeded", http.StatusPaymentRequired)
+	})
+	_, err := service.PublishEligibility(context.Background(), newTestSnapshot())
+	require.ErrorIs(t, err, ipfs.ErrPinningFailed)
+	assert.Contains(t, err.Error(), "quota exceeded")
+
+	service = newTestService(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"Name":"eligibility.json"}`))
+	})
+	_, err = service.PublishEligibility(context.Background(), newTestSnapshot())
+	require.ErrorIs(t, err, ipfs.ErrPinningFailed)
+
+	snapshot := newTestSnapshot()
+	snapshot.MerkleRoot = ""
+	_, err = service.PublishEligibility(context.Background(), snapshot)
+	require.ErrorIs(t, err, ipfs.ErrInvalidInput)
+}
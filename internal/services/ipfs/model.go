@@ -0,0 +1,26 @@
+package ipfs
+
+// SnapshotVersion is the version of the published snapshot format, raised whenever its fields change meaning
+const SnapshotVersion = 1
+
+// EligibilitySnapshot is what an epoch distribution is published as: every leaf of the vault's tree with the root
+// they build, hashed with LeafEncoding. Amounts are cumulative wei as decimal strings.
+type EligibilitySnapshot struct {
+	Version        int    `json:"version"`
+	VaultAddress   string `json:"vaultAddress"`
+	EpochNumber    string `json:"epochNumber"`
+	MerkleRoot     string `json:"merkleRoot"` // 0x-prefixed
+	LeafEncoding   string `json:"leafEncoding"`
+	TotalSubsidies string `json:"totalSubsidies"`
+	BlockNumber    uint64 `json:"blockNumber"` // snapshot block the subgraph was read at
+	BlockHash      string `json:"blockHash,omitempty"`
+	Fingerprint    string `json:"fingerprint,omitempty"` // hash of the inputs the distribution was computed from
+	Supersedes     string `json:"supersedes,omitempty"`  // 0x-prefixed root this tree replaced, set for root replacements
+	Leaves         []Leaf `json:"leaves"`                // sorted by account
+}
+
+// Leaf is an account's claim in a published tree
+type Leaf struct {
+	Account     string `json:"account"`
+	TotalEarned string `json:"totalEarned"`
+}
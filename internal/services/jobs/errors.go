@@ -0,0 +1,8 @@
+package jobs
+
+import "errors"
+
+// Predefined error types for job run operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
@@ -0,0 +1,25 @@
+package jobs
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate moq -out jobs_mocks.go . Recorder Service
+
+// Recorder keeps what the scheduler's jobs did and when they run next
+type Recorder interface {
+	// RecordRun stores a finished run of a job, dropping its oldest runs past the retained history
+	RecordRun(ctx context.Context, run Run) error
+	// SetNextRun stores when job runs next, nil when nothing schedules it
+	SetNextRun(ctx context.Context, job string, next *time.Time) error
+}
+
+// Service reports scheduler job runs. Runs are stored, so every replica sharing the database sees the
+// runs of the one that holds the scheduler lease.
+type Service interface {
+	Recorder
+
+	// Status returns every job's last run, last error, next run and up to limit of its most recent runs
+	Status(ctx context.Context, limit int) (*Status, error)
+}
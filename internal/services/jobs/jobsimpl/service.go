@@ -0,0 +1,74 @@
+package jobsimpl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+type Service struct {
+	store  *Store
+	logger lgr.L
+}
+
+func New(db storage.DB, logger lgr.L) *Service {
+	return &Service{
+		store:  NewStore(db, logger),
+		logger: logger,
+	}
+}
+
+// RecordRun stores a finished run, keeping the job's last MaxHistory runs
+func (s *Service) RecordRun(ctx context.Context, run jobs.Run) error {
+	run.StartedAt, run.FinishedAt = run.StartedAt.UTC(), run.FinishedAt.UTC()
+	run.DurationMs = run.FinishedAt.Sub(run.StartedAt).Milliseconds()
+	return s.store.SaveRun(run, jobs.MaxHistory)
+}
+
+// SetNextRun stores when job runs next
+func (s *Service) SetNextRun(ctx context.Context, job string, next *time.Time) error {
+	return s.store.SaveNextRun(job, next)
+}
+
+// Status returns the run state of every job, with up to limit of each job's most recent runs
+func (s *Service) Status(ctx context.Context, limit int) (_ *jobs.Status, err error) {
+	_, span := tracing.StartSpan(ctx, "jobs.Status", attribute.Int("jobs.limit", limit))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if limit < 1 || limit > jobs.MaxHistory {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d, got %d", jobs.ErrInvalidInput, jobs.MaxHistory, limit)
+	}
+
+	status := &jobs.Status{Jobs: make([]jobs.JobStatus, 0, len(jobs.Jobs))}
+	for _, job := range jobs.Jobs {
+		runs, err := s.store.ListRuns(job, limit)
+		if err != nil {
+			return nil, err
+		}
+		lastError, err := s.store.GetLastError(job)
+		if err != nil {
+			return nil, err
+		}
+		next, err := s.store.GetNextRun(job)
+		if err != nil {
+			return nil, err
+		}
+
+		state := jobs.JobStatus{Job: job, NextRun: next, History: runs}
+		if len(runs) > 0 {
+			state.LastRun = &runs[0]
+		}
+		if lastError != nil {
+			state.LastError = lastError.Error
+			state.LastErrorAt = &lastError.FinishedAt
+		}
+		status.Jobs = append(status.Jobs, state)
+	}
+	return status, nil
+}
@@ -0,0 +1,117 @@
+package jobsimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/pause"
+)
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+func TestService_RecordsRunsAndLastError(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, service.RecordRun(ctx, jobs.Run{
+		Job:        pause.JobDistribute,
+		StartedAt:  start,
+		FinishedAt: start.Add(1500 * time.Millisecond),
+		Outcome:    jobs.OutcomeFailed,
+		Error:      "subgraph unavailable",
+	}))
+	require.NoError(t, service.RecordRun(ctx, jobs.Run{
+		Job:        pause.JobDistribute,
+		StartedAt:  start.Add(time.Hour),
+		FinishedAt: start.Add(time.Hour + time.Second),
+		Outcome:    jobs.OutcomeSucceeded,
+	}))
+	next := start.Add(2 * time.Hour)
+	require.NoError(t, service.SetNextRun(ctx, pause.JobDistribute, &next))
+
+	status, err := service.Status(ctx, 10)
+	require.NoError(t, err)
+	require.Len(t, status.Jobs, len(jobs.Jobs))
+
+	var distribute jobs.JobStatus
+	for _, state := range status.Jobs {
+		if state.Job == pause.JobDistribute {
+			distribute = state
+			continue
+		}
+		assert.Nil(t, state.LastRun, "%s has not run", state.Job)
+		assert.Empty(t, state.History)
+	}
+	require.Len(t, distribute.History, 2)
+	require.NotNil(t, distribute.LastRun)
+	assert.Equal(t, jobs.OutcomeSucceeded, distribute.LastRun.Outcome, "newest run first")
+	assert.Equal(t, int64(1000), distribute.LastRun.DurationMs)
+	assert.Equal(t, int64(1500), distribute.History[1].DurationMs)
+	assert.Equal(t, "subgraph unavailable", distribute.LastError, "the last error outlives later successes")
+	require.NotNil(t, distribute.LastErrorAt)
+	assert.True(t, start.Add(1500*time.Millisecond).Equal(*distribute.LastErrorAt))
+	require.NotNil(t, distribute.NextRun)
+	assert.True(t, next.Equal(*distribute.NextRun))
+
+	// a stopped scheduler clears the next run
+	require.NoError(t, service.SetNextRun(ctx, pause.JobDistribute, nil))
+	status, err = service.Status(ctx, 1)
+	require.NoError(t, err)
+	for _, state := range status.Jobs {
+		assert.Nil(t, state.NextRun)
+		assert.LessOrEqual(t, len(state.History), 1)
+	}
+}
+
+func TestService_KeepsMaxHistory(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp)
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < jobs.MaxHistory+5; i++ {
+		startedAt := start.Add(time.Duration(i) * time.Minute)
+		require.NoError(t, service.RecordRun(ctx, jobs.Run{
+			Job:        pause.JobStartEpoch,
+			StartedAt:  startedAt,
+			FinishedAt: startedAt,
+			Outcome:    jobs.OutcomeSkipped,
+		}))
+	}
+
+	status, err := service.Status(ctx, jobs.MaxHistory)
+	require.NoError(t, err)
+	history := status.Jobs[0].History
+	require.Equal(t, pause.JobStartEpoch, status.Jobs[0].Job)
+	require.Len(t, history, jobs.MaxHistory)
+	assert.True(t, start.Add(time.Duration(jobs.MaxHistory+4)*time.Minute).Equal(history[0].StartedAt))
+	assert.True(t, start.Add(5*time.Minute).Equal(history[len(history)-1].StartedAt), "the oldest runs are dropped")
+}
+
+func TestService_RejectsInvalidLimit(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp)
+
+	for _, limit := range []int{0, -1, jobs.MaxHistory + 1} {
+		_, err := service.Status(context.Background(), limit)
+		assert.True(t, errors.Is(err, jobs.ErrInvalidInput), "limit %d", limit)
+	}
+}
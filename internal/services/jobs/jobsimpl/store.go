@@ -0,0 +1,180 @@
+package jobsimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	runPrefix       = "scheduler:jobs:run:"
+	lastErrorPrefix = "scheduler:jobs:last-error:"
+	nextRunPrefix   = "scheduler:jobs:next:"
+)
+
+// Store handles storage of job runs
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveRun stores a run and drops the job's oldest runs past keep. A failed run also becomes the job's last error.
+func (s *Store) SaveRun(run jobs.Run, keep int) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job run: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte(s.buildRunKey(run.Job, run.StartedAt)), data); err != nil {
+			return err
+		}
+		if run.Outcome == jobs.OutcomeFailed {
+			if err := txn.Set([]byte(lastErrorPrefix+run.Job), data); err != nil {
+				return err
+			}
+		}
+
+		// keys sort by start time, so the oldest runs come first
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildRunPrefix(run.Job))
+		opts.PrefetchValues = false
+		var keys [][]byte
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys = append(keys, it.Item().KeyCopy(nil))
+		}
+		it.Close()
+
+		for len(keys) > keep {
+			if err := txn.Delete(keys[0]); err != nil {
+				return err
+			}
+			keys = keys[1:]
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save run of job %s: %w", run.Job, err)
+	}
+
+	return nil
+}
+
+// ListRuns returns up to limit of the job's runs, newest first
+func (s *Store) ListRuns(job string, limit int) ([]jobs.Run, error) {
+	runs := make([]jobs.Run, 0)
+
+	prefix := s.buildRunPrefix(job)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Reverse = true
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Seek([]byte(prefix + "~")); it.ValidForPrefix([]byte(prefix)) && len(runs) < limit; it.Next() {
+			var run jobs.Run
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &run)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode job run: %w", err)
+			}
+			runs = append(runs, run)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs of job %s: %w", job, err)
+	}
+
+	return runs, nil
+}
+
+// GetLastError returns the job's most recent failed run, or nil when it never failed
+func (s *Store) GetLastError(job string) (*jobs.Run, error) {
+	var run *jobs.Run
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(lastErrorPrefix + job))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			run = &jobs.Run{}
+			return json.Unmarshal(val, run)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last error of job %s: %w", job, err)
+	}
+
+	return run, nil
+}
+
+// SaveNextRun stores when the job runs next, removing it when next is nil
+func (s *Store) SaveNextRun(job string, next *time.Time) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(nextRunPrefix + job)
+		if next == nil {
+			return txn.Delete(key)
+		}
+		return txn.Set(key, []byte(next.UTC().Format(time.RFC3339Nano)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save next run of job %s: %w", job, err)
+	}
+
+	return nil
+}
+
+// GetNextRun returns when the job runs next, or nil when it is not scheduled
+func (s *Store) GetNextRun(job string) (*time.Time, error) {
+	var next *time.Time
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(nextRunPrefix + job))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			parsed, err := time.Parse(time.RFC3339Nano, string(val))
+			if err != nil {
+				return err
+			}
+			next = &parsed
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get next run of job %s: %w", job, err)
+	}
+
+	return next, nil
+}
+
+func (s *Store) buildRunPrefix(job string) string {
+	return runPrefix + job + ":"
+}
+
+func (s *Store) buildRunKey(job string, startedAt time.Time) string {
+	return fmt.Sprintf("%s%020d", s.buildRunPrefix(job), startedAt.UnixNano())
+}
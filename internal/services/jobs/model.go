@@ -0,0 +1,47 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/pause"
+)
+
+// Jobs lists the jobs whose runs are recorded, the scheduler jobs that can be paused one by one
+var Jobs = []string{pause.JobStartEpoch, pause.JobAllocateYield, pause.JobDistribute, pause.JobCatchUp, pause.JobReconcile}
+
+// MaxHistory is how many of a job's most recent runs are kept
+const MaxHistory = 100
+
+// outcomes of a job run
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+	OutcomeSkipped   = "skipped" // paused, or the scheduler could not send transactions
+)
+
+// Run is one run of a scheduler job
+type Run struct {
+	Job        string    `json:"job" example:"distribute"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	DurationMs int64     `json:"durationMs" example:"5230"`
+	Outcome    string    `json:"outcome" example:"succeeded"`
+	Error      string    `json:"error,omitempty" example:"failed to distribute subsidies: execution reverted"` // why the run failed or was skipped
+	Actor      string    `json:"actor,omitempty" example:"scheduler"`
+}
+
+// JobStatus is when a job last ran, how that went and when it runs next
+type JobStatus struct {
+	Job     string `json:"job" example:"distribute"`
+	LastRun *Run   `json:"lastRun,omitempty"`
+	// LastError is the error of the job's most recent failed run, kept after later runs succeed
+	LastError   string     `json:"lastError,omitempty"`
+	LastErrorAt *time.Time `json:"lastErrorAt,omitempty"`
+	NextRun     *time.Time `json:"nextRun,omitempty"` // none in manual mode or when no scheduler runs
+	History     []Run      `json:"history"`           // most recent first
+}
+
+// Status is the run state of every scheduler job
+type Status struct {
+	Jobs []JobStatus `json:"jobs"`
+}
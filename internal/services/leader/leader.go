@@ -0,0 +1,26 @@
+package leader
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate moq -out leader_mocks.go . Elector LeaseStore
+
+// Elector decides which replica runs the scheduler.
+// At most one node holds the lease at a time, and another takes over once it expires.
+type Elector interface {
+	// Run campaigns for the lease and renews it until ctx is done, then releases it
+	Run(ctx context.Context)
+	// IsLeader reports whether this node holds an unexpired lease
+	IsLeader() bool
+}
+
+// LeaseStore holds named leases shared by every replica
+type LeaseStore interface {
+	// Acquire takes the lease for holder, or extends it when holder already owns it.
+	// It reports false without error while another holder's lease is unexpired.
+	Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error)
+	// Release gives up the lease if holder owns it
+	Release(ctx context.Context, name, holder string) error
+}
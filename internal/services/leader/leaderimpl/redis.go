@@ -0,0 +1,171 @@
+package leaderimpl
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+)
+
+// leasePrefix namespaces the lease keys
+const leasePrefix = "leader:lease:"
+
+// redisDialTimeout bounds connecting to redis when ctx carries no deadline
+const redisDialTimeout = 5 * time.Second
+
+// acquireScript sets the lease when it is free or already ours, in one atomic step
+const acquireScript = `
+local current = redis.call('GET', KEYS[1])
+if current == false or current == ARGV[1] then
+	redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+	return 1
+end
+return 0
+`
+
+// releaseScript deletes the lease only while it is still ours
+const releaseScript = `
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`
+
+// RedisStore keeps leases as redis keys that expire with the lease, so a crashed
+// holder's lease is dropped by redis itself. It speaks just enough RESP for the two
+// scripts and dials a connection per call, which is cheap at the renewal rate.
+type RedisStore struct {
+	addr     string
+	password string
+	logger   lgr.L
+}
+
+// NewRedisStore creates a lease store backed by the redis server at addr
+func NewRedisStore(addr, password string, logger lgr.L) *RedisStore {
+	return &RedisStore{
+		addr:     addr,
+		password: password,
+		logger:   logger,
+	}
+}
+
+func (s *RedisStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	reply, err := s.do(ctx, "EVAL", acquireScript, "1", leasePrefix+name, holder, strconv.FormatInt(ttl.Milliseconds(), 10))
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease %s: %w", name, err)
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return false, fmt.Errorf("unexpected reply %v acquiring lease %s", reply, name)
+	}
+	return n == 1, nil
+}
+
+func (s *RedisStore) Release(ctx context.Context, name, holder string) error {
+	if _, err := s.do(ctx, "EVAL", releaseScript, "1", leasePrefix+name, holder); err != nil {
+		return fmt.Errorf("failed to release lease %s: %w", name, err)
+	}
+	return nil
+}
+
+// do sends one command on a fresh connection, authenticating first when a password is set
+func (s *RedisStore) do(ctx context.Context, args ...string) (_ interface{}, err error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, redisDialTimeout)
+		defer cancel()
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	defer func() {
+		if closeErr := conn.Close(); closeErr != nil {
+			s.logger.Logf("DEBUG failed to close redis connection: %v", closeErr)
+		}
+	}()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	r := bufio.NewReader(conn)
+	if s.password != "" {
+		if _, err := roundTrip(conn, r, "AUTH", s.password); err != nil {
+			return nil, fmt.Errorf("redis auth failed: %w", err)
+		}
+	}
+	return roundTrip(conn, r, args...)
+}
+
+// roundTrip writes a command as a RESP array of bulk strings and reads its reply
+func roundTrip(w io.Writer, r *bufio.Reader, args ...string) (interface{}, error) {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := w.Write(buf); err != nil {
+		return nil, err
+	}
+	return readReply(r)
+}
+
+// readReply decodes one RESP reply; nil bulk strings and arrays come back as nil
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("malformed redis reply %q", line)
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, errors.New(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		size, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis bulk length %q", body)
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		data := make([]byte, size+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:size]), nil
+	case '*':
+		count, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("malformed redis array length %q", body)
+		}
+		if count < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("unsupported redis reply type %q", kind)
+	}
+}
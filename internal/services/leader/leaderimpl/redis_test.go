@@ -0,0 +1,122 @@
+package leaderimpl
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRedis serves the lease scripts from a map, ignoring expiry
+type fakeRedis struct {
+	mu       sync.Mutex
+	keys     map[string]string
+	password string
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	srv := &fakeRedis{keys: map[string]string{}, password: password}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	return srv, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		args := make([]string, 0)
+		for _, arg := range reply.([]interface{}) {
+			args = append(args, arg.(string))
+		}
+
+		var out string
+		switch {
+		case args[0] == "AUTH":
+			authed = args[1] == f.password
+			out = "+OK\r\n"
+			if !authed {
+				out = "-WRONGPASS invalid password\r\n"
+			}
+		case !authed:
+			out = "-NOAUTH Authentication required.\r\n"
+		case args[0] == "EVAL" && strings.Contains(args[1], "'SET'"):
+			out = ":0\r\n"
+			f.mu.Lock()
+			if current, ok := f.keys[args[3]]; !ok || current == args[4] {
+				f.keys[args[3]] = args[4]
+				out = ":1\r\n"
+			}
+			f.mu.Unlock()
+		case args[0] == "EVAL" && strings.Contains(args[1], "'DEL'"):
+			out = ":0\r\n"
+			f.mu.Lock()
+			if f.keys[args[3]] == args[4] {
+				delete(f.keys, args[3])
+				out = ":1\r\n"
+			}
+			f.mu.Unlock()
+		default:
+			out = "-ERR unknown command\r\n"
+		}
+		if _, err := conn.Write([]byte(out)); err != nil {
+			return
+		}
+	}
+}
+
+func TestRedisStore_AcquireAndRelease(t *testing.T) {
+	srv, addr := startFakeRedis(t, "s3cret")
+	nodeA := NewRedisStore(addr, "s3cret", lgr.NoOp)
+	nodeB := NewRedisStore(addr, "s3cret", lgr.NoOp)
+	ctx := context.Background()
+
+	acquired, err := nodeA.Acquire(ctx, "scheduler", "node-a", 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, "node-a", srv.keys[leasePrefix+"scheduler"])
+
+	acquired, err = nodeB.Acquire(ctx, "scheduler", "node-b", 30*time.Second)
+	require.NoError(t, err)
+	assert.False(t, acquired)
+
+	require.NoError(t, nodeA.Release(ctx, "scheduler", "node-a"))
+	acquired, err = nodeB.Acquire(ctx, "scheduler", "node-b", 30*time.Second)
+	require.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestRedisStore_Errors(t *testing.T) {
+	_, addr := startFakeRedis(t, "s3cret")
+
+	_, err := NewRedisStore(addr, "wrong", lgr.NoOp).Acquire(context.Background(), "scheduler", "node-a", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "WRONGPASS")
+
+	_, err = NewRedisStore(addr, "", lgr.NoOp).Acquire(context.Background(), "scheduler", "node-a", time.Second)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NOAUTH")
+}
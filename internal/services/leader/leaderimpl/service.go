@@ -0,0 +1,147 @@
+package leaderimpl
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/leader"
+	"github.com/go-pkgz/lgr"
+)
+
+// leaderMetric is 1 while this node runs the scheduler
+const leaderMetric = "epoch_server_scheduler_leader"
+
+// releaseTimeout bounds giving up the lease on shutdown
+const releaseTimeout = 5 * time.Second
+
+type Service struct {
+	store   leader.LeaseStore
+	metrics *metrics.Registry
+	logger  lgr.L
+	name    string
+	nodeID  string
+	ttl     time.Duration
+	now     func() time.Time
+
+	mu          sync.RWMutex
+	leaseExpiry time.Time // zero while another node leads
+}
+
+func New(store leader.LeaseStore, registry *metrics.Registry, logger lgr.L, cfg *config.Config) *Service {
+	nodeID := cfg.Leader.NodeID
+	if nodeID == "" {
+		nodeID = DefaultNodeID()
+	}
+	return &Service{
+		store:   store,
+		metrics: registry,
+		logger:  logger,
+		name:    cfg.Leader.LeaseName,
+		nodeID:  nodeID,
+		ttl:     cfg.Leader.TTL,
+		now:     time.Now,
+	}
+}
+
+// DefaultNodeID identifies this process as hostname-pid
+func DefaultNodeID() string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+// Run campaigns right away and then every third of the lease TTL, so a leader renews
+// twice before its lease could expire. The lease is released when ctx is done so
+// another node takes over without waiting for the TTL.
+func (s *Service) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.ttl / 3)
+	defer ticker.Stop()
+
+	s.logger.Logf("INFO leader election started for %s as %s, lease ttl %v", s.name, s.nodeID, s.ttl)
+	s.campaign(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.resign()
+			return
+		case <-ticker.C:
+			s.campaign(ctx)
+		}
+	}
+}
+
+// IsLeader checks the lease expiry as well, so a node whose renewals stall stops leading
+// by the time another node may have taken over
+func (s *Service) IsLeader() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.now().Before(s.leaseExpiry)
+}
+
+// campaign acquires or renews the lease. The expiry is counted from before the request,
+// so the local view never outlives the lease in the store. A store error steps down,
+// since the lease may be lost.
+func (s *Service) campaign(ctx context.Context) {
+	start := s.now()
+	acquired, err := s.store.Acquire(ctx, s.name, s.nodeID, s.ttl)
+	if err != nil {
+		s.logger.Logf("ERROR failed to acquire leader lease %s: %v", s.name, err)
+	}
+
+	s.mu.Lock()
+	wasLeader := start.Before(s.leaseExpiry)
+	if err == nil && acquired {
+		s.leaseExpiry = start.Add(s.ttl)
+	} else {
+		s.leaseExpiry = time.Time{}
+	}
+	isLeader := !s.leaseExpiry.IsZero()
+	s.mu.Unlock()
+
+	switch {
+	case isLeader && !wasLeader:
+		s.logger.Logf("INFO %s became leader for %s", s.nodeID, s.name)
+	case !isLeader && wasLeader:
+		s.logger.Logf("WARN %s lost leadership for %s", s.nodeID, s.name)
+	}
+	s.setGauge(isLeader)
+}
+
+func (s *Service) resign() {
+	s.mu.Lock()
+	wasLeader := !s.leaseExpiry.IsZero()
+	s.leaseExpiry = time.Time{}
+	s.mu.Unlock()
+	s.setGauge(false)
+
+	if !wasLeader {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	if err := s.store.Release(ctx, s.name, s.nodeID); err != nil {
+		s.logger.Logf("WARN failed to release leader lease %s: %v", s.name, err)
+		return
+	}
+	s.logger.Logf("INFO %s released leadership for %s", s.nodeID, s.name)
+}
+
+func (s *Service) setGauge(isLeader bool) {
+	if s.metrics == nil {
+		return
+	}
+	value := 0.0
+	if isLeader {
+		value = 1
+	}
+	s.metrics.SetGauge(leaderMetric, "Whether this node holds the scheduler leader lease", value)
+}
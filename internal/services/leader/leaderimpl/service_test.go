@@ -0,0 +1,133 @@
+package leaderimpl
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/leader"
+)
+
+// memoryStore is a lease store all test nodes share, with expiry read from *now
+type memoryStore struct {
+	now    *time.Time
+	leases map[string]memoryLease
+}
+
+type memoryLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+func newMemoryStore(now *time.Time) *memoryStore {
+	return &memoryStore{now: now, leases: map[string]memoryLease{}}
+}
+
+func (m *memoryStore) Acquire(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+	if current, ok := m.leases[name]; ok && current.holder != holder && m.now.Before(current.expiresAt) {
+		return false, nil
+	}
+	m.leases[name] = memoryLease{holder: holder, expiresAt: m.now.Add(ttl)}
+	return true, nil
+}
+
+func (m *memoryStore) Release(ctx context.Context, name, holder string) error {
+	if m.leases[name].holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+// newTestNode returns an elector named nodeID sharing store, whose clock is *now
+func newTestNode(store leader.LeaseStore, nodeID string, now *time.Time) (*Service, *metrics.Registry) {
+	cfg := &config.Config{}
+	cfg.Leader.LeaseName = "scheduler"
+	cfg.Leader.TTL = 30 * time.Second
+	cfg.Leader.NodeID = nodeID
+
+	registry := metrics.NewRegistry()
+	svc := New(store, registry, lgr.NoOp, cfg)
+	svc.now = func() time.Time { return *now }
+	return svc, registry
+}
+
+func TestService_FailoverAfterLeaseExpires(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemoryStore(&now)
+	nodeA, registryA := newTestNode(store, "node-a", &now)
+	nodeB, _ := newTestNode(store, "node-b", &now)
+	ctx := context.Background()
+
+	nodeA.campaign(ctx)
+	nodeB.campaign(ctx)
+	assert.True(t, nodeA.IsLeader())
+	assert.False(t, nodeB.IsLeader())
+	gauge, _ := registryA.Gauge(leaderMetric)
+	assert.Equal(t, 1.0, gauge)
+
+	// a renewal keeps the lease with the current leader
+	now = now.Add(10 * time.Second)
+	nodeA.campaign(ctx)
+	nodeB.campaign(ctx)
+	assert.True(t, nodeA.IsLeader())
+	assert.False(t, nodeB.IsLeader())
+
+	// node a stops renewing, its lease lapses on both sides before b takes over
+	now = now.Add(31 * time.Second)
+	assert.False(t, nodeA.IsLeader(), "a stalled leader steps down once its lease expires")
+	nodeB.campaign(ctx)
+	assert.True(t, nodeB.IsLeader())
+
+	nodeA.campaign(ctx)
+	assert.False(t, nodeA.IsLeader())
+	gauge, _ = registryA.Gauge(leaderMetric)
+	assert.Zero(t, gauge)
+}
+
+func TestService_ResignHandsOverImmediately(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := newMemoryStore(&now)
+	nodeA, _ := newTestNode(store, "node-a", &now)
+	nodeB, _ := newTestNode(store, "node-b", &now)
+	ctx := context.Background()
+
+	nodeA.campaign(ctx)
+	require.True(t, nodeA.IsLeader())
+
+	nodeA.resign()
+	assert.False(t, nodeA.IsLeader())
+
+	nodeB.campaign(ctx)
+	assert.True(t, nodeB.IsLeader(), "a released lease is free before its ttl")
+}
+
+func TestService_StoreErrorStepsDown(t *testing.T) {
+	fail := false
+	store := &leader.LeaseStoreMock{
+		AcquireFunc: func(ctx context.Context, name, holder string, ttl time.Duration) (bool, error) {
+			if fail {
+				return false, errors.New("connection refused")
+			}
+			return true, nil
+		},
+	}
+	cfg := &config.Config{}
+	cfg.Leader.LeaseName = "scheduler"
+	cfg.Leader.TTL = 30 * time.Second
+	svc := New(store, nil, lgr.NoOp, cfg)
+	assert.NotEmpty(t, svc.nodeID, "node id defaults to hostname-pid")
+
+	svc.campaign(context.Background())
+	assert.True(t, svc.IsLeader())
+
+	fail = true
+	svc.campaign(context.Background())
+	assert.False(t, svc.IsLeader())
+}
@@ -8,4 +8,16 @@ var (
 	ErrNotFound        = errors.New("resource not found")
 	ErrProofGeneration = errors.New("merkle proof generation failed")
 	ErrInvalidProof    = errors.New("invalid merkle proof")
+
+	// ErrLeafEncodingMismatch is returned when the contract a root would be pushed to verifies leaves
+	// hashed with another encoding than the vault is configured for
+	ErrLeafEncodingMismatch = errors.New("leaf encoding does not match the contract")
+
+	// ErrClaimUnavailable is returned for a claim claimSubsidy would revert: everything earned was claimed, or
+	// the root the proof is against is not the one on-chain yet
+	ErrClaimUnavailable = errors.New("claim unavailable")
+
+	// ErrRootMismatch is returned when a root is not the one it is checked against: a stored tree that no
+	// longer builds its root, or the root a proof is against that is not the one on-chain
+	ErrRootMismatch = errors.New("merkle root does not match")
 )
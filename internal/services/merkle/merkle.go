@@ -13,4 +13,28 @@ type Service interface {
 
 	// GenerateHistoricalMerkleProof generates a merkle proof for a user's earnings at a specific epoch
 	GenerateHistoricalMerkleProof(ctx context.Context, userAddress, vaultAddress, epochNumber string) (*UserMerkleProofResponse, error)
+
+	// GenerateMerkleProofForRoot generates a merkle proof against a specific root submitted for an epoch
+	GenerateMerkleProofForRoot(ctx context.Context, userAddress, vaultAddress, epochNumber, merkleRoot string) (*UserMerkleProofResponse, error)
+
+	// GetUserClaimable returns the user's claim in every vault with a distribution, with what was already claimed on-chain
+	GetUserClaimable(ctx context.Context, userAddress string) (*UserClaimable, error)
+
+	// GetClaimPayload encodes the user's claimSubsidy call in the vault's latest distribution for a relayer to
+	// submit, with EIP-712 typed data the user signs to request it
+	GetClaimPayload(ctx context.Context, userAddress, vaultAddress string) (*ClaimPayload, error)
+
+	// VerifyClaimSignature checks the recipient signed the typed data of their claim payload, by recovering the
+	// signer for an externally owned account and with ERC-1271 for a contract wallet
+	VerifyClaimSignature(ctx context.Context, userAddress, vaultAddress string, signature []byte) (*ClaimSignatureVerification, error)
+
+	// VerifyMerkleRoot recomputes the latest snapshot's root and compares it with the on-chain root
+	VerifyMerkleRoot(ctx context.Context, vaultAddress string) (*MerkleRootVerification, error)
+
+	// VerifyProofs checks every proof against the roots stored for its vault, and against the vault's on-chain
+	// root when onChain is set. A malformed or failing proof is reported in its result, not as an error.
+	VerifyProofs(ctx context.Context, proofs []ProofToVerify, onChain bool) (*ProofVerifications, error)
+
+	// ListRootUpdates returns every merkle root pushed for the vault, oldest first
+	ListRootUpdates(ctx context.Context, vaultAddress string) ([]RootUpdate, error)
 }
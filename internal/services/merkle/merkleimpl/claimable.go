@@ -0,0 +1,84 @@
+package merkleimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// GetUserClaimable builds the user's claim from the latest snapshot of every vault and subtracts what the
+// debt subsidizer says was already claimed. Earnings in a snapshot are cumulative, so the latest one holds
+// the amount claimSubsidy checks the proof against. Vaults whose latest snapshot has no leaf for the user
+// are left out.
+func (s *Service) GetUserClaimable(ctx context.Context, userAddress string) (_ *merkle.UserClaimable, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.GetUserClaimable", attribute.String("user.address", userAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if userAddress == "" {
+		return nil, fmt.Errorf("%w: userAddress cannot be empty", merkle.ErrInvalidInput)
+	}
+	userAddress = utils.NormalizeAddress(userAddress)
+
+	vaults, err := s.store.ListVaults(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &merkle.UserClaimable{UserAddress: userAddress, Vaults: make([]merkle.VaultClaimable, 0)}
+	totalEarned, totalClaimed, totalClaimable := big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	for _, vault := range vaults {
+		proof, err := s.latestProof(ctx, vault, userAddress)
+		if errors.Is(err, merkle.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		earned, ok := new(big.Int).SetString(proof.TotalEarned, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid total earned %q in snapshot of vault %s", proof.TotalEarned, vault)
+		}
+		claimed, err := s.contractClient.GetUserClaimedTotal(ctx, vault, userAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get claimed total for vault %s: %w", vault, err)
+		}
+		// a root not yet pushed after a claim can lag the claimed total, nothing is claimable then
+		claimable := new(big.Int).Sub(earned, claimed)
+		if claimable.Sign() < 0 {
+			claimable.SetInt64(0)
+		}
+
+		claimProof := make([]string, len(proof.MerkleProof))
+		for i, node := range proof.MerkleProof {
+			claimProof[i] = "0x" + node
+		}
+		result.Vaults = append(result.Vaults, merkle.VaultClaimable{
+			VaultAddress: vault,
+			EpochNumber:  proof.EpochNumber,
+			MerkleRoot:   proof.MerkleRoot,
+			TotalEarned:  earned.String(),
+			TotalClaimed: claimed.String(),
+			Claimable:    claimable.String(),
+			Claim: merkle.ClaimData{
+				Recipient:   userAddress,
+				TotalEarned: earned.String(),
+				MerkleProof: claimProof,
+			},
+		})
+		totalEarned.Add(totalEarned, earned)
+		totalClaimed.Add(totalClaimed, claimed)
+		totalClaimable.Add(totalClaimable, claimable)
+	}
+
+	result.TotalEarned = totalEarned.String()
+	result.TotalClaimed = totalClaimed.String()
+	result.TotalClaimable = totalClaimable.String()
+	return result, nil
+}
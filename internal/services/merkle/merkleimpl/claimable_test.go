@@ -0,0 +1,71 @@
+package merkleimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetUserClaimable(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	user := "0x3575b992c5337226aecf4e7f93dfbe80c576ce15"
+	vaultA := "0x1111111111111111111111111111111111111111"
+	vaultB := "0x2222222222222222222222222222222222222222"
+	vaultC := "0x3333333333333333333333333333333333333333"
+	contractClient := &stubContractClient{claimed: map[string]*big.Int{vaultA: big.NewInt(400), vaultB: big.NewInt(900)}}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+
+	saveSnapshot := func(vault string, epoch int64, entries ...merkle.Entry) {
+		root := service.BuildMerkleRootFromEntries(entries)
+		snapshot := merkle.MerkleSnapshot{VaultID: vault, MerkleRoot: fmt.Sprintf("%x", root)}
+		for _, entry := range entries {
+			snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+		}
+		require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(epoch), snapshot))
+	}
+	other := merkle.Entry{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)}
+	saveSnapshot(vaultA, 2, merkle.Entry{Address: user, TotalEarned: big.NewInt(600)}, other)
+	saveSnapshot(vaultA, 3, merkle.Entry{Address: user, TotalEarned: big.NewInt(1000)}, other)
+	saveSnapshot(vaultB, 3, merkle.Entry{Address: user, TotalEarned: big.NewInt(800)}, other)
+	saveSnapshot(vaultC, 1, other)
+
+	result, err := service.GetUserClaimable(ctx, "0x3575B992C5337226AECF4E7F93DFBE80C576CE15")
+	require.NoError(t, err)
+	assert.Equal(t, user, result.UserAddress)
+	assert.Equal(t, "1800", result.TotalEarned)
+	assert.Equal(t, "1300", result.TotalClaimed)
+	assert.Equal(t, "600", result.TotalClaimable, "a vault claimed past its latest root counts as nothing claimable")
+	require.Len(t, result.Vaults, 2, "vaults without a leaf for the user are left out")
+
+	inA := result.Vaults[0]
+	assert.Equal(t, vaultA, inA.VaultAddress)
+	assert.Equal(t, "3", inA.EpochNumber, "the latest snapshot holds the cumulative amount")
+	assert.Equal(t, "600", inA.Claimable)
+	assert.Equal(t, user, inA.Claim.Recipient)
+	assert.Equal(t, "1000", inA.Claim.TotalEarned)
+	require.Len(t, inA.Claim.MerkleProof, 1)
+	assert.Regexp(t, "^0x[0-9a-f]{64}$", inA.Claim.MerkleProof[0])
+	assert.Equal(t, "0", result.Vaults[1].Claimable)
+
+	contractClient.err = errors.New("rpc unavailable")
+	_, err = service.GetUserClaimable(ctx, user)
+	assert.Error(t, err)
+}
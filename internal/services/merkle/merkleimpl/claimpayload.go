@@ -0,0 +1,279 @@
+package merkleimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/pkg/contracts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	claimDomainName    = "DebtSubsidizer"
+	claimDomainVersion = "1"
+	claimPrimaryType   = "ClaimSubsidy"
+)
+
+// claimTypes are the EIP-712 types of a claim request, ClaimData mirrors IDebtSubsidizer.ClaimData
+var claimTypes = map[string][]merkle.TypedDataField{
+	"ClaimData": {
+		{Name: "recipient", Type: "address"},
+		{Name: "totalEarned", Type: "uint256"},
+		{Name: "merkleProof", Type: "bytes32[]"},
+	},
+	claimPrimaryType: {
+		{Name: "vaultAddress", Type: "address"},
+		{Name: "claim", Type: "ClaimData"},
+	},
+}
+
+// SetClaimDomain sets the chain and DebtSubsidizer claim payloads are encoded for. It is called once at startup,
+// before any payload is built.
+func (s *Service) SetClaimDomain(chainID uint64, debtSubsidizer string) {
+	s.claimChainID = chainID
+	s.claimSubsidizer = debtSubsidizer
+}
+
+// GetClaimPayload builds the user's claimSubsidy call from the vault's latest snapshot with EIP-712 typed data
+// of the same claim. claimSubsidy checks no signature, the typed data is for relayers to verify the user asked
+// for the claim before paying its gas. A claim that would revert is refused: nothing left to claim, or a latest
+// snapshot whose root is not the one on-chain yet.
+func (s *Service) GetClaimPayload(ctx context.Context, userAddress, vaultAddress string) (_ *merkle.ClaimPayload, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.GetClaimPayload", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(userAddress) {
+		return nil, fmt.Errorf("%w: invalid user address %q", merkle.ErrInvalidInput, userAddress)
+	}
+	if !utils.IsValidAddress(vaultAddress) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", merkle.ErrInvalidInput, vaultAddress)
+	}
+	if !utils.IsValidAddress(s.claimSubsidizer) {
+		return nil, fmt.Errorf("claim payloads need the DebtSubsidizer address, got %q", s.claimSubsidizer)
+	}
+	userAddress = utils.NormalizeAddress(userAddress)
+	vaultAddress = utils.NormalizeAddress(vaultAddress)
+
+	proof, err := s.latestProof(ctx, vaultAddress, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	earned, ok := new(big.Int).SetString(proof.TotalEarned, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid total earned %q in snapshot of vault %s", proof.TotalEarned, vaultAddress)
+	}
+	claimed, err := s.contractClient.GetUserClaimedTotal(ctx, vaultAddress, userAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get claimed total for vault %s: %w", vaultAddress, err)
+	}
+	claimable := new(big.Int).Sub(earned, claimed)
+	if claimable.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %s claimed %s of %s earned in vault %s",
+			merkle.ErrClaimUnavailable, userAddress, claimed, earned, vaultAddress)
+	}
+	onChainRoot, err := s.contractClient.GetMerkleRoot(ctx, vaultAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get merkle root for vault %s: %w", vaultAddress, err)
+	}
+	if normalizeRoot(common.Bytes2Hex(onChainRoot[:])) != normalizeRoot(proof.MerkleRoot) {
+		return nil, fmt.Errorf("%w: %w: root of epoch %s is not on-chain yet in vault %s",
+			merkle.ErrClaimUnavailable, merkle.ErrRootMismatch, proof.EpochNumber, vaultAddress)
+	}
+
+	claimProof := make([]string, len(proof.MerkleProof))
+	proofNodes := make([][32]byte, len(proof.MerkleProof))
+	for i, node := range proof.MerkleProof {
+		claimProof[i] = "0x" + node
+		proofNodes[i] = common.HexToHash(node)
+	}
+	data := contracts.NewIDebtSubsidizer().PackClaimSubsidy(common.HexToAddress(vaultAddress), contracts.IDebtSubsidizerClaimData{
+		Recipient:   common.HexToAddress(userAddress),
+		TotalEarned: earned,
+		MerkleProof: proofNodes,
+	})
+
+	typedData := s.claimTypedData(vaultAddress, userAddress, earned, claimProof)
+	hash, err := typedDataHash(typedData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash claim typed data: %w", err)
+	}
+	recipientType, err := s.recipientType(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &merkle.ClaimPayload{
+		UserAddress:  userAddress,
+		VaultAddress: vaultAddress,
+		EpochNumber:  proof.EpochNumber,
+		MerkleRoot:   proof.MerkleRoot,
+		Claimable:    claimable.String(),
+		Claim: merkle.ClaimData{
+			Recipient:   userAddress,
+			TotalEarned: earned.String(),
+			MerkleProof: claimProof,
+		},
+		Transaction: merkle.ClaimTransaction{
+			To:      utils.NormalizeAddress(s.claimSubsidizer),
+			Data:    hexutil.Encode(data),
+			Value:   "0",
+			ChainID: s.claimChainID,
+		},
+		TypedData:         typedData,
+		TypedDataHash:     hash,
+		RecipientType:     recipientType,
+		RecipientChecksum: utils.ChecksumAddress(userAddress),
+	}, nil
+}
+
+// VerifyClaimSignature checks signature is the recipient's signature of the typed data of their claim payload.
+// An externally owned account must have signed the digest with its key, a contract wallet must accept the
+// signature in isValidSignature, which checks whatever its owners sign with. The payload is built anew, so a
+// signature of a claim since superseded by a later distribution is invalid.
+func (s *Service) VerifyClaimSignature(
+	ctx context.Context,
+	userAddress, vaultAddress string,
+	signature []byte,
+) (_ *merkle.ClaimSignatureVerification, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.VerifyClaimSignature", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if len(signature) == 0 {
+		return nil, fmt.Errorf("%w: empty signature", merkle.ErrInvalidInput)
+	}
+	payload, err := s.GetClaimPayload(ctx, userAddress, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	digest := common.HexToHash(payload.TypedDataHash)
+
+	verification := &merkle.ClaimSignatureVerification{
+		UserAddress:   payload.UserAddress,
+		VaultAddress:  payload.VaultAddress,
+		RecipientType: payload.RecipientType,
+		TypedDataHash: payload.TypedDataHash,
+	}
+	if payload.RecipientType == merkle.RecipientContract {
+		valid, err := s.contractClient.IsValidSignature(ctx, payload.UserAddress, digest, signature)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check signature with contract wallet %s: %w", payload.UserAddress, err)
+		}
+		verification.Valid = valid
+		if !valid {
+			verification.Reason = "contract wallet did not accept the signature"
+		}
+		return verification, nil
+	}
+
+	signer, err := recoverSigner(digest, signature)
+	if err != nil {
+		verification.Reason = err.Error()
+		return verification, nil
+	}
+	verification.Signer = signer
+	verification.Valid = signer == payload.UserAddress
+	if !verification.Valid {
+		verification.Reason = fmt.Sprintf("signed by %s, not the recipient", signer)
+	}
+	return verification, nil
+}
+
+// recipientType tells a contract wallet from an externally owned account by the code deployed at the address
+func (s *Service) recipientType(ctx context.Context, address string) (string, error) {
+	isContract, err := s.contractClient.HasCode(ctx, address)
+	if err != nil {
+		return "", fmt.Errorf("failed to get code at recipient %s: %w", address, err)
+	}
+	if isContract {
+		return merkle.RecipientContract, nil
+	}
+	return merkle.RecipientEOA, nil
+}
+
+// recoverSigner returns the lowercase address that produced a 65-byte [R || S || V] signature of digest, with
+// V either 0/1 or the 27/28 eth_signTypedData_v4 returns
+func recoverSigner(digest common.Hash, signature []byte) (string, error) {
+	if len(signature) != crypto.SignatureLength {
+		return "", fmt.Errorf("signature is %d bytes, an EOA signature is %d", len(signature), crypto.SignatureLength)
+	}
+	sig := slices.Clone(signature)
+	if sig[crypto.RecoveryIDOffset] >= 27 {
+		sig[crypto.RecoveryIDOffset] -= 27
+	}
+	pub, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		return "", fmt.Errorf("signature does not recover a signer: %v", err)
+	}
+	return utils.NormalizeAddress(crypto.PubkeyToAddress(*pub).Hex()), nil
+}
+
+// claimTypedData returns the claim as EIP-712 typed data, without a chain id in the domain when none is configured
+func (s *Service) claimTypedData(vaultAddress, userAddress string, earned *big.Int, claimProof []string) merkle.TypedData {
+	domainType := []merkle.TypedDataField{{Name: "name", Type: "string"}, {Name: "version", Type: "string"}}
+	if s.claimChainID != 0 {
+		domainType = append(domainType, merkle.TypedDataField{Name: "chainId", Type: "uint256"})
+	}
+	domainType = append(domainType, merkle.TypedDataField{Name: "verifyingContract", Type: "address"})
+
+	types := map[string][]merkle.TypedDataField{"EIP712Domain": domainType}
+	for name, fields := range claimTypes {
+		types[name] = fields
+	}
+	return merkle.TypedData{
+		Types:       types,
+		PrimaryType: claimPrimaryType,
+		Domain: merkle.TypedDataDomain{
+			Name:              claimDomainName,
+			Version:           claimDomainVersion,
+			ChainID:           s.claimChainID,
+			VerifyingContract: utils.NormalizeAddress(s.claimSubsidizer),
+		},
+		Message: map[string]interface{}{
+			"vaultAddress": vaultAddress,
+			"claim": map[string]interface{}{
+				"recipient":   userAddress,
+				"totalEarned": earned.String(),
+				"merkleProof": claimProof,
+			},
+		},
+	}
+}
+
+// typedDataHash returns the 0x-prefixed EIP-712 digest of typedData
+func typedDataHash(typedData merkle.TypedData) (string, error) {
+	types := make(apitypes.Types, len(typedData.Types))
+	for name, fields := range typedData.Types {
+		for _, field := range fields {
+			types[name] = append(types[name], apitypes.Type{Name: field.Name, Type: field.Type})
+		}
+	}
+	domain := apitypes.TypedDataDomain{
+		Name:              typedData.Domain.Name,
+		Version:           typedData.Domain.Version,
+		VerifyingContract: typedData.Domain.VerifyingContract,
+	}
+	if typedData.Domain.ChainID != 0 {
+		domain.ChainId = math.NewHexOrDecimal256(int64(typedData.Domain.ChainID))
+	}
+
+	hash, _, err := apitypes.TypedDataAndHash(apitypes.TypedData{
+		Types:       types,
+		PrimaryType: typedData.PrimaryType,
+		Domain:      domain,
+		Message:     typedData.Message,
+	})
+	if err != nil {
+		return "", err
+	}
+	return hexutil.Encode(hash), nil
+}
@@ -0,0 +1,176 @@
+package merkleimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+func TestGetClaimPayload(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	user := "0x3575b992c5337226aecf4e7f93dfbe80c576ce15"
+	vault := "0x1111111111111111111111111111111111111111"
+	subsidizer := "0x9999999999999999999999999999999999999999"
+	contractClient := &stubContractClient{claimed: map[string]*big.Int{vault: big.NewInt(400)}}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+	service.SetClaimDomain(11155111, subsidizer)
+
+	entries := []merkle.Entry{
+		{Address: user, TotalEarned: big.NewInt(1000)},
+		{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)},
+	}
+	root := service.BuildMerkleRootFromEntries(entries)
+	snapshot := merkle.MerkleSnapshot{VaultID: vault, MerkleRoot: fmt.Sprintf("%x", root)}
+	for _, entry := range entries {
+		snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+	}
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), snapshot))
+
+	_, err = service.GetClaimPayload(ctx, user, vault)
+	assert.ErrorIs(t, err, merkle.ErrClaimUnavailable, "the root is not on-chain yet")
+
+	contractClient.root = root
+	payload, err := service.GetClaimPayload(ctx, "0x3575B992C5337226AECF4E7F93DFBE80C576CE15", vault)
+	require.NoError(t, err)
+	assert.Equal(t, user, payload.UserAddress)
+	assert.Equal(t, merkle.RecipientEOA, payload.RecipientType)
+	assert.Equal(t, "0x3575B992C5337226AEcf4e7f93Dfbe80c576CE15", payload.RecipientChecksum)
+	assert.Equal(t, "3", payload.EpochNumber)
+	assert.Equal(t, "600", payload.Claimable)
+	assert.Equal(t, "1000", payload.Claim.TotalEarned)
+	require.Len(t, payload.Claim.MerkleProof, 1)
+
+	assert.Equal(t, subsidizer, payload.Transaction.To)
+	assert.Equal(t, uint64(11155111), payload.Transaction.ChainID)
+	data, err := hexutil.Decode(payload.Transaction.Data)
+	require.NoError(t, err)
+	selector := crypto.Keccak256([]byte("claimSubsidy(address,(address,uint256,bytes32[]))"))[:4]
+	assert.Equal(t, selector, data[:4])
+	assert.Equal(t, common.HexToAddress(vault).Bytes(), data[4+12:4+32], "the vault is the first argument")
+
+	// a relayer decoding the typed data from JSON gets the digest the user signs
+	raw, err := json.Marshal(payload.TypedData)
+	require.NoError(t, err)
+	var typedData apitypes.TypedData
+	require.NoError(t, json.Unmarshal(raw, &typedData))
+	hash, _, err := apitypes.TypedDataAndHash(typedData)
+	require.NoError(t, err)
+	assert.Equal(t, hexutil.Encode(hash), payload.TypedDataHash)
+	assert.Equal(t, "ClaimSubsidy", typedData.PrimaryType)
+
+	contractClient.claimed[vault] = big.NewInt(1000)
+	_, err = service.GetClaimPayload(ctx, user, vault)
+	assert.ErrorIs(t, err, merkle.ErrClaimUnavailable, "everything earned was claimed")
+
+	_, err = service.GetClaimPayload(ctx, "0x8888888888888888888888888888888888888888", vault)
+	assert.ErrorIs(t, err, merkle.ErrNotFound)
+
+	_, err = service.GetClaimPayload(ctx, "bad", vault)
+	assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+}
+
+func TestVerifyClaimSignature(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	owner := strings.ToLower(crypto.PubkeyToAddress(key.PublicKey).Hex())
+	wallet := "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	walletSignature := []byte("signed by the wallet's owners")
+	vault := "0x1111111111111111111111111111111111111111"
+	contractClient := &stubContractClient{wallets: map[string][]byte{wallet: walletSignature}}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+	service.SetClaimDomain(11155111, "0x9999999999999999999999999999999999999999")
+
+	entries := []merkle.Entry{
+		{Address: owner, TotalEarned: big.NewInt(1000)},
+		{Address: wallet, TotalEarned: big.NewInt(500)},
+	}
+	root := service.BuildMerkleRootFromEntries(entries)
+	snapshot := merkle.MerkleSnapshot{VaultID: vault, MerkleRoot: fmt.Sprintf("%x", root)}
+	for _, entry := range entries {
+		snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+	}
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(1), snapshot))
+	contractClient.root = root
+
+	t.Run("eoa", func(t *testing.T) {
+		payload, err := service.GetClaimPayload(ctx, owner, vault)
+		require.NoError(t, err)
+		signature, err := crypto.Sign(common.HexToHash(payload.TypedDataHash).Bytes(), key)
+		require.NoError(t, err)
+		signature[crypto.RecoveryIDOffset] += 27 // as eth_signTypedData_v4 returns it
+
+		verification, err := service.VerifyClaimSignature(ctx, owner, vault, signature)
+		require.NoError(t, err)
+		assert.True(t, verification.Valid, verification.Reason)
+		assert.Equal(t, merkle.RecipientEOA, verification.RecipientType)
+		assert.Equal(t, owner, verification.Signer)
+
+		other, err := crypto.GenerateKey()
+		require.NoError(t, err)
+		signature, err = crypto.Sign(common.HexToHash(payload.TypedDataHash).Bytes(), other)
+		require.NoError(t, err)
+		verification, err = service.VerifyClaimSignature(ctx, owner, vault, signature)
+		require.NoError(t, err)
+		assert.False(t, verification.Valid)
+		assert.Contains(t, verification.Reason, "not the recipient")
+
+		verification, err = service.VerifyClaimSignature(ctx, owner, vault, walletSignature)
+		require.NoError(t, err)
+		assert.False(t, verification.Valid, "an EOA signature is 65 bytes")
+	})
+
+	t.Run("contract_wallet", func(t *testing.T) {
+		payload, err := service.GetClaimPayload(ctx, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", vault)
+		require.NoError(t, err)
+		assert.Equal(t, merkle.RecipientContract, payload.RecipientType)
+		assert.Equal(t, wallet, payload.Claim.Recipient)
+		assert.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", payload.RecipientChecksum)
+
+		verification, err := service.VerifyClaimSignature(ctx, wallet, vault, walletSignature)
+		require.NoError(t, err)
+		assert.True(t, verification.Valid)
+		assert.Equal(t, merkle.RecipientContract, verification.RecipientType)
+		assert.Empty(t, verification.Signer, "a contract wallet has no key to recover")
+
+		verification, err = service.VerifyClaimSignature(ctx, wallet, vault, []byte("forged"))
+		require.NoError(t, err)
+		assert.False(t, verification.Valid)
+	})
+
+	_, err = service.VerifyClaimSignature(ctx, owner, vault, nil)
+	assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+}
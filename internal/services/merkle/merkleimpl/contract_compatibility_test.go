@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/dgraph-io/badger/v4"
@@ -241,15 +242,16 @@ func createTestServiceForContract(t *testing.T) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil // Disable badger logging for tests
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &contractTestSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
 
 // contractTestSubgraphClient implements SubgraphClient for contract testing
@@ -279,13 +281,14 @@ func createTestServiceForContractBenchmark(b *testing.B) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil // Disable badger logging for tests
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		b.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &contractTestSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
@@ -0,0 +1,156 @@
+package merkleimpl
+
+import (
+	"context"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// deltaTree is the last tree built for a vault. The next build compares its sorted leaves with this one
+// position by position and only rehashes the leaves that changed and the nodes above them, reusing every
+// node whose subtree is unchanged. Leaves are sorted by address, so an account joining or leaving shifts
+// every later leaf and dirties the nodes above them, while accounts whose totalEarned did not move between
+// epochs keep their subtrees.
+type deltaTree struct {
+	version  uint64              // incremented on every build of the vault's tree
+	encoding merkle.LeafEncoding // how the leaves are hashed, a tree built with another encoding shares no nodes
+	entries  []merkle.Entry      // sorted leaves, addresses normalized
+	versions []uint64            // tree version each leaf last changed in
+	levels   [][][32]byte
+}
+
+func (t *deltaTree) root() [32]byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// rebuildTree builds the tree of sorted entries, reusing the nodes of prev that did not change.
+// prev may be nil for a full build, and shares no nodes when its leaves were hashed with another encoding.
+// It also returns which nodes of every level were hashed, which are the only ones to persist. The tree is
+// identical to buildLevels over the same entries.
+func rebuildTree(prev *deltaTree, sorted []merkle.Entry, encoding merkle.LeafEncoding, workers int) (*deltaTree, [][]bool) {
+	next := &deltaTree{version: 1, encoding: encoding, entries: sorted, versions: make([]uint64, len(sorted))}
+	var prevLevels [][][32]byte
+	if prev != nil {
+		next.version = prev.version + 1
+		if prev.encoding != encoding {
+			prev = nil
+		} else {
+			prevLevels = prev.levels
+		}
+	}
+
+	next.levels = allocLevels(len(sorted))
+	leaves := next.levels[0]
+	dirty := make([]bool, len(sorted))
+	parallelFor(len(sorted), workers, func(lo, hi int) {
+		h := newHasher()
+		for i := lo; i < hi; i++ {
+			if prev != nil && i < len(prev.entries) && prev.entries[i].Address == sorted[i].Address &&
+				prev.entries[i].TotalEarned.Cmp(sorted[i].TotalEarned) == 0 {
+				leaves[i] = prevLevels[0][i]
+				next.versions[i] = prev.versions[i]
+				continue
+			}
+			leaves[i] = h.leaf(encoding, sorted[i].Address, sorted[i].TotalEarned)
+			next.versions[i] = next.version
+			dirty[i] = true
+		}
+	})
+
+	hashed := [][]bool{dirty}
+	for l := 0; l+1 < len(next.levels); l++ {
+		level, up := next.levels[l], next.levels[l+1]
+		var prevLevel, prevUp [][32]byte
+		if l+1 < len(prevLevels) {
+			prevLevel, prevUp = prevLevels[l], prevLevels[l+1]
+		}
+
+		upDirty := make([]bool, len(up))
+		parallelFor(len(up), workers, func(lo, hi int) {
+			h := newHasher()
+			for j := lo; j < hi; j++ {
+				left, right := 2*j, 2*j+1
+				paired := right < len(level)
+				// a node is reused when its children are, and they were paired the same way
+				clean := j < len(prevUp) && !dirty[left] && (!paired || !dirty[right]) && paired == (right < len(prevLevel))
+				switch {
+				case clean:
+					up[j] = prevUp[j]
+					continue
+				case paired:
+					up[j] = h.pair(level[left], level[right])
+				default:
+					up[j] = level[left]
+				}
+				upDirty[j] = true
+			}
+		})
+
+		hashed = append(hashed, upDirty)
+		dirty = upDirty
+	}
+	return next, hashed
+}
+
+// BuildVaultMerkleRoot builds the root of the vault's next tree from the last tree built for it, kept in
+// memory and persisted so a restart does not fall back to a full build. Only leaves whose address or
+// amount changed at their sorted position are rehashed, with the vault's leaf encoding. The root is the one
+// BuildMerkleRootWithEncoding returns for the same entries and encoding.
+func (s *Service) BuildVaultMerkleRoot(ctx context.Context, vaultAddress string, entries []merkle.Entry) [32]byte {
+	if len(entries) == 0 {
+		return [32]byte{}
+	}
+
+	vault := utils.NormalizeAddress(vaultAddress)
+	sorted := make([]merkle.Entry, len(entries))
+	for i, entry := range entries {
+		sorted[i] = merkle.Entry{Address: utils.NormalizeAddress(entry.Address), TotalEarned: entry.TotalEarned}
+	}
+	s.sortEntries(sorted)
+
+	s.deltaMu.Lock()
+	defer s.deltaMu.Unlock()
+
+	prev, ok := s.deltaTrees[vault]
+	if !ok {
+		loaded, err := s.store.GetDeltaTree(ctx, vault)
+		if err != nil {
+			s.logger.Logf("WARN failed to load the last merkle tree of vault %s, building it in full: %v", vault, err)
+		}
+		prev = loaded
+	}
+
+	tree, hashed := rebuildTree(prev, sorted, s.LeafEncoding(vault), s.workers)
+	s.deltaTrees[vault] = tree
+
+	rehashed, nodes := 0, 0
+	for _, level := range hashed {
+		nodes += len(level)
+		for _, dirty := range level {
+			if dirty {
+				rehashed++
+			}
+		}
+	}
+	s.logger.Logf("DEBUG built merkle tree version %d of vault %s, rehashed %d of %d nodes",
+		tree.version, vault, rehashed, nodes)
+
+	if err := s.store.SaveDeltaTree(ctx, vault, prev, tree, hashed); err != nil {
+		s.logger.Logf("WARN failed to persist merkle tree version %d of vault %s: %v", tree.version, vault, err)
+	}
+	return tree.root()
+}
+
+// builtTree returns the sorted leaves and levels of the vault's last built tree when its root is root,
+// so indexing the proofs of a tree that was just built does not build it again
+func (s *Service) builtTree(vaultAddress string, root [32]byte) ([]merkle.Entry, [][][32]byte, bool) {
+	s.deltaMu.Lock()
+	defer s.deltaMu.Unlock()
+
+	tree, ok := s.deltaTrees[utils.NormalizeAddress(vaultAddress)]
+	if !ok || tree.root() != root {
+		return nil, nil, false
+	}
+	return tree.entries, tree.levels, true
+}
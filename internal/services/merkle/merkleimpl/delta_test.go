@@ -0,0 +1,160 @@
+package merkleimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// nextEpochEntries grows some totals, adds accounts and drops a few, like successive cumulative epochs
+func nextEpochEntries(rng *rand.Rand, entries []merkle.Entry, epoch int) []merkle.Entry {
+	next := make([]merkle.Entry, 0, len(entries)+8)
+	for _, entry := range entries {
+		switch r := rng.Intn(20); {
+		case r == 0 && len(entries) > 1:
+			continue
+		case r < 5:
+			entry.TotalEarned = new(big.Int).Add(entry.TotalEarned, big.NewInt(rng.Int63n(1e15)+1))
+		}
+		next = append(next, entry)
+	}
+	for i := rng.Intn(8); i >= 0; i-- {
+		addr := common.BigToAddress(big.NewInt(int64(epoch*1_000_003 + i*7919 + rng.Intn(7919))))
+		next = append(next, merkle.Entry{Address: addr.Hex(), TotalEarned: big.NewInt(rng.Int63n(1e15) + 1)})
+	}
+	rng.Shuffle(len(next), func(i, j int) { next[i], next[j] = next[j], next[i] })
+	return next
+}
+
+func TestBuildVaultMerkleRoot_MatchesFullBuild(t *testing.T) {
+	for _, size := range []int{1, 2, 7, 64, minParallelChunk*2 + 3} {
+		t.Run(fmt.Sprintf("entries_%d", size), func(t *testing.T) {
+			service := newProofsTestService(t)
+			service.workers = 4
+			ctx := context.Background()
+			rng := rand.New(rand.NewSource(int64(size)))
+
+			entries := generateTreeEntries(size)
+			for epoch := 1; epoch <= 12; epoch++ {
+				root := service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+				require.Equal(t, service.BuildMerkleRootFromEntries(entries), root, "epoch %d", epoch)
+
+				tree := service.deltaTrees[proofsTestVault]
+				require.Equal(t, uint64(epoch), tree.version)
+				sorted, levels, ok := service.builtTree(proofsTestVault, root)
+				require.True(t, ok)
+				assert.Equal(t, buildLevels(hashLeaves(sorted, merkle.LeafEncodingPacked, 1), 1), levels, "epoch %d", epoch)
+
+				entries = nextEpochEntries(rng, entries, epoch)
+			}
+		})
+	}
+}
+
+func TestBuildVaultMerkleRoot_ReusesUnchangedSubtrees(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	entries := generateTreeEntries(16)
+	service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+	prev := service.deltaTrees[proofsTestVault]
+
+	// only the path of the last leaf is rehashed when its amount grows
+	sorted := make([]merkle.Entry, len(prev.entries))
+	copy(sorted, prev.entries)
+	sorted[15].TotalEarned = new(big.Int).Add(sorted[15].TotalEarned, big.NewInt(1))
+	tree, hashed := rebuildTree(prev, sorted, merkle.LeafEncodingPacked, 1)
+	require.Len(t, hashed, 5)
+	for l, level := range hashed {
+		for i, dirty := range level {
+			assert.Equal(t, i == len(level)-1, dirty, "level %d node %d", l, i)
+		}
+	}
+	assert.Equal(t, uint64(1), tree.versions[0])
+	assert.Equal(t, uint64(2), tree.versions[15], "a changed leaf gets the new tree version")
+	assert.Equal(t, service.BuildMerkleRootFromEntries(sorted), tree.root())
+}
+
+func TestBuildVaultMerkleRoot_ResumesFromPersistedTree(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+	entries := generateTreeEntries(100)
+	service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+	entries = nextEpochEntries(rng, entries, 1)
+	service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+
+	// a restarted service loads the tree instead of building it in full
+	restarted := New(service.store.db, nil, nil, lgr.NoOp)
+	loaded, err := restarted.store.GetDeltaTree(ctx, proofsTestVault)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, service.deltaTrees[proofsTestVault], loaded)
+
+	// shrinking removes the nodes past the end of the tree
+	entries = entries[:37]
+	root := restarted.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+	assert.Equal(t, service.BuildMerkleRootFromEntries(entries), root)
+	assert.Equal(t, uint64(3), restarted.deltaTrees[proofsTestVault].version)
+	loaded, err = restarted.store.GetDeltaTree(ctx, proofsTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, restarted.deltaTrees[proofsTestVault], loaded)
+
+	empty, err := restarted.store.GetDeltaTree(ctx, "0x9999999999999999999999999999999999999999")
+	require.NoError(t, err)
+	assert.Nil(t, empty, "vaults without a tree have nothing to load")
+}
+
+func TestService_IndexesProofsFromBuiltTree(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	snapshot := proofsTestSnapshot(service)
+
+	plain := make([]merkle.Entry, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		plain[i] = merkle.Entry(entry)
+	}
+	root := service.BuildVaultMerkleRoot(ctx, proofsTestVault, plain)
+	require.Equal(t, snapshot.MerkleRoot, common.Bytes2Hex(root[:]))
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), snapshot))
+	snapshot.EpochNumber = big.NewInt(3)
+
+	for _, entry := range snapshot.Entries[:9] {
+		stored, err := service.GenerateHistoricalMerkleProof(ctx, entry.Address, proofsTestVault, "3")
+		require.NoError(t, err)
+		computed, err := service.generateProofFromSnapshot(&snapshot, entry.Address)
+		require.NoError(t, err)
+
+		stored.GeneratedAt, computed.GeneratedAt = 0, 0
+		assert.Equal(t, computed, stored)
+	}
+}
+
+func TestBuildVaultMerkleRoot_RebuildsOnEncodingChange(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	entries := generateTreeEntries(64)
+	service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+
+	service.SetLeafEncodings(merkle.LeafEncodingPacked, map[string]merkle.LeafEncoding{
+		common.HexToAddress(proofsTestVault).Hex(): merkle.LeafEncodingDoubleHash,
+	})
+	require.Equal(t, merkle.LeafEncodingDoubleHash, service.LeafEncoding(proofsTestVault))
+	root := service.BuildVaultMerkleRoot(ctx, proofsTestVault, entries)
+	assert.Equal(t, service.BuildMerkleRootWithEncoding(entries, merkle.LeafEncodingDoubleHash), root,
+		"no node of a tree hashed with another encoding is reused")
+
+	restarted := New(service.store.db, nil, nil, lgr.NoOp)
+	loaded, err := restarted.store.GetDeltaTree(ctx, proofsTestVault)
+	require.NoError(t, err)
+	require.NotNil(t, loaded)
+	assert.Equal(t, merkle.LeafEncodingDoubleHash, loaded.encoding)
+}
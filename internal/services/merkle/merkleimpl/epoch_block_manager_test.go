@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 	"github.com/dgraph-io/badger/v4"
 	"github.com/go-pkgz/lgr"
@@ -19,15 +20,16 @@ func TestService_New(t *testing.T) {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
 	defer func() {
-		assert.NoError(t, db.Close())
+		assert.NoError(t, badgerDB.Close())
 	}()
 
 	mockClient := &testServiceSubgraphClient{}
 
-	service := New(db, mockClient, logger)
+	service := New(db, mockClient, nil, logger)
 
 	assert.NotNil(t, service)
 	assert.NotNil(t, service.store)
@@ -96,15 +98,16 @@ func createTestServiceForEpochTests(t *testing.T) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &testServiceSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
 
 // testServiceSubgraphClient implements SubgraphClient for testing
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/dgraph-io/badger/v4"
@@ -225,15 +226,16 @@ func createTestService(t *testing.T) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil // Disable badger logging for tests
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &testSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
 
 // testSubgraphClient implements SubgraphClient for testing
@@ -0,0 +1,191 @@
+package merkleimpl
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/big"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// errProofsNotIndexed is returned by Store.GetProof for a tree whose leaf proofs were never saved
+var errProofsNotIndexed = errors.New("leaf proofs not indexed")
+
+// leafProof is the proof of an account's leaf, computed once when its tree is saved so proof requests
+// read one key instead of rebuilding the tree
+type leafProof struct {
+	Address     string   `json:"address"`
+	TotalEarned string   `json:"totalEarned"`
+	LeafIndex   int      `json:"leafIndex"`
+	Proof       []string `json:"proof"`
+	Root        string   `json:"root"` // root the proof was computed against, hex without 0x
+}
+
+// leafProofs yields the proof of every account in the tree from a single build of its levels with leaves
+// hashed with encoding, or from the levels BuildVaultMerkleRoot last built for the vault when they have the
+// same root. Proofs are yielded one at a time in sorted order and only converted to hex when yielded, so
+// the levels are the only part of the tree held in memory. An account with several entries is proven for
+// its first one, as generateProofFromSnapshot does.
+func (s *Service) leafProofs(
+	vaultAddress, merkleRoot string,
+	entries []merkle.MerkleEntry,
+	encoding merkle.LeafEncoding,
+) iter.Seq[leafProof] {
+	if len(entries) == 0 {
+		return func(func(leafProof) bool) {}
+	}
+
+	sorted, levels, ok := s.builtTree(vaultAddress, common.HexToHash(merkleRoot))
+	if !ok || len(sorted) != len(entries) {
+		sorted = make([]merkle.Entry, len(entries))
+		for i, entry := range entries {
+			sorted[i] = merkle.Entry(entry)
+		}
+		s.sortEntries(sorted)
+		levels = buildTree(sorted, encoding, s.workers)
+	}
+	root := levels[len(levels)-1][0]
+
+	// the entries of an account are adjacent once sorted; the few accounts with several of them are
+	// proven for the amount of their first entry, at the first sorted position holding it, as
+	// findLeafIndex reports it
+	firstAmounts := make(map[string]*big.Int)
+	for i := 1; i < len(sorted); i++ {
+		if address := utils.NormalizeAddress(sorted[i].Address); address == utils.NormalizeAddress(sorted[i-1].Address) {
+			firstAmounts[address] = nil
+		}
+	}
+	if len(firstAmounts) > 0 {
+		for _, entry := range entries {
+			address := utils.NormalizeAddress(entry.Address)
+			if amount, ok := firstAmounts[address]; ok && amount == nil {
+				firstAmounts[address] = entry.TotalEarned
+			}
+		}
+	}
+
+	return func(yield func(leafProof) bool) {
+		rootHex := common.Bytes2Hex(root[:])
+		for start := 0; start < len(sorted); {
+			address := utils.NormalizeAddress(sorted[start].Address)
+			end := start + 1
+			for end < len(sorted) && utils.NormalizeAddress(sorted[end].Address) == address {
+				end++
+			}
+
+			index := start
+			if amount := firstAmounts[address]; amount != nil {
+				for i := start; i < end; i++ {
+					if sorted[i].TotalEarned.Cmp(amount) == 0 {
+						index = i
+						break
+					}
+				}
+			}
+			start = end
+
+			path := proofFromLevels(levels, index)
+			proof := make([]string, len(path))
+			for i, node := range path {
+				proof[i] = common.Bytes2Hex(node[:])
+			}
+			if !yield(leafProof{
+				Address:     address,
+				TotalEarned: sorted[index].TotalEarned.String(),
+				LeafIndex:   index,
+				Proof:       proof,
+				Root:        rootHex,
+			}) {
+				return
+			}
+		}
+	}
+}
+
+// indexProofs computes and stores the proof of every leaf of the snapshot's tree, writing each proof as
+// it is computed
+func (s *Service) indexProofs(ctx context.Context, snapshot *merkle.MerkleSnapshot) error {
+	proofs := s.leafProofs(snapshot.VaultID, snapshot.MerkleRoot, snapshot.Entries, snapshot.Encoding())
+	count, err := s.store.SaveProofs(ctx, snapshot.EpochNumber, snapshot.VaultID, snapshot.MerkleRoot, proofs)
+	if err != nil {
+		return err
+	}
+	s.logger.Logf("DEBUG indexed %d leaf proofs for vault %s, epoch %s, root %s",
+		count, snapshot.VaultID, snapshot.EpochNumber.String(), snapshot.MerkleRoot)
+	return nil
+}
+
+// snapshotProof returns the user's proof in the epoch's tree with merkleRoot from the stored leaf proofs.
+// Trees saved before proofs were indexed are indexed on their first proof request.
+func (s *Service) snapshotProof(
+	ctx context.Context,
+	epochNumber *big.Int,
+	vaultAddress, merkleRoot, userAddress string,
+) (*merkle.UserMerkleProofResponse, error) {
+	proof, err := s.store.GetProof(ctx, epochNumber, vaultAddress, merkleRoot, userAddress)
+	if errors.Is(err, errProofsNotIndexed) {
+		snapshot, treeErr := s.treeSnapshot(ctx, epochNumber, vaultAddress, merkleRoot)
+		if treeErr != nil {
+			return nil, treeErr
+		}
+		if err := s.indexProofs(ctx, snapshot); err != nil {
+			s.logger.Logf("WARN failed to index leaf proofs for vault %s, epoch %s: %v", vaultAddress, epochNumber.String(), err)
+			return s.generateProofFromSnapshot(snapshot, userAddress)
+		}
+		proof, err = s.store.GetProof(ctx, epochNumber, vaultAddress, merkleRoot, userAddress)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a replaced root still proves what it was pushed with, the response says what replaced it
+	supersession, err := s.store.GetSupersession(ctx, vaultAddress, proof.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &merkle.UserMerkleProofResponse{
+		UserAddress:  userAddress,
+		VaultAddress: vaultAddress,
+		EpochNumber:  epochNumber.String(),
+		TotalEarned:  proof.TotalEarned,
+		MerkleProof:  proof.Proof,
+		MerkleRoot:   proof.Root,
+		LeafIndex:    proof.LeafIndex,
+		GeneratedAt:  time.Now().Unix(),
+	}
+	if supersession != nil {
+		response.SupersededBy = supersession.MerkleRoot
+	}
+	return response, nil
+}
+
+// latestProof returns the user's proof in the vault's latest snapshot
+func (s *Service) latestProof(ctx context.Context, vaultAddress, userAddress string) (*merkle.UserMerkleProofResponse, error) {
+	epochNumber, err := s.store.GetLatestEpoch(ctx, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	root, err := s.store.GetSnapshotRoot(ctx, epochNumber, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	return s.snapshotProof(ctx, epochNumber, vaultAddress, root, userAddress)
+}
+
+// treeSnapshot returns the epoch's tree with merkleRoot. Snapshots saved before trees were versioned
+// are only found as the epoch's current snapshot.
+func (s *Service) treeSnapshot(ctx context.Context, epochNumber *big.Int, vaultAddress, merkleRoot string) (*merkle.MerkleSnapshot, error) {
+	snapshot, err := s.store.GetSnapshotVersion(ctx, epochNumber, vaultAddress, merkleRoot)
+	if errors.Is(err, merkle.ErrNotFound) {
+		current, currentErr := s.store.GetSnapshot(ctx, epochNumber, vaultAddress)
+		if currentErr == nil && normalizeRoot(current.MerkleRoot) == normalizeRoot(merkleRoot) {
+			return current, nil
+		}
+	}
+	return snapshot, err
+}
@@ -0,0 +1,230 @@
+package merkleimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"os"
+	"os/exec"
+	"runtime"
+	"runtime/debug"
+	"runtime/metrics"
+	"testing"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	storageService "github.com/andrey/epoch-server/internal/services/storage"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const proofsTestVault = "0x1234567890123456789012345678901234567890"
+
+func newProofsTestService(t *testing.T) *Service {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return New(db, nil, nil, lgr.NoOp)
+}
+
+// proofsTestSnapshot has 9 accounts, the first of them with a second entry for another collection
+func proofsTestSnapshot(service *Service) merkle.MerkleSnapshot {
+	var entries []merkle.MerkleEntry
+	for i := 1; i <= 9; i++ {
+		entries = append(entries, merkle.MerkleEntry{
+			Address:     fmt.Sprintf("0x%040x", i*7919),
+			TotalEarned: big.NewInt(int64(i) * 1000),
+		})
+	}
+	entries = append(entries, merkle.MerkleEntry{Address: entries[0].Address, TotalEarned: big.NewInt(5)})
+
+	plain := make([]merkle.Entry, len(entries))
+	for i, entry := range entries {
+		plain[i] = merkle.Entry(entry)
+	}
+	root := service.BuildMerkleRootFromEntries(plain)
+	return merkle.MerkleSnapshot{
+		Entries:    entries,
+		MerkleRoot: common.Bytes2Hex(root[:]),
+		VaultID:    proofsTestVault,
+	}
+}
+
+func TestService_StoredProofsMatchComputedProofs(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	snapshot := proofsTestSnapshot(service)
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), snapshot))
+	snapshot.EpochNumber = big.NewInt(3)
+
+	for _, entry := range snapshot.Entries[:9] {
+		stored, err := service.GenerateHistoricalMerkleProof(ctx, entry.Address, proofsTestVault, "3")
+		require.NoError(t, err)
+		computed, err := service.generateProofFromSnapshot(&snapshot, entry.Address)
+		require.NoError(t, err)
+
+		stored.GeneratedAt, computed.GeneratedAt = 0, 0
+		assert.Equal(t, computed, stored)
+	}
+
+	latest, err := service.GenerateUserMerkleProof(ctx, snapshot.Entries[0].Address, proofsTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", latest.TotalEarned, "an account is proven for its first entry")
+
+	_, err = service.GenerateHistoricalMerkleProof(ctx, "0x9999999999999999999999999999999999999999", proofsTestVault, "3")
+	assert.ErrorIs(t, err, merkle.ErrNotFound)
+}
+
+func TestService_IndexesProofsOnFirstRequest(t *testing.T) {
+	service := newProofsTestService(t)
+	ctx := context.Background()
+	snapshot := proofsTestSnapshot(service)
+	// a snapshot saved before proofs were precomputed
+	require.NoError(t, service.store.SaveSnapshot(ctx, big.NewInt(4), snapshot))
+
+	user := snapshot.Entries[4].Address
+	_, err := service.store.GetProof(ctx, big.NewInt(4), proofsTestVault, snapshot.MerkleRoot, user)
+	require.ErrorIs(t, err, errProofsNotIndexed)
+
+	proof, err := service.GenerateMerkleProofForRoot(ctx, user, proofsTestVault, "4", "0x"+snapshot.MerkleRoot)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot.MerkleRoot, proof.MerkleRoot)
+	assert.Equal(t, "5000", proof.TotalEarned)
+
+	stored, err := service.store.GetProof(ctx, big.NewInt(4), proofsTestVault, snapshot.MerkleRoot, user)
+	require.NoError(t, err, "the tree was indexed by the first request")
+	assert.Equal(t, proof.MerkleProof, stored.Proof)
+
+	_, err = service.GenerateMerkleProofForRoot(ctx, user, proofsTestVault, "4", fmt.Sprintf("%064x", 1))
+	assert.ErrorIs(t, err, merkle.ErrNotFound, "unknown roots are not found")
+}
+
+// peakHeap samples the live heap until stop is called and returns the largest sample in bytes
+func peakHeap() (stop func() uint64) {
+	sample := []metrics.Sample{{Name: "/memory/classes/heap/objects:bytes"}}
+	done := make(chan struct{})
+	peak := make(chan uint64)
+	go func() {
+		var largest uint64
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			metrics.Read(sample)
+			largest = max(largest, sample[0].Value.Uint64())
+			select {
+			case <-done:
+				peak <- largest
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return func() uint64 {
+		close(done)
+		return <-peak
+	}
+}
+
+// TestIndexProofs_FitsLowMemoryContainer indexes a 1M-leaf tree with the options and memory limit of low-memory
+// mode and checks the heap stays within the limit, the rest of the 512MB container left to the runtime. The
+// heap of the other tests would count against it, so the tree is indexed in a test process of its own.
+func TestIndexProofs_FitsLowMemoryContainer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("indexes a 1M-leaf tree")
+	}
+	if os.Getenv("INDEX_PROOFS_ALONE") == "" {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestIndexProofs_FitsLowMemoryContainer$")
+		cmd.Env = append(os.Environ(), "INDEX_PROOFS_ALONE=1", "GOMEMLIMIT=")
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, "%s", out)
+		return
+	}
+	debug.SetMemoryLimit(storageService.LowMemoryLimit)
+
+	opts := storageService.LowMemoryOptions(badger.DefaultOptions(t.TempDir()))
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	defer badgerDB.Close()
+	service := New(storage.Badger(badgerDB), nil, nil, lgr.NoOp)
+
+	plain := generateTreeEntries(1_000_000)
+	entries := make([]merkle.MerkleEntry, len(plain))
+	for i, entry := range plain {
+		entries[i] = merkle.MerkleEntry(entry)
+	}
+	root := service.BuildMerkleRootFromEntries(plain)
+	snapshot := &merkle.MerkleSnapshot{
+		EpochNumber: big.NewInt(1),
+		Entries:     entries,
+		MerkleRoot:  common.Bytes2Hex(root[:]),
+		VaultID:     proofsTestVault,
+	}
+
+	runtime.GC()
+	stop := peakHeap()
+	require.NoError(t, service.indexProofs(context.Background(), snapshot))
+	peak := stop()
+	assert.Less(t, peak, uint64(storageService.LowMemoryLimit), "peak heap %d MB", peak>>20)
+}
+
+// BenchmarkIndexProofs reports the peak heap of computing and storing every leaf proof of a tree, the most
+// memory a distribution needs, with badger's default and low-memory options: run with -benchtime=1x for
+// the 1M-leaf tree. The heap includes the snapshot's entries, about 200MB of the 1M-leaf peak, and garbage
+// the collector has not reclaimed yet. Runs without a memory limit, as below; low-memory mode's limit keeps
+// the 1M-leaf run under 365MB, which TestIndexProofs_FitsLowMemoryContainer asserts.
+//
+//	                            proofs built in full   proofs streamed
+//	entries_100000/default      835 MB                 890 MB
+//	entries_100000/low_memory   466 MB                 149 MB
+//	entries_1000000/default     not run                3333 MB
+//	entries_1000000/low_memory  4097 MB                657 MB
+func BenchmarkIndexProofs(b *testing.B) {
+	databases := map[string]func(badger.Options) badger.Options{
+		"default":    func(opts badger.Options) badger.Options { return opts },
+		"low_memory": storageService.LowMemoryOptions,
+	}
+	for _, size := range []int{100_000, 1_000_000} {
+		for name, options := range databases {
+			b.Run(fmt.Sprintf("entries_%d/%s", size, name), func(b *testing.B) {
+				// on disk as in production, an in-memory database would count every stored proof as heap
+				opts := options(badger.DefaultOptions(b.TempDir()))
+				opts.Logger = nil
+				badgerDB, err := badger.Open(opts)
+				require.NoError(b, err)
+				db := storage.Badger(badgerDB)
+				defer badgerDB.Close()
+				service := New(db, nil, nil, lgr.NoOp)
+
+				plain := generateTreeEntries(size)
+				entries := make([]merkle.MerkleEntry, len(plain))
+				for i, entry := range plain {
+					entries[i] = merkle.MerkleEntry(entry)
+				}
+				root := service.BuildMerkleRootFromEntries(plain)
+				snapshot := &merkle.MerkleSnapshot{
+					EpochNumber: big.NewInt(1),
+					Entries:     entries,
+					MerkleRoot:  common.Bytes2Hex(root[:]),
+					VaultID:     proofsTestVault,
+				}
+
+				runtime.GC()
+				b.ReportAllocs()
+				b.ResetTimer()
+				stop := peakHeap()
+				for i := 0; i < b.N; i++ {
+					require.NoError(b, service.indexProofs(context.Background(), snapshot))
+				}
+				b.ReportMetric(float64(stop())/(1<<20), "peak-heap-MB")
+			})
+		}
+	}
+}
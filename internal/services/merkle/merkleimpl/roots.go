@@ -0,0 +1,105 @@
+package merkleimpl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// SetRootHistory sets the block root history is synced from and how many blocks deep a MerkleRootUpdated event
+// must be before it is stored. It is called once at startup, before roots are listed.
+func (s *Service) SetRootHistory(startBlock, confirmations uint64) {
+	s.rootsStartBlock = startBlock
+	s.rootsConfirmations = confirmations
+}
+
+// ListRootUpdates syncs the vault's MerkleRootUpdated events from the chain and returns every one stored, oldest
+// first, with the epoch of the stored tree each root was built from. When the chain cannot be read the history
+// stored so far is returned.
+func (s *Service) ListRootUpdates(ctx context.Context, vaultAddress string) (_ []merkle.RootUpdate, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.ListRootUpdates", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultAddress == "" {
+		return nil, fmt.Errorf("%w: vaultAddress cannot be empty", merkle.ErrInvalidInput)
+	}
+
+	if err := s.syncRootUpdates(ctx, vaultAddress); err != nil {
+		s.logger.Logf("WARN failed to sync merkle root history of vault %s, serving the stored history: %v", vaultAddress, err)
+	}
+
+	updates, err := s.store.ListRootUpdates(ctx, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	epochs, err := s.store.RootEpochs(ctx, vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	for i := range updates {
+		updates[i].EpochNumber = epochs[updates[i].MerkleRoot]
+	}
+	return updates, nil
+}
+
+// syncRootUpdates stores the vault's MerkleRootUpdated events from the block after the one its history is synced
+// through. Only blocks rootsConfirmations deep are read, so a reorg cannot take back a stored event.
+func (s *Service) syncRootUpdates(ctx context.Context, vaultAddress string) error {
+	if s.contractClient == nil {
+		return nil
+	}
+
+	s.rootsMu.Lock()
+	defer s.rootsMu.Unlock()
+
+	head, err := s.contractClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if head.Number < s.rootsConfirmations {
+		return nil
+	}
+	to := head.Number - s.rootsConfirmations
+
+	from := s.rootsStartBlock
+	synced, ok, err := s.store.GetRootsSyncedBlock(ctx, vaultAddress)
+	if err != nil {
+		return err
+	}
+	if ok {
+		from = synced + 1
+	}
+	if from > to {
+		return nil
+	}
+
+	events, err := s.contractClient.GetMerkleRootUpdates(ctx, vaultAddress, from, to)
+	if err != nil {
+		return err
+	}
+	updates := make([]merkle.RootUpdate, len(events))
+	for i, event := range events {
+		updates[i] = merkle.RootUpdate{
+			VaultAddress:   event.Vault,
+			MerkleRoot:     common.Bytes2Hex(event.MerkleRoot[:]),
+			TotalSubsidies: event.TotalSubsidies.String(),
+			UpdatedBy:      event.UpdatedBy,
+			TxHash:         event.TxHash,
+			BlockNumber:    event.BlockNumber,
+			BlockHash:      event.BlockHash,
+			LogIndex:       event.LogIndex,
+		}
+	}
+	if err := s.store.SaveRootUpdates(ctx, vaultAddress, updates, to); err != nil {
+		return err
+	}
+
+	if len(updates) > 0 {
+		s.logger.Logf("INFO synced %d merkle root updates of vault %s in blocks %d-%d", len(updates), vaultAddress, from, to)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package merkleimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListRootUpdates(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	vault := "0x1111111111111111111111111111111111111111"
+	contractClient := &stubContractClient{head: 100}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+	service.SetRootHistory(10, 6)
+
+	entries := []merkle.Entry{{Address: "0x3575b992c5337226aecf4e7f93dfbe80c576ce15", TotalEarned: big.NewInt(1000)}}
+	built := service.BuildMerkleRootFromEntries(entries)
+	snapshot := merkle.MerkleSnapshot{VaultID: vault, MerkleRoot: fmt.Sprintf("%x", built)}
+	snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entries[0]))
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(4), snapshot))
+
+	update := func(root [32]byte, block uint64, total int64) blockchain.MerkleRootUpdate {
+		return blockchain.MerkleRootUpdate{
+			Vault: vault, MerkleRoot: root, UpdatedBy: "0x742d35cc6634c0532925a3b844bc454e4438f44e",
+			TotalSubsidies: big.NewInt(total), TxHash: fmt.Sprintf("0x%064x", block), BlockNumber: block,
+		}
+	}
+	contractClient.updates = []blockchain.MerkleRootUpdate{
+		update([32]byte{1}, 5, 100),   // before the start block
+		update([32]byte{2}, 40, 600),  // pushed by another server, no stored tree has it
+		update(built, 90, 1000),       // built for epoch 4
+		update([32]byte{3}, 97, 1200), // not buried deep enough yet
+	}
+
+	updates, err := service.ListRootUpdates(ctx, vault)
+	require.NoError(t, err)
+	require.Len(t, updates, 2)
+	assert.Equal(t, fmt.Sprintf("%x", [32]byte{2}), updates[0].MerkleRoot)
+	assert.Empty(t, updates[0].EpochNumber)
+	assert.Equal(t, "600", updates[0].TotalSubsidies)
+	assert.Equal(t, fmt.Sprintf("%x", built), updates[1].MerkleRoot)
+	assert.Equal(t, "4", updates[1].EpochNumber)
+	assert.Equal(t, uint64(90), updates[1].BlockNumber)
+	assert.Equal(t, fmt.Sprintf("0x%064x", 90), updates[1].TxHash)
+
+	synced, ok, err := service.store.GetRootsSyncedBlock(ctx, vault)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, uint64(94), synced)
+
+	// the next listing only reads the blocks mined since
+	contractClient.head = 110
+	updates, err = service.ListRootUpdates(ctx, vault)
+	require.NoError(t, err)
+	require.Len(t, updates, 3)
+	assert.Equal(t, uint64(97), updates[2].BlockNumber)
+
+	// the stored history is served while the chain cannot be read
+	contractClient.err = errors.New("connection refused")
+	updates, err = service.ListRootUpdates(ctx, vault)
+	require.NoError(t, err)
+	assert.Len(t, updates, 3)
+
+	updates, err = service.ListRootUpdates(ctx, "0x2222222222222222222222222222222222222222")
+	require.NoError(t, err)
+	assert.Empty(t, updates)
+
+	_, err = service.ListRootUpdates(ctx, "")
+	assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+}
@@ -4,33 +4,77 @@ import (
 	"context"
 	"fmt"
 	"math/big"
+	"runtime"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/merkle"
-	"github.com/dgraph-io/badger/v4"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
-	store       *Store
-	graphClient merkle.SubgraphClient
-	logger      lgr.L
+	store          *Store
+	graphClient    merkle.SubgraphClient
+	contractClient merkle.ContractClient
+	logger         lgr.L
+	workers        int // goroutines hashing each tree level
+
+	leafEncoding   merkle.LeafEncoding            // leaf encoding of vaults without their own
+	vaultEncodings map[string]merkle.LeafEncoding // leaf encodings of vaults whose DebtSubsidizer hashes leaves differently, by normalized address
+
+	deltaMu    sync.Mutex
+	deltaTrees map[string]*deltaTree // last tree built for each vault, by normalized address
+
+	rootsMu            sync.Mutex // serializes root history syncs
+	rootsStartBlock    uint64     // block root history is synced from
+	rootsConfirmations uint64     // blocks a MerkleRootUpdated event must be buried under before it is stored
+
+	claimChainID    uint64 // chain claim payloads are encoded for, 0 when not configured
+	claimSubsidizer string // DebtSubsidizer claim payloads call
 }
 
-func New(db *badger.DB, graphClient merkle.SubgraphClient, logger lgr.L) *Service {
+func New(db storage.DB, graphClient merkle.SubgraphClient, contractClient merkle.ContractClient, logger lgr.L) *Service {
 	return &Service{
-		store:       NewStore(db, logger),
-		graphClient: graphClient,
-		logger:      logger,
+		store:          NewStore(db, logger),
+		graphClient:    graphClient,
+		contractClient: contractClient,
+		logger:         logger,
+		workers:        runtime.GOMAXPROCS(0),
+		leafEncoding:   merkle.LeafEncodingPacked,
+		deltaTrees:     make(map[string]*deltaTree),
 	}
 }
 
-func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaultAddress string) (*merkle.UserMerkleProofResponse, error) {
+// SetLeafEncodings selects the leaf encoding trees are built with, for every vault and for vaults keyed by address.
+// It is called once at startup, before any tree is built.
+func (s *Service) SetLeafEncodings(defaultEncoding merkle.LeafEncoding, vaultEncodings map[string]merkle.LeafEncoding) {
+	s.leafEncoding = defaultEncoding
+	s.vaultEncodings = make(map[string]merkle.LeafEncoding, len(vaultEncodings))
+	for vault, encoding := range vaultEncodings {
+		s.vaultEncodings[utils.NormalizeAddress(vault)] = encoding
+	}
+}
+
+// LeafEncoding returns the leaf encoding the vault's trees are built with
+func (s *Service) LeafEncoding(vaultAddress string) merkle.LeafEncoding {
+	if encoding, ok := s.vaultEncodings[utils.NormalizeAddress(vaultAddress)]; ok {
+		return encoding
+	}
+	return s.leafEncoding
+}
+
+func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaultAddress string) (_ *merkle.UserMerkleProofResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.GenerateUserMerkleProof", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if userAddress == "" {
 		return nil, fmt.Errorf("%w: userAddress cannot be empty", merkle.ErrInvalidInput)
 	}
@@ -41,11 +85,14 @@ func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaul
 	s.logger.Logf("INFO generating merkle proof for user %s in vault %s", userAddress, vaultAddress)
 
 	// First try to get from stored snapshot (prioritize snapshot over subgraph)
-	latestSnapshot, err := s.store.GetLatestSnapshot(ctx, vaultAddress)
-	if err == nil && latestSnapshot != nil {
-		s.logger.Logf("INFO found latest snapshot for vault %s, epoch %s with %d entries, root: %s",
-			vaultAddress, latestSnapshot.EpochNumber.String(), len(latestSnapshot.Entries), latestSnapshot.MerkleRoot)
-		return s.generateProofFromSnapshot(latestSnapshot, userAddress)
+	latestEpoch, err := s.store.GetLatestEpoch(ctx, vaultAddress)
+	if err == nil {
+		root, err := s.store.GetSnapshotRoot(ctx, latestEpoch, vaultAddress)
+		if err != nil {
+			return nil, err
+		}
+		s.logger.Logf("INFO found latest snapshot for vault %s, epoch %s, root: %s", vaultAddress, latestEpoch.String(), root)
+		return s.snapshotProof(ctx, latestEpoch, vaultAddress, root, userAddress)
 	}
 
 	s.logger.Logf("WARN no snapshot found for vault %s, falling back to subgraph: %v", vaultAddress, err)
@@ -54,14 +101,14 @@ func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaul
 	// that was used during the last distribution to ensure merkle root consistency
 
 	// Fallback: Get the latest processed epoch for this vault from subgraph
-	latestEpoch, err := s.getLatestProcessedEpochForVault(ctx, vaultAddress)
+	processedEpoch, err := s.getLatestProcessedEpochForVault(ctx, vaultAddress)
 	if err != nil {
 		s.logger.Logf("ERROR failed to get latest processed epoch: %v", err)
 		return nil, fmt.Errorf("%w: %v", merkle.ErrProofGeneration, err)
 	}
 
 	// Get epoch timestamp information
-	epochTimestamp, err := s.parseEpochTimestamp(latestEpoch)
+	epochTimestamp, err := s.parseEpochTimestamp(processedEpoch)
 	if err != nil {
 		s.logger.Logf("ERROR failed to parse epoch timestamp: %v", err)
 		return nil, fmt.Errorf("%w: %v", merkle.ErrProofGeneration, err)
@@ -97,7 +144,7 @@ func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaul
 	}
 
 	// Generate merkle proof
-	proof, root, err := s.GenerateProof(entries, userEntry.Address, userEntry.TotalEarned)
+	proof, root, err := s.generateProof(entries, userEntry.Address, userEntry.TotalEarned, s.LeafEncoding(vaultAddress))
 	if err != nil {
 		s.logger.Logf("ERROR failed to generate merkle proof: %v", err)
 		return nil, fmt.Errorf("%w: %v", merkle.ErrProofGeneration, err)
@@ -115,7 +162,7 @@ func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaul
 	return &merkle.UserMerkleProofResponse{
 		UserAddress:  userAddress,
 		VaultAddress: vaultAddress,
-		EpochNumber:  latestEpoch.EpochNumber,
+		EpochNumber:  processedEpoch.EpochNumber,
 		TotalEarned:  userEntry.TotalEarned.String(),
 		MerkleProof:  proofStrings,
 		MerkleRoot:   common.Bytes2Hex(root[:]),
@@ -124,7 +171,11 @@ func (s *Service) GenerateUserMerkleProof(ctx context.Context, userAddress, vaul
 	}, nil
 }
 
-func (s *Service) GenerateHistoricalMerkleProof(ctx context.Context, userAddress, vaultAddress, epochNumber string) (*merkle.UserMerkleProofResponse, error) {
+func (s *Service) GenerateHistoricalMerkleProof(ctx context.Context, userAddress, vaultAddress, epochNumber string) (_ *merkle.UserMerkleProofResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.GenerateHistoricalMerkleProof",
+		attribute.String("vault.id", vaultAddress), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if userAddress == "" {
 		return nil, fmt.Errorf("%w: userAddress cannot be empty", merkle.ErrInvalidInput)
 	}
@@ -143,10 +194,10 @@ func (s *Service) GenerateHistoricalMerkleProof(ctx context.Context, userAddress
 		return nil, fmt.Errorf("%w: invalid epoch number format", merkle.ErrInvalidInput)
 	}
 
-	snapshot, err := s.store.GetSnapshot(ctx, epochNum, vaultAddress)
+	snapshotRoot, err := s.store.GetSnapshotRoot(ctx, epochNum, vaultAddress)
 	if err == nil {
-		// Found stored snapshot, generate proof from it
-		return s.generateProofFromSnapshot(snapshot, userAddress)
+		// Found stored snapshot, read the proof precomputed from it
+		return s.snapshotProof(ctx, epochNum, vaultAddress, snapshotRoot, userAddress)
 	}
 
 	// If snapshot not found, generate from subgraph data
@@ -195,7 +246,7 @@ func (s *Service) GenerateHistoricalMerkleProof(ctx context.Context, userAddress
 	}
 
 	// Generate merkle proof
-	proof, root, err := s.GenerateProof(entries, userEntry.Address, userEntry.TotalEarned)
+	proof, root, err := s.generateProof(entries, userEntry.Address, userEntry.TotalEarned, s.LeafEncoding(vaultAddress))
 	if err != nil {
 		s.logger.Logf("ERROR failed to generate historical merkle proof: %v", err)
 		return nil, fmt.Errorf("%w: %v", merkle.ErrProofGeneration, err)
@@ -222,6 +273,153 @@ func (s *Service) GenerateHistoricalMerkleProof(ctx context.Context, userAddress
 	}, nil
 }
 
+// GenerateMerkleProofForRoot generates a proof against a specific root submitted for an epoch,
+// including roots that were later replaced by a resubmission
+func (s *Service) GenerateMerkleProofForRoot(
+	ctx context.Context,
+	userAddress, vaultAddress, epochNumber, merkleRoot string,
+) (_ *merkle.UserMerkleProofResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.GenerateMerkleProofForRoot",
+		attribute.String("vault.id", vaultAddress), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if userAddress == "" {
+		return nil, fmt.Errorf("%w: userAddress cannot be empty", merkle.ErrInvalidInput)
+	}
+	if vaultAddress == "" {
+		return nil, fmt.Errorf("%w: vaultAddress cannot be empty", merkle.ErrInvalidInput)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok {
+		return nil, fmt.Errorf("%w: invalid epoch number format", merkle.ErrInvalidInput)
+	}
+	root := normalizeRoot(merkleRoot)
+	if len(root) != 64 || !isHex(root) {
+		return nil, fmt.Errorf("%w: merkle root must be 32 bytes of hex", merkle.ErrInvalidInput)
+	}
+
+	s.logger.Logf("INFO generating merkle proof for user %s in vault %s for epoch %s against root %s",
+		userAddress, vaultAddress, epochNumber, root)
+
+	return s.snapshotProof(ctx, epochNum, vaultAddress, root, userAddress)
+}
+
+func (s *Service) VerifyMerkleRoot(ctx context.Context, vaultAddress string) (_ *merkle.MerkleRootVerification, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.VerifyMerkleRoot", attribute.String("vault.id", vaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultAddress == "" {
+		return nil, fmt.Errorf("%w: vaultAddress cannot be empty", merkle.ErrInvalidInput)
+	}
+	if s.contractClient == nil {
+		return nil, fmt.Errorf("contract client is not configured")
+	}
+
+	snapshot, err := s.store.GetLatestSnapshot(ctx, vaultAddress)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", merkle.ErrNotFound, err)
+	}
+
+	entries := make([]merkle.Entry, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		entries[i] = merkle.Entry(entry)
+	}
+	computed := s.BuildMerkleRootWithEncoding(entries, snapshot.Encoding())
+
+	onChain, err := s.contractClient.GetMerkleRoot(ctx, vaultAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain merkle root for vault %s: %w", vaultAddress, err)
+	}
+
+	result := &merkle.MerkleRootVerification{
+		VaultAddress: vaultAddress,
+		EpochNumber:  snapshot.EpochNumber.String(),
+		LeafCount:    len(entries),
+		ComputedRoot: common.Bytes2Hex(computed[:]),
+		StoredRoot:   normalizeRoot(snapshot.MerkleRoot),
+		OnChainRoot:  common.Bytes2Hex(onChain[:]),
+		LeafEncoding: string(snapshot.Encoding()),
+		VerifiedAt:   time.Now().Unix(),
+	}
+
+	if result.ComputedRoot != result.StoredRoot {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf(
+			"root recomputed from %d stored leaves (%s) differs from snapshot root (%s)",
+			result.LeafCount, result.ComputedRoot, result.StoredRoot))
+	}
+	if result.ComputedRoot != result.OnChainRoot {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf(
+			"root recomputed from stored leaves (%s) differs from on-chain root (%s)",
+			result.ComputedRoot, result.OnChainRoot))
+	}
+	if encoding := s.LeafEncoding(vaultAddress); snapshot.Encoding() != encoding {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf(
+			"snapshot leaves are hashed with the %s encoding, the vault is configured for %s",
+			snapshot.Encoding(), encoding))
+	}
+	result.Match = len(result.Mismatches) == 0
+
+	span.SetAttributes(attribute.Bool("merkle.root_match", result.Match))
+	if result.Match {
+		s.logger.Logf("INFO merkle root verified for vault %s epoch %s: %s", vaultAddress, result.EpochNumber, result.OnChainRoot)
+	} else {
+		s.logger.Logf("WARN merkle root mismatch for vault %s epoch %s: %v", vaultAddress, result.EpochNumber, result.Mismatches)
+	}
+
+	return result, nil
+}
+
+// leafEncodingLookback is how many of a vault's latest snapshots are searched for the tree its on-chain root
+// was built from. The latest may be the one about to be pushed, so the tree on-chain is usually the one before.
+const leafEncodingLookback = 3
+
+// CheckLeafEncoding checks the vault's leaf encoding against its DebtSubsidizer before a root built with it is
+// pushed. The contract does not expose how it hashes leaves, so the root it holds is looked up among the vault's
+// recent stored trees, and a root built with another encoding means the deployment verifies claims with that one.
+// A contract without a root, or holding one no recent stored tree produced, leaves nothing to check.
+func (s *Service) CheckLeafEncoding(ctx context.Context, vaultAddress string) error {
+	if s.contractClient == nil {
+		return nil
+	}
+
+	onChain, err := s.contractClient.GetMerkleRoot(ctx, vaultAddress)
+	if err != nil {
+		return fmt.Errorf("failed to read on-chain merkle root for vault %s: %w", vaultAddress, err)
+	}
+	if onChain == [32]byte{} {
+		return nil
+	}
+	onChainRoot := common.Bytes2Hex(onChain[:])
+
+	recent, err := s.store.ListSnapshots(ctx, vaultAddress, leafEncodingLookback)
+	if err != nil {
+		return err
+	}
+	var pushed *merkle.MerkleSnapshot
+	for i := range recent {
+		if normalizeRoot(recent[i].MerkleRoot) == onChainRoot {
+			pushed = &recent[i]
+			break
+		}
+		// an epoch that was resubmitted keeps the trees it replaced
+		if version, err := s.store.GetSnapshotVersion(ctx, recent[i].EpochNumber, vaultAddress, onChainRoot); err == nil {
+			pushed = version
+			break
+		}
+	}
+	encoding := s.LeafEncoding(vaultAddress)
+	if pushed == nil {
+		s.logger.Logf("DEBUG on-chain root %s of vault %s is not a recent stored tree, leaf encoding %s not checked",
+			onChainRoot, vaultAddress, encoding)
+		return nil
+	}
+	if pushed.Encoding() == encoding {
+		return nil
+	}
+	return fmt.Errorf("%w: on-chain root of vault %s was built with the %s encoding, the vault is configured for %s",
+		merkle.ErrLeafEncodingMismatch, vaultAddress, pushed.Encoding(), encoding)
+}
+
 func (s *Service) CalculateTotalEarned(subsidy subgraph.AccountSubsidy, endTimestamp int64) (*big.Int, error) {
 	secondsAccumulated, ok := new(big.Int).SetString(subsidy.SecondsAccumulated, 10)
 	if !ok {
@@ -274,7 +472,17 @@ func (s *Service) processAccountSubsidies(subsidies []subgraph.AccountSubsidy, e
 	return entries, nil
 }
 
+// GenerateProof builds the tree of entries with the default leaf encoding and returns the proof of the target leaf
 func (s *Service) GenerateProof(entries []merkle.Entry, targetAddress string, targetAmount *big.Int) ([][32]byte, [32]byte, error) {
+	return s.generateProof(entries, targetAddress, targetAmount, s.leafEncoding)
+}
+
+func (s *Service) generateProof(
+	entries []merkle.Entry,
+	targetAddress string,
+	targetAmount *big.Int,
+	encoding merkle.LeafEncoding,
+) ([][32]byte, [32]byte, error) {
 	if len(entries) == 0 {
 		return nil, [32]byte{}, nil
 	}
@@ -298,20 +506,22 @@ func (s *Service) GenerateProof(entries []merkle.Entry, targetAddress string, ta
 		return nil, [32]byte{}, nil
 	}
 
-	// Generate leaf hashes
-	leafHashes := make([][32]byte, len(sortedEntries))
-	for i, entry := range sortedEntries {
-		leafHashes[i] = s.CreateLeafHash(entry.Address, entry.TotalEarned)
-	}
-
-	// Generate proof and root
-	proof := s.generateMerkleProof(leafHashes, targetIndex)
-	root := s.buildMerkleRoot(leafHashes)
+	// Generate proof and root from the same tree
+	levels := buildTree(sortedEntries, encoding, s.workers)
+	proof := proofFromLevels(levels, targetIndex)
+	root := levels[len(levels)-1][0]
 
 	return proof, root, nil
 }
 
+// BuildMerkleRootFromEntries builds the root of entries with the default leaf encoding
 func (s *Service) BuildMerkleRootFromEntries(entries []merkle.Entry) [32]byte {
+	return s.BuildMerkleRootWithEncoding(entries, s.leafEncoding)
+}
+
+// BuildMerkleRootWithEncoding hashes leaves with encoding and levels with a worker pool sized by GOMAXPROCS.
+// The root only depends on the entries and encoding, not on the order of entries or the number of workers.
+func (s *Service) BuildMerkleRootWithEncoding(entries []merkle.Entry, encoding merkle.LeafEncoding) [32]byte {
 	if len(entries) == 0 {
 		return [32]byte{}
 	}
@@ -321,129 +531,17 @@ func (s *Service) BuildMerkleRootFromEntries(entries []merkle.Entry) [32]byte {
 	copy(sortedEntries, entries)
 	s.sortEntries(sortedEntries)
 
-	// Generate leaf hashes
-	leafHashes := make([][32]byte, len(sortedEntries))
-	for i, entry := range sortedEntries {
-		leafHashes[i] = s.CreateLeafHash(entry.Address, entry.TotalEarned)
-	}
-
-	return s.buildMerkleRoot(leafHashes)
+	return buildRoot(sortedEntries, encoding, s.workers)
 }
 
 func (s *Service) sortEntries(entries []merkle.Entry) {
-	for i := 1; i < len(entries); i++ {
-		key := entries[i]
-		j := i - 1
-		// Normalize addresses to lowercase for consistent comparison
-		keyAddr := utils.NormalizeAddress(key.Address)
-		for j >= 0 && utils.NormalizeAddress(entries[j].Address) > keyAddr {
-			entries[j+1] = entries[j]
-			j--
-		}
-		entries[j+1] = key
-	}
+	// Normalize addresses to lowercase for consistent comparison
+	sortEntriesByAddress(entries)
 }
 
+// CreateLeafHash hashes a leaf with the default leaf encoding
 func (s *Service) CreateLeafHash(address string, amount *big.Int) [32]byte {
-	// Convert address string to common.Address (normalize case first)
-	addr := common.HexToAddress(address)
-
-	// Create packed encoding: address (20 bytes) + amount (32 bytes)
-	packed := make([]byte, 0, 52)
-	packed = append(packed, addr.Bytes()...)
-
-	// Convert amount to 32-byte representation (big-endian)
-	amountBytes := make([]byte, 32)
-	amount.FillBytes(amountBytes)
-	packed = append(packed, amountBytes...)
-
-	// Hash using keccak256
-	return crypto.Keccak256Hash(packed)
-}
-
-func (s *Service) buildMerkleRoot(leaves [][32]byte) [32]byte {
-	if len(leaves) == 0 {
-		return [32]byte{}
-	}
-	if len(leaves) == 1 {
-		return leaves[0]
-	}
-
-	currentLevel := leaves
-	for len(currentLevel) > 1 {
-		var nextLevel [][32]byte
-		for i := 0; i < len(currentLevel); i += 2 {
-			if i+1 < len(currentLevel) {
-				// Sort pair to match OpenZeppelin's ordering
-				left, right := currentLevel[i], currentLevel[i+1]
-				if !s.IsLeftSmaller(left, right) {
-					left, right = right, left
-				}
-				// Hash the sorted pair using keccak256
-				combined := append(left[:], right[:]...)
-				nextLevel = append(nextLevel, crypto.Keccak256Hash(combined))
-			} else {
-				// Odd number of nodes, promote the last one
-				nextLevel = append(nextLevel, currentLevel[i])
-			}
-		}
-		currentLevel = nextLevel
-	}
-
-	return currentLevel[0]
-}
-
-func (s *Service) generateMerkleProof(leaves [][32]byte, leafIndex int) [][32]byte {
-	if len(leaves) == 0 || leafIndex < 0 || leafIndex >= len(leaves) {
-		return nil
-	}
-
-	var proof [][32]byte
-	currentLevel := leaves
-	currentIndex := leafIndex
-
-	for len(currentLevel) > 1 {
-		var nextLevel [][32]byte
-		var nextIndex int
-
-		for i := 0; i < len(currentLevel); i += 2 {
-			if i+1 < len(currentLevel) {
-				left, right := currentLevel[i], currentLevel[i+1]
-
-				// Add sibling to proof if this pair contains our target
-				if i == currentIndex || i+1 == currentIndex {
-					if i == currentIndex {
-						// Our node is on the left, add right sibling
-						proof = append(proof, right)
-					} else {
-						// Our node is on the right, add left sibling
-						proof = append(proof, left)
-					}
-					nextIndex = len(nextLevel) // Index in next level
-				}
-
-				// Sort pair to match OpenZeppelin's ordering
-				if !s.IsLeftSmaller(left, right) {
-					left, right = right, left
-				}
-
-				// Hash the sorted pair
-				combined := append(left[:], right[:]...)
-				nextLevel = append(nextLevel, crypto.Keccak256Hash(combined))
-			} else {
-				// Odd number of nodes, promote the last one
-				if i == currentIndex {
-					nextIndex = len(nextLevel)
-				}
-				nextLevel = append(nextLevel, currentLevel[i])
-			}
-		}
-
-		currentLevel = nextLevel
-		currentIndex = nextIndex
-	}
-
-	return proof
+	return newHasher().leaf(s.leafEncoding, address, amount)
 }
 
 func (s *Service) IsLeftSmaller(left, right [32]byte) bool {
@@ -572,7 +670,7 @@ func (s *Service) getLatestProcessedEpochForVault(ctx context.Context, vaultAddr
 	}
 
 	if len(response.MerkleDistributions) == 0 {
-		return nil, fmt.Errorf("no processed epochs found for vault %s", vaultAddress)
+		return nil, fmt.Errorf("%w: no processed epochs found for vault %s", merkle.ErrNotFound, vaultAddress)
 	}
 
 	s.logger.Logf("INFO found merkle distribution for epoch %s with root %s",
@@ -608,7 +706,7 @@ func (s *Service) generateProofFromSnapshot(snapshot *merkle.MerkleSnapshot, use
 	}
 
 	// Generate merkle proof
-	proof, root, err := s.GenerateProof(entries, userEntry.Address, userEntry.TotalEarned)
+	proof, root, err := s.generateProof(entries, userEntry.Address, userEntry.TotalEarned, snapshot.Encoding())
 	if err != nil {
 		return nil, fmt.Errorf("%w: %v", merkle.ErrProofGeneration, err)
 	}
@@ -634,8 +732,55 @@ func (s *Service) generateProofFromSnapshot(snapshot *merkle.MerkleSnapshot, use
 	}, nil
 }
 
+// normalizeRoot lowercases a hex merkle root and strips its 0x prefix, the form snapshots store
+func normalizeRoot(root string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(root, "0x"), "0X"))
+}
+
+func isHex(s string) bool {
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SaveSnapshot saves the epoch's snapshot and precomputes the proof of every leaf, so proof requests read
+// a stored proof instead of rebuilding the tree. Proofs that fail to save are computed on first request.
 func (s *Service) SaveSnapshot(ctx context.Context, epochNumber *big.Int, snapshot merkle.MerkleSnapshot) error {
-	return s.store.SaveSnapshot(ctx, epochNumber, snapshot)
+	if err := s.store.SaveSnapshot(ctx, epochNumber, snapshot); err != nil {
+		return err
+	}
+
+	snapshot.EpochNumber = epochNumber
+	if err := s.indexProofs(ctx, &snapshot); err != nil {
+		s.logger.Logf("WARN failed to index leaf proofs for vault %s, epoch %s: %v", snapshot.VaultID, epochNumber.String(), err)
+	}
+	return nil
+}
+
+// RecordSupersession records an admin replacing the root of the vault's epoch, so proofs against the replaced
+// root report the root that replaced it
+func (s *Service) RecordSupersession(ctx context.Context, supersession merkle.RootSupersession) error {
+	supersession.PreviousRoot = normalizeRoot(supersession.PreviousRoot)
+	supersession.MerkleRoot = normalizeRoot(supersession.MerkleRoot)
+	return s.store.SaveSupersession(ctx, supersession)
+}
+
+// GetSnapshot returns the snapshot distributed for an epoch, wrapping merkle.ErrNotFound when there is none
+func (s *Service) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return s.store.GetSnapshot(ctx, epochNumber, vaultID)
+}
+
+// GetLatestSnapshot returns the snapshot of the vault's latest distributed epoch, wrapping merkle.ErrNotFound when there is none
+func (s *Service) GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error) {
+	return s.store.GetLatestSnapshot(ctx, vaultID)
+}
+
+// ListSnapshots returns up to limit of the vault's snapshots, latest epoch first, every snapshot when limit is 0
+func (s *Service) ListSnapshots(ctx context.Context, vaultID string, limit int) ([]merkle.MerkleSnapshot, error) {
+	return s.store.ListSnapshots(ctx, vaultID, limit)
 }
 
 func (s *Service) getAccountSubsidiesForVault(ctx context.Context, vaultAddress string) ([]subgraph.AccountSubsidy, error) {
@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"testing"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/dgraph-io/badger/v4"
@@ -233,15 +234,16 @@ func createTestServiceForSolidity(t *testing.T) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil // Disable badger logging for tests
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		t.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &solidityTestSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
 
 // createTestServiceForSolidityBenchmark creates a service instance for solidity benchmark testing
@@ -252,15 +254,16 @@ func createTestServiceForSolidityBenchmark(b *testing.B) *Service {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = nil // Disable badger logging for tests
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	if err != nil {
 		b.Fatalf("Failed to open test database: %v", err)
 	}
+	db := storage.Badger(badgerDB)
 
 	// Create mock subgraph client
 	mockClient := &solidityTestSubgraphClient{}
 
-	return New(db, mockClient, logger)
+	return New(db, mockClient, nil, logger)
 }
 
 // solidityTestSubgraphClient implements SubgraphClient for solidity testing
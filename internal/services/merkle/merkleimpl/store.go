@@ -1,62 +1,96 @@
 package merkleimpl
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
+	"iter"
 	"math/big"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-pkgz/lgr"
 )
 
+// latestPrefix keys the pointer to each vault's latest snapshot epoch
+const latestPrefix = "merkle:latest:vault:"
+
 // Store handles storage operations for merkle service
 type Store struct {
-	db     *badger.DB
+	db     storage.DB
 	logger lgr.L
 }
 
 // NewStore creates a new store instance
-func NewStore(db *badger.DB, logger lgr.L) *Store {
+func NewStore(db storage.DB, logger lgr.L) *Store {
 	return &Store{
 		db:     db,
 		logger: logger,
 	}
 }
 
-// SaveSnapshot saves a merkle snapshot for an epoch
+// SaveSnapshot saves a merkle snapshot for an epoch.
+// Every root is also kept as an immutable tree version, so proofs stay available after the
+// epoch's snapshot is replaced by a resubmitted root.
 func (s *Store) SaveSnapshot(ctx context.Context, epochNumber *big.Int, snapshot merkle.MerkleSnapshot) error {
 	snapshot.EpochNumber = epochNumber
 	snapshot.CreatedAt = time.Now()
 
 	key := s.buildSnapshotKey(epochNumber, snapshot.VaultID)
+	versionKey := s.buildVersionKey(epochNumber, snapshot.VaultID, snapshot.MerkleRoot)
+	rootKey := s.buildRootKey(epochNumber, snapshot.VaultID)
 	data, err := json.Marshal(snapshot)
 	if err != nil {
 		return fmt.Errorf("failed to marshal snapshot: %w", err)
 	}
 
-	err = s.db.Update(func(txn *badger.Txn) error {
-		return txn.Set([]byte(key), data)
+	err = s.db.Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte(key), data); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte(rootKey), []byte(normalizeRoot(snapshot.MerkleRoot))); err != nil {
+			return err
+		}
+		return txn.Set([]byte(versionKey), data)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to save snapshot: %w", err)
 	}
 
-	// Update latest snapshot pointer
+	// Update latest snapshot pointer, never moving it back to an older epoch
 	latestKey := s.buildLatestKey(snapshot.VaultID)
-	err = s.db.Update(func(txn *badger.Txn) error {
+	err = s.db.Update(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(latestKey))
+		if err == nil {
+			var current *big.Int
+			if err := item.Value(func(val []byte) error {
+				current, _ = new(big.Int).SetString(string(val), 10)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if current != nil && current.Cmp(epochNumber) > 0 {
+				return nil
+			}
+		} else if err != badger.ErrKeyNotFound {
+			return err
+		}
 		return txn.Set([]byte(latestKey), []byte(epochNumber.String()))
 	})
 	if err != nil {
 		s.logger.Logf("WARN failed to update latest snapshot pointer: %v", err)
 	}
 
-	s.logger.Logf("INFO saved merkle snapshot for vault %s, epoch %s with %d entries",
-		snapshot.VaultID, epochNumber.String(), len(snapshot.Entries))
+	s.logger.Logf("INFO saved merkle snapshot for vault %s, epoch %s with %d entries, root %s",
+		snapshot.VaultID, epochNumber.String(), len(snapshot.Entries), snapshot.MerkleRoot)
 	return nil
 }
 
@@ -65,7 +99,7 @@ func (s *Store) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID s
 	key := s.buildSnapshotKey(epochNumber, vaultID)
 
 	var snapshot merkle.MerkleSnapshot
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
@@ -78,7 +112,7 @@ func (s *Store) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID s
 
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("snapshot not found for vault %s, epoch %s", vaultID, epochNumber.String())
+			return nil, fmt.Errorf("%w: snapshot not found for vault %s, epoch %s", merkle.ErrNotFound, vaultID, epochNumber.String())
 		}
 		return nil, fmt.Errorf("failed to get snapshot: %w", err)
 	}
@@ -88,10 +122,20 @@ func (s *Store) GetSnapshot(ctx context.Context, epochNumber *big.Int, vaultID s
 
 // GetLatestSnapshot retrieves the latest merkle snapshot for a vault
 func (s *Store) GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error) {
+	latestEpoch, err := s.GetLatestEpoch(ctx, vaultID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.GetSnapshot(ctx, latestEpoch, vaultID)
+}
+
+// GetLatestEpoch returns the epoch of the vault's latest snapshot
+func (s *Store) GetLatestEpoch(ctx context.Context, vaultID string) (*big.Int, error) {
 	latestKey := s.buildLatestKey(vaultID)
 
 	var latestEpochStr string
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		item, err := txn.Get([]byte(latestKey))
 		if err != nil {
 			return err
@@ -105,7 +149,7 @@ func (s *Store) GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.
 
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("no snapshots found for vault %s", vaultID)
+			return nil, fmt.Errorf("%w: no snapshots found for vault %s", merkle.ErrNotFound, vaultID)
 		}
 		return nil, fmt.Errorf("failed to get latest snapshot pointer: %w", err)
 	}
@@ -115,7 +159,60 @@ func (s *Store) GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.
 		return nil, fmt.Errorf("invalid latest epoch number: %s", latestEpochStr)
 	}
 
-	return s.GetSnapshot(ctx, latestEpoch, vaultID)
+	return latestEpoch, nil
+}
+
+// GetSnapshotRoot returns the merkle root of the epoch's current snapshot without reading its entries.
+// Snapshots saved before roots were recorded on their own are read in full.
+func (s *Store) GetSnapshotRoot(ctx context.Context, epochNumber *big.Int, vaultID string) (string, error) {
+	var root string
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildRootKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			root = string(val)
+			return nil
+		})
+	})
+
+	if err == badger.ErrKeyNotFound {
+		snapshot, err := s.GetSnapshot(ctx, epochNumber, vaultID)
+		if err != nil {
+			return "", err
+		}
+		return normalizeRoot(snapshot.MerkleRoot), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get snapshot root: %w", err)
+	}
+
+	return root, nil
+}
+
+// ListVaults returns the vaults that have a snapshot, in key order
+func (s *Store) ListVaults(ctx context.Context) ([]string, error) {
+	prefix := []byte(latestPrefix)
+	var vaults []string
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = prefix
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			vaults = append(vaults, strings.TrimPrefix(string(it.Item().Key()), latestPrefix))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vaults: %w", err)
+	}
+
+	return vaults, nil
 }
 
 // ListSnapshots retrieves multiple snapshots for a vault
@@ -123,7 +220,7 @@ func (s *Store) ListSnapshots(ctx context.Context, vaultID string, limit int) ([
 	prefix := s.buildVaultPrefix(vaultID)
 	var snapshots []merkle.MerkleSnapshot
 
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Reverse = true // Get latest first
 
@@ -170,18 +267,554 @@ func (s *Store) ListSnapshots(ctx context.Context, vaultID string, limit int) ([
 	return snapshots, nil
 }
 
+// GetSnapshotVersion retrieves the tree an epoch was built with for a specific merkle root
+func (s *Store) GetSnapshotVersion(ctx context.Context, epochNumber *big.Int, vaultID, merkleRoot string) (*merkle.MerkleSnapshot, error) {
+	key := s.buildVersionKey(epochNumber, vaultID, merkleRoot)
+
+	var snapshot merkle.MerkleSnapshot
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(key))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &snapshot)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, fmt.Errorf("%w: no tree with root %s for vault %s, epoch %s",
+				merkle.ErrNotFound, merkleRoot, vaultID, epochNumber.String())
+		}
+		return nil, fmt.Errorf("failed to get snapshot version: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ListSnapshotVersions retrieves every tree version saved for an epoch, oldest first
+func (s *Store) ListSnapshotVersions(ctx context.Context, epochNumber *big.Int, vaultID string) ([]merkle.MerkleSnapshot, error) {
+	var versions []merkle.MerkleSnapshot
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildVersionPrefix(epochNumber, vaultID))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var snapshot merkle.MerkleSnapshot
+				if err := json.Unmarshal(val, &snapshot); err != nil {
+					s.logger.Logf("WARN failed to unmarshal snapshot version: %v", err)
+					return nil
+				}
+				versions = append(versions, snapshot)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshot versions: %w", err)
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].CreatedAt.Before(versions[j].CreatedAt)
+	})
+	return versions, nil
+}
+
+// SaveProofs stores the proof of every leaf of a tree as it is yielded, then marks the tree indexed and
+// returns the number of proofs stored. Trees can be too large for one transaction, so proofs are written in
+// batches and a tree without the mark is indexed again.
+func (s *Store) SaveProofs(ctx context.Context, epochNumber *big.Int, vaultID, merkleRoot string, proofs iter.Seq[leafProof]) (int, error) {
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+
+	count := 0
+	for proof := range proofs {
+		data, err := json.Marshal(proof)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal leaf proof: %w", err)
+		}
+		if err := batch.Set([]byte(s.buildProofKey(epochNumber, vaultID, merkleRoot, proof.Address)), data); err != nil {
+			return 0, fmt.Errorf("failed to save leaf proofs: %w", err)
+		}
+		count++
+	}
+	if err := batch.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to save leaf proofs: %w", err)
+	}
+
+	err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildProofsIndexedKey(epochNumber, vaultID, merkleRoot)), []byte(strconv.Itoa(count)))
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark leaf proofs saved: %w", err)
+	}
+
+	return count, nil
+}
+
+// GetProof returns the stored proof of an account's leaf. It wraps merkle.ErrNotFound when the tree is
+// indexed but has no leaf for the account, and returns errProofsNotIndexed when the tree is not indexed.
+func (s *Store) GetProof(ctx context.Context, epochNumber *big.Int, vaultID, merkleRoot, account string) (*leafProof, error) {
+	var proof leafProof
+	err := s.db.View(func(txn storage.Txn) error {
+		if _, err := txn.Get([]byte(s.buildProofsIndexedKey(epochNumber, vaultID, merkleRoot))); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return errProofsNotIndexed
+			}
+			return err
+		}
+
+		item, err := txn.Get([]byte(s.buildProofKey(epochNumber, vaultID, merkleRoot, account)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &proof)
+		})
+	})
+
+	if err != nil {
+		if err == errProofsNotIndexed {
+			return nil, err
+		}
+		if err == badger.ErrKeyNotFound {
+			return nil, fmt.Errorf("%w: user not found in snapshot", merkle.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get leaf proof: %w", err)
+	}
+
+	return &proof, nil
+}
+
+// deltaTreeMeta describes a vault's persisted delta tree. It is written after the tree's nodes and
+// removed before they are changed, so a tree without it is incomplete and rebuilt in full.
+type deltaTreeMeta struct {
+	Version      uint64              `json:"version"`
+	LeafEncoding merkle.LeafEncoding `json:"leafEncoding,omitempty"` // empty for trees persisted before encodings were recorded, which are packed
+	Leaves       int                 `json:"leaves"`
+	Root         string              `json:"root"`
+}
+
+// deltaLeaf is a persisted leaf of a vault's delta tree
+type deltaLeaf struct {
+	Address     string `json:"address"`
+	TotalEarned string `json:"totalEarned"`
+	Version     uint64 `json:"version"` // tree version the leaf last changed in
+}
+
+// SaveDeltaTree persists the nodes of tree that were hashed when it was built from prev, and removes the
+// nodes prev had past the end of tree's levels. A tree built without prev replaces everything stored.
+func (s *Store) SaveDeltaTree(ctx context.Context, vaultID string, prev, tree *deltaTree, hashed [][]bool) error {
+	metaKey := []byte(s.buildDeltaMetaKey(vaultID))
+	if prev == nil {
+		if err := s.db.DropPrefix([]byte(s.buildDeltaPrefix(vaultID))); err != nil {
+			return fmt.Errorf("failed to clear delta tree: %w", err)
+		}
+	} else if err := s.db.Update(func(txn storage.Txn) error { return txn.Delete(metaKey) }); err != nil {
+		return fmt.Errorf("failed to clear delta tree meta: %w", err)
+	}
+
+	batch := s.db.NewWriteBatch()
+	defer batch.Cancel()
+
+	for l, level := range tree.levels {
+		for i, dirty := range hashed[l] {
+			if !dirty {
+				continue
+			}
+			if err := batch.Set([]byte(s.buildDeltaNodeKey(vaultID, l, i)), level[i][:]); err != nil {
+				return fmt.Errorf("failed to save delta tree node: %w", err)
+			}
+			if l > 0 {
+				continue
+			}
+			data, err := json.Marshal(deltaLeaf{
+				Address:     tree.entries[i].Address,
+				TotalEarned: tree.entries[i].TotalEarned.String(),
+				Version:     tree.versions[i],
+			})
+			if err != nil {
+				return fmt.Errorf("failed to marshal delta tree leaf: %w", err)
+			}
+			if err := batch.Set([]byte(s.buildDeltaLeafKey(vaultID, i)), data); err != nil {
+				return fmt.Errorf("failed to save delta tree leaf: %w", err)
+			}
+		}
+	}
+	if prev != nil {
+		for l, level := range prev.levels {
+			from := 0
+			if l < len(tree.levels) {
+				from = len(tree.levels[l])
+			}
+			for i := from; i < len(level); i++ {
+				if err := batch.Delete([]byte(s.buildDeltaNodeKey(vaultID, l, i))); err != nil {
+					return fmt.Errorf("failed to delete delta tree node: %w", err)
+				}
+				if l == 0 {
+					if err := batch.Delete([]byte(s.buildDeltaLeafKey(vaultID, i))); err != nil {
+						return fmt.Errorf("failed to delete delta tree leaf: %w", err)
+					}
+				}
+			}
+		}
+	}
+	if err := batch.Flush(); err != nil {
+		return fmt.Errorf("failed to save delta tree: %w", err)
+	}
+
+	root := tree.root()
+	data, err := json.Marshal(deltaTreeMeta{
+		Version:      tree.version,
+		LeafEncoding: tree.encoding,
+		Leaves:       len(tree.entries),
+		Root:         common.Bytes2Hex(root[:]),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal delta tree meta: %w", err)
+	}
+	if err := s.db.Update(func(txn storage.Txn) error { return txn.Set(metaKey, data) }); err != nil {
+		return fmt.Errorf("failed to save delta tree meta: %w", err)
+	}
+	return nil
+}
+
+// GetDeltaTree loads the vault's persisted delta tree, returning nil without an error when there is
+// no complete one
+func (s *Store) GetDeltaTree(ctx context.Context, vaultID string) (*deltaTree, error) {
+	var tree *deltaTree
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildDeltaMetaKey(vaultID)))
+		if err == badger.ErrKeyNotFound {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var meta deltaTreeMeta
+		if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &meta) }); err != nil {
+			return err
+		}
+		if meta.Leaves == 0 {
+			return fmt.Errorf("delta tree version %d has no leaves", meta.Version)
+		}
+
+		loaded := &deltaTree{
+			version:  meta.Version,
+			encoding: cmp.Or(meta.LeafEncoding, merkle.LeafEncodingPacked),
+			entries:  make([]merkle.Entry, 0, meta.Leaves),
+			versions: make([]uint64, 0, meta.Leaves),
+		}
+		err = iteratePrefix(txn, s.buildDeltaLeafPrefix(vaultID), func(val []byte) error {
+			var leaf deltaLeaf
+			if err := json.Unmarshal(val, &leaf); err != nil {
+				return err
+			}
+			amount, ok := new(big.Int).SetString(leaf.TotalEarned, 10)
+			if !ok {
+				return fmt.Errorf("invalid delta tree leaf amount %q", leaf.TotalEarned)
+			}
+			loaded.entries = append(loaded.entries, merkle.Entry{Address: leaf.Address, TotalEarned: amount})
+			loaded.versions = append(loaded.versions, leaf.Version)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(loaded.entries) != meta.Leaves {
+			return fmt.Errorf("delta tree version %d has %d of %d leaves", meta.Version, len(loaded.entries), meta.Leaves)
+		}
+
+		loaded.levels = allocLevels(meta.Leaves)
+		for l, level := range loaded.levels {
+			count := 0
+			err := iteratePrefix(txn, s.buildDeltaLevelPrefix(vaultID, l), func(val []byte) error {
+				if len(val) != len(level[0]) {
+					return fmt.Errorf("invalid delta tree node of %d bytes", len(val))
+				}
+				if count < len(level) {
+					copy(level[count][:], val)
+				}
+				count++
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if count != len(level) {
+				return fmt.Errorf("delta tree version %d has %d of %d nodes on level %d", meta.Version, count, len(level), l)
+			}
+		}
+
+		root := loaded.root()
+		if common.Bytes2Hex(root[:]) != meta.Root {
+			return fmt.Errorf("%w: delta tree version %d builds root %x, not %s", merkle.ErrRootMismatch, meta.Version, root, meta.Root)
+		}
+		tree = loaded
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load delta tree: %w", err)
+	}
+
+	return tree, nil
+}
+
+// SaveRootUpdates stores root updates read from the chain and records the vault's root history as synced
+// through syncedTo, in one transaction
+func (s *Store) SaveRootUpdates(ctx context.Context, vaultID string, updates []merkle.RootUpdate, syncedTo uint64) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		for _, update := range updates {
+			data, err := json.Marshal(update)
+			if err != nil {
+				return fmt.Errorf("failed to marshal root update: %w", err)
+			}
+			if err := txn.Set([]byte(s.buildRootUpdateKey(vaultID, update.BlockNumber, update.LogIndex)), data); err != nil {
+				return err
+			}
+		}
+		return txn.Set([]byte(s.buildRootsSyncedKey(vaultID)), []byte(strconv.FormatUint(syncedTo, 10)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save root updates: %w", err)
+	}
+	return nil
+}
+
+// GetRootsSyncedBlock returns the block the vault's root history is synced through, false when it was never synced
+func (s *Store) GetRootsSyncedBlock(ctx context.Context, vaultID string) (uint64, bool, error) {
+	var synced uint64
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildRootsSyncedKey(vaultID)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			synced, err = strconv.ParseUint(string(val), 10, 64)
+			return err
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get roots synced block: %w", err)
+	}
+	return synced, true, nil
+}
+
+// ListRootUpdates returns the vault's stored root updates in chain order
+func (s *Store) ListRootUpdates(ctx context.Context, vaultID string) ([]merkle.RootUpdate, error) {
+	var updates []merkle.RootUpdate
+	err := s.db.View(func(txn storage.Txn) error {
+		return iteratePrefix(txn, s.buildRootUpdatePrefix(vaultID), func(val []byte) error {
+			var update merkle.RootUpdate
+			if err := json.Unmarshal(val, &update); err != nil {
+				return fmt.Errorf("failed to unmarshal root update: %w", err)
+			}
+			updates = append(updates, update)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list root updates: %w", err)
+	}
+	return updates, nil
+}
+
+// SaveSupersession records that the root of the supersession's epoch was replaced, keyed by the replaced root
+func (s *Store) SaveSupersession(ctx context.Context, supersession merkle.RootSupersession) error {
+	data, err := json.Marshal(supersession)
+	if err != nil {
+		return fmt.Errorf("failed to marshal root supersession: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildSupersessionKey(supersession.VaultAddress, supersession.PreviousRoot)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save root supersession: %w", err)
+	}
+	return nil
+}
+
+// GetSupersession returns the replacement of the vault's root, nil when it was never replaced
+func (s *Store) GetSupersession(ctx context.Context, vaultID, merkleRoot string) (*merkle.RootSupersession, error) {
+	var supersession merkle.RootSupersession
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildSupersessionKey(vaultID, merkleRoot)))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &supersession)
+		})
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get root supersession: %w", err)
+	}
+	return &supersession, nil
+}
+
+// ListSupersessions returns every replacement of the vault's roots, by replaced root
+func (s *Store) ListSupersessions(ctx context.Context, vaultID string) (map[string]merkle.RootSupersession, error) {
+	supersessions := make(map[string]merkle.RootSupersession)
+	err := s.db.View(func(txn storage.Txn) error {
+		return iteratePrefix(txn, s.buildSupersessionPrefix(vaultID), func(val []byte) error {
+			var supersession merkle.RootSupersession
+			if err := json.Unmarshal(val, &supersession); err != nil {
+				return fmt.Errorf("failed to unmarshal root supersession: %w", err)
+			}
+			supersessions[normalizeRoot(supersession.PreviousRoot)] = supersession
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list root supersessions: %w", err)
+	}
+	return supersessions, nil
+}
+
+// RootEpochs returns the epoch of every tree version stored for the vault, by root. A root built for several
+// epochs maps to the latest of them.
+func (s *Store) RootEpochs(ctx context.Context, vaultID string) (map[string]string, error) {
+	prefix := s.buildTreePrefix(vaultID)
+	epochs := make(map[string]string)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		opts.Prefix = []byte(prefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			epoch, root, ok := strings.Cut(strings.TrimPrefix(string(it.Item().Key()), prefix), ":root:")
+			if !ok {
+				continue
+			}
+			if epoch = strings.TrimLeft(epoch, "0"); epoch == "" {
+				epoch = "0"
+			}
+			epochs[root] = epoch
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tree roots: %w", err)
+	}
+	return epochs, nil
+}
+
+// iteratePrefix calls fn with the value of every key under prefix, in key order
+func iteratePrefix(txn storage.Txn, prefix string, fn func(val []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	it := txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		if err := it.Item().Value(fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Key building functions
 func (s *Store) buildSnapshotKey(epochNumber *big.Int, vaultID string) string {
 	normalizedVaultID := utils.NormalizeAddress(vaultID)
 	return fmt.Sprintf("merkle:snapshot:vault:%s:epoch:%020s", normalizedVaultID, epochNumber.String())
 }
 
+func (s *Store) buildVersionKey(epochNumber *big.Int, vaultID, merkleRoot string) string {
+	return s.buildVersionPrefix(epochNumber, vaultID) + normalizeRoot(merkleRoot)
+}
+
+func (s *Store) buildVersionPrefix(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("%s%020s:root:", s.buildTreePrefix(vaultID), epochNumber.String())
+}
+
+func (s *Store) buildTreePrefix(vaultID string) string {
+	return fmt.Sprintf("merkle:tree:vault:%s:epoch:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildRootKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("merkle:root:vault:%s:epoch:%020s", utils.NormalizeAddress(vaultID), epochNumber.String())
+}
+
+func (s *Store) buildProofKey(epochNumber *big.Int, vaultID, merkleRoot, account string) string {
+	return fmt.Sprintf("merkle:proof:vault:%s:epoch:%020s:root:%s:account:%s", utils.NormalizeAddress(vaultID),
+		epochNumber.String(), normalizeRoot(merkleRoot), utils.NormalizeAddress(account))
+}
+
+func (s *Store) buildProofsIndexedKey(epochNumber *big.Int, vaultID, merkleRoot string) string {
+	return fmt.Sprintf("merkle:proofs:vault:%s:epoch:%020s:root:%s", utils.NormalizeAddress(vaultID),
+		epochNumber.String(), normalizeRoot(merkleRoot))
+}
+
+func (s *Store) buildRootUpdatePrefix(vaultID string) string {
+	return fmt.Sprintf("merkle:roots:vault:%s:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildRootUpdateKey(vaultID string, blockNumber uint64, logIndex uint) string {
+	return fmt.Sprintf("%sblock:%020d:log:%06d", s.buildRootUpdatePrefix(vaultID), blockNumber, logIndex)
+}
+
+func (s *Store) buildSupersessionPrefix(vaultID string) string {
+	return fmt.Sprintf("merkle:supersession:vault:%s:root:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildSupersessionKey(vaultID, merkleRoot string) string {
+	return s.buildSupersessionPrefix(vaultID) + normalizeRoot(merkleRoot)
+}
+
+func (s *Store) buildRootsSyncedKey(vaultID string) string {
+	return fmt.Sprintf("merkle:roots-synced:vault:%s", utils.NormalizeAddress(vaultID))
+}
+
 func (s *Store) buildLatestKey(vaultID string) string {
-	normalizedVaultID := utils.NormalizeAddress(vaultID)
-	return fmt.Sprintf("merkle:latest:vault:%s", normalizedVaultID)
+	return latestPrefix + utils.NormalizeAddress(vaultID)
 }
 
 func (s *Store) buildVaultPrefix(vaultID string) string {
 	normalizedVaultID := utils.NormalizeAddress(vaultID)
 	return fmt.Sprintf("merkle:snapshot:vault:%s:", normalizedVaultID)
 }
+
+func (s *Store) buildDeltaPrefix(vaultID string) string {
+	return fmt.Sprintf("merkle:delta:vault:%s:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildDeltaMetaKey(vaultID string) string {
+	return s.buildDeltaPrefix(vaultID) + "meta"
+}
+
+func (s *Store) buildDeltaLeafPrefix(vaultID string) string {
+	return s.buildDeltaPrefix(vaultID) + "leaf:"
+}
+
+func (s *Store) buildDeltaLeafKey(vaultID string, index int) string {
+	return fmt.Sprintf("%s%012d", s.buildDeltaLeafPrefix(vaultID), index)
+}
+
+func (s *Store) buildDeltaLevelPrefix(vaultID string, level int) string {
+	return fmt.Sprintf("%snode:%02d:", s.buildDeltaPrefix(vaultID), level)
+}
+
+func (s *Store) buildDeltaNodeKey(vaultID string, level, index int) string {
+	return fmt.Sprintf("%s%012d", s.buildDeltaLevelPrefix(vaultID, level), index)
+}
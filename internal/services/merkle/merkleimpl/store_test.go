@@ -3,12 +3,15 @@ package merkleimpl
 import (
 	"context"
 	"math/big"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-pkgz/lgr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,17 +24,18 @@ func TestMerkleStore(t *testing.T) {
 	// Create badger database
 	opts := badger.DefaultOptions(tempDir)
 	opts.Logger = &testLogger{logger}
-	db, err := badger.Open(opts)
+	badgerDB, err := badger.Open(opts)
 	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
 	defer func() {
-		assert.NoError(t, db.Close())
+		assert.NoError(t, badgerDB.Close())
 	}()
 
 	// Create mock subgraph client
 	mockClient := &mockSubgraphClient{}
 
 	// Create unified service
-	service := New(db, mockClient, logger)
+	service := New(db, mockClient, nil, logger)
 
 	epochNumber := big.NewInt(16)
 	vaultID := "0xf82b93f3d6a703b8b5949809771b1e725708590a"
@@ -186,3 +190,85 @@ func (m *mockSubgraphClient) QueryAccountSubsidiesForVault(ctx context.Context,
 func (m *mockSubgraphClient) ExecuteQuery(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
 	return nil
 }
+
+func TestMerkleStore_SnapshotVersions(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	service := New(db, &mockSubgraphClient{}, nil, lgr.NoOp)
+	ctx := context.Background()
+	vaultID := "0xf82b93f3d6a703b8b5949809771b1e725708590a"
+	user := "0x3575b992c5337226aecf4e7f93dfbe80c576ce15"
+
+	newSnapshot := func(amount int64) (merkle.MerkleSnapshot, string) {
+		entries := []merkle.MerkleEntry{
+			{Address: user, TotalEarned: big.NewInt(amount)},
+			{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)},
+		}
+		converted := make([]merkle.Entry, len(entries))
+		for i, entry := range entries {
+			converted[i] = merkle.Entry(entry)
+		}
+		root := service.BuildMerkleRootFromEntries(converted)
+		rootHex := "0x" + common.Bytes2Hex(root[:])
+		return merkle.MerkleSnapshot{
+			Entries:    entries,
+			MerkleRoot: rootHex,
+			Timestamp:  time.Now().Unix(),
+			VaultID:    vaultID,
+		}, rootHex
+	}
+
+	original, originalRoot := newSnapshot(1000)
+	replacement, replacementRoot := newSnapshot(1500)
+	newer, _ := newSnapshot(2000)
+
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), original))
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(4), newer))
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), replacement))
+
+	t.Run("latest_does_not_regress", func(t *testing.T) {
+		latest, err := service.store.GetLatestSnapshot(ctx, vaultID)
+		require.NoError(t, err)
+		assert.Equal(t, "4", latest.EpochNumber.String())
+	})
+
+	t.Run("epoch_returns_current_root", func(t *testing.T) {
+		proof, err := service.GenerateHistoricalMerkleProof(ctx, user, vaultID, "3")
+		require.NoError(t, err)
+		assert.Equal(t, "1500", proof.TotalEarned)
+		assert.Equal(t, replacementRoot[2:], proof.MerkleRoot)
+	})
+
+	t.Run("replaced_root_still_provable", func(t *testing.T) {
+		proof, err := service.GenerateMerkleProofForRoot(ctx, user, vaultID, "3", originalRoot)
+		require.NoError(t, err)
+		assert.Equal(t, "1000", proof.TotalEarned)
+		assert.Equal(t, originalRoot[2:], proof.MerkleRoot)
+	})
+
+	t.Run("versions_listed_in_order", func(t *testing.T) {
+		versions, err := service.store.ListSnapshotVersions(ctx, big.NewInt(3), vaultID)
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+		assert.Equal(t, originalRoot, versions[0].MerkleRoot)
+		assert.Equal(t, replacementRoot, versions[1].MerkleRoot)
+	})
+
+	t.Run("unknown_root", func(t *testing.T) {
+		unknown := "0x" + strings.Repeat("ab", 32)
+		_, err := service.GenerateMerkleProofForRoot(ctx, user, vaultID, "3", unknown)
+		assert.ErrorIs(t, err, merkle.ErrNotFound)
+	})
+
+	t.Run("invalid_root", func(t *testing.T) {
+		_, err := service.GenerateMerkleProofForRoot(ctx, user, vaultID, "3", "0x1234")
+		assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+	})
+}
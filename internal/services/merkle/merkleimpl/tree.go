@@ -0,0 +1,245 @@
+package merkleimpl
+
+import (
+	"bytes"
+	"math/big"
+	"sort"
+	"sync"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// minParallelChunk is the fewest hashes handed to a worker, smaller levels are hashed
+// on the calling goroutine since the goroutine overhead would outweigh the keccak work
+const minParallelChunk = 2048
+
+// parallelFor splits [0, n) into contiguous chunks, runs fn on each with up to workers
+// goroutines and waits for all of them
+func parallelFor(n, workers int, fn func(lo, hi int)) {
+	workers = min(workers, n/minParallelChunk)
+	if workers <= 1 {
+		fn(0, n)
+		return
+	}
+
+	chunk := (n + workers - 1) / workers
+	var wg sync.WaitGroup
+	for lo := 0; lo < n; lo += chunk {
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			fn(lo, hi)
+		}(lo, min(lo+chunk, n))
+	}
+	wg.Wait()
+}
+
+// hasher reuses one keccak state for every hash of a worker
+type hasher struct {
+	state crypto.KeccakState
+}
+
+func newHasher() *hasher {
+	return &hasher{state: crypto.NewKeccakState()}
+}
+
+// leaf hashes the recipient and amount of a leaf with encoding, which must match the vault's DebtSubsidizer
+func (h *hasher) leaf(encoding merkle.LeafEncoding, address string, amount *big.Int) (out [32]byte) {
+	switch encoding {
+	case merkle.LeafEncodingABI, merkle.LeafEncodingDoubleHash:
+		// abi.encode pads the address to a full word
+		var encoded [64]byte
+		copy(encoded[12:32], common.HexToAddress(address).Bytes())
+		amount.FillBytes(encoded[32:])
+
+		h.state.Reset()
+		h.state.Write(encoded[:])
+		h.state.Read(out[:])
+		if encoding == merkle.LeafEncodingDoubleHash {
+			h.state.Reset()
+			h.state.Write(out[:])
+			h.state.Read(out[:])
+		}
+	default:
+		var packed [52]byte
+		copy(packed[:20], common.HexToAddress(address).Bytes())
+		amount.FillBytes(packed[20:])
+
+		h.state.Reset()
+		h.state.Write(packed[:])
+		h.state.Read(out[:])
+	}
+	return out
+}
+
+// pair hashes two nodes in sorted order, matching OpenZeppelin's MerkleProof
+func (h *hasher) pair(left, right [32]byte) (out [32]byte) {
+	if bytes.Compare(left[:], right[:]) > 0 {
+		left, right = right, left
+	}
+
+	h.state.Reset()
+	h.state.Write(left[:])
+	h.state.Write(right[:])
+	h.state.Read(out[:])
+	return out
+}
+
+// allocLevels returns the levels of a tree with n leaves, from the leaves up to the root, sliced from one
+// backing array. A tree of n leaves has fewer than 2n nodes, so it costs a single allocation of under 64n
+// bytes instead of one per level.
+func allocLevels(n int) [][][32]byte {
+	if n == 0 {
+		return nil
+	}
+
+	total, depth := 0, 0
+	for size := n; ; size = (size + 1) / 2 {
+		total += size
+		depth++
+		if size == 1 {
+			break
+		}
+	}
+
+	arena := make([][32]byte, total)
+	levels := make([][][32]byte, 0, depth)
+	for size := n; ; size = (size + 1) / 2 {
+		levels = append(levels, arena[:size:size])
+		arena = arena[size:]
+		if size == 1 {
+			break
+		}
+	}
+	return levels
+}
+
+// hashLeavesInto writes the leaf hash of every entry with encoding to leaves, in entry order
+func hashLeavesInto(leaves [][32]byte, entries []merkle.Entry, encoding merkle.LeafEncoding, workers int) {
+	parallelFor(len(entries), workers, func(lo, hi int) {
+		h := newHasher()
+		for i := lo; i < hi; i++ {
+			leaves[i] = h.leaf(encoding, entries[i].Address, entries[i].TotalEarned)
+		}
+	})
+}
+
+// hashLeaves returns the leaf hash of every entry with encoding, in entry order
+func hashLeaves(entries []merkle.Entry, encoding merkle.LeafEncoding, workers int) [][32]byte {
+	leaves := make([][32]byte, len(entries))
+	hashLeavesInto(leaves, entries, encoding, workers)
+	return leaves
+}
+
+// hashLevel writes the parents of level to next, which holds (len(level)+1)/2 nodes. The last node of an
+// odd level is promoted unchanged. Workers write disjoint slots of next, so it is the same whatever the
+// number of workers.
+func hashLevel(level, next [][32]byte, workers int) {
+	parallelFor(len(level)/2, workers, func(lo, hi int) {
+		h := newHasher()
+		for i := lo; i < hi; i++ {
+			next[i] = h.pair(level[2*i], level[2*i+1])
+		}
+	})
+	if len(level)%2 == 1 {
+		next[len(next)-1] = level[len(level)-1]
+	}
+}
+
+// buildLevels returns every level of the tree, from the leaves up to the single root node, with the levels
+// above the leaves sliced from one allocation
+func buildLevels(leaves [][32]byte, workers int) [][][32]byte {
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	levels := [][][32]byte{leaves}
+	if len(leaves) > 1 {
+		levels = append(levels, allocLevels((len(leaves)+1)/2)...)
+	}
+	for l := 1; l < len(levels); l++ {
+		hashLevel(levels[l-1], levels[l], workers)
+	}
+	return levels
+}
+
+// buildTree hashes sorted entries with encoding and returns every level of their tree, leaves included,
+// from a single allocation
+func buildTree(sorted []merkle.Entry, encoding merkle.LeafEncoding, workers int) [][][32]byte {
+	levels := allocLevels(len(sorted))
+	if len(levels) == 0 {
+		return nil
+	}
+
+	hashLeavesInto(levels[0], sorted, encoding, workers)
+	for l := 1; l < len(levels); l++ {
+		hashLevel(levels[l-1], levels[l], workers)
+	}
+	return levels
+}
+
+// buildRoot returns the root of the tree of sorted entries hashed with encoding without keeping its levels:
+// each level is hashed from the one below into a buffer the level below that was hashed into, so at most
+// the leaves and half as many parents are held at once
+func buildRoot(sorted []merkle.Entry, encoding merkle.LeafEncoding, workers int) [32]byte {
+	if len(sorted) == 0 {
+		return [32]byte{}
+	}
+
+	level := hashLeaves(sorted, encoding, workers)
+	spare := make([][32]byte, (len(level)+1)/2)
+	for len(level) > 1 {
+		next := spare[:(len(level)+1)/2]
+		hashLevel(level, next, workers)
+		spare, level = level, next
+	}
+	return level[0]
+}
+
+// proofFromLevels collects the sibling of the leaf's ancestor on every level below the root,
+// skipping levels where the ancestor was promoted without a sibling
+func proofFromLevels(levels [][][32]byte, leafIndex int) [][32]byte {
+	if len(levels) == 0 || leafIndex < 0 || leafIndex >= len(levels[0]) {
+		return nil
+	}
+
+	var proof [][32]byte
+	index := leafIndex
+	for _, level := range levels[:len(levels)-1] {
+		if sibling := index ^ 1; sibling < len(level) {
+			proof = append(proof, level[sibling])
+		}
+		index /= 2
+	}
+	return proof
+}
+
+// entriesByAddress sorts entries by normalized address, keeping the keys alongside
+// so every address is normalized once instead of on each comparison
+type entriesByAddress struct {
+	entries []merkle.Entry
+	keys    []string
+}
+
+func newEntriesByAddress(entries []merkle.Entry) entriesByAddress {
+	keys := make([]string, len(entries))
+	for i := range entries {
+		keys[i] = utils.NormalizeAddress(entries[i].Address)
+	}
+	return entriesByAddress{entries: entries, keys: keys}
+}
+
+func (e entriesByAddress) Len() int           { return len(e.entries) }
+func (e entriesByAddress) Less(i, j int) bool { return e.keys[i] < e.keys[j] }
+func (e entriesByAddress) Swap(i, j int) {
+	e.entries[i], e.entries[j] = e.entries[j], e.entries[i]
+	e.keys[i], e.keys[j] = e.keys[j], e.keys[i]
+}
+
+// sortEntriesByAddress sorts in place; the sort is stable, so entries sharing an address keep their order
+func sortEntriesByAddress(entries []merkle.Entry) {
+	sort.Stable(newEntriesByAddress(entries))
+}
@@ -0,0 +1,191 @@
+package merkleimpl
+
+import (
+	"fmt"
+	"math/big"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// generateTreeEntries returns n entries in no particular address order
+func generateTreeEntries(n int) []merkle.Entry {
+	entries := make([]merkle.Entry, n)
+	for i := range entries {
+		addr := common.BigToAddress(new(big.Int).Mul(big.NewInt(int64(i+1)), big.NewInt(7919)))
+		entries[n-1-i] = merkle.Entry{Address: addr.Hex(), TotalEarned: big.NewInt(int64(i+1) * 1e15)}
+	}
+	return entries
+}
+
+// referenceRoot is the straightforward single-threaded construction the tree must match
+func referenceRoot(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+	level := leaves
+	for len(level) > 1 {
+		var next [][32]byte
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+			left, right := level[i], level[i+1]
+			if new(big.Int).SetBytes(left[:]).Cmp(new(big.Int).SetBytes(right[:])) > 0 {
+				left, right = right, left
+			}
+			next = append(next, crypto.Keccak256Hash(left[:], right[:]))
+		}
+		level = next
+	}
+	return level[0]
+}
+
+func TestBuildLevels_DeterministicAcrossWorkers(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 3, 7, 2*minParallelChunk + 1, 5*minParallelChunk + 3} {
+		t.Run(fmt.Sprintf("entries_%d", size), func(t *testing.T) {
+			entries := generateTreeEntries(size)
+			sortEntriesByAddress(entries)
+
+			sequential := hashLeaves(entries, merkle.LeafEncodingPacked, 1)
+			for i, entry := range entries {
+				require.Equal(t, simulateSolidityLeafCreation(entry.Address, entry.TotalEarned), sequential[i])
+			}
+			expected := referenceRoot(sequential)
+
+			for _, workers := range []int{1, 2, 8} {
+				leaves := hashLeaves(entries, merkle.LeafEncodingPacked, workers)
+				assert.Equal(t, sequential, leaves, "workers=%d", workers)
+
+				levels := buildLevels(leaves, workers)
+				if size == 0 {
+					assert.Nil(t, levels)
+					continue
+				}
+				assert.Equal(t, expected, levels[len(levels)-1][0], "workers=%d", workers)
+			}
+		})
+	}
+}
+
+func TestProofFromLevels_VerifiesEveryLeaf(t *testing.T) {
+	service := &Service{logger: lgr.NoOp, workers: 4}
+	entries := generateTreeEntries(minParallelChunk + 5)
+	sortEntriesByAddress(entries)
+
+	levels := buildLevels(hashLeaves(entries, merkle.LeafEncodingPacked, service.workers), service.workers)
+	root := levels[len(levels)-1][0]
+	for i, entry := range entries {
+		proof := proofFromLevels(levels, i)
+		require.True(t, service.verifyProof(proof, root, entry.Address, entry.TotalEarned), "leaf %d", i)
+	}
+
+	assert.Nil(t, proofFromLevels(levels, -1))
+	assert.Nil(t, proofFromLevels(levels, len(entries)))
+	assert.Nil(t, proofFromLevels(nil, 0))
+}
+
+func TestSortEntriesByAddress_StableAndCaseInsensitive(t *testing.T) {
+	entries := []merkle.Entry{
+		{Address: "0xBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBBB", TotalEarned: big.NewInt(1)},
+		{Address: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", TotalEarned: big.NewInt(2)},
+		{Address: "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", TotalEarned: big.NewInt(3)},
+	}
+	sortEntriesByAddress(entries)
+
+	var amounts []int64
+	for _, entry := range entries {
+		amounts = append(amounts, entry.TotalEarned.Int64())
+	}
+	assert.Equal(t, []int64{2, 1, 3}, amounts)
+}
+
+// BenchmarkBuildMerkleRoot compares a single worker with a pool sized by GOMAXPROCS
+func BenchmarkBuildMerkleRoot(b *testing.B) {
+	workerCounts := []int{1}
+	if n := runtime.GOMAXPROCS(0); n > 1 {
+		workerCounts = append(workerCounts, n)
+	}
+
+	for _, size := range []int{10_000, 100_000} {
+		entries := generateTreeEntries(size)
+		for _, workers := range workerCounts {
+			service := &Service{logger: lgr.NoOp, workers: workers}
+			b.Run(fmt.Sprintf("entries_%d/workers_%d", size, workers), func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					service.BuildMerkleRootFromEntries(entries)
+				}
+			})
+		}
+	}
+}
+
+func TestHasher_LeafEncodings(t *testing.T) {
+	address := "0x3575B992C5337226AECf4E7f93Dfbe80C576CE15"
+	amount := big.NewInt(1_000_000)
+	addressWord := common.LeftPadBytes(common.HexToAddress(address).Bytes(), 32)
+	amountWord := common.LeftPadBytes(amount.Bytes(), 32)
+
+	abiEncoded := crypto.Keccak256(addressWord, amountWord)
+	want := map[merkle.LeafEncoding][]byte{
+		merkle.LeafEncodingPacked:     crypto.Keccak256(common.HexToAddress(address).Bytes(), amountWord),
+		merkle.LeafEncodingABI:        abiEncoded,
+		merkle.LeafEncodingDoubleHash: crypto.Keccak256(abiEncoded),
+	}
+
+	h := newHasher()
+	roots := make(map[[32]byte]merkle.LeafEncoding)
+	service := New(nil, nil, nil, lgr.NoOp)
+	entries := generateTreeEntries(9)
+	for _, encoding := range merkle.LeafEncodings {
+		leaf := h.leaf(encoding, address, amount)
+		assert.Equal(t, want[encoding], leaf[:], "%s leaf", encoding)
+
+		root := service.BuildMerkleRootWithEncoding(entries, encoding)
+		assert.NotContains(t, roots, root, "%s root", encoding)
+		roots[root] = encoding
+	}
+	assert.Equal(t, service.BuildMerkleRootWithEncoding(entries, merkle.LeafEncodingPacked),
+		service.BuildMerkleRootFromEntries(entries), "trees are packed by default")
+}
+
+func TestHasher_ContractWalletLeaves(t *testing.T) {
+	// a Safe-style contract wallet in the three forms an API caller may give it
+	forms := []string{
+		"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed",
+		"0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED",
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+	}
+	amount := big.NewInt(1_000_000)
+
+	h := newHasher()
+	for _, encoding := range merkle.LeafEncodings {
+		want := h.leaf(encoding, forms[0], amount)
+		for _, form := range forms[1:] {
+			assert.Equal(t, want, h.leaf(encoding, form, amount), "%s leaf of %s", encoding, form)
+		}
+	}
+
+	// the proof of a contract wallet resolves to the root from the leaf of its lowercase form
+	entries := append(generateTreeEntries(6), merkle.Entry{Address: forms[2], TotalEarned: amount})
+	sortEntriesByAddress(entries)
+	index := slices.IndexFunc(entries, func(e merkle.Entry) bool { return e.Address == forms[2] })
+	require.GreaterOrEqual(t, index, 0)
+	service := &Service{logger: lgr.NoOp, workers: 1}
+	for _, encoding := range merkle.LeafEncodings {
+		levels := buildTree(entries, encoding, service.workers)
+		root := levels[len(levels)-1][0]
+		proof := proofFromLevels(levels, index)
+		assert.Equal(t, root, service.processProof(proof, h.leaf(encoding, forms[0], amount)), "%s proof", encoding)
+	}
+}
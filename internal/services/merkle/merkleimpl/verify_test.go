@@ -0,0 +1,197 @@
+package merkleimpl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubContractClient returns a fixed on-chain merkle root, claimed totals by vault and the root updates
+// mined up to head. Addresses in wallets are contract wallets accepting the signature mapped to them.
+type stubContractClient struct {
+	root    [32]byte
+	err     error
+	claimed map[string]*big.Int
+	head    uint64
+	updates []blockchain.MerkleRootUpdate
+	wallets map[string][]byte
+}
+
+func (c *stubContractClient) GetMerkleRoot(ctx context.Context, vaultAddress string) ([32]byte, error) {
+	return c.root, c.err
+}
+
+func (c *stubContractClient) GetUserClaimedTotal(ctx context.Context, vaultAddress, userAddress string) (*big.Int, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if claimed, ok := c.claimed[vaultAddress]; ok {
+		return claimed, nil
+	}
+	return big.NewInt(0), nil
+}
+
+func (c *stubContractClient) GetMerkleRootUpdates(
+	ctx context.Context,
+	vaultAddress string,
+	fromBlock, toBlock uint64,
+) ([]blockchain.MerkleRootUpdate, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	var updates []blockchain.MerkleRootUpdate
+	for _, update := range c.updates {
+		if update.BlockNumber >= fromBlock && update.BlockNumber <= toBlock {
+			updates = append(updates, update)
+		}
+	}
+	return updates, nil
+}
+
+func (c *stubContractClient) GetBlockRef(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &blockchain.BlockRef{Number: c.head}, nil
+}
+
+func (c *stubContractClient) HasCode(ctx context.Context, address string) (bool, error) {
+	_, ok := c.wallets[address]
+	return ok, c.err
+}
+
+func (c *stubContractClient) IsValidSignature(ctx context.Context, account string, hash [32]byte, signature []byte) (bool, error) {
+	return bytes.Equal(c.wallets[account], signature), c.err
+}
+
+func TestVerifyMerkleRoot(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	vaultID := "0xf82b93f3d6a703b8b5949809771b1e725708590a"
+	contractClient := &stubContractClient{}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+
+	entries := []merkle.Entry{
+		{Address: "0x3575b992c5337226aecf4e7f93dfbe80c576ce15", TotalEarned: big.NewInt(1000)},
+		{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)},
+	}
+	root := service.BuildMerkleRootFromEntries(entries)
+
+	t.Run("no_snapshot", func(t *testing.T) {
+		_, err := service.VerifyMerkleRoot(ctx, vaultID)
+		assert.ErrorIs(t, err, merkle.ErrNotFound)
+	})
+
+	snapshot := merkle.MerkleSnapshot{
+		VaultID:    vaultID,
+		MerkleRoot: fmt.Sprintf("%x", root),
+	}
+	for _, entry := range entries {
+		snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+	}
+	require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(3), snapshot))
+
+	t.Run("match", func(t *testing.T) {
+		contractClient.root = root
+
+		result, err := service.VerifyMerkleRoot(ctx, vaultID)
+		require.NoError(t, err)
+		assert.True(t, result.Match)
+		assert.Empty(t, result.Mismatches)
+		assert.Equal(t, "3", result.EpochNumber)
+		assert.Equal(t, 2, result.LeafCount)
+		assert.Equal(t, result.ComputedRoot, result.OnChainRoot)
+		assert.Equal(t, "packed", result.LeafEncoding, "snapshots without an encoding are packed")
+	})
+
+	t.Run("on_chain_mismatch", func(t *testing.T) {
+		contractClient.root = [32]byte{0x01}
+
+		result, err := service.VerifyMerkleRoot(ctx, vaultID)
+		require.NoError(t, err)
+		assert.False(t, result.Match)
+		require.Len(t, result.Mismatches, 1)
+		assert.Contains(t, result.Mismatches[0], "on-chain root")
+	})
+
+	t.Run("contract_error", func(t *testing.T) {
+		contractClient.err = errors.New("rpc unavailable")
+		defer func() { contractClient.err = nil }()
+
+		_, err := service.VerifyMerkleRoot(ctx, vaultID)
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckLeafEncoding(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	vaultID := "0xf82b93f3d6a703b8b5949809771b1e725708590a"
+	contractClient := &stubContractClient{}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+
+	saveTree := func(epoch int64, encoding merkle.LeafEncoding, amount int64) [32]byte {
+		entries := []merkle.Entry{
+			{Address: "0x3575b992c5337226aecf4e7f93dfbe80c576ce15", TotalEarned: big.NewInt(amount)},
+			{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(500)},
+		}
+		root := service.BuildMerkleRootWithEncoding(entries, encoding)
+		snapshot := merkle.MerkleSnapshot{VaultID: vaultID, MerkleRoot: fmt.Sprintf("%x", root), LeafEncoding: encoding}
+		for _, entry := range entries {
+			snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+		}
+		require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(epoch), snapshot))
+		return root
+	}
+
+	require.NoError(t, service.CheckLeafEncoding(ctx, vaultID), "a contract without a root has nothing to check")
+
+	contractClient.root = saveTree(1, merkle.LeafEncodingPacked, 1000)
+	require.NoError(t, service.CheckLeafEncoding(ctx, vaultID))
+
+	// the tree about to be pushed is stored before the push, so the one on-chain is found behind it
+	saveTree(2, merkle.LeafEncodingABI, 2000)
+	service.SetLeafEncodings(merkle.LeafEncodingABI, nil)
+	err = service.CheckLeafEncoding(ctx, vaultID)
+	require.ErrorIs(t, err, merkle.ErrLeafEncodingMismatch)
+	assert.Contains(t, err.Error(), "built with the packed encoding, the vault is configured for abi")
+
+	service.SetLeafEncodings(merkle.LeafEncodingPacked, map[string]merkle.LeafEncoding{vaultID: merkle.LeafEncodingABI})
+	require.ErrorIs(t, service.CheckLeafEncoding(ctx, vaultID), merkle.ErrLeafEncodingMismatch, "vault encodings override the default")
+
+	contractClient.root = saveTree(2, merkle.LeafEncodingABI, 2000)
+	require.NoError(t, service.CheckLeafEncoding(ctx, vaultID))
+
+	contractClient.root = [32]byte{0x01}
+	require.NoError(t, service.CheckLeafEncoding(ctx, vaultID), "a root no stored tree produced is not checked")
+
+	contractClient.err = errors.New("rpc unavailable")
+	require.Error(t, service.CheckLeafEncoding(ctx, vaultID))
+}
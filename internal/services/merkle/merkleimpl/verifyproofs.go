@@ -0,0 +1,177 @@
+package merkleimpl
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/ethereum/go-ethereum/common"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// maxVerifyProofs bounds the proofs one bulk verification may cover
+const maxVerifyProofs = 1000
+
+// vaultRoots are the roots a vault's proofs are checked against, loaded once per verification
+type vaultRoots struct {
+	encoding   merkle.LeafEncoding
+	stored     map[string]string                  // epoch of every stored tree, by root
+	superseded map[string]merkle.RootSupersession // replacements of the vault's roots, by replaced root
+	onChain    string                             // empty unless checked
+}
+
+// VerifyProofs folds every proof from its leaf and looks the resulting root up among the trees stored for its
+// vault. The leaf is hashed with the vault's configured encoding first, then with the others, so proofs from
+// trees built before an encoding change still resolve.
+func (s *Service) VerifyProofs(
+	ctx context.Context,
+	proofs []merkle.ProofToVerify,
+	onChain bool,
+) (_ *merkle.ProofVerifications, err error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.VerifyProofs",
+		attribute.Int("proofs", len(proofs)), attribute.Bool("merkle.on_chain", onChain))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if len(proofs) == 0 {
+		return nil, fmt.Errorf("%w: at least one proof is required", merkle.ErrInvalidInput)
+	}
+	if len(proofs) > maxVerifyProofs {
+		return nil, fmt.Errorf("%w: at most %d proofs can be verified at once, got %d",
+			merkle.ErrInvalidInput, maxVerifyProofs, len(proofs))
+	}
+	if onChain && s.contractClient == nil {
+		return nil, fmt.Errorf("contract client is not configured")
+	}
+
+	result := &merkle.ProofVerifications{Results: make([]merkle.ProofVerification, len(proofs))}
+	roots := make(map[string]*vaultRoots)
+	h := newHasher()
+	for i, proof := range proofs {
+		verification := merkle.ProofVerification{
+			VaultAddress: proof.VaultAddress,
+			Recipient:    proof.Recipient,
+			TotalEarned:  proof.TotalEarned,
+		}
+		reason, err := s.checkProof(ctx, h, proof, onChain, roots, &verification)
+		if err != nil {
+			return nil, err
+		}
+		if reason != "" {
+			verification.Reason = reason
+			result.Invalid++
+		} else {
+			verification.Valid = true
+			result.Valid++
+		}
+		result.Results[i] = verification
+	}
+	result.VerifiedAt = time.Now().Unix()
+
+	span.SetAttributes(attribute.Int("merkle.proofs_invalid", result.Invalid))
+	s.logger.Logf("INFO verified %d proofs, %d invalid", len(proofs), result.Invalid)
+	return result, nil
+}
+
+// checkProof fills in where the proof resolves to, returning why it is invalid or an empty string when it is
+// valid. A vault whose roots cannot be read fails the whole verification rather than its proofs.
+func (s *Service) checkProof(
+	ctx context.Context,
+	h *hasher,
+	proof merkle.ProofToVerify,
+	onChain bool,
+	roots map[string]*vaultRoots,
+	verification *merkle.ProofVerification,
+) (string, error) {
+	if !utils.IsValidAddress(proof.VaultAddress) {
+		return fmt.Sprintf("invalid vault address %q", proof.VaultAddress), nil
+	}
+	if !utils.IsValidAddress(proof.Recipient) {
+		return fmt.Sprintf("invalid recipient address %q", proof.Recipient), nil
+	}
+	earned, ok := new(big.Int).SetString(proof.TotalEarned, 10)
+	if !ok || earned.Sign() < 0 || earned.BitLen() > 256 {
+		return fmt.Sprintf("invalid total earned %q", proof.TotalEarned), nil
+	}
+	nodes := make([][32]byte, len(proof.MerkleProof))
+	for i, node := range proof.MerkleProof {
+		raw, err := hex.DecodeString(strings.TrimPrefix(node, "0x"))
+		if err != nil || len(raw) != 32 {
+			return fmt.Sprintf("invalid proof node %d %q", i, node), nil
+		}
+		nodes[i] = [32]byte(raw)
+	}
+
+	vault := utils.NormalizeAddress(proof.VaultAddress)
+	vr, err := s.loadVaultRoots(ctx, vault, onChain, roots)
+	if err != nil {
+		return "", err
+	}
+
+	encodings := append([]merkle.LeafEncoding{vr.encoding},
+		slices.DeleteFunc(slices.Clone(merkle.LeafEncodings), func(e merkle.LeafEncoding) bool { return e == vr.encoding })...)
+	for _, encoding := range encodings {
+		node := h.leaf(encoding, proof.Recipient, earned)
+		for _, sibling := range nodes {
+			node = h.pair(node, sibling)
+		}
+		root := common.Bytes2Hex(node[:])
+		epoch, stored := vr.stored[root]
+		if !stored {
+			continue
+		}
+
+		verification.MerkleRoot = root
+		verification.EpochNumber = epoch
+		verification.LeafEncoding = string(encoding)
+		supersession, superseded := vr.superseded[root]
+		if superseded {
+			verification.SupersededBy = supersession.MerkleRoot
+		}
+		if !onChain {
+			return "", nil
+		}
+		current := root == vr.onChain
+		verification.OnChain = &current
+		if !current && superseded {
+			return fmt.Sprintf("root of epoch %s was replaced by root %s", epoch, supersession.MerkleRoot), nil
+		}
+		if !current {
+			return fmt.Sprintf("root of epoch %s is not the vault's on-chain root", epoch), nil
+		}
+		return "", nil
+	}
+	return "proof does not resolve to a root stored for the vault", nil
+}
+
+// loadVaultRoots returns the vault's roots, reading them on first use
+func (s *Service) loadVaultRoots(ctx context.Context, vault string, onChain bool, roots map[string]*vaultRoots) (*vaultRoots, error) {
+	if vr, ok := roots[vault]; ok {
+		return vr, nil
+	}
+
+	stored, err := s.store.RootEpochs(ctx, vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stored roots of vault %s: %w", vault, err)
+	}
+	superseded, err := s.store.ListSupersessions(ctx, vault)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list replaced roots of vault %s: %w", vault, err)
+	}
+	vr := &vaultRoots{encoding: s.LeafEncoding(vault), stored: stored, superseded: superseded}
+	if onChain {
+		root, err := s.contractClient.GetMerkleRoot(ctx, vault)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read on-chain merkle root for vault %s: %w", vault, err)
+		}
+		vr.onChain = common.Bytes2Hex(root[:])
+	}
+	roots[vault] = vr
+	return vr, nil
+}
@@ -0,0 +1,99 @@
+package merkleimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+func TestVerifyProofs(t *testing.T) {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	defer func() {
+		assert.NoError(t, badgerDB.Close())
+	}()
+
+	ctx := context.Background()
+	vault := "0x1111111111111111111111111111111111111111"
+	user := "0x3575b992c5337226aecf4e7f93dfbe80c576ce15"
+	other := "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b"
+	contractClient := &stubContractClient{}
+	service := New(db, &mockSubgraphClient{}, contractClient, lgr.NoOp)
+
+	// proofs are checked against every stored tree, not only the latest
+	proofs := make(map[int64]merkle.ProofToVerify)
+	var roots [][32]byte
+	for _, epoch := range []int64{3, 4} {
+		earned := epoch * 500
+		entries := []merkle.Entry{
+			{Address: user, TotalEarned: big.NewInt(earned)},
+			{Address: other, TotalEarned: big.NewInt(500)},
+		}
+		root := service.BuildMerkleRootFromEntries(entries)
+		roots = append(roots, root)
+		snapshot := merkle.MerkleSnapshot{VaultID: vault, MerkleRoot: fmt.Sprintf("%x", root)}
+		for _, entry := range entries {
+			snapshot.Entries = append(snapshot.Entries, merkle.MerkleEntry(entry))
+		}
+		require.NoError(t, service.SaveSnapshot(ctx, big.NewInt(epoch), snapshot))
+
+		nodes, _, err := service.GenerateProof(entries, user, big.NewInt(earned))
+		require.NoError(t, err)
+		proof := merkle.ProofToVerify{VaultAddress: vault, Recipient: user, TotalEarned: fmt.Sprint(earned)}
+		for _, node := range nodes {
+			proof.MerkleProof = append(proof.MerkleProof, hexutil.Encode(node[:]))
+		}
+		proofs[epoch] = proof
+	}
+
+	inflated := proofs[4]
+	inflated.TotalEarned = "2500"
+	badNode := proofs[4]
+	badNode.MerkleProof = []string{"0x1234"}
+
+	result, err := service.VerifyProofs(ctx, []merkle.ProofToVerify{proofs[3], proofs[4], inflated, badNode}, false)
+	require.NoError(t, err)
+	require.Len(t, result.Results, 4)
+	assert.Equal(t, 2, result.Valid)
+	assert.Equal(t, 2, result.Invalid)
+	assert.True(t, result.Results[0].Valid)
+	assert.Equal(t, "3", result.Results[0].EpochNumber)
+	assert.Equal(t, string(merkle.LeafEncodingPacked), result.Results[0].LeafEncoding)
+	assert.Nil(t, result.Results[0].OnChain, "on-chain root not checked")
+	assert.Equal(t, "4", result.Results[1].EpochNumber)
+	assert.False(t, result.Results[2].Valid)
+	assert.Equal(t, "proof does not resolve to a root stored for the vault", result.Results[2].Reason)
+	assert.Contains(t, result.Results[3].Reason, "invalid proof node 0")
+
+	// only the epoch whose root is on-chain can be claimed
+	contractClient.root = roots[1]
+	result, err = service.VerifyProofs(ctx, []merkle.ProofToVerify{proofs[3], proofs[4]}, true)
+	require.NoError(t, err)
+	assert.False(t, result.Results[0].Valid)
+	assert.False(t, *result.Results[0].OnChain)
+	assert.Equal(t, "root of epoch 3 is not the vault's on-chain root", result.Results[0].Reason)
+	assert.True(t, result.Results[1].Valid)
+	assert.True(t, *result.Results[1].OnChain)
+
+	contractClient.err = fmt.Errorf("rpc unavailable")
+	_, err = service.VerifyProofs(ctx, []merkle.ProofToVerify{proofs[3]}, true)
+	assert.ErrorContains(t, err, "rpc unavailable")
+
+	_, err = service.VerifyProofs(ctx, nil, false)
+	assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+	_, err = service.VerifyProofs(ctx, make([]merkle.ProofToVerify, maxVerifyProofs+1), false)
+	assert.ErrorIs(t, err, merkle.ErrInvalidInput)
+}
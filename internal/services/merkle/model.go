@@ -5,6 +5,7 @@ import (
 	"math/big"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
 )
 
@@ -17,9 +18,105 @@ type UserMerkleProofResponse struct {
 	MerkleProof  []string `json:"merkleProof"`
 	MerkleRoot   string   `json:"merkleRoot"`
 	LeafIndex    int      `json:"leafIndex"`
+	SupersededBy string   `json:"supersededBy,omitempty"` // root that replaced the proof's root for its epoch, empty while it stands
 	GeneratedAt  int64    `json:"generatedAt"`
 }
 
+// UserClaimable sums what a user can claim across every vault with a distribution
+type UserClaimable struct {
+	UserAddress    string           `json:"userAddress" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	TotalEarned    string           `json:"totalEarned" example:"1500000000000000000"`    // wei, cumulative in the latest distributions
+	TotalClaimed   string           `json:"totalClaimed" example:"500000000000000000"`    // wei, from getUserClaimedTotal
+	TotalClaimable string           `json:"totalClaimable" example:"1000000000000000000"` // wei, earned minus claimed
+	Vaults         []VaultClaimable `json:"vaults"`
+}
+
+// VaultClaimable is a user's claim in one vault's latest distribution
+type VaultClaimable struct {
+	VaultAddress string    `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	EpochNumber  string    `json:"epochNumber" example:"5"`
+	MerkleRoot   string    `json:"merkleRoot"`
+	TotalEarned  string    `json:"totalEarned"`
+	TotalClaimed string    `json:"totalClaimed"`
+	Claimable    string    `json:"claimable"`
+	Claim        ClaimData `json:"claim"`
+}
+
+// ClaimData mirrors the contract's ClaimData struct, ready to pass to claimSubsidy(vault, claim)
+type ClaimData struct {
+	Recipient   string   `json:"recipient" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	TotalEarned string   `json:"totalEarned" example:"1500000000000000000"`
+	MerkleProof []string `json:"merkleProof"` // 0x-prefixed bytes32 values
+}
+
+// Kinds of claim recipient. A contract wallet signs through ERC-1271 rather than with a key, its leaf is
+// encoded exactly as an externally owned account's.
+const (
+	RecipientEOA      = "eoa"
+	RecipientContract = "contract"
+)
+
+// ClaimPayload is a user's claim encoded for a relayer submitting it on the user's behalf. The DebtSubsidizer pays
+// recipient whoever sends the transaction, the typed data lets a relayer check the user asked for it.
+type ClaimPayload struct {
+	UserAddress  string           `json:"userAddress" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	VaultAddress string           `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	EpochNumber  string           `json:"epochNumber" example:"5"`
+	MerkleRoot   string           `json:"merkleRoot"`
+	Claimable    string           `json:"claimable" example:"1000000000000000000"` // wei, totalEarned minus getUserClaimedTotal
+	Claim        ClaimData        `json:"claim"`
+	Transaction  ClaimTransaction `json:"transaction"`
+	TypedData    TypedData        `json:"typedData"`
+	// RecipientType is eoa or contract, whether code is deployed at the recipient, which decides how its signature
+	// of the typed data is checked
+	RecipientType string `json:"recipientType" example:"eoa" enums:"eoa,contract"`
+	// RecipientChecksum is the recipient in EIP-55 form, as wallets display it
+	RecipientChecksum string `json:"recipientChecksum" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	// TypedDataHash is the EIP-712 digest of TypedData, what eth_signTypedData_v4 signs, 0x-prefixed
+	TypedDataHash string `json:"typedDataHash"`
+}
+
+// ClaimSignatureVerification reports whether a claim's recipient signed its typed data
+type ClaimSignatureVerification struct {
+	UserAddress   string `json:"userAddress" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	VaultAddress  string `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	RecipientType string `json:"recipientType" example:"contract" enums:"eoa,contract"`
+	TypedDataHash string `json:"typedDataHash"` // digest the signature was checked against, 0x-prefixed
+	Valid         bool   `json:"valid"`
+	Signer        string `json:"signer,omitempty"` // account recovered from an EOA signature
+	Reason        string `json:"reason,omitempty"` // why the signature is invalid
+}
+
+// ClaimTransaction is the claimSubsidy(vault, claim) call, ready to send
+type ClaimTransaction struct {
+	To      string `json:"to"`                // DebtSubsidizer
+	Data    string `json:"data"`              // 0x-prefixed calldata
+	Value   string `json:"value" example:"0"` // wei
+	ChainID uint64 `json:"chainId,omitempty"` // left out when CHAIN_ID is not configured
+}
+
+// TypedData is EIP-712 typed data in the shape eth_signTypedData_v4 takes
+type TypedData struct {
+	Types       map[string][]TypedDataField `json:"types"`
+	PrimaryType string                      `json:"primaryType" example:"ClaimSubsidy"`
+	Domain      TypedDataDomain             `json:"domain"`
+	Message     map[string]interface{}      `json:"message"`
+}
+
+// TypedDataField is a member of an EIP-712 struct type
+type TypedDataField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// TypedDataDomain separates claim requests by chain and DebtSubsidizer
+type TypedDataDomain struct {
+	Name              string `json:"name" example:"DebtSubsidizer"`
+	Version           string `json:"version" example:"1"`
+	ChainID           uint64 `json:"chainId,omitempty"`
+	VerifyingContract string `json:"verifyingContract"`
+}
+
 // MerkleDistribution represents merkle distribution data for an epoch
 type MerkleDistribution struct {
 	EpochNumber       string   `json:"epochNumber"`
@@ -39,6 +136,88 @@ type SubgraphClient interface {
 	ExecuteQuery(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error
 }
 
+// ContractClient interface for reading merkle state from the chain
+type ContractClient interface {
+	GetMerkleRoot(ctx context.Context, vaultAddress string) ([32]byte, error)
+	GetUserClaimedTotal(ctx context.Context, vaultAddress, userAddress string) (*big.Int, error)
+	GetMerkleRootUpdates(ctx context.Context, vaultAddress string, fromBlock, toBlock uint64) ([]blockchain.MerkleRootUpdate, error)
+	GetBlockRef(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error)
+	HasCode(ctx context.Context, address string) (bool, error)
+	IsValidSignature(ctx context.Context, account string, hash [32]byte, signature []byte) (bool, error)
+}
+
+// RootUpdate is a merkle root the DebtSubsidizer accepted for a vault, from its MerkleRootUpdated event
+type RootUpdate struct {
+	VaultAddress   string `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	MerkleRoot     string `json:"merkleRoot"`                                   // lowercase hex without 0x, as snapshots store roots
+	EpochNumber    string `json:"epochNumber,omitempty" example:"5"`            // epoch of the stored tree with this root, empty when none has it
+	TotalSubsidies string `json:"totalSubsidies" example:"1500000000000000000"` // totalSubsidiesForEpoch, wei
+	UpdatedBy      string `json:"updatedBy" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	TxHash         string `json:"txHash"`
+	BlockNumber    uint64 `json:"blockNumber" example:"18500000"`
+	BlockHash      string `json:"blockHash"`
+	LogIndex       uint   `json:"logIndex"`
+}
+
+// RootSupersession records an admin replacing the root pushed for a vault's epoch with a corrected one. The
+// replaced tree is kept, so proofs against either root still resolve, and a root replaced more than once is
+// followed through its supersessions.
+type RootSupersession struct {
+	VaultAddress   string    `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	EpochNumber    string    `json:"epochNumber" example:"5"`
+	PreviousRoot   string    `json:"previousRoot"`                                          // lowercase hex without 0x
+	MerkleRoot     string    `json:"merkleRoot"`                                            // root that replaced it, lowercase hex without 0x
+	PreviousTotal  string    `json:"previousTotal,omitempty" example:"1500000000000000000"` // wei the replaced root was pushed with
+	TotalSubsidies string    `json:"totalSubsidies" example:"1400000000000000000"`          // wei the corrected root was pushed with
+	Reason         string    `json:"reason"`
+	ReplacedBy     string    `json:"replacedBy"` // actor that confirmed the replacement
+	ReplacedAt     time.Time `json:"replacedAt"`
+}
+
+// MerkleRootVerification reports whether the stored snapshot for a vault matches the on-chain root
+type MerkleRootVerification struct {
+	VaultAddress string   `json:"vaultAddress"`
+	EpochNumber  string   `json:"epochNumber"`
+	LeafCount    int      `json:"leafCount"`
+	ComputedRoot string   `json:"computedRoot"`
+	StoredRoot   string   `json:"storedRoot"`
+	OnChainRoot  string   `json:"onChainRoot"`
+	LeafEncoding string   `json:"leafEncoding"` // encoding the snapshot's tree was built with
+	Match        bool     `json:"match"`
+	Mismatches   []string `json:"mismatches,omitempty"`
+	VerifiedAt   int64    `json:"verifiedAt"`
+}
+
+// ProofToVerify is a claim to check: a recipient's leaf in a vault's tree and the proof of it
+type ProofToVerify struct {
+	VaultAddress string   `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	Recipient    string   `json:"recipient" example:"0x742d35cc6634c0532925a3b844bc454e4438f44e"`
+	TotalEarned  string   `json:"totalEarned" example:"1000000000000000000"` // wei
+	MerkleProof  []string `json:"merkleProof"`                               // 32-byte hex nodes, 0x optional
+}
+
+// ProofVerification reports whether a proof resolves to a root stored for its vault
+type ProofVerification struct {
+	VaultAddress string `json:"vaultAddress"`
+	Recipient    string `json:"recipient"`
+	TotalEarned  string `json:"totalEarned"`
+	Valid        bool   `json:"valid"`
+	MerkleRoot   string `json:"merkleRoot,omitempty"`   // stored root the proof resolves to, lowercase hex without 0x
+	EpochNumber  string `json:"epochNumber,omitempty"`  // epoch of the stored tree with that root
+	LeafEncoding string `json:"leafEncoding,omitempty"` // encoding the leaf was hashed with to reach that root
+	OnChain      *bool  `json:"onChain,omitempty"`      // whether that root is the vault's on-chain root, when checked
+	SupersededBy string `json:"supersededBy,omitempty"` // root that replaced that root for its epoch, empty while it stands
+	Reason       string `json:"reason,omitempty"`       // why the proof is invalid
+}
+
+// ProofVerifications are the results of verifying many proofs, in request order
+type ProofVerifications struct {
+	Results    []ProofVerification `json:"results"`
+	Valid      int                 `json:"valid"`
+	Invalid    int                 `json:"invalid"`
+	VerifiedAt int64               `json:"verifiedAt"`
+}
+
 // Entry represents a leaf entry in the Merkle tree
 type Entry struct {
 	Address     string
@@ -71,11 +250,44 @@ type MerkleEntry struct {
 
 // MerkleSnapshot represents a complete snapshot of merkle tree data for an epoch
 type MerkleSnapshot struct {
-	EpochNumber *big.Int      `json:"epochNumber"`
-	Entries     []MerkleEntry `json:"entries"`
-	MerkleRoot  string        `json:"merkleRoot"`
-	Timestamp   int64         `json:"timestamp"`
-	VaultID     string        `json:"vaultId"`
-	BlockNumber int64         `json:"blockNumber"`
-	CreatedAt   time.Time     `json:"createdAt"`
+	EpochNumber    *big.Int      `json:"epochNumber"`
+	Entries        []MerkleEntry `json:"entries"`
+	MerkleRoot     string        `json:"merkleRoot"`
+	Timestamp      int64         `json:"timestamp"`
+	VaultID        string        `json:"vaultId"`
+	BlockNumber    int64         `json:"blockNumber"`
+	BlockHash      string        `json:"blockHash,omitempty"`
+	BlockStrategy  string        `json:"blockStrategy,omitempty"`  // how BlockNumber was chosen: latest, finalized, epoch_end or pinned
+	LeafEncoding   LeafEncoding  `json:"leafEncoding,omitempty"`   // how the tree's leaves were hashed, empty for trees saved before encodings were recorded
+	Fingerprint    string        `json:"fingerprint,omitempty"`    // hash of the inputs the distribution was computed from, empty for trees saved before fingerprints were recorded
+	Supersedes     string        `json:"supersedes,omitempty"`     // root of the epoch's tree this one replaced on-chain, empty unless an admin replaced it
+	EligibilityCID string        `json:"eligibilityCid,omitempty"` // IPFS CID of the published leaves and root, empty unless publishing is configured
+	CreatedAt      time.Time     `json:"createdAt"`
+}
+
+// Encoding returns the leaf encoding the snapshot's tree was built with. Trees saved before encodings were
+// recorded were all packed.
+func (s *MerkleSnapshot) Encoding() LeafEncoding {
+	if s.LeafEncoding == "" {
+		return LeafEncodingPacked
+	}
+	return s.LeafEncoding
 }
+
+// LeafEncoding is how a leaf's recipient and totalEarned are hashed. A DebtSubsidizer deployment verifies
+// claims against one encoding, so a tree must be built with the encoding of the deployment its root is pushed to.
+type LeafEncoding string
+
+// supported leaf encodings
+const (
+	// LeafEncodingPacked hashes keccak256(abi.encodePacked(recipient, totalEarned))
+	LeafEncodingPacked LeafEncoding = "packed"
+	// LeafEncodingABI hashes keccak256(abi.encode(recipient, totalEarned))
+	LeafEncodingABI LeafEncoding = "abi"
+	// LeafEncodingDoubleHash hashes keccak256(bytes.concat(keccak256(abi.encode(recipient, totalEarned)))),
+	// the leaves of OpenZeppelin's StandardMerkleTree
+	LeafEncodingDoubleHash LeafEncoding = "double_hash"
+)
+
+// LeafEncodings lists every supported leaf encoding, the default first
+var LeafEncodings = []LeafEncoding{LeafEncodingPacked, LeafEncodingABI, LeafEncodingDoubleHash}
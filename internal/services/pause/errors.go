@@ -0,0 +1,8 @@
+package pause
+
+import "errors"
+
+// Predefined error types for pause operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+)
@@ -0,0 +1,32 @@
+package pause
+
+import (
+	"time"
+)
+
+// jobs the scheduler runs, each can be paused on its own
+const (
+	JobAll           = "all" // every scheduled job
+	JobStartEpoch    = "start_epoch"
+	JobAllocateYield = "allocate_yield" // allocating the vault's yield to the new epoch, when enabled
+	JobDistribute    = "distribute"
+	JobCatchUp       = "catch_up"  // processing epochs missed while no scheduler ran
+	JobReconcile     = "reconcile" // checking distributed subsidies against the allocated yield
+)
+
+// Jobs lists every job that can be paused, JobAll first
+var Jobs = []string{JobAll, JobStartEpoch, JobAllocateYield, JobDistribute, JobCatchUp, JobReconcile}
+
+// JobState is whether a job is paused, and by whom
+type JobState struct {
+	Job      string     `json:"job" example:"distribute"`
+	Paused   bool       `json:"paused"`
+	Reason   string     `json:"reason,omitempty" example:"contract upgrade"`
+	PausedBy string     `json:"pausedBy,omitempty" example:"api:10.0.0.1"`
+	PausedAt *time.Time `json:"pausedAt,omitempty"`
+}
+
+// Status is the pause state of every scheduler job
+type Status struct {
+	Jobs []JobState `json:"jobs"`
+}
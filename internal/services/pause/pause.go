@@ -0,0 +1,30 @@
+package pause
+
+import (
+	"context"
+)
+
+//go:generate moq -out pause_mocks.go . Service
+
+// Service keeps which scheduler jobs operators paused. The state is stored, so a pause survives restarts
+// and applies to every replica sharing the database.
+type Service interface {
+	// Pause stops job from running on later scheduler cycles until it is resumed
+	Pause(ctx context.Context, job, reason string) (*JobState, error)
+	// Resume lets job run again; resuming JobAll clears every pause
+	Resume(ctx context.Context, job string) (*JobState, error)
+	// Status returns the state of every job
+	Status(ctx context.Context) (*Status, error)
+	// IsPaused reports whether job is paused on its own or through JobAll
+	IsPaused(ctx context.Context, job string) (bool, error)
+}
+
+// ValidJob reports whether job can be paused
+func ValidJob(job string) bool {
+	for _, known := range Jobs {
+		if job == known {
+			return true
+		}
+	}
+	return false
+}
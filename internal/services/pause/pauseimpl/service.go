@@ -0,0 +1,133 @@
+package pauseimpl
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// audit actions recorded for pause changes
+const (
+	actionPause  = "pauseScheduler"
+	actionResume = "resumeScheduler"
+)
+
+type Service struct {
+	store    *Store
+	recorder audit.Recorder // nil disables audit entries
+	logger   lgr.L
+	now      func() time.Time
+}
+
+func New(db storage.DB, recorder audit.Recorder, logger lgr.L) *Service {
+	return &Service{
+		store:    NewStore(db, logger),
+		recorder: recorder,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Pause stores job as paused by the context's actor. Pausing a paused job replaces its reason.
+func (s *Service) Pause(ctx context.Context, job, reason string) (_ *pause.JobState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "pause.Pause", attribute.String("pause.job", job))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !pause.ValidJob(job) {
+		return nil, fmt.Errorf("%w: unknown job %q", pause.ErrInvalidInput, job)
+	}
+
+	pausedAt := s.now().UTC()
+	state := pause.JobState{
+		Job:      job,
+		Paused:   true,
+		Reason:   reason,
+		PausedBy: audit.ActorFromContext(ctx),
+		PausedAt: &pausedAt,
+	}
+	if err := s.store.SaveJob(state); err != nil {
+		return nil, err
+	}
+
+	s.logger.Logf("WARN scheduler job %s paused by %s: %s", job, state.PausedBy, reason)
+	s.record(ctx, actionPause, map[string]string{"job": job, "reason": reason})
+	return &state, nil
+}
+
+// Resume clears the pause of job, and of every job when job is JobAll
+func (s *Service) Resume(ctx context.Context, job string) (_ *pause.JobState, err error) {
+	ctx, span := tracing.StartSpan(ctx, "pause.Resume", attribute.String("pause.job", job))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !pause.ValidJob(job) {
+		return nil, fmt.Errorf("%w: unknown job %q", pause.ErrInvalidInput, job)
+	}
+
+	jobs := []string{job}
+	if job == pause.JobAll {
+		jobs = pause.Jobs
+	}
+	if err := s.store.DeleteJobs(jobs...); err != nil {
+		return nil, err
+	}
+
+	s.logger.Logf("INFO scheduler job %s resumed by %s", job, audit.ActorFromContext(ctx))
+	s.record(ctx, actionResume, map[string]string{"job": job})
+	return &pause.JobState{Job: job}, nil
+}
+
+// Status returns every job's pause, jobs that are not paused included
+func (s *Service) Status(ctx context.Context) (_ *pause.Status, err error) {
+	_, span := tracing.StartSpan(ctx, "pause.Status")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	status := &pause.Status{Jobs: make([]pause.JobState, 0, len(pause.Jobs))}
+	for _, job := range pause.Jobs {
+		state, err := s.store.GetJob(job)
+		if err != nil {
+			return nil, err
+		}
+		if state == nil {
+			state = &pause.JobState{Job: job}
+		}
+		status.Jobs = append(status.Jobs, *state)
+	}
+	return status, nil
+}
+
+// IsPaused reports whether job or JobAll is paused
+func (s *Service) IsPaused(ctx context.Context, job string) (bool, error) {
+	for _, candidate := range []string{pause.JobAll, job} {
+		state, err := s.store.GetJob(candidate)
+		if err != nil {
+			return false, err
+		}
+		if state != nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// record writes a pause change to the audit log. Failures are logged, the change itself is already stored.
+func (s *Service) record(ctx context.Context, action string, parameters map[string]string) {
+	if s.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action:     action,
+		Actor:      audit.ActorFromContext(ctx),
+		Parameters: parameters,
+		Result:     audit.ResultSuccess,
+	}
+	if err := s.recorder.Record(ctx, entry); err != nil {
+		s.logger.Logf("WARN failed to record %s in audit log: %v", action, err)
+	}
+}
@@ -0,0 +1,105 @@
+package pauseimpl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/pause"
+)
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+func TestService_PauseAndResume(t *testing.T) {
+	recorder := &audit.RecorderMock{
+		RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil },
+	}
+	service := New(newTestDB(t), recorder, lgr.NoOp)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	state, err := service.Pause(ctx, pause.JobDistribute, "contract upgrade")
+	require.NoError(t, err)
+	assert.True(t, state.Paused)
+	assert.Equal(t, "api:10.0.0.1", state.PausedBy)
+	require.NotNil(t, state.PausedAt)
+
+	paused, err := service.IsPaused(ctx, pause.JobDistribute)
+	require.NoError(t, err)
+	assert.True(t, paused)
+	paused, err = service.IsPaused(ctx, pause.JobStartEpoch)
+	require.NoError(t, err)
+	assert.False(t, paused, "only the paused job stops")
+
+	_, err = service.Resume(ctx, pause.JobDistribute)
+	require.NoError(t, err)
+	paused, err = service.IsPaused(ctx, pause.JobDistribute)
+	require.NoError(t, err)
+	assert.False(t, paused)
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "pauseScheduler", calls[0].Entry.Action)
+	assert.Equal(t, map[string]string{"job": "distribute", "reason": "contract upgrade"}, calls[0].Entry.Parameters)
+	assert.Equal(t, "resumeScheduler", calls[1].Entry.Action)
+}
+
+func TestService_PauseAllSurvivesRestart(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+
+	_, err := New(db, nil, lgr.NoOp).Pause(ctx, pause.JobAll, "contract upgrade")
+	require.NoError(t, err)
+	_, err = New(db, nil, lgr.NoOp).Pause(ctx, pause.JobCatchUp, "")
+	require.NoError(t, err)
+
+	// a new service over the same database sees the pauses, as after a restart
+	service := New(db, nil, lgr.NoOp)
+	for _, job := range []string{pause.JobStartEpoch, pause.JobDistribute, pause.JobCatchUp} {
+		paused, err := service.IsPaused(ctx, job)
+		require.NoError(t, err)
+		assert.True(t, paused, job)
+	}
+
+	status, err := service.Status(ctx)
+	require.NoError(t, err)
+	require.Len(t, status.Jobs, len(pause.Jobs))
+	assert.Equal(t, pause.JobAll, status.Jobs[0].Job)
+	assert.True(t, status.Jobs[0].Paused)
+	assert.Equal(t, "contract upgrade", status.Jobs[0].Reason)
+	assert.Equal(t, "system", status.Jobs[0].PausedBy)
+	assert.False(t, status.Jobs[1].Paused)
+
+	// resuming everything clears the job paused on its own too
+	_, err = service.Resume(ctx, pause.JobAll)
+	require.NoError(t, err)
+	status, err = service.Status(ctx)
+	require.NoError(t, err)
+	for _, state := range status.Jobs {
+		assert.False(t, state.Paused, state.Job)
+	}
+}
+
+func TestService_RejectsUnknownJob(t *testing.T) {
+	service := New(newTestDB(t), nil, lgr.NoOp)
+
+	_, err := service.Pause(context.Background(), "withdraw", "")
+	assert.ErrorIs(t, err, pause.ErrInvalidInput)
+	_, err = service.Resume(context.Background(), "")
+	assert.ErrorIs(t, err, pause.ErrInvalidInput)
+}
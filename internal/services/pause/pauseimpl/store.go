@@ -0,0 +1,83 @@
+package pauseimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const jobPrefix = "scheduler:pause:"
+
+// Store handles storage of paused jobs
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveJob stores a paused job, replacing an earlier pause of it
+func (s *Store) SaveJob(state pause.JobState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal paused job: %w", err)
+	}
+
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(jobPrefix+state.Job), data)
+	}); err != nil {
+		return fmt.Errorf("failed to save paused job %s: %w", state.Job, err)
+	}
+
+	return nil
+}
+
+// DeleteJobs removes the pauses of jobs
+func (s *Store) DeleteJobs(jobs ...string) error {
+	if err := s.db.Update(func(txn storage.Txn) error {
+		for _, job := range jobs {
+			if err := txn.Delete([]byte(jobPrefix + job)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("failed to delete paused jobs: %w", err)
+	}
+
+	return nil
+}
+
+// GetJob returns the pause of job, or nil when it is not paused
+func (s *Store) GetJob(job string) (*pause.JobState, error) {
+	var state *pause.JobState
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(jobPrefix + job))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			state = &pause.JobState{}
+			return json.Unmarshal(val, state)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get paused job %s: %w", job, err)
+	}
+
+	return state, nil
+}
@@ -0,0 +1,137 @@
+// Package preflight checks a configuration against the chain and subgraph it points at,
+// so a wrong address or endpoint fails at startup instead of on the first epoch boundary.
+package preflight
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/go-pkgz/lgr"
+)
+
+// requiredEntities are the subgraph query fields the server reads
+var requiredEntities = []string{"accounts", "accountSubsidies", "epoches", "merkleDistributions"}
+
+// schemaQuery lists the subgraph's query fields
+const schemaQuery = `
+	query Schema {
+		__schema {
+			queryType {
+				fields {
+					name
+				}
+			}
+		}
+	}
+`
+
+// Check verifies every configured contract is deployed, the signer can pay for gas and holds the roles its
+// transactions need, and the subgraph serves the entities the server reads in a schema version the client
+// can query. It returns a *config.ValidationError listing every problem found.
+func Check(
+	ctx context.Context,
+	cfg *config.Config,
+	chain blockchain.BlockchainClient,
+	subgraphClient subgraph.SubgraphClient,
+	logger lgr.L,
+) error {
+	var problems []error
+	if chain != nil {
+		problems = append(problems, checkContracts(ctx, cfg, chain)...)
+		if !cfg.Server.ReadOnly {
+			problems = append(problems, checkSigner(ctx, chain)...)
+			problems = append(problems, checkSignerRoles(ctx, cfg, chain)...)
+		}
+	}
+	if subgraphClient != nil {
+		problems = append(problems, checkSubgraph(ctx, subgraphClient)...)
+	}
+
+	if len(problems) > 0 {
+		return &config.ValidationError{Problems: problems}
+	}
+	logger.Logf("INFO configuration checks passed")
+	return nil
+}
+
+func checkContracts(ctx context.Context, cfg *config.Config, chain blockchain.BlockchainClient) []error {
+	var problems []error
+	for _, contract := range config.ContractAddresses(cfg) {
+		deployed, err := chain.HasCode(ctx, contract.Address)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("failed to check the %s contract: %w", contract.Name, err))
+			continue
+		}
+		if !deployed {
+			problems = append(problems, fmt.Errorf("no %s contract is deployed at %s", contract.Name, contract.Address))
+		}
+	}
+	return problems
+}
+
+func checkSigner(ctx context.Context, chain blockchain.BlockchainClient) []error {
+	balance, err := chain.GetSignerBalance(ctx)
+	if err != nil {
+		return []error{fmt.Errorf("failed to check the signer balance: %w", err)}
+	}
+	if balance.Balance.Sign() == 0 {
+		return []error{fmt.Errorf("signer %s derived from the private key has no ETH to pay for gas", balance.Address)}
+	}
+	return nil
+}
+
+// checkSignerRoles reports every role the signer is missing, a transaction it sends without one reverts
+func checkSignerRoles(ctx context.Context, cfg *config.Config, chain blockchain.BlockchainClient) []error {
+	roles, err := chain.GetSignerRoles(ctx, cfg.Contracts.CollectionsVault)
+	if err != nil {
+		return []error{fmt.Errorf("failed to check the signer roles: %w", err)}
+	}
+	var problems []error
+	for _, role := range roles {
+		if !role.Held {
+			problems = append(problems, fmt.Errorf("signer %s does not hold the %s role of the %s contract at %s",
+				role.Signer, role.Role, role.Contract, role.Address))
+		}
+	}
+	return problems
+}
+
+func checkSubgraph(ctx context.Context, subgraphClient subgraph.SubgraphClient) []error {
+	var response struct {
+		Schema struct {
+			QueryType struct {
+				Fields []struct {
+					Name string `json:"name"`
+				} `json:"fields"`
+			} `json:"queryType"`
+		} `json:"__schema"`
+	}
+	if err := subgraphClient.ExecuteQuery(ctx, subgraph.GraphQLRequest{Query: schemaQuery}, &response); err != nil {
+		return []error{fmt.Errorf("failed to read the subgraph schema: %w", err)}
+	}
+
+	served := make(map[string]bool, len(response.Schema.QueryType.Fields))
+	for _, field := range response.Schema.QueryType.Fields {
+		served[field.Name] = true
+	}
+	var missing []string
+	for _, entity := range requiredEntities {
+		if !served[entity] {
+			missing = append(missing, entity)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return []error{fmt.Errorf("subgraph schema is missing required entities %v", missing)}
+	}
+
+	// the entities are served, their fields decide which schema version queries are adapted to
+	if _, err := subgraphClient.NegotiateSchema(ctx); err != nil {
+		return []error{fmt.Errorf("failed to negotiate the subgraph schema version: %w", err)}
+	}
+	return nil
+}
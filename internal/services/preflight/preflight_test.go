@@ -0,0 +1,126 @@
+package preflight
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+func testConfig() *config.Config {
+	cfg := &config.Config{}
+	cfg.Contracts.Comptroller = "0x1111111111111111111111111111111111111111"
+	cfg.Contracts.EpochManager = "0x2222222222222222222222222222222222222222"
+	cfg.Contracts.DebtSubsidizer = "0x3333333333333333333333333333333333333333"
+	cfg.Contracts.LendingManager = "0x4444444444444444444444444444444444444444"
+	cfg.Contracts.CollectionRegistry = "0x5555555555555555555555555555555555555555"
+	cfg.Contracts.CollectionsVault = "0x6666666666666666666666666666666666666666"
+	return cfg
+}
+
+// testRoles returns the signer's roles, holding the epoch manager's automated system only when automated is set
+func testRoles(automated bool) func(ctx context.Context, vaultAddress string) ([]blockchain.SignerRole, error) {
+	return func(ctx context.Context, vaultAddress string) ([]blockchain.SignerRole, error) {
+		return []blockchain.SignerRole{
+			{Contract: "collections vault", Address: vaultAddress, Role: "DEBT_SUBSIDIZER_ROLE", Signer: "0xsigner", Held: true},
+			{Contract: "epoch manager", Address: "0x2222222222222222222222222222222222222222", Role: "automated system", Signer: "0xsigner", Held: automated},
+		}, nil
+	}
+}
+
+// testSubgraph serves a schema with the given query fields
+func testSubgraph(fields ...string) *subgraph.SubgraphClientMock {
+	return &subgraph.SubgraphClientMock{
+		ExecuteQueryFunc: func(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+			served := make([]map[string]string, len(fields))
+			for i, field := range fields {
+				served[i] = map[string]string{"name": field}
+			}
+			data, err := json.Marshal(map[string]interface{}{
+				"__schema": map[string]interface{}{"queryType": map[string]interface{}{"fields": served}},
+			})
+			if err != nil {
+				return err
+			}
+			return json.Unmarshal(data, response)
+		},
+		NegotiateSchemaFunc: func(ctx context.Context) (*subgraph.Schema, error) {
+			return &subgraph.Schema{Version: subgraph.SchemaLatest}, nil
+		},
+	}
+}
+
+func TestCheck_Passes(t *testing.T) {
+	chain := &blockchain.BlockchainClientMock{
+		HasCodeFunc: func(ctx context.Context, address string) (bool, error) { return true, nil },
+		GetSignerBalanceFunc: func(ctx context.Context) (*blockchain.SignerBalance, error) {
+			return &blockchain.SignerBalance{Address: "0xsigner", Balance: big.NewInt(1)}, nil
+		},
+		GetSignerRolesFunc: testRoles(true),
+	}
+	subgraphClient := testSubgraph("accounts", "accountSubsidies", "epoches", "merkleDistributions", "vaults")
+
+	require.NoError(t, Check(context.Background(), testConfig(), chain, subgraphClient, lgr.NoOp))
+	assert.Len(t, chain.HasCodeCalls(), 6, "optional contracts are only checked when configured")
+}
+
+func TestCheck_ReportsEveryProblem(t *testing.T) {
+	cfg := testConfig()
+	chain := &blockchain.BlockchainClientMock{
+		HasCodeFunc: func(ctx context.Context, address string) (bool, error) {
+			switch address {
+			case cfg.Contracts.EpochManager:
+				return false, nil
+			case cfg.Contracts.CollectionsVault:
+				return false, errors.New("connection refused")
+			}
+			return true, nil
+		},
+		GetSignerBalanceFunc: func(ctx context.Context) (*blockchain.SignerBalance, error) {
+			return &blockchain.SignerBalance{Address: "0xsigner", Balance: big.NewInt(0)}, nil
+		},
+		GetSignerRolesFunc: testRoles(false),
+	}
+
+	err := Check(context.Background(), cfg, chain, testSubgraph("accounts", "epoches"), lgr.NoOp)
+	require.Error(t, err)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 5)
+	assert.Contains(t, err.Error(), "no epoch manager contract is deployed at 0x2222222222222222222222222222222222222222")
+	assert.Contains(t, err.Error(), "failed to check the collections vault contract")
+	assert.Contains(t, err.Error(), "signer 0xsigner derived from the private key has no ETH")
+	assert.Contains(t, err.Error(),
+		"signer 0xsigner does not hold the automated system role of the epoch manager contract at 0x2222222222222222222222222222222222222222")
+	assert.Contains(t, err.Error(), "missing required entities [accountSubsidies merkleDistributions]")
+
+	// read-only servers have no signer to check
+	cfg.Server.ReadOnly = true
+	err = Check(context.Background(), cfg, chain, testSubgraph(requiredEntities...), lgr.NoOp)
+	require.ErrorAs(t, err, &validationErr)
+	assert.Len(t, validationErr.Problems, 2)
+}
+
+func TestCheck_IncompatibleSubgraphSchema(t *testing.T) {
+	subgraphClient := testSubgraph(requiredEntities...)
+	subgraphClient.NegotiateSchemaFunc = func(ctx context.Context) (*subgraph.Schema, error) {
+		return nil, fmt.Errorf("%w: missing AccountSubsidy.secondsAccumulated", subgraph.ErrSchemaIncompatible)
+	}
+
+	err := Check(context.Background(), testConfig(), nil, subgraphClient, lgr.NoOp)
+	var validationErr *config.ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	require.Len(t, validationErr.Problems, 1)
+	assert.ErrorIs(t, err, subgraph.ErrSchemaIncompatible)
+	assert.Contains(t, err.Error(), "missing AccountSubsidy.secondsAccumulated")
+}
@@ -0,0 +1,10 @@
+package queue
+
+import "errors"
+
+// Predefined error types for queue operations
+var (
+	ErrInvalidInput = errors.New("invalid input parameters")
+	ErrNotFound     = errors.New("job not found")
+	ErrQueueFull    = errors.New("job queue is full")
+)
@@ -0,0 +1,89 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// kinds of API-triggered work run from the queue
+const (
+	KindDistribute   = "distribute"
+	KindReplay       = "replay"
+	KindVerifyProofs = "verify_proofs"
+)
+
+// priorities of a job, queued jobs of a higher priority run first
+const (
+	PriorityLow    = "low"
+	PriorityNormal = "normal"
+	PriorityHigh   = "high"
+)
+
+// PriorityRanks orders the priorities, the highest rank runs first
+var PriorityRanks = map[string]int{PriorityLow: 0, PriorityNormal: 1, PriorityHigh: 2}
+
+// statuses of a job
+const (
+	StatusQueued    = "queued"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+)
+
+// Job is API-triggered work run in the background by the queue's workers
+type Job struct {
+	ID       string          `json:"id" example:"9f86d081884c7d65"`
+	Kind     string          `json:"kind" example:"distribute"`
+	Priority string          `json:"priority" example:"normal"`
+	Status   string          `json:"status" example:"succeeded"`
+	Payload  json.RawMessage `json:"payload,omitempty" swaggertype:"object"` // what the job was queued with
+	Result   json.RawMessage `json:"result,omitempty" swaggertype:"object"`  // what the work returned once it succeeded
+	Error    string          `json:"error,omitempty" example:"failed to distribute subsidies: execution reverted"`
+	// Attempts counts the runs started, more than one when a restart interrupted the job and it ran again
+	Attempts   int        `json:"attempts" example:"1"`
+	EnqueuedBy string     `json:"enqueuedBy,omitempty" example:"api:10.0.0.1"`
+	RequestID  string     `json:"requestId,omitempty"` // X-Request-ID of the request that queued the job
+	EnqueuedAt time.Time  `json:"enqueuedAt"`
+	StartedAt  *time.Time `json:"startedAt,omitempty"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// Finished reports whether the job succeeded or failed
+func (j Job) Finished() bool {
+	return j.Status == StatusSucceeded || j.Status == StatusFailed
+}
+
+// Runner runs the work of one kind of job from the payload it was queued with, returning its result
+type Runner func(ctx context.Context, payload json.RawMessage) (interface{}, error)
+
+// RunnerFor returns a Runner decoding the payload into P before running fn
+func RunnerFor[P any](fn func(ctx context.Context, payload P) (interface{}, error)) Runner {
+	return func(ctx context.Context, raw json.RawMessage) (interface{}, error) {
+		var payload P
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			return nil, fmt.Errorf("%w: malformed payload: %v", ErrInvalidInput, err)
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// DistributePayload is what a distribute job distributes
+type DistributePayload struct {
+	VaultID string `json:"vaultId"`
+}
+
+// ReplayPayload is the epoch a replay job recomputes
+type ReplayPayload struct {
+	VaultID     string `json:"vaultId"`
+	EpochNumber string `json:"epochNumber"`
+}
+
+// VerifyProofsPayload is the proofs a verify_proofs job verifies
+type VerifyProofsPayload struct {
+	Proofs  []merkle.ProofToVerify `json:"proofs"`
+	OnChain bool                   `json:"onChain"`
+}
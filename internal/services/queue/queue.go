@@ -0,0 +1,19 @@
+package queue
+
+import "context"
+
+//go:generate moq -out queue_mocks.go . Service
+
+// Service queues API-triggered work that can outlast an HTTP request. Jobs are stored, so queued jobs and the
+// jobs a restart interrupted run once the server is back, and their outcome can be polled by ID.
+type Service interface {
+	// Enqueue queues a job of kind to run with payload, ErrQueueFull when QUEUE_MAX_QUEUED jobs are waiting.
+	// An empty priority is PriorityNormal.
+	Enqueue(ctx context.Context, kind, priority string, payload interface{}) (*Job, error)
+
+	// Get returns the job, ErrNotFound when there is none or it finished longer than QUEUE_RETENTION ago
+	Get(ctx context.Context, id string) (*Job, error)
+
+	// List returns the kept jobs with the status, every status when it is empty, most recently queued first
+	List(ctx context.Context, status string) ([]Job, error)
+}
@@ -0,0 +1,262 @@
+package queueimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// claimRetry is how long a worker waits before claiming again after the store failed
+const claimRetry = 5 * time.Second
+
+// Service runs queued jobs on QUEUE_WORKERS workers, higher priorities first and in the order they were
+// queued within a priority
+type Service struct {
+	store   *Store
+	runners map[string]queue.Runner
+	wake    chan struct{}
+	mu      sync.Mutex // keeps the queue length checked when queuing consistent with claims
+	now     func() time.Time
+	logger  lgr.L
+	config  *config.Config
+}
+
+func New(db storage.DB, logger lgr.L, cfg *config.Config) *Service {
+	return &Service{
+		store:   NewStore(db, logger),
+		runners: make(map[string]queue.Runner),
+		wake:    make(chan struct{}, max(cfg.Queue.Workers, 1)),
+		now:     time.Now,
+		logger:  logger,
+		config:  cfg,
+	}
+}
+
+// Register sets how jobs of kind run, jobs of kinds without a runner cannot be queued. It is called once per
+// kind at startup, before Start.
+func (s *Service) Register(kind string, runner queue.Runner) {
+	s.runners[kind] = runner
+}
+
+// Enqueue stores a job of kind and wakes a worker to run it
+func (s *Service) Enqueue(ctx context.Context, kind, priority string, payload interface{}) (_ *queue.Job, err error) {
+	ctx, span := tracing.StartSpan(ctx, "queue.Enqueue", attribute.String("queue.kind", kind))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if _, ok := s.runners[kind]; !ok {
+		return nil, fmt.Errorf("%w: unknown job kind %q", queue.ErrInvalidInput, kind)
+	}
+	if priority == "" {
+		priority = queue.PriorityNormal
+	}
+	if _, ok := queue.PriorityRanks[priority]; !ok {
+		return nil, fmt.Errorf("%w: priority must be low, normal or high, got %q", queue.ErrInvalidInput, priority)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to encode payload: %v", queue.ErrInvalidInput, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queued, err := s.store.CountQueued()
+	if err != nil {
+		return nil, err
+	}
+	if queued >= s.config.Queue.MaxQueued {
+		return nil, fmt.Errorf("%w: %d jobs are waiting to run", queue.ErrQueueFull, queued)
+	}
+
+	job := queue.Job{
+		ID:         newJobID(),
+		Kind:       kind,
+		Priority:   priority,
+		Status:     queue.StatusQueued,
+		Payload:    data,
+		EnqueuedBy: audit.ActorFromContext(ctx),
+		RequestID:  logging.FieldsFromContext(ctx).RequestID,
+		EnqueuedAt: s.now().UTC(),
+	}
+	if err := s.store.SaveQueued(job); err != nil {
+		return nil, err
+	}
+	logging.FromContext(ctx, s.logger).Logf("INFO queued %s job %s with %s priority, %d jobs ahead", kind, job.ID, priority, queued)
+
+	select {
+	case s.wake <- struct{}{}:
+	default: // every worker is already woken
+	}
+	return &job, nil
+}
+
+// Get returns the job
+func (s *Service) Get(ctx context.Context, id string) (*queue.Job, error) {
+	job, err := s.store.GetJob(id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil {
+		return nil, fmt.Errorf("%w: %s", queue.ErrNotFound, id)
+	}
+	return job, nil
+}
+
+// List returns the kept jobs with the status, most recently queued first
+func (s *Service) List(ctx context.Context, status string) ([]queue.Job, error) {
+	switch status {
+	case "", queue.StatusQueued, queue.StatusRunning, queue.StatusSucceeded, queue.StatusFailed:
+	default:
+		return nil, fmt.Errorf("%w: status must be queued, running, succeeded or failed, got %q", queue.ErrInvalidInput, status)
+	}
+
+	jobs, err := s.store.ListJobs()
+	if err != nil {
+		return nil, err
+	}
+	matching := make([]queue.Job, 0, len(jobs))
+	for _, job := range jobs {
+		if status == "" || job.Status == status {
+			matching = append(matching, job)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool { return matching[i].EnqueuedAt.After(matching[j].EnqueuedAt) })
+	return matching, nil
+}
+
+// Start runs queued jobs until ctx is canceled. Jobs left running when the server last stopped are queued
+// again first. A job running when ctx is canceled stays running and is queued again on the next start.
+func (s *Service) Start(ctx context.Context) {
+	requeued, err := s.store.RequeueRunning()
+	if err != nil {
+		s.logger.Logf("ERROR failed to requeue interrupted jobs: %v", err)
+	} else if requeued > 0 {
+		s.logger.Logf("INFO requeued %d jobs interrupted by the last shutdown", requeued)
+	}
+	s.prune()
+
+	workers := max(s.config.Queue.Workers, 1)
+	s.logger.Logf("INFO job queue started with %d workers", workers)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.work(ctx)
+		}()
+	}
+	wg.Wait()
+	s.logger.Logf("INFO job queue stopped")
+}
+
+// work claims and runs jobs until ctx is canceled, waiting to be woken while none is queued
+func (s *Service) work(ctx context.Context) {
+	for ctx.Err() == nil {
+		s.mu.Lock()
+		job, err := s.store.ClaimNext(s.now().UTC())
+		s.mu.Unlock()
+		if err != nil {
+			s.logger.Logf("ERROR %v", err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(claimRetry):
+			}
+			continue
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+			case <-s.wake:
+			}
+			continue
+		}
+		s.run(ctx, *job)
+	}
+}
+
+// run runs the job and stores its outcome, unless ctx was canceled while it ran
+func (s *Service) run(ctx context.Context, job queue.Job) {
+	ctx = audit.WithActor(ctx, job.EnqueuedBy)
+	ctx = logging.WithFields(ctx, logging.Fields{RequestID: job.RequestID})
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Logf("INFO running %s job %s, attempt %d", job.Kind, job.ID, job.Attempts)
+
+	runCtx, cancel := ctx, context.CancelFunc(func() {})
+	if s.config.Queue.Timeout > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, s.config.Queue.Timeout)
+	}
+	result, err := s.runJob(runCtx, job)
+	cancel()
+	if ctx.Err() != nil {
+		logger.Logf("WARN %s job %s interrupted by shutdown, it runs again on the next start", job.Kind, job.ID)
+		return
+	}
+
+	finished := s.now().UTC()
+	job.FinishedAt = &finished
+	if err == nil {
+		job.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		job.Status, job.Error, job.Result = queue.StatusFailed, err.Error(), nil
+		logger.Logf("WARN %s job %s failed: %v", job.Kind, job.ID, err)
+	} else {
+		job.Status = queue.StatusSucceeded
+		logger.Logf("INFO %s job %s succeeded in %s", job.Kind, job.ID, finished.Sub(*job.StartedAt))
+	}
+	if err := s.store.SaveJob(job); err != nil {
+		logger.Logf("ERROR failed to save outcome of job %s: %v", job.ID, err)
+	}
+	s.prune()
+}
+
+// runJob runs the job's runner, turning a panic into the job's error so the worker keeps running
+func (s *Service) runJob(ctx context.Context, job queue.Job) (result interface{}, err error) {
+	runner, ok := s.runners[job.Kind]
+	if !ok {
+		return nil, fmt.Errorf("no runner for job kind %q", job.Kind)
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return runner(ctx, job.Payload)
+}
+
+// prune removes the jobs that finished longer than QUEUE_RETENTION ago
+func (s *Service) prune() {
+	if s.config.Queue.Retention <= 0 {
+		return
+	}
+	deleted, err := s.store.DeleteFinishedBefore(s.now().Add(-s.config.Queue.Retention))
+	if err != nil {
+		s.logger.Logf("WARN %v", err)
+		return
+	}
+	if deleted > 0 {
+		s.logger.Logf("DEBUG removed %d finished jobs past retention", deleted)
+	}
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
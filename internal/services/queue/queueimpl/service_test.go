@@ -0,0 +1,244 @@
+package queueimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/queue"
+)
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+func newTestConfig(workers, maxQueued int) *config.Config {
+	cfg := &config.Config{}
+	cfg.Queue.Workers = workers
+	cfg.Queue.MaxQueued = maxQueued
+	cfg.Queue.Timeout = time.Minute
+	cfg.Queue.Retention = time.Hour
+	return cfg
+}
+
+// startQueue runs the service's workers until the test ends
+func startQueue(t *testing.T, service *Service) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Start(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+// waitFinished polls the job until it succeeded or failed
+func waitFinished(t *testing.T, service *Service, id string) *queue.Job {
+	t.Helper()
+
+	var job *queue.Job
+	require.Eventually(t, func() bool {
+		var err error
+		job, err = service.Get(context.Background(), id)
+		require.NoError(t, err)
+		return job.Finished()
+	}, 5*time.Second, 10*time.Millisecond)
+	return job
+}
+
+func TestService_RunsJobsAndKeepsOutcome(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp, newTestConfig(2, 10))
+	service.Register(queue.KindReplay, queue.RunnerFor(func(ctx context.Context, payload queue.ReplayPayload) (interface{}, error) {
+		if payload.EpochNumber == "0" {
+			return nil, errors.New("nothing distributed in epoch 0")
+		}
+		return map[string]string{"epoch": payload.EpochNumber, "actor": audit.ActorFromContext(ctx)}, nil
+	}))
+	startQueue(t, service)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	_, err := service.Enqueue(ctx, "unknown", "", nil)
+	require.ErrorIs(t, err, queue.ErrInvalidInput)
+	_, err = service.Enqueue(ctx, queue.KindReplay, "urgent", nil)
+	require.ErrorIs(t, err, queue.ErrInvalidInput)
+
+	queued, err := service.Enqueue(ctx, queue.KindReplay, "", queue.ReplayPayload{VaultID: "0xvault", EpochNumber: "5"})
+	require.NoError(t, err)
+	assert.Equal(t, queue.StatusQueued, queued.Status)
+	assert.Equal(t, queue.PriorityNormal, queued.Priority)
+	assert.Equal(t, "api:10.0.0.1", queued.EnqueuedBy)
+
+	job := waitFinished(t, service, queued.ID)
+	assert.Equal(t, queue.StatusSucceeded, job.Status)
+	assert.Equal(t, 1, job.Attempts)
+	assert.JSONEq(t, `{"epoch":"5","actor":"api:10.0.0.1"}`, string(job.Result), "the job runs as whoever queued it")
+
+	failing, err := service.Enqueue(ctx, queue.KindReplay, queue.PriorityHigh, queue.ReplayPayload{EpochNumber: "0"})
+	require.NoError(t, err)
+	job = waitFinished(t, service, failing.ID)
+	assert.Equal(t, queue.StatusFailed, job.Status)
+	assert.Equal(t, "nothing distributed in epoch 0", job.Error)
+
+	failed, err := service.List(ctx, queue.StatusFailed)
+	require.NoError(t, err)
+	require.Len(t, failed, 1)
+	assert.Equal(t, failing.ID, failed[0].ID)
+	_, err = service.List(ctx, "done")
+	require.ErrorIs(t, err, queue.ErrInvalidInput)
+
+	_, err = service.Get(ctx, "missing")
+	require.ErrorIs(t, err, queue.ErrNotFound)
+}
+
+func TestService_RunsHigherPrioritiesFirst(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp, newTestConfig(1, 10))
+	service.Register(queue.KindVerifyProofs, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		return nil, nil
+	})
+	ctx := context.Background()
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	var ids []string
+	for i, priority := range []string{queue.PriorityLow, queue.PriorityNormal, queue.PriorityHigh, queue.PriorityNormal} {
+		service.now = func() time.Time { return start.Add(time.Duration(i) * time.Second) }
+		job, err := service.Enqueue(ctx, queue.KindVerifyProofs, priority, nil)
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	var order []string
+	for {
+		job, err := service.store.ClaimNext(start)
+		require.NoError(t, err)
+		if job == nil {
+			break
+		}
+		assert.Equal(t, queue.StatusRunning, job.Status)
+		order = append(order, job.ID)
+	}
+	assert.Equal(t, []string{ids[2], ids[1], ids[3], ids[0]}, order, "high, then normal in queued order, then low")
+}
+
+func TestService_LimitsQueuedAndRunningJobs(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp, newTestConfig(2, 3))
+	release := make(chan struct{})
+	var running, most atomic.Int32
+	service.Register(queue.KindDistribute, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		n := running.Add(1)
+		for {
+			current := most.Load()
+			if n <= current || most.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		<-release
+		running.Add(-1)
+		return nil, nil
+	})
+	ctx := context.Background()
+
+	var ids []string
+	for range 3 {
+		job, err := service.Enqueue(ctx, queue.KindDistribute, "", nil)
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+	_, err := service.Enqueue(ctx, queue.KindDistribute, "", nil)
+	require.ErrorIs(t, err, queue.ErrQueueFull)
+
+	startQueue(t, service)
+	require.Eventually(t, func() bool { return running.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	// a waiting slot freed up once the workers claimed two jobs
+	job, err := service.Enqueue(ctx, queue.KindDistribute, "", nil)
+	require.NoError(t, err)
+	ids = append(ids, job.ID)
+
+	close(release)
+	for _, id := range ids {
+		assert.Equal(t, queue.StatusSucceeded, waitFinished(t, service, id).Status)
+	}
+	assert.Equal(t, int32(2), most.Load(), "no more jobs run at once than there are workers")
+}
+
+func TestService_RequeuesJobsInterruptedByShutdown(t *testing.T) {
+	db := newTestDB(t)
+	service := New(db, lgr.NoOp, newTestConfig(1, 10))
+	started := make(chan struct{})
+	service.Register(queue.KindDistribute, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	job, err := service.Enqueue(context.Background(), queue.KindDistribute, "", queue.DistributePayload{VaultID: "0xvault"})
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		service.Start(ctx)
+		close(done)
+	}()
+	<-started
+	cancel()
+	<-done
+
+	interrupted, err := service.Get(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, queue.StatusRunning, interrupted.Status, "a shutdown does not fail the job")
+
+	restarted := New(db, lgr.NoOp, newTestConfig(1, 10))
+	restarted.Register(queue.KindDistribute, queue.RunnerFor(func(ctx context.Context, payload queue.DistributePayload) (interface{}, error) {
+		return payload, nil
+	}))
+	startQueue(t, restarted)
+	finished := waitFinished(t, restarted, job.ID)
+	assert.Equal(t, queue.StatusSucceeded, finished.Status)
+	assert.Equal(t, 2, finished.Attempts)
+	assert.JSONEq(t, `{"vaultId":"0xvault"}`, string(finished.Result))
+}
+
+func TestService_PrunesFinishedJobsPastRetention(t *testing.T) {
+	service := New(newTestDB(t), lgr.NoOp, newTestConfig(1, 10))
+	service.Register(queue.KindDistribute, func(ctx context.Context, payload json.RawMessage) (interface{}, error) {
+		panic("boom")
+	})
+	startQueue(t, service)
+	ctx := context.Background()
+
+	job, err := service.Enqueue(ctx, queue.KindDistribute, "", nil)
+	require.NoError(t, err)
+	finished := waitFinished(t, service, job.ID)
+	assert.Equal(t, queue.StatusFailed, finished.Status)
+	assert.Equal(t, "job panicked: boom", finished.Error)
+
+	// what prune removes an hour past retention
+	deleted, err := service.store.DeleteFinishedBefore(time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = service.Get(ctx, job.ID)
+	require.ErrorIs(t, err, queue.ErrNotFound)
+}
@@ -0,0 +1,245 @@
+package queueimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	jobPrefix     = "queue:job:"
+	pendingPrefix = "queue:pending:"
+)
+
+// Store handles storage of queued jobs. Every job is kept under its ID, and a queued job also has a pending key
+// that sorts by priority and then by when it was queued, so the next job to run is the first pending key.
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveQueued stores a job waiting to run
+func (s *Store) SaveQueued(job queue.Job) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		if err := s.setJob(txn, job); err != nil {
+			return err
+		}
+		return txn.Set([]byte(s.buildPendingKey(job)), []byte(job.ID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save queued job %s: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+// SaveJob stores a job that is no longer waiting to run
+func (s *Store) SaveJob(job queue.Job) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		return s.setJob(txn, job)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save job %s: %w", job.ID, err)
+	}
+
+	return nil
+}
+
+// CountQueued returns how many jobs are waiting to run
+func (s *Store) CountQueued() (int, error) {
+	count := 0
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(pendingPrefix)
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count queued jobs: %w", err)
+	}
+
+	return count, nil
+}
+
+// ClaimNext marks the queued job that runs next as running at now and returns it, or nil when none is queued
+func (s *Store) ClaimNext(now time.Time) (*queue.Job, error) {
+	var job *queue.Job
+	err := s.db.Update(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(pendingPrefix)
+		it := txn.NewIterator(opts)
+		it.Rewind()
+		if !it.Valid() {
+			it.Close()
+			return nil
+		}
+		key := it.Item().KeyCopy(nil)
+		id, err := it.Item().ValueCopy(nil)
+		it.Close()
+		if err != nil {
+			return err
+		}
+
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+		claimed, err := s.getJob(txn, string(id))
+		if err != nil {
+			return err
+		}
+		if claimed == nil {
+			s.logger.Logf("WARN queued job %s is no longer stored, dropping it", id)
+			return nil
+		}
+		claimed.Status = queue.StatusRunning
+		claimed.Attempts++
+		claimed.StartedAt = &now
+		job = claimed
+		return s.setJob(txn, *job)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim next queued job: %w", err)
+	}
+
+	return job, nil
+}
+
+// GetJob returns the job, or nil when it is not stored
+func (s *Store) GetJob(id string) (*queue.Job, error) {
+	var job *queue.Job
+	err := s.db.View(func(txn storage.Txn) error {
+		var err error
+		job, err = s.getJob(txn, id)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job %s: %w", id, err)
+	}
+
+	return job, nil
+}
+
+// ListJobs returns every stored job
+func (s *Store) ListJobs() ([]queue.Job, error) {
+	jobs := make([]queue.Job, 0)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(jobPrefix)
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var job queue.Job
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &job)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode job: %w", err)
+			}
+			jobs = append(jobs, job)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list jobs: %w", err)
+	}
+
+	return jobs, nil
+}
+
+// RequeueRunning queues the jobs left running when the server stopped again, returning how many there were
+func (s *Store) RequeueRunning() (int, error) {
+	jobs, err := s.ListJobs()
+	if err != nil {
+		return 0, err
+	}
+	requeued := 0
+	for _, job := range jobs {
+		if job.Status != queue.StatusRunning {
+			continue
+		}
+		job.Status = queue.StatusQueued
+		if err := s.SaveQueued(job); err != nil {
+			return requeued, err
+		}
+		requeued++
+	}
+	return requeued, nil
+}
+
+// DeleteFinishedBefore removes the jobs that finished before cutoff, returning how many there were
+func (s *Store) DeleteFinishedBefore(cutoff time.Time) (int, error) {
+	jobs, err := s.ListJobs()
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	err = s.db.Update(func(txn storage.Txn) error {
+		for _, job := range jobs {
+			if !job.Finished() || job.FinishedAt == nil || !job.FinishedAt.Before(cutoff) {
+				continue
+			}
+			if err := txn.Delete([]byte(jobPrefix + job.ID)); err != nil {
+				return err
+			}
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished jobs: %w", err)
+	}
+
+	return deleted, nil
+}
+
+func (s *Store) setJob(txn storage.Txn, job queue.Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return fmt.Errorf("failed to marshal job: %w", err)
+	}
+	return txn.Set([]byte(jobPrefix+job.ID), data)
+}
+
+func (s *Store) getJob(txn storage.Txn, id string) (*queue.Job, error) {
+	item, err := txn.Get([]byte(jobPrefix + id))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var job queue.Job
+	if err := item.Value(func(val []byte) error {
+		return json.Unmarshal(val, &job)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to decode job %s: %w", id, err)
+	}
+	return &job, nil
+}
+
+// buildPendingKey sorts higher priorities first, then the job queued earliest
+func (s *Store) buildPendingKey(job queue.Job) string {
+	rank := len(queue.PriorityRanks) - 1 - queue.PriorityRanks[job.Priority]
+	return fmt.Sprintf("%s%d:%020d:%s", pendingPrefix, rank, job.EnqueuedAt.UnixNano(), job.ID)
+}
@@ -0,0 +1,9 @@
+package reconciliation
+
+import "errors"
+
+// Predefined error types for yield reconciliation
+var (
+	ErrInvalidInput   = errors.New("invalid input parameters")
+	ErrNoDistribution = errors.New("no distribution to reconcile")
+)
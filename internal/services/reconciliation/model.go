@@ -0,0 +1,95 @@
+package reconciliation
+
+import (
+	"time"
+)
+
+// discrepancy kinds a report flags
+const (
+	// DiscrepancySubsidiesExceedYield is a tree paying out more than the yield allocated to the vault's epochs
+	DiscrepancySubsidiesExceedYield = "subsidies_exceed_yield"
+	// DiscrepancyClaimsExceedSubsidies is more claimed from the vault than its tree pays out
+	DiscrepancyClaimsExceedSubsidies = "claims_exceed_subsidies"
+	// DiscrepancyClaimExceedsEarned is an account that claimed more than the latest tree pays it in total
+	DiscrepancyClaimExceedsEarned = "claim_exceeds_earned"
+	// DiscrepancyEarnedDecreased is an account the latest tree pays less in total than an earlier tree did
+	DiscrepancyEarnedDecreased = "earned_decreased"
+)
+
+// MaxReports is the most reports a single listing returns
+const MaxReports = 500
+
+// Discrepancy is one amount exceeding what should cover it by more than the tolerance
+type Discrepancy struct {
+	Kind   string `json:"kind" example:"subsidies_exceed_yield"`
+	Amount string `json:"amount" example:"250"` // wei by which the amount exceeds what covers it
+	Detail string `json:"detail" example:"tree pays 1250 wei, epochs 1 to 3 were allocated 1000 wei"`
+}
+
+// Report reconciles an epoch's distribution with the yield funding it and the claims made against it.
+// Leaves are cumulative, so the tree total is compared with the yield allocated to every epoch up to
+// and including this one.
+type Report struct {
+	VaultID                  string        `json:"vaultId"`
+	EpochID                  string        `json:"epochId" example:"3"`
+	MerkleRoot               string        `json:"merkleRoot"`                // 0x-prefixed root of the reconciled tree
+	BlockNumber              uint64        `json:"blockNumber"`               // block claims were read at
+	YieldAllocated           string        `json:"yieldAllocated"`            // wei the vault allocated to the epoch
+	CumulativeYieldAllocated string        `json:"cumulativeYieldAllocated"`  // wei allocated to the epochs up to this one
+	Subsidies                string        `json:"subsidies"`                 // wei the tree's leaves add up to
+	Claimed                  string        `json:"claimed"`                   // wei claimed from the vault's subsidies so far
+	AssetsDeposited          string        `json:"assetsDeposited,omitempty"` // wei deposited in the vault when claims were read
+	Tolerance                string        `json:"tolerance"`                 // wei a discrepancy must exceed to be flagged
+	Discrepancies            []Discrepancy `json:"discrepancies"`
+	Flagged                  bool          `json:"flagged"`
+	ReconciledAt             time.Time     `json:"reconciledAt"`
+}
+
+// ReportFilter selects stored reports, zero values match everything
+type ReportFilter struct {
+	VaultID     string
+	EpochID     string
+	FlaggedOnly bool
+	Limit       int  // at most MaxReports, 0 for the default
+	Offset      int  // matching reports skipped
+	Ascending   bool // earliest epoch first instead of latest first
+}
+
+// ReportList is the stored reconciliation reports matching a filter
+type ReportList struct {
+	Reports []Report `json:"reports"` // latest epoch first unless the filter asked for the earliest
+	Total   int      `json:"total"`   // reports matching the filter, across all pages
+}
+
+// AccountDiscrepancy is one account whose cumulative total breaks what claims rely on: that it only grows and
+// that nobody claimed more of it than it pays
+type AccountDiscrepancy struct {
+	Account         string `json:"account"`
+	Kind            string `json:"kind" example:"claim_exceeds_earned"`
+	Amount          string `json:"amount" example:"250"`                  // wei claimed beyond earned, or earned lost since PreviousEpochID
+	Earned          string `json:"earned"`                                // wei the latest tree pays in total, 0 without a leaf
+	Claimed         string `json:"claimed"`                               // wei the account claimed on chain (getUserClaimedTotal)
+	PreviousEarned  string `json:"previousEarned,omitempty"`              // highest total an earlier tree paid, for earned_decreased
+	PreviousEpochID string `json:"previousEpochId,omitempty" example:"2"` // epoch of that tree
+}
+
+// ClaimsReport reconciles every account of a vault's trees with what it claimed. Accounts dropped from the
+// latest tree are checked against a total of 0, since the contract pays them nothing more.
+type ClaimsReport struct {
+	VaultID       string               `json:"vaultId"`
+	EpochID       string               `json:"epochId" example:"3"` // epoch of the latest tree
+	MerkleRoot    string               `json:"merkleRoot"`          // 0x-prefixed root of the latest tree
+	Epochs        int                  `json:"epochs"`              // trees whose accounts were checked
+	Accounts      int                  `json:"accounts"`            // accounts checked
+	Earned        string               `json:"earned"`              // wei the latest tree pays the accounts in total
+	Claimed       string               `json:"claimed"`             // wei the accounts claimed on chain
+	Tolerance     string               `json:"tolerance"`           // wei a discrepancy must exceed to be flagged
+	Discrepancies []AccountDiscrepancy `json:"discrepancies"`
+	Flagged       bool                 `json:"flagged"`
+	ReconciledAt  time.Time            `json:"reconciledAt"`
+}
+
+// ClaimsReportList is the latest claims report of every vault asked for
+type ClaimsReportList struct {
+	Reports []ClaimsReport `json:"reports"`
+}
@@ -0,0 +1,41 @@
+package reconciliation
+
+import (
+	"context"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+//go:generate moq -out reconciliation_mocks.go . Reconciler Service
+
+// Reconciler checks that a vault's distributed subsidies are covered by the yield it allocated to epochs
+type Reconciler interface {
+	// Reconcile compares the vault's latest distributed merkle tree with the yield allocated to its epochs
+	// and the subsidies claimed so far, stores the report and alerts on discrepancies above the tolerance.
+	// It returns ErrNoDistribution when the vault has no distribution yet.
+	Reconcile(ctx context.Context, vaultId string) (*Report, error)
+
+	// ReconcileClaims compares what every account of the vault's trees claimed on chain with the cumulative
+	// total the latest tree pays it, stores the report and alerts on accounts that claimed more than they
+	// earned. It returns ErrNoDistribution when the vault has no distribution yet.
+	ReconcileClaims(ctx context.Context, vaultId string) (*ClaimsReport, error)
+}
+
+// Service defines the interface for yield reconciliation reports
+type Service interface {
+	Reconciler
+
+	// Reports returns the stored reports matching filter, latest epoch first
+	Reports(ctx context.Context, filter ReportFilter) (*ReportList, error)
+
+	// ClaimsReports returns the latest claims report of vaultId, or of every vault when it is empty
+	ClaimsReports(ctx context.Context, vaultId string) (*ClaimsReportList, error)
+}
+
+// SnapshotStore reads the merkle trees distributed for a vault
+type SnapshotStore interface {
+	// GetLatestSnapshot returns the tree of the vault's latest distributed epoch, wrapping merkle.ErrNotFound when there is none
+	GetLatestSnapshot(ctx context.Context, vaultID string) (*merkle.MerkleSnapshot, error)
+	// ListSnapshots returns up to limit of the vault's trees, latest epoch first, every tree when limit is 0
+	ListSnapshots(ctx context.Context, vaultID string, limit int) ([]merkle.MerkleSnapshot, error)
+}
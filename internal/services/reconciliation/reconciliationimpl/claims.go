@@ -0,0 +1,199 @@
+package reconciliationimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// accountTotals is what the vault's trees paid an account in total
+type accountTotals struct {
+	earned       *big.Int // latest tree, 0 when it has no leaf for the account
+	highest      *big.Int // highest total an earlier tree paid
+	highestEpoch string
+}
+
+// ReconcileClaims reconciles every account of the vault's trees with what it claimed on chain. Leaves are
+// cumulative and the contract pays out the difference between an account's leaf and getUserClaimedTotal, so an
+// account that claimed more than its latest leaf was overpaid, and a leaf lower than an earlier one takes back
+// what the account may already have claimed. One claimed total is read per account.
+// An alert is sent when an account becomes flagged, not on every run that finds it still flagged.
+func (s *Service) ReconcileClaims(ctx context.Context, vaultId string) (_ *reconciliation.ClaimsReport, err error) {
+	ctx, span := tracing.StartSpan(ctx, "reconciliation.ReconcileClaims", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", reconciliation.ErrInvalidInput)
+	}
+
+	snapshots, err := s.snapshots.ListSnapshots(ctx, vaultId, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merkle snapshots: %w", err)
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("%w: vault %s", reconciliation.ErrNoDistribution, vaultId)
+	}
+	latest := snapshots[0]
+	if latest.EpochNumber == nil || latest.EpochNumber.Sign() <= 0 {
+		return nil, fmt.Errorf("latest merkle snapshot of vault %s has no epoch", vaultId)
+	}
+
+	accounts := make(map[string]*accountTotals)
+	totals := func(address string) *accountTotals {
+		account := utils.NormalizeAddress(address)
+		if accounts[account] == nil {
+			accounts[account] = &accountTotals{earned: big.NewInt(0), highest: big.NewInt(0)}
+		}
+		return accounts[account]
+	}
+	for _, entry := range latest.Entries {
+		if entry.TotalEarned != nil {
+			totals(entry.Address).earned = entry.TotalEarned
+		}
+	}
+	for _, snapshot := range snapshots[1:] {
+		for _, entry := range snapshot.Entries {
+			if entry.TotalEarned == nil {
+				continue
+			}
+			if account := totals(entry.Address); entry.TotalEarned.Cmp(account.highest) > 0 {
+				account.highest = entry.TotalEarned
+				if snapshot.EpochNumber != nil {
+					account.highestEpoch = snapshot.EpochNumber.String()
+				}
+			}
+		}
+	}
+
+	addresses := make([]string, 0, len(accounts))
+	for address := range accounts {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	report := reconciliation.ClaimsReport{
+		VaultID:       utils.NormalizeAddress(vaultId),
+		EpochID:       latest.EpochNumber.String(),
+		MerkleRoot:    "0x" + latest.MerkleRoot,
+		Epochs:        len(snapshots),
+		Accounts:      len(addresses),
+		Tolerance:     s.tolerance.String(),
+		Discrepancies: []reconciliation.AccountDiscrepancy{},
+		ReconciledAt:  s.now().UTC(),
+	}
+	claimedTotals, err := s.contractClient.GetClaimedTotals(ctx, vaultId, addresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read claimed totals: %w", err)
+	}
+	earnedTotal, claimedTotal := big.NewInt(0), big.NewInt(0)
+	for _, address := range addresses {
+		account := accounts[address]
+		claimed := claimedTotals[address]
+		if claimed == nil {
+			return nil, fmt.Errorf("no claimed total read for %s", address)
+		}
+		earnedTotal.Add(earnedTotal, account.earned)
+		claimedTotal.Add(claimedTotal, claimed)
+
+		if excess := s.excess(claimed, account.earned); excess != nil {
+			report.Discrepancies = append(report.Discrepancies, reconciliation.AccountDiscrepancy{
+				Account: address,
+				Kind:    reconciliation.DiscrepancyClaimExceedsEarned,
+				Amount:  excess.String(),
+				Earned:  account.earned.String(),
+				Claimed: claimed.String(),
+			})
+		}
+		if lost := s.excess(account.highest, account.earned); lost != nil {
+			report.Discrepancies = append(report.Discrepancies, reconciliation.AccountDiscrepancy{
+				Account:         address,
+				Kind:            reconciliation.DiscrepancyEarnedDecreased,
+				Amount:          lost.String(),
+				Earned:          account.earned.String(),
+				Claimed:         claimed.String(),
+				PreviousEarned:  account.highest.String(),
+				PreviousEpochID: account.highestEpoch,
+			})
+		}
+	}
+	report.Earned = earnedTotal.String()
+	report.Claimed = claimedTotal.String()
+	report.Flagged = len(report.Discrepancies) > 0
+
+	previous, err := s.store.GetClaimsReport(report.VaultID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveClaimsReport(report); err != nil {
+		s.logger.Logf("ERROR failed to save claims report of vault %s: %v", vaultId, err)
+		return nil, err
+	}
+
+	if !report.Flagged {
+		s.logger.Logf("INFO vault %s claims reconcile: %d accounts claimed %s of %s earned",
+			vaultId, report.Accounts, s.describe(ctx, vaultId, report.Claimed), s.describe(ctx, vaultId, report.Earned))
+		return &report, nil
+	}
+
+	type flag struct{ account, kind string }
+	flaggedBefore := make(map[flag]bool)
+	if previous != nil {
+		for _, discrepancy := range previous.Discrepancies {
+			flaggedBefore[flag{discrepancy.Account, discrepancy.Kind}] = true
+		}
+	}
+	var newly []reconciliation.AccountDiscrepancy
+	for _, discrepancy := range report.Discrepancies {
+		s.logger.Logf("WARN vault %s account %s %s by %s wei: earned %s wei, claimed %s wei",
+			vaultId, discrepancy.Account, discrepancy.Kind, discrepancy.Amount, discrepancy.Earned, discrepancy.Claimed)
+		if !flaggedBefore[flag{discrepancy.Account, discrepancy.Kind}] {
+			newly = append(newly, discrepancy)
+		}
+	}
+	if len(newly) > 0 {
+		s.notifier.Notify(ctx, webhook.EventClaimsDiscrepancy, map[string]interface{}{
+			"vaultAddress":  report.VaultID,
+			"epochId":       report.EpochID,
+			"discrepancies": newly,
+			"flagged":       len(report.Discrepancies),
+		})
+	}
+	return &report, nil
+}
+
+// ClaimsReports returns the stored claims report of vaultId, or of every vault when it is empty
+func (s *Service) ClaimsReports(ctx context.Context, vaultId string) (_ *reconciliation.ClaimsReportList, err error) {
+	_, span := tracing.StartSpan(ctx, "reconciliation.ClaimsReports")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	list := &reconciliation.ClaimsReportList{Reports: []reconciliation.ClaimsReport{}}
+	if vaultId != "" {
+		if !utils.IsValidAddress(vaultId) {
+			return nil, fmt.Errorf("%w: invalid vault address %q", reconciliation.ErrInvalidInput, vaultId)
+		}
+		report, err := s.store.GetClaimsReport(vaultId)
+		if err != nil {
+			s.logger.Logf("ERROR failed to get claims report of vault %s: %v", vaultId, err)
+			return nil, err
+		}
+		if report != nil {
+			list.Reports = append(list.Reports, *report)
+		}
+		return list, nil
+	}
+
+	if err := s.store.WalkClaimsReports(func(report reconciliation.ClaimsReport) {
+		list.Reports = append(list.Reports, report)
+	}); err != nil {
+		s.logger.Logf("ERROR failed to list claims reports: %v", err)
+		return nil, err
+	}
+	return list, nil
+}
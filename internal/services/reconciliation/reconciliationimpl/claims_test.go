@@ -0,0 +1,104 @@
+package reconciliationimpl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+// account is the address distribute gives the i-th amount
+func account(i int) string {
+	return fmt.Sprintf("0x%040x", i+1)
+}
+
+func TestService_ReconcileClaims(t *testing.T) {
+	f := newTestFixture(t, "0")
+	f.distribute(t, 1, 600, 400, 300)
+	f.distribute(t, 2, 900, 350)
+	f.chain.userClaimed = map[string]int64{account(0): 900, account(1): 400, account(2): 100}
+
+	report, err := f.service.ReconcileClaims(context.Background(), testVault)
+	require.NoError(t, err)
+	assert.Equal(t, "2", report.EpochID)
+	assert.Equal(t, 2, report.Epochs)
+	assert.Equal(t, 3, report.Accounts, "accounts dropped from the latest tree are checked")
+	assert.Equal(t, "1250", report.Earned)
+	assert.Equal(t, "1400", report.Claimed)
+	assert.True(t, report.Flagged)
+	assert.Equal(t, []reconciliation.AccountDiscrepancy{
+		{Account: account(1), Kind: reconciliation.DiscrepancyClaimExceedsEarned, Amount: "50", Earned: "350", Claimed: "400"},
+		{Account: account(1), Kind: reconciliation.DiscrepancyEarnedDecreased, Amount: "50", Earned: "350", Claimed: "400",
+			PreviousEarned: "400", PreviousEpochID: "1"},
+		{Account: account(2), Kind: reconciliation.DiscrepancyClaimExceedsEarned, Amount: "100", Earned: "0", Claimed: "100"},
+		{Account: account(2), Kind: reconciliation.DiscrepancyEarnedDecreased, Amount: "300", Earned: "0", Claimed: "100",
+			PreviousEarned: "300", PreviousEpochID: "1"},
+	}, report.Discrepancies)
+
+	list, err := f.service.ClaimsReports(context.Background(), testVault)
+	require.NoError(t, err)
+	require.Len(t, list.Reports, 1)
+	assert.Equal(t, report.Discrepancies, list.Reports[0].Discrepancies)
+}
+
+func TestService_ReconcileClaimsWithinTolerance(t *testing.T) {
+	f := newTestFixture(t, "100")
+	f.distribute(t, 1, 600, 400)
+	f.distribute(t, 2, 1000, 350)
+	f.chain.userClaimed = map[string]int64{account(0): 1000, account(1): 400}
+
+	report, err := f.service.ReconcileClaims(context.Background(), testVault)
+	require.NoError(t, err)
+	assert.False(t, report.Flagged)
+	assert.Empty(t, report.Discrepancies)
+	assert.Empty(t, f.notifier.NotifyCalls())
+}
+
+func TestService_ReconcileClaimsAlertsOncePerAccount(t *testing.T) {
+	f := newTestFixture(t, "0")
+	ctx := context.Background()
+	f.distribute(t, 1, 600, 400)
+	f.chain.userClaimed = map[string]int64{account(0): 700}
+
+	_, err := f.service.ReconcileClaims(ctx, testVault)
+	require.NoError(t, err)
+	calls := f.notifier.NotifyCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, webhook.EventClaimsDiscrepancy, calls[0].EventType)
+	require.Len(t, calls[0].Data["discrepancies"], 1)
+
+	_, err = f.service.ReconcileClaims(ctx, testVault)
+	require.NoError(t, err)
+	assert.Len(t, f.notifier.NotifyCalls(), 1, "an account still flagged is not alerted again")
+
+	// another account over-claiming alerts with only that account
+	f.chain.userClaimed[account(1)] = 500
+	_, err = f.service.ReconcileClaims(ctx, testVault)
+	require.NoError(t, err)
+	calls = f.notifier.NotifyCalls()
+	require.Len(t, calls, 2)
+	discrepancies := calls[1].Data["discrepancies"].([]reconciliation.AccountDiscrepancy)
+	require.Len(t, discrepancies, 1)
+	assert.Equal(t, account(1), discrepancies[0].Account)
+}
+
+func TestService_ReconcileClaimsWithoutDistribution(t *testing.T) {
+	f := newTestFixture(t, "0")
+
+	_, err := f.service.ReconcileClaims(context.Background(), testVault)
+	require.ErrorIs(t, err, reconciliation.ErrNoDistribution)
+
+	_, err = f.service.ReconcileClaims(context.Background(), "")
+	require.ErrorIs(t, err, reconciliation.ErrInvalidInput)
+
+	list, err := f.service.ClaimsReports(context.Background(), "")
+	require.NoError(t, err)
+	assert.Empty(t, list.Reports)
+	_, err = f.service.ClaimsReports(context.Background(), "not-an-address")
+	require.ErrorIs(t, err, reconciliation.ErrInvalidInput)
+}
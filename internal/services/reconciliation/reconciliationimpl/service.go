@@ -0,0 +1,263 @@
+package reconciliationimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultReports is how many reports a listing returns when no limit is given
+const defaultReports = 50
+
+type Service struct {
+	contractClient blockchain.BlockchainClient
+	snapshots      reconciliation.SnapshotStore
+	store          *Store
+	notifier       webhook.Notifier
+	assets         assets.Service // nil logs amounts in wei only
+	logger         lgr.L
+	tolerance      *big.Int // wei
+	now            func() time.Time
+}
+
+func New(
+	contractClient blockchain.BlockchainClient,
+	snapshots reconciliation.SnapshotStore,
+	db storage.DB,
+	notifier webhook.Notifier,
+	logger lgr.L,
+	cfg *config.Config,
+) *Service {
+	s := &Service{
+		contractClient: contractClient,
+		snapshots:      snapshots,
+		store:          NewStore(db, logger),
+		notifier:       notifier,
+		logger:         logger,
+		tolerance:      big.NewInt(0),
+		now:            time.Now,
+	}
+	// config.Load rejects malformed tolerances
+	if tolerance, ok := new(big.Int).SetString(cfg.Reconciliation.Tolerance, 10); ok {
+		s.tolerance = tolerance
+	}
+	return s
+}
+
+// SetAssets sets where vault assets are resolved from, so logged amounts include whole tokens. It is called once
+// at startup.
+func (s *Service) SetAssets(service assets.Service) {
+	s.assets = service
+}
+
+// describe returns a report's wei amount of the vault's asset for log lines
+func (s *Service) describe(ctx context.Context, vaultId, wei string) string {
+	amount, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return wei + " wei"
+	}
+	return assets.Describe(ctx, s.assets, vaultId, amount)
+}
+
+// Reconcile reconciles the vault's latest distributed epoch. Leaves hold what accounts earned in total, so the
+// tree is checked against the yield allocated to every epoch up to its own, and claims against the tree.
+// An alert is sent when an epoch's report becomes flagged, not on every run that finds it still flagged.
+func (s *Service) Reconcile(ctx context.Context, vaultId string) (_ *reconciliation.Report, err error) {
+	ctx, span := tracing.StartSpan(ctx, "reconciliation.Reconcile", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", reconciliation.ErrInvalidInput)
+	}
+
+	snapshot, err := s.snapshots.GetLatestSnapshot(ctx, vaultId)
+	if err != nil {
+		if errors.Is(err, merkle.ErrNotFound) {
+			return nil, fmt.Errorf("%w: vault %s", reconciliation.ErrNoDistribution, vaultId)
+		}
+		return nil, fmt.Errorf("failed to get latest merkle snapshot: %w", err)
+	}
+	if snapshot.EpochNumber == nil || snapshot.EpochNumber.Sign() <= 0 {
+		return nil, fmt.Errorf("latest merkle snapshot of vault %s has no epoch", vaultId)
+	}
+	epochID := snapshot.EpochNumber
+
+	subsidies := big.NewInt(0)
+	for _, entry := range snapshot.Entries {
+		if entry.TotalEarned != nil {
+			subsidies.Add(subsidies, entry.TotalEarned)
+		}
+	}
+
+	yield, cumulative, err := s.allocatedYield(ctx, vaultId, epochID)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := s.contractClient.GetOnChainEpochState(ctx, vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain subsidy state: %w", err)
+	}
+
+	report := reconciliation.Report{
+		VaultID:                  utils.NormalizeAddress(vaultId),
+		EpochID:                  epochID.String(),
+		MerkleRoot:               "0x" + snapshot.MerkleRoot,
+		BlockNumber:              state.Block.Number,
+		YieldAllocated:           yield.String(),
+		CumulativeYieldAllocated: cumulative.String(),
+		Subsidies:                subsidies.String(),
+		Claimed:                  state.TotalSubsidiesClaimed.String(),
+		Tolerance:                s.tolerance.String(),
+		Discrepancies:            []reconciliation.Discrepancy{},
+		ReconciledAt:             s.now().UTC(),
+	}
+	if excess := s.excess(subsidies, cumulative); excess != nil {
+		report.Discrepancies = append(report.Discrepancies, reconciliation.Discrepancy{
+			Kind:   reconciliation.DiscrepancySubsidiesExceedYield,
+			Amount: excess.String(),
+			Detail: fmt.Sprintf("tree pays %s wei, epochs 1 to %s were allocated %s wei", subsidies, epochID, cumulative),
+		})
+	}
+	if excess := s.excess(state.TotalSubsidiesClaimed, subsidies); excess != nil {
+		report.Discrepancies = append(report.Discrepancies, reconciliation.Discrepancy{
+			Kind:   reconciliation.DiscrepancyClaimsExceedSubsidies,
+			Amount: excess.String(),
+			Detail: fmt.Sprintf("%s wei claimed, tree pays %s wei", state.TotalSubsidiesClaimed, subsidies),
+		})
+	}
+	report.Flagged = len(report.Discrepancies) > 0
+	if state.TotalAssetsDeposited != nil {
+		report.AssetsDeposited = state.TotalAssetsDeposited.String()
+	}
+
+	previous, err := s.store.GetReport(report.VaultID, report.EpochID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.SaveReport(report); err != nil {
+		s.logger.Logf("ERROR failed to save reconciliation report of vault %s epoch %s: %v", vaultId, report.EpochID, err)
+		return nil, err
+	}
+
+	if !report.Flagged {
+		s.logger.Logf("INFO vault %s epoch %s reconciles: %s distributed, %s allocated, %s claimed", vaultId, report.EpochID,
+			s.describe(ctx, vaultId, report.Subsidies), s.describe(ctx, vaultId, report.CumulativeYieldAllocated),
+			s.describe(ctx, vaultId, report.Claimed))
+		return &report, nil
+	}
+
+	for _, discrepancy := range report.Discrepancies {
+		s.logger.Logf("WARN vault %s epoch %s %s by %s wei: %s",
+			vaultId, report.EpochID, discrepancy.Kind, discrepancy.Amount, discrepancy.Detail)
+	}
+	if previous == nil || !previous.Flagged {
+		kinds := make([]string, len(report.Discrepancies))
+		for i, discrepancy := range report.Discrepancies {
+			kinds[i] = discrepancy.Kind
+		}
+		s.notifier.Notify(ctx, webhook.EventYieldDiscrepancy, map[string]interface{}{
+			"vaultAddress":             report.VaultID,
+			"epochId":                  report.EpochID,
+			"discrepancies":            kinds,
+			"subsidies":                report.Subsidies,
+			"cumulativeYieldAllocated": report.CumulativeYieldAllocated,
+			"claimed":                  report.Claimed,
+		})
+	}
+	return &report, nil
+}
+
+// allocatedYield returns the yield the vault allocated to epochID and to every epoch up to it
+func (s *Service) allocatedYield(ctx context.Context, vaultId string, epochID *big.Int) (*big.Int, *big.Int, error) {
+	cumulative := big.NewInt(0)
+	var yield *big.Int
+	for epoch := big.NewInt(1); epoch.Cmp(epochID) <= 0; epoch = new(big.Int).Add(epoch, big.NewInt(1)) {
+		allocated, err := s.contractClient.GetEpochYieldAllocated(ctx, epoch, vaultId)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read yield allocated to epoch %s: %w", epoch, err)
+		}
+		cumulative.Add(cumulative, allocated)
+		yield = allocated
+	}
+	return yield, cumulative, nil
+}
+
+// excess returns how much amount exceeds covered by, nil unless that is more than the tolerance
+func (s *Service) excess(amount, covered *big.Int) *big.Int {
+	excess := new(big.Int).Sub(amount, covered)
+	if excess.Cmp(s.tolerance) <= 0 {
+		return nil
+	}
+	return excess
+}
+
+// Reports returns a page of the stored reports matching filter, latest epoch first unless filter.Ascending is set
+func (s *Service) Reports(ctx context.Context, filter reconciliation.ReportFilter) (_ *reconciliation.ReportList, err error) {
+	_, span := tracing.StartSpan(ctx, "reconciliation.Reports")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if filter.Limit < 0 || filter.Limit > reconciliation.MaxReports {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d, got %d",
+			reconciliation.ErrInvalidInput, reconciliation.MaxReports, filter.Limit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative, got %d", reconciliation.ErrInvalidInput, filter.Offset)
+	}
+	if filter.Limit == 0 {
+		filter.Limit = defaultReports
+	}
+	if filter.VaultID != "" && !utils.IsValidAddress(filter.VaultID) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", reconciliation.ErrInvalidInput, filter.VaultID)
+	}
+
+	list := &reconciliation.ReportList{Reports: []reconciliation.Report{}}
+	err = s.store.WalkReports(filter.VaultID, func(report reconciliation.Report) {
+		if filter.EpochID != "" && report.EpochID != filter.EpochID {
+			return
+		}
+		if filter.FlaggedOnly && !report.Flagged {
+			return
+		}
+		list.Reports = append(list.Reports, report)
+	})
+	if err != nil {
+		s.logger.Logf("ERROR failed to list reconciliation reports: %v", err)
+		return nil, err
+	}
+
+	sort.SliceStable(list.Reports, func(i, j int) bool {
+		if filter.Ascending {
+			return epochAfter(list.Reports[j].EpochID, list.Reports[i].EpochID)
+		}
+		return epochAfter(list.Reports[i].EpochID, list.Reports[j].EpochID)
+	})
+	list.Total = len(list.Reports)
+	list.Reports = list.Reports[min(filter.Offset, list.Total):min(filter.Offset+filter.Limit, list.Total)]
+	return list, nil
+}
+
+// epochAfter orders epoch IDs numerically, latest first
+func epochAfter(a, b string) bool {
+	x, okA := new(big.Int).SetString(a, 10)
+	y, okB := new(big.Int).SetString(b, 10)
+	if !okA || !okB {
+		return a > b
+	}
+	return x.Cmp(y) > 0
+}
@@ -0,0 +1,250 @@
+package reconciliationimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+const testVault = "0x1234567890123456789012345678901234567890"
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+// testChain allocates yield[i] to epoch i+1, reports claimed as claimed so far and each account's
+// userClaimed as what it claimed
+type testChain struct {
+	yield       []int64
+	claimed     int64
+	userClaimed map[string]int64
+}
+
+func (c *testChain) client() *blockchain.BlockchainClientMock {
+	return &blockchain.BlockchainClientMock{
+		GetEpochYieldAllocatedFunc: func(ctx context.Context, epochId *big.Int, vaultAddress string) (*big.Int, error) {
+			if i := int(epochId.Int64()) - 1; i < len(c.yield) {
+				return big.NewInt(c.yield[i]), nil
+			}
+			return big.NewInt(0), nil
+		},
+		GetOnChainEpochStateFunc: func(ctx context.Context, vaultAddress string) (*blockchain.OnChainEpochState, error) {
+			return &blockchain.OnChainEpochState{
+				Block:                 blockchain.BlockRef{Number: 120, Hash: "0xa"},
+				TotalSubsidiesClaimed: big.NewInt(c.claimed),
+				TotalAssetsDeposited:  big.NewInt(50000),
+			}, nil
+		},
+		GetClaimedTotalsFunc: func(ctx context.Context, vaultId string, users []string) (map[string]*big.Int, error) {
+			claimed := make(map[string]*big.Int, len(users))
+			for _, user := range users {
+				claimed[user] = big.NewInt(c.userClaimed[user])
+			}
+			return claimed, nil
+		},
+	}
+}
+
+type testFixture struct {
+	service  *Service
+	merkle   *merkleimpl.Service
+	chain    *testChain
+	notifier *webhook.NotifierMock
+}
+
+func newTestFixture(t *testing.T, tolerance string) *testFixture {
+	t.Helper()
+
+	db := newTestDB(t)
+	cfg := &config.Config{}
+	cfg.Reconciliation.Tolerance = tolerance
+	f := &testFixture{
+		merkle:   merkleimpl.New(db, nil, nil, lgr.NoOp),
+		chain:    &testChain{},
+		notifier: &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}},
+	}
+	f.service = New(f.chain.client(), f.merkle, db, f.notifier, lgr.NoOp, cfg)
+	return f
+}
+
+// distribute saves a tree paying the amounts as the epoch's distribution
+func (f *testFixture) distribute(t *testing.T, epoch int64, amounts ...int64) {
+	t.Helper()
+
+	entries := make([]merkle.MerkleEntry, len(amounts))
+	for i, amount := range amounts {
+		entries[i] = merkle.MerkleEntry{Address: fmt.Sprintf("0x%040x", i+1), TotalEarned: big.NewInt(amount)}
+	}
+	require.NoError(t, f.merkle.SaveSnapshot(context.Background(), big.NewInt(epoch), merkle.MerkleSnapshot{
+		Entries:     entries,
+		MerkleRoot:  "ab",
+		VaultID:     testVault,
+		BlockNumber: 100,
+	}))
+}
+
+func TestService_ReconcileBalanced(t *testing.T) {
+	f := newTestFixture(t, "0")
+	f.distribute(t, 1, 600, 400)
+	f.distribute(t, 2, 1500, 900)
+	f.chain.yield = []int64{1000, 1400}
+	f.chain.claimed = 1000
+
+	report, err := f.service.Reconcile(context.Background(), testVault)
+	require.NoError(t, err)
+	assert.Equal(t, "2", report.EpochID)
+	assert.Equal(t, "1400", report.YieldAllocated)
+	assert.Equal(t, "2400", report.CumulativeYieldAllocated, "leaves are cumulative, so every epoch's yield covers them")
+	assert.Equal(t, "2400", report.Subsidies)
+	assert.Equal(t, "1000", report.Claimed)
+	assert.Equal(t, "50000", report.AssetsDeposited)
+	assert.Equal(t, uint64(120), report.BlockNumber)
+	assert.False(t, report.Flagged)
+	assert.Empty(t, report.Discrepancies)
+	assert.Empty(t, f.notifier.NotifyCalls())
+}
+
+func TestService_ReconcileFlagsDiscrepancies(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance string
+		yield     []int64
+		claimed   int64
+		kinds     []string
+	}{
+		{name: "subsidies_exceed_yield", tolerance: "0", yield: []int64{900}, claimed: 0,
+			kinds: []string{reconciliation.DiscrepancySubsidiesExceedYield}},
+		{name: "claims_exceed_subsidies", tolerance: "0", yield: []int64{1000}, claimed: 1001,
+			kinds: []string{reconciliation.DiscrepancyClaimsExceedSubsidies}},
+		{name: "both", tolerance: "0", yield: []int64{500}, claimed: 2000,
+			kinds: []string{reconciliation.DiscrepancySubsidiesExceedYield, reconciliation.DiscrepancyClaimsExceedSubsidies}},
+		{name: "within_tolerance", tolerance: "100", yield: []int64{900}, claimed: 1100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTestFixture(t, tt.tolerance)
+			f.distribute(t, 1, 600, 400)
+			f.chain.yield, f.chain.claimed = tt.yield, tt.claimed
+
+			report, err := f.service.Reconcile(context.Background(), testVault)
+			require.NoError(t, err)
+			kinds := []string{}
+			for _, discrepancy := range report.Discrepancies {
+				kinds = append(kinds, discrepancy.Kind)
+			}
+			if tt.kinds == nil {
+				tt.kinds = []string{}
+			}
+			assert.Equal(t, tt.kinds, kinds)
+			assert.Equal(t, len(tt.kinds) > 0, report.Flagged)
+		})
+	}
+}
+
+func TestService_ReconcileAlertsOncePerEpoch(t *testing.T) {
+	f := newTestFixture(t, "0")
+	f.distribute(t, 1, 1000)
+	f.chain.yield = []int64{900}
+	ctx := context.Background()
+
+	_, err := f.service.Reconcile(ctx, testVault)
+	require.NoError(t, err)
+	calls := f.notifier.NotifyCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, webhook.EventYieldDiscrepancy, calls[0].EventType)
+	assert.Equal(t, "1", calls[0].Data["epochId"])
+
+	_, err = f.service.Reconcile(ctx, testVault)
+	require.NoError(t, err)
+	assert.Len(t, f.notifier.NotifyCalls(), 1, "an epoch still flagged is not alerted again")
+
+	// once the epoch reconciles, a new discrepancy alerts again
+	f.chain.yield = []int64{1000}
+	_, err = f.service.Reconcile(ctx, testVault)
+	require.NoError(t, err)
+	f.chain.claimed = 1500
+	_, err = f.service.Reconcile(ctx, testVault)
+	require.NoError(t, err)
+	assert.Len(t, f.notifier.NotifyCalls(), 2)
+}
+
+func TestService_ReconcileWithoutDistribution(t *testing.T) {
+	f := newTestFixture(t, "0")
+
+	_, err := f.service.Reconcile(context.Background(), testVault)
+	require.ErrorIs(t, err, reconciliation.ErrNoDistribution)
+
+	_, err = f.service.Reconcile(context.Background(), "")
+	require.ErrorIs(t, err, reconciliation.ErrInvalidInput)
+}
+
+func TestService_Reports(t *testing.T) {
+	f := newTestFixture(t, "0")
+	ctx := context.Background()
+	f.chain.yield = []int64{1000, 1000, 1000}
+	for _, epoch := range []struct{ id, total int64 }{{1, 1000}, {2, 2500}, {10, 2000}} {
+		f.distribute(t, epoch.id, epoch.total)
+		_, err := f.service.Reconcile(ctx, testVault)
+		require.NoError(t, err)
+	}
+
+	list, err := f.service.Reports(ctx, reconciliation.ReportFilter{})
+	require.NoError(t, err)
+	require.Len(t, list.Reports, 3)
+	assert.Equal(t, []string{"10", "2", "1"},
+		[]string{list.Reports[0].EpochID, list.Reports[1].EpochID, list.Reports[2].EpochID}, "latest epoch first")
+
+	flagged, err := f.service.Reports(ctx, reconciliation.ReportFilter{FlaggedOnly: true})
+	require.NoError(t, err)
+	require.Len(t, flagged.Reports, 1)
+	assert.Equal(t, "2", flagged.Reports[0].EpochID)
+
+	byEpoch, err := f.service.Reports(ctx, reconciliation.ReportFilter{VaultID: testVault, EpochID: "1"})
+	require.NoError(t, err)
+	require.Len(t, byEpoch.Reports, 1)
+
+	limited, err := f.service.Reports(ctx, reconciliation.ReportFilter{Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, limited.Reports, 1)
+	assert.Equal(t, "10", limited.Reports[0].EpochID)
+	assert.Equal(t, 3, limited.Total)
+
+	earliest, err := f.service.Reports(ctx, reconciliation.ReportFilter{Limit: 2, Offset: 1, Ascending: true})
+	require.NoError(t, err)
+	require.Len(t, earliest.Reports, 2)
+	assert.Equal(t, "2", earliest.Reports[0].EpochID)
+	assert.Equal(t, "10", earliest.Reports[1].EpochID)
+
+	past, err := f.service.Reports(ctx, reconciliation.ReportFilter{Offset: 5})
+	require.NoError(t, err)
+	assert.Empty(t, past.Reports)
+	assert.Equal(t, 3, past.Total)
+
+	_, err = f.service.Reports(ctx, reconciliation.ReportFilter{Limit: reconciliation.MaxReports + 1})
+	require.ErrorIs(t, err, reconciliation.ErrInvalidInput)
+	_, err = f.service.Reports(ctx, reconciliation.ReportFilter{VaultID: "not-an-address"})
+	require.ErrorIs(t, err, reconciliation.ErrInvalidInput)
+}
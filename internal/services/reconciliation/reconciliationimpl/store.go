@@ -0,0 +1,188 @@
+package reconciliationimpl
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	reportPrefix       = "reconciliation:report:"
+	claimsReportPrefix = "reconciliation:claims:"
+)
+
+// Store keeps the latest reconciliation report of every vault's epochs and the latest claims report of every vault
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveReport replaces the report of the report's vault and epoch
+func (s *Store) SaveReport(report reconciliation.Report) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal reconciliation report: %w", err)
+	}
+
+	key := []byte(s.buildReportKey(report.VaultID, report.EpochID))
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set(key, data)
+	}); err != nil {
+		return fmt.Errorf("failed to save reconciliation report: %w", err)
+	}
+
+	return nil
+}
+
+// GetReport returns the report of the vault's epoch, nil when it was never reconciled
+func (s *Store) GetReport(vaultID, epochID string) (*reconciliation.Report, error) {
+	var report *reconciliation.Report
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildReportKey(vaultID, epochID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			report = &reconciliation.Report{}
+			return json.Unmarshal(val, report)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get reconciliation report: %w", err)
+	}
+
+	return report, nil
+}
+
+// WalkReports calls fn with every report of vaultID, or of every vault when vaultID is empty
+func (s *Store) WalkReports(vaultID string, fn func(reconciliation.Report)) error {
+	prefix := reportPrefix
+	if vaultID != "" {
+		prefix = s.buildVaultPrefix(vaultID)
+	}
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(prefix)
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var report reconciliation.Report
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &report)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode reconciliation report: %w", err)
+			}
+			fn(report)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list reconciliation reports: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) buildVaultPrefix(vaultID string) string {
+	return fmt.Sprintf("%svault:%s:", reportPrefix, utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildReportKey(vaultID, epochID string) string {
+	return fmt.Sprintf("%sepoch:%020s", s.buildVaultPrefix(vaultID), epochID)
+}
+
+// SaveClaimsReport replaces the claims report of the report's vault
+func (s *Store) SaveClaimsReport(report reconciliation.ClaimsReport) error {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal claims report: %w", err)
+	}
+
+	key := []byte(s.buildClaimsReportKey(report.VaultID))
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set(key, data)
+	}); err != nil {
+		return fmt.Errorf("failed to save claims report: %w", err)
+	}
+
+	return nil
+}
+
+// GetClaimsReport returns the claims report of the vault, nil when its claims were never reconciled
+func (s *Store) GetClaimsReport(vaultID string) (*reconciliation.ClaimsReport, error) {
+	var report *reconciliation.ClaimsReport
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildClaimsReportKey(vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			report = &reconciliation.ClaimsReport{}
+			return json.Unmarshal(val, report)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get claims report: %w", err)
+	}
+
+	return report, nil
+}
+
+// WalkClaimsReports calls fn with the claims report of every vault
+func (s *Store) WalkClaimsReports(fn func(reconciliation.ClaimsReport)) error {
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(claimsReportPrefix)
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var report reconciliation.ClaimsReport
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &report)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode claims report: %w", err)
+			}
+			fn(report)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list claims reports: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) buildClaimsReportKey(vaultID string) string {
+	return fmt.Sprintf("%svault:%s", claimsReportPrefix, utils.NormalizeAddress(vaultID))
+}
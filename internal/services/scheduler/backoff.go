@@ -0,0 +1,124 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+// backoffJitter is the fraction a retry delay is randomly lengthened or shortened by, so replicas and tenants
+// failing on the same dependency do not retry in step
+const backoffJitter = 0.2
+
+// jobBackoff is the failure streak of a job
+type jobBackoff struct {
+	failures  int
+	lastError error
+	retryAt   time.Time // scheduled boundaries skip the job before this
+	suspended bool      // failed SCHEDULER_MAX_RETRIES times in a row, attempted once the health check passes
+}
+
+// SetNotifier sets where scheduler alerts are sent. It is called once at startup, before Start.
+func (s *Scheduler) SetNotifier(notifier webhook.Notifier) {
+	s.notifier = notifier
+}
+
+// SetHealthCheck sets the check of the dependencies jobs need, a job suspended after repeated failures is
+// attempted again once it passes. Without one suspended jobs keep being retried at the longest backoff.
+// It is called once at startup, before Start.
+func (s *Scheduler) SetHealthCheck(check func(ctx context.Context) error) {
+	s.healthCheck = check
+}
+
+// backingOff returns why a failing job is skipped this boundary, nil when it is attempted
+func (s *Scheduler) backingOff(ctx context.Context, job string) error {
+	b, ok := s.backoffs[job]
+	if !ok {
+		return nil
+	}
+	if b.suspended && s.healthCheck != nil {
+		if err := s.healthCheck(ctx); err != nil {
+			return fmt.Errorf("%w after %d failures, dependencies unhealthy: %v", errBackingOff, b.failures, err)
+		}
+		logging.FromContext(ctx, s.logger).Logf("INFO dependencies healthy again, resuming %s after %d failures", job, b.failures)
+		return nil
+	}
+	if s.now().Before(b.retryAt) {
+		return fmt.Errorf("%w until %s after %d failures", errBackingOff, b.retryAt.Format(time.RFC3339), b.failures)
+	}
+	return nil
+}
+
+// skipBackingOff records the job as skipped when it is backing off, reporting whether it was
+func (s *Scheduler) skipBackingOff(ctx context.Context, job string) bool {
+	err := s.backingOff(ctx, job)
+	if err == nil {
+		return false
+	}
+	logging.FromContext(ctx, s.logger).Logf("INFO %s %v, skipping", job, err)
+	s.recordRun(ctx, job, s.now(), jobs.OutcomeSkipped, err)
+	return true
+}
+
+// jobFailed extends the job's failure streak and schedules its next attempt. The failure reaching
+// SCHEDULER_MAX_RETRIES sends scheduler.job_failing and suspends the job.
+func (s *Scheduler) jobFailed(ctx context.Context, job string, err error) {
+	if s.config.Scheduler.RetryBackoff <= 0 {
+		return
+	}
+	b, ok := s.backoffs[job]
+	if !ok {
+		b = &jobBackoff{}
+		s.backoffs[job] = b
+	}
+	b.failures++
+	b.lastError = err
+	b.retryAt = s.now().Add(s.retryDelay(b.failures))
+
+	maxRetries := s.config.Scheduler.MaxRetries
+	if maxRetries <= 0 || b.failures < maxRetries || b.suspended {
+		return
+	}
+	b.suspended = true
+	logging.FromContext(ctx, s.logger).Logf("ERROR %s failed %d times in a row, suspended until dependencies are healthy: %v",
+		job, b.failures, err)
+	if s.notifier != nil {
+		s.notifier.Notify(ctx, webhook.EventJobFailing, map[string]interface{}{
+			"job":       job,
+			"failures":  b.failures,
+			"lastError": err.Error(),
+		})
+	}
+}
+
+// jobSucceeded ends the job's failure streak
+func (s *Scheduler) jobSucceeded(ctx context.Context, job string) {
+	if b, ok := s.backoffs[job]; ok {
+		logging.FromContext(ctx, s.logger).Logf("INFO %s recovered after %d failures", job, b.failures)
+		delete(s.backoffs, job)
+	}
+}
+
+// retryDelay returns how long a job is not attempted after its failures-th consecutive failure: the base
+// backoff doubled after each failure, at most the longest backoff, with jitter
+func (s *Scheduler) retryDelay(failures int) time.Duration {
+	base, longest := s.config.Scheduler.RetryBackoff, s.config.Scheduler.RetryBackoffMax
+	delay := base
+	for i := 1; i < failures && (longest <= 0 || delay < longest); i++ {
+		delay *= 2
+	}
+	if longest > 0 && delay > longest {
+		delay = longest
+	}
+	return time.Duration(float64(delay) * (1 + backoffJitter*(2*s.random()-1)))
+}
+
+// randomFloat is the default source of jitter
+func randomFloat() float64 {
+	return rand.Float64()
+}
@@ -0,0 +1,115 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduler_BacksOffFailingJobs(t *testing.T) {
+	startErr := fmt.Errorf("rpc unavailable")
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			if startErr != nil {
+				return nil, startErr
+			}
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	cfg.Scheduler.RetryBackoff = time.Minute
+	cfg.Scheduler.RetryBackoffMax = 4 * time.Minute
+	cfg.Scheduler.MaxRetries = 3
+	s := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, time.Minute, lgr.NoOp, cfg)
+	s.caughtUp = true
+	s.random = func() float64 { return 0.5 } // no jitter
+	healthErr := fmt.Errorf("subgraph down")
+	s.SetHealthCheck(func(ctx context.Context) error { return healthErr })
+	s.SetNotifier(notifier)
+
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	cycle := func(after time.Duration) {
+		now = now.Add(after)
+		s.runEpochCycle(context.Background())
+	}
+
+	cycle(0)
+	require.Len(t, mockEpochService.StartEpochCalls(), 1)
+
+	cycle(30 * time.Second)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1, "backing off for a minute")
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 2, "other jobs keep running")
+	last := mockRuns.RecordRunCalls()
+	skipped := last[len(last)-2].Run
+	assert.Equal(t, pause.JobStartEpoch, skipped.Job)
+	assert.Equal(t, jobs.OutcomeSkipped, skipped.Outcome)
+	assert.Contains(t, skipped.Error, "backing off until 2026-10-15T12:01:00Z after 1 failures")
+
+	cycle(30 * time.Second)
+	require.Len(t, mockEpochService.StartEpochCalls(), 2)
+	cycle(time.Minute)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 2, "the backoff doubled")
+	cycle(time.Minute)
+	require.Len(t, mockEpochService.StartEpochCalls(), 3)
+	require.Len(t, notifier.NotifyCalls(), 1, "the third failure in a row alerts")
+	assert.Equal(t, webhook.EventJobFailing, notifier.NotifyCalls()[0].EventType)
+	assert.Equal(t, pause.JobStartEpoch, notifier.NotifyCalls()[0].Data["job"])
+	assert.Equal(t, 3, notifier.NotifyCalls()[0].Data["failures"])
+
+	cycle(time.Hour)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 3, "suspended while dependencies are unhealthy")
+
+	// a triggered boundary runs the job regardless, and its failure does not alert again
+	require.Error(t, s.runBoundary(context.Background(), false))
+	assert.Len(t, mockEpochService.StartEpochCalls(), 4)
+	assert.Len(t, notifier.NotifyCalls(), 1)
+
+	healthErr, startErr = nil, nil
+	cycle(time.Minute)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 5, "resumed once dependencies are healthy")
+	assert.Empty(t, s.backoffs, "the streak ended")
+	cycle(time.Minute)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 6)
+}
+
+func TestScheduler_RetryDelay(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Scheduler.RetryBackoff = time.Minute
+	cfg.Scheduler.RetryBackoffMax = 10 * time.Minute
+	s := NewScheduler(nil, nil, nil, nil, nil, nil, nil, nil, time.Minute, lgr.NoOp, cfg)
+
+	s.random = func() float64 { return 0.5 }
+	assert.Equal(t, time.Minute, s.retryDelay(1))
+	assert.Equal(t, 2*time.Minute, s.retryDelay(2))
+	assert.Equal(t, 8*time.Minute, s.retryDelay(4))
+	assert.Equal(t, 10*time.Minute, s.retryDelay(5))
+	assert.Equal(t, 10*time.Minute, s.retryDelay(100), "no overflow on long streaks")
+
+	s.random = func() float64 { return 0 }
+	assert.Equal(t, 48*time.Second, s.retryDelay(1))
+	s.random = func() float64 { return 0.999 }
+	assert.InDelta(t, float64(72*time.Second), float64(s.retryDelay(1)), float64(time.Second))
+}
@@ -0,0 +1,194 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const (
+	// catchUpLookback is how many of the most recent epochs are checked for ones that ended unprocessed
+	catchUpLookback = 100
+
+	// epochStatusCompleted is the subgraph status of an epoch whose processing finished
+	epochStatusCompleted = "COMPLETED"
+)
+
+// missedEpoch is an epoch whose end passed without it being processed
+type missedEpoch struct {
+	id      uint64
+	endedAt time.Time
+	current bool // the epoch the contract still reports as current
+}
+
+// catchUp processes epochs that ended while no scheduler was running, oldest first. The current epoch
+// gets its subsidies distributed, older ones the contract has moved past are force ended, and once all
+// are closed a new epoch is started. It reports whether any epoch was missed, in which case the catch-up
+// replaces this cycle's regular run. A failed catch-up is retried on the next cycle, as is one that
+// reaches a paused job. While catch-up itself is paused, or the vault is decommissioned, the regular cycle
+// runs instead.
+func (s *Scheduler) catchUp(ctx context.Context) bool {
+	logger := logging.FromContext(ctx, s.logger)
+
+	if s.paused(ctx, pause.JobCatchUp) {
+		logger.Logf("INFO catch-up paused, skipping")
+		s.recordRun(ctx, pause.JobCatchUp, s.now(), jobs.OutcomeSkipped, errJobPaused)
+		return false
+	}
+
+	if s.decommissioned(ctx, s.config.Contracts.CollectionsVault) {
+		logger.Logf("INFO vault decommissioned, skipping catch-up")
+		s.recordRun(ctx, pause.JobCatchUp, s.now(), jobs.OutcomeSkipped, errVaultDecommissioned)
+		return false
+	}
+
+	limit := s.config.Scheduler.CatchUpLimit
+	if limit <= 0 {
+		s.caughtUp = true
+		return false
+	}
+
+	missed, err := s.findMissedEpochs(ctx)
+	if err != nil {
+		logger.Logf("WARN failed to check for missed epochs, retrying next cycle: %v", err)
+		return false
+	}
+	if len(missed) == 0 {
+		s.caughtUp = true
+		return false
+	}
+
+	// a catch-up with epochs to process is recorded as a run, however far it gets
+	started := s.now()
+	outcome, reason := jobs.OutcomeSucceeded, error(nil)
+	defer func() { s.recordRun(ctx, pause.JobCatchUp, started, outcome, reason) }()
+
+	newest := missed[len(missed)-1]
+	overdue := s.now().Sub(newest.endedAt)
+	cycles := 1
+	if s.interval > 0 {
+		cycles += int(overdue / s.interval)
+	}
+	logger.Logf("WARN found %d missed epochs, epoch %d ended %s ago (%d scheduled cycles missed)",
+		len(missed), newest.id, overdue.Round(time.Second), cycles)
+	truncated := len(missed) > limit
+	if truncated {
+		logger.Logf("WARN catching up on the oldest %d of %d missed epochs, the rest follow next cycle", limit, len(missed))
+		missed = missed[:limit]
+	}
+
+	vaultId := s.config.Contracts.CollectionsVault
+	for _, epoch := range missed {
+		if !epoch.current {
+			if _, err := s.epochService.ForceEndEpoch(ctx, epoch.id, vaultId); err != nil {
+				logger.Logf("ERROR catch-up failed to force end missed epoch %d, retrying next cycle: %v", epoch.id, err)
+				outcome, reason = jobs.OutcomeFailed, fmt.Errorf("failed to force end missed epoch %d: %w", epoch.id, err)
+				return true
+			}
+			logger.Logf("INFO catch-up closed missed epoch %d", epoch.id)
+			continue
+		}
+
+		if s.paused(ctx, pause.JobDistribute) {
+			logger.Logf("INFO subsidy distribution paused, catch-up of missed epoch %d resumes with it", epoch.id)
+			outcome, reason = jobs.OutcomeSkipped, fmt.Errorf("%w, missed epoch %d waits", errJobPaused, epoch.id)
+			return true
+		}
+		if s.contractPaused(ctx) {
+			logger.Logf("WARN DebtSubsidizer paused, catch-up of missed epoch %d resumes once it is unpaused", epoch.id)
+			outcome, reason = jobs.OutcomeSkipped, fmt.Errorf("%w, missed epoch %d waits", errContractPaused, epoch.id)
+			return true
+		}
+		if running := s.runningEpoch(ctx); running != nil {
+			// the subgraph's end passed by the server's clock, not yet by the chain's
+			logger.Logf("WARN epoch %d ends on-chain at %s, it was not missed", epoch.id, endOf(running))
+			outcome, reason = jobs.OutcomeSkipped, notEnded(running)
+			s.caughtUp = true
+			return true
+		}
+		response, err := s.subsidyService.DistributeSubsidies(ctx, vaultId)
+		if errors.Is(err, subsidy.ErrSubgraphLagging) {
+			logger.Logf("WARN catch-up of missed epoch %d waits for the subgraph: %v", epoch.id, err)
+			outcome, reason = jobs.OutcomeSkipped, fmt.Errorf("missed epoch %d waits: %w", epoch.id, err)
+			return true
+		}
+		if err != nil {
+			logger.Logf("ERROR catch-up failed to distribute subsidies for missed epoch %d, retrying next cycle: %v", epoch.id, err)
+			outcome, reason = jobs.OutcomeFailed, fmt.Errorf("failed to distribute subsidies for missed epoch %d: %w", epoch.id, err)
+			return true
+		}
+		if response.Status == subsidy.StagedPendingApproval {
+			// the epoch stays open until the distribution is approved, so no new epoch can start yet
+			logger.Logf("INFO catch-up distribution for missed epoch %d awaits approval as %s", epoch.id, response.StagedID)
+			s.caughtUp = true
+			return true
+		}
+		logger.Logf("INFO catch-up distributed subsidies for missed epoch %d", epoch.id)
+	}
+	if truncated {
+		return true
+	}
+	if !newest.current {
+		// the current epoch is still running, only older epochs needed closing
+		s.caughtUp = true
+		return true
+	}
+
+	if s.paused(ctx, pause.JobStartEpoch) {
+		logger.Logf("INFO epoch start paused, catch-up starts a new epoch once it is resumed")
+		outcome, reason = jobs.OutcomeSkipped, fmt.Errorf("%w, the new epoch waits", errJobPaused)
+		return true
+	}
+	response, err := s.epochService.StartEpoch(ctx)
+	if err != nil {
+		logger.Logf("ERROR catch-up failed to start a new epoch, retrying next cycle: %v", err)
+		outcome, reason = jobs.OutcomeFailed, fmt.Errorf("failed to start a new epoch: %w", err)
+		return true
+	}
+	logger.Logf("INFO catch-up finished, started epoch %s", response.EpochID)
+	s.caughtUp = true
+	return true
+}
+
+// findMissedEpochs compares the contract's current epoch with the subgraph's epoch timing and returns
+// the epochs up to the current one that have ended but were not completed, oldest first
+func (s *Scheduler) findMissedEpochs(ctx context.Context) ([]missedEpoch, error) {
+	currentID, err := s.epochService.GetCurrentEpochId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if currentID == 0 {
+		return nil, nil
+	}
+
+	epochs, err := s.epochService.ListEpochs(ctx, epoch.ListEpochsQuery{Limit: catchUpLookback})
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	var missed []missedEpoch
+	for _, summary := range epochs.Epochs {
+		id, err := strconv.ParseUint(summary.EpochNumber, 10, 64)
+		if err != nil || id > currentID || summary.Status == epochStatusCompleted {
+			continue
+		}
+		end, err := strconv.ParseInt(summary.EndTimestamp, 10, 64)
+		if err != nil || end <= 0 || time.Unix(end, 0).After(now) {
+			continue
+		}
+		missed = append(missed, missedEpoch{id: id, endedAt: time.Unix(end, 0), current: id == currentID})
+	}
+
+	sort.Slice(missed, func(i, j int) bool { return missed[i].id < missed[j].id })
+	return missed, nil
+}
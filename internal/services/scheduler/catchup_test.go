@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// catchUpFixture is a chain at epoch current whose epochs are summarized by the subgraph as epochs
+type catchUpFixture struct {
+	current  uint64
+	epochs   []epoch.EpochSummary
+	calls    []string
+	epochSvc *epoch.ServiceMock
+	subsidy  *subsidy.ServiceMock
+}
+
+func newCatchUpFixture(current uint64, epochs ...epoch.EpochSummary) *catchUpFixture {
+	f := &catchUpFixture{current: current, epochs: epochs}
+	f.epochSvc = &epoch.ServiceMock{
+		GetCurrentEpochIdFunc: func(ctx context.Context) (uint64, error) {
+			return f.current, nil
+		},
+		ListEpochsFunc: func(ctx context.Context, query epoch.ListEpochsQuery) (*epoch.ListEpochsResponse, error) {
+			return &epoch.ListEpochsResponse{Epochs: f.epochs, Count: len(f.epochs)}, nil
+		},
+		ForceEndEpochFunc: func(ctx context.Context, epochId uint64, vaultId string) (*epoch.ForceEndEpochResponse, error) {
+			f.calls = append(f.calls, fmt.Sprintf("force-end %d", epochId))
+			return &epoch.ForceEndEpochResponse{Status: "already_completed"}, nil
+		},
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			f.current++
+			f.calls = append(f.calls, "start")
+			return &epoch.StartEpochResponse{EpochID: strconv.FormatUint(f.current, 10), Status: "started"}, nil
+		},
+	}
+	f.subsidy = &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			f.calls = append(f.calls, fmt.Sprintf("distribute %d", f.current))
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	return f
+}
+
+func (f *catchUpFixture) scheduler(limit int, now time.Time) *Scheduler {
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	cfg.Scheduler.CatchUpLimit = limit
+	s := NewScheduler(f.epochSvc, f.subsidy, nil, nil, nil, nil, nil, nil, time.Hour, lgr.NoOp, cfg)
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func epochSummary(number int, status string, end time.Time) epoch.EpochSummary {
+	return epoch.EpochSummary{
+		EpochNumber:  strconv.Itoa(number),
+		Status:       status,
+		EndTimestamp: strconv.FormatInt(end.Unix(), 10),
+	}
+}
+
+func TestScheduler_CatchUp_ProcessesMissedEpochsInOrder(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5,
+		epochSummary(5, "ACTIVE", now.Add(-3*time.Hour)),
+		epochSummary(4, "PROCESSING", now.Add(-5*time.Hour)),
+		epochSummary(3, "COMPLETED", now.Add(-7*time.Hour)),
+	)
+	s := f.scheduler(10, now)
+
+	s.runEpochCycle(context.Background())
+	assert.Equal(t, []string{"force-end 4", "distribute 5", "start"}, f.calls,
+		"missed epochs are closed oldest first and a new epoch started, replacing the regular cycle")
+	assert.True(t, s.caughtUp)
+
+	// once caught up the regular cycle runs again
+	s.runEpochCycle(context.Background())
+	require.Len(t, f.calls, 5)
+	assert.Equal(t, "start", f.calls[3])
+	assert.Len(t, f.subsidy.DistributeSubsidiesCalls(), 2)
+	assert.Len(t, f.epochSvc.ListEpochsCalls(), 1, "missed epochs are checked once")
+}
+
+func TestScheduler_CatchUp_NothingMissed(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5,
+		epochSummary(5, "ACTIVE", now.Add(time.Hour)),
+		epochSummary(4, "COMPLETED", now.Add(-time.Hour)),
+	)
+	s := f.scheduler(10, now)
+
+	assert.False(t, s.catchUp(context.Background()))
+	assert.True(t, s.caughtUp)
+	assert.Empty(t, f.calls)
+}
+
+func TestScheduler_CatchUp_FollowsChainClock(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5,
+		epochSummary(5, "ACTIVE", now.Add(-time.Minute)),
+		epochSummary(4, "PROCESSING", now.Add(-5*time.Hour)),
+	)
+	f.epochSvc.GetEpochTimingFunc = func(ctx context.Context) (*epoch.EpochTiming, error) {
+		// the server's clock runs ahead of the chain's
+		return &epoch.EpochTiming{EpochID: "5", EndTime: 1_700_000_000, ChainTime: 1_699_999_800}, nil
+	}
+	s := f.scheduler(10, now)
+	s.SetEpochClock(f.epochSvc)
+
+	require.True(t, s.catchUp(context.Background()))
+	assert.Equal(t, []string{"force-end 4"}, f.calls, "the current epoch is not ended before its on-chain end")
+	assert.True(t, s.caughtUp)
+}
+
+func TestScheduler_CatchUp_Limit(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(4,
+		epochSummary(2, "ACTIVE", now.Add(-9*time.Hour)),
+		epochSummary(3, "ACTIVE", now.Add(-6*time.Hour)),
+		epochSummary(4, "ACTIVE", now.Add(-3*time.Hour)),
+	)
+	s := f.scheduler(2, now)
+
+	require.True(t, s.catchUp(context.Background()))
+	assert.Equal(t, []string{"force-end 2", "force-end 3"}, f.calls)
+	assert.False(t, s.caughtUp, "the rest is processed on the next cycle")
+
+	f.epochs = f.epochs[2:]
+	require.True(t, s.catchUp(context.Background()))
+	assert.Equal(t, []string{"force-end 2", "force-end 3", "distribute 4", "start"}, f.calls)
+	assert.True(t, s.caughtUp)
+}
+
+func TestScheduler_CatchUp_RetriesAfterFailure(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5, epochSummary(5, "ACTIVE", now.Add(-time.Hour)))
+	f.subsidy.DistributeSubsidiesFunc = func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+		return nil, fmt.Errorf("subgraph unavailable")
+	}
+	s := f.scheduler(10, now)
+
+	assert.True(t, s.catchUp(context.Background()))
+	assert.False(t, s.caughtUp)
+	assert.Empty(t, f.epochSvc.StartEpochCalls(), "no new epoch while the missed one is open")
+}
+
+func TestScheduler_CatchUp_PendingApproval(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5, epochSummary(5, "ACTIVE", now.Add(-time.Hour)))
+	f.subsidy.DistributeSubsidiesFunc = func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+		return &subsidy.SubsidyDistributionResponse{Status: subsidy.StagedPendingApproval, StagedID: "staged-1"}, nil
+	}
+	s := f.scheduler(10, now)
+
+	assert.True(t, s.catchUp(context.Background()))
+	assert.True(t, s.caughtUp)
+	assert.Empty(t, f.epochSvc.StartEpochCalls())
+}
+
+func TestScheduler_CatchUp_Disabled(t *testing.T) {
+	f := newCatchUpFixture(5)
+	s := f.scheduler(0, time.Now())
+
+	assert.False(t, s.catchUp(context.Background()))
+	assert.True(t, s.caughtUp)
+	assert.Empty(t, f.epochSvc.GetCurrentEpochIdCalls())
+}
+
+func TestScheduler_CatchUp_Paused(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	f := newCatchUpFixture(5,
+		epochSummary(5, "ACTIVE", now.Add(-3*time.Hour)),
+		epochSummary(4, "PROCESSING", now.Add(-5*time.Hour)),
+	)
+	s := f.scheduler(10, now)
+	pausedJobs := map[string]bool{pause.JobDistribute: true}
+	s.pauses = &pause.ServiceMock{
+		IsPausedFunc: func(ctx context.Context, job string) (bool, error) {
+			return pausedJobs[job], nil
+		},
+	}
+
+	assert.True(t, s.catchUp(context.Background()))
+	assert.Equal(t, []string{"force-end 4"}, f.calls, "catch-up stops at the paused distribution")
+	assert.False(t, s.caughtUp)
+
+	pausedJobs = map[string]bool{pause.JobCatchUp: true}
+	assert.False(t, s.catchUp(context.Background()), "the regular cycle runs while catch-up is paused")
+	assert.Len(t, f.calls, 1)
+
+	pausedJobs = nil
+	assert.True(t, s.catchUp(context.Background()))
+	assert.Equal(t, []string{"force-end 4", "force-end 4", "distribute 5", "start"}, f.calls)
+	assert.True(t, s.caughtUp)
+}
@@ -0,0 +1,20 @@
+package scheduler
+
+import "errors"
+
+var (
+	// ErrCannotRun is returned when an epoch boundary is triggered while this replica may not run jobs
+	ErrCannotRun = errors.New("scheduler cannot run jobs")
+	// ErrNotRunning is returned when an epoch boundary is triggered without a running scheduler
+	ErrNotRunning = errors.New("scheduler is not running")
+
+	// errJobPaused and errContractPaused are why a job run was skipped
+	errJobPaused      = errors.New("job paused")
+	errContractPaused = errors.New("DebtSubsidizer paused")
+	// errVaultDecommissioned is why the vault's jobs were skipped
+	errVaultDecommissioned = errors.New("vault decommissioned")
+	// errEpochNotEnded is why jobs were skipped while the chain's clock has not reached the epoch's end
+	errEpochNotEnded = errors.New("epoch not ended on-chain")
+	// errBackingOff is why a failing job was skipped until its next attempt
+	errBackingOff = errors.New("backing off")
+)
@@ -2,15 +2,24 @@ package scheduler
 
 import (
 	"context"
+	"sync/atomic"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/leader"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/signer"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"github.com/andrey/epoch-server/internal/services/webhook"
 	"github.com/go-pkgz/lgr"
 )
 
-//go:generate moq -out scheduler_mocks.go . EpochService SubsidyService
+//go:generate moq -out scheduler_mocks.go . EpochService SubsidyService Trigger
 
 // EpochService interface for epoch operations
 type EpochService interface {
@@ -22,11 +31,63 @@ type SubsidyService interface {
 	DistributeSubsidies(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error)
 }
 
+// EpochClock reads when the current epoch ends by the chain's clock
+type EpochClock interface {
+	GetEpochTiming(ctx context.Context) (*epoch.EpochTiming, error)
+}
+
+// scheduler modes, chosen with SCHEDULER_MODE
+const (
+	ModeInterval = "interval" // a boundary every SCHEDULER_INTERVAL
+	ModeCalendar = "calendar" // boundaries on SCHEDULER_CALENDAR, e.g. weekly on Mondays
+	ModeManual   = "manual"   // boundaries only when triggered
+)
+
+// Trigger runs epoch boundaries on demand
+type Trigger interface {
+	Trigger(ctx context.Context) (*BoundaryResult, error)
+}
+
+// BoundaryResult acknowledges an epoch boundary queued on demand
+type BoundaryResult struct {
+	Mode         string     `json:"mode" example:"calendar"`
+	TriggeredAt  time.Time  `json:"triggeredAt"`
+	NextBoundary *time.Time `json:"nextBoundary,omitempty"` // next calendar boundary, none in the other modes
+}
+
+// triggerRequest asks the scheduler loop to run a boundary on behalf of actor
+type triggerRequest struct {
+	actor     string
+	requestID string // of the API request that triggered the boundary, its lines are logged with it
+}
+
 // Scheduler manages automated epoch operations
 type Scheduler struct {
 	epochService   epoch.Service
 	subsidyService subsidy.Service
+	signerService  signer.Service            // nil disables balance checks
+	contracts      contractstate.Service     // nil disables contract pause checks
+	elector        leader.Elector            // nil runs jobs on every replica
+	pauses         pause.Service             // nil never pauses jobs
+	runs           jobs.Recorder             // nil records no job runs
+	reconciler     reconciliation.Reconciler // nil runs no reconciliation
 	logger         lgr.L
 	interval       time.Duration
 	config         *config.Config
+	now            func() time.Time
+
+	mode     string
+	calendar *config.Calendar    // nil unless boundaries follow the calendar
+	triggers chan triggerRequest // holds at most one queued boundary
+	running  atomic.Bool
+
+	caughtUp bool // missed epochs were checked since this replica started running jobs
+
+	backoffs    map[string]*jobBackoff          // failure streaks of failing jobs, by job
+	notifier    webhook.Notifier                // nil sends no alerts
+	healthCheck func(ctx context.Context) error // nil retries suspended jobs at the longest backoff
+	random      func() float64                  // jitter source, in [0, 1)
+	vaults      vaults.Service                  // nil never skips a decommissioned vault
+	clock       EpochClock                      // nil leaves checking epochs ended to the contracts
+	deferral    time.Duration                   // until a scheduled boundary runs again: the epoch's on-chain end or the lag retry
 }
@@ -2,54 +2,547 @@ package scheduler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/leader"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/signer"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
 	"github.com/go-pkgz/lgr"
 )
 
-func NewScheduler(epochService epoch.Service, subsidyService subsidy.Service, interval time.Duration, logger lgr.L, cfg *config.Config) *Scheduler {
-	return &Scheduler{
+func NewScheduler(
+	epochService epoch.Service,
+	subsidyService subsidy.Service,
+	signerService signer.Service,
+	contracts contractstate.Service,
+	elector leader.Elector,
+	pauses pause.Service,
+	runs jobs.Recorder,
+	reconciler reconciliation.Reconciler,
+	interval time.Duration,
+	logger lgr.L,
+	cfg *config.Config,
+) *Scheduler {
+	s := &Scheduler{
 		epochService:   epochService,
 		subsidyService: subsidyService,
+		signerService:  signerService,
+		contracts:      contracts,
+		elector:        elector,
+		pauses:         pauses,
+		runs:           runs,
+		reconciler:     reconciler,
 		logger:         logger,
 		interval:       interval,
 		config:         cfg,
+		now:            time.Now,
+		mode:           ModeInterval,
+		triggers:       make(chan triggerRequest, 1),
+		backoffs:       make(map[string]*jobBackoff),
+		random:         randomFloat,
 	}
+
+	switch cfg.Scheduler.Mode {
+	case ModeCalendar:
+		// config.Load rejects malformed calendars
+		calendar, err := config.ParseCalendar(cfg.Scheduler.Calendar, cfg.Scheduler.Timezone)
+		if err != nil {
+			logger.Logf("ERROR invalid scheduler calendar, falling back to interval %v: %v", interval, err)
+			break
+		}
+		s.mode, s.calendar = ModeCalendar, calendar
+	case ModeManual:
+		s.mode = ModeManual
+	}
+	return s
+}
+
+// SetVaults sets the registry whose decommissioned vaults the scheduler no longer runs jobs for. It is called
+// once at startup, before Start.
+func (s *Scheduler) SetVaults(registry vaults.Service) {
+	s.vaults = registry
+}
+
+// SetEpochClock sets where the scheduler reads when the current epoch ends. Epochs are then only started and
+// ended once the chain's clock passed the current epoch's end, whatever SCHEDULER_INTERVAL or SCHEDULER_CALENDAR
+// say, and a scheduled boundary that came early runs again at the epoch's end. It is called once at startup,
+// before Start.
+func (s *Scheduler) SetEpochClock(clock EpochClock) {
+	s.clock = clock
 }
 
 func (s *Scheduler) Start(ctx context.Context) {
-	ticker := time.NewTicker(s.interval)
-	defer ticker.Stop()
+	s.running.Store(true)
+	defer s.running.Store(false)
 
-	s.logger.Logf("INFO scheduler started with interval %v", s.interval)
+	var ticks <-chan time.Time
+	var nextTick time.Time
+	if s.mode == ModeInterval {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		ticks = ticker.C
+		nextTick = s.now().Add(s.interval)
+		s.logger.Logf("INFO scheduler started with interval %v", s.interval)
+	} else {
+		s.logger.Logf("INFO scheduler started in %s mode", s.mode)
+	}
+
+	// epochs missed while the server was down are processed now rather than on the first boundary,
+	// unless operators decide when boundaries run
+	if s.mode != ModeManual {
+		s.runCatchUp(ctx)
+	}
+
+	// a boundary that came before the on-chain end of the epoch runs again once it ended, and one whose
+	// distribution a lagging subgraph delayed once SUBGRAPH_LAG_RETRY passed
+	deferred := time.NewTimer(time.Hour)
+	deferred.Stop()
+	defer deferred.Stop()
 
 	for {
+		boundary, release := s.nextBoundary(ticks)
+		s.scheduleNextRuns(ctx, s.upcomingBoundary(nextTick))
 		select {
 		case <-ctx.Done():
+			release()
+			s.scheduleNextRuns(ctx, nil)
 			s.logger.Logf("INFO scheduler stopped")
 			return
-		case <-ticker.C:
+		case tick := <-boundary:
+			if s.mode == ModeInterval {
+				nextTick = tick.Add(s.interval)
+			}
+			s.runEpochCycle(ctx)
+			s.rearm(deferred)
+		case <-deferred.C:
+			s.logger.Logf("INFO running deferred epoch boundary")
 			s.runEpochCycle(ctx)
+			s.rearm(deferred)
+		case req := <-s.triggers:
+			s.logger.Logf("INFO running epoch boundary triggered by %s", req.actor)
+			triggered := logging.WithFields(audit.WithActor(ctx, req.actor), logging.Fields{RequestID: req.requestID})
+			if err := s.runBoundary(triggered, false); err != nil {
+				s.logger.Logf("ERROR epoch boundary triggered by %s failed: %v", req.actor, err)
+			}
+		}
+		release()
+	}
+}
+
+// rearm sets timer to when the last scheduled boundary deferred its jobs to, if it did
+func (s *Scheduler) rearm(timer *time.Timer) {
+	if s.deferral <= 0 {
+		return
+	}
+	timer.Stop()
+	timer.Reset(s.deferral)
+	s.deferral = 0
+}
+
+// nextBoundary returns a channel firing at the next automatic epoch boundary and a func releasing it.
+// In interval mode that is the ticker, and in manual mode a channel that never fires.
+func (s *Scheduler) nextBoundary(ticks <-chan time.Time) (<-chan time.Time, func()) {
+	if s.calendar == nil {
+		return ticks, func() {}
+	}
+	next := s.calendar.Next(s.now())
+	s.logger.Logf("INFO next epoch boundary at %s", next.Format(time.RFC3339))
+	timer := time.NewTimer(next.Sub(s.now()))
+	return timer.C, func() { timer.Stop() }
+}
+
+// upcomingBoundary returns when the next automatic boundary runs: nextTick in interval mode, the next
+// calendar boundary in calendar mode, and nil in manual mode
+func (s *Scheduler) upcomingBoundary(nextTick time.Time) *time.Time {
+	switch {
+	case s.calendar != nil:
+		next := s.calendar.Next(s.now())
+		return &next
+	case s.mode == ModeInterval:
+		return &nextTick
+	}
+	return nil
+}
+
+// scheduleNextRuns records that the jobs run next at next, nil when no boundary is scheduled.
+// Catch-up only runs again while missed epochs may remain.
+func (s *Scheduler) scheduleNextRuns(ctx context.Context, next *time.Time) {
+	if s.runs == nil {
+		return
+	}
+	for _, job := range jobs.Jobs {
+		jobNext := next
+		if job == pause.JobCatchUp && s.caughtUp {
+			jobNext = nil
+		}
+		if err := s.runs.SetNextRun(ctx, job, jobNext); err != nil {
+			s.logger.Logf("WARN failed to record next run of %s: %v", job, err)
 		}
 	}
 }
 
-func (s *Scheduler) runEpochCycle(ctx context.Context) {
+// recordRun stores how a job run went. A failure to store it is logged, the run itself is over.
+func (s *Scheduler) recordRun(ctx context.Context, job string, startedAt time.Time, outcome string, reason error) {
+	if s.runs == nil {
+		return
+	}
+	run := jobs.Run{
+		Job:        job,
+		StartedAt:  startedAt,
+		FinishedAt: s.now(),
+		Outcome:    outcome,
+		Actor:      audit.ActorFromContext(ctx),
+	}
+	if reason != nil {
+		run.Error = reason.Error()
+	}
+	if err := s.runs.RecordRun(ctx, run); err != nil {
+		s.logger.Logf("WARN failed to record run of %s: %v", job, err)
+	}
+}
+
+// Trigger queues an epoch boundary to run now, in addition to the automatic ones. The boundary runs on
+// the scheduler's loop so it never overlaps a scheduled one, and its transactions are audited under the
+// caller's actor.
+func (s *Scheduler) Trigger(ctx context.Context) (*BoundaryResult, error) {
+	if !s.running.Load() {
+		return nil, ErrNotRunning
+	}
+	if err := s.canRun(ctx); err != nil {
+		return nil, err
+	}
+
+	select {
+	case s.triggers <- triggerRequest{actor: audit.ActorFromContext(ctx), requestID: logging.FieldsFromContext(ctx).RequestID}:
+	default:
+		return nil, fmt.Errorf("%w: a triggered boundary is already queued", ErrCannotRun)
+	}
+
+	result := &BoundaryResult{Mode: s.mode, TriggeredAt: s.now()}
+	if s.calendar != nil {
+		next := s.calendar.Next(s.now())
+		result.NextBoundary = &next
+	}
+	return result, nil
+}
+
+// runEpochCycle runs a scheduled epoch boundary
+func (s *Scheduler) runEpochCycle(ctx context.Context) error {
+	ctx = logging.WithFields(ctx, logging.Fields{RequestID: logging.NewRequestID()})
+	return s.runBoundary(audit.WithActor(ctx, "scheduler"), true)
+}
+
+// runBoundary starts the next epoch and distributes subsidies, reporting why it could not run
+// and what failed. Scheduled boundaries skip jobs backing off after failures, triggered ones run them.
+func (s *Scheduler) runBoundary(ctx context.Context, scheduled bool) error {
+	ctx = logging.WithFields(ctx, logging.Fields{Vault: s.config.Contracts.CollectionsVault})
+	logger := logging.FromContext(ctx, s.logger)
+
+	if err := s.canRun(ctx); err != nil {
+		// only the lease holder records runs, so replicas sharing the database do not overwrite its history
+		if s.elector == nil || s.elector.IsLeader() {
+			s.recordRun(ctx, pause.JobStartEpoch, s.now(), jobs.OutcomeSkipped, err)
+			s.skipVaultJobs(ctx, err)
+		}
+		return err
+	}
+
+	// a replica that was down or only now became the leader first processes the epochs it missed
+	if !s.caughtUp && s.catchUp(ctx) {
+		return nil
+	}
+
+	var errs []error
+
 	// Start epoch if needed
-	if response, err := s.epochService.StartEpoch(ctx); err != nil {
-		s.logger.Logf("ERROR failed to start epoch: %v", err)
+	started := s.now()
+	if s.paused(ctx, pause.JobStartEpoch) {
+		logger.Logf("INFO epoch start paused, skipping")
+		s.recordRun(ctx, pause.JobStartEpoch, started, jobs.OutcomeSkipped, errJobPaused)
+	} else if running := s.runningEpoch(ctx); running != nil {
+		logger.Logf("INFO epoch %s runs until %s on-chain, not starting another", running.EpochID, endOf(running))
+		s.recordRun(ctx, pause.JobStartEpoch, started, jobs.OutcomeSkipped, notEnded(running))
+	} else if scheduled && s.skipBackingOff(ctx, pause.JobStartEpoch) {
+		// recorded as skipped, retried once its backoff is over
+	} else if response, err := s.epochService.StartEpoch(ctx); err != nil {
+		logger.Logf("ERROR failed to start epoch: %v", err)
+		err = fmt.Errorf("failed to start epoch: %w", err)
+		s.recordRun(ctx, pause.JobStartEpoch, started, jobs.OutcomeFailed, err)
+		s.jobFailed(ctx, pause.JobStartEpoch, err)
+		errs = append(errs, err)
 	} else {
-		s.logger.Logf("INFO successfully started epoch: %s", response.EpochID)
+		logger.Logf("INFO successfully started epoch: %s", response.EpochID)
+		s.recordRun(ctx, pause.JobStartEpoch, started, jobs.OutcomeSucceeded, nil)
+		s.jobSucceeded(ctx, pause.JobStartEpoch)
 	}
 
 	// Use vault address from configuration for subsidy distribution
 	vaultId := s.config.Contracts.CollectionsVault
-	if response, err := s.subsidyService.DistributeSubsidies(ctx, vaultId); err != nil {
-		s.logger.Logf("ERROR failed to distribute subsidies: %v", err)
+	if s.decommissioned(ctx, vaultId) {
+		// epochs are the EpochManager's and keep advancing, the vault's jobs no longer run
+		logger.Logf("INFO vault %s decommissioned, skipping its jobs", vaultId)
+		s.skipVaultJobs(ctx, errVaultDecommissioned)
+		return errors.Join(errs...)
+	}
+	if err := s.allocateYield(ctx, vaultId, scheduled); err != nil {
+		errs = append(errs, err)
+	}
+
+	started = s.now()
+	if s.paused(ctx, pause.JobDistribute) {
+		logger.Logf("INFO subsidy distribution paused, skipping")
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeSkipped, errJobPaused)
+	} else if s.contractPaused(ctx) {
+		logger.Logf("WARN DebtSubsidizer paused for vault %s, skipping subsidy distribution", vaultId)
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeSkipped, errContractPaused)
+	} else if running := s.runningEpoch(ctx); running != nil {
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeSkipped, notEnded(running))
+		if scheduled {
+			// the boundary is due by SCHEDULER_INTERVAL or SCHEDULER_CALENDAR but not by the EpochManager
+			s.deferral = time.Duration(running.EndTime-running.ChainTime) * time.Second
+			logger.Logf("WARN epoch %s ends on-chain at %s, %v after this boundary: the schedule drifted from the "+
+				"EpochManager's epochs, subsidies are distributed at the epoch's end", running.EpochID, endOf(running), s.deferral)
+		} else {
+			logger.Logf("INFO epoch %s runs until %s on-chain, not distributing subsidies yet", running.EpochID, endOf(running))
+		}
+	} else if scheduled && s.skipBackingOff(ctx, pause.JobDistribute) {
+		// recorded as skipped, retried once its backoff is over
+	} else if response, err := s.subsidyService.DistributeSubsidies(ctx, vaultId); errors.Is(err, subsidy.ErrSubgraphLagging) {
+		// stale balances are not distributed, nor is the subgraph's lag a failure of the job
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeSkipped, err)
+		if scheduled && s.config.Subgraph.LagRetry > 0 {
+			s.deferral = s.config.Subgraph.LagRetry
+			logger.Logf("WARN subsidy distribution delayed by %v: %v", s.deferral, err)
+		} else {
+			logger.Logf("WARN subsidy distribution delayed to the next boundary: %v", err)
+		}
+	} else if err != nil {
+		logger.Logf("ERROR failed to distribute subsidies: %v", err)
+		err = fmt.Errorf("failed to distribute subsidies: %w", err)
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeFailed, err)
+		s.jobFailed(ctx, pause.JobDistribute, err)
+		errs = append(errs, err)
 	} else {
-		s.logger.Logf("INFO successfully distributed subsidies: %s", response.Status)
+		logger.Logf("INFO successfully distributed subsidies: %s", response.Status)
+		s.recordRun(ctx, pause.JobDistribute, started, jobs.OutcomeSucceeded, nil)
+		s.jobSucceeded(ctx, pause.JobDistribute)
+	}
+
+	if err := s.reconcile(ctx, vaultId, scheduled); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// skipVaultJobs records the jobs run for the vault as skipped because of reason
+func (s *Scheduler) skipVaultJobs(ctx context.Context, reason error) {
+	if s.config.Yield.Allocate {
+		s.recordRun(ctx, pause.JobAllocateYield, s.now(), jobs.OutcomeSkipped, reason)
+	}
+	s.recordRun(ctx, pause.JobDistribute, s.now(), jobs.OutcomeSkipped, reason)
+	if s.reconciler != nil {
+		s.recordRun(ctx, pause.JobReconcile, s.now(), jobs.OutcomeSkipped, reason)
+	}
+}
+
+// allocateYield allocates the vault's remaining yield to the new epoch, less the configured reserve,
+// when allocation is enabled
+func (s *Scheduler) allocateYield(ctx context.Context, vaultId string, scheduled bool) error {
+	if !s.config.Yield.Allocate {
+		return nil
+	}
+	logger := logging.FromContext(ctx, s.logger)
+	started := s.now()
+	if s.paused(ctx, pause.JobAllocateYield) {
+		logger.Logf("INFO yield allocation paused, skipping")
+		s.recordRun(ctx, pause.JobAllocateYield, started, jobs.OutcomeSkipped, errJobPaused)
+		return nil
+	}
+	if scheduled && s.skipBackingOff(ctx, pause.JobAllocateYield) {
+		return nil
+	}
+	allocation, err := s.epochService.AllocateYield(ctx, vaultId)
+	switch {
+	case errors.Is(err, epoch.ErrYieldAlreadyAllocated):
+		logger.Logf("INFO %v", err)
+		s.recordRun(ctx, pause.JobAllocateYield, started, jobs.OutcomeSkipped, err)
+		s.jobSucceeded(ctx, pause.JobAllocateYield)
+		return nil
+	case err != nil:
+		logger.Logf("ERROR failed to allocate yield: %v", err)
+		err = fmt.Errorf("failed to allocate yield: %w", err)
+		s.recordRun(ctx, pause.JobAllocateYield, started, jobs.OutcomeFailed, err)
+		s.jobFailed(ctx, pause.JobAllocateYield, err)
+		return err
+	}
+	logger.Logf("INFO allocated %s wei yield to epoch %s, held back %s", allocation.Allocated, allocation.EpochID, allocation.HeldBack)
+	s.recordRun(ctx, pause.JobAllocateYield, started, jobs.OutcomeSucceeded, nil)
+	s.jobSucceeded(ctx, pause.JobAllocateYield)
+	return nil
+}
+
+// reconcile checks the vault's latest distribution against the yield allocated to it, and every account's
+// claims against its cumulative total. It only reads the chain, so it runs whether or not this boundary
+// distributed anything.
+func (s *Scheduler) reconcile(ctx context.Context, vaultId string, scheduled bool) error {
+	if s.reconciler == nil {
+		return nil
+	}
+	logger := logging.FromContext(ctx, s.logger)
+	started := s.now()
+	if s.paused(ctx, pause.JobReconcile) {
+		logger.Logf("INFO yield reconciliation paused, skipping")
+		s.recordRun(ctx, pause.JobReconcile, started, jobs.OutcomeSkipped, errJobPaused)
+		return nil
+	}
+	if scheduled && s.skipBackingOff(ctx, pause.JobReconcile) {
+		return nil
+	}
+	report, err := s.reconciler.Reconcile(ctx, vaultId)
+	switch {
+	case errors.Is(err, reconciliation.ErrNoDistribution):
+		logger.Logf("INFO no distribution to reconcile for vault %s yet", vaultId)
+		s.recordRun(ctx, pause.JobReconcile, started, jobs.OutcomeSkipped, err)
+		s.jobSucceeded(ctx, pause.JobReconcile)
+		return nil
+	case err != nil:
+		logger.Logf("ERROR failed to reconcile yield: %v", err)
+		err = fmt.Errorf("failed to reconcile yield: %w", err)
+		s.recordRun(ctx, pause.JobReconcile, started, jobs.OutcomeFailed, err)
+		s.jobFailed(ctx, pause.JobReconcile, err)
+		return err
+	}
+	claims, err := s.reconciler.ReconcileClaims(ctx, vaultId)
+	if err != nil {
+		logger.Logf("ERROR failed to reconcile claims: %v", err)
+		err = fmt.Errorf("failed to reconcile claims: %w", err)
+		s.recordRun(ctx, pause.JobReconcile, started, jobs.OutcomeFailed, err)
+		s.jobFailed(ctx, pause.JobReconcile, err)
+		return err
+	}
+	logger.Logf("INFO reconciled vault %s epoch %s, flagged: %t, %d of %d accounts' claims flagged",
+		vaultId, report.EpochID, report.Flagged, len(claims.Discrepancies), claims.Accounts)
+	s.recordRun(ctx, pause.JobReconcile, started, jobs.OutcomeSucceeded, nil)
+	s.jobSucceeded(ctx, pause.JobReconcile)
+	return nil
+}
+
+// runCatchUp processes missed epochs when jobs can run, outside the regular cycle
+func (s *Scheduler) runCatchUp(ctx context.Context) {
+	ctx = logging.WithFields(audit.WithActor(ctx, "scheduler"), logging.Fields{RequestID: logging.NewRequestID()})
+	if !s.caughtUp && s.canRun(ctx) == nil {
+		s.catchUp(ctx)
+	}
+}
+
+// canRun returns an error wrapping ErrCannotRun when this replica may not send transactions now
+func (s *Scheduler) canRun(ctx context.Context) error {
+	// with several replicas only the lease holder runs jobs
+	if s.elector != nil && !s.elector.IsLeader() {
+		s.logger.Logf("DEBUG not the scheduler leader, skipping epoch cycle")
+		return fmt.Errorf("%w: not the scheduler leader", ErrCannotRun)
+	}
+
+	// operators pause every job during contract upgrades
+	if s.paused(ctx, pause.JobAll) {
+		s.logger.Logf("INFO scheduler paused, skipping epoch cycle")
+		return fmt.Errorf("%w: scheduler paused", ErrCannotRun)
+	}
+
+	// skip every transaction while the signer cannot pay for gas, rather than failing mid-epoch
+	if s.signerHalted(ctx) {
+		s.logger.Logf("WARN signer balance below minimum, skipping epoch cycle")
+		return fmt.Errorf("%w: signer balance below minimum", ErrCannotRun)
+	}
+	return nil
+}
+
+// paused reports whether an operator paused job. When the pause state cannot be read the job is
+// treated as paused, so a storage failure never runs a job during an upgrade.
+func (s *Scheduler) paused(ctx context.Context, job string) bool {
+	if s.pauses == nil {
+		return false
+	}
+	paused, err := s.pauses.IsPaused(ctx, job)
+	if err != nil {
+		s.logger.Logf("ERROR failed to read pause state of %s, treating it as paused: %v", job, err)
+		return true
+	}
+	return paused
+}
+
+// decommissioned reports whether the vault was decommissioned. When the registry cannot be read the vault is
+// treated as decommissioned, like a job whose pause state cannot be read.
+func (s *Scheduler) decommissioned(ctx context.Context, vaultId string) bool {
+	if s.vaults == nil {
+		return false
+	}
+	decommissioned, err := s.vaults.IsDecommissioned(ctx, vaultId)
+	if err != nil {
+		s.logger.Logf("ERROR failed to read registry status of vault %s, treating it as decommissioned: %v", vaultId, err)
+		return true
+	}
+	return decommissioned
+}
+
+// runningEpoch returns the timing of the current epoch while the chain's clock has not reached its end, nil
+// once it has. When the timing cannot be read the contracts' own checks decide, as they did without a clock.
+func (s *Scheduler) runningEpoch(ctx context.Context) *epoch.EpochTiming {
+	if s.clock == nil {
+		return nil
+	}
+	timing, err := s.clock.GetEpochTiming(ctx)
+	if err != nil {
+		s.logger.Logf("WARN failed to read on-chain epoch timing, leaving the check to the contracts: %v", err)
+		return nil
+	}
+	if timing.Ended {
+		return nil
+	}
+	return timing
+}
+
+// notEnded is why jobs were skipped while the epoch runs
+func notEnded(timing *epoch.EpochTiming) error {
+	return fmt.Errorf("%w: epoch %s ends at %s", errEpochNotEnded, timing.EpochID, endOf(timing))
+}
+
+// endOf formats the on-chain end of the epoch
+func endOf(timing *epoch.EpochTiming) string {
+	return time.Unix(int64(timing.EndTime), 0).UTC().Format(time.RFC3339)
+}
+
+// signerHalted checks the signer balance and reports whether transaction-submitting jobs are paused.
+// When the balance cannot be read the outcome of the previous check stands.
+func (s *Scheduler) signerHalted(ctx context.Context) bool {
+	if s.signerService == nil {
+		return false
+	}
+	if _, err := s.signerService.CheckBalance(ctx); err != nil {
+		s.logger.Logf("ERROR failed to check signer balance: %v", err)
+	}
+	return s.signerService.Status().Halted
+}
+
+// contractPaused checks the DebtSubsidizer pause state and reports whether distributions would revert.
+// When the state cannot be read the outcome of the previous check stands.
+func (s *Scheduler) contractPaused(ctx context.Context) bool {
+	if s.contracts == nil {
+		return false
+	}
+	if _, err := s.contracts.Check(ctx); err != nil {
+		s.logger.Logf("ERROR failed to check contract pause state: %v", err)
 	}
+	return s.contracts.Status().Paused
 }
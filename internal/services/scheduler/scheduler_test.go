@@ -7,8 +7,16 @@ import (
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
 	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/jobs"
+	"github.com/andrey/epoch-server/internal/services/leader"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/reconciliation"
+	"github.com/andrey/epoch-server/internal/services/signer"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
 	"github.com/go-pkgz/lgr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -39,7 +47,7 @@ func TestScheduler_NewScheduler(t *testing.T) {
 
 	interval := 10 * time.Second
 
-	scheduler := NewScheduler(mockEpochService, mockSubsidyService, interval, logger, cfg)
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, nil, nil, interval, logger, cfg)
 
 	require.NotNil(t, scheduler, "NewScheduler returned nil")
 	require.NotNil(t, scheduler.epochService, "Scheduler epochService is nil")
@@ -83,7 +91,7 @@ func TestScheduler_runEpochCycle(t *testing.T) {
 
 	interval := 10 * time.Second
 
-	scheduler := NewScheduler(mockEpochService, mockSubsidyService, interval, logger, cfg)
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, nil, nil, interval, logger, cfg)
 
 	ctx := context.Background()
 	scheduler.runEpochCycle(ctx)
@@ -127,7 +135,7 @@ func TestScheduler_runEpochCycle_WithErrors(t *testing.T) {
 
 	interval := 10 * time.Second
 
-	scheduler := NewScheduler(mockEpochService, mockSubsidyService, interval, logger, cfg)
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, nil, nil, interval, logger, cfg)
 
 	ctx := context.Background()
 	scheduler.runEpochCycle(ctx)
@@ -140,3 +148,561 @@ func TestScheduler_runEpochCycle_WithErrors(t *testing.T) {
 		t.Error("Expected DistributeSubsidies to be called")
 	}
 }
+
+func TestScheduler_runEpochCycle_SignerHalted(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+
+	halted := true
+	mockSignerService := &signer.ServiceMock{
+		CheckBalanceFunc: func(ctx context.Context) (*signer.BalanceStatus, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+		StatusFunc: func() signer.BalanceStatus {
+			return signer.BalanceStatus{Halted: halted}
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, mockSignerService, nil, nil, nil, nil, nil, 10*time.Second, lgr.NoOp, cfg)
+
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockSignerService.CheckBalanceCalls(), 1)
+	assert.Empty(t, mockEpochService.StartEpochCalls(), "no transactions while the signer is halted")
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls())
+
+	halted = false
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+}
+
+func TestScheduler_runEpochCycle_ContractPaused(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+
+	paused := true
+	mockContracts := &contractstate.ServiceMock{
+		CheckFunc: func(ctx context.Context) (*contractstate.PauseStatus, error) {
+			return nil, fmt.Errorf("connection refused")
+		},
+		StatusFunc: func() contractstate.PauseStatus {
+			return contractstate.PauseStatus{Paused: paused, SubsidizerPaused: paused}
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, mockContracts, nil, nil, nil, nil, 10*time.Second, lgr.NoOp, cfg)
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockContracts.CheckCalls(), 1)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1, "only distributions go to the DebtSubsidizer")
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls(), "no distributions while the contract is paused")
+
+	paused = false
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+}
+
+func TestScheduler_runEpochCycle_VaultDecommissioned(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+
+	decommissioned := true
+	var registryErr error
+	mockVaults := &vaults.ServiceMock{
+		IsDecommissionedFunc: func(ctx context.Context, vault string) (bool, error) {
+			return decommissioned, registryErr
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, 10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+	scheduler.SetVaults(mockVaults)
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1, "epochs keep advancing")
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls(), "no distributions for a decommissioned vault")
+	assert.Equal(t, "0x1234567890123456789012345678901234567890", mockVaults.IsDecommissionedCalls()[0].Vault)
+	runs := mockRuns.RecordRunCalls()
+	require.Len(t, runs, 2)
+	assert.Equal(t, pause.JobDistribute, runs[1].Run.Job)
+	assert.Equal(t, jobs.OutcomeSkipped, runs[1].Run.Outcome)
+	assert.Equal(t, "vault decommissioned", runs[1].Run.Error)
+
+	decommissioned, registryErr = false, fmt.Errorf("db closed")
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls(), "an unreadable registry skips the vault")
+
+	registryErr = nil
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+}
+
+func TestScheduler_runEpochCycle_Follower(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockSignerService := &signer.ServiceMock{
+		CheckBalanceFunc: func(ctx context.Context) (*signer.BalanceStatus, error) {
+			return &signer.BalanceStatus{}, nil
+		},
+		StatusFunc: func() signer.BalanceStatus {
+			return signer.BalanceStatus{}
+		},
+	}
+
+	isLeader := false
+	mockElector := &leader.ElectorMock{
+		IsLeaderFunc: func() bool { return isLeader },
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, mockSignerService, nil, mockElector, nil, nil, nil, 10*time.Second, lgr.NoOp, cfg)
+
+	scheduler.runEpochCycle(context.Background())
+	assert.Empty(t, mockSignerService.CheckBalanceCalls(), "followers do not touch the chain")
+	assert.Empty(t, mockEpochService.StartEpochCalls())
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls())
+
+	isLeader = true
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+}
+
+func TestScheduler_runEpochCycle_Paused(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+
+	pausedJobs := map[string]bool{pause.JobAll: true}
+	var pauseErr error
+	mockPauses := &pause.ServiceMock{
+		IsPausedFunc: func(ctx context.Context, job string) (bool, error) {
+			return pausedJobs[job], pauseErr
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, mockPauses, nil, nil, 10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+
+	scheduler.runEpochCycle(context.Background())
+	assert.Empty(t, mockEpochService.StartEpochCalls(), "nothing runs while the scheduler is paused")
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls())
+
+	pausedJobs = map[string]bool{pause.JobDistribute: true}
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1)
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls(), "only the paused job is skipped")
+
+	pausedJobs, pauseErr = nil, fmt.Errorf("database closed")
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1, "an unreadable pause state counts as paused")
+
+	pauseErr = nil
+	scheduler.runEpochCycle(context.Background())
+	assert.Len(t, mockEpochService.StartEpochCalls(), 2)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+}
+
+func TestScheduler_RecordsJobRuns(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return nil, fmt.Errorf("epoch already started")
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+		SetNextRunFunc: func(ctx context.Context, job string, next *time.Time) error {
+			return fmt.Errorf("database closed")
+		},
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, 10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+
+	err := scheduler.runEpochCycle(context.Background())
+	require.Error(t, err)
+	calls := mockRuns.RecordRunCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, pause.JobStartEpoch, calls[0].Run.Job)
+	assert.Equal(t, jobs.OutcomeFailed, calls[0].Run.Outcome)
+	assert.Contains(t, calls[0].Run.Error, "epoch already started")
+	assert.Equal(t, "scheduler", calls[0].Run.Actor)
+	assert.Equal(t, pause.JobDistribute, calls[1].Run.Job)
+	assert.Equal(t, jobs.OutcomeSucceeded, calls[1].Run.Outcome)
+	assert.Empty(t, calls[1].Run.Error)
+
+	// interval mode runs every job at the next tick, but catch-up only until it caught up
+	next := time.Now().Add(10 * time.Second)
+	scheduler.scheduleNextRuns(context.Background(), scheduler.upcomingBoundary(next))
+	scheduled := mockRuns.SetNextRunCalls()
+	require.Len(t, scheduled, len(jobs.Jobs), "a failure to record the next run is only logged")
+	for _, call := range scheduled {
+		if call.Job == pause.JobCatchUp {
+			assert.Nil(t, call.Next)
+			continue
+		}
+		require.NotNil(t, call.Next)
+		assert.True(t, next.Equal(*call.Next))
+	}
+}
+
+func TestScheduler_ReconcilesAfterDistribution(t *testing.T) {
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{EpochID: "3"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	var reconcileErr, claimsErr error
+	mockReconciler := &reconciliation.ReconcilerMock{
+		ReconcileFunc: func(ctx context.Context, vaultId string) (*reconciliation.Report, error) {
+			if reconcileErr != nil {
+				return nil, reconcileErr
+			}
+			return &reconciliation.Report{VaultID: vaultId, EpochID: "2", Flagged: true}, nil
+		},
+		ReconcileClaimsFunc: func(ctx context.Context, vaultId string) (*reconciliation.ClaimsReport, error) {
+			if claimsErr != nil {
+				return nil, claimsErr
+			}
+			return &reconciliation.ClaimsReport{VaultID: vaultId, EpochID: "2", Accounts: 3}, nil
+		},
+	}
+	pausedJobs := map[string]bool{}
+	mockPauses := &pause.ServiceMock{
+		IsPausedFunc: func(ctx context.Context, job string) (bool, error) { return pausedJobs[job], nil },
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+	lastRun := func() jobs.Run {
+		calls := mockRuns.RecordRunCalls()
+		return calls[len(calls)-1].Run
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, mockPauses, mockRuns, mockReconciler,
+		10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()), "a flagged report is not a failed run")
+	require.Len(t, mockReconciler.ReconcileCalls(), 1)
+	assert.Equal(t, cfg.Contracts.CollectionsVault, mockReconciler.ReconcileCalls()[0].VaultId)
+	require.Len(t, mockReconciler.ReconcileClaimsCalls(), 1, "claims are reconciled with the yield")
+	assert.Equal(t, pause.JobReconcile, lastRun().Job)
+	assert.Equal(t, jobs.OutcomeSucceeded, lastRun().Outcome)
+
+	claimsErr = fmt.Errorf("rpc unavailable")
+	require.ErrorContains(t, scheduler.runEpochCycle(context.Background()), "failed to reconcile claims")
+	assert.Equal(t, jobs.OutcomeFailed, lastRun().Outcome)
+	claimsErr = nil
+
+	reconcileErr = fmt.Errorf("%w: vault", reconciliation.ErrNoDistribution)
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Equal(t, jobs.OutcomeSkipped, lastRun().Outcome, "nothing to reconcile before the first distribution")
+
+	reconcileErr = fmt.Errorf("rpc unavailable")
+	require.ErrorContains(t, scheduler.runEpochCycle(context.Background()), "failed to reconcile yield")
+	assert.Equal(t, jobs.OutcomeFailed, lastRun().Outcome)
+
+	pausedJobs[pause.JobReconcile] = true
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockReconciler.ReconcileCalls(), 4)
+	assert.Len(t, mockReconciler.ReconcileClaimsCalls(), 2, "claims are not reconciled without a yield report")
+	assert.Equal(t, jobs.OutcomeSkipped, lastRun().Outcome)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 5, "only the paused job is skipped")
+}
+
+func TestScheduler_AllocatesYieldAfterStart(t *testing.T) {
+	var allocateErr error
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{EpochID: "3"}, nil
+		},
+		AllocateYieldFunc: func(ctx context.Context, vaultId string) (*epoch.YieldAllocation, error) {
+			if allocateErr != nil {
+				return nil, allocateErr
+			}
+			return &epoch.YieldAllocation{EpochID: "3", VaultAddress: vaultId, Allocated: "900", HeldBack: "100"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+	runOf := func(job string) jobs.Run {
+		calls := mockRuns.RecordRunCalls()
+		for i := len(calls) - 1; i >= 0; i-- {
+			if calls[i].Run.Job == job {
+				return calls[i].Run
+			}
+		}
+		return jobs.Run{}
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, 10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Empty(t, mockEpochService.AllocateYieldCalls(), "yield is only allocated when enabled")
+
+	cfg.Yield.Allocate = true
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	require.Len(t, mockEpochService.AllocateYieldCalls(), 1)
+	assert.Equal(t, cfg.Contracts.CollectionsVault, mockEpochService.AllocateYieldCalls()[0].VaultId)
+	assert.Equal(t, jobs.OutcomeSucceeded, runOf(pause.JobAllocateYield).Outcome)
+
+	allocateErr = fmt.Errorf("%w: epoch 3", epoch.ErrYieldAlreadyAllocated)
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Equal(t, jobs.OutcomeSkipped, runOf(pause.JobAllocateYield).Outcome)
+
+	allocateErr = fmt.Errorf("rpc unavailable")
+	require.ErrorContains(t, scheduler.runEpochCycle(context.Background()), "failed to allocate yield")
+	assert.Equal(t, jobs.OutcomeFailed, runOf(pause.JobAllocateYield).Outcome)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 4, "a failed allocation does not stop distribution")
+}
+
+func TestScheduler_TriggerManualMode(t *testing.T) {
+	actors := make(chan string, 1)
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			actors <- audit.ActorFromContext(ctx)
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	isLeader := false
+	mockElector := &leader.ElectorMock{IsLeaderFunc: func() bool { return isLeader }}
+
+	cfg := &config.Config{}
+	cfg.Scheduler.Mode = ModeManual
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, mockElector, nil, nil, nil, time.Millisecond, lgr.NoOp, cfg)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	_, err := scheduler.Trigger(ctx)
+	require.ErrorIs(t, err, ErrNotRunning)
+
+	runCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go scheduler.Start(runCtx)
+	require.Eventually(t, scheduler.running.Load, time.Second, time.Millisecond)
+
+	_, err = scheduler.Trigger(ctx)
+	require.ErrorIs(t, err, ErrCannotRun, "followers refuse triggered boundaries")
+
+	isLeader = true
+	result, err := scheduler.Trigger(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, ModeManual, result.Mode)
+	assert.Nil(t, result.NextBoundary)
+
+	select {
+	case actor := <-actors:
+		assert.Equal(t, "api:10.0.0.1", actor, "a triggered boundary is audited under the caller")
+	case <-time.After(time.Second):
+		t.Fatal("triggered boundary never ran")
+	}
+	require.Eventually(t, func() bool { return len(mockSubsidyService.DistributeSubsidiesCalls()) == 1 }, time.Second, time.Millisecond)
+
+	// manual mode runs no boundaries of its own despite the short interval
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1)
+}
+
+func TestScheduler_CalendarMode(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Scheduler.Mode = ModeCalendar
+	cfg.Scheduler.Calendar = "weekly:monday@00:00"
+	cfg.Scheduler.Timezone = "UTC"
+	scheduler := NewScheduler(&epoch.ServiceMock{}, &subsidy.ServiceMock{}, nil, nil, nil, nil, nil, nil, time.Hour, lgr.NoOp, cfg)
+	require.Equal(t, ModeCalendar, scheduler.mode)
+
+	// Thursday 2026-10-15 12:00 UTC
+	scheduler.now = func() time.Time { return time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC) }
+	assert.Equal(t, time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), scheduler.calendar.Next(scheduler.now()))
+
+	cfg.Scheduler.Calendar = "fortnightly@00:00"
+	scheduler = NewScheduler(&epoch.ServiceMock{}, &subsidy.ServiceMock{}, nil, nil, nil, nil, nil, nil, time.Hour, lgr.NoOp, cfg)
+	assert.Equal(t, ModeInterval, scheduler.mode, "an invalid calendar falls back to the interval")
+	assert.Nil(t, scheduler.calendar)
+}
+
+func TestScheduler_FollowsOnChainEpochClock(t *testing.T) {
+	timing := &epoch.EpochTiming{EpochID: "5", EndTime: 1_700_000_090, ChainTime: 1_700_000_000}
+	var timingErr error
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{EpochID: "6", Status: "started"}, nil
+		},
+		GetEpochTimingFunc: func(ctx context.Context) (*epoch.EpochTiming, error) { return timing, timingErr },
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, 10*time.Second, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+	scheduler.SetEpochClock(mockEpochService)
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Empty(t, mockEpochService.StartEpochCalls(), "no epoch is started while the current one runs")
+	assert.Empty(t, mockSubsidyService.DistributeSubsidiesCalls(), "no epoch is ended before its on-chain end")
+	runs := mockRuns.RecordRunCalls()
+	require.Len(t, runs, 2)
+	for _, run := range runs {
+		assert.Equal(t, jobs.OutcomeSkipped, run.Run.Outcome)
+		assert.Equal(t, "epoch not ended on-chain: epoch 5 ends at 2023-11-14T22:14:50Z", run.Run.Error)
+	}
+	assert.Equal(t, 90*time.Second, scheduler.deferral, "the boundary runs again at the epoch's end")
+
+	scheduler.deferral = 0
+	require.NoError(t, scheduler.runBoundary(context.Background(), false))
+	assert.Zero(t, scheduler.deferral, "triggered boundaries are not deferred")
+
+	timing = &epoch.EpochTiming{EpochID: "5", EndTime: 1_700_000_090, ChainTime: 1_700_000_090, Ended: true}
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockEpochService.StartEpochCalls(), 1)
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 1)
+
+	timing, timingErr = nil, fmt.Errorf("rpc unavailable")
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 2, "an unreadable clock leaves the check to the contracts")
+}
+
+func TestScheduler_DelaysDistributionForLaggingSubgraph(t *testing.T) {
+	lagging := true
+	mockEpochService := &epoch.ServiceMock{
+		StartEpochFunc: func(ctx context.Context) (*epoch.StartEpochResponse, error) {
+			return &epoch.StartEpochResponse{Status: "started"}, nil
+		},
+	}
+	mockSubsidyService := &subsidy.ServiceMock{
+		DistributeSubsidiesFunc: func(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+			if lagging {
+				return nil, fmt.Errorf("%w: indexed block 100 is 1200 blocks behind", subsidy.ErrSubgraphLagging)
+			}
+			return &subsidy.SubsidyDistributionResponse{Status: "completed"}, nil
+		},
+	}
+	mockRuns := &jobs.RecorderMock{
+		RecordRunFunc: func(ctx context.Context, run jobs.Run) error { return nil },
+	}
+
+	cfg := &config.Config{}
+	cfg.Contracts.CollectionsVault = "0x1234567890123456789012345678901234567890"
+	cfg.Scheduler.RetryBackoff = time.Minute
+	cfg.Subgraph.LagRetry = 5 * time.Minute
+	scheduler := NewScheduler(mockEpochService, mockSubsidyService, nil, nil, nil, nil, mockRuns, nil, time.Hour, lgr.NoOp, cfg)
+	scheduler.caughtUp = true
+
+	require.NoError(t, scheduler.runEpochCycle(context.Background()), "a delayed distribution is not a failure")
+	runs := mockRuns.RecordRunCalls()
+	require.Len(t, runs, 2)
+	assert.Equal(t, pause.JobDistribute, runs[1].Run.Job)
+	assert.Equal(t, jobs.OutcomeSkipped, runs[1].Run.Outcome)
+	assert.Contains(t, runs[1].Run.Error, "subgraph is too far behind the chain head")
+	assert.Equal(t, 5*time.Minute, scheduler.deferral, "the boundary runs again once the lag retry passed")
+	assert.Empty(t, scheduler.backoffs, "the job does not back off")
+
+	scheduler.deferral = 0
+	lagging = false
+	require.NoError(t, scheduler.runEpochCycle(context.Background()))
+	assert.Len(t, mockSubsidyService.DistributeSubsidiesCalls(), 2)
+	assert.Zero(t, scheduler.deferral)
+}
+
+func TestScheduler_RearmsDeferredBoundary(t *testing.T) {
+	scheduler := NewScheduler(&epoch.ServiceMock{}, &subsidy.ServiceMock{}, nil, nil, nil, nil, nil, nil, time.Hour, lgr.NoOp, &config.Config{})
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	scheduler.deferral = time.Millisecond
+	scheduler.rearm(timer)
+	assert.Zero(t, scheduler.deferral)
+	select {
+	case <-timer.C:
+	case <-time.After(time.Second):
+		t.Fatal("deferred boundary did not fire")
+	}
+}
@@ -0,0 +1,15 @@
+package signer
+
+import (
+	"time"
+)
+
+// BalanceStatus is the outcome of the latest signer balance check
+type BalanceStatus struct {
+	Address    string    `json:"address,omitempty" example:"0x742d35Cc6634C0532925a3b844Bc454e4438f44e"`
+	Balance    string    `json:"balance,omitempty" example:"250000000000000000"`    // wei
+	MinBalance string    `json:"minBalance,omitempty" example:"100000000000000000"` // wei, empty when halting is disabled
+	Halted     bool      `json:"halted"`
+	CheckedAt  time.Time `json:"checkedAt"` // time of the last successful check
+	LastError  string    `json:"lastError,omitempty"`
+}
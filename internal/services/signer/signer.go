@@ -0,0 +1,16 @@
+package signer
+
+import (
+	"context"
+)
+
+//go:generate moq -out signer_mocks.go . Service
+
+// Service watches the balance of the account that signs transactions.
+// Transaction-submitting jobs are halted while the balance is below the configured minimum.
+type Service interface {
+	// CheckBalance reads the current balance and updates the halt state and metrics
+	CheckBalance(ctx context.Context) (*BalanceStatus, error)
+	// Status returns the outcome of the last balance check without querying the chain
+	Status() BalanceStatus
+}
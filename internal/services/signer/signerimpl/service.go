@@ -0,0 +1,125 @@
+package signerimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/signer"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+// metric names exposed on /metrics
+const (
+	balanceMetric    = "epoch_server_signer_balance_wei"
+	minBalanceMetric = "epoch_server_signer_min_balance_wei"
+	haltedMetric     = "epoch_server_signer_halted"
+	checkedMetric    = "epoch_server_signer_balance_checked_timestamp_seconds"
+)
+
+type Service struct {
+	blockchainClient blockchain.BlockchainClient
+	notifier         webhook.Notifier
+	metrics          *metrics.Registry
+	logger           lgr.L
+	minBalance       *big.Int // nil when halting is disabled
+	now              func() time.Time
+
+	mu     sync.RWMutex
+	status signer.BalanceStatus
+}
+
+func New(
+	blockchainClient blockchain.BlockchainClient,
+	notifier webhook.Notifier,
+	registry *metrics.Registry,
+	logger lgr.L,
+	cfg *config.Config,
+) *Service {
+	s := &Service{
+		blockchainClient: blockchainClient,
+		notifier:         notifier,
+		metrics:          registry,
+		logger:           logger,
+		now:              time.Now,
+	}
+	// config.Load rejects malformed balances
+	if minBalance, ok := new(big.Int).SetString(cfg.Signer.MinBalance, 10); ok {
+		s.minBalance = minBalance
+		s.status.MinBalance = minBalance.String()
+	}
+	return s
+}
+
+// CheckBalance reads the signer balance and halts transaction-submitting jobs while it is below the minimum.
+// An alert is sent once when the balance drops below the minimum, not on every check.
+// A failed read keeps the previous halt state, since it says nothing about the balance.
+func (s *Service) CheckBalance(ctx context.Context) (_ *signer.BalanceStatus, err error) {
+	ctx, span := tracing.StartSpan(ctx, "signer.CheckBalance")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	balance, err := s.blockchainClient.GetSignerBalance(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil {
+		s.status.LastError = err.Error()
+		return nil, fmt.Errorf("failed to get signer balance: %w", err)
+	}
+
+	wasHalted := s.status.Halted
+	s.status.Address = balance.Address
+	s.status.Balance = balance.Balance.String()
+	s.status.Halted = s.minBalance != nil && balance.Balance.Cmp(s.minBalance) < 0
+	s.status.CheckedAt = s.now().UTC()
+	s.status.LastError = ""
+	s.recordMetrics(balance.Balance)
+
+	switch {
+	case s.status.Halted && !wasHalted:
+		s.logger.Logf("WARN signer %s balance %s wei is below minimum %s wei, pausing transaction-submitting jobs",
+			balance.Address, s.status.Balance, s.status.MinBalance)
+		s.notifier.Notify(ctx, webhook.EventLowSignerBalance, map[string]interface{}{
+			"signerAddress": balance.Address,
+			"balance":       s.status.Balance,
+			"minBalance":    s.status.MinBalance,
+		})
+	case !s.status.Halted && wasHalted:
+		s.logger.Logf("INFO signer %s balance %s wei is back above minimum %s wei, resuming transaction-submitting jobs",
+			balance.Address, s.status.Balance, s.status.MinBalance)
+	}
+
+	status := s.status
+	return &status, nil
+}
+
+// Status returns the outcome of the last balance check
+func (s *Service) Status() signer.BalanceStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.status
+}
+
+// recordMetrics publishes the checked balance, float precision is enough for dashboards and alerts
+func (s *Service) recordMetrics(balance *big.Int) {
+	wei, _ := new(big.Float).SetInt(balance).Float64()
+	s.metrics.SetGauge(balanceMetric, "ETH balance of the transaction signer in wei", wei)
+	if s.minBalance != nil {
+		minWei, _ := new(big.Float).SetInt(s.minBalance).Float64()
+		s.metrics.SetGauge(minBalanceMetric, "Signer balance in wei below which transaction-submitting jobs are paused", minWei)
+	}
+	halted := 0.0
+	if s.status.Halted {
+		halted = 1
+	}
+	s.metrics.SetGauge(haltedMetric, "Whether transaction-submitting jobs are paused for a low signer balance", halted)
+	s.metrics.SetGauge(checkedMetric, "Unix time of the last successful signer balance check", float64(s.status.CheckedAt.Unix()))
+}
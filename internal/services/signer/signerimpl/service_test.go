@@ -0,0 +1,96 @@
+package signerimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/metrics"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+const testSigner = "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+
+// newTestService returns a service whose balance reads come from balances in order, nil entries fail
+func newTestService(minBalance string, balances ...*big.Int) (*Service, *webhook.NotifierMock, *metrics.Registry) {
+	calls := 0
+	chain := &blockchain.BlockchainClientMock{
+		GetSignerBalanceFunc: func(ctx context.Context) (*blockchain.SignerBalance, error) {
+			balance := balances[calls]
+			calls++
+			if balance == nil {
+				return nil, errors.New("connection refused")
+			}
+			return &blockchain.SignerBalance{Address: testSigner, Balance: balance}, nil
+		},
+	}
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+	registry := metrics.NewRegistry()
+
+	cfg := &config.Config{}
+	cfg.Signer.MinBalance = minBalance
+	return New(chain, notifier, registry, lgr.NoOp, cfg), notifier, registry
+}
+
+func TestService_CheckBalance_HaltsAndResumes(t *testing.T) {
+	svc, notifier, registry := newTestService("100", big.NewInt(150), big.NewInt(99), big.NewInt(50), nil, big.NewInt(100))
+	ctx := context.Background()
+
+	status, err := svc.CheckBalance(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Halted)
+	assert.Equal(t, testSigner, status.Address)
+	assert.Equal(t, "150", status.Balance)
+	assert.Equal(t, "100", status.MinBalance)
+	assert.Empty(t, notifier.NotifyCalls())
+
+	status, err = svc.CheckBalance(ctx)
+	require.NoError(t, err)
+	assert.True(t, status.Halted)
+	require.Len(t, notifier.NotifyCalls(), 1)
+	assert.Equal(t, webhook.EventLowSignerBalance, notifier.NotifyCalls()[0].EventType)
+	assert.Equal(t, "99", notifier.NotifyCalls()[0].Data["balance"])
+
+	_, err = svc.CheckBalance(ctx)
+	require.NoError(t, err)
+	assert.Len(t, notifier.NotifyCalls(), 1, "alert is sent once per drop below the minimum")
+
+	_, err = svc.CheckBalance(ctx)
+	require.Error(t, err)
+	assert.True(t, svc.Status().Halted, "a failed read keeps jobs halted")
+	assert.Contains(t, svc.Status().LastError, "connection refused")
+
+	status, err = svc.CheckBalance(ctx)
+	require.NoError(t, err)
+	assert.False(t, status.Halted, "a balance equal to the minimum is enough")
+	assert.Empty(t, status.LastError)
+
+	halted, _ := registry.Gauge(haltedMetric)
+	assert.Zero(t, halted)
+	balance, _ := registry.Gauge(balanceMetric)
+	assert.Equal(t, 100.0, balance)
+	minBalance, _ := registry.Gauge(minBalanceMetric)
+	assert.Equal(t, 100.0, minBalance)
+}
+
+func TestService_CheckBalance_HaltingDisabled(t *testing.T) {
+	svc, notifier, registry := newTestService("", big.NewInt(0))
+
+	status, err := svc.CheckBalance(context.Background())
+	require.NoError(t, err)
+	assert.False(t, status.Halted)
+	assert.Empty(t, status.MinBalance)
+	assert.Empty(t, notifier.NotifyCalls())
+
+	_, ok := registry.Gauge(minBalanceMetric)
+	assert.False(t, ok)
+	_, ok = registry.Gauge(balanceMetric)
+	assert.True(t, ok)
+}
@@ -2,6 +2,8 @@ package storage
 
 import (
 	"fmt"
+	"os"
+	"runtime/debug"
 
 	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/dgraph-io/badger/v4"
@@ -18,6 +20,10 @@ func ProvideClient(config storage.Config, logger lgr.L) (storage.StorageClient,
 	switch config.Type {
 	case "badger":
 		opts := badger.DefaultOptions(config.Path)
+		if config.LowMemory {
+			opts = LowMemoryOptions(opts)
+			applyLowMemoryLimit(logger)
+		}
 		opts.Logger = newBadgerLogger(logger)
 
 		db, err := badger.Open(opts)
@@ -29,13 +35,44 @@ func ProvideClient(config storage.Config, logger lgr.L) (storage.StorageClient,
 			db:     db,
 			logger: logger,
 		}, nil
+	case "sqlite":
+		client, err := OpenSQLite(config.Path, logger)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
 	default:
 		return nil, fmt.Errorf("unsupported storage type: %s", config.Type)
 	}
 }
 
-func (c *Client) GetDB() *badger.DB {
-	return c.db
+// LowMemoryOptions shrinks badger's memtables and block cache, which otherwise take most of the memory of
+// indexing a large tree's leaf proofs, and keeps values over 256 bytes such as those proofs in the value log
+// so they are not rewritten by every LSM compaction
+func LowMemoryOptions(opts badger.Options) badger.Options {
+	return opts.
+		WithMemTableSize(16 << 20).
+		WithNumMemtables(2).
+		WithBlockCacheSize(16 << 20).
+		WithValueThreshold(256)
+}
+
+// LowMemoryLimit is the soft memory limit low-memory mode holds the Go runtime to, leaving the rest of a 512MB
+// container to goroutine stacks and the runtime. Indexing a 1M-leaf tree keeps about 300MB live, which the
+// collector would otherwise let grow to twice that before collecting.
+const LowMemoryLimit = 400 << 20
+
+// applyLowMemoryLimit sets LowMemoryLimit unless GOMEMLIMIT set a limit of its own
+func applyLowMemoryLimit(logger lgr.L) {
+	if os.Getenv("GOMEMLIMIT") != "" {
+		return
+	}
+	debug.SetMemoryLimit(LowMemoryLimit)
+	logger.Logf("INFO low-memory mode holds the Go runtime to a soft memory limit of %d MiB", LowMemoryLimit>>20)
+}
+
+func (c *Client) GetDB() storage.DB {
+	return storage.Badger(c.db)
 }
 
 func (c *Client) Close() error {
@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"runtime/debug"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+)
+
+func TestProvideClient_LowMemoryLimitsRuntime(t *testing.T) {
+	previous := debug.SetMemoryLimit(-1)
+	defer debug.SetMemoryLimit(previous)
+
+	t.Setenv("GOMEMLIMIT", "1GiB")
+	client, err := ProvideClient(storage.Config{Type: "badger", Path: t.TempDir(), LowMemory: true}, lgr.NoOp)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+	assert.Equal(t, previous, debug.SetMemoryLimit(-1), "GOMEMLIMIT takes precedence")
+
+	t.Setenv("GOMEMLIMIT", "")
+	client, err = ProvideClient(storage.Config{Type: "badger", Path: t.TempDir(), LowMemory: true}, lgr.NoOp)
+	require.NoError(t, err)
+	require.NoError(t, client.Close())
+	assert.Equal(t, int64(LowMemoryLimit), debug.SetMemoryLimit(-1))
+}
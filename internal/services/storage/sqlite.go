@@ -0,0 +1,595 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/dgraph-io/badger/v4"
+	badgerpb "github.com/dgraph-io/badger/v4/pb"
+	"github.com/go-pkgz/lgr"
+	"google.golang.org/protobuf/proto"
+	_ "modernc.org/sqlite" // registers the pure Go sqlite driver, the server is built without cgo
+)
+
+// sqliteSchema keeps the whole keyspace in one table. Keys are the stores' text keys and values mostly JSON,
+// so state can be queried in place, e.g.
+// SELECT key, json_extract(value, '$.status') FROM kv WHERE key LIKE 'subsidy:staged:%'
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS kv (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	expires_at INTEGER NOT NULL DEFAULT 0
+)`
+
+const sqliteUpsert = `
+INSERT INTO kv (key, value, expires_at) VALUES (?, ?, ?)
+ON CONFLICT (key) DO UPDATE SET value = excluded.value, expires_at = excluded.expires_at`
+
+// sqliteLive matches the rows whose TTL has not passed, badger treats a key as gone from its ExpiresAt on
+const sqliteLive = `(expires_at = 0 OR expires_at > ?)`
+
+const (
+	sqliteIteratorPage  = 256  // rows an iterator reads per query
+	sqliteBatchSize     = 1000 // writes a write batch commits per transaction
+	sqliteBackupList    = 1000 // keys per KVList of a backup
+	sqliteSweepInterval = time.Minute
+
+	// sqliteVersion is the version of every key in a backup, sqlite keeps no history
+	sqliteVersion = 1
+	// bitDelete marks a delete marker in a badger backup
+	bitDelete byte = 1 << 0
+)
+
+// SQLiteClient serves the stores from a single SQLite file. Every transaction runs against the file and an
+// Update returns once its writes are committed, so nothing the stores write is held only in memory.
+type SQLiteClient struct {
+	db     *sqliteDB
+	logger lgr.L
+
+	cancel context.CancelFunc
+	done   chan struct{} // closed when the sweep of expired keys stopped
+}
+
+// OpenSQLite opens or creates the SQLite database at path
+func OpenSQLite(path string, logger lgr.L) (*SQLiteClient, error) {
+	if path == "" {
+		return nil, fmt.Errorf("sqlite database requires a file path")
+	}
+
+	dsn := func(txlock string) string {
+		return fmt.Sprintf("file:%s?_pragma=journal_mode(WAL)&_pragma=synchronous(FULL)&_pragma=busy_timeout(5000)&_txlock=%s",
+			path, txlock)
+	}
+
+	// sqlite takes one writer at a time, so updates share a single connection and queue for it. Their
+	// transactions take the write lock when they begin, a read upgraded to a write could fail with SQLITE_BUSY.
+	writer, err := sql.Open("sqlite", dsn("immediate"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	writer.SetMaxOpenConns(1)
+	if _, err := writer.Exec(sqliteSchema); err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to create sqlite schema: %w", err)
+	}
+
+	reader, err := sql.Open("sqlite", dsn("deferred"))
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	c := &SQLiteClient{
+		db:     &sqliteDB{writer: writer, reader: reader},
+		logger: logger,
+		done:   make(chan struct{}),
+	}
+	if err := c.sweep(); err != nil {
+		c.db.close()
+		return nil, err
+	}
+
+	var ctx context.Context
+	ctx, c.cancel = context.WithCancel(context.Background())
+	go func() {
+		defer close(c.done)
+		ticker := time.NewTicker(sqliteSweepInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := c.sweep(); err != nil {
+					logger.Logf("WARN %v", err)
+				}
+			}
+		}
+	}()
+
+	logger.Logf("INFO opened sqlite database %s", path)
+	return c, nil
+}
+
+// sweep deletes the rows whose TTL has passed. Reads skip them anyway, this only keeps the file from growing.
+func (c *SQLiteClient) sweep() error {
+	res, err := c.db.writer.Exec(`DELETE FROM kv WHERE expires_at != 0 AND expires_at <= ?`, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to delete expired sqlite keys: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n > 0 {
+		c.logger.Logf("DEBUG deleted %d expired keys from sqlite database", n)
+	}
+	return nil
+}
+
+func (c *SQLiteClient) GetDB() storage.DB {
+	return c.db
+}
+
+// Close stops the sweep of expired keys and closes the file
+func (c *SQLiteClient) Close() error {
+	c.cancel()
+	<-c.done
+	return c.db.close()
+}
+
+// sqliteDB runs the stores' transactions against the file
+type sqliteDB struct {
+	writer *sql.DB
+	reader *sql.DB
+}
+
+func (db *sqliteDB) close() error {
+	return errors.Join(db.reader.Close(), db.writer.Close())
+}
+
+func (db *sqliteDB) View(fn func(txn storage.Txn) error) error {
+	tx, err := db.reader.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txn := &sqliteTxn{tx: tx}
+	if err := fn(txn); err != nil {
+		return err
+	}
+	return txn.err
+}
+
+// Update commits the transaction before returning, an error from fn or from any of its reads rolls it back
+func (db *sqliteDB) Update(fn func(txn storage.Txn) error) error {
+	tx, err := db.writer.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sqlite transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	txn := &sqliteTxn{tx: tx, update: true}
+	if err := fn(txn); err != nil {
+		return err
+	}
+	if txn.err != nil {
+		return txn.err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sqlite transaction: %w", err)
+	}
+	return nil
+}
+
+func (db *sqliteDB) NewWriteBatch() storage.WriteBatch {
+	return &sqliteWriteBatch{db: db}
+}
+
+func (db *sqliteDB) DropPrefix(prefixes ...[]byte) error {
+	return db.Update(func(txn storage.Txn) error {
+		tx := txn.(*sqliteTxn).tx
+		for _, prefix := range prefixes {
+			query, args := `DELETE FROM kv WHERE key >= ?`, []any{string(prefix)}
+			if end := prefixEnd(prefix); end != nil {
+				query, args = query+` AND key < ?`, append(args, string(end))
+			}
+			if _, err := tx.Exec(query, args...); err != nil {
+				return fmt.Errorf("failed to drop prefix %s: %w", prefix, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Backup writes the keys that have not expired in badger's backup format, so a backup restores into
+// either backend. since is ignored, sqlite keeps no versions to take an incremental backup from.
+func (db *sqliteDB) Backup(w io.Writer, since uint64) (uint64, error) {
+	var version uint64
+	err := db.View(func(txn storage.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		list := &badgerpb.KVList{}
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			value, err := item.ValueCopy(nil)
+			if err != nil {
+				return err
+			}
+			list.Kv = append(list.Kv, &badgerpb.KV{
+				Key:       item.KeyCopy(nil),
+				Value:     value,
+				UserMeta:  []byte{0},
+				Version:   sqliteVersion,
+				ExpiresAt: item.ExpiresAt(),
+				Meta:      []byte{0},
+			})
+			if len(list.Kv) == sqliteBackupList {
+				if err := writeKVList(w, list); err != nil {
+					return err
+				}
+				list.Kv = list.Kv[:0]
+			}
+			version = sqliteVersion
+		}
+		if len(list.Kv) == 0 {
+			return nil
+		}
+		return writeKVList(w, list)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to back up sqlite database: %w", err)
+	}
+	return version, nil
+}
+
+func writeKVList(w io.Writer, list *badgerpb.KVList) error {
+	buf, err := proto.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.LittleEndian, uint64(len(buf))); err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+// Load restores a backup in badger's format. A key's versions are listed newest first, so the first one
+// decides the key: a delete marker or an expired version removes it, any other version is written.
+func (db *sqliteDB) Load(r io.Reader, _ int) error {
+	br := bufio.NewReaderSize(r, 16<<10)
+	batch := db.NewWriteBatch()
+	defer batch.Cancel()
+
+	var buf, lastKey []byte
+	now := uint64(time.Now().Unix())
+	for {
+		var size uint64
+		err := binary.Read(br, binary.LittleEndian, &size)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		if uint64(cap(buf)) < size {
+			buf = make([]byte, size)
+		}
+		if _, err := io.ReadFull(br, buf[:size]); err != nil {
+			return fmt.Errorf("failed to read backup: %w", err)
+		}
+		list := &badgerpb.KVList{}
+		if err := proto.Unmarshal(buf[:size], list); err != nil {
+			return fmt.Errorf("failed to decode backup: %w", err)
+		}
+
+		for _, kv := range list.Kv {
+			if kv.StreamDone || (lastKey != nil && bytes.Equal(kv.Key, lastKey)) {
+				continue
+			}
+			lastKey = kv.Key
+
+			deleted := len(kv.Meta) > 0 && kv.Meta[0]&bitDelete != 0
+			if deleted || (kv.ExpiresAt != 0 && kv.ExpiresAt <= now) {
+				err = batch.Delete(kv.Key)
+			} else {
+				err = batch.SetEntry(&badger.Entry{Key: kv.Key, Value: kv.Value, ExpiresAt: kv.ExpiresAt})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to load key %s: %w", kv.Key, err)
+			}
+		}
+	}
+
+	if err := batch.Flush(); err != nil {
+		return fmt.Errorf("failed to load backup: %w", err)
+	}
+	return nil
+}
+
+// sqliteTxn reads and writes within one SQL transaction
+type sqliteTxn struct {
+	tx     *sql.Tx
+	update bool
+	err    error // first failed read of an iterator, which has no other way to report it
+}
+
+func (txn *sqliteTxn) Get(key []byte) (storage.Item, error) {
+	if len(key) == 0 {
+		return nil, badger.ErrEmptyKey
+	}
+
+	item := &sqliteItem{txn: txn, key: bytes.Clone(key), loaded: true}
+	err := txn.tx.QueryRow(`SELECT value, expires_at FROM kv WHERE key = ? AND `+sqliteLive, string(key), time.Now().Unix()).
+		Scan(&item.value, &item.expiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, badger.ErrKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", key, err)
+	}
+	return item, nil
+}
+
+func (txn *sqliteTxn) Set(key, val []byte) error {
+	return txn.SetEntry(badger.NewEntry(key, val))
+}
+
+func (txn *sqliteTxn) SetEntry(e *badger.Entry) error {
+	if !txn.update {
+		return badger.ErrReadOnlyTxn
+	}
+	if len(e.Key) == 0 {
+		return badger.ErrEmptyKey
+	}
+	if _, err := txn.tx.Exec(sqliteUpsert, string(e.Key), string(e.Value), int64(e.ExpiresAt)); err != nil {
+		return fmt.Errorf("failed to write key %s: %w", e.Key, err)
+	}
+	return nil
+}
+
+func (txn *sqliteTxn) Delete(key []byte) error {
+	if !txn.update {
+		return badger.ErrReadOnlyTxn
+	}
+	if len(key) == 0 {
+		return badger.ErrEmptyKey
+	}
+	if _, err := txn.tx.Exec(`DELETE FROM kv WHERE key = ?`, string(key)); err != nil {
+		return fmt.Errorf("failed to delete key %s: %w", key, err)
+	}
+	return nil
+}
+
+func (txn *sqliteTxn) NewIterator(opt badger.IteratorOptions) storage.Iterator {
+	return &sqliteIterator{txn: txn, opt: opt}
+}
+
+// sqliteItem is a row read by Get or an iterator. An iterator that does not prefetch values reads only keys,
+// the value is read when asked for.
+type sqliteItem struct {
+	txn       *sqliteTxn
+	key       []byte
+	value     []byte
+	expiresAt uint64
+	loaded    bool
+}
+
+func (item *sqliteItem) Key() []byte {
+	return item.key
+}
+
+func (item *sqliteItem) KeyCopy(dst []byte) []byte {
+	return append(dst[:0], item.key...)
+}
+
+func (item *sqliteItem) Value(fn func(val []byte) error) error {
+	if !item.loaded {
+		err := item.txn.tx.QueryRow(`SELECT value FROM kv WHERE key = ?`, string(item.key)).Scan(&item.value)
+		if err != nil {
+			return fmt.Errorf("failed to read value of key %s: %w", item.key, err)
+		}
+		item.loaded = true
+	}
+	return fn(item.value)
+}
+
+func (item *sqliteItem) ValueCopy(dst []byte) ([]byte, error) {
+	err := item.Value(func(val []byte) error {
+		dst = append(dst[:0], val...)
+		return nil
+	})
+	return dst, err
+}
+
+func (item *sqliteItem) ExpiresAt() uint64 {
+	return item.expiresAt
+}
+
+// sqliteIterator walks the keys a page of rows at a time, so no query stays open while the transaction
+// writes. It positions like badger's: Seek finds the first key at or after the given one, or at or before
+// it when reversed, Rewind seeks to the prefix, and keys outside the prefix end the iteration.
+type sqliteIterator struct {
+	txn  *sqliteTxn
+	opt  badger.IteratorOptions
+	page []*sqliteItem
+	pos  int
+	last bool // the page holds the last rows of the iteration
+}
+
+func (it *sqliteIterator) Rewind() {
+	it.Seek(nil)
+}
+
+func (it *sqliteIterator) Seek(key []byte) {
+	if len(key) == 0 {
+		key = it.opt.Prefix
+	}
+	it.fetch(key, true)
+}
+
+func (it *sqliteIterator) Valid() bool {
+	return it.pos < len(it.page) && bytes.HasPrefix(it.page[it.pos].key, it.opt.Prefix)
+}
+
+func (it *sqliteIterator) ValidForPrefix(prefix []byte) bool {
+	return it.Valid() && bytes.HasPrefix(it.page[it.pos].key, prefix)
+}
+
+func (it *sqliteIterator) Next() {
+	it.pos++
+	if it.pos == len(it.page) && !it.last {
+		it.fetch(it.page[len(it.page)-1].key, false)
+	}
+}
+
+func (it *sqliteIterator) Item() storage.Item {
+	if it.pos >= len(it.page) {
+		return nil
+	}
+	return it.page[it.pos]
+}
+
+func (it *sqliteIterator) Close() {
+	it.page = nil
+}
+
+// fetch reads the page of rows from key on, with key itself when inclusive. An empty key starts at the first
+// key, or the last when reversed. Rows past the prefix are never valid, so the query stops at its end.
+func (it *sqliteIterator) fetch(key []byte, inclusive bool) {
+	it.page, it.pos, it.last = it.page[:0], 0, true
+
+	columns := "key, expires_at"
+	if it.opt.PrefetchValues {
+		columns = "key, value, expires_at"
+	}
+	where, args := []string{sqliteLive}, []any{time.Now().Unix()}
+	order, from := "ASC", ">"
+	if it.opt.Reverse {
+		order, from = "DESC", "<"
+	}
+	if inclusive {
+		from += "="
+	}
+	if len(key) > 0 {
+		where, args = append(where, "key "+from+" ?"), append(args, string(key))
+	}
+	if it.opt.Reverse && len(it.opt.Prefix) > 0 {
+		where, args = append(where, "key >= ?"), append(args, string(it.opt.Prefix))
+	}
+	if end := prefixEnd(it.opt.Prefix); !it.opt.Reverse && end != nil {
+		where, args = append(where, "key < ?"), append(args, string(end))
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM kv WHERE %s ORDER BY key %s LIMIT %d",
+		columns, strings.Join(where, " AND "), order, sqliteIteratorPage)
+	rows, err := it.txn.tx.Query(query, args...)
+	if err != nil {
+		it.fail(err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &sqliteItem{txn: it.txn, loaded: it.opt.PrefetchValues}
+		dest := []any{&item.key, &item.expiresAt}
+		if it.opt.PrefetchValues {
+			dest = []any{&item.key, &item.value, &item.expiresAt}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			it.fail(err)
+			return
+		}
+		it.page = append(it.page, item)
+	}
+	if err := rows.Err(); err != nil {
+		it.fail(err)
+		return
+	}
+	it.last = len(it.page) < sqliteIteratorPage
+}
+
+// fail ends the iteration and fails the transaction it runs in
+func (it *sqliteIterator) fail(err error) {
+	it.page, it.last = it.page[:0], true
+	if it.txn.err == nil {
+		it.txn.err = fmt.Errorf("failed to iterate sqlite keys: %w", err)
+	}
+}
+
+// sqliteWriteBatch commits its writes in transactions of sqliteBatchSize, and the rest on Flush
+type sqliteWriteBatch struct {
+	db      *sqliteDB
+	entries []*badger.Entry
+	deleted []bool // deleted[i] deletes the key of entries[i] instead of writing it
+}
+
+func (wb *sqliteWriteBatch) Set(key, val []byte) error {
+	return wb.SetEntry(badger.NewEntry(key, val))
+}
+
+func (wb *sqliteWriteBatch) SetEntry(e *badger.Entry) error {
+	return wb.add(e, false)
+}
+
+func (wb *sqliteWriteBatch) Delete(key []byte) error {
+	return wb.add(&badger.Entry{Key: key}, true)
+}
+
+func (wb *sqliteWriteBatch) add(e *badger.Entry, deleted bool) error {
+	wb.entries, wb.deleted = append(wb.entries, e), append(wb.deleted, deleted)
+	if len(wb.entries) < sqliteBatchSize {
+		return nil
+	}
+	return wb.commit()
+}
+
+func (wb *sqliteWriteBatch) commit() error {
+	err := wb.db.Update(func(txn storage.Txn) error {
+		for i, e := range wb.entries {
+			var err error
+			if wb.deleted[i] {
+				err = txn.Delete(e.Key)
+			} else {
+				err = txn.SetEntry(e)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	wb.Cancel()
+	return err
+}
+
+func (wb *sqliteWriteBatch) Flush() error {
+	if len(wb.entries) == 0 {
+		return nil
+	}
+	return wb.commit()
+}
+
+func (wb *sqliteWriteBatch) Cancel() {
+	wb.entries, wb.deleted = wb.entries[:0], wb.deleted[:0]
+}
+
+// prefixEnd is the first key after every key with prefix, nil when there is none
+func prefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,267 @@
+package storage
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+)
+
+func newTestSQLite(t *testing.T) (storage.StorageClient, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "epoch.db")
+	client, err := ProvideClient(storage.Config{Type: "sqlite", Path: path}, lgr.NoOp)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client, path
+}
+
+func newTestBadger(t *testing.T) storage.DB {
+	t.Helper()
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	db, err := badger.Open(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return storage.Badger(db)
+}
+
+func TestSQLiteClient_WritesThroughToFile(t *testing.T) {
+	client, path := newTestSQLite(t)
+	err := client.GetDB().Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte("subsidy:staged:a"), []byte(`{"status":"pending_approval"}`)); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte("subsidy:staged:b"), []byte(`{"status":"approved"}`)); err != nil {
+			return err
+		}
+		if err := txn.SetEntry(badger.NewEntry([]byte("jobs:lock"), []byte("a")).WithTTL(time.Hour)); err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry([]byte("jobs:stale"), []byte("b")).WithTTL(time.Second))
+	})
+	require.NoError(t, err)
+	require.NoError(t, client.GetDB().Update(func(txn storage.Txn) error {
+		return txn.Delete([]byte("subsidy:staged:b"))
+	}))
+
+	// committed writes are in the file while the client is still open
+	file, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer file.Close()
+	var status string
+	require.NoError(t, file.QueryRow(`SELECT json_extract(value, '$.status') FROM kv WHERE key = 'subsidy:staged:a'`).Scan(&status))
+	assert.Equal(t, "pending_approval", status)
+	var count int
+	require.NoError(t, file.QueryRow(`SELECT COUNT(*) FROM kv WHERE key LIKE 'subsidy:staged:%'`).Scan(&count))
+	assert.Equal(t, 1, count, "deleted keys are removed from the file")
+
+	time.Sleep(1100 * time.Millisecond) // let jobs:stale expire
+	reopened, err := ProvideClient(storage.Config{Type: "sqlite", Path: path}, lgr.NoOp)
+	require.NoError(t, err)
+	defer reopened.Close()
+	err = reopened.GetDB().View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte("subsidy:staged:a"))
+		require.NoError(t, err)
+		value, err := item.ValueCopy(nil)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"status":"pending_approval"}`, string(value))
+
+		lock, err := txn.Get([]byte("jobs:lock"))
+		require.NoError(t, err)
+		assert.NotZero(t, lock.ExpiresAt(), "TTLs survive a restart")
+
+		_, err = txn.Get([]byte("subsidy:staged:b"))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+		_, err = txn.Get([]byte("jobs:stale"))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound, "expired keys are not read")
+
+		assert.ErrorIs(t, txn.Set([]byte("subsidy:staged:c"), []byte("{}")), badger.ErrReadOnlyTxn)
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, file.QueryRow(`SELECT COUNT(*) FROM kv WHERE key = 'jobs:stale'`).Scan(&count))
+	assert.Zero(t, count, "expired keys are swept when the file is opened")
+}
+
+func TestSQLiteClient_FailedUpdateRollsBack(t *testing.T) {
+	client, _ := newTestSQLite(t)
+	db := client.GetDB()
+	require.NoError(t, db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte("queue:a"), []byte("1"))
+	}))
+
+	errAbort := errors.New("abort")
+	err := db.Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte("queue:a"), []byte("2")); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte("queue:b"), []byte("2")); err != nil {
+			return err
+		}
+		item, err := txn.Get([]byte("queue:b"))
+		require.NoError(t, err, "a transaction reads its own writes")
+		assert.Equal(t, []byte("2"), mustValue(t, item))
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	require.NoError(t, db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte("queue:a"))
+		require.NoError(t, err)
+		assert.Equal(t, []byte("1"), mustValue(t, item))
+		_, err = txn.Get([]byte("queue:b"))
+		assert.ErrorIs(t, err, badger.ErrKeyNotFound)
+		return nil
+	}))
+}
+
+// TestSQLiteClient_IteratesLikeBadger runs the iterations the stores use against both backends
+func TestSQLiteClient_IteratesLikeBadger(t *testing.T) {
+	client, _ := newTestSQLite(t)
+	backends := map[string]storage.DB{"badger": newTestBadger(t), "sqlite": client.GetDB()}
+
+	for _, db := range backends {
+		batch := db.NewWriteBatch()
+		for i := range 600 { // more than a page of the sqlite iterator and a batch of its write batch
+			require.NoError(t, batch.Set([]byte(fmt.Sprintf("merkle:proof:%04d", i)), []byte(fmt.Sprint(i))))
+		}
+		require.NoError(t, batch.Set([]byte("merkle:"), []byte("prefix")))
+		require.NoError(t, batch.Set([]byte("audit:entry:1"), []byte("a")))
+		require.NoError(t, batch.Set([]byte("vaults:1"), []byte("v")))
+		require.NoError(t, batch.SetEntry(badger.NewEntry([]byte("merkle:proof:0100"), []byte("gone")).WithTTL(-time.Second)))
+		require.NoError(t, batch.Delete([]byte("merkle:proof:0200")))
+		require.NoError(t, batch.Flush())
+	}
+
+	type iteration struct {
+		opts func(*badger.IteratorOptions)
+		seek []byte
+		none bool // badger finds no key
+	}
+	iterations := map[string]iteration{
+		"all":                {opts: func(*badger.IteratorOptions) {}},
+		"prefix":             {opts: func(o *badger.IteratorOptions) { o.Prefix = []byte("merkle:proof:") }},
+		"keys only":          {opts: func(o *badger.IteratorOptions) { o.Prefix = []byte("merkle:"); o.PrefetchValues = false }},
+		"reverse":            {opts: func(o *badger.IteratorOptions) { o.Reverse = true }},
+		"reverse prefix":     {opts: func(o *badger.IteratorOptions) { o.Prefix = []byte("merkle:"); o.Reverse = true }},
+		"reverse from":       {opts: func(o *badger.IteratorOptions) { o.Prefix = []byte("merkle:"); o.Reverse = true }, seek: []byte("merkle:proof:0300\xff")},
+		"seek":               {opts: func(*badger.IteratorOptions) {}, seek: []byte("merkle:proof:0150")},
+		"seek before prefix": {opts: func(o *badger.IteratorOptions) { o.Prefix = []byte("merkle:") }, seek: []byte("audit:"), none: true},
+	}
+	for name, iteration := range iterations {
+		t.Run(name, func(t *testing.T) {
+			results := map[string][]string{}
+			for backend, db := range backends {
+				require.NoError(t, db.View(func(txn storage.Txn) error {
+					opts := badger.DefaultIteratorOptions
+					iteration.opts(&opts)
+					it := txn.NewIterator(opts)
+					defer it.Close()
+					for it.Seek(iteration.seek); it.Valid(); it.Next() {
+						results[backend] = append(results[backend], string(it.Item().Key())+"="+string(mustValue(t, it.Item())))
+					}
+					return nil
+				}))
+			}
+			assert.Equal(t, iteration.none, len(results["badger"]) == 0)
+			assert.Equal(t, results["badger"], results["sqlite"])
+		})
+	}
+
+	for _, db := range backends {
+		require.NoError(t, db.DropPrefix([]byte("merkle:proof:")))
+	}
+	for backend, db := range backends {
+		var keys []string
+		require.NoError(t, db.View(func(txn storage.Txn) error {
+			it := txn.NewIterator(badger.DefaultIteratorOptions)
+			defer it.Close()
+			for it.Rewind(); it.Valid(); it.Next() {
+				keys = append(keys, string(it.Item().KeyCopy(nil)))
+			}
+			return nil
+		}))
+		assert.Equal(t, []string{"audit:entry:1", "merkle:", "vaults:1"}, keys, backend)
+	}
+}
+
+func TestSQLiteClient_BackupRestoresIntoEitherBackend(t *testing.T) {
+	client, _ := newTestSQLite(t)
+	source := newTestBadger(t)
+	require.NoError(t, source.Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte("merkle:snapshot:1"), []byte("root-1")); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte("merkle:snapshot:2"), []byte("stale")); err != nil {
+			return err
+		}
+		return txn.SetEntry(badger.NewEntry([]byte("jobs:lock"), []byte("a")).WithTTL(time.Hour))
+	}))
+	require.NoError(t, source.Update(func(txn storage.Txn) error {
+		if err := txn.Set([]byte("merkle:snapshot:2"), []byte("root-2")); err != nil {
+			return err
+		}
+		return txn.Delete([]byte("merkle:snapshot:1"))
+	}))
+
+	// badger to sqlite, newest versions and delete markers decide each key
+	var fromBadger bytes.Buffer
+	_, err := source.Backup(&fromBadger, 0)
+	require.NoError(t, err)
+	require.NoError(t, client.GetDB().Update(func(txn storage.Txn) error {
+		return txn.Set([]byte("merkle:snapshot:1"), []byte("local"))
+	}))
+	require.NoError(t, client.GetDB().Load(&fromBadger, 16))
+	assert.Equal(t, map[string]string{"merkle:snapshot:2": "root-2", "jobs:lock": "a"}, dump(t, client.GetDB()))
+
+	// sqlite to badger
+	var fromSQLite bytes.Buffer
+	_, err = client.GetDB().Backup(&fromSQLite, 0)
+	require.NoError(t, err)
+	restored := newTestBadger(t)
+	require.NoError(t, restored.Load(&fromSQLite, 16))
+	assert.Equal(t, dump(t, client.GetDB()), dump(t, restored))
+	require.NoError(t, restored.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte("jobs:lock"))
+		require.NoError(t, err)
+		assert.NotZero(t, item.ExpiresAt())
+		return nil
+	}))
+}
+
+func TestProvideClient_SQLiteRequiresPath(t *testing.T) {
+	_, err := ProvideClient(storage.Config{Type: "sqlite"}, lgr.NoOp)
+	assert.Error(t, err)
+}
+
+func mustValue(t *testing.T, item storage.Item) []byte {
+	t.Helper()
+	value, err := item.ValueCopy(nil)
+	require.NoError(t, err)
+	return value
+}
+
+func dump(t *testing.T, db storage.DB) map[string]string {
+	t.Helper()
+	keys := map[string]string{}
+	require.NoError(t, db.View(func(txn storage.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			keys[string(it.Item().Key())] = string(mustValue(t, it.Item()))
+		}
+		return nil
+	}))
+	return keys
+}
@@ -0,0 +1,307 @@
+package subgraph
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// refreshTimeout bounds background revalidation so a hung subgraph can't pin a refresh forever
+	refreshTimeout = 30 * time.Second
+	// defaultCacheMaxEntries bounds the cache when the config leaves it unset
+	defaultCacheMaxEntries = 1000
+	// sweepInterval is how often storing a result also drops the entries past their stale window
+	sweepInterval = time.Minute
+)
+
+type cacheState int
+
+const (
+	cacheMiss cacheState = iota
+	cacheFresh
+	cacheStale
+)
+
+type cacheEntry struct {
+	key      string
+	data     json.RawMessage
+	storedAt time.Time
+	ttl      time.Duration
+}
+
+// queryCache keeps JSON-encoded query results. Entries are fresh for their ttl and then
+// served stale for up to staleTTL while a single background refresh replaces them.
+// Invalidation bumps the generation so refreshes started before it don't store old data.
+// At most maxEntries are kept, storing one more evicts the least recently used. Entries past
+// their stale window are swept as results are stored, at most once every sweepInterval, so
+// they don't linger until evicted.
+type queryCache struct {
+	mu         sync.Mutex
+	entries    map[string]*list.Element // of *cacheEntry
+	recent     *list.List               // most recently used first
+	refreshing map[string]bool
+	generation uint64
+	epoch      string
+	sweptAt    time.Time
+
+	ttl        time.Duration
+	blockTTL   time.Duration
+	staleTTL   time.Duration
+	maxEntries int
+	now        func() time.Time
+}
+
+func newQueryCache(ttl, blockTTL, staleTTL time.Duration, maxEntries int) *queryCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &queryCache{
+		entries:    make(map[string]*list.Element),
+		recent:     list.New(),
+		refreshing: make(map[string]bool),
+		ttl:        ttl,
+		blockTTL:   blockTTL,
+		staleTTL:   staleTTL,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		sweptAt:    time.Now(),
+	}
+}
+
+func (qc *queryCache) lookup(key string) (json.RawMessage, cacheState) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	elem, ok := qc.entries[key]
+	if !ok {
+		return nil, cacheMiss
+	}
+	entry := elem.Value.(*cacheEntry)
+
+	age := qc.now().Sub(entry.storedAt)
+	switch {
+	case age < entry.ttl:
+		qc.recent.MoveToFront(elem)
+		return entry.data, cacheFresh
+	case age < entry.ttl+qc.staleTTL:
+		qc.recent.MoveToFront(elem)
+		return entry.data, cacheStale
+	default:
+		qc.remove(elem)
+		return nil, cacheMiss
+	}
+}
+
+func (qc *queryCache) store(key string, data json.RawMessage, ttl time.Duration, generation uint64) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	if generation != qc.generation {
+		return
+	}
+
+	now := qc.now()
+	if now.Sub(qc.sweptAt) >= sweepInterval {
+		qc.sweep(now)
+	}
+
+	entry := &cacheEntry{key: key, data: data, storedAt: now, ttl: ttl}
+	if elem, ok := qc.entries[key]; ok {
+		elem.Value = entry
+		qc.recent.MoveToFront(elem)
+		return
+	}
+	qc.entries[key] = qc.recent.PushFront(entry)
+	for qc.recent.Len() > qc.maxEntries {
+		qc.remove(qc.recent.Back())
+	}
+}
+
+// sweep drops the entries past their stale window, which lookup would only report as misses
+func (qc *queryCache) sweep(now time.Time) {
+	qc.sweptAt = now
+	for elem := qc.recent.Back(); elem != nil; {
+		prev := elem.Prev()
+		entry := elem.Value.(*cacheEntry)
+		if now.Sub(entry.storedAt) >= entry.ttl+qc.staleTTL {
+			qc.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+func (qc *queryCache) remove(elem *list.Element) {
+	qc.recent.Remove(elem)
+	delete(qc.entries, elem.Value.(*cacheEntry).key)
+}
+
+// clear drops every entry, callers hold mu
+func (qc *queryCache) clear() {
+	qc.entries = make(map[string]*list.Element)
+	qc.recent.Init()
+}
+
+func (qc *queryCache) currentGeneration() uint64 {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	return qc.generation
+}
+
+// beginRefresh reports whether the caller should refresh key, ensuring one refresh per key at a time
+func (qc *queryCache) beginRefresh(key string) bool {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	if qc.refreshing[key] {
+		return false
+	}
+	qc.refreshing[key] = true
+	return true
+}
+
+func (qc *queryCache) endRefresh(key string) {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+	delete(qc.refreshing, key)
+}
+
+func (qc *queryCache) invalidate() {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	qc.clear()
+	qc.generation++
+}
+
+// observeEpoch invalidates the cache when the active epoch differs from the last one seen
+func (qc *queryCache) observeEpoch(epochNumber string) bool {
+	qc.mu.Lock()
+	defer qc.mu.Unlock()
+
+	changed := qc.epoch != "" && qc.epoch != epochNumber
+	qc.epoch = epochNumber
+	if changed {
+		qc.clear()
+		qc.generation++
+	}
+	return changed
+}
+
+// cacheKey identifies a request by its operation name plus a digest of query, variables and block
+func cacheKey(request subgraph.GraphQLRequest) (string, error) {
+	raw, err := json.Marshal(request)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal cache key: %w", err)
+	}
+	sum := sha256.Sum256(raw)
+	return operationName(request.Query) + ":" + hex.EncodeToString(sum[:]), nil
+}
+
+// queryFetcher executes a query, decoding its result into out
+type queryFetcher func(ctx context.Context, out interface{}) error
+
+// cachedQuery decodes the result for request into response, serving it from the cache when
+// possible. Stale entries are returned immediately and revalidated in the background; callers
+// that need current data mark ctx with subgraph.WithFreshData to bypass cached results.
+func (c *Client) cachedQuery(
+	ctx context.Context,
+	request subgraph.GraphQLRequest,
+	response interface{},
+	fetch queryFetcher,
+) error {
+	if c.cache == nil {
+		return fetch(ctx, response)
+	}
+
+	key, err := cacheKey(request)
+	if err != nil {
+		return err
+	}
+
+	ttl := c.cache.ttl
+	if request.Block != nil {
+		ttl = c.cache.blockTTL
+	}
+
+	span := trace.SpanFromContext(ctx)
+
+	if !subgraph.FreshDataRequired(ctx) {
+		data, state := c.cache.lookup(key)
+		switch state {
+		case cacheFresh:
+			span.SetAttributes(attribute.String("subgraph.cache", "hit"))
+			return json.Unmarshal(data, response)
+		case cacheStale:
+			span.SetAttributes(attribute.String("subgraph.cache", "stale"))
+			c.revalidate(ctx, key, ttl, response, fetch)
+			return json.Unmarshal(data, response)
+		}
+	}
+
+	span.SetAttributes(attribute.String("subgraph.cache", "miss"))
+
+	generation := c.cache.currentGeneration()
+	if err := fetch(ctx, response); err != nil {
+		return err
+	}
+	return c.storeResult(key, response, ttl, generation)
+}
+
+// InvalidateCache drops all cached query results, e.g. when an epoch starts or ends
+func (c *Client) InvalidateCache() {
+	if c.cache == nil {
+		return
+	}
+	c.cache.invalidate()
+	c.logger.Logf("DEBUG subgraph query cache invalidated")
+}
+
+// revalidate refreshes key in the background, decoding into a new value of response's type
+func (c *Client) revalidate(
+	ctx context.Context,
+	key string,
+	ttl time.Duration,
+	response interface{},
+	fetch queryFetcher,
+) {
+	if !c.cache.beginRefresh(key) {
+		return
+	}
+
+	generation := c.cache.currentGeneration()
+	target := reflect.New(reflect.TypeOf(response).Elem()).Interface()
+	refreshCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), refreshTimeout)
+
+	go func() {
+		defer cancel()
+		defer c.cache.endRefresh(key)
+
+		if err := fetch(refreshCtx, target); err != nil {
+			c.logger.Logf("WARN failed to revalidate cached subgraph query %s: %v", key, err)
+			return
+		}
+		if err := c.storeResult(key, target, ttl, generation); err != nil {
+			c.logger.Logf("WARN failed to cache revalidated subgraph query %s: %v", key, err)
+		}
+	}()
+}
+
+func (c *Client) storeResult(key string, result interface{}, ttl time.Duration, generation uint64) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached result: %w", err)
+	}
+	c.cache.store(key, data, ttl, generation)
+	return nil
+}
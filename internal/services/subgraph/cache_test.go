@@ -0,0 +1,246 @@
+package subgraph
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/go-pkgz/lgr"
+)
+
+// newCountingServer returns a subgraph stub whose account id reflects the number of requests served
+func newCountingServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":{"accountSubsidies":[{"id":"s%d","account":{"id":"user%d"},`+
+			`"secondsAccumulated":"1","collectionParticipation":{"id":"p1"}}]}}`, n, n)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newCachedClient(serverURL string, now *time.Time, mu *sync.Mutex) *Client {
+	client := ProvideClientWithConfig(subgraph.Config{
+		Endpoint:      serverURL,
+		CacheTTL:      time.Minute,
+		CacheStaleTTL: time.Minute,
+	}, lgr.NoOp).(*Client)
+	client.cache.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return *now
+	}
+	return client
+}
+
+func TestClient_CacheHit(t *testing.T) {
+	server, calls := newCountingServer(t)
+	now, mu := time.Now(), &sync.Mutex{}
+	client := newCachedClient(server.URL, &now, mu)
+
+	for i := 0; i < 3; i++ {
+		subsidies, err := client.QueryAccountSubsidiesForVault(context.Background(), "0xvault")
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if subsidies[0].Account.ID != "user1" {
+			t.Errorf("expected cached user1, got %s", subsidies[0].Account.ID)
+		}
+	}
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("expected 1 subgraph request, got %d", got)
+	}
+
+	if _, err := client.QueryAccountSubsidiesForVault(context.Background(), "0xother"); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected different variables to miss the cache, got %d requests", got)
+	}
+}
+
+func TestClient_CacheStaleWhileRevalidate(t *testing.T) {
+	server, calls := newCountingServer(t)
+	now, mu := time.Now(), &sync.Mutex{}
+	client := newCachedClient(server.URL, &now, mu)
+
+	if _, err := client.QueryAccountSubsidiesForVault(context.Background(), "0xvault"); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	mu.Lock()
+	now = now.Add(90 * time.Second)
+	mu.Unlock()
+
+	subsidies, err := client.QueryAccountSubsidiesForVault(context.Background(), "0xvault")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if subsidies[0].Account.ID != "user1" {
+		t.Errorf("expected stale user1 while revalidating, got %s", subsidies[0].Account.ID)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		subsidies, err = client.QueryAccountSubsidiesForVault(context.Background(), "0xvault")
+		if err != nil {
+			t.Fatalf("query failed: %v", err)
+		}
+		if subsidies[0].Account.ID == "user2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("background revalidation did not refresh the entry")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("expected exactly one revalidation request, got %d total", got)
+	}
+
+	mu.Lock()
+	now = now.Add(10 * time.Minute)
+	mu.Unlock()
+
+	subsidies, err = client.QueryAccountSubsidiesForVault(context.Background(), "0xvault")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if subsidies[0].Account.ID != "user3" {
+		t.Errorf("expected expired entry to be fetched synchronously, got %s", subsidies[0].Account.ID)
+	}
+}
+
+func TestClient_CacheBypassAndInvalidation(t *testing.T) {
+	server, calls := newCountingServer(t)
+	now, mu := time.Now(), &sync.Mutex{}
+	client := newCachedClient(server.URL, &now, mu)
+	ctx := context.Background()
+
+	if _, err := client.QueryAccountSubsidiesForVault(ctx, "0xvault"); err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+
+	subsidies, err := client.QueryAccountSubsidiesForVault(subgraph.WithFreshData(ctx), "0xvault")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if subsidies[0].Account.ID != "user2" {
+		t.Errorf("expected fresh data to bypass the cache, got %s", subsidies[0].Account.ID)
+	}
+
+	// the fresh result replaces the cached one
+	subsidies, err = client.QueryAccountSubsidiesForVault(ctx, "0xvault")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if subsidies[0].Account.ID != "user2" {
+		t.Errorf("expected cached user2, got %s", subsidies[0].Account.ID)
+	}
+
+	client.InvalidateCache()
+
+	subsidies, err = client.QueryAccountSubsidiesForVault(ctx, "0xvault")
+	if err != nil {
+		t.Fatalf("query failed: %v", err)
+	}
+	if subsidies[0].Account.ID != "user3" {
+		t.Errorf("expected invalidation to force a refetch, got %s", subsidies[0].Account.ID)
+	}
+	if got := calls.Load(); got != 3 {
+		t.Errorf("expected 3 subgraph requests, got %d", got)
+	}
+}
+
+func TestQueryCache_ObserveEpoch(t *testing.T) {
+	cache := newQueryCache(time.Minute, time.Hour, time.Minute, 0)
+	cache.store("key", []byte(`{}`), time.Minute, cache.currentGeneration())
+
+	if cache.observeEpoch("1") {
+		t.Errorf("first observed epoch should not invalidate")
+	}
+	if cache.observeEpoch("1") {
+		t.Errorf("unchanged epoch should not invalidate")
+	}
+	if _, state := cache.lookup("key"); state != cacheFresh {
+		t.Errorf("expected entry to survive while epoch is unchanged")
+	}
+
+	generation := cache.currentGeneration()
+	if !cache.observeEpoch("2") {
+		t.Errorf("epoch change should invalidate")
+	}
+	if _, state := cache.lookup("key"); state != cacheMiss {
+		t.Errorf("expected entry to be dropped at epoch boundary")
+	}
+
+	// results fetched before the boundary must not repopulate the cache
+	cache.store("key", []byte(`{}`), time.Minute, generation)
+	if _, state := cache.lookup("key"); state != cacheMiss {
+		t.Errorf("expected pre-boundary result to be discarded")
+	}
+}
+
+func TestQueryCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newQueryCache(time.Minute, time.Hour, time.Minute, 2)
+	generation := cache.currentGeneration()
+	cache.store("a", []byte(`{}`), time.Minute, generation)
+	cache.store("b", []byte(`{}`), time.Minute, generation)
+
+	if _, state := cache.lookup("a"); state != cacheFresh {
+		t.Fatalf("expected a to be cached")
+	}
+	cache.store("c", []byte(`{}`), time.Minute, generation)
+
+	if _, state := cache.lookup("b"); state != cacheMiss {
+		t.Errorf("expected the least recently used entry to be evicted")
+	}
+	for _, key := range []string{"a", "c"} {
+		if _, state := cache.lookup(key); state != cacheFresh {
+			t.Errorf("expected %s to stay cached", key)
+		}
+	}
+
+	cache.store("a", []byte(`{"updated":true}`), time.Minute, generation)
+	if got := len(cache.entries); got != 2 || cache.recent.Len() != 2 {
+		t.Errorf("expected storing a cached key to replace it, got %d entries", got)
+	}
+}
+
+func TestQueryCache_SweepsExpiredEntries(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	cache := newQueryCache(time.Minute, time.Hour, time.Minute, 0)
+	cache.now = func() time.Time { return now }
+	cache.sweptAt = now
+	generation := cache.currentGeneration()
+
+	cache.store("short", []byte(`{}`), time.Second, generation)
+	cache.store("block", []byte(`{}`), time.Hour, generation)
+
+	now = now.Add(30 * time.Second)
+	cache.store("other", []byte(`{}`), time.Minute, generation)
+	if _, ok := cache.entries["short"]; !ok {
+		t.Errorf("expected no sweep before the sweep interval")
+	}
+
+	now = now.Add(sweepInterval)
+	cache.store("other", []byte(`{}`), time.Minute, generation)
+	if _, ok := cache.entries["short"]; ok {
+		t.Errorf("expected the entry past its stale window to be swept")
+	}
+	for _, key := range []string{"block", "other"} {
+		if _, ok := cache.entries[key]; !ok {
+			t.Errorf("expected %s to survive the sweep", key)
+		}
+	}
+}
@@ -6,16 +6,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
 )
 
+// maxPageSize is the most entities a subgraph returns for one query
+const maxPageSize = 1000
+
 type Client struct {
 	httpClient *http.Client
 	endpoint   string
 	logger     lgr.L
+	cache      *queryCache
+	pageSize   int
+	schema     schemaState
 }
 
 var _ subgraph.SubgraphClient = (*Client)(nil)
@@ -27,7 +38,42 @@ func ProvideClient(endpoint string, logger lgr.L) subgraph.SubgraphClient {
 		},
 		endpoint: endpoint,
 		logger:   logger,
+		pageSize: maxPageSize,
+	}
+}
+
+// ProvideClientWithConfig creates a client from config, enabling the query cache when CacheTTL is set
+func ProvideClientWithConfig(config subgraph.Config, logger lgr.L) subgraph.SubgraphClient {
+	timeout := config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	client := &Client{
+		httpClient: &http.Client{
+			Timeout:   timeout,
+			Transport: config.Transport,
+		},
+		endpoint: config.Endpoint,
+		logger:   logger,
+		pageSize: maxPageSize,
+	}
+	client.schema.minVersion = config.MinSchemaVersion
+	if config.PageSize > 0 && config.PageSize < maxPageSize {
+		client.pageSize = config.PageSize
+	}
+
+	if config.CacheTTL > 0 {
+		blockTTL := config.CacheBlockTTL
+		if blockTTL < config.CacheTTL {
+			blockTTL = config.CacheTTL
+		}
+		client.cache = newQueryCache(config.CacheTTL, blockTTL, config.CacheStaleTTL, config.CacheMaxEntries)
+		logger.Logf("INFO subgraph query cache enabled (ttl %s, block ttl %s, stale ttl %s, max entries %d)",
+			config.CacheTTL, blockTTL, config.CacheStaleTTL, client.cache.maxEntries)
 	}
+
+	return client
 }
 
 func (c *Client) QueryAccounts(ctx context.Context) ([]subgraph.Account, error) {
@@ -51,99 +97,46 @@ func (c *Client) QueryAccounts(ctx context.Context) ([]subgraph.Account, error)
 
 	var response subgraph.AccountsResponse
 
-	if err := c.ExecutePaginatedQuery(ctx, query, map[string]interface{}{}, "accounts", &response); err != nil {
+	req := subgraph.GraphQLRequest{Query: query}
+	if err := c.cachedQuery(ctx, req, &response, func(ctx context.Context, out interface{}) error {
+		return c.ExecutePaginatedQuery(ctx, query, map[string]interface{}{}, "accounts", out)
+	}); err != nil {
 		return nil, fmt.Errorf("failed to query accounts: %w", err)
 	}
 
 	return response.Accounts, nil
 }
 
+// QueryAccountSubsidiesForVault collects every page of StreamAccountSubsidiesForVault.
+// Prefer streaming when the subsidies can be processed one page at a time.
 func (c *Client) QueryAccountSubsidiesForVault(
 	ctx context.Context,
 	vaultAddress string,
 ) ([]subgraph.AccountSubsidy, error) {
-	query := `
-		query GetAccountSubsidies($vaultId: String!, $first: Int!, $skip: Int!) {
-			accountSubsidies(
-				where: { 
-					collectionParticipation_: { vault: $vaultId }
-					secondsAccumulated_gt: "0" 
-				}
-				orderBy: id
-				orderDirection: asc
-				first: $first
-				skip: $skip
-			) {
-				id
-				account { id }
-				secondsAccumulated
-				secondsClaimed
-				lastEffectiveValue
-				updatedAtTimestamp
-				totalRewardsEarned
-				subsidiesAccrued
-				subsidiesClaimed
-				collectionParticipation { id }
-			}
-		}
-	`
-
-	variables := map[string]interface{}{
-		"vaultId": vaultAddress,
-		"first":   1000,
-		"skip":    0,
-	}
-
-	type AccountSubsidyWithNestedParticipation struct {
-		ID                      string           `json:"id"`
-		Account                 subgraph.Account `json:"account"`
-		SecondsAccumulated      string           `json:"secondsAccumulated"`
-		SecondsClaimed          string           `json:"secondsClaimed"`
-		LastEffectiveValue      string           `json:"lastEffectiveValue"`
-		UpdatedAtTimestamp      string           `json:"updatedAtTimestamp"`
-		TotalRewardsEarned      string           `json:"totalRewardsEarned"`
-		SubsidiesAccrued        string           `json:"subsidiesAccrued"`
-		SubsidiesClaimed        string           `json:"subsidiesClaimed"`
-		CollectionParticipation struct {
-			ID string `json:"id"`
-		} `json:"collectionParticipation"`
-	}
-
-	type CustomResponse struct {
-		AccountSubsidies []AccountSubsidyWithNestedParticipation `json:"accountSubsidies"`
-	}
-
-	var response CustomResponse
+	var subsidies []subgraph.AccountSubsidy
 
 	req := subgraph.GraphQLRequest{
-		Query:     query,
-		Variables: variables,
-	}
-
-	if err := c.executeQuery(ctx, req, &response); err != nil {
+		Query:     accountSubsidiesForVaultQuery,
+		Variables: map[string]interface{}{"vaultId": vaultAddress},
+	}
+	if err := c.cachedQuery(ctx, req, &subsidies, func(ctx context.Context, out interface{}) error {
+		all := out.(*[]subgraph.AccountSubsidy)
+		return c.StreamAccountSubsidiesForVault(ctx, vaultAddress, func(page []subgraph.AccountSubsidy) error {
+			*all = append(*all, page...)
+			return nil
+		})
+	}); err != nil {
 		return nil, fmt.Errorf("failed to query account subsidies for vault %s: %w", vaultAddress, err)
 	}
 
-	result := make([]subgraph.AccountSubsidy, len(response.AccountSubsidies))
-	for i, item := range response.AccountSubsidies {
-		result[i] = subgraph.AccountSubsidy{
-			ID:                      item.ID,
-			Account:                 item.Account,
-			SecondsAccumulated:      item.SecondsAccumulated,
-			SecondsClaimed:          item.SecondsClaimed,
-			LastEffectiveValue:      item.LastEffectiveValue,
-			UpdatedAtTimestamp:      item.UpdatedAtTimestamp,
-			TotalRewardsEarned:      item.TotalRewardsEarned,
-			SubsidiesAccrued:        item.SubsidiesAccrued,
-			SubsidiesClaimed:        item.SubsidiesClaimed,
-			CollectionParticipation: item.CollectionParticipation.ID,
-		}
-	}
-
-	return result, nil
+	return subsidies, nil
 }
 
-func (c *Client) executeQuery(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+func (c *Client) executeQuery(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "subgraph.query",
+		attribute.String("graphql.operation.name", operationName(request.Query)))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	c.logger.Logf("DEBUG executing GraphQL query: %s", request.Query)
 	c.logger.Logf("DEBUG with variables: %+v", request.Variables)
 
@@ -158,6 +151,7 @@ func (c *Client) executeQuery(ctx context.Context, request subgraph.GraphQLReque
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -409,6 +403,10 @@ func (c *Client) QueryCurrentActiveEpoch(ctx context.Context) (*subgraph.Epoch,
 		return nil, fmt.Errorf("no active epoch found")
 	}
 
+	if c.cache != nil && c.cache.observeEpoch(response.Epochs[0].EpochNumber) {
+		c.logger.Logf("INFO active epoch changed to %s, subgraph query cache invalidated", response.Epochs[0].EpochNumber)
+	}
+
 	return &response.Epochs[0], nil
 }
 
@@ -551,9 +549,14 @@ func (c *Client) QueryAccountSubsidiesAtBlock(
 
 	var response subgraph.AccountSubsidiesResponse
 
-	if err := c.ExecutePaginatedQueryAtBlock(
-		ctx, query, variables, "accountSubsidies", blockNumber, &response,
-	); err != nil {
+	req := subgraph.GraphQLRequest{
+		Query:     query,
+		Variables: variables,
+		Block:     &subgraph.BlockParameter{Number: &blockNumber},
+	}
+	if err := c.cachedQuery(ctx, req, &response, func(ctx context.Context, out interface{}) error {
+		return c.ExecutePaginatedQueryAtBlock(ctx, query, variables, "accountSubsidies", blockNumber, out)
+	}); err != nil {
 		return nil, fmt.Errorf(
 			"failed to query account subsidies at block %d for vault %s: %w",
 			blockNumber,
@@ -601,7 +604,10 @@ func (c *Client) QueryAccountSubsidiesForEpoch(
 
 	var response subgraph.AccountSubsidiesResponse
 
-	if err := c.ExecutePaginatedQuery(ctx, query, variables, "accountSubsidies", &response); err != nil {
+	req := subgraph.GraphQLRequest{Query: query, Variables: variables}
+	if err := c.cachedQuery(ctx, req, &response, func(ctx context.Context, out interface{}) error {
+		return c.ExecutePaginatedQuery(ctx, query, variables, "accountSubsidies", out)
+	}); err != nil {
 		return nil, fmt.Errorf(
 			"failed to query account subsidies for epoch timestamp %s vault %s: %w",
 			epochEndTimestamp,
@@ -619,8 +625,14 @@ func (c *Client) fetchAllPages(
 	variables map[string]interface{},
 	entityField string,
 	blockParam *subgraph.BlockParameter,
-) ([]json.RawMessage, error) {
-	const pageSize = 1000
+) (_ []json.RawMessage, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subgraph.paginatedQuery",
+		attribute.String("graphql.operation.name", operationName(queryTemplate)),
+		attribute.String("subgraph.entity", entityField),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	pageSize := c.pageSize
 	var allResults []json.RawMessage
 	skip := 0
 
@@ -662,6 +674,7 @@ func (c *Client) fetchAllPages(
 		}
 
 		allResults = append(allResults, entities...)
+		span.SetAttributes(attribute.Int("subgraph.entities", len(allResults)))
 
 		if len(entities) < pageSize {
 			break
@@ -703,3 +716,13 @@ func (c *Client) reconstructResponse(allResults []json.RawMessage, entityField s
 
 	return nil
 }
+
+// operationName extracts the GraphQL operation name from a query for span labelling
+func operationName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) < 2 || fields[0] != "query" || strings.HasPrefix(fields[1], "{") {
+		return "anonymous"
+	}
+	name, _, _ := strings.Cut(fields[1], "(")
+	return name
+}
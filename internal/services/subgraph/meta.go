@@ -0,0 +1,48 @@
+package subgraph
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+// indexedBlockQuery reads the block the subgraph has indexed up to
+const indexedBlockQuery = `
+	query IndexedBlock {
+		_meta {
+			block { number hash timestamp }
+			hasIndexingErrors
+		}
+	}
+`
+
+// IndexedBlock returns the latest block the subgraph has indexed
+func (c *Client) IndexedBlock(ctx context.Context) (*subgraph.IndexedBlock, error) {
+	var response struct {
+		Meta *struct {
+			Block struct {
+				Number    int64  `json:"number"`
+				Hash      string `json:"hash"`
+				Timestamp *int64 `json:"timestamp"`
+			} `json:"block"`
+			HasIndexingErrors bool `json:"hasIndexingErrors"`
+		} `json:"_meta"`
+	}
+	if err := c.executeQuery(ctx, subgraph.GraphQLRequest{Query: indexedBlockQuery}, &response); err != nil {
+		return nil, fmt.Errorf("failed to query the subgraph's indexed block: %w", err)
+	}
+	if response.Meta == nil {
+		return nil, fmt.Errorf("subgraph reported no indexed block")
+	}
+
+	block := &subgraph.IndexedBlock{
+		Number:            response.Meta.Block.Number,
+		Hash:              response.Meta.Block.Hash,
+		HasIndexingErrors: response.Meta.HasIndexingErrors,
+	}
+	if response.Meta.Block.Timestamp != nil {
+		block.Timestamp = *response.Meta.Block.Timestamp
+	}
+	return block, nil
+}
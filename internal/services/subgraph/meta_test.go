@@ -0,0 +1,55 @@
+package subgraph
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+func TestClient_IndexedBlock(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     *subgraph.IndexedBlock
+		wantErr  string
+	}{
+		{
+			name: "indexed",
+			response: `{"data":{"_meta":{"block":{"number":1200,"hash":"0xabc","timestamp":1700000000},` +
+				`"hasIndexingErrors":false}}}`,
+			want: &subgraph.IndexedBlock{Number: 1200, Hash: "0xabc", Timestamp: 1700000000},
+		},
+		{
+			name:     "without timestamp",
+			response: `{"data":{"_meta":{"block":{"number":1200,"hash":"0xabc","timestamp":null},"hasIndexingErrors":true}}}`,
+			want:     &subgraph.IndexedBlock{Number: 1200, Hash: "0xabc", HasIndexingErrors: true},
+		},
+		{name: "no meta", response: `{"data":{"_meta":null}}`, wantErr: "no indexed block"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			t.Cleanup(server.Close)
+			client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL}, lgr.NoOp)
+
+			block, err := client.IndexedBlock(context.Background())
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, block)
+		})
+	}
+}
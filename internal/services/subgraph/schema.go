@@ -0,0 +1,157 @@
+package subgraph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+// schemaRecheckInterval is how long a negotiated schema version is trusted before the next snapshot query
+// introspects the subgraph again, so a redeploy is noticed between epochs rather than after one
+const schemaRecheckInterval = 5 * time.Minute
+
+// schemaIntrospectionQuery lists the fields of every entity the server reads
+const schemaIntrospectionQuery = `
+	query SchemaIntrospection {
+		Account: __type(name: "Account") { fields { name } }
+		AccountSubsidy: __type(name: "AccountSubsidy") { fields { name } }
+		CollectionParticipation: __type(name: "CollectionParticipation") { fields { name } }
+		Collection: __type(name: "Collection") { fields { name } }
+		Epoch: __type(name: "Epoch") { fields { name } }
+		MerkleDistribution: __type(name: "MerkleDistribution") { fields { name } }
+		WrappedPosition: __type(name: "WrappedPosition") { fields { name } }
+	}
+`
+
+// schemaFieldsV1 are the entity fields the server reads from a schema v1 subgraph
+var schemaFieldsV1 = map[string][]string{
+	"Account": {"id", "totalBorrowVolume"},
+	"AccountSubsidy": {
+		"id", "account", "secondsAccumulated", "secondsClaimed", "lastEffectiveValue", "updatedAtTimestamp",
+		"totalRewardsEarned", "subsidiesAccrued", "subsidiesClaimed", "collectionParticipation",
+	},
+	"CollectionParticipation": {"id", "collection", "vault"},
+	"Collection":              {"id"},
+	"Epoch":                   {"epochNumber", "status", "startTimestamp", "endTimestamp"},
+	"MerkleDistribution":      {"id", "merkleRoot", "totalAmount"},
+}
+
+// schemaFieldsV2 are the fields schema v2 adds to v1
+var schemaFieldsV2 = map[string][]string{
+	"Collection":      {"collectionType"},
+	"WrappedPosition": {"id", "wrapper", "owner", "balance", "collectionParticipation"},
+}
+
+// schemaV1Rewrites adapt the queries written for the latest schema to v1, which has no collection type
+var schemaV1Rewrites = strings.NewReplacer("collection { id collectionType }", "collection { id }")
+
+// schemaState is the schema version negotiated with the subgraph. Version is zero until NegotiateSchema
+// is called, queries are then sent as written for the latest version.
+type schemaState struct {
+	mu         sync.Mutex
+	version    int
+	checkedAt  time.Time
+	minVersion int
+}
+
+// NegotiateSchema introspects the subgraph and records the latest schema version it fully serves
+func (c *Client) NegotiateSchema(ctx context.Context) (*subgraph.Schema, error) {
+	var response map[string]*struct {
+		Fields []struct {
+			Name string `json:"name"`
+		} `json:"fields"`
+	}
+	if err := c.executeQuery(ctx, subgraph.GraphQLRequest{Query: schemaIntrospectionQuery}, &response); err != nil {
+		return nil, fmt.Errorf("failed to introspect the subgraph schema: %w", err)
+	}
+	served := make(map[string]bool)
+	for entity, entityType := range response {
+		if entityType == nil {
+			continue
+		}
+		for _, field := range entityType.Fields {
+			served[entity+"."+field.Name] = true
+		}
+	}
+
+	missingV1 := missingFields(served, schemaFieldsV1)
+	if len(missingV1) > 0 {
+		return nil, fmt.Errorf("%w: missing %s", subgraph.ErrSchemaIncompatible, strings.Join(missingV1, ", "))
+	}
+	schema := &subgraph.Schema{Version: subgraph.SchemaV2, Missing: missingFields(served, schemaFieldsV2)}
+	if len(schema.Missing) > 0 {
+		schema.Version = subgraph.SchemaV1
+	}
+
+	c.schema.mu.Lock()
+	defer c.schema.mu.Unlock()
+	if schema.Version < c.schema.minVersion {
+		return schema, fmt.Errorf("%w: subgraph serves schema v%d, v%d or later is required, missing %s",
+			subgraph.ErrSchemaIncompatible, schema.Version, c.schema.minVersion, strings.Join(schema.Missing, ", "))
+	}
+	if schema.Version != c.schema.version {
+		if schema.Version < subgraph.SchemaLatest {
+			c.logger.Logf("WARN subgraph serves schema v%d, queries are adapted to it (missing %s)",
+				schema.Version, strings.Join(schema.Missing, ", "))
+		} else {
+			c.logger.Logf("INFO subgraph serves schema v%d", schema.Version)
+		}
+	}
+	c.schema.version = schema.Version
+	c.schema.checkedAt = time.Now()
+	return schema, nil
+}
+
+// schemaVersion returns the version queries are adapted to. Once a version was negotiated it is negotiated
+// again when it is older than schemaRecheckInterval; a subgraph that cannot be introspected keeps the last
+// version, one that became incompatible fails the query.
+func (c *Client) schemaVersion(ctx context.Context) (int, error) {
+	c.schema.mu.Lock()
+	version, stale := c.schema.version, time.Since(c.schema.checkedAt) > schemaRecheckInterval
+	c.schema.mu.Unlock()
+
+	if version == 0 {
+		return subgraph.SchemaLatest, nil
+	}
+	if !stale {
+		return version, nil
+	}
+
+	schema, err := c.NegotiateSchema(ctx)
+	if err != nil {
+		if errors.Is(err, subgraph.ErrSchemaIncompatible) {
+			return 0, err
+		}
+		c.logger.Logf("WARN failed to recheck the subgraph schema, keeping v%d: %v", version, err)
+		return version, nil
+	}
+	return schema.Version, nil
+}
+
+// adaptQuery rewrites a query written for the latest schema to version
+func adaptQuery(query string, version int) string {
+	if version == subgraph.SchemaV1 {
+		return schemaV1Rewrites.Replace(query)
+	}
+	return query
+}
+
+// missingFields returns the Entity.field pairs of required that served lacks, sorted
+func missingFields(served map[string]bool, required map[string][]string) []string {
+	var missing []string
+	for entity, fields := range required {
+		for _, field := range fields {
+			if !served[entity+"."+field] {
+				missing = append(missing, entity+"."+field)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,151 @@
+package subgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+// schemaServer introspects as the schema version it is set to and serves one account subsidy, recording
+// the subsidy queries it was sent
+type schemaServer struct {
+	mu      sync.Mutex
+	version int
+	queries []string
+}
+
+func (s *schemaServer) setVersion(version int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.version = version
+}
+
+func (s *schemaServer) start(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req subgraph.GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		if strings.Contains(req.Query, "SchemaIntrospection") {
+			served := []map[string][]string{schemaFieldsV1}
+			if s.version >= subgraph.SchemaV2 {
+				served = append(served, schemaFieldsV2)
+			}
+			fields := map[string][]map[string]string{}
+			for _, entities := range served {
+				for entity, names := range entities {
+					for _, name := range names {
+						fields[entity] = append(fields[entity], map[string]string{"name": name})
+					}
+				}
+			}
+			data := map[string]interface{}{}
+			for entity, entityFields := range fields {
+				data[entity] = map[string]interface{}{"fields": entityFields}
+			}
+			if s.version == 0 {
+				data["AccountSubsidy"] = nil
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]interface{}{"data": data}))
+			return
+		}
+
+		s.queries = append(s.queries, req.Query)
+		collection := `{"id":"0xc1","collectionType":"ERC1155"}`
+		if strings.Contains(req.Query, "collection { id }") {
+			collection = `{"id":"0xc1"}`
+		}
+		_, _ = fmt.Fprintf(w, `{"data":{"accountSubsidies":[{"id":"s1","account":{"id":"0xuser"},`+
+			`"collectionParticipation":{"id":"p1","collection":%s}}]}}`, collection)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func streamCollectionTypes(t *testing.T, client subgraph.SubgraphClient) []string {
+	t.Helper()
+
+	var types []string
+	err := client.StreamAccountSubsidiesForVault(context.Background(), "0xvault", func(page []subgraph.AccountSubsidy) error {
+		for _, s := range page {
+			types = append(types, s.CollectionType)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return types
+}
+
+func TestClient_NegotiateSchema(t *testing.T) {
+	backend := &schemaServer{version: subgraph.SchemaV2}
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: backend.start(t).URL}, lgr.NoOp)
+
+	schema, err := client.NegotiateSchema(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, subgraph.SchemaV2, schema.Version)
+	assert.Empty(t, schema.Missing)
+	assert.Equal(t, []string{subgraph.CollectionTypeERC1155}, streamCollectionTypes(t, client))
+	assert.Contains(t, backend.queries[0], "collectionType")
+}
+
+func TestClient_NegotiateSchema_AdaptsQueriesToV1(t *testing.T) {
+	backend := &schemaServer{version: subgraph.SchemaV1}
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: backend.start(t).URL}, lgr.NoOp)
+
+	schema, err := client.NegotiateSchema(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, subgraph.SchemaV1, schema.Version)
+	assert.Contains(t, schema.Missing, "Collection.collectionType")
+	assert.Contains(t, schema.Missing, "WrappedPosition.wrapper")
+
+	// v1 collections are ERC-721 and the query does not ask for a type
+	assert.Equal(t, []string{subgraph.CollectionTypeERC721}, streamCollectionTypes(t, client))
+	assert.NotContains(t, backend.queries[0], "collectionType")
+
+	_, err = client.QueryWrappedPositions(context.Background(), "0xvault", "0xwrapper")
+	assert.ErrorIs(t, err, subgraph.ErrSchemaIncompatible)
+}
+
+func TestClient_NegotiateSchema_Incompatible(t *testing.T) {
+	backend := &schemaServer{version: subgraph.SchemaV1}
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: backend.start(t).URL, MinSchemaVersion: subgraph.SchemaV2}, lgr.NoOp)
+
+	_, err := client.NegotiateSchema(context.Background())
+	require.ErrorIs(t, err, subgraph.ErrSchemaIncompatible)
+	assert.Contains(t, err.Error(), "v2 or later is required")
+
+	// a subgraph without the account subsidies the snapshots read matches no version
+	backend.setVersion(0)
+	_, err = ProvideClientWithConfig(subgraph.Config{Endpoint: backend.start(t).URL}, lgr.NoOp).NegotiateSchema(context.Background())
+	require.ErrorIs(t, err, subgraph.ErrSchemaIncompatible)
+	assert.Contains(t, err.Error(), "AccountSubsidy.secondsAccumulated")
+}
+
+func TestClient_SchemaRecheckedBeforeSnapshotQueries(t *testing.T) {
+	backend := &schemaServer{version: subgraph.SchemaV2}
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: backend.start(t).URL}, lgr.NoOp).(*Client)
+
+	_, err := client.NegotiateSchema(context.Background())
+	require.NoError(t, err)
+
+	// the subgraph is redeployed with the previous schema, noticed once the negotiated version is stale
+	backend.setVersion(subgraph.SchemaV1)
+	assert.Equal(t, []string{subgraph.CollectionTypeERC1155}, streamCollectionTypes(t, client))
+
+	client.schema.checkedAt = time.Now().Add(-2 * schemaRecheckInterval)
+	assert.Equal(t, []string{subgraph.CollectionTypeERC721}, streamCollectionTypes(t, client))
+}
@@ -61,3 +61,21 @@ func TestClient_DirectQuery(t *testing.T) {
 		t.Errorf("Expected user1, got %s", response.Accounts[0].ID)
 	}
 }
+
+func TestOperationName(t *testing.T) {
+	tests := []struct {
+		query    string
+		expected string
+	}{
+		{`query GetAccounts($first: Int!, $skip: Int!) { accounts { id } }`, "GetAccounts"},
+		{"\n\t\tquery HealthCheck {\n\t\t\t__schema { queryType { name } }\n\t\t}", "HealthCheck"},
+		{`query { accounts { id } }`, "anonymous"},
+		{`{ accounts { id } }`, "anonymous"},
+	}
+
+	for _, tt := range tests {
+		if got := operationName(tt.query); got != tt.expected {
+			t.Errorf("operationName(%q) = %q, expected %q", tt.query, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,614 @@
+package subgraph
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// accountSubsidiesForVaultQuery pages through a vault's account subsidies with an id cursor.
+// The account's borrow volume is the debt that debt-percentage caps are measured against.
+const accountSubsidiesForVaultQuery = `
+	query StreamAccountSubsidies($vaultId: String!, $first: Int!, $lastId: String!) {
+		accountSubsidies(
+			where: {
+				collectionParticipation_: { vault: $vaultId }
+				secondsAccumulated_gt: "0"
+				id_gt: $lastId
+			}
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			account { id totalBorrowVolume }
+			secondsAccumulated
+			secondsClaimed
+			lastEffectiveValue
+			updatedAtTimestamp
+			totalRewardsEarned
+			subsidiesAccrued
+			subsidiesClaimed
+			collectionParticipation { id collection { id collectionType } }
+		}
+	}
+`
+
+// accountSubsidiesForVaultAtBlockQuery is the stream pinned to a past block
+const accountSubsidiesForVaultAtBlockQuery = `
+	query StreamAccountSubsidiesAtBlock($vaultId: String!, $block: Int!, $first: Int!, $lastId: String!) {
+		accountSubsidies(
+			where: {
+				collectionParticipation_: { vault: $vaultId }
+				secondsAccumulated_gt: "0"
+				id_gt: $lastId
+			}
+			block: { number: $block }
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			account { id totalBorrowVolume }
+			secondsAccumulated
+			secondsClaimed
+			lastEffectiveValue
+			updatedAtTimestamp
+			totalRewardsEarned
+			subsidiesAccrued
+			subsidiesClaimed
+			collectionParticipation { id collection { id collectionType } }
+		}
+	}
+`
+
+// accountSubsidiesForAccountAtBlockQuery selects the same fields as the stream for a single account
+const accountSubsidiesForAccountAtBlockQuery = `
+	query GetAccountSubsidiesForAccountAtBlock($vaultId: String!, $accountId: String!, $block: Int!) {
+		accountSubsidies(
+			where: {
+				account: $accountId
+				collectionParticipation_: { vault: $vaultId }
+			}
+			block: { number: $block }
+			orderBy: id
+			orderDirection: asc
+			first: 1000
+		) {
+			id
+			account { id totalBorrowVolume }
+			secondsAccumulated
+			secondsClaimed
+			lastEffectiveValue
+			updatedAtTimestamp
+			totalRewardsEarned
+			subsidiesAccrued
+			subsidiesClaimed
+			collectionParticipation { id collection { id collectionType } }
+		}
+	}
+`
+
+// accountSubsidiesForAccountsAtBlockQuery pages through the subsidies of a batch of accounts with an id cursor
+const accountSubsidiesForAccountsAtBlockQuery = `
+	query GetAccountSubsidiesForAccountsAtBlock(
+		$vaultId: String!
+		$accountIds: [String!]!
+		$block: Int!
+		$first: Int!
+		$lastId: String!
+	) {
+		accountSubsidies(
+			where: {
+				account_in: $accountIds
+				collectionParticipation_: { vault: $vaultId }
+				id_gt: $lastId
+			}
+			block: { number: $block }
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			account { id totalBorrowVolume }
+			secondsAccumulated
+			secondsClaimed
+			lastEffectiveValue
+			updatedAtTimestamp
+			totalRewardsEarned
+			subsidiesAccrued
+			subsidiesClaimed
+			collectionParticipation { id collection { id collectionType } }
+		}
+	}
+`
+
+// wrappedPositionsQuery pages through what a wrapper contract holds for each owner in the vault's collections
+const wrappedPositionsQuery = `
+	query WrappedPositions($vaultId: String!, $wrapper: String!, $first: Int!, $lastId: String!) {
+		wrappedPositions(
+			where: {
+				wrapper: $wrapper
+				collectionParticipation_: { vault: $vaultId }
+				balance_gt: "0"
+				id_gt: $lastId
+			}
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			wrapper
+			owner { id totalBorrowVolume }
+			balance
+			collectionParticipation { id }
+		}
+	}
+`
+
+// wrappedPositionsAtBlockQuery is the wrapped positions query pinned to a past block
+const wrappedPositionsAtBlockQuery = `
+	query WrappedPositionsAtBlock($vaultId: String!, $wrapper: String!, $block: Int!, $first: Int!, $lastId: String!) {
+		wrappedPositions(
+			where: {
+				wrapper: $wrapper
+				collectionParticipation_: { vault: $vaultId }
+				balance_gt: "0"
+				id_gt: $lastId
+			}
+			block: { number: $block }
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			wrapper
+			owner { id totalBorrowVolume }
+			balance
+			collectionParticipation { id }
+		}
+	}
+`
+
+// accountBatchSize is the number of accounts looked up per request. It keeps the account_in
+// filter well inside the subgraph's query size limits.
+const accountBatchSize = 100
+
+// nftTransfersQuery pages through the transfers of tokens in the collections up to a timestamp
+const nftTransfersQuery = `
+	query NFTTransfers($collections: [String!]!, $until: BigInt!, $first: Int!, $lastId: String!) {
+		nftTransfers(
+			where: {
+				collection_in: $collections
+				timestamp_lte: $until
+				id_gt: $lastId
+			}
+			orderBy: id
+			orderDirection: asc
+			first: $first
+		) {
+			id
+			collection { id }
+			tokenId
+			from
+			to
+			amount
+			blockNumber
+			logIndex
+			timestamp
+		}
+	}
+`
+
+// vaultAccountSubsidy is an account subsidy as returned with its nested collection participation
+type vaultAccountSubsidy struct {
+	ID                      string           `json:"id"`
+	Account                 subgraph.Account `json:"account"`
+	SecondsAccumulated      string           `json:"secondsAccumulated"`
+	SecondsClaimed          string           `json:"secondsClaimed"`
+	LastEffectiveValue      string           `json:"lastEffectiveValue"`
+	UpdatedAtTimestamp      string           `json:"updatedAtTimestamp"`
+	TotalRewardsEarned      string           `json:"totalRewardsEarned"`
+	SubsidiesAccrued        string           `json:"subsidiesAccrued"`
+	SubsidiesClaimed        string           `json:"subsidiesClaimed"`
+	CollectionParticipation struct {
+		ID         string `json:"id"`
+		Collection struct {
+			ID             string `json:"id"`
+			CollectionType string `json:"collectionType"`
+		} `json:"collection"`
+	} `json:"collectionParticipation"`
+}
+
+// toAccountSubsidy flattens the subsidy queried from a subgraph serving schema version. Collections of
+// schema v1 subgraphs have no type and are all ERC-721.
+func (v vaultAccountSubsidy) toAccountSubsidy(version int) subgraph.AccountSubsidy {
+	if version == subgraph.SchemaV1 {
+		v.CollectionParticipation.Collection.CollectionType = subgraph.CollectionTypeERC721
+	}
+	return subgraph.AccountSubsidy{
+		ID:                      v.ID,
+		Account:                 v.Account,
+		SecondsAccumulated:      v.SecondsAccumulated,
+		SecondsClaimed:          v.SecondsClaimed,
+		LastEffectiveValue:      v.LastEffectiveValue,
+		UpdatedAtTimestamp:      v.UpdatedAtTimestamp,
+		TotalRewardsEarned:      v.TotalRewardsEarned,
+		SubsidiesAccrued:        v.SubsidiesAccrued,
+		SubsidiesClaimed:        v.SubsidiesClaimed,
+		CollectionParticipation: v.CollectionParticipation.ID,
+		Collection:              v.CollectionParticipation.Collection.ID,
+		CollectionType:          v.CollectionParticipation.Collection.CollectionType,
+	}
+}
+
+// StreamPaginatedQuery walks a query page by page and hands each page's entity array to fn.
+// Pages are fetched with an id cursor rather than skip, so every page costs the same and the
+// subgraph's skip limit does not apply: the template must declare $first and $lastId, filter
+// on id_gt: $lastId and order by id ascending. Results bypass the query cache, and an error
+// returned by fn stops the stream and is returned as is.
+func (c *Client) StreamPaginatedQuery(
+	ctx context.Context,
+	queryTemplate string,
+	variables map[string]interface{},
+	entityField string,
+	fn func(page json.RawMessage) error,
+) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "subgraph.streamQuery",
+		attribute.String("graphql.operation.name", operationName(queryTemplate)),
+		attribute.String("subgraph.entity", entityField),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	lastID := ""
+	streamed := 0
+	for {
+		pageVars := make(map[string]interface{}, len(variables)+2)
+		for k, v := range variables {
+			pageVars[k] = v
+		}
+		pageVars["first"] = c.pageSize
+		pageVars["lastId"] = lastID
+
+		var data map[string]json.RawMessage
+		if err := c.executeQuery(ctx, subgraph.GraphQLRequest{Query: queryTemplate, Variables: pageVars}, &data); err != nil {
+			return fmt.Errorf("failed to execute streaming query after id %q: %w", lastID, err)
+		}
+
+		page, ok := data[entityField]
+		if !ok {
+			return fmt.Errorf("missing %s field in response", entityField)
+		}
+
+		var ids []struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(page, &ids); err != nil {
+			return fmt.Errorf("failed to parse %s array: %w", entityField, err)
+		}
+		if len(ids) == 0 {
+			break
+		}
+
+		next := ids[len(ids)-1].ID
+		if next == "" {
+			return fmt.Errorf("%s entities must select id to be streamed", entityField)
+		}
+		if next <= lastID {
+			return fmt.Errorf("%s cursor did not advance past id %q, is the query ordered by id?", entityField, lastID)
+		}
+
+		if err := fn(page); err != nil {
+			return err
+		}
+		streamed += len(ids)
+		span.SetAttributes(attribute.Int("subgraph.entities", streamed))
+
+		if len(ids) < c.pageSize {
+			break
+		}
+		lastID = next
+	}
+
+	c.logger.Logf("DEBUG streamed %d %s", streamed, entityField)
+	return nil
+}
+
+// StreamAccountSubsidiesForVault hands the vault's account subsidies with accumulated seconds
+// to fn one page at a time, ordered by id
+func (c *Client) StreamAccountSubsidiesForVault(
+	ctx context.Context,
+	vaultAddress string,
+	fn func(page []subgraph.AccountSubsidy) error,
+) error {
+	variables := map[string]interface{}{"vaultId": vaultAddress}
+	if err := c.streamVaultAccountSubsidies(ctx, accountSubsidiesForVaultQuery, variables, fn); err != nil {
+		return fmt.Errorf("failed to stream account subsidies for vault %s: %w", vaultAddress, err)
+	}
+	return nil
+}
+
+// StreamAccountSubsidiesForVaultAtBlock streams the vault's account subsidies as the subgraph saw them
+// at blockNumber, so a past distribution can be rebuilt from the state it was computed from
+func (c *Client) StreamAccountSubsidiesForVaultAtBlock(
+	ctx context.Context,
+	vaultAddress string,
+	blockNumber int64,
+	fn func(page []subgraph.AccountSubsidy) error,
+) error {
+	variables := map[string]interface{}{"vaultId": vaultAddress, "block": blockNumber}
+	if err := c.streamVaultAccountSubsidies(ctx, accountSubsidiesForVaultAtBlockQuery, variables, fn); err != nil {
+		return fmt.Errorf("failed to stream account subsidies for vault %s at block %d: %w", vaultAddress, blockNumber, err)
+	}
+	return nil
+}
+
+// streamVaultAccountSubsidies streams account subsidies with query adapted to the subgraph's schema version,
+// these are the queries snapshots are built from
+func (c *Client) streamVaultAccountSubsidies(
+	ctx context.Context,
+	query string,
+	variables map[string]interface{},
+	fn func(page []subgraph.AccountSubsidy) error,
+) error {
+	version, err := c.schemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	return c.StreamPaginatedQuery(ctx, adaptQuery(query, version), variables, "accountSubsidies",
+		func(raw json.RawMessage) error {
+			var items []vaultAccountSubsidy
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return fmt.Errorf("failed to parse account subsidies: %w", err)
+			}
+
+			page := make([]subgraph.AccountSubsidy, len(items))
+			for i, item := range items {
+				page[i] = item.toAccountSubsidy(version)
+			}
+			return fn(page)
+		})
+}
+
+// QueryAccountSubsidiesForAccountAtBlock returns an account's subsidies in the vault as the subgraph
+// saw them at blockNumber, in the shape the distributor streams. An account holds one subsidy per
+// collection, so the result fits a single page.
+func (c *Client) QueryAccountSubsidiesForAccountAtBlock(
+	ctx context.Context,
+	vaultAddress string,
+	accountAddress string,
+	blockNumber int64,
+) ([]subgraph.AccountSubsidy, error) {
+	version, err := c.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	variables := map[string]interface{}{
+		"vaultId":   vaultAddress,
+		"accountId": accountAddress,
+		"block":     blockNumber,
+	}
+	req := subgraph.GraphQLRequest{
+		Query:     adaptQuery(accountSubsidiesForAccountAtBlockQuery, version),
+		Variables: variables,
+		Block:     &subgraph.BlockParameter{Number: &blockNumber},
+	}
+
+	var response struct {
+		AccountSubsidies []vaultAccountSubsidy `json:"accountSubsidies"`
+	}
+	if err := c.cachedQuery(ctx, req, &response, func(ctx context.Context, out interface{}) error {
+		return c.executeQuery(ctx, req, out)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to query account subsidies of %s at block %d for vault %s: %w",
+			accountAddress, blockNumber, vaultAddress, err)
+	}
+
+	subsidies := make([]subgraph.AccountSubsidy, len(response.AccountSubsidies))
+	for i, item := range response.AccountSubsidies {
+		subsidies[i] = item.toAccountSubsidy(version)
+	}
+	return subsidies, nil
+}
+
+// QueryAccountSubsidiesForAccountsAtBlock returns the subsidies in the vault of every account in accounts as
+// the subgraph saw them at blockNumber, keyed by normalized account address. Accounts are looked up
+// accountBatchSize at a time with an account_in filter, so explaining hundreds of users costs a handful
+// of requests. Accounts without subsidies are absent from the result.
+func (c *Client) QueryAccountSubsidiesForAccountsAtBlock(
+	ctx context.Context,
+	vaultAddress string,
+	accounts []string,
+	blockNumber int64,
+) (_ map[string][]subgraph.AccountSubsidy, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subgraph.queryAccountSubsidiesForAccounts",
+		attribute.String("vault.id", vaultAddress),
+		attribute.Int("subgraph.accounts", len(accounts)),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	seen := make(map[string]bool, len(accounts))
+	ids := make([]string, 0, len(accounts))
+	for _, account := range accounts {
+		id := utils.NormalizeAddress(account)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	result := make(map[string][]subgraph.AccountSubsidy, len(ids))
+	for start := 0; start < len(ids); start += accountBatchSize {
+		batch := ids[start:min(start+accountBatchSize, len(ids))]
+		variables := map[string]interface{}{
+			"vaultId":    vaultAddress,
+			"accountIds": batch,
+			"block":      blockNumber,
+		}
+		err := c.streamVaultAccountSubsidies(ctx, accountSubsidiesForAccountsAtBlockQuery, variables,
+			func(page []subgraph.AccountSubsidy) error {
+				for _, s := range page {
+					account := utils.NormalizeAddress(s.Account.ID)
+					result[account] = append(result[account], s)
+				}
+				return nil
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to query account subsidies of %d accounts at block %d for vault %s: %w",
+				len(batch), blockNumber, vaultAddress, err)
+		}
+	}
+	return result, nil
+}
+
+// wrappedPosition is a wrapped position as returned with its nested collection participation
+type wrappedPosition struct {
+	ID                      string           `json:"id"`
+	Wrapper                 string           `json:"wrapper"`
+	Owner                   subgraph.Account `json:"owner"`
+	Balance                 string           `json:"balance"`
+	CollectionParticipation struct {
+		ID string `json:"id"`
+	} `json:"collectionParticipation"`
+}
+
+// QueryWrappedPositions returns every position the wrapper holds for an owner in the vault's collections,
+// ordered by id. Results bypass the query cache like the subsidies they split.
+func (c *Client) QueryWrappedPositions(ctx context.Context, vaultAddress, wrapperAddress string) ([]subgraph.WrappedPosition, error) {
+	variables := map[string]interface{}{"vaultId": vaultAddress, "wrapper": utils.NormalizeAddress(wrapperAddress)}
+	positions, err := c.streamWrappedPositions(ctx, wrappedPositionsQuery, variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query positions wrapped by %s in vault %s: %w", wrapperAddress, vaultAddress, err)
+	}
+	return positions, nil
+}
+
+// QueryWrappedPositionsAtBlock returns the wrapper's positions as the subgraph saw them at blockNumber
+func (c *Client) QueryWrappedPositionsAtBlock(
+	ctx context.Context,
+	vaultAddress string,
+	wrapperAddress string,
+	blockNumber int64,
+) ([]subgraph.WrappedPosition, error) {
+	variables := map[string]interface{}{
+		"vaultId": vaultAddress,
+		"wrapper": utils.NormalizeAddress(wrapperAddress),
+		"block":   blockNumber,
+	}
+	positions, err := c.streamWrappedPositions(ctx, wrappedPositionsAtBlockQuery, variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query positions wrapped by %s in vault %s at block %d: %w",
+			wrapperAddress, vaultAddress, blockNumber, err)
+	}
+	return positions, nil
+}
+
+// streamWrappedPositions streams wrapped positions, which schema v1 subgraphs do not index. Wrapper shares
+// cannot be split then, so the query fails rather than leave every subsidy with the wrapper.
+func (c *Client) streamWrappedPositions(
+	ctx context.Context,
+	query string,
+	variables map[string]interface{},
+) ([]subgraph.WrappedPosition, error) {
+	version, err := c.schemaVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version == subgraph.SchemaV1 {
+		return nil, fmt.Errorf("%w: schema v1 has no wrapped positions", subgraph.ErrSchemaIncompatible)
+	}
+
+	var positions []subgraph.WrappedPosition
+	err = c.StreamPaginatedQuery(ctx, query, variables, "wrappedPositions",
+		func(raw json.RawMessage) error {
+			var items []wrappedPosition
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return fmt.Errorf("failed to parse wrapped positions: %w", err)
+			}
+			for _, item := range items {
+				positions = append(positions, subgraph.WrappedPosition{
+					ID:                      item.ID,
+					Wrapper:                 item.Wrapper,
+					Owner:                   item.Owner,
+					CollectionParticipation: item.CollectionParticipation.ID,
+					Balance:                 item.Balance,
+				})
+			}
+			return nil
+		})
+	return positions, err
+}
+
+// nftTransfer is a transfer as returned with its nested collection and numbers as strings
+type nftTransfer struct {
+	ID         string `json:"id"`
+	Collection struct {
+		ID string `json:"id"`
+	} `json:"collection"`
+	TokenID     string `json:"tokenId"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Amount      string `json:"amount"`
+	BlockNumber string `json:"blockNumber"`
+	LogIndex    string `json:"logIndex"`
+	Timestamp   string `json:"timestamp"`
+}
+
+// QueryNFTTransfers returns every transfer of tokens in the collections up to untilTimestamp, ordered by block
+// and log index. Results bypass the query cache like the subsidies they are weighed against.
+func (c *Client) QueryNFTTransfers(ctx context.Context, collections []string, untilTimestamp int64) ([]subgraph.NFTTransfer, error) {
+	normalized := make([]string, len(collections))
+	for i, collection := range collections {
+		normalized[i] = utils.NormalizeAddress(collection)
+	}
+	variables := map[string]interface{}{"collections": normalized, "until": strconv.FormatInt(untilTimestamp, 10)}
+
+	var transfers []subgraph.NFTTransfer
+	err := c.StreamPaginatedQuery(ctx, nftTransfersQuery, variables, "nftTransfers",
+		func(raw json.RawMessage) error {
+			var items []nftTransfer
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return fmt.Errorf("failed to parse nft transfers: %w", err)
+			}
+			for _, item := range items {
+				transfer := subgraph.NFTTransfer{
+					ID:         item.ID,
+					Collection: item.Collection.ID,
+					TokenID:    item.TokenID,
+					From:       item.From,
+					To:         item.To,
+					Amount:     item.Amount,
+				}
+				var err error
+				if transfer.BlockNumber, err = strconv.ParseInt(item.BlockNumber, 10, 64); err != nil {
+					return fmt.Errorf("invalid blockNumber of nft transfer %s: %q", item.ID, item.BlockNumber)
+				}
+				if transfer.LogIndex, err = strconv.ParseInt(item.LogIndex, 10, 64); err != nil {
+					return fmt.Errorf("invalid logIndex of nft transfer %s: %q", item.ID, item.LogIndex)
+				}
+				if transfer.Timestamp, err = strconv.ParseInt(item.Timestamp, 10, 64); err != nil {
+					return fmt.Errorf("invalid timestamp of nft transfer %s: %q", item.ID, item.Timestamp)
+				}
+				transfers = append(transfers, transfer)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nft transfers up to %d: %w", untilTimestamp, err)
+	}
+
+	sort.SliceStable(transfers, func(i, j int) bool {
+		if transfers[i].BlockNumber != transfers[j].BlockNumber {
+			return transfers[i].BlockNumber < transfers[j].BlockNumber
+		}
+		return transfers[i].LogIndex < transfers[j].LogIndex
+	})
+	return transfers, nil
+}
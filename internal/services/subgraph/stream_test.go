@@ -0,0 +1,159 @@
+package subgraph
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+// newPagingServer serves n account subsidies ordered by id, honouring $first and $lastId
+func newPagingServer(t *testing.T, n int) (*httptest.Server, *[]map[string]interface{}) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req subgraph.GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req.Variables)
+		mu.Unlock()
+
+		first := int(req.Variables["first"].(float64))
+		lastID := req.Variables["lastId"].(string)
+
+		var items []string
+		for i := 0; i < n && len(items) < first; i++ {
+			id := fmt.Sprintf("s%03d", i)
+			if id > lastID {
+				items = append(items, fmt.Sprintf(`{"id":%q,"account":{"id":"user%d"},"secondsAccumulated":"1",`+
+					`"collectionParticipation":{"id":"p1"}}`, id, i))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":{"accountSubsidies":[%s]}}`, strings.Join(items, ","))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestClient_StreamAccountSubsidiesForVault(t *testing.T) {
+	server, requests := newPagingServer(t, 5)
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL, PageSize: 2}, lgr.NoOp)
+
+	var pages [][]string
+	err := client.StreamAccountSubsidiesForVault(context.Background(), "0xvault", func(page []subgraph.AccountSubsidy) error {
+		var ids []string
+		for _, s := range page {
+			ids = append(ids, s.ID)
+			assert.Equal(t, "p1", s.CollectionParticipation)
+		}
+		pages = append(pages, ids)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, [][]string{{"s000", "s001"}, {"s002", "s003"}, {"s004"}}, pages)
+	require.Len(t, *requests, 3, "a short page ends the stream")
+	var cursors []interface{}
+	for _, vars := range *requests {
+		cursors = append(cursors, vars["lastId"])
+		assert.Equal(t, "0xvault", vars["vaultId"])
+	}
+	assert.Equal(t, []interface{}{"", "s001", "s003"}, cursors)
+}
+
+func TestClient_StreamAccountSubsidiesForVault_StopsOnCallbackError(t *testing.T) {
+	server, requests := newPagingServer(t, 5)
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL, PageSize: 2}, lgr.NoOp)
+
+	stop := errors.New("stop")
+	err := client.StreamAccountSubsidiesForVault(context.Background(), "0xvault", func([]subgraph.AccountSubsidy) error {
+		return stop
+	})
+	assert.ErrorIs(t, err, stop)
+	assert.Len(t, *requests, 1)
+}
+
+func TestClient_StreamAccountSubsidiesForVaultAtBlock(t *testing.T) {
+	server, requests := newPagingServer(t, 3)
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL, PageSize: 2}, lgr.NoOp)
+
+	streamed := 0
+	err := client.StreamAccountSubsidiesForVaultAtBlock(context.Background(), "0xvault", 1234, func(page []subgraph.AccountSubsidy) error {
+		streamed += len(page)
+		return nil
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, streamed)
+	require.Len(t, *requests, 2)
+	for _, vars := range *requests {
+		assert.Equal(t, float64(1234), vars["block"], "every page is read at the same block")
+	}
+}
+
+func TestClient_QueryAccountSubsidiesForVault_CollectsAllPages(t *testing.T) {
+	server, _ := newPagingServer(t, 5)
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL, PageSize: 2}, lgr.NoOp)
+
+	subsidies, err := client.QueryAccountSubsidiesForVault(context.Background(), "0xvault")
+	require.NoError(t, err)
+	require.Len(t, subsidies, 5)
+	assert.Equal(t, "user4", subsidies[4].Account.ID)
+}
+
+func TestClient_QueryAccountSubsidiesForAccountsAtBlock(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req subgraph.GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(t, float64(77), req.Variables["block"])
+		accounts := req.Variables["accountIds"].([]interface{})
+		mu.Lock()
+		batches = append(batches, len(accounts))
+		mu.Unlock()
+
+		// every account holds a subsidy in two collections
+		var items []string
+		for _, account := range accounts {
+			for _, collection := range []string{"p1", "p2"} {
+				items = append(items, fmt.Sprintf(`{"id":"%s-%s","account":{"id":%q},"collectionParticipation":{"id":%q}}`,
+					account, collection, account, collection))
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"data":{"accountSubsidies":[%s]}}`, strings.Join(items, ","))
+	}))
+	t.Cleanup(server.Close)
+	client := ProvideClientWithConfig(subgraph.Config{Endpoint: server.URL, PageSize: 1000}, lgr.NoOp)
+
+	var accounts []string
+	for i := 0; i < 250; i++ {
+		accounts = append(accounts, fmt.Sprintf("0xAB%038d", i))
+	}
+	// a repeated account is looked up once
+	accounts = append(accounts, strings.ToLower(accounts[0]))
+
+	subsidies, err := client.QueryAccountSubsidiesForAccountsAtBlock(context.Background(), "0xvault", accounts, 77)
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{100, 100, 50}, batches, "accounts are looked up a batch at a time")
+	assert.Len(t, subsidies, 250)
+	first := subsidies[strings.ToLower(accounts[0])]
+	require.Len(t, first, 2)
+	assert.Equal(t, "p1", first[0].CollectionParticipation)
+	assert.Equal(t, "p2", first[1].CollectionParticipation)
+}
@@ -3,10 +3,19 @@ package subsidy
 import "errors"
 
 var (
-	ErrTransactionFailed  = errors.New("blockchain transaction failed")
-	ErrInvalidInput       = errors.New("invalid input parameters")
-	ErrNotFound           = errors.New("resource not found")
-	ErrTimeout            = errors.New("operation timed out")
-	ErrDistributionFailed = errors.New("subsidy distribution failed")
-	ErrInvalidEpochState  = errors.New("epoch is not in valid state for operation")
+	ErrTransactionFailed   = errors.New("blockchain transaction failed")
+	ErrInvalidInput        = errors.New("invalid input parameters")
+	ErrNotFound            = errors.New("resource not found")
+	ErrTimeout             = errors.New("operation timed out")
+	ErrDistributionFailed  = errors.New("subsidy distribution failed")
+	ErrInvalidEpochState   = errors.New("epoch is not in valid state for operation")
+	ErrSnapshotReorged     = errors.New("snapshot block was orphaned by a chain reorg")
+	ErrBatchTooLarge       = errors.New("repayment batch cannot be reduced to fit limits")
+	ErrPreflightFailed     = errors.New("epoch finalization pre-flight checks failed")
+	ErrFingerprintMismatch = errors.New("distribution fingerprint differs from the committed one")
+	ErrSameApprover        = errors.New("approver must be someone other than the proposer")
+	ErrRootNotReplaceable  = errors.New("epoch root cannot be replaced")
+	ErrSubgraphLagging     = errors.New("subgraph is too far behind the chain head")
+	ErrSnapshotStale       = errors.New("snapshot no longer matches the chain")
+	ErrInsufficientYield   = errors.New("vault has no yield to distribute for the epoch")
 )
@@ -22,6 +22,15 @@ type SubsidyDistributionResponse struct {
 	MerkleRoot        string `json:"merkleRoot"`
 	TransactionHash   string `json:"transactionHash,omitempty"`
 	Status            string `json:"status"`
+	StagedID          string `json:"stagedId,omitempty"` // set when the distribution waits for approval
+	// AccountsQuarantined counts the account subsidies skipped for malformed subgraph data
+	AccountsQuarantined int `json:"accountsQuarantined,omitempty"`
+	// AccountsDeferred counts the accounts left out below the vault's minimum allocation, their amounts carried
+	AccountsDeferred int `json:"accountsDeferred,omitempty"`
+	// Resumed is set when a distribution computed by an earlier, failed run was submitted instead of a new one
+	Resumed bool `json:"resumed,omitempty"`
+	// Checks are the pre-flight checks run before the root was pushed or staged
+	Checks []FinalizationCheck `json:"checks,omitempty"`
 }
 
 // DistributionResult represents the result of a subsidy distribution
@@ -29,12 +38,525 @@ type DistributionResult struct {
 	TotalSubsidies    *big.Int `json:"totalSubsidies"`
 	AccountsProcessed int      `json:"accountsProcessed"`
 	MerkleRoot        string   `json:"merkleRoot"`
+	// StagedID is set when the root was staged for approval instead of being pushed on-chain
+	StagedID string `json:"stagedId,omitempty"`
+	// AccountsQuarantined counts the account subsidies skipped for malformed subgraph data
+	AccountsQuarantined int `json:"accountsQuarantined,omitempty"`
+	// AccountsDeferred counts the accounts left out below the vault's minimum allocation, their amounts carried
+	AccountsDeferred int `json:"accountsDeferred,omitempty"`
+	// Resumed is set when the distribution was computed by an earlier run whose submission failed
+	Resumed bool `json:"resumed,omitempty"`
+	// Checks are the pre-flight checks run before the root was pushed or staged
+	Checks []FinalizationCheck `json:"checks,omitempty"`
 }
 
 // LazyDistributor interface for subsidy distribution
 type LazyDistributor interface {
 	Run(ctx context.Context, vaultId string) (*DistributionResult, error)
 	RunWithEpoch(ctx context.Context, vaultId string, epochNumber *big.Int) (*DistributionResult, error)
+	// FinishSubmission forgets the distribution kept for resuming the epoch once the epoch is completed
+	FinishSubmission(ctx context.Context, vaultId string, epochNumber *big.Int) error
+	// ListStaged returns staged distributions with the given status, or all of them when status is empty
+	ListStaged(ctx context.Context, status string) ([]StagedDistribution, error)
+	// SubmitStaged pushes a pending staged root on-chain and marks it approved
+	SubmitStaged(ctx context.Context, id string) (*StagedDistribution, error)
+	// RejectStaged discards a pending staged root so the next run computes a new one
+	RejectStaged(ctx context.Context, id, reason string) (*StagedDistribution, error)
+	// Explain recomputes a user's amount in an epoch's distribution from the subgraph state it was built from
+	Explain(ctx context.Context, vaultId string, epochNumber *big.Int, userAddress string) (*AllocationExplanation, error)
+	// ExplainBatch explains many users' amounts with batched subgraph lookups
+	ExplainBatch(ctx context.Context, vaultId string, epochNumber *big.Int, userAddresses []string) (*AllocationExplanations, error)
+	// ListQuarantined returns the vault's quarantined account subsidies, only the epoch's when epochNumber is set
+	ListQuarantined(ctx context.Context, vaultId string, epochNumber *big.Int) ([]QuarantinedAccount, error)
+	// Replay recomputes an epoch's distribution with the current code and diffs it against the stored one
+	Replay(ctx context.Context, vaultId string, epochNumber *big.Int) (*ReplayResult, error)
+	// PinSnapshotBlock makes the epoch's distribution snapshot the vault at blockNumber
+	PinSnapshotBlock(ctx context.Context, vaultId string, epochNumber *big.Int, blockNumber uint64) (*SnapshotBlockPin, error)
+	// SetCollectionWeight stores a collection's weight, replacing the one set before
+	SetCollectionWeight(ctx context.Context, weight CollectionWeight) (*CollectionWeight, error)
+	// ListCollectionWeights returns the weights set for the vault's collections
+	ListCollectionWeights(ctx context.Context, vaultId string) ([]CollectionWeight, error)
+	// DeleteCollectionWeight removes a collection's weight
+	DeleteCollectionWeight(ctx context.Context, vaultId, collection string) error
+	// BlockAddress adds an address to the blocklist, replacing the entry added for it before
+	BlockAddress(ctx context.Context, blocked BlockedAddress) (*BlockedAddress, error)
+	// ListBlockedAddresses returns the blocklist
+	ListBlockedAddresses(ctx context.Context) ([]BlockedAddress, error)
+	// UnblockAddress removes an address from the blocklist
+	UnblockAddress(ctx context.Context, address string) error
+	// OverrideFingerprint lets the next distribution of the epoch replace its committed one once
+	OverrideFingerprint(ctx context.Context, vaultId string, epochNumber *big.Int, reason string) (*FingerprintOverride, error)
+	// ReplaceRoot recomputes the epoch's root and, when confirm is set, pushes it in place of the one on-chain
+	ReplaceRoot(ctx context.Context, vaultId string, epochNumber *big.Int, reason string, confirm bool) (*RootReplacement, error)
+	// Diff compares the epoch's stored distribution with the vault's previous one, listing top accounts per change
+	Diff(ctx context.Context, vaultId string, epochNumber *big.Int, top int) (*AllocationDiff, error)
+	// Stats returns the distribution statistics of the epoch's vaults, only vaultId's when it is set
+	Stats(ctx context.Context, epochNumber *big.Int, vaultId string) (*EpochStats, error)
+	// ProposeAdjustment stores a manual allocation adjustment awaiting a second admin's approval
+	ProposeAdjustment(ctx context.Context, adjustment AllocationAdjustment) (*AllocationAdjustment, error)
+	// ListAdjustments returns the adjustments matching the filters that are set
+	ListAdjustments(ctx context.Context, vaultId, epochNumber, status string) ([]AllocationAdjustment, error)
+	// DecideAdjustment approves or rejects a pending adjustment on behalf of an admin other than its proposer
+	DecideAdjustment(ctx context.Context, id string, approve bool, reason string) (*AllocationAdjustment, error)
+	// ListPositions returns the positions the vaults' distributions recorded for the user
+	ListPositions(ctx context.Context, userAddress string) ([]EpochPosition, error)
+	// ListExpirations returns the user's unclaimed amounts with the epochs they expire in
+	ListExpirations(ctx context.Context, userAddress string) (*UserExpirations, error)
+}
+
+// how a collection allocation's amount was obtained
+const (
+	AllocationSourceSubgraph    = "subgraph"    // totalRewardsEarned reported by the subgraph
+	AllocationSourceAccrued     = "accrued"     // accrued from deposit-seconds up to the valuation time
+	AllocationSourceSkipped     = "skipped"     // excluded from the distribution, see Reason
+	AllocationSourceQuarantined = "quarantined" // malformed record set aside for review, see Reason
+	AllocationSourceBlocked     = "blocked"     // the account was blocklisted when the distribution was built
+	AllocationSourceDeferred    = "deferred"    // the account's total was below the vault's minimum allocation
+)
+
+// AllocationExplanation is the computation trail behind a user's amount in an epoch's distribution
+type AllocationExplanation struct {
+	VaultID     string `json:"vaultId"`
+	EpochNumber string `json:"epochNumber"`
+	UserAddress string `json:"userAddress"`
+	MerkleRoot  string `json:"merkleRoot"`
+	BlockNumber int64  `json:"blockNumber"` // block the subgraph state was read at
+	// ValuedAt is the unix time accrual was valued at. Snapshots taken before it was recorded
+	// fall back to the snapshot's creation time and set ValuedAtEstimated.
+	ValuedAt          int64                  `json:"valuedAt"`
+	ValuedAtEstimated bool                   `json:"valuedAtEstimated,omitempty"`
+	Collections       []CollectionAllocation `json:"collections"`
+	ComputedAmount    string                 `json:"computedAmount"`    // wei, sum of the collection amounts
+	DistributedAmount string                 `json:"distributedAmount"` // wei, the user's amount in the merkle tree
+	VaultTotal        string                 `json:"vaultTotal"`        // wei, every amount in the merkle tree
+	YieldShare        string                 `json:"yieldShare"`        // distributedAmount / vaultTotal
+	Matches           bool                   `json:"matches"`           // computedAmount equals distributedAmount
+	// OutstandingDebt is what the user owed the vault's lending market at the snapshot block and DebtCoverage
+	// distributedAmount / outstandingDebt, set when the distribution held accounts to their outstanding debt
+	OutstandingDebt string `json:"outstandingDebt,omitempty"`
+	DebtCoverage    string `json:"debtCoverage,omitempty"`
+	// Expired is what of the user's earlier earnings expired unclaimed by the epoch, left out of computedAmount as
+	// it was of distributedAmount
+	Expired string `json:"expired,omitempty"`
+}
+
+// EpochPosition is what a user held, borrowed and was paid in one vault's epoch distribution, recorded when the
+// distribution was saved. Holdings, borrow volume and claims are as the subgraph indexed them at the snapshot block;
+// a user paid only through a wrapper holds nothing in its own name.
+type EpochPosition struct {
+	VaultID        string `json:"vaultId"`
+	EpochNumber    string `json:"epochNumber"`
+	MerkleRoot     string `json:"merkleRoot"`
+	BlockNumber    int64  `json:"blockNumber"`                                      // block the subgraph state was read at
+	ValuedAt       int64  `json:"valuedAt"`                                         // unix time deposit-seconds were accrued up to
+	DepositSeconds string `json:"depositSeconds" example:"86400000000000000000000"` // accrued across the user's collections, scaled by 1e18
+	NFTsCounted    string `json:"nftsCounted" example:"3"`                          // NFTs, or ERC-1155 units, held in the vault's collections
+	Collections    int    `json:"collections"`                                      // collections the user participated in
+	BorrowVolume   string `json:"borrowVolume"`                                     // wei the user borrowed in total
+	Earned         string `json:"earned"`                                           // wei this tree added to the user's total since the vault's previous tree paying the user
+	TotalEarned    string `json:"totalEarned"`                                      // wei the tree pays the user in total, 0 without a leaf
+	Claimed        string `json:"claimed"`                                          // wei the user had claimed in total
+	Expired        string `json:"expired,omitempty"`                                // wei left out of totalEarned in total, having expired unclaimed
+}
+
+// UserExpirations are the amounts a user has yet to claim, each expiring a number of epochs after the one it was
+// earned in. Claims are as the vault's latest distribution saw them.
+type UserExpirations struct {
+	UserAddress  string            `json:"userAddress"`
+	ExpiryEpochs uint64            `json:"expiryEpochs"` // epochs an amount stays claimable, 0 when amounts never expire
+	Expirations  []ClaimExpiration `json:"expirations"`  // soonest first
+}
+
+// ClaimExpiration is what is left unclaimed of what a vault's epoch added to a user's total
+type ClaimExpiration struct {
+	VaultID        string `json:"vaultId"`
+	EarnedInEpoch  string `json:"earnedInEpoch"`
+	Amount         string `json:"amount" example:"1000000000000000000"` // wei still unclaimed
+	ExpiresAtEpoch string `json:"expiresAtEpoch"`                       // epoch whose tree leaves the amount out when it is still unclaimed
+}
+
+// AllocationExplanations are the explanations of many users' amounts in an epoch's distribution
+type AllocationExplanations struct {
+	VaultID      string                   `json:"vaultId"`
+	EpochNumber  string                   `json:"epochNumber"`
+	MerkleRoot   string                   `json:"merkleRoot"`
+	BlockNumber  int64                    `json:"blockNumber"`
+	Explanations []*AllocationExplanation `json:"explanations"`
+	NotFound     []string                 `json:"notFound"`   // requested users without an allocation
+	Mismatched   int                      `json:"mismatched"` // explanations whose computed amount differs from the tree
+}
+
+// CollectionAllocation is how one collection participation contributed to a user's amount.
+// Deposit-seconds are scaled by 1e18 and lastEffectiveValue is the weight they accrue at per second.
+type CollectionAllocation struct {
+	CollectionParticipation string `json:"collectionParticipation"`
+	SecondsAccumulated      string `json:"secondsAccumulated"`
+	LastEffectiveValue      string `json:"lastEffectiveValue"`
+	UpdatedAtTimestamp      string `json:"updatedAtTimestamp"`
+	TotalRewardsEarned      string `json:"totalRewardsEarned,omitempty"`
+	CollectionType          string `json:"collectionType,omitempty"` // ERC721 or ERC1155 as the subgraph reports it
+	Share                   string `json:"share,omitempty"`          // fraction of the indexed record valued, for ERC-1155 units, wrapped positions and collection weights
+	WrappedBy               string `json:"wrappedBy,omitempty"`      // wrapper contract the user's share was accrued by
+	ElapsedSeconds          int64  `json:"elapsedSeconds,omitempty"` // valuation time minus updatedAtTimestamp
+	TotalSeconds            string `json:"totalSeconds,omitempty"`   // secondsAccumulated + elapsedSeconds * lastEffectiveValue
+	RoundedOff              string `json:"roundedOff,omitempty"`     // fraction of a wei the valued amount was rounded down by
+	Source                  string `json:"source"`
+	Amount                  string `json:"amount"` // wei, after caps and redistribution
+	Reason                  string `json:"reason,omitempty"`
+	// UncappedAmount, CapsApplied and Redistributed are set when caps or the blocklist changed the amount
+	UncappedAmount string       `json:"uncappedAmount,omitempty"` // wei as valued, before caps
+	CapsApplied    []AppliedCap `json:"capsApplied,omitempty"`
+	Redistributed  string       `json:"redistributed,omitempty"` // wei received from amounts clamped elsewhere or withheld from blocked accounts
+	RoundingBonus  string       `json:"roundingBonus,omitempty"` // wei received from the fractions rounded off allocations
+}
+
+// cap rules, see config.Caps
+const (
+	CapUserMax               = "user_max"
+	CapUserDebtPercent       = "user_debt_percent"
+	CapCollectionMax         = "collection_max"
+	CapCollectionDebtPercent = "collection_debt_percent"
+	CapUserOutstandingDebt   = "user_outstanding_debt"
+	CapRemainderRedistribute = "redistribute"  // clamped amounts go to allocations below their caps
+	CapRemainderCarryForward = "carry_forward" // clamped amounts are added to the next distribution
+)
+
+// what happens to the amounts blocked accounts would have received, see config.Blocklist
+const (
+	BlockedRemainderBurn         = "burn"         // left out of the tree, so nobody can claim them
+	BlockedRemainderRedistribute = "redistribute" // spread over the other allocations pro-rata
+)
+
+// what failed finalization checks do, see config.Finalization
+const (
+	FinalizationChecksWarn    = "warn"    // logged and returned, the root is pushed anyway
+	FinalizationChecksEnforce = "enforce" // the root is not pushed
+	FinalizationChecksOff     = "off"
+)
+
+// pre-flight checks run before a distribution finalizes its epoch
+const (
+	CheckSubgraphSynced   = "subgraph_synced"   // the subgraph indexed the snapshot block
+	CheckEpochYield       = "epoch_yield"       // the vault has yield to allocate for the epoch
+	CheckSignerAuthorized = "signer_authorized" // the signer may push the root and end the epoch
+	CheckRootChanged      = "root_changed"      // the root differs from the one on-chain
+)
+
+// FinalizationCheck is the outcome of one pre-flight check
+type FinalizationCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"` // why the check failed or could not run
+}
+
+// rounding policies, see config.Rounding
+const (
+	RoundingFloor          = "floor"           // rounded off fractions are left undistributed
+	RoundingLargestHolders = "largest_holders" // the whole wei they add up to go one each to the largest allocations
+	RoundingCarryForward   = "carry_forward"   // they are added to the next distribution, which pays them to its largest allocations
+)
+
+// AppliedCap is a cap that clamped an allocation
+type AppliedCap struct {
+	Rule    string `json:"rule"`
+	Limit   string `json:"limit"`   // wei the account or collection was held to
+	Clamped string `json:"clamped"` // wei this allocation lost to the rule
+}
+
+// ReplayResult compares an epoch's distribution, recomputed from the subgraph state at its snapshot
+// block with the code running now, with the distribution stored when the epoch was distributed
+type ReplayResult struct {
+	VaultID           string `json:"vaultId"`
+	EpochNumber       string `json:"epochNumber"`
+	BlockNumber       int64  `json:"blockNumber"`
+	ValuedAt          int64  `json:"valuedAt"`
+	ValuedAtEstimated bool   `json:"valuedAtEstimated,omitempty"`
+	StoredRoot        string `json:"storedRoot"`
+	// OnChainRoot is the root the subgraph indexed for the epoch, empty when it has none
+	OnChainRoot       string            `json:"onChainRoot,omitempty"`
+	RecomputedRoot    string            `json:"recomputedRoot"`
+	StoredTotal       string            `json:"storedTotal"`     // wei
+	RecomputedTotal   string            `json:"recomputedTotal"` // wei
+	StoredEntries     int               `json:"storedEntries"`
+	RecomputedEntries int               `json:"recomputedEntries"`
+	Matches           bool              `json:"matches"` // recomputedRoot equals storedRoot
+	Drift             []AllocationDrift `json:"drift"`   // accounts whose amount changed, by address
+	// Diff compares the recomputed distribution with the vault's previous one
+	Diff *AllocationDiff `json:"diff,omitempty"`
+}
+
+// AllocationDrift is an account whose amount differs between the stored and the recomputed distribution
+type AllocationDrift struct {
+	Account    string `json:"account"`
+	Stored     string `json:"stored"`     // wei, 0 when the account was not in the stored distribution
+	Recomputed string `json:"recomputed"` // wei, 0 when the account is not in the recomputed distribution
+	Difference string `json:"difference"` // recomputed minus stored
+}
+
+// DefaultDiffTop is how many accounts an allocation diff lists per change kind when no limit is given,
+// MaxDiffTop the most it lists
+const (
+	DefaultDiffTop = 10
+	MaxDiffTop     = 100
+)
+
+// AllocationChange is an account's amount in a distribution next to its amount in the previous one
+type AllocationChange struct {
+	Account  string `json:"account"`
+	Previous string `json:"previous"` // wei, 0 when the account was not in the previous distribution
+	Current  string `json:"current"`  // wei, 0 when the account is not in this distribution
+	Change   string `json:"change"`   // current minus previous
+}
+
+// AllocationDiff compares an epoch's distribution with the vault's previous one, so reviewers can spot anomalies
+// before a root is approved. Leaves are cumulative, so an account's change is what it earned in between and a
+// decrease or a dropped account takes back what it may already have claimed.
+type AllocationDiff struct {
+	VaultID             string `json:"vaultId"`
+	EpochNumber         string `json:"epochNumber"`
+	PreviousEpochNumber string `json:"previousEpochNumber,omitempty"` // empty for the vault's first distribution
+	Accounts            int    `json:"accounts"`
+	PreviousAccounts    int    `json:"previousAccounts"`
+	Total               string `json:"total"`                   // wei
+	PreviousTotal       string `json:"previousTotal"`           // wei
+	TotalGrowth         string `json:"totalGrowth"`             // total minus previous total
+	GrowthPercent       string `json:"growthPercent,omitempty"` // of the previous total, empty when it was 0
+	NewAccounts         int    `json:"newAccounts"`
+	DroppedAccounts     int    `json:"droppedAccounts"`
+	// the lists hold at most the requested number of accounts each, largest change first
+	New          []AllocationChange `json:"new"`
+	Dropped      []AllocationChange `json:"dropped"`
+	TopIncreases []AllocationChange `json:"topIncreases"` // accounts in both distributions whose amount grew
+	TopDecreases []AllocationChange `json:"topDecreases"` // accounts in both distributions whose amount shrank
+}
+
+// StatsDecimals is how many decimals the Gini coefficient and concentration shares are given with
+const StatsDecimals = 6
+
+// StatsTopAccounts is how many of the largest allocations the concentration share covers
+const StatsTopAccounts = 10
+
+// DistributionStats summarizes how evenly an epoch's distribution spread subsidies across the vault's
+// accounts, computed when the distribution is built so governance can follow concentration across epochs.
+// Amounts are the tree's cumulative wei, shares are decimal fractions, 0.05 being 5%.
+type DistributionStats struct {
+	VaultID     string `json:"vaultId"`
+	EpochNumber string `json:"epochNumber"`
+	MerkleRoot  string `json:"merkleRoot"`
+	Accounts    int    `json:"accounts"`
+	Total       string `json:"total"`
+	Mean        string `json:"mean"`   // rounded down
+	Median      string `json:"median"` // rounded down between the two middle allocations of an even count
+	// Gini is 0 when every account received the same and approaches 1 as one account receives everything
+	Gini       string `json:"gini" example:"0.412300"`
+	Top10Total string `json:"top10Total"` // what the 10 largest allocations receive together
+	Top10Share string `json:"top10Share"` // top10Total / total
+	// allocations below DustThreshold cost their recipient more gas to claim than they are worth
+	DustThreshold string    `json:"dustThreshold"`
+	DustAccounts  int       `json:"dustAccounts"`
+	DustTotal     string    `json:"dustTotal"`
+	ComputedAt    time.Time `json:"computedAt"`
+}
+
+// EpochStats are the distribution statistics of an epoch's vaults
+type EpochStats struct {
+	EpochNumber string              `json:"epochNumber"`
+	Vaults      []DistributionStats `json:"vaults"` // by vault address
+}
+
+// how an epoch's snapshot block was chosen, the first three name the configured strategies
+const (
+	SnapshotBlockLatest    = "latest"    // chain head when the distribution ran
+	SnapshotBlockFinalized = "finalized" // latest finalized block when the distribution ran
+	SnapshotBlockEpochEnd  = "epoch_end" // last block of the epoch, less the configured offset
+	SnapshotBlockPinned    = "pinned"    // block an operator pinned through the admin API
+)
+
+// how an epoch's accrual is shared among the holders of a collection
+const (
+	EligibilitySnapshot     = "snapshot"      // as the subgraph accounted it at the snapshot block
+	EligibilityTimeWeighted = "time_weighted" // by the seconds each account held tokens over the epoch
+)
+
+// SnapshotBlockPin is a block an operator chose for an epoch's distribution snapshot, overriding the
+// configured strategy
+type SnapshotBlockPin struct {
+	VaultID     string    `json:"vaultId"`
+	EpochNumber string    `json:"epochNumber"`
+	BlockNumber uint64    `json:"blockNumber"`
+	BlockHash   string    `json:"blockHash"` // canonical hash when the block was pinned
+	PinnedBy    string    `json:"pinnedBy,omitempty"`
+	PinnedAt    time.Time `json:"pinnedAt"`
+}
+
+// DistributionStrategyVersion identifies how distributions are computed from their inputs. It is part of every
+// distribution's fingerprint and is bumped whenever a code change makes the same inputs build a different tree.
+const DistributionStrategyVersion = "1"
+
+// Fingerprint identifies the inputs an epoch's distribution was computed from. Distributions computed from the
+// same inputs have the same hash, so a distribution can be reproduced for an audit and a committed one is not
+// silently replaced by one computed from other inputs.
+type Fingerprint struct {
+	VaultID         string            `json:"vaultId"`
+	EpochNumber     string            `json:"epochNumber"`
+	Hash            string            `json:"hash"` // keccak256 of the inputs below, hex with 0x
+	StrategyVersion string            `json:"strategyVersion"`
+	SnapshotBlock   uint64            `json:"snapshotBlock"`
+	BlockHash       string            `json:"blockHash"`
+	BlockStrategy   string            `json:"blockStrategy"`
+	ValuedAt        int64             `json:"valuedAt"`
+	Params          map[string]string `json:"params"`         // configuration and carried state the distribution was computed with
+	AccountSetHash  string            `json:"accountSetHash"` // keccak256 of the sorted accounts the subgraph reported
+	Accounts        int               `json:"accounts"`
+	MerkleRoot      string            `json:"merkleRoot"`
+	// Committed is set once the distribution's root was pushed on-chain
+	Committed  bool      `json:"committed"`
+	ComputedAt time.Time `json:"computedAt"`
+}
+
+// FingerprintOverride lets the next distribution of an epoch replace the committed one although it was
+// computed from other inputs. It applies once, and only while the committed fingerprint is still Fingerprint.
+type FingerprintOverride struct {
+	VaultID      string    `json:"vaultId"`
+	EpochNumber  string    `json:"epochNumber"`
+	Fingerprint  string    `json:"fingerprint"` // hash of the committed fingerprint being overridden
+	Reason       string    `json:"reason"`
+	OverriddenBy string    `json:"overriddenBy,omitempty"`
+	OverriddenAt time.Time `json:"overriddenAt"`
+}
+
+// RootReplacement is a corrected root recomputed for the vault's latest epoch to replace the root pushed for it.
+// It is pushed only when confirmed, an unconfirmed replacement shows what would be pushed. The replaced tree is
+// kept and recorded as superseded, so proofs against either root resolve.
+type RootReplacement struct {
+	VaultID           string              `json:"vaultId"`
+	EpochNumber       string              `json:"epochNumber"`
+	PreviousRoot      string              `json:"previousRoot"` // root on-chain before the replacement, hex without 0x
+	MerkleRoot        string              `json:"merkleRoot"`   // recomputed root, hex without 0x
+	PreviousTotal     string              `json:"previousTotal"`
+	TotalSubsidies    string              `json:"totalSubsidies"`
+	AccountsProcessed int                 `json:"accountsProcessed"`
+	BlockNumber       uint64              `json:"blockNumber"` // block the recomputed tree was snapshotted at
+	Fingerprint       string              `json:"fingerprint"` // hash of the inputs the recomputed tree was built from
+	Diff              *AllocationDiff     `json:"diff"`        // the recomputed tree against the replaced one
+	Checks            []FinalizationCheck `json:"checks,omitempty"`
+	Reason            string              `json:"reason"`
+	Confirmed         bool                `json:"confirmed"` // whether the root was pushed
+	ReplacedBy        string              `json:"replacedBy,omitempty"`
+	ReplacedAt        *time.Time          `json:"replacedAt,omitempty"`
+}
+
+// MaxCollectionMultiplier bounds a collection weight, so a mistyped multiplier cannot drain the vault into one collection
+const MaxCollectionMultiplier = 100
+
+// CollectionWeight multiplies what a collection's participants earn in a vault's distributions for epochs
+// FromEpoch through ToEpoch, on top of how their deposits are valued. Setting a collection's weight again
+// replaces it.
+type CollectionWeight struct {
+	VaultID    string    `json:"vaultId"`
+	Collection string    `json:"collection"` // collection contract address
+	Multiplier string    `json:"multiplier"` // decimal such as "2" or "1.5"
+	FromEpoch  uint64    `json:"fromEpoch"`
+	ToEpoch    uint64    `json:"toEpoch"` // last epoch the weight applies to
+	Reason     string    `json:"reason,omitempty"`
+	SetBy      string    `json:"setBy,omitempty"`
+	SetAt      time.Time `json:"setAt"`
+}
+
+// AppliesTo reports whether the weight is in effect for epochNumber
+func (w CollectionWeight) AppliesTo(epochNumber uint64) bool {
+	return epochNumber >= w.FromEpoch && epochNumber <= w.ToEpoch
+}
+
+// BlockedAddress is a sanctioned or compromised address left out of every distribution while it is blocklisted
+type BlockedAddress struct {
+	Address   string    `json:"address"`
+	Reason    string    `json:"reason,omitempty"`
+	BlockedBy string    `json:"blockedBy,omitempty"`
+	BlockedAt time.Time `json:"blockedAt"`
+}
+
+// allocation adjustment statuses
+const (
+	AdjustmentPendingApproval = "pending_approval"
+	AdjustmentApproved        = "approved"
+	AdjustmentRejected        = "rejected"
+)
+
+// AllocationAdjustment is a manual correction of an account's allocation in an epoch's distribution, such as
+// compensating a user a bug shortchanged. One admin proposes it with a justification and another approves it;
+// approved adjustments are applied to the allocations before the epoch's tree is built and are part of its
+// fingerprint.
+type AllocationAdjustment struct {
+	ID            string `json:"id"`
+	VaultID       string `json:"vaultId"`
+	EpochNumber   string `json:"epochNumber"`
+	Account       string `json:"account"`
+	Amount        string `json:"amount" example:"-1500000"` // wei added to the allocation, negative to take from it
+	Justification string `json:"justification"`
+	Status        string `json:"status"`
+	ProposedBy    string `json:"proposedBy"`
+	// ProposedWith identifies the API key the adjustment was proposed with, it cannot approve it
+	ProposedWith    string     `json:"proposedWith,omitempty" example:"key:1a2b3c4d"`
+	ProposedAt      time.Time  `json:"proposedAt"`
+	DecidedBy       string     `json:"decidedBy,omitempty"` // who approved or rejected it
+	DecidedWith     string     `json:"decidedWith,omitempty"`
+	DecidedAt       *time.Time `json:"decidedAt,omitempty"`
+	RejectionReason string     `json:"rejectionReason,omitempty"`
+}
+
+// AppliedAdjustment is what an approved adjustment changed in a distribution
+type AppliedAdjustment struct {
+	ID      string `json:"id"`
+	Account string `json:"account"`
+	Amount  string `json:"amount"`  // as approved
+	Applied string `json:"applied"` // what the allocation changed by, less than amount when taking more than it had
+}
+
+// staged distribution statuses
+const (
+	StagedPendingApproval = "pending_approval"
+	StagedApproved        = "approved" // root was pushed on-chain
+	StagedRejected        = "rejected"
+)
+
+// StagedDistribution is a computed distribution held back until it is approved
+type StagedDistribution struct {
+	ID                string          `json:"id"`
+	VaultID           string          `json:"vaultId"`
+	EpochNumber       string          `json:"epochNumber,omitempty"`
+	MerkleRoot        string          `json:"merkleRoot"`
+	TotalSubsidies    string          `json:"totalSubsidies"`
+	AccountsProcessed int             `json:"accountsProcessed"`
+	BlockNumber       uint64          `json:"blockNumber"`
+	BlockHash         string          `json:"blockHash,omitempty"`
+	BlockStrategy     string          `json:"blockStrategy,omitempty"` // how the snapshot block was chosen
+	Fingerprint       string          `json:"fingerprint,omitempty"`   // hash of the inputs the distribution was computed from
+	Status            string          `json:"status"`
+	Reason            string          `json:"reason,omitempty"`         // why approval was required, or why it was rejected
+	CarriedForward    string          `json:"carriedForward,omitempty"` // wei clamped by caps and left for the next distribution
+	DustCarried       string          `json:"dustCarried,omitempty"`    // fraction of wei rounding left for the next distribution
+	Diff              *AllocationDiff `json:"diff,omitempty"`           // changes against the vault's previous distribution
+	DecidedBy         string          `json:"decidedBy,omitempty"`
+	CreatedAt         time.Time       `json:"createdAt"`
+	DecidedAt         time.Time       `json:"decidedAt,omitempty"`
+}
+
+// QuarantinedAccount is an account subsidy a distribution skipped because its subgraph record could not be
+// valued safely. The raw values are kept as read so the record can be reviewed by hand.
+type QuarantinedAccount struct {
+	VaultID                 string    `json:"vaultId"`
+	EpochNumber             string    `json:"epochNumber,omitempty"`
+	BlockNumber             uint64    `json:"blockNumber"` // snapshot block the record was read at
+	SubsidyID               string    `json:"subsidyId,omitempty"`
+	Account                 string    `json:"account"`
+	CollectionParticipation string    `json:"collectionParticipation"`
+	SecondsAccumulated      string    `json:"secondsAccumulated"`
+	LastEffectiveValue      string    `json:"lastEffectiveValue"`
+	UpdatedAtTimestamp      string    `json:"updatedAtTimestamp"`
+	TotalRewardsEarned      string    `json:"totalRewardsEarned"`
+	Reason                  string    `json:"reason"`
+	QuarantinedAt           time.Time `json:"quarantinedAt"`
 }
 
 // SubsidyDistribution represents a subsidy distribution record
@@ -50,3 +572,51 @@ type SubsidyDistribution struct {
 	CreatedAt         time.Time `json:"createdAt"`
 	UpdatedAt         time.Time `json:"updatedAt"`
 }
+
+// repayment plan statuses
+const (
+	RepaymentPlanInProgress = "in_progress"
+	RepaymentPlanCompleted  = "completed"
+	RepaymentPlanPartial    = "partial" // some chunks have an unknown outcome and need reconciliation
+)
+
+// repayment chunk statuses
+const (
+	RepaymentChunkSubmitted   = "submitted"
+	RepaymentChunkConfirmed   = "confirmed"
+	RepaymentChunkFailed      = "failed"      // certainly not executed, borrowers are retried
+	RepaymentChunkUnconfirmed = "unconfirmed" // may have executed, borrowers are never retried automatically
+)
+
+// Repayment is a debt repayment made on a borrower's behalf
+type Repayment struct {
+	Borrower string   `json:"borrower"`
+	Amount   *big.Int `json:"amount"`
+}
+
+// RepaymentPlanner splits repayments into batches the vault and the gas limit accept
+type RepaymentPlanner interface {
+	Execute(ctx context.Context, planID, vaultId string, repayments []Repayment) (*RepaymentPlan, error)
+}
+
+// RepaymentPlan tracks a batch repayment across the chunks it was split into,
+// so a retried plan only repays borrowers whose chunk certainly did not execute
+type RepaymentPlan struct {
+	ID        string           `json:"id"`
+	VaultID   string           `json:"vaultId"`
+	Status    string           `json:"status"`
+	Chunks    []RepaymentChunk `json:"chunks"`
+	CreatedAt time.Time        `json:"createdAt"`
+	UpdatedAt time.Time        `json:"updatedAt"`
+}
+
+// RepaymentChunk is a single repayBorrowBehalfBatch call
+type RepaymentChunk struct {
+	Index       int       `json:"index"`
+	Borrowers   []string  `json:"borrowers"`
+	TotalAmount string    `json:"totalAmount"`
+	GasEstimate uint64    `json:"gasEstimate"`
+	Status      string    `json:"status"`
+	Error       string    `json:"error,omitempty"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
@@ -10,4 +10,63 @@ import (
 type Service interface {
 	// DistributeSubsidies manages the distribution of subsidies for a vault
 	DistributeSubsidies(ctx context.Context, vaultId string) (*SubsidyDistributionResponse, error)
+	// RepayBorrowers repays borrowers' debt in gas-bounded batches, resuming a partially completed plan
+	RepayBorrowers(ctx context.Context, planID, vaultId string, repayments []Repayment) (*RepaymentPlan, error)
+	// ListStagedDistributions returns distributions staged for approval, filtered by status when it is set
+	ListStagedDistributions(ctx context.Context, status string) ([]StagedDistribution, error)
+	// ApproveDistribution pushes a staged merkle root on-chain and completes its epoch
+	ApproveDistribution(ctx context.Context, id string) (*SubsidyDistributionResponse, error)
+	// RejectDistribution discards a staged distribution
+	RejectDistribution(ctx context.Context, id, reason string) (*StagedDistribution, error)
+	// ExplainAllocation returns the computation trail behind a user's amount in an epoch's distribution
+	ExplainAllocation(ctx context.Context, vaultId, epochNumber, userAddress string) (*AllocationExplanation, error)
+	// ExplainAllocations explains many users' amounts in an epoch's distribution at once
+	ExplainAllocations(ctx context.Context, vaultId, epochNumber string, userAddresses []string) (*AllocationExplanations, error)
+	// ListQuarantinedAccounts returns the account subsidies distributions skipped for malformed data,
+	// only those of epochNumber when it is set
+	ListQuarantinedAccounts(ctx context.Context, vaultId, epochNumber string) ([]QuarantinedAccount, error)
+	// ReplayEpoch recomputes an epoch's distribution with the current code and reports drift from the stored one
+	ReplayEpoch(ctx context.Context, vaultId, epochNumber string) (*ReplayResult, error)
+	// PinSnapshotBlock makes an epoch's distribution snapshot the vault at blockNumber instead of the block
+	// the configured strategy would choose
+	PinSnapshotBlock(ctx context.Context, vaultId, epochNumber string, blockNumber uint64) (*SnapshotBlockPin, error)
+	// SetCollectionWeight multiplies what a collection's participants earn in the distributions of an epoch
+	// range, replacing the weight set for the collection before
+	SetCollectionWeight(ctx context.Context, weight CollectionWeight) (*CollectionWeight, error)
+	// ListCollectionWeights returns the weights set for the vault's collections
+	ListCollectionWeights(ctx context.Context, vaultId string) ([]CollectionWeight, error)
+	// DeleteCollectionWeight removes a collection's weight; distributions already built keep it
+	DeleteCollectionWeight(ctx context.Context, vaultId, collection string) error
+	// BlockAddress blocklists an address, leaving it out of the merkle trees of every distribution built after
+	BlockAddress(ctx context.Context, blocked BlockedAddress) (*BlockedAddress, error)
+	// ListBlockedAddresses returns the blocklisted addresses
+	ListBlockedAddresses(ctx context.Context) ([]BlockedAddress, error)
+	// UnblockAddress removes an address from the blocklist; distributions already built keep it out
+	UnblockAddress(ctx context.Context, address string) error
+	// OverrideFingerprint lets the next distribution of an epoch replace its committed distribution although it
+	// was computed from other inputs
+	OverrideFingerprint(ctx context.Context, vaultId, epochNumber, reason string) (*FingerprintOverride, error)
+	// ReplaceRoot replaces the root pushed for the vault's latest epoch with one recomputed and checked again.
+	// Without confirm it only reports the recomputed root and how it differs from the pushed one.
+	ReplaceRoot(ctx context.Context, vaultId, epochNumber, reason string, confirm bool) (*RootReplacement, error)
+	// DiffAllocations compares an epoch's distribution with the vault's previous one: new and dropped accounts,
+	// the top increases and decreases and the total's growth, listing at most top accounts of each
+	DiffAllocations(ctx context.Context, vaultId, epochNumber string, top int) (*AllocationDiff, error)
+	// EpochStats returns the fairness statistics of the epoch's distributions, only vaultId's when it is set
+	EpochStats(ctx context.Context, epochNumber, vaultId string) (*EpochStats, error)
+	// ProposeAdjustment proposes a manual correction of an account's allocation in an epoch's distribution,
+	// applied once an admin other than the proposer approves it
+	ProposeAdjustment(ctx context.Context, adjustment AllocationAdjustment) (*AllocationAdjustment, error)
+	// ListAdjustments returns the allocation adjustments, filtered by vault, epoch and status when they are set
+	ListAdjustments(ctx context.Context, vaultId, epochNumber, status string) ([]AllocationAdjustment, error)
+	// ApproveAdjustment approves a pending adjustment, applying it to the next distribution of its epoch
+	ApproveAdjustment(ctx context.Context, id string) (*AllocationAdjustment, error)
+	// RejectAdjustment discards a pending adjustment
+	RejectAdjustment(ctx context.Context, id, reason string) (*AllocationAdjustment, error)
+	// UserHistory returns the user's position in every epoch distribution that recorded one, only vaultId's when it
+	// is set, latest epoch first
+	UserHistory(ctx context.Context, userAddress, vaultId string) ([]EpochPosition, error)
+	// UserExpirations returns what the user has yet to claim with the epochs it expires in, only vaultId's when it
+	// is set, soonest first
+	UserExpirations(ctx context.Context, userAddress, vaultId string) (*UserExpirations, error)
 }
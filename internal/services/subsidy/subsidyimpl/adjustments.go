@@ -0,0 +1,209 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// audit actions recorded for allocation adjustments
+const (
+	actionProposeAdjustment = "proposeAllocationAdjustment"
+	actionApproveAdjustment = "approveAllocationAdjustment"
+	actionRejectAdjustment  = "rejectAllocationAdjustment"
+)
+
+// adjustmentPolicy bounds manual allocation adjustments
+type adjustmentPolicy struct {
+	maxAmount *big.Int // most wei one adjustment may move, nil refuses adjustments
+}
+
+func newAdjustmentPolicy(cfg *config.Config) adjustmentPolicy {
+	maxAmount, ok := new(big.Int).SetString(cfg.Adjustments.MaxAmount, 10)
+	if !ok || maxAmount.Sign() < 0 {
+		return adjustmentPolicy{}
+	}
+	return adjustmentPolicy{maxAmount: maxAmount}
+}
+
+// ProposeAdjustment stores the adjustment as proposed by the context's actor, pending a second admin's approval
+func (d *LazyDistributor) ProposeAdjustment(
+	ctx context.Context,
+	adjustment subsidy.AllocationAdjustment,
+) (*subsidy.AllocationAdjustment, error) {
+	if d.adjustments.maxAmount == nil {
+		return nil, fmt.Errorf("%w: manual adjustments are disabled, ADJUSTMENTS_MAX_AMOUNT is not set", subsidy.ErrInvalidInput)
+	}
+	amount, ok := new(big.Int).SetString(adjustment.Amount, 10)
+	if !ok || amount.Sign() == 0 {
+		return nil, fmt.Errorf("%w: adjustment amount must be a non-zero integer amount of wei, got %q",
+			subsidy.ErrInvalidInput, adjustment.Amount)
+	}
+	if new(big.Int).Abs(amount).Cmp(d.adjustments.maxAmount) > 0 {
+		return nil, fmt.Errorf("%w: adjustment of %s wei exceeds the %s wei bound", subsidy.ErrInvalidInput, amount, d.adjustments.maxAmount)
+	}
+
+	adjustment.ID = newStagedID()
+	adjustment.Amount = amount.String()
+	adjustment.Status = subsidy.AdjustmentPendingApproval
+	adjustment.ProposedBy = audit.ActorFromContext(ctx)
+	adjustment.ProposedWith = audit.CredentialFromContext(ctx)
+	adjustment.ProposedAt = time.Now()
+	if err := d.store.SaveAdjustment(ctx, adjustment); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO adjustment %s of %s wei to %s in vault %s epoch %s proposed by %s: %s", adjustment.ID,
+		adjustment.Amount, adjustment.Account, adjustment.VaultID, adjustment.EpochNumber, adjustment.ProposedBy, adjustment.Justification)
+	d.record(ctx, actionProposeAdjustment, map[string]string{
+		"id":            adjustment.ID,
+		"vault":         adjustment.VaultID,
+		"epoch":         adjustment.EpochNumber,
+		"account":       adjustment.Account,
+		"amount":        adjustment.Amount,
+		"justification": adjustment.Justification,
+		"key":           adjustment.ProposedWith,
+	})
+	return &adjustment, nil
+}
+
+// ListAdjustments returns the adjustments matching the vault, epoch and status that are set, oldest first
+func (d *LazyDistributor) ListAdjustments(ctx context.Context, vaultId, epochNumber, status string) ([]subsidy.AllocationAdjustment, error) {
+	return d.store.ListAdjustments(ctx, vaultId, epochNumber, status)
+}
+
+// DecideAdjustment approves or rejects a pending adjustment. Whoever proposed it cannot decide it: the API key
+// it was proposed with is refused, or the same actor when it was proposed without one.
+func (d *LazyDistributor) DecideAdjustment(
+	ctx context.Context,
+	id string,
+	approve bool,
+	reason string,
+) (*subsidy.AllocationAdjustment, error) {
+	d.approvalMu.Lock()
+	defer d.approvalMu.Unlock()
+
+	adjustment, err := d.store.GetAdjustment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if adjustment.Status != subsidy.AdjustmentPendingApproval {
+		return nil, fmt.Errorf("%w: adjustment %s is already %s", subsidy.ErrInvalidInput, id, adjustment.Status)
+	}
+
+	actor, credential := audit.ActorFromContext(ctx), audit.CredentialFromContext(ctx)
+	sameAdmin := actor == adjustment.ProposedBy
+	if credential != "" && adjustment.ProposedWith != "" {
+		sameAdmin = credential == adjustment.ProposedWith
+	}
+	if sameAdmin {
+		return nil, fmt.Errorf("%w: adjustment %s was proposed by %s", subsidy.ErrSameApprover, id, adjustment.ProposedBy)
+	}
+
+	now := time.Now()
+	adjustment.DecidedBy, adjustment.DecidedWith, adjustment.DecidedAt = actor, credential, &now
+	action := actionApproveAdjustment
+	adjustment.Status = subsidy.AdjustmentApproved
+	if !approve {
+		action = actionRejectAdjustment
+		adjustment.Status = subsidy.AdjustmentRejected
+		adjustment.RejectionReason = reason
+	}
+	if err := d.store.SaveAdjustment(ctx, *adjustment); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO adjustment %s of %s wei to %s in vault %s epoch %s %s by %s", adjustment.ID, adjustment.Amount,
+		adjustment.Account, adjustment.VaultID, adjustment.EpochNumber, adjustment.Status, actor)
+	parameters := map[string]string{
+		"id":         adjustment.ID,
+		"vault":      adjustment.VaultID,
+		"epoch":      adjustment.EpochNumber,
+		"account":    adjustment.Account,
+		"amount":     adjustment.Amount,
+		"proposedBy": adjustment.ProposedBy,
+		"key":        credential,
+	}
+	if !approve {
+		parameters["reason"] = reason
+	}
+	d.record(ctx, action, parameters)
+	return adjustment, nil
+}
+
+// approvedAdjustments returns the approved adjustments of the vault's epoch. Distributions run without an
+// epoch apply none.
+func (d *LazyDistributor) approvedAdjustments(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+) ([]subsidy.AllocationAdjustment, error) {
+	if epochNumber == nil {
+		return nil, nil
+	}
+	return d.store.ListAdjustments(ctx, vaultId, epochNumber.String(), subsidy.AdjustmentApproved)
+}
+
+// applyAdjustments adds what each adjustment gives to the first allocation of its account, appending one for
+// an account without any, and takes what it takes from the account's allocations in order, never below zero
+func applyAdjustments(allocations []*allocation, adjustments []subsidy.AllocationAdjustment) ([]*allocation, []subsidy.AppliedAdjustment) {
+	byAccount := make(map[string][]*allocation)
+	for _, a := range allocations {
+		account := utils.NormalizeAddress(a.account)
+		byAccount[account] = append(byAccount[account], a)
+	}
+
+	applied := make([]subsidy.AppliedAdjustment, 0, len(adjustments))
+	for _, adjustment := range adjustments {
+		amount, ok := new(big.Int).SetString(adjustment.Amount, 10)
+		if !ok {
+			continue
+		}
+		account := utils.NormalizeAddress(adjustment.Account)
+		change := big.NewInt(0)
+		if amount.Sign() > 0 {
+			if len(byAccount[account]) == 0 {
+				a := newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: account}}, big.NewInt(0))
+				allocations = append(allocations, a)
+				byAccount[account] = append(byAccount[account], a)
+			}
+			byAccount[account][0].amount.Add(byAccount[account][0].amount, amount)
+			change.Set(amount)
+		} else {
+			owed := new(big.Int).Neg(amount)
+			for _, a := range byAccount[account] {
+				taken := new(big.Int).Set(a.amount)
+				if taken.Cmp(owed) > 0 {
+					taken.Set(owed)
+				}
+				a.amount.Sub(a.amount, taken)
+				owed.Sub(owed, taken)
+				change.Sub(change, taken)
+			}
+		}
+		applied = append(applied, subsidy.AppliedAdjustment{
+			ID:      adjustment.ID,
+			Account: account,
+			Amount:  amount.String(),
+			Applied: change.String(),
+		})
+	}
+	return allocations, applied
+}
+
+// adjustmentsParam is how applied adjustments enter a distribution's fingerprint
+func adjustmentsParam(applied []subsidy.AppliedAdjustment) string {
+	adjustments := make([]string, len(applied))
+	for i, adjustment := range applied {
+		adjustments[i] = adjustment.ID + ":" + adjustment.Account + ":" + adjustment.Amount
+	}
+	return strings.Join(sorted(adjustments), ",")
+}
@@ -0,0 +1,162 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const adjustmentTestAccount = "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b"
+
+func newAdjustmentTestDistributor(t *testing.T) (*LazyDistributor, *audit.RecorderMock) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	distributor.adjustments = adjustmentPolicy{maxAmount: big.NewInt(500)}
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	distributor.recorder = recorder
+	return distributor, recorder
+}
+
+func adminContext(actor, apiKey string) context.Context {
+	return audit.WithCredential(audit.WithActor(context.Background(), actor), audit.KeyID(apiKey))
+}
+
+func TestLazyDistributor_ProposeAdjustment(t *testing.T) {
+	distributor, _ := newAdjustmentTestDistributor(t)
+	ctx := adminContext("api:10.0.0.1", "alice-key")
+	proposal := subsidy.AllocationAdjustment{
+		VaultID: planTestVault, EpochNumber: "5", Account: adjustmentTestAccount, Justification: "double credit",
+	}
+
+	for _, amount := range []string{"", "0", "1.5", "501", "-501"} {
+		proposal.Amount = amount
+		_, err := distributor.ProposeAdjustment(ctx, proposal)
+		assert.ErrorIs(t, err, subsidy.ErrInvalidInput, "amount %q", amount)
+	}
+
+	proposal.Amount = "-500"
+	proposed, err := distributor.ProposeAdjustment(ctx, proposal)
+	require.NoError(t, err)
+	assert.NotEmpty(t, proposed.ID)
+	assert.Equal(t, subsidy.AdjustmentPendingApproval, proposed.Status)
+	assert.Equal(t, "api:10.0.0.1", proposed.ProposedBy)
+	assert.Equal(t, audit.KeyID("alice-key"), proposed.ProposedWith)
+
+	distributor.adjustments = adjustmentPolicy{}
+	_, err = distributor.ProposeAdjustment(ctx, proposal)
+	assert.ErrorIs(t, err, subsidy.ErrInvalidInput, "adjustments are refused when no bound is configured")
+}
+
+func TestLazyDistributor_DecideAdjustmentRequiresAnotherAdmin(t *testing.T) {
+	distributor, recorder := newAdjustmentTestDistributor(t)
+	alice := adminContext("api:10.0.0.1", "alice-key")
+	proposed, err := distributor.ProposeAdjustment(alice, subsidy.AllocationAdjustment{
+		VaultID: planTestVault, EpochNumber: "5", Account: adjustmentTestAccount, Amount: "200", Justification: "missed deposit",
+	})
+	require.NoError(t, err)
+
+	_, err = distributor.DecideAdjustment(alice, proposed.ID, true, "")
+	assert.ErrorIs(t, err, subsidy.ErrSameApprover)
+	_, err = distributor.DecideAdjustment(adminContext("api:10.0.0.2", "alice-key"), proposed.ID, true, "")
+	assert.ErrorIs(t, err, subsidy.ErrSameApprover, "the same API key from another address is the same admin")
+	_, err = distributor.DecideAdjustment(context.Background(), "missing", true, "")
+	assert.ErrorIs(t, err, subsidy.ErrNotFound)
+
+	// admins behind the same proxy are told apart by their keys
+	approved, err := distributor.DecideAdjustment(adminContext("api:10.0.0.1", "bob-key"), proposed.ID, true, "")
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.AdjustmentApproved, approved.Status)
+	assert.Equal(t, audit.KeyID("bob-key"), approved.DecidedWith)
+	require.NotNil(t, approved.DecidedAt)
+
+	_, err = distributor.DecideAdjustment(adminContext("api:10.0.0.3", "carol-key"), proposed.ID, false, "changed my mind")
+	assert.ErrorIs(t, err, subsidy.ErrInvalidInput, "a decided adjustment cannot be decided again")
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, actionProposeAdjustment, calls[0].Entry.Action)
+	assert.Equal(t, "missed deposit", calls[0].Entry.Parameters["justification"])
+	assert.Equal(t, actionApproveAdjustment, calls[1].Entry.Action)
+	assert.Equal(t, audit.KeyID("bob-key"), calls[1].Entry.Parameters["key"])
+
+	listed, err := distributor.ListAdjustments(context.Background(), planTestVault, "5", subsidy.AdjustmentApproved)
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, proposed.ID, listed[0].ID)
+	pending, err := distributor.ListAdjustments(context.Background(), planTestVault, "5", subsidy.AdjustmentPendingApproval)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestLazyDistributor_AppliesApprovedAdjustments(t *testing.T) {
+	distributor, _ := newAdjustmentTestDistributor(t)
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+	alice, bob := adminContext("api:10.0.0.1", "alice-key"), adminContext("api:10.0.0.2", "bob-key")
+
+	propose := func(account, amount string) *subsidy.AllocationAdjustment {
+		proposed, err := distributor.ProposeAdjustment(alice, subsidy.AllocationAdjustment{
+			VaultID: planTestVault, EpochNumber: "5", Account: account, Amount: amount, Justification: "test",
+		})
+		require.NoError(t, err)
+		return proposed
+	}
+	taken := propose(adjustmentTestAccount, "-300")
+	given := propose("0x1111111111111111111111111111111111111111", "100")
+	rejected := propose(adjustmentTestAccount, "500")
+	propose(adjustmentTestAccount, "50") // left pending
+	for _, id := range []string{taken.ID, given.ID} {
+		_, err := distributor.DecideAdjustment(bob, id, true, "")
+		require.NoError(t, err)
+	}
+	_, err := distributor.DecideAdjustment(bob, rejected.ID, false, "wrong account")
+	require.NoError(t, err)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	assert.Equal(t, "800", result.TotalSubsidies.String(), "1000 less 300 plus 100, pending and rejected adjustments left out")
+
+	fingerprint, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	require.NotNil(t, fingerprint)
+	assert.Equal(t, adjustmentsParam([]subsidy.AppliedAdjustment{
+		{ID: taken.ID, Account: adjustmentTestAccount, Amount: "-300"},
+		{ID: given.ID, Account: "0x1111111111111111111111111111111111111111", Amount: "100"},
+	}), fingerprint.Params["adjustments"])
+
+	snapshot, err := distributor.merkleService.(*merkleimpl.Service).GetSnapshot(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Len(t, snapshot.Entries, 2, "the account given an amount is in the tree")
+}
+
+func TestApplyAdjustments(t *testing.T) {
+	allocations := []*allocation{
+		newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: "0xA"}}, big.NewInt(30)),
+		newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: "0xa"}}, big.NewInt(20)),
+		newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: "0xb"}}, big.NewInt(10)),
+	}
+
+	adjusted, applied := applyAdjustments(allocations, []subsidy.AllocationAdjustment{
+		{ID: "1", Account: "0xa", Amount: "-40"},
+		{ID: "2", Account: "0xb", Amount: "-25"},
+		{ID: "3", Account: "0xc", Amount: "5"},
+	})
+
+	require.Len(t, adjusted, 4, "an account without allocations gets one")
+	assert.Equal(t, "0", adjusted[0].amount.String())
+	assert.Equal(t, "10", adjusted[1].amount.String(), "taken from the account's allocations in order")
+	assert.Equal(t, "0", adjusted[2].amount.String(), "never below zero")
+	assert.Equal(t, "5", adjusted[3].amount.String())
+	assert.Equal(t, []subsidy.AppliedAdjustment{
+		{ID: "1", Account: "0xa", Amount: "-40", Applied: "-40"},
+		{ID: "2", Account: "0xb", Amount: "-25", Applied: "-10"},
+		{ID: "3", Account: "0xc", Amount: "5", Applied: "5"},
+	}, applied)
+}
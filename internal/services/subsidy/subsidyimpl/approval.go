@@ -0,0 +1,264 @@
+package subsidyimpl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+// approvalPolicy decides which distributions must be approved before their root is pushed on-chain
+type approvalPolicy struct {
+	enabled     bool
+	maxTotal    *big.Int // nil when no total threshold is configured
+	maxAccounts int      // zero when no accounts threshold is configured
+}
+
+func newApprovalPolicy(cfg *config.Config) approvalPolicy {
+	policy := approvalPolicy{
+		enabled:     cfg.Approval.Enabled,
+		maxAccounts: cfg.Approval.AutoApproveMaxAccounts,
+	}
+	// config.Load rejects malformed totals
+	if total, ok := new(big.Int).SetString(cfg.Approval.AutoApproveMaxTotal, 10); ok {
+		policy.maxTotal = total
+	}
+	return policy
+}
+
+// approvalReason returns why a distribution needs approval, or "" when it can be pushed right away.
+// A distribution is auto-approved only when it is within every configured threshold.
+func (p approvalPolicy) approvalReason(total *big.Int, accounts int) string {
+	switch {
+	case !p.enabled:
+		return ""
+	case p.maxTotal == nil && p.maxAccounts == 0:
+		return "no auto-approve thresholds configured"
+	case p.maxTotal != nil && total.Cmp(p.maxTotal) > 0:
+		return fmt.Sprintf("total %s exceeds auto-approve limit %s", total, p.maxTotal)
+	case p.maxAccounts > 0 && accounts > p.maxAccounts:
+		return fmt.Sprintf("%d accounts exceed auto-approve limit %d", accounts, p.maxAccounts)
+	default:
+		return ""
+	}
+}
+
+// approvalReason returns why the distribution needs approval under the approval policy. While auto-approval is
+// switched off every distribution needs one when approval is enabled.
+func (d *LazyDistributor) approvalReason(ctx context.Context, total *big.Int, accounts int) string {
+	reason := d.approval.approvalReason(total, accounts)
+	if reason == "" && d.approval.enabled && !d.featureEnabled(ctx, features.FlagAutoApproval) {
+		return "auto-approval is switched off"
+	}
+	return reason
+}
+
+// ListStaged returns staged distributions with the given status, or all of them when status is empty
+func (d *LazyDistributor) ListStaged(ctx context.Context, status string) ([]subsidy.StagedDistribution, error) {
+	return d.store.ListStagedDistributions(ctx, status)
+}
+
+// SubmitStaged pushes a pending staged root on-chain and marks it approved.
+// A failed push leaves the distribution pending so the approval can be retried.
+func (d *LazyDistributor) SubmitStaged(ctx context.Context, id string) (*subsidy.StagedDistribution, error) {
+	d.approvalMu.Lock()
+	defer d.approvalMu.Unlock()
+
+	staged, err := d.pendingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	rootBytes, err := hex.DecodeString(staged.MerkleRoot)
+	if err != nil || len(rootBytes) != 32 {
+		return nil, fmt.Errorf("staged distribution %s has a malformed merkle root %q", id, staged.MerkleRoot)
+	}
+	var merkleRoot [32]byte
+	copy(merkleRoot[:], rootBytes)
+	totalSubsidies, ok := new(big.Int).SetString(staged.TotalSubsidies, 10)
+	if !ok {
+		return nil, fmt.Errorf("staged distribution %s has a malformed total %q", id, staged.TotalSubsidies)
+	}
+
+	d.logger.Logf("INFO pushing approved merkle root %s for vault %s (staged distribution %s)", staged.MerkleRoot, staged.VaultID, id)
+	if err := d.updateMerkleRoot(ctx, staged.VaultID, merkleRoot, totalSubsidies); err != nil {
+		d.logger.Logf("ERROR failed to push approved merkle root for staged distribution %s: %v", id, err)
+		return nil, fmt.Errorf("failed to update merkle root on blockchain: %w", err)
+	}
+	if carried, ok := new(big.Int).SetString(staged.CarriedForward, 10); ok {
+		d.saveCarryForward(ctx, staged.VaultID, carried)
+	}
+	if dust, ok := new(big.Rat).SetString(staged.DustCarried); ok {
+		d.saveDustCarry(ctx, staged.VaultID, dust)
+	}
+	if epochNumber, ok := new(big.Int).SetString(staged.EpochNumber, 10); ok {
+		d.commitFingerprint(ctx, staged.VaultID, epochNumber, staged.Fingerprint)
+	}
+
+	staged.Status = subsidy.StagedApproved
+	staged.DecidedBy = audit.ActorFromContext(ctx)
+	staged.DecidedAt = time.Now()
+	if err := d.store.SaveStagedDistribution(ctx, *staged); err != nil {
+		// the root is on-chain, so report success and leave the record for an operator to fix
+		d.logger.Logf("ERROR merkle root for staged distribution %s was pushed but its status was not saved: %v", id, err)
+	}
+
+	d.events.Publish(ctx, events.Event{
+		Type:    events.RootSubmitted,
+		EpochID: staged.EpochNumber,
+		Vault:   staged.VaultID,
+		Data: map[string]interface{}{
+			"merkleRoot":        "0x" + staged.MerkleRoot,
+			"totalSubsidies":    staged.TotalSubsidies,
+			"accountsProcessed": staged.AccountsProcessed,
+			"blockNumber":       staged.BlockNumber,
+			"stagedId":          staged.ID,
+		},
+	})
+
+	return staged, nil
+}
+
+// RejectStaged discards a pending staged root so the next run computes a new one
+func (d *LazyDistributor) RejectStaged(ctx context.Context, id, reason string) (*subsidy.StagedDistribution, error) {
+	d.approvalMu.Lock()
+	defer d.approvalMu.Unlock()
+
+	staged, err := d.pendingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	staged.Status = subsidy.StagedRejected
+	staged.Reason = reason
+	staged.DecidedBy = audit.ActorFromContext(ctx)
+	staged.DecidedAt = time.Now()
+	if err := d.store.SaveStagedDistribution(ctx, *staged); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO staged distribution %s for vault %s rejected by %s: %s", id, staged.VaultID, staged.DecidedBy, reason)
+	return staged, nil
+}
+
+// stage records the snapshot's root for approval instead of pushing it
+func (d *LazyDistributor) stage(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	snapshot *distributionSnapshot,
+	reason string,
+) (*subsidy.StagedDistribution, error) {
+	staged := subsidy.StagedDistribution{
+		ID:                newStagedID(),
+		VaultID:           vaultId,
+		MerkleRoot:        fmt.Sprintf("%x", snapshot.merkleRoot),
+		TotalSubsidies:    snapshot.totalSubsidies.String(),
+		AccountsProcessed: len(snapshot.entries),
+		BlockNumber:       snapshot.block.Number,
+		BlockHash:         snapshot.block.Hash,
+		BlockStrategy:     snapshot.strategy,
+		Status:            subsidy.StagedPendingApproval,
+		Reason:            reason,
+		CreatedAt:         time.Now(),
+	}
+	if epochNumber != nil {
+		staged.EpochNumber = epochNumber.String()
+	}
+	if snapshot.carriedOut != nil {
+		staged.CarriedForward = snapshot.carriedOut.String()
+	}
+	if snapshot.dustCarriedOut != nil {
+		staged.DustCarried = snapshot.dustCarriedOut.RatString()
+	}
+	if snapshot.fingerprint != nil {
+		staged.Fingerprint = snapshot.fingerprint.Hash
+	}
+	staged.Diff = snapshot.diff
+
+	if err := d.store.SaveStagedDistribution(ctx, staged); err != nil {
+		return nil, fmt.Errorf("failed to stage distribution: %w", err)
+	}
+
+	d.logger.Logf("INFO staged distribution %s for vault %s awaits approval: %s", staged.ID, vaultId, reason)
+	payload := map[string]interface{}{
+		"stagedId":          staged.ID,
+		"vaultAddress":      vaultId,
+		"epochId":           staged.EpochNumber,
+		"merkleRoot":        "0x" + staged.MerkleRoot,
+		"totalSubsidies":    staged.TotalSubsidies,
+		"accountsProcessed": staged.AccountsProcessed,
+		"reason":            reason,
+	}
+	if staged.Diff != nil {
+		payload["diff"] = staged.Diff
+	}
+	d.notifier.Notify(ctx, webhook.EventDistributionStaged, payload)
+	return &staged, nil
+}
+
+// pendingFor returns the distribution of the vault and epoch still awaiting approval, if any
+func (d *LazyDistributor) pendingFor(ctx context.Context, vaultId string, epochNumber *big.Int) (*subsidy.StagedDistribution, error) {
+	pending, err := d.store.ListStagedDistributions(ctx, subsidy.StagedPendingApproval)
+	if err != nil {
+		return nil, err
+	}
+
+	epoch := ""
+	if epochNumber != nil {
+		epoch = epochNumber.String()
+	}
+	for i := range pending {
+		if strings.EqualFold(pending[i].VaultID, vaultId) && pending[i].EpochNumber == epoch {
+			return &pending[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (d *LazyDistributor) pendingByID(ctx context.Context, id string) (*subsidy.StagedDistribution, error) {
+	if id == "" {
+		return nil, fmt.Errorf("%w: staged distribution id cannot be empty", subsidy.ErrInvalidInput)
+	}
+	staged, err := d.store.GetStagedDistribution(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if staged.Status != subsidy.StagedPendingApproval {
+		return nil, fmt.Errorf("%w: staged distribution %s is already %s", subsidy.ErrInvalidInput, id, staged.Status)
+	}
+	return staged, nil
+}
+
+// stagedResult reports a distribution that is waiting for approval
+func stagedResult(staged *subsidy.StagedDistribution) *subsidy.DistributionResult {
+	total, ok := new(big.Int).SetString(staged.TotalSubsidies, 10)
+	if !ok {
+		total = big.NewInt(0)
+	}
+	return &subsidy.DistributionResult{
+		TotalSubsidies:    total,
+		AccountsProcessed: staged.AccountsProcessed,
+		MerkleRoot:        staged.MerkleRoot,
+		StagedID:          staged.ID,
+	}
+}
+
+func newStagedID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
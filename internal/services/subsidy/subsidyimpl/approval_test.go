@@ -0,0 +1,204 @@
+package subsidyimpl
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func TestApprovalPolicy_ApprovalReason(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      approvalPolicy
+		total       int64
+		accounts    int
+		needsReview bool
+	}{
+		{name: "disabled", policy: approvalPolicy{}, total: 1_000_000, accounts: 1000},
+		{name: "no_thresholds", policy: approvalPolicy{enabled: true}, total: 1, accounts: 1, needsReview: true},
+		{name: "within_total", policy: approvalPolicy{enabled: true, maxTotal: big.NewInt(100)}, total: 100, accounts: 1000},
+		{name: "over_total", policy: approvalPolicy{enabled: true, maxTotal: big.NewInt(100)}, total: 101, accounts: 1, needsReview: true},
+		{name: "within_accounts", policy: approvalPolicy{enabled: true, maxAccounts: 10}, total: 1_000_000, accounts: 10},
+		{name: "over_accounts", policy: approvalPolicy{enabled: true, maxAccounts: 10}, total: 1, accounts: 11, needsReview: true},
+		{
+			name:        "within_total_over_accounts",
+			policy:      approvalPolicy{enabled: true, maxTotal: big.NewInt(100), maxAccounts: 10},
+			total:       50,
+			accounts:    20,
+			needsReview: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason := tt.policy.approvalReason(big.NewInt(tt.total), tt.accounts)
+			assert.Equal(t, tt.needsReview, reason != "", reason)
+		})
+	}
+}
+
+func newApprovalTestDistributor(db storage.DB, chain *blockchain.BlockchainClientMock, policy approvalPolicy) *LazyDistributor {
+	return &LazyDistributor{
+		blockchainClient: chain,
+		merkleService:    merkleimpl.New(db, nil, nil, lgr.NoOp),
+		subgraphClient:   testSubgraphWithSubsidies(),
+		notifier:         &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}},
+		events:           &events.PublisherMock{PublishFunc: func(ctx context.Context, event events.Event) {}},
+		logger:           lgr.NoOp,
+		store:            NewStore(db, lgr.NoOp),
+		approval:         policy,
+	}
+}
+
+func newApprovalTestChain(pushErr error) *blockchain.BlockchainClientMock {
+	return &blockchain.BlockchainClientMock{
+		GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+			return &blockchain.BlockRef{Number: 100, Hash: "0xa"}, nil
+		},
+		UpdateMerkleRootAndWaitForConfirmationFunc: func(ctx context.Context, vaultId string, root [32]byte, totalSubsidies *big.Int) error {
+			return pushErr
+		},
+	}
+}
+
+func publishedEvents(d *LazyDistributor) []events.Type {
+	var published []events.Type
+	for _, call := range d.events.(*events.PublisherMock).PublishCalls() {
+		published = append(published, call.Event.Type)
+	}
+	return published
+}
+
+func notifiedEvents(d *LazyDistributor) []webhook.EventType {
+	var events []webhook.EventType
+	for _, call := range d.notifier.(*webhook.NotifierMock).NotifyCalls() {
+		events = append(events, call.EventType)
+	}
+	return events
+}
+
+func TestLazyDistributor_StagesAndSubmitsAfterApproval(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(nil)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{enabled: true, maxTotal: big.NewInt(500)})
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	require.NotEmpty(t, result.StagedID)
+	assert.Equal(t, "1000", result.TotalSubsidies.String())
+	assert.Empty(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), "root over the threshold must wait for approval")
+	assert.Equal(t, []webhook.EventType{webhook.EventDistributionStaged}, notifiedEvents(distributor))
+	assert.Equal(t, []events.Type{events.EpochSnapshotted, events.DistributionComputed}, publishedEvents(distributor))
+
+	again, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, result.StagedID, again.StagedID, "pending distribution is not recomputed")
+	assert.Len(t, distributor.subgraphClient.(*subgraph.SubgraphClientMock).StreamAccountSubsidiesForVaultCalls(), 1)
+
+	staged, err := distributor.SubmitStaged(audit.WithActor(ctx, "api:10.0.0.1"), result.StagedID)
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.StagedApproved, staged.Status)
+	assert.Equal(t, "api:10.0.0.1", staged.DecidedBy)
+	assert.Equal(t, "5", staged.EpochNumber)
+	require.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+	assert.Equal(t, result.MerkleRoot, hex.EncodeToString(chain.UpdateMerkleRootAndWaitForConfirmationCalls()[0].Root[:]))
+	assert.Equal(t, []events.Type{events.EpochSnapshotted, events.DistributionComputed, events.RootSubmitted},
+		publishedEvents(distributor))
+
+	_, err = distributor.SubmitStaged(ctx, result.StagedID)
+	assert.ErrorIs(t, err, subsidy.ErrInvalidInput, "an approved distribution cannot be pushed twice")
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+}
+
+func TestLazyDistributor_RejectedDistributionIsRecomputed(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(nil)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{enabled: true})
+	ctx := context.Background()
+
+	first, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	rejected, err := distributor.RejectStaged(ctx, first.StagedID, "totals look wrong")
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.StagedRejected, rejected.Status)
+	assert.Equal(t, "totals look wrong", rejected.Reason)
+
+	second, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.StagedID, second.StagedID)
+
+	staged, err := distributor.ListStaged(ctx, subsidy.StagedPendingApproval)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	assert.Equal(t, second.StagedID, staged[0].ID)
+	assert.Empty(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls())
+
+	_, err = distributor.RejectStaged(ctx, "missing", "")
+	assert.ErrorIs(t, err, subsidy.ErrNotFound)
+}
+
+func TestLazyDistributor_AutoApprovedWithinThreshold(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(nil)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{enabled: true, maxTotal: big.NewInt(1000)})
+
+	result, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Empty(t, result.StagedID)
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+}
+
+func TestLazyDistributor_AutoApprovalSwitchedOff(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(nil)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{enabled: true, maxTotal: big.NewInt(1000)})
+	distributor.SetFeatures(&features.ServiceMock{
+		EnabledFunc: func(ctx context.Context, flag string) bool { return flag != features.FlagAutoApproval },
+	})
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	require.NotEmpty(t, result.StagedID, "a distribution within the thresholds waits for approval")
+	assert.Empty(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls())
+
+	staged, err := distributor.ListStaged(ctx, subsidy.StagedPendingApproval)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	assert.Equal(t, "auto-approval is switched off", staged[0].Reason)
+}
+
+func TestLazyDistributor_FailedSubmitStaysPending(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(errors.New("execution reverted"))
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{enabled: true})
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	_, err = distributor.SubmitStaged(ctx, result.StagedID)
+	require.Error(t, err)
+
+	staged, err := distributor.ListStaged(ctx, subsidy.StagedPendingApproval)
+	require.NoError(t, err)
+	require.Len(t, staged, 1, "a failed push can be approved again")
+}
@@ -0,0 +1,54 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/services/archive"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// SetArchive sets where epoch distributions are also written for offline analysis. It is called once at
+// startup, before any distribution runs.
+func (d *LazyDistributor) SetArchive(service archive.Service) {
+	d.archive = service
+}
+
+// archiveDistribution writes the saved snapshot of the vault's epoch to the archive with the allocations it
+// was built from. The archive is a copy for analysis, so a failure is logged and the distribution goes on.
+func (d *LazyDistributor) archiveDistribution(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	saved *merkle.MerkleSnapshot,
+	distribution *distributionSnapshot,
+) {
+	if d.archive == nil {
+		return
+	}
+
+	allocations := make([]archive.Allocation, len(distribution.allocations))
+	for i, a := range distribution.allocations {
+		allocations[i] = archive.Allocation{
+			Account:    a.account,
+			Collection: a.collection,
+			Debt:       a.debt,
+			Valued:     a.uncapped,
+			Amount:     a.amount,
+		}
+	}
+	_, err := d.archive.WriteDistribution(ctx, archive.Distribution{
+		VaultID:       vaultId,
+		EpochNumber:   epochNumber,
+		BlockNumber:   distribution.block.Number,
+		BlockHash:     saved.BlockHash,
+		BlockStrategy: saved.BlockStrategy,
+		ValuedAt:      saved.Timestamp,
+		MerkleRoot:    saved.MerkleRoot,
+		Fingerprint:   saved.Fingerprint,
+		Allocations:   allocations,
+	})
+	if err != nil {
+		d.logger.Logf("WARN failed to archive distribution of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+	}
+}
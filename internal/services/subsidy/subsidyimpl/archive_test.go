@@ -0,0 +1,48 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/archive"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+)
+
+func TestLazyDistributor_ArchivesEpochDistributions(t *testing.T) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	archiveService := &archive.ServiceMock{
+		WriteDistributionFunc: func(ctx context.Context, distribution archive.Distribution) (string, error) {
+			return "", fmt.Errorf("bucket unavailable")
+		},
+	}
+	distributor.SetArchive(archiveService)
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+
+	// the archive is a copy, failing to write it does not fail the distribution
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	require.Len(t, archiveService.WriteDistributionCalls(), 1)
+
+	archived := archiveService.WriteDistributionCalls()[0].Distribution
+	snapshot, err := distributor.merkleService.(*merkleimpl.Service).GetSnapshot(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, planTestVault, archived.VaultID)
+	assert.Equal(t, epoch, archived.EpochNumber)
+	assert.Equal(t, uint64(100), archived.BlockNumber)
+	assert.Equal(t, snapshot.MerkleRoot, archived.MerkleRoot)
+	assert.Equal(t, snapshot.Fingerprint, archived.Fingerprint)
+	require.Len(t, archived.Allocations, len(snapshot.Entries))
+	assert.Equal(t, snapshot.Entries[0].TotalEarned, archived.Allocations[0].Amount)
+
+	// distributions without an epoch save no snapshot and are not archived
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, epoch))
+	_, err = distributor.Run(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Len(t, archiveService.WriteDistributionCalls(), 1)
+}
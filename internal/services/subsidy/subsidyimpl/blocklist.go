@@ -0,0 +1,137 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// audit actions recorded for blocklist changes
+const (
+	actionBlockAddress   = "blockAddress"
+	actionUnblockAddress = "unblockAddress"
+)
+
+// blocklistPolicy is what a distribution does with the amounts blocked accounts would have received
+type blocklistPolicy struct {
+	redistribute bool // spread over the other allocations instead of burned
+}
+
+func newBlocklistPolicy(cfg *config.Config) blocklistPolicy {
+	return blocklistPolicy{redistribute: cfg.Blocklist.Remainder == subsidy.BlockedRemainderRedistribute}
+}
+
+// blockedRecord is what a distribution left out for blocked accounts, kept with the epoch so explanations
+// and replays leave out the same accounts after the blocklist changes
+type blockedRecord struct {
+	Accounts  []string `json:"accounts"`  // blocked accounts that had allocations, normalized and sorted
+	Withheld  string   `json:"withheld"`  // wei they would have received
+	Burned    string   `json:"burned"`    // wei of it left out of the tree
+	Remainder string   `json:"remainder"` // burn or redistribute
+}
+
+// blocks reports whether the distribution left account out
+func (r blockedRecord) blocks(account string) bool {
+	account = utils.NormalizeAddress(account)
+	i := sort.SearchStrings(r.Accounts, account)
+	return i < len(r.Accounts) && r.Accounts[i] == account
+}
+
+// accounts returns the accounts the distribution left out as a set
+func (r blockedRecord) accounts() map[string]bool {
+	accounts := make(map[string]bool, len(r.Accounts))
+	for _, account := range r.Accounts {
+		accounts[account] = true
+	}
+	return accounts
+}
+
+// exclude removes the allocations of blocked accounts. What they would have received is burned, or with
+// redistribute spread over the allocations left pro-rata to their amounts, before caps see them; the few
+// wei the pro-rata split cannot place are burned.
+func (p blocklistPolicy) exclude(allocations []*allocation, blocked map[string]bool) ([]*allocation, blockedRecord) {
+	record := blockedRecord{Remainder: subsidy.BlockedRemainderBurn}
+	if p.redistribute {
+		record.Remainder = subsidy.BlockedRemainderRedistribute
+	}
+
+	withheld := big.NewInt(0)
+	kept := make([]*allocation, 0, len(allocations))
+	seen := make(map[string]bool)
+	for _, a := range allocations {
+		account := utils.NormalizeAddress(a.account)
+		if !blocked[account] {
+			kept = append(kept, a)
+			continue
+		}
+		withheld.Add(withheld, a.amount)
+		if !seen[account] {
+			seen[account] = true
+			record.Accounts = append(record.Accounts, account)
+		}
+	}
+	sort.Strings(record.Accounts)
+
+	burned := withheld
+	if p.redistribute && withheld.Sign() > 0 {
+		// without caps configured the groups have no limits, so only rounding stops the pool being placed
+		collections, users := capPolicy{}.groups(kept)
+		burned = redistribute(kept, append(collections, users...), withheld)
+	}
+	record.Withheld = withheld.String()
+	record.Burned = burned.String()
+	return kept, record
+}
+
+// blockedAccounts returns the blocklisted addresses, normalized
+func (d *LazyDistributor) blockedAccounts(ctx context.Context) (map[string]bool, error) {
+	entries, err := d.store.ListBlockedAddresses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	blocked := make(map[string]bool, len(entries))
+	for _, entry := range entries {
+		blocked[utils.NormalizeAddress(entry.Address)] = true
+	}
+	return blocked, nil
+}
+
+// BlockAddress blocklists the address as the context's actor, replacing the entry added for it before
+func (d *LazyDistributor) BlockAddress(ctx context.Context, blocked subsidy.BlockedAddress) (*subsidy.BlockedAddress, error) {
+	blocked.BlockedBy = audit.ActorFromContext(ctx)
+	blocked.BlockedAt = time.Now()
+	if err := d.store.SaveBlockedAddress(ctx, blocked); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO address %s blocklisted by %s: %s", blocked.Address, blocked.BlockedBy, blocked.Reason)
+	d.record(ctx, actionBlockAddress, map[string]string{"address": blocked.Address, "reason": blocked.Reason})
+	return &blocked, nil
+}
+
+// ListBlockedAddresses returns the blocklist, ordered by address
+func (d *LazyDistributor) ListBlockedAddresses(ctx context.Context) ([]subsidy.BlockedAddress, error) {
+	return d.store.ListBlockedAddresses(ctx)
+}
+
+// UnblockAddress removes the address from the blocklist. Distributions already built keep it out.
+func (d *LazyDistributor) UnblockAddress(ctx context.Context, address string) error {
+	found, err := d.store.DeleteBlockedAddress(ctx, address)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: address %s is not blocklisted", subsidy.ErrNotFound, address)
+	}
+
+	d.logger.Logf("INFO address %s removed from the blocklist by %s", address, audit.ActorFromContext(ctx))
+	d.record(ctx, actionUnblockAddress, map[string]string{"address": address})
+	return nil
+}
@@ -0,0 +1,191 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const blocklistTestOwnerC = "0x3333333333333333333333333333333333333333"
+
+// blocklistTestSubgraph serves owners A, B and C earning 300, 500 and 200
+func blocklistTestSubgraph() *subgraph.SubgraphClientMock {
+	subsidies := []subgraph.AccountSubsidy{
+		{ID: "a", Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xcollection-a", TotalRewardsEarned: "300"},
+		{ID: "b", Account: subgraph.Account{ID: holdingsTestOwnerB}, CollectionParticipation: "0xcollection-a", TotalRewardsEarned: "500"},
+		{ID: "c", Account: subgraph.Account{ID: blocklistTestOwnerC}, CollectionParticipation: "0xcollection-b", TotalRewardsEarned: "200"},
+	}
+	byAccount := func(accounts ...string) map[string][]subgraph.AccountSubsidy {
+		found := make(map[string][]subgraph.AccountSubsidy)
+		for _, account := range accounts {
+			for _, s := range subsidies {
+				if s.Account.ID == account {
+					found[account] = append(found[account], s)
+				}
+			}
+		}
+		return found
+	}
+	return &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accountAddress string,
+			blockNumber int64,
+		) ([]subgraph.AccountSubsidy, error) {
+			return byAccount(accountAddress)[accountAddress], nil
+		},
+		QueryAccountSubsidiesForAccountsAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accounts []string,
+			blockNumber int64,
+		) (map[string][]subgraph.AccountSubsidy, error) {
+			return byAccount(accounts...), nil
+		},
+		QueryMerkleDistributionForEpochFunc: func(ctx context.Context, epochNumber, vaultAddress string) (*subgraph.MerkleDistribution, error) {
+			return nil, errors.New("merkle distribution not found")
+		},
+	}
+}
+
+func newBlocklistTestDistributor(t *testing.T, remainder string) *LazyDistributor {
+	t.Helper()
+	cfg := &config.Config{}
+	cfg.Blocklist.Remainder = remainder
+	distributor := newApprovalTestDistributor(newPlannerTestDB(t), newApprovalTestChain(nil), approvalPolicy{})
+	distributor.subgraphClient = blocklistTestSubgraph()
+	distributor.blocklist = newBlocklistPolicy(cfg)
+	return distributor
+}
+
+func TestLazyDistributor_BlockedAccountsAreBurned(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	ctx := context.Background()
+
+	_, err := distributor.BlockAddress(ctx, subsidy.BlockedAddress{Address: holdingsTestOwnerB, Reason: "sanctioned"})
+	require.NoError(t, err)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.Equal(t, "500", result.TotalSubsidies.String(), "the blocked account's 500 is left out of the tree")
+	assert.Equal(t, 2, result.AccountsProcessed)
+
+	record, err := distributor.store.GetBlockedRecord(ctx, big.NewInt(3), planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, []string{holdingsTestOwnerB}, record.Accounts)
+	assert.Equal(t, "500", record.Withheld)
+	assert.Equal(t, "500", record.Burned)
+
+	// the epoch keeps the account out once it is unblocked
+	require.NoError(t, distributor.UnblockAddress(ctx, holdingsTestOwnerB))
+	explanations, err := distributor.ExplainBatch(ctx, planTestVault, big.NewInt(3), []string{holdingsTestOwnerA, holdingsTestOwnerB})
+	require.NoError(t, err)
+	require.Len(t, explanations.Explanations, 2)
+	assert.Zero(t, explanations.Mismatched)
+	blocked := explanations.Explanations[1]
+	assert.Equal(t, "0", blocked.DistributedAmount)
+	require.Len(t, blocked.Collections, 1)
+	assert.Equal(t, subsidy.AllocationSourceBlocked, blocked.Collections[0].Source)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches, "replays leave out the accounts the epoch recorded as blocked")
+
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", result.TotalSubsidies.String())
+}
+
+func TestLazyDistributor_BlockedAccountsAreRedistributed(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderRedistribute)
+	ctx := context.Background()
+
+	_, err := distributor.BlockAddress(ctx, subsidy.BlockedAddress{Address: holdingsTestOwnerB})
+	require.NoError(t, err)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.Equal(t, "1000", result.TotalSubsidies.String(), "the blocked account's 500 goes to the others")
+	assert.Equal(t, 2, result.AccountsProcessed)
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(3), holdingsTestOwnerA)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches)
+	assert.Equal(t, "600", explanation.DistributedAmount, "300 plus 300 of the 500 pro-rata")
+	require.Len(t, explanation.Collections, 1)
+	assert.Equal(t, "300", explanation.Collections[0].Redistributed)
+
+	explanation, err = distributor.Explain(ctx, planTestVault, big.NewInt(3), blocklistTestOwnerC)
+	require.NoError(t, err)
+	assert.Equal(t, "400", explanation.DistributedAmount)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches)
+}
+
+func TestLazyDistributor_BlocklistIsAudited(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	distributor.recorder = recorder
+	ctx := audit.WithActor(context.Background(), "compliance")
+
+	blocked, err := distributor.BlockAddress(ctx, subsidy.BlockedAddress{Address: holdingsTestOwnerB, Reason: "sanctioned"})
+	require.NoError(t, err)
+	assert.Equal(t, "compliance", blocked.BlockedBy)
+	_, err = distributor.BlockAddress(ctx, subsidy.BlockedAddress{Address: holdingsTestOwnerA, Reason: "compromised"})
+	require.NoError(t, err)
+
+	list, err := distributor.ListBlockedAddresses(ctx)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, holdingsTestOwnerA, list[0].Address)
+	assert.Equal(t, "compromised", list[0].Reason)
+
+	require.NoError(t, distributor.UnblockAddress(ctx, holdingsTestOwnerA))
+	require.ErrorIs(t, distributor.UnblockAddress(ctx, holdingsTestOwnerA), subsidy.ErrNotFound)
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 3, "a failed unblock is not recorded")
+	assert.Equal(t, actionBlockAddress, calls[0].Entry.Action)
+	assert.Equal(t, map[string]string{"address": holdingsTestOwnerB, "reason": "sanctioned"}, calls[0].Entry.Parameters)
+	assert.Equal(t, actionUnblockAddress, calls[2].Entry.Action)
+	assert.Equal(t, "compliance", calls[2].Entry.Actor)
+}
+
+func TestService_BlockAddressValidates(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	service := New(distributor, nil, nil, nil, lgr.NoOp, &config.Config{})
+	ctx := context.Background()
+
+	_, err := service.BlockAddress(ctx, subsidy.BlockedAddress{Address: "0x12"})
+	require.ErrorIs(t, err, subsidy.ErrInvalidInput)
+	require.ErrorIs(t, service.UnblockAddress(ctx, "account"), subsidy.ErrInvalidInput)
+
+	blocked, err := service.BlockAddress(ctx, subsidy.BlockedAddress{Address: "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"})
+	require.NoError(t, err)
+	assert.Equal(t, "0x742d35cc6634c0532925a3b844bc454e4438f44e", blocked.Address)
+	require.NoError(t, service.UnblockAddress(ctx, "0x742D35CC6634C0532925A3B844BC454E4438F44E"))
+}
@@ -0,0 +1,348 @@
+package subsidyimpl
+
+import (
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// maxRedistributionRounds bounds how often a remainder is spread again after allocations reach their caps
+const maxRedistributionRounds = 16
+
+// capPolicy holds the caps governance set on what a distribution pays out
+type capPolicy struct {
+	userMax               *big.Int // nil when no absolute user cap is configured
+	userDebtPercent       uint64
+	collectionMax         *big.Int // nil when no absolute collection cap is configured
+	collectionDebtPercent uint64
+	outstandingDebt       bool // an account receives at most what it owes the vault's lending market
+	carryForward          bool // clamped amounts go to the next distribution instead of being redistributed
+}
+
+func newCapPolicy(cfg *config.Config) capPolicy {
+	policy := capPolicy{
+		userDebtPercent:       cfg.Caps.UserMaxDebtPercent,
+		collectionDebtPercent: cfg.Caps.CollectionMaxDebtPercent,
+		outstandingDebt:       cfg.Caps.OutstandingDebt,
+		carryForward:          cfg.Caps.Remainder == subsidy.CapRemainderCarryForward,
+	}
+	// config.Load rejects malformed caps
+	if limit, ok := new(big.Int).SetString(cfg.Caps.UserMax, 10); ok {
+		policy.userMax = limit
+	}
+	if limit, ok := new(big.Int).SetString(cfg.Caps.CollectionMax, 10); ok {
+		policy.collectionMax = limit
+	}
+	return policy
+}
+
+// enabled reports whether any cap is configured. Without caps a distribution is left as valued
+// and an amount carried forward earlier waits until caps are configured again.
+func (p capPolicy) enabled() bool {
+	return p.userMax != nil || p.userDebtPercent > 0 || p.collectionMax != nil || p.collectionDebtPercent > 0 ||
+		p.outstandingDebt
+}
+
+// allocation is an account's earnings in one collection on their way into the merkle tree
+type allocation struct {
+	account       string
+	collection    string
+	debt          *big.Int // the account's debt, zero when the subgraph does not report it
+	outstanding   *big.Int // what the account owes the lending market, nil unless outstanding debt caps it
+	uncapped      *big.Int // amount as valued
+	amount        *big.Int // amount after caps and redistribution
+	applied       []subsidy.AppliedCap
+	redistributed *big.Int
+	roundedOff    *big.Rat // fraction of a wei valuation rounded amount down by
+	roundingBonus *big.Int // wei the rounding policy added to amount
+
+	collectionGroup *capGroup
+	userGroup       *capGroup
+}
+
+func newAllocation(accountSubsidy subgraph.AccountSubsidy, amount *big.Int) *allocation {
+	debt, ok := new(big.Int).SetString(accountSubsidy.Account.TotalBorrowVolume, 10)
+	if !ok || debt.Sign() < 0 {
+		debt = big.NewInt(0)
+	}
+	return &allocation{
+		account:       accountSubsidy.Account.ID,
+		collection:    accountSubsidy.CollectionParticipation,
+		debt:          debt,
+		uncapped:      amount,
+		amount:        new(big.Int).Set(amount),
+		redistributed: big.NewInt(0),
+		roundedOff:    new(big.Rat),
+		roundingBonus: big.NewInt(0),
+	}
+}
+
+// adjusted reports whether caps changed the allocation
+func (a *allocation) adjusted() bool {
+	return len(a.applied) > 0 || a.redistributed.Sign() > 0
+}
+
+// capGroup is the allocations a cap limits together: all of an account's, or all of a collection's
+type capGroup struct {
+	rule    string
+	limit   *big.Int // nil when the group is uncapped
+	members []*allocation
+}
+
+func (g *capGroup) total() *big.Int {
+	total := big.NewInt(0)
+	for _, member := range g.members {
+		total.Add(total, member.amount)
+	}
+	return total
+}
+
+// full reports whether the group has no room left under its cap
+func (g *capGroup) full() bool {
+	return g.limit != nil && g.total().Cmp(g.limit) >= 0
+}
+
+// clamp scales the members down pro-rata until the group is within its limit, rounding down,
+// and returns the amount taken off
+func (g *capGroup) clamp() *big.Int {
+	clamped := big.NewInt(0)
+	if g.limit == nil {
+		return clamped
+	}
+	total := g.total()
+	if total.Cmp(g.limit) <= 0 {
+		return clamped
+	}
+
+	for _, member := range g.members {
+		capped := new(big.Int).Mul(member.amount, g.limit)
+		capped.Div(capped, total)
+		lost := new(big.Int).Sub(member.amount, capped)
+		if lost.Sign() > 0 {
+			member.applied = append(member.applied, subsidy.AppliedCap{
+				Rule:    g.rule,
+				Limit:   g.limit.String(),
+				Clamped: lost.String(),
+			})
+			clamped.Add(clamped, lost)
+		}
+		member.amount = capped
+	}
+	return clamped
+}
+
+// fit scales down the shares offered to the members so the group stays within its limit
+func (g *capGroup) fit(shares map[*allocation]*big.Int) {
+	if g.limit == nil {
+		return
+	}
+	room := new(big.Int).Sub(g.limit, g.total())
+	if room.Sign() < 0 {
+		room.SetInt64(0)
+	}
+
+	offered := big.NewInt(0)
+	for _, member := range g.members {
+		if share, ok := shares[member]; ok {
+			offered.Add(offered, share)
+		}
+	}
+	if offered.Cmp(room) <= 0 {
+		return
+	}
+	for _, member := range g.members {
+		if share, ok := shares[member]; ok {
+			share.Mul(share, room)
+			share.Div(share, offered)
+		}
+	}
+}
+
+// apply clamps the allocations to the collection caps and then the user caps, and spreads the
+// remainder over allocations with room left. It returns the amount left for the next distribution:
+// what no allocation had room for, plus everything clamped when the policy carries forward.
+// carriedIn, left by the previous distribution, is spread the same way.
+func (p capPolicy) apply(allocations []*allocation, carriedIn *big.Int) *big.Int {
+	collections, users := p.groups(allocations)
+
+	clamped := big.NewInt(0)
+	for _, group := range collections {
+		clamped.Add(clamped, group.clamp())
+	}
+	for _, group := range users {
+		clamped.Add(clamped, group.clamp())
+	}
+
+	pool := new(big.Int).Set(carriedIn)
+	carriedOut := big.NewInt(0)
+	if p.carryForward {
+		carriedOut.Add(carriedOut, clamped)
+	} else {
+		pool.Add(pool, clamped)
+	}
+
+	unplaced := redistribute(allocations, append(collections, users...), pool)
+	return carriedOut.Add(carriedOut, unplaced)
+}
+
+// groups gathers the allocations by collection and by account, in the order they were first seen.
+// An account has one subsidy per collection, so a collection's debt is the sum of its accounts' debts.
+func (p capPolicy) groups(allocations []*allocation) (collections, users []*capGroup) {
+	byCollection := make(map[string]*capGroup)
+	byUser := make(map[string]*capGroup)
+	collectionDebt := make(map[string]*big.Int)
+	userDebt := make(map[string]*big.Int)
+	userOutstanding := make(map[string]*big.Int)
+
+	for _, a := range allocations {
+		account := utils.NormalizeAddress(a.account)
+
+		if _, ok := byCollection[a.collection]; !ok {
+			byCollection[a.collection] = &capGroup{}
+			collectionDebt[a.collection] = big.NewInt(0)
+			collections = append(collections, byCollection[a.collection])
+		}
+		a.collectionGroup = byCollection[a.collection]
+		a.collectionGroup.members = append(a.collectionGroup.members, a)
+
+		if _, ok := byUser[account]; !ok {
+			byUser[account] = &capGroup{}
+			userDebt[account] = a.debt
+			userOutstanding[account] = a.outstanding
+			users = append(users, byUser[account])
+		}
+		a.userGroup = byUser[account]
+		a.userGroup.members = append(a.userGroup.members, a)
+		collectionDebt[a.collection].Add(collectionDebt[a.collection], a.debt)
+	}
+
+	for collection, group := range byCollection {
+		group.rule, group.limit = capLimit(p.collectionMax, subsidy.CapCollectionMax,
+			p.collectionDebtPercent, subsidy.CapCollectionDebtPercent, collectionDebt[collection])
+	}
+	for account, group := range byUser {
+		group.rule, group.limit = capLimit(p.userMax, subsidy.CapUserMax,
+			p.userDebtPercent, subsidy.CapUserDebtPercent, userDebt[account])
+		// an account whose balance was not read is not held to it
+		if outstanding := userOutstanding[account]; p.outstandingDebt && outstanding != nil &&
+			(group.limit == nil || outstanding.Cmp(group.limit) < 0) {
+			group.rule, group.limit = subsidy.CapUserOutstandingDebt, outstanding
+		}
+	}
+	return collections, users
+}
+
+// capLimit returns the tighter of the absolute and debt percentage caps and the rule it comes from,
+// or a nil limit when neither is configured
+func capLimit(max *big.Int, maxRule string, debtPercent uint64, debtRule string, debt *big.Int) (string, *big.Int) {
+	if debtPercent > 0 {
+		limit := new(big.Int).Mul(debt, new(big.Int).SetUint64(debtPercent))
+		limit.Div(limit, big.NewInt(100))
+		if max == nil || limit.Cmp(max) < 0 {
+			return debtRule, limit
+		}
+	}
+	if max != nil {
+		return maxRule, max
+	}
+	return "", nil
+}
+
+// redistribute spreads pool over allocations whose account and collection both have room left,
+// pro-rata to their amounts. Allocations clamped to zero get nothing, and an allocation whose share
+// a cap cut is left out of later rounds. It returns what could not be placed.
+func redistribute(allocations []*allocation, groups []*capGroup, pool *big.Int) *big.Int {
+	pool = new(big.Int).Set(pool)
+	saturated := make(map[*allocation]bool)
+	eligible := func(a *allocation) bool {
+		return a.amount.Sign() > 0 && !saturated[a] && !a.collectionGroup.full() && !a.userGroup.full()
+	}
+
+	for round := 0; round < maxRedistributionRounds && pool.Sign() > 0; round++ {
+		weight := big.NewInt(0)
+		for _, a := range allocations {
+			if eligible(a) {
+				weight.Add(weight, a.amount)
+			}
+		}
+		if weight.Sign() == 0 {
+			break
+		}
+
+		offered := make(map[*allocation]*big.Int)
+		shares := make(map[*allocation]*big.Int)
+		for _, a := range allocations {
+			if eligible(a) {
+				share := new(big.Int).Mul(pool, a.amount)
+				share.Div(share, weight)
+				offered[a] = share
+				shares[a] = new(big.Int).Set(share)
+			}
+		}
+		for _, group := range groups {
+			group.fit(shares)
+		}
+
+		placed := big.NewInt(0)
+		for _, a := range allocations {
+			share, ok := shares[a]
+			if !ok {
+				continue
+			}
+			if share.Cmp(offered[a]) < 0 {
+				saturated[a] = true
+			}
+			a.amount.Add(a.amount, share)
+			a.redistributed.Add(a.redistributed, share)
+			placed.Add(placed, share)
+		}
+		if placed.Sign() == 0 {
+			break
+		}
+		pool.Sub(pool, placed)
+	}
+	return pool
+}
+
+// capRecord is how caps changed one allocation, kept with the epoch so explanations can show it
+type capRecord struct {
+	Account                 string               `json:"account"`
+	CollectionParticipation string               `json:"collectionParticipation"`
+	UncappedAmount          string               `json:"uncappedAmount"`
+	Amount                  string               `json:"amount"`
+	CapsApplied             []subsidy.AppliedCap `json:"capsApplied,omitempty"`
+	Redistributed           string               `json:"redistributed,omitempty"`
+}
+
+// capRecords returns the records of the allocations caps changed
+func capRecords(allocations []*allocation) []capRecord {
+	var records []capRecord
+	for _, a := range allocations {
+		if !a.adjusted() {
+			continue
+		}
+		record := capRecord{
+			Account:                 utils.NormalizeAddress(a.account),
+			CollectionParticipation: a.collection,
+			UncappedAmount:          a.uncapped.String(),
+			Amount:                  a.amount.String(),
+			CapsApplied:             a.applied,
+		}
+		if a.redistributed.Sign() > 0 {
+			record.Redistributed = a.redistributed.String()
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// applyCapRecord shows on an explained allocation how caps changed it
+func applyCapRecord(collection *subsidy.CollectionAllocation, record capRecord) {
+	collection.UncappedAmount = record.UncappedAmount
+	collection.Amount = record.Amount
+	collection.CapsApplied = record.CapsApplied
+	collection.Redistributed = record.Redistributed
+}
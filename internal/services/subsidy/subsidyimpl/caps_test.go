@@ -0,0 +1,163 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func capTestAllocation(account, collection string, debt, amount int64) *allocation {
+	return newAllocation(subgraph.AccountSubsidy{
+		Account:                 subgraph.Account{ID: account, TotalBorrowVolume: big.NewInt(debt).String()},
+		CollectionParticipation: collection,
+	}, big.NewInt(amount))
+}
+
+func TestCapPolicy_Apply(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     capPolicy
+		carriedIn  int64
+		amounts    []int64
+		carriedOut int64
+	}{
+		{name: "uncapped", policy: capPolicy{}, amounts: []int64{600, 200, 100, 200}},
+		{
+			// 0xa is held to 500 and the 300 clamped goes to 0xb and 0xc pro-rata
+			name:    "user_max_redistributed",
+			policy:  capPolicy{userMax: big.NewInt(500)},
+			amounts: []int64{375, 125, 200, 400},
+		},
+		{
+			name:       "user_max_carried_forward",
+			policy:     capPolicy{userMax: big.NewInt(500), carryForward: true},
+			amounts:    []int64{375, 125, 100, 200},
+			carriedOut: 300,
+		},
+		{
+			// 0xb is held to 10% of 1000 and 0xc, without debt, to nothing
+			name:    "user_debt_percent",
+			policy:  capPolicy{userDebtPercent: 10},
+			amounts: []int64{750, 250, 100, 0},
+		},
+		{
+			// collection a is held to 600, rounding down leaves it 1 short, and only collection b takes the remainder
+			name:       "collection_max",
+			policy:     capPolicy{collectionMax: big.NewInt(600)},
+			amounts:    []int64{514, 250, 85, 250},
+			carriedOut: 1,
+		},
+		{
+			name:       "nobody_has_room",
+			policy:     capPolicy{userMax: big.NewInt(100)},
+			carriedIn:  50,
+			amounts:    []int64{75, 25, 100, 100},
+			carriedOut: 850,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations := []*allocation{
+				capTestAllocation("0xa", "collection-a", 100_000, 600),
+				capTestAllocation("0xa", "collection-b", 100_000, 200),
+				capTestAllocation("0xb", "collection-a", 1000, 100),
+				capTestAllocation("0xc", "collection-b", 0, 200),
+			}
+
+			carriedOut := tt.policy.apply(allocations, big.NewInt(tt.carriedIn))
+
+			total := new(big.Int).Set(carriedOut)
+			amounts := make([]int64, len(allocations))
+			for i, a := range allocations {
+				amounts[i] = a.amount.Int64()
+				total.Add(total, a.amount)
+			}
+			assert.Equal(t, tt.amounts, amounts)
+			assert.Equal(t, tt.carriedOut, carriedOut.Int64())
+			assert.Equal(t, 1100+tt.carriedIn, total.Int64(), "caps must not create or lose wei")
+		})
+	}
+}
+
+func TestCapPolicy_TighterCapIsRecorded(t *testing.T) {
+	allocations := []*allocation{
+		capTestAllocation("0xa", "collection-a", 1000, 800),
+		capTestAllocation("0xb", "collection-a", 0, 200),
+	}
+	policy := capPolicy{userMax: big.NewInt(600), userDebtPercent: 50, carryForward: true}
+
+	carriedOut := policy.apply(allocations, big.NewInt(0))
+	assert.Equal(t, "500", carriedOut.String())
+
+	records := capRecords(allocations)
+	require.Len(t, records, 2)
+	assert.Equal(t, capRecord{
+		Account:                 "0xa",
+		CollectionParticipation: "collection-a",
+		UncappedAmount:          "800",
+		Amount:                  "500",
+		CapsApplied:             []subsidy.AppliedCap{{Rule: subsidy.CapUserDebtPercent, Limit: "500", Clamped: "300"}},
+	}, records[0])
+	assert.Equal(t, "0", records[1].Amount)
+	assert.Equal(t, []subsidy.AppliedCap{{Rule: subsidy.CapUserDebtPercent, Limit: "0", Clamped: "200"}}, records[1].CapsApplied)
+}
+
+func TestLazyDistributor_CapsCarryForwardAndExplain(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.caps = capPolicy{userMax: big.NewInt(2500), carryForward: true}
+	subsidies := []subgraph.AccountSubsidy{
+		{Account: subgraph.Account{ID: explainTestUser}, CollectionParticipation: "0xcollection-a", TotalRewardsEarned: "1000"},
+		{Account: subgraph.Account{ID: "0x1111111111111111111111111111111111111111"}, CollectionParticipation: "0xcollection-a", TotalRewardsEarned: "3000"},
+	}
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress, accountAddress string,
+			blockNumber int64,
+		) ([]subgraph.AccountSubsidy, error) {
+			return subsidies[1:], nil
+		},
+	}
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, "3500", result.TotalSubsidies.String())
+	carried, err := distributor.store.GetCarryForward(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "500", carried.String())
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(5), subsidies[1].Account.ID)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches, "computed %s, distributed %s", explanation.ComputedAmount, explanation.DistributedAmount)
+	require.Len(t, explanation.Collections, 1)
+	assert.Equal(t, "2500", explanation.Collections[0].Amount)
+	assert.Equal(t, "3000", explanation.Collections[0].UncappedAmount)
+	assert.Equal(t, []subsidy.AppliedCap{{Rule: subsidy.CapUserMax, Limit: "2500", Clamped: "500"}}, explanation.Collections[0].CapsApplied)
+
+	// the next epoch pays the carried 500 to the account below its cap
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Equal(t, "4000", result.TotalSubsidies.String())
+	carried, err = distributor.store.GetCarryForward(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "500", carried.String(), "the second epoch clamps another 500")
+
+	subsidies[1].TotalRewardsEarned = "1000"
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(7))
+	require.NoError(t, err)
+	carried, err = distributor.store.GetCarryForward(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "0", carried.String())
+}
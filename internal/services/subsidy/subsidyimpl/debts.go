@@ -0,0 +1,72 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+)
+
+// debtRecord is what the accounts of a distribution owed the vault's lending market at its snapshot block, kept
+// with the epoch so replays cap them at the same balances and explanations show debt against subsidy
+type debtRecord struct {
+	BlockNumber uint64            `json:"blockNumber"`
+	Balances    map[string]string `json:"balances"` // wei owed by normalized account
+}
+
+// balance returns what account owed, nil when the distribution did not read it
+func (r debtRecord) balance(account string) *big.Int {
+	value, ok := r.Balances[utils.NormalizeAddress(account)]
+	if !ok {
+		return nil
+	}
+	balance, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil
+	}
+	return balance
+}
+
+// apply sets what each allocation's account owed, so caps hold the account to it
+func (r debtRecord) apply(allocations []*allocation) {
+	for _, a := range allocations {
+		a.outstanding = r.balance(a.account)
+	}
+}
+
+// outstandingDebts reads what the allocations' accounts owe the vault's lending market at the snapshot block.
+// A distribution capped at debts it could not read would pay more than they owe, so a failed read fails it.
+func (d *LazyDistributor) outstandingDebts(
+	ctx context.Context,
+	vaultId string,
+	blockNumber uint64,
+	allocations []*allocation,
+) (debtRecord, error) {
+	seen := make(map[string]bool)
+	accounts := make([]string, 0)
+	for _, a := range allocations {
+		account := utils.NormalizeAddress(a.account)
+		if !seen[account] {
+			seen[account] = true
+			accounts = append(accounts, account)
+		}
+	}
+
+	record := debtRecord{BlockNumber: blockNumber, Balances: make(map[string]string, len(accounts))}
+	if len(accounts) == 0 {
+		return record, nil
+	}
+	balances, err := d.blockchainClient.GetBorrowBalances(ctx, vaultId, accounts, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return debtRecord{}, fmt.Errorf("failed to read borrow balances at block %d: %w", blockNumber, err)
+	}
+	for _, account := range accounts {
+		balance, ok := balances[account]
+		if !ok || balance == nil {
+			return debtRecord{}, fmt.Errorf("no borrow balance read for %s at block %d", account, blockNumber)
+		}
+		record.Balances[account] = balance.String()
+	}
+	return record, nil
+}
@@ -0,0 +1,75 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func TestLazyDistributor_OutstandingDebtCaps(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	distributor.caps = capPolicy{outstandingDebt: true}
+	owed := map[string]int64{holdingsTestOwnerA: 1000, holdingsTestOwnerB: 200, blocklistTestOwnerC: 150}
+	chain := distributor.blockchainClient.(*blockchain.BlockchainClientMock)
+	chain.GetBorrowBalancesFunc = func(ctx context.Context, vaultAddress string, borrowers []string, blockNumber *big.Int) (map[string]*big.Int, error) {
+		balances := make(map[string]*big.Int)
+		for _, borrower := range borrowers {
+			balances[borrower] = big.NewInt(owed[borrower])
+		}
+		return balances, nil
+	}
+	ctx := context.Background()
+	epoch := big.NewInt(3)
+
+	// B and C are held to what they owe, and the 350 above it goes to A, who owes more than it earned
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", result.TotalSubsidies.String())
+	require.Len(t, chain.GetBorrowBalancesCalls(), 1, "balances are read once per distribution")
+	assert.Len(t, chain.GetBorrowBalancesCalls()[0].Borrowers, 3)
+
+	explanations, err := distributor.ExplainBatch(ctx, planTestVault, epoch,
+		[]string{holdingsTestOwnerA, holdingsTestOwnerB, blocklistTestOwnerC})
+	require.NoError(t, err)
+	require.Len(t, explanations.Explanations, 3)
+	assert.Zero(t, explanations.Mismatched)
+	a, b := explanations.Explanations[0], explanations.Explanations[1]
+	assert.Equal(t, "650", a.DistributedAmount)
+	assert.Equal(t, "1000", a.OutstandingDebt)
+	assert.Equal(t, "0.650000000000000000", a.DebtCoverage)
+	assert.Equal(t, "200", b.DistributedAmount)
+	assert.Equal(t, "200", b.OutstandingDebt)
+	assert.Equal(t, "1.000000000000000000", b.DebtCoverage)
+	require.Len(t, b.Collections, 1)
+	assert.Equal(t, []subsidy.AppliedCap{{Rule: subsidy.CapUserOutstandingDebt, Limit: "200", Clamped: "300"}}, b.Collections[0].CapsApplied)
+
+	// replays hold accounts to the debts the epoch recorded, not to what they owe now
+	owed[holdingsTestOwnerB] = 0
+	replay, err := distributor.Replay(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	assert.True(t, replay.Matches)
+	assert.Len(t, chain.GetBorrowBalancesCalls(), 1)
+}
+
+func TestCapPolicy_OutstandingDebtIsTighterCap(t *testing.T) {
+	account := utils.NormalizeAddress(holdingsTestOwnerA)
+	allocations := []*allocation{capTestAllocation(account, "x", 0, 900)}
+	debtRecord{Balances: map[string]string{account: "400"}}.apply(allocations)
+
+	policy := capPolicy{userMax: big.NewInt(600), outstandingDebt: true}
+	policy.apply(allocations, big.NewInt(0))
+	assert.Equal(t, "400", allocations[0].amount.String())
+	assert.Equal(t, []subsidy.AppliedCap{{Rule: subsidy.CapUserOutstandingDebt, Limit: "400", Clamped: "500"}}, allocations[0].applied)
+
+	// an account whose balance was not read is held to the other caps only
+	allocations = []*allocation{capTestAllocation(account, "x", 0, 900)}
+	policy.apply(allocations, big.NewInt(0))
+	assert.Equal(t, "600", allocations[0].amount.String())
+}
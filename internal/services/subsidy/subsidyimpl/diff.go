@@ -0,0 +1,168 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// growthPercentDecimals is the precision of an allocation diff's growth percentage
+const growthPercentDecimals = 2
+
+// Diff compares the epoch's stored distribution with the vault's previous one
+func (d *LazyDistributor) Diff(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	top int,
+) (_ *subsidy.AllocationDiff, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.Diff",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	snapshot, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]merkle.Entry, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		entries[i] = merkle.Entry(entry)
+	}
+	return d.diffWithPrevious(ctx, vaultId, epochNumber, entries, top)
+}
+
+// diffWithPrevious compares entries, the epoch's distribution, with the snapshot of the vault's latest epoch
+// before it
+func (d *LazyDistributor) diffWithPrevious(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	entries []merkle.Entry,
+	top int,
+) (*subsidy.AllocationDiff, error) {
+	previous, err := d.previousSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make(map[string]*big.Int)
+	for _, entry := range entries {
+		addAmount(current, entry.Address, entry.TotalEarned)
+	}
+	before := make(map[string]*big.Int)
+	if previous != nil {
+		for _, entry := range previous.Entries {
+			addAmount(before, entry.Address, entry.TotalEarned)
+		}
+	}
+
+	diff := diffAllocations(before, current, top)
+	if previous != nil {
+		diff.PreviousEpochNumber = previous.EpochNumber.String()
+	}
+	diff.VaultID = vaultId
+	diff.EpochNumber = epochNumber.String()
+	return diff, nil
+}
+
+// previousSnapshot returns the snapshot of the vault's latest epoch before epochNumber, or of its latest epoch
+// when epochNumber is nil, nil when there is none
+func (d *LazyDistributor) previousSnapshot(ctx context.Context, vaultId string, epochNumber *big.Int) (*merkle.MerkleSnapshot, error) {
+	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
+	if !ok {
+		return nil, fmt.Errorf("merkle service is not the expected implementation type")
+	}
+
+	snapshots, err := merkleImpl.ListSnapshots(ctx, vaultId, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list merkle snapshots: %w", err)
+	}
+	// latest epoch first, so the first earlier epoch is the previous distribution
+	for i := range snapshots {
+		if snapshots[i].EpochNumber != nil && (epochNumber == nil || snapshots[i].EpochNumber.Cmp(epochNumber) < 0) {
+			return &snapshots[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// diffAllocations compares the summed amounts of every account before and after, listing at most top accounts
+// of each change kind, largest change first
+func diffAllocations(before, after map[string]*big.Int, top int) *subsidy.AllocationDiff {
+	diff := &subsidy.AllocationDiff{
+		Accounts:         len(after),
+		PreviousAccounts: len(before),
+		New:              []subsidy.AllocationChange{},
+		Dropped:          []subsidy.AllocationChange{},
+		TopIncreases:     []subsidy.AllocationChange{},
+		TopDecreases:     []subsidy.AllocationChange{},
+	}
+
+	type change struct {
+		account       string
+		before, after *big.Int
+		delta         *big.Int
+	}
+	var added, dropped, increased, decreased []change
+	total, previousTotal := big.NewInt(0), big.NewInt(0)
+	for account, amount := range after {
+		total.Add(total, amount)
+		previous, ok := before[account]
+		if !ok {
+			added = append(added, change{account, big.NewInt(0), amount, amount})
+			continue
+		}
+		delta := new(big.Int).Sub(amount, previous)
+		switch delta.Sign() {
+		case 1:
+			increased = append(increased, change{account, previous, amount, delta})
+		case -1:
+			decreased = append(decreased, change{account, previous, amount, delta})
+		}
+	}
+	for account, amount := range before {
+		previousTotal.Add(previousTotal, amount)
+		if _, ok := after[account]; !ok {
+			dropped = append(dropped, change{account, amount, big.NewInt(0), new(big.Int).Neg(amount)})
+		}
+	}
+
+	// largest change first, by address between equal changes so the lists are stable
+	list := func(changes []change) []subsidy.AllocationChange {
+		sort.Slice(changes, func(i, j int) bool {
+			x, y := new(big.Int).Abs(changes[i].delta), new(big.Int).Abs(changes[j].delta)
+			if c := x.Cmp(y); c != 0 {
+				return c > 0
+			}
+			return changes[i].account < changes[j].account
+		})
+		listed := make([]subsidy.AllocationChange, 0, min(len(changes), top))
+		for _, c := range changes[:min(len(changes), top)] {
+			listed = append(listed, subsidy.AllocationChange{
+				Account:  c.account,
+				Previous: c.before.String(),
+				Current:  c.after.String(),
+				Change:   c.delta.String(),
+			})
+		}
+		return listed
+	}
+	diff.NewAccounts, diff.DroppedAccounts = len(added), len(dropped)
+	diff.New, diff.Dropped = list(added), list(dropped)
+	diff.TopIncreases, diff.TopDecreases = list(increased), list(decreased)
+
+	growth := new(big.Int).Sub(total, previousTotal)
+	diff.Total, diff.PreviousTotal, diff.TotalGrowth = total.String(), previousTotal.String(), growth.String()
+	if previousTotal.Sign() > 0 {
+		percent := new(big.Rat).SetFrac(new(big.Int).Mul(growth, big.NewInt(100)), previousTotal)
+		diff.GrowthPercent = percent.FloatString(growthPercentDecimals)
+	}
+	return diff
+}
@@ -0,0 +1,107 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func TestDiffAllocations(t *testing.T) {
+	before := map[string]*big.Int{
+		"0xa": big.NewInt(100), "0xb": big.NewInt(200), "0xc": big.NewInt(300), "0xd": big.NewInt(50), "0xe": big.NewInt(10),
+	}
+	after := map[string]*big.Int{
+		"0xa": big.NewInt(150), "0xb": big.NewInt(500), "0xc": big.NewInt(250), "0xd": big.NewInt(50), "0xf": big.NewInt(40),
+	}
+
+	diff := diffAllocations(before, after, 1)
+	assert.Equal(t, 5, diff.Accounts)
+	assert.Equal(t, 5, diff.PreviousAccounts)
+	assert.Equal(t, "990", diff.Total)
+	assert.Equal(t, "660", diff.PreviousTotal)
+	assert.Equal(t, "330", diff.TotalGrowth)
+	assert.Equal(t, "50.00", diff.GrowthPercent)
+	assert.Equal(t, 1, diff.NewAccounts)
+	assert.Equal(t, 1, diff.DroppedAccounts)
+	assert.Equal(t, []subsidy.AllocationChange{{Account: "0xf", Previous: "0", Current: "40", Change: "40"}}, diff.New)
+	assert.Equal(t, []subsidy.AllocationChange{{Account: "0xe", Previous: "10", Current: "0", Change: "-10"}}, diff.Dropped)
+	assert.Equal(t, []subsidy.AllocationChange{{Account: "0xb", Previous: "200", Current: "500", Change: "300"}},
+		diff.TopIncreases, "only the largest increase is listed")
+	assert.Equal(t, []subsidy.AllocationChange{{Account: "0xc", Previous: "300", Current: "250", Change: "-50"}}, diff.TopDecreases)
+
+	first := diffAllocations(map[string]*big.Int{}, after, 10)
+	assert.Empty(t, first.GrowthPercent, "there is nothing to grow from")
+	assert.Len(t, first.New, 5)
+	assert.Equal(t, "0xb", first.New[0].Account, "largest first")
+	assert.Empty(t, first.TopIncreases)
+}
+
+func TestLazyDistributor_DiffAgainstPreviousEpoch(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	ctx := context.Background()
+	save := func(epoch int64, amounts map[string]int64) {
+		var entries []merkle.MerkleEntry
+		for address, amount := range amounts {
+			entries = append(entries, merkle.MerkleEntry{Address: address, TotalEarned: big.NewInt(amount)})
+		}
+		require.NoError(t, distributor.merkleService.(*merkleimpl.Service).SaveSnapshot(ctx, big.NewInt(epoch),
+			merkle.MerkleSnapshot{VaultID: planTestVault, MerkleRoot: "ab", Entries: entries}))
+	}
+	user := "0x1111111111111111111111111111111111111111"
+	save(3, map[string]int64{user: 100})
+	save(5, map[string]int64{user: 400, "0x2222222222222222222222222222222222222222": 100})
+
+	diff, err := distributor.Diff(ctx, planTestVault, big.NewInt(5), subsidy.DefaultDiffTop)
+	require.NoError(t, err)
+	assert.Equal(t, "3", diff.PreviousEpochNumber, "skipped epochs are not compared with")
+	assert.Equal(t, "400", diff.TotalGrowth)
+	assert.Equal(t, "400.00", diff.GrowthPercent)
+	assert.Equal(t, 1, diff.NewAccounts)
+	require.Len(t, diff.TopIncreases, 1)
+	assert.Equal(t, user, diff.TopIncreases[0].Account)
+
+	first, err := distributor.Diff(ctx, planTestVault, big.NewInt(3), subsidy.DefaultDiffTop)
+	require.NoError(t, err)
+	assert.Empty(t, first.PreviousEpochNumber)
+	assert.Equal(t, 1, first.NewAccounts)
+
+	_, err = distributor.Diff(ctx, planTestVault, big.NewInt(4), subsidy.DefaultDiffTop)
+	assert.ErrorIs(t, err, subsidy.ErrNotFound)
+}
+
+func TestLazyDistributor_StagedDistributionCarriesDiff(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{enabled: true})
+	ctx := context.Background()
+	require.NoError(t, distributor.merkleService.(*merkleimpl.Service).SaveSnapshot(ctx, big.NewInt(4), merkle.MerkleSnapshot{
+		VaultID:    planTestVault,
+		MerkleRoot: "ab",
+		Entries:    []merkle.MerkleEntry{{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(800)}},
+	}))
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	require.NotEmpty(t, result.StagedID)
+
+	staged, err := distributor.ListStaged(ctx, subsidy.StagedPendingApproval)
+	require.NoError(t, err)
+	require.Len(t, staged, 1)
+	require.NotNil(t, staged[0].Diff, "reviewers see the diff with the staged root")
+	assert.Equal(t, "4", staged[0].Diff.PreviousEpochNumber)
+	assert.Equal(t, "200", staged[0].Diff.TotalGrowth)
+	assert.Equal(t, "25.00", staged[0].Diff.GrowthPercent)
+
+	calls := distributor.notifier.(*webhook.NotifierMock).NotifyCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, webhook.EventDistributionStaged, calls[0].EventType)
+	assert.Equal(t, staged[0].Diff, calls[0].Data["diff"], "the approval alert carries the diff")
+}
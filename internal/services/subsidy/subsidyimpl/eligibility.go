@@ -0,0 +1,277 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// zeroAddress holds tokens before they are minted and after they are burned, and earns nothing
+const zeroAddress = "0x0000000000000000000000000000000000000000"
+
+// eligibilityPolicy decides who shares an epoch's accrual in a collection. Snapshot eligibility keeps the
+// amounts the subgraph accounted at the snapshot block. Time weighting keeps what every account had earned
+// when the epoch started and shares what the collection's participants accrued since among everyone who held
+// its tokens during the epoch, pro rata to how long they held them, so a token transferred mid-epoch earns
+// for each of its holders rather than for the one the snapshot saw.
+type eligibilityPolicy struct {
+	mode string
+}
+
+func newEligibilityPolicy(cfg *config.Config) eligibilityPolicy {
+	return eligibilityPolicy{mode: cfg.Holdings.Eligibility}
+}
+
+// timeWeighted reports whether accrual is shared by holding time
+func (p eligibilityPolicy) timeWeighted() bool {
+	return p.mode == subsidy.EligibilityTimeWeighted
+}
+
+// tokenHolding is how long an account held units of one token within a window, in unit-seconds
+type tokenHolding struct {
+	collection string
+	tokenID    string
+	account    string
+	seconds    *big.Int
+}
+
+// holdingDurations replays transfers, ordered as they happened, and returns how long every account held every
+// token within [start, end], ordered by collection, token and account. ERC-1155 units count separately, so
+// two units held for a second count two. Tokens held by the zero address are left out.
+func holdingDurations(transfers []subgraph.NFTTransfer, start, end int64) []tokenHolding {
+	type position struct {
+		units *big.Int
+		since int64
+	}
+	type holdingKey struct{ collection, tokenID, account string }
+
+	clamp := func(t int64) int64 {
+		return min(max(t, start), end)
+	}
+	positions := make(map[holdingKey]*position)
+	held := make(map[holdingKey]*big.Int)
+	settle := func(key holdingKey, at int64) *position {
+		p, ok := positions[key]
+		if !ok {
+			p = &position{units: new(big.Int), since: at}
+			positions[key] = p
+		}
+		if elapsed := clamp(at) - clamp(p.since); elapsed > 0 && p.units.Sign() > 0 {
+			if held[key] == nil {
+				held[key] = new(big.Int)
+			}
+			held[key].Add(held[key], new(big.Int).Mul(p.units, big.NewInt(elapsed)))
+		}
+		p.since = at
+		return p
+	}
+
+	for _, transfer := range transfers {
+		if transfer.Timestamp > end {
+			break
+		}
+		units, ok := new(big.Int).SetString(transfer.Amount, 10)
+		if !ok || units.Sign() <= 0 {
+			units = big.NewInt(1)
+		}
+		collection := utils.NormalizeAddress(transfer.Collection)
+		if from := utils.NormalizeAddress(transfer.From); from != zeroAddress {
+			p := settle(holdingKey{collection, transfer.TokenID, from}, transfer.Timestamp)
+			// a sender the history does not show receiving the units had them before it starts
+			if p.units.Sub(p.units, units).Sign() < 0 {
+				p.units.SetInt64(0)
+			}
+		}
+		if to := utils.NormalizeAddress(transfer.To); to != zeroAddress {
+			p := settle(holdingKey{collection, transfer.TokenID, to}, transfer.Timestamp)
+			p.units.Add(p.units, units)
+		}
+	}
+	for key := range positions {
+		settle(key, end)
+	}
+
+	holdings := make([]tokenHolding, 0, len(held))
+	for key, seconds := range held {
+		if seconds.Sign() > 0 {
+			holdings = append(holdings, tokenHolding{key.collection, key.tokenID, key.account, seconds})
+		}
+	}
+	sort.Slice(holdings, func(i, j int) bool {
+		a, b := holdings[i], holdings[j]
+		if a.collection != b.collection {
+			return a.collection < b.collection
+		}
+		if a.tokenID != b.tokenID {
+			return a.tokenID < b.tokenID
+		}
+		return a.account < b.account
+	})
+	return holdings
+}
+
+// holdingTimes sums token holdings per collection and account
+func holdingTimes(holdings []tokenHolding) map[string]map[string]*big.Int {
+	times := make(map[string]map[string]*big.Int)
+	for _, holding := range holdings {
+		byAccount, ok := times[holding.collection]
+		if !ok {
+			byAccount = make(map[string]*big.Int)
+			times[holding.collection] = byAccount
+		}
+		addAmount(byAccount, holding.account, holding.seconds)
+	}
+	return times
+}
+
+// weighByHoldingTime replaces the allocations of every collection the transfer history shows held during the
+// epoch: each account keeps what it had earned at the epoch's start, and what the collection's allocations grew
+// by since is shared among its holders by holding time. collections maps every collection participation to
+// its collection, and normalize prepares the subsidies read at the epoch's start the way the distribution
+// prepared its own. Shares are rounded down, the fractions are left to the rounding policy.
+func (d *LazyDistributor) weighByHoldingTime(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	endBlock uint64,
+	valuedAt int64,
+	allocations []*allocation,
+	collections map[string]string,
+	normalize func(page []subgraph.AccountSubsidy) []subgraph.AccountSubsidy,
+) ([]*allocation, error) {
+	epoch, err := d.subgraphClient.QueryEpochByNumber(ctx, epochNumber.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get epoch %s: %w", epochNumber.String(), err)
+	}
+	start, err := strconv.ParseInt(epoch.StartTimestamp, 10, 64)
+	if err != nil || start <= 0 {
+		return nil, fmt.Errorf("epoch %s has no start timestamp to weigh holdings from, got %q", epochNumber.String(), epoch.StartTimestamp)
+	}
+	if start >= valuedAt {
+		d.logger.Logf("WARN epoch %s starts at %d, not before it is valued at %d, keeping snapshot eligibility", epochNumber.String(), start, valuedAt)
+		return allocations, nil
+	}
+
+	startBlock, err := d.lastBlockAt(ctx, uint64(start), endBlock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the first block of epoch %s: %w", epochNumber.String(), err)
+	}
+	earnedAtStart := make(map[string]*big.Int)
+	err = d.subgraphClient.StreamAccountSubsidiesForVaultAtBlock(ctx, vaultId, int64(startBlock.Number),
+		func(page []subgraph.AccountSubsidy) error {
+			valued, _ := d.valueSubsidiesAt(normalize(page), start)
+			for _, a := range valued {
+				addAmount(earnedAtStart, allocationKey(a), a.amount)
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account subsidies at block %d: %w", startBlock.Number, err)
+	}
+
+	collectionList := make([]string, 0, len(collections))
+	seen := make(map[string]bool)
+	for _, collection := range collections {
+		if collection = utils.NormalizeAddress(collection); collection != "" && !seen[collection] {
+			seen[collection] = true
+			collectionList = append(collectionList, collection)
+		}
+	}
+	transfers, err := d.subgraphClient.QueryNFTTransfers(ctx, sorted(collectionList), valuedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nft transfers: %w", err)
+	}
+	times := holdingTimes(holdingDurations(transfers, start, valuedAt))
+
+	byParticipation := make(map[string][]*allocation)
+	var participations []string
+	weighted := make([]*allocation, 0, len(allocations))
+	for _, a := range allocations {
+		if len(times[utils.NormalizeAddress(collections[a.collection])]) == 0 {
+			weighted = append(weighted, a)
+			continue
+		}
+		if _, ok := byParticipation[a.collection]; !ok {
+			participations = append(participations, a.collection)
+		}
+		byParticipation[a.collection] = append(byParticipation[a.collection], a)
+	}
+	sort.Strings(participations)
+
+	for _, participation := range participations {
+		held := times[utils.NormalizeAddress(collections[participation])]
+		shared := d.shareByHoldingTime(byParticipation[participation], participation, held, earnedAtStart)
+		weighted = append(weighted, shared...)
+	}
+
+	d.logger.Logf("INFO weighed %d collections of vault %s epoch %s by holding time from %d transfers between %d and %d",
+		len(participations), vaultId, epochNumber.String(), len(transfers), start, valuedAt)
+	return weighted, nil
+}
+
+// shareByHoldingTime returns the allocations of one collection participation with what they grew by over the
+// epoch shared among the accounts in held by their holding time
+func (d *LazyDistributor) shareByHoldingTime(
+	allocations []*allocation,
+	participation string,
+	held map[string]*big.Int,
+	earnedAtStart map[string]*big.Int,
+) []*allocation {
+	accrued := new(big.Int)
+	byAccount := make(map[string]*allocation, len(allocations))
+	for _, a := range allocations {
+		base := new(big.Int).Set(a.amount)
+		if earned, ok := earnedAtStart[allocationKey(a)]; ok && earned.Cmp(base) < 0 {
+			base.Set(earned)
+		}
+		accrued.Add(accrued, new(big.Int).Sub(a.amount, base))
+		a.uncapped, a.amount, a.roundedOff = base, new(big.Int).Set(base), new(big.Rat)
+		byAccount[utils.NormalizeAddress(a.account)] = a
+	}
+
+	holders := make([]string, 0, len(held))
+	total := new(big.Int)
+	for account, seconds := range held {
+		holders = append(holders, account)
+		total.Add(total, seconds)
+	}
+	sort.Strings(holders)
+
+	shared := allocations
+	for _, holder := range holders {
+		share, remainder := new(big.Int).DivMod(new(big.Int).Mul(accrued, held[holder]), total, new(big.Int))
+		a, ok := byAccount[holder]
+		if !ok {
+			a = newAllocation(subgraph.AccountSubsidy{
+				Account:                 subgraph.Account{ID: holder},
+				CollectionParticipation: participation,
+			}, big.NewInt(0))
+			shared = append(shared, a)
+		}
+		a.uncapped.Add(a.uncapped, share)
+		a.amount.Add(a.amount, share)
+		if remainder.Sign() > 0 {
+			a.roundedOff = new(big.Rat).SetFrac(remainder, total)
+		}
+	}
+
+	kept := shared[:0]
+	for _, a := range shared {
+		if a.amount.Sign() > 0 || a.roundedOff.Sign() > 0 {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// allocationKey identifies an account's allocation in a collection participation
+func allocationKey(a *allocation) string {
+	return utils.NormalizeAddress(a.account) + "/" + a.collection
+}
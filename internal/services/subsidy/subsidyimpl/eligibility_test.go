@@ -0,0 +1,104 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const eligibilityTestCollection = "0x7777777777777777777777777777777777777777"
+
+func TestHoldingDurations(t *testing.T) {
+	transfers := []subgraph.NFTTransfer{
+		{Collection: eligibilityTestCollection, TokenID: "1", From: zeroAddress, To: holdingsTestOwnerA, Timestamp: 0},
+		{Collection: eligibilityTestCollection, TokenID: "1", From: holdingsTestOwnerA, To: holdingsTestOwnerB, Timestamp: 700},
+		{Collection: eligibilityTestCollection, TokenID: "2", From: zeroAddress, To: holdingsTestOwnerB, Amount: "3", Timestamp: 600},
+		{Collection: eligibilityTestCollection, TokenID: "2", From: holdingsTestOwnerB, To: zeroAddress, Amount: "3", Timestamp: 900},
+		{Collection: eligibilityTestCollection, TokenID: "1", From: holdingsTestOwnerB, To: holdingsTestOwnerA, Timestamp: 1200},
+	}
+
+	holdings := holdingDurations(transfers, 500, 1000)
+	require.Len(t, holdings, 3)
+	assert.Equal(t, tokenHolding{eligibilityTestCollection, "1", holdingsTestOwnerA, big.NewInt(200)}, holdings[0],
+		"holding before the epoch starts does not count")
+	assert.Equal(t, tokenHolding{eligibilityTestCollection, "1", holdingsTestOwnerB, big.NewInt(300)}, holdings[1],
+		"transfers after the epoch ends do not count")
+	assert.Equal(t, tokenHolding{eligibilityTestCollection, "2", holdingsTestOwnerB, big.NewInt(900)}, holdings[2],
+		"ERC-1155 units count separately until burned")
+
+	times := holdingTimes(holdings)
+	assert.Equal(t, "200", times[eligibilityTestCollection][holdingsTestOwnerA].String())
+	assert.Equal(t, "1200", times[eligibilityTestCollection][holdingsTestOwnerB].String())
+}
+
+func TestLazyDistributor_WeighByHoldingTime(t *testing.T) {
+	// block n is produced at n*10, so the epoch starting at 500 starts with block 50
+	chain := &blockchain.BlockchainClientMock{
+		GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+			return &blockchain.BlockRef{Number: blockNumber.Uint64(), Timestamp: blockNumber.Uint64() * 10}, nil
+		},
+	}
+	atStart := []subgraph.AccountSubsidy{
+		{Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xparticipation", TotalRewardsEarned: "100"},
+	}
+	client := &subgraph.SubgraphClientMock{
+		QueryEpochByNumberFunc: func(ctx context.Context, epochNumber string) (*subgraph.Epoch, error) {
+			return &subgraph.Epoch{EpochNumber: epochNumber, StartTimestamp: "500", EndTimestamp: "1000"}, nil
+		},
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			assert.Equal(t, int64(50), blockNumber)
+			return fn(atStart)
+		},
+		QueryNFTTransfersFunc: func(ctx context.Context, collections []string, untilTimestamp int64) ([]subgraph.NFTTransfer, error) {
+			assert.Equal(t, []string{eligibilityTestCollection, "0x8888888888888888888888888888888888888888"}, collections)
+			return []subgraph.NFTTransfer{
+				{Collection: eligibilityTestCollection, TokenID: "1", From: zeroAddress, To: holdingsTestOwnerA, Timestamp: 0},
+				{Collection: eligibilityTestCollection, TokenID: "1", From: holdingsTestOwnerA, To: holdingsTestOwnerB, Timestamp: 700},
+			}, nil
+		},
+	}
+	distributor := &LazyDistributor{
+		blockchainClient: chain,
+		subgraphClient:   client,
+		logger:           lgr.NoOp,
+		eligibility:      eligibilityPolicy{mode: subsidy.EligibilityTimeWeighted},
+	}
+
+	allocations := []*allocation{
+		newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xparticipation"}, big.NewInt(401)),
+		newAllocation(subgraph.AccountSubsidy{Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xunindexed"}, big.NewInt(50)),
+	}
+	collections := map[string]string{"0xparticipation": eligibilityTestCollection, "0xunindexed": "0x8888888888888888888888888888888888888888"}
+	same := func(page []subgraph.AccountSubsidy) []subgraph.AccountSubsidy { return page }
+
+	weighted, err := distributor.weighByHoldingTime(context.Background(), planTestVault, big.NewInt(5), 100, 1000,
+		allocations, collections, same)
+	require.NoError(t, err)
+	require.Len(t, weighted, 3)
+
+	assert.Equal(t, "0xunindexed", weighted[0].collection, "collections without transfer history keep their amounts")
+	assert.Equal(t, "50", weighted[0].amount.String())
+
+	// 301 accrued over the epoch, A held the token for 200 of its 500 seconds and B for 300
+	ownerA, ownerB := weighted[1], weighted[2]
+	assert.Equal(t, holdingsTestOwnerA, ownerA.account)
+	assert.Equal(t, "220", ownerA.amount.String(), "what A earned before the epoch plus 2/5 of the accrual")
+	assert.Equal(t, "2/5", ownerA.roundedOff.RatString())
+	assert.Equal(t, holdingsTestOwnerB, ownerB.account, "a holder the snapshot missed shares the accrual")
+	assert.Equal(t, "0xparticipation", ownerB.collection)
+	assert.Equal(t, "180", ownerB.amount.String())
+	assert.Equal(t, "3/5", ownerB.roundedOff.RatString())
+}
@@ -0,0 +1,288 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// expiryPolicy is how many epochs an amount stays claimable
+type expiryPolicy struct {
+	epochs uint64 // 0 when amounts never expire
+}
+
+func newExpiryPolicy(cfg *config.Config) expiryPolicy {
+	return expiryPolicy{epochs: cfg.ClaimExpiry.Epochs}
+}
+
+func (p expiryPolicy) enabled() bool {
+	return p.epochs > 0
+}
+
+// expiryRecord is what expired of the accounts' amounts by a vault's epoch distribution, kept with the epoch so the
+// next one knows what is already left out of the trees and replays leave out the same. What expired is withheld:
+// it stays in the vault and no later distribution pays it to anyone.
+type expiryRecord struct {
+	Epochs   uint64          `json:"epochs"`   // epochs an amount stayed claimable
+	Accounts []expiryAccount `json:"accounts"` // accounts with expired amounts, sorted
+	Expired  string          `json:"expired"`  // wei that expired with this epoch, withheld from the vault's trees
+	Total    string          `json:"total"`    // wei expired in the vault so far
+	Withheld string          `json:"withheld"` // wei left out of this epoch's leaves, less than total when accounts earn less
+}
+
+// expiryAccount is what expired of an account's amounts
+type expiryAccount struct {
+	Account string `json:"account"`
+	Expired string `json:"expired"`          // cumulative wei left out of the account's leaf
+	Now     string `json:"now,omitempty"`    // wei of it that expired with this epoch
+	Earned  string `json:"earned,omitempty"` // epoch the amounts that expired with this one were earned by
+}
+
+// expired returns the cumulative amount left out of the account's leaf, nil when none was
+func (r expiryRecord) expired(account string) *big.Int {
+	account = utils.NormalizeAddress(account)
+	i := sort.Search(len(r.Accounts), func(i int) bool { return r.Accounts[i].Account >= account })
+	if i == len(r.Accounts) || r.Accounts[i].Account != account {
+		return nil
+	}
+	expired, ok := new(big.Int).SetString(r.Accounts[i].Expired, 10)
+	if !ok || expired.Sign() <= 0 {
+		return nil
+	}
+	return expired
+}
+
+// amounts returns the cumulative amount left out of every account's leaf
+func (r expiryRecord) amounts() map[string]*big.Int {
+	amounts := make(map[string]*big.Int, len(r.Accounts))
+	for _, account := range r.Accounts {
+		if expired, ok := new(big.Int).SetString(account.Expired, 10); ok && expired.Sign() > 0 {
+			amounts[account.Account] = expired
+		}
+	}
+	return amounts
+}
+
+// apply takes what expired of every account's amounts out of its allocations, so its leaf pays what it earned less
+// what expired. An account earning less than expired, its allocations changed since, is left with nothing. It returns
+// the wei taken.
+func (r expiryRecord) apply(allocations []*allocation) *big.Int {
+	withheld := big.NewInt(0)
+	expired := r.amounts()
+	if len(expired) == 0 {
+		return withheld
+	}
+	for _, a := range allocations {
+		owed, ok := expired[utils.NormalizeAddress(a.account)]
+		if !ok || owed.Sign() == 0 {
+			continue
+		}
+		taken := new(big.Int).Set(a.amount)
+		if taken.Cmp(owed) > 0 {
+			taken.Set(owed)
+		}
+		a.amount.Sub(a.amount, taken)
+		owed.Sub(owed, taken)
+		withheld.Add(withheld, taken)
+	}
+	return withheld
+}
+
+// expire works out what expired of the accounts' amounts by the epoch. An account's earnings up to the tree it had
+// epochs ago, with what already expired added back, are what the tree paid it then; what of them it has neither
+// claimed by the snapshot block nor already lost expires now. Claims and expiry both settle the oldest earnings
+// first. previous is the record of the vault's previous epoch, old the tree it had epochs ago with its record.
+func (p expiryPolicy) expire(
+	previous expiryRecord,
+	old *merkle.MerkleSnapshot,
+	oldRecord expiryRecord,
+	claimed positions,
+) expiryRecord {
+	record := expiryRecord{Epochs: p.epochs, Accounts: []expiryAccount{}}
+	totals := previous.amounts()
+
+	now := make(map[string]*big.Int)
+	if old != nil {
+		earned := newTreeTotals(old).accounts
+		for account, expired := range oldRecord.amounts() {
+			addAmount(earned, account, expired)
+		}
+		for account, amount := range earned {
+			unclaimed := new(big.Int).Set(amount)
+			if expired, ok := totals[account]; ok {
+				unclaimed.Sub(unclaimed, expired)
+			}
+			if held, ok := claimed[account]; ok {
+				unclaimed.Sub(unclaimed, held.claimed)
+			}
+			if unclaimed.Sign() > 0 {
+				now[account] = unclaimed
+				addAmount(totals, account, unclaimed)
+			}
+		}
+	}
+
+	expired, total := big.NewInt(0), big.NewInt(0)
+	for account, amount := range totals {
+		entry := expiryAccount{Account: account, Expired: amount.String()}
+		if amount, ok := now[account]; ok {
+			entry.Now = amount.String()
+			entry.Earned = old.EpochNumber.String()
+			expired.Add(expired, amount)
+		}
+		record.Accounts = append(record.Accounts, entry)
+		total.Add(total, amount)
+	}
+	sort.Slice(record.Accounts, func(i, j int) bool { return record.Accounts[i].Account < record.Accounts[j].Account })
+	record.Expired = expired.String()
+	record.Total = total.String()
+	return record
+}
+
+// expireUnclaimed works out what expired of the vault's amounts by the epoch and takes it out of the allocations.
+// What expired before is carried from the vault's latest epoch before epochNumber, and what expires now is read
+// from its latest tree at least the policy's epochs older.
+func (d *LazyDistributor) expireUnclaimed(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	allocations []*allocation,
+	claimed positions,
+) (*expiryRecord, error) {
+	var previous expiryRecord
+	last, err := d.previousSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		if previous, err = d.store.GetExpiryRecord(ctx, last.EpochNumber, vaultId); err != nil {
+			return nil, err
+		}
+	}
+
+	var old *merkle.MerkleSnapshot
+	var oldRecord expiryRecord
+	if cutoff := new(big.Int).Sub(epochNumber, new(big.Int).SetUint64(d.expiry.epochs)); cutoff.Sign() > 0 {
+		// the latest tree before the epoch after the cutoff is the one the vault had at the cutoff
+		if old, err = d.previousSnapshot(ctx, vaultId, cutoff.Add(cutoff, big.NewInt(1))); err != nil {
+			return nil, err
+		}
+		if old != nil {
+			if oldRecord, err = d.store.GetExpiryRecord(ctx, old.EpochNumber, vaultId); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	record := d.expiry.expire(previous, old, oldRecord, claimed)
+	record.Withheld = record.apply(allocations).String()
+	return &record, nil
+}
+
+// ListExpirations returns the user's amounts that are still unclaimed, by the epoch they were earned in and when
+// they expire, soonest first. Earnings are read from the positions the vaults' distributions recorded, and claims
+// as the latest of them saw them.
+func (d *LazyDistributor) ListExpirations(ctx context.Context, userAddress string) (_ *subsidy.UserExpirations, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.ListExpirations", attribute.String("user.address", userAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result := &subsidy.UserExpirations{
+		UserAddress:  utils.NormalizeAddress(userAddress),
+		ExpiryEpochs: d.expiry.epochs,
+		Expirations:  []subsidy.ClaimExpiration{},
+	}
+	if !d.expiry.enabled() {
+		return result, nil
+	}
+
+	records, err := d.store.ListPositions(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	// records come by vault and then epoch, oldest first
+	byVault := make(map[string][]subsidy.EpochPosition)
+	vaults := make([]string, 0)
+	for _, record := range records {
+		if _, ok := byVault[record.VaultID]; !ok {
+			vaults = append(vaults, record.VaultID)
+		}
+		byVault[record.VaultID] = append(byVault[record.VaultID], record)
+	}
+	for _, vault := range vaults {
+		result.Expirations = append(result.Expirations, d.expirations(byVault[vault])...)
+	}
+
+	sort.SliceStable(result.Expirations, func(i, j int) bool {
+		a, _ := new(big.Int).SetString(result.Expirations[i].ExpiresAtEpoch, 10)
+		b, _ := new(big.Int).SetString(result.Expirations[j].ExpiresAtEpoch, 10)
+		return a.Cmp(b) < 0
+	})
+	return result, nil
+}
+
+// expirations splits what a vault's trees paid the user into what each epoch added, settles its claims and what
+// expired against the oldest of them and returns the rest, each with the epoch it expires in. records are the
+// user's positions in the vault, oldest epoch first.
+func (d *LazyDistributor) expirations(records []subsidy.EpochPosition) []subsidy.ClaimExpiration {
+	type tranche struct {
+		epoch  *big.Int
+		amount *big.Int
+	}
+	var tranches []tranche
+	earned := big.NewInt(0)
+	for _, record := range records {
+		epoch, ok := new(big.Int).SetString(record.EpochNumber, 10)
+		if !ok {
+			continue
+		}
+		// what expired is still part of what the account earned
+		total := positionAmount(record.TotalEarned)
+		total.Add(total, positionAmount(record.Expired))
+		if total.Cmp(earned) > 0 {
+			tranches = append(tranches, tranche{epoch: epoch, amount: new(big.Int).Sub(total, earned)})
+			earned = total
+		}
+	}
+	if len(records) == 0 {
+		return nil
+	}
+
+	latest := records[len(records)-1]
+	settled := positionAmount(latest.Claimed)
+	settled.Add(settled, positionAmount(latest.Expired))
+	expirations := make([]subsidy.ClaimExpiration, 0, len(tranches))
+	for _, t := range tranches {
+		taken := new(big.Int).Set(t.amount)
+		if taken.Cmp(settled) > 0 {
+			taken.Set(settled)
+		}
+		settled.Sub(settled, taken)
+		remaining := new(big.Int).Sub(t.amount, taken)
+		if remaining.Sign() <= 0 {
+			continue
+		}
+		expirations = append(expirations, subsidy.ClaimExpiration{
+			VaultID:        latest.VaultID,
+			EarnedInEpoch:  t.epoch.String(),
+			Amount:         remaining.String(),
+			ExpiresAtEpoch: new(big.Int).Add(t.epoch, new(big.Int).SetUint64(d.expiry.epochs)).String(),
+		})
+	}
+	return expirations
+}
+
+// positionAmount parses a wei amount of a position, zero when it is empty or malformed
+func positionAmount(value string) *big.Int {
+	amount, ok := new(big.Int).SetString(value, 10)
+	if !ok || amount.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return amount
+}
@@ -0,0 +1,135 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// expiryTestSubgraph serves owners A, B and C earning 300, 500 and 200, of which A claimed 100 and B everything
+func expiryTestSubgraph() *subgraph.SubgraphClientMock {
+	claimed := map[string]string{holdingsTestOwnerA: "100", holdingsTestOwnerB: "500"}
+	withClaims := func(fn func(page []subgraph.AccountSubsidy) error) func(page []subgraph.AccountSubsidy) error {
+		return func(page []subgraph.AccountSubsidy) error {
+			claiming := make([]subgraph.AccountSubsidy, len(page))
+			for i, accountSubsidy := range page {
+				accountSubsidy.SubsidiesClaimed = claimed[accountSubsidy.Account.ID]
+				claiming[i] = accountSubsidy
+			}
+			return fn(claiming)
+		}
+	}
+	client := blocklistTestSubgraph()
+	stream, streamAt := client.StreamAccountSubsidiesForVaultFunc, client.StreamAccountSubsidiesForVaultAtBlockFunc
+	client.StreamAccountSubsidiesForVaultFunc = func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+		return stream(ctx, vaultAddress, withClaims(fn))
+	}
+	client.StreamAccountSubsidiesForVaultAtBlockFunc = func(
+		ctx context.Context,
+		vaultAddress string,
+		blockNumber int64,
+		fn func(page []subgraph.AccountSubsidy) error,
+	) error {
+		return streamAt(ctx, vaultAddress, blockNumber, withClaims(fn))
+	}
+	return client
+}
+
+func TestLazyDistributor_ClaimExpiry(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ClaimExpiry.Epochs = 2
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	distributor.subgraphClient = expiryTestSubgraph()
+	distributor.expiry = newExpiryPolicy(cfg)
+	ctx := context.Background()
+
+	for _, epoch := range []int64{3, 4} {
+		result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(epoch))
+		require.NoError(t, err)
+		assert.Equal(t, "1000", result.TotalSubsidies.String(), "nothing is old enough to expire by epoch %d", epoch)
+	}
+
+	// what epoch 3 paid and is still unclaimed expires with epoch 5: A's 200 and C's 200, withheld from the tree
+	// rather than paid to B
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, "600", result.TotalSubsidies.String())
+
+	record, err := distributor.store.GetExpiryRecord(ctx, big.NewInt(5), planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), record.Epochs)
+	assert.Equal(t, "400", record.Expired)
+	assert.Equal(t, "400", record.Total)
+	assert.Equal(t, "400", record.Withheld)
+	assert.Equal(t, []expiryAccount{
+		{Account: holdingsTestOwnerA, Expired: "200", Now: "200", Earned: "3"},
+		{Account: blocklistTestOwnerC, Expired: "200", Now: "200", Earned: "3"},
+	}, record.Accounts)
+
+	positions, err := distributor.ListPositions(ctx, holdingsTestOwnerA)
+	require.NoError(t, err)
+	require.Len(t, positions, 3)
+	assert.Equal(t, "100", positions[0].TotalEarned)
+	assert.Equal(t, "200", positions[0].Expired)
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(5), holdingsTestOwnerA)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches)
+	assert.Equal(t, "200", explanation.Expired)
+	assert.Equal(t, "100", explanation.ComputedAmount)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches, "replays leave out what the epoch recorded as expired")
+
+	// epoch 4's tree paid no more than epoch 3's, so what expired is carried and nothing more expires
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Equal(t, "600", result.TotalSubsidies.String())
+	record, err = distributor.store.GetExpiryRecord(ctx, big.NewInt(6), planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "0", record.Expired)
+	assert.Equal(t, "400", record.Total)
+	assert.Equal(t, "400", record.Withheld, "what expired stays withheld")
+}
+
+func TestLazyDistributor_Expirations(t *testing.T) {
+	const vault = "0xf82b93f3d6a703b8b5949809771b1e725708590a"
+	distributor := &LazyDistributor{expiry: expiryPolicy{epochs: 3}}
+	records := []subsidy.EpochPosition{
+		{VaultID: vault, EpochNumber: "1", TotalEarned: "100", Claimed: "0"},
+		{VaultID: vault, EpochNumber: "2", TotalEarned: "250", Claimed: "50"},
+		{VaultID: vault, EpochNumber: "3", TotalEarned: "250", Claimed: "50"},
+		{VaultID: vault, EpochNumber: "4", TotalEarned: "300", Claimed: "80", Expired: "50"},
+	}
+
+	// the 80 claimed and 50 expired settle epoch 1's 100 and 30 of epoch 2's 150
+	assert.Equal(t, []subsidy.ClaimExpiration{
+		{VaultID: vault, EarnedInEpoch: "2", Amount: "120", ExpiresAtEpoch: "5"},
+		{VaultID: vault, EarnedInEpoch: "4", Amount: "100", ExpiresAtEpoch: "7"},
+	}, distributor.expirations(records))
+}
+
+func TestExpiryRecord_Apply(t *testing.T) {
+	const accountA, accountB = "0xaaaa", "0xbbbb"
+	allocations := []*allocation{
+		{account: accountA, collection: "x", amount: big.NewInt(30)},
+		{account: accountA, collection: "y", amount: big.NewInt(100)},
+		{account: accountB, collection: "x", amount: big.NewInt(40)},
+	}
+	record := expiryRecord{Accounts: []expiryAccount{{Account: accountA, Expired: "50"}, {Account: accountB, Expired: "60"}}}
+
+	assert.Equal(t, "90", record.apply(allocations).String(), "B earns only 40 of its 60")
+	assert.Equal(t, "0", allocations[0].amount.String())
+	assert.Equal(t, "80", allocations[1].amount.String(), "what the first allocation could not cover comes out of the next")
+	assert.Equal(t, "0", allocations[2].amount.String(), "an account is never left owing")
+	assert.Equal(t, "50", record.expired(accountA).String())
+	assert.Nil(t, record.expired("0xcccc"))
+}
@@ -0,0 +1,353 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// yieldShareDecimals is the precision of an explanation's yield share
+const yieldShareDecimals = 18
+
+// Explain recomputes a user's amount in an epoch's distribution. It reads the user's subsidies at
+// the snapshot block and values them at the snapshot's valuation time with valueSubsidy, the same
+// code the distribution ran, with the collection weights the epoch recorded, applies what caps and
+// rounding recorded for the user's allocations and whether the user was blocked, then compares the
+// result with the amount in the stored merkle tree.
+func (d *LazyDistributor) Explain(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	userAddress string,
+) (_ *subsidy.AllocationExplanation, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.Explain",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	snapshot, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	user := utils.NormalizeAddress(userAddress)
+	subsidies, err := d.subgraphClient.QueryAccountSubsidiesForAccountAtBlock(ctx, vaultId, user, snapshot.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account subsidies at block %d: %w", snapshot.BlockNumber, err)
+	}
+	weights, err := d.appliedWeights(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	explained, err := d.explainedSubsidies(ctx, vaultId, snapshot.BlockNumber, []string{user},
+		map[string][]subgraph.AccountSubsidy{user: subsidies}, weights)
+	if err != nil {
+		return nil, err
+	}
+
+	// caps and rounding depend on the whole vault, so they are read from what the distribution recorded
+	records, err := d.store.ListCapRecords(ctx, epochNumber, vaultId, user)
+	if err != nil {
+		return nil, err
+	}
+	rounding, err := d.store.GetRoundingRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := d.store.GetBlockedRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	deferred, err := d.store.GetDeferredRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	debts, err := d.store.GetDebtRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := d.store.GetExpiryRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+
+	explanation, ok := d.explainAccount(vaultId, epochNumber, snapshot, newTreeTotals(snapshot), user, explained[user],
+		records, rounding.bonusesFor(user), blocked.blocks(user), deferred.defers(user), expiry.expired(user))
+	if !ok {
+		return nil, fmt.Errorf("%w: user %s has no allocation in vault %s for epoch %s",
+			subsidy.ErrNotFound, user, vaultId, epochNumber.String())
+	}
+	explainDebt(explanation, debts)
+	return explanation, nil
+}
+
+// ExplainBatch explains many users' amounts in an epoch's distribution. The users' subsidies are read
+// from the subgraph in batched requests and the epoch's cap records in one pass, so the cost grows with
+// the number of batches rather than the number of users. Users without an allocation are listed in
+// NotFound instead of failing the batch.
+func (d *LazyDistributor) ExplainBatch(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	userAddresses []string,
+) (_ *subsidy.AllocationExplanations, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.ExplainBatch",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()),
+		attribute.Int("users", len(userAddresses)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	snapshot, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	subsidies, err := d.subgraphClient.QueryAccountSubsidiesForAccountsAtBlock(ctx, vaultId, userAddresses, snapshot.BlockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account subsidies at block %d: %w", snapshot.BlockNumber, err)
+	}
+	weights, err := d.appliedWeights(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	if subsidies, err = d.explainedSubsidies(ctx, vaultId, snapshot.BlockNumber, userAddresses, subsidies, weights); err != nil {
+		return nil, err
+	}
+
+	records, err := d.store.ListCapRecords(ctx, epochNumber, vaultId, "")
+	if err != nil {
+		return nil, err
+	}
+	recordsByAccount := make(map[string][]capRecord)
+	for _, record := range records {
+		account := utils.NormalizeAddress(record.Account)
+		recordsByAccount[account] = append(recordsByAccount[account], record)
+	}
+	rounding, err := d.store.GetRoundingRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	blocked, err := d.store.GetBlockedRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	deferred, err := d.store.GetDeferredRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	debts, err := d.store.GetDebtRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	expiry, err := d.store.GetExpiryRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &subsidy.AllocationExplanations{
+		VaultID:      vaultId,
+		EpochNumber:  epochNumber.String(),
+		MerkleRoot:   snapshot.MerkleRoot,
+		BlockNumber:  snapshot.BlockNumber,
+		Explanations: make([]*subsidy.AllocationExplanation, 0, len(userAddresses)),
+		NotFound:     make([]string, 0),
+	}
+	totals := newTreeTotals(snapshot)
+	seen := make(map[string]bool, len(userAddresses))
+	for _, userAddress := range userAddresses {
+		user := utils.NormalizeAddress(userAddress)
+		if seen[user] {
+			continue
+		}
+		seen[user] = true
+
+		explanation, ok := d.explainAccount(vaultId, epochNumber, snapshot, totals, user, subsidies[user],
+			recordsByAccount[user], rounding.bonusesFor(user), blocked.blocks(user), deferred.defers(user),
+			expiry.expired(user))
+		if !ok {
+			result.NotFound = append(result.NotFound, user)
+			continue
+		}
+		explainDebt(explanation, debts)
+		if !explanation.Matches {
+			result.Mismatched++
+		}
+		result.Explanations = append(result.Explanations, explanation)
+	}
+	return result, nil
+}
+
+// treeTotals are the amounts in a snapshot's merkle tree, per account and for the whole vault
+type treeTotals struct {
+	accounts map[string]*big.Int
+	vault    *big.Int
+}
+
+// newTreeTotals sums the snapshot's entries. An account has an entry per collection it earned in,
+// all of them sharing its address.
+func newTreeTotals(snapshot *merkle.MerkleSnapshot) treeTotals {
+	totals := treeTotals{accounts: make(map[string]*big.Int), vault: big.NewInt(0)}
+	for _, entry := range snapshot.Entries {
+		totals.vault.Add(totals.vault, entry.TotalEarned)
+		account := utils.NormalizeAddress(entry.Address)
+		if totals.accounts[account] == nil {
+			totals.accounts[account] = big.NewInt(0)
+		}
+		totals.accounts[account].Add(totals.accounts[account], entry.TotalEarned)
+	}
+	return totals
+}
+
+// explainAccount recomputes user's amount from its subsidies at the snapshot block and the caps and
+// rounding bonuses recorded for it, and compares it with the amount in the tree. The allocations of a blocked
+// user, or of one left out below the minimum allocation, are shown but add nothing. It reports false when the user is neither in the tree nor has
+// subsidies in the vault.
+func (d *LazyDistributor) explainAccount(
+	vaultId string,
+	epochNumber *big.Int,
+	snapshot *merkle.MerkleSnapshot,
+	totals treeTotals,
+	user string,
+	subsidies []subgraph.AccountSubsidy,
+	records []capRecord,
+	bonuses map[string]*big.Int,
+	blocked bool,
+	deferred bool,
+	expired *big.Int,
+) (*subsidy.AllocationExplanation, bool) {
+	distributed, inTree := totals.accounts[user]
+	if !inTree {
+		if len(subsidies) == 0 {
+			return nil, false
+		}
+		distributed = big.NewInt(0)
+	}
+
+	explanation := &subsidy.AllocationExplanation{
+		VaultID:     vaultId,
+		EpochNumber: epochNumber.String(),
+		UserAddress: user,
+		MerkleRoot:  snapshot.MerkleRoot,
+		BlockNumber: snapshot.BlockNumber,
+		Collections: make([]subsidy.CollectionAllocation, 0),
+	}
+	explanation.ValuedAt, explanation.ValuedAtEstimated = snapshotValuedAt(snapshot)
+
+	capped := make(map[string]capRecord, len(records))
+	for _, record := range records {
+		capped[record.CollectionParticipation] = record
+	}
+
+	computed := big.NewInt(0)
+	for _, accountSubsidy := range subsidies {
+		amount, allocation, err := valueSubsidy(accountSubsidy, explanation.ValuedAt)
+		if checkErr := checkSubsidy(accountSubsidy); checkErr != nil {
+			err = checkErr
+		}
+		if record, ok := capped[accountSubsidy.CollectionParticipation]; ok && err == nil && amount.Sign() > 0 {
+			if cappedAmount, ok := new(big.Int).SetString(record.Amount, 10); ok {
+				applyCapRecord(&allocation, record)
+				amount = cappedAmount
+			}
+		}
+		if bonus, ok := bonuses[accountSubsidy.CollectionParticipation]; ok && err == nil && amount.Sign() > 0 {
+			amount = new(big.Int).Add(amount, bonus)
+			allocation.Amount = amount.String()
+			allocation.RoundingBonus = bonus.String()
+		}
+		switch {
+		case err != nil:
+			// the distribution quarantined the record rather than valuing it
+			allocation.Source = subsidy.AllocationSourceQuarantined
+			allocation.Amount = "0"
+			allocation.Reason = err.Error()
+		case blocked && amount.Sign() > 0:
+			allocation.Source = subsidy.AllocationSourceBlocked
+			allocation.Amount = "0"
+			allocation.Reason = "account was blocklisted when the distribution was built"
+		case deferred && amount.Sign() > 0:
+			allocation.Source = subsidy.AllocationSourceDeferred
+			allocation.Amount = "0"
+			allocation.Reason = "account's total was below the vault's minimum allocation, it is carried to its next one"
+		case amount.Sign() <= 0 && len(allocation.CapsApplied) == 0:
+			allocation.Source = subsidy.AllocationSourceSkipped
+			allocation.Reason = "no earnings"
+		default:
+			computed.Add(computed, amount)
+		}
+		explanation.Collections = append(explanation.Collections, allocation)
+	}
+
+	// what expired unclaimed is left out of the account's amount as a whole rather than of one collection's
+	if expired != nil {
+		explanation.Expired = expired.String()
+		if expired.Cmp(computed) > 0 {
+			expired = computed
+		}
+		computed.Sub(computed, expired)
+	}
+
+	explanation.ComputedAmount = computed.String()
+	explanation.DistributedAmount = distributed.String()
+	explanation.VaultTotal = totals.vault.String()
+	explanation.YieldShare = "0"
+	if totals.vault.Sign() > 0 {
+		explanation.YieldShare = new(big.Rat).SetFrac(distributed, totals.vault).FloatString(yieldShareDecimals)
+	}
+	explanation.Matches = computed.Cmp(distributed) == 0
+
+	if !explanation.Matches {
+		d.logger.Logf("WARN recomputed amount %s for %s in vault %s epoch %s differs from distributed %s",
+			explanation.ComputedAmount, user, vaultId, epochNumber.String(), explanation.DistributedAmount)
+	}
+	return explanation, true
+}
+
+// explainDebt shows what the user owed the lending market against what the tree gives it, when the distribution
+// recorded its debts
+func explainDebt(explanation *subsidy.AllocationExplanation, debts *debtRecord) {
+	if debts == nil {
+		return
+	}
+	debt := debts.balance(explanation.UserAddress)
+	if debt == nil {
+		return
+	}
+	explanation.OutstandingDebt = debt.String()
+	if distributed, ok := new(big.Int).SetString(explanation.DistributedAmount, 10); ok && debt.Sign() > 0 {
+		explanation.DebtCoverage = new(big.Rat).SetFrac(distributed, debt).FloatString(yieldShareDecimals)
+	}
+}
+
+// epochSnapshot returns the merkle snapshot stored when the vault's epoch was distributed
+func (d *LazyDistributor) epochSnapshot(ctx context.Context, vaultId string, epochNumber *big.Int) (*merkle.MerkleSnapshot, error) {
+	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
+	if !ok {
+		return nil, fmt.Errorf("merkle service is not the expected implementation type")
+	}
+
+	snapshot, err := merkleImpl.GetSnapshot(ctx, epochNumber, vaultId)
+	if err != nil {
+		if errors.Is(err, merkle.ErrNotFound) {
+			return nil, fmt.Errorf("%w: no distribution for vault %s in epoch %s", subsidy.ErrNotFound, vaultId, epochNumber.String())
+		}
+		return nil, fmt.Errorf("failed to get merkle snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// snapshotValuedAt returns the unix time the snapshot's accrual was valued at. Snapshots taken before
+// it was recorded fall back to their creation time, reported as estimated.
+func snapshotValuedAt(snapshot *merkle.MerkleSnapshot) (int64, bool) {
+	if snapshot.Timestamp != 0 {
+		return snapshot.Timestamp, false
+	}
+	return snapshot.CreatedAt.Unix(), true
+}
@@ -0,0 +1,165 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const explainTestUser = "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b"
+
+// explainTestSubsidies has the user earning in two collections, one valued by the subgraph and one accrued
+func explainTestSubsidies() []subgraph.AccountSubsidy {
+	updatedAt := time.Now().Add(-time.Hour).Unix()
+	return []subgraph.AccountSubsidy{
+		{
+			Account:                 subgraph.Account{ID: explainTestUser},
+			CollectionParticipation: "0xcollection-a",
+			TotalRewardsEarned:      "1000",
+		},
+		{
+			Account:                 subgraph.Account{ID: explainTestUser},
+			CollectionParticipation: "0xcollection-b",
+			SecondsAccumulated:      "5000000000000000000000",
+			LastEffectiveValue:      "2000000000000000000",
+			UpdatedAtTimestamp:      big.NewInt(updatedAt).String(),
+			TotalRewardsEarned:      "0",
+		},
+		{
+			Account:                 subgraph.Account{ID: "0x1111111111111111111111111111111111111111"},
+			CollectionParticipation: "0xcollection-a",
+			TotalRewardsEarned:      "3000",
+		},
+	}
+}
+
+func TestLazyDistributor_ExplainMatchesDistribution(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	subsidies := explainTestSubsidies()
+	subgraphClient := &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress, accountAddress string,
+			blockNumber int64,
+		) ([]subgraph.AccountSubsidy, error) {
+			assert.Equal(t, int64(100), blockNumber, "explanations read the snapshot block")
+			return subsidies[:2], nil
+		},
+	}
+	distributor.subgraphClient = subgraphClient
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(5), "0x8F37C5C4FA708E06A656D858003EF7DC5F60A29B")
+	require.NoError(t, err)
+	assert.Equal(t, explainTestUser, explanation.UserAddress)
+	assert.Equal(t, result.MerkleRoot, explanation.MerkleRoot)
+	assert.Equal(t, int64(100), explanation.BlockNumber)
+	assert.False(t, explanation.ValuedAtEstimated)
+	assert.True(t, explanation.Matches, "computed %s, distributed %s", explanation.ComputedAmount, explanation.DistributedAmount)
+	assert.Equal(t, result.TotalSubsidies.String(), explanation.VaultTotal)
+
+	require.Len(t, explanation.Collections, 2)
+	assert.Equal(t, subsidy.AllocationSourceSubgraph, explanation.Collections[0].Source)
+	assert.Equal(t, "1000", explanation.Collections[0].Amount)
+
+	accrued := explanation.Collections[1]
+	assert.Equal(t, subsidy.AllocationSourceAccrued, accrued.Source)
+	assert.Equal(t, "0xcollection-b", accrued.CollectionParticipation)
+	assert.GreaterOrEqual(t, accrued.ElapsedSeconds, int64(3600))
+	// 5000 tokens accumulated plus 2 tokens per elapsed second
+	assert.Equal(t, big.NewInt(5000+2*accrued.ElapsedSeconds).String(), accrued.Amount)
+
+	distributed, ok := new(big.Int).SetString(explanation.DistributedAmount, 10)
+	require.True(t, ok)
+	total := new(big.Int).Add(distributed, big.NewInt(3000))
+	assert.Equal(t, total.String(), explanation.VaultTotal)
+	share, ok := new(big.Rat).SetString(explanation.YieldShare)
+	require.True(t, ok)
+	assert.Equal(t, new(big.Rat).SetFrac(distributed, total).FloatString(yieldShareDecimals), share.FloatString(yieldShareDecimals))
+}
+
+func TestLazyDistributor_ExplainNotFound(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	ctx := context.Background()
+
+	_, err := distributor.Explain(ctx, planTestVault, big.NewInt(5), explainTestUser)
+	assert.ErrorIs(t, err, subsidy.ErrNotFound, "no distribution for the epoch")
+
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	distributor.subgraphClient.(*subgraph.SubgraphClientMock).QueryAccountSubsidiesForAccountAtBlockFunc = func(
+		ctx context.Context,
+		vaultAddress, accountAddress string,
+		blockNumber int64,
+	) ([]subgraph.AccountSubsidy, error) {
+		return nil, nil
+	}
+
+	_, err = distributor.Explain(ctx, planTestVault, big.NewInt(5), "0x2222222222222222222222222222222222222222")
+	assert.ErrorIs(t, err, subsidy.ErrNotFound, "user without an allocation")
+}
+
+func TestLazyDistributor_ExplainBatch(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	subsidies := explainTestSubsidies()
+	var lookups int
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountsAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accounts []string,
+			blockNumber int64,
+		) (map[string][]subgraph.AccountSubsidy, error) {
+			lookups++
+			assert.Equal(t, int64(100), blockNumber)
+			return map[string][]subgraph.AccountSubsidy{
+				explainTestUser:         subsidies[:2],
+				subsidies[2].Account.ID: subsidies[2:],
+			}, nil
+		},
+	}
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	result, err := distributor.ExplainBatch(ctx, planTestVault, big.NewInt(5), []string{
+		"0x8F37C5C4FA708E06A656D858003EF7DC5F60A29B",
+		subsidies[2].Account.ID,
+		"0x2222222222222222222222222222222222222222",
+		explainTestUser,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, lookups, "every user is read in one batched lookup")
+	assert.Equal(t, int64(100), result.BlockNumber)
+	assert.Equal(t, []string{"0x2222222222222222222222222222222222222222"}, result.NotFound)
+	assert.Zero(t, result.Mismatched)
+
+	require.Len(t, result.Explanations, 2, "a repeated user is explained once")
+	assert.Equal(t, explainTestUser, result.Explanations[0].UserAddress)
+	assert.Len(t, result.Explanations[0].Collections, 2)
+	assert.Equal(t, "3000", result.Explanations[1].DistributedAmount)
+	for _, explanation := range result.Explanations {
+		assert.True(t, explanation.Matches, "%s computed %s, distributed %s",
+			explanation.UserAddress, explanation.ComputedAmount, explanation.DistributedAmount)
+	}
+}
@@ -0,0 +1,159 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// subgraphHeadQuery returns the latest block the subgraph indexed
+const subgraphHeadQuery = `
+	query SubgraphHead {
+		_meta {
+			block {
+				number
+			}
+		}
+	}
+`
+
+// finalizationPolicy decides whether pre-flight checks run before an epoch's root is pushed and whether a failed
+// check keeps it from being pushed
+type finalizationPolicy struct {
+	enabled bool
+	enforce bool
+}
+
+func newFinalizationPolicy(cfg *config.Config) finalizationPolicy {
+	return finalizationPolicy{
+		enabled: cfg.Finalization.Checks != subsidy.FinalizationChecksOff,
+		enforce: cfg.Finalization.Checks == subsidy.FinalizationChecksEnforce,
+	}
+}
+
+// noEpochYield is the detail of the epoch_yield check for a vault without yield for the epoch
+const noEpochYield = "vault has no yield for the current epoch, force end the epoch with zero yield instead"
+
+// checkFinalization runs the pre-flight checks on the snapshot about to finalize the vault's epoch and logs each
+// result. A check that cannot run fails. Failures are returned as ErrPreflightFailed only when checks are enforced,
+// also wrapping ErrInsufficientYield when the vault has no yield for the epoch.
+func (d *LazyDistributor) checkFinalization(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	snapshot *distributionSnapshot,
+) ([]subsidy.FinalizationCheck, error) {
+	if !d.finalization.enabled {
+		return nil, nil
+	}
+
+	checks := []subsidy.FinalizationCheck{
+		d.checkSubgraphSynced(ctx, snapshot.block.Number),
+		d.checkEpochYield(ctx, vaultId),
+		d.checkSignerAuthorized(ctx, vaultId, epochNumber, snapshot),
+		d.checkRootChanged(ctx, vaultId, snapshot.merkleRoot),
+	}
+
+	var failed []string
+	noYield := false
+	for _, check := range checks {
+		if check.Passed {
+			d.logger.Logf("INFO finalization check %s passed for vault %s epoch %s", check.Name, vaultId, epochNumber)
+			continue
+		}
+		failed = append(failed, fmt.Sprintf("%s: %s", check.Name, check.Detail))
+		noYield = noYield || check.Name == subsidy.CheckEpochYield && check.Detail == noEpochYield
+		d.logger.Logf("WARN finalization check %s failed for vault %s epoch %s: %s", check.Name, vaultId, epochNumber, check.Detail)
+	}
+	if len(failed) > 0 && d.finalization.enforce {
+		err := fmt.Errorf("%w for vault %s epoch %s: %s", subsidy.ErrPreflightFailed, vaultId, epochNumber, strings.Join(failed, "; "))
+		if noYield {
+			err = fmt.Errorf("%w: %w", subsidy.ErrInsufficientYield, err)
+		}
+		return checks, err
+	}
+	return checks, nil
+}
+
+// checkSubgraphSynced passes when the subgraph has indexed the snapshot block, so the tree was not built from
+// state it had yet to catch up with
+func (d *LazyDistributor) checkSubgraphSynced(ctx context.Context, snapshotBlock uint64) subsidy.FinalizationCheck {
+	check := subsidy.FinalizationCheck{Name: subsidy.CheckSubgraphSynced}
+
+	var response struct {
+		Meta struct {
+			Block struct {
+				Number uint64 `json:"number"`
+			} `json:"block"`
+		} `json:"_meta"`
+	}
+	if err := d.subgraphClient.ExecuteQuery(subgraph.WithFreshData(ctx), subgraph.GraphQLRequest{Query: subgraphHeadQuery}, &response); err != nil {
+		check.Detail = fmt.Sprintf("failed to read the subgraph head: %v", err)
+		return check
+	}
+	if head := response.Meta.Block.Number; head < snapshotBlock {
+		check.Detail = fmt.Sprintf("subgraph indexed up to block %d, before snapshot block %d", head, snapshotBlock)
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkEpochYield passes when the vault holds yield for the epoch. An epoch without any is ended through the
+// zero-yield force end rather than a distribution.
+func (d *LazyDistributor) checkEpochYield(ctx context.Context, vaultId string) subsidy.FinalizationCheck {
+	check := subsidy.FinalizationCheck{Name: subsidy.CheckEpochYield}
+
+	yield, err := d.blockchainClient.GetCurrentEpochYield(ctx, vaultId)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to read the vault's epoch yield: %v", err)
+		return check
+	}
+	if yield.Sign() <= 0 {
+		check.Detail = noEpochYield
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkSignerAuthorized passes when the signer's calls finalizing the epoch would not revert
+func (d *LazyDistributor) checkSignerAuthorized(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	snapshot *distributionSnapshot,
+) subsidy.FinalizationCheck {
+	check := subsidy.FinalizationCheck{Name: subsidy.CheckSignerAuthorized}
+
+	err := d.blockchainClient.SimulateEpochFinalization(ctx, epochNumber, vaultId, snapshot.merkleRoot, snapshot.totalSubsidies)
+	if err != nil {
+		check.Detail = err.Error()
+		return check
+	}
+	check.Passed = true
+	return check
+}
+
+// checkRootChanged passes when the root differs from the one the vault has on-chain, a root already pushed
+// is not pushed again
+func (d *LazyDistributor) checkRootChanged(ctx context.Context, vaultId string, root [32]byte) subsidy.FinalizationCheck {
+	check := subsidy.FinalizationCheck{Name: subsidy.CheckRootChanged}
+
+	current, err := d.blockchainClient.GetMerkleRoot(ctx, vaultId)
+	if err != nil {
+		check.Detail = fmt.Sprintf("failed to read the vault's merkle root: %v", err)
+		return check
+	}
+	if current == root {
+		check.Detail = fmt.Sprintf("root %x is already on-chain", root)
+		return check
+	}
+	check.Passed = true
+	return check
+}
@@ -0,0 +1,119 @@
+package subsidyimpl
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// newFinalizationTestDistributor runs the checks in mode against a subgraph indexed up to subgraphHead and a vault
+// holding yield, whose signer simulation fails with simulateErr. The snapshot is taken at block 100.
+func newFinalizationTestDistributor(t *testing.T, mode string, subgraphHead uint64, yield int64, simulateErr error) *LazyDistributor {
+	t.Helper()
+	chain := newApprovalTestChain(nil)
+	chain.GetCurrentEpochYieldFunc = func(ctx context.Context, vaultAddress string) (*big.Int, error) {
+		return big.NewInt(yield), nil
+	}
+	chain.SimulateEpochFinalizationFunc = func(ctx context.Context, epochId *big.Int, vaultId string, root [32]byte, totalSubsidies *big.Int) error {
+		return simulateErr
+	}
+	chain.GetMerkleRootFunc = func(ctx context.Context, vaultId string) ([32]byte, error) {
+		return [32]byte{1}, nil
+	}
+
+	distributor := newApprovalTestDistributor(newPlannerTestDB(t), chain, approvalPolicy{})
+	graph := distributor.subgraphClient.(*subgraph.SubgraphClientMock)
+	graph.ExecuteQueryFunc = func(ctx context.Context, request subgraph.GraphQLRequest, response interface{}) error {
+		head := map[string]interface{}{"_meta": map[string]interface{}{"block": map[string]interface{}{"number": subgraphHead}}}
+		data, err := json.Marshal(head)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, response)
+	}
+
+	cfg := &config.Config{}
+	cfg.Finalization.Checks = mode
+	distributor.finalization = newFinalizationPolicy(cfg)
+	return distributor
+}
+
+func TestLazyDistributor_FinalizationChecksPass(t *testing.T) {
+	distributor := newFinalizationTestDistributor(t, subsidy.FinalizationChecksEnforce, 120, 1000, nil)
+
+	result, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	require.Len(t, result.Checks, 4)
+	for _, check := range result.Checks {
+		assert.True(t, check.Passed, check.Name)
+	}
+	assert.Len(t, distributor.blockchainClient.(*blockchain.BlockchainClientMock).UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+}
+
+func TestLazyDistributor_FinalizationChecksWarn(t *testing.T) {
+	distributor := newFinalizationTestDistributor(t, subsidy.FinalizationChecksWarn, 90, 0, errors.New("updateMerkleRoot would revert"))
+
+	result, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	require.Len(t, result.Checks, 4)
+	assert.Equal(t, subsidy.FinalizationCheck{
+		Name:   subsidy.CheckSubgraphSynced,
+		Detail: "subgraph indexed up to block 90, before snapshot block 100",
+	}, result.Checks[0])
+	assert.False(t, result.Checks[1].Passed, "a vault without yield is ended with zero yield")
+	assert.Equal(t, "updateMerkleRoot would revert", result.Checks[2].Detail)
+	assert.True(t, result.Checks[3].Passed)
+	assert.Len(t, distributor.blockchainClient.(*blockchain.BlockchainClientMock).UpdateMerkleRootAndWaitForConfirmationCalls(), 1,
+		"failed checks only warn")
+}
+
+func TestLazyDistributor_FinalizationChecksEnforced(t *testing.T) {
+	distributor := newFinalizationTestDistributor(t, subsidy.FinalizationChecksEnforce, 100, 1000, nil)
+	chain := distributor.blockchainClient.(*blockchain.BlockchainClientMock)
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	root := result.MerkleRoot
+
+	// the same tree again would push the root already on-chain
+	chain.GetMerkleRootFunc = func(ctx context.Context, vaultId string) ([32]byte, error) {
+		var current [32]byte
+		copy(current[:], common.FromHex(root))
+		return current, nil
+	}
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.ErrorIs(t, err, subsidy.ErrPreflightFailed)
+	assert.NotErrorIs(t, err, subsidy.ErrInsufficientYield)
+	assert.Contains(t, err.Error(), subsidy.CheckRootChanged)
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1, "the root is not pushed")
+}
+
+func TestLazyDistributor_FinalizationChecksEnforcedWithoutYield(t *testing.T) {
+	distributor := newFinalizationTestDistributor(t, subsidy.FinalizationChecksEnforce, 100, 0, nil)
+
+	_, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(3))
+	require.ErrorIs(t, err, subsidy.ErrInsufficientYield)
+	require.ErrorIs(t, err, subsidy.ErrPreflightFailed)
+	assert.Empty(t, distributor.blockchainClient.(*blockchain.BlockchainClientMock).UpdateMerkleRootAndWaitForConfirmationCalls())
+}
+
+func TestLazyDistributor_FinalizationChecksOff(t *testing.T) {
+	distributor := newFinalizationTestDistributor(t, subsidy.FinalizationChecksOff, 0, 0, errors.New("not authorized"))
+
+	result, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.Empty(t, result.Checks)
+	assert.Empty(t, distributor.subgraphClient.(*subgraph.SubgraphClientMock).ExecuteQueryCalls())
+}
@@ -0,0 +1,262 @@
+package subsidyimpl
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// audit actions recorded for fingerprint overrides
+const (
+	actionOverrideFingerprint          = "overrideFingerprint"
+	actionReplaceCommittedDistribution = "replaceCommittedDistribution"
+)
+
+// newFingerprintParams returns the configuration every distribution is fingerprinted with, in a canonical form
+// so equivalent configurations fingerprint the same
+func newFingerprintParams(cfg *config.Config) map[string]string {
+	caps, holdings := newCapPolicy(cfg), newHoldingsPolicy(cfg)
+	params := map[string]string{
+		"caps.userMax":               amountOrEmpty(caps.userMax),
+		"caps.userMaxDebtPercent":    strconv.FormatUint(caps.userDebtPercent, 10),
+		"caps.collectionMax":         amountOrEmpty(caps.collectionMax),
+		"caps.collectionDebtPercent": strconv.FormatUint(caps.collectionDebtPercent, 10),
+		"caps.remainder":             cfg.Caps.Remainder,
+		"rounding.policy":            cfg.Rounding.Policy,
+		"blocklist.remainder":        cfg.Blocklist.Remainder,
+		"holdings.wrappers":          strings.Join(sorted(holdings.wrappers), ","),
+	}
+	units := make([]string, 0, len(holdings.erc1155Units))
+	for collection, perToken := range holdings.erc1155Units {
+		units = append(units, collection+":"+perToken.String())
+	}
+	params["holdings.erc1155Units"] = strings.Join(sorted(units), ",")
+	// recorded only when set, so fingerprints of snapshot eligibility stay what they were
+	if cfg.Holdings.Eligibility == subsidy.EligibilityTimeWeighted {
+		params["holdings.eligibility"] = cfg.Holdings.Eligibility
+	}
+	// as is capping accounts at their outstanding debt
+	if caps.outstandingDebt {
+		params["caps.outstandingDebt"] = "true"
+	}
+	// and expiring unclaimed amounts
+	if cfg.ClaimExpiry.Epochs > 0 {
+		params["expiry.epochs"] = strconv.FormatUint(cfg.ClaimExpiry.Epochs, 10)
+	}
+	return params
+}
+
+// fingerprintInputs is what a fingerprint's hash is computed over
+type fingerprintInputs struct {
+	StrategyVersion string            `json:"strategyVersion"`
+	VaultID         string            `json:"vaultId"`
+	EpochNumber     string            `json:"epochNumber"`
+	SnapshotBlock   uint64            `json:"snapshotBlock"`
+	BlockHash       string            `json:"blockHash"`
+	BlockStrategy   string            `json:"blockStrategy"`
+	ValuedAt        int64             `json:"valuedAt"`
+	Params          map[string]string `json:"params"`
+	AccountSetHash  string            `json:"accountSetHash"`
+}
+
+// fingerprint identifies the inputs the snapshot of the vault's epoch was computed from: the snapshot block, the
+// strategy version, the configuration and the state carried into it, and the accounts the subgraph reported
+func (d *LazyDistributor) fingerprint(vaultId string, epochNumber *big.Int, snapshot *distributionSnapshot) *subsidy.Fingerprint {
+	params := make(map[string]string, len(d.fingerprintParams)+5)
+	for name, value := range d.fingerprintParams {
+		params[name] = value
+	}
+	// time-weighted eligibility switched off values the epoch as snapshot eligibility does, and fingerprints it so
+	if !snapshot.timeWeighted {
+		delete(params, "holdings.eligibility")
+	}
+	params["caps.carriedIn"] = amountOrEmpty(snapshot.carriedIn)
+	params["rounding.dustCarriedIn"] = snapshot.rounding.CarriedIn
+	params["blocklist.accounts"] = strings.Join(snapshot.blocked.Accounts, ",")
+	weights := make([]string, len(snapshot.weights))
+	for i, weight := range snapshot.weights {
+		weights[i] = utils.NormalizeAddress(weight.Collection) + ":" + weight.Multiplier
+	}
+	params["weights"] = strings.Join(sorted(weights), ",")
+	// recorded only when there are any, so fingerprints of unadjusted epochs stay what they were
+	if len(snapshot.adjustments) > 0 {
+		params["adjustments"] = adjustmentsParam(snapshot.adjustments)
+	}
+	// and only for vaults with a minimum allocation
+	if minimum := d.minimums.minimum(vaultId); minimum != nil {
+		params["minimum.allocation"] = minimum.String()
+	}
+	if merkleImpl, ok := d.merkleService.(*merkleimpl.Service); ok {
+		params["merkle.leafEncoding"] = string(merkleImpl.LeafEncoding(vaultId))
+	}
+
+	accounts := make([]string, 0, len(snapshot.accounts))
+	for account := range snapshot.accounts {
+		accounts = append(accounts, account)
+	}
+	accountSetHash := crypto.Keccak256Hash([]byte(strings.Join(sorted(accounts), ","))).Hex()
+
+	inputs := fingerprintInputs{
+		StrategyVersion: subsidy.DistributionStrategyVersion,
+		VaultID:         utils.NormalizeAddress(vaultId),
+		EpochNumber:     epochNumber.String(),
+		SnapshotBlock:   snapshot.block.Number,
+		BlockHash:       snapshot.block.Hash,
+		BlockStrategy:   snapshot.strategy,
+		ValuedAt:        snapshot.valuedAt,
+		Params:          params,
+		AccountSetHash:  accountSetHash,
+	}
+	// map keys are marshaled sorted, so the encoding is canonical
+	encoded, _ := json.Marshal(inputs)
+
+	return &subsidy.Fingerprint{
+		VaultID:         vaultId,
+		EpochNumber:     inputs.EpochNumber,
+		Hash:            crypto.Keccak256Hash(encoded).Hex(),
+		StrategyVersion: inputs.StrategyVersion,
+		SnapshotBlock:   inputs.SnapshotBlock,
+		BlockHash:       inputs.BlockHash,
+		BlockStrategy:   inputs.BlockStrategy,
+		ValuedAt:        inputs.ValuedAt,
+		Params:          params,
+		AccountSetHash:  accountSetHash,
+		Accounts:        len(accounts),
+		MerkleRoot:      fmt.Sprintf("%x", snapshot.merkleRoot),
+		ComputedAt:      time.Now(),
+	}
+}
+
+// checkFingerprint refuses a distribution that would replace the committed distribution of the vault's epoch
+// when it was computed from other inputs, unless an admin overrode the committed fingerprint. The override is
+// used up by the distribution it lets through.
+func (d *LazyDistributor) checkFingerprint(ctx context.Context, vaultId string, epochNumber *big.Int, fingerprint *subsidy.Fingerprint) error {
+	committed, err := d.store.GetFingerprint(ctx, epochNumber, vaultId)
+	if err != nil {
+		return err
+	}
+	if committed == nil || !committed.Committed {
+		return nil
+	}
+	if committed.Hash == fingerprint.Hash {
+		// the same inputs rebuild the committed distribution, which stays committed
+		fingerprint.Committed = true
+		return nil
+	}
+
+	override, err := d.store.GetFingerprintOverride(ctx, epochNumber, vaultId)
+	if err != nil {
+		return err
+	}
+	if override == nil || override.Fingerprint != committed.Hash {
+		d.logger.Logf("ERROR distribution of vault %s epoch %s has fingerprint %s, the committed one has %s",
+			vaultId, epochNumber.String(), fingerprint.Hash, committed.Hash)
+		return fmt.Errorf("%w: epoch %s of vault %s was committed with fingerprint %s, this distribution has %s and needs an admin override",
+			subsidy.ErrFingerprintMismatch, epochNumber.String(), vaultId, committed.Hash, fingerprint.Hash)
+	}
+
+	if err := d.store.DeleteFingerprintOverride(ctx, epochNumber, vaultId); err != nil {
+		return err
+	}
+	d.logger.Logf("WARN replacing committed distribution of vault %s epoch %s (fingerprint %s) with fingerprint %s, overridden by %s: %s",
+		vaultId, epochNumber.String(), committed.Hash, fingerprint.Hash, override.OverriddenBy, override.Reason)
+	d.record(ctx, actionReplaceCommittedDistribution, map[string]string{
+		"vault":        vaultId,
+		"epoch":        epochNumber.String(),
+		"committed":    committed.Hash,
+		"fingerprint":  fingerprint.Hash,
+		"overriddenBy": override.OverriddenBy,
+		"reason":       override.Reason,
+	})
+	return nil
+}
+
+// commitFingerprint marks the fingerprint of the vault's epoch committed once its root is on-chain. The root
+// is already pushed, so a failure is logged and the distribution goes on.
+func (d *LazyDistributor) commitFingerprint(ctx context.Context, vaultId string, epochNumber *big.Int, hash string) {
+	if hash == "" {
+		return
+	}
+	fingerprint, err := d.store.GetFingerprint(ctx, epochNumber, vaultId)
+	if err != nil {
+		d.logger.Logf("WARN failed to commit fingerprint of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+		return
+	}
+	if fingerprint == nil || fingerprint.Hash != hash {
+		d.logger.Logf("WARN fingerprint %s of vault %s epoch %s is no longer stored, not committing it", hash, vaultId, epochNumber.String())
+		return
+	}
+	if fingerprint.Committed {
+		return
+	}
+	fingerprint.Committed = true
+	if err := d.store.SaveFingerprint(ctx, *fingerprint); err != nil {
+		d.logger.Logf("WARN failed to commit fingerprint of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+	}
+}
+
+// OverrideFingerprint lets the next distribution of the vault's epoch replace its committed distribution
+// although it was computed from other inputs. The override applies once, and not after the committed
+// distribution changed.
+func (d *LazyDistributor) OverrideFingerprint(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	reason string,
+) (*subsidy.FingerprintOverride, error) {
+	committed, err := d.store.GetFingerprint(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	if committed == nil || !committed.Committed {
+		return nil, fmt.Errorf("%w: epoch %s of vault %s has no committed distribution", subsidy.ErrNotFound, epochNumber.String(), vaultId)
+	}
+
+	override := subsidy.FingerprintOverride{
+		VaultID:      vaultId,
+		EpochNumber:  epochNumber.String(),
+		Fingerprint:  committed.Hash,
+		Reason:       reason,
+		OverriddenBy: audit.ActorFromContext(ctx),
+		OverriddenAt: time.Now(),
+	}
+	if err := d.store.SaveFingerprintOverride(ctx, override); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO fingerprint %s of vault %s epoch %s overridden by %s: %s",
+		committed.Hash, vaultId, override.EpochNumber, override.OverriddenBy, reason)
+	d.record(ctx, actionOverrideFingerprint, map[string]string{
+		"vault":       vaultId,
+		"epoch":       override.EpochNumber,
+		"fingerprint": committed.Hash,
+		"reason":      reason,
+	})
+	return &override, nil
+}
+
+// amountOrEmpty formats a wei amount, empty when it is nil
+func amountOrEmpty(amount *big.Int) string {
+	if amount == nil {
+		return ""
+	}
+	return amount.String()
+}
+
+// sorted sorts values in place and returns them
+func sorted(values []string) []string {
+	sort.Strings(values)
+	return values
+}
@@ -0,0 +1,171 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// newFingerprintTestDistributor snapshots the finalized block, so a run's inputs do not depend on when it ran
+func newFingerprintTestDistributor(t *testing.T, policy approvalPolicy) *LazyDistributor {
+	distributor := newApprovalTestDistributor(newPlannerTestDB(t), newApprovalTestChain(nil), policy)
+	distributor.snapshotStrategy = subsidy.SnapshotBlockFinalized
+	subgraphClient := distributor.subgraphClient.(*subgraph.SubgraphClientMock)
+	subgraphClient.StreamAccountSubsidiesForVaultAtBlockFunc = func(
+		ctx context.Context,
+		vaultAddress string,
+		blockNumber int64,
+		fn func(page []subgraph.AccountSubsidy) error,
+	) error {
+		return subgraphClient.StreamAccountSubsidiesForVault(ctx, vaultAddress, fn)
+	}
+	distributor.fingerprintParams = newFingerprintParams(&config.Config{})
+	return distributor
+}
+
+func TestLazyDistributor_FingerprintsDistributions(t *testing.T) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	fingerprint, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	require.NotNil(t, fingerprint)
+	assert.True(t, fingerprint.Committed, "the pushed distribution is committed")
+	assert.Equal(t, subsidy.DistributionStrategyVersion, fingerprint.StrategyVersion)
+	assert.Equal(t, uint64(100), fingerprint.SnapshotBlock)
+	assert.Equal(t, subsidy.SnapshotBlockFinalized, fingerprint.BlockStrategy)
+	assert.Equal(t, 1, fingerprint.Accounts)
+	assert.Equal(t, "packed", fingerprint.Params["merkle.leafEncoding"])
+
+	snapshot, err := distributor.merkleService.(*merkleimpl.Service).GetSnapshot(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint.Hash, snapshot.Fingerprint)
+
+	// the same inputs rebuild the committed distribution
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, epoch))
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	again, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint.Hash, again.Hash)
+	assert.True(t, again.Committed)
+	assert.Len(t, distributor.blockchainClient.(*blockchain.BlockchainClientMock).UpdateMerkleRootAndWaitForConfirmationCalls(), 2)
+}
+
+func TestLazyDistributor_RefusesChangedCommittedDistribution(t *testing.T) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+
+	_, err := distributor.OverrideFingerprint(ctx, planTestVault, epoch, "nothing committed yet")
+	require.ErrorIs(t, err, subsidy.ErrNotFound)
+
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	committed, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, epoch))
+
+	cfg := &config.Config{}
+	cfg.Rounding.Policy = subsidy.RoundingLargestHolders
+	distributor.fingerprintParams = newFingerprintParams(cfg)
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.ErrorIs(t, err, subsidy.ErrFingerprintMismatch)
+	stored, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, committed.Hash, stored.Hash, "the committed fingerprint is kept")
+
+	override, err := distributor.OverrideFingerprint(audit.WithActor(ctx, "api:10.0.0.1"), planTestVault, epoch, "rounding policy changed")
+	require.NoError(t, err)
+	assert.Equal(t, committed.Hash, override.Fingerprint)
+	assert.Equal(t, "api:10.0.0.1", override.OverriddenBy)
+
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	replaced, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.NotEqual(t, committed.Hash, replaced.Hash)
+	assert.True(t, replaced.Committed)
+	remaining, err := distributor.store.GetFingerprintOverride(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Nil(t, remaining, "the override is used up")
+
+	// the override does not carry over to the next change
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, epoch))
+	distributor.fingerprintParams = newFingerprintParams(&config.Config{})
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.ErrorIs(t, err, subsidy.ErrFingerprintMismatch)
+}
+
+func TestLazyDistributor_ApprovalCommitsFingerprint(t *testing.T) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{enabled: true, maxTotal: big.NewInt(500)})
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	fingerprint, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.False(t, fingerprint.Committed, "a staged distribution is not committed")
+
+	staged, err := distributor.SubmitStaged(ctx, result.StagedID)
+	require.NoError(t, err)
+	assert.Equal(t, fingerprint.Hash, staged.Fingerprint)
+	fingerprint, err = distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.True(t, fingerprint.Committed)
+}
+
+func TestNewFingerprintParams(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Holdings.Wrappers = []string{"0x00000000000000000000000000000000000000B2", "0x00000000000000000000000000000000000000a1"}
+	cfg.Caps.UserMax = "1000"
+	params := newFingerprintParams(cfg)
+	assert.Equal(t, "0x00000000000000000000000000000000000000a1,0x00000000000000000000000000000000000000b2", params["holdings.wrappers"],
+		"wrappers are fingerprinted normalized and in order")
+	assert.Equal(t, "1000", params["caps.userMax"])
+	assert.Equal(t, "", params["caps.collectionMax"])
+}
+
+func TestLazyDistributor_SwitchedOffFeatures(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Holdings.Eligibility = subsidy.EligibilityTimeWeighted
+	ctx := context.Background()
+
+	// the subgraph mock has no transfer history, so weighing by holding time would fail the run
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	distributor.eligibility = newEligibilityPolicy(cfg)
+	distributor.fingerprintParams = newFingerprintParams(cfg)
+	distributor.SetFeatures(&features.ServiceMock{
+		EnabledFunc: func(ctx context.Context, flag string) bool { return false },
+	})
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	fingerprint, err := distributor.store.GetFingerprint(ctx, big.NewInt(5), planTestVault)
+	require.NoError(t, err)
+	assert.NotContains(t, fingerprint.Params, "holdings.eligibility", "the epoch is fingerprinted as snapshot eligibility")
+
+	// a tree built in full has the root the delta build gives the same entries
+	snapshotted := newFingerprintTestDistributor(t, approvalPolicy{})
+	expected, err := snapshotted.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, expected.MerkleRoot, result.MerkleRoot)
+	expectedFingerprint, err := snapshotted.store.GetFingerprint(ctx, big.NewInt(5), planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, expectedFingerprint.Hash, fingerprint.Hash)
+}
@@ -0,0 +1,217 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// holdingsPolicy values holdings other than ERC-721 tokens held by the account earning on them.
+// ERC-1155 balances count fungible units, so a collection configured with units earns per NFT-equivalent
+// of that many units. Staking and wrapper contracts hold tokens for their owners, so what a wrapper
+// accrues is split among the owners of its positions pro rata to their balances.
+type holdingsPolicy struct {
+	erc1155Units map[string]*big.Int // units per NFT-equivalent, by normalized collection address
+	wrappers     []string            // normalized wrapper addresses
+	isWrapper    map[string]bool
+}
+
+func newHoldingsPolicy(cfg *config.Config) holdingsPolicy {
+	// config.Load rejects malformed units
+	units, _ := config.ParseERC1155Units(cfg.Holdings.ERC1155Units)
+	policy := holdingsPolicy{erc1155Units: units, isWrapper: make(map[string]bool)}
+	for _, wrapper := range cfg.Holdings.Wrappers {
+		wrapper = utils.NormalizeAddress(wrapper)
+		if wrapper != "" && !policy.isWrapper[wrapper] {
+			policy.isWrapper[wrapper] = true
+			policy.wrappers = append(policy.wrappers, wrapper)
+		}
+	}
+	return policy
+}
+
+// enabled reports whether any subsidy is valued differently from a plain ERC-721 holding
+func (p holdingsPolicy) enabled() bool {
+	return len(p.erc1155Units) > 0 || len(p.wrappers) > 0
+}
+
+// wrappedPositions are the positions of every configured wrapper, by wrapper and collection participation
+type wrappedPositions map[string]map[string][]subgraph.WrappedPosition
+
+// loadWrappedPositions reads what every configured wrapper holds in the vault at blockNumber,
+// or as currently indexed when blockNumber is nil
+func (d *LazyDistributor) loadWrappedPositions(ctx context.Context, vaultId string, blockNumber *int64) (wrappedPositions, error) {
+	positions := make(wrappedPositions, len(d.holdings.wrappers))
+	for _, wrapper := range d.holdings.wrappers {
+		var held []subgraph.WrappedPosition
+		var err error
+		if blockNumber == nil {
+			held, err = d.subgraphClient.QueryWrappedPositions(ctx, vaultId, wrapper)
+		} else {
+			held, err = d.subgraphClient.QueryWrappedPositionsAtBlock(ctx, vaultId, wrapper, *blockNumber)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		byParticipation := make(map[string][]subgraph.WrappedPosition)
+		for _, position := range held {
+			byParticipation[position.CollectionParticipation] = append(byParticipation[position.CollectionParticipation], position)
+		}
+		positions[wrapper] = byParticipation
+	}
+	return positions, nil
+}
+
+// normalize returns the subsidies valuation sees for a page read from the subgraph: ERC-1155 subsidies
+// scaled to NFT-equivalents, subsidies in weighted collections scaled by their multiplier, and every
+// wrapper's subsidy replaced by its owners' shares. A wrapper subsidy
+// without positions to split it among is returned as skipped rather than paid to the contract.
+// Shares are rounded down, so up to a wei per owner of what a wrapper accrued stays undistributed.
+func (p holdingsPolicy) normalize(
+	page []subgraph.AccountSubsidy,
+	positions wrappedPositions,
+	weights collectionWeights,
+) ([]subgraph.AccountSubsidy, []subsidy.QuarantinedAccount) {
+	if !p.enabled() && len(weights) == 0 {
+		return page, nil
+	}
+
+	normalized := make([]subgraph.AccountSubsidy, 0, len(page))
+	var skipped []subsidy.QuarantinedAccount
+	for _, accountSubsidy := range page {
+		share := big.NewRat(1, 1)
+		if accountSubsidy.CollectionType == subgraph.CollectionTypeERC1155 {
+			if units, ok := p.erc1155Units[utils.NormalizeAddress(accountSubsidy.Collection)]; ok {
+				share.SetFrac(big.NewInt(1), units)
+			}
+		}
+		if multiplier := weights.of(accountSubsidy.Collection); multiplier != nil {
+			share.Mul(share, multiplier)
+		}
+
+		wrapper := utils.NormalizeAddress(accountSubsidy.Account.ID)
+		if !p.isWrapper[wrapper] {
+			normalized = append(normalized, scaleSubsidy(accountSubsidy, share))
+			continue
+		}
+
+		held := positions[wrapper][accountSubsidy.CollectionParticipation]
+		total := big.NewInt(0)
+		balances := make([]*big.Int, len(held))
+		for i, position := range held {
+			if balance, ok := new(big.Int).SetString(position.Balance, 10); ok && balance.Sign() > 0 {
+				balances[i] = balance
+				total.Add(total, balance)
+			}
+		}
+		if total.Sign() == 0 {
+			skipped = append(skipped, quarantined(accountSubsidy,
+				fmt.Errorf("wrapper %s has no positions in %s to delegate to", wrapper, accountSubsidy.CollectionParticipation)))
+			continue
+		}
+
+		for i, position := range held {
+			if balances[i] == nil {
+				continue
+			}
+			owned := new(big.Rat).SetFrac(balances[i], total)
+			split := scaleSubsidy(accountSubsidy, owned.Mul(owned, share))
+			split.ID = accountSubsidy.ID + "/" + utils.NormalizeAddress(position.Owner.ID)
+			split.Account = position.Owner
+			split.WrappedBy = wrapper
+			normalized = append(normalized, split)
+		}
+	}
+	return normalized, skipped
+}
+
+// scaleSubsidy returns the subsidy with the amounts it is valued from multiplied by share, rounded down,
+// recording the fraction of a wei totalRewardsEarned lost. Fields that do not parse are left for
+// checkSubsidy and valueSubsidy to reject.
+func scaleSubsidy(accountSubsidy subgraph.AccountSubsidy, share *big.Rat) subgraph.AccountSubsidy {
+	if share.Cmp(big.NewRat(1, 1)) == 0 {
+		return accountSubsidy
+	}
+
+	scale := func(value string) (string, *big.Int) {
+		n, ok := new(big.Int).SetString(value, 10)
+		if !ok {
+			return value, new(big.Int)
+		}
+		n.Mul(n, share.Num())
+		remainder := new(big.Int)
+		n.DivMod(n, share.Denom(), remainder)
+		return n.String(), remainder
+	}
+	accountSubsidy.SecondsAccumulated, _ = scale(accountSubsidy.SecondsAccumulated)
+	accountSubsidy.LastEffectiveValue, _ = scale(accountSubsidy.LastEffectiveValue)
+	var remainder *big.Int
+	accountSubsidy.TotalRewardsEarned, remainder = scale(accountSubsidy.TotalRewardsEarned)
+	accountSubsidy.Share = share.RatString()
+	if remainder.Sign() > 0 {
+		accountSubsidy.RoundedOff = new(big.Rat).SetFrac(remainder, share.Denom()).RatString()
+	}
+	return accountSubsidy
+}
+
+// explainedSubsidies applies the holdings policy and the epoch's collection weights to the subsidies read for
+// explained accounts and adds each account's shares of what wrappers accrued, so explanations value what the
+// distribution valued
+func (d *LazyDistributor) explainedSubsidies(
+	ctx context.Context,
+	vaultId string,
+	blockNumber int64,
+	accounts []string,
+	subsidies map[string][]subgraph.AccountSubsidy,
+	weights collectionWeights,
+) (map[string][]subgraph.AccountSubsidy, error) {
+	if !d.holdings.enabled() && len(weights) == 0 {
+		return subsidies, nil
+	}
+
+	var positions wrappedPositions
+	var wrapperSubsidies map[string][]subgraph.AccountSubsidy
+	if len(d.holdings.wrappers) > 0 {
+		var err error
+		if positions, err = d.loadWrappedPositions(ctx, vaultId, &blockNumber); err != nil {
+			return nil, err
+		}
+		wrapperSubsidies, err = d.subgraphClient.QueryAccountSubsidiesForAccountsAtBlock(ctx, vaultId, d.holdings.wrappers, blockNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get wrapper subsidies at block %d: %w", blockNumber, err)
+		}
+	}
+
+	explained := make(map[string][]subgraph.AccountSubsidy, len(accounts))
+	for _, account := range accounts {
+		account = utils.NormalizeAddress(account)
+		if _, ok := explained[account]; ok {
+			continue
+		}
+		normalized, _ := d.holdings.normalize(subsidies[account], positions, weights)
+		own := make([]subgraph.AccountSubsidy, 0, len(normalized))
+		for _, accountSubsidy := range normalized {
+			// a wrapper's own subsidies went to its owners
+			if accountSubsidy.WrappedBy == "" {
+				own = append(own, accountSubsidy)
+			}
+		}
+		explained[account] = own
+	}
+	for _, wrapper := range d.holdings.wrappers {
+		shares, _ := d.holdings.normalize(wrapperSubsidies[wrapper], positions, weights)
+		for _, share := range shares {
+			owner := utils.NormalizeAddress(share.Account.ID)
+			if owned, ok := explained[owner]; ok {
+				explained[owner] = append(owned, share)
+			}
+		}
+	}
+	return explained, nil
+}
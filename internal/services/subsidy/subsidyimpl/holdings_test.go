@@ -0,0 +1,186 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const (
+	holdingsTestWrapper    = "0x5555555555555555555555555555555555555555"
+	holdingsTestCollection = "0x6666666666666666666666666666666666666666"
+	holdingsTestOwnerA     = "0x1111111111111111111111111111111111111111"
+	holdingsTestOwnerB     = "0x2222222222222222222222222222222222222222"
+)
+
+func newHoldingsTestPolicy(t *testing.T) holdingsPolicy {
+	cfg := &config.Config{}
+	cfg.Holdings.ERC1155Units = []string{holdingsTestCollection + ":10"}
+	cfg.Holdings.Wrappers = []string{"0x5555555555555555555555555555555555555555", holdingsTestWrapper}
+	policy := newHoldingsPolicy(cfg)
+	require.Len(t, policy.wrappers, 1, "wrappers are deduplicated")
+	return policy
+}
+
+// holdingsTestPositions has the wrapper holding 1 token for owner A and 3 for owner B in collection-a
+func holdingsTestPositions() wrappedPositions {
+	return wrappedPositions{holdingsTestWrapper: {"0xcollection-a": {
+		{Owner: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xcollection-a", Balance: "1"},
+		{Owner: subgraph.Account{ID: holdingsTestOwnerB, TotalBorrowVolume: "500"}, CollectionParticipation: "0xcollection-a", Balance: "3"},
+	}}}
+}
+
+func holdingsTestSubsidies() []subgraph.AccountSubsidy {
+	return []subgraph.AccountSubsidy{
+		{
+			ID:                      "erc721",
+			Account:                 subgraph.Account{ID: holdingsTestOwnerA},
+			CollectionParticipation: "0xcollection-b",
+			TotalRewardsEarned:      "700",
+		},
+		{
+			ID:                      "erc1155",
+			Account:                 subgraph.Account{ID: holdingsTestOwnerA},
+			CollectionParticipation: "0xcollection-c",
+			Collection:              holdingsTestCollection,
+			CollectionType:          subgraph.CollectionTypeERC1155,
+			SecondsAccumulated:      "2000",
+			LastEffectiveValue:      "30",
+			TotalRewardsEarned:      "5000",
+		},
+		{
+			ID:                      "wrapped",
+			Account:                 subgraph.Account{ID: holdingsTestWrapper},
+			CollectionParticipation: "0xcollection-a",
+			TotalRewardsEarned:      "1001",
+		},
+	}
+}
+
+func TestHoldingsPolicy_Normalize(t *testing.T) {
+	policy := newHoldingsTestPolicy(t)
+	subsidies := append(holdingsTestSubsidies(), subgraph.AccountSubsidy{
+		ID:                      "unwrapped",
+		Account:                 subgraph.Account{ID: holdingsTestWrapper},
+		CollectionParticipation: "0xcollection-b",
+		TotalRewardsEarned:      "10",
+	})
+
+	normalized, skipped := policy.normalize(subsidies, holdingsTestPositions(), nil)
+	require.Len(t, normalized, 4)
+
+	assert.Equal(t, subsidies[0], normalized[0], "ERC-721 holdings are valued as indexed")
+
+	erc1155 := normalized[1]
+	assert.Equal(t, "200", erc1155.SecondsAccumulated, "10 units count as one NFT")
+	assert.Equal(t, "3", erc1155.LastEffectiveValue)
+	assert.Equal(t, "500", erc1155.TotalRewardsEarned)
+	assert.Equal(t, "1/10", erc1155.Share)
+
+	ownerA, ownerB := normalized[2], normalized[3]
+	assert.Equal(t, holdingsTestOwnerA, ownerA.Account.ID)
+	assert.Equal(t, "250", ownerA.TotalRewardsEarned, "shares are rounded down")
+	assert.Equal(t, "1/4", ownerA.Share)
+	assert.Equal(t, holdingsTestWrapper, ownerA.WrappedBy)
+	assert.Equal(t, "wrapped/"+holdingsTestOwnerA, ownerA.ID)
+	assert.Equal(t, "500", ownerB.Account.TotalBorrowVolume, "owners keep their own debt for caps")
+	assert.Equal(t, "750", ownerB.TotalRewardsEarned)
+
+	require.Len(t, skipped, 1, "a wrapper without positions is not paid")
+	assert.Equal(t, "unwrapped", skipped[0].SubsidyID)
+
+	var disabled holdingsPolicy
+	same, none := disabled.normalize(subsidies, nil, nil)
+	assert.Equal(t, subsidies, same)
+	assert.Empty(t, none)
+}
+
+func TestLazyDistributor_DelegatesWrappedHoldings(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.holdings = newHoldingsTestPolicy(t)
+	subsidies := holdingsTestSubsidies()
+	positions := holdingsTestPositions()[holdingsTestWrapper]["0xcollection-a"]
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			return fn(subsidies)
+		},
+		QueryWrappedPositionsFunc: func(ctx context.Context, vaultAddress, wrapperAddress string) ([]subgraph.WrappedPosition, error) {
+			assert.Equal(t, holdingsTestWrapper, wrapperAddress)
+			return positions, nil
+		},
+		QueryWrappedPositionsAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress, wrapperAddress string,
+			blockNumber int64,
+		) ([]subgraph.WrappedPosition, error) {
+			return positions, nil
+		},
+		QueryAccountSubsidiesForAccountsAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accounts []string,
+			blockNumber int64,
+		) (map[string][]subgraph.AccountSubsidy, error) {
+			result := make(map[string][]subgraph.AccountSubsidy)
+			for _, s := range subsidies {
+				for _, account := range accounts {
+					if s.Account.ID == account {
+						result[account] = append(result[account], s)
+					}
+				}
+			}
+			return result, nil
+		},
+		QueryMerkleDistributionForEpochFunc: func(ctx context.Context, epochNumber, vaultAddress string) (*subgraph.MerkleDistribution, error) {
+			return nil, errors.New("merkle distribution not found")
+		},
+	}
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	// 700 + 500 of ERC-1155 + 250 and 750 wrapped
+	assert.Equal(t, "2200", result.TotalSubsidies.String())
+
+	snapshot, err := distributor.epochSnapshot(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	for _, entry := range snapshot.Entries {
+		assert.NotEqual(t, holdingsTestWrapper, entry.Address, "wrappers are not in the tree")
+	}
+
+	explanations, err := distributor.ExplainBatch(ctx, planTestVault, big.NewInt(5), []string{holdingsTestOwnerA, holdingsTestOwnerB})
+	require.NoError(t, err)
+	require.Len(t, explanations.Explanations, 2)
+	for _, explanation := range explanations.Explanations {
+		assert.True(t, explanation.Matches, "%s computed %s, distributed %s",
+			explanation.UserAddress, explanation.ComputedAmount, explanation.DistributedAmount)
+	}
+	ownerA := explanations.Explanations[0]
+	assert.Equal(t, "1450", ownerA.DistributedAmount)
+	require.Len(t, ownerA.Collections, 3)
+	assert.Equal(t, subgraph.CollectionTypeERC1155, ownerA.Collections[1].CollectionType)
+	assert.Equal(t, "1/10", ownerA.Collections[1].Share)
+	assert.Equal(t, holdingsTestWrapper, ownerA.Collections[2].WrappedBy)
+	assert.Equal(t, subsidy.AllocationSourceSubgraph, ownerA.Collections[2].Source)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches, "replays delegate the same way")
+}
@@ -0,0 +1,67 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+// lagGuard is how far the subgraph may trail the chain head for a distribution to be computed from what it indexed
+type lagGuard struct {
+	maxBlocks uint64       // 0 disables the guard
+	alerted   *atomic.Bool // subgraph.lagging was sent and the subgraph has not caught up since
+}
+
+func newLagGuard(cfg *config.Config) lagGuard {
+	return lagGuard{maxBlocks: cfg.Subgraph.MaxLag, alerted: new(atomic.Bool)}
+}
+
+// checkSubgraphLag compares the block the subgraph indexed with the chain head and returns ErrSubgraphLagging when
+// it trails by more than the guard allows, so no allocations are computed from stale balances. subgraph.lagging is
+// sent when the subgraph starts lagging, not on every distribution delayed until it catches up.
+func (d *LazyDistributor) checkSubgraphLag(ctx context.Context, vaultId string) error {
+	if d.lag.maxBlocks == 0 {
+		return nil
+	}
+	logger := logging.FromContext(ctx, d.logger)
+
+	indexed, err := d.subgraphClient.IndexedBlock(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the subgraph's indexed block: %w", err)
+	}
+	head, err := d.blockchainClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get latest block: %w", err)
+	}
+	var lag uint64
+	if indexed.Number >= 0 && uint64(indexed.Number) < head.Number {
+		lag = head.Number - uint64(indexed.Number)
+	}
+
+	if lag <= d.lag.maxBlocks {
+		if d.lag.alerted.CompareAndSwap(true, false) {
+			logger.Logf("INFO subgraph caught up, indexed block %d is %d blocks behind the chain head %d", indexed.Number, lag, head.Number)
+		}
+		return nil
+	}
+
+	logger.Logf("WARN subgraph indexed block %d is %d blocks behind the chain head %d, more than %d (indexing errors: %t)",
+		indexed.Number, lag, head.Number, d.lag.maxBlocks, indexed.HasIndexingErrors)
+	if d.lag.alerted.CompareAndSwap(false, true) {
+		d.notifier.Notify(ctx, webhook.EventSubgraphLagging, map[string]interface{}{
+			"vaultAddress":      vaultId,
+			"indexedBlock":      indexed.Number,
+			"chainHead":         head.Number,
+			"lag":               lag,
+			"maxLag":            d.lag.maxBlocks,
+			"hasIndexingErrors": indexed.HasIndexingErrors,
+		})
+	}
+	return fmt.Errorf("%w: indexed block %d is %d blocks behind the chain head %d, at most %d allowed",
+		subsidy.ErrSubgraphLagging, indexed.Number, lag, head.Number, d.lag.maxBlocks)
+}
@@ -0,0 +1,59 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func TestLazyDistributor_SubgraphLag(t *testing.T) {
+	indexed := int64(900)
+	notifier := &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}}
+	distributor := &LazyDistributor{
+		blockchainClient: &blockchain.BlockchainClientMock{
+			GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+				return &blockchain.BlockRef{Number: 1000, Hash: "0x3e8"}, nil
+			},
+		},
+		subgraphClient: &subgraph.SubgraphClientMock{
+			IndexedBlockFunc: func(ctx context.Context) (*subgraph.IndexedBlock, error) {
+				return &subgraph.IndexedBlock{Number: indexed}, nil
+			},
+		},
+		notifier: notifier,
+		logger:   lgr.NoOp,
+		lag:      lagGuard{maxBlocks: 50, alerted: new(atomic.Bool)},
+	}
+	ctx := context.Background()
+
+	_, err := distributor.takeSnapshot(ctx, planTestVault, big.NewInt(5), nil)
+	require.ErrorIs(t, err, subsidy.ErrSubgraphLagging, "nothing is computed from a subgraph 100 blocks behind")
+	assert.Contains(t, err.Error(), "indexed block 900 is 100 blocks behind the chain head 1000")
+	require.ErrorIs(t, distributor.checkSubgraphLag(ctx, planTestVault), subsidy.ErrSubgraphLagging)
+
+	calls := notifier.NotifyCalls()
+	require.Len(t, calls, 1, "the alert is sent when the subgraph starts lagging, not for every delayed distribution")
+	assert.Equal(t, webhook.EventSubgraphLagging, calls[0].EventType)
+	assert.Equal(t, uint64(100), calls[0].Data["lag"])
+	assert.Equal(t, int64(900), calls[0].Data["indexedBlock"])
+
+	indexed = 950
+	require.NoError(t, distributor.checkSubgraphLag(ctx, planTestVault), "a lag within the threshold is allowed")
+
+	indexed = 800
+	require.ErrorIs(t, distributor.checkSubgraphLag(ctx, planTestVault), subsidy.ErrSubgraphLagging)
+	assert.Len(t, notifier.NotifyCalls(), 2, "lagging again after catching up alerts again")
+
+	distributor.lag = lagGuard{}
+	assert.NoError(t, distributor.checkSubgraphLag(ctx, planTestVault), "without a threshold the subgraph is not checked")
+}
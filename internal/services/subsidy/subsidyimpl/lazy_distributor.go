@@ -2,209 +2,880 @@ package subsidyimpl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/archive"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/ipfs"
 	"github.com/andrey/epoch-server/internal/services/merkle"
 	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type LazyDistributor struct {
-	blockchainClient blockchain.BlockchainClient
-	merkleService    merkle.Service
-	subgraphClient   subgraph.SubgraphClient
-	logger           lgr.L
+	blockchainClient  blockchain.BlockchainClient
+	merkleService     merkle.Service
+	subgraphClient    subgraph.SubgraphClient
+	notifier          webhook.Notifier
+	events            events.Publisher
+	recorder          audit.Recorder   // nil disables audit entries for collection weight, blocklist and fingerprint changes
+	archive           archive.Service  // nil keeps distributions in the serving store only
+	assets            assets.Service   // nil logs amounts in wei only
+	ipfs              ipfs.Service     // nil publishes no eligibility snapshots
+	features          features.Service // nil keeps every feature on
+	logger            lgr.L
+	confirmationDepth uint64 // blocks a snapshot block must be buried under before its root is submitted
+	maxResnapshots    int
+	blockPollInterval time.Duration
+	snapshotStrategy  string
+	snapshotOffset    uint64
+
+	store        *Store
+	approval     approvalPolicy
+	caps         capPolicy
+	rounding     roundingPolicy
+	holdings     holdingsPolicy
+	eligibility  eligibilityPolicy
+	blocklist    blocklistPolicy
+	finalization finalizationPolicy
+	adjustments  adjustmentPolicy
+	minimums     minimumPolicy
+	expiry       expiryPolicy
+	lag          lagGuard
+	// dustThreshold is the amount below which distribution statistics count an allocation as dust
+	dustThreshold *big.Int
+	// fingerprintParams is the configuration every distribution is fingerprinted with
+	fingerprintParams map[string]string
+	approvalMu        sync.Mutex // serializes approval decisions so a root is never pushed twice
+}
+
+// distributionSnapshot is a merkle tree built from subgraph state observed at block
+type distributionSnapshot struct {
+	block          *blockchain.BlockRef
+	strategy       string // how block was chosen
+	entries        []merkle.Entry
+	totalSubsidies *big.Int
+	allocated      *big.Int // wei the subsidies were valued at, before any account was left out or adjusted
+	merkleRoot     [32]byte
+	valuedAt       int64                        // unix time accrual was valued at
+	carriedIn      *big.Int                     // wei the previous distribution left for this one, nil when no caps are configured
+	carriedOut     *big.Int                     // wei caps left for the next distribution, nil when no caps are configured
+	capRecords     []capRecord                  // allocations caps or blocked accounts' redistribution changed
+	rounding       roundingRecord               // what rounding did with the dust
+	dustCarriedOut *big.Rat                     // dust rounding left for the next distribution, nil unless it carries forward
+	quarantined    []subsidy.QuarantinedAccount // subsidies skipped for malformed data
+	weights        []subsidy.CollectionWeight   // collection weights the epoch was valued with
+	blocked        blockedRecord                // blocked accounts left out of the tree
+	adjustments    []subsidy.AppliedAdjustment  // approved manual adjustments applied before the tree was built
+	deferred       deferredRecord               // accounts left out below the vault's minimum allocation
+	expiry         *expiryRecord                // what expired unclaimed, set for epoch distributions when claims expire
+	debts          *debtRecord                  // what accounts owed the lending market, set when caps hold them to it
+	accounts       map[string]bool              // normalized accounts the subgraph reported subsidies of
+	positions      positions                    // what the accounts held, borrowed and claimed, for their history
+	timeWeighted   bool                         // whether accrual was shared by holding time
+	fingerprint    *subsidy.Fingerprint         // inputs the tree was computed from, set for epoch distributions
+	allocations    []*allocation                // valued subsidies as distributed, for the archive
+	diff           *subsidy.AllocationDiff      // changes against the vault's previous distribution, set for epoch distributions
+	supersedes     string                       // root of the epoch's tree this one replaces, set for root replacements
+}
+
+// carry is what a distribution is carried in by the one before it
+type carry struct {
+	caps *big.Int // nil when no caps are configured
+	dust *big.Rat // zero unless rounding carries forward
 }
 
 func NewLazyDistributor(
 	blockchainClient blockchain.BlockchainClient,
 	merkleService merkle.Service,
 	subgraphClient subgraph.SubgraphClient,
+	notifier webhook.Notifier,
+	publisher events.Publisher,
+	recorder audit.Recorder,
+	db storage.DB,
 	logger lgr.L,
+	cfg *config.Config,
 ) *LazyDistributor {
 	return &LazyDistributor{
-		blockchainClient: blockchainClient,
-		merkleService:    merkleService,
-		subgraphClient:   subgraphClient,
-		logger:           logger,
+		blockchainClient:  blockchainClient,
+		merkleService:     merkleService,
+		subgraphClient:    subgraphClient,
+		notifier:          notifier,
+		events:            publisher,
+		recorder:          recorder,
+		logger:            logger,
+		confirmationDepth: snapshotConfirmations(cfg),
+		maxResnapshots:    cfg.Ethereum.MaxResnapshots,
+		blockPollInterval: cfg.Ethereum.BlockPollInterval,
+		snapshotStrategy:  cfg.Ethereum.SnapshotStrategy,
+		snapshotOffset:    cfg.Ethereum.SnapshotOffset,
+		store:             NewStore(db, logger),
+		approval:          newApprovalPolicy(cfg),
+		caps:              newCapPolicy(cfg),
+		rounding:          newRoundingPolicy(cfg),
+		holdings:          newHoldingsPolicy(cfg),
+		eligibility:       newEligibilityPolicy(cfg),
+		blocklist:         newBlocklistPolicy(cfg),
+		finalization:      newFinalizationPolicy(cfg),
+		adjustments:       newAdjustmentPolicy(cfg),
+		minimums:          newMinimumPolicy(cfg),
+		expiry:            newExpiryPolicy(cfg),
+		lag:               newLagGuard(cfg),
+		dustThreshold:     newDustThreshold(cfg),
+		fingerprintParams: newFingerprintParams(cfg),
 	}
 }
 
+// SetAssets sets where vault assets are resolved from, so logged amounts include whole tokens. It is called once
+// at startup.
+func (d *LazyDistributor) SetAssets(service assets.Service) {
+	d.assets = service
+}
+
+// SetFeatures sets the feature flags that switch risky behaviors off at runtime. It is called once at startup.
+func (d *LazyDistributor) SetFeatures(service features.Service) {
+	d.features = service
+}
+
+// featureEnabled reports whether flag is on, every flag is when no feature flags are set
+func (d *LazyDistributor) featureEnabled(ctx context.Context, flag string) bool {
+	return d.features == nil || d.features.Enabled(ctx, flag)
+}
+
+// timeWeighted reports whether accrual is shared by holding time: configured and not switched off
+func (d *LazyDistributor) timeWeighted(ctx context.Context) bool {
+	return d.eligibility.timeWeighted() && d.featureEnabled(ctx, features.FlagTimeWeightedEligibility)
+}
+
 func (d *LazyDistributor) Run(ctx context.Context, vaultId string) (*subsidy.DistributionResult, error) {
 	return d.RunWithEpoch(ctx, vaultId, nil)
 }
 
-func (d *LazyDistributor) RunWithEpoch(ctx context.Context, vaultId string, epochNumber *big.Int) (*subsidy.DistributionResult, error) {
+func (d *LazyDistributor) RunWithEpoch(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+) (_ *subsidy.DistributionResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.RunWithEpoch", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if vaultId == "" {
-		return nil, fmt.Errorf("vaultId cannot be empty")
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+
+	fields := logging.Fields{Vault: vaultId}
+	if epochNumber != nil {
+		fields.EpochID = epochNumber.String()
+	}
+	ctx = logging.WithFields(ctx, fields)
+	logger := logging.FromContext(ctx, d.logger)
+	logger.Logf("INFO starting lazy distributor for vault %s", vaultId)
+
+	// a root awaiting approval is what the approver reviews, so it is not recomputed underneath them
+	if d.approval.enabled {
+		pending, err := d.pendingFor(ctx, vaultId, epochNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check staged distributions: %w", err)
+		}
+		if pending != nil {
+			logger.Logf("INFO distribution %s for vault %s is still awaiting approval", pending.ID, vaultId)
+			return stagedResult(pending), nil
+		}
+	}
+
+	// a distribution whose submission failed is submitted again rather than recomputed from newer state
+	if epochNumber != nil {
+		resumed, err := d.resume(ctx, vaultId, epochNumber)
+		if err != nil {
+			return nil, err
+		}
+		if resumed != nil {
+			return resumed, nil
+		}
+	}
+
+	var snapshot *distributionSnapshot
+	for attempt := 1; ; attempt++ {
+		snapshot, err = d.takeSnapshot(ctx, vaultId, epochNumber, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(snapshot.entries) == 0 {
+			d.saveQuarantined(ctx, vaultId, epochNumber, snapshot)
+			return &subsidy.DistributionResult{
+				TotalSubsidies:      big.NewInt(0),
+				AccountsProcessed:   0,
+				MerkleRoot:          "",
+				AccountsQuarantined: len(snapshot.quarantined),
+			}, nil
+		}
+
+		err = d.waitForSnapshotFinality(ctx, snapshot.block)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, subsidy.ErrSnapshotReorged) || attempt > d.maxResnapshots {
+			logger.Logf("ERROR snapshot for vault %s is not final: %v", vaultId, err)
+			return nil, notFinal(vaultId, err)
+		}
+
+		logger.Logf("WARN %v, re-snapshotting vault %s (attempt %d of %d)", err, vaultId, attempt, d.maxResnapshots)
+		span.AddEvent("reorg detected", trace.WithAttributes(attribute.Int64("block.number", int64(snapshot.block.Number))))
+	}
+
+	d.publish(ctx, events.EpochSnapshotted, vaultId, epochNumber, map[string]interface{}{
+		"blockNumber":         snapshot.block.Number,
+		"blockHash":           snapshot.block.Hash,
+		"blockStrategy":       snapshot.strategy,
+		"accountsProcessed":   len(snapshot.entries),
+		"accountsQuarantined": len(snapshot.quarantined),
+	})
+
+	logger.Logf("INFO generated merkle root for vault %s: %x", vaultId, snapshot.merkleRoot)
+	logger.Logf("INFO total subsidies for vault %s: %s", vaultId, assets.Describe(ctx, d.assets, vaultId, snapshot.totalSubsidies))
+
+	// the contract holds claims against the total, so a tree that does not add up to it is never pushed
+	if err := checkLeafTotal(snapshot.entries, snapshot.expectedTotal()); err != nil {
+		logger.Logf("ERROR distribution for vault %s does not add up: %v", vaultId, err)
+		return nil, err
+	}
+
+	// a committed distribution is only replaced by one computed from the same inputs, unless an admin overrode it
+	if epochNumber != nil {
+		snapshot.fingerprint = d.fingerprint(vaultId, epochNumber, snapshot)
+		if err := d.checkFingerprint(ctx, vaultId, epochNumber, snapshot.fingerprint); err != nil {
+			return nil, err
+		}
+	}
+
+	// the checks see the root before it is pushed or staged, an enforced failure leaves the epoch as it was
+	var checks []subsidy.FinalizationCheck
+	if epochNumber != nil {
+		if checks, err = d.checkFinalization(ctx, vaultId, epochNumber, snapshot); err != nil {
+			return nil, err
+		}
+	}
+
+	if epochNumber != nil {
+		// proofs are served from the saved tree, so a root is never pushed or staged without it
+		if err := d.saveSnapshot(ctx, vaultId, snapshot, epochNumber); err != nil {
+			logger.Logf("ERROR failed to save merkle snapshot for vault %s epoch %s: %v", vaultId, epochNumber, err)
+			return nil, fmt.Errorf("failed to save merkle snapshot: %w", err)
+		}
+		// the diff is for reviewers, a distribution is not held back when it cannot be computed
+		if snapshot.diff, err = d.diffWithPrevious(ctx, vaultId, epochNumber, snapshot.entries, subsidy.DefaultDiffTop); err != nil {
+			logger.Logf("WARN failed to diff vault %s epoch %s against the previous distribution: %v", vaultId, epochNumber, err)
+		} else {
+			logger.Logf("INFO vault %s epoch %s against epoch %q: %d new and %d dropped accounts, total grew by %s wei",
+				vaultId, epochNumber, snapshot.diff.PreviousEpochNumber, snapshot.diff.NewAccounts,
+				snapshot.diff.DroppedAccounts, snapshot.diff.TotalGrowth)
+		}
+		d.recordStats(ctx, vaultId, epochNumber, snapshot)
+	}
+	d.saveQuarantined(ctx, vaultId, epochNumber, snapshot)
+
+	computed := map[string]interface{}{
+		"merkleRoot":        fmt.Sprintf("0x%x", snapshot.merkleRoot),
+		"totalSubsidies":    snapshot.totalSubsidies.String(),
+		"accountsProcessed": len(snapshot.entries),
+		"blockNumber":       snapshot.block.Number,
+	}
+	if snapshot.fingerprint != nil {
+		computed["fingerprint"] = snapshot.fingerprint.Hash
+	}
+	d.publish(ctx, events.DistributionComputed, vaultId, epochNumber, computed)
+
+	if reason := d.approvalReason(ctx, snapshot.totalSubsidies, len(snapshot.entries)); reason != "" {
+		staged, err := d.stage(ctx, vaultId, epochNumber, snapshot, reason)
+		if err != nil {
+			return nil, err
+		}
+		result := stagedResult(staged)
+		result.AccountsQuarantined = len(snapshot.quarantined)
+		result.AccountsDeferred = len(snapshot.deferred.Accounts)
+		result.Checks = checks
+		return result, nil
+	}
+
+	var pending submission
+	if epochNumber != nil {
+		pending = newSubmission(vaultId, epochNumber, snapshot)
+		d.keepSubmission(ctx, vaultId, epochNumber, pending)
+	}
+	if err := d.updateMerkleRoot(ctx, vaultId, snapshot.merkleRoot, snapshot.totalSubsidies); err != nil {
+		logger.Logf("ERROR failed to update merkle root on blockchain: %v", err)
+		return nil, fmt.Errorf("failed to update merkle root on blockchain: %w", err)
+	}
+	if epochNumber != nil {
+		d.rootPushed(ctx, vaultId, epochNumber, &pending)
+	} else {
+		if snapshot.carriedOut != nil {
+			d.saveCarryForward(ctx, vaultId, snapshot.carriedOut)
+		}
+		if snapshot.dustCarriedOut != nil {
+			d.saveDustCarry(ctx, vaultId, snapshot.dustCarriedOut)
+		}
 	}
 
-	d.logger.Logf("INFO starting lazy distributor for vault %s", vaultId)
+	d.publish(ctx, events.RootSubmitted, vaultId, epochNumber, map[string]interface{}{
+		"merkleRoot":        fmt.Sprintf("0x%x", snapshot.merkleRoot),
+		"totalSubsidies":    snapshot.totalSubsidies.String(),
+		"accountsProcessed": len(snapshot.entries),
+		"blockNumber":       snapshot.block.Number,
+	})
+
+	logger.Logf("INFO successfully completed lazy distributor for vault %s", vaultId)
+	return &subsidy.DistributionResult{
+		TotalSubsidies:      snapshot.totalSubsidies,
+		AccountsProcessed:   len(snapshot.entries),
+		MerkleRoot:          fmt.Sprintf("%x", snapshot.merkleRoot),
+		AccountsQuarantined: len(snapshot.quarantined),
+		AccountsDeferred:    len(snapshot.deferred.Accounts),
+		Checks:              checks,
+	}, nil
+}
+
+// publish publishes an event of the distribution of vaultId for epochNumber, nil outside epochs
+func (d *LazyDistributor) publish(
+	ctx context.Context,
+	eventType events.Type,
+	vaultId string,
+	epochNumber *big.Int,
+	data map[string]interface{},
+) {
+	event := events.Event{Type: eventType, Vault: vaultId, Data: data}
+	if epochNumber != nil {
+		event.EpochID = epochNumber.String()
+	}
+	d.events.Publish(ctx, event)
+}
 
-	d.logger.Logf("DEBUG querying account subsidies for vault %s", vaultId)
-	subsidies, err := d.subgraphClient.QueryAccountSubsidiesForVault(ctx, vaultId)
+// takeSnapshot chooses the snapshot block and builds the merkle tree from subgraph state at it, carried in
+// carriedIn or, when it is nil, what the vault's previous distribution left for the next one.
+// The chain head is recorded before current state is read: a reorg orphaning any block the subgraph
+// indexed also orphans it.
+func (d *LazyDistributor) takeSnapshot(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	carriedIn *carry,
+) (*distributionSnapshot, error) {
+	if err := d.checkSubgraphLag(ctx, vaultId); err != nil {
+		return nil, err
+	}
+
+	block, strategy, err := d.snapshotBlock(ctx, vaultId, epochNumber)
+	if err != nil {
+		d.logger.Logf("ERROR failed to get snapshot block: %v", err)
+		return nil, fmt.Errorf("failed to get snapshot block: %w", err)
+	}
+	d.logger.Logf("DEBUG taking snapshot for vault %s at %s block %d (%s)", vaultId, strategy, block.Number, block.Hash)
+
+	snapshot := &distributionSnapshot{block: block, strategy: strategy, accounts: make(map[string]bool), positions: make(positions)}
+
+	// subsidies are valued page by page so only their allocations are held in memory,
+	// and all of them are valued at the same timestamp
+	var allocations []*allocation
+	subsidiesSeen := 0
+	valuedAt := time.Now().Unix()
+
+	// distribution must be built from current balances, never from cached query results
+	stream := func(fn func(page []subgraph.AccountSubsidy) error) error {
+		return d.subgraphClient.StreamAccountSubsidiesForVault(subgraph.WithFreshData(ctx), vaultId, fn)
+	}
+	var atBlock *int64
+	if strategy != subsidy.SnapshotBlockLatest {
+		// a past block is read as the subgraph indexed it and valued when it was produced,
+		// so running the epoch again builds the same tree
+		valuedAt = int64(block.Timestamp)
+		stream = func(fn func(page []subgraph.AccountSubsidy) error) error {
+			return d.subgraphClient.StreamAccountSubsidiesForVaultAtBlock(ctx, vaultId, int64(block.Number), fn)
+		}
+		number := int64(block.Number)
+		atBlock = &number
+	}
+
+	var positions wrappedPositions
+	if len(d.holdings.wrappers) > 0 {
+		if positions, err = d.loadWrappedPositions(ctx, vaultId, atBlock); err != nil {
+			d.logger.Logf("ERROR failed to get wrapped positions for vault %s: %v", vaultId, err)
+			return nil, fmt.Errorf("failed to get wrapped positions: %w", err)
+		}
+	}
+
+	// collection weights are set by operators for epoch ranges and scale the valuation of their collections
+	if snapshot.weights, err = d.activeWeights(ctx, vaultId, epochNumber); err != nil {
+		return nil, fmt.Errorf("failed to get collection weights: %w", err)
+	}
+	weights := newCollectionWeights(snapshot.weights)
+
+	blocked, err := d.blockedAccounts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist: %w", err)
+	}
+	adjustments, err := d.approvedAdjustments(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get allocation adjustments: %w", err)
+	}
+	collections := make(map[string]string)
+
+	d.logger.Logf("DEBUG streaming account subsidies for vault %s", vaultId)
+	err = stream(
+		func(page []subgraph.AccountSubsidy) error {
+			for i, subsidy := range page {
+				d.logger.Logf(
+					"DEBUG subsidy[%d]: account=%s, secondsAccumulated=%s, lastEffectiveValue=%s, totalRewardsEarned=%s, updatedAt=%s",
+					subsidiesSeen+i,
+					subsidy.Account.ID,
+					subsidy.SecondsAccumulated,
+					subsidy.LastEffectiveValue,
+					subsidy.TotalRewardsEarned,
+					subsidy.UpdatedAtTimestamp,
+				)
+			}
+			subsidiesSeen += len(page)
+			for _, subsidy := range page {
+				snapshot.accounts[utils.NormalizeAddress(subsidy.Account.ID)] = true
+				snapshot.positions.add(subsidy, valuedAt)
+				collections[subsidy.CollectionParticipation] = subsidy.Collection
+			}
+
+			page, delegated := d.holdings.normalize(page, positions, weights)
+			snapshot.quarantined = append(snapshot.quarantined, delegated...)
+			valued, skipped := d.valueSubsidiesAt(page, valuedAt)
+			allocations = append(allocations, valued...)
+			snapshot.quarantined = append(snapshot.quarantined, skipped...)
+			return nil
+		})
 	if err != nil {
 		d.logger.Logf("ERROR failed to get account subsidies for vault %s: %v", vaultId, err)
 		return nil, fmt.Errorf("failed to get account subsidies: %w", err)
 	}
-	d.logger.Logf("DEBUG query completed successfully, returned %d subsidies", len(subsidies))
 
-	d.logger.Logf("DEBUG found %d potential subsidies for vault %s", len(subsidies), vaultId)
-	for i, subsidy := range subsidies {
-		d.logger.Logf(
-			"DEBUG subsidy[%d]: account=%s, secondsAccumulated=%s, lastEffectiveValue=%s, totalRewardsEarned=%s, updatedAt=%s",
-			i,
-			subsidy.Account.ID,
-			subsidy.SecondsAccumulated,
-			subsidy.LastEffectiveValue,
-			subsidy.TotalRewardsEarned,
-			subsidy.UpdatedAtTimestamp,
-		)
+	// holding times only weigh epochs, which have a window to weigh them over
+	if d.timeWeighted(ctx) && epochNumber != nil {
+		snapshot.timeWeighted = true
+		normalize := func(page []subgraph.AccountSubsidy) []subgraph.AccountSubsidy {
+			normalized, _ := d.holdings.normalize(page, positions, weights)
+			return normalized
+		}
+		allocations, err = d.weighByHoldingTime(ctx, vaultId, epochNumber, block.Number, valuedAt, allocations, collections, normalize)
+		if err != nil {
+			d.logger.Logf("ERROR failed to weigh vault %s holdings by holding time: %v", vaultId, err)
+			return nil, fmt.Errorf("failed to weigh holdings by holding time: %w", err)
+		}
 	}
 
-	if len(subsidies) == 0 {
-		d.logger.Logf("INFO no subsidies found for vault %s, skipping distribution", vaultId)
-		return &subsidy.DistributionResult{
-			TotalSubsidies:    big.NewInt(0),
-			AccountsProcessed: 0,
-			MerkleRoot:        "",
-		}, nil
+	snapshot.allocated = allocatedTotal(allocations)
+
+	// blocked accounts are left out before caps, so what is redistributed from them stays within the caps
+	allocations, snapshot.blocked = d.blocklist.exclude(allocations, blocked)
+	if len(snapshot.blocked.Accounts) > 0 {
+		d.logger.Logf("INFO left %d blocked accounts out of vault %s, withholding %s wei (%s, %s burned)",
+			len(snapshot.blocked.Accounts), vaultId, snapshot.blocked.Withheld, snapshot.blocked.Remainder, snapshot.blocked.Burned)
 	}
 
-	entries, totalSubsidies, err := d.convertSubsidiesToEntries(subsidies)
-	if err != nil {
-		d.logger.Logf("ERROR failed to convert subsidies to entries: %v", err)
-		return nil, fmt.Errorf("failed to convert subsidies to entries: %w", err)
+	if carriedIn == nil {
+		if carriedIn, err = d.vaultCarry(ctx, vaultId); err != nil {
+			return nil, err
+		}
+	}
+
+	// what accounts owe is read at the snapshot block for the accounts left in, so caps can hold them to it
+	if d.caps.outstandingDebt {
+		debts, err := d.outstandingDebts(ctx, vaultId, block.Number, allocations)
+		if err != nil {
+			d.logger.Logf("ERROR failed to read outstanding debts for vault %s: %v", vaultId, err)
+			return nil, err
+		}
+		debts.apply(allocations)
+		snapshot.debts = &debts
+	}
+
+	// caps see the whole vault, so they apply once every page is in
+	if d.caps.enabled() {
+		snapshot.carriedIn = carriedIn.caps
+		snapshot.carriedOut = d.caps.apply(allocations, carriedIn.caps)
+		snapshot.capRecords = capRecords(allocations)
+		d.logger.Logf("INFO caps changed %d allocations for vault %s, carrying %s in and %s forward",
+			len(snapshot.capRecords), vaultId, carriedIn.caps, snapshot.carriedOut)
+	} else if d.blocklist.redistribute {
+		// what blocked accounts' allocations gave the others is recorded the way caps record redistribution
+		snapshot.capRecords = capRecords(allocations)
+	}
+
+	// rounding settles the dust once caps are done moving amounts
+	rounded := d.rounding.apply(allocations, carriedIn.dust)
+	snapshot.rounding = rounded.record(d.rounding.mode, allocations)
+	if d.rounding.carryForward() {
+		snapshot.dustCarriedOut = rounded.carriedOut
+	}
+	d.logger.Logf("DEBUG rounding of vault %s left %s wei of dust, paid %s and carried %s forward",
+		vaultId, rounded.dust.FloatString(6), rounded.paid, rounded.carriedOut.FloatString(6))
+
+	// manual adjustments correct the allocations as they would otherwise be paid, so caps and rounding leave them be
+	if len(adjustments) > 0 {
+		allocations, snapshot.adjustments = applyAdjustments(allocations, adjustments)
+		for _, adjustment := range snapshot.adjustments {
+			d.logger.Logf("INFO applied adjustment %s to %s in vault %s: %s wei of %s approved",
+				adjustment.ID, adjustment.Account, vaultId, adjustment.Applied, adjustment.Amount)
+		}
+	}
+
+	// the minimum applies to what accounts would be paid, so it comes once every amount is final
+	if allocations, snapshot.deferred, err = d.deferBelowMinimum(ctx, vaultId, epochNumber, allocations); err != nil {
+		return nil, fmt.Errorf("failed to apply minimum allocation: %w", err)
+	}
+	if len(snapshot.deferred.Accounts) > 0 || len(snapshot.deferred.Released) > 0 {
+		d.logger.Logf("INFO left %d accounts below the %s wei minimum out of vault %s, carrying %s wei; %d reached it",
+			len(snapshot.deferred.Accounts), snapshot.deferred.Minimum, vaultId, snapshot.deferred.Deferred,
+			len(snapshot.deferred.Released))
+	}
+
+	// what expired unclaimed is withheld from the amounts as they would be paid, and not paid to anyone else
+	if d.expiry.enabled() && epochNumber != nil {
+		if snapshot.expiry, err = d.expireUnclaimed(ctx, vaultId, epochNumber, allocations, snapshot.positions); err != nil {
+			return nil, fmt.Errorf("failed to expire unclaimed amounts: %w", err)
+		}
+		if snapshot.expiry.Expired != "0" {
+			d.logger.Logf("INFO %s wei unclaimed for %d epochs expired in vault %s, withholding %s of the %s wei expired so far",
+				snapshot.expiry.Expired, d.expiry.epochs, vaultId, snapshot.expiry.Withheld, snapshot.expiry.Total)
+		}
+	}
+
+	entries, totalSubsidies := entriesFor(allocations)
+	d.logger.Logf("INFO processed %d subsidies for vault %s, generated %d valid entries", subsidiesSeen, vaultId, len(entries))
+
+	if subsidiesSeen == 0 {
+		d.logger.Logf("INFO no subsidies found for vault %s, skipping distribution", vaultId)
+		return snapshot, nil
 	}
 
 	if len(entries) == 0 {
 		d.logger.Logf("INFO no valid entries found for vault %s, skipping distribution", vaultId)
-		return &subsidy.DistributionResult{
-			TotalSubsidies:    big.NewInt(0),
-			AccountsProcessed: 0,
-			MerkleRoot:        "",
-		}, nil
+		return snapshot, nil
 	}
 
-	merkleRoot, err := d.generateMerkleRoot(entries)
+	merkleRoot, err := d.generateVaultMerkleRoot(ctx, vaultId, entries)
 	if err != nil {
 		d.logger.Logf("ERROR failed to generate merkle root: %v", err)
 		return nil, fmt.Errorf("failed to generate merkle root: %w", err)
 	}
 
-	d.logger.Logf("INFO generated merkle root for vault %s: %x", vaultId, merkleRoot)
-	d.logger.Logf("INFO total subsidies for vault %s: %s", vaultId, totalSubsidies.String())
+	snapshot.entries = entries
+	snapshot.allocations = allocations
+	snapshot.totalSubsidies = totalSubsidies
+	snapshot.merkleRoot = merkleRoot
+	snapshot.valuedAt = valuedAt
+	return snapshot, nil
+}
 
-	if epochNumber != nil {
-		if err := d.saveSnapshot(ctx, vaultId, entries, merkleRoot, epochNumber); err != nil {
-			d.logger.Logf("WARN failed to save merkle snapshot: %v", err)
+// vaultCarry returns what the vault's previous distribution left for the next one
+func (d *LazyDistributor) vaultCarry(ctx context.Context, vaultId string) (*carry, error) {
+	carried := &carry{dust: new(big.Rat)}
+	var err error
+	if d.caps.enabled() {
+		if carried.caps, err = d.store.GetCarryForward(ctx, vaultId); err != nil {
+			return nil, err
+		}
+	}
+	if d.rounding.carryForward() {
+		if carried.dust, err = d.store.GetDustCarry(ctx, vaultId); err != nil {
+			return nil, err
 		}
 	}
+	return carried, nil
+}
 
-	if err := d.updateMerkleRoot(ctx, vaultId, merkleRoot, totalSubsidies); err != nil {
-		d.logger.Logf("ERROR failed to update merkle root on blockchain: %v", err)
-		return nil, fmt.Errorf("failed to update merkle root on blockchain: %w", err)
+// waitForSnapshotFinality blocks until the snapshot block is buried under confirmationDepth blocks,
+// returning ErrSnapshotReorged as soon as the canonical chain no longer contains it
+func (d *LazyDistributor) waitForSnapshotFinality(ctx context.Context, block *blockchain.BlockRef) error {
+	if d.confirmationDepth == 0 {
+		return nil
 	}
 
-	d.logger.Logf("INFO successfully completed lazy distributor for vault %s", vaultId)
-	return &subsidy.DistributionResult{
-		TotalSubsidies:    totalSubsidies,
-		AccountsProcessed: len(entries),
-		MerkleRoot:        fmt.Sprintf("%x", merkleRoot),
-	}, nil
+	target := block.Number + d.confirmationDepth
+	ticker := time.NewTicker(d.blockPollInterval)
+	defer ticker.Stop()
+
+	for {
+		head, err := d.blockchainClient.GetBlockRef(ctx, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get latest block: %w", err)
+		}
+
+		canonical, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(block.Number))
+		if err != nil {
+			return fmt.Errorf("failed to get snapshot block %d: %w", block.Number, err)
+		}
+		if canonical.Hash != block.Hash {
+			return fmt.Errorf("%w: block %d hash changed from %s to %s",
+				subsidy.ErrSnapshotReorged, block.Number, block.Hash, canonical.Hash)
+		}
+
+		if head.Number >= target {
+			d.logger.Logf("INFO snapshot block %d confirmed at head %d", block.Number, head.Number)
+			return nil
+		}
+
+		d.logger.Logf("DEBUG waiting for snapshot block %d to reach %d confirmations (head %d)",
+			block.Number, d.confirmationDepth, head.Number)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// notFinal wraps the error waiting for the vault's snapshot to become final failed with, as ErrSnapshotStale
+// when a reorg orphaned its block
+func notFinal(vaultId string, err error) error {
+	if errors.Is(err, subsidy.ErrSnapshotReorged) {
+		return fmt.Errorf("%w: snapshot for vault %s is not final: %w", subsidy.ErrSnapshotStale, vaultId, err)
+	}
+	return fmt.Errorf("snapshot for vault %s is not final: %w", vaultId, err)
 }
 
 func (d *LazyDistributor) convertSubsidiesToEntries(
 	subsidies []subgraph.AccountSubsidy,
 ) ([]merkle.Entry, *big.Int, error) {
-	entries := make([]merkle.Entry, 0, len(subsidies))
-	totalSubsidies := big.NewInt(0)
-	currentTimestamp := time.Now().Unix()
+	entries, totalSubsidies, err := d.convertSubsidiesAt(subsidies, time.Now().Unix())
+	if err != nil {
+		return nil, nil, err
+	}
+	d.logger.Logf("INFO processed %d subsidies, generated %d valid entries", len(subsidies), len(entries))
+	return entries, totalSubsidies, nil
+}
 
-	for _, subsidy := range subsidies {
-		amount, ok := new(big.Int).SetString(subsidy.TotalRewardsEarned, 10)
-		if !ok || amount.Sign() <= 0 {
-			calculatedAmount, err := d.calculateTotalEarned(subsidy, currentTimestamp)
-			if err != nil {
-				d.logger.Logf(
-					"WARN failed to calculate total earned for account %s: %v, using totalRewardsEarned=%s",
-					subsidy.Account.ID,
-					err,
-					subsidy.TotalRewardsEarned,
-				)
-				continue
-			}
-			amount = calculatedAmount
+// convertSubsidiesAt values subsidies whose earnings the subgraph has not computed yet at currentTimestamp
+func (d *LazyDistributor) convertSubsidiesAt(
+	subsidies []subgraph.AccountSubsidy,
+	currentTimestamp int64,
+) ([]merkle.Entry, *big.Int, error) {
+	allocations, _ := d.valueSubsidiesAt(subsidies, currentTimestamp)
+	entries, totalSubsidies := entriesFor(allocations)
+	return entries, totalSubsidies, nil
+}
+
+// valueSubsidiesAt values subsidies at currentTimestamp, skipping those without earnings. Subsidies whose
+// records are malformed are quarantined: they are returned for review and the rest are valued as usual.
+func (d *LazyDistributor) valueSubsidiesAt(
+	subsidies []subgraph.AccountSubsidy,
+	currentTimestamp int64,
+) ([]*allocation, []subsidy.QuarantinedAccount) {
+	allocations := make([]*allocation, 0, len(subsidies))
+	var skipped []subsidy.QuarantinedAccount
+
+	for _, accountSubsidy := range subsidies {
+		err := checkSubsidy(accountSubsidy)
+		var amount *big.Int
+		var valued subsidy.CollectionAllocation
+		if err == nil {
+			amount, valued, err = valueSubsidy(accountSubsidy, currentTimestamp)
+		}
+		if err != nil {
+			d.logger.Logf("WARN quarantining subsidy of account %s in %s: %v",
+				accountSubsidy.Account.ID, accountSubsidy.CollectionParticipation, err)
+			skipped = append(skipped, quarantined(accountSubsidy, err))
+			continue
+		}
+		if valued.Source == subsidy.AllocationSourceAccrued {
 			d.logger.Logf(
 				"DEBUG calculated earnings for account %s: %s (secondsAccumulated=%s, lastEffectiveValue=%s)",
-				subsidy.Account.ID,
+				accountSubsidy.Account.ID,
 				amount.String(),
-				subsidy.SecondsAccumulated,
-				subsidy.LastEffectiveValue,
+				accountSubsidy.SecondsAccumulated,
+				accountSubsidy.LastEffectiveValue,
 			)
 		}
 
 		if amount.Sign() <= 0 {
 			d.logger.Logf(
 				"DEBUG skipping account %s with zero earnings (amount=%s)",
-				subsidy.Account.ID,
+				accountSubsidy.Account.ID,
 				amount.String(),
 			)
 			continue
 		}
 
-		entry := merkle.Entry{
-			Address:     subsidy.Account.ID,
-			TotalEarned: amount,
+		a := newAllocation(accountSubsidy, amount)
+		if roundedOff, ok := new(big.Rat).SetString(valued.RoundedOff); ok {
+			a.roundedOff = roundedOff
 		}
+		allocations = append(allocations, a)
+	}
+
+	return allocations, skipped
+}
 
-		entries = append(entries, entry)
-		totalSubsidies.Add(totalSubsidies, amount)
+// allocatedTotal returns the wei the allocations add up to
+func allocatedTotal(allocations []*allocation) *big.Int {
+	total := big.NewInt(0)
+	for _, a := range allocations {
+		total.Add(total, a.amount)
 	}
+	return total
+}
 
-	d.logger.Logf("INFO processed %d subsidies, generated %d valid entries", len(subsidies), len(entries))
-	return entries, totalSubsidies, nil
+// entriesFor turns allocations into merkle entries, one per allocation left with earnings
+func entriesFor(allocations []*allocation) ([]merkle.Entry, *big.Int) {
+	entries := make([]merkle.Entry, 0, len(allocations))
+	totalSubsidies := big.NewInt(0)
+	for _, a := range allocations {
+		if a.amount.Sign() <= 0 {
+			continue
+		}
+		entries = append(entries, merkle.Entry{Address: a.account, TotalEarned: a.amount})
+		totalSubsidies.Add(totalSubsidies, a.amount)
+	}
+	return entries, totalSubsidies
+}
+
+// saveCarryForward records what the pushed distribution left for the next one. The root is already
+// on-chain, so a failure is logged rather than returned.
+func (d *LazyDistributor) saveCarryForward(ctx context.Context, vaultId string, carried *big.Int) {
+	if err := d.store.SaveCarryForward(ctx, vaultId, carried); err != nil {
+		d.logger.Logf("ERROR merkle root for vault %s was pushed but %s wei carried forward was not saved: %v", vaultId, carried, err)
+	}
+}
+
+// saveDustCarry records the dust the pushed distribution left for the next one. The root is already
+// on-chain, so a failure is logged rather than returned.
+func (d *LazyDistributor) saveDustCarry(ctx context.Context, vaultId string, dust *big.Rat) {
+	if err := d.store.SaveDustCarry(ctx, vaultId, dust); err != nil {
+		d.logger.Logf("ERROR merkle root for vault %s was pushed but %s wei of dust carried forward was not saved: %v",
+			vaultId, dust.RatString(), err)
+	}
 }
 
-func (d *LazyDistributor) generateMerkleRoot(entries []merkle.Entry) ([32]byte, error) {
+func (d *LazyDistributor) generateMerkleRoot(
+	ctx context.Context,
+	entries []merkle.Entry,
+	encoding merkle.LeafEncoding,
+) ([32]byte, error) {
+	_, span := tracing.StartSpan(ctx, "merkle.BuildMerkleRoot", attribute.Int("merkle.entries", len(entries)))
+	defer span.End()
+
 	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
 	if !ok {
 		return [32]byte{}, fmt.Errorf("merkle service is not the expected implementation type")
 	}
 
-	root := merkleImpl.BuildMerkleRootFromEntries(entries)
+	root := merkleImpl.BuildMerkleRootWithEncoding(entries, encoding)
 	return root, nil
 }
 
+// generateVaultMerkleRoot builds the vault's next tree incrementally from the last one built for it, or in full
+// with the vault's leaf encoding while delta trees are switched off. Replays of past epochs use generateMerkleRoot
+// instead, so they do not replace that tree.
+func (d *LazyDistributor) generateVaultMerkleRoot(ctx context.Context, vaultId string, entries []merkle.Entry) ([32]byte, error) {
+	ctx, span := tracing.StartSpan(ctx, "merkle.BuildVaultMerkleRoot", attribute.Int("merkle.entries", len(entries)))
+	defer span.End()
+
+	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
+	if !ok {
+		return [32]byte{}, fmt.Errorf("merkle service is not the expected implementation type")
+	}
+
+	if !d.featureEnabled(ctx, features.FlagDeltaMerkleTrees) {
+		// the last tree is left as it was, the next delta build diffs against it and still rehashes every change
+		return merkleImpl.BuildMerkleRootWithEncoding(entries, merkleImpl.LeafEncoding(vaultId)), nil
+	}
+	return merkleImpl.BuildVaultMerkleRoot(ctx, vaultId, entries), nil
+}
+
+// secondsPerToken converts 1e18-scaled deposit-seconds into token wei
+var secondsPerToken = big.NewInt(1000000000000000000)
+
 func (d *LazyDistributor) calculateTotalEarned(subsidy subgraph.AccountSubsidy, endTimestamp int64) (*big.Int, error) {
+	totalSeconds, _, err := accrueSeconds(subsidy, endTimestamp)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).Div(totalSeconds, secondsPerToken), nil
+}
+
+// accrueSeconds returns the deposit-seconds accumulated up to endTimestamp, accruing lastEffectiveValue
+// per second since the subgraph last updated the subsidy, and the number of seconds accrued
+func accrueSeconds(subsidy subgraph.AccountSubsidy, endTimestamp int64) (*big.Int, int64, error) {
 	secondsAccumulated, ok := new(big.Int).SetString(subsidy.SecondsAccumulated, 10)
 	if !ok {
-		return nil, fmt.Errorf("invalid secondsAccumulated: %s", subsidy.SecondsAccumulated)
+		return nil, 0, fmt.Errorf("invalid secondsAccumulated: %s", subsidy.SecondsAccumulated)
 	}
 
 	lastEffectiveValue, ok := new(big.Int).SetString(subsidy.LastEffectiveValue, 10)
 	if !ok {
-		return nil, fmt.Errorf("invalid lastEffectiveValue: %s", subsidy.LastEffectiveValue)
+		return nil, 0, fmt.Errorf("invalid lastEffectiveValue: %s", subsidy.LastEffectiveValue)
 	}
 
 	updatedAtTimestamp, err := strconv.ParseInt(subsidy.UpdatedAtTimestamp, 10, 64)
 	if err != nil {
-		return nil, fmt.Errorf("invalid updatedAtTimestamp: %s", subsidy.UpdatedAtTimestamp)
+		return nil, 0, fmt.Errorf("invalid updatedAtTimestamp: %s", subsidy.UpdatedAtTimestamp)
 	}
 
 	deltaT := endTimestamp - updatedAtTimestamp
 	extraSeconds := new(big.Int).Mul(big.NewInt(deltaT), lastEffectiveValue)
-	newTotalSeconds := new(big.Int).Add(secondsAccumulated, extraSeconds)
+	return new(big.Int).Add(secondsAccumulated, extraSeconds), deltaT, nil
+}
+
+// valueSubsidy is how the distributor values one account subsidy: the subgraph's totalRewardsEarned
+// when it is positive, otherwise the deposit-seconds accrued up to valuedAt. The allocation records
+// every intermediate value, so explanations follow exactly the path distributions take.
+func valueSubsidy(accountSubsidy subgraph.AccountSubsidy, valuedAt int64) (*big.Int, subsidy.CollectionAllocation, error) {
+	allocation := subsidy.CollectionAllocation{
+		CollectionParticipation: accountSubsidy.CollectionParticipation,
+		SecondsAccumulated:      accountSubsidy.SecondsAccumulated,
+		LastEffectiveValue:      accountSubsidy.LastEffectiveValue,
+		UpdatedAtTimestamp:      accountSubsidy.UpdatedAtTimestamp,
+		TotalRewardsEarned:      accountSubsidy.TotalRewardsEarned,
+		CollectionType:          accountSubsidy.CollectionType,
+		Share:                   accountSubsidy.Share,
+		WrappedBy:               accountSubsidy.WrappedBy,
+	}
+
+	if amount, ok := new(big.Int).SetString(accountSubsidy.TotalRewardsEarned, 10); ok && amount.Sign() > 0 {
+		allocation.Source = subsidy.AllocationSourceSubgraph
+		allocation.Amount = amount.String()
+		allocation.RoundedOff = accountSubsidy.RoundedOff
+		return amount, allocation, nil
+	}
 
-	conversionRate := big.NewInt(1000000000000000000)
-	totalEarned := new(big.Int).Div(newTotalSeconds, conversionRate)
-	return totalEarned, nil
+	totalSeconds, elapsed, err := accrueSeconds(accountSubsidy, valuedAt)
+	if err != nil {
+		return nil, allocation, err
+	}
+	amount, remainder := new(big.Int).DivMod(totalSeconds, secondsPerToken, new(big.Int))
+
+	allocation.Source = subsidy.AllocationSourceAccrued
+	allocation.ElapsedSeconds = elapsed
+	allocation.TotalSeconds = totalSeconds.String()
+	allocation.Amount = amount.String()
+	if remainder.Sign() > 0 {
+		allocation.RoundedOff = new(big.Rat).SetFrac(remainder, secondsPerToken).RatString()
+	}
+	return amount, allocation, nil
 }
 
 func (d *LazyDistributor) updateMerkleRoot(
@@ -213,41 +884,101 @@ func (d *LazyDistributor) updateMerkleRoot(
 	merkleRoot [32]byte,
 	totalSubsidies *big.Int,
 ) error {
+	// a root hashed differently from what the contract verifies would leave every claim against it failing
+	if merkleImpl, ok := d.merkleService.(*merkleimpl.Service); ok {
+		if err := merkleImpl.CheckLeafEncoding(ctx, vaultId); err != nil {
+			return fmt.Errorf("failed to check leaf encoding: %w", err)
+		}
+	}
 	return d.blockchainClient.UpdateMerkleRootAndWaitForConfirmation(ctx, vaultId, merkleRoot, totalSubsidies)
 }
 
 func (d *LazyDistributor) saveSnapshot(
 	ctx context.Context,
 	vaultId string,
-	entries []merkle.Entry,
-	merkleRoot [32]byte,
+	distribution *distributionSnapshot,
 	epochNumber *big.Int,
 ) error {
-	merkleEntries := make([]merkle.MerkleEntry, len(entries))
-	for i, entry := range entries {
+	merkleEntries := make([]merkle.MerkleEntry, len(distribution.entries))
+	for i, entry := range distribution.entries {
 		merkleEntries[i] = merkle.MerkleEntry{
 			Address:     entry.Address,
 			TotalEarned: entry.TotalEarned,
 		}
 	}
 
-	snapshot := merkle.MerkleSnapshot{
-		VaultID:     vaultId,
-		MerkleRoot:  fmt.Sprintf("%x", merkleRoot),
-		Entries:     merkleEntries,
-		EpochNumber: epochNumber,
-	}
-
 	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
 	if !ok {
 		return fmt.Errorf("merkle service is not the expected implementation type")
 	}
 
+	snapshot := merkle.MerkleSnapshot{
+		VaultID:       vaultId,
+		MerkleRoot:    fmt.Sprintf("%x", distribution.merkleRoot),
+		Entries:       merkleEntries,
+		EpochNumber:   epochNumber,
+		Timestamp:     distribution.valuedAt,
+		BlockNumber:   int64(distribution.block.Number),
+		BlockHash:     distribution.block.Hash,
+		BlockStrategy: distribution.strategy,
+		LeafEncoding:  merkleImpl.LeafEncoding(vaultId),
+		Supersedes:    distribution.supersedes,
+	}
+	if distribution.fingerprint != nil {
+		snapshot.Fingerprint = distribution.fingerprint.Hash
+	}
+	snapshot.EligibilityCID = d.publishEligibility(ctx, epochNumber, &snapshot, distribution.totalSubsidies)
+
 	if err := merkleImpl.SaveSnapshot(ctx, epochNumber, snapshot); err != nil {
 		return fmt.Errorf("failed to save snapshot: %w", err)
 	}
+	if d.caps.enabled() || len(distribution.capRecords) > 0 {
+		if err := d.store.SaveCapRecords(ctx, epochNumber, vaultId, amountOrZero(distribution.carriedIn), distribution.capRecords); err != nil {
+			return err
+		}
+	}
+	if err := d.store.SaveRoundingRecord(ctx, epochNumber, vaultId, distribution.rounding); err != nil {
+		return err
+	}
+	if err := d.store.SaveAppliedWeights(ctx, epochNumber, vaultId, distribution.weights); err != nil {
+		return err
+	}
+	if err := d.store.SaveBlockedRecord(ctx, epochNumber, vaultId, distribution.blocked); err != nil {
+		return err
+	}
+	if distribution.debts != nil {
+		if err := d.store.SaveDebtRecord(ctx, epochNumber, vaultId, *distribution.debts); err != nil {
+			return err
+		}
+	}
+	records := distribution.positions.records(&snapshot)
+	if distribution.expiry != nil {
+		if err := d.store.SaveExpiryRecord(ctx, epochNumber, vaultId, *distribution.expiry); err != nil {
+			return err
+		}
+		for account, record := range records {
+			if expired := distribution.expiry.expired(account); expired != nil {
+				record.Expired = expired.String()
+				records[account] = record
+			}
+		}
+	}
+	if err := d.store.SavePositions(ctx, epochNumber, vaultId, records); err != nil {
+		return err
+	}
+	if distribution.deferred.Minimum != "" {
+		if err := d.store.SaveDeferredRecord(ctx, epochNumber, vaultId, distribution.deferred); err != nil {
+			return err
+		}
+	}
+	if distribution.fingerprint != nil {
+		if err := d.store.SaveFingerprint(ctx, *distribution.fingerprint); err != nil {
+			return err
+		}
+	}
 
-	d.logger.Logf("INFO saved merkle snapshot for vault %s, epoch %s with %d entries",
-		vaultId, epochNumber.String(), len(entries))
+	d.logger.Logf("INFO saved merkle snapshot for vault %s, epoch %s with %d entries at block %d",
+		vaultId, epochNumber.String(), len(merkleEntries), distribution.block.Number)
+	d.archiveDistribution(ctx, vaultId, epochNumber, &snapshot, distribution)
 	return nil
 }
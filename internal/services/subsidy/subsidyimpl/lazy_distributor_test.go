@@ -1,14 +1,23 @@
 package subsidyimpl
 
 import (
+	"context"
+	"errors"
 	"math/big"
 	"testing"
+	"time"
 
 	"github.com/go-pkgz/lgr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
 )
 
 func TestLazyDistributor_CalculateTotalEarned(t *testing.T) {
@@ -263,3 +272,177 @@ func TestLazyDistributor_ConvertSubsidiesToEntries_RealData(t *testing.T) {
 	t.Logf("User 2 earnings: %s", entries[1].TotalEarned.String())
 	t.Logf("Total subsidies: %s", totalSubsidies.String())
 }
+
+// reorgChain simulates a chain whose heads advance on every latest-block query
+type reorgChain struct {
+	heads     []blockchain.BlockRef
+	canonical map[uint64]string
+	headIdx   int
+}
+
+func (c *reorgChain) client() *blockchain.BlockchainClientMock {
+	return &blockchain.BlockchainClientMock{
+		GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+			if blockNumber == nil {
+				head := c.heads[min(c.headIdx, len(c.heads)-1)]
+				c.headIdx++
+				return &head, nil
+			}
+			return &blockchain.BlockRef{Number: blockNumber.Uint64(), Hash: c.canonical[blockNumber.Uint64()]}, nil
+		},
+		UpdateMerkleRootAndWaitForConfirmationFunc: func(ctx context.Context, vaultId string, root [32]byte, totalSubsidies *big.Int) error {
+			return nil
+		},
+	}
+}
+
+func newReorgTestDistributor(
+	t *testing.T,
+	chain *blockchain.BlockchainClientMock,
+	subgraphClient *subgraph.SubgraphClientMock,
+) *LazyDistributor {
+	db := newPlannerTestDB(t)
+	return &LazyDistributor{
+		blockchainClient:  chain,
+		merkleService:     merkleimpl.New(db, nil, nil, lgr.NoOp),
+		store:             NewStore(db, lgr.NoOp),
+		subgraphClient:    subgraphClient,
+		notifier:          &webhook.NotifierMock{NotifyFunc: func(ctx context.Context, eventType webhook.EventType, data map[string]interface{}) {}},
+		events:            &events.PublisherMock{PublishFunc: func(ctx context.Context, event events.Event) {}},
+		logger:            lgr.NoOp,
+		confirmationDepth: 2,
+		maxResnapshots:    1,
+		blockPollInterval: time.Millisecond,
+	}
+}
+
+func testSubgraphWithSubsidies() *subgraph.SubgraphClientMock {
+	return &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			return fn([]subgraph.AccountSubsidy{{
+				Account:            subgraph.Account{ID: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b"},
+				TotalRewardsEarned: "1000",
+			}})
+		},
+	}
+}
+
+func TestLazyDistributor_ResnapshotsAfterReorg(t *testing.T) {
+	chain := &reorgChain{
+		heads: []blockchain.BlockRef{
+			{Number: 100, Hash: "0xa"}, // first snapshot
+			{Number: 102, Hash: "0xb"}, // block 100 orphaned while waiting
+			{Number: 103, Hash: "0xc"}, // second snapshot
+			{Number: 105, Hash: "0xd"}, // confirmed
+		},
+		canonical: map[uint64]string{100: "0xa2", 103: "0xc"},
+	}
+	client := chain.client()
+	subgraphClient := testSubgraphWithSubsidies()
+
+	distributor := newReorgTestDistributor(t, client, subgraphClient)
+	result, err := distributor.Run(context.Background(), "0xvault")
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.AccountsProcessed)
+	assert.Len(t, subgraphClient.StreamAccountSubsidiesForVaultCalls(), 2, "should re-snapshot once after the reorg")
+	assert.Len(t, client.UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+
+	published := distributor.events.(*events.PublisherMock).PublishCalls()
+	require.Len(t, published, 3)
+	assert.Equal(t, events.EpochSnapshotted, published[0].Event.Type)
+	assert.Equal(t, uint64(103), published[0].Event.Data["blockNumber"], "event should report the confirmed snapshot block")
+	assert.Equal(t, events.RootSubmitted, published[2].Event.Type)
+	assert.Equal(t, "0xvault", published[2].Event.Vault)
+	assert.Equal(t, uint64(103), published[2].Event.Data["blockNumber"])
+}
+
+func TestLazyDistributor_GivesUpAfterMaxResnapshots(t *testing.T) {
+	chain := &reorgChain{
+		heads:     []blockchain.BlockRef{{Number: 100, Hash: "0xa"}},
+		canonical: map[uint64]string{100: "0xother"},
+	}
+	client := chain.client()
+
+	distributor := newReorgTestDistributor(t, client, testSubgraphWithSubsidies())
+	_, err := distributor.Run(context.Background(), "0xvault")
+
+	require.ErrorIs(t, err, subsidy.ErrSnapshotReorged)
+	require.ErrorIs(t, err, subsidy.ErrSnapshotStale)
+	assert.Empty(t, client.UpdateMerkleRootAndWaitForConfirmationCalls(), "must not submit an orphaned root")
+	assert.Empty(t, distributor.events.(*events.PublisherMock).PublishCalls())
+}
+
+func TestLazyDistributor_SnapshotAccumulatesStreamedPages(t *testing.T) {
+	pages := [][]subgraph.AccountSubsidy{
+		{
+			{Account: subgraph.Account{ID: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b"}, TotalRewardsEarned: "1000"},
+			{Account: subgraph.Account{ID: "0x1111111111111111111111111111111111111111"}, TotalRewardsEarned: "0"},
+		},
+		{
+			{Account: subgraph.Account{ID: "0x2222222222222222222222222222222222222222"}, TotalRewardsEarned: "250"},
+		},
+	}
+	subgraphClient := &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			assert.True(t, subgraph.FreshDataRequired(ctx), "snapshots must not use cached results")
+			for _, page := range pages {
+				if err := fn(page); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	chain := &blockchain.BlockchainClientMock{
+		GetBlockRefFunc: func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+			return &blockchain.BlockRef{Number: 100, Hash: "0xa"}, nil
+		},
+	}
+
+	distributor := newReorgTestDistributor(t, chain, subgraphClient)
+	snapshot, err := distributor.takeSnapshot(context.Background(), "0xvault", nil, nil)
+	require.NoError(t, err)
+
+	require.Len(t, snapshot.entries, 2, "accounts without earnings are skipped")
+	assert.Equal(t, "1250", snapshot.totalSubsidies.String())
+	merkleService := distributor.merkleService.(*merkleimpl.Service)
+	assert.Equal(t, merkleService.BuildMerkleRootFromEntries(snapshot.entries), snapshot.merkleRoot)
+}
+
+// readOnlyDB fails every write, the way a full disk fails them
+type readOnlyDB struct {
+	storage.DB
+}
+
+func (readOnlyDB) Update(fn func(txn storage.Txn) error) error {
+	return errors.New("no space left on device")
+}
+
+func TestLazyDistributor_DoesNotPushRootWithoutSnapshot(t *testing.T) {
+	db := newPlannerTestDB(t)
+	chain := newApprovalTestChain(nil)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{})
+	distributor.merkleService = merkleimpl.New(readOnlyDB{db}, nil, nil, lgr.NoOp)
+
+	_, err := distributor.RunWithEpoch(context.Background(), planTestVault, big.NewInt(5))
+	require.ErrorContains(t, err, "failed to save merkle snapshot")
+	assert.Empty(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), "proofs against the root could not be served")
+	submission, err := distributor.store.GetSubmission(context.Background(), big.NewInt(5), planTestVault)
+	require.NoError(t, err)
+	assert.Nil(t, submission, "nothing is kept for resuming")
+}
+
+func TestLazyDistributor_RejectsEmptyVault(t *testing.T) {
+	distributor := newApprovalTestDistributor(newPlannerTestDB(t), newApprovalTestChain(nil), approvalPolicy{})
+	_, err := distributor.RunWithEpoch(context.Background(), "", big.NewInt(5))
+	assert.ErrorIs(t, err, subsidy.ErrInvalidInput)
+}
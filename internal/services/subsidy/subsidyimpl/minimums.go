@@ -0,0 +1,174 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+)
+
+// minimumPolicy holds the minimum allocation an account needs to get a leaf in a vault's tree
+type minimumPolicy struct {
+	allocation *big.Int            // nil when no minimum is configured
+	vaults     map[string]*big.Int // by normalized vault address, replacing allocation for the vault
+}
+
+func newMinimumPolicy(cfg *config.Config) minimumPolicy {
+	var policy minimumPolicy
+	// config.Load rejects malformed minimums
+	if minimum, ok := new(big.Int).SetString(cfg.Minimums.Allocation, 10); ok {
+		policy.allocation = minimum
+	}
+	policy.vaults, _ = config.ParseVaultMinimums(cfg.Minimums.VaultAllocations)
+	return policy
+}
+
+// minimum returns the vault's minimum allocation, nil when it has none
+func (p minimumPolicy) minimum(vaultId string) *big.Int {
+	minimum, ok := p.vaults[utils.NormalizeAddress(vaultId)]
+	if !ok {
+		minimum = p.allocation
+	}
+	if minimum == nil || minimum.Sign() == 0 {
+		return nil
+	}
+	return minimum
+}
+
+// deferredRecord is what a distribution left out of its tree for the minimum allocation, kept with the epoch so
+// replays and explanations leave out the same accounts and the next epoch knows since when an account waits
+type deferredRecord struct {
+	Minimum  string            `json:"minimum"`            // the vault's minimum allocation in wei
+	Accounts []deferredAccount `json:"accounts"`           // accounts left out, sorted
+	Deferred string            `json:"deferred"`           // wei they would have received
+	Released []string          `json:"released,omitempty"` // accounts the previous distribution left out that reached the minimum, sorted
+}
+
+// deferredAccount is an account left out of a tree, its amount carried to the next one
+type deferredAccount struct {
+	Account string `json:"account"`
+	Amount  string `json:"amount"`          // cumulative wei it would have received
+	Since   string `json:"since,omitempty"` // epoch it was first left out of, empty outside epochs
+}
+
+// defers reports whether the distribution left account out
+func (r deferredRecord) defers(account string) bool {
+	account = utils.NormalizeAddress(account)
+	i := sort.Search(len(r.Accounts), func(i int) bool { return r.Accounts[i].Account >= account })
+	return i < len(r.Accounts) && r.Accounts[i].Account == account
+}
+
+// accounts returns the accounts the distribution left out as a set
+func (r deferredRecord) accounts() map[string]bool {
+	accounts := make(map[string]bool, len(r.Accounts))
+	for _, account := range r.Accounts {
+		accounts[account.Account] = true
+	}
+	return accounts
+}
+
+// apply leaves out the allocations of accounts whose amounts add up to less than minimum and that had no leaf
+// in the previous tree. Leaves are cumulative, so what an account is left out with is part of its total in the
+// first tree it gets a leaf in, and an account with a leaf keeps one: dropping it would take back what the
+// account could already claim. previous is what the previous distribution left out, epoch the one being
+// distributed, empty outside epochs.
+func (p minimumPolicy) apply(
+	allocations []*allocation,
+	minimum *big.Int,
+	leaves map[string]bool,
+	previous deferredRecord,
+	epoch string,
+) ([]*allocation, deferredRecord) {
+	record := deferredRecord{Minimum: minimum.String(), Accounts: []deferredAccount{}}
+
+	totals := make(map[string]*big.Int)
+	for _, a := range allocations {
+		addAmount(totals, a.account, a.amount)
+	}
+	below := make(map[string]bool)
+	for account, total := range totals {
+		if total.Sign() > 0 && total.Cmp(minimum) < 0 && !leaves[account] {
+			below[account] = true
+		}
+	}
+
+	since := make(map[string]string, len(previous.Accounts))
+	for _, account := range previous.Accounts {
+		since[account.Account] = account.Since
+	}
+
+	deferred := big.NewInt(0)
+	for account := range below {
+		first, ok := since[account]
+		if !ok || first == "" {
+			first = epoch
+		}
+		record.Accounts = append(record.Accounts, deferredAccount{Account: account, Amount: totals[account].String(), Since: first})
+		deferred.Add(deferred, totals[account])
+	}
+	sort.Slice(record.Accounts, func(i, j int) bool { return record.Accounts[i].Account < record.Accounts[j].Account })
+	record.Deferred = deferred.String()
+
+	for account := range since {
+		if total, ok := totals[account]; ok && total.Sign() > 0 && !below[account] {
+			record.Released = append(record.Released, account)
+		}
+	}
+	sort.Strings(record.Released)
+	return withoutAccounts(allocations, below), record
+}
+
+// withoutAccounts returns the allocations of every account not in accounts
+func withoutAccounts(allocations []*allocation, accounts map[string]bool) []*allocation {
+	if len(accounts) == 0 {
+		return allocations
+	}
+	kept := make([]*allocation, 0, len(allocations))
+	for _, a := range allocations {
+		if !accounts[utils.NormalizeAddress(a.account)] {
+			kept = append(kept, a)
+		}
+	}
+	return kept
+}
+
+// deferBelowMinimum leaves out of the vault's tree the accounts new to it whose allocations are below the vault's
+// minimum. The previous tree and what its distribution left out are those of the vault's latest epoch before
+// epochNumber, or of its latest epoch outside epochs.
+func (d *LazyDistributor) deferBelowMinimum(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	allocations []*allocation,
+) ([]*allocation, deferredRecord, error) {
+	minimum := d.minimums.minimum(vaultId)
+	if minimum == nil {
+		return allocations, deferredRecord{}, nil
+	}
+
+	previous, err := d.previousSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, deferredRecord{}, err
+	}
+	leaves := make(map[string]bool)
+	var carried deferredRecord
+	if previous != nil {
+		for _, entry := range previous.Entries {
+			if entry.TotalEarned != nil && entry.TotalEarned.Sign() > 0 {
+				leaves[utils.NormalizeAddress(entry.Address)] = true
+			}
+		}
+		if carried, err = d.store.GetDeferredRecord(ctx, previous.EpochNumber, vaultId); err != nil {
+			return nil, deferredRecord{}, err
+		}
+	}
+
+	epoch := ""
+	if epochNumber != nil {
+		epoch = epochNumber.String()
+	}
+	kept, record := d.minimums.apply(allocations, minimum, leaves, carried, epoch)
+	return kept, record, nil
+}
@@ -0,0 +1,95 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func TestLazyDistributor_MinimumAllocation(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Minimums.Allocation = "400"
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	distributor.minimums = newMinimumPolicy(cfg)
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.Equal(t, "500", result.TotalSubsidies.String(), "only B's 500 reaches the minimum")
+	assert.Equal(t, 1, result.AccountsProcessed)
+	assert.Equal(t, 2, result.AccountsDeferred)
+
+	record, err := distributor.store.GetDeferredRecord(ctx, big.NewInt(3), planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "400", record.Minimum)
+	assert.Equal(t, "500", record.Deferred)
+	assert.Equal(t, []deferredAccount{
+		{Account: holdingsTestOwnerA, Amount: "300", Since: "3"},
+		{Account: blocklistTestOwnerC, Amount: "200", Since: "3"},
+	}, record.Accounts)
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(3), holdingsTestOwnerA)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches)
+	require.Len(t, explanation.Collections, 1)
+	assert.Equal(t, subsidy.AllocationSourceDeferred, explanation.Collections[0].Source)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches, "replays leave out the accounts the epoch deferred")
+
+	// the next epoch still finds A and C below the minimum, and keeps the epoch they were first left out of
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.NoError(t, err)
+	record, err = distributor.store.GetDeferredRecord(ctx, big.NewInt(4), planTestVault)
+	require.NoError(t, err)
+	require.Len(t, record.Accounts, 2)
+	assert.Equal(t, "3", record.Accounts[0].Since)
+}
+
+func TestMinimumPolicy_Apply(t *testing.T) {
+	const accountA, accountB, accountC = "0xaaaa", "0xbbbb", "0xcccc"
+	allocations := func() []*allocation {
+		return []*allocation{
+			{account: accountA, collection: "x", amount: big.NewInt(150)},
+			{account: accountA, collection: "y", amount: big.NewInt(100)},
+			{account: accountB, collection: "x", amount: big.NewInt(50)},
+			{account: accountC, collection: "x", amount: big.NewInt(80)},
+		}
+	}
+	policy := minimumPolicy{}
+	previous := deferredRecord{Accounts: []deferredAccount{
+		{Account: accountA, Amount: "180", Since: "2"},
+		{Account: accountB, Amount: "30", Since: "1"},
+	}}
+
+	kept, record := policy.apply(allocations(), big.NewInt(200), map[string]bool{accountC: true}, previous, "3")
+	require.Len(t, kept, 3, "A's allocations add up to the minimum and C has a leaf in the previous tree")
+	for _, a := range kept {
+		assert.NotEqual(t, accountB, a.account)
+	}
+	assert.Equal(t, []deferredAccount{{Account: accountB, Amount: "50", Since: "1"}}, record.Accounts)
+	assert.Equal(t, "50", record.Deferred)
+	assert.Equal(t, []string{accountA}, record.Released)
+	assert.True(t, record.defers(accountB))
+	assert.False(t, record.defers(accountA))
+}
+
+func TestMinimumPolicy_Minimum(t *testing.T) {
+	const vault, other = "0x1111111111111111111111111111111111111111", "0x2222222222222222222222222222222222222222"
+	cfg := &config.Config{}
+	assert.Nil(t, newMinimumPolicy(cfg).minimum(vault), "no minimum is configured by default")
+
+	cfg.Minimums.Allocation = "1000"
+	cfg.Minimums.VaultAllocations = []string{vault + ":0", other + ":5000"}
+	policy := newMinimumPolicy(cfg)
+	assert.Nil(t, policy.minimum(vault), "a vault minimum of zero turns the default off")
+	assert.Equal(t, "5000", policy.minimum(other).String())
+	assert.Equal(t, "1000", policy.minimum("0x3333333333333333333333333333333333333333").String())
+}
@@ -0,0 +1,137 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// position is what an account held, borrowed and claimed across its subsidies in a vault
+type position struct {
+	depositSeconds *big.Int
+	nfts           *big.Int
+	claimed        *big.Int
+	borrowVolume   string
+	collections    map[string]bool
+}
+
+// positions accumulates the position of every account a distribution read subsidies of, by normalized account
+type positions map[string]*position
+
+// add counts an account subsidy as the subgraph reported it, its deposit-seconds accrued up to valuedAt. Values
+// that do not parse count as zero; the subsidy is quarantined by valuation anyway.
+func (p positions) add(accountSubsidy subgraph.AccountSubsidy, valuedAt int64) {
+	account := utils.NormalizeAddress(accountSubsidy.Account.ID)
+	held, ok := p[account]
+	if !ok {
+		held = &position{
+			depositSeconds: big.NewInt(0),
+			nfts:           big.NewInt(0),
+			claimed:        big.NewInt(0),
+			collections:    make(map[string]bool),
+		}
+		p[account] = held
+	}
+
+	if seconds, _, err := accrueSeconds(accountSubsidy, valuedAt); err == nil && seconds.Sign() > 0 {
+		held.depositSeconds.Add(held.depositSeconds, seconds)
+	}
+	if nfts, ok := new(big.Int).SetString(accountSubsidy.BalanceNFT, 10); ok && nfts.Sign() > 0 {
+		held.nfts.Add(held.nfts, nfts)
+	}
+	if claimed, ok := new(big.Int).SetString(accountSubsidy.SubsidiesClaimed, 10); ok && claimed.Sign() > 0 {
+		held.claimed.Add(held.claimed, claimed)
+	}
+	if borrowed, ok := new(big.Int).SetString(accountSubsidy.Account.TotalBorrowVolume, 10); ok && borrowed.Sign() >= 0 {
+		held.borrowVolume = borrowed.String()
+	}
+	held.collections[accountSubsidy.CollectionParticipation] = true
+}
+
+// records returns the position of every account with subsidies or a leaf in the snapshot's tree, by normalized
+// account. Earned is left for the history, which knows the account's previous leaf.
+func (p positions) records(snapshot *merkle.MerkleSnapshot) map[string]subsidy.EpochPosition {
+	totals := newTreeTotals(snapshot)
+	accounts := make(map[string]bool, len(p)+len(totals.accounts))
+	for account := range p {
+		accounts[account] = true
+	}
+	for account := range totals.accounts {
+		accounts[account] = true
+	}
+
+	records := make(map[string]subsidy.EpochPosition, len(accounts))
+	for account := range accounts {
+		record := subsidy.EpochPosition{
+			VaultID:        utils.NormalizeAddress(snapshot.VaultID),
+			EpochNumber:    snapshot.EpochNumber.String(),
+			MerkleRoot:     snapshot.MerkleRoot,
+			BlockNumber:    snapshot.BlockNumber,
+			ValuedAt:       snapshot.Timestamp,
+			DepositSeconds: "0",
+			NFTsCounted:    "0",
+			BorrowVolume:   "0",
+			TotalEarned:    "0",
+			Claimed:        "0",
+		}
+		if held, ok := p[account]; ok {
+			record.DepositSeconds = held.depositSeconds.String()
+			record.NFTsCounted = held.nfts.String()
+			record.Collections = len(held.collections)
+			record.Claimed = held.claimed.String()
+			if held.borrowVolume != "" {
+				record.BorrowVolume = held.borrowVolume
+			}
+		}
+		if earned, ok := totals.accounts[account]; ok {
+			record.TotalEarned = earned.String()
+		}
+		records[account] = record
+	}
+	return records
+}
+
+// ListPositions returns the positions the vaults' distributions recorded for the user, latest epoch first, each
+// with what its tree added to the user's total since the vault's previous tree paying the user
+func (d *LazyDistributor) ListPositions(ctx context.Context, userAddress string) (_ []subsidy.EpochPosition, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.ListPositions", attribute.String("user.address", userAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	records, err := d.store.ListPositions(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	// records come by vault and then epoch, so each leaf is compared with the one before it
+	previous := make(map[string]*big.Int)
+	for i := range records {
+		total, ok := new(big.Int).SetString(records[i].TotalEarned, 10)
+		if !ok || total.Sign() <= 0 {
+			records[i].Earned = "0"
+			continue
+		}
+		earned := new(big.Int).Set(total)
+		if last, ok := previous[records[i].VaultID]; ok {
+			earned.Sub(earned, last)
+		}
+		records[i].Earned = earned.String()
+		previous[records[i].VaultID] = total
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		a, _ := new(big.Int).SetString(records[i].EpochNumber, 10)
+		b, _ := new(big.Int).SetString(records[j].EpochNumber, 10)
+		if a == nil || b == nil {
+			return records[i].EpochNumber > records[j].EpochNumber
+		}
+		return a.Cmp(b) > 0
+	})
+	return records, nil
+}
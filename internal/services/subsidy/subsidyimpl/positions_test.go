@@ -0,0 +1,91 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func TestLazyDistributor_ListPositions(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	earned := "300"
+	subsidies := func() []subgraph.AccountSubsidy {
+		owner := subgraph.Account{ID: holdingsTestOwnerA, TotalBorrowVolume: "700"}
+		return []subgraph.AccountSubsidy{
+			{ID: "a1", Account: owner, CollectionParticipation: "0xcollection-a", BalanceNFT: "2", SecondsAccumulated: "1000",
+				LastEffectiveValue: "0", UpdatedAtTimestamp: "0", SubsidiesClaimed: "50", TotalRewardsEarned: earned},
+			{ID: "a2", Account: owner, CollectionParticipation: "0xcollection-b", BalanceNFT: "1", SecondsAccumulated: "500",
+				LastEffectiveValue: "0", UpdatedAtTimestamp: "0", SubsidiesClaimed: "25", TotalRewardsEarned: "0"},
+			{ID: "b", Account: subgraph.Account{ID: holdingsTestOwnerB}, CollectionParticipation: "0xcollection-a",
+				BalanceNFT: "1", TotalRewardsEarned: "500"},
+		}
+	}
+	graph := distributor.subgraphClient.(*subgraph.SubgraphClientMock)
+	graph.StreamAccountSubsidiesForVaultFunc = func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+		return fn(subsidies())
+	}
+	graph.StreamAccountSubsidiesForVaultAtBlockFunc = func(
+		ctx context.Context,
+		vaultAddress string,
+		blockNumber int64,
+		fn func(page []subgraph.AccountSubsidy) error,
+	) error {
+		return fn(subsidies())
+	}
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(3))
+	require.NoError(t, err)
+	earned = "450"
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.NoError(t, err)
+
+	positions, err := distributor.ListPositions(ctx, holdingsTestOwnerA)
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+	latest, first := positions[0], positions[1]
+	assert.Equal(t, "4", latest.EpochNumber, "latest epoch first")
+	assert.Equal(t, utils.NormalizeAddress(planTestVault), latest.VaultID)
+	assert.Equal(t, "1500", latest.DepositSeconds)
+	assert.Equal(t, "3", latest.NFTsCounted)
+	assert.Equal(t, 2, latest.Collections)
+	assert.Equal(t, "700", latest.BorrowVolume)
+	assert.Equal(t, "75", latest.Claimed)
+	assert.Equal(t, "450", latest.TotalEarned)
+	assert.Equal(t, "150", latest.Earned, "the tree added 150 to the 300 the previous one paid")
+	assert.Equal(t, "3", first.EpochNumber)
+	assert.Equal(t, "300", first.TotalEarned)
+	assert.Equal(t, "300", first.Earned)
+
+	positions, err = distributor.ListPositions(ctx, "0x9999999999999999999999999999999999999999")
+	require.NoError(t, err)
+	assert.Empty(t, positions)
+}
+
+func TestStore_SavePositionsDropsStaleAccounts(t *testing.T) {
+	store := NewStore(newPlannerTestDB(t), lgr.NoOp)
+	ctx := context.Background()
+	epoch := big.NewInt(3)
+	position := subsidy.EpochPosition{VaultID: planTestVault, EpochNumber: "3", TotalEarned: "10"}
+
+	require.NoError(t, store.SavePositions(ctx, epoch, planTestVault,
+		map[string]subsidy.EpochPosition{holdingsTestOwnerA: position, holdingsTestOwnerB: position}))
+	position.TotalEarned = "20"
+	require.NoError(t, store.SavePositions(ctx, epoch, planTestVault, map[string]subsidy.EpochPosition{holdingsTestOwnerA: position}))
+
+	positions, err := store.ListPositions(ctx, holdingsTestOwnerA)
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "20", positions[0].TotalEarned)
+	positions, err = store.ListPositions(ctx, holdingsTestOwnerB)
+	require.NoError(t, err)
+	assert.Empty(t, positions, "an account the replacing distribution does not have keeps no position")
+}
@@ -0,0 +1,54 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/andrey/epoch-server/internal/services/ipfs"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+)
+
+// SetIPFS sets where each epoch's leaves and root are published for the community to verify. It is called once
+// at startup, before any distribution runs.
+func (d *LazyDistributor) SetIPFS(service ipfs.Service) {
+	d.ipfs = service
+}
+
+// publishEligibility publishes the snapshot's leaves and root to IPFS and returns the CID, to be saved with the
+// snapshot. The publication is a public copy, so a failure is logged and the distribution goes on without one.
+func (d *LazyDistributor) publishEligibility(
+	ctx context.Context,
+	epochNumber *big.Int,
+	snapshot *merkle.MerkleSnapshot,
+	totalSubsidies *big.Int,
+) string {
+	if d.ipfs == nil {
+		return ""
+	}
+
+	leaves := make([]ipfs.Leaf, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		leaves[i] = ipfs.Leaf{Account: entry.Address, TotalEarned: entry.TotalEarned.String()}
+	}
+	published := ipfs.EligibilitySnapshot{
+		VaultAddress:   snapshot.VaultID,
+		EpochNumber:    epochNumber.String(),
+		MerkleRoot:     "0x" + snapshot.MerkleRoot,
+		LeafEncoding:   string(snapshot.Encoding()),
+		TotalSubsidies: amountOrZero(totalSubsidies).String(),
+		BlockNumber:    uint64(snapshot.BlockNumber),
+		BlockHash:      snapshot.BlockHash,
+		Fingerprint:    snapshot.Fingerprint,
+		Leaves:         leaves,
+	}
+	if snapshot.Supersedes != "" {
+		published.Supersedes = "0x" + snapshot.Supersedes
+	}
+	cid, err := d.ipfs.PublishEligibility(ctx, published)
+	if err != nil {
+		d.logger.Logf("WARN failed to publish eligibility of vault %s epoch %s to IPFS: %v",
+			snapshot.VaultID, epochNumber.String(), err)
+		return ""
+	}
+	return cid
+}
@@ -0,0 +1,54 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/ipfs"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+)
+
+func TestLazyDistributor_PublishesEligibility(t *testing.T) {
+	distributor := newFingerprintTestDistributor(t, approvalPolicy{})
+	ipfsService := &ipfs.ServiceMock{
+		PublishEligibilityFunc: func(ctx context.Context, snapshot ipfs.EligibilitySnapshot) (string, error) {
+			return "bafkreiepoch5", nil
+		},
+	}
+	distributor.SetIPFS(ipfsService)
+	ctx := context.Background()
+	epoch := big.NewInt(5)
+	merkleImpl := distributor.merkleService.(*merkleimpl.Service)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	require.Len(t, ipfsService.PublishEligibilityCalls(), 1)
+
+	published := ipfsService.PublishEligibilityCalls()[0].Snapshot
+	snapshot, err := merkleImpl.GetSnapshot(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "bafkreiepoch5", snapshot.EligibilityCID)
+	assert.Equal(t, planTestVault, published.VaultAddress)
+	assert.Equal(t, "5", published.EpochNumber)
+	assert.Equal(t, "0x"+snapshot.MerkleRoot, published.MerkleRoot)
+	assert.Equal(t, result.TotalSubsidies.String(), published.TotalSubsidies)
+	assert.Equal(t, snapshot.Fingerprint, published.Fingerprint)
+	require.Len(t, published.Leaves, len(snapshot.Entries))
+	assert.Equal(t, snapshot.Entries[0].TotalEarned.String(), published.Leaves[0].TotalEarned)
+
+	// the publication is a public copy, failing to pin it does not fail the distribution
+	ipfsService.PublishEligibilityFunc = func(ctx context.Context, snapshot ipfs.EligibilitySnapshot) (string, error) {
+		return "", fmt.Errorf("%w: quota exceeded", ipfs.ErrPinningFailed)
+	}
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, epoch))
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	snapshot, err = merkleImpl.GetSnapshot(ctx, big.NewInt(6), planTestVault)
+	require.NoError(t, err)
+	assert.Empty(t, snapshot.EligibilityCID)
+}
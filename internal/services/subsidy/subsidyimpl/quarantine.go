@@ -0,0 +1,101 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+// checkSubsidy returns why an account subsidy's record cannot be valued safely, nil when it can.
+// Such records come from subgraph data issues and are quarantined instead of failing the distribution.
+func checkSubsidy(accountSubsidy subgraph.AccountSubsidy) error {
+	if !utils.IsValidAddress(accountSubsidy.Account.ID) {
+		return fmt.Errorf("invalid account address %q", accountSubsidy.Account.ID)
+	}
+
+	fields := []struct{ name, value string }{
+		{"secondsAccumulated", accountSubsidy.SecondsAccumulated},
+		{"lastEffectiveValue", accountSubsidy.LastEffectiveValue},
+		{"totalRewardsEarned", accountSubsidy.TotalRewardsEarned},
+	}
+	for _, field := range fields {
+		if n, ok := new(big.Int).SetString(field.value, 10); ok && n.Sign() < 0 {
+			return fmt.Errorf("negative %s: %s", field.name, field.value)
+		}
+	}
+	return nil
+}
+
+// quarantined returns the review record of a subsidy skipped for reason
+func quarantined(accountSubsidy subgraph.AccountSubsidy, reason error) subsidy.QuarantinedAccount {
+	return subsidy.QuarantinedAccount{
+		SubsidyID:               accountSubsidy.ID,
+		Account:                 utils.NormalizeAddress(accountSubsidy.Account.ID),
+		CollectionParticipation: accountSubsidy.CollectionParticipation,
+		SecondsAccumulated:      accountSubsidy.SecondsAccumulated,
+		LastEffectiveValue:      accountSubsidy.LastEffectiveValue,
+		UpdatedAtTimestamp:      accountSubsidy.UpdatedAtTimestamp,
+		TotalRewardsEarned:      accountSubsidy.TotalRewardsEarned,
+		Reason:                  reason.Error(),
+	}
+}
+
+// saveQuarantined records the subsidies the snapshot skipped and notifies reviewers. The distribution
+// goes ahead for every other account, so a failure to record them is logged rather than returned.
+func (d *LazyDistributor) saveQuarantined(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	snapshot *distributionSnapshot,
+) {
+	if len(snapshot.quarantined) == 0 {
+		return
+	}
+
+	epoch := ""
+	if epochNumber != nil {
+		epoch = epochNumber.String()
+	}
+	now := time.Now()
+	for i := range snapshot.quarantined {
+		snapshot.quarantined[i].VaultID = vaultId
+		snapshot.quarantined[i].EpochNumber = epoch
+		snapshot.quarantined[i].BlockNumber = snapshot.block.Number
+		snapshot.quarantined[i].QuarantinedAt = now
+	}
+
+	d.logger.Logf("WARN quarantined %d account subsidies of vault %s at block %d",
+		len(snapshot.quarantined), vaultId, snapshot.block.Number)
+	if err := d.store.SaveQuarantined(ctx, snapshot.quarantined); err != nil {
+		d.logger.Logf("ERROR failed to record quarantined accounts of vault %s: %v", vaultId, err)
+	}
+
+	data := map[string]interface{}{
+		"vaultAddress": vaultId,
+		"count":        len(snapshot.quarantined),
+		"blockNumber":  snapshot.block.Number,
+	}
+	if epoch != "" {
+		data["epochId"] = epoch
+	}
+	d.notifier.Notify(ctx, webhook.EventAccountsQuarantined, data)
+}
+
+// ListQuarantined returns the vault's quarantined account subsidies, only the epoch's when epochNumber is set
+func (d *LazyDistributor) ListQuarantined(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+) ([]subsidy.QuarantinedAccount, error) {
+	epoch := ""
+	if epochNumber != nil {
+		epoch = epochNumber.String()
+	}
+	return d.store.ListQuarantined(ctx, vaultId, epoch)
+}
@@ -0,0 +1,99 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+)
+
+func TestCheckSubsidy(t *testing.T) {
+	valid := subgraph.AccountSubsidy{
+		Account:            subgraph.Account{ID: explainTestUser},
+		SecondsAccumulated: "100",
+		LastEffectiveValue: "2",
+		TotalRewardsEarned: "",
+	}
+	assert.NoError(t, checkSubsidy(valid))
+
+	tests := []struct {
+		name   string
+		modify func(s *subgraph.AccountSubsidy)
+		reason string
+	}{
+		{"invalid address", func(s *subgraph.AccountSubsidy) { s.Account.ID = "user-1" }, "invalid account address"},
+		{"negative seconds", func(s *subgraph.AccountSubsidy) { s.SecondsAccumulated = "-5" }, "negative secondsAccumulated"},
+		{"negative weight", func(s *subgraph.AccountSubsidy) { s.LastEffectiveValue = "-1" }, "negative lastEffectiveValue"},
+		{"negative rewards", func(s *subgraph.AccountSubsidy) { s.TotalRewardsEarned = "-10" }, "negative totalRewardsEarned"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := valid
+			tt.modify(&s)
+			err := checkSubsidy(s)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.reason)
+		})
+	}
+}
+
+func TestLazyDistributor_QuarantinesPoisonAccounts(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	poison := []subgraph.AccountSubsidy{
+		{
+			ID:                      "bad-seconds",
+			Account:                 subgraph.Account{ID: "0x2222222222222222222222222222222222222222"},
+			CollectionParticipation: "0xcollection-a",
+			SecondsAccumulated:      "-5000000000000000000000",
+			LastEffectiveValue:      "0",
+			UpdatedAtTimestamp:      "1",
+		},
+		{
+			ID:                      "bad-timestamp",
+			Account:                 subgraph.Account{ID: "0x3333333333333333333333333333333333333333"},
+			CollectionParticipation: "0xcollection-a",
+			SecondsAccumulated:      "1",
+			LastEffectiveValue:      "1",
+			UpdatedAtTimestamp:      "yesterday",
+		},
+	}
+	subsidies := append(explainTestSubsidies(), poison...)
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+	}
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err, "poison accounts do not abort the distribution")
+	assert.Equal(t, 3, result.AccountsProcessed)
+	assert.Equal(t, 2, result.AccountsQuarantined)
+	assert.Contains(t, notifiedEvents(distributor), webhook.EventAccountsQuarantined)
+
+	quarantined, err := distributor.ListQuarantined(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	require.Len(t, quarantined, 2)
+	assert.Equal(t, "bad-seconds", quarantined[0].SubsidyID)
+	assert.Equal(t, "5", quarantined[0].EpochNumber)
+	assert.Equal(t, uint64(100), quarantined[0].BlockNumber)
+	assert.Equal(t, "-5000000000000000000000", quarantined[0].SecondsAccumulated)
+	assert.Contains(t, quarantined[0].Reason, "negative secondsAccumulated")
+	assert.Contains(t, quarantined[1].Reason, "invalid updatedAtTimestamp")
+
+	// a re-run of the same snapshot overwrites rather than duplicates the records
+	require.NoError(t, distributor.store.SaveQuarantined(ctx, quarantined))
+	all, err := distributor.ListQuarantined(ctx, planTestVault, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, 2)
+
+	other, err := distributor.ListQuarantined(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Empty(t, other)
+}
@@ -0,0 +1,259 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RepaymentPlanner sends repayBorrowBehalfBatch calls in chunks sized from gas estimates.
+// Chunks shrink when the vault rejects them with BatchSizeExceedsLimit or their estimate exceeds
+// the gas budget, and the learned size is reused for the rest of the plan.
+// Every chunk is persisted before it is sent, so a retried plan never repays a borrower whose
+// chunk may already have executed.
+type RepaymentPlanner struct {
+	blockchainClient blockchain.BlockchainClient
+	store            *Store
+	logger           lgr.L
+	maxBatchSize     int
+	gasBudget        uint64
+	gasMarginPercent uint64
+}
+
+func NewRepaymentPlanner(
+	blockchainClient blockchain.BlockchainClient,
+	db storage.DB,
+	logger lgr.L,
+	cfg *config.Config,
+) *RepaymentPlanner {
+	return &RepaymentPlanner{
+		blockchainClient: blockchainClient,
+		store:            NewStore(db, logger),
+		logger:           logger,
+		maxBatchSize:     cfg.Repayment.MaxBatchSize,
+		gasBudget:        cfg.Repayment.GasBudget,
+		gasMarginPercent: cfg.Repayment.GasMarginPercent,
+	}
+}
+
+// Execute repays every borrower in repayments that is not already covered by a chunk of the plan.
+// Calling it again with the same planID resumes the plan after a failure.
+func (p *RepaymentPlanner) Execute(
+	ctx context.Context,
+	planID, vaultId string,
+	repayments []subsidy.Repayment,
+) (_ *subsidy.RepaymentPlan, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.RepaymentPlanner.Execute",
+		attribute.String("plan.id", planID), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if planID == "" {
+		return nil, fmt.Errorf("%w: planID cannot be empty", subsidy.ErrInvalidInput)
+	}
+	vaultId = utils.NormalizeAddress(vaultId)
+	pending, err := normalizeRepayments(repayments)
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := p.loadPlan(ctx, planID, vaultId)
+	if err != nil {
+		return nil, err
+	}
+
+	pending = excludeSettled(pending, plan)
+	p.logger.Logf("INFO executing repayment plan %s for vault %s: %d of %d borrowers pending",
+		planID, vaultId, len(pending), len(repayments))
+
+	batchSize := p.maxBatchSize
+	for len(pending) > 0 {
+		size := min(batchSize, len(pending))
+		chunk := pending[:size]
+		borrowers, amounts := splitRepayments(chunk)
+
+		gas, estimateErr := p.blockchainClient.EstimateRepayBorrowBehalfBatchGas(ctx, vaultId, borrowers, amounts)
+		switch {
+		case errors.Is(estimateErr, blockchain.ErrBatchSizeExceedsLimit):
+			if size == 1 {
+				return p.finish(ctx, plan, fmt.Errorf("%w: vault rejects a single repayment", subsidy.ErrBatchTooLarge))
+			}
+			batchSize = size / 2
+			p.logger.Logf("WARN vault %s rejected a batch of %d repayments, retrying with %d", vaultId, size, batchSize)
+			continue
+		case estimateErr != nil:
+			return p.finish(ctx, plan, fmt.Errorf("failed to estimate repayment batch gas: %w", estimateErr))
+		case p.gasBudget > 0 && gas > p.gasBudget:
+			if size == 1 {
+				return p.finish(ctx, plan, fmt.Errorf("%w: a single repayment needs %d gas, budget is %d",
+					subsidy.ErrBatchTooLarge, gas, p.gasBudget))
+			}
+			batchSize = max(1, min(size-1, int(uint64(size)*p.gasBudget/gas)))
+			p.logger.Logf("INFO batch of %d repayments needs %d gas over budget %d, retrying with %d",
+				size, gas, p.gasBudget, batchSize)
+			continue
+		}
+
+		if err := p.sendChunk(ctx, plan, chunk, gas); err != nil {
+			return p.finish(ctx, plan, err)
+		}
+		pending = pending[size:]
+	}
+
+	return p.finish(ctx, plan, nil)
+}
+
+// sendChunk records the chunk as submitted, sends it and records the outcome
+func (p *RepaymentPlanner) sendChunk(ctx context.Context, plan *subsidy.RepaymentPlan, chunk []subsidy.Repayment, gas uint64) error {
+	borrowers, amounts := splitRepayments(chunk)
+	total := new(big.Int)
+	for _, amount := range amounts {
+		total.Add(total, amount)
+	}
+
+	plan.Chunks = append(plan.Chunks, subsidy.RepaymentChunk{
+		Index:       len(plan.Chunks),
+		Borrowers:   borrowers,
+		TotalAmount: total.String(),
+		GasEstimate: gas,
+		Status:      subsidy.RepaymentChunkSubmitted,
+		UpdatedAt:   time.Now(),
+	})
+	current := &plan.Chunks[len(plan.Chunks)-1]
+	if err := p.store.SaveRepaymentPlan(ctx, *plan); err != nil {
+		// without a record of the chunk a retry could repay these borrowers twice
+		plan.Chunks = plan.Chunks[:len(plan.Chunks)-1]
+		return fmt.Errorf("failed to record repayment chunk before sending: %w", err)
+	}
+
+	gasLimit := gas + gas*p.gasMarginPercent/100
+	sendErr := p.blockchainClient.RepayBorrowBehalfBatch(ctx, plan.VaultID, borrowers, amounts, gasLimit)
+	current.UpdatedAt = time.Now()
+	switch {
+	case sendErr == nil:
+		current.Status = subsidy.RepaymentChunkConfirmed
+		p.logger.Logf("INFO repayment plan %s chunk %d repaid %s to %d borrowers",
+			plan.ID, current.Index, current.TotalAmount, len(borrowers))
+		return nil
+	case errors.Is(sendErr, blockchain.ErrTxNotSent), errors.Is(sendErr, blockchain.ErrTxReverted):
+		current.Status = subsidy.RepaymentChunkFailed
+		current.Error = sendErr.Error()
+	default:
+		current.Status = subsidy.RepaymentChunkUnconfirmed
+		current.Error = sendErr.Error()
+		p.logger.Logf("ERROR repayment plan %s chunk %d outcome unknown, its borrowers will not be retried: %v",
+			plan.ID, current.Index, sendErr)
+	}
+	return fmt.Errorf("%w: repayment chunk %d: %v", subsidy.ErrTransactionFailed, current.Index, sendErr)
+}
+
+// loadPlan resumes a stored plan or starts a new one.
+// Chunks left submitted by an interrupted run may have executed, so they become unconfirmed.
+func (p *RepaymentPlanner) loadPlan(ctx context.Context, planID, vaultId string) (*subsidy.RepaymentPlan, error) {
+	plan, err := p.store.GetRepaymentPlan(ctx, planID)
+	if errors.Is(err, subsidy.ErrNotFound) {
+		return &subsidy.RepaymentPlan{ID: planID, VaultID: vaultId, Status: subsidy.RepaymentPlanInProgress}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if plan.VaultID != vaultId {
+		return nil, fmt.Errorf("%w: repayment plan %s belongs to vault %s", subsidy.ErrInvalidInput, planID, plan.VaultID)
+	}
+
+	for i := range plan.Chunks {
+		if plan.Chunks[i].Status == subsidy.RepaymentChunkSubmitted {
+			plan.Chunks[i].Status = subsidy.RepaymentChunkUnconfirmed
+			plan.Chunks[i].Error = "interrupted before the transaction outcome was recorded"
+		}
+	}
+	plan.Status = subsidy.RepaymentPlanInProgress
+	return plan, nil
+}
+
+// finish stores the plan with its final status and passes err through
+func (p *RepaymentPlanner) finish(ctx context.Context, plan *subsidy.RepaymentPlan, err error) (*subsidy.RepaymentPlan, error) {
+	plan.Status = subsidy.RepaymentPlanCompleted
+	if err != nil {
+		plan.Status = subsidy.RepaymentPlanInProgress
+	}
+	for _, chunk := range plan.Chunks {
+		if chunk.Status == subsidy.RepaymentChunkUnconfirmed {
+			plan.Status = subsidy.RepaymentPlanPartial
+		}
+	}
+
+	if saveErr := p.store.SaveRepaymentPlan(ctx, *plan); saveErr != nil {
+		p.logger.Logf("ERROR failed to save repayment plan %s: %v", plan.ID, saveErr)
+		if err == nil {
+			err = saveErr
+		}
+	}
+	return plan, err
+}
+
+// normalizeRepayments validates repayments and lowercases borrower addresses
+func normalizeRepayments(repayments []subsidy.Repayment) ([]subsidy.Repayment, error) {
+	if len(repayments) == 0 {
+		return nil, fmt.Errorf("%w: no repayments given", subsidy.ErrInvalidInput)
+	}
+
+	seen := make(map[string]bool, len(repayments))
+	normalized := make([]subsidy.Repayment, 0, len(repayments))
+	for _, repayment := range repayments {
+		borrower, err := utils.ValidateAndNormalizeAddress(repayment.Borrower)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", subsidy.ErrInvalidInput, err)
+		}
+		if repayment.Amount == nil || repayment.Amount.Sign() <= 0 {
+			return nil, fmt.Errorf("%w: repayment for %s must be positive", subsidy.ErrInvalidInput, borrower)
+		}
+		if seen[borrower] {
+			return nil, fmt.Errorf("%w: duplicate repayment for %s", subsidy.ErrInvalidInput, borrower)
+		}
+		seen[borrower] = true
+		normalized = append(normalized, subsidy.Repayment{Borrower: borrower, Amount: repayment.Amount})
+	}
+	return normalized, nil
+}
+
+// excludeSettled drops borrowers covered by a chunk that executed or may have executed
+func excludeSettled(repayments []subsidy.Repayment, plan *subsidy.RepaymentPlan) []subsidy.Repayment {
+	settled := make(map[string]bool)
+	for _, chunk := range plan.Chunks {
+		if chunk.Status == subsidy.RepaymentChunkFailed {
+			continue
+		}
+		for _, borrower := range chunk.Borrowers {
+			settled[borrower] = true
+		}
+	}
+
+	pending := make([]subsidy.Repayment, 0, len(repayments))
+	for _, repayment := range repayments {
+		if !settled[repayment.Borrower] {
+			pending = append(pending, repayment)
+		}
+	}
+	return pending
+}
+
+func splitRepayments(repayments []subsidy.Repayment) ([]string, []*big.Int) {
+	borrowers := make([]string, len(repayments))
+	amounts := make([]*big.Int, len(repayments))
+	for i, repayment := range repayments {
+		borrowers[i] = repayment.Borrower
+		amounts[i] = repayment.Amount
+	}
+	return borrowers, amounts
+}
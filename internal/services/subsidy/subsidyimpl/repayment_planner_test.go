@@ -0,0 +1,228 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const planTestVault = "0xf82b93f3d6a703b8b5949809771b1e725708590a"
+
+func newPlannerTestDB(t *testing.T) storage.DB {
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() {
+		assert.NoError(t, badgerDB.Close())
+	})
+	return db
+}
+
+func newTestPlanner(db storage.DB, chain *blockchain.BlockchainClientMock, maxBatchSize int, gasBudget uint64) *RepaymentPlanner {
+	return &RepaymentPlanner{
+		blockchainClient: chain,
+		store:            NewStore(db, lgr.NoOp),
+		logger:           lgr.NoOp,
+		maxBatchSize:     maxBatchSize,
+		gasBudget:        gasBudget,
+		gasMarginPercent: 20,
+	}
+}
+
+func testRepayments(n int) []subsidy.Repayment {
+	repayments := make([]subsidy.Repayment, n)
+	for i := range repayments {
+		repayments[i] = subsidy.Repayment{
+			Borrower: fmt.Sprintf("0x%040x", i+1),
+			Amount:   big.NewInt(int64(100 * (i + 1))),
+		}
+	}
+	return repayments
+}
+
+// newRepayChain returns a chain that estimates 100k gas per borrower and rejects batches above contractLimit
+func newRepayChain(contractLimit int, send func(borrowers []string) error) *blockchain.BlockchainClientMock {
+	return &blockchain.BlockchainClientMock{
+		EstimateRepayBorrowBehalfBatchGasFunc: func(ctx context.Context, vaultAddress string, borrowers []string, amounts []*big.Int) (uint64, error) {
+			if contractLimit > 0 && len(borrowers) > contractLimit {
+				return 0, blockchain.ErrBatchSizeExceedsLimit
+			}
+			return uint64(len(borrowers)) * 100_000, nil
+		},
+		RepayBorrowBehalfBatchFunc: func(ctx context.Context, vaultAddress string, borrowers []string, amounts []*big.Int, gasLimit uint64) error {
+			if send == nil {
+				return nil
+			}
+			return send(borrowers)
+		},
+	}
+}
+
+func repaidBorrowers(chain *blockchain.BlockchainClientMock) map[string]int {
+	repaid := make(map[string]int)
+	for _, call := range chain.RepayBorrowBehalfBatchCalls() {
+		for _, borrower := range call.Borrowers {
+			repaid[borrower]++
+		}
+	}
+	return repaid
+}
+
+func TestRepaymentPlanner_ChunkSizing(t *testing.T) {
+	tests := []struct {
+		name          string
+		maxBatchSize  int
+		gasBudget     uint64
+		contractLimit int
+		expectedSizes []int
+	}{
+		{name: "max_batch_size", maxBatchSize: 4, expectedSizes: []int{4, 4, 2}},
+		{name: "contract_limit_halves_batch", maxBatchSize: 10, contractLimit: 3, expectedSizes: []int{2, 2, 2, 2, 2}},
+		{name: "gas_budget_shrinks_batch", maxBatchSize: 10, gasBudget: 350_000, expectedSizes: []int{3, 3, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newRepayChain(tt.contractLimit, nil)
+			planner := newTestPlanner(newPlannerTestDB(t), chain, tt.maxBatchSize, tt.gasBudget)
+
+			plan, err := planner.Execute(context.Background(), "plan-1", planTestVault, testRepayments(10))
+			require.NoError(t, err)
+			assert.Equal(t, subsidy.RepaymentPlanCompleted, plan.Status)
+
+			var sizes []int
+			for _, call := range chain.RepayBorrowBehalfBatchCalls() {
+				sizes = append(sizes, len(call.Borrowers))
+				assert.Equal(t, uint64(len(call.Borrowers))*120_000, call.GasLimit, "gas limit should include the margin")
+			}
+			assert.Equal(t, tt.expectedSizes, sizes)
+			require.Len(t, plan.Chunks, len(tt.expectedSizes))
+		})
+	}
+}
+
+func TestRepaymentPlanner_RetryAfterRevert(t *testing.T) {
+	db := newPlannerTestDB(t)
+	calls := 0
+	chain := newRepayChain(0, func(borrowers []string) error {
+		calls++
+		if calls == 2 {
+			return fmt.Errorf("%w: out of yield", blockchain.ErrTxReverted)
+		}
+		return nil
+	})
+	planner := newTestPlanner(db, chain, 2, 0)
+	repayments := testRepayments(5)
+
+	plan, err := planner.Execute(context.Background(), "plan-1", planTestVault, repayments)
+	require.ErrorIs(t, err, subsidy.ErrTransactionFailed)
+	assert.Equal(t, subsidy.RepaymentPlanInProgress, plan.Status)
+	require.Len(t, plan.Chunks, 2)
+	assert.Equal(t, subsidy.RepaymentChunkFailed, plan.Chunks[1].Status)
+
+	plan, err = planner.Execute(context.Background(), "plan-1", planTestVault, repayments)
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.RepaymentPlanCompleted, plan.Status)
+
+	repaid := repaidBorrowers(chain)
+	require.Len(t, repaid, 5)
+	assert.Equal(t, 1, repaid[repayments[0].Borrower], "confirmed chunk must not be repaid again")
+	assert.Equal(t, 2, repaid[repayments[2].Borrower], "reverted chunk is retried")
+	assert.Equal(t, 1, repaid[repayments[4].Borrower])
+}
+
+func TestRepaymentPlanner_UnknownOutcomeNotRetried(t *testing.T) {
+	db := newPlannerTestDB(t)
+	calls := 0
+	chain := newRepayChain(0, func(borrowers []string) error {
+		calls++
+		if calls == 1 {
+			return errors.New("failed to wait for transaction: context deadline exceeded")
+		}
+		return nil
+	})
+	planner := newTestPlanner(db, chain, 2, 0)
+	repayments := testRepayments(4)
+
+	plan, err := planner.Execute(context.Background(), "plan-1", planTestVault, repayments)
+	require.Error(t, err)
+	assert.Equal(t, subsidy.RepaymentPlanPartial, plan.Status)
+
+	plan, err = planner.Execute(context.Background(), "plan-1", planTestVault, repayments)
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.RepaymentPlanPartial, plan.Status, "unconfirmed chunks need reconciliation")
+
+	repaid := repaidBorrowers(chain)
+	assert.Equal(t, 1, repaid[repayments[0].Borrower])
+	assert.Equal(t, 1, repaid[repayments[1].Borrower])
+	assert.Equal(t, 1, repaid[repayments[3].Borrower])
+}
+
+func TestRepaymentPlanner_InterruptedChunkNotRetried(t *testing.T) {
+	db := newPlannerTestDB(t)
+	repayments := testRepayments(3)
+
+	// a chunk persisted as submitted means the process stopped before the outcome was known
+	store := NewStore(db, lgr.NoOp)
+	require.NoError(t, store.SaveRepaymentPlan(context.Background(), subsidy.RepaymentPlan{
+		ID:      "plan-1",
+		VaultID: planTestVault,
+		Status:  subsidy.RepaymentPlanInProgress,
+		Chunks: []subsidy.RepaymentChunk{{
+			Borrowers: []string{repayments[0].Borrower},
+			Status:    subsidy.RepaymentChunkSubmitted,
+		}},
+	}))
+
+	chain := newRepayChain(0, nil)
+	plan, err := newTestPlanner(db, chain, 10, 0).Execute(context.Background(), "plan-1", planTestVault, repayments)
+	require.NoError(t, err)
+	assert.Equal(t, subsidy.RepaymentChunkUnconfirmed, plan.Chunks[0].Status)
+
+	repaid := repaidBorrowers(chain)
+	assert.Len(t, repaid, 2)
+	assert.Zero(t, repaid[repayments[0].Borrower])
+}
+
+func TestRepaymentPlanner_InvalidInput(t *testing.T) {
+	tests := []struct {
+		name       string
+		planID     string
+		repayments []subsidy.Repayment
+	}{
+		{name: "empty_plan_id", planID: "", repayments: testRepayments(1)},
+		{name: "no_repayments", planID: "plan-1"},
+		{name: "invalid_borrower", planID: "plan-1", repayments: []subsidy.Repayment{{Borrower: "0x12", Amount: big.NewInt(1)}}},
+		{name: "zero_amount", planID: "plan-1", repayments: []subsidy.Repayment{{Borrower: testRepayments(1)[0].Borrower, Amount: big.NewInt(0)}}},
+		{name: "duplicate_borrower", planID: "plan-1", repayments: append(testRepayments(1), testRepayments(1)...)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain := newRepayChain(0, nil)
+			_, err := newTestPlanner(newPlannerTestDB(t), chain, 10, 0).Execute(context.Background(), tt.planID, planTestVault, tt.repayments)
+			assert.ErrorIs(t, err, subsidy.ErrInvalidInput)
+			assert.Empty(t, chain.RepayBorrowBehalfBatchCalls())
+		})
+	}
+}
+
+func TestRepaymentPlanner_SingleRepaymentOverBudget(t *testing.T) {
+	chain := newRepayChain(0, nil)
+	_, err := newTestPlanner(newPlannerTestDB(t), chain, 10, 50_000).Execute(context.Background(), "plan-1", planTestVault, testRepayments(2))
+	assert.ErrorIs(t, err, subsidy.ErrBatchTooLarge)
+	assert.Empty(t, chain.RepayBorrowBehalfBatchCalls())
+}
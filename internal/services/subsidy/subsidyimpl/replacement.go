@@ -0,0 +1,260 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// actionReplaceRoot is the audit action recorded when an admin replaces the root pushed for an epoch
+const actionReplaceRoot = "replaceRoot"
+
+// ReplaceRoot recomputes the distribution of the vault's latest epoch, carried in what the epoch was carried
+// in, and runs the leaf total and finalization checks on it again. Unless confirm is set it stops there and
+// reports the recomputed root. Confirmed, it pushes the root in place of the one on-chain, settles what the
+// recomputed distribution carries forward and records the replaced root as superseded. The committed
+// fingerprint is not checked: the replacement is the admin's override, recorded with its reason.
+func (d *LazyDistributor) ReplaceRoot(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	reason string,
+	confirm bool,
+) (_ *subsidy.RootReplacement, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.ReplaceRoot",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	ctx = logging.WithFields(ctx, logging.Fields{Vault: vaultId, EpochID: epochNumber.String()})
+	logger := logging.FromContext(ctx, d.logger)
+
+	// a replacement pushes a root, so it is serialized with approvals like any other push
+	d.approvalMu.Lock()
+	defer d.approvalMu.Unlock()
+
+	current, err := d.replaceableSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	carriedIn, err := d.epochCarry(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot, err := d.takeSnapshot(ctx, vaultId, epochNumber, carriedIn)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshot.entries) == 0 {
+		return nil, fmt.Errorf("%w: the recomputed distribution of vault %s epoch %s has no entries",
+			subsidy.ErrRootNotReplaceable, vaultId, epochNumber.String())
+	}
+	if err := checkLeafTotal(snapshot.entries, snapshot.expectedTotal()); err != nil {
+		logger.Logf("ERROR recomputed distribution for vault %s does not add up: %v", vaultId, err)
+		return nil, err
+	}
+	previousRoot := strings.ToLower(strings.TrimPrefix(current.MerkleRoot, "0x"))
+	if fmt.Sprintf("%x", snapshot.merkleRoot) == previousRoot {
+		return nil, fmt.Errorf("%w: vault %s epoch %s recomputes to its pushed root %s",
+			subsidy.ErrRootNotReplaceable, vaultId, epochNumber.String(), previousRoot)
+	}
+	snapshot.fingerprint = d.fingerprint(vaultId, epochNumber, snapshot)
+	snapshot.supersedes = previousRoot
+
+	checks, err := d.checkFinalization(ctx, vaultId, epochNumber, snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	previousTotal := big.NewInt(0)
+	before := make(map[string]*big.Int)
+	for _, entry := range current.Entries {
+		addAmount(before, entry.Address, entry.TotalEarned)
+		previousTotal.Add(previousTotal, entry.TotalEarned)
+	}
+	after := make(map[string]*big.Int)
+	for _, entry := range snapshot.entries {
+		addAmount(after, entry.Address, entry.TotalEarned)
+	}
+	diff := diffAllocations(before, after, subsidy.DefaultDiffTop)
+	diff.VaultID = vaultId
+	diff.EpochNumber = epochNumber.String()
+	diff.PreviousEpochNumber = epochNumber.String()
+
+	replacement := &subsidy.RootReplacement{
+		VaultID:           vaultId,
+		EpochNumber:       epochNumber.String(),
+		PreviousRoot:      previousRoot,
+		MerkleRoot:        fmt.Sprintf("%x", snapshot.merkleRoot),
+		PreviousTotal:     previousTotal.String(),
+		TotalSubsidies:    snapshot.totalSubsidies.String(),
+		AccountsProcessed: len(snapshot.entries),
+		BlockNumber:       snapshot.block.Number,
+		Fingerprint:       snapshot.fingerprint.Hash,
+		Diff:              diff,
+		Checks:            checks,
+		Reason:            reason,
+	}
+	if !confirm {
+		logger.Logf("INFO recomputed root %s to replace root %s of vault %s epoch %s, not confirmed",
+			replacement.MerkleRoot, previousRoot, vaultId, epochNumber.String())
+		return replacement, nil
+	}
+
+	if err := d.waitForSnapshotFinality(ctx, snapshot.block); err != nil {
+		return nil, notFinal(vaultId, err)
+	}
+	// the tree is saved before the push, so proofs against the new root resolve as soon as it is on-chain
+	if err := d.saveSnapshot(ctx, vaultId, snapshot, epochNumber); err != nil {
+		return nil, fmt.Errorf("failed to save replacement snapshot: %w", err)
+	}
+	if err := d.updateMerkleRoot(ctx, vaultId, snapshot.merkleRoot, snapshot.totalSubsidies); err != nil {
+		logger.Logf("ERROR failed to replace merkle root of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+		return nil, fmt.Errorf("failed to update merkle root on blockchain: %w", err)
+	}
+
+	inFlight, err := d.store.GetSubmission(ctx, epochNumber, vaultId)
+	if err != nil {
+		logger.Logf("WARN failed to read kept distribution of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+	}
+	pending := newSubmission(vaultId, epochNumber, snapshot)
+	d.rootPushed(ctx, vaultId, epochNumber, &pending)
+	if inFlight == nil {
+		// the epoch was completed with the replaced root, so there is nothing to resume
+		if err := d.store.DeleteSubmission(ctx, epochNumber, vaultId); err != nil {
+			logger.Logf("WARN failed to forget replacement of vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+		}
+	}
+
+	now := time.Now()
+	replacement.Confirmed = true
+	replacement.ReplacedBy = audit.ActorFromContext(ctx)
+	replacement.ReplacedAt = &now
+	d.recordSupersession(ctx, replacement)
+	d.recordStats(ctx, vaultId, epochNumber, snapshot)
+
+	logger.Logf("WARN replaced merkle root %s of vault %s epoch %s with %s, total %s to %s, by %s: %s",
+		previousRoot, vaultId, epochNumber.String(), replacement.MerkleRoot, replacement.PreviousTotal,
+		replacement.TotalSubsidies, replacement.ReplacedBy, reason)
+	d.record(ctx, actionReplaceRoot, map[string]string{
+		"vault":          vaultId,
+		"epoch":          epochNumber.String(),
+		"previousRoot":   previousRoot,
+		"merkleRoot":     replacement.MerkleRoot,
+		"previousTotal":  replacement.PreviousTotal,
+		"totalSubsidies": replacement.TotalSubsidies,
+		"reason":         reason,
+	})
+	d.publish(ctx, events.RootSubmitted, vaultId, epochNumber, map[string]interface{}{
+		"merkleRoot":        "0x" + replacement.MerkleRoot,
+		"totalSubsidies":    replacement.TotalSubsidies,
+		"accountsProcessed": replacement.AccountsProcessed,
+		"blockNumber":       replacement.BlockNumber,
+		"supersedes":        "0x" + previousRoot,
+	})
+	return replacement, nil
+}
+
+// replaceableSnapshot returns the stored snapshot of the vault's epoch when its root may be replaced: the epoch
+// is the vault's latest distributed one, its root is the one on-chain, and no run of it is still staged or
+// submitting. A later epoch's cumulative leaves build on the epoch, so only the latest one is replaced.
+func (d *LazyDistributor) replaceableSnapshot(ctx context.Context, vaultId string, epochNumber *big.Int) (*merkle.MerkleSnapshot, error) {
+	current, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	latest, err := d.previousSnapshot(ctx, vaultId, nil)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && latest.EpochNumber.Cmp(epochNumber) != 0 {
+		return nil, fmt.Errorf("%w: epoch %s of vault %s was distributed after epoch %s",
+			subsidy.ErrRootNotReplaceable, latest.EpochNumber.String(), vaultId, epochNumber.String())
+	}
+
+	if d.approval.enabled {
+		staged, err := d.pendingFor(ctx, vaultId, epochNumber)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check staged distributions: %w", err)
+		}
+		if staged != nil {
+			return nil, fmt.Errorf("%w: distribution %s of vault %s epoch %s is awaiting approval",
+				subsidy.ErrRootNotReplaceable, staged.ID, vaultId, epochNumber.String())
+		}
+	}
+	pending, err := d.store.GetSubmission(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	if pending != nil && !pending.RootPushed {
+		return nil, fmt.Errorf("%w: the distribution of vault %s epoch %s is still being submitted",
+			subsidy.ErrRootNotReplaceable, vaultId, epochNumber.String())
+	}
+
+	onChain, err := d.blockchainClient.GetMerkleRoot(ctx, vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain merkle root: %w", err)
+	}
+	if fmt.Sprintf("%x", onChain) != strings.ToLower(strings.TrimPrefix(current.MerkleRoot, "0x")) {
+		return nil, fmt.Errorf("%w: vault %s has root %x on-chain, not the root %s of epoch %s",
+			subsidy.ErrRootNotReplaceable, vaultId, onChain, current.MerkleRoot, epochNumber.String())
+	}
+	return current, nil
+}
+
+// epochCarry returns what the vault's epoch distribution was carried in, as caps and rounding recorded it
+func (d *LazyDistributor) epochCarry(ctx context.Context, vaultId string, epochNumber *big.Int) (*carry, error) {
+	carried := &carry{dust: new(big.Rat)}
+	var err error
+	if d.caps.enabled() {
+		if carried.caps, err = d.store.GetCarriedIn(ctx, epochNumber, vaultId); err != nil {
+			return nil, err
+		}
+	}
+	if d.rounding.carryForward() {
+		record, err := d.store.GetRoundingRecord(ctx, epochNumber, vaultId)
+		if err != nil {
+			return nil, err
+		}
+		if record != nil {
+			carried.dust = ratOrZero(record.CarriedIn)
+		}
+	}
+	return carried, nil
+}
+
+// recordSupersession records the replaced root as superseded by the replacement. The root is already pushed,
+// so a failure is logged and the replacement goes on.
+func (d *LazyDistributor) recordSupersession(ctx context.Context, replacement *subsidy.RootReplacement) {
+	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
+	if !ok {
+		d.logger.Logf("WARN merkle service is not the expected implementation type, not recording root supersession")
+		return
+	}
+	supersession := merkle.RootSupersession{
+		VaultAddress:   replacement.VaultID,
+		EpochNumber:    replacement.EpochNumber,
+		PreviousRoot:   replacement.PreviousRoot,
+		MerkleRoot:     replacement.MerkleRoot,
+		PreviousTotal:  replacement.PreviousTotal,
+		TotalSubsidies: replacement.TotalSubsidies,
+		Reason:         replacement.Reason,
+		ReplacedBy:     replacement.ReplacedBy,
+		ReplacedAt:     *replacement.ReplacedAt,
+	}
+	if err := merkleImpl.RecordSupersession(ctx, supersession); err != nil {
+		d.logger.Logf("ERROR failed to record root %s of vault %s epoch %s as superseded by %s: %v",
+			replacement.PreviousRoot, replacement.VaultID, replacement.EpochNumber, replacement.MerkleRoot, err)
+	}
+}
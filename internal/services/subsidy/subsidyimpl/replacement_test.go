@@ -0,0 +1,91 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func TestLazyDistributor_ReplaceRoot(t *testing.T) {
+	distributor := newBlocklistTestDistributor(t, subsidy.BlockedRemainderBurn)
+	chain := distributor.blockchainClient.(*blockchain.BlockchainClientMock)
+	// the vault's on-chain root is the last one pushed
+	chain.GetMerkleRootFunc = func(ctx context.Context, vaultId string) ([32]byte, error) {
+		calls := chain.UpdateMerkleRootAndWaitForConfirmationCalls()
+		if len(calls) == 0 {
+			return [32]byte{}, nil
+		}
+		return calls[len(calls)-1].Root, nil
+	}
+	ctx := context.Background()
+	epoch := big.NewInt(3)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, epoch)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", result.TotalSubsidies.String())
+
+	_, err = distributor.ReplaceRoot(ctx, planTestVault, epoch, "nothing changed", true)
+	require.ErrorIs(t, err, subsidy.ErrRootNotReplaceable, "a recomputation building the pushed root replaces nothing")
+
+	// B should have been left out of the epoch
+	_, err = distributor.BlockAddress(ctx, subsidy.BlockedAddress{Address: holdingsTestOwnerB, Reason: "sanctioned"})
+	require.NoError(t, err)
+
+	dryRun, err := distributor.ReplaceRoot(ctx, planTestVault, epoch, "blocked account was left in the tree", false)
+	require.NoError(t, err)
+	assert.False(t, dryRun.Confirmed)
+	assert.Equal(t, result.MerkleRoot, dryRun.PreviousRoot)
+	assert.Equal(t, "1000", dryRun.PreviousTotal)
+	assert.Equal(t, "500", dryRun.TotalSubsidies)
+	assert.Equal(t, 1, dryRun.Diff.DroppedAccounts)
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1, "an unconfirmed replacement pushes nothing")
+
+	replacement, err := distributor.ReplaceRoot(ctx, planTestVault, epoch, "blocked account was left in the tree", true)
+	require.NoError(t, err)
+	assert.True(t, replacement.Confirmed)
+	assert.Equal(t, dryRun.MerkleRoot, replacement.MerkleRoot)
+	pushes := chain.UpdateMerkleRootAndWaitForConfirmationCalls()
+	require.Len(t, pushes, 2)
+	assert.Equal(t, replacement.MerkleRoot, fmt.Sprintf("%x", pushes[1].Root))
+	assert.Equal(t, "500", pushes[1].TotalSubsidies.String())
+
+	merkleImpl := distributor.merkleService.(*merkleimpl.Service)
+	snapshot, err := merkleImpl.GetSnapshot(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, replacement.MerkleRoot, snapshot.MerkleRoot)
+	assert.Equal(t, replacement.PreviousRoot, snapshot.Supersedes)
+
+	// proofs against the replaced root still resolve and report the root that replaced it
+	old, err := merkleImpl.GenerateMerkleProofForRoot(ctx, holdingsTestOwnerA, planTestVault, "3", replacement.PreviousRoot)
+	require.NoError(t, err)
+	assert.Equal(t, replacement.MerkleRoot, old.SupersededBy)
+	current, err := merkleImpl.GenerateMerkleProofForRoot(ctx, holdingsTestOwnerA, planTestVault, "3", replacement.MerkleRoot)
+	require.NoError(t, err)
+	assert.Empty(t, current.SupersededBy)
+
+	verified, err := merkleImpl.VerifyProofs(ctx, []merkle.ProofToVerify{{
+		VaultAddress: planTestVault, Recipient: holdingsTestOwnerA, TotalEarned: old.TotalEarned, MerkleProof: old.MerkleProof,
+	}}, false)
+	require.NoError(t, err)
+	assert.Equal(t, replacement.MerkleRoot, verified.Results[0].SupersededBy)
+
+	fingerprint, err := distributor.store.GetFingerprint(ctx, epoch, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, replacement.Fingerprint, fingerprint.Hash)
+	assert.True(t, fingerprint.Committed, "the replacement's fingerprint is committed once it is pushed")
+
+	// a later epoch builds on the replaced one, which can no longer be replaced
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.NoError(t, err)
+	_, err = distributor.ReplaceRoot(ctx, planTestVault, epoch, "too late", false)
+	require.ErrorIs(t, err, subsidy.ErrRootNotReplaceable)
+}
@@ -0,0 +1,220 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Replay rebuilds an epoch's distribution the way takeSnapshot would today: the vault's subsidies are
+// read at the stored snapshot block, valued at its valuation time, weighed by holding time when configured,
+// stripped of the accounts the epoch left out as blocked, clamped by the configured caps with the amount the epoch was carried in and the debts it recorded, and rounded by
+// the configured policy with the dust it was carried in, stripped of the accounts it left out below the
+// minimum allocation and of what it recorded as expired unclaimed. The result is diffed per account against the
+// stored snapshot, so a change in valuation or caps that moves past allocations shows up as drift.
+func (d *LazyDistributor) Replay(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+) (_ *subsidy.ReplayResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.Replay",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	snapshot, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &subsidy.ReplayResult{
+		VaultID:       vaultId,
+		EpochNumber:   epochNumber.String(),
+		BlockNumber:   snapshot.BlockNumber,
+		StoredRoot:    snapshot.MerkleRoot,
+		StoredEntries: len(snapshot.Entries),
+		Drift:         make([]subsidy.AllocationDrift, 0),
+	}
+	result.ValuedAt, result.ValuedAtEstimated = snapshotValuedAt(snapshot)
+
+	var positions wrappedPositions
+	if len(d.holdings.wrappers) > 0 {
+		if positions, err = d.loadWrappedPositions(ctx, vaultId, &snapshot.BlockNumber); err != nil {
+			return nil, fmt.Errorf("failed to get wrapped positions at block %d: %w", snapshot.BlockNumber, err)
+		}
+	}
+
+	// weights are set for epoch ranges, so the epoch is valued with those its distribution recorded
+	weights, err := d.appliedWeights(ctx, vaultId, epochNumber)
+	if err != nil {
+		return nil, err
+	}
+	// so is the blocklist, which leaves out the accounts the distribution left out
+	blocked, err := d.store.GetBlockedRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	// and the accounts it left out below the minimum allocation, which depend on the tree before it
+	deferred, err := d.store.GetDeferredRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	// and what expired unclaimed by it, which depends on claims at the snapshot block
+	expired, err := d.store.GetExpiryRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocations []*allocation
+	collections := make(map[string]string)
+	err = d.subgraphClient.StreamAccountSubsidiesForVaultAtBlock(ctx, vaultId, snapshot.BlockNumber,
+		func(page []subgraph.AccountSubsidy) error {
+			for _, accountSubsidy := range page {
+				collections[accountSubsidy.CollectionParticipation] = accountSubsidy.Collection
+			}
+			page, _ = d.holdings.normalize(page, positions, weights)
+			valued, _ := d.valueSubsidiesAt(page, result.ValuedAt)
+			allocations = append(allocations, valued...)
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account subsidies at block %d: %w", snapshot.BlockNumber, err)
+	}
+	if d.timeWeighted(ctx) {
+		normalize := func(page []subgraph.AccountSubsidy) []subgraph.AccountSubsidy {
+			normalized, _ := d.holdings.normalize(page, positions, weights)
+			return normalized
+		}
+		allocations, err = d.weighByHoldingTime(ctx, vaultId, epochNumber, uint64(snapshot.BlockNumber), result.ValuedAt,
+			allocations, collections, normalize)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	allocations, _ = d.blocklist.exclude(allocations, blocked.accounts())
+	if d.caps.enabled() {
+		carriedIn, err := d.store.GetCarriedIn(ctx, epochNumber, vaultId)
+		if err != nil {
+			return nil, err
+		}
+		// accounts are held to what they owed when the epoch was distributed, epochs that did not read it hold none
+		if d.caps.outstandingDebt {
+			debts, err := d.store.GetDebtRecord(ctx, epochNumber, vaultId)
+			if err != nil {
+				return nil, err
+			}
+			if debts != nil {
+				debts.apply(allocations)
+			}
+		}
+		d.caps.apply(allocations, carriedIn)
+	}
+
+	// the dust an epoch was carried in is recorded with it, and earlier epochs recorded none
+	dustIn := new(big.Rat)
+	record, err := d.store.GetRoundingRecord(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	if record != nil {
+		if carried, ok := new(big.Rat).SetString(record.CarriedIn); ok && d.rounding.carryForward() {
+			dustIn = carried
+		}
+	}
+	d.rounding.apply(allocations, dustIn)
+	allocations = withoutAccounts(allocations, deferred.accounts())
+	expired.apply(allocations)
+
+	entries, total := entriesFor(allocations)
+	result.RecomputedEntries = len(entries)
+	result.RecomputedTotal = total.String()
+	if len(entries) > 0 {
+		root, err := d.generateMerkleRoot(ctx, entries, snapshot.Encoding())
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate merkle root: %w", err)
+		}
+		result.RecomputedRoot = fmt.Sprintf("%x", root)
+	}
+	result.Matches = strings.EqualFold(result.RecomputedRoot, result.StoredRoot)
+
+	stored := make(map[string]*big.Int)
+	storedTotal := big.NewInt(0)
+	for _, entry := range snapshot.Entries {
+		addAmount(stored, entry.Address, entry.TotalEarned)
+		storedTotal.Add(storedTotal, entry.TotalEarned)
+	}
+	result.StoredTotal = storedTotal.String()
+	result.Drift = allocationDrift(stored, entries)
+	if result.Diff, err = d.diffWithPrevious(ctx, vaultId, epochNumber, entries, subsidy.DefaultDiffTop); err != nil {
+		return nil, err
+	}
+
+	// the subgraph indexes the root each epoch committed on-chain, which a replay can also be held to
+	if distribution, err := d.subgraphClient.QueryMerkleDistributionForEpoch(ctx, epochNumber.String(), vaultId); err == nil {
+		result.OnChainRoot = strings.TrimPrefix(strings.ToLower(distribution.MerkleRoot), "0x")
+	} else {
+		d.logger.Logf("WARN no on-chain root indexed for vault %s epoch %s: %v", vaultId, epochNumber.String(), err)
+	}
+
+	if !result.Matches {
+		d.logger.Logf("WARN replay of vault %s epoch %s drifted: root %s recomputed as %s, %d accounts changed",
+			vaultId, epochNumber.String(), result.StoredRoot, result.RecomputedRoot, len(result.Drift))
+	}
+	return result, nil
+}
+
+// allocationDrift lists the accounts whose summed amount differs between stored and recomputed, by address
+func allocationDrift(stored map[string]*big.Int, recomputedEntries []merkle.Entry) []subsidy.AllocationDrift {
+	recomputed := make(map[string]*big.Int)
+	for _, entry := range recomputedEntries {
+		addAmount(recomputed, entry.Address, entry.TotalEarned)
+	}
+
+	accounts := make(map[string]bool, len(stored)+len(recomputed))
+	for account := range stored {
+		accounts[account] = true
+	}
+	for account := range recomputed {
+		accounts[account] = true
+	}
+
+	drift := make([]subsidy.AllocationDrift, 0)
+	for account := range accounts {
+		before, after := amountOrZero(stored[account]), amountOrZero(recomputed[account])
+		if before.Cmp(after) == 0 {
+			continue
+		}
+		drift = append(drift, subsidy.AllocationDrift{
+			Account:    account,
+			Stored:     before.String(),
+			Recomputed: after.String(),
+			Difference: new(big.Int).Sub(after, before).String(),
+		})
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Account < drift[j].Account })
+	return drift
+}
+
+func addAmount(amounts map[string]*big.Int, address string, amount *big.Int) {
+	account := utils.NormalizeAddress(address)
+	if _, ok := amounts[account]; !ok {
+		amounts[account] = big.NewInt(0)
+	}
+	amounts[account].Add(amounts[account], amount)
+}
+
+func amountOrZero(amount *big.Int) *big.Int {
+	if amount == nil {
+		return big.NewInt(0)
+	}
+	return amount
+}
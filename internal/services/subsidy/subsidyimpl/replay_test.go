@@ -0,0 +1,95 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// newReplayTestSubgraph serves subsidies to both the live stream and the stream pinned to a block
+func newReplayTestSubgraph(t *testing.T, subsidies *[]subgraph.AccountSubsidy, onChainRoot string) *subgraph.SubgraphClientMock {
+	return &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(*subsidies)
+		},
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			assert.Equal(t, int64(100), blockNumber, "replays read the snapshot block")
+			return fn(*subsidies)
+		},
+		QueryMerkleDistributionForEpochFunc: func(ctx context.Context, epochNumber, vaultAddress string) (*subgraph.MerkleDistribution, error) {
+			if onChainRoot == "" {
+				return nil, errors.New("merkle distribution not found")
+			}
+			return &subgraph.MerkleDistribution{MerkleRoot: onChainRoot}, nil
+		},
+	}
+}
+
+func TestLazyDistributor_ReplayMatchesUnchangedState(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.caps = capPolicy{userMax: big.NewInt(2500)}
+	subsidies := explainTestSubsidies()
+	distributor.subgraphClient = newReplayTestSubgraph(t, &subsidies, "")
+	ctx := context.Background()
+
+	distributed, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	distributor.subgraphClient = newReplayTestSubgraph(t, &subsidies, "0x"+distributed.MerkleRoot)
+
+	result, err := distributor.Replay(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, result.Matches)
+	assert.Empty(t, result.Drift)
+	assert.Equal(t, distributed.MerkleRoot, result.StoredRoot)
+	assert.Equal(t, distributed.MerkleRoot, result.RecomputedRoot)
+	assert.Equal(t, distributed.MerkleRoot, result.OnChainRoot)
+	assert.Equal(t, distributed.TotalSubsidies.String(), result.RecomputedTotal)
+	assert.Equal(t, int64(100), result.BlockNumber)
+	require.NotNil(t, result.Diff)
+	assert.Empty(t, result.Diff.PreviousEpochNumber, "the vault's first distribution has nothing to diff against")
+	assert.Equal(t, result.RecomputedTotal, result.Diff.TotalGrowth)
+}
+
+func TestLazyDistributor_ReplayReportsDrift(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	// the user earns 1000 and the other account 3000, both in collection a
+	subsidies := []subgraph.AccountSubsidy{explainTestSubsidies()[0], explainTestSubsidies()[2]}
+	distributor.subgraphClient = newReplayTestSubgraph(t, &subsidies, "")
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	// a cap introduced after the epoch was distributed moves the other account's allocation
+	distributor.caps = capPolicy{userMax: big.NewInt(2000), carryForward: true}
+	result, err := distributor.Replay(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	assert.False(t, result.Matches)
+	assert.NotEqual(t, result.StoredRoot, result.RecomputedRoot)
+	assert.Empty(t, result.OnChainRoot)
+	require.Len(t, result.Drift, 1)
+	assert.Equal(t, subsidy.AllocationDrift{
+		Account:    "0x1111111111111111111111111111111111111111",
+		Stored:     "3000",
+		Recomputed: "2000",
+		Difference: "-1000",
+	}, result.Drift[0])
+
+	_, err = distributor.Replay(ctx, planTestVault, big.NewInt(6))
+	assert.ErrorIs(t, err, subsidy.ErrNotFound)
+}
@@ -0,0 +1,272 @@
+package subsidyimpl
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/events"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// submission is an epoch's distribution kept from before its root is pushed until the epoch is completed,
+// keyed by epoch, vault and snapshot block. A run retried after the push failed, or after the epoch failed
+// to complete, resumes at the submission step with it instead of recomputing the distribution.
+type submission struct {
+	VaultID             string    `json:"vaultId"`
+	EpochNumber         string    `json:"epochNumber"`
+	BlockNumber         uint64    `json:"blockNumber"`
+	BlockHash           string    `json:"blockHash"`
+	BlockStrategy       string    `json:"blockStrategy"`
+	MerkleRoot          string    `json:"merkleRoot"` // hex without 0x
+	TotalSubsidies      string    `json:"totalSubsidies"`
+	AccountsProcessed   int       `json:"accountsProcessed"`
+	AccountsQuarantined int       `json:"accountsQuarantined,omitempty"`
+	AccountsDeferred    int       `json:"accountsDeferred,omitempty"`
+	CarriedIn           string    `json:"carriedIn,omitempty"`      // wei caps carried in, set when caps are configured
+	CarriedForward      string    `json:"carriedForward,omitempty"` // wei caps carry forward, set when caps are configured
+	DustCarriedIn       string    `json:"dustCarriedIn,omitempty"`  // dust carried in, set when rounding carries forward
+	DustCarried         string    `json:"dustCarried,omitempty"`    // dust carried forward, set when rounding carries forward
+	Fingerprint         string    `json:"fingerprint,omitempty"`    // hash of the inputs the distribution was computed from
+	RootPushed          bool      `json:"rootPushed"`
+	CreatedAt           time.Time `json:"createdAt"`
+}
+
+// newSubmission records the snapshot of the vault's epoch before its root is pushed
+func newSubmission(vaultId string, epochNumber *big.Int, snapshot *distributionSnapshot) submission {
+	pending := submission{
+		VaultID:             vaultId,
+		EpochNumber:         epochNumber.String(),
+		BlockNumber:         snapshot.block.Number,
+		BlockHash:           snapshot.block.Hash,
+		BlockStrategy:       snapshot.strategy,
+		MerkleRoot:          fmt.Sprintf("%x", snapshot.merkleRoot),
+		TotalSubsidies:      snapshot.totalSubsidies.String(),
+		AccountsProcessed:   len(snapshot.entries),
+		AccountsQuarantined: len(snapshot.quarantined),
+		AccountsDeferred:    len(snapshot.deferred.Accounts),
+		CreatedAt:           time.Now(),
+	}
+	if snapshot.carriedIn != nil {
+		pending.CarriedIn = snapshot.carriedIn.String()
+		pending.CarriedForward = snapshot.carriedOut.String()
+	}
+	if snapshot.fingerprint != nil {
+		pending.Fingerprint = snapshot.fingerprint.Hash
+	}
+	if snapshot.dustCarriedOut != nil {
+		pending.DustCarriedIn = snapshot.rounding.CarriedIn
+		pending.DustCarried = snapshot.dustCarriedOut.RatString()
+	}
+	return pending
+}
+
+// result reports the kept distribution as a resumed run
+func (p *submission) result() *subsidy.DistributionResult {
+	total, ok := new(big.Int).SetString(p.TotalSubsidies, 10)
+	if !ok {
+		total = big.NewInt(0)
+	}
+	return &subsidy.DistributionResult{
+		TotalSubsidies:      total,
+		AccountsProcessed:   p.AccountsProcessed,
+		MerkleRoot:          p.MerkleRoot,
+		AccountsQuarantined: p.AccountsQuarantined,
+		AccountsDeferred:    p.AccountsDeferred,
+		Resumed:             true,
+	}
+}
+
+// keepSubmission records the snapshot so a failed push or epoch completion can be resumed. Resuming is an
+// optimization, so a failure is logged and the distribution goes on.
+func (d *LazyDistributor) keepSubmission(ctx context.Context, vaultId string, epochNumber *big.Int, pending submission) {
+	if err := d.store.SaveSubmission(ctx, epochNumber, pending); err != nil {
+		d.logger.Logf("WARN failed to keep distribution of vault %s epoch %s for resuming: %v", vaultId, epochNumber.String(), err)
+	}
+}
+
+// FinishSubmission forgets the distribution kept for resuming the vault's epoch, once the epoch is completed
+func (d *LazyDistributor) FinishSubmission(ctx context.Context, vaultId string, epochNumber *big.Int) error {
+	return d.store.DeleteSubmission(ctx, epochNumber, vaultId)
+}
+
+// resume submits the distribution an earlier run of the vault's epoch computed, when there is one and its
+// inputs have not changed since. It returns nil when the distribution must be computed again.
+func (d *LazyDistributor) resume(ctx context.Context, vaultId string, epochNumber *big.Int) (*subsidy.DistributionResult, error) {
+	pending, err := d.store.GetSubmission(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, nil
+	}
+
+	if err := d.checkSubmission(ctx, epochNumber, pending); err != nil {
+		d.logger.Logf("WARN distribution of vault %s epoch %s computed at block %d is stale, recomputing: %v",
+			vaultId, pending.EpochNumber, pending.BlockNumber, err)
+		if err := d.store.DeleteSubmission(ctx, epochNumber, vaultId); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	if pending.RootPushed {
+		d.logger.Logf("INFO merkle root %s of vault %s epoch %s is already pushed, resuming at epoch completion",
+			pending.MerkleRoot, vaultId, pending.EpochNumber)
+		return pending.result(), nil
+	}
+
+	merkleRoot, totalSubsidies, err := pending.decode()
+	if err != nil {
+		return nil, err
+	}
+	onChain, err := d.blockchainClient.GetMerkleRoot(ctx, vaultId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read on-chain merkle root: %w", err)
+	}
+	if onChain == merkleRoot {
+		// the earlier push landed even though waiting for it failed
+		d.logger.Logf("INFO merkle root %s of vault %s epoch %s is already on-chain", pending.MerkleRoot, vaultId, pending.EpochNumber)
+	} else {
+		d.logger.Logf("INFO resuming distribution of vault %s epoch %s computed at block %d, pushing merkle root %s",
+			vaultId, pending.EpochNumber, pending.BlockNumber, pending.MerkleRoot)
+		if err := d.updateMerkleRoot(ctx, vaultId, merkleRoot, totalSubsidies); err != nil {
+			d.logger.Logf("ERROR failed to update merkle root on blockchain: %v", err)
+			return nil, fmt.Errorf("failed to update merkle root on blockchain: %w", err)
+		}
+	}
+	d.rootPushed(ctx, vaultId, epochNumber, pending)
+
+	d.events.Publish(ctx, events.Event{
+		Type:    events.RootSubmitted,
+		EpochID: pending.EpochNumber,
+		Vault:   vaultId,
+		Data: map[string]interface{}{
+			"merkleRoot":        "0x" + pending.MerkleRoot,
+			"totalSubsidies":    pending.TotalSubsidies,
+			"accountsProcessed": pending.AccountsProcessed,
+			"blockNumber":       pending.BlockNumber,
+		},
+	})
+	return pending.result(), nil
+}
+
+// rootPushed settles what the pushed distribution carries forward, commits its fingerprint and marks its
+// submission pushed, so a run retried before the epoch is completed goes straight to completing it
+func (d *LazyDistributor) rootPushed(ctx context.Context, vaultId string, epochNumber *big.Int, pending *submission) {
+	if carried, ok := new(big.Int).SetString(pending.CarriedForward, 10); ok {
+		d.saveCarryForward(ctx, vaultId, carried)
+	}
+	if dust, ok := new(big.Rat).SetString(pending.DustCarried); ok {
+		d.saveDustCarry(ctx, vaultId, dust)
+	}
+	d.commitFingerprint(ctx, vaultId, epochNumber, pending.Fingerprint)
+	pending.RootPushed = true
+	d.keepSubmission(ctx, vaultId, epochNumber, *pending)
+}
+
+// checkSubmission verifies the kept distribution still stands: its snapshot block is canonical and still the
+// one the epoch is snapshotted at, the stored tree still builds its root, and what it carried in is still
+// what the vault carries
+func (d *LazyDistributor) checkSubmission(ctx context.Context, epochNumber *big.Int, pending *submission) error {
+	block, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(pending.BlockNumber))
+	if err != nil {
+		return fmt.Errorf("failed to get snapshot block %d: %w", pending.BlockNumber, err)
+	}
+	if block.Hash != pending.BlockHash {
+		return fmt.Errorf("%w: block %d hash changed from %s to %s",
+			subsidy.ErrSnapshotReorged, pending.BlockNumber, pending.BlockHash, block.Hash)
+	}
+
+	pin, err := d.store.GetSnapshotBlockPin(ctx, epochNumber, pending.VaultID)
+	if err != nil {
+		return err
+	}
+	if pin != nil && pin.BlockNumber != pending.BlockNumber {
+		return fmt.Errorf("%w: block %d was pinned for the snapshot since", subsidy.ErrSnapshotStale, pin.BlockNumber)
+	}
+
+	merkleImpl, ok := d.merkleService.(*merkleimpl.Service)
+	if !ok {
+		return fmt.Errorf("merkle service is not the expected implementation type")
+	}
+	snapshot, err := merkleImpl.GetSnapshot(ctx, epochNumber, pending.VaultID)
+	if err != nil {
+		return fmt.Errorf("failed to get merkle snapshot: %w", err)
+	}
+	if snapshot.MerkleRoot != pending.MerkleRoot || uint64(snapshot.BlockNumber) != pending.BlockNumber {
+		return fmt.Errorf("%w: merkle snapshot was replaced by root %s at block %d",
+			merkle.ErrRootMismatch, snapshot.MerkleRoot, snapshot.BlockNumber)
+	}
+	entries := make([]merkle.Entry, len(snapshot.Entries))
+	for i, entry := range snapshot.Entries {
+		entries[i] = merkle.Entry(entry)
+	}
+	if root := merkleImpl.BuildMerkleRootWithEncoding(entries, snapshot.Encoding()); fmt.Sprintf("%x", root) != pending.MerkleRoot {
+		return fmt.Errorf("%w: stored merkle snapshot builds root %x, not %s", merkle.ErrRootMismatch, root, pending.MerkleRoot)
+	}
+	if err := checkLeafTotal(entries, mustAmount(pending.TotalSubsidies)); err != nil {
+		return err
+	}
+
+	// once the root is pushed the carries have moved on to what it carried forward
+	if pending.RootPushed {
+		return nil
+	}
+	if pending.CarriedIn != "" {
+		carriedIn, err := d.store.GetCarryForward(ctx, pending.VaultID)
+		if err != nil {
+			return err
+		}
+		if carriedIn.String() != pending.CarriedIn {
+			return fmt.Errorf("caps now carry %s wei in, not %s", carriedIn, pending.CarriedIn)
+		}
+	}
+	if pending.DustCarried != "" {
+		dustIn, err := d.store.GetDustCarry(ctx, pending.VaultID)
+		if err != nil {
+			return err
+		}
+		if expected := ratOrZero(pending.DustCarriedIn); dustIn.Cmp(expected) != 0 {
+			return fmt.Errorf("rounding now carries %s wei of dust in, not %s", dustIn.RatString(), expected.RatString())
+		}
+	}
+	return nil
+}
+
+// decode returns the kept root and total in the form they are pushed in
+func (p *submission) decode() ([32]byte, *big.Int, error) {
+	var merkleRoot [32]byte
+	rootBytes, err := hex.DecodeString(p.MerkleRoot)
+	if err != nil || len(rootBytes) != 32 {
+		return merkleRoot, nil, fmt.Errorf("kept distribution of vault %s epoch %s has a malformed merkle root %q",
+			p.VaultID, p.EpochNumber, p.MerkleRoot)
+	}
+	copy(merkleRoot[:], rootBytes)
+	totalSubsidies, ok := new(big.Int).SetString(p.TotalSubsidies, 10)
+	if !ok {
+		return merkleRoot, nil, fmt.Errorf("kept distribution of vault %s epoch %s has a malformed total %q",
+			p.VaultID, p.EpochNumber, p.TotalSubsidies)
+	}
+	return merkleRoot, totalSubsidies, nil
+}
+
+// mustAmount parses a wei amount, zero when it is malformed
+func mustAmount(amount string) *big.Int {
+	if parsed, ok := new(big.Int).SetString(amount, 10); ok {
+		return parsed
+	}
+	return big.NewInt(0)
+}
+
+// ratOrZero parses a fraction of a wei such as 3/4, zero when it is empty or malformed
+func ratOrZero(value string) *big.Rat {
+	if parsed, ok := new(big.Rat).SetString(value); ok {
+		return parsed
+	}
+	return new(big.Rat)
+}
@@ -0,0 +1,134 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+// newResumeTestChain is a chain whose pushes fail with *pushErr and whose on-chain root is *onChain
+func newResumeTestChain(pushErr *error, onChain *[32]byte) *blockchain.BlockchainClientMock {
+	chain := newApprovalTestChain(nil)
+	chain.UpdateMerkleRootAndWaitForConfirmationFunc = func(ctx context.Context, vaultId string, root [32]byte, totalSubsidies *big.Int) error {
+		return *pushErr
+	}
+	chain.GetMerkleRootFunc = func(ctx context.Context, vaultId string) ([32]byte, error) {
+		return *onChain, nil
+	}
+	return chain
+}
+
+func streamedTimes(d *LazyDistributor) int {
+	return len(d.subgraphClient.(*subgraph.SubgraphClientMock).StreamAccountSubsidiesForVaultCalls())
+}
+
+func TestLazyDistributor_ResumesAfterFailedPush(t *testing.T) {
+	db := newPlannerTestDB(t)
+	pushErr := errors.New("transaction reverted")
+	var onChain [32]byte
+	chain := newResumeTestChain(&pushErr, &onChain)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{})
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.Error(t, err)
+	require.Equal(t, 1, streamedTimes(distributor))
+
+	pushErr = nil
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, result.Resumed)
+	assert.Equal(t, "1000", result.TotalSubsidies.String())
+	assert.Equal(t, 1, streamedTimes(distributor), "the kept distribution is pushed without reading the subgraph again")
+	pushes := chain.UpdateMerkleRootAndWaitForConfirmationCalls()
+	require.Len(t, pushes, 2)
+	assert.Equal(t, pushes[0].Root, pushes[1].Root)
+
+	// completing the epoch failed, so the next run goes straight to completing it
+	again, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, again.Resumed)
+	assert.Equal(t, result.MerkleRoot, again.MerkleRoot)
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 2, "a pushed root is not pushed again")
+
+	require.NoError(t, distributor.FinishSubmission(ctx, planTestVault, big.NewInt(5)))
+	// the chain head is valued when the run starts, so a later run of the committed epoch needs an override
+	_, err = distributor.OverrideFingerprint(ctx, planTestVault, big.NewInt(5), "recompute")
+	require.NoError(t, err)
+	fresh, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.False(t, fresh.Resumed)
+	assert.Equal(t, 2, streamedTimes(distributor))
+}
+
+func TestLazyDistributor_RecomputesWhenInputsChanged(t *testing.T) {
+	pushFailed := errors.New("transaction reverted")
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T, d *LazyDistributor, chain *blockchain.BlockchainClientMock)
+	}{
+		{
+			name: "snapshot_block_reorged",
+			change: func(t *testing.T, d *LazyDistributor, chain *blockchain.BlockchainClientMock) {
+				chain.GetBlockRefFunc = func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+					return &blockchain.BlockRef{Number: 100, Hash: "0xb"}, nil
+				}
+			},
+		},
+		{
+			name: "carry_changed",
+			change: func(t *testing.T, d *LazyDistributor, chain *blockchain.BlockchainClientMock) {
+				require.NoError(t, d.store.SaveCarryForward(context.Background(), planTestVault, big.NewInt(7)))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := newPlannerTestDB(t)
+			pushErr := pushFailed
+			var onChain [32]byte
+			chain := newResumeTestChain(&pushErr, &onChain)
+			distributor := newApprovalTestDistributor(db, chain, approvalPolicy{})
+			distributor.caps = capPolicy{userMax: big.NewInt(10_000), carryForward: true}
+			ctx := context.Background()
+
+			_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+			require.Error(t, err)
+
+			tt.change(t, distributor, chain)
+			pushErr = nil
+			result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+			require.NoError(t, err)
+			assert.False(t, result.Resumed)
+			assert.Equal(t, 2, streamedTimes(distributor), "a stale distribution is computed again")
+		})
+	}
+}
+
+func TestLazyDistributor_ResumeSkipsRootAlreadyOnChain(t *testing.T) {
+	db := newPlannerTestDB(t)
+	pushErr := errors.New("timed out waiting for receipt")
+	var onChain [32]byte
+	chain := newResumeTestChain(&pushErr, &onChain)
+	distributor := newApprovalTestDistributor(db, chain, approvalPolicy{})
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.Error(t, err)
+
+	// the push landed even though waiting for it failed
+	onChain = chain.UpdateMerkleRootAndWaitForConfirmationCalls()[0].Root
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, result.Resumed)
+	assert.Len(t, chain.UpdateMerkleRootAndWaitForConfirmationCalls(), 1)
+}
@@ -0,0 +1,216 @@
+package subsidyimpl
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// roundingPolicy decides what happens to the dust of a distribution: the fractions of a wei its allocations
+// were rounded down by when they were valued. Subsidies worth less than a wei are skipped before they
+// become allocations, so their fractions are not part of it.
+type roundingPolicy struct {
+	mode string
+}
+
+func newRoundingPolicy(cfg *config.Config) roundingPolicy {
+	return roundingPolicy{mode: cfg.Rounding.Policy}
+}
+
+// carryForward reports whether dust is left for the next distribution rather than settled in this one
+func (p roundingPolicy) carryForward() bool {
+	return p.mode == subsidy.RoundingCarryForward
+}
+
+// roundingResult is what the rounding policy did with one distribution's dust
+type roundingResult struct {
+	dust       *big.Rat // fractions of a wei this distribution's allocations were rounded down by
+	carriedIn  *big.Rat // dust the previous distribution left for this one
+	paid       *big.Int // wei added to allocations
+	carriedOut *big.Rat // dust left for the next distribution, zero unless the policy carries forward
+	left       *big.Rat // dust neither paid nor carried forward
+}
+
+// apply settles the dust of allocations after caps. Largest holders pays the whole wei of this
+// distribution's dust and carry forward those of carriedIn, one wei each to the largest allocations;
+// whatever is not paid is left, or carried forward with this distribution's dust.
+func (p roundingPolicy) apply(allocations []*allocation, carriedIn *big.Rat) roundingResult {
+	result := roundingResult{
+		dust:       new(big.Rat),
+		carriedIn:  new(big.Rat).Set(carriedIn),
+		paid:       big.NewInt(0),
+		carriedOut: new(big.Rat),
+		left:       new(big.Rat),
+	}
+	for _, a := range allocations {
+		result.dust.Add(result.dust, a.roundedOff)
+	}
+
+	switch p.mode {
+	case subsidy.RoundingLargestHolders:
+		result.paid = payLargest(allocations, wholeWei(result.dust))
+		result.left.Sub(result.dust, new(big.Rat).SetInt(result.paid))
+	case subsidy.RoundingCarryForward:
+		result.paid = payLargest(allocations, wholeWei(carriedIn))
+		result.carriedOut.Sub(carriedIn, new(big.Rat).SetInt(result.paid))
+		result.carriedOut.Add(result.carriedOut, result.dust)
+	default:
+		result.left.Set(result.dust)
+	}
+	return result
+}
+
+// wholeWei returns the whole wei in dust
+func wholeWei(dust *big.Rat) *big.Int {
+	return new(big.Int).Quo(dust.Num(), dust.Denom())
+}
+
+// payLargest adds wei to the allocations one at a time, largest amount first, skipping allocations whose
+// account or collection reached its cap. Ties are broken by account and collection, so replays pay the
+// same allocations. It returns the wei paid, less than wei only when no allocation has room left.
+func payLargest(allocations []*allocation, wei *big.Int) *big.Int {
+	paid := big.NewInt(0)
+	if wei.Sign() == 0 {
+		return paid
+	}
+
+	ranked := make([]*allocation, 0, len(allocations))
+	for _, a := range allocations {
+		if a.amount.Sign() > 0 {
+			ranked = append(ranked, a)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if c := ranked[i].amount.Cmp(ranked[j].amount); c != 0 {
+			return c > 0
+		}
+		if ranked[i].account != ranked[j].account {
+			return utils.NormalizeAddress(ranked[i].account) < utils.NormalizeAddress(ranked[j].account)
+		}
+		return ranked[i].collection < ranked[j].collection
+	})
+
+	one := big.NewInt(1)
+	for paid.Cmp(wei) < 0 {
+		placed := false
+		for _, a := range ranked {
+			if paid.Cmp(wei) >= 0 {
+				break
+			}
+			// caps group allocations only when they are configured
+			if a.collectionGroup != nil && (a.collectionGroup.full() || a.userGroup.full()) {
+				continue
+			}
+			a.amount.Add(a.amount, one)
+			a.roundingBonus.Add(a.roundingBonus, one)
+			paid.Add(paid, one)
+			placed = true
+		}
+		if !placed {
+			break
+		}
+	}
+	return paid
+}
+
+// roundingRecord is what rounding did to an epoch's distribution, kept with the epoch so explanations
+// can show the wei an allocation received and replays can start from the same carried in dust.
+// Fractions of a wei are ratios such as 3/4.
+type roundingRecord struct {
+	Policy     string          `json:"policy"`
+	Dust       string          `json:"dust"`
+	CarriedIn  string          `json:"carriedIn,omitempty"`
+	Paid       string          `json:"paid"`
+	CarriedOut string          `json:"carriedOut,omitempty"`
+	Left       string          `json:"left"`
+	Bonuses    []roundingBonus `json:"bonuses,omitempty"`
+}
+
+// roundingBonus is the wei rounding added to one allocation
+type roundingBonus struct {
+	Account                 string `json:"account"`
+	CollectionParticipation string `json:"collectionParticipation"`
+	Amount                  string `json:"amount"`
+}
+
+// record returns the record of the result, with the allocations it paid
+func (r roundingResult) record(policy string, allocations []*allocation) roundingRecord {
+	record := roundingRecord{
+		Policy: policy,
+		Dust:   r.dust.RatString(),
+		Paid:   r.paid.String(),
+		Left:   r.left.RatString(),
+	}
+	if r.carriedIn.Sign() > 0 {
+		record.CarriedIn = r.carriedIn.RatString()
+	}
+	if r.carriedOut.Sign() > 0 {
+		record.CarriedOut = r.carriedOut.RatString()
+	}
+	for _, a := range allocations {
+		if a.roundingBonus.Sign() > 0 {
+			record.Bonuses = append(record.Bonuses, roundingBonus{
+				Account:                 utils.NormalizeAddress(a.account),
+				CollectionParticipation: a.collection,
+				Amount:                  a.roundingBonus.String(),
+			})
+		}
+	}
+	return record
+}
+
+// bonusesFor returns the wei rounding added to each of the account's allocations, by collection participation
+func (r *roundingRecord) bonusesFor(account string) map[string]*big.Int {
+	bonuses := make(map[string]*big.Int)
+	if r == nil {
+		return bonuses
+	}
+	for _, bonus := range r.Bonuses {
+		if bonus.Account != account {
+			continue
+		}
+		if amount, ok := new(big.Int).SetString(bonus.Amount, 10); ok {
+			bonuses[bonus.CollectionParticipation] = amount
+		}
+	}
+	return bonuses
+}
+
+// checkLeafTotal verifies the merkle leaves add up to total, the total expected of a distribution or pushed with
+// their root, which the contract holds claims against
+func checkLeafTotal(entries []merkle.Entry, total *big.Int) error {
+	sum := big.NewInt(0)
+	for _, entry := range entries {
+		sum.Add(sum, entry.TotalEarned)
+	}
+	if sum.Cmp(total) != 0 {
+		return fmt.Errorf("merkle leaves add up to %s wei but the distribution total is %s", sum, total)
+	}
+	return nil
+}
+
+// expectedTotal works out what the snapshot's leaves add up to from what each step of the distribution recorded
+// doing rather than from the leaves: the wei allocated, less what blocked accounts left to burn, plus what caps
+// were carried in less what they carried forward, plus the wei rounding paid and the adjustments applied, less
+// what accounts below the minimum were deferred and what was withheld for expiring unclaimed. A step changing
+// the allocations by other than what it recorded leaves the leaves adding up to something else.
+func (s *distributionSnapshot) expectedTotal() *big.Int {
+	total := new(big.Int).Set(amountOrZero(s.allocated))
+	total.Sub(total, mustAmount(s.blocked.Burned))
+	total.Add(total, amountOrZero(s.carriedIn))
+	total.Sub(total, amountOrZero(s.carriedOut))
+	total.Add(total, mustAmount(s.rounding.Paid))
+	for _, adjustment := range s.adjustments {
+		total.Add(total, mustAmount(adjustment.Applied))
+	}
+	total.Sub(total, mustAmount(s.deferred.Deferred))
+	if s.expiry != nil {
+		total.Sub(total, mustAmount(s.expiry.Withheld))
+	}
+	return total
+}
@@ -0,0 +1,199 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// roundingTestAllocations are three allocations rounded down by 1/2, 3/4 and 1/2 wei
+func roundingTestAllocations() []*allocation {
+	var allocations []*allocation
+	for _, a := range []struct {
+		account    string
+		amount     int64
+		roundedOff *big.Rat
+	}{
+		{"0x1111111111111111111111111111111111111111", 100, big.NewRat(1, 2)},
+		{"0x2222222222222222222222222222222222222222", 300, big.NewRat(3, 4)},
+		{"0x3333333333333333333333333333333333333333", 300, big.NewRat(1, 2)},
+	} {
+		allocation := newAllocation(subgraph.AccountSubsidy{
+			Account:                 subgraph.Account{ID: a.account},
+			CollectionParticipation: "0xcollection-a",
+		}, big.NewInt(a.amount))
+		allocation.roundedOff = a.roundedOff
+		allocations = append(allocations, allocation)
+	}
+	return allocations
+}
+
+func TestRoundingPolicy_Apply(t *testing.T) {
+	amounts := func(allocations []*allocation) []string {
+		var result []string
+		for _, a := range allocations {
+			result = append(result, a.amount.String())
+		}
+		return result
+	}
+
+	t.Run("floor", func(t *testing.T) {
+		allocations := roundingTestAllocations()
+		result := roundingPolicy{mode: subsidy.RoundingFloor}.apply(allocations, new(big.Rat))
+		assert.Equal(t, "7/4", result.dust.RatString())
+		assert.Equal(t, "0", result.paid.String())
+		assert.Equal(t, "7/4", result.left.RatString())
+		assert.Equal(t, []string{"100", "300", "300"}, amounts(allocations))
+	})
+
+	t.Run("largest holders", func(t *testing.T) {
+		allocations := roundingTestAllocations()
+		result := roundingPolicy{mode: subsidy.RoundingLargestHolders}.apply(allocations, new(big.Rat))
+		assert.Equal(t, "1", result.paid.String())
+		assert.Equal(t, "3/4", result.left.RatString())
+		assert.Equal(t, []string{"100", "301", "300"}, amounts(allocations), "ties go to the lower address")
+
+		record := result.record(subsidy.RoundingLargestHolders, allocations)
+		assert.Equal(t, []roundingBonus{{
+			Account:                 "0x2222222222222222222222222222222222222222",
+			CollectionParticipation: "0xcollection-a",
+			Amount:                  "1",
+		}}, record.Bonuses)
+	})
+
+	t.Run("carry forward", func(t *testing.T) {
+		allocations := roundingTestAllocations()
+		result := roundingPolicy{mode: subsidy.RoundingCarryForward}.apply(allocations, big.NewRat(9, 4))
+		assert.Equal(t, "2", result.paid.String(), "the whole wei carried in are paid")
+		assert.Equal(t, "2", result.carriedOut.RatString(), "1/4 carried in and 7/4 of dust")
+		assert.Equal(t, "0", result.left.RatString())
+		assert.Equal(t, []string{"100", "301", "301"}, amounts(allocations))
+	})
+
+	t.Run("caps", func(t *testing.T) {
+		allocations := roundingTestAllocations()
+		caps := capPolicy{userMax: big.NewInt(300)}
+		caps.apply(allocations, big.NewInt(0))
+		result := roundingPolicy{mode: subsidy.RoundingLargestHolders}.apply(allocations, new(big.Rat))
+		assert.Equal(t, []string{"101", "300", "300"}, amounts(allocations), "capped accounts are passed over")
+		assert.Equal(t, "1", result.paid.String())
+	})
+}
+
+func TestCheckLeafTotal(t *testing.T) {
+	entries := []merkle.Entry{
+		{Address: "0x1111111111111111111111111111111111111111", TotalEarned: big.NewInt(100)},
+		{Address: "0x2222222222222222222222222222222222222222", TotalEarned: big.NewInt(301)},
+	}
+	require.NoError(t, checkLeafTotal(entries, big.NewInt(401)))
+	err := checkLeafTotal(entries, big.NewInt(400))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "merkle leaves add up to 401 wei but the distribution total is 400")
+}
+
+func TestDistributionSnapshot_ExpectedTotalCatchesMiscountedWei(t *testing.T) {
+	distributor, _ := newAdjustmentTestDistributor(t)
+	distributor.rounding = roundingPolicy{mode: subsidy.RoundingLargestHolders}
+	ctx := context.Background()
+	proposed, err := distributor.ProposeAdjustment(adminContext("api:10.0.0.1", "alice-key"), subsidy.AllocationAdjustment{
+		VaultID: planTestVault, EpochNumber: "5", Account: adjustmentTestAccount, Amount: "200", Justification: "missed deposit",
+	})
+	require.NoError(t, err)
+	_, err = distributor.DecideAdjustment(adminContext("api:10.0.0.2", "bob-key"), proposed.ID, true, "")
+	require.NoError(t, err)
+
+	snapshot, err := distributor.takeSnapshot(ctx, planTestVault, big.NewInt(5), nil)
+	require.NoError(t, err)
+	require.Len(t, snapshot.adjustments, 1)
+	require.NoError(t, checkLeafTotal(snapshot.entries, snapshot.expectedTotal()))
+
+	// a wei rounding pays without recording it
+	payLargest(snapshot.allocations, big.NewInt(1))
+	entries, _ := entriesFor(snapshot.allocations)
+	assert.Error(t, checkLeafTotal(entries, snapshot.expectedTotal()))
+
+	// an adjustment applied twice
+	snapshot, err = distributor.takeSnapshot(ctx, planTestVault, big.NewInt(5), nil)
+	require.NoError(t, err)
+	applyAdjustments(snapshot.allocations, []subsidy.AllocationAdjustment{{Account: adjustmentTestAccount, Amount: "200"}})
+	entries, _ = entriesFor(snapshot.allocations)
+	err = checkLeafTotal(entries, snapshot.expectedTotal())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "but the distribution total is "+snapshot.totalSubsidies.String())
+}
+
+func TestLazyDistributor_RoundingCarryForwardAndExplain(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.rounding = roundingPolicy{mode: subsidy.RoundingCarryForward}
+	// accrued deposit-seconds worth 1.5 and 2.75 wei
+	subsidies := []subgraph.AccountSubsidy{
+		{
+			Account:                 subgraph.Account{ID: explainTestUser},
+			CollectionParticipation: "0xcollection-a",
+			SecondsAccumulated:      "1500000000000000000",
+			LastEffectiveValue:      "0",
+			UpdatedAtTimestamp:      "0",
+			TotalRewardsEarned:      "0",
+		},
+		{
+			Account:                 subgraph.Account{ID: "0x1111111111111111111111111111111111111111"},
+			CollectionParticipation: "0xcollection-a",
+			SecondsAccumulated:      "2750000000000000000",
+			LastEffectiveValue:      "0",
+			UpdatedAtTimestamp:      "0",
+			TotalRewardsEarned:      "0",
+		},
+	}
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress, accountAddress string,
+			blockNumber int64,
+		) ([]subgraph.AccountSubsidy, error) {
+			return subsidies[1:], nil
+		},
+	}
+	ctx := context.Background()
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, "3", result.TotalSubsidies.String(), "the first epoch pays only whole wei")
+	carried, err := distributor.store.GetDustCarry(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "5/4", carried.RatString())
+
+	// the next epoch pays the whole wei carried in to the largest allocation
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Equal(t, "4", result.TotalSubsidies.String())
+	carried, err = distributor.store.GetDustCarry(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Equal(t, "3/2", carried.RatString(), "1/4 left over and another 5/4")
+
+	record, err := distributor.store.GetRoundingRecord(ctx, big.NewInt(6), planTestVault)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, "5/4", record.Dust)
+	assert.Equal(t, "5/4", record.CarriedIn)
+	assert.Equal(t, "1", record.Paid)
+	assert.Equal(t, "3/2", record.CarriedOut)
+
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(6), subsidies[1].Account.ID)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches, "computed %s, distributed %s", explanation.ComputedAmount, explanation.DistributedAmount)
+	require.Len(t, explanation.Collections, 1)
+	assert.Equal(t, "3", explanation.Collections[0].Amount)
+	assert.Equal(t, "3/4", explanation.Collections[0].RoundedOff)
+	assert.Equal(t, "1", explanation.Collections[0].RoundingBonus)
+}
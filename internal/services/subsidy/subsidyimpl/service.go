@@ -2,80 +2,658 @@ package subsidyimpl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/logging"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/epoch"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/webhook"
 	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type Service struct {
-	lazyDistributor subsidy.LazyDistributor
-	epochService    epoch.Service
-	logger          lgr.L
-	config          *config.Config
+	lazyDistributor  subsidy.LazyDistributor
+	repaymentPlanner subsidy.RepaymentPlanner
+	epochService     epoch.Service
+	notifier         webhook.Notifier
+	logger           lgr.L
+	config           *config.Config
 }
 
-func New(lazyDistributor subsidy.LazyDistributor, epochService epoch.Service, logger lgr.L, cfg *config.Config) *Service {
+func New(
+	lazyDistributor subsidy.LazyDistributor,
+	repaymentPlanner subsidy.RepaymentPlanner,
+	epochService epoch.Service,
+	notifier webhook.Notifier,
+	logger lgr.L,
+	cfg *config.Config,
+) *Service {
 	return &Service{
-		lazyDistributor: lazyDistributor,
-		epochService:    epochService,
-		logger:          logger,
-		config:          cfg,
+		lazyDistributor:  lazyDistributor,
+		repaymentPlanner: repaymentPlanner,
+		epochService:     epochService,
+		notifier:         notifier,
+		logger:           logger,
+		config:           cfg,
 	}
 }
 
-func (s *Service) DistributeSubsidies(ctx context.Context, vaultId string) (*subsidy.SubsidyDistributionResponse, error) {
+func (s *Service) DistributeSubsidies(ctx context.Context, vaultId string) (_ *subsidy.SubsidyDistributionResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.DistributeSubsidies", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
 	if vaultId == "" {
 		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
 	}
 
-	s.logger.Logf("INFO starting subsidy distribution for vault %s", vaultId)
+	ctx = logging.WithFields(ctx, logging.Fields{Vault: vaultId})
+	logger := logging.FromContext(ctx, s.logger)
+	logger.Logf("INFO starting subsidy distribution for vault %s", vaultId)
 
 	currentEpochId, err := s.epochService.GetCurrentEpochId(ctx)
 	if err != nil {
-		s.logger.Logf("ERROR failed to get current epoch ID: %v", err)
+		logger.Logf("ERROR failed to get current epoch ID: %v", err)
 		return nil, fmt.Errorf("failed to get current epoch ID: %w", err)
 	}
 
 	if currentEpochId == 0 {
-		s.logger.Logf("ERROR no active epoch found for distribution")
-		return nil, fmt.Errorf("%w: no active epoch found (epoch ID is 0)", subsidy.ErrInvalidEpochState)
+		logger.Logf("ERROR no active epoch found for distribution")
+		return nil, fmt.Errorf("%w: epoch ID is 0", epoch.ErrEpochNotActive)
 	}
 
-	s.logger.Logf("INFO distributing subsidies for epoch %d in vault %s", currentEpochId, vaultId)
+	ctx = logging.WithFields(ctx, logging.Fields{EpochID: strconv.FormatUint(currentEpochId, 10)})
+	logger = logging.FromContext(ctx, s.logger)
+	logger.Logf("INFO distributing subsidies for epoch %d in vault %s", currentEpochId, vaultId)
 
 	distributionResult, err := s.lazyDistributor.RunWithEpoch(ctx, vaultId, big.NewInt(int64(currentEpochId)))
 	if err != nil {
-		s.logger.Logf("ERROR subsidy distribution failed for vault %s: %v", vaultId, err)
+		if errors.Is(err, subsidy.ErrSubgraphLagging) {
+			// subgraph.lagging was sent, the distribution is attempted again once the subgraph caught up
+			logger.Logf("WARN subsidy distribution for vault %s delayed: %v", vaultId, err)
+			return nil, err
+		}
+		logger.Logf("ERROR subsidy distribution failed for vault %s: %v", vaultId, err)
+		s.notifyFailure(ctx, vaultId, currentEpochId, "distribution", err)
 		if isTransactionError(err) {
 			return nil, fmt.Errorf("%w: failed to run lazy distributor for vault %s: %v", subsidy.ErrTransactionFailed, vaultId, err)
 		}
 		return nil, fmt.Errorf("failed to run lazy distributor for vault %s: %w", vaultId, err)
 	}
 
-	s.logger.Logf("INFO successfully completed subsidy distribution for vault %s", vaultId)
+	if distributionResult.StagedID != "" {
+		logger.Logf("INFO distribution for epoch %d in vault %s awaits approval as %s", currentEpochId, vaultId, distributionResult.StagedID)
+		return &subsidy.SubsidyDistributionResponse{
+			VaultID:             vaultId,
+			EpochID:             strconv.FormatUint(currentEpochId, 10),
+			TotalSubsidies:      distributionResult.TotalSubsidies.String(),
+			AccountsProcessed:   distributionResult.AccountsProcessed,
+			MerkleRoot:          distributionResult.MerkleRoot,
+			Status:              subsidy.StagedPendingApproval,
+			StagedID:            distributionResult.StagedID,
+			AccountsQuarantined: distributionResult.AccountsQuarantined,
+			AccountsDeferred:    distributionResult.AccountsDeferred,
+			Checks:              distributionResult.Checks,
+		}, nil
+	}
+
+	logger.Logf("INFO successfully completed subsidy distribution for vault %s", vaultId)
 
 	epochResponse, err := s.epochService.CompleteEpochAfterDistribution(ctx, currentEpochId, vaultId)
 	if err != nil {
-		s.logger.Logf("ERROR failed to complete epoch %d after distribution for vault %s: %v", currentEpochId, vaultId, err)
+		logger.Logf("ERROR failed to complete epoch %d after distribution for vault %s: %v", currentEpochId, vaultId, err)
+		s.notifyFailure(ctx, vaultId, currentEpochId, "epoch_completion", err)
 		return nil, fmt.Errorf("failed to complete epoch %d after subsidy distribution for vault %s: %w", currentEpochId, vaultId, err)
 	}
 
-	s.logger.Logf("INFO successfully completed epoch %s after distribution for vault %s", epochResponse.EpochID, vaultId)
+	logger.Logf("INFO successfully completed epoch %s after distribution for vault %s", epochResponse.EpochID, vaultId)
+	if err := s.lazyDistributor.FinishSubmission(ctx, vaultId, big.NewInt(int64(currentEpochId))); err != nil {
+		logger.Logf("WARN failed to forget submitted distribution of vault %s epoch %d: %v", vaultId, currentEpochId, err)
+	}
 
 	return &subsidy.SubsidyDistributionResponse{
-		VaultID:           vaultId,
-		EpochID:           epochResponse.EpochID,
-		TotalSubsidies:    distributionResult.TotalSubsidies.String(),
-		AccountsProcessed: distributionResult.AccountsProcessed,
-		MerkleRoot:        distributionResult.MerkleRoot,
-		Status:            "completed",
+		VaultID:             vaultId,
+		EpochID:             epochResponse.EpochID,
+		TotalSubsidies:      distributionResult.TotalSubsidies.String(),
+		AccountsProcessed:   distributionResult.AccountsProcessed,
+		MerkleRoot:          distributionResult.MerkleRoot,
+		Status:              "completed",
+		AccountsQuarantined: distributionResult.AccountsQuarantined,
+		AccountsDeferred:    distributionResult.AccountsDeferred,
+		Resumed:             distributionResult.Resumed,
+		Checks:              distributionResult.Checks,
 	}, nil
 }
 
+func (s *Service) RepayBorrowers(
+	ctx context.Context,
+	planID, vaultId string,
+	repayments []subsidy.Repayment,
+) (_ *subsidy.RepaymentPlan, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.RepayBorrowers", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+
+	plan, err := s.repaymentPlanner.Execute(ctx, planID, vaultId, repayments)
+	if err != nil {
+		s.logger.Logf("ERROR repayment plan %s for vault %s stopped: %v", planID, vaultId, err)
+		if plan != nil {
+			s.notifier.Notify(ctx, webhook.EventDistributionFailed, map[string]interface{}{
+				"vaultAddress": vaultId,
+				"planId":       planID,
+				"stage":        "repayment",
+				"error":        err.Error(),
+			})
+		}
+		return plan, err
+	}
+
+	s.logger.Logf("INFO repayment plan %s for vault %s finished with status %s in %d chunks",
+		planID, vaultId, plan.Status, len(plan.Chunks))
+	return plan, nil
+}
+
+func (s *Service) ListStagedDistributions(ctx context.Context, status string) (_ []subsidy.StagedDistribution, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ListStagedDistributions", attribute.String("staged.status", status))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	switch status {
+	case "", subsidy.StagedPendingApproval, subsidy.StagedApproved, subsidy.StagedRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown staged distribution status %q", subsidy.ErrInvalidInput, status)
+	}
+
+	return s.lazyDistributor.ListStaged(ctx, status)
+}
+
+func (s *Service) ApproveDistribution(ctx context.Context, id string) (_ *subsidy.SubsidyDistributionResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ApproveDistribution", attribute.String("staged.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	staged, err := s.lazyDistributor.SubmitStaged(ctx, id)
+	if err != nil {
+		if errors.Is(err, subsidy.ErrInvalidInput) || errors.Is(err, subsidy.ErrNotFound) {
+			return nil, err
+		}
+		s.logger.Logf("ERROR failed to submit staged distribution %s: %v", id, err)
+		s.notifier.Notify(ctx, webhook.EventDistributionFailed, map[string]interface{}{
+			"stagedId": id,
+			"stage":    "approval",
+			"error":    err.Error(),
+		})
+		if isTransactionError(err) {
+			return nil, fmt.Errorf("%w: failed to submit staged distribution %s: %v", subsidy.ErrTransactionFailed, id, err)
+		}
+		return nil, fmt.Errorf("failed to submit staged distribution %s: %w", id, err)
+	}
+
+	response := &subsidy.SubsidyDistributionResponse{
+		VaultID:           staged.VaultID,
+		EpochID:           staged.EpochNumber,
+		TotalSubsidies:    staged.TotalSubsidies,
+		AccountsProcessed: staged.AccountsProcessed,
+		MerkleRoot:        staged.MerkleRoot,
+		Status:            "completed",
+		StagedID:          staged.ID,
+	}
+	if staged.EpochNumber == "" {
+		return response, nil
+	}
+
+	epochId, err := strconv.ParseUint(staged.EpochNumber, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("staged distribution %s has an invalid epoch %q: %w", id, staged.EpochNumber, err)
+	}
+	if _, err := s.epochService.CompleteEpochAfterDistribution(ctx, epochId, staged.VaultID); err != nil {
+		s.logger.Logf("ERROR failed to complete epoch %d after approved distribution %s: %v", epochId, id, err)
+		s.notifyFailure(ctx, staged.VaultID, epochId, "epoch_completion", err)
+		return nil, fmt.Errorf("failed to complete epoch %d after approved distribution %s: %w", epochId, id, err)
+	}
+
+	s.logger.Logf("INFO approved distribution %s completed epoch %d for vault %s", id, epochId, staged.VaultID)
+	return response, nil
+}
+
+func (s *Service) RejectDistribution(ctx context.Context, id, reason string) (_ *subsidy.StagedDistribution, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.RejectDistribution", attribute.String("staged.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.lazyDistributor.RejectStaged(ctx, id, reason)
+}
+
+func (s *Service) ExplainAllocation(
+	ctx context.Context,
+	vaultId, epochNumber, userAddress string,
+) (_ *subsidy.AllocationExplanation, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ExplainAllocation",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+	if userAddress == "" {
+		return nil, fmt.Errorf("%w: userAddress cannot be empty", subsidy.ErrInvalidInput)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+
+	return s.lazyDistributor.Explain(ctx, vaultId, epochNum, userAddress)
+}
+
+// maxExplainUsers bounds the users one batched explanation may cover
+const maxExplainUsers = 1000
+
+func (s *Service) ExplainAllocations(
+	ctx context.Context,
+	vaultId, epochNumber string,
+	userAddresses []string,
+) (_ *subsidy.AllocationExplanations, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ExplainAllocations",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber),
+		attribute.Int("users", len(userAddresses)))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+	if len(userAddresses) == 0 {
+		return nil, fmt.Errorf("%w: at least one user address is required", subsidy.ErrInvalidInput)
+	}
+	if len(userAddresses) > maxExplainUsers {
+		return nil, fmt.Errorf("%w: at most %d users can be explained at once, got %d",
+			subsidy.ErrInvalidInput, maxExplainUsers, len(userAddresses))
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+
+	return s.lazyDistributor.ExplainBatch(ctx, vaultId, epochNum, userAddresses)
+}
+
+func (s *Service) ListQuarantinedAccounts(
+	ctx context.Context,
+	vaultId, epochNumber string,
+) (_ []subsidy.QuarantinedAccount, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ListQuarantinedAccounts",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+	var epochNum *big.Int
+	if epochNumber != "" {
+		var ok bool
+		epochNum, ok = new(big.Int).SetString(epochNumber, 10)
+		if !ok || epochNum.Sign() < 0 {
+			return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+		}
+	}
+
+	return s.lazyDistributor.ListQuarantined(ctx, vaultId, epochNum)
+}
+
+func (s *Service) ReplayEpoch(ctx context.Context, vaultId, epochNumber string) (_ *subsidy.ReplayResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ReplayEpoch",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId == "" {
+		return nil, fmt.Errorf("%w: vaultId cannot be empty", subsidy.ErrInvalidInput)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+
+	return s.lazyDistributor.Replay(ctx, vaultId, epochNum)
+}
+
+func (s *Service) PinSnapshotBlock(
+	ctx context.Context,
+	vaultId, epochNumber string,
+	blockNumber uint64,
+) (_ *subsidy.SnapshotBlockPin, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.PinSnapshotBlock",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+
+	return s.lazyDistributor.PinSnapshotBlock(ctx, utils.NormalizeAddress(vaultId), epochNum, blockNumber)
+}
+
+func (s *Service) OverrideFingerprint(
+	ctx context.Context,
+	vaultId, epochNumber, reason string,
+) (_ *subsidy.FingerprintOverride, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.OverrideFingerprint",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required to override a committed distribution", subsidy.ErrInvalidInput)
+	}
+
+	return s.lazyDistributor.OverrideFingerprint(ctx, utils.NormalizeAddress(vaultId), epochNum, reason)
+}
+
+func (s *Service) ReplaceRoot(
+	ctx context.Context,
+	vaultId, epochNumber, reason string,
+	confirm bool,
+) (_ *subsidy.RootReplacement, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ReplaceRoot",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber),
+		attribute.Bool("confirm", confirm))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+	if reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required to replace a pushed root", subsidy.ErrInvalidInput)
+	}
+
+	return s.lazyDistributor.ReplaceRoot(ctx, utils.NormalizeAddress(vaultId), epochNum, reason, confirm)
+}
+
+func (s *Service) DiffAllocations(
+	ctx context.Context,
+	vaultId, epochNumber string,
+	top int,
+) (_ *subsidy.AllocationDiff, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.DiffAllocations",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+	if top < 0 || top > subsidy.MaxDiffTop {
+		return nil, fmt.Errorf("%w: top must be between 1 and %d, got %d", subsidy.ErrInvalidInput, subsidy.MaxDiffTop, top)
+	}
+	if top == 0 {
+		top = subsidy.DefaultDiffTop
+	}
+
+	return s.lazyDistributor.Diff(ctx, utils.NormalizeAddress(vaultId), epochNum, top)
+}
+
+func (s *Service) EpochStats(ctx context.Context, epochNumber, vaultId string) (_ *subsidy.EpochStats, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.EpochStats",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId != "" && !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+	}
+
+	return s.lazyDistributor.Stats(ctx, epochNum, utils.NormalizeAddress(vaultId))
+}
+
+func (s *Service) SetCollectionWeight(
+	ctx context.Context,
+	weight subsidy.CollectionWeight,
+) (_ *subsidy.CollectionWeight, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.SetCollectionWeight",
+		attribute.String("vault.id", weight.VaultID), attribute.String("collection", weight.Collection))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(weight.VaultID) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, weight.VaultID)
+	}
+	if !utils.IsValidAddress(weight.Collection) {
+		return nil, fmt.Errorf("%w: invalid collection address %q", subsidy.ErrInvalidInput, weight.Collection)
+	}
+	multiplier, ok := new(big.Rat).SetString(weight.Multiplier)
+	if !ok || multiplier.Sign() <= 0 || multiplier.Cmp(big.NewRat(subsidy.MaxCollectionMultiplier, 1)) > 0 {
+		return nil, fmt.Errorf("%w: multiplier must be a positive decimal of at most %d, got %q",
+			subsidy.ErrInvalidInput, subsidy.MaxCollectionMultiplier, weight.Multiplier)
+	}
+	if weight.FromEpoch == 0 || weight.ToEpoch < weight.FromEpoch {
+		return nil, fmt.Errorf("%w: epochs must run from 1 or later up to an epoch no earlier than the first, got %d to %d",
+			subsidy.ErrInvalidInput, weight.FromEpoch, weight.ToEpoch)
+	}
+
+	weight.VaultID = utils.NormalizeAddress(weight.VaultID)
+	weight.Collection = utils.NormalizeAddress(weight.Collection)
+	return s.lazyDistributor.SetCollectionWeight(ctx, weight)
+}
+
+func (s *Service) ListCollectionWeights(ctx context.Context, vaultId string) (_ []subsidy.CollectionWeight, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ListCollectionWeights", attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+
+	return s.lazyDistributor.ListCollectionWeights(ctx, utils.NormalizeAddress(vaultId))
+}
+
+func (s *Service) DeleteCollectionWeight(ctx context.Context, vaultId, collection string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.DeleteCollectionWeight",
+		attribute.String("vault.id", vaultId), attribute.String("collection", collection))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultId) {
+		return fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	if !utils.IsValidAddress(collection) {
+		return fmt.Errorf("%w: invalid collection address %q", subsidy.ErrInvalidInput, collection)
+	}
+
+	return s.lazyDistributor.DeleteCollectionWeight(ctx, utils.NormalizeAddress(vaultId), utils.NormalizeAddress(collection))
+}
+
+func (s *Service) BlockAddress(ctx context.Context, blocked subsidy.BlockedAddress) (_ *subsidy.BlockedAddress, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.BlockAddress", attribute.String("address", blocked.Address))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(blocked.Address) {
+		return nil, fmt.Errorf("%w: invalid address %q", subsidy.ErrInvalidInput, blocked.Address)
+	}
+
+	blocked.Address = utils.NormalizeAddress(blocked.Address)
+	return s.lazyDistributor.BlockAddress(ctx, blocked)
+}
+
+func (s *Service) ListBlockedAddresses(ctx context.Context) (_ []subsidy.BlockedAddress, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ListBlockedAddresses")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.lazyDistributor.ListBlockedAddresses(ctx)
+}
+
+func (s *Service) UnblockAddress(ctx context.Context, address string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.UnblockAddress", attribute.String("address", address))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(address) {
+		return fmt.Errorf("%w: invalid address %q", subsidy.ErrInvalidInput, address)
+	}
+
+	return s.lazyDistributor.UnblockAddress(ctx, utils.NormalizeAddress(address))
+}
+
+func (s *Service) ProposeAdjustment(
+	ctx context.Context,
+	adjustment subsidy.AllocationAdjustment,
+) (_ *subsidy.AllocationAdjustment, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ProposeAdjustment",
+		attribute.String("vault.id", adjustment.VaultID), attribute.String("epoch.number", adjustment.EpochNumber))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(adjustment.VaultID) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, adjustment.VaultID)
+	}
+	epochNum, ok := new(big.Int).SetString(adjustment.EpochNumber, 10)
+	if !ok || epochNum.Sign() < 0 {
+		return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, adjustment.EpochNumber)
+	}
+	if !utils.IsValidAddress(adjustment.Account) {
+		return nil, fmt.Errorf("%w: invalid account address %q", subsidy.ErrInvalidInput, adjustment.Account)
+	}
+	if strings.TrimSpace(adjustment.Justification) == "" {
+		return nil, fmt.Errorf("%w: a justification is required to adjust an allocation", subsidy.ErrInvalidInput)
+	}
+
+	adjustment.VaultID = utils.NormalizeAddress(adjustment.VaultID)
+	adjustment.EpochNumber = epochNum.String()
+	adjustment.Account = utils.NormalizeAddress(adjustment.Account)
+	return s.lazyDistributor.ProposeAdjustment(ctx, adjustment)
+}
+
+func (s *Service) ListAdjustments(
+	ctx context.Context,
+	vaultId, epochNumber, status string,
+) (_ []subsidy.AllocationAdjustment, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ListAdjustments", attribute.String("vault.id", vaultId),
+		attribute.String("epoch.number", epochNumber), attribute.String("adjustment.status", status))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if vaultId != "" && !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+	if epochNumber != "" {
+		epochNum, ok := new(big.Int).SetString(epochNumber, 10)
+		if !ok || epochNum.Sign() < 0 {
+			return nil, fmt.Errorf("%w: invalid epoch number %q", subsidy.ErrInvalidInput, epochNumber)
+		}
+		epochNumber = epochNum.String()
+	}
+	switch status {
+	case "", subsidy.AdjustmentPendingApproval, subsidy.AdjustmentApproved, subsidy.AdjustmentRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown adjustment status %q", subsidy.ErrInvalidInput, status)
+	}
+
+	return s.lazyDistributor.ListAdjustments(ctx, utils.NormalizeAddress(vaultId), epochNumber, status)
+}
+
+func (s *Service) ApproveAdjustment(ctx context.Context, id string) (_ *subsidy.AllocationAdjustment, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.ApproveAdjustment", attribute.String("adjustment.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.lazyDistributor.DecideAdjustment(ctx, id, true, "")
+}
+
+func (s *Service) RejectAdjustment(ctx context.Context, id, reason string) (_ *subsidy.AllocationAdjustment, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.RejectAdjustment", attribute.String("adjustment.id", id))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.lazyDistributor.DecideAdjustment(ctx, id, false, reason)
+}
+
+func (s *Service) UserHistory(ctx context.Context, userAddress, vaultId string) (_ []subsidy.EpochPosition, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.UserHistory",
+		attribute.String("user.address", userAddress), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(userAddress) {
+		return nil, fmt.Errorf("%w: invalid user address %q", subsidy.ErrInvalidInput, userAddress)
+	}
+	if vaultId != "" && !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+
+	positions, err := s.lazyDistributor.ListPositions(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	if vaultId == "" {
+		return positions, nil
+	}
+	vault := utils.NormalizeAddress(vaultId)
+	filtered := make([]subsidy.EpochPosition, 0, len(positions))
+	for _, position := range positions {
+		if position.VaultID == vault {
+			filtered = append(filtered, position)
+		}
+	}
+	return filtered, nil
+}
+
+func (s *Service) UserExpirations(ctx context.Context, userAddress, vaultId string) (_ *subsidy.UserExpirations, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.UserExpirations",
+		attribute.String("user.address", userAddress), attribute.String("vault.id", vaultId))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(userAddress) {
+		return nil, fmt.Errorf("%w: invalid user address %q", subsidy.ErrInvalidInput, userAddress)
+	}
+	if vaultId != "" && !utils.IsValidAddress(vaultId) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", subsidy.ErrInvalidInput, vaultId)
+	}
+
+	expirations, err := s.lazyDistributor.ListExpirations(ctx, userAddress)
+	if err != nil {
+		return nil, err
+	}
+	if vaultId == "" {
+		return expirations, nil
+	}
+	vault := utils.NormalizeAddress(vaultId)
+	filtered := make([]subsidy.ClaimExpiration, 0, len(expirations.Expirations))
+	for _, expiration := range expirations.Expirations {
+		if expiration.VaultID == vault {
+			filtered = append(filtered, expiration)
+		}
+	}
+	expirations.Expirations = filtered
+	return expirations, nil
+}
+
+// notifyFailure reports a failed distribution, stage tells receivers whether the merkle root was submitted
+func (s *Service) notifyFailure(ctx context.Context, vaultId string, epochId uint64, stage string, err error) {
+	s.notifier.Notify(ctx, webhook.EventDistributionFailed, map[string]interface{}{
+		"epochId":      fmt.Sprintf("%d", epochId),
+		"vaultAddress": vaultId,
+		"stage":        stage,
+		"error":        err.Error(),
+	})
+}
+
 func isTransactionError(err error) bool {
 	errStr := err.Error()
 	transactionErrors := []string{
@@ -0,0 +1,171 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// snapshotConfirmations returns how many blocks a snapshot block must be buried under, the snapshot confirmations
+// when they are configured and the confirmation depth otherwise
+func snapshotConfirmations(cfg *config.Config) uint64 {
+	// validated when loaded
+	confirmations, _ := config.ParseConfirmations(cfg.Ethereum.Confirmations, cfg.Ethereum.ReceiptConfirmations, cfg.Ethereum.ConfirmationDepth)
+	return confirmations.Snapshot
+}
+
+// snapshotBlock returns the block the vault's distribution is snapshotted at and how it was chosen.
+// A block pinned for the epoch wins over the configured strategy.
+func (d *LazyDistributor) snapshotBlock(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+) (*blockchain.BlockRef, string, error) {
+	if epochNumber != nil {
+		pin, err := d.store.GetSnapshotBlockPin(ctx, epochNumber, vaultId)
+		if err != nil {
+			return nil, "", err
+		}
+		if pin != nil {
+			block, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(pin.BlockNumber))
+			if err != nil {
+				return nil, "", fmt.Errorf("failed to get pinned block %d: %w", pin.BlockNumber, err)
+			}
+			if block.Hash != pin.BlockHash {
+				d.logger.Logf("WARN pinned block %d of vault %s epoch %s was reorged from %s to %s, snapshotting the canonical block",
+					pin.BlockNumber, vaultId, epochNumber.String(), pin.BlockHash, block.Hash)
+			}
+			return block, subsidy.SnapshotBlockPinned, nil
+		}
+	}
+
+	switch d.snapshotStrategy {
+	case subsidy.SnapshotBlockFinalized:
+		block, err := d.blockchainClient.GetBlockRef(ctx, big.NewInt(int64(rpc.FinalizedBlockNumber)))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get finalized block: %w", err)
+		}
+		return block, subsidy.SnapshotBlockFinalized, nil
+	case subsidy.SnapshotBlockEpochEnd:
+		if epochNumber != nil {
+			block, err := d.epochEndBlock(ctx, epochNumber)
+			if err != nil {
+				return nil, "", err
+			}
+			return block, subsidy.SnapshotBlockEpochEnd, nil
+		}
+		d.logger.Logf("WARN distribution of vault %s has no epoch to end, snapshotting the chain head", vaultId)
+	}
+
+	block, err := d.blockchainClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	return block, subsidy.SnapshotBlockLatest, nil
+}
+
+// epochEndBlock returns the last block produced by the epoch's end timestamp, less the configured offset.
+// An epoch the chain has not reached the end of yet ends at the chain head.
+func (d *LazyDistributor) epochEndBlock(ctx context.Context, epochNumber *big.Int) (*blockchain.BlockRef, error) {
+	epoch, err := d.subgraphClient.QueryEpochByNumber(ctx, epochNumber.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get epoch %s: %w", epochNumber.String(), err)
+	}
+	endTimestamp, err := strconv.ParseUint(epoch.EndTimestamp, 10, 64)
+	if err != nil || endTimestamp == 0 {
+		return nil, fmt.Errorf("epoch %s has no end timestamp to snapshot at, got %q", epochNumber.String(), epoch.EndTimestamp)
+	}
+
+	last, err := d.blockchainClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if last.Timestamp > endTimestamp {
+		if last, err = d.lastBlockAt(ctx, endTimestamp, last.Number); err != nil {
+			return nil, err
+		}
+	} else {
+		d.logger.Logf("WARN epoch %s ends at %d, after the chain head %d, snapshotting the chain head",
+			epochNumber.String(), endTimestamp, last.Number)
+	}
+
+	if d.snapshotOffset == 0 {
+		return last, nil
+	}
+	if d.snapshotOffset > last.Number {
+		return nil, fmt.Errorf("snapshot block offset %d is larger than the epoch's last block %d", d.snapshotOffset, last.Number)
+	}
+	block, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(last.Number-d.snapshotOffset))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", last.Number-d.snapshotOffset, err)
+	}
+	return block, nil
+}
+
+// lastBlockAt binary searches for the last block produced at or before timestamp, block after being
+// produced after it
+func (d *LazyDistributor) lastBlockAt(ctx context.Context, timestamp, after uint64) (*blockchain.BlockRef, error) {
+	var last *blockchain.BlockRef
+	lo, hi := uint64(0), after
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		block, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(mid))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get block %d: %w", mid, err)
+		}
+		if block.Timestamp > timestamp {
+			hi = mid
+		} else {
+			last = block
+			lo = mid + 1
+		}
+	}
+	if last == nil {
+		return nil, fmt.Errorf("no block was produced by %d", timestamp)
+	}
+	return last, nil
+}
+
+// PinSnapshotBlock makes the epoch's distribution snapshot the vault at blockNumber, which must already be
+// on the chain. Pinning again replaces the block.
+func (d *LazyDistributor) PinSnapshotBlock(
+	ctx context.Context,
+	vaultId string,
+	epochNumber *big.Int,
+	blockNumber uint64,
+) (*subsidy.SnapshotBlockPin, error) {
+	head, err := d.blockchainClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest block: %w", err)
+	}
+	if blockNumber > head.Number {
+		return nil, fmt.Errorf("%w: block %d is ahead of the chain head %d", subsidy.ErrInvalidInput, blockNumber, head.Number)
+	}
+	block, err := d.blockchainClient.GetBlockRef(ctx, new(big.Int).SetUint64(blockNumber))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", blockNumber, err)
+	}
+
+	pin := subsidy.SnapshotBlockPin{
+		VaultID:     vaultId,
+		EpochNumber: epochNumber.String(),
+		BlockNumber: block.Number,
+		BlockHash:   block.Hash,
+		PinnedBy:    audit.ActorFromContext(ctx),
+		PinnedAt:    time.Now(),
+	}
+	if err := d.store.SaveSnapshotBlockPin(ctx, pin); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO pinned the snapshot of vault %s epoch %s to block %d (%s)", vaultId, pin.EpochNumber, block.Number, block.Hash)
+	return &pin, nil
+}
@@ -0,0 +1,114 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// newSnapshotBlockTestChain has blocks 0 to 1000 produced every 12 seconds from 1000, block 936 finalized
+func newSnapshotBlockTestChain() *blockchain.BlockchainClientMock {
+	chain := newApprovalTestChain(nil)
+	chain.GetBlockRefFunc = func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+		n := uint64(1000)
+		switch {
+		case blockNumber == nil:
+		case blockNumber.Int64() == int64(rpc.FinalizedBlockNumber):
+			n = 936
+		case blockNumber.Uint64() > 1000:
+			return nil, fmt.Errorf("header not found")
+		default:
+			n = blockNumber.Uint64()
+		}
+		return &blockchain.BlockRef{Number: n, Hash: fmt.Sprintf("0x%x", n), Timestamp: 1000 + 12*n}, nil
+	}
+	return chain
+}
+
+func TestLazyDistributor_SnapshotBlock(t *testing.T) {
+	tests := []struct {
+		name         string
+		strategy     string
+		offset       uint64
+		endTimestamp string
+		wantBlock    uint64
+		wantStrategy string
+	}{
+		{"latest by default", "", 0, "", 1000, subsidy.SnapshotBlockLatest},
+		{"finalized", subsidy.SnapshotBlockFinalized, 0, "", 936, subsidy.SnapshotBlockFinalized},
+		{"epoch end", subsidy.SnapshotBlockEpochEnd, 0, "7005", 500, subsidy.SnapshotBlockEpochEnd},
+		{"epoch end at a block boundary", subsidy.SnapshotBlockEpochEnd, 0, "7000", 500, subsidy.SnapshotBlockEpochEnd},
+		{"epoch end with offset", subsidy.SnapshotBlockEpochEnd, 10, "7005", 490, subsidy.SnapshotBlockEpochEnd},
+		{"epoch not ended on chain", subsidy.SnapshotBlockEpochEnd, 0, "99999", 1000, subsidy.SnapshotBlockEpochEnd},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distributor := newApprovalTestDistributor(newPlannerTestDB(t), newSnapshotBlockTestChain(), approvalPolicy{})
+			distributor.snapshotStrategy = tt.strategy
+			distributor.snapshotOffset = tt.offset
+			distributor.subgraphClient = &subgraph.SubgraphClientMock{
+				QueryEpochByNumberFunc: func(ctx context.Context, epochNumber string) (*subgraph.Epoch, error) {
+					return &subgraph.Epoch{EpochNumber: epochNumber, EndTimestamp: tt.endTimestamp}, nil
+				},
+			}
+
+			block, strategy, err := distributor.snapshotBlock(context.Background(), planTestVault, big.NewInt(5))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBlock, block.Number)
+			assert.Equal(t, tt.wantStrategy, strategy)
+		})
+	}
+}
+
+func TestLazyDistributor_PinnedSnapshotBlock(t *testing.T) {
+	distributor := newApprovalTestDistributor(newPlannerTestDB(t), newSnapshotBlockTestChain(), approvalPolicy{})
+	distributor.snapshotStrategy = subsidy.SnapshotBlockFinalized
+	var streamedAt []int64
+	subsidies := explainTestSubsidies()
+	distributor.subgraphClient = &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			streamedAt = append(streamedAt, blockNumber)
+			return fn(subsidies)
+		},
+	}
+	ctx := context.Background()
+
+	_, err := distributor.PinSnapshotBlock(ctx, planTestVault, big.NewInt(5), 1001)
+	assert.ErrorIs(t, err, subsidy.ErrInvalidInput, "blocks ahead of the chain cannot be pinned")
+
+	pin, err := distributor.PinSnapshotBlock(ctx, planTestVault, big.NewInt(5), 300)
+	require.NoError(t, err)
+	assert.Equal(t, "0x12c", pin.BlockHash)
+
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{300}, streamedAt, "the pinned block wins over the strategy")
+
+	snapshot, err := distributor.epochSnapshot(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), snapshot.BlockNumber)
+	assert.Equal(t, "0x12c", snapshot.BlockHash)
+	assert.Equal(t, subsidy.SnapshotBlockPinned, snapshot.BlockStrategy)
+	assert.Equal(t, merkle.LeafEncodingPacked, snapshot.LeafEncoding, "the tree records its leaf encoding")
+	assert.Equal(t, int64(1000+12*300), snapshot.Timestamp, "a past block is valued when it was produced")
+
+	// other epochs keep the configured strategy
+	_, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Equal(t, []int64{300, 936}, streamedAt)
+}
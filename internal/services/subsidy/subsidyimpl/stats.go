@@ -0,0 +1,143 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// newDustThreshold returns the amount below which distribution statistics count an allocation as dust,
+// 0 when none is configured
+func newDustThreshold(cfg *config.Config) *big.Int {
+	threshold, ok := new(big.Int).SetString(cfg.Stats.DustThreshold, 10)
+	if !ok || threshold.Sign() < 0 {
+		return big.NewInt(0)
+	}
+	return threshold
+}
+
+// Stats returns the statistics recorded for the epoch's distributions. A vault distributed before statistics
+// were recorded has them computed from its stored distribution.
+func (d *LazyDistributor) Stats(
+	ctx context.Context,
+	epochNumber *big.Int,
+	vaultId string,
+) (_ *subsidy.EpochStats, err error) {
+	ctx, span := tracing.StartSpan(ctx, "subsidy.LazyDistributor.Stats",
+		attribute.String("vault.id", vaultId), attribute.String("epoch.number", epochNumber.String()))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	result := &subsidy.EpochStats{EpochNumber: epochNumber.String(), Vaults: []subsidy.DistributionStats{}}
+	if vaultId == "" {
+		stats, err := d.store.ListDistributionStats(ctx, epochNumber)
+		if err != nil {
+			return nil, err
+		}
+		result.Vaults = append(result.Vaults, stats...)
+		return result, nil
+	}
+
+	stats, err := d.store.GetDistributionStats(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		snapshot, err := d.epochSnapshot(ctx, vaultId, epochNumber)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]merkle.Entry, len(snapshot.Entries))
+		for i, entry := range snapshot.Entries {
+			entries[i] = merkle.Entry(entry)
+		}
+		computed := distributionStats(entries, d.dustThreshold)
+		computed.VaultID = utils.NormalizeAddress(vaultId)
+		computed.EpochNumber = epochNumber.String()
+		computed.MerkleRoot = snapshot.MerkleRoot
+		stats = &computed
+	}
+	result.Vaults = append(result.Vaults, *stats)
+	return result, nil
+}
+
+// recordStats saves the statistics of the epoch's distribution. They only inform governance, so a
+// distribution is not held back when they cannot be saved.
+func (d *LazyDistributor) recordStats(ctx context.Context, vaultId string, epochNumber *big.Int, snapshot *distributionSnapshot) {
+	stats := distributionStats(snapshot.entries, d.dustThreshold)
+	stats.VaultID = utils.NormalizeAddress(vaultId)
+	stats.EpochNumber = epochNumber.String()
+	stats.MerkleRoot = fmt.Sprintf("%x", snapshot.merkleRoot)
+	if err := d.store.SaveDistributionStats(ctx, stats); err != nil {
+		d.logger.Logf("WARN failed to save distribution stats of vault %s epoch %s: %v", vaultId, epochNumber, err)
+		return
+	}
+	d.logger.Logf("INFO vault %s epoch %s: gini %s, top %d accounts receive %s, median %s wei, %d accounts below %s wei of dust",
+		vaultId, epochNumber, stats.Gini, subsidy.StatsTopAccounts, stats.Top10Share, stats.Median,
+		stats.DustAccounts, stats.DustThreshold)
+}
+
+// distributionStats summarizes entries, summing the amounts of an account that has several
+func distributionStats(entries []merkle.Entry, dustThreshold *big.Int) subsidy.DistributionStats {
+	if dustThreshold == nil {
+		dustThreshold = big.NewInt(0)
+	}
+	byAccount := make(map[string]*big.Int, len(entries))
+	for _, entry := range entries {
+		addAmount(byAccount, entry.Address, entry.TotalEarned)
+	}
+	amounts := make([]*big.Int, 0, len(byAccount))
+	for _, amount := range byAccount {
+		amounts = append(amounts, amount)
+	}
+	sort.Slice(amounts, func(i, j int) bool { return amounts[i].Cmp(amounts[j]) < 0 })
+
+	n := len(amounts)
+	stats := subsidy.DistributionStats{
+		Accounts:      n,
+		DustThreshold: dustThreshold.String(),
+		ComputedAt:    time.Now().UTC(),
+	}
+
+	// with amounts ascending, gini = sum((2i - n - 1) * x_i) / (n * total) for i from 1 to n
+	total, dustTotal, weighted, top := big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0)
+	for i, amount := range amounts {
+		total.Add(total, amount)
+		weighted.Add(weighted, new(big.Int).Mul(big.NewInt(int64(2*(i+1)-n-1)), amount))
+		if amount.Cmp(dustThreshold) < 0 {
+			stats.DustAccounts++
+			dustTotal.Add(dustTotal, amount)
+		}
+		if i >= n-subsidy.StatsTopAccounts {
+			top.Add(top, amount)
+		}
+	}
+
+	mean, median := big.NewInt(0), big.NewInt(0)
+	gini, share := new(big.Rat), new(big.Rat)
+	if n > 0 {
+		mean.Quo(total, big.NewInt(int64(n)))
+		median.Set(amounts[n/2])
+		if n%2 == 0 {
+			median.Add(median, amounts[n/2-1]).Rsh(median, 1)
+		}
+	}
+	if total.Sign() > 0 {
+		gini.SetFrac(weighted, new(big.Int).Mul(total, big.NewInt(int64(n))))
+		share.SetFrac(top, total)
+	}
+
+	stats.Total, stats.Mean, stats.Median = total.String(), mean.String(), median.String()
+	stats.Gini = gini.FloatString(subsidy.StatsDecimals)
+	stats.Top10Total, stats.Top10Share = top.String(), share.FloatString(subsidy.StatsDecimals)
+	stats.DustTotal = dustTotal.String()
+	return stats
+}
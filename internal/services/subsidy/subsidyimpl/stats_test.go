@@ -0,0 +1,116 @@
+package subsidyimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/merkle/merkleimpl"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+func TestDistributionStats(t *testing.T) {
+	t.Run("even distribution", func(t *testing.T) {
+		entries := []merkle.Entry{
+			{Address: "0xa", TotalEarned: big.NewInt(100)},
+			{Address: "0xb", TotalEarned: big.NewInt(100)},
+			{Address: "0xc", TotalEarned: big.NewInt(100)},
+			{Address: "0xd", TotalEarned: big.NewInt(100)},
+		}
+		stats := distributionStats(entries, big.NewInt(50))
+		assert.Equal(t, 4, stats.Accounts)
+		assert.Equal(t, "400", stats.Total)
+		assert.Equal(t, "100", stats.Median)
+		assert.Equal(t, "0.000000", stats.Gini)
+		assert.Equal(t, "1.000000", stats.Top10Share, "fewer than 10 accounts share everything among the top")
+		assert.Zero(t, stats.DustAccounts)
+	})
+
+	t.Run("concentrated distribution", func(t *testing.T) {
+		var entries []merkle.Entry
+		for i := range 19 {
+			entries = append(entries, merkle.Entry{Address: string(rune('a' + i)), TotalEarned: big.NewInt(1)})
+		}
+		entries = append(entries, merkle.Entry{Address: "whale", TotalEarned: big.NewInt(981)})
+		stats := distributionStats(entries, big.NewInt(10))
+		assert.Equal(t, 20, stats.Accounts)
+		assert.Equal(t, "1000", stats.Total)
+		assert.Equal(t, "50", stats.Mean)
+		assert.Equal(t, "1", stats.Median)
+		// the small accounts weigh sum(2i - 21) = -19 and the whale 19 * 981, over 20 * 1000
+		assert.Equal(t, "0.931000", stats.Gini)
+		assert.Equal(t, "990", stats.Top10Total)
+		assert.Equal(t, "0.990000", stats.Top10Share)
+		assert.Equal(t, 19, stats.DustAccounts)
+		assert.Equal(t, "19", stats.DustTotal)
+		assert.Equal(t, "10", stats.DustThreshold)
+	})
+
+	t.Run("median of an even count and repeated accounts", func(t *testing.T) {
+		entries := []merkle.Entry{
+			{Address: "0xA", TotalEarned: big.NewInt(10)},
+			{Address: "0xa", TotalEarned: big.NewInt(20)},
+			{Address: "0xb", TotalEarned: big.NewInt(5)},
+			{Address: "0xc", TotalEarned: big.NewInt(40)},
+			{Address: "0xd", TotalEarned: big.NewInt(1)},
+		}
+		stats := distributionStats(entries, nil)
+		assert.Equal(t, 4, stats.Accounts, "an account's amounts are summed")
+		assert.Equal(t, "17", stats.Median, "(5 + 30) / 2 rounded down")
+		assert.Equal(t, "0", stats.DustThreshold)
+		assert.Zero(t, stats.DustAccounts)
+	})
+
+	t.Run("empty distribution", func(t *testing.T) {
+		stats := distributionStats(nil, big.NewInt(10))
+		assert.Zero(t, stats.Accounts)
+		assert.Equal(t, "0", stats.Median)
+		assert.Equal(t, "0.000000", stats.Gini)
+	})
+}
+
+func TestLazyDistributor_Stats(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{enabled: true})
+	distributor.dustThreshold = big.NewInt(150)
+	ctx := context.Background()
+
+	_, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+
+	stats, err := distributor.Stats(ctx, big.NewInt(5), "")
+	require.NoError(t, err)
+	assert.Equal(t, "5", stats.EpochNumber)
+	require.Len(t, stats.Vaults, 1, "stats are recorded when the distribution is computed")
+	recorded := stats.Vaults[0]
+	assert.Equal(t, planTestVault, recorded.VaultID)
+	assert.Equal(t, "1000", recorded.Total)
+	assert.NotEmpty(t, recorded.MerkleRoot)
+	assert.Equal(t, "150", recorded.DustThreshold)
+
+	// a distribution stored before stats were recorded has them computed on request
+	require.NoError(t, distributor.merkleService.(*merkleimpl.Service).SaveSnapshot(ctx, big.NewInt(4), merkle.MerkleSnapshot{
+		VaultID:    planTestVault,
+		MerkleRoot: "ab",
+		Entries: []merkle.MerkleEntry{
+			{Address: "0x8f37c5c4fa708e06a656d858003ef7dc5f60a29b", TotalEarned: big.NewInt(800)},
+			{Address: "0x1111111111111111111111111111111111111111", TotalEarned: big.NewInt(100)},
+		},
+	}))
+	previous, err := distributor.Stats(ctx, big.NewInt(4), planTestVault)
+	require.NoError(t, err)
+	require.Len(t, previous.Vaults, 1)
+	assert.Equal(t, "ab", previous.Vaults[0].MerkleRoot)
+	assert.Equal(t, "450", previous.Vaults[0].Median)
+	assert.Equal(t, 1, previous.Vaults[0].DustAccounts)
+
+	none, err := distributor.Stats(ctx, big.NewInt(3), "")
+	require.NoError(t, err)
+	assert.Empty(t, none.Vaults)
+	_, err = distributor.Stats(ctx, big.NewInt(3), planTestVault)
+	assert.ErrorIs(t, err, subsidy.ErrNotFound)
+}
@@ -5,8 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"math/big"
+	"sort"
 	"time"
 
+	"github.com/andrey/epoch-server/internal/infra/storage"
 	"github.com/andrey/epoch-server/internal/infra/utils"
 	"github.com/andrey/epoch-server/internal/services/subsidy"
 	"github.com/dgraph-io/badger/v4"
@@ -15,12 +17,12 @@ import (
 
 // Store handles storage operations for subsidy service
 type Store struct {
-	db     *badger.DB
+	db     storage.DB
 	logger lgr.L
 }
 
 // NewStore creates a new store instance
-func NewStore(db *badger.DB, logger lgr.L) *Store {
+func NewStore(db storage.DB, logger lgr.L) *Store {
 	return &Store{
 		db:     db,
 		logger: logger,
@@ -40,7 +42,7 @@ func (s *Store) SaveDistribution(ctx context.Context, distribution subsidy.Subsi
 		return fmt.Errorf("failed to marshal distribution: %w", err)
 	}
 
-	err = s.db.Update(func(txn *badger.Txn) error {
+	err = s.db.Update(func(txn storage.Txn) error {
 		return txn.Set([]byte(key), data)
 	})
 	if err != nil {
@@ -49,7 +51,7 @@ func (s *Store) SaveDistribution(ctx context.Context, distribution subsidy.Subsi
 
 	// Also save by epoch and vault for easier querying
 	epochKey := s.buildEpochDistributionKey(distribution.EpochNumber, distribution.VaultID, distribution.ID)
-	err = s.db.Update(func(txn *badger.Txn) error {
+	err = s.db.Update(func(txn storage.Txn) error {
 		return txn.Set([]byte(epochKey), []byte(distribution.ID))
 	})
 	if err != nil {
@@ -66,7 +68,7 @@ func (s *Store) GetDistribution(ctx context.Context, distributionID string) (*su
 	key := s.buildDistributionKey(distributionID)
 
 	var distribution subsidy.SubsidyDistribution
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
@@ -79,7 +81,7 @@ func (s *Store) GetDistribution(ctx context.Context, distributionID string) (*su
 
 	if err != nil {
 		if err == badger.ErrKeyNotFound {
-			return nil, fmt.Errorf("distribution not found: %s", distributionID)
+			return nil, fmt.Errorf("%w: distribution not found: %s", subsidy.ErrNotFound, distributionID)
 		}
 		return nil, fmt.Errorf("failed to get distribution: %w", err)
 	}
@@ -92,7 +94,7 @@ func (s *Store) ListDistributionsByEpoch(ctx context.Context, epochNumber *big.I
 	prefix := s.buildEpochVaultPrefix(epochNumber, vaultID)
 	var distributions []subsidy.SubsidyDistribution
 
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = []byte(prefix)
 
@@ -130,7 +132,7 @@ func (s *Store) ListDistributionsByEpoch(ctx context.Context, epochNumber *big.I
 func (s *Store) ListDistributionsByStatus(ctx context.Context, status string, limit int) ([]subsidy.SubsidyDistribution, error) {
 	var distributions []subsidy.SubsidyDistribution
 
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.db.View(func(txn storage.Txn) error {
 		opts := badger.DefaultIteratorOptions
 		opts.Prefix = []byte("subsidy:distribution:")
 
@@ -188,6 +190,1244 @@ func (s *Store) UpdateDistributionStatus(ctx context.Context, distributionID str
 	return s.SaveDistribution(ctx, *distribution)
 }
 
+// SaveRepaymentPlan saves the progress of a batch repayment plan
+func (s *Store) SaveRepaymentPlan(ctx context.Context, plan subsidy.RepaymentPlan) error {
+	plan.UpdatedAt = time.Now()
+	if plan.CreatedAt.IsZero() {
+		plan.CreatedAt = plan.UpdatedAt
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal repayment plan: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildRepaymentPlanKey(plan.ID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save repayment plan: %w", err)
+	}
+
+	return nil
+}
+
+// GetRepaymentPlan retrieves a batch repayment plan by ID
+func (s *Store) GetRepaymentPlan(ctx context.Context, planID string) (*subsidy.RepaymentPlan, error) {
+	var plan subsidy.RepaymentPlan
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildRepaymentPlanKey(planID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &plan)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, fmt.Errorf("%w: repayment plan %s", subsidy.ErrNotFound, planID)
+		}
+		return nil, fmt.Errorf("failed to get repayment plan: %w", err)
+	}
+
+	return &plan, nil
+}
+
+// SaveStagedDistribution saves a distribution staged for approval
+func (s *Store) SaveStagedDistribution(ctx context.Context, staged subsidy.StagedDistribution) error {
+	data, err := json.Marshal(staged)
+	if err != nil {
+		return fmt.Errorf("failed to marshal staged distribution: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildStagedDistributionKey(staged.ID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save staged distribution: %w", err)
+	}
+
+	return nil
+}
+
+// GetStagedDistribution retrieves a staged distribution by ID
+func (s *Store) GetStagedDistribution(ctx context.Context, id string) (*subsidy.StagedDistribution, error) {
+	var staged subsidy.StagedDistribution
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildStagedDistributionKey(id)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &staged)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, fmt.Errorf("%w: staged distribution %s", subsidy.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get staged distribution: %w", err)
+	}
+
+	return &staged, nil
+}
+
+// ListStagedDistributions retrieves staged distributions with the given status, oldest first.
+// An empty status matches every distribution.
+func (s *Store) ListStagedDistributions(ctx context.Context, status string) ([]subsidy.StagedDistribution, error) {
+	staged := []subsidy.StagedDistribution{}
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte("subsidy:staged:")
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var distribution subsidy.StagedDistribution
+				if err := json.Unmarshal(val, &distribution); err != nil {
+					s.logger.Logf("WARN failed to unmarshal staged distribution: %v", err)
+					return nil // Continue iteration
+				}
+
+				if status == "" || distribution.Status == status {
+					staged = append(staged, distribution)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list staged distributions: %w", err)
+	}
+
+	sort.Slice(staged, func(i, j int) bool {
+		return staged[i].CreatedAt.Before(staged[j].CreatedAt)
+	})
+	return staged, nil
+}
+
+// SaveAdjustment stores an allocation adjustment, replacing the one with the same ID
+func (s *Store) SaveAdjustment(ctx context.Context, adjustment subsidy.AllocationAdjustment) error {
+	data, err := json.Marshal(adjustment)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allocation adjustment: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildAdjustmentKey(adjustment.ID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save allocation adjustment: %w", err)
+	}
+
+	return nil
+}
+
+// GetAdjustment retrieves an allocation adjustment by ID
+func (s *Store) GetAdjustment(ctx context.Context, id string) (*subsidy.AllocationAdjustment, error) {
+	var adjustment subsidy.AllocationAdjustment
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildAdjustmentKey(id)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &adjustment)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, fmt.Errorf("%w: allocation adjustment %s", subsidy.ErrNotFound, id)
+		}
+		return nil, fmt.Errorf("failed to get allocation adjustment: %w", err)
+	}
+
+	return &adjustment, nil
+}
+
+// ListAdjustments retrieves the allocation adjustments matching the vault, epoch and status that are set,
+// oldest first
+func (s *Store) ListAdjustments(ctx context.Context, vaultID, epochNumber, status string) ([]subsidy.AllocationAdjustment, error) {
+	adjustments := []subsidy.AllocationAdjustment{}
+	vaultID = utils.NormalizeAddress(vaultID)
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildAdjustmentKey(""))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var adjustment subsidy.AllocationAdjustment
+				if err := json.Unmarshal(val, &adjustment); err != nil {
+					s.logger.Logf("WARN failed to unmarshal allocation adjustment: %v", err)
+					return nil // Continue iteration
+				}
+
+				if (vaultID == "" || adjustment.VaultID == vaultID) &&
+					(epochNumber == "" || adjustment.EpochNumber == epochNumber) &&
+					(status == "" || adjustment.Status == status) {
+					adjustments = append(adjustments, adjustment)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allocation adjustments: %w", err)
+	}
+
+	sort.Slice(adjustments, func(i, j int) bool {
+		if !adjustments[i].ProposedAt.Equal(adjustments[j].ProposedAt) {
+			return adjustments[i].ProposedAt.Before(adjustments[j].ProposedAt)
+		}
+		return adjustments[i].ID < adjustments[j].ID
+	})
+	return adjustments, nil
+}
+
+// GetCarryForward returns the wei caps left for the vault's next distribution, zero when there is none
+func (s *Store) GetCarryForward(ctx context.Context, vaultID string) (*big.Int, error) {
+	carried := big.NewInt(0)
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildCarryForwardKey(vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			if _, ok := carried.SetString(string(val), 10); !ok {
+				return fmt.Errorf("malformed amount %q", val)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return big.NewInt(0), nil
+		}
+		return nil, fmt.Errorf("failed to get carried forward amount: %w", err)
+	}
+
+	return carried, nil
+}
+
+// SaveCarryForward replaces the wei left for the vault's next distribution
+func (s *Store) SaveCarryForward(ctx context.Context, vaultID string, amount *big.Int) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(s.buildCarryForwardKey(vaultID))
+		if amount.Sign() == 0 {
+			return txn.Delete(key)
+		}
+		return txn.Set(key, []byte(amount.String()))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save carried forward amount: %w", err)
+	}
+
+	return nil
+}
+
+// SaveCapRecords replaces the records of how caps changed allocations in the vault's epoch distribution,
+// so a recomputed distribution does not leave records of the one it replaced. carriedIn is the amount
+// the distribution received from the previous one.
+func (s *Store) SaveCapRecords(
+	ctx context.Context,
+	epochNumber *big.Int,
+	vaultID string,
+	carriedIn *big.Int,
+	records []capRecord,
+) error {
+	prefix := []byte(s.buildCapRecordPrefix(epochNumber, vaultID, ""))
+
+	err := s.db.Update(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = prefix
+		opts.PrefetchValues = false
+
+		var stale [][]byte
+		it := txn.NewIterator(opts)
+		for it.Rewind(); it.Valid(); it.Next() {
+			stale = append(stale, it.Item().KeyCopy(nil))
+		}
+		it.Close()
+
+		for _, key := range stale {
+			if err := txn.Delete(key); err != nil {
+				return err
+			}
+		}
+
+		if err := txn.Set([]byte(s.buildCarriedInKey(epochNumber, vaultID)), []byte(carriedIn.String())); err != nil {
+			return err
+		}
+
+		for _, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to marshal cap record: %w", err)
+			}
+			key := s.buildCapRecordPrefix(epochNumber, vaultID, record.Account) + record.CollectionParticipation
+			if err := txn.Set([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save cap records: %w", err)
+	}
+
+	return nil
+}
+
+// GetCarriedIn returns the amount the vault's epoch distribution received from the previous one,
+// zero when caps recorded nothing for it
+func (s *Store) GetCarriedIn(ctx context.Context, epochNumber *big.Int, vaultID string) (*big.Int, error) {
+	carried := big.NewInt(0)
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildCarriedInKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			if _, ok := carried.SetString(string(val), 10); !ok {
+				return fmt.Errorf("malformed amount %q", val)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return big.NewInt(0), nil
+		}
+		return nil, fmt.Errorf("failed to get carried in amount: %w", err)
+	}
+
+	return carried, nil
+}
+
+// GetDustCarry returns the dust rounding left for the vault's next distribution, zero when there is none
+func (s *Store) GetDustCarry(ctx context.Context, vaultID string) (*big.Rat, error) {
+	carried := new(big.Rat)
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildDustCarryKey(vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			if _, ok := carried.SetString(string(val)); !ok {
+				return fmt.Errorf("malformed dust %q", val)
+			}
+			return nil
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return new(big.Rat), nil
+		}
+		return nil, fmt.Errorf("failed to get carried dust: %w", err)
+	}
+
+	return carried, nil
+}
+
+// SaveDustCarry replaces the dust left for the vault's next distribution
+func (s *Store) SaveDustCarry(ctx context.Context, vaultID string, dust *big.Rat) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(s.buildDustCarryKey(vaultID))
+		if dust.Sign() == 0 {
+			return txn.Delete(key)
+		}
+		return txn.Set(key, []byte(dust.RatString()))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save carried dust: %w", err)
+	}
+
+	return nil
+}
+
+// SaveRoundingRecord replaces the record of what rounding did to the vault's epoch distribution
+func (s *Store) SaveRoundingRecord(ctx context.Context, epochNumber *big.Int, vaultID string, record roundingRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rounding record: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildRoundingRecordKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save rounding record: %w", err)
+	}
+
+	return nil
+}
+
+// GetRoundingRecord returns what rounding did to the vault's epoch distribution, nil when nothing was recorded
+func (s *Store) GetRoundingRecord(ctx context.Context, epochNumber *big.Int, vaultID string) (*roundingRecord, error) {
+	var record roundingRecord
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildRoundingRecordKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get rounding record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// SaveDistributionStats replaces the statistics of the vault's epoch distribution
+func (s *Store) SaveDistributionStats(ctx context.Context, stats subsidy.DistributionStats) error {
+	data, err := json.Marshal(stats)
+	if err != nil {
+		return fmt.Errorf("failed to marshal distribution stats: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildStatsKey(stats.EpochNumber, stats.VaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save distribution stats: %w", err)
+	}
+
+	return nil
+}
+
+// GetDistributionStats returns the statistics of the vault's epoch distribution, nil when none were recorded
+func (s *Store) GetDistributionStats(ctx context.Context, epochNumber *big.Int, vaultID string) (*subsidy.DistributionStats, error) {
+	var stats subsidy.DistributionStats
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildStatsKey(epochNumber.String(), vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &stats)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get distribution stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
+// ListDistributionStats returns the statistics recorded for the epoch's distributions, by vault address
+func (s *Store) ListDistributionStats(ctx context.Context, epochNumber *big.Int) ([]subsidy.DistributionStats, error) {
+	var result []subsidy.DistributionStats
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildStatsKey(epochNumber.String(), ""))
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var stats subsidy.DistributionStats
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &stats)
+			}); err != nil {
+				return err
+			}
+			result = append(result, stats)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list distribution stats: %w", err)
+	}
+
+	return result, nil
+}
+
+// SaveQuarantined records account subsidies a distribution skipped. Records of the same snapshot block
+// overwrite each other, so re-running a distribution does not duplicate them.
+func (s *Store) SaveQuarantined(ctx context.Context, accounts []subsidy.QuarantinedAccount) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		for _, account := range accounts {
+			data, err := json.Marshal(account)
+			if err != nil {
+				return fmt.Errorf("failed to marshal quarantined account: %w", err)
+			}
+			if err := txn.Set([]byte(s.buildQuarantineKey(account)), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save quarantined accounts: %w", err)
+	}
+
+	return nil
+}
+
+// ListQuarantined returns the vault's quarantined account subsidies ordered by snapshot block.
+// An empty epochNumber matches every epoch.
+func (s *Store) ListQuarantined(ctx context.Context, vaultID, epochNumber string) ([]subsidy.QuarantinedAccount, error) {
+	accounts := []subsidy.QuarantinedAccount{}
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildQuarantinePrefix(vaultID))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var account subsidy.QuarantinedAccount
+				if err := json.Unmarshal(val, &account); err != nil {
+					s.logger.Logf("WARN failed to unmarshal quarantined account: %v", err)
+					return nil // Continue iteration
+				}
+
+				if epochNumber == "" || account.EpochNumber == epochNumber {
+					accounts = append(accounts, account)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined accounts: %w", err)
+	}
+
+	return accounts, nil
+}
+
+// ListCapRecords returns how caps changed an account's allocations in the vault's epoch distribution
+func (s *Store) ListCapRecords(ctx context.Context, epochNumber *big.Int, vaultID, account string) ([]capRecord, error) {
+	var records []capRecord
+
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildCapRecordPrefix(epochNumber, vaultID, utils.NormalizeAddress(account)))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var record capRecord
+				if err := json.Unmarshal(val, &record); err != nil {
+					return fmt.Errorf("failed to unmarshal cap record: %w", err)
+				}
+				records = append(records, record)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cap records: %w", err)
+	}
+
+	return records, nil
+}
+
+// SaveSnapshotBlockPin replaces the block pinned for the vault's epoch snapshot
+func (s *Store) SaveSnapshotBlockPin(ctx context.Context, pin subsidy.SnapshotBlockPin) error {
+	data, err := json.Marshal(pin)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot block pin: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildSnapshotBlockPinKey(pin.EpochNumber, pin.VaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot block pin: %w", err)
+	}
+
+	return nil
+}
+
+// GetSnapshotBlockPin returns the block pinned for the vault's epoch snapshot, nil when none is
+func (s *Store) GetSnapshotBlockPin(ctx context.Context, epochNumber *big.Int, vaultID string) (*subsidy.SnapshotBlockPin, error) {
+	var pin subsidy.SnapshotBlockPin
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildSnapshotBlockPinKey(epochNumber.String(), vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &pin)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get snapshot block pin: %w", err)
+	}
+
+	return &pin, nil
+}
+
+// SaveFingerprint replaces the fingerprint of the fingerprint's vault and epoch
+func (s *Store) SaveFingerprint(ctx context.Context, fingerprint subsidy.Fingerprint) error {
+	data, err := json.Marshal(fingerprint)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fingerprint: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildFingerprintKey(fingerprint.EpochNumber, fingerprint.VaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save fingerprint: %w", err)
+	}
+
+	return nil
+}
+
+// GetFingerprint returns the fingerprint of the vault's epoch distribution, nil when none was stored
+func (s *Store) GetFingerprint(ctx context.Context, epochNumber *big.Int, vaultID string) (*subsidy.Fingerprint, error) {
+	var fingerprint subsidy.Fingerprint
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildFingerprintKey(epochNumber.String(), vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &fingerprint)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get fingerprint: %w", err)
+	}
+
+	return &fingerprint, nil
+}
+
+// SaveFingerprintOverride replaces the fingerprint override of the override's vault and epoch
+func (s *Store) SaveFingerprintOverride(ctx context.Context, override subsidy.FingerprintOverride) error {
+	data, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to marshal fingerprint override: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildFingerprintOverrideKey(override.EpochNumber, override.VaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save fingerprint override: %w", err)
+	}
+
+	return nil
+}
+
+// GetFingerprintOverride returns the fingerprint override of the vault's epoch, nil when none is
+func (s *Store) GetFingerprintOverride(ctx context.Context, epochNumber *big.Int, vaultID string) (*subsidy.FingerprintOverride, error) {
+	var override subsidy.FingerprintOverride
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildFingerprintOverrideKey(epochNumber.String(), vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &override)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get fingerprint override: %w", err)
+	}
+
+	return &override, nil
+}
+
+// DeleteFingerprintOverride removes the fingerprint override of the vault's epoch, if any
+func (s *Store) DeleteFingerprintOverride(ctx context.Context, epochNumber *big.Int, vaultID string) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Delete([]byte(s.buildFingerprintOverrideKey(epochNumber.String(), vaultID)))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete fingerprint override: %w", err)
+	}
+
+	return nil
+}
+
+// SaveCollectionWeight replaces the weight of the weight's vault and collection
+func (s *Store) SaveCollectionWeight(ctx context.Context, weight subsidy.CollectionWeight) error {
+	data, err := json.Marshal(weight)
+	if err != nil {
+		return fmt.Errorf("failed to marshal collection weight: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildCollectionWeightKey(weight.VaultID, weight.Collection)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save collection weight: %w", err)
+	}
+
+	return nil
+}
+
+// ListCollectionWeights returns the weights set for the vault's collections, ordered by collection
+func (s *Store) ListCollectionWeights(ctx context.Context, vaultID string) ([]subsidy.CollectionWeight, error) {
+	weights := make([]subsidy.CollectionWeight, 0)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildCollectionWeightPrefix(vaultID))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var weight subsidy.CollectionWeight
+				if err := json.Unmarshal(val, &weight); err != nil {
+					return err
+				}
+				weights = append(weights, weight)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collection weights: %w", err)
+	}
+
+	return weights, nil
+}
+
+// DeleteCollectionWeight removes the weight of the vault's collection, reporting whether there was one
+func (s *Store) DeleteCollectionWeight(ctx context.Context, vaultID, collection string) (bool, error) {
+	found := false
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(s.buildCollectionWeightKey(vaultID, collection))
+		if _, err := txn.Get(key); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+		found = true
+		return txn.Delete(key)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete collection weight: %w", err)
+	}
+
+	return found, nil
+}
+
+// SaveAppliedWeights replaces the collection weights recorded as applied to the vault's epoch distribution
+func (s *Store) SaveAppliedWeights(ctx context.Context, epochNumber *big.Int, vaultID string, weights []subsidy.CollectionWeight) error {
+	data, err := json.Marshal(weights)
+	if err != nil {
+		return fmt.Errorf("failed to marshal applied weights: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildAppliedWeightsKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save applied weights: %w", err)
+	}
+
+	return nil
+}
+
+// GetAppliedWeights returns the collection weights the vault's epoch distribution applied, none when nothing was recorded
+func (s *Store) GetAppliedWeights(ctx context.Context, epochNumber *big.Int, vaultID string) ([]subsidy.CollectionWeight, error) {
+	var weights []subsidy.CollectionWeight
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildAppliedWeightsKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &weights)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get applied weights: %w", err)
+	}
+
+	return weights, nil
+}
+
+// SaveBlockedAddress replaces the blocklist entry of the entry's address
+func (s *Store) SaveBlockedAddress(ctx context.Context, blocked subsidy.BlockedAddress) error {
+	data, err := json.Marshal(blocked)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocked address: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildBlockedAddressKey(blocked.Address)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save blocked address: %w", err)
+	}
+
+	return nil
+}
+
+// ListBlockedAddresses returns the blocklist, ordered by address
+func (s *Store) ListBlockedAddresses(ctx context.Context) ([]subsidy.BlockedAddress, error) {
+	blocked := make([]subsidy.BlockedAddress, 0)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildBlockedAddressKey(""))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			err := it.Item().Value(func(val []byte) error {
+				var entry subsidy.BlockedAddress
+				if err := json.Unmarshal(val, &entry); err != nil {
+					return err
+				}
+				blocked = append(blocked, entry)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocked addresses: %w", err)
+	}
+
+	return blocked, nil
+}
+
+// DeleteBlockedAddress removes the address from the blocklist, reporting whether it was on it
+func (s *Store) DeleteBlockedAddress(ctx context.Context, address string) (bool, error) {
+	found := false
+	err := s.db.Update(func(txn storage.Txn) error {
+		key := []byte(s.buildBlockedAddressKey(address))
+		if _, err := txn.Get(key); err != nil {
+			if err == badger.ErrKeyNotFound {
+				return nil
+			}
+			return err
+		}
+		found = true
+		return txn.Delete(key)
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete blocked address: %w", err)
+	}
+
+	return found, nil
+}
+
+// SaveBlockedRecord replaces the record of the accounts the vault's epoch distribution left out
+func (s *Store) SaveBlockedRecord(ctx context.Context, epochNumber *big.Int, vaultID string, record blockedRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal blocked record: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildBlockedRecordKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save blocked record: %w", err)
+	}
+
+	return nil
+}
+
+// GetBlockedRecord returns the accounts the vault's epoch distribution left out, an empty record when
+// nothing was recorded
+func (s *Store) GetBlockedRecord(ctx context.Context, epochNumber *big.Int, vaultID string) (blockedRecord, error) {
+	var record blockedRecord
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildBlockedRecordKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return blockedRecord{}, nil
+		}
+		return blockedRecord{}, fmt.Errorf("failed to get blocked record: %w", err)
+	}
+
+	return record, nil
+}
+
+// SaveDeferredRecord replaces the record of the accounts the vault's epoch distribution left out for the minimum
+// allocation
+func (s *Store) SaveDeferredRecord(ctx context.Context, epochNumber *big.Int, vaultID string, record deferredRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal deferred record: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildDeferredRecordKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save deferred record: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeferredRecord returns the accounts the vault's epoch distribution left out for the minimum allocation, an
+// empty record when nothing was recorded
+func (s *Store) GetDeferredRecord(ctx context.Context, epochNumber *big.Int, vaultID string) (deferredRecord, error) {
+	var record deferredRecord
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildDeferredRecordKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return deferredRecord{}, nil
+		}
+		return deferredRecord{}, fmt.Errorf("failed to get deferred record: %w", err)
+	}
+
+	return record, nil
+}
+
+// SaveDebtRecord replaces the record of what the accounts of the vault's epoch distribution owed the lending market
+func (s *Store) SaveDebtRecord(ctx context.Context, epochNumber *big.Int, vaultID string, record debtRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal debt record: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildDebtRecordKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save debt record: %w", err)
+	}
+
+	return nil
+}
+
+// GetDebtRecord returns what the accounts of the vault's epoch distribution owed the lending market, nil when the
+// distribution did not cap them at their outstanding debt
+func (s *Store) GetDebtRecord(ctx context.Context, epochNumber *big.Int, vaultID string) (*debtRecord, error) {
+	var record debtRecord
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildDebtRecordKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get debt record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// SaveExpiryRecord replaces the record of what expired of the accounts' amounts by the vault's epoch distribution
+func (s *Store) SaveExpiryRecord(ctx context.Context, epochNumber *big.Int, vaultID string, record expiryRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expiry record: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildExpiryRecordKey(epochNumber, vaultID)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save expiry record: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpiryRecord returns what expired of the accounts' amounts by the vault's epoch distribution, an empty record
+// when nothing was recorded
+func (s *Store) GetExpiryRecord(ctx context.Context, epochNumber *big.Int, vaultID string) (expiryRecord, error) {
+	var record expiryRecord
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildExpiryRecordKey(epochNumber, vaultID)))
+		if err != nil {
+			return err
+		}
+
+		return item.Value(func(val []byte) error {
+			return json.Unmarshal(val, &record)
+		})
+	})
+
+	if err != nil {
+		if err == badger.ErrKeyNotFound {
+			return expiryRecord{}, nil
+		}
+		return expiryRecord{}, fmt.Errorf("failed to get expiry record: %w", err)
+	}
+
+	return record, nil
+}
+
+// SavePositions replaces the positions the vault's epoch distribution recorded, dropping those of accounts it no
+// longer has
+func (s *Store) SavePositions(
+	ctx context.Context,
+	epochNumber *big.Int,
+	vaultID string,
+	records map[string]subsidy.EpochPosition,
+) error {
+	indexKey := []byte(s.buildPositionIndexKey(epochNumber, vaultID))
+	accounts := make([]string, 0, len(records))
+	for account := range records {
+		accounts = append(accounts, utils.NormalizeAddress(account))
+	}
+	sort.Strings(accounts)
+	index, err := json.Marshal(accounts)
+	if err != nil {
+		return fmt.Errorf("failed to marshal position index: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		var stale []string
+		item, err := txn.Get(indexKey)
+		switch {
+		case err == nil:
+			if err := item.Value(func(val []byte) error { return json.Unmarshal(val, &stale) }); err != nil {
+				return err
+			}
+		case err != badger.ErrKeyNotFound:
+			return err
+		}
+		for _, account := range stale {
+			if err := txn.Delete([]byte(s.buildPositionKey(account, vaultID, epochNumber))); err != nil {
+				return err
+			}
+		}
+
+		for account, record := range records {
+			data, err := json.Marshal(record)
+			if err != nil {
+				return fmt.Errorf("failed to marshal position: %w", err)
+			}
+			if err := txn.Set([]byte(s.buildPositionKey(account, vaultID, epochNumber)), data); err != nil {
+				return err
+			}
+		}
+		return txn.Set(indexKey, index)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save positions: %w", err)
+	}
+
+	return nil
+}
+
+// ListPositions returns the positions the vaults' distributions recorded for account, by vault and then epoch
+func (s *Store) ListPositions(ctx context.Context, account string) ([]subsidy.EpochPosition, error) {
+	records := make([]subsidy.EpochPosition, 0)
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildPositionPrefix(account))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			var record subsidy.EpochPosition
+			if err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &record)
+			}); err != nil {
+				return err
+			}
+			records = append(records, record)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list positions: %w", err)
+	}
+
+	return records, nil
+}
+
+// SaveSubmission replaces the distribution kept for resuming the vault's epoch, dropping any computed at
+// another snapshot block
+func (s *Store) SaveSubmission(ctx context.Context, epochNumber *big.Int, pending submission) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("failed to marshal submission: %w", err)
+	}
+
+	err = s.db.Update(func(txn storage.Txn) error {
+		if err := deletePrefix(txn, s.buildSubmissionPrefix(epochNumber, pending.VaultID)); err != nil {
+			return err
+		}
+		return txn.Set([]byte(s.buildSubmissionKey(epochNumber, pending.VaultID, pending.BlockNumber)), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save submission: %w", err)
+	}
+
+	return nil
+}
+
+// GetSubmission returns the distribution kept for resuming the vault's epoch, nil when there is none
+func (s *Store) GetSubmission(ctx context.Context, epochNumber *big.Int, vaultID string) (*submission, error) {
+	var pending *submission
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(s.buildSubmissionPrefix(epochNumber, vaultID))
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		// SaveSubmission keeps one per epoch and vault
+		for it.Rewind(); it.Valid(); it.Next() {
+			return it.Item().Value(func(val []byte) error {
+				pending = &submission{}
+				return json.Unmarshal(val, pending)
+			})
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get submission: %w", err)
+	}
+
+	return pending, nil
+}
+
+// DeleteSubmission forgets the distribution kept for resuming the vault's epoch
+func (s *Store) DeleteSubmission(ctx context.Context, epochNumber *big.Int, vaultID string) error {
+	err := s.db.Update(func(txn storage.Txn) error {
+		return deletePrefix(txn, s.buildSubmissionPrefix(epochNumber, vaultID))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete submission: %w", err)
+	}
+
+	return nil
+}
+
+// deletePrefix deletes every key with prefix in txn
+func deletePrefix(txn storage.Txn, prefix string) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = []byte(prefix)
+	opts.PrefetchValues = false
+
+	it := txn.NewIterator(opts)
+	var keys [][]byte
+	for it.Rewind(); it.Valid(); it.Next() {
+		keys = append(keys, it.Item().KeyCopy(nil))
+	}
+	it.Close()
+
+	for _, key := range keys {
+		if err := txn.Delete(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Key building functions
 func (s *Store) buildDistributionKey(distributionID string) string {
 	return fmt.Sprintf("subsidy:distribution:%s", distributionID)
@@ -202,3 +1442,121 @@ func (s *Store) buildEpochVaultPrefix(epochNumber *big.Int, vaultID string) stri
 	normalizedVaultID := utils.NormalizeAddress(vaultID)
 	return fmt.Sprintf("subsidy:epoch:%020s:vault:%s:", epochNumber.String(), normalizedVaultID)
 }
+
+func (s *Store) buildStagedDistributionKey(id string) string {
+	return fmt.Sprintf("subsidy:staged:%s", id)
+}
+
+func (s *Store) buildAdjustmentKey(id string) string {
+	return fmt.Sprintf("subsidy:adjustment:%s", id)
+}
+
+func (s *Store) buildCarryForwardKey(vaultID string) string {
+	return fmt.Sprintf("subsidy:caps:carry:vault:%s", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildCarriedInKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:caps:carried-in:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildDustCarryKey(vaultID string) string {
+	return fmt.Sprintf("subsidy:rounding:carry:vault:%s", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildRoundingRecordKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:rounding:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+// buildStatsKey keys an epoch's distribution statistics by vault, the epoch's prefix when vaultID is empty
+func (s *Store) buildStatsKey(epochNumber, vaultID string) string {
+	return fmt.Sprintf("subsidy:stats:epoch:%020s:vault:%s", epochNumber, utils.NormalizeAddress(vaultID))
+}
+
+// buildCapRecordPrefix scopes cap records to an epoch and vault, and to an account when one is given
+func (s *Store) buildQuarantinePrefix(vaultID string) string {
+	return fmt.Sprintf("subsidy:quarantine:vault:%s:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildQuarantineKey(account subsidy.QuarantinedAccount) string {
+	return fmt.Sprintf("%sblock:%020d:%s:%s", s.buildQuarantinePrefix(account.VaultID), account.BlockNumber,
+		utils.NormalizeAddress(account.Account), account.CollectionParticipation)
+}
+
+func (s *Store) buildCapRecordPrefix(epochNumber *big.Int, vaultID, account string) string {
+	prefix := fmt.Sprintf("subsidy:caps:epoch:%020s:vault:%s:", epochNumber.String(), utils.NormalizeAddress(vaultID))
+	if account == "" {
+		return prefix
+	}
+	return prefix + account + ":"
+}
+
+func (s *Store) buildSnapshotBlockPinKey(epochNumber, vaultID string) string {
+	return fmt.Sprintf("subsidy:snapshot-block:epoch:%020s:vault:%s", epochNumber, utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildFingerprintKey(epochNumber, vaultID string) string {
+	return fmt.Sprintf("subsidy:fingerprint:epoch:%020s:vault:%s", epochNumber, utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildFingerprintOverrideKey(epochNumber, vaultID string) string {
+	return fmt.Sprintf("subsidy:fingerprint-override:epoch:%020s:vault:%s", epochNumber, utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildCollectionWeightPrefix(vaultID string) string {
+	return fmt.Sprintf("subsidy:weight:vault:%s:", utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildCollectionWeightKey(vaultID, collection string) string {
+	return s.buildCollectionWeightPrefix(vaultID) + utils.NormalizeAddress(collection)
+}
+
+func (s *Store) buildAppliedWeightsKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:weight:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+// buildBlockedAddressKey returns the blocklist prefix when address is empty
+func (s *Store) buildBlockedAddressKey(address string) string {
+	return "subsidy:blocklist:address:" + utils.NormalizeAddress(address)
+}
+
+func (s *Store) buildBlockedRecordKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:blocklist:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildDeferredRecordKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:minimum:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildDebtRecordKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:debts:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildExpiryRecordKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:expiry:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+// buildPositionPrefix scopes positions to an account, so its history is one scan
+func (s *Store) buildPositionPrefix(account string) string {
+	return fmt.Sprintf("subsidy:position:account:%s:", utils.NormalizeAddress(account))
+}
+
+func (s *Store) buildPositionKey(account, vaultID string, epochNumber *big.Int) string {
+	return fmt.Sprintf("%svault:%s:epoch:%020s", s.buildPositionPrefix(account), utils.NormalizeAddress(vaultID), epochNumber.String())
+}
+
+// buildPositionIndexKey keys the accounts an epoch's distribution recorded positions of, so replacing it drops them
+func (s *Store) buildPositionIndexKey(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:position:epoch:%020s:vault:%s", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildSubmissionPrefix(epochNumber *big.Int, vaultID string) string {
+	return fmt.Sprintf("subsidy:submission:epoch:%020s:vault:%s:", epochNumber.String(), utils.NormalizeAddress(vaultID))
+}
+
+func (s *Store) buildSubmissionKey(epochNumber *big.Int, vaultID string, blockNumber uint64) string {
+	return fmt.Sprintf("%sblock:%020d", s.buildSubmissionPrefix(epochNumber, vaultID), blockNumber)
+}
+
+func (s *Store) buildRepaymentPlanKey(planID string) string {
+	return fmt.Sprintf("subsidy:repayment:plan:%s", planID)
+}
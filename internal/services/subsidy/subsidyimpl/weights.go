@@ -0,0 +1,124 @@
+package subsidyimpl
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+// audit actions recorded for collection weight changes
+const (
+	actionSetCollectionWeight    = "setCollectionWeight"
+	actionDeleteCollectionWeight = "deleteCollectionWeight"
+)
+
+// collectionWeights are the multipliers a distribution values collections with, by normalized collection address
+type collectionWeights map[string]*big.Rat
+
+// newCollectionWeights parses the multipliers of weights. SetCollectionWeight only stores multipliers that parse.
+func newCollectionWeights(weights []subsidy.CollectionWeight) collectionWeights {
+	parsed := make(collectionWeights, len(weights))
+	for _, weight := range weights {
+		if multiplier, ok := new(big.Rat).SetString(weight.Multiplier); ok {
+			parsed[utils.NormalizeAddress(weight.Collection)] = multiplier
+		}
+	}
+	return parsed
+}
+
+// of returns the multiplier of collection, nil when the collection is not weighted
+func (w collectionWeights) of(collection string) *big.Rat {
+	return w[utils.NormalizeAddress(collection)]
+}
+
+// activeWeights returns the weights set for the vault that apply to epochNumber. Distributions run without
+// an epoch apply none.
+func (d *LazyDistributor) activeWeights(ctx context.Context, vaultId string, epochNumber *big.Int) ([]subsidy.CollectionWeight, error) {
+	if epochNumber == nil || !epochNumber.IsUint64() {
+		return nil, nil
+	}
+	weights, err := d.store.ListCollectionWeights(ctx, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	var active []subsidy.CollectionWeight
+	for _, weight := range weights {
+		if weight.AppliesTo(epochNumber.Uint64()) {
+			active = append(active, weight)
+		}
+	}
+	return active, nil
+}
+
+// appliedWeights returns the weights the vault's epoch distribution was built with. Weights are read from what
+// the distribution recorded, so changing or removing a weight later does not change how the epoch is explained.
+func (d *LazyDistributor) appliedWeights(ctx context.Context, vaultId string, epochNumber *big.Int) (collectionWeights, error) {
+	weights, err := d.store.GetAppliedWeights(ctx, epochNumber, vaultId)
+	if err != nil {
+		return nil, err
+	}
+	return newCollectionWeights(weights), nil
+}
+
+// SetCollectionWeight stores the weight as set by the context's actor, replacing the one set for the collection before
+func (d *LazyDistributor) SetCollectionWeight(ctx context.Context, weight subsidy.CollectionWeight) (*subsidy.CollectionWeight, error) {
+	weight.SetBy = audit.ActorFromContext(ctx)
+	weight.SetAt = time.Now()
+	if err := d.store.SaveCollectionWeight(ctx, weight); err != nil {
+		return nil, err
+	}
+
+	d.logger.Logf("INFO collection %s of vault %s weighted %s for epochs %d to %d by %s",
+		weight.Collection, weight.VaultID, weight.Multiplier, weight.FromEpoch, weight.ToEpoch, weight.SetBy)
+	d.record(ctx, actionSetCollectionWeight, map[string]string{
+		"vault":      weight.VaultID,
+		"collection": weight.Collection,
+		"multiplier": weight.Multiplier,
+		"fromEpoch":  strconv.FormatUint(weight.FromEpoch, 10),
+		"toEpoch":    strconv.FormatUint(weight.ToEpoch, 10),
+		"reason":     weight.Reason,
+	})
+	return &weight, nil
+}
+
+// ListCollectionWeights returns the weights set for the vault's collections, ordered by collection
+func (d *LazyDistributor) ListCollectionWeights(ctx context.Context, vaultId string) ([]subsidy.CollectionWeight, error) {
+	return d.store.ListCollectionWeights(ctx, vaultId)
+}
+
+// DeleteCollectionWeight removes the weight of the vault's collection. Distributions already built keep it.
+func (d *LazyDistributor) DeleteCollectionWeight(ctx context.Context, vaultId, collection string) error {
+	found, err := d.store.DeleteCollectionWeight(ctx, vaultId, collection)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: collection %s of vault %s has no weight", subsidy.ErrNotFound, collection, vaultId)
+	}
+
+	d.logger.Logf("INFO weight of collection %s in vault %s removed by %s", collection, vaultId, audit.ActorFromContext(ctx))
+	d.record(ctx, actionDeleteCollectionWeight, map[string]string{"vault": vaultId, "collection": collection})
+	return nil
+}
+
+// record writes a weight, blocklist or fingerprint change to the audit log. Failures are logged, the change itself is already stored.
+func (d *LazyDistributor) record(ctx context.Context, action string, parameters map[string]string) {
+	if d.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action:     action,
+		Actor:      audit.ActorFromContext(ctx),
+		Parameters: parameters,
+		Result:     audit.ResultSuccess,
+	}
+	if err := d.recorder.Record(ctx, entry); err != nil {
+		d.logger.Logf("WARN failed to record %s in audit log: %v", action, err)
+	}
+}
@@ -0,0 +1,174 @@
+package subsidyimpl
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+)
+
+const (
+	weightTestBoosted = "0x7777777777777777777777777777777777777777"
+	weightTestPlain   = "0x8888888888888888888888888888888888888888"
+)
+
+// weightTestSubgraph serves owner A earning 300 in the boosted collection and 500 in the plain one
+func weightTestSubgraph() *subgraph.SubgraphClientMock {
+	subsidies := []subgraph.AccountSubsidy{
+		{ID: "boosted", Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xcollection-boosted",
+			Collection: weightTestBoosted, TotalRewardsEarned: "300"},
+		{ID: "plain", Account: subgraph.Account{ID: holdingsTestOwnerA}, CollectionParticipation: "0xcollection-plain",
+			Collection: weightTestPlain, TotalRewardsEarned: "500"},
+	}
+	return &subgraph.SubgraphClientMock{
+		StreamAccountSubsidiesForVaultFunc: func(ctx context.Context, vaultAddress string, fn func(page []subgraph.AccountSubsidy) error) error {
+			return fn(subsidies)
+		},
+		StreamAccountSubsidiesForVaultAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			blockNumber int64,
+			fn func(page []subgraph.AccountSubsidy) error,
+		) error {
+			return fn(subsidies)
+		},
+		QueryAccountSubsidiesForAccountAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accountAddress string,
+			blockNumber int64,
+		) ([]subgraph.AccountSubsidy, error) {
+			return subsidies, nil
+		},
+		QueryAccountSubsidiesForAccountsAtBlockFunc: func(
+			ctx context.Context,
+			vaultAddress string,
+			accounts []string,
+			blockNumber int64,
+		) (map[string][]subgraph.AccountSubsidy, error) {
+			return map[string][]subgraph.AccountSubsidy{holdingsTestOwnerA: subsidies}, nil
+		},
+		QueryMerkleDistributionForEpochFunc: func(ctx context.Context, epochNumber, vaultAddress string) (*subgraph.MerkleDistribution, error) {
+			return nil, errors.New("merkle distribution not found")
+		},
+	}
+}
+
+func TestLazyDistributor_AppliesCollectionWeights(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.subgraphClient = weightTestSubgraph()
+	ctx := context.Background()
+
+	_, err := distributor.SetCollectionWeight(ctx, subsidy.CollectionWeight{
+		VaultID: planTestVault, Collection: weightTestBoosted, Multiplier: "2.5", FromEpoch: 5, ToEpoch: 7,
+	})
+	require.NoError(t, err)
+
+	result, err := distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(4))
+	require.NoError(t, err)
+	assert.Equal(t, "800", result.TotalSubsidies.String(), "the weight does not apply before its first epoch")
+
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, "1250", result.TotalSubsidies.String(), "300 weighted 2.5x plus 500")
+
+	// the epoch keeps the weights it was built with once the weight is gone
+	require.NoError(t, distributor.DeleteCollectionWeight(ctx, planTestVault, weightTestBoosted))
+	explanation, err := distributor.Explain(ctx, planTestVault, big.NewInt(5), holdingsTestOwnerA)
+	require.NoError(t, err)
+	assert.True(t, explanation.Matches, "computed %s, distributed %s", explanation.ComputedAmount, explanation.DistributedAmount)
+	require.Len(t, explanation.Collections, 2)
+	assert.Equal(t, "5/2", explanation.Collections[0].Share)
+	assert.Empty(t, explanation.Collections[1].Share)
+
+	replay, err := distributor.Replay(ctx, planTestVault, big.NewInt(5))
+	require.NoError(t, err)
+	assert.True(t, replay.Matches, "replays apply the weights the epoch recorded")
+
+	result, err = distributor.RunWithEpoch(ctx, planTestVault, big.NewInt(6))
+	require.NoError(t, err)
+	assert.Equal(t, "800", result.TotalSubsidies.String())
+}
+
+func TestLazyDistributor_CollectionWeightsAreAudited(t *testing.T) {
+	db := newPlannerTestDB(t)
+	recorder := &audit.RecorderMock{RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil }}
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	distributor.recorder = recorder
+	ctx := audit.WithActor(context.Background(), "operator")
+
+	weight, err := distributor.SetCollectionWeight(ctx, subsidy.CollectionWeight{
+		VaultID: planTestVault, Collection: weightTestBoosted, Multiplier: "2", FromEpoch: 5, ToEpoch: 7, Reason: "launch",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "operator", weight.SetBy)
+	_, err = distributor.SetCollectionWeight(ctx, subsidy.CollectionWeight{
+		VaultID: planTestVault, Collection: weightTestBoosted, Multiplier: "3", FromEpoch: 6, ToEpoch: 6,
+	})
+	require.NoError(t, err)
+
+	weights, err := distributor.ListCollectionWeights(ctx, planTestVault)
+	require.NoError(t, err)
+	require.Len(t, weights, 1, "setting a collection's weight again replaces it")
+	assert.Equal(t, "3", weights[0].Multiplier)
+	assert.True(t, weights[0].AppliesTo(6))
+	assert.False(t, weights[0].AppliesTo(7))
+
+	require.NoError(t, distributor.DeleteCollectionWeight(ctx, planTestVault, weightTestBoosted))
+	require.ErrorIs(t, distributor.DeleteCollectionWeight(ctx, planTestVault, weightTestBoosted), subsidy.ErrNotFound)
+	weights, err = distributor.ListCollectionWeights(ctx, planTestVault)
+	require.NoError(t, err)
+	assert.Empty(t, weights)
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 3, "a failed delete is not recorded")
+	assert.Equal(t, actionSetCollectionWeight, calls[0].Entry.Action)
+	assert.Equal(t, "operator", calls[0].Entry.Actor)
+	assert.Equal(t, map[string]string{
+		"vault": planTestVault, "collection": weightTestBoosted, "multiplier": "2", "fromEpoch": "5", "toEpoch": "7", "reason": "launch",
+	}, calls[0].Entry.Parameters)
+	assert.Equal(t, actionDeleteCollectionWeight, calls[2].Entry.Action)
+}
+
+func TestService_SetCollectionWeightValidates(t *testing.T) {
+	db := newPlannerTestDB(t)
+	distributor := newApprovalTestDistributor(db, newApprovalTestChain(nil), approvalPolicy{})
+	service := New(distributor, nil, nil, nil, lgr.NoOp, &config.Config{})
+	valid := subsidy.CollectionWeight{VaultID: planTestVault, Collection: weightTestBoosted, Multiplier: "1.5", FromEpoch: 1, ToEpoch: 3}
+
+	tests := []struct {
+		name   string
+		modify func(w *subsidy.CollectionWeight)
+	}{
+		{name: "vault", modify: func(w *subsidy.CollectionWeight) { w.VaultID = "vault" }},
+		{name: "collection", modify: func(w *subsidy.CollectionWeight) { w.Collection = "0x12" }},
+		{name: "multiplier_malformed", modify: func(w *subsidy.CollectionWeight) { w.Multiplier = "double" }},
+		{name: "multiplier_zero", modify: func(w *subsidy.CollectionWeight) { w.Multiplier = "0" }},
+		{name: "multiplier_too_large", modify: func(w *subsidy.CollectionWeight) { w.Multiplier = "101" }},
+		{name: "epoch_zero", modify: func(w *subsidy.CollectionWeight) { w.FromEpoch = 0 }},
+		{name: "epochs_reversed", modify: func(w *subsidy.CollectionWeight) { w.FromEpoch, w.ToEpoch = 4, 3 }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weight := valid
+			tt.modify(&weight)
+			_, err := service.SetCollectionWeight(context.Background(), weight)
+			require.ErrorIs(t, err, subsidy.ErrInvalidInput)
+		})
+	}
+
+	weight, err := service.SetCollectionWeight(context.Background(), valid)
+	require.NoError(t, err)
+	assert.Equal(t, "1.5", weight.Multiplier)
+	require.ErrorIs(t, service.DeleteCollectionWeight(context.Background(), planTestVault, "0x12"), subsidy.ErrInvalidInput)
+}
@@ -0,0 +1,10 @@
+package vaults
+
+import "errors"
+
+// Predefined error types for vault onboarding
+var (
+	ErrInvalidInput      = errors.New("invalid input parameters")
+	ErrDecommissioned    = errors.New("vault decommissioned")
+	ErrTransactionFailed = errors.New("transaction failed")
+)
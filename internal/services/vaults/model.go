@@ -0,0 +1,83 @@
+package vaults
+
+import (
+	"time"
+)
+
+// registry status of a vault
+const (
+	StatusOnboarding      = "onboarding" // some onboarding steps are done, the rest failed or did not run
+	StatusActive          = "active"
+	StatusDecommissioning = "decommissioning" // out of scheduler runs, still on the DebtSubsidizer until confirmed
+	StatusDecommissioned  = "decommissioned"  // removed from the DebtSubsidizer
+)
+
+// onboarding steps, in the order they run
+const (
+	StepAddVault            = "add_vault"
+	StepGrantVaultRole      = "grant_vault_role"
+	StepWhitelistCollection = "whitelist_collection" // once per collection
+	StepRegisterVault       = "register_vault"
+)
+
+// status of an onboarding step
+const (
+	StepDone        = "done"
+	StepAlreadyDone = "already_done" // done by an earlier onboarding or outside the server
+	StepFailed      = "failed"
+	StepNotRun      = "not_run" // an earlier step failed
+)
+
+// Vault is a vault in the server's registry
+type Vault struct {
+	Address        string    `json:"address" example:"0x1234567890123456789012345678901234567890"`
+	LendingManager string    `json:"lendingManager" example:"0x2345678901234567890123456789012345678901"`
+	Collections    []string  `json:"collections"`
+	Status         string    `json:"status" example:"active"`
+	RoleGranted    bool      `json:"roleGranted"`
+	OnboardedBy    string    `json:"onboardedBy,omitempty" example:"api:10.0.0.1"`
+	OnboardedAt    time.Time `json:"onboardedAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+
+	// set for vaults whose VaultAdded event was seen on the DebtSubsidizer, Discovered when that is how the
+	// registry learned of them
+	Discovered   bool   `json:"discovered,omitempty"`
+	CToken       string `json:"cToken,omitempty" example:"0x3456789012345678901234567890123456789012"`
+	AddedBlock   uint64 `json:"addedBlock,omitempty" example:"19000000"`
+	RemovedBlock uint64 `json:"removedBlock,omitempty" example:"19500000"`
+
+	DecommissionReason string     `json:"decommissionReason,omitempty" example:"migrated to the v2 vault"`
+	DecommissionedBy   string     `json:"decommissionedBy,omitempty" example:"api:10.0.0.1"`
+	DecommissionedAt   *time.Time `json:"decommissionedAt,omitempty"`
+	RemovedAt          *time.Time `json:"removedAt,omitempty"` // when removeVault was mined, or found already done
+}
+
+// OnboardRequest is a vault to onboard
+type OnboardRequest struct {
+	VaultAddress   string   `json:"vaultAddress" example:"0x1234567890123456789012345678901234567890"`
+	LendingManager string   `json:"lendingManager,omitempty" example:"0x2345678901234567890123456789012345678901"` // defaults to the configured lending manager
+	Collections    []string `json:"collections,omitempty"`                                                         // whitelisted for the vault
+}
+
+// DecommissionRequest is why a vault is decommissioned and whether it is removed from the DebtSubsidizer now
+type DecommissionRequest struct {
+	Reason  string `json:"reason" example:"migrated to the v2 vault"`
+	Confirm bool   `json:"confirm"` // call removeVault, which cannot be undone
+}
+
+// OnboardingStep is the outcome of one onboarding step
+type OnboardingStep struct {
+	Name   string `json:"name" example:"whitelist_collection"`
+	Target string `json:"target,omitempty" example:"0x3456789012345678901234567890123456789012"` // collection of a whitelist step
+	Status string `json:"status" example:"done"`
+	Error  string `json:"error,omitempty"`
+}
+
+// OnboardingResult is how far an onboarding got. When it did not complete, Rollback lists the calls that undo
+// the steps done by this onboarding, for operators who abandon it instead of retrying.
+type OnboardingResult struct {
+	Vault    Vault            `json:"vault"`
+	Steps    []OnboardingStep `json:"steps"`
+	Complete bool             `json:"complete"`
+	Rollback []string         `json:"rollback,omitempty"`
+}
@@ -0,0 +1,23 @@
+package vaults
+
+import (
+	"context"
+)
+
+//go:generate moq -out vaults_mocks.go . Service
+
+// Service onboards vaults onto the contracts, decommissions them and keeps the registry of vaults the server
+// knows about
+type Service interface {
+	// Onboard adds the vault to the DebtSubsidizer, grants it the vault role on the EpochManager, whitelists the
+	// request's collections and registers it. Steps already done are skipped, so a failed onboarding is resumed by
+	// sending the same request again.
+	Onboard(ctx context.Context, req OnboardRequest) (*OnboardingResult, error)
+	// ListVaults returns every registered vault, including those whose onboarding did not complete
+	ListVaults(ctx context.Context) ([]Vault, error)
+	// Decommission takes the vault out of scheduler runs. Once req.Confirm is set it is also removed from the
+	// DebtSubsidizer, which cannot be undone. Its stored distributions, proofs and claims are kept and served.
+	Decommission(ctx context.Context, vault string, req DecommissionRequest) (*Vault, error)
+	// IsDecommissioned reports whether the vault is decommissioning or decommissioned
+	IsDecommissioned(ctx context.Context, vault string) (bool, error)
+}
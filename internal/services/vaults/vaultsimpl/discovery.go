@@ -0,0 +1,162 @@
+package vaultsimpl
+
+import (
+	"context"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// discoveryActor is who registry changes made from the DebtSubsidizer's vault events are attributed to
+const discoveryActor = "chain"
+
+// discoveryPolicy is how the registry follows the DebtSubsidizer's vault events
+type discoveryPolicy struct {
+	enabled       bool
+	startBlock    uint64 // block events are scanned from
+	confirmations uint64 // blocks an event must be buried under before it is applied
+	interval      time.Duration
+}
+
+func newDiscoveryPolicy(cfg *config.Config) discoveryPolicy {
+	return discoveryPolicy{
+		enabled:       cfg.Discovery.Enabled,
+		startBlock:    cfg.Discovery.StartBlock,
+		confirmations: cfg.Ethereum.ConfirmationDepth,
+		interval:      cfg.Discovery.Interval,
+	}
+}
+
+// Run scans for vault events every discovery interval until ctx is done. It returns at once when discovery is
+// disabled.
+func (s *Service) Run(ctx context.Context) {
+	if !s.discovery.enabled {
+		return
+	}
+	s.logger.Logf("INFO discovering vaults from DebtSubsidizer events every %v, from block %d",
+		s.discovery.interval, s.discovery.startBlock)
+	ticker := time.NewTicker(s.discovery.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Discover(ctx); err != nil {
+			s.logger.Logf("WARN failed to discover vaults, retrying in %v: %v", s.discovery.interval, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Discover applies the VaultAdded and VaultRemoved events emitted since the last scan to the registry. A vault
+// added on-chain is registered active unless the registry has it already, and a vault removed on-chain is marked
+// decommissioned, which takes it out of scheduler runs. Only blocks deep enough to survive a reorg are scanned.
+// Events are applied before the scanned block is saved, and applying one twice changes nothing, so a scan that
+// fails halfway is repeated whole.
+func (s *Service) Discover(ctx context.Context) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "vaults.Discover")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	s.discoveryMu.Lock()
+	defer s.discoveryMu.Unlock()
+
+	head, err := s.contractClient.GetBlockRef(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if head.Number < s.discovery.confirmations {
+		return nil
+	}
+	to := head.Number - s.discovery.confirmations
+
+	from := s.discovery.startBlock
+	synced, ok, err := s.store.GetDiscoverySyncedBlock()
+	if err != nil {
+		return err
+	}
+	if ok {
+		from = synced + 1
+	}
+	if from > to {
+		return nil
+	}
+	span.SetAttributes(attribute.Int64("block.from", int64(from)), attribute.Int64("block.to", int64(to)))
+
+	events, err := s.contractClient.GetVaultEvents(ctx, from, to)
+	if err != nil {
+		return err
+	}
+	for _, event := range events {
+		if err := s.applyVaultEvent(event); err != nil {
+			return err
+		}
+	}
+	if err := s.store.SaveDiscoverySyncedBlock(to); err != nil {
+		return err
+	}
+
+	if len(events) > 0 {
+		s.logger.Logf("INFO applied %d vault events of blocks %d-%d to the registry", len(events), from, to)
+	}
+	return nil
+}
+
+// applyVaultEvent registers the vault a VaultAdded event added and decommissions the one a VaultRemoved event
+// removed. Records the registry has already are completed, not replaced.
+func (s *Service) applyVaultEvent(event blockchain.VaultEvent) error {
+	address := utils.NormalizeAddress(event.Vault)
+	vault, err := s.store.GetVault(address)
+	if err != nil {
+		return err
+	}
+	if vault == nil {
+		vault = &vaults.Vault{
+			Address:     address,
+			Collections: []string{},
+			Status:      vaults.StatusActive,
+			RoleGranted: false,
+			OnboardedBy: discoveryActor,
+			OnboardedAt: s.now().UTC(),
+			Discovered:  true,
+		}
+		s.logger.Logf("INFO discovered vault %s added to the DebtSubsidizer in block %d", event.Vault, event.BlockNumber)
+	}
+
+	switch event.Kind {
+	case blockchain.VaultEventAdded:
+		if vault.AddedBlock == event.BlockNumber && vault.CToken == event.CToken {
+			return nil
+		}
+		if vault.LendingManager == "" {
+			vault.LendingManager = utils.NormalizeAddress(event.LendingManager)
+		}
+		vault.CToken = event.CToken
+		vault.AddedBlock = event.BlockNumber
+	case blockchain.VaultEventRemoved:
+		if vault.Status == vaults.StatusDecommissioned && vault.RemovedBlock == event.BlockNumber {
+			return nil
+		}
+		now := s.now().UTC()
+		if vault.DecommissionedAt == nil {
+			vault.DecommissionReason = "removed from the DebtSubsidizer"
+			vault.DecommissionedBy = discoveryActor
+			vault.DecommissionedAt = &now
+		}
+		if vault.RemovedAt == nil {
+			vault.RemovedAt = &now
+		}
+		vault.Status = vaults.StatusDecommissioned
+		vault.RemovedBlock = event.BlockNumber
+		s.logger.Logf("WARN vault %s was removed from the DebtSubsidizer in block %d, decommissioned", event.Vault, event.BlockNumber)
+	default:
+		return nil
+	}
+	return s.saveVault(vault)
+}
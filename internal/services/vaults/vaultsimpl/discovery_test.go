@@ -0,0 +1,109 @@
+package vaultsimpl
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+)
+
+const testDiscoveredVault = "0x5555555555555555555555555555555555555555"
+
+// discoveryChainMock is chainMock serving the vault events it is given, with the head at head
+func discoveryChainMock(head *uint64, events []blockchain.VaultEvent) *blockchain.BlockchainClientMock {
+	client := chainMock()
+	client.GetBlockRefFunc = func(ctx context.Context, blockNumber *big.Int) (*blockchain.BlockRef, error) {
+		return &blockchain.BlockRef{Number: *head}, nil
+	}
+	client.GetVaultEventsFunc = func(ctx context.Context, fromBlock, toBlock uint64) ([]blockchain.VaultEvent, error) {
+		var found []blockchain.VaultEvent
+		for _, event := range events {
+			if event.BlockNumber >= fromBlock && event.BlockNumber <= toBlock {
+				found = append(found, event)
+			}
+		}
+		return found, nil
+	}
+	return client
+}
+
+func TestService_Discover(t *testing.T) {
+	head := uint64(110)
+	client := discoveryChainMock(&head, []blockchain.VaultEvent{
+		{
+			Kind: blockchain.VaultEventAdded, Vault: testVault, BlockNumber: 20,
+			CToken: "0x6666666666666666666666666666666666666666", LendingManager: "0x7777777777777777777777777777777777777777",
+		},
+		{Kind: blockchain.VaultEventAdded, Vault: testDiscoveredVault, LendingManager: testLendingManager, BlockNumber: 30},
+		{Kind: blockchain.VaultEventRemoved, Vault: testDiscoveredVault, BlockNumber: 105},
+	})
+	service := newTestService(t, client, nil)
+	service.discovery = discoveryPolicy{enabled: true, startBlock: 10, confirmations: 10}
+	ctx := context.Background()
+
+	// the onboarded vault keeps its record, completed with what the event tells
+	_, err := service.Onboard(ctx, vaults.OnboardRequest{VaultAddress: testVault})
+	require.NoError(t, err)
+
+	require.NoError(t, service.Discover(ctx))
+	calls := client.GetVaultEventsCalls()
+	require.Len(t, calls, 1)
+	assert.Equal(t, uint64(10), calls[0].FromBlock)
+	assert.Equal(t, uint64(100), calls[0].ToBlock, "blocks above the confirmation depth are left for later")
+
+	registry, err := service.ListVaults(ctx)
+	require.NoError(t, err)
+	require.Len(t, registry, 2)
+	assert.Equal(t, testVault, registry[0].Address)
+	assert.False(t, registry[0].Discovered)
+	assert.Equal(t, testLendingManager, registry[0].LendingManager, "the onboarded lending manager is kept")
+	assert.Equal(t, uint64(20), registry[0].AddedBlock)
+	assert.Equal(t, testDiscoveredVault, registry[1].Address)
+	assert.True(t, registry[1].Discovered)
+	assert.Equal(t, vaults.StatusActive, registry[1].Status)
+	assert.Equal(t, discoveryActor, registry[1].OnboardedBy)
+
+	head = 120
+	require.NoError(t, service.Discover(ctx))
+	calls = client.GetVaultEventsCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, uint64(101), calls[1].FromBlock, "scans resume after the last scanned block")
+
+	removed, err := service.store.GetVault(testDiscoveredVault)
+	require.NoError(t, err)
+	assert.Equal(t, vaults.StatusDecommissioned, removed.Status)
+	assert.Equal(t, uint64(105), removed.RemovedBlock)
+	assert.Equal(t, discoveryActor, removed.DecommissionedBy)
+	require.NotNil(t, removed.RemovedAt)
+	decommissioned, err := service.IsDecommissioned(ctx, testDiscoveredVault)
+	require.NoError(t, err)
+	assert.True(t, decommissioned, "a vault removed on-chain leaves scheduler runs")
+
+	// a scan within the confirmation depth of the last one asks for no events
+	require.NoError(t, service.Discover(ctx))
+	assert.Len(t, client.GetVaultEventsCalls(), 2)
+}
+
+func TestService_DiscoverAppliesEventsOnce(t *testing.T) {
+	head := uint64(50)
+	removal := blockchain.VaultEvent{Kind: blockchain.VaultEventRemoved, Vault: testDiscoveredVault, BlockNumber: 40}
+	service := newTestService(t, discoveryChainMock(&head, []blockchain.VaultEvent{removal}), nil)
+	service.discovery = discoveryPolicy{enabled: true}
+
+	require.NoError(t, service.Discover(context.Background()))
+	first, err := service.store.GetVault(testDiscoveredVault)
+	require.NoError(t, err)
+	require.NotNil(t, first)
+	assert.Equal(t, vaults.StatusDecommissioned, first.Status, "a removal seen first still registers the vault")
+
+	// a scan repeated after failing to save its block applies the same events again
+	require.NoError(t, service.applyVaultEvent(removal))
+	again, err := service.store.GetVault(testDiscoveredVault)
+	require.NoError(t, err)
+	assert.Equal(t, first.UpdatedAt, again.UpdatedAt, "applying an event twice changes nothing")
+}
@@ -0,0 +1,382 @@
+package vaultsimpl
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/tracing"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"github.com/go-pkgz/lgr"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// audit actions recorded next to the transactions the workflows send
+const (
+	actionOnboard      = "onboardVault"
+	actionDecommission = "decommissionVault"
+)
+
+type Service struct {
+	contractClient blockchain.BlockchainClient
+	store          *Store
+	recorder       audit.Recorder // nil disables audit entries
+	logger         lgr.L
+	lendingManager string // used when a request names none
+	discovery      discoveryPolicy
+	discoveryMu    sync.Mutex // one scan at a time
+	now            func() time.Time
+}
+
+func New(
+	contractClient blockchain.BlockchainClient,
+	db storage.DB,
+	recorder audit.Recorder,
+	logger lgr.L,
+	cfg *config.Config,
+) *Service {
+	return &Service{
+		contractClient: contractClient,
+		store:          NewStore(db, logger),
+		recorder:       recorder,
+		logger:         logger,
+		lendingManager: cfg.Contracts.LendingManager,
+		discovery:      newDiscoveryPolicy(cfg),
+		now:            time.Now,
+	}
+}
+
+// onboarding is the state of one Onboard call
+type onboarding struct {
+	result *vaults.OnboardingResult
+	failed bool
+}
+
+// step runs fn unless an earlier step failed. fn reports whether the step was already done, and the call that
+// undoes it when it was done now.
+func (o *onboarding) step(name, target string, fn func() (alreadyDone bool, rollback string, err error)) {
+	step := vaults.OnboardingStep{Name: name, Target: target, Status: vaults.StepNotRun}
+	defer func() { o.result.Steps = append(o.result.Steps, step) }()
+	if o.failed {
+		return
+	}
+
+	alreadyDone, rollback, err := fn()
+	switch {
+	case err != nil:
+		o.failed = true
+		step.Status = vaults.StepFailed
+		step.Error = err.Error()
+	case alreadyDone:
+		step.Status = vaults.StepAlreadyDone
+	default:
+		step.Status = vaults.StepDone
+		if rollback != "" {
+			o.result.Rollback = append(o.result.Rollback, rollback)
+		}
+	}
+}
+
+// Onboard runs the onboarding steps in order, stopping at the first failure. The registry record is saved
+// after every step that changes it, so a retried onboarding knows the vault role was granted.
+func (s *Service) Onboard(ctx context.Context, req vaults.OnboardRequest) (_ *vaults.OnboardingResult, err error) {
+	ctx, span := tracing.StartSpan(ctx, "vaults.Onboard", attribute.String("vault.id", req.VaultAddress))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	vaultAddress, lendingManager, collections, err := s.validate(req)
+	if err != nil {
+		return nil, err
+	}
+
+	vault, err := s.store.GetVault(vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	if vault != nil && vault.Status == vaults.StatusDecommissioned {
+		return nil, fmt.Errorf("%w: %s was removed from the DebtSubsidizer", vaults.ErrDecommissioned, vaultAddress)
+	}
+	if vault == nil {
+		vault = &vaults.Vault{
+			Address:     vaultAddress,
+			Collections: []string{},
+			Status:      vaults.StatusOnboarding,
+			OnboardedBy: audit.ActorFromContext(ctx),
+			OnboardedAt: s.now().UTC(),
+		}
+	}
+	vault.LendingManager = lendingManager
+
+	o := &onboarding{result: &vaults.OnboardingResult{Steps: []vaults.OnboardingStep{}}}
+	o.step(vaults.StepAddVault, "", func() (bool, string, error) {
+		return s.addVault(ctx, vaultAddress, lendingManager)
+	})
+	o.step(vaults.StepGrantVaultRole, "", func() (bool, string, error) {
+		if vault.RoleGranted {
+			return true, "", nil
+		}
+		if err := s.contractClient.GrantVaultRole(ctx, vaultAddress); err != nil {
+			return false, "", err
+		}
+		vault.RoleGranted = true
+		rollback := fmt.Sprintf("revokeVaultRole(%s) on the EpochManager", vaultAddress)
+		return false, rollback, s.saveVault(vault)
+	})
+	for _, collection := range collections {
+		o.step(vaults.StepWhitelistCollection, collection, func() (bool, string, error) {
+			alreadyDone, rollback, err := s.whitelistCollection(ctx, vaultAddress, collection)
+			if err == nil && !slices.Contains(vault.Collections, collection) {
+				vault.Collections = append(vault.Collections, collection)
+			}
+			return alreadyDone, rollback, err
+		})
+	}
+	o.step(vaults.StepRegisterVault, "", func() (bool, string, error) {
+		if vault.Status == vaults.StatusActive {
+			return true, "", s.saveVault(vault)
+		}
+		// onboarding a decommissioning vault again puts it back into scheduler runs
+		previous := *vault
+		vault.Status = vaults.StatusActive
+		vault.DecommissionReason, vault.DecommissionedBy, vault.DecommissionedAt = "", "", nil
+		if err := s.saveVault(vault); err != nil {
+			*vault = previous
+			return false, "", err
+		}
+		return false, "", nil
+	})
+
+	result := o.result
+	result.Complete = !o.failed
+	if o.failed {
+		// progress is kept, a vault that never completed an onboarding stays in the registry as onboarding
+		if err := s.saveVault(vault); err != nil {
+			s.logger.Logf("WARN failed to save partially onboarded vault %s: %v", vaultAddress, err)
+		}
+		s.logger.Logf("ERROR onboarding of vault %s failed, %d steps to roll back", vaultAddress, len(result.Rollback))
+	} else {
+		result.Rollback = nil
+		s.logger.Logf("INFO vault %s onboarded by %s", vaultAddress, audit.ActorFromContext(ctx))
+	}
+	result.Vault = *vault
+
+	span.SetAttributes(attribute.Bool("vaults.complete", result.Complete))
+	s.record(ctx, result, lendingManager, collections)
+	return result, nil
+}
+
+// ListVaults returns the registry
+func (s *Service) ListVaults(ctx context.Context) (_ []vaults.Vault, err error) {
+	_, span := tracing.StartSpan(ctx, "vaults.ListVaults")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.store.ListVaults()
+}
+
+// Decommission marks the vault decommissioning, which the scheduler checks every boundary, and with req.Confirm
+// removes it from the DebtSubsidizer. A vault never added to the DebtSubsidizer or already removed there is only
+// marked. Decommissioning a decommissioned vault returns it unchanged.
+func (s *Service) Decommission(ctx context.Context, vaultAddress string, req vaults.DecommissionRequest) (_ *vaults.Vault, err error) {
+	ctx, span := tracing.StartSpan(ctx, "vaults.Decommission",
+		attribute.String("vault.id", vaultAddress), attribute.Bool("vaults.confirm", req.Confirm))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	if !utils.IsValidAddress(vaultAddress) {
+		return nil, fmt.Errorf("%w: invalid vault address %q", vaults.ErrInvalidInput, vaultAddress)
+	}
+	vaultAddress = utils.NormalizeAddress(vaultAddress)
+
+	vault, err := s.store.GetVault(vaultAddress)
+	if err != nil {
+		return nil, err
+	}
+	if vault != nil && vault.Status == vaults.StatusDecommissioned {
+		return vault, nil
+	}
+	if vault == nil {
+		// vaults configured before the registry existed are decommissioned the same way
+		vault = &vaults.Vault{Address: vaultAddress, Collections: []string{}, OnboardedAt: s.now().UTC()}
+	}
+
+	if vault.Status != vaults.StatusDecommissioning {
+		if req.Reason == "" {
+			return nil, fmt.Errorf("%w: a reason is required to decommission a vault", vaults.ErrInvalidInput)
+		}
+		decommissionedAt := s.now().UTC()
+		vault.Status = vaults.StatusDecommissioning
+		vault.DecommissionReason = req.Reason
+		vault.DecommissionedBy = audit.ActorFromContext(ctx)
+		vault.DecommissionedAt = &decommissionedAt
+		if err := s.saveVault(vault); err != nil {
+			return nil, err
+		}
+		s.logger.Logf("WARN vault %s decommissioning by %s, excluded from scheduler runs: %s",
+			vaultAddress, vault.DecommissionedBy, req.Reason)
+	}
+	if !req.Confirm {
+		s.recordDecommission(ctx, vault, false, nil)
+		return vault, nil
+	}
+
+	if err := s.removeVault(ctx, vaultAddress); err != nil {
+		s.recordDecommission(ctx, vault, true, err)
+		return nil, err
+	}
+	removedAt := s.now().UTC()
+	vault.Status = vaults.StatusDecommissioned
+	vault.RemovedAt = &removedAt
+	if err := s.saveVault(vault); err != nil {
+		return nil, err
+	}
+	s.logger.Logf("WARN vault %s decommissioned by %s", vaultAddress, audit.ActorFromContext(ctx))
+	s.recordDecommission(ctx, vault, true, nil)
+	return vault, nil
+}
+
+// IsDecommissioned reads the vault's registry status, a vault missing from the registry is not decommissioned
+func (s *Service) IsDecommissioned(ctx context.Context, vaultAddress string) (bool, error) {
+	vault, err := s.store.GetVault(vaultAddress)
+	if err != nil {
+		return false, err
+	}
+	return vault != nil && (vault.Status == vaults.StatusDecommissioning || vault.Status == vaults.StatusDecommissioned), nil
+}
+
+// removeVault removes the vault from the DebtSubsidizer unless it is not there
+func (s *Service) removeVault(ctx context.Context, vaultAddress string) error {
+	registration, err := s.contractClient.GetVaultRegistration(ctx, vaultAddress)
+	if err != nil {
+		return err
+	}
+	if registration.Removed || registration.LendingManager == "" {
+		s.logger.Logf("INFO vault %s is not on the DebtSubsidizer, nothing to remove", vaultAddress)
+		return nil
+	}
+	if err := s.contractClient.RemoveVault(ctx, vaultAddress); err != nil {
+		return fmt.Errorf("%w: removeVault(%s): %v", vaults.ErrTransactionFailed, vaultAddress, err)
+	}
+	return nil
+}
+
+// validate returns the request's addresses normalized, the lending manager defaulted and collections deduplicated
+func (s *Service) validate(req vaults.OnboardRequest) (vault, lendingManager string, collections []string, err error) {
+	if !utils.IsValidAddress(req.VaultAddress) {
+		return "", "", nil, fmt.Errorf("%w: invalid vault address %q", vaults.ErrInvalidInput, req.VaultAddress)
+	}
+	lendingManager = req.LendingManager
+	if lendingManager == "" {
+		lendingManager = s.lendingManager
+	}
+	if !utils.IsValidAddress(lendingManager) {
+		return "", "", nil, fmt.Errorf("%w: invalid lending manager address %q", vaults.ErrInvalidInput, lendingManager)
+	}
+	for _, collection := range req.Collections {
+		if !utils.IsValidAddress(collection) {
+			return "", "", nil, fmt.Errorf("%w: invalid collection address %q", vaults.ErrInvalidInput, collection)
+		}
+		if collection = utils.NormalizeAddress(collection); !slices.Contains(collections, collection) {
+			collections = append(collections, collection)
+		}
+	}
+	return utils.NormalizeAddress(req.VaultAddress), utils.NormalizeAddress(lendingManager), collections, nil
+}
+
+// addVault adds the vault to the DebtSubsidizer unless it is there already. A vault added with another lending
+// manager, or removed, is not changed: the DebtSubsidizer cannot re-add it.
+func (s *Service) addVault(ctx context.Context, vault, lendingManager string) (bool, string, error) {
+	registration, err := s.contractClient.GetVaultRegistration(ctx, vault)
+	if err != nil {
+		return false, "", err
+	}
+	if registration.Removed {
+		return false, "", fmt.Errorf("vault %s was removed from the DebtSubsidizer", vault)
+	}
+	if registration.LendingManager != "" {
+		if utils.NormalizeAddress(registration.LendingManager) != lendingManager {
+			return false, "", fmt.Errorf("vault %s is already added with lending manager %s", vault, registration.LendingManager)
+		}
+		return true, "", nil
+	}
+	if err := s.contractClient.AddVault(ctx, vault, lendingManager); err != nil {
+		return false, "", err
+	}
+	return false, fmt.Sprintf("removeVault(%s) on the DebtSubsidizer", vault), nil
+}
+
+// whitelistCollection whitelists the collection for the vault unless it is whitelisted already
+func (s *Service) whitelistCollection(ctx context.Context, vault, collection string) (bool, string, error) {
+	whitelisted, err := s.contractClient.IsCollectionWhitelisted(ctx, vault, collection)
+	if err != nil {
+		return false, "", err
+	}
+	if whitelisted {
+		return true, "", nil
+	}
+	if err := s.contractClient.WhitelistCollection(ctx, vault, collection); err != nil {
+		return false, "", err
+	}
+	return false, fmt.Sprintf("removeCollection(%s, %s) on the DebtSubsidizer", vault, collection), nil
+}
+
+func (s *Service) saveVault(vault *vaults.Vault) error {
+	vault.UpdatedAt = s.now().UTC()
+	return s.store.SaveVault(*vault)
+}
+
+// recordDecommission writes the decommissioning to the audit log. Failures are logged, the change is already stored.
+func (s *Service) recordDecommission(ctx context.Context, vault *vaults.Vault, confirm bool, failure error) {
+	if s.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action: actionDecommission,
+		Actor:  audit.ActorFromContext(ctx),
+		Parameters: map[string]string{
+			"vault":   vault.Address,
+			"reason":  vault.DecommissionReason,
+			"confirm": fmt.Sprint(confirm),
+		},
+		Result: audit.ResultSuccess,
+	}
+	if failure != nil {
+		entry.Result = audit.ResultFailed
+		entry.Error = failure.Error()
+	}
+	if err := s.recorder.Record(ctx, entry); err != nil {
+		s.logger.Logf("WARN failed to record %s in audit log: %v", actionDecommission, err)
+	}
+}
+
+// record writes the onboarding to the audit log. Failures are logged, the onboarding itself is already done.
+func (s *Service) record(ctx context.Context, result *vaults.OnboardingResult, lendingManager string, collections []string) {
+	if s.recorder == nil {
+		return
+	}
+	entry := audit.Entry{
+		Action: actionOnboard,
+		Actor:  audit.ActorFromContext(ctx),
+		Parameters: map[string]string{
+			"vault":          result.Vault.Address,
+			"lendingManager": lendingManager,
+		},
+		Result: audit.ResultSuccess,
+	}
+	if len(collections) > 0 {
+		entry.Parameters["collections"] = fmt.Sprint(collections)
+	}
+	for _, step := range result.Steps {
+		if step.Status == vaults.StepFailed {
+			entry.Result = audit.ResultFailed
+			entry.Error = fmt.Sprintf("%s: %s", step.Name, step.Error)
+		}
+	}
+	if err := s.recorder.Record(ctx, entry); err != nil {
+		s.logger.Logf("WARN failed to record %s in audit log: %v", actionOnboard, err)
+	}
+}
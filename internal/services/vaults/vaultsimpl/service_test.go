@@ -0,0 +1,284 @@
+package vaultsimpl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/blockchain"
+	"github.com/andrey/epoch-server/internal/infra/config"
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+)
+
+const (
+	testVault          = "0x1111111111111111111111111111111111111111"
+	testLendingManager = "0x2222222222222222222222222222222222222222"
+	testCollectionA    = "0x3333333333333333333333333333333333333333"
+	testCollectionB    = "0x4444444444444444444444444444444444444444"
+)
+
+func newTestDB(t *testing.T) storage.DB {
+	t.Helper()
+
+	opts := badger.DefaultOptions("").WithInMemory(true)
+	opts.Logger = nil
+	badgerDB, err := badger.Open(opts)
+	require.NoError(t, err)
+	db := storage.Badger(badgerDB)
+	t.Cleanup(func() { badgerDB.Close() })
+	return db
+}
+
+// chainMock keeps the on-chain state the onboarding changes
+func chainMock() *blockchain.BlockchainClientMock {
+	registration := &blockchain.VaultRegistration{}
+	whitelisted := map[string]bool{}
+	return &blockchain.BlockchainClientMock{
+		GetVaultRegistrationFunc: func(ctx context.Context, vaultAddress string) (*blockchain.VaultRegistration, error) {
+			return registration, nil
+		},
+		AddVaultFunc: func(ctx context.Context, vaultAddress, lendingManagerAddress string) error {
+			registration = &blockchain.VaultRegistration{LendingManager: lendingManagerAddress}
+			return nil
+		},
+		GrantVaultRoleFunc: func(ctx context.Context, vaultAddress string) error { return nil },
+		IsCollectionWhitelistedFunc: func(ctx context.Context, vaultAddress, collectionAddress string) (bool, error) {
+			return whitelisted[collectionAddress], nil
+		},
+		WhitelistCollectionFunc: func(ctx context.Context, vaultAddress, collectionAddress string) error {
+			whitelisted[collectionAddress] = true
+			return nil
+		},
+	}
+}
+
+func newTestService(t *testing.T, client blockchain.BlockchainClient, recorder audit.Recorder) *Service {
+	cfg := &config.Config{}
+	cfg.Contracts.LendingManager = testLendingManager
+	return New(client, newTestDB(t), recorder, lgr.NoOp, cfg)
+}
+
+func TestService_Onboard(t *testing.T) {
+	client := chainMock()
+	recorder := &audit.RecorderMock{
+		RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil },
+	}
+	service := newTestService(t, client, recorder)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	result, err := service.Onboard(ctx, vaults.OnboardRequest{
+		VaultAddress: testVault,
+		Collections:  []string{testCollectionA, testCollectionA},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Complete)
+	assert.Empty(t, result.Rollback)
+	assert.Equal(t, []vaults.OnboardingStep{
+		{Name: vaults.StepAddVault, Status: vaults.StepDone},
+		{Name: vaults.StepGrantVaultRole, Status: vaults.StepDone},
+		{Name: vaults.StepWhitelistCollection, Target: testCollectionA, Status: vaults.StepDone},
+		{Name: vaults.StepRegisterVault, Status: vaults.StepDone},
+	}, result.Steps)
+	require.Len(t, client.AddVaultCalls(), 1)
+	assert.Equal(t, testLendingManager, client.AddVaultCalls()[0].LendingManagerAddress, "the configured lending manager is the default")
+	assert.Equal(t, vaults.StatusActive, result.Vault.Status)
+	assert.Equal(t, []string{testCollectionA}, result.Vault.Collections)
+	assert.Equal(t, "api:10.0.0.1", result.Vault.OnboardedBy)
+
+	// onboarding again only whitelists the new collection
+	result, err = service.Onboard(ctx, vaults.OnboardRequest{
+		VaultAddress: testVault,
+		Collections:  []string{testCollectionA, testCollectionB},
+	})
+	require.NoError(t, err)
+	assert.True(t, result.Complete)
+	for _, step := range result.Steps {
+		if step.Target != testCollectionB {
+			assert.Equal(t, vaults.StepAlreadyDone, step.Status, step.Name)
+		}
+	}
+	assert.Len(t, client.AddVaultCalls(), 1)
+	assert.Len(t, client.GrantVaultRoleCalls(), 1)
+	assert.Len(t, client.WhitelistCollectionCalls(), 2)
+	assert.Equal(t, []string{testCollectionA, testCollectionB}, result.Vault.Collections)
+
+	registry, err := service.ListVaults(ctx)
+	require.NoError(t, err)
+	require.Len(t, registry, 1)
+	assert.Equal(t, result.Vault, registry[0])
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 2)
+	assert.Equal(t, "onboardVault", calls[0].Entry.Action)
+	assert.Equal(t, audit.ResultSuccess, calls[0].Entry.Result)
+}
+
+func TestService_OnboardPartialFailure(t *testing.T) {
+	client := chainMock()
+	whitelistErr := fmt.Errorf("execution reverted")
+	whitelist := client.WhitelistCollectionFunc
+	client.WhitelistCollectionFunc = func(ctx context.Context, vaultAddress, collectionAddress string) error {
+		if collectionAddress == testCollectionB && whitelistErr != nil {
+			return whitelistErr
+		}
+		return whitelist(ctx, vaultAddress, collectionAddress)
+	}
+	recorder := &audit.RecorderMock{
+		RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil },
+	}
+	service := newTestService(t, client, recorder)
+	ctx := context.Background()
+	req := vaults.OnboardRequest{VaultAddress: testVault, Collections: []string{testCollectionA, testCollectionB}}
+
+	result, err := service.Onboard(ctx, req)
+	require.NoError(t, err)
+	assert.False(t, result.Complete)
+	assert.Equal(t, []vaults.OnboardingStep{
+		{Name: vaults.StepAddVault, Status: vaults.StepDone},
+		{Name: vaults.StepGrantVaultRole, Status: vaults.StepDone},
+		{Name: vaults.StepWhitelistCollection, Target: testCollectionA, Status: vaults.StepDone},
+		{Name: vaults.StepWhitelistCollection, Target: testCollectionB, Status: vaults.StepFailed, Error: "execution reverted"},
+		{Name: vaults.StepRegisterVault, Status: vaults.StepNotRun},
+	}, result.Steps)
+	assert.Equal(t, []string{
+		"removeVault(" + testVault + ") on the DebtSubsidizer",
+		"revokeVaultRole(" + testVault + ") on the EpochManager",
+		"removeCollection(" + testVault + ", " + testCollectionA + ") on the DebtSubsidizer",
+	}, result.Rollback)
+	assert.Equal(t, vaults.StatusOnboarding, result.Vault.Status)
+	assert.True(t, result.Vault.RoleGranted)
+	require.Len(t, recorder.RecordCalls(), 1)
+	assert.Equal(t, audit.ResultFailed, recorder.RecordCalls()[0].Entry.Result)
+	assert.Equal(t, "whitelist_collection: execution reverted", recorder.RecordCalls()[0].Entry.Error)
+
+	// retrying resumes at the failed step
+	whitelistErr = nil
+	result, err = service.Onboard(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.Complete)
+	assert.Empty(t, result.Rollback)
+	assert.Len(t, client.AddVaultCalls(), 1)
+	assert.Len(t, client.GrantVaultRoleCalls(), 1)
+	assert.Equal(t, vaults.StatusActive, result.Vault.Status)
+}
+
+func TestService_OnboardConflictingRegistration(t *testing.T) {
+	client := chainMock()
+	client.GetVaultRegistrationFunc = func(ctx context.Context, vaultAddress string) (*blockchain.VaultRegistration, error) {
+		return &blockchain.VaultRegistration{LendingManager: "0x5555555555555555555555555555555555555555"}, nil
+	}
+	service := newTestService(t, client, nil)
+
+	result, err := service.Onboard(context.Background(), vaults.OnboardRequest{VaultAddress: testVault})
+	require.NoError(t, err)
+	assert.False(t, result.Complete)
+	assert.Equal(t, vaults.StepFailed, result.Steps[0].Status)
+	assert.Contains(t, result.Steps[0].Error, "already added with lending manager 0x5555555555555555555555555555555555555555")
+	assert.Empty(t, result.Rollback)
+	assert.Empty(t, client.AddVaultCalls())
+	assert.Empty(t, client.GrantVaultRoleCalls())
+}
+
+func TestService_OnboardInvalidInput(t *testing.T) {
+	service := newTestService(t, chainMock(), nil)
+
+	for _, req := range []vaults.OnboardRequest{
+		{VaultAddress: "not-an-address"},
+		{VaultAddress: testVault, LendingManager: "0x12"},
+		{VaultAddress: testVault, Collections: []string{"bad"}},
+	} {
+		_, err := service.Onboard(context.Background(), req)
+		assert.ErrorIs(t, err, vaults.ErrInvalidInput)
+	}
+}
+
+func TestService_Decommission(t *testing.T) {
+	client := chainMock()
+	client.RemoveVaultFunc = func(ctx context.Context, vaultAddress string) error { return nil }
+	recorder := &audit.RecorderMock{
+		RecordFunc: func(ctx context.Context, entry audit.Entry) error { return nil },
+	}
+	service := newTestService(t, client, recorder)
+	ctx := audit.WithActor(context.Background(), "api:10.0.0.1")
+
+	_, err := service.Onboard(ctx, vaults.OnboardRequest{VaultAddress: testVault})
+	require.NoError(t, err)
+
+	_, err = service.Decommission(ctx, testVault, vaults.DecommissionRequest{})
+	assert.ErrorIs(t, err, vaults.ErrInvalidInput, "a reason is required")
+
+	vault, err := service.Decommission(ctx, testVault, vaults.DecommissionRequest{Reason: "migrated"})
+	require.NoError(t, err)
+	assert.Equal(t, vaults.StatusDecommissioning, vault.Status)
+	assert.Equal(t, "api:10.0.0.1", vault.DecommissionedBy)
+	require.NotNil(t, vault.DecommissionedAt)
+	assert.Nil(t, vault.RemovedAt)
+	assert.Empty(t, client.RemoveVaultCalls(), "nothing is removed until confirmed")
+	decommissioned, err := service.IsDecommissioned(ctx, testVault)
+	require.NoError(t, err)
+	assert.True(t, decommissioned)
+
+	// confirming keeps the reason given first
+	vault, err = service.Decommission(ctx, testVault, vaults.DecommissionRequest{Confirm: true})
+	require.NoError(t, err)
+	assert.Equal(t, vaults.StatusDecommissioned, vault.Status)
+	assert.Equal(t, "migrated", vault.DecommissionReason)
+	require.NotNil(t, vault.RemovedAt)
+	require.Len(t, client.RemoveVaultCalls(), 1)
+
+	_, err = service.Decommission(ctx, testVault, vaults.DecommissionRequest{Confirm: true})
+	require.NoError(t, err)
+	assert.Len(t, client.RemoveVaultCalls(), 1, "decommissioning twice changes nothing")
+
+	_, err = service.Onboard(ctx, vaults.OnboardRequest{VaultAddress: testVault})
+	assert.ErrorIs(t, err, vaults.ErrDecommissioned)
+
+	calls := recorder.RecordCalls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, "decommissionVault", calls[1].Entry.Action)
+	assert.Equal(t, "false", calls[1].Entry.Parameters["confirm"])
+	assert.Equal(t, "true", calls[2].Entry.Parameters["confirm"])
+}
+
+func TestService_DecommissionReinstatedByOnboarding(t *testing.T) {
+	service := newTestService(t, chainMock(), nil)
+	ctx := context.Background()
+
+	// a vault missing from the registry is decommissioned all the same
+	vault, err := service.Decommission(ctx, testVault, vaults.DecommissionRequest{Reason: "paused for review"})
+	require.NoError(t, err)
+	assert.Equal(t, vaults.StatusDecommissioning, vault.Status)
+
+	result, err := service.Onboard(ctx, vaults.OnboardRequest{VaultAddress: testVault})
+	require.NoError(t, err)
+	assert.Equal(t, vaults.StatusActive, result.Vault.Status)
+	assert.Empty(t, result.Vault.DecommissionReason)
+	decommissioned, err := service.IsDecommissioned(ctx, testVault)
+	require.NoError(t, err)
+	assert.False(t, decommissioned)
+}
+
+func TestService_DecommissionRemoveFails(t *testing.T) {
+	client := chainMock()
+	client.GetVaultRegistrationFunc = func(ctx context.Context, vaultAddress string) (*blockchain.VaultRegistration, error) {
+		return &blockchain.VaultRegistration{LendingManager: testLendingManager}, nil
+	}
+	client.RemoveVaultFunc = func(ctx context.Context, vaultAddress string) error { return fmt.Errorf("execution reverted") }
+	service := newTestService(t, client, nil)
+	ctx := context.Background()
+
+	_, err := service.Decommission(ctx, testVault, vaults.DecommissionRequest{Reason: "migrated", Confirm: true})
+	require.ErrorIs(t, err, vaults.ErrTransactionFailed)
+
+	registry, err := service.ListVaults(ctx)
+	require.NoError(t, err)
+	require.Len(t, registry, 1)
+	assert.Equal(t, vaults.StatusDecommissioning, registry[0].Status, "out of scheduler runs even though removal failed")
+}
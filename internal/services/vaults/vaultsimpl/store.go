@@ -0,0 +1,137 @@
+package vaultsimpl
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/andrey/epoch-server/internal/infra/storage"
+	"github.com/andrey/epoch-server/internal/infra/utils"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+	"github.com/dgraph-io/badger/v4"
+	"github.com/go-pkgz/lgr"
+)
+
+const (
+	vaultPrefix        = "vaults:registry:"
+	discoverySyncedKey = "vaults:discovery:synced"
+)
+
+// Store keeps the vault registry
+type Store struct {
+	db     storage.DB
+	logger lgr.L
+}
+
+// NewStore creates a new store instance
+func NewStore(db storage.DB, logger lgr.L) *Store {
+	return &Store{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// SaveVault stores the vault, replacing its earlier record
+func (s *Store) SaveVault(vault vaults.Vault) error {
+	data, err := json.Marshal(vault)
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(s.buildVaultKey(vault.Address)), data)
+	}); err != nil {
+		return fmt.Errorf("failed to save vault %s: %w", vault.Address, err)
+	}
+
+	return nil
+}
+
+// GetVault returns the vault's record, or nil when it is not registered
+func (s *Store) GetVault(address string) (*vaults.Vault, error) {
+	var vault *vaults.Vault
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(s.buildVaultKey(address)))
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			vault = &vaults.Vault{}
+			return json.Unmarshal(val, vault)
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get vault %s: %w", address, err)
+	}
+
+	return vault, nil
+}
+
+// ListVaults returns every registered vault, ordered by address
+func (s *Store) ListVaults() ([]vaults.Vault, error) {
+	result := []vaults.Vault{}
+	err := s.db.View(func(txn storage.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.Prefix = []byte(vaultPrefix)
+
+		it := txn.NewIterator(opts)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			var vault vaults.Vault
+			err := it.Item().Value(func(val []byte) error {
+				return json.Unmarshal(val, &vault)
+			})
+			if err != nil {
+				return fmt.Errorf("failed to decode vault: %w", err)
+			}
+			result = append(result, vault)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list vaults: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetDiscoverySyncedBlock returns the block vault events were scanned through, false when none were scanned yet
+func (s *Store) GetDiscoverySyncedBlock() (uint64, bool, error) {
+	var synced uint64
+	err := s.db.View(func(txn storage.Txn) error {
+		item, err := txn.Get([]byte(discoverySyncedKey))
+		if err != nil {
+			return err
+		}
+		return item.Value(func(val []byte) error {
+			synced, err = strconv.ParseUint(string(val), 10, 64)
+			return err
+		})
+	})
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get discovery synced block: %w", err)
+	}
+	return synced, true, nil
+}
+
+// SaveDiscoverySyncedBlock stores the block vault events were scanned through
+func (s *Store) SaveDiscoverySyncedBlock(block uint64) error {
+	if err := s.db.Update(func(txn storage.Txn) error {
+		return txn.Set([]byte(discoverySyncedKey), []byte(strconv.FormatUint(block, 10)))
+	}); err != nil {
+		return fmt.Errorf("failed to save discovery synced block: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) buildVaultKey(address string) string {
+	return vaultPrefix + utils.NormalizeAddress(address)
+}
@@ -0,0 +1,51 @@
+package webhook
+
+import (
+	"time"
+)
+
+// EventType identifies the kind of event a webhook payload carries
+type EventType string
+
+const (
+	EventEpochStarted        EventType = "epoch.started"
+	EventEpochFinalized      EventType = "epoch.finalized"
+	EventEpochForceEnded     EventType = "epoch.force_ended"
+	EventMerkleRootUpdated   EventType = "merkle_root.updated"
+	EventDistributionFailed  EventType = "distribution.failed"
+	EventDistributionStaged  EventType = "distribution.pending_approval"
+	EventAccountsQuarantined EventType = "distribution.accounts_quarantined"
+	EventLowSignerBalance    EventType = "signer.low_balance"
+	EventContractPaused      EventType = "contract.paused"
+	EventGasBudgetExceeded   EventType = "gas.budget_exceeded"
+	EventEpochFailed         EventType = "epoch.failed"
+	EventTransactionFailed   EventType = "transaction.failed"
+	EventYieldDiscrepancy    EventType = "reconciliation.discrepancy"
+	EventClaimsDiscrepancy   EventType = "reconciliation.claims_discrepancy"
+	EventJobFailing          EventType = "scheduler.job_failing"
+	EventSubgraphLagging     EventType = "subgraph.lagging"
+)
+
+// Event is the JSON payload POSTed to every configured webhook endpoint.
+// Text is a human readable summary so the payload can be sent straight to a Slack incoming webhook.
+type Event struct {
+	ID        string                 `json:"id"`
+	Type      EventType              `json:"type"`
+	Timestamp int64                  `json:"timestamp"`
+	Text      string                 `json:"text"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// Endpoint is a webhook receiver and the secret used to sign payloads sent to it
+type Endpoint struct {
+	URL    string
+	Secret string
+}
+
+// Config holds webhook delivery settings
+type Config struct {
+	Endpoints    []Endpoint
+	Timeout      time.Duration
+	MaxRetries   int
+	RetryBackoff time.Duration
+}
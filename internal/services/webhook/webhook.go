@@ -0,0 +1,13 @@
+package webhook
+
+import (
+	"context"
+)
+
+//go:generate moq -out webhook_mocks.go . Notifier
+
+// Notifier delivers epoch lifecycle events to external systems
+type Notifier interface {
+	// Notify queues an event for delivery and returns without waiting for it to be sent
+	Notify(ctx context.Context, eventType EventType, data map[string]interface{})
+}
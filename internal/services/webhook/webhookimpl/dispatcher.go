@@ -0,0 +1,300 @@
+package webhookimpl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+)
+
+// headers set on every webhook request
+const (
+	EventHeader     = "X-Epoch-Event"
+	DeliveryHeader  = "X-Epoch-Delivery"
+	TimestampHeader = "X-Epoch-Timestamp"
+	SignatureHeader = "X-Epoch-Signature"
+)
+
+// Dispatcher POSTs events to the configured endpoints in the background.
+// Failed deliveries are retried with exponential backoff and recorded as dead letters
+// once retries are exhausted or the dispatcher shuts down.
+type Dispatcher struct {
+	endpoints    []webhook.Endpoint
+	httpClient   *http.Client
+	deadLetters  deadletter.Recorder
+	logger       lgr.L
+	maxRetries   int
+	retryBackoff time.Duration
+	now          func() time.Time
+
+	mu     sync.Mutex
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+// statusError is returned when an endpoint responds with a non-2xx status
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("endpoint returned status %d", e.code)
+}
+
+func New(cfg webhook.Config, deadLetters deadletter.Recorder, logger lgr.L) *Dispatcher {
+	return &Dispatcher{
+		endpoints:    cfg.Endpoints,
+		httpClient:   &http.Client{Timeout: cfg.Timeout},
+		deadLetters:  deadLetters,
+		logger:       logger,
+		maxRetries:   cfg.MaxRetries,
+		retryBackoff: cfg.RetryBackoff,
+		now:          time.Now,
+		done:         make(chan struct{}),
+	}
+}
+
+// Notify delivers the event to every endpoint in its own goroutine.
+// Deliveries outlive the caller's request, so ctx is not used to cancel them.
+func (d *Dispatcher) Notify(_ context.Context, eventType webhook.EventType, data map[string]interface{}) {
+	if len(d.endpoints) == 0 {
+		return
+	}
+
+	event := webhook.Event{
+		ID:        newEventID(),
+		Type:      eventType,
+		Timestamp: d.now().Unix(),
+		Text:      summarize(eventType, data),
+		Data:      data,
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		d.logger.Logf("ERROR failed to marshal webhook event %s: %v", eventType, err)
+		return
+	}
+
+	d.mu.Lock()
+	closed := d.closed
+	if !closed {
+		d.wg.Add(len(d.endpoints))
+	}
+	d.mu.Unlock()
+
+	for _, endpoint := range d.endpoints {
+		if closed {
+			d.deadLetter(event, endpoint, 0, fmt.Errorf("dispatcher is shut down"))
+			continue
+		}
+		go func(endpoint webhook.Endpoint) {
+			defer d.wg.Done()
+			d.deliver(event, body, endpoint)
+		}(endpoint)
+	}
+}
+
+// Close stops retrying, dead-letters pending deliveries and waits for in-flight requests to finish
+func (d *Dispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		close(d.done)
+	}
+	d.mu.Unlock()
+
+	finished := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for webhook deliveries: %w", ctx.Err())
+	}
+}
+
+// Retry delivers the event of a webhook dead letter to its endpoint once more. The event keeps its ID, so a
+// receiver that did get it before can tell the delivery apart.
+func (d *Dispatcher) Retry(ctx context.Context, entry deadletter.Entry) (string, error) {
+	var event webhook.Event
+	if err := json.Unmarshal(entry.Payload, &event); err != nil {
+		return "", fmt.Errorf("%w: malformed webhook event: %v", deadletter.ErrNotRetryable, err)
+	}
+	endpoint, ok := d.endpoint(entry.Target)
+	if !ok {
+		return "", fmt.Errorf("%w: %s is no longer a webhook endpoint", deadletter.ErrNotRetryable, entry.Target)
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal webhook event %s: %w", event.ID, err)
+	}
+
+	if err := d.send(ctx, event, body, endpoint); err != nil {
+		return "", err
+	}
+	d.logger.Logf("INFO redelivered webhook %s (%s) to %s", event.ID, event.Type, endpoint.URL)
+	return deadletter.OutcomeDelivered, nil
+}
+
+// endpoint returns the configured endpoint with the URL, whose current secret signs the redelivery
+func (d *Dispatcher) endpoint(url string) (webhook.Endpoint, bool) {
+	for _, endpoint := range d.endpoints {
+		if endpoint.URL == url {
+			return endpoint, true
+		}
+	}
+	return webhook.Endpoint{}, false
+}
+
+func (d *Dispatcher) deliver(event webhook.Event, body []byte, endpoint webhook.Endpoint) {
+	backoff := d.retryBackoff
+	attempts := 0
+
+	for {
+		attempts++
+		err := d.send(context.Background(), event, body, endpoint)
+		if err == nil {
+			d.logger.Logf("DEBUG delivered webhook %s (%s) to %s", event.ID, event.Type, endpoint.URL)
+			return
+		}
+
+		if attempts > d.maxRetries || !isRetryable(err) {
+			d.deadLetter(event, endpoint, attempts, err)
+			return
+		}
+
+		d.logger.Logf("WARN webhook %s to %s failed (attempt %d of %d), retrying in %s: %v",
+			event.ID, endpoint.URL, attempts, d.maxRetries+1, backoff, err)
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-d.done:
+			timer.Stop()
+			d.deadLetter(event, endpoint, attempts, fmt.Errorf("shut down before retry: %w", err))
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, event webhook.Event, body []byte, endpoint webhook.Endpoint) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(event.Type))
+	req.Header.Set(DeliveryHeader, event.ID)
+
+	if endpoint.Secret != "" {
+		timestamp := strconv.FormatInt(d.now().Unix(), 10)
+		req.Header.Set(TimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, Sign(endpoint.Secret, timestamp, body))
+	}
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // body is drained and discarded
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &statusError{code: resp.StatusCode}
+	}
+	return nil
+}
+
+func (d *Dispatcher) deadLetter(event webhook.Event, endpoint webhook.Endpoint, attempts int, err error) {
+	d.logger.Logf("ERROR webhook %s (%s) to %s failed after %d attempts: %v", event.ID, event.Type, endpoint.URL, attempts, err)
+
+	payload, marshalErr := json.Marshal(event)
+	if marshalErr != nil {
+		d.logger.Logf("ERROR failed to marshal webhook dead letter %s: %v", event.ID, marshalErr)
+		return
+	}
+	letter := deadletter.Entry{
+		Kind:      deadletter.KindWebhook,
+		Target:    endpoint.URL,
+		Payload:   payload,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		FailedAt:  d.now(),
+	}
+	if err := d.deadLetters.Record(context.Background(), letter); err != nil {
+		d.logger.Logf("ERROR failed to persist webhook dead letter %s: %v", event.ID, err)
+	}
+}
+
+// Sign returns the signature for a payload: hex HMAC-SHA256 of "<timestamp>.<body>" keyed by secret.
+// Receivers recompute it from the timestamp header and raw body to authenticate the request.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// isRetryable reports whether a failed delivery may succeed later.
+// Client errors other than timeouts and rate limits mean the request itself is rejected.
+func isRetryable(err error) bool {
+	var statusErr *statusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+	switch {
+	case statusErr.code == http.StatusRequestTimeout, statusErr.code == http.StatusTooManyRequests:
+		return true
+	case statusErr.code >= 400 && statusErr.code < 500:
+		return false
+	default:
+		return true
+	}
+}
+
+// summarize renders the event as a single line, with data keys sorted for stable output
+func summarize(eventType webhook.EventType, data map[string]interface{}) string {
+	keys := make([]string, 0, len(data))
+	for k := range data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString("[epoch-server] ")
+	sb.WriteString(string(eventType))
+	for _, k := range keys {
+		fmt.Fprintf(&sb, " %s=%v", k, data[k])
+	}
+	return sb.String()
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,164 @@
+package webhookimpl
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andrey/epoch-server/internal/services/deadletter"
+	"github.com/andrey/epoch-server/internal/services/webhook"
+	"github.com/go-pkgz/lgr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDispatcher(t *testing.T, url string, maxRetries int, backoff time.Duration) (*Dispatcher, *deadletter.RecorderMock) {
+	recorder := &deadletter.RecorderMock{RecordFunc: func(ctx context.Context, entry deadletter.Entry) error { return nil }}
+	return New(webhook.Config{
+		Endpoints:    []webhook.Endpoint{{URL: url, Secret: "s3cret"}},
+		Timeout:      time.Second,
+		MaxRetries:   maxRetries,
+		RetryBackoff: backoff,
+	}, recorder, lgr.NoOp), recorder
+}
+
+// deadLetters returns the dead letters recorded so far
+func deadLetters(recorder *deadletter.RecorderMock) []deadletter.Entry {
+	letters := make([]deadletter.Entry, 0)
+	for _, call := range recorder.RecordCalls() {
+		letters = append(letters, call.Entry)
+	}
+	return letters
+}
+
+func TestDispatcher_SignedDelivery(t *testing.T) {
+	received := make(chan webhook.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		expected := Sign("s3cret", r.Header.Get(TimestampHeader), body)
+		assert.Equal(t, expected, r.Header.Get(SignatureHeader))
+		assert.Equal(t, string(webhook.EventEpochStarted), r.Header.Get(EventHeader))
+
+		var event webhook.Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		assert.Equal(t, event.ID, r.Header.Get(DeliveryHeader))
+		received <- event
+	}))
+	defer server.Close()
+
+	dispatcher, recorder := newTestDispatcher(t, server.URL, 3, time.Millisecond)
+	dispatcher.Notify(context.Background(), webhook.EventEpochStarted, map[string]interface{}{"epochId": "7"})
+
+	select {
+	case event := <-received:
+		assert.Equal(t, webhook.EventEpochStarted, event.Type)
+		assert.Equal(t, "7", event.Data["epochId"])
+		assert.Equal(t, "[epoch-server] epoch.started epochId=7", event.Text)
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not delivered")
+	}
+
+	require.NoError(t, dispatcher.Close(context.Background()))
+	assert.Empty(t, deadLetters(recorder))
+}
+
+func TestDispatcher_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		expectedAttempts int32
+	}{
+		{name: "server_error_retried", status: http.StatusBadGateway, expectedAttempts: 3},
+		{name: "rate_limited_retried", status: http.StatusTooManyRequests, expectedAttempts: 3},
+		{name: "client_error_not_retried", status: http.StatusBadRequest, expectedAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			dispatcher, recorder := newTestDispatcher(t, server.URL, 2, time.Millisecond)
+			dispatcher.Notify(context.Background(), webhook.EventDistributionFailed, map[string]interface{}{"vaultAddress": "0xvault"})
+
+			require.Eventually(t, func() bool {
+				return len(deadLetters(recorder)) == 1
+			}, 5*time.Second, 5*time.Millisecond)
+			require.NoError(t, dispatcher.Close(context.Background()))
+
+			letters := deadLetters(recorder)
+			assert.Equal(t, tt.expectedAttempts, atomic.LoadInt32(&attempts))
+			assert.Equal(t, deadletter.KindWebhook, letters[0].Kind)
+			assert.Equal(t, int(tt.expectedAttempts), letters[0].Attempts)
+			assert.Equal(t, server.URL, letters[0].Target)
+			var event webhook.Event
+			require.NoError(t, json.Unmarshal(letters[0].Payload, &event))
+			assert.Equal(t, webhook.EventDistributionFailed, event.Type)
+		})
+	}
+}
+
+func TestDispatcher_CloseDeadLettersPendingRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	dispatcher, recorder := newTestDispatcher(t, server.URL, 5, time.Hour)
+	dispatcher.Notify(context.Background(), webhook.EventEpochFinalized, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, dispatcher.Close(ctx))
+
+	letters := deadLetters(recorder)
+	require.Len(t, letters, 1)
+	assert.Equal(t, 1, letters[0].Attempts)
+	assert.Contains(t, letters[0].LastError, "shut down before retry")
+
+	dispatcher.Notify(context.Background(), webhook.EventEpochStarted, nil)
+	assert.Len(t, deadLetters(recorder), 2, "events raised after shutdown should be dead-lettered immediately")
+}
+
+func TestDispatcher_Retry(t *testing.T) {
+	var status int32 = http.StatusOK
+	received := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.Equal(t, Sign("s3cret", r.Header.Get(TimestampHeader), body), r.Header.Get(SignatureHeader))
+		received <- r.Header.Get(DeliveryHeader)
+		w.WriteHeader(int(atomic.LoadInt32(&status)))
+	}))
+	defer server.Close()
+
+	dispatcher, _ := newTestDispatcher(t, server.URL, 0, time.Millisecond)
+	payload, err := json.Marshal(webhook.Event{ID: "abc", Type: webhook.EventEpochStarted})
+	require.NoError(t, err)
+	entry := deadletter.Entry{Kind: deadletter.KindWebhook, Target: server.URL, Payload: payload}
+
+	outcome, err := dispatcher.Retry(context.Background(), entry)
+	require.NoError(t, err)
+	assert.Equal(t, deadletter.OutcomeDelivered, outcome)
+	assert.Equal(t, "abc", <-received, "a redelivered event keeps its ID")
+
+	atomic.StoreInt32(&status, http.StatusBadGateway)
+	_, err = dispatcher.Retry(context.Background(), entry)
+	require.Error(t, err)
+	<-received
+
+	entry.Target = "https://removed.example.com"
+	_, err = dispatcher.Retry(context.Background(), entry)
+	require.ErrorIs(t, err, deadletter.ErrNotRetryable)
+}
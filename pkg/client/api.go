@@ -0,0 +1,595 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ProofQuery selects the proof returned by Proof. Epoch and Root are optional: without an epoch the
+// latest distribution is used, and a root selects a replaced distribution of the epoch.
+type ProofQuery struct {
+	Address string
+	Vault   string
+	Epoch   string
+	Root    string
+}
+
+// AuditQuery filters the audit log, zero fields match everything
+type AuditQuery struct {
+	Action string
+	Actor  string
+	Result string
+	TxHash string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Cursor string // NextCursor of the previous page
+}
+
+// GasReportQuery narrows a gas report to a time window and an epoch, zero fields match everything
+type GasReportQuery struct {
+	Since time.Time
+	Until time.Time
+	Epoch string
+}
+
+// Health returns the server's health checks. An unhealthy server is reported as an APIError with status 503.
+func (c *Client) Health(ctx context.Context) (*HealthResponse, error) {
+	var resp HealthResponse
+	if err := c.get(ctx, "/health", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListEpochs returns the most recent epochs, newest first; limit 0 uses the server default
+func (c *Client) ListEpochs(ctx context.Context, limit int) (*ListEpochsResponse, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var resp ListEpochsResponse
+	if err := c.get(ctx, "/api/epochs", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// StartEpoch starts a new epoch
+func (c *Client) StartEpoch(ctx context.Context) (*StartEpochResponse, error) {
+	var resp StartEpochResponse
+	if err := c.post(ctx, "/api/epochs/start", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ForceEndEpoch ends the epoch with zero yield
+func (c *Client) ForceEndEpoch(ctx context.Context, epochID uint64) (*ForceEndEpochResponse, error) {
+	query := url.Values{"epochId": {strconv.FormatUint(epochID, 10)}}
+	var resp ForceEndEpochResponse
+	if err := c.post(ctx, "/api/epochs/force-end", query, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DistributeSubsidies distributes the current epoch's subsidies for the server's vault
+func (c *Client) DistributeSubsidies(ctx context.Context) (*SubsidyDistributionResponse, error) {
+	var resp SubsidyDistributionResponse
+	if err := c.post(ctx, "/api/epochs/distribute", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QueueDistribution queues the distribution of the current epoch's subsidies for the server's vault, its outcome
+// polled with GetJob; an empty priority is normal
+func (c *Client) QueueDistribution(ctx context.Context, priority string) (*Job, error) {
+	var resp Job
+	if err := c.post(ctx, "/api/epochs/distribute", asyncQuery(priority), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserTotalEarned returns the subsidies a user earned so far
+func (c *Client) UserTotalEarned(ctx context.Context, address string) (*UserEarningsResponse, error) {
+	var resp UserEarningsResponse
+	if err := c.get(ctx, "/api/users/"+url.PathEscape(address)+"/total-earned", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserMerkleProof returns a user's proof in the latest distribution; an empty vault uses the server default
+func (c *Client) UserMerkleProof(ctx context.Context, address, vault string) (*UserMerkleProofResponse, error) {
+	var resp UserMerkleProofResponse
+	if err := c.get(ctx, "/api/users/"+url.PathEscape(address)+"/merkle-proof", vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserClaimable returns what a user can claim in every vault, with the claim data to submit on-chain
+func (c *Client) UserClaimable(ctx context.Context, address string) (*UserClaimable, error) {
+	var resp UserClaimable
+	if err := c.get(ctx, "/api/users/"+url.PathEscape(address)+"/claimable", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserHistory returns a user's position in every distributed epoch, latest first; an empty vault returns
+// every vault
+func (c *Client) UserHistory(ctx context.Context, address, vault string) ([]EpochPosition, error) {
+	var resp []EpochPosition
+	if err := c.get(ctx, "/api/users/"+url.PathEscape(address)+"/history", vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// UserExpirations returns what a user has yet to claim with the epochs it expires in, soonest first; an empty
+// vault returns every vault
+func (c *Client) UserExpirations(ctx context.Context, address, vault string) (*UserExpirations, error) {
+	var resp UserExpirations
+	if err := c.get(ctx, "/api/users/"+url.PathEscape(address)+"/expirations", vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UserHistoricalMerkleProof returns a user's proof in an epoch's distribution
+func (c *Client) UserHistoricalMerkleProof(
+	ctx context.Context,
+	address, vault, epochNumber string,
+) (*UserMerkleProofResponse, error) {
+	path := "/api/users/" + url.PathEscape(address) + "/merkle-proof/epoch/" + url.PathEscape(epochNumber)
+	var resp UserMerkleProofResponse
+	if err := c.get(ctx, path, vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Proof returns a proof for the latest, an epoch's or a replaced root
+func (c *Client) Proof(ctx context.Context, q ProofQuery) (*UserMerkleProofResponse, error) {
+	query := vaultQuery(q.Vault)
+	query.Set("address", q.Address)
+	setIfNotEmpty(query, "epoch", q.Epoch)
+	setIfNotEmpty(query, "root", q.Root)
+
+	var resp UserMerkleProofResponse
+	if err := c.get(ctx, "/api/proofs", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyMerkleRoot rebuilds the vault's latest root and compares it with the stored and on-chain roots.
+// A mismatch is not an error, the returned verification has Match unset and lists the differences.
+func (c *Client) VerifyMerkleRoot(ctx context.Context, vault string) (*MerkleRootVerification, error) {
+	var resp MerkleRootVerification
+	err := c.get(ctx, "/api/vaults/"+url.PathEscape(vault)+"/merkle-root/verify", nil, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusConflict && apiErr.Body != nil {
+		if jsonErr := json.Unmarshal(apiErr.Body, &resp); jsonErr == nil {
+			return &resp, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// VerifyProofs checks up to 1000 proofs against the roots the server stored for their vaults, and against each
+// vault's on-chain root when onChain is set. An invalid proof is reported in its result, not as an error.
+func (c *Client) VerifyProofs(ctx context.Context, proofs []ProofToVerify, onChain bool) (*ProofVerifications, error) {
+	body := struct {
+		Proofs  []ProofToVerify `json:"proofs"`
+		OnChain bool            `json:"onChain"`
+	}{Proofs: proofs, OnChain: onChain}
+	var resp ProofVerifications
+	if err := c.post(ctx, "/api/proofs/verify", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QueueVerifyProofs queues the verification of proofs VerifyProofs does, its result polled with GetJob
+func (c *Client) QueueVerifyProofs(ctx context.Context, proofs []ProofToVerify, onChain bool, priority string) (*Job, error) {
+	body := struct {
+		Proofs  []ProofToVerify `json:"proofs"`
+		OnChain bool            `json:"onChain"`
+	}{Proofs: proofs, OnChain: onChain}
+	var resp Job
+	if err := c.post(ctx, "/api/proofs/verify", asyncQuery(priority), body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExplainAllocation returns how a user's amount in an epoch's distribution was computed
+func (c *Client) ExplainAllocation(ctx context.Context, vault, epochNumber, address string) (*AllocationExplanation, error) {
+	path := "/api/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) +
+		"/users/" + url.PathEscape(address) + "/explain"
+	var resp AllocationExplanation
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ExplainAllocations returns how many users' amounts in an epoch's distribution were computed, up to 1000 per call
+func (c *Client) ExplainAllocations(ctx context.Context, vault, epochNumber string, addresses []string) (*AllocationExplanations, error) {
+	path := "/api/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/explain"
+	body := struct {
+		Users []string `json:"users"`
+	}{Users: addresses}
+	var resp AllocationExplanations
+	if err := c.post(ctx, path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListQuarantinedAccounts returns the account subsidies distributions of the vault skipped for malformed
+// data, only those of epochNumber when it is not empty
+func (c *Client) ListQuarantinedAccounts(ctx context.Context, vault, epochNumber string) ([]QuarantinedAccount, error) {
+	query := url.Values{}
+	if epochNumber != "" {
+		query.Set("epoch", epochNumber)
+	}
+	var resp []QuarantinedAccount
+	if err := c.get(ctx, "/api/vaults/"+url.PathEscape(vault)+"/quarantine", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// GetVaultAsset returns the ERC-20 the vault's wei amounts are denominated in; Asset.Format converts them to
+// whole tokens
+func (c *Client) GetVaultAsset(ctx context.Context, vault string) (*Asset, error) {
+	var resp Asset
+	if err := c.get(ctx, "/api/vaults/"+url.PathEscape(vault)+"/asset", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// OnChainEpochState returns the vault's current epoch, yield and subsidy state as the contracts report it,
+// the server's configured vault when vault is empty
+func (c *Client) OnChainEpochState(ctx context.Context, vault string) (*OnChainStateResponse, error) {
+	var resp OnChainStateResponse
+	if err := c.get(ctx, "/api/epochs/current/onchain", vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// EpochTimeline returns the milestones of the vault's epoch processing with the time between them, the
+// server's configured vault when vault is empty
+func (c *Client) EpochTimeline(ctx context.Context, epochID uint64, vault string) (*EpochTimeline, error) {
+	path := "/api/epochs/" + strconv.FormatUint(epochID, 10) + "/timeline"
+	var resp EpochTimeline
+	if err := c.get(ctx, path, vaultQuery(vault), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ReplayEpoch recomputes an epoch's distribution on the server and returns its drift from the stored one
+func (c *Client) ReplayEpoch(ctx context.Context, vault, epochNumber string) (*ReplayResult, error) {
+	path := "/api/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/replay"
+	var resp ReplayResult
+	if err := c.get(ctx, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DiffAllocations compares an epoch's distribution with the vault's previous one, listing at most top accounts
+// per change kind, the server's default when top is 0
+func (c *Client) DiffAllocations(ctx context.Context, vault, epochNumber string, top int) (*AllocationDiff, error) {
+	path := "/api/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/diff"
+	query := url.Values{}
+	if top > 0 {
+		query.Set("top", strconv.Itoa(top))
+	}
+	var resp AllocationDiff
+	if err := c.get(ctx, path, query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// QueueReplay queues the replay ReplayEpoch runs, its result polled with GetJob
+func (c *Client) QueueReplay(ctx context.Context, vault, epochNumber, priority string) (*Job, error) {
+	path := "/api/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/replay"
+	var resp Job
+	if err := c.get(ctx, path, asyncQuery(priority), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetJob returns a queued job, with its result once it succeeded or its error once it failed
+func (c *Client) GetJob(ctx context.Context, id string) (*Job, error) {
+	var resp Job
+	if err := c.get(ctx, "/api/jobs/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListJobs returns the kept queued jobs, most recently queued first, optionally filtered by status
+func (c *Client) ListJobs(ctx context.Context, status string) ([]Job, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "status", status)
+	var resp []Job
+	if err := c.get(ctx, "/api/jobs", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ListDistributions returns the staged distributions, optionally filtered by status
+func (c *Client) ListDistributions(ctx context.Context, status string) ([]StagedDistribution, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "status", status)
+	var resp []StagedDistribution
+	if err := c.get(ctx, "/api/distributions", query, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// ApproveDistribution submits a staged distribution's root; requires Config.APIKey
+func (c *Client) ApproveDistribution(ctx context.Context, id string) (*SubsidyDistributionResponse, error) {
+	var resp SubsidyDistributionResponse
+	if err := c.post(ctx, "/api/distributions/"+url.PathEscape(id)+"/approve", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RejectDistribution discards a staged distribution; requires Config.APIKey
+func (c *Client) RejectDistribution(ctx context.Context, id, reason string) (*StagedDistribution, error) {
+	body := struct {
+		Reason string `json:"reason"`
+	}{Reason: reason}
+	var resp StagedDistribution
+	if err := c.post(ctx, "/api/distributions/"+url.PathEscape(id)+"/reject", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SignerStatus returns the signer's balance and whether scheduled transactions are paused
+func (c *Client) SignerStatus(ctx context.Context) (*SignerStatus, error) {
+	var resp SignerStatus
+	if err := c.get(ctx, "/api/signer", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Status returns whether the DebtSubsidizer accepts distributions and the signer balance
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	var resp StatusResponse
+	if err := c.get(ctx, "/api/status", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListAudit returns a page of audit log entries, newest first; pass the response's NextCursor to get the next one
+func (c *Client) ListAudit(ctx context.Context, q AuditQuery) (*AuditListResponse, error) {
+	query := url.Values{}
+	setIfNotEmpty(query, "action", q.Action)
+	setIfNotEmpty(query, "actor", q.Actor)
+	setIfNotEmpty(query, "result", q.Result)
+	setIfNotEmpty(query, "txHash", q.TxHash)
+	setTime(query, "since", q.Since)
+	setTime(query, "until", q.Until)
+	if q.Limit > 0 {
+		query.Set("limit", strconv.Itoa(q.Limit))
+	}
+	setIfNotEmpty(query, "cursor", q.Cursor)
+
+	var resp AuditListResponse
+	if err := c.get(ctx, "/api/audit", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GasReport returns gas spend rolled up per operation and epoch
+func (c *Client) GasReport(ctx context.Context, q GasReportQuery) (*GasReport, error) {
+	query := url.Values{}
+	setTime(query, "since", q.Since)
+	setTime(query, "until", q.Until)
+	setIfNotEmpty(query, "epoch", q.Epoch)
+
+	var resp GasReport
+	if err := c.get(ctx, "/api/reports/gas", query, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SchedulerStatus returns which scheduler jobs are paused; requires an admin Config.APIKey
+func (c *Client) SchedulerStatus(ctx context.Context) (*SchedulerStatus, error) {
+	var resp SchedulerStatus
+	if err := c.get(ctx, "/admin/scheduler", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PauseScheduler pauses a scheduler job until it is resumed, an empty job pauses all of them;
+// requires an admin Config.APIKey
+func (c *Client) PauseScheduler(ctx context.Context, job, reason string) (*SchedulerJobState, error) {
+	body := struct {
+		Job    string `json:"job,omitempty"`
+		Reason string `json:"reason,omitempty"`
+	}{Job: job, Reason: reason}
+	var resp SchedulerJobState
+	if err := c.post(ctx, "/admin/scheduler/pause", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ResumeScheduler resumes a paused scheduler job, an empty job clears every pause;
+// requires an admin Config.APIKey
+func (c *Client) ResumeScheduler(ctx context.Context, job string) (*SchedulerJobState, error) {
+	body := struct {
+		Job string `json:"job,omitempty"`
+	}{Job: job}
+	var resp SchedulerJobState
+	if err := c.post(ctx, "/admin/scheduler/resume", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TriggerBoundary queues an epoch boundary to run now, which is how epochs advance in manual
+// scheduler mode; requires an admin Config.APIKey
+func (c *Client) TriggerBoundary(ctx context.Context) (*BoundaryResult, error) {
+	var resp BoundaryResult
+	if err := c.post(ctx, "/admin/scheduler/trigger", nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// FeatureFlags returns whether each feature flag is on and where that comes from; requires an admin Config.APIKey
+func (c *Client) FeatureFlags(ctx context.Context) (*FeatureFlags, error) {
+	var resp FeatureFlags
+	if err := c.get(ctx, "/admin/features", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SetFeatureFlag switches a feature flag on or off on every replica until its override is cleared;
+// requires an admin Config.APIKey
+func (c *Client) SetFeatureFlag(ctx context.Context, flag string, enabled bool, reason string) (*FeatureFlagState, error) {
+	body := struct {
+		Enabled bool   `json:"enabled"`
+		Reason  string `json:"reason,omitempty"`
+	}{Enabled: enabled, Reason: reason}
+	var resp FeatureFlagState
+	if err := c.do(ctx, http.MethodPut, "/admin/features/"+url.PathEscape(flag), nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ClearFeatureFlag removes the override of a feature flag, returning it to the server's features file;
+// requires an admin Config.APIKey
+func (c *Client) ClearFeatureFlag(ctx context.Context, flag string) (*FeatureFlagState, error) {
+	var resp FeatureFlagState
+	if err := c.do(ctx, http.MethodDelete, "/admin/features/"+url.PathEscape(flag), nil, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// PinSnapshotBlock makes an epoch's distribution snapshot the vault at blockNumber instead of the block the
+// server's snapshot strategy would choose; requires an admin Config.APIKey
+func (c *Client) PinSnapshotBlock(ctx context.Context, vault, epochNumber string, blockNumber uint64) (*SnapshotBlockPin, error) {
+	path := "/admin/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/snapshot-block"
+	body := struct {
+		BlockNumber uint64 `json:"blockNumber"`
+	}{BlockNumber: blockNumber}
+	var resp SnapshotBlockPin
+	if err := c.post(ctx, path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// OverrideFingerprint lets the next distribution of the epoch replace its committed distribution although it was
+// computed from other inputs; requires an admin Config.APIKey
+func (c *Client) OverrideFingerprint(ctx context.Context, vault, epochNumber, reason string) (*FingerprintOverride, error) {
+	path := "/admin/vaults/" + url.PathEscape(vault) + "/epochs/" + url.PathEscape(epochNumber) + "/fingerprint-override"
+	body := struct {
+		Reason string `json:"reason"`
+	}{Reason: reason}
+	var resp FingerprintOverride
+	if err := c.post(ctx, path, nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// OnboardVault adds the vault to the contracts, whitelists req.Collections and registers it with the server,
+// skipping steps already done; requires an admin Config.APIKey. A failed step is not an error, the returned
+// result has Complete unset and lists the calls that roll back what this onboarding did.
+func (c *Client) OnboardVault(ctx context.Context, req OnboardVaultRequest) (*VaultOnboarding, error) {
+	var resp VaultOnboarding
+	err := c.post(ctx, "/admin/vaults", nil, req, &resp)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadGateway && apiErr.Body != nil {
+		if jsonErr := json.Unmarshal(apiErr.Body, &resp); jsonErr == nil {
+			return &resp, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// DecommissionVault takes the vault out of the server's scheduler runs, and with confirm removes it from the
+// DebtSubsidizer for good; requires an admin Config.APIKey
+func (c *Client) DecommissionVault(ctx context.Context, vault, reason string, confirm bool) (*Vault, error) {
+	body := DecommissionVaultRequest{Reason: reason, Confirm: confirm}
+	var resp Vault
+	if err := c.post(ctx, "/admin/vaults/"+url.PathEscape(vault)+"/decommission", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListVaults returns the server's vault registry; requires an admin Config.APIKey
+func (c *Client) ListVaults(ctx context.Context) ([]Vault, error) {
+	var resp []Vault
+	if err := c.get(ctx, "/admin/vaults", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func vaultQuery(vault string) url.Values {
+	query := url.Values{}
+	setIfNotEmpty(query, "vault", vault)
+	return query
+}
+
+func asyncQuery(priority string) url.Values {
+	query := url.Values{"async": {"true"}}
+	setIfNotEmpty(query, "priority", priority)
+	return query
+}
+
+func setIfNotEmpty(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
+
+func setTime(query url.Values, key string, t time.Time) {
+	if !t.IsZero() {
+		query.Set(key, t.UTC().Format(time.RFC3339))
+	}
+}
@@ -0,0 +1,257 @@
+// Package client is a typed Go client for the epoch server HTTP API.
+//
+// Read requests are retried on network errors and on 429 and 5xx responses. Requests that send
+// transactions (starting, ending and distributing epochs, approving distributions) are only
+// retried when the connection could not be established, so a retry never submits twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config configures a Client
+type Config struct {
+	BaseURL      string        // server address, e.g. http://localhost:8080
+	APIKey       string        // sent as X-API-Key, required to approve or reject distributions and by /admin
+	Tenant       string        // sent as X-Tenant to a server hosting several deployments
+	Timeout      time.Duration // per attempt, defaults to 30s
+	MaxRetries   int           // retries after the first attempt, defaults to 3, negative disables retries
+	RetryBackoff time.Duration // delay before the first retry, doubled on each retry, defaults to 200ms
+	HTTPClient   *http.Client  // overrides the client built from Timeout
+}
+
+// Client calls the epoch server API
+type Client struct {
+	baseURL      string
+	apiKey       string
+	tenant       string
+	httpClient   *http.Client
+	maxRetries   int
+	retryBackoff time.Duration
+}
+
+// Error codes the server sends with error responses, see APIError.Code
+const (
+	ErrorCodeInvalidInput          = "invalid_input"
+	ErrorCodeNotFound              = "not_found"
+	ErrorCodeTimeout               = "timeout"
+	ErrorCodeConflict              = "conflict"
+	ErrorCodeForbidden             = "forbidden"
+	ErrorCodeTransactionFailed     = "transaction_failed"
+	ErrorCodeInternal              = "internal_error"
+	ErrorCodeEpochNotActive        = "epoch_not_active"
+	ErrorCodeYieldAlreadyAllocated = "yield_already_allocated"
+	ErrorCodeInsufficientYield     = "insufficient_yield"
+	ErrorCodeSnapshotStale         = "snapshot_stale"
+	ErrorCodeSubgraphLagging       = "subgraph_lagging"
+	ErrorCodeRootMismatch          = "root_mismatch"
+	ErrorCodePreflightFailed       = "preflight_failed"
+	ErrorCodeClaimUnavailable      = "claim_unavailable"
+	ErrorCodeQueueFull             = "queue_full"
+)
+
+// APIError is returned for non-2xx responses
+type APIError struct {
+	StatusCode int
+	Code       string // machine-readable error code, one of the ErrorCode constants when the server sent one
+	Message    string
+	Details    string
+	Body       json.RawMessage // the response body when it is JSON
+}
+
+func (e *APIError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("server returned %d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("server returned %d", e.StatusCode)
+}
+
+// IsNotFound reports whether err is an APIError for a missing resource
+func IsNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// HasErrorCode reports whether err is an APIError with the error code
+func HasErrorCode(err error, code string) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == code
+}
+
+// New creates a client for the server at cfg.BaseURL
+func New(cfg Config) (*Client, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q", cfg.BaseURL)
+	}
+
+	c := &Client{
+		baseURL:      strings.TrimRight(cfg.BaseURL, "/"),
+		apiKey:       cfg.APIKey,
+		tenant:       cfg.Tenant,
+		httpClient:   cfg.HTTPClient,
+		maxRetries:   cfg.MaxRetries,
+		retryBackoff: cfg.RetryBackoff,
+	}
+	if c.httpClient == nil {
+		timeout := cfg.Timeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		c.httpClient = &http.Client{Timeout: timeout}
+	}
+	switch {
+	case c.maxRetries == 0:
+		c.maxRetries = 3
+	case c.maxRetries < 0:
+		c.maxRetries = 0
+	}
+	if c.retryBackoff <= 0 {
+		c.retryBackoff = 200 * time.Millisecond
+	}
+
+	return c, nil
+}
+
+// get performs a GET request and decodes the JSON response into out
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// post performs a POST request with an optional JSON body and decodes the JSON response into out
+func (c *Client) post(ctx context.Context, path string, query url.Values, body, out interface{}) error {
+	return c.do(ctx, http.MethodPost, path, query, body, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	endpoint := c.baseURL + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	backoff := c.retryBackoff
+	for attempt := 0; ; attempt++ {
+		respBody, retryAfter, err := c.attempt(ctx, method, endpoint, payload)
+		if err == nil {
+			if out == nil {
+				return nil
+			}
+			if err := json.Unmarshal(respBody, out); err != nil {
+				return fmt.Errorf("failed to decode %s %s response: %w", method, path, err)
+			}
+			return nil
+		}
+
+		if attempt >= c.maxRetries || ctx.Err() != nil || !retryable(method, err) {
+			return err
+		}
+
+		delay := backoff
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		backoff *= 2
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends a single request, returning the response body or an error and the server's Retry-After
+func (c *Client) attempt(ctx context.Context, method, endpoint string, payload []byte) ([]byte, time.Duration, error) {
+	var reader io.Reader
+	if payload != nil {
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, reader)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.tenant != "" {
+		req.Header.Set("X-Tenant", c.tenant)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to call %s %s: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close() //nolint:errcheck // read-only body
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		var errBody struct {
+			Error     string `json:"error"`
+			ErrorCode string `json:"errorCode"`
+			Details   string `json:"details"`
+		}
+		if json.Unmarshal(body, &errBody) == nil {
+			apiErr.Code = errBody.ErrorCode
+			apiErr.Message = errBody.Error
+			apiErr.Details = errBody.Details
+		}
+		if json.Valid(body) {
+			apiErr.Body = body
+		}
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return nil, retryAfter, apiErr
+	}
+
+	return body, 0, nil
+}
+
+// retryable reports whether a failed request can be sent again. Reads are retried on any
+// transient failure, writes only when the server never received them.
+func retryable(method string, err error) bool {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		if method != http.MethodGet {
+			return false
+		}
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+
+	if method == http.MethodGet {
+		return true
+	}
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
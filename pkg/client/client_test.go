@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{BaseURL: server.URL + "/", APIKey: "approver-key", RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+	return c
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	_, err := New(Config{BaseURL: "localhost:8080"})
+	assert.Error(t, err)
+}
+
+func TestClient_DecodesTypedResponses(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/epochs":
+			assert.Equal(t, "5", r.URL.Query().Get("limit"))
+			_, _ = w.Write([]byte(`{"epochs":[{"epochNumber":"3","status":"ACTIVE"}],"count":1}`))
+		case "/api/reports/gas":
+			assert.Equal(t, "2025-01-01T00:00:00Z", r.URL.Query().Get("since"))
+			assert.Equal(t, "3", r.URL.Query().Get("epoch"))
+			_, _ = w.Write([]byte(`{"total":{"transactions":2,"gasUsed":42000,"cost":"84000"},"byOperation":{},"epochs":[]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	})
+	ctx := context.Background()
+
+	epochs, err := c.ListEpochs(ctx, 5)
+	require.NoError(t, err)
+	require.Len(t, epochs.Epochs, 1)
+	assert.Equal(t, "3", epochs.Epochs[0].EpochNumber)
+
+	report, err := c.GasReport(ctx, GasReportQuery{Since: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Epoch: "3"})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(42000), report.Total.GasUsed)
+	assert.Equal(t, "84000", report.Total.Cost)
+}
+
+func TestClient_RetriesReads(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error":"subgraph unavailable"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"balance":"1000","halted":false}`))
+	})
+
+	status, err := c.SignerStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1000", status.Balance)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_DoesNotRetryWrites(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "approver-key", r.Header.Get("X-API-Key"))
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(`{"error":"Failed to approve distribution","details":"execution reverted"}`))
+	})
+
+	_, err := c.ApproveDistribution(context.Background(), "staged-1")
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, "Failed to approve distribution", apiErr.Message)
+	assert.Equal(t, "execution reverted", apiErr.Details)
+	assert.Equal(t, int32(1), calls.Load(), "a write that reached the server is never repeated")
+}
+
+func TestClient_RetriesWritesThatNeverConnected(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	addr := server.URL
+	server.Close()
+
+	c, err := New(Config{BaseURL: addr, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = c.StartEpoch(context.Background())
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 3*time.Millisecond, "dial failures are retried with backoff")
+}
+
+func TestClient_RejectSendsReason(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/distributions/staged-1/reject", r.URL.Path)
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "wrong totals", body["reason"])
+		_, _ = w.Write([]byte(`{"id":"staged-1","status":"rejected"}`))
+	})
+
+	staged, err := c.RejectDistribution(context.Background(), "staged-1", "wrong totals")
+	require.NoError(t, err)
+	assert.Equal(t, "staged-1", staged.ID)
+}
+
+func TestClient_VerifyMerkleRootMismatch(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"vaultAddress":"0xabc","match":false,"mismatches":["on-chain root differs"]}`))
+	})
+
+	verification, err := c.VerifyMerkleRoot(context.Background(), "0xabc")
+	require.NoError(t, err)
+	assert.False(t, verification.Match)
+	assert.Equal(t, []string{"on-chain root differs"}, verification.Mismatches)
+}
+
+func TestClient_NotFound(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"error":"no distribution"}`))
+	})
+
+	_, err := c.ExplainAllocation(context.Background(), "0xabc", "5", "0xdef")
+	assert.True(t, IsNotFound(err))
+}
+
+func TestClient_ErrorCode(t *testing.T) {
+	c := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		_, _ = w.Write([]byte(`{"error":"Failed to distribute subsidies","code":409,"errorCode":"epoch_not_active"}`))
+	})
+
+	_, err := c.DistributeSubsidies(context.Background())
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, ErrorCodeEpochNotActive, apiErr.Code)
+	assert.True(t, HasErrorCode(err, ErrorCodeEpochNotActive))
+	assert.False(t, HasErrorCode(err, ErrorCodeSnapshotStale))
+}
+
+func TestClient_SendsTenant(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "sepolia", r.Header.Get("X-Tenant"))
+		_, _ = w.Write([]byte(`{"status":"healthy"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	c, err := New(Config{BaseURL: server.URL, Tenant: "sepolia"})
+	require.NoError(t, err)
+	_, err = c.Health(context.Background())
+	require.NoError(t, err)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"github.com/andrey/epoch-server/internal/api/handlers"
+	"github.com/andrey/epoch-server/internal/services/assets"
+	"github.com/andrey/epoch-server/internal/services/audit"
+	"github.com/andrey/epoch-server/internal/services/contractstate"
+	"github.com/andrey/epoch-server/internal/services/epoch"
+	"github.com/andrey/epoch-server/internal/services/features"
+	"github.com/andrey/epoch-server/internal/services/gas"
+	"github.com/andrey/epoch-server/internal/services/merkle"
+	"github.com/andrey/epoch-server/internal/services/pause"
+	"github.com/andrey/epoch-server/internal/services/queue"
+	"github.com/andrey/epoch-server/internal/services/scheduler"
+	"github.com/andrey/epoch-server/internal/services/signer"
+	"github.com/andrey/epoch-server/internal/services/subsidy"
+	"github.com/andrey/epoch-server/internal/services/vaults"
+)
+
+// Response types are the server's own, aliased so they stay in sync with the API and can be
+// named by importers outside this module.
+type (
+	HealthResponse = handlers.HealthResponse
+	StatusResponse = handlers.StatusResponse
+
+	StartEpochResponse    = epoch.StartEpochResponse
+	ForceEndEpochResponse = epoch.ForceEndEpochResponse
+	ListEpochsResponse    = epoch.ListEpochsResponse
+	EpochSummary          = epoch.EpochSummary
+	UserEarningsResponse  = epoch.UserEarningsResponse
+	OnChainStateResponse  = epoch.OnChainStateResponse
+	EpochTimeline         = epoch.Timeline
+	EpochMilestone        = epoch.Milestone
+
+	UserMerkleProofResponse = merkle.UserMerkleProofResponse
+	MerkleRootVerification  = merkle.MerkleRootVerification
+	UserClaimable           = merkle.UserClaimable
+	VaultClaimable          = merkle.VaultClaimable
+	ClaimData               = merkle.ClaimData
+	ProofToVerify           = merkle.ProofToVerify
+	ProofVerification       = merkle.ProofVerification
+	ProofVerifications      = merkle.ProofVerifications
+
+	SubsidyDistributionResponse = subsidy.SubsidyDistributionResponse
+	StagedDistribution          = subsidy.StagedDistribution
+	AllocationExplanation       = subsidy.AllocationExplanation
+	AllocationExplanations      = subsidy.AllocationExplanations
+	CollectionAllocation        = subsidy.CollectionAllocation
+	AppliedCap                  = subsidy.AppliedCap
+	ReplayResult                = subsidy.ReplayResult
+	QuarantinedAccount          = subsidy.QuarantinedAccount
+	EpochPosition               = subsidy.EpochPosition
+	UserExpirations             = subsidy.UserExpirations
+	ClaimExpiration             = subsidy.ClaimExpiration
+	AllocationDrift             = subsidy.AllocationDrift
+	AllocationDiff              = subsidy.AllocationDiff
+	AllocationChange            = subsidy.AllocationChange
+	SnapshotBlockPin            = subsidy.SnapshotBlockPin
+	FingerprintOverride         = subsidy.FingerprintOverride
+
+	SignerStatus   = signer.BalanceStatus
+	ContractStatus = contractstate.PauseStatus
+
+	AuditEntry        = audit.Entry
+	AuditListResponse = audit.ListResponse
+
+	GasReport      = gas.Report
+	GasRollup      = gas.Rollup
+	GasEpochRollup = gas.EpochRollup
+	GasBudget      = gas.BudgetStatus
+
+	SchedulerStatus   = pause.Status
+	SchedulerJobState = pause.JobState
+	BoundaryResult    = scheduler.BoundaryResult
+
+	FeatureFlags     = features.Status
+	FeatureFlagState = features.FlagState
+
+	Job = queue.Job
+
+	Vault               = vaults.Vault
+	OnboardVaultRequest = vaults.OnboardRequest
+	VaultOnboarding     = vaults.OnboardingResult
+	OnboardingStep      = vaults.OnboardingStep
+
+	DecommissionVaultRequest = vaults.DecommissionRequest
+
+	Asset = assets.Asset
+)
@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+interface IEpochManagerAllocation {
+    function allocateVaultYield(address vault, uint256 amount) external;
+}
+
+/// @notice Stand-in for a CollectionsVault with the calls epoch-server makes, for the integration tests.
+/// Yield is bookkeeping only and repayments are recorded per borrower instead of reaching a lending market.
+contract MockCollectionsVault {
+    error InvalidEpochId();
+    error AllocationAmountZero();
+    error BatchSizeExceedsLimit();
+    error RepayBorrowFailed();
+
+    event VaultYieldAllocatedToEpoch(uint256 indexed epochId, uint256 amount);
+    event YieldBatchRepaid(uint256 totalAmount, address indexed recipient);
+
+    uint256 public constant MAX_BATCH_SIZE = 500;
+
+    address public immutable epochManager;
+    uint256 public totalYieldAllocated;
+    uint256 public totalYieldReserved;
+    mapping(uint256 => uint256) private epochYieldAllocated;
+    mapping(address => uint256) public repaid;
+
+    constructor(address epochManager_) {
+        epochManager = epochManager_;
+    }
+
+    function getEpochYieldAllocated(uint256 epochId) external view returns (uint256) {
+        return epochYieldAllocated[epochId];
+    }
+
+    function allocateYieldToEpoch(uint256 epochId) external pure {
+        if (epochId == 0) revert InvalidEpochId();
+    }
+
+    function allocateCumulativeYieldToEpoch(uint256 epochId, uint256 amount) external {
+        if (epochId == 0) revert InvalidEpochId();
+        if (amount == 0) revert AllocationAmountZero();
+        totalYieldAllocated += amount;
+        epochYieldAllocated[epochId] += amount;
+        IEpochManagerAllocation(epochManager).allocateVaultYield(address(this), amount);
+        emit VaultYieldAllocatedToEpoch(epochId, amount);
+    }
+
+    function repayBorrowBehalfBatch(uint256[] calldata amounts, address[] calldata borrowers, uint256 totalAmount)
+        external
+    {
+        if (borrowers.length > MAX_BATCH_SIZE) revert BatchSizeExceedsLimit();
+        if (amounts.length != borrowers.length) revert RepayBorrowFailed();
+        uint256 sum;
+        for (uint256 i = 0; i < amounts.length; i++) {
+            sum += amounts[i];
+            repaid[borrowers[i]] += amounts[i];
+        }
+        if (sum != totalAmount) revert RepayBorrowFailed();
+        emit YieldBatchRepaid(totalAmount, msg.sender);
+    }
+}
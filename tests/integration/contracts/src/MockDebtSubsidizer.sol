@@ -0,0 +1,67 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @notice Stand-in for the DebtSubsidizer with the calls epoch-server makes, for the integration tests.
+/// Claims are not verified against the merkle root; setClaimed records them directly.
+contract MockDebtSubsidizer {
+    error VaultNotRegistered(address vaultAddress);
+
+    event MerkleRootUpdated(
+        address indexed vaultAddress, bytes32 merkleRoot, address indexed updatedBy, uint256 totalSubsidiesForEpoch
+    );
+    event VaultRemoved(address indexed vaultAddress);
+
+    bool public paused;
+    mapping(address => bool) public isVaultRemoved;
+    mapping(address => bytes32) private merkleRoots;
+    mapping(address => uint256) private totalSubsidies;
+    mapping(address => uint256) private totalClaimed;
+    mapping(address => mapping(address => uint256)) private userClaimed;
+
+    function setPaused(bool paused_) external {
+        paused = paused_;
+    }
+
+    function removeVault(address vaultAddress_) external {
+        isVaultRemoved[vaultAddress_] = true;
+        emit VaultRemoved(vaultAddress_);
+    }
+
+    function setClaimed(address vaultAddress, address user, uint256 amount) external {
+        totalClaimed[vaultAddress] = totalClaimed[vaultAddress] - userClaimed[vaultAddress][user] + amount;
+        userClaimed[vaultAddress][user] = amount;
+    }
+
+    function updateMerkleRoot(address vaultAddress, bytes32 merkleRoot, uint256 totalSubsidiesForEpoch) external {
+        require(!paused, "Pausable: paused");
+        if (isVaultRemoved[vaultAddress]) revert VaultNotRegistered(vaultAddress);
+        merkleRoots[vaultAddress] = merkleRoot;
+        totalSubsidies[vaultAddress] = totalSubsidiesForEpoch;
+        emit MerkleRootUpdated(vaultAddress, merkleRoot, msg.sender, totalSubsidiesForEpoch);
+    }
+
+    function getMerkleRoot(address vaultAddress) external view returns (bytes32) {
+        return merkleRoots[vaultAddress];
+    }
+
+    function getTotalSubsidies(address vaultAddress) external view returns (uint256) {
+        return totalSubsidies[vaultAddress];
+    }
+
+    function getTotalSubsidiesClaimed(address vaultAddress) external view returns (uint256) {
+        return totalClaimed[vaultAddress];
+    }
+
+    function getTotalClaimedForVault(address vaultAddress) external view returns (uint256) {
+        return totalClaimed[vaultAddress];
+    }
+
+    function getRemainingSubsidies(address vaultAddress) external view returns (uint256) {
+        uint256 claimed = totalClaimed[vaultAddress];
+        return claimed >= totalSubsidies[vaultAddress] ? 0 : totalSubsidies[vaultAddress] - claimed;
+    }
+
+    function getUserClaimedTotal(address vaultAddress, address user) external view returns (uint256) {
+        return userClaimed[vaultAddress][user];
+    }
+}
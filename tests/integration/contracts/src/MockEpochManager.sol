@@ -0,0 +1,56 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @notice Stand-in for the EpochManager with the calls epoch-server makes, for the integration tests.
+/// Anyone may call it; roles and epoch durations are not enforced.
+contract MockEpochManager {
+    error EpochManager__InvalidEpochId(uint256 epochId);
+
+    event EpochStarted(uint256 indexed epochId, uint256 startTime, uint256 endTime);
+    event VaultYieldAllocated(uint256 indexed epochId, address indexed vault, uint256 amount);
+    event EpochFinalized(uint256 indexed epochId, uint256 totalYieldAvailable, uint256 totalSubsidiesDistributed);
+
+    uint256 public constant EPOCH_DURATION = 1 days;
+
+    uint256 private currentEpochId;
+    mapping(uint256 => mapping(address => uint256)) private vaultYield;
+
+    function getCurrentEpochId() external view returns (uint256) {
+        return currentEpochId;
+    }
+
+    function getVaultYieldForEpoch(uint256 epochId, address vault) external view returns (uint256) {
+        return vaultYield[epochId][vault];
+    }
+
+    function startEpoch() external returns (uint256) {
+        currentEpochId++;
+        emit EpochStarted(currentEpochId, block.timestamp, block.timestamp + EPOCH_DURATION);
+        return currentEpochId;
+    }
+
+    /// @notice Called by a vault allocating yield to the current epoch
+    function allocateVaultYield(address vault, uint256 amount) external {
+        _checkEpoch(currentEpochId);
+        vaultYield[currentEpochId][vault] += amount;
+        emit VaultYieldAllocated(currentEpochId, vault, amount);
+    }
+
+    function endEpochWithSubsidies(uint256 epochId, address vaultAddress, bytes32, uint256 subsidiesDistributed)
+        external
+    {
+        _checkEpoch(epochId);
+        emit EpochFinalized(epochId, vaultYield[epochId][vaultAddress], subsidiesDistributed);
+    }
+
+    function forceEndEpochWithZeroYield(uint256 epochId, address) external {
+        _checkEpoch(epochId);
+        emit EpochFinalized(epochId, 0, 0);
+    }
+
+    function _checkEpoch(uint256 epochId) private view {
+        if (epochId == 0 || epochId > currentEpochId) {
+            revert EpochManager__InvalidEpochId(epochId);
+        }
+    }
+}
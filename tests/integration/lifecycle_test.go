@@ -0,0 +1,115 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/andrey/epoch-server/internal/infra/subgraph"
+)
+
+const (
+	lifecycleBorrowerA = "0x1111111111111111111111111111111111111111"
+	lifecycleBorrowerB = "0x2222222222222222222222222222222222222222"
+)
+
+// withSubsidies makes the subgraph report two borrowers earning 1000 and 250 wei in the vault
+func (h *harness) withSubsidies() {
+	h.subgraph.QueryAccountsFunc = func(ctx context.Context) ([]subgraph.Account, error) {
+		return []subgraph.Account{{ID: lifecycleBorrowerA}, {ID: lifecycleBorrowerB}}, nil
+	}
+	h.subgraph.StreamAccountSubsidiesForVaultFunc = func(
+		ctx context.Context,
+		vaultAddress string,
+		fn func(page []subgraph.AccountSubsidy) error,
+	) error {
+		return fn([]subgraph.AccountSubsidy{
+			{Account: subgraph.Account{ID: lifecycleBorrowerA}, TotalRewardsEarned: "1000"},
+			{Account: subgraph.Account{ID: lifecycleBorrowerB}, TotalRewardsEarned: "250"},
+		})
+	}
+}
+
+func TestAnvilEpochLifecycle(t *testing.T) {
+	h := newHarness(t)
+	h.withSubsidies()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	started, err := h.epochs.StartEpoch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "1", started.EpochID)
+
+	// the vault allocates its yield to the running epoch, as its operator would
+	require.NoError(t, h.client.AllocateCumulativeYieldToEpoch(ctx, big.NewInt(1), h.vault, big.NewInt(5000)))
+
+	distributed, err := h.subsidy.DistributeSubsidies(ctx, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, "1", distributed.EpochID)
+	assert.Equal(t, "1250", distributed.TotalSubsidies)
+	assert.Equal(t, 2, distributed.AccountsProcessed)
+
+	state, err := h.client.GetOnChainEpochState(ctx, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, "1", state.CurrentEpochID.String())
+	assert.Equal(t, "5000", state.VaultYieldForEpoch.String())
+	assert.Equal(t, "5000", state.TotalYieldAllocated.String())
+	assert.Equal(t, common.HexToHash(distributed.MerkleRoot), common.Hash(state.MerkleRoot))
+	assert.Equal(t, "1250", state.TotalSubsidies.String())
+	assert.Equal(t, "1250", state.RemainingSubsidies.String())
+
+	verification, err := h.merkle.VerifyMerkleRoot(ctx, h.vault)
+	require.NoError(t, err)
+	assert.True(t, verification.Match, "mismatches: %v", verification.Mismatches)
+
+	proof, err := h.merkle.GenerateUserMerkleProof(ctx, lifecycleBorrowerA, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", proof.TotalEarned)
+	assert.Equal(t, common.HexToHash(proof.MerkleRoot), common.Hash(state.MerkleRoot), "proofs are served for the pushed root")
+
+	// a claim against the pushed root is what the subsidizer counts down from
+	require.NoError(t, h.anvil.Send(ctx, h.subsidizer, "setClaimed(address,address,uint256)", h.vault, lifecycleBorrowerA, "1000"))
+	claimed, err := h.client.GetUserClaimedTotal(ctx, h.vault, lifecycleBorrowerA)
+	require.NoError(t, err)
+	assert.Equal(t, "1000", claimed.String())
+	state, err = h.client.GetOnChainEpochState(ctx, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, "250", state.RemainingSubsidies.String())
+
+	// the next epoch starts from the completed one
+	started, err = h.epochs.StartEpoch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "2", started.EpochID)
+}
+
+func TestAnvilPausedSubsidizerKeepsRoot(t *testing.T) {
+	h := newHarness(t)
+	h.withSubsidies()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	_, err := h.epochs.StartEpoch(ctx)
+	require.NoError(t, err)
+	require.NoError(t, h.anvil.Send(ctx, h.subsidizer, "setPaused(bool)", "true"))
+
+	pause, err := h.client.GetPauseState(ctx, h.vault)
+	require.NoError(t, err)
+	assert.True(t, pause.SubsidizerPaused)
+
+	_, err = h.subsidy.DistributeSubsidies(ctx, h.vault)
+	require.Error(t, err, "a paused subsidizer refuses the root")
+
+	root, err := h.client.GetMerkleRoot(ctx, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, [32]byte{}, root)
+	total, err := h.client.GetOnChainEpochState(ctx, h.vault)
+	require.NoError(t, err)
+	assert.Equal(t, "0", total.TotalSubsidies.String())
+}